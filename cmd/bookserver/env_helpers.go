@@ -1,6 +1,10 @@
 package main
 
-import "os"
+import (
+	"flag"
+	"os"
+	"time"
+)
 
 func envString(key, def string) string {
 	if env, ok := os.LookupEnv(key); ok {
@@ -15,3 +19,35 @@ func envBool(key string) bool {
 	}
 	return false
 }
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if env, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(env); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// secretFlag defines the string flag of a secret, or of its reference, see
+// secrets.Resolver. It defaults to the variable key, left out of the usage
+// so that the secret isn't printed.
+func secretFlag(name, key, usage string) *string {
+	v := &secretValue{value: os.Getenv(key)}
+	flag.Var(v, name, usage+" ($"+key+")")
+	return &v.value
+}
+
+// secretValue is a flag.Value printing empty.
+type secretValue struct {
+	value string
+}
+
+func (v *secretValue) Set(s string) error {
+	v.value = s
+	return nil
+}
+
+func (v *secretValue) String() string {
+	return ""
+}
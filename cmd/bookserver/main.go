@@ -1,22 +1,85 @@
 package main
 
 import (
+	"crypto/rand"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 
 	"context"
 
+	"github.com/go-kit/kit/endpoint"
 	kitlog "github.com/go-kit/kit/log"
 	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/kavirajk/bookshop/accounting"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/affiliate"
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/audiobook"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/browsing"
 	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/credit"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/currency"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/kavirajk/bookshop/dashboard"
+	"github.com/kavirajk/bookshop/db"
 	"github.com/kavirajk/bookshop/db/postgres"
+	"github.com/kavirajk/bookshop/dedupe"
+	"github.com/kavirajk/bookshop/delivery"
+	"github.com/kavirajk/bookshop/denylist"
+	"github.com/kavirajk/bookshop/deprecation"
+	"github.com/kavirajk/bookshop/donation"
+	"github.com/kavirajk/bookshop/ebook"
+	"github.com/kavirajk/bookshop/erasure"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/feeds"
+	"github.com/kavirajk/bookshop/fraud"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/health"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/images"
+	"github.com/kavirajk/bookshop/labels"
+	"github.com/kavirajk/bookshop/lock"
 	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/passwordpolicy"
+	"github.com/kavirajk/bookshop/payment"
+	"github.com/kavirajk/bookshop/payout"
+	"github.com/kavirajk/bookshop/picking"
+	"github.com/kavirajk/bookshop/quality"
+	"github.com/kavirajk/bookshop/ratelimit"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/reading"
+	"github.com/kavirajk/bookshop/realip"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/redis"
+	"github.com/kavirajk/bookshop/restock"
+	"github.com/kavirajk/bookshop/returns"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/secrets"
+	"github.com/kavirajk/bookshop/secure"
+	"github.com/kavirajk/bookshop/segment"
+	"github.com/kavirajk/bookshop/shelf"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/stocktake"
+	"github.com/kavirajk/bookshop/storage"
+	"github.com/kavirajk/bookshop/storefront"
+	"github.com/kavirajk/bookshop/support"
+	"github.com/kavirajk/bookshop/transport"
 	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vat"
+	"github.com/kavirajk/bookshop/vendors"
 )
 
 func main() {
@@ -25,111 +88,2221 @@ func main() {
 			"db-driver", envString("DB_DRIVER", "postgres"),
 			"Name of the database driver. e.g: postgres",
 		)
-		dbSource = flag.String(
-			"db-source", envString("DB_SOURCE", ""),
+		dbSource = secretFlag(
+			"db-source", "DB_SOURCE",
 			"Database source to connect to.e.g: user=<user> password=<password> dbname=<dbname>",
 		)
+		dbCredentials = flag.String(
+			"db-credentials", envString("DB_CREDENTIALS", ""),
+			"Reference of the secret of the username and password the database is connected with, e.g. vault:database/creds/bookshop. Empty connects with the ones of db-source",
+		)
 		listenAddr = flag.String(
 			"http-addr", envString("HTTP_ADDR", "0.0.0.0:8080"),
 			"http address to listen to e.g: 0.0.0.0:8080",
 		)
+		audiobookDir = flag.String(
+			"audiobook-dir", envString("AUDIOBOOK_DIR", "./media/audiobooks"),
+			"Directory where audiobook files are stored",
+		)
+		ebookDir = flag.String(
+			"ebook-dir", envString("EBOOK_DIR", "./media/ebooks"),
+			"Directory where ebook master copies and watermarked artifacts are stored",
+		)
+		podURL = flag.String(
+			"pod-url", envString("POD_URL", ""),
+			"Base URL of the print-on-demand provider API",
+		)
+		podAPIKey = secretFlag(
+			"pod-api-key", "POD_API_KEY",
+			"API key of the print-on-demand provider",
+		)
+		podWebhookSecret = secretFlag(
+			"pod-webhook-secret", "POD_WEBHOOK_SECRET",
+			"Secret used to verify print-on-demand webhook signatures",
+		)
+		labelURL = flag.String(
+			"label-url", envString("LABEL_URL", ""),
+			"Base URL of the shipping label provider API. Empty leaves the labels to the warehouse",
+		)
+		labelAPIKey = secretFlag(
+			"label-api-key", "LABEL_API_KEY",
+			"API key of the shipping label provider",
+		)
+		labelWebhookSecret = secretFlag(
+			"label-webhook-secret", "LABEL_WEBHOOK_SECRET",
+			"Secret used to verify the tracking webhook signatures of the shipping label provider",
+		)
+		returnWindow = flag.Int(
+			"return-window-days", returns.DefaultWindowDays,
+			"Days after its shipping an order can be returned",
+		)
+		fulfillmentPollInterval = flag.Duration(
+			"fulfillment-poll-interval", envDuration("FULFILLMENT_POLL_INTERVAL", 15*time.Minute),
+			"How often to poll the fulfillment provider for job status",
+		)
+		donationCharity = flag.String(
+			"donation-charity", envString("DONATION_CHARITY", ""),
+			"Charity collecting the checkout donations. Empty disables donations",
+		)
+		donationRoundUp = flag.Bool(
+			"donation-round-up", envBool("DONATION_ROUND_UP"),
+			"Offer rounding the order total up as donation",
+		)
+		donationMax = flag.Float64(
+			"donation-max", 100,
+			"Maximum amount of a fixed donation",
+		)
+		creditRefundExpiry = flag.Int(
+			"credit-refund-expiry", 0,
+			"Days the store credit of a refund is spendable, never expiring if 0",
+		)
+		creditPromotionExpiry = flag.Int(
+			"credit-promotion-expiry", 90,
+			"Days the store credit of a promotion is spendable, never expiring if 0",
+		)
+		creditExpiryInterval = flag.Duration(
+			"credit-expiry-interval", envDuration("CREDIT_EXPIRY_INTERVAL", time.Hour),
+			"How often to write off the expired store credit",
+		)
+		vendorSyncDir = flag.String(
+			"vendor-sync-dir", envString("VENDOR_SYNC_DIR", ""),
+			"Directory vendors drop inventory CSV files into, one sub directory per vendor id. Empty disables it",
+		)
+		vendorSyncInterval = flag.Duration(
+			"vendor-sync-interval", envDuration("VENDOR_SYNC_INTERVAL", time.Minute),
+			"How often to process vendor inventory sync batches",
+		)
+		commissionRate = flag.Float64(
+			"commission-rate", 0.15,
+			"Default commission rate kept on marketplace sales",
+		)
+		payoutMin = flag.Float64(
+			"payout-min", 10,
+			"Minimum vendor balance to be paid out",
+		)
+		payoutInterval = flag.Duration(
+			"payout-interval", envDuration("PAYOUT_INTERVAL", 7*24*time.Hour),
+			"How often to generate vendor payout statements",
+		)
+		fraudReviewScore = flag.Int(
+			"fraud-review-score", 50,
+			"Fraud score sending an order to manual review",
+		)
+		fraudDeclineScore = flag.Int(
+			"fraud-decline-score", 90,
+			"Fraud score declining an order",
+		)
+		paymentURL = flag.String(
+			"payment-url", envString("PAYMENT_URL", ""),
+			"Base URL of the payment provider API, charging and refunding the order edits",
+		)
+		paymentAPIKey = secretFlag(
+			"payment-api-key", "PAYMENT_API_KEY",
+			"API key of the payment provider",
+		)
+		applePayMerchantID = flag.String(
+			"apple-pay-merchant-id", envString("APPLE_PAY_MERCHANT_ID", ""),
+			"Apple Pay merchant identifier. Empty disables Apple Pay",
+		)
+		applePayDomain = flag.String(
+			"apple-pay-domain", envString("APPLE_PAY_DOMAIN", ""),
+			"Domain of the shop verified for Apple Pay",
+		)
+		googlePayMerchantID = flag.String(
+			"google-pay-merchant-id", envString("GOOGLE_PAY_MERCHANT_ID", ""),
+			"Google Pay merchant ID. Empty disables Google Pay",
+		)
+		paymentGateway = flag.String(
+			"payment-gateway", envString("PAYMENT_GATEWAY", ""),
+			"Gateway name of the payment provider the Google Pay tokens are encrypted for",
+		)
+		paymentGatewayMerchantID = flag.String(
+			"payment-gateway-merchant-id", envString("PAYMENT_GATEWAY_MERCHANT_ID", ""),
+			"Merchant ID of the shop at the payment provider, for Google Pay",
+		)
+		paymentWebhookSecret = secretFlag(
+			"payment-webhook-secret", "PAYMENT_WEBHOOK_SECRET",
+			"Secret used to verify payment provider webhook signatures",
+		)
+		disputeEmails = flag.String(
+			"dispute-emails", envString("DISPUTE_EMAILS", ""),
+			"Comma separated emails of the staff told about payment disputes",
+		)
+		bnplName = flag.String(
+			"bnpl-name", envString("BNPL_NAME", "klarna"),
+			"Name of the buy-now-pay-later provider",
+		)
+		bnplURL = flag.String(
+			"bnpl-url", envString("BNPL_URL", ""),
+			"Base URL of the buy-now-pay-later provider API. Empty disables paying later",
+		)
+		bnplAPIKey = secretFlag(
+			"bnpl-api-key", "BNPL_API_KEY",
+			"API key of the buy-now-pay-later provider",
+		)
+		bnplWebhookSecret = secretFlag(
+			"bnpl-webhook-secret", "BNPL_WEBHOOK_SECRET",
+			"Secret used to verify buy-now-pay-later webhook signatures",
+		)
+		bnplMin = flag.Float64(
+			"bnpl-min", 35,
+			"Minimum order total that can be paid later",
+		)
+		bnplMax = flag.Float64(
+			"bnpl-max", 1000,
+			"Maximum order total that can be paid later",
+		)
+		bnplCaptureInterval = flag.Duration(
+			"bnpl-capture-interval", envDuration("BNPL_CAPTURE_INTERVAL", 15*time.Minute),
+			"How often to capture the buy-now-pay-later authorizations of the shipped orders",
+		)
+		fxURL = flag.String(
+			"fx-url", envString("FX_URL", ""),
+			"Base URL of the exchange rates provider API",
+		)
+		fxAPIKey = secretFlag(
+			"fx-api-key", "FX_API_KEY",
+			"API key of the exchange rates provider",
+		)
+		fxBase = flag.String(
+			"fx-base", envString("FX_BASE", "EUR"),
+			"Currency of the catalog prices",
+		)
+		fxInterval = flag.Duration(
+			"fx-interval", envDuration("FX_INTERVAL", 24*time.Hour),
+			"How often to fetch exchange rates",
+		)
+		vatCountry = flag.String(
+			"vat-country", envString("VAT_COUNTRY", ""),
+			"EU member state the shop is established in",
+		)
+		vatID = flag.String(
+			"vat-id", envString("VAT_ID", ""),
+			"VAT ID of the shop, sent to VIES as requester",
+		)
+		viesURL = flag.String(
+			"vies-url", envString("VIES_URL", vat.DefaultVIESURL),
+			"VIES VAT number validation service URL",
+		)
+		suggestInterval = flag.Duration(
+			"suggest-interval", envDuration("SUGGEST_INTERVAL", time.Minute),
+			"How often to rebuild the search autocomplete index",
+		)
+		publishInterval = flag.Duration(
+			"publish-interval", envDuration("PUBLISH_INTERVAL", time.Minute),
+			"How often to publish and retire the scheduled books and apply the scheduled prices",
+		)
+		shelfImportInterval = flag.Duration(
+			"shelf-import-interval", envDuration("SHELF_IMPORT_INTERVAL", 30*time.Second),
+			"How often to process the queued shelf imports",
+		)
+		userJobsInterval = flag.Duration(
+			"user-jobs-interval", envDuration("USER_JOBS_INTERVAL", 30*time.Second),
+			"How often to run the queued bulk user jobs",
+		)
+		deprecationInterval = flag.Duration(
+			"deprecation-interval", envDuration("DEPRECATION_INTERVAL", time.Minute),
+			"How often to record the calls to the deprecated routes and reload them",
+		)
+		erasureInterval = flag.Duration(
+			"erasure-interval", envDuration("ERASURE_INTERVAL", time.Minute),
+			"How often to erase the users of the approved erasure requests",
+		)
+		exportInterval = flag.Duration(
+			"export-interval", envDuration("EXPORT_INTERVAL", time.Minute),
+			"How often to run the queued async CSV exports",
+		)
+		feedInterval = flag.Duration(
+			"feed-interval", envDuration("FEED_INTERVAL", time.Minute),
+			"How often to push catalog changes to partner webhooks",
+		)
+		affiliateRate = flag.Float64(
+			"affiliate-rate", 0.05,
+			"Default commission rate of the affiliates",
+		)
+		storeURL = flag.String(
+			"store-url", envString("STORE_URL", "http://localhost:8080"),
+			"Public URL of the storefront, linked from label QR codes and link previews",
+		)
+		siteName = flag.String(
+			"site-name", envString("SITE_NAME", "Bookshop"),
+			"Storefront name shown in link previews",
+		)
+		addressURL = flag.String(
+			"address-url", envString("ADDRESS_URL", ""),
+			"Base URL of the address validation provider. Empty uses the development stub",
+		)
+		addressAPIKey = secretFlag(
+			"address-api-key", "ADDRESS_API_KEY",
+			"API key of the address validation provider",
+		)
+		deliveryCutOff = flag.Duration(
+			"delivery-cutoff", envDuration("DELIVERY_CUTOFF", 14*time.Hour),
+			"Time of day the orders are to be placed before to leave the warehouse that day, e.g. 14h30m",
+		)
+		deliveryTimezone = flag.String(
+			"delivery-timezone", envString("DELIVERY_TIMEZONE", "UTC"),
+			"Time zone of the warehouse cut-off and of the delivery dates",
+		)
+		deliveryHandlingDays = flag.Int(
+			"delivery-handling-days", 0,
+			"Working days to pick and pack an order before it ships",
+		)
+		embargoList = flag.String(
+			"embargo", envString("EMBARGO", ""),
+			"Comma separated countries the shop doesn't ship to e.g: CU,IR,KP",
+		)
+		customsOrigin = flag.String(
+			"customs-origin", envString("CUSTOMS_ORIGIN", ""),
+			"Country the parcels are sent from, the VAT country if empty",
+		)
+		customsCN22Limit = flag.Float64(
+			"customs-cn22-limit", customs.DefaultCN22Limit,
+			"Value of a parcel above which a commercial invoice is declared instead of a CN22",
+		)
+		camelCaseVersions = flag.String(
+			"camel-case-versions", envString("CAMEL_CASE_VERSIONS", ""),
+			"Comma separated API versions responding in camelCase by default e.g: v2",
+		)
+		strictSchema = flag.Bool(
+			"strict-schema", envBool("STRICT_SCHEMA"),
+			"Reject request bodies with unknown fields or nulls for fields which can't be null",
+		)
+		archiveTerms = flag.String(
+			"archive-terms", envString("ARCHIVE_TERMS", ""),
+			"Path of the terms of sale text archived with every sale",
+		)
+		archiveRetention = flag.Int(
+			"archive-retention", archive.DefaultRetentionYears,
+			"Years the order documents are retained for bookkeeping",
+		)
+		accountingFormat = flag.String(
+			"accounting-format", envString("ACCOUNTING_FORMAT", accounting.FormatXero),
+			"Format of the accounting export e.g: xero, quickbooks",
+		)
+		accountingSalesAccount = flag.String(
+			"accounting-sales-account", envString("ACCOUNTING_SALES_ACCOUNT", "200"),
+			"Xero account code the sales are booked on",
+		)
+		accountingRefundAccount = flag.String(
+			"accounting-refund-account", envString("ACCOUNTING_REFUND_ACCOUNT", "200"),
+			"Xero account code the refunds are booked on",
+		)
+		accountingChargebackAccount = flag.String(
+			"accounting-chargeback-account", envString("ACCOUNTING_CHARGEBACK_ACCOUNT", "200"),
+			"Xero account code the chargebacks of lost disputes are booked on",
+		)
+		accountingInterval = flag.Duration(
+			"accounting-interval", envDuration("ACCOUNTING_INTERVAL", time.Hour),
+			"How often to export the days over to the accounting software",
+		)
+		segmentInterval = flag.Duration(
+			"segment-interval", envDuration("SEGMENT_INTERVAL", 24*time.Hour),
+			"How often to evaluate the customer segments",
+		)
+		qualityInterval = flag.Duration(
+			"quality-interval", envDuration("QUALITY_INTERVAL", 24*time.Hour),
+			"How often to check the catalog for missing covers, descriptions, prices and duplicate ISBNs",
+		)
+		coverURL = flag.String(
+			"cover-url", envString("COVER_URL", ""),
+			"URL of the cover of a book by its {isbn}, suggested for the books missing one",
+		)
+		lowStock = flag.Int(
+			"low-stock", 5,
+			"Stock at or below which the dashboard counts a book as low on stock",
+		)
+		dashboardTTL = flag.Duration(
+			"dashboard-ttl", envDuration("DASHBOARD_TTL", time.Minute),
+			"How long the admin dashboard summary is cached",
+		)
+		supportURL = flag.String(
+			"support-url", envString("SUPPORT_URL", ""),
+			"Base URL of the Zendesk style help desk tickets are created in. Empty keeps them in the shop only",
+		)
+		supportAPIKey = secretFlag(
+			"support-api-key", "SUPPORT_API_KEY",
+			"API key of the help desk",
+		)
+		supportWebhookSecret = secretFlag(
+			"support-webhook-secret", "SUPPORT_WEBHOOK_SECRET",
+			"Secret used to verify help desk webhook signatures",
+		)
+		jwtSecret = secretFlag(
+			"jwt-secret", "JWT_SECRET",
+			"Secret the access tokens are signed with. Empty signs with a random one, the tokens not surviving a restart",
+		)
+		jwtTTL = flag.Duration(
+			"jwt-ttl", envDuration("JWT_TTL", auth.DefaultTTL),
+			"How long an access token is valid",
+		)
+		revocationRedis = flag.String(
+			"revocation-redis", envString("REVOCATION_REDIS", ""),
+			"Address of the Redis server listing the revoked access tokens, shared by the servers. Empty keeps them in memory",
+		)
+		revocationRedisPassword = secretFlag(
+			"revocation-redis-password", "REVOCATION_REDIS_PASSWORD",
+			"Password of the revocation Redis server",
+		)
+		rateLimit = flag.Int(
+			"rate-limit", 600,
+			"Requests a user or else an IP address can make to the user endpoints per minute, unlimited if 0",
+		)
+		loginRateLimit = flag.Int(
+			"login-rate-limit", 10,
+			"Logins and forgotten passwords an IP address can make per minute, unlimited if 0",
+		)
+		rateLimitRedis = flag.String(
+			"rate-limit-redis", envString("RATE_LIMIT_REDIS", ""),
+			"Address of the Redis server keeping the rate limits, shared by the servers. Empty keeps them in memory",
+		)
+		rateLimitRedisPassword = secretFlag(
+			"rate-limit-redis-password", "RATE_LIMIT_REDIS_PASSWORD",
+			"Password of the rate limit Redis server",
+		)
+		csrfGroups = flag.String(
+			"csrf", envString("CSRF", "account,admin"),
+			"Route groups of the user endpoints checking the CSRF token of the cookie sessions, comma separated: account, admin",
+		)
+		oauthSecret = secretFlag(
+			"oauth-secret", "OAUTH_SECRET",
+			"Secret the social login states are signed with. Empty signs with a random one",
+		)
+		oauthCallbackURL = flag.String(
+			"oauth-callback-url", envString("OAUTH_CALLBACK_URL", "http://localhost:8080"),
+			"Base URL of the server the social login providers redirect back to",
+		)
+		googleClientID = flag.String(
+			"google-client-id", envString("GOOGLE_CLIENT_ID", ""),
+			"OAuth client ID of the Google login. Empty disables it",
+		)
+		googleClientSecret = secretFlag(
+			"google-client-secret", "GOOGLE_CLIENT_SECRET",
+			"OAuth client secret of the Google login",
+		)
+		githubClientID = flag.String(
+			"github-client-id", envString("GITHUB_CLIENT_ID", ""),
+			"OAuth client ID of the GitHub login. Empty disables it",
+		)
+		githubClientSecret = secretFlag(
+			"github-client-secret", "GITHUB_CLIENT_SECRET",
+			"OAuth client secret of the GitHub login",
+		)
+		twoFactorKey = secretFlag(
+			"two-factor-key", "TWO_FACTOR_KEY",
+			"Key the TOTP secrets are encrypted with. Empty disables two-factor authentication",
+		)
+		passwordMinLength = flag.Int(
+			"password-min-length", 8,
+			"Minimum length of the new passwords",
+		)
+		passwordClasses = flag.Int(
+			"password-classes", 2,
+			"Character classes, of lower case, upper case, digits and symbols, the new passwords must mix",
+		)
+		passwordBreachURL = flag.String(
+			"password-breach-url", envString("PASSWORD_BREACH_URL", "https://api.pwnedpasswords.com"),
+			"Base URL of the Pwned Passwords API the new passwords are checked against. Empty disables the check",
+		)
+		loginMaxFailures = flag.Int(
+			"login-max-failures", user.DefaultMaxFailedLogins,
+			"Failed logins within the login-failure-window locking the account",
+		)
+		loginFailureWindow = flag.Duration(
+			"login-failure-window", envDuration("LOGIN_FAILURE_WINDOW", user.DefaultLockoutWindow),
+			"Window the failed logins are counted in",
+		)
+		loginLockout = flag.Duration(
+			"login-lockout", envDuration("LOGIN_LOCKOUT", user.DefaultLockoutDuration),
+			"How long an account stays locked, unless an admin unlocks it",
+		)
+		resetTokenTTL = flag.Duration(
+			"reset-token-ttl", envDuration("RESET_TOKEN_TTL", user.DefaultResetTokenTTL),
+			"How long a password reset link can be used",
+		)
+		avatarStorage = flag.String(
+			"avatar-storage", envString("AVATAR_STORAGE", "local"),
+			"Where the uploaded avatars are stored: local, s3, or none to disable the uploads",
+		)
+		avatarDir = flag.String(
+			"avatar-dir", envString("AVATAR_DIR", "media"),
+			"Directory of the avatars stored locally, served under /media/",
+		)
+		avatarBaseURL = flag.String(
+			"avatar-base-url", envString("AVATAR_BASE_URL", "http://localhost:8080/media"),
+			"Public URL the stored avatars are served from, e.g. the CDN of the S3 bucket",
+		)
+		s3Endpoint = flag.String(
+			"s3-endpoint", envString("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			"Endpoint of the S3 compatible object store",
+		)
+		s3Region = flag.String(
+			"s3-region", envString("S3_REGION", "us-east-1"),
+			"Region of the S3 bucket",
+		)
+		s3Bucket = flag.String(
+			"s3-bucket", envString("S3_BUCKET", ""),
+			"S3 bucket of the avatars",
+		)
+		s3AccessKey = flag.String(
+			"s3-access-key", envString("S3_ACCESS_KEY", ""),
+			"Access key of the S3 bucket",
+		)
+		s3SecretKey = secretFlag(
+			"s3-secret-key", "S3_SECRET_KEY",
+			"Secret key of the S3 bucket",
+		)
+		publicMaxAge = flag.Duration(
+			"public-max-age", envDuration("PUBLIC_MAX_AGE", storefront.DefaultMaxAge),
+			"How long browsers cache the responses of /public/v1/",
+		)
+		publicEdgeMaxAge = flag.Duration(
+			"public-edge-max-age", envDuration("PUBLIC_EDGE_MAX_AGE", storefront.DefaultEdgeMaxAge),
+			"How long the CDN caches the responses of /public/v1/, unless purged earlier",
+		)
+		cdnPurgeURL = flag.String(
+			"cdn-purge-url", envString("CDN_PURGE_URL", ""),
+			"Fastly API URL purging surrogate keys, e.g. https://api.fastly.com/service/{id}/purge. Empty purges nothing",
+		)
+		cdnPurgeKey = secretFlag(
+			"cdn-purge-key", "CDN_PURGE_KEY",
+			"API key of the CDN purges",
+		)
+		cdnPurgeInterval = flag.Duration(
+			"cdn-purge-interval", envDuration("CDN_PURGE_INTERVAL", 30*time.Second),
+			"How often to purge the changed books from the CDN",
+		)
+		imageSecret = secretFlag(
+			"image-secret", "IMAGE_SECRET",
+			"Secret the image URLs are signed with. Empty signs with a random one, the image URLs not surviving a restart",
+		)
+		imageBaseURL = flag.String(
+			"image-base-url", envString("IMAGE_BASE_URL", "http://localhost:8080"),
+			"Public URL the resized images are served from under /images/v1/, e.g. the CDN in front of the shop",
+		)
+		imageCacheSize = flag.Int(
+			"image-cache-size", images.DefaultCacheSize,
+			"Bytes of resized images kept in memory",
+		)
+		shopContextSecret = secretFlag(
+			"shop-context-secret", "SHOP_CONTEXT_SECRET",
+			"Secret the X-Shop-Context header is signed with. Empty signs with a random one, the sessions not surviving a restart",
+		)
+		shopCurrency = flag.String(
+			"shop-currency", envString("SHOP_CURRENCY", ""),
+			"Currency of new sessions, the fx-base currency if empty",
+		)
+		shopLocale = flag.String(
+			"shop-locale", envString("SHOP_LOCALE", i18n.DefaultLocale),
+			"Locale of new sessions without Accept-Language",
+		)
+		shopStore = flag.String(
+			"shop-store", envString("SHOP_STORE", ""),
+			"Store of new sessions",
+		)
+		experimentBuckets = flag.Int(
+			"experiment-buckets", shopctx.DefaultBuckets,
+			"Number of experiment buckets the sessions are spread over",
+		)
+		hstsMaxAge = flag.Duration(
+			"hsts-max-age", envDuration("HSTS_MAX_AGE", secure.DefaultHSTSMaxAge),
+			"How long browsers keep to HTTPS, 0 to send no Strict-Transport-Security",
+		)
+		hstsSubdomains = flag.Bool(
+			"hsts-subdomains", false,
+			"Extend Strict-Transport-Security to the subdomains",
+		)
+		frameOptions = flag.String(
+			"frame-options", envString("FRAME_OPTIONS", secure.DefaultFrameOptions),
+			"X-Frame-Options of the responses, DENY or SAMEORIGIN",
+		)
+		contentSecurityPolicy = flag.String(
+			"content-security-policy", envString("CONTENT_SECURITY_POLICY", secure.DefaultContentSecurityPolicy),
+			"Content-Security-Policy of the responses, empty to send none",
+		)
+		trustedProxies = flag.String(
+			"trusted-proxies", envString("TRUSTED_PROXIES", realip.DefaultProxies),
+			"Comma separated IP addresses and CIDR ranges of the proxies whose X-Forwarded-For and Forwarded headers are trusted, empty to trust none",
+		)
+		jobLocks = flag.String(
+			"job-locks", envString("JOB_LOCKS", "memory"),
+			"Where the locks of the scheduled jobs are taken, so that each runs on a single server at a time: memory for a single server, redis or postgres for its advisory locks",
+		)
+		jobLockRedis = flag.String(
+			"job-lock-redis", envString("JOB_LOCK_REDIS", ""),
+			"Address of the Redis server of the job locks, with job-locks redis",
+		)
+		jobLockRedisPassword = secretFlag(
+			"job-lock-redis-password", "JOB_LOCK_REDIS_PASSWORD",
+			"Password of the job lock Redis server",
+		)
+		jobLockTTL = flag.Duration(
+			"job-lock-ttl", envDuration("JOB_LOCK_TTL", lock.DefaultTTL),
+			"How long a job lock outlives its server, before another server takes the job over",
+		)
+		schemaIncompatible = flag.String(
+			"schema-incompatible", envString("SCHEMA_INCOMPATIBLE", "refuse"),
+			"What a server does on a database schema migrated past the versions it is compatible with: refuse to serve, or read-only to serve the reads only",
+		)
+		schemaCheckInterval = flag.Duration(
+			"schema-check-interval", envDuration("SCHEMA_CHECK_INTERVAL", time.Minute),
+			"How often a serving server checks the database schema is still compatible, exiting to restart read-only or refusing once it isn't",
+		)
+		secretRefresh = flag.Duration(
+			"secret-refresh", envDuration("SECRET_REFRESH", secrets.DefaultRefresh),
+			"How often the secrets of Vault and AWS Secrets Manager are fetched again to pick up their rotation",
+		)
+		vaultAddr = flag.String(
+			"vault-addr", envString("VAULT_ADDR", ""),
+			"URL of the Vault server of the vault:<path>#<field> secrets. Empty disables Vault",
+		)
+		vaultToken = secretFlag(
+			"vault-token", "VAULT_TOKEN",
+			"Token of the Vault server, renewed before it expires",
+		)
+		awsRegion = flag.String(
+			"aws-region", envString("AWS_REGION", ""),
+			"Region of the AWS Secrets Manager of the aws:<secret>#<field> secrets. Empty disables AWS Secrets Manager",
+		)
+		awsAccessKeyID = flag.String(
+			"aws-access-key-id", envString("AWS_ACCESS_KEY_ID", ""),
+			"Access key of AWS Secrets Manager",
+		)
+		awsSecretAccessKey = secretFlag(
+			"aws-secret-access-key", "AWS_SECRET_ACCESS_KEY",
+			"Secret key of AWS Secrets Manager",
+		)
+		awsSessionToken = secretFlag(
+			"aws-session-token", "AWS_SESSION_TOKEN",
+			"Session token of the temporary credentials of AWS Secrets Manager",
+		)
 	)
 	flag.Parse()
+	transport.CamelCaseVersions(strings.Split(*camelCaseVersions, ",")...)
+	schema.SetStrict(*strictSchema)
+
+	var logger kitlog.Logger
+	logger = kitlog.NewLogfmtLogger(os.Stderr)
+	ctx := context.Background()
+
+	// The secret flags are the secrets, or references to them, e.g.
+	// file:/run/secrets/jwt or vault:secret/data/bookshop#jwt_secret.
+	secretStore := secrets.NewResolver(secrets.Config{
+		Refresh: *secretRefresh,
+		Logger:  kitlog.NewContext(logger).With("component", "secrets"),
+	})
+	if *vaultAddr != "" {
+		secretStore.Register("vault", secrets.NewVault(secrets.VaultConfig{
+			Addr:  *vaultAddr,
+			Token: resolveSecret(ctx, secretStore, "vault-token", *vaultToken).Get(),
+		}, nil))
+	}
+	if *awsRegion != "" {
+		secretStore.Register("aws", secrets.NewAWS(secrets.AWSConfig{
+			Region:       *awsRegion,
+			AccessKey:    *awsAccessKeyID,
+			SecretKey:    resolveSecret(ctx, secretStore, "aws-secret-access-key", *awsSecretAccessKey).Get(),
+			SessionToken: resolveSecret(ctx, secretStore, "aws-session-token", *awsSessionToken).Get(),
+		}, nil))
+	}
+	// The database and the access tokens follow the rotation of their
+	// secrets, the others are read once, a rotation taking a restart.
+	for name, ref := range map[string]*string{
+		"pod-api-key":               podAPIKey,
+		"pod-webhook-secret":        podWebhookSecret,
+		"label-api-key":             labelAPIKey,
+		"label-webhook-secret":      labelWebhookSecret,
+		"payment-api-key":           paymentAPIKey,
+		"payment-webhook-secret":    paymentWebhookSecret,
+		"bnpl-api-key":              bnplAPIKey,
+		"bnpl-webhook-secret":       bnplWebhookSecret,
+		"fx-api-key":                fxAPIKey,
+		"address-api-key":           addressAPIKey,
+		"support-api-key":           supportAPIKey,
+		"support-webhook-secret":    supportWebhookSecret,
+		"revocation-redis-password": revocationRedisPassword,
+		"rate-limit-redis-password": rateLimitRedisPassword,
+		"job-lock-redis-password":   jobLockRedisPassword,
+		"oauth-secret":              oauthSecret,
+		"google-client-secret":      googleClientSecret,
+		"github-client-secret":      githubClientSecret,
+		"two-factor-key":            twoFactorKey,
+		"s3-secret-key":             s3SecretKey,
+		"cdn-purge-key":             cdnPurgeKey,
+		"image-secret":              imageSecret,
+		"shop-context-secret":       shopContextSecret,
+	} {
+		*ref = resolveSecret(ctx, secretStore, name, *ref).Get()
+	}
+	jwtKey := resolveSecret(ctx, secretStore, "jwt-secret", *jwtSecret)
+	dbSourceValue := resolveSecret(ctx, secretStore, "db-source", *dbSource)
+	*dbSource = dbSourceValue.Get()
+	var dbCredentialsValue *secrets.Value
+	if *dbCredentials != "" {
+		dbCredentialsValue = resolveSecret(ctx, secretStore, "db-credentials", *dbCredentials)
+	}
+	go secretStore.Run(ctx)
+
+	if *dbSource == "" {
+		fmt.Println("db-source argument is missing. Type --help for more info")
+		os.Exit(1)
+	}
+	// A server of a database schema it's incompatible with connects
+	// read-only, the database refusing the writes and the migrations.
+	var dbReadOnly bool
+	if !dbSourceValue.Static() || dbCredentialsValue != nil {
+		// The new connections pick up the rotated source and credentials.
+		const driver = "bookshop-secrets"
+		err := postgres.RegisterSource(driver, *dbDriver, func() string {
+			source := dbSourceValue.Get()
+			if dbCredentialsValue != nil {
+				source = postgres.Credentials(source, dbCredentialsValue.Field("username"), dbCredentialsValue.Field("password"))
+			}
+			if dbReadOnly {
+				source = postgres.ReadOnly(source)
+			}
+			return source
+		})
+		if err != nil {
+			log.Fatalf("error registering the database driver: %v\n", err)
+		}
+		*dbDriver = driver
+	}
+
+	readiness := health.NewMonitor()
+	schemas, err := postgres.NewSchemaRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating schema repo: %v\n", err)
+	}
+	storedSchema, err := schemas.Schema()
+	if err != nil {
+		log.Fatalf("error reading the database schema: %v\n", err)
+	}
+	schemaErr := db.CheckSchema(db.CurrentSchema, storedSchema)
+	if schemaErr != nil {
+		reason := schemaReason(storedSchema)
+		switch *schemaIncompatible {
+		case "refuse":
+			readiness.Set(health.StatusUnavailable, reason)
+		case "read-only":
+			readiness.Set(health.StatusReadOnly, reason)
+		default:
+			log.Fatalf("unknown schema-incompatible %q\n", *schemaIncompatible)
+		}
+		log.Printf("bookserver: %s, serving %s\n", reason, *schemaIncompatible)
+		dbReadOnly = true
+		*dbSource = postgres.ReadOnly(*dbSource)
+	}
+
+	var locker lock.Locker
+	switch *jobLocks {
+	case "memory":
+		locker = lock.NewMemoryLocker()
+	case "redis":
+		locker = lock.NewRedisLocker(*jobLockRedis, *jobLockRedisPassword)
+	case "postgres":
+		l, err := postgres.NewLocker(*dbDriver, *dbSource)
+		if err != nil {
+			log.Fatalf("error creating job locker: %v\n", err)
+		}
+		locker = l
+	default:
+		log.Fatalf("unknown job locks %q\n", *jobLocks)
+	}
+	if schemaErr != nil {
+		locker = lock.NewNopLocker()
+	}
+	// The scheduled jobs run on the server holding their lock, e.g. the
+	// drop directory of the vendors being shared by the servers.
+	jobs := lock.NewJobs(locker, *jobLockTTL, kitlog.NewContext(logger).With("component", "jobs"))
+
+	urepo, err := postgres.NewUserRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating user repo: %v\n", err)
+	}
+
+	crepo, err := postgres.NewCatalogRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating user repo: %v\n", err)
+	}
+
+	orepo, err := postgres.NewOrderRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating user repo: %v\n", err)
+	}
+
+	arepo, err := postgres.NewAudiobookRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating audiobook repo: %v\n", err)
+	}
+
+	erepo, err := postgres.NewEbookRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating ebook repo: %v\n", err)
+	}
+
+	rrepo, err := postgres.NewReadingRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating reading repo: %v\n", err)
+	}
+
+	frepo, err := postgres.NewFulfillmentRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating fulfillment repo: %v\n", err)
+	}
+
+	drepo, err := postgres.NewDonationRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating donation repo: %v\n", err)
+	}
+
+	crrepo, err := postgres.NewCreditRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating credit repo: %v\n", err)
+	}
+
+	pmrepo, err := postgres.NewPaymentRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating payment repo: %v\n", err)
+	}
+
+	aurepo, err := postgres.NewAuditRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating audit repo: %v\n", err)
+	}
+
+	vrepo, err := postgres.NewVendorRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating vendor repo: %v\n", err)
+	}
+
+	prepo, err := postgres.NewPayoutRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating payout repo: %v\n", err)
+	}
+
+	frrepo, err := postgres.NewFraudRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating fraud repo: %v\n", err)
+	}
+
+	dlrepo, err := postgres.NewDenylistRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating denylist repo: %v\n", err)
+	}
+
+	currepo, err := postgres.NewCurrencyRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating currency repo: %v\n", err)
+	}
+
+	vtrepo, err := postgres.NewVATRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating vat repo: %v\n", err)
+	}
+
+	adrepo, err := postgres.NewAddressRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating address repo: %v\n", err)
+	}
+
+	dvrepo, err := postgres.NewDeliveryRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating delivery repo: %v\n", err)
+	}
+
+	csrepo, err := postgres.NewCustomsRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating customs repo: %v\n", err)
+	}
+
+	fdrepo, err := postgres.NewFeedRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating feeds repo: %v\n", err)
+	}
+
+	afrepo, err := postgres.NewAffiliateRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating affiliate repo: %v\n", err)
+	}
+
+	rsrepo, err := postgres.NewRestockRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating restock repo: %v\n", err)
+	}
+
+	rtrepo, err := postgres.NewReturnsRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating returns repo: %v\n", err)
+	}
+
+	pkrepo, err := postgres.NewPickingRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating picking repo: %v\n", err)
+	}
+
+	strepo, err := postgres.NewStocktakeRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating stocktake repo: %v\n", err)
+	}
+
+	shrepo, err := postgres.NewShelfRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating shelf repo: %v\n", err)
+	}
+
+	dprepo, err := postgres.NewDeprecationRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating deprecation repo: %v\n", err)
+	}
+
+	exrepo, err := postgres.NewExportRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating export repo: %v\n", err)
+	}
+
+	rcrepo, err := postgres.NewRectificationRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating rectification repo: %v\n", err)
+	}
+
+	cnrepo, err := postgres.NewConsentRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating consent repo: %v\n", err)
+	}
+
+	errepo, err := postgres.NewErasureRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating erasure repo: %v\n", err)
+	}
+
+	arcrepo, err := postgres.NewArchiveRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating archive repo: %v\n", err)
+	}
+
+	acrepo, err := postgres.NewAccountingRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating accounting repo: %v\n", err)
+	}
+
+	sprepo, err := postgres.NewSupportRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating support repo: %v\n", err)
+	}
+
+	qrepo, err := postgres.NewQualityRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating quality repo: %v\n", err)
+	}
+
+	ddrepo, err := postgres.NewDedupeRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating dedupe repo: %v\n", err)
+	}
+
+	sfrepo, err := postgres.NewStorefrontRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating storefront repo: %v\n", err)
+	}
+
+	brrepo, err := postgres.NewBrowsingRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating browsing repo: %v\n", err)
+	}
+
+	sgrepo, err := postgres.NewSegmentRepo(*dbDriver, *dbSource)
+	if err != nil {
+		log.Fatalf("error creating segment repo: %v\n", err)
+	}
+
+	if schemaErr == nil {
+		// The tables are migrated, the binaries of the versions the schema
+		// isn't compatible with anymore turn read-only or away.
+		if err := schemas.Upgrade(db.CurrentSchema); err != nil {
+			log.Fatalf("error recording the database schema: %v\n", err)
+		}
+		go watchSchema(ctx, schemas, *schemaCheckInterval)
+	}
+
+	fieldKeys := []string{"method", "error"}
+
+	var dls denylist.Service
+	dls = denylist.NewService(dlrepo)
+	dls = denylist.LoggingMiddleware(kitlog.NewContext(logger).With("component", "denylist"))(dls)
+	dls = denylist.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "denylist_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "denylist_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(dls)
+
+	revocations := auth.NewMemoryRevocations()
+	if *revocationRedis != "" {
+		revocations = auth.NewRedisRevocations(*revocationRedis, *revocationRedisPassword)
+	}
+	var tokens auth.Service
+	if jwtKey.Get() != "" {
+		// The tokens signed before a rotation are verified with the key
+		// rotated from, see secrets.Value.Keys.
+		tokens = auth.NewRotatingService(jwtKey, *jwtTTL, revocations, user.NewSessions(urepo))
+	} else {
+		tokens = auth.NewService(secret("", "jwt-secret"), *jwtTTL, revocations, user.NewSessions(urepo))
+	}
+
+	var providers []oauth.Provider
+	callbackURL := func(provider string) string {
+		return strings.TrimRight(*oauthCallbackURL, "/") + "/users/v1/oauth/" + provider + "/callback"
+	}
+	if *googleClientID != "" {
+		providers = append(providers, oauth.NewGoogleProvider(*googleClientID, *googleClientSecret, callbackURL(oauth.Google), nil))
+	}
+	if *githubClientID != "" {
+		providers = append(providers, oauth.NewGitHubProvider(*githubClientID, *githubClientSecret, callbackURL(oauth.GitHub), nil))
+	}
+	social := oauth.NewLogin(secret(*oauthSecret, "oauth-secret"), providers...)
+
+	var aus audit.Service
+	aus = audit.NewService(aurepo)
+	aus = audit.LoggingMiddleware(kitlog.NewContext(logger).With("component", "audit"))(aus)
+	aus = audit.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "audit_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "audit_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(aus)
+
+	var avatars storage.Storage
+	switch *avatarStorage {
+	case "local":
+		avatars = storage.NewLocal(*avatarDir, *avatarBaseURL)
+	case "s3":
+		avatars = storage.NewS3(storage.S3Config{
+			Endpoint:  *s3Endpoint,
+			Region:    *s3Region,
+			Bucket:    *s3Bucket,
+			AccessKey: *s3AccessKey,
+			SecretKey: *s3SecretKey,
+			BaseURL:   *avatarBaseURL,
+		}, nil)
+	case "none":
+	default:
+		log.Fatalf("unknown avatar storage %q\n", *avatarStorage)
+	}
+
+	var brs browsing.Service
+	brs = browsing.NewService(brrepo, crepo, urepo)
+	brs = browsing.LoggingMiddleware(kitlog.NewContext(logger).With("component", "browsing"))(brs)
+	brs = browsing.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "browsing_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "browsing_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(brs)
+
+	var us user.Service
+	us = user.NewService(urepo, user.NewEmailNotifier(*storeURL+"/reset-password"), user.Config{
+		TwoFactorKey:    []byte(*twoFactorKey),
+		Issuer:          *siteName,
+		MaxFailedLogins: *loginMaxFailures,
+		LockoutWindow:   *loginFailureWindow,
+		LockoutDuration: *loginLockout,
+		ResetTokenTTL:   *resetTokenTTL,
+		Avatars:         avatars,
+		Logins:          aus,
+	})
+	us = denylist.UserMiddleware(dls)(us)
+	us = browsing.UserMiddleware(brs)(us)
+	us = user.LoggingMiddleware(kitlog.NewContext(logger).With("component", "user"))(us)
+	us = user.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "user_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "user_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(us)
+
+	var cs catalog.Service
+	cs = catalog.NewService(crepo, catalog.Config{StoreURL: *storeURL, SiteName: *siteName, Currency: *fxBase})
+	cs = browsing.CatalogMiddleware(brs)(cs)
+	cs = catalog.LoggingMiddleware(kitlog.NewContext(logger).With("component", "catalog"))(cs)
+	cs = catalog.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "catalog_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "catalog_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(cs)
+
+	// The suggestion index is kept in memory, rebuilt by every server.
+	go catalog.RunIndexer(ctx, cs, *suggestInterval, kitlog.NewContext(logger).With("component", "catalog"))
+	go jobs.Run(ctx, "catalog-publisher", func(ctx context.Context) {
+		catalog.RunPublisher(ctx, cs, *publishInterval, kitlog.NewContext(logger).With("component", "catalog"))
+	})
+
+	paymentProvider := payment.NewHTTPProvider(*paymentURL, *paymentAPIKey, nil)
+	creditConfig := credit.Config{
+		RefundExpiryDays:    *creditRefundExpiry,
+		PromotionExpiryDays: *creditPromotionExpiry,
+	}
+	// The edits and the returns of the orders paid with store credit move
+	// the difference on the credit ledger.
+	orderPayments := credit.NewPayments(crrepo, paymentProvider, creditConfig)
+
+	var os order.Service
+	os = order.NewService(orepo, cs, orderPayments)
+	os = order.LoggingMiddleware(kitlog.NewContext(logger).With("component", "order"))(os)
+	os = order.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "order_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "order_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(os)
+
+	var as audiobook.Service
+	as = audiobook.NewService(arepo, audiobook.NewDirStore(*audiobookDir), audiobook.NewOrderEntitlements(orepo))
+	as = audiobook.LoggingMiddleware(kitlog.NewContext(logger).With("component", "audiobook"))(as)
+	as = audiobook.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "audiobook_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "audiobook_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(as)
+
+	var es ebook.Service
+	es = ebook.NewService(erepo, orepo, urepo, ebook.NewDirStore(*ebookDir), ebook.NewPipeline())
+	es = ebook.LoggingMiddleware(kitlog.NewContext(logger).With("component", "ebook"))(es)
+	es = ebook.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "ebook_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "ebook_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(es)
+
+	var rs reading.Service
+	rs = reading.NewService(rrepo)
+	rs = reading.LoggingMiddleware(kitlog.NewContext(logger).With("component", "reading"))(rs)
+	rs = reading.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "reading_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "reading_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(rs)
+
+	embargo := address.ParseEmbargo(*embargoList)
+	if *customsOrigin == "" {
+		*customsOrigin = *vatCountry
+	}
+	var css customs.Service
+	css = customs.NewService(csrepo, orepo, crepo, customs.Config{
+		Origin:    *customsOrigin,
+		CN22Limit: *customsCN22Limit,
+		Embargo:   embargo,
+	})
+	css = customs.LoggingMiddleware(kitlog.NewContext(logger).With("component", "customs"))(css)
+	css = customs.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "customs_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "customs_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(css)
+
+	var labelProvider fulfillment.LabelProvider
+	if *labelURL != "" {
+		labelProvider = fulfillment.NewHTTPLabelProvider(*labelURL, *labelAPIKey, nil)
+	}
+	var fs fulfillment.Service
+	fs = fulfillment.NewService(frepo, orepo, adrepo, fulfillment.NewPODProvider(*podURL, *podAPIKey, *podWebhookSecret, nil), labelProvider, css)
+	fs = fulfillment.LoggingMiddleware(kitlog.NewContext(logger).With("component", "fulfillment"))(fs)
+	fs = fulfillment.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "fulfillment_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "fulfillment_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(fs)
+	go jobs.Run(ctx, "fulfillment-poller", func(ctx context.Context) {
+		fulfillment.RunPoller(ctx, fs, *fulfillmentPollInterval, kitlog.NewContext(logger).With("component", "fulfillment"))
+	})
+
+	var bnplProvider payment.BNPLProvider
+	if *bnplURL != "" {
+		bnplProvider = payment.NewBNPLProvider(*bnplName, *bnplURL, *bnplAPIKey, *bnplWebhookSecret, nil)
+	}
+
+	var disputeStaff []string
+	if *disputeEmails != "" {
+		disputeStaff = strings.Split(*disputeEmails, ",")
+	}
+
+	var pms payment.Service
+	pms = payment.NewService(pmrepo, orepo, paymentProvider, bnplProvider, payment.NewEmailNotifier(disputeStaff), fs, payment.Config{
+		ApplePayMerchantID:  *applePayMerchantID,
+		Domain:              *applePayDomain,
+		DisplayName:         *siteName,
+		GooglePayMerchantID: *googlePayMerchantID,
+		Gateway:             *paymentGateway,
+		GatewayMerchantID:   *paymentGatewayMerchantID,
+		BNPLMinAmount:       *bnplMin,
+		BNPLMaxAmount:       *bnplMax,
+		WebhookSecret:       *paymentWebhookSecret,
+	})
+	pms = payment.LoggingMiddleware(kitlog.NewContext(logger).With("component", "payment"))(pms)
+	pms = payment.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "payment_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "payment_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(pms)
+	go jobs.Run(ctx, "payment-capturer", func(ctx context.Context) {
+		payment.RunCapturer(ctx, pms, *bnplCaptureInterval, kitlog.NewContext(logger).With("component", "payment"))
+	})
+
+	var ds donation.Service
+	ds = donation.NewService(drepo, orepo, donation.Config{
+		Charity:   *donationCharity,
+		RoundUp:   *donationRoundUp,
+		MaxAmount: *donationMax,
+	})
+	ds = donation.LoggingMiddleware(kitlog.NewContext(logger).With("component", "donation"))(ds)
+	ds = donation.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "donation_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "donation_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(ds)
+
+	var crs credit.Service
+	crs = credit.NewService(crrepo, orepo, fs, creditConfig)
+	crs = credit.LoggingMiddleware(kitlog.NewContext(logger).With("component", "credit"))(crs)
+	crs = credit.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "credit_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "credit_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(crs)
+	go jobs.Run(ctx, "credit-expirer", func(ctx context.Context) {
+		credit.RunExpirer(ctx, crs, *creditExpiryInterval, kitlog.NewContext(logger).With("component", "credit"))
+	})
+
+	var vs vendors.Service
+	vs = vendors.NewService(vrepo, crepo, orepo)
+	vs = vendors.LoggingMiddleware(kitlog.NewContext(logger).With("component", "vendors"))(vs)
+	vs = vendors.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "vendors_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "vendors_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(vs)
+	go jobs.Run(ctx, "vendors-sync-worker", func(ctx context.Context) {
+		vendors.RunSyncWorker(ctx, vs, *vendorSyncInterval, kitlog.NewContext(logger).With("component", "vendors"))
+	})
+	if *vendorSyncDir != "" {
+		go jobs.Run(ctx, "vendors-drop-scanner", func(ctx context.Context) {
+			vendors.RunDropScanner(ctx, vs, *vendorSyncDir, *vendorSyncInterval, kitlog.NewContext(logger).With("component", "vendors"))
+		})
+	}
+
+	var ps payout.Service
+	ps = payout.NewService(prepo, orepo, vrepo, payout.Config{
+		DefaultRate: *commissionRate,
+		MinAmount:   *payoutMin,
+	})
+	ps = payout.LoggingMiddleware(kitlog.NewContext(logger).With("component", "payout"))(ps)
+	ps = payout.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "payout_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "payout_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(ps)
+	go jobs.Run(ctx, "payout-scheduler", func(ctx context.Context) {
+		payout.RunScheduler(ctx, ps, *payoutInterval, kitlog.NewContext(logger).With("component", "payout"))
+	})
+
+	fraudCfg := fraud.DefaultConfig()
+	fraudCfg.ReviewScore = *fraudReviewScore
+	fraudCfg.DeclineScore = *fraudDeclineScore
+
+	var frs fraud.Service
+	frs = fraud.NewService(frrepo, orepo, fraudCfg, denylist.Checkout(dls))
+	frs = fraud.LoggingMiddleware(kitlog.NewContext(logger).With("component", "fraud"))(frs)
+	frs = fraud.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "fraud_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "fraud_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(frs)
+
+	var curs currency.Service
+	curs = currency.NewService(currepo, orepo, currency.NewHTTPProvider(*fxURL, *fxAPIKey, nil), currency.Config{
+		Base: *fxBase,
+		// Rates are published once a day, allow a few misses.
+		MaxAge: 3 * *fxInterval,
+	})
+	curs = currency.LoggingMiddleware(kitlog.NewContext(logger).With("component", "currency"))(curs)
+	curs = currency.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "currency_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "currency_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(curs)
+	// The rates are cached in memory, refreshed by every server.
+	go currency.RunUpdater(ctx, curs, *fxInterval, kitlog.NewContext(logger).With("component", "currency"))
+
+	var vts vat.Service
+	vts = vat.NewService(vtrepo, orepo, adrepo, vat.NewVIESChecker(*viesURL, *vatID, nil), vat.Config{SellerCountry: *vatCountry})
+	vts = vat.LoggingMiddleware(kitlog.NewContext(logger).With("component", "vat"))(vts)
+	vts = vat.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "vat_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "vat_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(vts)
+
+	addressProvider := address.NewStubProvider()
+	if *addressURL != "" {
+		addressProvider = address.NewHTTPProvider(*addressURL, *addressAPIKey, nil)
+	}
+
+	var ads address.Service
+	ads = address.NewService(adrepo, orepo, addressProvider, embargo)
+	ads = address.LoggingMiddleware(kitlog.NewContext(logger).With("component", "address"))(ads)
+	ads = address.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "address_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "address_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(ads)
+
+	warehouseLoc, err := time.LoadLocation(*deliveryTimezone)
+	if err != nil {
+		log.Fatalf("error loading delivery timezone: %v\n", err)
+	}
+	var dvs delivery.Service
+	dvs = delivery.NewService(dvrepo, orepo, adrepo, delivery.Warehouse{
+		CutOff:       *deliveryCutOff,
+		Location:     warehouseLoc,
+		HandlingDays: *deliveryHandlingDays,
+	})
+	dvs = delivery.LoggingMiddleware(kitlog.NewContext(logger).With("component", "delivery"))(dvs)
+	dvs = delivery.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "delivery_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "delivery_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(dvs)
+
+	var fds feeds.Service
+	fds = feeds.NewService(fdrepo, crepo, nil)
+	fds = feeds.LoggingMiddleware(kitlog.NewContext(logger).With("component", "feeds"))(fds)
+	fds = feeds.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "feeds_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "feeds_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(fds)
+
+	go jobs.Run(ctx, "feeds-dispatcher", func(ctx context.Context) {
+		feeds.RunDispatcher(ctx, fds, *feedInterval, kitlog.NewContext(logger).With("component", "feeds"))
+	})
+
+	var cns consent.Service
+	cns = consent.NewService(cnrepo, consent.DefaultConfig())
+	cns = consent.LoggingMiddleware(kitlog.NewContext(logger).With("component", "consent"))(cns)
+	cns = consent.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "consent_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "consent_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(cns)
+
+	var afs affiliate.Service
+	afs = affiliate.NewService(afrepo, orepo, *affiliateRate, cns)
+	afs = affiliate.LoggingMiddleware(kitlog.NewContext(logger).With("component", "affiliate"))(afs)
+	afs = affiliate.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "affiliate_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "affiliate_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(afs)
 
-	var logger kitlog.Logger
-	logger = kitlog.NewLogfmtLogger(os.Stderr)
-	ctx := context.Background()
+	var rss restock.Service
+	rss = restock.NewService(rsrepo, crepo, restock.NewEmailNotifier())
+	rss = restock.LoggingMiddleware(kitlog.NewContext(logger).With("component", "restock"))(rss)
+	rss = restock.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "restock_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "restock_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(rss)
 
-	if *dbSource == "" {
-		fmt.Println("db-source argument is missing. Type --help for more info")
-		os.Exit(1)
+	var pks picking.Service
+	pks = picking.NewService(pkrepo, orepo, crepo, fs)
+	pks = picking.LoggingMiddleware(kitlog.NewContext(logger).With("component", "picking"))(pks)
+	pks = picking.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "picking_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "picking_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(pks)
+
+	var sts stocktake.Service
+	sts = stocktake.NewService(strepo, crepo)
+	sts = stocktake.LoggingMiddleware(kitlog.NewContext(logger).With("component", "stocktake"))(sts)
+	sts = stocktake.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "stocktake_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "stocktake_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(sts)
+
+	var lbs labels.Service
+	lbs = labels.NewService(crepo, orepo, rsrepo, pkrepo, labels.Config{StoreURL: *storeURL, Currency: *fxBase})
+	lbs = labels.LoggingMiddleware(kitlog.NewContext(logger).With("component", "labels"))(lbs)
+	lbs = labels.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "labels_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "labels_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(lbs)
+
+	var shs shelf.Service
+	shs = shelf.NewService(shrepo, crepo)
+	shs = shelf.LoggingMiddleware(kitlog.NewContext(logger).With("component", "shelf"))(shs)
+	shs = shelf.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "shelf_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "shelf_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(shs)
+
+	go jobs.Run(ctx, "shelf-importer", func(ctx context.Context) {
+		shelf.RunImporter(ctx, shs, *shelfImportInterval, kitlog.NewContext(logger).With("component", "shelf"))
+	})
+	go jobs.Run(ctx, "user-jobs", func(ctx context.Context) {
+		user.RunJobs(ctx, us, *userJobsInterval, kitlog.NewContext(logger).With("component", "user"))
+	})
+
+	var dps deprecation.Service
+	dps = deprecation.NewService(dprepo)
+	dps = deprecation.LoggingMiddleware(kitlog.NewContext(logger).With("component", "deprecation"))(dps)
+	dps = deprecation.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "deprecation_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "deprecation_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(dps)
+
+	deprecations := deprecation.NewRegistry()
+	// The registry counts the calls of this server, run by every server.
+	go deprecation.Run(ctx, dps, deprecations, *deprecationInterval, kitlog.NewContext(logger).With("component", "deprecation"))
+
+	var forgery user.CSRF
+	for _, g := range strings.Split(*csrfGroups, ",") {
+		switch strings.TrimSpace(g) {
+		case "account":
+			forgery.Account = true
+		case "admin":
+			forgery.Admin = true
+		}
 	}
 
-	urepo, err := postgres.NewUserRepo(*dbDriver, *dbSource)
-	if err != nil {
-		log.Fatalf("error creating user repo: %v\n", err)
+	// account, admin and staff let the users, the admins and the whole shop
+	// staff through to the routes of the other services, checking the CSRF
+	// token of the cookie sessions like the user routes do.
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := rbac.Staff(tokens, user.RoleAdmin)
+	staff := rbac.Staff(tokens, user.RoleAdmin, user.RoleSupport)
+	if forgery.Account {
+		account = endpoint.Chain(csrf.NewMiddleware(), account)
+	}
+	if forgery.Admin {
+		admin = endpoint.Chain(csrf.NewMiddleware(), admin)
+		staff = endpoint.Chain(csrf.NewMiddleware(), staff)
+	}
+	// The user list is open to the support staff and the tokens scoped to
+	// read the users, its export too.
+	userList := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin, user.RoleSupport), rbac.RequireScope(user.ScopeUsersRead))
+	if forgery.Admin {
+		userList = endpoint.Chain(csrf.NewMiddleware(), userList)
 	}
+	exportSources := []export.Source{
+		user.ExportSource(us, userList),
+		catalog.ExportSource(cs, admin),
+		order.ExportSource(os, admin),
+	}
+	var exs export.Service
+	exs = export.NewService(exrepo, exportSources, export.NewEmailNotifier(), *storeURL)
+	exs = export.LoggingMiddleware(kitlog.NewContext(logger).With("component", "export"))(exs)
+	exs = export.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "export_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "export_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(exs)
 
-	crepo, err := postgres.NewCatalogRepo(*dbDriver, *dbSource)
-	if err != nil {
-		log.Fatalf("error creating user repo: %v\n", err)
+	go jobs.Run(ctx, "export-jobs", func(ctx context.Context) {
+		export.RunJobs(ctx, exs, *exportInterval, kitlog.NewContext(logger).With("component", "export"))
+	})
+
+	var rcs rectification.Service
+	rcs = rectification.NewService(rcrepo, urepo)
+	rcs = rectification.LoggingMiddleware(kitlog.NewContext(logger).With("component", "rectification"))(rcs)
+	rcs = rectification.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "rectification_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "rectification_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(rcs)
+
+	var ers erasure.Service
+	ers = erasure.NewService(errepo, erasure.Repos{
+		Users:          urepo,
+		Orders:         orepo,
+		Support:        sprepo,
+		Browsing:       brrepo,
+		Consents:       cnrepo,
+		Rectifications: rcrepo,
+		Restock:        rsrepo,
+	})
+	ers = erasure.LoggingMiddleware(kitlog.NewContext(logger).With("component", "erasure"))(ers)
+	ers = erasure.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "erasure_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "erasure_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(ers)
+
+	go jobs.Run(ctx, "erasure-requests", func(ctx context.Context) {
+		erasure.RunRequests(ctx, ers, *erasureInterval, kitlog.NewContext(logger).With("component", "erasure"))
+	})
+
+	var terms string
+	if *archiveTerms != "" {
+		b, err := ioutil.ReadFile(*archiveTerms)
+		if err != nil {
+			log.Fatalf("error reading terms of sale: %v\n", err)
+		}
+		terms = string(b)
 	}
 
-	orepo, err := postgres.NewOrderRepo(*dbDriver, *dbSource)
-	if err != nil {
-		log.Fatalf("error creating user repo: %v\n", err)
+	var arcs archive.Service
+	arcs = archive.NewService(arcrepo, orepo, vtrepo, urepo, archive.NewEmailNotifier(), archive.Config{
+		Terms:          terms,
+		RetentionYears: *archiveRetention,
+	})
+	arcs = archive.LoggingMiddleware(kitlog.NewContext(logger).With("component", "archive"))(arcs)
+	arcs = archive.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "archive_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "archive_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(arcs)
+
+	var rts returns.Service
+	rts = returns.NewService(rtrepo, orepo, adrepo, returns.NewHTTPProvider(*labelURL, *labelAPIKey, *labelWebhookSecret, nil), orderPayments, arcs, returns.Config{
+		WindowDays: *returnWindow,
+	})
+	rts = returns.LoggingMiddleware(kitlog.NewContext(logger).With("component", "returns"))(rts)
+	rts = returns.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "returns_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "returns_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(rts)
+
+	accountingCfg := accounting.DefaultConfig()
+	accountingCfg.Format = *accountingFormat
+	accountingCfg.SalesAccount = *accountingSalesAccount
+	accountingCfg.RefundAccount = *accountingRefundAccount
+	accountingCfg.ChargebackAccount = *accountingChargebackAccount
+	if _, err := accounting.Columns(accountingCfg.Format, accountingCfg); err != nil {
+		log.Fatalf("error configuring accounting export: %v\n", err)
 	}
 
-	fieldKeys := []string{"method", "error"}
+	var acs accounting.Service
+	acs = accounting.NewService(acrepo, orepo, vtrepo, arcrepo, pmrepo, accountingCfg)
+	acs = accounting.LoggingMiddleware(kitlog.NewContext(logger).With("component", "accounting"))(acs)
+	acs = accounting.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "accounting_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "accounting_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(acs)
 
-	var us user.Service
-	us = user.NewService(urepo)
-	us = user.LoggingMiddleware(kitlog.NewContext(logger).With("component", "user"))(us)
-	us = user.InstrumentingMiddleware(
+	go jobs.Run(ctx, "accounting-exporter", func(ctx context.Context) {
+		accounting.RunExporter(ctx, acs, *accountingInterval, kitlog.NewContext(logger).With("component", "accounting"))
+	})
+
+	dashboardCfg := dashboard.Config{LowStock: *lowStock, TTL: *dashboardTTL}
+
+	var dbs dashboard.Service
+	dbs = dashboard.NewService(dashboard.Repos{
+		Orders:         orepo,
+		Books:          crepo,
+		Fraud:          frrepo,
+		Rectifications: rcrepo,
+		Vendors:        vrepo,
+		Exports:        exrepo,
+	}, dashboardCfg)
+	dbs = dashboard.LoggingMiddleware(kitlog.NewContext(logger).With("component", "dashboard"))(dbs)
+	dbs = dashboard.InstrumentingMiddleware(
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: "api",
-			Subsystem: "user_service",
+			Subsystem: "dashboard_service",
 			Name:      "request_count",
 			Help:      "Number of requests received",
 		}, fieldKeys),
 		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
 			Namespace: "api",
-			Subsystem: "user_service",
+			Subsystem: "dashboard_service",
 			Name:      "request_latency_microseconds",
 			Help:      "Total duration of requests in microseconds",
 		}, fieldKeys),
-	)(us)
+	)(dbs)
 
-	var cs catalog.Service
-	cs = catalog.NewService(crepo)
-	cs = catalog.LoggingMiddleware(kitlog.NewContext(logger).With("component", "catalog"))(cs)
-	cs = catalog.InstrumentingMiddleware(
+	supportProvider := support.NewLocalProvider()
+	if *supportURL != "" {
+		supportProvider = support.NewZendeskProvider(*supportURL, *supportAPIKey, *supportWebhookSecret, nil)
+	}
+
+	var sps support.Service
+	sps = support.NewService(sprepo, orepo, urepo, supportProvider)
+	sps = support.LoggingMiddleware(kitlog.NewContext(logger).With("component", "support"))(sps)
+	sps = support.InstrumentingMiddleware(
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: "api",
-			Subsystem: "catalog_service",
+			Subsystem: "support_service",
 			Name:      "request_count",
 			Help:      "Number of requests received",
 		}, fieldKeys),
 		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
 			Namespace: "api",
-			Subsystem: "catalog_service",
+			Subsystem: "support_service",
 			Name:      "request_latency_microseconds",
 			Help:      "Total duration of requests in microseconds",
 		}, fieldKeys),
-	)(cs)
+	)(sps)
 
-	var os order.Service
-	os = order.NewService(orepo)
-	os = order.LoggingMiddleware(kitlog.NewContext(logger).With("component", "order"))(os)
-	os = order.InstrumentingMiddleware(
+	var sgs segment.Service
+	sgs = segment.NewService(sgrepo, urepo)
+	sgs = segment.LoggingMiddleware(kitlog.NewContext(logger).With("component", "segment"))(sgs)
+	sgs = segment.InstrumentingMiddleware(
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
 			Namespace: "api",
-			Subsystem: "order_service",
+			Subsystem: "segment_service",
 			Name:      "request_count",
 			Help:      "Number of requests received",
 		}, fieldKeys),
 		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
 			Namespace: "api",
-			Subsystem: "order_service",
+			Subsystem: "segment_service",
 			Name:      "request_latency_microseconds",
 			Help:      "Total duration of requests in microseconds",
 		}, fieldKeys),
-	)(os)
+	)(sgs)
+	go jobs.Run(ctx, "segment-evaluator", func(ctx context.Context) {
+		segment.RunEvaluator(ctx, sgs, *segmentInterval, kitlog.NewContext(logger).With("component", "segment"))
+	})
+
+	var qs quality.Service
+	qs = quality.NewService(qrepo, crepo, quality.Config{CoverURL: *coverURL})
+	qs = quality.LoggingMiddleware(kitlog.NewContext(logger).With("component", "quality"))(qs)
+	qs = quality.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "quality_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "quality_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(qs)
+	go jobs.Run(ctx, "quality-checker", func(ctx context.Context) {
+		quality.RunChecker(ctx, qs, *qualityInterval, kitlog.NewContext(logger).With("component", "quality"))
+	})
+
+	var dds dedupe.Service
+	dds = dedupe.NewService(ddrepo, crepo)
+	dds = dedupe.LoggingMiddleware(kitlog.NewContext(logger).With("component", "dedupe"))(dds)
+	dds = dedupe.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "dedupe_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "dedupe_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(dds)
+
+	var purger storefront.Purger
+	if *cdnPurgeURL != "" {
+		purger = storefront.NewHTTPPurger(*cdnPurgeURL, *cdnPurgeKey, nil)
+	}
+	var sfs storefront.Service
+	sfs = storefront.NewService(sfrepo, crepo, purger, storefront.Config{Currency: *fxBase})
+	sfs = storefront.LoggingMiddleware(kitlog.NewContext(logger).With("component", "storefront"))(sfs)
+	sfs = storefront.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "storefront_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "storefront_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(sfs)
+	go jobs.Run(ctx, "storefront-purger", func(ctx context.Context) {
+		storefront.RunPurger(ctx, sfs, *cdnPurgeInterval, kitlog.NewContext(logger).With("component", "storefront"))
+	})
+
+	var ims images.Service
+	ims = images.NewService(crepo, urepo, images.Config{
+		Secret:    secret(*imageSecret, "image-secret"),
+		BaseURL:   *imageBaseURL,
+		StoreURL:  *storeURL,
+		CacheSize: *imageCacheSize,
+	})
+	ims = images.LoggingMiddleware(kitlog.NewContext(logger).With("component", "images"))(ims)
+	ims = images.InstrumentingMiddleware(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "images_service",
+			Name:      "request_count",
+			Help:      "Number of requests received",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "images_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds",
+		}, fieldKeys),
+	)(ims)
 
 	httpLogger := kitlog.NewContext(logger).With("component", "http")
 	mux := http.NewServeMux()
 
-	userHandler := user.MakeHTTPHandler(ctx, us, httpLogger)
-	catalogHandler := catalog.MakeHTTPHandler(ctx, cs, httpLogger)
-	orderHandler := order.MakeHTTPHandler(ctx, os, httpLogger)
+	policy := passwordpolicy.Policy{
+		passwordpolicy.MinLength(*passwordMinLength),
+		passwordpolicy.Classes(*passwordClasses),
+	}
+	if *passwordBreachURL != "" {
+		policy = append(policy, passwordpolicy.Breached(*passwordBreachURL, &http.Client{Timeout: 5 * time.Second}))
+	}
+	var limiterRedis *redis.Client
+	if *rateLimitRedis != "" {
+		limiterRedis = redis.NewClient(*rateLimitRedis, *rateLimitRedisPassword)
+	}
+	limiter := func(prefix string, limit int) ratelimit.Limiter {
+		rate := ratelimit.Rate{Limit: limit, Per: time.Minute}
+		switch {
+		case limit <= 0:
+			return nil
+		case limiterRedis != nil:
+			return ratelimit.NewRedisLimiter(limiterRedis, prefix, rate)
+		}
+		return ratelimit.NewMemoryLimiter(rate)
+	}
+	limits := user.Limits{
+		Requests: limiter("ratelimit:users:", *rateLimit),
+		Login:    limiter("ratelimit:login:", *loginRateLimit),
+	}
+
+	userHandler := user.MakeHTTPHandler(ctx, us, tokens, social, policy, aus, limits, forgery, httpLogger)
+	erasureHandler := erasure.MakeHTTPHandler(ctx, ers, account, admin, aus, httpLogger)
+	auditHandler := audit.MakeHTTPHandler(ctx, aus, rbac.Staff(tokens, user.RoleAdmin), httpLogger)
+	catalogHandler := catalog.MakeHTTPHandler(ctx, cs, admin, httpLogger)
+	orderHandler := order.MakeHTTPHandler(ctx, os, admin, httpLogger)
+	paymentHandler := payment.MakeHTTPHandler(ctx, pms, admin, httpLogger)
+	audiobookHandler := audiobook.MakeHTTPHandler(ctx, as, account, httpLogger)
+	ebookHandler := ebook.MakeHTTPHandler(ctx, es, account, httpLogger)
+	readingHandler := reading.MakeHTTPHandler(ctx, rs, account, httpLogger)
+	fulfillmentHandler := fulfillment.MakeHTTPHandler(ctx, fs, admin, httpLogger)
+	donationHandler := donation.MakeHTTPHandler(ctx, ds, account, admin, httpLogger)
+	creditHandler := credit.MakeHTTPHandler(ctx, crs, account, admin, httpLogger)
+	vendorsHandler := vendors.MakeHTTPHandler(ctx, vs, admin, httpLogger)
+	fraudHandler := fraud.MakeHTTPHandler(ctx, frs, admin, httpLogger)
+	denylistHandler := denylist.MakeHTTPHandler(ctx, dls, admin, httpLogger)
+	currencyHandler := currency.MakeHTTPHandler(ctx, curs, httpLogger)
+	vatHandler := vat.MakeHTTPHandler(ctx, vts, account, admin, httpLogger)
+	addressHandler := address.MakeHTTPHandler(ctx, ads, httpLogger)
+	deliveryHandler := delivery.MakeHTTPHandler(ctx, dvs, admin, httpLogger)
+	customsHandler := customs.MakeHTTPHandler(ctx, css, admin, httpLogger)
+	feedsHandler := feeds.MakeHTTPHandler(ctx, fds, admin, httpLogger)
+	affiliateHandler := affiliate.MakeHTTPHandler(ctx, afs, account, admin, httpLogger)
+	restockHandler := restock.MakeHTTPHandler(ctx, rss, admin, httpLogger)
+	labelsHandler := labels.MakeHTTPHandler(ctx, lbs, staff, httpLogger)
+	pickingHandler := picking.MakeHTTPHandler(ctx, pks, staff, httpLogger)
+	stocktakeHandler := stocktake.MakeHTTPHandler(ctx, sts, staff, httpLogger)
+	shelfHandler := shelf.MakeHTTPHandler(ctx, shs, account, httpLogger)
+	deprecationHandler := deprecation.MakeHTTPHandler(ctx, dps, admin, httpLogger)
+	exportHandler := export.MakeHTTPHandler(ctx, exs, httpLogger)
+	rectificationHandler := rectification.MakeHTTPHandler(ctx, rcs, account, admin, httpLogger)
+	archiveHandler := archive.MakeHTTPHandler(ctx, arcs, account, admin, httpLogger)
+	returnsHandler := returns.MakeHTTPHandler(ctx, rts, us, httpLogger)
+	accountingHandler := accounting.MakeHTTPHandler(ctx, acs, admin, httpLogger)
+	dashboardHandler := dashboard.MakeHTTPHandler(ctx, dbs, admin, httpLogger)
+	supportHandler := support.MakeHTTPHandler(ctx, sps, account, httpLogger)
+	segmentHandler := segment.MakeHTTPHandler(ctx, sgs, admin, httpLogger)
+	qualityHandler := quality.MakeHTTPHandler(ctx, qs, admin, httpLogger)
+	dedupeHandler := dedupe.MakeHTTPHandler(ctx, dds, admin, httpLogger)
+	imagesHandler := images.MakeHTTPHandler(ctx, ims, httpLogger)
+	browsingHandler := browsing.MakeHTTPHandler(ctx, brs, us, httpLogger)
+	storefrontHandler := storefront.MakeHTTPHandler(ctx, sfs, storefront.Cache{MaxAge: *publicMaxAge, EdgeMaxAge: *publicEdgeMaxAge}, httpLogger)
+	consentHandler := consent.MakeHTTPHandler(ctx, cns, us, httpLogger)
+	payoutHandler := payout.MakeHTTPHandler(ctx, ps, vs, admin, httpLogger)
 
 	mux.Handle("/users/v1/", userHandler)
 	mux.Handle("/catalog/v1/", catalogHandler)
-	mux.Handle("/order/v1/", orderHandler)
+	mux.Handle("/books/v1/", catalogHandler)
+	mux.Handle("/orders/v1/", support.Handler(supportHandler, orderHandler))
+	mux.Handle("/audiobooks/v1/", audiobookHandler)
+	mux.Handle("/ebooks/v1/", ebookHandler)
+	mux.Handle("/reading/v1/", readingHandler)
+	mux.Handle("/fulfillment/v1/", fulfillmentHandler)
+	mux.Handle("/donations/v1/", donationHandler)
+	mux.Handle("/credit/v1/", creditHandler)
+	mux.Handle("/vendors/v1/", vendorsHandler)
+	mux.Handle("/payouts/v1/", payoutHandler)
+	mux.Handle("/fraud/v1/", fraudHandler)
+	mux.Handle("/denylist/v1/", denylistHandler)
+	mux.Handle("/currency/v1/", currencyHandler)
+	mux.Handle("/vat/v1/", vatHandler)
+	mux.Handle("/address/v1/", addressHandler)
+	mux.Handle("/delivery/v1/", deliveryHandler)
+	mux.Handle("/customs/v1/", customsHandler)
+	mux.Handle("/feeds/v1/", feedsHandler)
+	mux.Handle("/affiliates/v1/", affiliateHandler)
+	mux.Handle("/restock/v1/", restockHandler)
+	mux.Handle("/labels/v1/", labelsHandler)
+	mux.Handle("/picking/v1/", pickingHandler)
+	mux.Handle("/stocktake/v1/", stocktakeHandler)
+	mux.Handle("/shelves/v1/", shelfHandler)
+	mux.Handle("/deprecations/v1/", deprecationHandler)
+	mux.Handle("/exports/v1/", exportHandler)
+	mux.Handle("/rectifications/v1/", rectificationHandler)
+	mux.Handle("/users/v1/me/consents", consentHandler)
+	mux.Handle("/users/v1/me/consents/", consentHandler)
+	mux.Handle("/users/v1/me", erasureHandler)
+	mux.Handle("/users/v1/me/erasure", erasureHandler)
+	mux.Handle("/erasures/v1/", erasureHandler)
+	mux.Handle("/archive/v1/", archiveHandler)
+	mux.Handle("/returns/v1/", returnsHandler)
+	mux.Handle("/accounting/v1/", accountingHandler)
+	mux.Handle("/admin/v1/", dashboardHandler)
+	mux.Handle("/support/v1/", supportHandler)
+	mux.Handle("/segments/v1/", segmentHandler)
+	mux.Handle("/quality/v1/", qualityHandler)
+	mux.Handle("/dedupe/v1/", dedupeHandler)
+	mux.Handle("/images/v1/", imagesHandler)
+	mux.Handle("/public/v1/", storefrontHandler)
+	mux.Handle("/browsing/v1/", browsingHandler)
+	mux.Handle("/payments/v1/", paymentHandler)
+	mux.Handle("/audit/v1/", auditHandler)
+
+	if *avatarStorage == "local" {
+		mux.Handle("/media/", http.StripPrefix("/media/", noListing(http.FileServer(http.Dir(*avatarDir)))))
+	}
 
 	mux.Handle("/metrics", stdprometheus.Handler())
-	http.Handle("/", mux)
+	if *shopCurrency == "" {
+		*shopCurrency = *fxBase
+	}
+	shopContexts := shopctx.NewResolver(shopctx.Config{
+		Secret:   secret(*shopContextSecret, "shop-context-secret"),
+		Currency: *shopCurrency,
+		Locale:   *shopLocale,
+		Store:    *shopStore,
+		Buckets:  *experimentBuckets,
+	})
+
+	exportLogger := kitlog.NewContext(logger).With("component", "export")
+	headers := secure.Config{
+		HSTSMaxAge:            *hstsMaxAge,
+		HSTSSubdomains:        *hstsSubdomains,
+		FrameOptions:          *frameOptions,
+		ContentSecurityPolicy: *contentSecurityPolicy,
+	}
+	proxies, err := realip.ParseProxies(*trustedProxies)
+	if err != nil {
+		log.Fatalf("error parsing trusted proxies: %v\n", err)
+	}
+	http.Handle("/readyz", health.Handler(readiness))
+	http.Handle("/", health.Guard(readiness, realip.Handler(proxies, secure.Handler(headers, shopctx.Handler(shopContexts, deprecations.Handler(user.TimezoneHandler(us, export.Handler(exs, exportSources, exportRequester(us), exportLogger, dedupe.Handler(dds, mux)))))))))
 
 	log.Println("bookserver: Listening on", *listenAddr)
 	log.Fatal(http.ListenAndServe(*listenAddr, nil))
 }
+
+// resolveSecret resolves the reference of the secret flag name, exiting if
+// it fails.
+func resolveSecret(ctx context.Context, r *secrets.Resolver, name, ref string) *secrets.Value {
+	v, err := r.Resolve(ctx, name, ref)
+	if err != nil {
+		log.Fatalf("error resolving %s: %v\n", name, err)
+	}
+	return v
+}
+
+// secret returns the secret of the flag name, or a random one when it is
+// empty, which doesn't survive a restart.
+func secret(value, name string) []byte {
+	if value != "" {
+		return []byte(value)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("error creating %s: %v\n", name, err)
+	}
+	log.Printf("bookserver: no %s set, using a random secret\n", name)
+	return b
+}
+
+// noListing serves the files of h, not the listings of its directories,
+// which would enumerate the users with an avatar.
+func noListing(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") || r.URL.Path == "" {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// exportRequester mails the async exports to the email of the user of the
+// request.
+func exportRequester(us user.Service) export.Requester {
+	return func(ctx context.Context, userID string) (string, error) {
+		u, err := us.Get(ctx, userID)
+		return u.Email, err
+	}
+}
+
+// schemaReason tells why the database schema stored is incompatible with
+// the server.
+func schemaReason(stored db.Schema) string {
+	return fmt.Sprintf("database schema version %d needs version %d or newer, this server is version %d", stored.Version, stored.Compatible, db.CurrentSchema.Version)
+}
+
+// watchSchema checks every interval that the database schema is still
+// compatible, a newer version having migrated it during a rolling deploy.
+// The server exits once it isn't rather than writing in the old one,
+// restarting read-only or refusing to serve.
+func watchSchema(ctx context.Context, schemas db.SchemaRepo, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stored, err := schemas.Schema()
+		if err != nil {
+			log.Printf("bookserver: error checking the database schema: %v\n", err)
+			continue
+		}
+		if db.CheckSchema(db.CurrentSchema, stored) != nil {
+			log.Fatalf("bookserver: %s, exiting\n", schemaReason(stored))
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package accounting
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kavirajk/bookshop/export"
+)
+
+var (
+	ErrUnknownFormat = errors.New("format must be xero or quickbooks")
+)
+
+// Kinds of the exported lines.
+const (
+	KindSale       = "sale"
+	KindRefund     = "refund"
+	KindChargeback = "chargeback"
+)
+
+// Tax codes of the lines, mapped to the tax types of the accounting
+// software by Config.TaxTypes.
+const (
+	TaxStandard      = "standard"
+	TaxReverseCharge = "reverse_charge"
+	TaxNone          = "none"
+)
+
+// Formats of the batches.
+const (
+	FormatXero       = "xero"
+	FormatQuickBooks = "quickbooks"
+)
+
+// Line is a sale, a refund or a chargeback, with its VAT, as exported.
+// Refunds and chargebacks are negative.
+type Line struct {
+	Kind string
+	// Number is the invoice number, the order for a sale, the credit note
+	// for a refund or the dispute for a chargeback.
+	Number      string
+	OrderID     string
+	Date        time.Time
+	Description string
+	Currency    string
+	Net         float64
+	Tax         float64
+	Rate        float64
+	TaxCode     string
+}
+
+// Total sums the lines of a currency, Refunds and Chargebacks being
+// positive.
+type Total struct {
+	BatchID     string  `json:"-" gorm:"primary_key"`
+	Currency    string  `json:"currency" gorm:"primary_key"`
+	Lines       int     `json:"lines"`
+	Sales       float64 `json:"sales"`       // net sales
+	Refunds     float64 `json:"refunds"`     // net refunds
+	Chargebacks float64 `json:"chargebacks"` // net revenue reversed by lost disputes
+	Tax         float64 `json:"tax"`         // VAT on the sales less the VAT refunded or charged back
+}
+
+func (Total) TableName() string {
+	return "accounting_totals"
+}
+
+// equal compares t and o to the cent.
+func (t Total) equal(o Total) bool {
+	return t.Lines == o.Lines && cents(t.Sales) == cents(o.Sales) &&
+		cents(t.Refunds) == cents(o.Refunds) && cents(t.Chargebacks) == cents(o.Chargebacks) &&
+		cents(t.Tax) == cents(o.Tax)
+}
+
+// Totals sums lines per currency, sorted by currency.
+func Totals(lines []Line) []Total {
+	type sum struct{ lines, sales, refunds, chargebacks, tax int64 }
+	sums := make(map[string]*sum)
+	for _, l := range lines {
+		s, ok := sums[l.Currency]
+		if !ok {
+			s = &sum{}
+			sums[l.Currency] = s
+		}
+		s.lines++
+		switch l.Kind {
+		case KindRefund:
+			s.refunds -= cents(l.Net)
+		case KindChargeback:
+			s.chargebacks -= cents(l.Net)
+		default:
+			s.sales += cents(l.Net)
+		}
+		s.tax += cents(l.Tax)
+	}
+	totals := make([]Total, 0, len(sums))
+	for currency, s := range sums {
+		totals = append(totals, Total{
+			Currency:    currency,
+			Lines:       int(s.lines),
+			Sales:       float64(s.sales) / 100,
+			Refunds:     float64(s.refunds) / 100,
+			Chargebacks: float64(s.chargebacks) / 100,
+			Tax:         float64(s.tax) / 100,
+		})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
+
+// Batch is the export of the lines of a period, a day. A period is exported
+// once, exporting it again returns its batch.
+type Batch struct {
+	ID          string    `json:"id"`
+	PeriodStart time.Time `json:"period_start" gorm:"unique_index"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Format      string    `json:"format"`
+	Lines       int       `json:"lines"`
+	Totals      []Total   `json:"totals" gorm:"ForeignKey:BatchID"`
+	Data        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Batch) TableName() string {
+	return "accounting_batches"
+}
+
+// FileName is the name of the file of the batch.
+func (b Batch) FileName() string {
+	return "accounting-" + b.PeriodStart.UTC().Format("20060102") + "-" + b.Format + ".csv"
+}
+
+// Difference compares the exported totals of a currency with the ones of
+// the current order data.
+type Difference struct {
+	Currency string `json:"currency"`
+	Exported Total  `json:"exported"`
+	Current  Total  `json:"current"`
+	Matched  bool   `json:"matched"`
+}
+
+// Reconciliation compares a batch with the current order data of its
+// period. Orders, VAT, credit notes or disputes changed or added since the export
+// show up as differences.
+type Reconciliation struct {
+	BatchID     string       `json:"batch_id"`
+	PeriodStart time.Time    `json:"period_start"`
+	PeriodEnd   time.Time    `json:"period_end"`
+	Matched     bool         `json:"matched"`
+	Currencies  []Difference `json:"currencies"`
+}
+
+// Reconcile compares the exported totals with the current ones, per
+// currency.
+func Reconcile(b Batch, current []Total) Reconciliation {
+	rec := Reconciliation{BatchID: b.ID, PeriodStart: b.PeriodStart, PeriodEnd: b.PeriodEnd, Matched: true}
+	byCurrency := make(map[string]*Difference)
+	var currencies []string
+	diff := func(currency string) *Difference {
+		d, ok := byCurrency[currency]
+		if !ok {
+			d = &Difference{Currency: currency, Exported: Total{Currency: currency}, Current: Total{Currency: currency}}
+			byCurrency[currency] = d
+			currencies = append(currencies, currency)
+		}
+		return d
+	}
+	for _, t := range b.Totals {
+		t.BatchID = ""
+		diff(t.Currency).Exported = t
+	}
+	for _, t := range current {
+		diff(t.Currency).Current = t
+	}
+	sort.Strings(currencies)
+	rec.Currencies = make([]Difference, 0, len(currencies))
+	for _, c := range currencies {
+		d := byCurrency[c]
+		d.Matched = d.Exported.equal(d.Current)
+		rec.Matched = rec.Matched && d.Matched
+		rec.Currencies = append(rec.Currencies, *d)
+	}
+	return rec
+}
+
+// Config controls the accounting export.
+type Config struct {
+	Format string
+	// Contact is the customer the lines are booked on, the shop's sales
+	// being booked together rather than per customer.
+	Contact           string
+	SalesAccount      string
+	RefundAccount     string
+	ChargebackAccount string
+	// TaxTypes maps the tax codes of the lines to the tax types of the
+	// accounting software.
+	TaxTypes map[string]string
+	// Since is the first day exported when there is no batch yet, the day
+	// before the first run by default.
+	Since time.Time
+}
+
+// DefaultConfig exports to Xero on its default sales account.
+func DefaultConfig() Config {
+	return Config{
+		Format:            FormatXero,
+		Contact:           "Webshop customers",
+		SalesAccount:      "200",
+		RefundAccount:     "200",
+		ChargebackAccount: "200",
+		TaxTypes: map[string]string{
+			TaxStandard:      "OUTPUT",
+			TaxReverseCharge: "ZERORATEDOUTPUT",
+			TaxNone:          "NONE",
+		},
+	}
+}
+
+// Columns returns the CSV columns of format, the invoice import of the
+// accounting software, rows being Lines.
+func Columns(format string, cfg Config) (export.Columns, error) {
+	line := func(f func(l Line) string) func(row interface{}) string {
+		return func(row interface{}) string { return f(row.(Line)) }
+	}
+	date := line(func(l Line) string { return l.Date.UTC().Format("2006-01-02") })
+	account := line(func(l Line) string {
+		switch l.Kind {
+		case KindRefund:
+			return cfg.RefundAccount
+		case KindChargeback:
+			return cfg.ChargebackAccount
+		}
+		return cfg.SalesAccount
+	})
+	taxType := line(func(l Line) string { return cfg.TaxTypes[l.TaxCode] })
+	switch format {
+	case FormatXero:
+		return export.Columns{
+			{Name: "ContactName", Value: line(func(l Line) string { return cfg.Contact })},
+			{Name: "InvoiceNumber", Value: line(func(l Line) string { return l.Number })},
+			{Name: "Reference", Value: line(func(l Line) string { return l.OrderID })},
+			{Name: "InvoiceDate", Value: date},
+			{Name: "DueDate", Value: date},
+			{Name: "Description", Value: line(func(l Line) string { return l.Description })},
+			{Name: "Quantity", Value: line(func(l Line) string { return "1" })},
+			{Name: "UnitAmount", Value: line(func(l Line) string { return amount(l.Net) })},
+			{Name: "AccountCode", Value: account},
+			{Name: "TaxType", Value: taxType},
+			{Name: "TaxAmount", Value: line(func(l Line) string { return amount(l.Tax) })},
+			{Name: "Currency", Value: line(func(l Line) string { return l.Currency })},
+		}, nil
+	case FormatQuickBooks:
+		return export.Columns{
+			{Name: "InvoiceNo", Value: line(func(l Line) string { return l.Number })},
+			{Name: "Customer", Value: line(func(l Line) string { return cfg.Contact })},
+			{Name: "InvoiceDate", Value: date},
+			{Name: "DueDate", Value: date},
+			{Name: "ItemDescription", Value: line(func(l Line) string { return l.Description })},
+			{Name: "ItemQuantity", Value: line(func(l Line) string { return "1" })},
+			{Name: "ItemRate", Value: line(func(l Line) string { return amount(l.Net) })},
+			{Name: "ItemAmount", Value: line(func(l Line) string { return amount(l.Net) })},
+			{Name: "ItemTaxCode", Value: taxType},
+			{Name: "ItemTaxAmount", Value: line(func(l Line) string { return amount(l.Tax) })},
+			{Name: "Currency", Value: line(func(l Line) string { return l.Currency })},
+		}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func amount(a float64) string {
+	return strconv.FormatFloat(float64(cents(a))/100, 'f', 2, 64)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
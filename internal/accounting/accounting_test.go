@@ -0,0 +1,149 @@
+package accounting_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/accounting"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var day = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+var lines = []accounting.Line{
+	{Kind: accounting.KindSale, Number: "o1", OrderID: "o1", Date: day, Currency: "EUR", Net: 10.10, Tax: 2.02, Rate: 20, TaxCode: accounting.TaxStandard},
+	{Kind: accounting.KindSale, Number: "o2", OrderID: "o2", Date: day, Currency: "EUR", Net: 0.20, TaxCode: accounting.TaxNone},
+	{Kind: accounting.KindRefund, Number: "c1", OrderID: "o1", Date: day, Currency: "EUR", Net: -5, Tax: -1, Rate: 20, TaxCode: accounting.TaxStandard},
+	{Kind: accounting.KindSale, Number: "o3", OrderID: "o3", Date: day, Currency: "USD", Net: 7, TaxCode: accounting.TaxNone},
+}
+
+func TestTotals(t *testing.T) {
+	totals := accounting.Totals(lines)
+	if len(totals) != 2 {
+		t.Fatalf("totals: expected EUR and USD, got %+v", totals)
+	}
+	eur := totals[0]
+	if eur.Currency != "EUR" || eur.Lines != 3 || eur.Sales != 10.30 || eur.Refunds != 5 || eur.Tax != 1.02 {
+		t.Errorf("EUR: unexpected totals %+v", eur)
+	}
+	if usd := totals[1]; usd.Currency != "USD" || usd.Sales != 7 {
+		t.Errorf("USD: unexpected totals %+v", usd)
+	}
+}
+
+func TestChargebacks(t *testing.T) {
+	lost := append([]accounting.Line{}, lines...)
+	lost = append(lost, accounting.Line{Kind: accounting.KindChargeback, Number: "d1", OrderID: "o1", Date: day, Currency: "EUR", Net: -10.10, Tax: -2.02, Rate: 20, TaxCode: accounting.TaxStandard})
+	eur := accounting.Totals(lost)[0]
+	if eur.Lines != 4 || eur.Sales != 10.30 || eur.Refunds != 5 || eur.Chargebacks != 10.10 || eur.Tax != -1 {
+		t.Errorf("EUR: unexpected totals %+v", eur)
+	}
+	b := accounting.Batch{ID: "b1", Totals: accounting.Totals(lines)}
+	if rec := accounting.Reconcile(b, accounting.Totals(lost)); rec.Matched {
+		t.Errorf("lost dispute: expected a difference, got %+v", rec)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	b := accounting.Batch{ID: "b1", PeriodStart: day, PeriodEnd: day.AddDate(0, 0, 1), Totals: accounting.Totals(lines)}
+	if rec := accounting.Reconcile(b, accounting.Totals(lines)); !rec.Matched || len(rec.Currencies) != 2 {
+		t.Errorf("same data: expected matched, got %+v", rec)
+	}
+
+	changed := append([]accounting.Line{}, lines[:3]...)
+	changed[1].Net = 0.30
+	rec := accounting.Reconcile(b, accounting.Totals(changed))
+	if rec.Matched || len(rec.Currencies) != 2 {
+		t.Fatalf("changed data: expected differences, got %+v", rec)
+	}
+	if eur := rec.Currencies[0]; eur.Matched || eur.Current.Sales != 10.40 {
+		t.Errorf("EUR: expected the changed sale, got %+v", eur)
+	}
+	if usd := rec.Currencies[1]; usd.Matched || usd.Current.Lines != 0 {
+		t.Errorf("USD: expected a missing sale, got %+v", usd)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	cfg := accounting.DefaultConfig()
+	cols, err := accounting.Columns(accounting.FormatXero, cfg)
+	if err != nil {
+		t.Fatalf("xero: unexpected error %v", err)
+	}
+	var b bytes.Buffer
+	rows := func(limit, offset int) ([]interface{}, error) {
+		if offset > 0 {
+			return nil, nil
+		}
+		return []interface{}{lines[2]}, nil
+	}
+	if _, err := export.Write(context.Background(), &b, cols, export.Rows(rows)); err != nil {
+		t.Fatalf("write: unexpected error %v", err)
+	}
+	out := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := "Webshop customers,c1,o1,2026-03-01,2026-03-01,,1,-5.00,200,OUTPUT,-1.00,EUR"
+	if len(out) != 2 || out[1] != want {
+		t.Errorf("xero: expected %s, got %v", want, out)
+	}
+
+	if _, err := accounting.Columns("sage", cfg); err != accounting.ErrUnknownFormat {
+		t.Errorf("unknown format: expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+// batchRepo has no batch, the other methods of the Repo aren't used.
+type batchRepo struct {
+	accounting.Repo
+}
+
+func (batchRepo) Get(ID string) (accounting.Batch, error) {
+	return accounting.Batch{}, db.ErrNotFound
+}
+
+func (batchRepo) List() ([]accounting.Batch, error) {
+	return nil, nil
+}
+
+func TestAdminRoutes(t *testing.T) {
+	s := accounting.NewService(batchRepo{}, nil, nil, nil, nil, accounting.DefaultConfig())
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := accounting.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"export without token", "POST", "/accounting/v1/batches", `{"date":"2026-03-01"}`, "", http.StatusUnauthorized},
+		{"export by customer", "POST", "/accounting/v1/batches", `{"date":"2026-03-01"}`, customer, http.StatusForbidden},
+		{"batches by customer", "GET", "/accounting/v1/batches", "", customer, http.StatusForbidden},
+		{"batch by customer", "GET", "/accounting/v1/batches/b1", "", customer, http.StatusForbidden},
+		{"download by customer", "GET", "/accounting/v1/batches/b1/download", "", customer, http.StatusForbidden},
+		{"reconciliation by customer", "GET", "/accounting/v1/batches/b1/reconciliation", "", customer, http.StatusForbidden},
+		{"batches by admin", "GET", "/accounting/v1/batches", "", staff, http.StatusOK},
+		{"batch by admin", "GET", "/accounting/v1/batches/b1", "", staff, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package accounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the accounting service endpoints under single
+// type.
+type Endpoints struct {
+	ExportDayEndpoint endpoint.Endpoint
+	BatchesEndpoint   endpoint.Endpoint
+	BatchEndpoint     endpoint.Endpoint
+	DownloadEndpoint  endpoint.Endpoint
+	ReconcileEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the accounting service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ExportDayEndpoint: admin(MakeExportDayEndpoint(s)),
+		BatchesEndpoint:   admin(MakeBatchesEndpoint(s)),
+		BatchEndpoint:     admin(MakeBatchEndpoint(s)),
+		DownloadEndpoint:  admin(MakeDownloadEndpoint(s)),
+		ReconcileEndpoint: admin(MakeReconcileEndpoint(s)),
+	}
+}
+
+func MakeExportDayEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(exportDayRequest)
+		b, e := s.ExportDay(ctx, req.Day)
+		if e != nil {
+			return batchResponse{Batch: nil, Error: e}, nil
+		}
+		return batchResponse{Batch: &b}, nil
+	}
+}
+
+func MakeBatchesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		batches, e := s.Batches(ctx)
+		if e != nil {
+			return batchesResponse{Batches: make([]Batch, 0), Error: e}, nil
+		}
+		return batchesResponse{Batches: batches}, nil
+	}
+}
+
+func MakeBatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchRequest)
+		b, e := s.Batch(ctx, req.ID)
+		if e != nil {
+			return batchResponse{Batch: nil, Error: e}, nil
+		}
+		return batchResponse{Batch: &b}, nil
+	}
+}
+
+func MakeDownloadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchRequest)
+		b, e := s.Batch(ctx, req.ID)
+		if e != nil {
+			return downloadResponse{Error: e}, nil
+		}
+		return downloadResponse{Name: b.FileName(), Data: b.Data}, nil
+	}
+}
+
+func MakeReconcileEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchRequest)
+		rec, e := s.Reconcile(ctx, req.ID)
+		if e != nil {
+			return reconcileResponse{Error: e}, nil
+		}
+		return reconcileResponse{Reconciliation: &rec}, nil
+	}
+}
+
+type exportDayRequest struct {
+	Date string    `json:"date"`
+	Day  time.Time `json:"-"`
+}
+
+type batchRequest struct {
+	ID string
+}
+
+type batchResponse struct {
+	Batch *Batch `json:"batch,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r batchResponse) error() error {
+	return r.Error
+}
+
+type batchesResponse struct {
+	Batches []Batch `json:"batches"`
+	Error   error   `json:"error,omitempty"`
+}
+
+func (r batchesResponse) error() error {
+	return r.Error
+}
+
+type downloadResponse struct {
+	Name  string
+	Data  []byte
+	Error error
+}
+
+type reconcileResponse struct {
+	Reconciliation *Reconciliation `json:"reconciliation,omitempty"`
+	Error          error           `json:"error,omitempty"`
+}
+
+func (r reconcileResponse) error() error {
+	return r.Error
+}
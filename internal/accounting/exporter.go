@@ -0,0 +1,24 @@
+package accounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunExporter exports the days over every interval until ctx is done.
+func RunExporter(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Export(ctx); err != nil {
+				logger.Log("exporter", "accounting", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package accounting
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Export(ctx context.Context) (batchs []Batch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "export", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batchs, err = mw.next.Export(ctx)
+	return
+}
+
+func (mw instrmw) ExportDay(ctx context.Context, day time.Time) (batch Batch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "export_day", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batch, err = mw.next.ExportDay(ctx, day)
+	return
+}
+
+func (mw instrmw) Batches(ctx context.Context) (batchs []Batch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "batches", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batchs, err = mw.next.Batches(ctx)
+	return
+}
+
+func (mw instrmw) Batch(ctx context.Context, ID string) (batch Batch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "batch", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batch, err = mw.next.Batch(ctx, ID)
+	return
+}
+
+func (mw instrmw) Reconcile(ctx context.Context, ID string) (reconciliation Reconciliation, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reconcile", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	reconciliation, err = mw.next.Reconcile(ctx, ID)
+	return
+}
@@ -0,0 +1,78 @@
+package accounting
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Export(ctx context.Context) (batchs []Batch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "export",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Export(ctx)
+}
+
+func (s loggingService) ExportDay(ctx context.Context, day time.Time) (batch Batch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "export_day",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ExportDay(ctx, day)
+}
+
+func (s loggingService) Batches(ctx context.Context) (batchs []Batch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "batches",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Batches(ctx)
+}
+
+func (s loggingService) Batch(ctx context.Context, ID string) (batch Batch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "batch",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Batch(ctx, ID)
+}
+
+func (s loggingService) Reconcile(ctx context.Context, ID string) (reconciliation Reconciliation, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reconcile",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Reconcile(ctx, ID)
+}
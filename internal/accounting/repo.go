@@ -0,0 +1,18 @@
+package accounting
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Accounting
+// service.
+type Repo interface {
+	// Create stores the batch along with its totals.
+	Create(b *Batch) error
+	// Get returns a batch with its totals and data.
+	Get(ID string) (Batch, error)
+	GetByPeriod(start time.Time) (Batch, error)
+	// Last returns the batch of the latest period.
+	Last() (Batch, error)
+	// List returns the batches with their totals, latest period first.
+	List() ([]Batch, error)
+	Drop() error
+}
@@ -0,0 +1,326 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payment"
+	"github.com/kavirajk/bookshop/vat"
+)
+
+var (
+	ErrBatchNotFound = errors.New("batch not found")
+	ErrPeriodOpen    = errors.New("day isn't over yet")
+)
+
+// pageSize is the orders read at once.
+const pageSize = 500
+
+type Service interface {
+	// Export exports every day over since the latest batch, one batch a
+	// day.
+	Export(ctx context.Context) ([]Batch, error)
+
+	// ExportDay exports the UTC day of day, or returns its batch if it was
+	// exported already.
+	ExportDay(ctx context.Context, day time.Time) (Batch, error)
+
+	// Batches returns the batches, latest period first.
+	Batches(ctx context.Context) ([]Batch, error)
+
+	// Batch returns a batch along with its data.
+	Batch(ctx context.Context, ID string) (Batch, error)
+
+	// Reconcile compares the totals of a batch with the current order data
+	// of its period. It only reads, it can be run any number of times.
+	Reconcile(ctx context.Context, ID string) (Reconciliation, error)
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	taxes     vat.Repo
+	documents archive.Repo
+	payments  payment.Repo
+	cfg       Config
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, taxes vat.Repo, documents archive.Repo, payments payment.Repo, cfg Config) Service {
+	return basicService{r: r, orders: orders, taxes: taxes, documents: documents, payments: payments, cfg: cfg}
+}
+
+// Export starts from Config.Since when there is no batch yet.
+func (s basicService) Export(ctx context.Context) ([]Batch, error) {
+	today := day(time.Now())
+	from := today.AddDate(0, 0, -1)
+	if !s.cfg.Since.IsZero() {
+		from = day(s.cfg.Since)
+	}
+	last, err := s.r.Last()
+	switch {
+	case err == nil:
+		from = last.PeriodEnd
+	case err != db.ErrNotFound:
+		return nil, err
+	}
+	var batches []Batch
+	for d := from; d.Before(today); d = d.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return batches, err
+		}
+		b, err := s.ExportDay(ctx, d)
+		if err != nil {
+			return batches, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
+
+// ExportDay is idempotent, a concurrent export of the same day stores a
+// single batch, the unique period start failing the other one.
+func (s basicService) ExportDay(ctx context.Context, d time.Time) (Batch, error) {
+	from := day(d)
+	to := from.AddDate(0, 0, 1)
+	if to.After(time.Now()) {
+		return Batch{}, ErrPeriodOpen
+	}
+	if b, err := s.r.GetByPeriod(from); err == nil {
+		return b, nil
+	} else if err != db.ErrNotFound {
+		return Batch{}, err
+	}
+	lines, err := s.lines(from, to)
+	if err != nil {
+		return Batch{}, err
+	}
+	cols, err := Columns(s.cfg.Format, s.cfg)
+	if err != nil {
+		return Batch{}, err
+	}
+	var buf bytes.Buffer
+	if _, err := export.Write(ctx, &buf, cols, rows(lines)); err != nil {
+		return Batch{}, err
+	}
+	b := Batch{
+		PeriodStart: from,
+		PeriodEnd:   to,
+		Format:      s.cfg.Format,
+		Lines:       len(lines),
+		Totals:      Totals(lines),
+		Data:        buf.Bytes(),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.r.Create(&b); err != nil {
+		if existing, gerr := s.r.GetByPeriod(from); gerr == nil {
+			return existing, nil
+		}
+		return Batch{}, err
+	}
+	return b, nil
+}
+
+// Batches leaves the data out.
+func (s basicService) Batches(ctx context.Context) ([]Batch, error) {
+	return s.r.List()
+}
+
+// Batch returns ErrBatchNotFound for unknown IDs.
+func (s basicService) Batch(ctx context.Context, ID string) (Batch, error) {
+	b, err := s.r.Get(ID)
+	if err == db.ErrNotFound {
+		return Batch{}, ErrBatchNotFound
+	}
+	return b, err
+}
+
+// Reconcile reads the lines of the period of the batch again.
+func (s basicService) Reconcile(ctx context.Context, ID string) (Reconciliation, error) {
+	b, err := s.Batch(ctx, ID)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	lines, err := s.lines(b.PeriodStart, b.PeriodEnd)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	return Reconcile(b, Totals(lines)), nil
+}
+
+// lines returns the sales of the orders placed between from and to, the
+// refunds of the credit notes issued then and the chargebacks of the
+// disputes lost then, by date.
+func (s basicService) lines(from, to time.Time) ([]Line, error) {
+	var lines []Line
+	f := filter.Expr{SQL: "created_at >= ? AND created_at < ?", Args: []interface{}{from, to}}
+	for offset := 0; ; offset += pageSize {
+		orders, _, err := s.orders.List(f, pageSize, offset, db.CountNone)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			tax, err := s.tax(o.ID)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, sale(o, tax))
+		}
+		if len(orders) < pageSize {
+			break
+		}
+	}
+	notes, err := s.documents.ListIssued(archive.KindCreditNote, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range notes {
+		var n archive.CreditNote
+		if err := json.Unmarshal([]byte(d.Content), &n); err != nil {
+			return nil, err
+		}
+		tax, err := s.tax(d.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, refund(d, n, tax))
+	}
+	disputes, err := s.payments.ListLostDisputes(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range disputes {
+		tax, err := s.tax(d.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, chargeback(d, tax))
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if !lines[i].Date.Equal(lines[j].Date) {
+			return lines[i].Date.Before(lines[j].Date)
+		}
+		return lines[i].Number < lines[j].Number
+	})
+	return lines, nil
+}
+
+// tax returns the VAT applied to an order, nil if none was.
+func (s basicService) tax(orderID string) (*vat.OrderTax, error) {
+	t, err := s.taxes.GetOrderTax(orderID)
+	if err == db.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// sale books an order on its net price and VAT, or on its total when no
+// VAT was applied.
+func sale(o order.Order, tax *vat.OrderTax) Line {
+	l := Line{
+		Kind:        KindSale,
+		Number:      o.ID,
+		OrderID:     o.ID,
+		Date:        o.CreatedAt,
+		Description: "Order " + o.ID,
+		Currency:    o.Currency,
+		Net:         o.TotalPrice,
+		TaxCode:     TaxNone,
+	}
+	if tax != nil {
+		l.Net, l.Tax, l.Rate, l.TaxCode = tax.Net, tax.VAT, tax.Rate, taxCode(*tax)
+	}
+	return l
+}
+
+// refund books a credit note, its amount being gross, the VAT refunded at
+// the rate of the order. The credit notes issued before the refund ledger
+// have no number and are booked under their document.
+func refund(d archive.Document, n archive.CreditNote, tax *vat.OrderTax) Line {
+	number := n.Number
+	if number == "" {
+		number = d.ID
+	}
+	l := Line{
+		Kind:        KindRefund,
+		Number:      number,
+		OrderID:     d.OrderID,
+		Date:        d.IssuedAt,
+		Description: "Credit note for order " + d.OrderID + ": " + n.Reason,
+		Currency:    n.Currency,
+		Net:         -n.Amount,
+		TaxCode:     TaxNone,
+	}
+	reverse(&l, n.Amount, tax)
+	return l
+}
+
+// chargeback books a lost dispute as a refund would be, the revenue of the
+// order being reversed by the bank of the customer.
+func chargeback(d payment.Dispute, tax *vat.OrderTax) Line {
+	l := Line{
+		Kind:        KindChargeback,
+		Number:      d.ID,
+		OrderID:     d.OrderID,
+		Date:        *d.ClosedAt,
+		Description: "Chargeback of order " + d.OrderID + ": " + d.Reason,
+		Currency:    d.Currency,
+		Net:         -d.Amount,
+		TaxCode:     TaxNone,
+	}
+	reverse(&l, d.Amount, tax)
+	return l
+}
+
+// reverse splits the gross amount taken back into its net and VAT, at the
+// rate of the order.
+func reverse(l *Line, gross float64, tax *vat.OrderTax) {
+	if tax == nil {
+		return
+	}
+	net := math.Floor(gross/(1+tax.Rate/100)*100+0.5) / 100
+	l.Net, l.Tax, l.Rate, l.TaxCode = -net, -(float64(cents(gross)-cents(net)) / 100), tax.Rate, taxCode(*tax)
+}
+
+func taxCode(t vat.OrderTax) string {
+	switch {
+	case t.ReverseCharge:
+		return TaxReverseCharge
+	case t.Rate > 0:
+		return TaxStandard
+	}
+	return TaxNone
+}
+
+// rows pages lines for export.Write.
+func rows(lines []Line) export.Rows {
+	return func(limit, offset int) ([]interface{}, error) {
+		var page []interface{}
+		for i := offset; i < len(lines) && i < offset+limit; i++ {
+			page = append(page, lines[i])
+		}
+		return page, nil
+	}
+}
+
+// day returns the start of the UTC day of t.
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,200 @@
+package accounting
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting  = errors.New("bad routing")
+	ErrInvalidDate = errors.New("invalid date, expected format 2006-01-02")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrBatchNotFound: "accounting.batch_not_found",
+		ErrPeriodOpen:    "accounting.period_open",
+		ErrInvalidDate:   "accounting.invalid_date",
+	})
+}
+
+// MakeHTTPHandler mounts the accounting endpoints, served to the requests
+// admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	exportDayHandler := httptransport.NewServer(
+		e.ExportDayEndpoint,
+		decodeExportDayRequest,
+		encodeResponse,
+		options...,
+	)
+	batchesHandler := httptransport.NewServer(
+		e.BatchesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	batchHandler := httptransport.NewServer(
+		e.BatchEndpoint,
+		decodeBatchRequest,
+		encodeResponse,
+		options...,
+	)
+	downloadHandler := httptransport.NewServer(
+		e.DownloadEndpoint,
+		decodeBatchRequest,
+		encodeDownload,
+		options...,
+	)
+	reconcileHandler := httptransport.NewServer(
+		e.ReconcileEndpoint,
+		decodeBatchRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/accounting/v1/batches", batchesHandler).Methods("GET")
+	r.Handle("/accounting/v1/batches", exportDayHandler).Methods("POST")
+	r.Handle("/accounting/v1/batches/{batch-id}", batchHandler).Methods("GET")
+	r.Handle("/accounting/v1/batches/{batch-id}/download", downloadHandler).Methods("GET")
+	r.Handle("/accounting/v1/batches/{batch-id}/reconciliation", reconcileHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeExportDayRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r exportDayRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	day, err := time.Parse(transport.DateLayout, r.Date)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	r.Day = day
+	return r, nil
+}
+
+func decodeBatchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["batch-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "batch-id")
+	}
+	return batchRequest{ID: ID}, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// encodeDownload sends the batch as a CSV attachment, to be imported in the
+// accounting software.
+func encodeDownload(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(downloadResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", export.ContentType+"; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrBatchNotFound:
+		return http.StatusNotFound
+	case ErrPeriodOpen:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrInvalidDate, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
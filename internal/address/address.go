@@ -0,0 +1,81 @@
+package address
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Statuses of an address verification.
+const (
+	StatusValid         = "valid"         // deliverable as given
+	StatusCorrected     = "corrected"     // deliverable after applying the suggestion
+	StatusUndeliverable = "undeliverable" // can't be delivered, ask the customer to fix it
+	StatusUnverified    = "unverified"    // provider couldn't tell
+)
+
+// Address is a postal address.
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// Validate does basic validation before verifying the address, reporting
+// all the failing fields.
+func (a Address) Validate() error {
+	var v validate.Validator
+	v.Required("line1", a.Line1)
+	v.Required("city", a.City)
+	v.Required("country", a.Country)
+	return v.Err()
+}
+
+// Normalize trims and collapses white space, upper cases the country and the
+// postal code.
+func Normalize(a Address) Address {
+	clean := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	return Address{
+		Name:       clean(a.Name),
+		Line1:      clean(a.Line1),
+		Line2:      clean(a.Line2),
+		City:       clean(a.City),
+		Region:     clean(a.Region),
+		PostalCode: strings.ToUpper(clean(a.PostalCode)),
+		Country:    strings.ToUpper(clean(a.Country)),
+	}
+}
+
+// Result of verifying an address.
+type Result struct {
+	Status      string    `json:"status"`
+	Address     Address   `json:"address"`
+	Suggestions []Address `json:"suggestions,omitempty"`
+	Messages    []string  `json:"messages,omitempty"`
+}
+
+// Deliverable tells whether the address can be shipped to.
+func (r Result) Deliverable() bool {
+	return r.Status == StatusValid || r.Status == StatusCorrected
+}
+
+// Check is the verification of an order's shipping address, consulted
+// before payment.
+type Check struct {
+	OrderID string `json:"order_id" gorm:"primary_key"`
+	Address
+	Status      string    `json:"status"`
+	Deliverable bool      `json:"deliverable"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+func (Check) TableName() string {
+	return "address_checks"
+}
@@ -0,0 +1,37 @@
+package address
+
+import (
+	"sort"
+	"strings"
+)
+
+// Embargo is the set of the countries the shop doesn't ship to, by ISO
+// 3166 code.
+type Embargo map[string]bool
+
+// ParseEmbargo reads a comma separated list of country codes, e.g.
+// "CU, IR, KP".
+func ParseEmbargo(list string) Embargo {
+	e := make(Embargo)
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			e[c] = true
+		}
+	}
+	return e
+}
+
+// Blocks tells whether country is embargoed.
+func (e Embargo) Blocks(country string) bool {
+	return e[strings.ToUpper(strings.TrimSpace(country))]
+}
+
+// Countries returns the embargoed countries in order.
+func (e Embargo) Countries() []string {
+	list := make([]string, 0, len(e))
+	for c := range e {
+		list = append(list, c)
+	}
+	sort.Strings(list)
+	return list
+}
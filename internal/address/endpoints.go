@@ -0,0 +1,84 @@
+package address
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the address service endpoints under single type.
+type Endpoints struct {
+	VerifyEndpoint     endpoint.Endpoint
+	CheckOrderEndpoint endpoint.Endpoint
+	OrderCheckEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the address service endpoints.
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		VerifyEndpoint:     MakeVerifyEndpoint(s),
+		CheckOrderEndpoint: MakeCheckOrderEndpoint(s),
+		OrderCheckEndpoint: MakeOrderCheckEndpoint(s),
+	}
+}
+
+func MakeVerifyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyRequest)
+		r, e := s.Verify(ctx, req.Address)
+		if e != nil {
+			return resultResponse{Result: nil, Error: e}, nil
+		}
+		return resultResponse{Result: &r}, nil
+	}
+}
+
+func MakeCheckOrderEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyRequest)
+		r, e := s.CheckOrder(ctx, req.OrderID, req.Address)
+		if e != nil {
+			return resultResponse{Result: nil, Error: e}, nil
+		}
+		return resultResponse{Result: &r}, nil
+	}
+}
+
+func MakeOrderCheckEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderCheckRequest)
+		c, e := s.OrderCheck(ctx, req.OrderID)
+		if e != nil {
+			return checkResponse{Check: nil, Error: e}, nil
+		}
+		return checkResponse{Check: &c}, nil
+	}
+}
+
+type verifyRequest struct {
+	OrderID string `json:"-"`
+	Address
+}
+
+type resultResponse struct {
+	Result *Result `json:"result,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r resultResponse) error() error {
+	return r.Error
+}
+
+type orderCheckRequest struct {
+	OrderID string
+}
+
+type checkResponse struct {
+	Check *Check `json:"check,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r checkResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,59 @@
+package address
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Verify(ctx context.Context, a Address) (result Result, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "verify", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	result, err = mw.next.Verify(ctx, a)
+	return
+}
+
+func (mw instrmw) CheckOrder(ctx context.Context, orderID string, a Address) (result Result, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "check_order", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	result, err = mw.next.CheckOrder(ctx, orderID, a)
+	return
+}
+
+func (mw instrmw) OrderCheck(ctx context.Context, orderID string) (check Check, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_check", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	check, err = mw.next.OrderCheck(ctx, orderID)
+	return
+}
@@ -0,0 +1,56 @@
+package address
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Verify(ctx context.Context, a Address) (result Result, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "verify",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Verify(ctx, a)
+}
+
+func (s loggingService) CheckOrder(ctx context.Context, orderID string, a Address) (result Result, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "check_order",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CheckOrder(ctx, orderID, a)
+}
+
+func (s loggingService) OrderCheck(ctx context.Context, orderID string) (check Check, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_check",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderCheck(ctx, orderID)
+}
@@ -0,0 +1,110 @@
+package address
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the third party verifying postal addresses.
+type Provider interface {
+	Verify(ctx context.Context, a Address) (Result, error)
+}
+
+type httpProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPProvider returns Provider posting the address to <baseURL>/verify
+// and reading back the Result as JSON.
+func NewHTTPProvider(baseURL, apiKey string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client}
+}
+
+func (p httpProvider) Verify(ctx context.Context, a Address) (Result, error) {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return Result{}, err
+	}
+	req, err := http.NewRequest("POST", p.baseURL+"/verify", bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "address verify")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("address verify: unexpected status %d", resp.StatusCode)
+	}
+	var r Result
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return Result{}, errors.Wrap(err, "address verify")
+	}
+	return r, nil
+}
+
+// postalCodes are the postal code formats known to the stub provider.
+var postalCodes = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] \d[A-Z]\d$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"NL": regexp.MustCompile(`^\d{4} [A-Z]{2}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+}
+
+// postalSuffix is the length of the part after the space, for the postal
+// codes having one.
+var postalSuffix = map[string]int{"CA": 3, "GB": 3, "NL": 2}
+
+type stubProvider struct{}
+
+// NewStubProvider returns Provider for development without a third party.
+// It only normalizes the address and checks the postal code format of a few
+// countries.
+func NewStubProvider() Provider {
+	return stubProvider{}
+}
+
+func (stubProvider) Verify(ctx context.Context, a Address) (Result, error) {
+	n := Normalize(a)
+	// Postal codes are often typed without the separating space.
+	if k, ok := postalSuffix[n.Country]; ok {
+		if pc := strings.Replace(n.PostalCode, " ", "", -1); len(pc) > k {
+			n.PostalCode = pc[:len(pc)-k] + " " + pc[len(pc)-k:]
+		}
+	}
+
+	re, ok := postalCodes[n.Country]
+	switch {
+	case !ok:
+		return Result{Status: StatusUnverified, Address: n}, nil
+	case !re.MatchString(n.PostalCode):
+		return Result{
+			Status:   StatusUndeliverable,
+			Address:  n,
+			Messages: []string{"invalid postal code for " + n.Country},
+		}, nil
+	case n != a:
+		return Result{Status: StatusCorrected, Address: n, Suggestions: []Address{n}}, nil
+	}
+	return Result{Status: StatusValid, Address: n}, nil
+}
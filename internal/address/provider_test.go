@@ -0,0 +1,52 @@
+package address_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/address"
+)
+
+func TestStubProvider(t *testing.T) {
+	cases := []struct {
+		in       address.Address
+		status   string
+		postcode string
+	}{
+		{address.Address{Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}, address.StatusValid, "12345"},
+		{address.Address{Line1: " 1  Main St", City: "Springfield", PostalCode: "12345", Country: "us"}, address.StatusCorrected, "12345"},
+		{address.Address{Line1: "10 Downing St", City: "London", PostalCode: "sw1a2aa", Country: "GB"}, address.StatusCorrected, "SW1A 2AA"},
+		{address.Address{Line1: "Damrak 1", City: "Amsterdam", PostalCode: "1012LG", Country: "NL"}, address.StatusCorrected, "1012 LG"},
+		{address.Address{Line1: "Unter den Linden 1", City: "Berlin", PostalCode: "1011", Country: "DE"}, address.StatusUndeliverable, "1011"},
+		{address.Address{Line1: "1 Rue", City: "Somewhere", PostalCode: "X1", Country: "ZZ"}, address.StatusUnverified, "X1"},
+	}
+	p := address.NewStubProvider()
+	for _, c := range cases {
+		r, err := p.Verify(context.Background(), c.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != c.status || r.Address.PostalCode != c.postcode {
+			t.Errorf("Verify(%+v): expected %s %q, got %s %q", c.in, c.status, c.postcode, r.Status, r.Address.PostalCode)
+		}
+	}
+}
+
+func TestEmbargo(t *testing.T) {
+	e := address.ParseEmbargo(" cu, IR,,kp")
+	if got := e.Countries(); len(got) != 3 || got[0] != "CU" || got[2] != "KP" {
+		t.Errorf("expected CU, IR and KP, got %v", got)
+	}
+	s := address.NewService(nil, nil, address.NewStubProvider(), e)
+	r, err := s.Verify(context.Background(), address.Address{Line1: "Calle 23", City: "La Habana", PostalCode: "10400", Country: "cu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != address.StatusUndeliverable || len(r.Suggestions) != 0 {
+		t.Errorf("embargoed country: expected undeliverable, got %+v", r)
+	}
+	r, err = s.Verify(context.Background(), address.Address{Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"})
+	if err != nil || r.Status != address.StatusValid {
+		t.Errorf("expected the US address valid, got %+v, %v", r, err)
+	}
+}
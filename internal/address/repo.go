@@ -0,0 +1,8 @@
+package address
+
+// Repo abstracts all the persistant storage operations of Address Service
+type Repo interface {
+	SaveCheck(c *Check) error
+	GetCheck(orderID string) (Check, error)
+	Drop() error
+}
@@ -0,0 +1,92 @@
+package address
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrCheckNotFound = errors.New("shipping address is not checked")
+)
+
+type Service interface {
+	// Verify normalizes and verifies the address, suggesting corrections.
+	Verify(ctx context.Context, a Address) (Result, error)
+
+	// CheckOrder verifies the shipping address of the order and records the
+	// result to flag undeliverable addresses before payment. Checking again,
+	// e.g after the customer fixed the address, replaces the result.
+	CheckOrder(ctx context.Context, orderID string, a Address) (Result, error)
+
+	// OrderCheck returns the recorded check of the order's shipping address.
+	OrderCheck(ctx context.Context, orderID string) (Check, error)
+}
+
+type basicService struct {
+	r        Repo
+	orders   order.Repo
+	provider Provider
+	embargo  Embargo
+}
+
+// NewService return basic Service implementation. The addresses in the
+// countries of embargo are undeliverable.
+func NewService(r Repo, orders order.Repo, provider Provider, embargo Embargo) Service {
+	return basicService{r: r, orders: orders, provider: provider, embargo: embargo}
+}
+
+// Verify normalizes and verifies the address, suggesting corrections.
+func (s basicService) Verify(ctx context.Context, a Address) (Result, error) {
+	if err := a.Validate(); err != nil {
+		return Result{}, err
+	}
+	r, err := s.provider.Verify(ctx, a)
+	if err != nil {
+		return Result{}, err
+	}
+	if s.embargo.Blocks(r.Address.Country) {
+		r.Status, r.Suggestions = StatusUndeliverable, nil
+		r.Messages = append(r.Messages, "no shipping to "+strings.ToUpper(r.Address.Country))
+	}
+	return r, nil
+}
+
+// CheckOrder verifies the shipping address of the order and records the
+// result. Corrected addresses are recorded as suggested, the client is
+// expected to confirm the suggestion to the customer.
+func (s basicService) CheckOrder(ctx context.Context, orderID string, a Address) (Result, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return Result{}, order.ErrOrderNotFound
+	}
+	r, err := s.Verify(ctx, a)
+	if err != nil {
+		return Result{}, err
+	}
+	c := Check{
+		OrderID:     orderID,
+		Address:     r.Address,
+		Status:      r.Status,
+		Deliverable: r.Deliverable(),
+		CheckedAt:   time.Now().UTC(),
+	}
+	if err := s.r.SaveCheck(&c); err != nil {
+		return Result{}, err
+	}
+	return r, nil
+}
+
+// OrderCheck returns the recorded check of the order's shipping address.
+func (s basicService) OrderCheck(ctx context.Context, orderID string) (Check, error) {
+	c, err := s.r.GetCheck(orderID)
+	if err != nil {
+		return Check{}, ErrCheckNotFound
+	}
+	return c, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,152 @@
+package address
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrCheckNotFound: "address.check_not_found",
+	})
+}
+
+func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	verifyHandler := httptransport.NewServer(
+		e.VerifyEndpoint,
+		decodeVerifyRequest,
+		encodeResponse,
+		options...,
+	)
+	checkOrderHandler := httptransport.NewServer(
+		e.CheckOrderEndpoint,
+		decodeCheckOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	orderCheckHandler := httptransport.NewServer(
+		e.OrderCheckEndpoint,
+		decodeOrderCheckRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/address/v1/verify", verifyHandler).Methods("POST")
+	r.Handle("/address/v1/orders/{order-id}", checkOrderHandler).Methods("POST")
+	r.Handle("/address/v1/orders/{order-id}", orderCheckHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeVerifyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r verifyRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeCheckOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r verifyRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeOrderCheckRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderCheckRequest{OrderID: orderID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrCheckNotFound, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
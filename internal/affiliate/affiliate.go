@@ -0,0 +1,135 @@
+package affiliate
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var (
+	ErrInvalidCode = errors.New("referral code must be 3 to 32 letters, digits or dashes")
+)
+
+// Affiliate statuses.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+)
+
+// Sources of an order attribution.
+const (
+	SourceCode   = "code"   // referral code entered at checkout
+	SourceCookie = "cookie" // first-touch referral cookie
+)
+
+// CookieName is the referral cookie set by the referral link.
+const CookieName = "bookshop_ref"
+
+// CookieMaxAge is how long a referral link visit is attributed for.
+const CookieMaxAge = 30 * 24 * time.Hour
+
+// Affiliate is a partner earning a commission on the orders it refers.
+type Affiliate struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Code      string    `json:"code" sql:"unique_index"`
+	Rate      float64   `json:"rate"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListFields are the fields the affiliate list can be filtered on.
+var ListFields = filter.Fields{
+	"id":         {Column: "id", Type: filter.String},
+	"name":       {Column: "name", Type: filter.String},
+	"email":      {Column: "email", Type: filter.String},
+	"code":       {Column: "code", Type: filter.String},
+	"rate":       {Column: "rate", Type: filter.Number},
+	"status":     {Column: "status", Type: filter.String},
+	"created_at": {Column: "created_at", Type: filter.Time},
+}
+
+// Click is a visit of a referral link.
+type Click struct {
+	ID          string    `json:"id"`
+	AffiliateID string    `json:"affiliate_id"`
+	At          time.Time `json:"at"`
+}
+
+func (Click) TableName() string {
+	return "affiliate_clicks"
+}
+
+// Attribution credits an order to an affiliate. An order is attributed at
+// most once, the first attribution wins.
+type Attribution struct {
+	OrderID     string    `json:"order_id" gorm:"primary_key"`
+	AffiliateID string    `json:"affiliate_id"`
+	Source      string    `json:"source"`
+	OrderTotal  float64   `json:"order_total"`
+	Currency    string    `json:"currency"`
+	Rate        float64   `json:"rate"`
+	Commission  float64   `json:"commission"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Attribution) TableName() string {
+	return "affiliate_attributions"
+}
+
+// Earnings is the report of an affiliate over a period.
+type Earnings struct {
+	AffiliateID string    `json:"affiliate_id"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Clicks      int       `json:"clicks"`
+	Orders      int       `json:"orders"`
+	Sales       float64   `json:"sales"`
+	Commission  float64   `json:"commission"`
+	// Conversion is the share of clicks turned into orders.
+	Conversion   float64       `json:"conversion"`
+	Attributions []Attribution `json:"attributions"`
+}
+
+// NormalizeCode upper cases a referral code and checks its format.
+func NormalizeCode(code string) (string, error) {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	if len(c) < 3 || len(c) > 32 {
+		return "", ErrInvalidCode
+	}
+	for _, r := range c {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return "", ErrInvalidCode
+		}
+	}
+	return c, nil
+}
+
+// codeAlphabet leaves out the look-alike characters 0, O, 1 and I.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewCode returns a random 8 character referral code.
+func NewCode() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// Commission returns the commission on total at rate, rounded to cents.
+func Commission(total, rate float64) float64 {
+	return float64(cents(total*rate)) / 100
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
@@ -0,0 +1,143 @@
+package affiliate_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/affiliate"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestNormalizeCode(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected string
+		err      error
+	}{
+		{" summer-17 ", "SUMMER-17", nil},
+		{"AB", "", affiliate.ErrInvalidCode},
+		{"no spaces", "", affiliate.ErrInvalidCode},
+		{strings.Repeat("A", 33), "", affiliate.ErrInvalidCode},
+	}
+	for _, c := range cases {
+		got, err := affiliate.NormalizeCode(c.in)
+		if got != c.expected || err != c.err {
+			t.Errorf("NormalizeCode(%q): expected %q %v, got %q %v", c.in, c.expected, c.err, got, err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := affiliate.NormalizeCode(affiliate.NewCode()); err != nil {
+			t.Fatalf("generated code is invalid: %v", err)
+		}
+	}
+}
+
+func TestCommission(t *testing.T) {
+	cases := []struct {
+		total, rate, expected float64
+	}{
+		{100, 0.05, 5},
+		{19.99, 0.05, 1},
+		{33.33, 0.075, 2.5},
+		{0, 0.1, 0},
+	}
+	for _, c := range cases {
+		if got := affiliate.Commission(c.total, c.rate); got != c.expected {
+			t.Errorf("Commission(%v, %v): expected %v, got %v", c.total, c.rate, c.expected, got)
+		}
+	}
+}
+
+// affiliateRepo keeps a single active affiliate and the attributions in
+// memory.
+type affiliateRepo struct {
+	affiliate.Repo
+	attributions map[string]affiliate.Attribution
+}
+
+func (r *affiliateRepo) GetByCode(code string) (affiliate.Affiliate, error) {
+	if code != "SUMMER" {
+		return affiliate.Affiliate{}, db.ErrNotFound
+	}
+	return affiliate.Affiliate{ID: "a1", Code: code, Status: affiliate.StatusActive, Rate: 0.1}, nil
+}
+
+func (r *affiliateRepo) List(f filter.Expr) ([]affiliate.Affiliate, error) {
+	return nil, nil
+}
+
+func (r *affiliateRepo) CreateAttribution(a *affiliate.Attribution) error {
+	r.attributions[a.OrderID] = *a
+	return nil
+}
+
+func (r *affiliateRepo) GetAttribution(orderID string) (affiliate.Attribution, error) {
+	a, ok := r.attributions[orderID]
+	if !ok {
+		return affiliate.Attribution{}, db.ErrNotFound
+	}
+	return a, nil
+}
+
+// orderRepo returns the order o1 of the user u1.
+type orderRepo struct {
+	order.Repo
+}
+
+func (orderRepo) GetByID(id string) (order.Order, error) {
+	if id != "o1" {
+		return order.Order{}, db.ErrNotFound
+	}
+	return order.Order{ID: id, CreatedByID: "u1", TotalPrice: 20}, nil
+}
+
+func TestHTTPAccess(t *testing.T) {
+	r := &affiliateRepo{attributions: make(map[string]affiliate.Attribution)}
+	s := affiliate.NewService(r, orderRepo{}, 0.1, nil)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := affiliate.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	owner, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	other, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"attribute without token", "POST", "/affiliates/v1/orders/o1", "", http.StatusUnauthorized},
+		{"attribute by other user", "POST", "/affiliates/v1/orders/o1", other, http.StatusNotFound},
+		{"attribute by owner", "POST", "/affiliates/v1/orders/o1", owner, http.StatusOK},
+		{"list without token", "GET", "/affiliates/v1/admin/list", "", http.StatusUnauthorized},
+		{"list by customer", "GET", "/affiliates/v1/admin/list", owner, http.StatusForbidden},
+		{"create by customer", "POST", "/affiliates/v1/admin", owner, http.StatusForbidden},
+		{"status by customer", "POST", "/affiliates/v1/admin/a1/status", owner, http.StatusForbidden},
+		{"earnings by customer", "GET", "/affiliates/v1/admin/a1/earnings", owner, http.StatusForbidden},
+		{"list by admin", "GET", "/affiliates/v1/admin/list", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{"code":"summer"}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if at, ok := r.attributions["o1"]; !ok || at.AffiliateID != "a1" {
+		t.Errorf("expected o1 attributed to a1, got %+v", r.attributions)
+	}
+}
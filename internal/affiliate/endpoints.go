@@ -0,0 +1,197 @@
+package affiliate
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// Endpoints combine all the affiliate service endpoints under single type.
+type Endpoints struct {
+	ClickEndpoint     endpoint.Endpoint
+	AttributeEndpoint endpoint.Endpoint
+	CreateEndpoint    endpoint.Endpoint
+	ListEndpoint      endpoint.Endpoint
+	SetStatusEndpoint endpoint.Endpoint
+	EarningsEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the affiliate service endpoints. The attributions are restricted by
+// account, e.g. to the unscoped tokens of the buyers, the shop admin
+// endpoints by admin.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ClickEndpoint:     MakeClickEndpoint(s),
+		AttributeEndpoint: account(MakeAttributeEndpoint(s)),
+		CreateEndpoint:    admin(MakeCreateEndpoint(s)),
+		ListEndpoint:      admin(MakeListEndpoint(s)),
+		SetStatusEndpoint: admin(MakeSetStatusEndpoint(s)),
+		EarningsEndpoint:  admin(MakeEarningsEndpoint(s)),
+	}
+}
+
+// MakeClickEndpoint always redirects, the referral cookie is only set for
+// the first valid referral link visited.
+func MakeClickEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(clickRequest)
+		res := redirectResponse{To: req.To}
+		if req.Referred {
+			return res, nil
+		}
+		if a, e := s.Click(ctx, req.Code); e == nil {
+			res.Code = a.Code
+		}
+		return res, nil
+	}
+}
+
+// MakeAttributeEndpoint credits an order of the user of the request.
+func MakeAttributeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(attributeRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		at, e := s.Attribute(ctx, userID, req.OrderID, req.Code, req.Source)
+		if e != nil {
+			return attributionResponse{Attribution: nil, Error: e}, nil
+		}
+		return attributionResponse{Attribution: &at}, nil
+	}
+}
+
+func MakeCreateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createRequest)
+		a, e := s.Create(ctx, Affiliate{
+			Name:  req.Name,
+			Email: req.Email,
+			Code:  req.Code,
+			Rate:  req.Rate,
+		})
+		if e != nil {
+			return affiliateResponse{Affiliate: nil, Error: e}, nil
+		}
+		return affiliateResponse{Affiliate: &a, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		affiliates, e := s.List(ctx, req.Filter)
+		if e != nil {
+			return listResponse{Affiliates: make([]Affiliate, 0), Error: e}, nil
+		}
+		return listResponse{Affiliates: affiliates}, nil
+	}
+}
+
+func MakeSetStatusEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setStatusRequest)
+		a, e := s.SetStatus(ctx, req.AffiliateID, req.Status)
+		if e != nil {
+			return affiliateResponse{Affiliate: nil, Error: e}, nil
+		}
+		return affiliateResponse{Affiliate: &a}, nil
+	}
+}
+
+func MakeEarningsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(earningsRequest)
+		earnings, e := s.Earnings(ctx, req.AffiliateID, req.From, req.To)
+		if e != nil {
+			return earningsResponse{Earnings: nil, Error: e}, nil
+		}
+		return earningsResponse{Earnings: &earnings}, nil
+	}
+}
+
+type clickRequest struct {
+	Code     string
+	To       string
+	Referred bool // a referral cookie is already set
+}
+
+type redirectResponse struct {
+	To   string
+	Code string // referral code to set the cookie for, if any
+}
+
+type attributeRequest struct {
+	OrderID string `json:"-"`
+	Code    string `json:"code"`
+	Source  string `json:"-"`
+}
+
+type attributionResponse struct {
+	Attribution *Attribution `json:"attribution,omitempty"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r attributionResponse) error() error {
+	return r.Error
+}
+
+type createRequest struct {
+	Name  string  `json:"name"`
+	Email string  `json:"email"`
+	Code  string  `json:"code"`
+	Rate  float64 `json:"rate"`
+}
+
+type affiliateResponse struct {
+	Status    int        `json:"-"`
+	Affiliate *Affiliate `json:"affiliate,omitempty"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r affiliateResponse) status() int {
+	return r.Status
+}
+
+func (r affiliateResponse) error() error {
+	return r.Error
+}
+
+type listRequest struct {
+	Filter filter.Expr `json:"-"`
+}
+
+type listResponse struct {
+	Affiliates []Affiliate `json:"affiliates"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+type setStatusRequest struct {
+	AffiliateID string `json:"-"`
+	Status      string `json:"status"`
+}
+
+type earningsRequest struct {
+	AffiliateID string
+	From        time.Time
+	To          time.Time
+}
+
+type earningsResponse struct {
+	Earnings *Earnings `json:"earnings,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r earningsResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,93 @@
+package affiliate
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Create(ctx context.Context, a Affiliate) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	aff, err = mw.next.Create(ctx, a)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context, f filter.Expr) (affiliates []Affiliate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	affiliates, err = mw.next.List(ctx, f)
+	return
+}
+
+func (mw instrmw) SetStatus(ctx context.Context, id, status string) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_status", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	aff, err = mw.next.SetStatus(ctx, id, status)
+	return
+}
+
+func (mw instrmw) Click(ctx context.Context, code string) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "click", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	aff, err = mw.next.Click(ctx, code)
+	return
+}
+
+func (mw instrmw) Attribute(ctx context.Context, userID, orderID, code, source string) (attribution Attribution, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "attribute", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	attribution, err = mw.next.Attribute(ctx, userID, orderID, code, source)
+	return
+}
+
+func (mw instrmw) Earnings(ctx context.Context, id string, from, to time.Time) (earnings Earnings, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "earnings", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	earnings, err = mw.next.Earnings(ctx, id, from, to)
+	return
+}
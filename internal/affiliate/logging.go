@@ -0,0 +1,90 @@
+package affiliate
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Create(ctx context.Context, a Affiliate) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Create(ctx, a)
+}
+
+func (s loggingService) List(ctx context.Context, f filter.Expr) (affiliates []Affiliate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx, f)
+}
+
+func (s loggingService) SetStatus(ctx context.Context, id, status string) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_status",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetStatus(ctx, id, status)
+}
+
+func (s loggingService) Click(ctx context.Context, code string) (aff Affiliate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "click",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Click(ctx, code)
+}
+
+func (s loggingService) Attribute(ctx context.Context, userID, orderID, code, source string) (attribution Attribution, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "attribute",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Attribute(ctx, userID, orderID, code, source)
+}
+
+func (s loggingService) Earnings(ctx context.Context, id string, from, to time.Time) (earnings Earnings, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "earnings",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Earnings(ctx, id, from, to)
+}
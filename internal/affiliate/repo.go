@@ -0,0 +1,22 @@
+package affiliate
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// Repo abstracts all the persistant storage operations of Affiliate Service
+type Repo interface {
+	Create(a *Affiliate) error
+	Save(a *Affiliate) error
+	GetByID(id string) (Affiliate, error)
+	GetByCode(code string) (Affiliate, error)
+	List(f filter.Expr) ([]Affiliate, error)
+	CreateClick(c *Click) error
+	CountClicks(affiliateID string, from, to time.Time) (int, error)
+	CreateAttribution(a *Attribution) error
+	GetAttribution(orderID string) (Attribution, error)
+	ListAttributions(affiliateID string, from, to time.Time) ([]Attribution, error)
+	Drop() error
+}
@@ -0,0 +1,230 @@
+package affiliate
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrAffiliateNotFound = errors.New("affiliate not found")
+	ErrUnknownCode       = errors.New("unknown referral code")
+	ErrCodeTaken         = errors.New("referral code already taken")
+	ErrInvalidAffiliate  = errors.New("affiliate needs a name, a valid email and a rate between 0 and 1")
+	ErrNoReferral        = errors.New("no referral code or cookie")
+	ErrBadPeriod         = errors.New("bad report period")
+	ErrNoConsent         = errors.New("referral tracking needs the marketing consent of the user")
+)
+
+type Service interface {
+	// Create registers an affiliate. A referral code is generated unless
+	// one is given.
+	Create(ctx context.Context, a Affiliate) (Affiliate, error)
+
+	// List returns the affiliates matching the filter f on ListFields.
+	List(ctx context.Context, f filter.Expr) ([]Affiliate, error)
+
+	// SetStatus activates or suspends an affiliate.
+	SetStatus(ctx context.Context, id, status string) (Affiliate, error)
+
+	// Click records a visit of the referral link of code, returning the
+	// affiliate it belongs to.
+	Click(ctx context.Context, code string) (Affiliate, error)
+
+	// Attribute credits an order of the user to the affiliate of code,
+	// computing its commission. An order already attributed keeps its
+	// first attribution.
+	Attribute(ctx context.Context, userID, orderID, code, source string) (Attribution, error)
+
+	// Earnings returns the clicks, orders and commissions of an affiliate
+	// from from to to.
+	Earnings(ctx context.Context, id string, from, to time.Time) (Earnings, error)
+}
+
+// Consent tells whether a user granted a purpose, consent.Service
+// implements it.
+type Consent interface {
+	Allowed(ctx context.Context, userID, purpose string) (bool, error)
+}
+
+type basicService struct {
+	r       Repo
+	orders  order.Repo
+	rate    float64
+	consent Consent
+}
+
+// NewService return basic Service implementation. rate is the commission
+// rate of the affiliates created without one. consent is optional, without
+// it the referral cookies are always tracked.
+func NewService(r Repo, orders order.Repo, rate float64, consent Consent) Service {
+	return basicService{r: r, orders: orders, rate: rate, consent: consent}
+}
+
+// Create registers an active affiliate.
+func (s basicService) Create(ctx context.Context, a Affiliate) (Affiliate, error) {
+	if a.Rate == 0 {
+		a.Rate = s.rate
+	}
+	if _, err := mail.ParseAddress(a.Email); err != nil || strings.TrimSpace(a.Name) == "" || a.Rate < 0 || a.Rate > 1 {
+		return Affiliate{}, ErrInvalidAffiliate
+	}
+	if a.Code == "" {
+		a.Code = NewCode()
+	}
+	code, err := NormalizeCode(a.Code)
+	if err != nil {
+		return Affiliate{}, err
+	}
+	if _, err := s.r.GetByCode(code); err == nil {
+		return Affiliate{}, ErrCodeTaken
+	}
+	a.ID = ""
+	a.Code = code
+	a.Status = StatusActive
+	a.CreatedAt = time.Now().UTC()
+	if err := s.r.Create(&a); err != nil {
+		return Affiliate{}, err
+	}
+	return a, nil
+}
+
+// List returns the affiliates matching f.
+func (s basicService) List(ctx context.Context, f filter.Expr) ([]Affiliate, error) {
+	return s.r.List(f)
+}
+
+// SetStatus activates or suspends an affiliate. Suspended affiliates earn
+// nothing on new orders.
+func (s basicService) SetStatus(ctx context.Context, id, status string) (Affiliate, error) {
+	if status != StatusActive && status != StatusSuspended {
+		return Affiliate{}, ErrInvalidAffiliate
+	}
+	a, err := s.r.GetByID(id)
+	if err != nil {
+		return Affiliate{}, ErrAffiliateNotFound
+	}
+	a.Status = status
+	if err := s.r.Save(&a); err != nil {
+		return Affiliate{}, err
+	}
+	return a, nil
+}
+
+func (s basicService) active(code string) (Affiliate, error) {
+	c, err := NormalizeCode(code)
+	if err != nil {
+		return Affiliate{}, ErrUnknownCode
+	}
+	a, err := s.r.GetByCode(c)
+	if err != nil || a.Status != StatusActive {
+		return Affiliate{}, ErrUnknownCode
+	}
+	return a, nil
+}
+
+// Click records a visit of the referral link of an active affiliate.
+func (s basicService) Click(ctx context.Context, code string) (Affiliate, error) {
+	a, err := s.active(code)
+	if err != nil {
+		return Affiliate{}, err
+	}
+	c := Click{AffiliateID: a.ID, At: time.Now().UTC()}
+	if err := s.r.CreateClick(&c); err != nil {
+		return Affiliate{}, err
+	}
+	return a, nil
+}
+
+// Attribute credits an order to an active affiliate. The orders of the
+// other users aren't found. The referral cookie tracks the user, it is
+// only credited with their marketing consent.
+func (s basicService) Attribute(ctx context.Context, userID, orderID, code, source string) (Attribution, error) {
+	if code == "" {
+		return Attribution{}, ErrNoReferral
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Attribution{}, order.ErrOrderNotFound
+	}
+	if at, err := s.r.GetAttribution(orderID); err == nil {
+		return at, nil
+	} else if err != db.ErrNotFound {
+		return Attribution{}, err
+	}
+	if source == SourceCookie && s.consent != nil {
+		allowed, err := s.consent.Allowed(ctx, o.CreatedByID, consent.PurposeMarketing)
+		if err != nil {
+			return Attribution{}, err
+		}
+		if !allowed {
+			return Attribution{}, ErrNoConsent
+		}
+	}
+	a, err := s.active(code)
+	if err != nil {
+		return Attribution{}, err
+	}
+	at := Attribution{
+		OrderID:     o.ID,
+		AffiliateID: a.ID,
+		Source:      source,
+		OrderTotal:  o.TotalPrice,
+		Currency:    o.Currency,
+		Rate:        a.Rate,
+		Commission:  Commission(o.TotalPrice, a.Rate),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.r.CreateAttribution(&at); err != nil {
+		return Attribution{}, err
+	}
+	return at, nil
+}
+
+// Earnings returns the report of an affiliate, from inclusive and to
+// exclusive.
+func (s basicService) Earnings(ctx context.Context, id string, from, to time.Time) (Earnings, error) {
+	if !to.After(from) {
+		return Earnings{}, ErrBadPeriod
+	}
+	if _, err := s.r.GetByID(id); err != nil {
+		return Earnings{}, ErrAffiliateNotFound
+	}
+	clicks, err := s.r.CountClicks(id, from, to)
+	if err != nil {
+		return Earnings{}, err
+	}
+	attributions, err := s.r.ListAttributions(id, from, to)
+	if err != nil {
+		return Earnings{}, err
+	}
+	e := Earnings{
+		AffiliateID:  id,
+		From:         from,
+		To:           to,
+		Clicks:       clicks,
+		Orders:       len(attributions),
+		Attributions: attributions,
+	}
+	var sales, commission int64
+	for _, at := range attributions {
+		sales += cents(at.OrderTotal)
+		commission += cents(at.Commission)
+	}
+	e.Sales = float64(sales) / 100
+	e.Commission = float64(commission) / 100
+	if clicks > 0 {
+		e.Conversion = float64(e.Orders) / float64(clicks)
+	}
+	return e, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
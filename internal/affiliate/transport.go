@@ -0,0 +1,292 @@
+package affiliate
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// defaultReportDays is the period of an earnings report without dates.
+const defaultReportDays = 30
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrNoReferral:  "affiliate.no_referral",
+		ErrNoConsent:   "affiliate.no_consent",
+		ErrUnknownCode: "affiliate.unknown_code",
+	})
+}
+
+// MakeHTTPHandler mounts the affiliate endpoints, the attributions served
+// to the requests account lets through, e.g. auth.NewMiddleware chained
+// with rbac.RequireUnscoped, the shop admin ones to the ones of admin,
+// e.g. with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	clickHandler := httptransport.NewServer(
+		e.ClickEndpoint,
+		decodeClickRequest,
+		encodeRedirect,
+		options...,
+	)
+	attributeHandler := httptransport.NewServer(
+		e.AttributeEndpoint,
+		decodeAttributeRequest,
+		encodeResponse,
+		options...,
+	)
+	createHandler := httptransport.NewServer(
+		e.CreateEndpoint,
+		decodeCreateRequest,
+		encodeResponse,
+		options...,
+	)
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeListRequest,
+		encodeResponse,
+		options...,
+	)
+	setStatusHandler := httptransport.NewServer(
+		e.SetStatusEndpoint,
+		decodeSetStatusRequest,
+		encodeResponse,
+		options...,
+	)
+	earningsHandler := httptransport.NewServer(
+		e.EarningsEndpoint,
+		decodeEarningsRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(transport.PopulateTimezone))...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/affiliates/v1/r/{code}", clickHandler).Methods("GET")
+	r.Handle("/affiliates/v1/orders/{order-id}", attributeHandler).Methods("POST")
+
+	// Shop admin endpoints
+	r.Handle("/affiliates/v1/admin", createHandler).Methods("POST")
+	r.Handle("/affiliates/v1/admin/list", listHandler).Methods("GET")
+	r.Handle("/affiliates/v1/admin/{affiliate-id}/status", setStatusHandler).Methods("POST")
+	r.Handle("/affiliates/v1/admin/{affiliate-id}/earnings", earningsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeClickRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	code, ok := mux.Vars(req)["code"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "code")
+	}
+	// Only redirect within the shop.
+	to := req.FormValue("to")
+	if !strings.HasPrefix(to, "/") || strings.HasPrefix(to, "//") {
+		to = "/"
+	}
+	_, err := req.Cookie(CookieName)
+	return clickRequest{Code: code, To: to, Referred: err == nil}, nil
+}
+
+// encodeRedirect sets the first-touch referral cookie, if any, and
+// redirects to the landing page of the referral link.
+func encodeRedirect(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(redirectResponse)
+	if res.Code != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     CookieName,
+			Value:    res.Code,
+			Path:     "/",
+			MaxAge:   int(CookieMaxAge / time.Second),
+			HttpOnly: true,
+		})
+	}
+	w.Header().Set("Location", res.To)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// decodeAttributeRequest prefers the code entered at checkout over the
+// referral cookie.
+func decodeAttributeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r attributeRequest
+	if req.ContentLength != 0 {
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	r.Source = SourceCode
+	if r.Code == "" {
+		if c, err := req.Cookie(CookieName); err == nil {
+			r.Code = c.Value
+			r.Source = SourceCookie
+		}
+	}
+	return r, nil
+}
+
+func decodeCreateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	f, err := filter.Parse(req.FormValue("filter"), ListFields)
+	if err != nil {
+		return nil, err
+	}
+	return listRequest{Filter: f}, nil
+}
+
+func decodeSetStatusRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r setStatusRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	affiliateID, ok := mux.Vars(req)["affiliate-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "affiliate-id")
+	}
+	r.AffiliateID = affiliateID
+	return r, nil
+}
+
+// decodeEarningsRequest reads the report period from the from and to dates,
+// to is inclusive. It defaults to the last 30 days, by the days of the time
+// zone of the request.
+func decodeEarningsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	affiliateID, ok := mux.Vars(req)["affiliate-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "affiliate-id")
+	}
+	loc, err := transport.Timezone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if v := req.FormValue("to"); v != "" {
+		t, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrBadPeriod
+		}
+		to = t
+		if len(v) == len(transport.DateLayout) {
+			to = t.AddDate(0, 0, 1)
+		}
+	}
+	from := to.AddDate(0, 0, -defaultReportDays)
+	if v := req.FormValue("from"); v != "" {
+		t, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrBadPeriod
+		}
+		from = t
+	}
+	return earningsRequest{AffiliateID: affiliateID, From: from, To: to}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrAffiliateNotFound, ErrUnknownCode, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrCodeTaken:
+		return http.StatusConflict
+	case ErrNoConsent:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrInvalidCode, ErrInvalidAffiliate, ErrNoReferral, ErrBadPeriod, filter.ErrInvalid, transport.ErrBadTimezone, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/vat"
+)
+
+// Kinds of the archived documents.
+const (
+	KindInvoice    = "invoice"
+	KindCreditNote = "credit_note"
+	KindTerms      = "terms"
+)
+
+// Document is the snapshot of a document of an order as issued, kept as is
+// for the bookkeeping retention period. Documents are never updated nor
+// deleted, each one is sealed into the audit chain of all the documents, so
+// that changing or removing any of them breaks the chain from there on.
+type Document struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id" gorm:"index"`
+	Kind    string `json:"kind"`
+	// Amount is the amount credited by a credit note.
+	Amount      float64 `json:"amount,omitempty"`
+	ContentType string  `json:"content_type"`
+	Content     string  `json:"content,omitempty" sql:"type:text"`
+	// Hash is the SHA-256 of Content.
+	Hash string `json:"hash"`
+	// Seq is the position of the document in the audit chain, from 1.
+	Seq int64 `json:"seq" gorm:"unique_index"`
+	// PrevHash is the ChainHash of the previous document of the chain, empty
+	// for the first one.
+	PrevHash string `json:"prev_hash"`
+	// ChainHash seals the document along with PrevHash.
+	ChainHash   string    `json:"chain_hash"`
+	IssuedAt    time.Time `json:"issued_at"`
+	RetainUntil time.Time `json:"retain_until"`
+}
+
+func (Document) TableName() string {
+	return "archived_documents"
+}
+
+// Seal links d after prev, the last document of the chain or the zero
+// Document for the first one.
+func (d *Document) Seal(prev Document) {
+	d.Seq = prev.Seq + 1
+	d.PrevHash = prev.ChainHash
+	d.ChainHash = d.chainHash()
+}
+
+// chainHash hashes PrevHash along with the fields which identify d.
+func (d Document) chainHash() string {
+	h := sha256.New()
+	for _, f := range []string{
+		d.PrevHash,
+		strconv.FormatInt(d.Seq, 10),
+		d.ID,
+		d.OrderID,
+		d.Kind,
+		strconv.FormatFloat(d.Amount, 'f', -1, 64),
+		d.ContentType,
+		d.Hash,
+		d.IssuedAt.UTC().Format(time.RFC3339Nano),
+		d.RetainUntil.UTC().Format(time.RFC3339Nano),
+	} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Intact tells whether the content of d is the one it was archived with.
+func (d Document) Intact() bool {
+	return Hash(d.Content) == d.Hash
+}
+
+// Hash returns the SHA-256 of content, hex encoded.
+func Hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verification is the result of checking the audit chain.
+type Verification struct {
+	Documents int  `json:"documents"`
+	Valid     bool `json:"valid"`
+	// BrokenAt is the first document whose content or seal doesn't match.
+	BrokenAt *Document `json:"broken_at,omitempty"`
+}
+
+// Verify checks docs, the whole chain in order, from its first document.
+func Verify(docs []Document) Verification {
+	v := Verification{Documents: len(docs), Valid: true}
+	prev := Document{}
+	for i := range docs {
+		d := docs[i]
+		if !d.Intact() || d.Seq != prev.Seq+1 || d.PrevHash != prev.ChainHash || d.chainHash() != d.ChainHash {
+			d.Content = ""
+			v.Valid, v.BrokenAt = false, &d
+			return v
+		}
+		prev = d
+	}
+	return v
+}
+
+// Invoice is the content of an invoice snapshot, the order as sold along
+// with its VAT.
+type Invoice struct {
+	Order order.Order   `json:"order"`
+	Tax   *vat.OrderTax `json:"tax,omitempty"`
+}
+
+// CreditNote is the content of a credit note snapshot. Number is empty
+// for the credit notes issued before the refund ledger.
+type CreditNote struct {
+	Number     string  `json:"number,omitempty"`
+	OrderID    string  `json:"order_id"`
+	InvoiceID  string  `json:"invoice_id"`
+	Kind       string  `json:"kind,omitempty"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency"`
+	ReasonCode string  `json:"reason_code,omitempty"`
+	Reason     string  `json:"reason"`
+	IssuedBy   string  `json:"issued_by"`
+}
+
+// NewCreditNote is the request to credit an amount of an order.
+type NewCreditNote struct {
+	Amount     float64 `json:"amount"`
+	ReasonCode string  `json:"reason_code"`
+	Reason     string  `json:"reason"`
+	// IssuedBy is the user issuing the credit note, the one of the token
+	// over HTTP.
+	IssuedBy string `json:"-"`
+}
+
+// Config controls the archive.
+type Config struct {
+	// Terms is the text of the terms of sale in force, archived with every
+	// sale.
+	Terms string
+	// RetentionYears is how long the documents are kept, counted from
+	// their issue.
+	RetentionYears int
+}
+
+// DefaultRetentionYears is the longest of the usual bookkeeping retention
+// periods in the EU.
+const DefaultRetentionYears = 10
@@ -0,0 +1,141 @@
+package archive_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// chain seals n documents one after the other.
+func chain(n int) []archive.Document {
+	docs := make([]archive.Document, n)
+	prev := archive.Document{}
+	for i := range docs {
+		content := `{"order":{"id":"o1"}}`
+		docs[i] = archive.Document{
+			ID:          string(rune('a' + i)),
+			OrderID:     "o1",
+			Kind:        archive.KindInvoice,
+			ContentType: "application/json",
+			Content:     content,
+			Hash:        archive.Hash(content),
+			IssuedAt:    time.Date(2026, 3, 1, 10, i, 0, 0, time.UTC),
+		}
+		docs[i].Seal(prev)
+		prev = docs[i]
+	}
+	return docs
+}
+
+func TestVerify(t *testing.T) {
+	if v := archive.Verify(chain(3)); !v.Valid || v.Documents != 3 {
+		t.Errorf("intact: expected a valid chain of 3, got %+v", v)
+	}
+	if v := archive.Verify(nil); !v.Valid {
+		t.Errorf("empty: expected a valid chain")
+	}
+
+	cases := []struct {
+		name   string
+		tamper func(docs []archive.Document) []archive.Document
+		broken string
+	}{
+		{"content", func(docs []archive.Document) []archive.Document {
+			docs[1].Content = `{"order":{"id":"o2"}}`
+			return docs
+		}, "b"},
+		{"rehashed content", func(docs []archive.Document) []archive.Document {
+			docs[1].Content = `{"order":{"id":"o2"}}`
+			docs[1].Hash = archive.Hash(docs[1].Content)
+			return docs
+		}, "b"},
+		{"amount", func(docs []archive.Document) []archive.Document {
+			docs[0].Amount = 10
+			return docs
+		}, "a"},
+		{"removed", func(docs []archive.Document) []archive.Document {
+			return append(docs[:1], docs[2:]...)
+		}, "c"},
+		{"resealed", func(docs []archive.Document) []archive.Document {
+			docs[1].OrderID = "o2"
+			docs[1].Seal(docs[0])
+			return docs
+		}, "c"},
+	}
+	for _, c := range cases {
+		v := archive.Verify(c.tamper(chain(3)))
+		if v.Valid || v.BrokenAt == nil || v.BrokenAt.ID != c.broken {
+			t.Errorf("%s: expected the chain broken at %s, got %+v", c.name, c.broken, v)
+		}
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	r := &ledgerRepo{}
+	r.Append(invoice(order.Order{ID: "o1"}, nil))
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 30, Currency: "EUR"},
+	}}
+	s := archive.NewService(r, orders, nil, nil, nil, archive.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := archive.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	other, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+	support, _, _ := tokens.Sign("u8", user.RoleSupport, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	note := `{"amount":10,"reason_code":"damaged","reason":"torn cover","issued_by":"u1"}`
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"credit note without token", "POST", "/archive/v1/orders/o1/credit-notes", "", http.StatusUnauthorized},
+		{"credit note by customer", "POST", "/archive/v1/orders/o1/credit-notes", customer, http.StatusForbidden},
+		{"documents by customer", "GET", "/archive/v1/orders/o1", customer, http.StatusForbidden},
+		{"sale by customer", "POST", "/archive/v1/orders/o1", customer, http.StatusForbidden},
+		{"verify by customer", "GET", "/archive/v1/verify", customer, http.StatusForbidden},
+		{"credit note by admin", "POST", "/archive/v1/orders/o1/credit-notes", staff, http.StatusOK},
+		{"refunds by customer", "GET", "/archive/v1/refunds", customer, http.StatusForbidden},
+		{"refund report by customer", "GET", "/archive/v1/refunds/report", customer, http.StatusForbidden},
+		{"invoice without token", "GET", "/archive/v1/orders/o1/invoice", "", http.StatusUnauthorized},
+		{"invoice by other user", "GET", "/archive/v1/orders/o1/invoice", other, http.StatusNotFound},
+		{"invoice by customer", "GET", "/archive/v1/orders/o1/invoice", customer, http.StatusOK},
+		{"invoice by support", "GET", "/archive/v1/orders/o1/invoice", support, http.StatusOK},
+		{"credit note pdf without token", "GET", "/archive/v1/refunds/r/credit-note", "", http.StatusUnauthorized},
+		{"credit note pdf by other user", "GET", "/archive/v1/refunds/r/credit-note", other, http.StatusNotFound},
+		{"credit note pdf by customer", "GET", "/archive/v1/refunds/r/credit-note", customer, http.StatusOK},
+		{"credit note pdf by admin", "GET", "/archive/v1/refunds/r/credit-note", staff, http.StatusOK},
+		{"footers by customer", "GET", "/archive/v1/footers", customer, http.StatusForbidden},
+		{"set footer without token", "PUT", "/archive/v1/footers/DE", "", http.StatusUnauthorized},
+		{"set footer by customer", "PUT", "/archive/v1/footers/DE", customer, http.StatusForbidden},
+		{"delete footer by customer", "DELETE", "/archive/v1/footers/DE", customer, http.StatusForbidden},
+		{"delete footer by support", "DELETE", "/archive/v1/footers/DE", support, http.StatusForbidden},
+		{"footers by admin", "GET", "/archive/v1/footers", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(note))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if len(r.refunds) != 1 || r.refunds[0].IssuedBy != "u9" {
+		t.Errorf("expected a credit note issued by the admin of the token, got %+v", r.refunds)
+	}
+}
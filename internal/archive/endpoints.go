@@ -0,0 +1,309 @@
+package archive
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Endpoints combine all the archive service endpoints under single type.
+type Endpoints struct {
+	SaleEndpoint          endpoint.Endpoint
+	CreditNoteEndpoint    endpoint.Endpoint
+	DocumentsEndpoint     endpoint.Endpoint
+	DocumentEndpoint      endpoint.Endpoint
+	VerifyEndpoint        endpoint.Endpoint
+	RefundsEndpoint       endpoint.Endpoint
+	RefundReportEndpoint  endpoint.Endpoint
+	InvoicePDFEndpoint    endpoint.Endpoint
+	CreditNotePDFEndpoint endpoint.Endpoint
+	FootersEndpoint       endpoint.Endpoint
+	SetFooterEndpoint     endpoint.Endpoint
+	DeleteFooterEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the archive service endpoints. The archive, the credit notes, the
+// refund ledger and the legal footers are restricted by admin, the PDFs by
+// account, e.g. to the unscoped tokens of the customers and of the staff.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		SaleEndpoint:          admin(MakeSaleEndpoint(s)),
+		CreditNoteEndpoint:    admin(MakeCreditNoteEndpoint(s)),
+		DocumentsEndpoint:     admin(MakeDocumentsEndpoint(s)),
+		DocumentEndpoint:      admin(MakeDocumentEndpoint(s)),
+		VerifyEndpoint:        admin(MakeVerifyEndpoint(s)),
+		RefundsEndpoint:       admin(MakeRefundsEndpoint(s)),
+		RefundReportEndpoint:  admin(MakeRefundReportEndpoint(s)),
+		InvoicePDFEndpoint:    account(MakeInvoicePDFEndpoint(s)),
+		CreditNotePDFEndpoint: account(MakeCreditNotePDFEndpoint(s)),
+		FootersEndpoint:       admin(MakeFootersEndpoint(s)),
+		SetFooterEndpoint:     admin(MakeSetFooterEndpoint(s)),
+		DeleteFooterEndpoint:  admin(MakeDeleteFooterEndpoint(s)),
+	}
+}
+
+func MakeSaleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		docs, e := s.Sale(ctx, req.OrderID)
+		if e != nil {
+			return documentsResponse{Documents: make([]Document, 0), Error: e}, nil
+		}
+		return documentsResponse{Documents: docs, Status: http.StatusCreated}, nil
+	}
+}
+
+// MakeCreditNoteEndpoint issues a credit note on behalf of the user of the
+// request.
+func MakeCreditNoteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(creditNoteRequest)
+		issuer, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req.IssuedBy = issuer
+		d, e := s.CreditNote(ctx, req.OrderID, req.NewCreditNote)
+		if e != nil {
+			return documentResponse{Document: nil, Error: e}, nil
+		}
+		return documentResponse{Document: &d, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeDocumentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		docs, e := s.Documents(ctx, req.OrderID)
+		if e != nil {
+			return documentsResponse{Documents: make([]Document, 0), Error: e}, nil
+		}
+		return documentsResponse{Documents: docs}, nil
+	}
+}
+
+func MakeDocumentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(documentRequest)
+		d, e := s.Document(ctx, req.ID)
+		if e != nil {
+			return documentResponse{Document: nil, Error: e}, nil
+		}
+		return documentResponse{Document: &d}, nil
+	}
+}
+
+func MakeVerifyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		v, e := s.Verify(ctx)
+		if e != nil {
+			return verifyResponse{Error: e}, nil
+		}
+		return verifyResponse{Verification: &v}, nil
+	}
+}
+
+func MakeRefundsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reportRequest)
+		refunds, e := s.Refunds(ctx, req.From, req.To)
+		if e != nil {
+			return refundsResponse{Refunds: make([]Refund, 0), Error: e}, nil
+		}
+		return refundsResponse{Refunds: refunds}, nil
+	}
+}
+
+func MakeRefundReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reportRequest)
+		report, e := s.RefundReport(ctx, req.From, req.To, req.Period)
+		if e != nil {
+			return refundReportResponse{Summaries: make([]RefundSummary, 0), Error: e}, nil
+		}
+		return refundReportResponse{Summaries: report}, nil
+	}
+}
+
+// owner returns the user the PDFs of the request are restricted to, the
+// one of the token, none for the staff.
+func owner(ctx context.Context) (string, error) {
+	c, ok := auth.ClaimsFrom(ctx)
+	if !ok {
+		return "", auth.ErrMissingToken
+	}
+	if c.Role == user.RoleAdmin || c.Role == user.RoleSupport {
+		return "", nil
+	}
+	return c.Subject, nil
+}
+
+// MakeInvoicePDFEndpoint returns the invoice of an order to its customer,
+// and to the staff.
+func MakeInvoicePDFEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		userID, err := owner(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f, e := s.InvoicePDF(ctx, userID, req.OrderID, req.Locale)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+// MakeCreditNotePDFEndpoint returns the credit note of a refund to the
+// customer of the order, and to the staff.
+func MakeCreditNotePDFEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refundRequest)
+		userID, err := owner(ctx)
+		if err != nil {
+			return nil, err
+		}
+		f, e := s.CreditNotePDF(ctx, userID, req.ID, req.Locale)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakeFootersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		footers, e := s.Footers(ctx)
+		if e != nil {
+			return footersResponse{Footers: make([]Footer, 0), Error: e}, nil
+		}
+		return footersResponse{Footers: footers}, nil
+	}
+}
+
+func MakeSetFooterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(Footer)
+		f, e := s.SetFooter(ctx, req)
+		if e != nil {
+			return footerResponse{Footer: nil, Error: e}, nil
+		}
+		return footerResponse{Footer: &f}, nil
+	}
+}
+
+func MakeDeleteFooterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(footerRequest)
+		e := s.DeleteFooter(ctx, req.Country)
+		return footerResponse{Error: e}, nil
+	}
+}
+
+type orderRequest struct {
+	OrderID string
+	// Locale is the one of the rendered invoice, the customer's if empty.
+	Locale string
+}
+
+type creditNoteRequest struct {
+	OrderID string `json:"-"`
+	NewCreditNote
+}
+
+type documentRequest struct {
+	ID string
+}
+
+type documentsResponse struct {
+	Status    int        `json:"-"`
+	Documents []Document `json:"documents"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r documentsResponse) status() int {
+	return r.Status
+}
+
+func (r documentsResponse) error() error {
+	return r.Error
+}
+
+type documentResponse struct {
+	Status   int       `json:"-"`
+	Document *Document `json:"document,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r documentResponse) status() int {
+	return r.Status
+}
+
+func (r documentResponse) error() error {
+	return r.Error
+}
+
+type verifyResponse struct {
+	Verification *Verification `json:"verification,omitempty"`
+	Error        error         `json:"error,omitempty"`
+}
+
+func (r verifyResponse) error() error {
+	return r.Error
+}
+
+type reportRequest struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Period string    `json:"period"`
+}
+
+type refundRequest struct {
+	ID     string
+	Locale string
+}
+
+type footerRequest struct {
+	Country string
+}
+
+type footersResponse struct {
+	Footers []Footer `json:"footers"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r footersResponse) error() error {
+	return r.Error
+}
+
+type footerResponse struct {
+	Footer *Footer `json:"footer,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r footerResponse) error() error {
+	return r.Error
+}
+
+type refundsResponse struct {
+	Refunds []Refund `json:"refunds"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r refundsResponse) error() error {
+	return r.Error
+}
+
+type refundReportResponse struct {
+	Summaries []RefundSummary `json:"summaries"`
+	Error     error           `json:"error,omitempty"`
+}
+
+func (r refundReportResponse) error() error {
+	return r.Error
+}
+
+type fileResponse struct {
+	File
+	Error error
+}
@@ -0,0 +1,45 @@
+package archive
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// AnyCountry is the country of the footer of the customers whose country
+// has no footer of its own.
+const AnyCountry = "*"
+
+// maxFooterLength keeps a footer within the bottom of a page.
+const maxFooterLength = 1000
+
+// Footer is the legal block printed at the bottom of the documents and
+// emails of the customers of a country, e.g. the registration of the shop
+// and the notices the law of the country requires.
+type Footer struct {
+	Country   string    `json:"country" gorm:"primary_key"`
+	Text      string    `json:"text" sql:"type:text"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Footer) TableName() string {
+	return "legal_footers"
+}
+
+var countryRe = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Validate checks f is the footer of a country, or AnyCountry, once its
+// country upper cased.
+func (f *Footer) Validate() error {
+	var v validate.Validator
+	f.Country = strings.ToUpper(strings.TrimSpace(f.Country))
+	v.Check(f.Country == AnyCountry || countryRe.MatchString(f.Country), "country", validate.CodeInvalid, "country must be an ISO 3166 code or *")
+	if v.Required("text", f.Text) {
+		v.MaxLength("text", f.Text, maxFooterLength)
+	}
+	v.Required("updated_by", f.UpdatedBy)
+	return v.Err()
+}
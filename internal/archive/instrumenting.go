@@ -0,0 +1,158 @@
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Sale(ctx context.Context, orderID string) (documents []Document, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sale", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	documents, err = mw.next.Sale(ctx, orderID)
+	return
+}
+
+func (mw instrmw) CreditNote(ctx context.Context, orderID string, n NewCreditNote) (document Document, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "credit_note", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	document, err = mw.next.CreditNote(ctx, orderID, n)
+	return
+}
+
+func (mw instrmw) Documents(ctx context.Context, orderID string) (documents []Document, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "documents", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	documents, err = mw.next.Documents(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Document(ctx context.Context, ID string) (document Document, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "document", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	document, err = mw.next.Document(ctx, ID)
+	return
+}
+
+func (mw instrmw) Verify(ctx context.Context) (verification Verification, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "verify", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	verification, err = mw.next.Verify(ctx)
+	return
+}
+
+func (mw instrmw) Refunds(ctx context.Context, from, to time.Time) (refunds []Refund, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "refunds", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	refunds, err = mw.next.Refunds(ctx, from, to)
+	return
+}
+
+func (mw instrmw) RefundReport(ctx context.Context, from, to time.Time, period string) (summaries []RefundSummary, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "refund_report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	summaries, err = mw.next.RefundReport(ctx, from, to, period)
+	return
+}
+
+func (mw instrmw) InvoicePDF(ctx context.Context, userID, orderID, locale string) (f File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "invoice_pdf", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	f, err = mw.next.InvoicePDF(ctx, userID, orderID, locale)
+	return
+}
+
+func (mw instrmw) CreditNotePDF(ctx context.Context, userID, refundID, locale string) (f File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "credit_note_pdf", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	f, err = mw.next.CreditNotePDF(ctx, userID, refundID, locale)
+	return
+}
+
+func (mw instrmw) Footers(ctx context.Context) (footers []Footer, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "footers", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	footers, err = mw.next.Footers(ctx)
+	return
+}
+
+func (mw instrmw) SetFooter(ctx context.Context, f Footer) (footer Footer, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_footer", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	footer, err = mw.next.SetFooter(ctx, f)
+	return
+}
+
+func (mw instrmw) DeleteFooter(ctx context.Context, country string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete_footer", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DeleteFooter(ctx, country)
+	return
+}
@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Sale(ctx context.Context, orderID string) (documents []Document, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sale",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Sale(ctx, orderID)
+}
+
+func (s loggingService) CreditNote(ctx context.Context, orderID string, n NewCreditNote) (document Document, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "credit_note",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreditNote(ctx, orderID, n)
+}
+
+func (s loggingService) Documents(ctx context.Context, orderID string) (documents []Document, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "documents",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Documents(ctx, orderID)
+}
+
+func (s loggingService) Document(ctx context.Context, ID string) (document Document, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "document",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Document(ctx, ID)
+}
+
+func (s loggingService) Verify(ctx context.Context) (verification Verification, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "verify",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Verify(ctx)
+}
+
+func (s loggingService) Refunds(ctx context.Context, from, to time.Time) (refunds []Refund, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "refunds",
+			"from", from,
+			"to", to,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Refunds(ctx, from, to)
+}
+
+func (s loggingService) RefundReport(ctx context.Context, from, to time.Time, period string) (summaries []RefundSummary, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "refund_report",
+			"from", from,
+			"to", to,
+			"period", period,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RefundReport(ctx, from, to, period)
+}
+
+func (s loggingService) InvoicePDF(ctx context.Context, userID, orderID, locale string) (f File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "invoice_pdf",
+			"user_id", userID,
+			"order_id", orderID,
+			"locale", locale,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.InvoicePDF(ctx, userID, orderID, locale)
+}
+
+func (s loggingService) CreditNotePDF(ctx context.Context, userID, refundID, locale string) (f File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "credit_note_pdf",
+			"user_id", userID,
+			"refund_id", refundID,
+			"locale", locale,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreditNotePDF(ctx, userID, refundID, locale)
+}
+
+func (s loggingService) Footers(ctx context.Context) (footers []Footer, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "footers",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Footers(ctx)
+}
+
+func (s loggingService) SetFooter(ctx context.Context, f Footer) (footer Footer, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_footer",
+			"country", f.Country,
+			"updated_by", f.UpdatedBy,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetFooter(ctx, f)
+}
+
+func (s loggingService) DeleteFooter(ctx context.Context, country string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_footer",
+			"country", country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeleteFooter(ctx, country)
+}
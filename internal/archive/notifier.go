@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"github.com/kavirajk/bookshop/notification/email"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Mail is a document sent to its customer, its texts in the locale of the
+// customer.
+type Mail struct {
+	To      user.User
+	Locale  string
+	Subject string
+	// Number is the order of a receipt, the credit note number of a
+	// credit note.
+	Number string
+	// Total and Date are formatted for Locale.
+	Total      string
+	Date       string
+	Footer     string
+	Attachment File
+}
+
+// Notifier sends the customers their documents.
+type Notifier interface {
+	// Receipt sends the invoice of a sale.
+	Receipt(m Mail) error
+	// CreditNote sends a credit note.
+	CreditNote(m Mail) error
+}
+
+type emailNotifier struct{}
+
+// NewEmailNotifier returns a Notifier sending emails.
+func NewEmailNotifier() Notifier {
+	return emailNotifier{}
+}
+
+func (emailNotifier) Receipt(m Mail) error {
+	return email.Receipt([]string{m.To.Email}, m.context())
+}
+
+func (emailNotifier) CreditNote(m Mail) error {
+	return email.CreditNote([]string{m.To.Email}, m.context())
+}
+
+func (m Mail) context() map[string]interface{} {
+	return map[string]interface{}{
+		"user":       m.To,
+		"locale":     m.Locale,
+		"subject":    m.Subject,
+		"number":     m.Number,
+		"total":      m.Total,
+		"date":       m.Date,
+		"footer":     m.Footer,
+		"attachment": m.Attachment,
+	}
+}
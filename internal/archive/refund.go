@@ -0,0 +1,142 @@
+package archive
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Reason codes of the refunds.
+const (
+	ReasonReturned    = "returned"     // books sent back by the customer
+	ReasonDamaged     = "damaged"      // books damaged in transit
+	ReasonNotReceived = "not_received" // parcel lost in transit
+	ReasonWrongItem   = "wrong_item"   // books shipped by mistake
+	ReasonCancelled   = "cancelled"    // order cancelled before shipping
+	ReasonGoodwill    = "goodwill"     // commercial gesture
+)
+
+var reasonCodes = map[string]bool{
+	ReasonReturned:    true,
+	ReasonDamaged:     true,
+	ReasonNotReceived: true,
+	ReasonWrongItem:   true,
+	ReasonCancelled:   true,
+	ReasonGoodwill:    true,
+}
+
+// Kinds of the refunds.
+const (
+	RefundFull    = "full"
+	RefundPartial = "partial"
+)
+
+// Periods of the refund report.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Refund is an entry of the refund ledger, one for every credit note
+// issued. The ledger is kept apart from the orders, the refunds being
+// numbered in sequence, with no gap, as their credit notes are.
+type Refund struct {
+	ID string `json:"id"`
+	// Number is the number of the credit note, e.g. CN-000042.
+	Number     string `json:"number" sql:"unique_index"`
+	Seq        int64  `json:"-" sql:"unique_index"`
+	OrderID    string `json:"order_id" sql:"index"`
+	InvoiceID  string `json:"invoice_id"`
+	DocumentID string `json:"document_id"` // the archived credit note
+	// Kind is full when the whole order total is refunded at once.
+	Kind       string    `json:"kind"`
+	ReasonCode string    `json:"reason_code" sql:"index"`
+	Reason     string    `json:"reason"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+	IssuedBy   string    `json:"issued_by"`
+	IssuedAt   time.Time `json:"issued_at" sql:"index"`
+}
+
+func (Refund) TableName() string {
+	return "refund_ledger"
+}
+
+// Number returns the credit note number at position seq of the ledger.
+func Number(seq int64) string {
+	return fmt.Sprintf("CN-%06d", seq)
+}
+
+// RefundSummary is the total of the refunds of a reason in a period.
+type RefundSummary struct {
+	Period     time.Time `json:"period"`
+	ReasonCode string    `json:"reason_code"`
+	Currency   string    `json:"currency"`
+	Count      int       `json:"count"`
+	Full       int       `json:"full"` // refunds of the whole order
+	Total      float64   `json:"total"`
+}
+
+// Summarize totals refunds per period, reason and currency, the amounts of
+// currencies not adding up. The periods start in the time zone of loc.
+func Summarize(refunds []Refund, period string, loc *time.Location) []RefundSummary {
+	type key struct {
+		period               time.Time
+		reasonCode, currency string
+	}
+	sums := make(map[key]*RefundSummary)
+	cents := make(map[key]int64)
+	for _, rf := range refunds {
+		k := key{truncate(rf.IssuedAt.In(loc), period), rf.ReasonCode, rf.Currency}
+		sum, ok := sums[k]
+		if !ok {
+			sum = &RefundSummary{Period: k.period, ReasonCode: rf.ReasonCode, Currency: rf.Currency}
+			sums[k] = sum
+		}
+		sum.Count++
+		if rf.Kind == RefundFull {
+			sum.Full++
+		}
+		cents[k] += int64(math.Round(rf.Amount * 100))
+	}
+
+	report := make([]RefundSummary, 0, len(sums))
+	for k, sum := range sums {
+		sum.Total = float64(cents[k]) / 100
+		report = append(report, *sum)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if !report[i].Period.Equal(report[j].Period) {
+			return report[i].Period.Before(report[j].Period)
+		}
+		if report[i].ReasonCode != report[j].ReasonCode {
+			return report[i].ReasonCode < report[j].ReasonCode
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	return report
+}
+
+// truncate returns the start of the period t falls into, in the location
+// of t.
+// Weeks start on Monday.
+func truncate(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// File is a generated document, ready for download.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,187 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/vat"
+)
+
+// ledgerRepo keeps the chain and the ledger in memory, the other methods of
+// the Repo aren't used.
+type ledgerRepo struct {
+	archive.Repo
+	docs    []archive.Document
+	refunds []archive.Refund
+	footers map[string]archive.Footer
+}
+
+func (r *ledgerRepo) Append(docs ...*archive.Document) error {
+	for _, d := range docs {
+		r.seal(d)
+	}
+	return nil
+}
+
+func (r *ledgerRepo) seal(d *archive.Document) {
+	prev := archive.Document{}
+	if len(r.docs) > 0 {
+		prev = r.docs[len(r.docs)-1]
+	}
+	d.ID = string(rune('a' + len(r.docs)))
+	d.Seal(prev)
+	r.docs = append(r.docs, *d)
+}
+
+func (r *ledgerRepo) AppendRefund(rf *archive.Refund, note func(rf archive.Refund) (*archive.Document, error)) error {
+	rf.ID = string(rune('r' + len(r.refunds)))
+	rf.Seq = int64(len(r.refunds) + 1)
+	rf.Number = archive.Number(rf.Seq)
+	d, err := note(*rf)
+	if err != nil {
+		return err
+	}
+	r.seal(d)
+	rf.DocumentID, rf.IssuedAt = d.ID, d.IssuedAt
+	r.refunds = append(r.refunds, *rf)
+	return nil
+}
+
+func (r *ledgerRepo) GetRefund(ID string) (archive.Refund, error) {
+	for _, rf := range r.refunds {
+		if rf.ID == ID {
+			return rf, nil
+		}
+	}
+	return archive.Refund{}, db.ErrNotFound
+}
+
+func (r *ledgerRepo) ListByOrder(orderID string) ([]archive.Document, error) {
+	docs := make([]archive.Document, 0)
+	for _, d := range r.docs {
+		if d.OrderID == orderID {
+			docs = append(docs, d)
+		}
+	}
+	return docs, nil
+}
+
+func (r *ledgerRepo) GetFooter(country string) (archive.Footer, error) {
+	f, ok := r.footers[country]
+	if !ok {
+		return archive.Footer{}, db.ErrNotFound
+	}
+	return f, nil
+}
+
+func (r *ledgerRepo) ListFooters() ([]archive.Footer, error) {
+	footers := make([]archive.Footer, 0, len(r.footers))
+	for _, f := range r.footers {
+		footers = append(footers, f)
+	}
+	return footers, nil
+}
+
+// orderRepo returns the orders by their ID.
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+// invoice returns the invoice of o, untaxed if tax is nil.
+func invoice(o order.Order, tax *vat.OrderTax) *archive.Document {
+	b, _ := json.Marshal(archive.Invoice{Order: o, Tax: tax})
+	return &archive.Document{OrderID: o.ID, Kind: archive.KindInvoice, Content: string(b), Hash: archive.Hash(string(b))}
+}
+
+func TestCreditNoteLedger(t *testing.T) {
+	ctx := context.Background()
+	r := &ledgerRepo{}
+	r.Append(invoice(order.Order{ID: "o1"}, nil), invoice(order.Order{ID: "o2"}, nil))
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", TotalPrice: 30, Currency: "EUR"},
+		"o2": {ID: "o2", TotalPrice: 12.5, Currency: "EUR"},
+	}}
+	s := archive.NewService(r, orders, nil, nil, nil, archive.Config{})
+
+	notes := []struct {
+		orderID string
+		n       archive.NewCreditNote
+		kind    string
+	}{
+		{"o1", archive.NewCreditNote{Amount: 10, ReasonCode: archive.ReasonDamaged, Reason: "torn cover", IssuedBy: "u1"}, archive.RefundPartial},
+		{"o2", archive.NewCreditNote{Amount: 12.5, ReasonCode: archive.ReasonNotReceived, Reason: "lost parcel", IssuedBy: "u1"}, archive.RefundFull},
+	}
+	for i, c := range notes {
+		d, err := s.CreditNote(ctx, c.orderID, c.n)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.orderID, err)
+		}
+		rf := r.refunds[i]
+		if want := archive.Number(int64(i + 1)); rf.Number != want || rf.Kind != c.kind || rf.DocumentID != d.ID {
+			t.Errorf("%s: expected refund %s %s of document %s, got %+v", c.orderID, want, c.kind, d.ID, rf)
+		}
+		var n archive.CreditNote
+		if err := json.Unmarshal([]byte(d.Content), &n); err != nil || n.Number != rf.Number || n.ReasonCode != c.n.ReasonCode {
+			t.Errorf("%s: expected the credit note %s, got %+v, %v", c.orderID, rf.Number, n, err)
+		}
+	}
+	if len(r.docs) != 4 || r.docs[3].Seq != 4 || r.docs[3].Kind != archive.KindCreditNote {
+		t.Errorf("expected the credit notes sealed after the invoices, got %+v", r.docs)
+	}
+
+	if _, err := s.CreditNote(ctx, "o1", archive.NewCreditNote{Amount: 5, ReasonCode: "bored", Reason: "r", IssuedBy: "u1"}); err != archive.ErrUnknownReason {
+		t.Errorf("unknown reason: expected ErrUnknownReason, got %v", err)
+	}
+	if _, err := s.CreditNote(ctx, "o1", archive.NewCreditNote{Amount: 25, ReasonCode: archive.ReasonGoodwill, Reason: "r", IssuedBy: "u1"}); err != archive.ErrCreditExceeds || len(r.refunds) != 2 {
+		t.Errorf("exceeds: expected ErrCreditExceeds and no refund, got %v", err)
+	}
+
+	f, err := s.CreditNotePDF(ctx, "", r.refunds[0].ID, "")
+	if err != nil || f.ContentType != "application/pdf" || !bytes.Contains(f.Data, []byte("(Credit note CN-000001)")) {
+		t.Errorf("pdf: expected credit note CN-000001, got %q, %v", f.Name, err)
+	}
+	if _, err := s.CreditNotePDF(ctx, "", "none", ""); err != archive.ErrRefundNotFound {
+		t.Errorf("pdf: expected ErrRefundNotFound, got %v", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	refunds := []archive.Refund{
+		{ReasonCode: archive.ReasonDamaged, Kind: archive.RefundPartial, Amount: 0.1, Currency: "EUR", IssuedAt: day(2)},
+		{ReasonCode: archive.ReasonDamaged, Kind: archive.RefundFull, Amount: 0.2, Currency: "EUR", IssuedAt: day(3)},
+		{ReasonCode: archive.ReasonDamaged, Kind: archive.RefundFull, Amount: 4, Currency: "USD", IssuedAt: day(3)},
+		{ReasonCode: archive.ReasonCancelled, Kind: archive.RefundFull, Amount: 9, Currency: "EUR", IssuedAt: day(10)},
+	}
+	monday := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	want := []archive.RefundSummary{
+		{Period: monday(2), ReasonCode: archive.ReasonDamaged, Currency: "EUR", Count: 2, Full: 1, Total: 0.3},
+		{Period: monday(2), ReasonCode: archive.ReasonDamaged, Currency: "USD", Count: 1, Full: 1, Total: 4},
+		{Period: monday(9), ReasonCode: archive.ReasonCancelled, Currency: "EUR", Count: 1, Full: 1, Total: 9},
+	}
+	got := archive.Summarize(refunds, archive.PeriodWeek, time.UTC)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d summaries, got %+v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Period.Equal(want[i].Period) || got[i].ReasonCode != want[i].ReasonCode || got[i].Currency != want[i].Currency ||
+			got[i].Count != want[i].Count || got[i].Full != want[i].Full || got[i].Total != want[i].Total {
+			t.Errorf("%d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
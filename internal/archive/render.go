@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/pdf"
+)
+
+const (
+	margin = 50
+	// footerTop is where the legal footer starts, at most footerLines of
+	// it fitting below.
+	footerTop   = pdf.A4Height - 90
+	footerLines = 6
+	// bodyBottom is the last line of a page before the footer.
+	bodyBottom = footerTop - 30
+)
+
+// reasonTexts are the English labels of the reason codes.
+var reasonTexts = map[string]string{
+	ReasonReturned:    "Returned",
+	ReasonDamaged:     "Damaged in transit",
+	ReasonNotReceived: "Not received",
+	ReasonWrongItem:   "Wrong item",
+	ReasonCancelled:   "Cancelled",
+	ReasonGoodwill:    "Goodwill gesture",
+}
+
+// texts returns the translation func of the documents in locale.
+func texts(locale string) func(key, message string) string {
+	return func(key, message string) string {
+		return i18n.Translate(locale, "doc."+key, message)
+	}
+}
+
+// writer lays out the rows of a document, starting a new page, with the
+// footer, when one is full.
+type writer struct {
+	d      *pdf.Document
+	p      *pdf.Page
+	y      float64
+	footer []string
+}
+
+func newWriter(footer string) *writer {
+	w := &writer{d: pdf.New(pdf.A4Width, pdf.A4Height)}
+	if footer != "" {
+		w.footer = pdf.Wrap(footer, 110)
+		if len(w.footer) > footerLines {
+			w.footer = w.footer[:footerLines]
+		}
+	}
+	w.page()
+	return w
+}
+
+func (w *writer) page() {
+	w.p = w.d.AddPage()
+	w.y = 70
+	for i, l := range w.footer {
+		w.p.Text(margin, footerTop+float64(i)*10, 7, false, l)
+	}
+	if len(w.footer) > 0 {
+		w.p.Line(margin, pdf.A4Width-margin, footerTop-12)
+	}
+}
+
+// next moves down by dy, to the next page if need be.
+func (w *writer) next(dy float64) {
+	w.y += dy
+	if w.y > bodyBottom {
+		w.page()
+	}
+}
+
+// row writes a label and its value.
+func (w *writer) row(label, value string, bold bool) {
+	w.p.Text(margin, w.y, 10, true, label)
+	w.p.Text(margin+120, w.y, 10, bold, value)
+	w.next(18)
+}
+
+func (w *writer) file(name string) File {
+	return File{Name: name, ContentType: "application/pdf", Data: w.d.Bytes()}
+}
+
+// InvoicePDF renders the invoice of d, its content inv, in locale with the
+// legal footer on every page.
+func InvoicePDF(d Document, inv Invoice, locale, footer string) File {
+	t := texts(locale)
+	o := inv.Order
+	money := func(amount float64) string { return i18n.FormatMoney(locale, amount, o.Currency) }
+
+	w := newWriter(footer)
+	w.p.Text(margin, w.y, 20, true, t("invoice", "Invoice")+" "+o.ID)
+	w.next(22)
+	w.p.Text(margin, w.y, 10, false, t("issued", "Issued")+" "+i18n.FormatDate(locale, d.IssuedAt.UTC()))
+	w.next(48)
+
+	columns := []float64{margin, margin + 300, margin + 360, margin + 440}
+	for i, h := range []string{t("book", "Book"), t("quantity", "Qty"), t("price", "Price"), t("amount", "Amount")} {
+		w.p.Text(columns[i], w.y, 10, true, h)
+	}
+	w.next(6)
+	w.p.Line(margin, pdf.A4Width-margin, w.y)
+	w.next(14)
+	for _, l := range o.Lines {
+		amount := math.Round(l.Price*100*float64(l.Quantity)) / 100
+		for i, v := range []string{pdf.Truncate(l.BookID, 48), fmt.Sprint(l.Quantity), money(l.Price), money(amount)} {
+			w.p.Text(columns[i], w.y, 10, false, v)
+		}
+		w.next(16)
+	}
+	w.p.Line(margin, pdf.A4Width-margin, w.y)
+	w.next(24)
+
+	if tax := inv.Tax; tax != nil {
+		w.row(t("net", "Net"), money(tax.Net), false)
+		w.row(t("vat", "VAT")+" "+i18n.FormatNumber(locale, tax.Rate, 1)+" %", money(tax.VAT), false)
+		if tax.VATID != "" {
+			w.row(t("vat_id", "VAT ID"), tax.VATID, false)
+		}
+		if tax.ReverseCharge {
+			w.p.Text(margin, w.y, 9, false, t("reverse_charge", "Reverse charge, the VAT is due by the customer"))
+			w.next(18)
+		}
+	}
+	w.row(t("total", "Total"), money(o.TotalPrice), true)
+	return w.file("invoice-" + o.ID + ".pdf")
+}
+
+// CreditNotePDF renders the credit note of rf in locale with the legal
+// footer.
+func CreditNotePDF(rf Refund, locale, footer string) File {
+	t := texts(locale)
+	kind := t("refund_partial", "Partial refund")
+	if rf.Kind == RefundFull {
+		kind = t("refund_full", "Full refund")
+	}
+
+	w := newWriter(footer)
+	w.p.Text(margin, w.y, 20, true, t("credit_note", "Credit note")+" "+rf.Number)
+	w.next(22)
+	w.p.Text(margin, w.y, 10, false, t("issued", "Issued")+" "+i18n.FormatDate(locale, rf.IssuedAt.UTC()))
+	w.next(48)
+	w.row(t("order", "Order"), rf.OrderID, false)
+	w.row(t("invoice", "Invoice"), rf.InvoiceID, false)
+	w.row(t("refund", "Refund"), kind, false)
+	w.row(t("reason", "Reason"), t("reason."+rf.ReasonCode, reasonTexts[rf.ReasonCode]), false)
+	w.row(t("note", "Note"), pdf.Truncate(rf.Reason, 70), false)
+	w.row(t("issued_by", "Issued by"), rf.IssuedBy, false)
+	w.p.Line(margin, pdf.A4Width-margin, w.y)
+	w.next(24)
+	w.p.Text(margin, w.y, 12, true, t("credited", "Credited"))
+	w.p.Text(margin+120, w.y, 12, true, i18n.FormatMoney(locale, rf.Amount, rf.Currency))
+	return w.file("credit-note-" + rf.Number + ".pdf")
+}
@@ -0,0 +1,88 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vat"
+)
+
+// notifier records the mails sent.
+type notifier struct {
+	mails []archive.Mail
+}
+
+func (n *notifier) Receipt(m archive.Mail) error {
+	n.mails = append(n.mails, m)
+	return nil
+}
+
+func (n *notifier) CreditNote(m archive.Mail) error {
+	n.mails = append(n.mails, m)
+	return nil
+}
+
+func TestLocalizedDocuments(t *testing.T) {
+	ctx := context.Background()
+	r := &ledgerRepo{footers: map[string]archive.Footer{
+		"DE":               {Country: "DE", Text: "Amtsgericht Berlin HRB 1"},
+		archive.AnyCountry: {Country: archive.AnyCountry, Text: "Bookshop Ltd"},
+	}}
+	r.Append(
+		invoice(order.Order{ID: "o1", Lines: []order.Line{{BookID: "b1", Quantity: 2, Price: 617.25}}, TotalPrice: 1234.5, Currency: "EUR"},
+			&vat.OrderTax{Country: "DE", Rate: 19, Net: 1037.39, VAT: 197.11, Gross: 1234.5}),
+		invoice(order.Order{ID: "o2", TotalPrice: 20, Currency: "EUR"}, nil),
+	)
+
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 1234.5, Currency: "EUR"},
+		"o2": {ID: "o2", CreatedByID: "u2", TotalPrice: 20, Currency: "EUR"},
+	}}
+	users := inmem.NewUserRepo()
+	users.Create(&user.User{ID: "u1", Email: "anna@example.com", Locale: "de"})
+	users.Create(&user.User{ID: "u2", Email: "bob@example.com"})
+	n := &notifier{}
+	s := archive.NewService(r, orders, nil, users, n, archive.Config{})
+
+	cases := []struct {
+		locale   string
+		expected []string
+	}{
+		{"", []string{"(Rechnung o1)", `(1.234,50\240\200)`, "(MwSt. 19,0 %)", "(Amtsgericht Berlin HRB 1)"}},
+		{"fr", []string{"(Facture o1)", `(1\240234,50\240\200)`, "(Amtsgericht Berlin HRB 1)"}},
+		{"en", []string{"(Invoice o1)", `(\2001,234.50)`, "(VAT 19.0 %)"}},
+	}
+	for _, c := range cases {
+		f, err := s.InvoicePDF(ctx, "u1", "o1", c.locale)
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", c.locale, err)
+		}
+		for _, e := range c.expected {
+			if !bytes.Contains(f.Data, []byte(e)) {
+				t.Errorf("%q: expected %s in the invoice", c.locale, e)
+			}
+		}
+	}
+	if _, err := s.InvoicePDF(ctx, "u1", "o1", "xx"); err != archive.ErrUnknownLocale {
+		t.Errorf("unknown locale: expected ErrUnknownLocale, got %v", err)
+	}
+
+	if _, err := s.CreditNote(ctx, "o2", archive.NewCreditNote{Amount: 5, ReasonCode: archive.ReasonGoodwill, Reason: "late", IssuedBy: "u9"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.mails) != 1 {
+		t.Fatalf("expected the credit note mailed, got %+v", n.mails)
+	}
+	m := n.mails[0]
+	if m.To.ID != "u2" || m.Locale != "en" || m.Subject != "Your credit note CN-000001" || m.Total != "€5.00" || m.Footer != "Bookshop Ltd" {
+		t.Errorf("expected the credit note in english with the default footer, got %+v", m)
+	}
+	if !bytes.Contains(m.Attachment.Data, []byte("(Goodwill gesture)")) {
+		t.Error("expected the credit note attached")
+	}
+}
@@ -0,0 +1,36 @@
+package archive
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Archive service.
+// Documents are only ever appended.
+type Repo interface {
+	// Append seals docs, in order, after the last document of the chain and
+	// stores them at once.
+	Append(docs ...*Document) error
+	Get(ID string) (Document, error)
+	// ListByOrder returns the documents of an order, oldest first.
+	ListByOrder(orderID string) ([]Document, error)
+	// ListIssued returns the documents of kind issued between from and to,
+	// oldest first.
+	ListIssued(kind string, from, to time.Time) ([]Document, error)
+	// List returns the whole chain, oldest first.
+	List() ([]Document, error)
+
+	// AppendRefund numbers rf after the last refund of the ledger, then
+	// stores it along with its credit note, which note builds from the
+	// numbered refund, sealed after the last document of the chain.
+	AppendRefund(rf *Refund, note func(rf Refund) (*Document, error)) error
+	GetRefund(ID string) (Refund, error)
+	// ListRefunds returns the refunds issued between from and to, in
+	// sequence.
+	ListRefunds(from, to time.Time) ([]Refund, error)
+
+	// ListFooters returns the legal footers by country.
+	ListFooters() ([]Footer, error)
+	GetFooter(country string) (Footer, error)
+	// SaveFooter creates or replaces the footer of f.Country.
+	SaveFooter(f *Footer) error
+	DeleteFooter(country string) error
+	Drop() error
+}
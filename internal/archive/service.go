@@ -0,0 +1,496 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vat"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrAlreadyArchived  = errors.New("sale already archived")
+	ErrNoInvoice        = errors.New("order has no archived invoice")
+	ErrInvalidAmount    = errors.New("credited amount must be positive")
+	ErrCreditExceeds    = errors.New("credited amount exceeds the order total")
+	ErrReasonRequired   = errors.New("credit note reason is required")
+	ErrMissingIssuer    = errors.New("credit note issuer is required")
+	ErrTampered         = errors.New("document content doesn't match its hash")
+	ErrUnknownReason    = errors.New("unknown refund reason code")
+	ErrRefundNotFound   = errors.New("refund not found")
+	ErrInvalidPeriod    = errors.New("invalid period")
+	ErrInvalidDate      = errors.New("invalid date")
+	ErrFooterNotFound   = errors.New("legal footer not found")
+	ErrUnknownLocale    = errors.New("unknown locale")
+)
+
+type Service interface {
+	// Sale archives the invoice of an order along with the terms of sale in
+	// force, at the moment of sale. A sale is archived once.
+	Sale(ctx context.Context, orderID string) ([]Document, error)
+
+	// CreditNote archives a credit note crediting part or all of the
+	// invoice of an order, recording the refund in the ledger.
+	CreditNote(ctx context.Context, orderID string, n NewCreditNote) (Document, error)
+
+	// Refunds returns the ledger of the refunds issued between from and
+	// to.
+	Refunds(ctx context.Context, from, to time.Time) ([]Refund, error)
+
+	// RefundReport summarizes the refunds issued between from and to per
+	// period and reason.
+	RefundReport(ctx context.Context, from, to time.Time, period string) ([]RefundSummary, error)
+
+	// InvoicePDF returns the archived invoice of an order of the user as a
+	// PDF, in locale or else in the one of the customer. An empty userID
+	// is the one of the staff, reaching the orders of all the users.
+	InvoicePDF(ctx context.Context, userID, orderID, locale string) (File, error)
+
+	// CreditNotePDF returns the credit note of a refund of the user as a
+	// PDF, in locale or else in the one of the customer. An empty userID
+	// is the one of the staff.
+	CreditNotePDF(ctx context.Context, userID, refundID, locale string) (File, error)
+
+	// Footers returns the legal footers of all the countries.
+	Footers(ctx context.Context) ([]Footer, error)
+
+	// SetFooter creates or replaces the legal footer of a country.
+	SetFooter(ctx context.Context, f Footer) (Footer, error)
+
+	// DeleteFooter removes the legal footer of a country.
+	DeleteFooter(ctx context.Context, country string) error
+
+	// Documents returns the documents of an order, without their content,
+	// oldest first.
+	Documents(ctx context.Context, orderID string) ([]Document, error)
+
+	// Document returns a document with its content, once checked against
+	// its hash.
+	Document(ctx context.Context, ID string) (Document, error)
+
+	// Verify checks the whole audit chain.
+	Verify(ctx context.Context) (Verification, error)
+}
+
+type basicService struct {
+	r        Repo
+	orders   order.Repo
+	taxes    vat.Repo
+	users    user.Repo
+	notifier Notifier
+	cfg      Config
+}
+
+// NewService return basic Service implementation. The customers are sent
+// their documents by notifier, unless nil.
+func NewService(r Repo, orders order.Repo, taxes vat.Repo, users user.Repo, notifier Notifier, cfg Config) Service {
+	if cfg.RetentionYears <= 0 {
+		cfg.RetentionYears = DefaultRetentionYears
+	}
+	return basicService{r: r, orders: orders, taxes: taxes, users: users, notifier: notifier, cfg: cfg}
+}
+
+// Sale archives the invoice, with the VAT applied at checkout if any, and
+// the terms, then sends the receipt.
+func (s basicService) Sale(ctx context.Context, orderID string) ([]Document, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	if _, err := s.invoice(orderID); err != ErrNoInvoice {
+		if err == nil {
+			return nil, ErrAlreadyArchived
+		}
+		return nil, err
+	}
+	inv := Invoice{Order: o}
+	if t, err := s.taxes.GetOrderTax(orderID); err == nil {
+		inv.Tax = &t
+	} else if err != db.ErrNotFound {
+		return nil, err
+	}
+	content, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+	invoice := s.document(orderID, KindInvoice, "application/json", string(content))
+	terms := s.document(orderID, KindTerms, "text/plain; charset=utf-8", s.cfg.Terms)
+	if err := s.r.Append(&invoice, &terms); err != nil {
+		return nil, err
+	}
+	if err := s.sendReceipt(ctx, invoice, inv, o.CreatedByID); err != nil {
+		return nil, errors.Wrapf(err, "sale %s archived, receipt not sent", orderID)
+	}
+	return []Document{invoice, terms}, nil
+}
+
+// CreditNote checks the credited amounts of the order, all its credit notes
+// included, don't exceed its total.
+func (s basicService) CreditNote(ctx context.Context, orderID string, n NewCreditNote) (Document, error) {
+	switch {
+	case n.Amount <= 0 || math.IsNaN(n.Amount) || math.IsInf(n.Amount, 0):
+		return Document{}, ErrInvalidAmount
+	case strings.TrimSpace(n.Reason) == "":
+		return Document{}, ErrReasonRequired
+	case !reasonCodes[n.ReasonCode]:
+		return Document{}, ErrUnknownReason
+	case n.IssuedBy == "":
+		return Document{}, ErrMissingIssuer
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return Document{}, order.ErrOrderNotFound
+	}
+	inv, err := s.invoice(orderID)
+	if err != nil {
+		return Document{}, err
+	}
+	docs, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return Document{}, err
+	}
+	credited := n.Amount
+	for _, d := range docs {
+		if d.Kind == KindCreditNote {
+			credited += d.Amount
+		}
+	}
+	if math.Round(credited*100) > math.Round(o.TotalPrice*100) {
+		return Document{}, ErrCreditExceeds
+	}
+	rf := Refund{
+		OrderID:    orderID,
+		InvoiceID:  inv.ID,
+		Kind:       RefundPartial,
+		ReasonCode: n.ReasonCode,
+		Reason:     n.Reason,
+		Amount:     n.Amount,
+		Currency:   o.Currency,
+		IssuedBy:   n.IssuedBy,
+	}
+	if math.Round(n.Amount*100) == math.Round(o.TotalPrice*100) {
+		rf.Kind = RefundFull
+	}
+	var d Document
+	err = s.r.AppendRefund(&rf, func(rf Refund) (*Document, error) {
+		content, err := json.Marshal(CreditNote{
+			Number:     rf.Number,
+			OrderID:    orderID,
+			InvoiceID:  inv.ID,
+			Kind:       rf.Kind,
+			Amount:     rf.Amount,
+			Currency:   rf.Currency,
+			ReasonCode: rf.ReasonCode,
+			Reason:     rf.Reason,
+			IssuedBy:   rf.IssuedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		d = s.document(orderID, KindCreditNote, "application/json", string(content))
+		d.Amount = rf.Amount
+		return &d, nil
+	})
+	if err != nil {
+		return Document{}, err
+	}
+	if err := s.sendCreditNote(ctx, rf, inv, o.CreatedByID); err != nil {
+		return Document{}, errors.Wrapf(err, "credit note %s archived, not sent", rf.Number)
+	}
+	return d, nil
+}
+
+func (s basicService) Refunds(ctx context.Context, from, to time.Time) ([]Refund, error) {
+	return s.r.ListRefunds(from, to)
+}
+
+// RefundReport starts the periods in the time zone of from.
+func (s basicService) RefundReport(ctx context.Context, from, to time.Time, period string) ([]RefundSummary, error) {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+	refunds, err := s.r.ListRefunds(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(refunds, period, from.Location()), nil
+}
+
+// InvoicePDF renders the invoice as archived, once checked against its
+// hash. The orders of the other users aren't found.
+func (s basicService) InvoicePDF(ctx context.Context, userID, orderID, locale string) (File, error) {
+	if locale != "" && !i18n.Supported(locale) {
+		return File{}, ErrUnknownLocale
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || (userID != "" && o.CreatedByID != userID) {
+		return File{}, order.ErrOrderNotFound
+	}
+	d, err := s.invoice(orderID)
+	if err != nil {
+		return File{}, err
+	}
+	inv, err := content(d)
+	if err != nil {
+		return File{}, err
+	}
+	_, locale = s.customer(ctx, o.CreatedByID, locale)
+	footer, err := s.footer(country(inv))
+	if err != nil {
+		return File{}, err
+	}
+	return InvoicePDF(d, inv, locale, footer), nil
+}
+
+// CreditNotePDF renders the credit note of a refund, the refunds of the
+// orders of the other users aren't found.
+func (s basicService) CreditNotePDF(ctx context.Context, userID, refundID, locale string) (File, error) {
+	if locale != "" && !i18n.Supported(locale) {
+		return File{}, ErrUnknownLocale
+	}
+	rf, err := s.r.GetRefund(refundID)
+	if err == db.ErrNotFound {
+		return File{}, ErrRefundNotFound
+	}
+	if err != nil {
+		return File{}, err
+	}
+	o, err := s.orders.GetByID(rf.OrderID)
+	if err != nil {
+		return File{}, order.ErrOrderNotFound
+	}
+	if userID != "" && o.CreatedByID != userID {
+		return File{}, ErrRefundNotFound
+	}
+	d, err := s.invoice(rf.OrderID)
+	if err != nil {
+		return File{}, err
+	}
+	inv, err := content(d)
+	if err != nil {
+		return File{}, err
+	}
+	_, locale = s.customer(ctx, o.CreatedByID, locale)
+	footer, err := s.footer(country(inv))
+	if err != nil {
+		return File{}, err
+	}
+	return CreditNotePDF(rf, locale, footer), nil
+}
+
+func (s basicService) Footers(ctx context.Context) ([]Footer, error) {
+	return s.r.ListFooters()
+}
+
+func (s basicService) SetFooter(ctx context.Context, f Footer) (Footer, error) {
+	if err := f.Validate(); err != nil {
+		return Footer{}, err
+	}
+	f.UpdatedAt = time.Now().UTC()
+	if err := s.r.SaveFooter(&f); err != nil {
+		return Footer{}, err
+	}
+	return f, nil
+}
+
+func (s basicService) DeleteFooter(ctx context.Context, country string) error {
+	err := s.r.DeleteFooter(strings.ToUpper(country))
+	if err == db.ErrNotFound {
+		return ErrFooterNotFound
+	}
+	return err
+}
+
+// Documents leaves the content out, it is read one document at a time.
+func (s basicService) Documents(ctx context.Context, orderID string) ([]Document, error) {
+	docs, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		docs[i].Content = ""
+	}
+	return docs, nil
+}
+
+// Document fails with ErrTampered if the stored content was changed.
+func (s basicService) Document(ctx context.Context, ID string) (Document, error) {
+	d, err := s.r.Get(ID)
+	if err == db.ErrNotFound {
+		return Document{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return Document{}, err
+	}
+	if !d.Intact() {
+		return Document{}, ErrTampered
+	}
+	return d, nil
+}
+
+// Verify reads the whole chain.
+func (s basicService) Verify(ctx context.Context) (Verification, error) {
+	docs, err := s.r.List()
+	if err != nil {
+		return Verification{}, err
+	}
+	return Verify(docs), nil
+}
+
+// invoice returns the archived invoice of an order, or ErrNoInvoice.
+func (s basicService) invoice(orderID string) (Document, error) {
+	docs, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return Document{}, err
+	}
+	for _, d := range docs {
+		if d.Kind == KindInvoice {
+			return d, nil
+		}
+	}
+	return Document{}, ErrNoInvoice
+}
+
+// content returns the invoice archived in d, once checked against its
+// hash.
+func content(d Document) (Invoice, error) {
+	if !d.Intact() {
+		return Invoice{}, ErrTampered
+	}
+	var inv Invoice
+	if err := json.Unmarshal([]byte(d.Content), &inv); err != nil {
+		return Invoice{}, err
+	}
+	return inv, nil
+}
+
+// country returns the country the VAT of inv was applied for, which is
+// the one of the customer, empty if none.
+func country(inv Invoice) string {
+	if inv.Tax == nil {
+		return ""
+	}
+	return inv.Tax.Country
+}
+
+// customer returns the customer userID and the locale of their documents:
+// locale if set, else the one of the customer, else the one of the
+// request.
+func (s basicService) customer(ctx context.Context, userID, locale string) (user.User, string) {
+	var u user.User
+	if s.users != nil && userID != "" {
+		u, _ = s.users.GetByID(userID)
+	}
+	switch {
+	case locale != "":
+	case i18n.Supported(u.Locale):
+		locale = u.Locale
+	default:
+		locale = i18n.Locale(ctx)
+	}
+	return u, locale
+}
+
+// footer returns the legal footer of country, else the AnyCountry one,
+// empty if neither is set.
+func (s basicService) footer(country string) (string, error) {
+	for _, c := range []string{country, AnyCountry} {
+		if c == "" {
+			continue
+		}
+		f, err := s.r.GetFooter(c)
+		if err == nil {
+			return f.Text, nil
+		}
+		if err != db.ErrNotFound {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// sendReceipt emails the invoice d to the customer userID.
+func (s basicService) sendReceipt(ctx context.Context, d Document, inv Invoice, userID string) error {
+	if s.notifier == nil {
+		return nil
+	}
+	u, locale := s.customer(ctx, userID, "")
+	if u.Email == "" {
+		return nil
+	}
+	footer, err := s.footer(country(inv))
+	if err != nil {
+		return err
+	}
+	t := texts(locale)
+	return s.notifier.Receipt(Mail{
+		To:         u,
+		Locale:     locale,
+		Subject:    fmt.Sprintf(t("receipt_subject", "Your receipt for order %s"), inv.Order.ID),
+		Number:     inv.Order.ID,
+		Total:      i18n.FormatMoney(locale, inv.Order.TotalPrice, inv.Order.Currency),
+		Date:       i18n.FormatDate(locale, d.IssuedAt.UTC()),
+		Footer:     footer,
+		Attachment: InvoicePDF(d, inv, locale, footer),
+	})
+}
+
+// sendCreditNote emails the credit note of rf to the customer userID,
+// the invoice being the one of the credited order.
+func (s basicService) sendCreditNote(ctx context.Context, rf Refund, invoice Document, userID string) error {
+	if s.notifier == nil {
+		return nil
+	}
+	u, locale := s.customer(ctx, userID, "")
+	if u.Email == "" {
+		return nil
+	}
+	inv, err := content(invoice)
+	if err != nil {
+		return err
+	}
+	footer, err := s.footer(country(inv))
+	if err != nil {
+		return err
+	}
+	t := texts(locale)
+	return s.notifier.CreditNote(Mail{
+		To:         u,
+		Locale:     locale,
+		Subject:    fmt.Sprintf(t("credit_note_subject", "Your credit note %s"), rf.Number),
+		Number:     rf.Number,
+		Total:      i18n.FormatMoney(locale, rf.Amount, rf.Currency),
+		Date:       i18n.FormatDate(locale, rf.IssuedAt.UTC()),
+		Footer:     footer,
+		Attachment: CreditNotePDF(rf, locale, footer),
+	})
+}
+
+// document returns a new document issued now. The times are truncated to
+// what the database keeps, so that the seal still matches once read back.
+func (s basicService) document(orderID, kind, contentType, content string) Document {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return Document{
+		OrderID:     orderID,
+		Kind:        kind,
+		ContentType: contentType,
+		Content:     content,
+		Hash:        Hash(content),
+		IssuedAt:    now,
+		RetainUntil: now.AddDate(s.cfg.RetentionYears, 0, 0),
+	}
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
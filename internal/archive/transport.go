@@ -0,0 +1,332 @@
+package archive
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrDocumentNotFound: "archive.document_not_found",
+		ErrAlreadyArchived:  "archive.already_archived",
+		ErrNoInvoice:        "archive.no_invoice",
+		ErrCreditExceeds:    "archive.credit_exceeds",
+		ErrTampered:         "archive.tampered",
+		ErrUnknownReason:    "archive.unknown_reason",
+		ErrRefundNotFound:   "archive.refund_not_found",
+		ErrInvalidPeriod:    "archive.invalid_period",
+		ErrInvalidDate:      "archive.invalid_date",
+		ErrFooterNotFound:   "archive.footer_not_found",
+		ErrUnknownLocale:    "archive.unknown_locale",
+	})
+}
+
+// MakeHTTPHandler mounts the archive endpoints, the invoices and credit
+// notes as PDFs served to the requests account lets through, e.g.
+// auth.NewMiddleware chained with rbac.RequireUnscoped, the rest of the
+// archive to the ones of admin, e.g. with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	saleHandler := httptransport.NewServer(
+		e.SaleEndpoint,
+		decodeOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	creditNoteHandler := httptransport.NewServer(
+		e.CreditNoteEndpoint,
+		decodeCreditNoteRequest,
+		encodeResponse,
+		options...,
+	)
+	documentsHandler := httptransport.NewServer(
+		e.DocumentsEndpoint,
+		decodeOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	documentHandler := httptransport.NewServer(
+		e.DocumentEndpoint,
+		decodeDocumentRequest,
+		encodeResponse,
+		options...,
+	)
+	verifyHandler := httptransport.NewServer(
+		e.VerifyEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	refundsHandler := httptransport.NewServer(
+		e.RefundsEndpoint,
+		decodeReportRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(transport.PopulateTimezone))...,
+	)
+	refundReportHandler := httptransport.NewServer(
+		e.RefundReportEndpoint,
+		decodeReportRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(transport.PopulateTimezone))...,
+	)
+	invoicePDFHandler := httptransport.NewServer(
+		e.InvoicePDFEndpoint,
+		decodeOrderRequest,
+		encodeFile,
+		options...,
+	)
+	creditNotePDFHandler := httptransport.NewServer(
+		e.CreditNotePDFEndpoint,
+		decodeRefundRequest,
+		encodeFile,
+		options...,
+	)
+	footersHandler := httptransport.NewServer(
+		e.FootersEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	setFooterHandler := httptransport.NewServer(
+		e.SetFooterEndpoint,
+		decodeSetFooterRequest,
+		encodeResponse,
+		options...,
+	)
+	deleteFooterHandler := httptransport.NewServer(
+		e.DeleteFooterEndpoint,
+		decodeFooterRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/archive/v1/orders/{order-id}", saleHandler).Methods("POST")
+	r.Handle("/archive/v1/orders/{order-id}", documentsHandler).Methods("GET")
+	r.Handle("/archive/v1/orders/{order-id}/credit-notes", creditNoteHandler).Methods("POST")
+	r.Handle("/archive/v1/orders/{order-id}/invoice", invoicePDFHandler).Methods("GET")
+	r.Handle("/archive/v1/documents/{document-id}", documentHandler).Methods("GET")
+	r.Handle("/archive/v1/verify", verifyHandler).Methods("GET")
+	r.Handle("/archive/v1/refunds", refundsHandler).Methods("GET")
+	r.Handle("/archive/v1/refunds/report", refundReportHandler).Methods("GET")
+	r.Handle("/archive/v1/refunds/{refund-id}/credit-note", creditNotePDFHandler).Methods("GET")
+	r.Handle("/archive/v1/footers", footersHandler).Methods("GET")
+	r.Handle("/archive/v1/footers/{country}", setFooterHandler).Methods("PUT")
+	r.Handle("/archive/v1/footers/{country}", deleteFooterHandler).Methods("DELETE")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderRequest{OrderID: orderID, Locale: req.FormValue("locale")}, nil
+}
+
+func decodeCreditNoteRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r creditNoteRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeDocumentRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["document-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "document-id")
+	}
+	return documentRequest{ID: ID}, nil
+}
+
+func decodeRefundRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["refund-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "refund-id")
+	}
+	return refundRequest{ID: ID, Locale: req.FormValue("locale")}, nil
+}
+
+func decodeSetFooterRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var f Footer
+	if err := schema.Decode(req.Body, &f); err != nil {
+		return nil, err
+	}
+	country, ok := mux.Vars(req)["country"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "country")
+	}
+	f.Country = country
+	return f, nil
+}
+
+func decodeFooterRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	country, ok := mux.Vars(req)["country"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "country")
+	}
+	return footerRequest{Country: country}, nil
+}
+
+// decodeReportRequest defaults to the current month, summed up per month.
+// The dates and periods are those of the time zone of the request.
+func decodeReportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	loc, err := transport.Timezone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	r := reportRequest{
+		From:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc),
+		To:     now,
+		Period: req.FormValue("period"),
+	}
+	if r.Period == "" {
+		r.Period = PeriodMonth
+	}
+	if v := req.FormValue("from"); v != "" {
+		from, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		r.From = from.In(loc)
+	}
+	if v := req.FormValue("to"); v != "" {
+		to, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		// a to date is inclusive
+		if len(v) == len(transport.DateLayout) {
+			to = to.AddDate(0, 0, 1)
+		}
+		r.To = to.In(loc)
+	}
+	return r, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+// encodeFile writes the generated file inline, with its name for saving.
+func encodeFile(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(fileResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `inline; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrDocumentNotFound, ErrRefundNotFound, ErrFooterNotFound, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyArchived:
+		return http.StatusConflict
+	case ErrNoInvoice, ErrCreditExceeds:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrInvalidAmount, ErrReasonRequired, ErrUnknownReason, ErrMissingIssuer, ErrInvalidPeriod, ErrInvalidDate, ErrUnknownLocale, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
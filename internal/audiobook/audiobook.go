@@ -0,0 +1,42 @@
+package audiobook
+
+import (
+	"io"
+	"time"
+)
+
+// Variant is a single encoding of an audiobook. Every audiobook can have
+// multiple variants with different bitrates so that clients on slow
+// networks can pick a lighter one.
+type Variant struct {
+	ID       string `json:"id"`
+	BookID   string `json:"book_id"`
+	Bitrate  int    `json:"bitrate"` // in kbps
+	Format   string `json:"format"`  // e.g: mp3, aac
+	Path     string `json:"-"`
+	Size     int64  `json:"size"`
+	Duration int    `json:"duration"` // in seconds
+}
+
+// Position is the playback position of an user on particular device.
+type Position struct {
+	ID        string    `json:"-"`
+	UserID    string    `json:"user_id"`
+	BookID    string    `json:"book_id"`
+	DeviceID  string    `json:"device_id"`
+	Offset    int       `json:"offset"` // in seconds from the start
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReadSeekCloser is the content of an audio file.
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// Media is an opened audio file ready to be streamed.
+type Media struct {
+	Variant Variant
+	ModTime time.Time
+	Content ReadSeekCloser
+}
@@ -0,0 +1,136 @@
+package audiobook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/audiobook"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the positions in memory, the audiobooks having a single
+// variant.
+type repo struct {
+	audiobook.Repo
+	positions []audiobook.Position
+}
+
+func (r *repo) ListVariants(bookID string) ([]audiobook.Variant, error) {
+	return []audiobook.Variant{{ID: "v1", BookID: bookID, Bitrate: 64, Path: bookID + ".mp3"}}, nil
+}
+
+func (r *repo) SavePosition(p *audiobook.Position) error {
+	r.positions = append(r.positions, *p)
+	return nil
+}
+
+func (r *repo) ListPositions(userID, bookID string) ([]audiobook.Position, error) {
+	positions := make([]audiobook.Position, 0)
+	for _, p := range r.positions {
+		if p.UserID == userID && p.BookID == bookID {
+			positions = append(positions, p)
+		}
+	}
+	return positions, nil
+}
+
+type content struct {
+	*strings.Reader
+}
+
+func (content) Close() error {
+	return nil
+}
+
+type store struct{}
+
+func (store) Open(path string) (audiobook.ReadSeekCloser, time.Time, error) {
+	return content{strings.NewReader("audio")}, time.Now(), nil
+}
+
+// orderRepo returns the orders of u1: b1 paid, b2 not.
+type orderRepo struct {
+	order.Repo
+}
+
+func (orderRepo) ListByUser(userID string) ([]order.Order, error) {
+	if userID != "u1" {
+		return nil, nil
+	}
+	paid := time.Now()
+	return []order.Order{
+		{ID: "o1", CreatedByID: "u1", Items: []catalog.Book{{ID: "b1"}}, PaidAt: &paid},
+		{ID: "o2", CreatedByID: "u1", Items: []catalog.Book{{ID: "b2"}}},
+	}, nil
+}
+
+func TestEntitled(t *testing.T) {
+	ent := audiobook.NewOrderEntitlements(orderRepo{})
+	for _, c := range []struct {
+		userID, bookID string
+		expected       bool
+	}{
+		{"u1", "b1", true},
+		{"u1", "b2", false},
+		{"u2", "b1", false},
+	} {
+		if ok, err := ent.Entitled(c.userID, c.bookID); err != nil || ok != c.expected {
+			t.Errorf("%s %s: expected %v, got %v, %v", c.userID, c.bookID, c.expected, ok, err)
+		}
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	r := &repo{}
+	s := audiobook.NewService(r, store{}, audiobook.NewOrderEntitlements(orderRepo{}))
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	h := audiobook.MakeHTTPHandler(context.Background(), s, account, log.NewNopLogger())
+	u1, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	u2, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+	position := `{"device_id":"d1","offset":42,"user_id":"u2"}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"stream without token", "GET", "/audiobooks/v1/b1/stream", "", "", http.StatusUnauthorized},
+		{"stream paid", "GET", "/audiobooks/v1/b1/stream", "", u1, http.StatusOK},
+		{"stream unpaid", "GET", "/audiobooks/v1/b2/stream", "", u1, http.StatusForbidden},
+		{"stream by another user", "GET", "/audiobooks/v1/b1/stream", "", u2, http.StatusForbidden},
+		{"stream at the user route", "GET", "/audiobooks/v1/u1/b1/stream", "", u1, http.StatusNotFound},
+		{"save position without token", "PUT", "/audiobooks/v1/b1/position", position, "", http.StatusUnauthorized},
+		{"save position", "PUT", "/audiobooks/v1/b1/position", position, u1, http.StatusOK},
+		{"positions without token", "GET", "/audiobooks/v1/b1/position", "", "", http.StatusUnauthorized},
+		{"positions", "GET", "/audiobooks/v1/b1/position", "", u1, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+		if c.name == "stream paid" {
+			if b, _ := ioutil.ReadAll(w.Body); string(b) != "audio" {
+				t.Errorf("%s: expected the audio streamed, got %q", c.name, b)
+			}
+		}
+	}
+	if len(r.positions) != 1 || r.positions[0].UserID != "u1" || r.positions[0].Offset != 42 {
+		t.Errorf("expected the position saved for the user of the token, got %+v", r.positions)
+	}
+}
@@ -0,0 +1,136 @@
+package audiobook
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the audiobook service endpoints under single type.
+type Endpoints struct {
+	VariantsEndpoint     endpoint.Endpoint
+	StreamEndpoint       endpoint.Endpoint
+	SavePositionEndpoint endpoint.Endpoint
+	PositionsEndpoint    endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the audiobook service endpoints. The streams and the positions are
+// restricted by account, e.g. to the unscoped tokens of the users.
+func MakeEndpoints(s Service, account endpoint.Middleware) Endpoints {
+	return Endpoints{
+		VariantsEndpoint:     MakeVariantsEndpoint(s),
+		StreamEndpoint:       account(MakeStreamEndpoint(s)),
+		SavePositionEndpoint: account(MakeSavePositionEndpoint(s)),
+		PositionsEndpoint:    account(MakePositionsEndpoint(s)),
+	}
+}
+
+func MakeVariantsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(variantsRequest)
+		variants, e := s.Variants(ctx, req.BookID)
+		if e != nil {
+			return variantsResponse{Variants: make([]Variant, 0), Error: e}, nil
+		}
+		return variantsResponse{Variants: variants}, nil
+	}
+}
+
+// MakeStreamEndpoint opens an audiobook of the user of the request.
+func MakeStreamEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(streamRequest)
+		media, e := s.Stream(ctx, userID, req.BookID, req.Bitrate)
+		return streamResponse{Media: media, Error: e}, nil
+	}
+}
+
+// MakeSavePositionEndpoint stores a position of the user of the request.
+func MakeSavePositionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(savePositionRequest)
+		req.UserID = userID
+		pos, e := s.SavePosition(ctx, req.Position)
+		if e != nil {
+			return savePositionResponse{Position: nil, Error: e}, nil
+		}
+		return savePositionResponse{Position: &pos}, nil
+	}
+}
+
+// MakePositionsEndpoint returns the positions of the user of the request.
+func MakePositionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(positionsRequest)
+		positions, e := s.Positions(ctx, userID, req.BookID)
+		if e != nil {
+			return positionsResponse{Positions: make([]Position, 0), Error: e}, nil
+		}
+		return positionsResponse{Positions: positions}, nil
+	}
+}
+
+type variantsRequest struct {
+	BookID string `json:"book_id"`
+}
+
+type variantsResponse struct {
+	Variants []Variant `json:"variants"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r variantsResponse) error() error {
+	return r.Error
+}
+
+type streamRequest struct {
+	BookID  string
+	Bitrate int
+}
+
+// streamResponse is written by the streamHandler, the Content of the Media
+// being closed once served.
+type streamResponse struct {
+	Media Media
+	Error error
+}
+
+type savePositionRequest struct {
+	Position
+}
+
+type savePositionResponse struct {
+	Position *Position `json:"position,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r savePositionResponse) error() error {
+	return r.Error
+}
+
+type positionsRequest struct {
+	BookID string `json:"book_id"`
+}
+
+type positionsResponse struct {
+	Positions []Position `json:"positions"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r positionsResponse) error() error {
+	return r.Error
+}
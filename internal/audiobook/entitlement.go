@@ -0,0 +1,36 @@
+package audiobook
+
+import "github.com/kavirajk/bookshop/order"
+
+// Entitlements tells whether an user is allowed to listen to a book.
+type Entitlements interface {
+	Entitled(userID, bookID string) (bool, error)
+}
+
+type orderEntitlements struct {
+	r order.Repo
+}
+
+// NewOrderEntitlements returns Entitlements based on the orders paid by the
+// user.
+func NewOrderEntitlements(r order.Repo) Entitlements {
+	return orderEntitlements{r}
+}
+
+func (e orderEntitlements) Entitled(userID, bookID string) (bool, error) {
+	orders, err := e.r.ListByUser(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orders {
+		if o.PaidAt == nil {
+			continue
+		}
+		for _, b := range o.Items {
+			if b.ID == bookID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
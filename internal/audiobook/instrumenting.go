@@ -0,0 +1,70 @@
+package audiobook
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Variants(ctx context.Context, bookID string) (variants []Variant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "variants", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	variants, err = mw.next.Variants(ctx, bookID)
+	return
+}
+
+func (mw instrmw) Stream(ctx context.Context, userID, bookID string, maxBitrate int) (media Media, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "stream", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	media, err = mw.next.Stream(ctx, userID, bookID, maxBitrate)
+	return
+}
+
+func (mw instrmw) SavePosition(ctx context.Context, pos Position) (p Position, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "save_position", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	p, err = mw.next.SavePosition(ctx, pos)
+	return
+}
+
+func (mw instrmw) Positions(ctx context.Context, userID, bookID string) (positions []Position, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "positions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	positions, err = mw.next.Positions(ctx, userID, bookID)
+	return
+}
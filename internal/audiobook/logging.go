@@ -0,0 +1,67 @@
+package audiobook
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Variants(ctx context.Context, bookID string) (variants []Variant, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "variants",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Variants(ctx, bookID)
+}
+
+func (s loggingService) Stream(ctx context.Context, userID, bookID string, maxBitrate int) (media Media, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "stream",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Stream(ctx, userID, bookID, maxBitrate)
+}
+
+func (s loggingService) SavePosition(ctx context.Context, pos Position) (p Position, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "save_position",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SavePosition(ctx, pos)
+}
+
+func (s loggingService) Positions(ctx context.Context, userID, bookID string) (positions []Position, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "positions",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Positions(ctx, userID, bookID)
+}
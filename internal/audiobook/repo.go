@@ -0,0 +1,10 @@
+package audiobook
+
+// Repo abstracts all the persistant storage operations of Audiobook Service
+type Repo interface {
+	CreateVariant(v *Variant) error
+	ListVariants(bookID string) ([]Variant, error)
+	SavePosition(p *Position) error
+	ListPositions(userID, bookID string) ([]Position, error)
+	Drop() error
+}
@@ -0,0 +1,128 @@
+package audiobook
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotEntitled     = errors.New("not entitled to the audiobook")
+	ErrVariantNotFound = errors.New("audiobook variant not found")
+	ErrMissingDevice   = errors.New("missing device id")
+)
+
+type Service interface {
+	// Variants lists all the available bitrate variants of an audiobook.
+	Variants(ctx context.Context, bookID string) ([]Variant, error)
+
+	// Stream opens the best variant not exceeding maxBitrate for the user.
+	// maxBitrate 0 means the lightest variant.
+	Stream(ctx context.Context, userID, bookID string, maxBitrate int) (Media, error)
+
+	// SavePosition stores the playback position of an user's device.
+	SavePosition(ctx context.Context, pos Position) (Position, error)
+
+	// Positions returns playback positions of all the user's devices,
+	// most recently updated first.
+	Positions(ctx context.Context, userID, bookID string) ([]Position, error)
+}
+
+type basicService struct {
+	r     Repo
+	store Store
+	ent   Entitlements
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, store Store, ent Entitlements) Service {
+	return basicService{r: r, store: store, ent: ent}
+}
+
+// Variants lists all the available bitrate variants of an audiobook.
+func (s basicService) Variants(ctx context.Context, bookID string) ([]Variant, error) {
+	return s.r.ListVariants(bookID)
+}
+
+// Stream checks the user is entitled to the audiobook and opens the
+// matching variant. Caller is responsible to close the Media content.
+func (s basicService) Stream(ctx context.Context, userID, bookID string, maxBitrate int) (Media, error) {
+	ok, err := s.ent.Entitled(userID, bookID)
+	if err != nil {
+		return Media{}, err
+	}
+	if !ok {
+		return Media{}, ErrNotEntitled
+	}
+	variants, err := s.r.ListVariants(bookID)
+	if err != nil {
+		return Media{}, err
+	}
+	v, ok := pickVariant(variants, maxBitrate)
+	if !ok {
+		return Media{}, ErrVariantNotFound
+	}
+	content, modtime, err := s.store.Open(v.Path)
+	if err != nil {
+		return Media{}, err
+	}
+	return Media{Variant: v, ModTime: modtime, Content: content}, nil
+}
+
+// SavePosition stores the playback position of an user's device.
+// Updates older than the stored position are ignored, so that a device
+// coming back online doesn't override newer progress.
+func (s basicService) SavePosition(ctx context.Context, pos Position) (Position, error) {
+	if pos.DeviceID == "" {
+		return Position{}, ErrMissingDevice
+	}
+	if pos.UpdatedAt.IsZero() {
+		pos.UpdatedAt = time.Now().UTC()
+	}
+	positions, err := s.r.ListPositions(pos.UserID, pos.BookID)
+	if err != nil {
+		return Position{}, err
+	}
+	for _, p := range positions {
+		if p.DeviceID != pos.DeviceID {
+			continue
+		}
+		if p.UpdatedAt.After(pos.UpdatedAt) {
+			return p, nil
+		}
+		pos.ID = p.ID
+	}
+	if err := s.r.SavePosition(&pos); err != nil {
+		return Position{}, err
+	}
+	return pos, nil
+}
+
+// Positions returns playback positions of all the user's devices.
+func (s basicService) Positions(ctx context.Context, userID, bookID string) ([]Position, error) {
+	return s.r.ListPositions(userID, bookID)
+}
+
+// pickVariant returns the highest bitrate variant not exceeding max.
+// If none fits, the lightest variant is returned.
+func pickVariant(variants []Variant, max int) (Variant, bool) {
+	if len(variants) == 0 {
+		return Variant{}, false
+	}
+	lightest, best := variants[0], -1
+	for i, v := range variants {
+		if v.Bitrate < lightest.Bitrate {
+			lightest = v
+		}
+		if max > 0 && v.Bitrate <= max && (best < 0 || v.Bitrate > variants[best].Bitrate) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return lightest, true
+	}
+	return variants[best], true
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,36 @@
+package audiobook
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store abstracts where the audio files are kept.
+type Store interface {
+	Open(path string) (ReadSeekCloser, time.Time, error)
+}
+
+type dirStore struct {
+	root string
+}
+
+// NewDirStore returns a Store that serves audio files from root directory.
+func NewDirStore(root string) Store {
+	return dirStore{root: root}
+}
+
+// Open opens the file relative to the root. path is cleaned so that it can't
+// escape the root directory.
+func (s dirStore) Open(path string) (ReadSeekCloser, time.Time, error) {
+	f, err := os.Open(filepath.Join(s.root, filepath.Clean("/"+path)))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, err
+	}
+	return f, fi.ModTime(), nil
+}
@@ -0,0 +1,216 @@
+package audiobook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrMissingDevice:   "audiobook.missing_device",
+		ErrNotEntitled:     "audiobook.not_entitled",
+		ErrVariantNotFound: "audiobook.variant_not_found",
+	})
+}
+
+// MakeHTTPHandler mounts the audiobook endpoints, the streams and the
+// positions of the user served to the requests account lets through, e.g.
+// auth.NewMiddleware chained with rbac.RequireUnscoped.
+func MakeHTTPHandler(ctx context.Context, s Service, account endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	variantsHandler := httptransport.NewServer(
+		e.VariantsEndpoint,
+		decodeVariantsRequest,
+		encodeResponse,
+		options...,
+	)
+	savePositionHandler := httptransport.NewServer(
+		e.SavePositionEndpoint,
+		decodeSavePositionRequest,
+		encodeResponse,
+		options...,
+	)
+	positionsHandler := httptransport.NewServer(
+		e.PositionsEndpoint,
+		decodePositionsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/audiobooks/v1/{book-id}/variants", variantsHandler).Methods("GET")
+	r.Handle("/audiobooks/v1/{book-id}/stream", streamHandler{e.StreamEndpoint}).Methods("GET", "HEAD")
+	r.Handle("/audiobooks/v1/{book-id}/position", positionsHandler).Methods("GET")
+	r.Handle("/audiobooks/v1/{book-id}/position", savePositionHandler).Methods("PUT")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// streamHandler serves the audio content directly instead of going through
+// go-kit transport, since the response is not json and range requests needs
+// the original http request. The stream goes through the endpoint e all the
+// same, for its middlewares.
+type streamHandler struct {
+	e endpoint.Endpoint
+}
+
+func (h streamHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	for _, f := range []httptransport.RequestFunc{i18n.PopulateLocale, auth.PopulateToken, csrf.Populate} {
+		ctx = f(ctx, req)
+	}
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		encodeError(ctx, errors.Wrap(ErrBadRouting, "book-id"), w)
+		return
+	}
+	// Ignoring error since zero value means the lightest variant.
+	bitrate, _ := strconv.Atoi(req.FormValue("bitrate"))
+
+	resp, err := h.e(ctx, streamRequest{BookID: bookID, Bitrate: bitrate})
+	if err != nil {
+		encodeError(ctx, err, w)
+		return
+	}
+	r := resp.(streamResponse)
+	if r.Error != nil {
+		encodeError(ctx, r.Error, w)
+		return
+	}
+	media := r.Media
+	defer media.Content.Close()
+
+	w.Header().Set("X-Audio-Bitrate", strconv.Itoa(media.Variant.Bitrate))
+	// ServeContent takes care of Range, If-Range and conditional requests.
+	http.ServeContent(w, req, media.Variant.Path, media.ModTime, media.Content)
+}
+
+func decodeVariantsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	bookID, ok := vars["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	return variantsRequest{
+		BookID: bookID,
+	}, nil
+}
+
+func decodeSavePositionRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r savePositionRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	r.BookID = bookID
+	return r, nil
+}
+
+func decodePositionsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	return positionsRequest{
+		BookID: bookID,
+	}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrVariantNotFound:
+		return http.StatusNotFound
+	case ErrNotEntitled:
+		return http.StatusForbidden
+	case ErrBadRouting, ErrMissingDevice, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
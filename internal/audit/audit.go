@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// Actions recorded.
+const (
+	ActionRegister       = "register"
+	ActionLogin          = "login"
+	ActionPasswordChange = "password_change"
+	ActionRoleChange     = "role_change"
+	ActionImpersonate    = "impersonate"
+	ActionDelete         = "delete"
+	ActionBan            = "ban"
+	ActionUnban          = "unban"
+	ActionPasswordReset  = "password_reset"
+	ActionErasureRequest = "erasure_request"
+	ActionErasureReview  = "erasure_review"
+)
+
+// Outcomes of an action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is a security relevant action of a user, e.g. a failed login. The
+// log is only ever appended to.
+type Event struct {
+	ID     string `json:"id"`
+	Action string `json:"action" sql:"index"`
+	// ActorID is the user acting, empty when unknown e.g. on a login with a
+	// wrong email. Actor is who they claimed to be, e.g. the email of a
+	// login.
+	ActorID string `json:"actor_id,omitempty" sql:"index"`
+	Actor   string `json:"actor,omitempty"`
+	// ImpersonatedBy is the admin acting as ActorID, if any.
+	ImpersonatedBy string `json:"impersonated_by,omitempty" sql:"index"`
+	// Subject is what the action is about, e.g. the role users are changed
+	// to.
+	Subject string `json:"subject,omitempty"`
+	Outcome string `json:"outcome"`
+	// Reason is the error code of a failure, or its message if it has
+	// none.
+	Reason    string    `json:"reason,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at" sql:"index"`
+}
+
+func (Event) TableName() string {
+	return "audit_events"
+}
+
+// ListFields are the fields the audit log can be filtered on.
+var ListFields = filter.Fields{
+	"action":          {Column: "action", Type: filter.String},
+	"actor_id":        {Column: "actor_id", Type: filter.String},
+	"actor":           {Column: "actor", Type: filter.String},
+	"impersonated_by": {Column: "impersonated_by", Type: filter.String},
+	"outcome":         {Column: "outcome", Type: filter.String},
+	"ip":              {Column: "ip", Type: filter.String},
+	"created_at":      {Column: "created_at", Type: filter.Time},
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+// Endpoints combine all the audit service endpoints under single type.
+type Endpoints struct {
+	EventsEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the audit service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		EventsEndpoint: admin(MakeEventsEndpoint(s)),
+	}
+}
+
+func MakeEventsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(eventsRequest)
+		events, total, e := s.Events(ctx, req.Filter, req.Limit, req.Offset, req.Count)
+		if e != nil {
+			return eventsResponse{Events: make([]Event, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		if req.Count == db.CountNone {
+			// total only tells about the next page, leave it out.
+			total = 0
+		}
+		return eventsResponse{Events: events, Total: total, Prev: prev, Next: next}, nil
+	}
+}
+
+type eventsRequest struct {
+	Filter filter.Expr
+	Limit  int
+	Offset int
+	Count  db.Count
+	URL    *url.URL
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+	Error  error   `json:"error,omitempty"`
+
+	Total int    `json:"-"`
+	Prev  string `json:"-"`
+	Next  string `json:"-"`
+}
+
+func (r eventsResponse) error() error {
+	return r.Error
+}
+
+func (r eventsResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
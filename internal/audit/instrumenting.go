@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Record(ctx context.Context, e Event) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "record", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Record(ctx, e)
+	return
+}
+
+func (mw instrmw) Events(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (events []Event, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "events", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	events, total, err = mw.next.Events(ctx, f, limit, offset, count)
+	return
+}
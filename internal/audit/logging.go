@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Record(ctx context.Context, e Event) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "record",
+			"action", e.Action,
+			"actor_id", e.ActorID,
+			"outcome", e.Outcome,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Record(ctx, e)
+}
+
+func (s loggingService) Events(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (events []Event, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "events",
+			"events", len(events),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Events(ctx, f, limit, offset, count)
+}
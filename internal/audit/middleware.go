@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+// Describer describes a request to an endpoint and its response, nil if
+// the endpoint failed, as an event. The action is left empty for the
+// requests not recorded. The error is the one the response carries.
+type Describer func(request, response interface{}) (Event, error)
+
+// NewMiddleware returns an endpoint middleware recording the requests as
+// described by describe, with their outcome and the client they came from.
+// The actor is the user of the access token unless describe tells, along
+// with the admin impersonating them. An event failing to be recorded
+// doesn't fail the request, l is expected to log it.
+func NewMiddleware(l AuditLogger, describe Describer) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+			e, failure := describe(request, response)
+			if e.Action == "" {
+				return response, err
+			}
+			if err != nil {
+				failure = err
+			}
+			if e.ActorID == "" {
+				e.ActorID, _ = auth.UserID(ctx)
+			}
+			if c, ok := auth.ClaimsFrom(ctx); ok {
+				e.ImpersonatedBy = c.ImpersonatedBy
+			}
+			e.Outcome = OutcomeSuccess
+			if failure != nil {
+				e.Outcome, e.Reason = OutcomeFailure, i18n.Code(failure)
+				if e.Reason == "" {
+					e.Reason = failure.Error()
+				}
+			}
+			e.IP, e.UserAgent = transport.ClientIP(ctx), transport.UserAgent(ctx)
+			_ = l.Record(ctx, e)
+			return response, err
+		}
+	}
+}
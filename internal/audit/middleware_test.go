@@ -0,0 +1,96 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// recorder keeps the events recorded in memory.
+type recorder struct {
+	events []audit.Event
+}
+
+func (r *recorder) Record(ctx context.Context, e audit.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+var errDenied = errors.New("denied")
+
+// response is the response of a login, failed with Error.
+type response struct {
+	UserID string
+	Error  error
+}
+
+func describe(request, res interface{}) (audit.Event, error) {
+	email := request.(string)
+	if email == "" {
+		return audit.Event{}, nil
+	}
+	e := audit.Event{Action: audit.ActionLogin, Actor: email}
+	r, _ := res.(response)
+	e.ActorID = r.UserID
+	return e, r.Error
+}
+
+func TestMiddleware(t *testing.T) {
+	r := &recorder{}
+	login := func(ctx context.Context, request interface{}) (interface{}, error) {
+		switch request.(string) {
+		case "ann@example.com":
+			return response{UserID: "u1"}, nil
+		case "broken@example.com":
+			return nil, errDenied
+		}
+		return response{Error: errDenied}, nil
+	}
+	e := audit.NewMiddleware(r, describe)(login)
+	// The remote address is the client one, resolved by realip.Handler.
+	ctx := context.WithValue(context.Background(), httptransport.ContextKeyRequestRemoteAddr, "203.0.113.9:4242")
+	ctx = context.WithValue(ctx, httptransport.ContextKeyRequestUserAgent, "curl/7.0")
+
+	for _, email := range []string{"ann@example.com", "bob@example.com", "broken@example.com", ""} {
+		e(ctx, email)
+	}
+	if len(r.events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", r.events)
+	}
+	if ev := r.events[0]; ev.Outcome != audit.OutcomeSuccess || ev.ActorID != "u1" || ev.IP != "203.0.113.9" || ev.UserAgent != "curl/7.0" {
+		t.Errorf("success: unexpected event %+v", ev)
+	}
+	for _, ev := range r.events[1:] {
+		if ev.Outcome != audit.OutcomeFailure || ev.Reason != "denied" || ev.ActorID != "" {
+			t.Errorf("%s: expected a failure, got %+v", ev.Actor, ev)
+		}
+	}
+}
+
+func TestMiddlewareImpersonation(t *testing.T) {
+	r := &recorder{}
+	tokens := auth.NewService([]byte("secret"), 0, auth.NewMemoryRevocations(), nil)
+	ok := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	change := func(request, response interface{}) (audit.Event, error) {
+		return audit.Event{Action: audit.ActionPasswordChange}, nil
+	}
+	e := auth.NewMiddleware(tokens)(audit.NewMiddleware(r, change)(ok))
+
+	token, _, _ := tokens.SignImpersonation("u2", "customer", "", "u1", time.Minute)
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if _, err := e(auth.PopulateToken(context.Background(), req), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 1 || r.events[0].ActorID != "u2" || r.events[0].ImpersonatedBy != "u1" {
+		t.Errorf("expected the user acted as by the admin, got %+v", r.events)
+	}
+}
@@ -0,0 +1,14 @@
+package audit
+
+import (
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// Repo abstracts all the persistant storage operations of Audit Service
+type Repo interface {
+	Create(e *Event) error
+	// List returns a page of the events matching f, newest first.
+	List(f filter.Expr, limit, offset int, count db.Count) (events []Event, total int, err error)
+	Drop() error
+}
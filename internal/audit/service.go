@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// AuditLogger records the events of the endpoints, see NewMiddleware.
+type AuditLogger interface {
+	// Record appends e to the log.
+	Record(ctx context.Context, e Event) error
+}
+
+type Service interface {
+	AuditLogger
+
+	// Events returns a page of the events matching the filter f on
+	// ListFields, newest first, for the shop admins.
+	Events(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (events []Event, total int, err error)
+}
+
+type basicService struct {
+	r Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo) Service {
+	return basicService{r: r}
+}
+
+// Record stamps the events recorded without a time.
+func (s basicService) Record(ctx context.Context, e Event) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	return s.r.Create(&e)
+}
+
+func (s basicService) Events(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) ([]Event, int, error) {
+	return s.r.List(f, limit, offset, count)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+const defaultPageLimit = 20
+
+// MakeHTTPHandler serves the audit log to the users admin lets through,
+// e.g. auth.NewMiddleware chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+	}
+	eventsHandler := httptransport.NewServer(
+		e.EventsEndpoint,
+		decodeEventsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/audit/v1/events", eventsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeEventsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	lreq := eventsRequest{URL: req.URL}
+
+	// Ignoring errors since zero values makes sense for limit and offset
+	lreq.Limit, _ = strconv.Atoi(req.FormValue("limit"))
+	if lreq.Limit <= 0 {
+		lreq.Limit = defaultPageLimit
+	}
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+
+	count, err := db.ParseCount(req.FormValue("count"))
+	if err != nil {
+		return nil, err
+	}
+	lreq.Count = count
+
+	// filter=action eq "login" and outcome eq "failure"
+	if lreq.Filter, err = filter.Parse(req.FormValue("filter"), ListFields); err != nil {
+		return nil, err
+	}
+	return lreq, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// pager used to paginate any transport response.
+type pager interface {
+	page() (total int, previous, next string)
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	if page, ok := d.(pager); ok {
+		t, p, n := page.page()
+		f.Meta.Total = t
+		f.Meta.Previous = p
+		f.Meta.Next = n
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden:
+		return http.StatusForbidden
+	case db.ErrBadCount, filter.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
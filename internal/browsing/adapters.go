@@ -0,0 +1,72 @@
+package browsing
+
+import (
+	"context"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// CatalogMiddleware records the books got of the catalog as viewed by the
+// visitor of the shop context of the request.
+func CatalogMiddleware(s Service) catalog.Middleware {
+	return func(next catalog.Service) catalog.Service {
+		return viewRecorder{Service: next, browsing: s}
+	}
+}
+
+type viewRecorder struct {
+	catalog.Service
+	browsing Service
+}
+
+func (r viewRecorder) Get(ctx context.Context, id string) (catalog.Book, error) {
+	b, err := r.Service.Get(ctx, id)
+	if err != nil {
+		return b, err
+	}
+	if c, ok := shopctx.FromContext(ctx); ok && c.Visitor != "" {
+		// The history is not worth failing the request for.
+		_ = r.browsing.View(ctx, c.Visitor, b.ID)
+	}
+	return b, nil
+}
+
+// UserMiddleware reconciles the visitor of the shop context of the request
+// into the user on registration and on the start of every login session,
+// whichever the way of login.
+func UserMiddleware(s Service) user.Middleware {
+	return func(next user.Service) user.Service {
+		return reconciler{Service: next, browsing: s}
+	}
+}
+
+type reconciler struct {
+	user.Service
+	browsing Service
+}
+
+// reconcile links the visitor of ctx to the user. The history is not worth
+// failing the login for.
+func (r reconciler) reconcile(ctx context.Context, userID string) {
+	if c, ok := shopctx.FromContext(ctx); ok && c.Visitor != "" {
+		_ = r.browsing.Reconcile(ctx, c.Visitor, userID)
+	}
+}
+
+func (r reconciler) Register(ctx context.Context, nuser user.NewUser) (user.User, error) {
+	u, err := r.Service.Register(ctx, nuser)
+	if err == nil {
+		r.reconcile(ctx, u.ID)
+	}
+	return u, err
+}
+
+func (r reconciler) StartSession(ctx context.Context, userID string, c user.Client) (user.Session, string, error) {
+	session, token, err := r.Service.StartSession(ctx, userID, c)
+	if err == nil {
+		r.reconcile(ctx, userID)
+	}
+	return session, token, err
+}
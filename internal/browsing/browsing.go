@@ -0,0 +1,143 @@
+package browsing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+// MaxViews is the most views kept of a visitor, the oldest are dropped.
+const MaxViews = 50
+
+// Weights of what a book shares with a viewed one in its recommendation
+// score.
+const (
+	weightAuthor = 3
+	weightSeries = 2
+	weightGenre  = 2
+	// weightPreferred is the weight of a genre the user prefers.
+	weightPreferred = 1
+)
+
+// View is the last view of a book by a visitor. UserID is set once the
+// visitor signed in, the views of all the visitors of a user make its
+// history.
+type View struct {
+	ID        string    `json:"-" gorm:"primary_key"`
+	VisitorID string    `json:"-" sql:"index"`
+	UserID    string    `json:"-" sql:"index"`
+	BookID    string    `json:"book_id"`
+	ViewedAt  time.Time `json:"viewed_at"`
+}
+
+func (View) TableName() string {
+	return "browsing_views"
+}
+
+// Visitor is a browser, identified by the visitor of its shop context.
+// UserID is the user last signed in on it.
+type Visitor struct {
+	ID        string    `json:"id" gorm:"primary_key"`
+	UserID    string    `json:"user_id" sql:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	LinkedAt  time.Time `json:"linked_at"`
+}
+
+func (Visitor) TableName() string {
+	return "browsing_visitors"
+}
+
+// History is whose views to read: the ones of the user when signed in,
+// else the ones of the visitor.
+type History struct {
+	VisitorID string
+	UserID    string
+}
+
+// latest returns the views by book, the last one of each, newest first.
+func latest(views []View) []View {
+	views = append([]View(nil), views...)
+	sort.SliceStable(views, func(i, j int) bool { return views[i].ViewedAt.After(views[j].ViewedAt) })
+	seen := make(map[string]bool, len(views))
+	list := make([]View, 0, len(views))
+	for _, v := range views {
+		if !seen[v.BookID] {
+			seen[v.BookID] = true
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// recommend returns the live books of books not viewed, by their score
+// against the viewed ones, newest views weighing the most, and against the
+// preferred genres. The books scoring nothing are left out.
+func recommend(books []catalog.Book, viewed []catalog.Book, preferred []string, now time.Time, limit int) []catalog.Book {
+	seen := make(map[string]bool, len(viewed))
+	for _, b := range viewed {
+		seen[b.ID] = true
+	}
+	type scored struct {
+		book  catalog.Book
+		score float64
+	}
+	list := make([]scored, 0)
+	for _, b := range books {
+		if seen[b.ID] || b.Delisted || !b.LiveAt(now) {
+			continue
+		}
+		score := 0.0
+		for i, v := range viewed {
+			score += float64(shared(b, v)) / float64(i+1)
+		}
+		for _, g := range b.Genres {
+			for _, p := range preferred {
+				if g.ID == p {
+					score += weightPreferred
+				}
+			}
+		}
+		if score > 0 {
+			list = append(list, scored{book: b, score: score})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].book.Title < list[j].book.Title
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	recommended := make([]catalog.Book, len(list))
+	for i, s := range list {
+		recommended[i] = s.book
+	}
+	return recommended
+}
+
+// shared returns the weight of the authors, series and genres b shares
+// with v.
+func shared(b, v catalog.Book) int {
+	w := 0
+	for _, a := range b.Authors {
+		for _, va := range v.Authors {
+			if a.ID == va.ID {
+				w += weightAuthor
+			}
+		}
+	}
+	if b.Series != "" && b.Series == v.Series {
+		w += weightSeries
+	}
+	for _, g := range b.Genres {
+		for _, vg := range v.Genres {
+			if g.ID == vg.ID {
+				w += weightGenre
+			}
+		}
+	}
+	return w
+}
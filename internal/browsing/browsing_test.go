@@ -0,0 +1,225 @@
+package browsing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/browsing"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// memRepo keeps the visitors and views in memory.
+type memRepo struct {
+	visitors map[string]browsing.Visitor
+	views    []browsing.View
+	ids      int
+}
+
+func (r *memRepo) Visitor(id string) (browsing.Visitor, error) {
+	v, ok := r.visitors[id]
+	if !ok {
+		return browsing.Visitor{}, db.ErrNotFound
+	}
+	return v, nil
+}
+
+func (r *memRepo) SaveVisitor(v *browsing.Visitor) error {
+	r.visitors[v.ID] = *v
+	return nil
+}
+
+func (r *memRepo) Views(visitorID string) ([]browsing.View, error) {
+	var views []browsing.View
+	for _, v := range r.views {
+		if v.VisitorID == visitorID {
+			views = append(views, v)
+		}
+	}
+	return views, nil
+}
+
+func (r *memRepo) UserViews(userID string) ([]browsing.View, error) {
+	var views []browsing.View
+	for _, v := range r.views {
+		if v.UserID == userID {
+			views = append(views, v)
+		}
+	}
+	return views, nil
+}
+
+func (r *memRepo) SaveView(v *browsing.View) error {
+	for i := range r.views {
+		if r.views[i].ID == v.ID {
+			r.views[i] = *v
+			return nil
+		}
+	}
+	r.ids++
+	v.ID = string(rune('a' + r.ids))
+	r.views = append(r.views, *v)
+	return nil
+}
+
+func (r *memRepo) DeleteViews(ids []string) error {
+	deleted := make(map[string]bool)
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	views := r.views[:0]
+	for _, v := range r.views {
+		if !deleted[v.ID] {
+			views = append(views, v)
+		}
+	}
+	r.views = views
+	return nil
+}
+
+func (r *memRepo) LinkViews(visitorID, userID string) error {
+	for i, v := range r.views {
+		if v.VisitorID == visitorID && v.UserID == "" {
+			r.views[i].UserID = userID
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) Drop() error {
+	return nil
+}
+
+// catalogRepo keeps the books in memory.
+type catalogRepo struct {
+	catalog.Repo
+	books []catalog.Book
+}
+
+func (r catalogRepo) GetByID(id string) (catalog.Book, error) {
+	for _, b := range r.books {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return catalog.Book{}, db.ErrNotFound
+}
+
+func (r catalogRepo) ListAll() ([]catalog.Book, error) {
+	return r.books, nil
+}
+
+// poetryReader returns a user repo of u1, preferring poetry.
+func poetryReader() user.Repo {
+	users := inmem.NewUserRepo()
+	users.Create(&user.User{ID: "u1", PreferenceString: `{"genres":["poetry"]}`})
+	return users
+}
+
+// sessions starts every login session.
+type sessions struct {
+	user.Service
+}
+
+func (sessions) StartSession(ctx context.Context, userID string, c user.Client) (user.Session, string, error) {
+	return user.Session{}, "token", nil
+}
+
+func ids(books []catalog.Book) []string {
+	list := make([]string, len(books))
+	for i, b := range books {
+		list[i] = b.ID
+	}
+	return list
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	fantasy, poetry := catalog.Genre{ID: "fantasy"}, catalog.Genre{ID: "poetry"}
+	tolkien := catalog.Author{ID: "tolkien"}
+	books := catalogRepo{books: []catalog.Book{
+		{ID: "hobbit", Title: "The Hobbit", Authors: []catalog.Author{tolkien}, Genres: []catalog.Genre{fantasy}},
+		{ID: "lotr", Title: "The Lord of the Rings", Authors: []catalog.Author{tolkien}, Genres: []catalog.Genre{fantasy}},
+		{ID: "earthsea", Title: "A Wizard of Earthsea", Genres: []catalog.Genre{fantasy}},
+		{ID: "odes", Title: "Odes", Genres: []catalog.Genre{poetry}},
+		{ID: "gone", Title: "Gone", Genres: []catalog.Genre{fantasy}, Delisted: true},
+		{ID: "cookbook", Title: "Cookbook"},
+	}}
+	r := &memRepo{visitors: make(map[string]browsing.Visitor)}
+	users := poetryReader()
+	s := browsing.NewService(r, books, users)
+
+	if err := s.View(ctx, "", "hobbit"); err != browsing.ErrNoVisitor {
+		t.Errorf("no visitor: expected ErrNoVisitor, got %v", err)
+	}
+	if err := s.View(ctx, "v1", "missing"); err != browsing.ErrBookNotFound {
+		t.Errorf("unknown book: expected ErrBookNotFound, got %v", err)
+	}
+	for _, id := range []string{"hobbit", "cookbook", "hobbit"} {
+		if err := s.View(ctx, "v1", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	anonymous := browsing.History{VisitorID: "v1"}
+	recent, err := s.Recent(ctx, anonymous, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(recent); len(got) != 2 || got[0] != "hobbit" || got[1] != "cookbook" {
+		t.Errorf("expected hobbit then cookbook, got %v", got)
+	}
+
+	recommended, err := s.Recommendations(ctx, anonymous, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(recommended); len(got) != 2 || got[0] != "lotr" || got[1] != "earthsea" {
+		t.Errorf("expected the same author first, then the same genre, got %v", got)
+	}
+
+	// The visitor signs in, its history becomes the one of the user.
+	us := browsing.UserMiddleware(browsing.NewService(r, books, users))(sessions{})
+	signedIn := shopctx.NewContext(ctx, shopctx.Context{Visitor: "v1"})
+	if _, _, err := us.StartSession(signedIn, "u1", user.Client{}); err != nil {
+		t.Fatal(err)
+	}
+	if r.visitors["v1"].UserID != "u1" {
+		t.Fatalf("expected the visitor linked to the user, got %+v", r.visitors["v1"])
+	}
+	if err := s.View(ctx, "v1", "lotr"); err != nil {
+		t.Fatal(err)
+	}
+	mine := browsing.History{UserID: "u1"}
+	if recent, _ := s.Recent(ctx, mine, 10); len(recent) != 3 || recent[0].ID != "lotr" {
+		t.Errorf("expected the views before and after login, got %v", ids(recent))
+	}
+	recommended, _ = s.Recommendations(ctx, mine, 10)
+	if got := ids(recommended); len(got) != 2 || got[0] != "earthsea" || got[1] != "odes" {
+		t.Errorf("expected the preferred genre recommended too, got %v", got)
+	}
+}
+
+func TestMaxViews(t *testing.T) {
+	ctx := context.Background()
+	var books catalogRepo
+	for i := 0; i < browsing.MaxViews+5; i++ {
+		books.books = append(books.books, catalog.Book{ID: string(rune('A' + i))})
+	}
+	r := &memRepo{visitors: make(map[string]browsing.Visitor)}
+	s := browsing.NewService(r, books, inmem.NewUserRepo())
+	for _, b := range books.books {
+		if err := s.View(ctx, "v1", b.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(r.views) != browsing.MaxViews {
+		t.Fatalf("expected %d views kept, got %d", browsing.MaxViews, len(r.views))
+	}
+	recent, _ := s.Recent(ctx, browsing.History{VisitorID: "v1"}, 1)
+	if len(recent) != 1 || recent[0].ID != books.books[len(books.books)-1].ID {
+		t.Errorf("expected the last book viewed, got %v", ids(recent))
+	}
+}
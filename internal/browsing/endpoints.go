@@ -0,0 +1,122 @@
+package browsing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Authenticator resolves the token of a request into its user,
+// user.Service implements it.
+type Authenticator interface {
+	AuthToken(ctx context.Context, token string) (user.User, error)
+}
+
+// Endpoints combine all the browsing service endpoints under single type.
+type Endpoints struct {
+	ViewEndpoint            endpoint.Endpoint
+	RecentEndpoint          endpoint.Endpoint
+	RecommendationsEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the browsing service endpoints.
+func MakeEndpoints(s Service, auth Authenticator) Endpoints {
+	return Endpoints{
+		ViewEndpoint:            MakeViewEndpoint(s),
+		RecentEndpoint:          MakeRecentEndpoint(s, auth),
+		RecommendationsEndpoint: MakeRecommendationsEndpoint(s, auth),
+	}
+}
+
+func MakeViewEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewRequest)
+		if e := s.View(ctx, req.VisitorID, req.BookID); e != nil {
+			return viewResponse{Error: e}, nil
+		}
+		return viewResponse{BookID: req.BookID, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeRecentEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(historyRequest)
+		h, e := historyOf(ctx, auth, req)
+		if e != nil {
+			return nil, e
+		}
+		books, e := s.Recent(ctx, h, req.Limit)
+		if e != nil {
+			return booksResponse{Books: make([]catalog.Book, 0), Error: e}, nil
+		}
+		return booksResponse{Books: books}, nil
+	}
+}
+
+func MakeRecommendationsEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(historyRequest)
+		h, e := historyOf(ctx, auth, req)
+		if e != nil {
+			return nil, e
+		}
+		books, e := s.Recommendations(ctx, h, req.Limit)
+		if e != nil {
+			return booksResponse{Books: make([]catalog.Book, 0), Error: e}, nil
+		}
+		return booksResponse{Books: books}, nil
+	}
+}
+
+// historyOf returns the history of the user of the token of req, of its
+// visitor when signed out.
+func historyOf(ctx context.Context, auth Authenticator, req historyRequest) (History, error) {
+	h := History{VisitorID: req.VisitorID}
+	if req.Token == "" {
+		return h, nil
+	}
+	u, e := auth.AuthToken(ctx, req.Token)
+	if e != nil {
+		return History{}, user.ErrUnauthorized
+	}
+	h.UserID = u.ID
+	return h, nil
+}
+
+type viewRequest struct {
+	VisitorID string `json:"-"`
+	BookID    string `json:"book_id"`
+}
+
+type viewResponse struct {
+	BookID string `json:"book_id,omitempty"`
+	Status int    `json:"-"`
+	Error  error  `json:"error,omitempty"`
+}
+
+func (r viewResponse) error() error {
+	return r.Error
+}
+
+func (r viewResponse) status() int {
+	return r.Status
+}
+
+type historyRequest struct {
+	Token     string
+	VisitorID string
+	Limit     int
+}
+
+type booksResponse struct {
+	Books []catalog.Book `json:"books"`
+	Error error          `json:"error,omitempty"`
+}
+
+func (r booksResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,71 @@
+package browsing
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) View(ctx context.Context, visitorID, bookID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "view", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.View(ctx, visitorID, bookID)
+	return
+}
+
+func (mw instrmw) Recent(ctx context.Context, h History, limit int) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "recent", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, err = mw.next.Recent(ctx, h, limit)
+	return
+}
+
+func (mw instrmw) Recommendations(ctx context.Context, h History, limit int) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "recommendations", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, err = mw.next.Recommendations(ctx, h, limit)
+	return
+}
+
+func (mw instrmw) Reconcile(ctx context.Context, visitorID, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reconcile", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Reconcile(ctx, visitorID, userID)
+	return
+}
@@ -0,0 +1,72 @@
+package browsing
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) View(ctx context.Context, visitorID, bookID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "view",
+			"book_id", bookID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.View(ctx, visitorID, bookID)
+}
+
+func (s loggingService) Recent(ctx context.Context, h History, limit int) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "recent",
+			"user_id", h.UserID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Recent(ctx, h, limit)
+}
+
+func (s loggingService) Recommendations(ctx context.Context, h History, limit int) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "recommendations",
+			"user_id", h.UserID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Recommendations(ctx, h, limit)
+}
+
+func (s loggingService) Reconcile(ctx context.Context, visitorID, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reconcile",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Reconcile(ctx, visitorID, userID)
+}
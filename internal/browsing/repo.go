@@ -0,0 +1,17 @@
+package browsing
+
+// Repo abstracts all the persistant storage operations of Browsing service.
+type Repo interface {
+	Visitor(id string) (Visitor, error)
+	SaveVisitor(v *Visitor) error
+	// Views returns the views of a visitor.
+	Views(visitorID string) ([]View, error)
+	// UserViews returns the views of all the visitors of a user.
+	UserViews(userID string) ([]View, error)
+	// SaveView creates v, or updates it if it has an ID.
+	SaveView(v *View) error
+	DeleteViews(ids []string) error
+	// LinkViews gives the views of a visitor not yet of a user to userID.
+	LinkViews(visitorID, userID string) error
+	Drop() error
+}
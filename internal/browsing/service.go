@@ -0,0 +1,187 @@
+package browsing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrNoVisitor    = errors.New("no visitor")
+	ErrBookNotFound = errors.New("book not found")
+)
+
+type Service interface {
+	// View records that a visitor viewed a book, the visitor being the one
+	// of the shop context of the request.
+	View(ctx context.Context, visitorID, bookID string) error
+
+	// Recent returns the books last viewed of a history, newest first,
+	// leaving out the ones no more live.
+	Recent(ctx context.Context, h History, limit int) ([]catalog.Book, error)
+
+	// Recommendations returns the live books sharing the most authors,
+	// series and genres with the books viewed of a history, and with the
+	// genres the user prefers.
+	Recommendations(ctx context.Context, h History, limit int) ([]catalog.Book, error)
+
+	// Reconcile links a visitor to the user signed in on it, its views
+	// so far and from now on becoming part of the history of the user.
+	Reconcile(ctx context.Context, visitorID, userID string) error
+}
+
+type basicService struct {
+	r     Repo
+	books catalog.Repo
+	users user.Repo
+}
+
+// NewService return basic Service implementation. The users are read for
+// their preferred genres.
+func NewService(r Repo, books catalog.Repo, users user.Repo) Service {
+	return basicService{r: r, books: books, users: users}
+}
+
+// visitor returns the visitor of id, a new one if never seen.
+func (s basicService) visitor(id string) (Visitor, error) {
+	v, err := s.r.Visitor(id)
+	if err == db.ErrNotFound {
+		v = Visitor{ID: id, CreatedAt: time.Now()}
+		return v, s.r.SaveVisitor(&v)
+	}
+	return v, err
+}
+
+// View keeps one view per book and visitor, the last one, and drops the
+// oldest past MaxViews.
+func (s basicService) View(ctx context.Context, visitorID, bookID string) error {
+	if visitorID == "" {
+		return ErrNoVisitor
+	}
+	if _, err := s.books.GetByID(bookID); err != nil {
+		return ErrBookNotFound
+	}
+	visitor, err := s.visitor(visitorID)
+	if err != nil {
+		return err
+	}
+	views, err := s.r.Views(visitorID)
+	if err != nil {
+		return err
+	}
+	view := View{VisitorID: visitorID, BookID: bookID}
+	for _, v := range views {
+		if v.BookID == bookID {
+			view = v
+		}
+	}
+	view.UserID, view.ViewedAt = visitor.UserID, time.Now()
+	if err := s.r.SaveView(&view); err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, MaxViews)
+	for _, v := range latest(append(views, view)) {
+		if len(kept) < MaxViews {
+			kept[v.ID] = true
+		}
+	}
+	dropped := make([]string, 0)
+	for _, v := range views {
+		if !kept[v.ID] && v.ID != view.ID {
+			dropped = append(dropped, v.ID)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	return s.r.DeleteViews(dropped)
+}
+
+// viewed returns the live books viewed of h, newest view first.
+func (s basicService) viewed(h History) ([]catalog.Book, error) {
+	var views []View
+	var err error
+	switch {
+	case h.UserID != "":
+		views, err = s.r.UserViews(h.UserID)
+	case h.VisitorID != "":
+		views, err = s.r.Views(h.VisitorID)
+	default:
+		return nil, ErrNoVisitor
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	books := make([]catalog.Book, 0)
+	for _, v := range latest(views) {
+		if len(books) == MaxViews {
+			break
+		}
+		b, err := s.books.GetByID(v.BookID)
+		if err != nil {
+			// The book was deleted or merged since.
+			continue
+		}
+		if !b.Delisted && b.LiveAt(now) {
+			books = append(books, b)
+		}
+	}
+	return books, nil
+}
+
+func (s basicService) Recent(ctx context.Context, h History, limit int) ([]catalog.Book, error) {
+	books, err := s.viewed(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(books) > limit {
+		books = books[:limit]
+	}
+	return books, nil
+}
+
+func (s basicService) Recommendations(ctx context.Context, h History, limit int) ([]catalog.Book, error) {
+	viewed, err := s.viewed(h)
+	if err != nil {
+		return nil, err
+	}
+	var preferred []string
+	if h.UserID != "" {
+		if u, err := s.users.GetByID(h.UserID); err == nil {
+			preferred = u.Preferences().Genres
+		}
+	}
+	books, err := s.books.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	return recommend(books, viewed, preferred, time.Now(), limit), nil
+}
+
+// Reconcile relinks a visitor shared by several users to the last one
+// signed in, the views of the ones before staying theirs.
+func (s basicService) Reconcile(ctx context.Context, visitorID, userID string) error {
+	if visitorID == "" {
+		return ErrNoVisitor
+	}
+	visitor, err := s.visitor(visitorID)
+	if err != nil {
+		return err
+	}
+	if visitor.UserID != userID {
+		visitor.UserID, visitor.LinkedAt = userID, time.Now()
+		if err := s.r.SaveVisitor(&visitor); err != nil {
+			return err
+		}
+	}
+	return s.r.LinkViews(visitorID, userID)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
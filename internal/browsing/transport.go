@@ -0,0 +1,168 @@
+package browsing
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+	ErrBadLimit   = errors.New("limit must be between 1 and 50")
+)
+
+const defaultLimit = 10
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrNoVisitor:    "browsing.no_visitor",
+		ErrBookNotFound: "browsing.book_not_found",
+		ErrBadLimit:     "browsing.bad_limit",
+	})
+}
+
+// MakeHTTPHandler serves the history of the visitor of the shop context of
+// the requests, or of the user of their bearer token if any.
+func MakeHTTPHandler(ctx context.Context, s Service, auth Authenticator, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, auth)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	viewHandler := httptransport.NewServer(
+		e.ViewEndpoint,
+		decodeViewRequest,
+		encodeResponse,
+		options...,
+	)
+	recentHandler := httptransport.NewServer(
+		e.RecentEndpoint,
+		decodeHistoryRequest,
+		encodeResponse,
+		options...,
+	)
+	recommendationsHandler := httptransport.NewServer(
+		e.RecommendationsEndpoint,
+		decodeHistoryRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/browsing/v1/views", viewHandler).Methods("POST")
+	r.Handle("/browsing/v1/recent", recentHandler).Methods("GET")
+	r.Handle("/browsing/v1/recommendations", recommendationsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// visitorOf returns the visitor of the shop context of the request.
+func visitorOf(req *http.Request) string {
+	c, _ := shopctx.FromContext(req.Context())
+	return c.Visitor
+}
+
+func decodeViewRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r viewRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	r.VisitorID = visitorOf(req)
+	return r, nil
+}
+
+func decodeHistoryRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	r := historyRequest{
+		Token:     strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "),
+		VisitorID: visitorOf(req),
+		Limit:     defaultLimit,
+	}
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > MaxViews {
+			return nil, ErrBadLimit
+		}
+		r.Limit = n
+	}
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case user.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrBadLimit, ErrNoVisitor, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
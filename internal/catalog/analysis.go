@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrConfigNotFound  = errors.New("search config not found")
+	ErrInvalidSynonyms = errors.New("synonym set needs at least two terms")
+)
+
+// maxQueryVariants caps the number of synonym expansions searched for
+// a single query.
+const maxQueryVariants = 8
+
+// SearchConfig is a version of the synonym sets and the stopwords used by
+// search. Versions are immutable, a change creates a new version which can
+// be activated, or rolled back by activating an older one.
+type SearchConfig struct {
+	Version   int        `json:"version" gorm:"primary_key"`
+	Synonyms  [][]string `json:"synonyms" gorm:"-"`
+	Stopwords []string   `json:"stopwords" gorm:"-"`
+	Note      string     `json:"note,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	Rules     string     `json:"-" sql:"type:text"`
+}
+
+func (SearchConfig) TableName() string {
+	return "search_configs"
+}
+
+type searchRules struct {
+	Synonyms  [][]string `json:"synonyms"`
+	Stopwords []string   `json:"stopwords"`
+}
+
+// BeforeSave serializes the synonyms and the stopwords into Rules.
+func (c *SearchConfig) BeforeSave() error {
+	b, err := json.Marshal(searchRules{c.Synonyms, c.Stopwords})
+	if err != nil {
+		return err
+	}
+	c.Rules = string(b)
+	return nil
+}
+
+// AfterFind restores the synonyms and the stopwords from Rules.
+func (c *SearchConfig) AfterFind() error {
+	if c.Rules == "" {
+		return nil
+	}
+	var r searchRules
+	if err := json.Unmarshal([]byte(c.Rules), &r); err != nil {
+		return err
+	}
+	c.Synonyms, c.Stopwords = r.Synonyms, r.Stopwords
+	return nil
+}
+
+// Normalize folds all the terms the same way the index does, and drops
+// empty and duplicate ones.
+func (c *SearchConfig) Normalize() error {
+	var sets [][]string
+	for _, set := range c.Synonyms {
+		terms := uniqueFolded(set)
+		if len(terms) < 2 {
+			return ErrInvalidSynonyms
+		}
+		sets = append(sets, terms)
+	}
+	c.Synonyms = sets
+	c.Stopwords = uniqueFolded(c.Stopwords)
+	return nil
+}
+
+func uniqueFolded(terms []string) []string {
+	out := make([]string, 0, len(terms))
+	seen := make(map[string]bool)
+	for _, t := range terms {
+		f := fold(t)
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// analyzer applies a search config to queries and indexed text.
+type analyzer struct {
+	stopwords map[string]bool
+	synonyms  [][]string
+}
+
+func newAnalyzer(c SearchConfig) analyzer {
+	a := analyzer{stopwords: make(map[string]bool), synonyms: c.Synonyms}
+	for _, w := range c.Stopwords {
+		a.stopwords[w] = true
+	}
+	return a
+}
+
+// strip removes the stopwords from folded text. Text made of stopwords
+// only is kept as is, so "The The" is still searchable.
+func (a analyzer) strip(text string) string {
+	var words []string
+	for _, w := range strings.Fields(text) {
+		if !a.stopwords[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return text
+	}
+	return strings.Join(words, " ")
+}
+
+// expand returns the query without stopwords, followed by its variants
+// with synonymous terms swapped in.
+func (a analyzer) expand(query string) []string {
+	q := a.strip(fold(query))
+	variants := []string{q}
+	seen := map[string]bool{q: true}
+	for _, set := range a.synonyms {
+		for _, v := range variants {
+			for _, term := range set {
+				if !containsTerm(v, term) {
+					continue
+				}
+				for _, other := range set {
+					e := replaceTerm(v, term, other)
+					if seen[e] || len(variants) >= maxQueryVariants {
+						continue
+					}
+					seen[e] = true
+					variants = append(variants, e)
+				}
+			}
+		}
+	}
+	return variants
+}
+
+func containsTerm(text, term string) bool {
+	return strings.Contains(" "+text+" ", " "+term+" ")
+}
+
+func replaceTerm(text, term, with string) string {
+	r := strings.Replace(" "+text+" ", " "+term+" ", " "+with+" ", -1)
+	return strings.TrimSpace(r)
+}
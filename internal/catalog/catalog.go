@@ -3,12 +3,35 @@ package catalog
 import (
 	"strings"
 	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// Visibilities of the books. Only live books are public, the admin
+// endpoints see them all.
+const (
+	VisibilityDraft     = "draft"
+	VisibilityScheduled = "scheduled"
+	VisibilityLive      = "live"
+	VisibilityRetired   = "retired"
 )
 
+// ValidVisibility tells whether v is one of the visibilities.
+func ValidVisibility(v string) bool {
+	switch v {
+	case VisibilityDraft, VisibilityScheduled, VisibilityLive, VisibilityRetired:
+		return true
+	}
+	return false
+}
+
 type Book struct {
 	ID              string     `json:"id"`
 	ISBN            string     `json:"isbn"`
 	Title           string     `json:"title"`
+	Series          string     `json:"series,omitempty"`
+	Description     string     `json:"description,omitempty" gorm:"type:text"`
+	CoverURL        string     `json:"cover_url,omitempty"`
 	TagString       string     `json:"-"`
 	Authors         []Author   `json:"-" gorm:"many_to_many"`
 	Genres          []Genre    `json:"-" gorm:"many_to_many"`
@@ -19,6 +42,71 @@ type Book struct {
 	SampleURL       string     `json:"-"`
 	FullURL         string     `json:"-"`
 	Price           float64    `json:"price"`
+	Currency        string     `json:"currency,omitempty" sql:"-"` // of Price, the base one or the one of the market price list
+	PrintOnDemand   bool       `json:"print_on_demand"`
+	VendorID        string     `json:"vendor_id,omitempty"`
+	Stock           int        `json:"stock"`
+	// Location is the shelf of the book in the warehouse, e.g. "B-12-3",
+	// the pick lists walk the shelves in its order.
+	Location   string `json:"location,omitempty"`
+	Delisted   bool   `json:"delisted"`
+	Visibility string `json:"visibility" sql:"not null;default:'live'"`
+	// PublishAt is when a scheduled book goes live, RetireAt when a live
+	// book is retired, if set.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	RetireAt  *time.Time `json:"retire_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	// Version is bumped on every save, a save of an older version fails.
+	Version int `json:"version" sql:"not null;default:0"`
+}
+
+// ListFields are the fields the admin book list can be filtered and
+// ordered on.
+var ListFields = filter.Fields{
+	"id":               {Column: "id", Type: filter.String},
+	"isbn":             {Column: "isbn", Type: filter.String},
+	"title":            {Column: "title", Type: filter.String},
+	"series":           {Column: "series", Type: filter.String},
+	"publication_year": {Column: "publication_year", Type: filter.String},
+	"price":            {Column: "price", Type: filter.Number},
+	"print_on_demand":  {Column: "print_on_demand", Type: filter.Bool},
+	"vendor_id":        {Column: "vendor_id", Type: filter.String},
+	"stock":            {Column: "stock", Type: filter.Number},
+	"location":         {Column: "location", Type: filter.String},
+	"delisted":         {Column: "delisted", Type: filter.Bool},
+	"visibility":       {Column: "visibility", Type: filter.String},
+	"publish_at":       {Column: "publish_at", Type: filter.Time},
+	"retire_at":        {Column: "retire_at", Type: filter.Time},
+	"updated_at":       {Column: "updated_at", Type: filter.Time},
+}
+
+// LiveAt tells whether the book is public at now. A scheduled book due to
+// go live, or a live one due to retire, is taken as transitioned already,
+// the publisher catching up with it later.
+func (b Book) LiveAt(now time.Time) bool {
+	if b.RetireAt != nil && !now.Before(*b.RetireAt) {
+		return false
+	}
+	switch b.Visibility {
+	case VisibilityLive, "":
+		return true
+	case VisibilityScheduled:
+		return b.PublishAt != nil && !now.Before(*b.PublishAt)
+	}
+	return false
+}
+
+// Transition returns the visibility of the book at now, following its
+// schedule.
+func (b Book) Transition(now time.Time) string {
+	switch {
+	case b.Visibility == VisibilityScheduled && b.LiveAt(now):
+		return VisibilityLive
+	case (b.Visibility == VisibilityLive || b.Visibility == VisibilityScheduled) &&
+		b.RetireAt != nil && !now.Before(*b.RetireAt):
+		return VisibilityRetired
+	}
+	return b.Visibility
 }
 
 func (b *Book) Tags() []string {
@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of catalog changes.
+const (
+	ChangeCreated  = "created"
+	ChangeUpdated  = "updated"
+	ChangeDelisted = "delisted"
+	// ChangePublished and ChangeRetired are a book going live and being
+	// retired.
+	ChangePublished = "published"
+	ChangeRetired   = "retired"
+)
+
+// Change is an entry of the catalog change log. The repo records one along
+// with every book write, Seq orders them.
+type Change struct {
+	Seq       int64     `json:"-" gorm:"primary_key"`
+	BookID    string    `json:"book_id"`
+	Type      string    `json:"type"`
+	Data      string    `json:"-" sql:"type:text"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (Change) TableName() string {
+	return "catalog_changes"
+}
+
+// NewChange returns the change of writing b, prev is the stored book
+// before the write or nil if b is new.
+func NewChange(b *Book, prev *Book) (Change, error) {
+	t := ChangeUpdated
+	switch {
+	case prev == nil:
+		t = ChangeCreated
+	case b.Delisted && !prev.Delisted:
+		t = ChangeDelisted
+	case b.Visibility == VisibilityLive && prev.Visibility != VisibilityLive:
+		t = ChangePublished
+	case b.Visibility == VisibilityRetired && prev.Visibility != VisibilityRetired:
+		t = ChangeRetired
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return Change{}, err
+	}
+	return Change{BookID: b.ID, Type: t, Data: string(data), ChangedAt: b.UpdatedAt}, nil
+}
+
+// Book returns the book as it was written.
+func (c Change) Book() (Book, error) {
+	var b Book
+	err := json.Unmarshal([]byte(c.Data), &b)
+	return b, err
+}
@@ -2,24 +2,92 @@ package catalog
 
 import (
 	"net/http"
+	"net/url"
 
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/transport"
 )
 
 // Endpoints combine all the catalog service endpoints under single type.
 type Endpoints struct {
-	SearchEndpoint endpoint.Endpoint
-	GetEndpoint    endpoint.Endpoint
+	SearchEndpoint  endpoint.Endpoint
+	GetEndpoint     endpoint.Endpoint
+	SuggestEndpoint endpoint.Endpoint
+	OGEndpoint      endpoint.Endpoint
+	PatchEndpoint   endpoint.Endpoint
+	ListEndpoint    endpoint.Endpoint
+
+	SearchConfigEndpoint         endpoint.Endpoint
+	SearchConfigsEndpoint        endpoint.Endpoint
+	CreateSearchConfigEndpoint   endpoint.Endpoint
+	ActivateSearchConfigEndpoint endpoint.Endpoint
+	ReindexEndpoint              endpoint.Endpoint
+
+	RulesEndpoint       endpoint.Endpoint
+	CreateRuleEndpoint  endpoint.Endpoint
+	UpdateRuleEndpoint  endpoint.Endpoint
+	DeleteRuleEndpoint  endpoint.Endpoint
+	RuleChangesEndpoint endpoint.Endpoint
+
+	PricesEndpoint        endpoint.Endpoint
+	SchedulePriceEndpoint endpoint.Endpoint
+	CancelPriceEndpoint   endpoint.Endpoint
+
+	PriceListsEndpoint      endpoint.Endpoint
+	CreatePriceListEndpoint endpoint.Endpoint
 }
 
 // MakeEndpoints returns Endpoints type which is the combination of
-// all the catalog service endpoints.
-func MakeEndpoints(s Service) Endpoints {
+// all the catalog service endpoints. The shop admin endpoints are restricted
+// by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
 	return Endpoints{
-		SearchEndpoint: MakeSearchEndpoint(s),
-		GetEndpoint:    MakeGetEndpoint(s),
+		SearchEndpoint:  MakeSearchEndpoint(s),
+		GetEndpoint:     MakeGetEndpoint(s),
+		SuggestEndpoint: MakeSuggestEndpoint(s),
+		OGEndpoint:      MakeOGEndpoint(s),
+		PatchEndpoint:   admin(MakePatchEndpoint(s)),
+		ListEndpoint:    admin(MakeListEndpoint(s)),
+
+		SearchConfigEndpoint:         admin(MakeSearchConfigEndpoint(s)),
+		SearchConfigsEndpoint:        admin(MakeSearchConfigsEndpoint(s)),
+		CreateSearchConfigEndpoint:   admin(MakeCreateSearchConfigEndpoint(s)),
+		ActivateSearchConfigEndpoint: admin(MakeActivateSearchConfigEndpoint(s)),
+		ReindexEndpoint:              admin(MakeReindexEndpoint(s)),
+
+		RulesEndpoint:       admin(MakeRulesEndpoint(s)),
+		CreateRuleEndpoint:  admin(MakeCreateRuleEndpoint(s)),
+		UpdateRuleEndpoint:  admin(MakeUpdateRuleEndpoint(s)),
+		DeleteRuleEndpoint:  admin(MakeDeleteRuleEndpoint(s)),
+		RuleChangesEndpoint: admin(MakeRuleChangesEndpoint(s)),
+
+		PricesEndpoint:        admin(MakePricesEndpoint(s)),
+		SchedulePriceEndpoint: admin(MakeSchedulePriceEndpoint(s)),
+		CancelPriceEndpoint:   admin(MakeCancelPriceEndpoint(s)),
+
+		PriceListsEndpoint:      admin(MakePriceListsEndpoint(s)),
+		CreatePriceListEndpoint: admin(MakeCreatePriceListEndpoint(s)),
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		books, total, e := s.List(ctx, req.Filter, req.Order, req.Limit, req.Offset, req.Count)
+		if e != nil {
+			return listResponse{Books: make([]Book, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		if req.Count == db.CountNone {
+			// total only tells about the next page, leave it out.
+			total = 0
+		}
+		return listResponse{Books: books, Total: total, Prev: prev, Next: next}, nil
 	}
 }
 
@@ -30,7 +98,13 @@ func MakeSearchEndpoint(s Service) endpoint.Endpoint {
 		if e != nil {
 			return searchResponse{Books: make([]Book, 0), Error: e}, nil
 		}
-		return searchResponse{Books: books, Status: http.StatusOK}, nil
+		var c *Correction
+		if len(books) < FewResults {
+			if c, e = s.DidYouMean(ctx, req.Q, len(books)); e != nil {
+				return searchResponse{Books: make([]Book, 0), Error: e}, nil
+			}
+		}
+		return searchResponse{Books: books, DidYouMean: c, Status: http.StatusOK}, nil
 	}
 }
 
@@ -45,14 +119,207 @@ func MakeGetEndpoint(s Service) endpoint.Endpoint {
 	}
 }
 
+func MakeSuggestEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(suggestRequest)
+		suggestions, e := s.Suggest(ctx, req.Q, req.Limit)
+		if e != nil {
+			return suggestResponse{Suggestions: make([]Suggestion, 0), Error: e}, nil
+		}
+		return suggestResponse{Suggestions: suggestions}, nil
+	}
+}
+
+func MakeOGEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getRequest)
+		m, e := s.OpenGraph(ctx, req.ID)
+		if e != nil {
+			return ogResponse{Metadata: nil, Error: e}, nil
+		}
+		return ogResponse{Metadata: &m}, nil
+	}
+}
+
+func MakePatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(patchRequest)
+		book, e := s.Patch(ctx, req.ID, req.Match, req.Patch)
+		if e != nil {
+			return getResponse{Book: nil, Error: e}, nil
+		}
+		return getResponse{Book: &book}, nil
+	}
+}
+
+func MakeSearchConfigEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		c, e := s.SearchConfig(ctx)
+		if e != nil {
+			return searchConfigResponse{Config: nil, Error: e}, nil
+		}
+		return searchConfigResponse{Config: &c}, nil
+	}
+}
+
+func MakeSearchConfigsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		configs, e := s.SearchConfigs(ctx)
+		if e != nil {
+			return searchConfigsResponse{Configs: make([]SearchConfig, 0), Error: e}, nil
+		}
+		return searchConfigsResponse{Configs: configs}, nil
+	}
+}
+
+func MakeCreateSearchConfigEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSearchConfigRequest)
+		c, e := s.CreateSearchConfig(ctx, SearchConfig{
+			Synonyms:  req.Synonyms,
+			Stopwords: req.Stopwords,
+			Note:      req.Note,
+		}, req.Activate)
+		if e != nil {
+			return searchConfigResponse{Config: nil, Error: e}, nil
+		}
+		return searchConfigResponse{Config: &c, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeActivateSearchConfigEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(activateSearchConfigRequest)
+		c, e := s.ActivateSearchConfig(ctx, req.Version)
+		if e != nil {
+			return searchConfigResponse{Config: nil, Error: e}, nil
+		}
+		return searchConfigResponse{Config: &c}, nil
+	}
+}
+
+func MakeReindexEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		e := s.Reindex(ctx)
+		return reindexResponse{Error: e}, nil
+	}
+}
+
+func MakeRulesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rules, e := s.Rules(ctx)
+		if e != nil {
+			return rulesResponse{Rules: make([]Rule, 0), Error: e}, nil
+		}
+		return rulesResponse{Rules: rules}, nil
+	}
+}
+
+func MakeCreateRuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ruleRequest)
+		r, e := s.CreateRule(ctx, req.Rule, req.ChangedBy)
+		if e != nil {
+			return ruleResponse{Rule: nil, Error: e}, nil
+		}
+		return ruleResponse{Rule: &r, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeUpdateRuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ruleRequest)
+		r, e := s.UpdateRule(ctx, req.Rule, req.ChangedBy)
+		if e != nil {
+			return ruleResponse{Rule: nil, Error: e}, nil
+		}
+		return ruleResponse{Rule: &r}, nil
+	}
+}
+
+func MakeDeleteRuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteRuleRequest)
+		e := s.DeleteRule(ctx, req.RuleID, req.ChangedBy)
+		return ruleResponse{Error: e}, nil
+	}
+}
+
+func MakeRuleChangesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ruleChangesRequest)
+		changes, e := s.RuleChanges(ctx, req.RuleID)
+		if e != nil {
+			return ruleChangesResponse{Changes: make([]RuleChange, 0), Error: e}, nil
+		}
+		return ruleChangesResponse{Changes: changes}, nil
+	}
+}
+
+func MakePricesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(pricesRequest)
+		changes, e := s.UpcomingPrices(ctx, req.BookID)
+		if e != nil {
+			return pricesResponse{Changes: make([]PriceChange, 0), Error: e}, nil
+		}
+		return pricesResponse{Changes: changes}, nil
+	}
+}
+
+func MakeSchedulePriceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PriceChange)
+		c, e := s.SchedulePrice(ctx, req)
+		if e != nil {
+			return priceResponse{Error: e}, nil
+		}
+		return priceResponse{Change: &c, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeCancelPriceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cancelPriceRequest)
+		c, e := s.CancelPrice(ctx, req.ID)
+		if e != nil {
+			return priceResponse{Error: e}, nil
+		}
+		return priceResponse{Change: &c}, nil
+	}
+}
+
+func MakePriceListsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(priceListsRequest)
+		lists, e := s.PriceLists(ctx, req.Market)
+		if e != nil {
+			return priceListsResponse{Lists: make([]PriceList, 0), Error: e}, nil
+		}
+		return priceListsResponse{Lists: lists}, nil
+	}
+}
+
+func MakeCreatePriceListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PriceList)
+		l, e := s.CreatePriceList(ctx, req)
+		if e != nil {
+			return priceListResponse{Error: e}, nil
+		}
+		return priceListResponse{List: &l, Status: http.StatusCreated}, nil
+	}
+}
+
 type searchRequest struct {
 	Q string `json:"q"`
 }
 
 type searchResponse struct {
-	Status int    `json:"-"`
-	Books  []Book `json:"books,omitempty"`
-	Error  error  `json:"error,omitempty"`
+	Status     int         `json:"-"`
+	Books      []Book      `json:"books,omitempty"`
+	DidYouMean *Correction `json:"did_you_mean,omitempty"`
+	Error      error       `json:"error,omitempty"`
 }
 
 func (r searchResponse) status() int {
@@ -80,3 +347,204 @@ func (l getResponse) status() int {
 func (r getResponse) error() error {
 	return r.Error
 }
+
+type suggestRequest struct {
+	Q     string `json:"q"`
+	Limit int    `json:"limit"`
+}
+
+type suggestResponse struct {
+	Suggestions []Suggestion `json:"suggestions"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r suggestResponse) error() error {
+	return r.Error
+}
+
+type patchRequest struct {
+	ID    string
+	Match etag.Condition
+	Patch []byte
+}
+
+type ogResponse struct {
+	Metadata *Metadata `json:"og,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r ogResponse) error() error {
+	return r.Error
+}
+
+type searchConfigResponse struct {
+	Status int           `json:"-"`
+	Config *SearchConfig `json:"config,omitempty"`
+	Error  error         `json:"error,omitempty"`
+}
+
+func (r searchConfigResponse) status() int {
+	return r.Status
+}
+
+func (r searchConfigResponse) error() error {
+	return r.Error
+}
+
+type searchConfigsResponse struct {
+	Configs []SearchConfig `json:"configs"`
+	Error   error          `json:"error,omitempty"`
+}
+
+func (r searchConfigsResponse) error() error {
+	return r.Error
+}
+
+type createSearchConfigRequest struct {
+	Synonyms  [][]string `json:"synonyms"`
+	Stopwords []string   `json:"stopwords"`
+	Note      string     `json:"note"`
+	Activate  bool       `json:"activate"`
+}
+
+type activateSearchConfigRequest struct {
+	Version int
+}
+
+type reindexResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r reindexResponse) error() error {
+	return r.Error
+}
+
+type rulesResponse struct {
+	Rules []Rule `json:"rules"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r rulesResponse) error() error {
+	return r.Error
+}
+
+type ruleRequest struct {
+	Rule
+	ChangedBy string `json:"changed_by"`
+}
+
+type ruleResponse struct {
+	Status int   `json:"-"`
+	Rule   *Rule `json:"rule,omitempty"`
+	Error  error `json:"error,omitempty"`
+}
+
+func (r ruleResponse) status() int {
+	return r.Status
+}
+
+func (r ruleResponse) error() error {
+	return r.Error
+}
+
+type deleteRuleRequest struct {
+	RuleID    string
+	ChangedBy string
+}
+
+type ruleChangesRequest struct {
+	RuleID string
+}
+
+type ruleChangesResponse struct {
+	Changes []RuleChange `json:"changes"`
+	Error   error        `json:"error,omitempty"`
+}
+
+func (r ruleChangesResponse) error() error {
+	return r.Error
+}
+
+type pricesRequest struct {
+	BookID string
+}
+
+type pricesResponse struct {
+	Changes []PriceChange `json:"changes"`
+	Error   error         `json:"error,omitempty"`
+}
+
+func (r pricesResponse) error() error {
+	return r.Error
+}
+
+type priceResponse struct {
+	Status int          `json:"-"`
+	Change *PriceChange `json:"change,omitempty"`
+	Error  error        `json:"error,omitempty"`
+}
+
+func (r priceResponse) status() int {
+	return r.Status
+}
+
+func (r priceResponse) error() error {
+	return r.Error
+}
+
+type cancelPriceRequest struct {
+	ID string
+}
+
+type priceListsRequest struct {
+	Market string
+}
+
+type priceListsResponse struct {
+	Lists []PriceList `json:"price_lists"`
+	Error error       `json:"error,omitempty"`
+}
+
+func (r priceListsResponse) error() error {
+	return r.Error
+}
+
+type priceListResponse struct {
+	Status int        `json:"-"`
+	List   *PriceList `json:"price_list,omitempty"`
+	Error  error      `json:"error,omitempty"`
+}
+
+func (r priceListResponse) status() int {
+	return r.Status
+}
+
+func (r priceListResponse) error() error {
+	return r.Error
+}
+
+type listRequest struct {
+	Filter filter.Expr
+	Order  string
+	Limit  int
+	Offset int
+	Count  db.Count
+	URL    *url.URL
+}
+
+type listResponse struct {
+	Books []Book `json:"books"`
+	Error error  `json:"error,omitempty"`
+
+	Total int    `json:"-"`
+	Prev  string `json:"-"`
+	Next  string `json:"-"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+func (r listResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
@@ -0,0 +1,60 @@
+package catalog
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var exportColumns = export.Columns{
+	{Name: "id", Value: func(r interface{}) string { return r.(Book).ID }},
+	{Name: "isbn", Value: func(r interface{}) string { return r.(Book).ISBN }},
+	{Name: "title", Value: func(r interface{}) string { return r.(Book).Title }},
+	{Name: "series", Value: func(r interface{}) string { return r.(Book).Series }},
+	{Name: "publication_year", Value: func(r interface{}) string { return r.(Book).PublicationYear }},
+	{Name: "price", Value: func(r interface{}) string { return strconv.FormatFloat(r.(Book).Price, 'f', 2, 64) }},
+	{Name: "print_on_demand", Value: func(r interface{}) string { return strconv.FormatBool(r.(Book).PrintOnDemand) }},
+	{Name: "vendor_id", Value: func(r interface{}) string { return r.(Book).VendorID }},
+	{Name: "stock", Value: func(r interface{}) string { return strconv.Itoa(r.(Book).Stock) }},
+	{Name: "delisted", Value: func(r interface{}) string { return strconv.FormatBool(r.(Book).Delisted) }},
+	{Name: "updated_at", Value: func(r interface{}) string { return r.(Book).UpdatedAt.UTC().Format(time.RFC3339) }},
+}
+
+// ExportSource exports the admin book list as CSV, filtered and ordered
+// by the same query parameters, to the requests access lets through.
+func ExportSource(s Service, access endpoint.Middleware) export.Source {
+	return export.Source{
+		Name:    "books",
+		Path:    "/catalog/v1/admin/books",
+		Columns: exportColumns,
+		Access:  access,
+		Rows: func(ctx context.Context, query url.Values) (export.Rows, error) {
+			f, err := filter.Parse(query.Get("filter"), ListFields)
+			if err != nil {
+				return nil, err
+			}
+			order, err := filter.Order(query.Get("order"), ListFields)
+			if err != nil {
+				return nil, err
+			}
+			if order == "" {
+				// pages of an export must not overlap.
+				order = "id"
+			}
+			return func(limit, offset int) ([]interface{}, error) {
+				books, _, err := s.List(ctx, f, order, limit, offset, db.CountNone)
+				rows := make([]interface{}, len(books))
+				for i, b := range books {
+					rows[i] = b
+				}
+				return rows, err
+			}, nil
+		},
+	}
+}
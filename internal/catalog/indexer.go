@@ -0,0 +1,29 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunIndexer builds the suggestion index right away and then rebuilds it
+// every interval until ctx is done, so new and changed books show up in
+// the completions.
+func RunIndexer(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	if err := s.Reindex(ctx); err != nil {
+		logger.Log("indexer", "suggest", "err", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reindex(ctx); err != nil {
+				logger.Log("indexer", "suggest", "err", err)
+			}
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type instrmw struct {
@@ -36,14 +39,14 @@ func (mw instrmw) Search(ctx context.Context, query string) (books []Book, err e
 	return
 }
 
-func (mw instrmw) List(ctx context.Context, order string, limit, offset int) (books []Book, total int, err error) {
+func (mw instrmw) List(ctx context.Context, f filter.Expr, order string, limit, offset int, count db.Count) (books []Book, total int, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "search", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	books, total, err = mw.next.List(ctx, order, limit, offset)
+	books, total, err = mw.next.List(ctx, f, order, limit, offset, count)
 	return
 }
 
@@ -57,3 +60,234 @@ func (mw instrmw) Get(ctx context.Context, ID string) (book Book, err error) {
 	book, err = mw.next.Get(ctx, ID)
 	return
 }
+
+func (mw instrmw) Suggest(ctx context.Context, query string, limit int) (suggestions []Suggestion, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "suggest", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	suggestions, err = mw.next.Suggest(ctx, query, limit)
+	return
+}
+
+func (mw instrmw) Reindex(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reindex", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Reindex(ctx)
+	return
+}
+
+func (mw instrmw) DidYouMean(ctx context.Context, query string, found int) (correction *Correction, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "did_you_mean", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	correction, err = mw.next.DidYouMean(ctx, query, found)
+	return
+}
+
+func (mw instrmw) SearchConfig(ctx context.Context) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "search_config", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	config, err = mw.next.SearchConfig(ctx)
+	return
+}
+
+func (mw instrmw) SearchConfigs(ctx context.Context) (configs []SearchConfig, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "search_configs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	configs, err = mw.next.SearchConfigs(ctx)
+	return
+}
+
+func (mw instrmw) CreateSearchConfig(ctx context.Context, c SearchConfig, activate bool) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_search_config", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	config, err = mw.next.CreateSearchConfig(ctx, c, activate)
+	return
+}
+
+func (mw instrmw) ActivateSearchConfig(ctx context.Context, version int) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "activate_search_config", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	config, err = mw.next.ActivateSearchConfig(ctx, version)
+	return
+}
+
+func (mw instrmw) Rules(ctx context.Context) (rules []Rule, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "rules", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rules, err = mw.next.Rules(ctx)
+	return
+}
+
+func (mw instrmw) CreateRule(ctx context.Context, r Rule, by string) (rule Rule, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_rule", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rule, err = mw.next.CreateRule(ctx, r, by)
+	return
+}
+
+func (mw instrmw) UpdateRule(ctx context.Context, r Rule, by string) (rule Rule, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "update_rule", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rule, err = mw.next.UpdateRule(ctx, r, by)
+	return
+}
+
+func (mw instrmw) DeleteRule(ctx context.Context, id, by string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete_rule", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DeleteRule(ctx, id, by)
+	return
+}
+
+func (mw instrmw) RuleChanges(ctx context.Context, ruleID string) (changes []RuleChange, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "rule_changes", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	changes, err = mw.next.RuleChanges(ctx, ruleID)
+	return
+}
+
+func (mw instrmw) OpenGraph(ctx context.Context, id string) (metadata Metadata, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "open_graph", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	metadata, err = mw.next.OpenGraph(ctx, id)
+	return
+}
+
+func (mw instrmw) Patch(ctx context.Context, id string, match etag.Condition, p []byte) (book Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "patch", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	book, err = mw.next.Patch(ctx, id, match, p)
+	return
+}
+
+func (mw instrmw) Publish(ctx context.Context) (books []Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "publish", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, err = mw.next.Publish(ctx)
+	return
+}
+
+func (mw instrmw) SchedulePrice(ctx context.Context, c PriceChange) (change PriceChange, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "schedule_price", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	change, err = mw.next.SchedulePrice(ctx, c)
+	return
+}
+
+func (mw instrmw) CancelPrice(ctx context.Context, id string) (change PriceChange, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "cancel_price", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	change, err = mw.next.CancelPrice(ctx, id)
+	return
+}
+
+func (mw instrmw) UpcomingPrices(ctx context.Context, bookID string) (changes []PriceChange, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "upcoming_prices", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	changes, err = mw.next.UpcomingPrices(ctx, bookID)
+	return
+}
+
+func (mw instrmw) ApplyPrices(ctx context.Context) (changes []PriceChange, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "apply_prices", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	changes, err = mw.next.ApplyPrices(ctx)
+	return
+}
+
+func (mw instrmw) CreatePriceList(ctx context.Context, l PriceList) (list PriceList, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_price_list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.CreatePriceList(ctx, l)
+	return
+}
+
+func (mw instrmw) PriceLists(ctx context.Context, market string) (lists []PriceList, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "price_lists", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	lists, err = mw.next.PriceLists(ctx, market)
+	return
+}
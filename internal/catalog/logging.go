@@ -6,6 +6,9 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type loggingService struct {
@@ -33,7 +36,7 @@ func (s loggingService) Search(ctx context.Context, query string) (books []Book,
 	return s.next.Search(ctx, query)
 }
 
-func (s loggingService) List(ctx context.Context, order string, limit, offset int) (books []Book, total int, err error) {
+func (s loggingService) List(ctx context.Context, f filter.Expr, order string, limit, offset int, count db.Count) (books []Book, total int, err error) {
 	defer func(begin time.Time) {
 		_ = s.logger.Log(
 			"method", "list",
@@ -41,7 +44,7 @@ func (s loggingService) List(ctx context.Context, order string, limit, offset in
 			"took", time.Since(begin),
 		)
 	}(time.Now())
-	return s.next.List(ctx, order, limit, offset)
+	return s.next.List(ctx, f, order, limit, offset, count)
 }
 
 func (s loggingService) Get(ctx context.Context, ID string) (book Book, err error) {
@@ -54,3 +57,241 @@ func (s loggingService) Get(ctx context.Context, ID string) (book Book, err erro
 	}(time.Now())
 	return s.next.Get(ctx, ID)
 }
+
+func (s loggingService) Suggest(ctx context.Context, query string, limit int) (suggestions []Suggestion, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "suggest",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Suggest(ctx, query, limit)
+}
+
+func (s loggingService) Reindex(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reindex",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Reindex(ctx)
+}
+
+func (s loggingService) DidYouMean(ctx context.Context, query string, found int) (correction *Correction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "did_you_mean",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DidYouMean(ctx, query, found)
+}
+
+func (s loggingService) SearchConfig(ctx context.Context) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "search_config",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SearchConfig(ctx)
+}
+
+func (s loggingService) SearchConfigs(ctx context.Context) (configs []SearchConfig, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "search_configs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SearchConfigs(ctx)
+}
+
+func (s loggingService) CreateSearchConfig(ctx context.Context, c SearchConfig, activate bool) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_search_config",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreateSearchConfig(ctx, c, activate)
+}
+
+func (s loggingService) ActivateSearchConfig(ctx context.Context, version int) (config SearchConfig, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "activate_search_config",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ActivateSearchConfig(ctx, version)
+}
+
+func (s loggingService) Rules(ctx context.Context) (rules []Rule, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "rules",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Rules(ctx)
+}
+
+func (s loggingService) CreateRule(ctx context.Context, r Rule, by string) (rule Rule, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_rule",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreateRule(ctx, r, by)
+}
+
+func (s loggingService) UpdateRule(ctx context.Context, r Rule, by string) (rule Rule, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "update_rule",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UpdateRule(ctx, r, by)
+}
+
+func (s loggingService) DeleteRule(ctx context.Context, id, by string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_rule",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeleteRule(ctx, id, by)
+}
+
+func (s loggingService) RuleChanges(ctx context.Context, ruleID string) (changes []RuleChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "rule_changes",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RuleChanges(ctx, ruleID)
+}
+
+func (s loggingService) OpenGraph(ctx context.Context, id string) (metadata Metadata, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "open_graph",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OpenGraph(ctx, id)
+}
+
+func (s loggingService) Patch(ctx context.Context, id string, match etag.Condition, p []byte) (book Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "patch",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Patch(ctx, id, match, p)
+}
+
+func (s loggingService) Publish(ctx context.Context) (books []Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "publish",
+			"changed", len(books),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Publish(ctx)
+}
+
+func (s loggingService) SchedulePrice(ctx context.Context, c PriceChange) (change PriceChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "schedule_price",
+			"book_id", c.BookID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SchedulePrice(ctx, c)
+}
+
+func (s loggingService) CancelPrice(ctx context.Context, id string) (change PriceChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "cancel_price",
+			"id", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CancelPrice(ctx, id)
+}
+
+func (s loggingService) UpcomingPrices(ctx context.Context, bookID string) (changes []PriceChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "upcoming_prices",
+			"book_id", bookID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UpcomingPrices(ctx, bookID)
+}
+
+func (s loggingService) ApplyPrices(ctx context.Context) (changes []PriceChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "apply_prices",
+			"applied", len(changes),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ApplyPrices(ctx)
+}
+
+func (s loggingService) CreatePriceList(ctx context.Context, l PriceList) (list PriceList, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_price_list",
+			"market", l.Market,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreatePriceList(ctx, l)
+}
+
+func (s loggingService) PriceLists(ctx context.Context, market string) (lists []PriceList, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "price_lists",
+			"market", market,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PriceLists(ctx, market)
+}
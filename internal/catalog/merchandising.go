@@ -0,0 +1,203 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrRuleNotFound = errors.New("merchandising rule not found")
+	ErrInvalidRule  = errors.New("invalid merchandising rule")
+	ErrMissingActor = errors.New("changed_by is required")
+)
+
+// Kinds of merchandising rules.
+const (
+	RulePin   = "pin"
+	RuleBoost = "boost"
+)
+
+// Fields a boost rule can match books on.
+const (
+	BoostGenre     = "genre"
+	BoostPublisher = "publisher"
+)
+
+// Actions recorded in the merchandising audit trail.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Rule pins a book to a position, or boosts the books of a genre or
+// publisher, in the search results of a query. Rules with StartsAt or
+// EndsAt only apply within that window, e.g. during award season.
+type Rule struct {
+	ID        string     `json:"id"`
+	Query     string     `json:"query"`
+	Kind      string     `json:"kind"`
+	BookID    string     `json:"book_id,omitempty"`
+	Position  int        `json:"position,omitempty"`
+	Field     string     `json:"field,omitempty"`
+	Value     string     `json:"value,omitempty"`
+	Weight    float64    `json:"weight,omitempty"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (Rule) TableName() string {
+	return "merchandising_rules"
+}
+
+// Validate checks the rule is complete for its kind, and folds its query
+// the way search queries are.
+func (r *Rule) Validate() error {
+	r.Query = fold(r.Query)
+	if r.Query == "" {
+		return ErrInvalidRule
+	}
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return ErrInvalidRule
+	}
+	switch r.Kind {
+	case RulePin:
+		if r.BookID == "" || r.Position < 1 {
+			return ErrInvalidRule
+		}
+	case RuleBoost:
+		if (r.Field != BoostGenre && r.Field != BoostPublisher) || r.Value == "" || r.Weight == 0 {
+			return ErrInvalidRule
+		}
+	default:
+		return ErrInvalidRule
+	}
+	return nil
+}
+
+// ActiveAt tells if the rule applies at t.
+func (r Rule) ActiveAt(t time.Time) bool {
+	if r.StartsAt != nil && t.Before(*r.StartsAt) {
+		return false
+	}
+	if r.EndsAt != nil && !t.Before(*r.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// RuleChange is an entry of the merchandising audit trail, recording who
+// changed a rule and its content before and after.
+type RuleChange struct {
+	ID        string    `json:"id"`
+	RuleID    string    `json:"rule_id"`
+	Action    string    `json:"action"`
+	ChangedBy string    `json:"changed_by"`
+	Before    string    `json:"-" sql:"type:text"`
+	After     string    `json:"-" sql:"type:text"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (RuleChange) TableName() string {
+	return "merchandising_changes"
+}
+
+// MarshalJSON inlines the rule snapshots.
+func (c RuleChange) MarshalJSON() ([]byte, error) {
+	type change RuleChange
+	return json.Marshal(struct {
+		change
+		Before json.RawMessage `json:"before,omitempty"`
+		After  json.RawMessage `json:"after,omitempty"`
+	}{change(c), raw(c.Before), raw(c.After)})
+}
+
+func raw(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+func snapshot(r *Rule) string {
+	if r == nil {
+		return ""
+	}
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Merchandise reranks books with the rules active at t. Boosted books move
+// up by their total weight, ties keep their search order. Pinned books are
+// then put at their position, pinned is used to fetch the ones missing
+// from the results, nil if it isn't found.
+func Merchandise(books []Book, rules []Rule, t time.Time, pinned func(id string) *Book) []Book {
+	var pins []Rule
+	boost := make(map[string]float64)
+	for _, r := range rules {
+		if !r.ActiveAt(t) {
+			continue
+		}
+		switch r.Kind {
+		case RulePin:
+			pins = append(pins, r)
+		case RuleBoost:
+			boost[r.Field+"\x00"+r.Value] += r.Weight
+		}
+	}
+	if len(pins) == 0 && len(boost) == 0 {
+		return books
+	}
+
+	score := func(b Book) float64 {
+		s := boost[BoostPublisher+"\x00"+b.PublisherID]
+		for _, g := range b.Genres {
+			s += boost[BoostGenre+"\x00"+g.ID]
+		}
+		return s
+	}
+	isPinned := make(map[string]bool)
+	for _, p := range pins {
+		isPinned[p.BookID] = true
+	}
+	found := make(map[string]Book)
+	out := make([]Book, 0, len(books)+len(pins))
+	for _, b := range books {
+		if isPinned[b.ID] {
+			found[b.ID] = b
+			continue
+		}
+		out = append(out, b)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return score(out[i]) > score(out[j]) })
+
+	sort.SliceStable(pins, func(i, j int) bool { return pins[i].Position < pins[j].Position })
+	placed := make(map[string]bool)
+	for _, p := range pins {
+		if placed[p.BookID] {
+			continue
+		}
+		b, ok := found[p.BookID]
+		if !ok {
+			f := pinned(p.BookID)
+			if f == nil {
+				continue
+			}
+			b = *f
+		}
+		placed[p.BookID] = true
+		i := p.Position - 1
+		if i > len(out) {
+			i = len(out)
+		}
+		out = append(out, Book{})
+		copy(out[i+1:], out[i:])
+		out[i] = b
+	}
+	return out
+}
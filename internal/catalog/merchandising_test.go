@@ -0,0 +1,108 @@
+package catalog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestMerchandise(t *testing.T) {
+	now := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-48 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	books := []catalog.Book{
+		{ID: "1", PublisherID: "p1"},
+		{ID: "2", PublisherID: "p2", Genres: []catalog.Genre{{ID: "scifi"}}},
+		{ID: "3", PublisherID: "p2"},
+		{ID: "4", PublisherID: "p1"},
+	}
+	rules := []catalog.Rule{
+		{Kind: catalog.RuleBoost, Field: catalog.BoostPublisher, Value: "p2", Weight: 1},
+		{Kind: catalog.RuleBoost, Field: catalog.BoostGenre, Value: "scifi", Weight: 1},
+		{Kind: catalog.RulePin, BookID: "4", Position: 1},
+		{Kind: catalog.RulePin, BookID: "9", Position: 3},
+		{Kind: catalog.RulePin, BookID: "missing", Position: 2},
+		{Kind: catalog.RulePin, BookID: "1", Position: 1, StartsAt: &past, EndsAt: &yesterday},
+	}
+	pinned := func(id string) *catalog.Book {
+		if id == "9" {
+			return &catalog.Book{ID: "9"}
+		}
+		return nil
+	}
+
+	got := catalog.Merchandise(books, rules, now, pinned)
+	expected := []string{"4", "2", "9", "3", "1"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d books, got %+v", len(expected), got)
+	}
+	for i := range got {
+		if got[i].ID != expected[i] {
+			t.Errorf("position %d: expected book %s, got %s", i+1, expected[i], got[i].ID)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	cases := []struct {
+		rule  catalog.Rule
+		valid bool
+	}{
+		{catalog.Rule{Query: "Booker Prize", Kind: catalog.RulePin, BookID: "1", Position: 1}, true},
+		{catalog.Rule{Query: "booker", Kind: catalog.RulePin, BookID: "1"}, false},
+		{catalog.Rule{Query: "booker", Kind: catalog.RuleBoost, Field: catalog.BoostGenre, Value: "g1", Weight: 2}, true},
+		{catalog.Rule{Query: "booker", Kind: catalog.RuleBoost, Field: "author", Value: "a1", Weight: 2}, false},
+		{catalog.Rule{Query: " ", Kind: catalog.RulePin, BookID: "1", Position: 1}, false},
+		{catalog.Rule{Query: "booker", Kind: "hide"}, false},
+	}
+	for _, c := range cases {
+		r := c.rule
+		if err := r.Validate(); (err == nil) != c.valid {
+			t.Errorf("Validate(%+v): expected valid %v, got %v", c.rule, c.valid, err)
+		}
+	}
+	r := catalog.Rule{Query: "  Booker-Prize ", Kind: catalog.RulePin, BookID: "1", Position: 1}
+	r.Validate()
+	if r.Query != "booker prize" {
+		t.Errorf("expected folded query, got %q", r.Query)
+	}
+}
+
+func TestRulesRequireAdmin(t *testing.T) {
+	r := &memRepo{rules: []catalog.Rule{{ID: "r1", Query: "booker", Kind: catalog.RulePin, BookID: "1", Position: 1}}}
+	s := catalog.NewService(r, catalog.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	h := catalog.MakeHTTPHandler(context.Background(), s, rbac.Staff(tokens, user.RoleAdmin), log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "s1", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"scoped admin", scoped, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/catalog/v1/admin/merchandising", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxDescription is the length card descriptions are cut at, unfurlers
+// truncate longer ones on their own anyway.
+const maxDescription = 200
+
+// Config is the storefront information used to build the public links of
+// the catalog.
+type Config struct {
+	StoreURL string // book pages are at StoreURL/books/{id}
+	SiteName string
+	Currency string
+}
+
+// Price is an amount in a currency.
+type Price struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MetaTag is a single <meta> tag. Open Graph tags use property, Twitter
+// card tags use name.
+type MetaTag struct {
+	Property string `json:"property,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Content  string `json:"content"`
+}
+
+// Metadata is the Open Graph and Twitter card preview of a book page.
+type Metadata struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	Image       string    `json:"image,omitempty"`
+	Price       Price     `json:"price"`
+	InStock     bool      `json:"in_stock"`
+	Tags        []MetaTag `json:"tags"`
+}
+
+// OpenGraph returns the preview metadata of b, along with the meta tags
+// ready to be rendered in the page head. The price is in the currency of
+// b, the one of c if not set.
+func OpenGraph(b Book, c Config) Metadata {
+	store := strings.TrimRight(c.StoreURL, "/")
+	if b.Currency == "" {
+		b.Currency = c.Currency
+	}
+	m := Metadata{
+		Title:       b.Title,
+		Description: truncate(description(b), maxDescription),
+		URL:         store + "/books/" + url.PathEscape(b.ID),
+		Image:       absolute(store, b.CoverURL),
+		Price:       Price{Amount: fmt.Sprintf("%.2f", b.Price), Currency: b.Currency},
+		InStock:     b.Stock > 0 || b.PrintOnDemand,
+	}
+
+	availability := "oos"
+	if m.InStock {
+		availability = "instock"
+	}
+	card := "summary"
+	if m.Image != "" {
+		card = "summary_large_image"
+	}
+	og := func(p, v string) {
+		if v != "" {
+			m.Tags = append(m.Tags, MetaTag{Property: p, Content: v})
+		}
+	}
+	tw := func(n, v string) {
+		if v != "" {
+			m.Tags = append(m.Tags, MetaTag{Name: n, Content: v})
+		}
+	}
+	og("og:type", "book")
+	og("og:site_name", c.SiteName)
+	og("og:title", m.Title)
+	og("og:description", m.Description)
+	og("og:url", m.URL)
+	og("og:image", m.Image)
+	og("book:isbn", b.ISBN)
+	og("product:price:amount", m.Price.Amount)
+	og("product:price:currency", m.Price.Currency)
+	og("product:availability", availability)
+	tw("twitter:card", card)
+	tw("twitter:title", m.Title)
+	tw("twitter:description", m.Description)
+	tw("twitter:image", m.Image)
+	return m
+}
+
+// description returns the book description, or a line made of its
+// authors, series and publisher when there is none.
+func description(b Book) string {
+	if d := strings.Join(strings.Fields(b.Description), " "); d != "" {
+		return d
+	}
+	var parts []string
+	if len(b.Authors) > 0 {
+		names := make([]string, 0, len(b.Authors))
+		for _, a := range b.Authors {
+			names = append(names, strings.TrimSpace(a.FirstName+" "+a.LastName))
+		}
+		parts = append(parts, b.Title+" by "+strings.Join(names, ", "))
+	} else {
+		parts = append(parts, b.Title)
+	}
+	if b.Series != "" {
+		parts = append(parts, "Part of "+b.Series)
+	}
+	if b.Publisher != nil && b.Publisher.Name != "" {
+		p := "Published by " + b.Publisher.Name
+		if b.PublicationYear != "" {
+			p += " in " + b.PublicationYear
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ". ") + "."
+}
+
+// truncate cuts s to at most n runes at a word boundary.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	cut := string(r[:n-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// absolute resolves a cover path relative to the store, unfurlers
+// ignore relative image URLs.
+func absolute(store, u string) string {
+	if u == "" || strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	return store + "/" + strings.TrimLeft(u, "/")
+}
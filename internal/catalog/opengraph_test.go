@@ -0,0 +1,59 @@
+package catalog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+func TestOpenGraph(t *testing.T) {
+	c := catalog.Config{StoreURL: "https://shop.example/", SiteName: "Bookshop", Currency: "EUR"}
+	b := catalog.Book{
+		ID:              "b1",
+		ISBN:            "9780261103344",
+		Title:           "The Hobbit",
+		Authors:         []catalog.Author{{FirstName: "J.R.R.", LastName: "Tolkien"}},
+		Publisher:       &catalog.Publisher{Name: "Allen & Unwin"},
+		PublicationYear: "1937",
+		CoverURL:        "/covers/b1.jpg",
+		Price:           9.5,
+	}
+
+	m := catalog.OpenGraph(b, c)
+	if m.URL != "https://shop.example/books/b1" {
+		t.Errorf("url: expected book page, got %q", m.URL)
+	}
+	if m.Image != "https://shop.example/covers/b1.jpg" {
+		t.Errorf("image: expected absolute cover, got %q", m.Image)
+	}
+	expected := "The Hobbit by J.R.R. Tolkien. Published by Allen & Unwin in 1937."
+	if m.Description != expected {
+		t.Errorf("description: expected %q, got %q", expected, m.Description)
+	}
+	if m.Price.Amount != "9.50" || m.InStock {
+		t.Errorf("price: expected 9.50 out of stock, got %+v %v", m.Price, m.InStock)
+	}
+	tags := make(map[string]string)
+	for _, tag := range m.Tags {
+		tags[tag.Property+tag.Name] = tag.Content
+	}
+	if tags["twitter:card"] != "summary_large_image" || tags["product:availability"] != "oos" {
+		t.Errorf("tags: unexpected %v", tags)
+	}
+
+	b.Description = strings.Repeat("word ", 100)
+	b.CoverURL = ""
+	m = catalog.OpenGraph(b, c)
+	if n := len([]rune(m.Description)); n > 200 || !strings.HasSuffix(m.Description, "word…") {
+		t.Errorf("description: expected truncated at a word, got %d runes %q", n, m.Description)
+	}
+	if _, ok := tags["og:image"]; !ok {
+		t.Errorf("tags: expected og:image with a cover")
+	}
+	for _, tag := range m.Tags {
+		if tag.Property == "og:image" {
+			t.Errorf("tags: unexpected og:image without a cover")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// PriceList is the prices of the books in a market, in its own currency
+// rather than converted from the base price. It's in effect from
+// EffectiveFrom until EffectiveTo, if set. The market is the store of the
+// shop context.
+type PriceList struct {
+	ID            string      `json:"id"`
+	Market        string      `json:"market" sql:"index"`
+	Currency      string      `json:"currency"`
+	EffectiveFrom time.Time   `json:"effective_from"`
+	EffectiveTo   *time.Time  `json:"effective_to,omitempty"`
+	CreatedBy     string      `json:"created_by"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Prices        []ListPrice `json:"prices,omitempty" sql:"-"`
+}
+
+func (PriceList) TableName() string {
+	return "catalog_price_lists"
+}
+
+// ListPrice is the price of a book in a price list.
+type ListPrice struct {
+	PriceListID string  `json:"-" sql:"primary_key"`
+	BookID      string  `json:"book_id" sql:"primary_key"`
+	Price       float64 `json:"price"`
+}
+
+func (ListPrice) TableName() string {
+	return "catalog_list_prices"
+}
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate checks the list is complete, upper casing its currency.
+func (l *PriceList) Validate() error {
+	l.Currency = strings.ToUpper(l.Currency)
+	var v validate.Validator
+	v.Required("market", l.Market)
+	v.Check(currencyRe.MatchString(l.Currency), "currency", validate.CodeInvalid, "currency must be an ISO 4217 code")
+	v.Check(!l.EffectiveFrom.IsZero(), "effective_from", validate.CodeRequired, "effective_from is required")
+	if l.EffectiveTo != nil {
+		v.Check(l.EffectiveTo.After(l.EffectiveFrom), "effective_to", validate.CodeOutOfRange, "effective_to must be after effective_from")
+	}
+	if v.Check(len(l.Prices) > 0, "prices", validate.CodeRequired, "prices are required") {
+		seen := make(map[string]bool)
+		for _, p := range l.Prices {
+			if v.Required("prices.book_id", p.BookID) {
+				v.Check(!seen[p.BookID], "prices.book_id", validate.CodeInvalid, "a book is priced once per list")
+			}
+			v.Check(p.Price >= 0, "prices.price", validate.CodeOutOfRange, "price can't be negative")
+			seen[p.BookID] = true
+		}
+	}
+	return v.Err()
+}
+
+// localize prices books in the price list of the market of the shop
+// context in effect at now. The books it doesn't price, and all of them
+// outside a market with a price list, keep their base price in the base
+// currency.
+func (s basicService) localize(ctx context.Context, books []Book, now time.Time) ([]Book, error) {
+	for i := range books {
+		books[i].Currency = s.config.Currency
+	}
+	c, ok := shopctx.FromContext(ctx)
+	if !ok || c.Store == "" || len(books) == 0 {
+		return books, nil
+	}
+	l, err := s.r.PriceListAt(c.Store, now)
+	if err == db.ErrNotFound {
+		return books, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+	prices, err := s.r.ListPrices(l.ID, ids...)
+	if err != nil {
+		return nil, err
+	}
+	byBook := make(map[string]float64, len(prices))
+	for _, p := range prices {
+		byBook[p.BookID] = p.Price
+	}
+	for i, b := range books {
+		if p, ok := byBook[b.ID]; ok {
+			books[i].Price, books[i].Currency = p, l.Currency
+		}
+	}
+	return books, nil
+}
+
+// localizeOne is localize of a single book.
+func (s basicService) localizeOne(ctx context.Context, b Book, now time.Time) (Book, error) {
+	books, err := s.localize(ctx, []Book{b}, now)
+	if err != nil {
+		return Book{}, err
+	}
+	return books[0], nil
+}
@@ -0,0 +1,55 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/shopctx"
+)
+
+func TestRegionalPrice(t *testing.T) {
+	r := &memRepo{
+		books: map[string]catalog.Book{
+			"b1": {ID: "b1", Price: 10, Visibility: catalog.VisibilityLive},
+			"b2": {ID: "b2", Price: 20, Visibility: catalog.VisibilityLive},
+		},
+		list: catalog.PriceList{
+			ID:            "l1",
+			Market:        "uk",
+			Currency:      "GBP",
+			EffectiveFrom: time.Now().Add(-time.Hour),
+			Prices:        []catalog.ListPrice{{PriceListID: "l1", BookID: "b1", Price: 8.5}},
+		},
+	}
+	s := catalog.NewService(r, catalog.Config{Currency: "EUR"})
+	uk := shopctx.NewContext(context.Background(), shopctx.Context{Store: "uk"})
+	fr := shopctx.NewContext(context.Background(), shopctx.Context{Store: "fr"})
+
+	for name, c := range map[string]struct {
+		ctx      context.Context
+		id       string
+		price    float64
+		currency string
+	}{
+		"listed":     {uk, "b1", 8.5, "GBP"},
+		"not listed": {uk, "b2", 20, "EUR"},
+		"no list":    {fr, "b1", 10, "EUR"},
+		"no context": {context.Background(), "b1", 10, "EUR"},
+	} {
+		b, err := s.Get(c.ctx, c.id)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+			continue
+		}
+		if b.Price != c.price || b.Currency != c.currency {
+			t.Errorf("%s: expected %v %s, got %v %s", name, c.price, c.currency, b.Price, b.Currency)
+		}
+	}
+
+	m, err := s.OpenGraph(uk, "b1")
+	if err != nil || m.Price.Amount != "8.50" || m.Price.Currency != "GBP" {
+		t.Errorf("open graph: expected 8.50 GBP, got %+v, %v", m.Price, err)
+	}
+}
@@ -0,0 +1,141 @@
+package catalog
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrPriceChangeNotFound = errors.New("price change not found")
+	ErrPriceOverlap        = errors.New("promotion overlaps another promotion of the book")
+	ErrPriceNotScheduled   = errors.New("price change is not scheduled anymore")
+)
+
+// Kinds of price changes.
+const (
+	PriceKindPrice     = "price"
+	PriceKindPromotion = "promotion"
+)
+
+// Statuses of price changes.
+const (
+	PriceScheduled = "scheduled"
+	// PriceActive is a promotion running until its EndsAt.
+	PriceActive   = "active"
+	PriceApplied  = "applied"
+	PriceEnded    = "ended"
+	PriceCanceled = "canceled"
+)
+
+// PriceChange sets the price of a book at StartsAt. A promotion sets it
+// until EndsAt only, the regular price of the book being restored then.
+type PriceChange struct {
+	ID       string     `json:"id"`
+	BookID   string     `json:"book_id" sql:"index"`
+	Kind     string     `json:"kind"`
+	Price    float64    `json:"price"`
+	StartsAt time.Time  `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Status   string     `json:"status" sql:"index"`
+	// RegularPrice is the price of the book the change replaced, the one a
+	// promotion restores when it ends.
+	RegularPrice float64   `json:"regular_price"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// Version is bumped on every save, a save of an older version fails.
+	Version int `json:"version" sql:"not null;default:0"`
+}
+
+func (PriceChange) TableName() string {
+	return "catalog_price_changes"
+}
+
+// Validate checks the change can be scheduled at now.
+func (c *PriceChange) Validate(now time.Time) error {
+	var v validate.Validator
+	v.Required("book_id", c.BookID)
+	v.Check(c.Price >= 0, "price", validate.CodeOutOfRange, "price can't be negative")
+	v.Check(c.StartsAt.After(now), "starts_at", validate.CodeOutOfRange, "starts_at must be in the future")
+	switch c.Kind {
+	case PriceKindPrice:
+		v.Check(c.EndsAt == nil, "ends_at", validate.CodeInvalid, "only a promotion ends")
+	case PriceKindPromotion:
+		if v.Check(c.EndsAt != nil, "ends_at", validate.CodeRequired, "ends_at is required for a promotion") {
+			v.Check(c.EndsAt.After(c.StartsAt), "ends_at", validate.CodeOutOfRange, "ends_at must be after starts_at")
+		}
+	default:
+		v.Add("kind", validate.CodeInvalid, "kind must be price or promotion")
+	}
+	return v.Err()
+}
+
+// DueAt returns when the change is due next, its start if scheduled or
+// the end of an active promotion. It's zero once the change is done.
+func (c PriceChange) DueAt() time.Time {
+	switch {
+	case c.Status == PriceScheduled:
+		return c.StartsAt
+	case c.Status == PriceActive && c.EndsAt != nil:
+		return *c.EndsAt
+	}
+	return time.Time{}
+}
+
+// overlaps tells whether the promotions c and o run at the same time.
+func (c PriceChange) overlaps(o PriceChange) bool {
+	if c.Kind != PriceKindPromotion || o.Kind != PriceKindPromotion || c.EndsAt == nil || o.EndsAt == nil {
+		return false
+	}
+	return c.StartsAt.Before(*o.EndsAt) && o.StartsAt.Before(*c.EndsAt)
+}
+
+// apply moves c, due at now, on and sets the price of b. A price change
+// during a promotion replaces the regular price of the promotion instead,
+// applied when it ends. A price set by hand during a promotion is kept at
+// its end. It returns whether b changed, and the active promotion to save
+// along with c if it changed.
+func (c *PriceChange) apply(b *Book, active []PriceChange, now time.Time) (bool, *PriceChange) {
+	c.UpdatedAt = now
+	switch c.Status {
+	case PriceScheduled:
+		if c.Kind == PriceKindPromotion {
+			c.RegularPrice, b.Price = b.Price, c.Price
+			c.Status = PriceActive
+			return true, nil
+		}
+		c.Status = PriceApplied
+		for _, p := range active {
+			if p.ID != c.ID && p.Kind == PriceKindPromotion {
+				c.RegularPrice, p.RegularPrice = p.RegularPrice, c.Price
+				p.UpdatedAt = now
+				return false, &p
+			}
+		}
+		c.RegularPrice, b.Price = b.Price, c.Price
+		return true, nil
+	case PriceActive:
+		c.Status = PriceEnded
+		if b.Price != c.Price {
+			return false, nil
+		}
+		b.Price = c.RegularPrice
+		return true, nil
+	}
+	return false, nil
+}
+
+// sortDue orders changes by DueAt, the end of a promotion before the start
+// of the next one at the same time so the latter keeps the regular price.
+func sortDue(changes []PriceChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i].DueAt(), changes[j].DueAt()
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return changes[i].Status == PriceActive && changes[j].Status != PriceActive
+	})
+}
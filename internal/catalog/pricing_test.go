@@ -0,0 +1,73 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+func TestApplyPrices(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+	r := &memRepo{
+		books: map[string]catalog.Book{"b1": {ID: "b1", Price: 5}},
+		changes: map[string]*catalog.PriceChange{
+			// the end of the running promotion restores 10 before 12 is set.
+			"p1": {ID: "p1", BookID: "b1", Kind: catalog.PriceKindPromotion, Price: 5, RegularPrice: 10,
+				StartsAt: *at(-3 * time.Hour), EndsAt: at(-2 * time.Hour), Status: catalog.PriceActive},
+			"c1": {ID: "c1", BookID: "b1", Kind: catalog.PriceKindPrice, Price: 12,
+				StartsAt: *at(-time.Hour), Status: catalog.PriceScheduled},
+			"p2": {ID: "p2", BookID: "b1", Kind: catalog.PriceKindPromotion, Price: 8,
+				StartsAt: *at(-30 * time.Minute), EndsAt: at(time.Hour), Status: catalog.PriceScheduled},
+			// set during p2, it waits for its end.
+			"c2": {ID: "c2", BookID: "b1", Kind: catalog.PriceKindPrice, Price: 15,
+				StartsAt: *at(-10 * time.Minute), Status: catalog.PriceScheduled},
+			"c3": {ID: "c3", BookID: "b1", Kind: catalog.PriceKindPrice, Price: 20,
+				StartsAt: *at(time.Hour), Status: catalog.PriceScheduled},
+		},
+	}
+	s := catalog.NewService(r, catalog.Config{})
+
+	applied, err := s.ApplyPrices(context.Background())
+	if err != nil {
+		t.Fatalf("apply: unexpected error %v", err)
+	}
+	if len(applied) != 4 {
+		t.Errorf("apply: expected 4 changes, got %+v", applied)
+	}
+	if r.books["b1"].Price != 8 {
+		t.Errorf("apply: expected the promotion price 8, got %v", r.books["b1"].Price)
+	}
+	for id, want := range map[string]struct {
+		status  string
+		regular float64
+	}{
+		"p1": {catalog.PriceEnded, 10},
+		"c1": {catalog.PriceApplied, 10},
+		"p2": {catalog.PriceActive, 15},
+		"c2": {catalog.PriceApplied, 12},
+		"c3": {catalog.PriceScheduled, 0},
+	} {
+		if c := r.changes[id]; c.Status != want.status || c.RegularPrice != want.regular {
+			t.Errorf("%s: expected %s at regular price %v, got %s at %v", id, want.status, want.regular, c.Status, c.RegularPrice)
+		}
+	}
+
+	upcoming, _ := s.UpcomingPrices(context.Background(), "b1")
+	if len(upcoming) != 2 || upcoming[0].ID != "p2" || upcoming[1].ID != "c3" {
+		t.Errorf("upcoming: expected p2 then c3, got %+v", upcoming)
+	}
+
+	r.changes["p2"].EndsAt = at(-time.Minute)
+	if _, err := s.ApplyPrices(context.Background()); err != nil {
+		t.Fatalf("end: unexpected error %v", err)
+	}
+	if r.books["b1"].Price != 15 || r.changes["p2"].Status != catalog.PriceEnded {
+		t.Errorf("end: expected the regular price 15, got %v and %s", r.books["b1"].Price, r.changes["p2"].Status)
+	}
+}
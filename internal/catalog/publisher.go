@@ -0,0 +1,28 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunPublisher publishes and retires the books, and applies the price
+// changes, on their schedule every interval until ctx is done.
+func RunPublisher(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Publish(ctx); err != nil {
+				logger.Log("publisher", "catalog", "err", err)
+			}
+			if _, err := s.ApplyPrices(ctx); err != nil {
+				logger.Log("publisher", "prices", "err", err)
+			}
+		}
+	}
+}
@@ -1,13 +1,66 @@
 package catalog
 
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
 // Repo abstracts all the persistant storage operations of Catalog Service
 type Repo interface {
+	// Create and Save record a Change in the change log along with the
+	// book, setting its UpdatedAt. Save bumps the Version of book, it fails
+	// with db.ErrConflict if the stored book has another version.
 	Create(book *Book) error
 	Save(book *Book) error
 	GetByID(ID string) (Book, error)
-	List(order string, limit, offset int) ([]Book, int, error)
+	List(f filter.Expr, order string, limit, offset int, count db.Count) ([]Book, int, error)
+	ListAll() ([]Book, error)
+	// ListDue returns the scheduled and live books due to transition at
+	// now, see Book.Transition.
+	ListDue(now time.Time) ([]Book, error)
 	Search(name string) ([]Book, error)
 	GetByISBN(ISBN string) (Book, error)
 	ListByAuthor(authorID string) ([]Book, error)
+	ListByVendor(vendorID string) ([]Book, error)
+	ActiveSearchConfig() (SearchConfig, error)
+	ListSearchConfigs() ([]SearchConfig, error)
+	CreateSearchConfig(c *SearchConfig) error
+	ActivateSearchConfig(version int) error
+	ListRules() ([]Rule, error)
+	ListRulesByQuery(query string) ([]Rule, error)
+	GetRule(id string) (Rule, error)
+	// CreateRule, SaveRule and DeleteRule record the change along with
+	// the rule, c.After is set by CreateRule once the rule has its ID.
+	CreateRule(r *Rule, c *RuleChange) error
+	SaveRule(r *Rule, c *RuleChange) error
+	DeleteRule(id string, c *RuleChange) error
+	ListRuleChanges(ruleID string) ([]RuleChange, error)
+	// ListChanges returns up to limit changes after the afterSeq change
+	// made no earlier than since, in order.
+	ListChanges(afterSeq int64, since time.Time, limit int) ([]Change, error)
+	CreatePriceChange(c *PriceChange) error
+	GetPriceChange(id string) (PriceChange, error)
+	// ListPriceChanges returns the price changes of any of status of a
+	// book, or of all the books if bookID is empty, by StartsAt.
+	ListPriceChanges(bookID string, status ...string) ([]PriceChange, error)
+	// ListDuePriceChanges returns the scheduled changes starting and the
+	// active promotions ending at now.
+	ListDuePriceChanges(now time.Time) ([]PriceChange, error)
+	// SavePriceChanges saves book, unless nil, and changes in one go,
+	// bumping their Version. It fails with db.ErrConflict if any of them
+	// has another version stored.
+	SavePriceChanges(book *Book, changes ...*PriceChange) error
+	// CreatePriceList creates l along with its prices.
+	CreatePriceList(l *PriceList) error
+	// ListPriceLists returns the lists of a market, or of all the markets
+	// if market is empty, by EffectiveFrom and without their prices.
+	ListPriceLists(market string) ([]PriceList, error)
+	// PriceListAt returns the list of market in effect at at, the latest
+	// effective one if they overlap, without its prices.
+	PriceListAt(market string, at time.Time) (PriceList, error)
+	// ListPrices returns the prices of the books of a list.
+	ListPrices(listID string, bookIDs ...string) ([]ListPrice, error)
 	Drop() error
 }
@@ -0,0 +1,93 @@
+package catalog_test
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+)
+
+// memRepo keeps the books, their price changes, a price list and the
+// merchandising rules in memory for the tests of the service, the other
+// methods of the Repo aren't used.
+type memRepo struct {
+	catalog.Repo
+	books   map[string]catalog.Book
+	changes map[string]*catalog.PriceChange
+	list    catalog.PriceList
+	rules   []catalog.Rule
+}
+
+func (r *memRepo) ListRules() ([]catalog.Rule, error) {
+	return r.rules, nil
+}
+
+func (r *memRepo) GetByID(id string) (catalog.Book, error) {
+	b, ok := r.books[id]
+	if !ok {
+		return catalog.Book{}, db.ErrNotFound
+	}
+	return b, nil
+}
+
+func (r *memRepo) ListPriceChanges(bookID string, status ...string) ([]catalog.PriceChange, error) {
+	var out []catalog.PriceChange
+	for _, c := range r.changes {
+		for _, s := range status {
+			if c.Status == s && (bookID == "" || c.BookID == bookID) {
+				out = append(out, *c)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) ListDuePriceChanges(now time.Time) ([]catalog.PriceChange, error) {
+	var out []catalog.PriceChange
+	for _, c := range r.changes {
+		if due := c.DueAt(); !due.IsZero() && !due.After(now) {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) SavePriceChanges(book *catalog.Book, changes ...*catalog.PriceChange) error {
+	if book != nil && book.Version != r.books[book.ID].Version {
+		return db.ErrConflict
+	}
+	for _, c := range changes {
+		if r.changes[c.ID].Version != c.Version {
+			return db.ErrConflict
+		}
+	}
+	if book != nil {
+		book.Version++
+		r.books[book.ID] = *book
+	}
+	for _, c := range changes {
+		c.Version++
+		saved := *c
+		r.changes[c.ID] = &saved
+	}
+	return nil
+}
+
+func (r *memRepo) PriceListAt(market string, at time.Time) (catalog.PriceList, error) {
+	if market != r.list.Market || at.Before(r.list.EffectiveFrom) {
+		return catalog.PriceList{}, db.ErrNotFound
+	}
+	return r.list, nil
+}
+
+func (r *memRepo) ListPrices(listID string, bookIDs ...string) ([]catalog.ListPrice, error) {
+	var out []catalog.ListPrice
+	for _, p := range r.list.Prices {
+		for _, id := range bookIDs {
+			if p.BookID == id {
+				out = append(out, p)
+			}
+		}
+	}
+	return out, nil
+}
@@ -3,12 +3,24 @@ package catalog
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/validate"
 )
 
 var (
 	ErrBookNotFound = errors.New("book not found")
 )
 
+// FewResults is the number of search results below which a spelling
+// correction is looked up.
+const FewResults = 3
+
 type Service interface {
 	// Search books based on free text
 	Search(ctx context.Context, query string) ([]Book, error)
@@ -16,37 +28,574 @@ type Service interface {
 	// List available items based on limit and offset.
 	// order takes string in the format "name asc" or "name desc"
 	// or in combination of multiple fields like "name asc, isbn desc"
-	List(ctx context.Context, order string, limit, offset int) ([]Book, int, error)
+	// count tells how to compute the total, skipping it saves a COUNT(*).
+	// f filters the books on ListFields.
+	List(ctx context.Context, f filter.Expr, order string, limit, offset int, count db.Count) ([]Book, int, error)
 
 	// Get details about single book
 	Get(ctx context.Context, id string) (Book, error)
+
+	// Patch applies a JSON merge patch to a book, if it matches the
+	// If-Match condition.
+	Patch(ctx context.Context, id string, match etag.Condition, p []byte) (Book, error)
+
+	// OpenGraph returns the Open Graph and Twitter card metadata of a
+	// book page.
+	OpenGraph(ctx context.Context, id string) (Metadata, error)
+
+	// Suggest returns ranked title, author and series completions of
+	// a partially typed query.
+	Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error)
+
+	// DidYouMean returns a spelling corrected query along with its
+	// result count. It returns nil if the correction doesn't find more
+	// than found results.
+	DidYouMean(ctx context.Context, query string, found int) (*Correction, error)
+
+	// Reindex rebuilds the suggestion index from the catalog.
+	Reindex(ctx context.Context) error
+
+	// SearchConfig returns the active synonyms and stopwords config.
+	SearchConfig(ctx context.Context) (SearchConfig, error)
+
+	// SearchConfigs returns all the versions of the search config.
+	SearchConfigs(ctx context.Context) ([]SearchConfig, error)
+
+	// CreateSearchConfig stores a new version of the search config,
+	// activating it right away if activate is set.
+	CreateSearchConfig(ctx context.Context, c SearchConfig, activate bool) (SearchConfig, error)
+
+	// ActivateSearchConfig makes version the active search config and
+	// reindexes with it.
+	ActivateSearchConfig(ctx context.Context, version int) (SearchConfig, error)
+
+	// Rules returns all the merchandising rules.
+	Rules(ctx context.Context) ([]Rule, error)
+
+	// CreateRule adds a pin or boost rule on behalf of by.
+	CreateRule(ctx context.Context, r Rule, by string) (Rule, error)
+
+	// UpdateRule replaces the rule with the same ID on behalf of by.
+	UpdateRule(ctx context.Context, r Rule, by string) (Rule, error)
+
+	// DeleteRule removes a rule on behalf of by.
+	DeleteRule(ctx context.Context, id, by string) error
+
+	// RuleChanges returns the audit trail of a rule, or of all the rules
+	// if ruleID is empty.
+	RuleChanges(ctx context.Context, ruleID string) ([]RuleChange, error)
+
+	// Publish makes the scheduled books due to go live live and retires
+	// the live books due to retire. It returns the books it changed.
+	Publish(ctx context.Context) ([]Book, error)
+
+	// SchedulePrice schedules a price change or a promotion of a book.
+	SchedulePrice(ctx context.Context, c PriceChange) (PriceChange, error)
+
+	// CancelPrice cancels a price change not applied yet.
+	CancelPrice(ctx context.Context, id string) (PriceChange, error)
+
+	// UpcomingPrices returns the scheduled price changes and the running
+	// promotions of a book, or of all the books if bookID is empty, in the
+	// order they are due.
+	UpcomingPrices(ctx context.Context, bookID string) ([]PriceChange, error)
+
+	// ApplyPrices applies the due price changes, starting and ending the
+	// promotions. It returns the changes it applied.
+	ApplyPrices(ctx context.Context) ([]PriceChange, error)
+
+	// CreatePriceList adds the price list of a market.
+	CreatePriceList(ctx context.Context, l PriceList) (PriceList, error)
+
+	// PriceLists returns the price lists of a market, or of all the
+	// markets if market is empty, without their prices.
+	PriceLists(ctx context.Context, market string) ([]PriceList, error)
 }
 
 type basicService struct {
-	r Repo
+	r      Repo
+	index  *Index
+	config Config
 }
 
 // NewCatalogService return basic Service implementation.
-func NewService(r Repo) Service {
-	return basicService{r}
+func NewService(r Repo, config Config) Service {
+	return basicService{r: r, index: NewIndex(), config: config}
 }
 
-// Search return books that matches with query.
+// Search return the live books that matches with query or any of its
+// synonym variants.
 func (s basicService) Search(ctx context.Context, query string) ([]Book, error) {
-	return s.r.Search(query)
+	now := time.Now().UTC()
+	books, err := s.r.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	books = live(books, now)
+	found := make(map[string]bool)
+	for _, b := range books {
+		found[b.ID] = true
+	}
+	for _, v := range s.index.Expand(query) {
+		if v == fold(query) {
+			continue
+		}
+		// Variants are folded, match the words in order with anything,
+		// punctuation or stopwords, between them.
+		more, err := s.r.Search(strings.Replace(v, " ", "%", -1))
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range live(more, now) {
+			if !found[b.ID] {
+				found[b.ID] = true
+				books = append(books, b)
+			}
+		}
+	}
+
+	rules, err := s.r.ListRulesByQuery(fold(query))
+	if err != nil {
+		return nil, err
+	}
+	books = Merchandise(books, rules, now, func(id string) *Book {
+		b, err := s.r.GetByID(id)
+		if err != nil || !b.LiveAt(now) {
+			return nil
+		}
+		return &b
+	})
+	return s.localize(ctx, books, now)
+}
+
+// live returns the books of books live at now.
+func live(books []Book, now time.Time) []Book {
+	res := books[:0]
+	for _, b := range books {
+		if b.LiveAt(now) {
+			res = append(res, b)
+		}
+	}
+	return res
 }
 
-// Get return a book for the matched ID. Empty book incase of non-error.
+// Get return a live book for the matched ID. Empty book incase of
+// non-error.
 func (s basicService) Get(ctx context.Context, ID string) (Book, error) {
-	return s.r.GetByID(ID)
+	now := time.Now().UTC()
+	b, err := s.r.GetByID(ID)
+	if err != nil {
+		return Book{}, err
+	}
+	if !b.LiveAt(now) {
+		return Book{}, ErrBookNotFound
+	}
+	return s.localizeOne(ctx, b, now)
+}
+
+// PatchFields are the JSON names of the book fields Patch can change.
+var PatchFields = []string{
+	"title", "series", "description", "cover_url", "publication_year",
+	"price", "print_on_demand", "stock", "location", "delisted",
+	"visibility", "publish_at", "retire_at",
+}
+
+// Patch applies a JSON merge patch to the PatchFields of a book, which
+// must still be at a version match allows.
+func (s basicService) Patch(ctx context.Context, id string, match etag.Condition, p []byte) (Book, error) {
+	b, err := s.r.GetByID(id)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return Book{}, ErrBookNotFound
+		}
+		return Book{}, err
+	}
+	if err := match.Check(b.Version); err != nil {
+		return Book{}, err
+	}
+	if err := patch.Apply(&b, p, PatchFields...); err != nil {
+		return Book{}, err
+	}
+	var v validate.Validator
+	v.Required("title", b.Title)
+	v.Check(b.Price >= 0, "price", validate.CodeOutOfRange, "price can't be negative")
+	v.Check(b.Stock >= 0, "stock", validate.CodeOutOfRange, "stock can't be negative")
+	v.MaxLength("location", b.Location, 20)
+	if v.Check(ValidVisibility(b.Visibility), "visibility", validate.CodeInvalid, "visibility must be draft, scheduled, live or retired") &&
+		b.Visibility == VisibilityScheduled {
+		v.Check(b.PublishAt != nil, "publish_at", validate.CodeRequired, "publish_at is required to schedule a book")
+	}
+	if b.PublishAt != nil && b.RetireAt != nil {
+		v.Check(b.RetireAt.After(*b.PublishAt), "retire_at", validate.CodeOutOfRange, "retire_at must be after publish_at")
+	}
+	if err := v.Err(); err != nil {
+		return Book{}, err
+	}
+	if err := s.r.Save(&b); err != nil {
+		if err == db.ErrConflict {
+			return Book{}, etag.ErrPreconditionFailed
+		}
+		return Book{}, err
+	}
+	return b, nil
 }
 
 // List available items based on limit and offset.
 // order takes string in the format "name asc" or "name desc"
 // or in combination of multiple fields like "name asc, isbn desc"
 // List return all the books in the system
-func (s basicService) List(ctx context.Context, order string, limit, offset int) ([]Book, int, error) {
-	return s.r.List(order, limit, offset)
+func (s basicService) List(ctx context.Context, f filter.Expr, order string, limit, offset int, count db.Count) ([]Book, int, error) {
+	return s.r.List(f, order, limit, offset, count)
+}
+
+// OpenGraph returns the preview metadata of a book page.
+func (s basicService) OpenGraph(ctx context.Context, id string) (Metadata, error) {
+	b, err := s.r.GetByID(id)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return Metadata{}, ErrBookNotFound
+		}
+		return Metadata{}, err
+	}
+	now := time.Now().UTC()
+	if !b.LiveAt(now) {
+		return Metadata{}, ErrBookNotFound
+	}
+	if b, err = s.localizeOne(ctx, b, now); err != nil {
+		return Metadata{}, err
+	}
+	return OpenGraph(b, s.config), nil
+}
+
+// Suggest returns ranked completions of query from the suggestion index.
+// It never touches the database, the index is kept fresh by Reindex.
+func (s basicService) Suggest(ctx context.Context, query string, limit int) ([]Suggestion, error) {
+	return s.index.Suggest(query, limit), nil
+}
+
+// DidYouMean corrects query against the indexed titles and authors.
+func (s basicService) DidYouMean(ctx context.Context, query string, found int) (*Correction, error) {
+	q, ok := s.index.Correct(query)
+	if !ok {
+		return nil, nil
+	}
+	books, err := s.r.Search(q)
+	if err != nil {
+		return nil, err
+	}
+	books = live(books, time.Now().UTC())
+	if len(books) <= found {
+		return nil, nil
+	}
+	return &Correction{Query: q, Results: len(books)}, nil
+}
+
+// Reindex rebuilds the suggestion index from all the live books in the
+// catalog with the active search config.
+func (s basicService) Reindex(ctx context.Context) error {
+	c, err := s.r.ActiveSearchConfig()
+	if err != nil && err != db.ErrNotFound {
+		return err
+	}
+	books, err := s.r.ListAll()
+	if err != nil {
+		return err
+	}
+	s.index.Build(live(books, time.Now().UTC()), c)
+	return nil
+}
+
+// SearchConfig returns the active search config.
+func (s basicService) SearchConfig(ctx context.Context) (SearchConfig, error) {
+	c, err := s.r.ActiveSearchConfig()
+	if err == db.ErrNotFound {
+		return SearchConfig{}, ErrConfigNotFound
+	}
+	return c, err
+}
+
+// SearchConfigs returns all the versions of the search config, latest first.
+func (s basicService) SearchConfigs(ctx context.Context) ([]SearchConfig, error) {
+	return s.r.ListSearchConfigs()
+}
+
+// CreateSearchConfig stores c as a new version of the search config.
+func (s basicService) CreateSearchConfig(ctx context.Context, c SearchConfig, activate bool) (SearchConfig, error) {
+	if err := c.Normalize(); err != nil {
+		return SearchConfig{}, err
+	}
+	c.Version = 0
+	c.Active = false
+	c.CreatedAt = time.Now().UTC()
+	if err := s.r.CreateSearchConfig(&c); err != nil {
+		return SearchConfig{}, err
+	}
+	if !activate {
+		return c, nil
+	}
+	return s.ActivateSearchConfig(ctx, c.Version)
+}
+
+// ActivateSearchConfig activates version, an older version rolls the
+// config back. The index of this instance is rebuilt right away, others
+// pick it up on their next reindex.
+func (s basicService) ActivateSearchConfig(ctx context.Context, version int) (SearchConfig, error) {
+	if err := s.r.ActivateSearchConfig(version); err != nil {
+		if err == db.ErrNotFound {
+			return SearchConfig{}, ErrConfigNotFound
+		}
+		return SearchConfig{}, err
+	}
+	if err := s.Reindex(ctx); err != nil {
+		return SearchConfig{}, err
+	}
+	return s.r.ActiveSearchConfig()
+}
+
+// Rules returns all the merchandising rules.
+func (s basicService) Rules(ctx context.Context) ([]Rule, error) {
+	return s.r.ListRules()
+}
+
+// CreateRule adds a merchandising rule, recording it in the audit trail.
+func (s basicService) CreateRule(ctx context.Context, r Rule, by string) (Rule, error) {
+	if by == "" {
+		return Rule{}, ErrMissingActor
+	}
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	now := time.Now().UTC()
+	r.ID = ""
+	r.CreatedBy = by
+	r.CreatedAt, r.UpdatedAt = now, now
+	c := RuleChange{Action: ActionCreate, ChangedBy: by, ChangedAt: now}
+	if err := s.r.CreateRule(&r, &c); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+// UpdateRule replaces a merchandising rule, recording both versions in
+// the audit trail.
+func (s basicService) UpdateRule(ctx context.Context, r Rule, by string) (Rule, error) {
+	if by == "" {
+		return Rule{}, ErrMissingActor
+	}
+	old, err := s.r.GetRule(r.ID)
+	if err != nil {
+		return Rule{}, ErrRuleNotFound
+	}
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	r.CreatedBy, r.CreatedAt = old.CreatedBy, old.CreatedAt
+	r.UpdatedAt = time.Now().UTC()
+	c := RuleChange{
+		RuleID:    r.ID,
+		Action:    ActionUpdate,
+		ChangedBy: by,
+		Before:    snapshot(&old),
+		After:     snapshot(&r),
+		ChangedAt: r.UpdatedAt,
+	}
+	if err := s.r.SaveRule(&r, &c); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+// DeleteRule removes a merchandising rule, keeping its last version in
+// the audit trail.
+func (s basicService) DeleteRule(ctx context.Context, id, by string) error {
+	if by == "" {
+		return ErrMissingActor
+	}
+	old, err := s.r.GetRule(id)
+	if err != nil {
+		return ErrRuleNotFound
+	}
+	c := RuleChange{
+		RuleID:    id,
+		Action:    ActionDelete,
+		ChangedBy: by,
+		Before:    snapshot(&old),
+		ChangedAt: time.Now().UTC(),
+	}
+	return s.r.DeleteRule(id, &c)
+}
+
+// RuleChanges returns the merchandising audit trail, latest first.
+func (s basicService) RuleChanges(ctx context.Context, ruleID string) ([]RuleChange, error) {
+	return s.r.ListRuleChanges(ruleID)
+}
+
+// Publish saves the due transitions of the books. A book changed meanwhile
+// is left for the next run.
+func (s basicService) Publish(ctx context.Context) ([]Book, error) {
+	now := time.Now().UTC()
+	due, err := s.r.ListDue(now)
+	if err != nil {
+		return nil, err
+	}
+	changed := make([]Book, 0, len(due))
+	for _, b := range due {
+		if err := ctx.Err(); err != nil {
+			return changed, err
+		}
+		v := b.Transition(now)
+		if v == b.Visibility {
+			continue
+		}
+		b.Visibility = v
+		if err := s.r.Save(&b); err != nil {
+			if err == db.ErrConflict {
+				continue
+			}
+			return changed, err
+		}
+		changed = append(changed, b)
+	}
+	return changed, nil
+}
+
+// SchedulePrice rejects a promotion overlapping another one of the book,
+// the regular price to restore would be ambiguous.
+func (s basicService) SchedulePrice(ctx context.Context, c PriceChange) (PriceChange, error) {
+	if c.CreatedBy == "" {
+		return PriceChange{}, ErrMissingActor
+	}
+	now := time.Now().UTC()
+	if err := c.Validate(now); err != nil {
+		return PriceChange{}, err
+	}
+	if _, err := s.r.GetByID(c.BookID); err != nil {
+		if err == db.ErrNotFound {
+			return PriceChange{}, ErrBookNotFound
+		}
+		return PriceChange{}, err
+	}
+	upcoming, err := s.r.ListPriceChanges(c.BookID, PriceScheduled, PriceActive)
+	if err != nil {
+		return PriceChange{}, err
+	}
+	for _, o := range upcoming {
+		if c.overlaps(o) {
+			return PriceChange{}, ErrPriceOverlap
+		}
+	}
+	c.ID = ""
+	c.Status = PriceScheduled
+	c.RegularPrice = 0
+	c.CreatedAt, c.UpdatedAt = now, now
+	c.Version = 0
+	if err := s.r.CreatePriceChange(&c); err != nil {
+		return PriceChange{}, err
+	}
+	return c, nil
+}
+
+// CancelPrice leaves the running promotions be, they end on schedule.
+func (s basicService) CancelPrice(ctx context.Context, id string) (PriceChange, error) {
+	c, err := s.r.GetPriceChange(id)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return PriceChange{}, ErrPriceChangeNotFound
+		}
+		return PriceChange{}, err
+	}
+	if c.Status != PriceScheduled {
+		return PriceChange{}, ErrPriceNotScheduled
+	}
+	c.Status = PriceCanceled
+	c.UpdatedAt = time.Now().UTC()
+	if err := s.r.SavePriceChanges(nil, &c); err != nil {
+		if err == db.ErrConflict {
+			// applied meanwhile.
+			return PriceChange{}, ErrPriceNotScheduled
+		}
+		return PriceChange{}, err
+	}
+	return c, nil
+}
+
+func (s basicService) UpcomingPrices(ctx context.Context, bookID string) ([]PriceChange, error) {
+	changes, err := s.r.ListPriceChanges(bookID, PriceScheduled, PriceActive)
+	if err != nil {
+		return nil, err
+	}
+	sortDue(changes)
+	return changes, nil
+}
+
+// ApplyPrices saves every due change along with its book in one go, the
+// price and the status of the change can't disagree. A change of which
+// the book changed meanwhile is left for the next run.
+func (s basicService) ApplyPrices(ctx context.Context) ([]PriceChange, error) {
+	now := time.Now().UTC()
+	due, err := s.r.ListDuePriceChanges(now)
+	if err != nil {
+		return nil, err
+	}
+	sortDue(due)
+	applied := make([]PriceChange, 0, len(due))
+	for _, c := range due {
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+		b, err := s.r.GetByID(c.BookID)
+		if err == db.ErrNotFound {
+			c.Status, c.UpdatedAt = PriceCanceled, now
+			if err := s.r.SavePriceChanges(nil, &c); err != nil && err != db.ErrConflict {
+				return applied, err
+			}
+			continue
+		}
+		if err != nil {
+			return applied, err
+		}
+		active, err := s.r.ListPriceChanges(c.BookID, PriceActive)
+		if err != nil {
+			return applied, err
+		}
+		changes := []*PriceChange{&c}
+		changed, p := c.apply(&b, active, now)
+		if p != nil {
+			changes = append(changes, p)
+		}
+		book := &b
+		if !changed {
+			book = nil
+		}
+		if err := s.r.SavePriceChanges(book, changes...); err != nil {
+			if err == db.ErrConflict {
+				continue
+			}
+			return applied, err
+		}
+		applied = append(applied, c)
+	}
+	return applied, nil
+}
+
+// CreatePriceList stores the list along with its prices.
+func (s basicService) CreatePriceList(ctx context.Context, l PriceList) (PriceList, error) {
+	if l.CreatedBy == "" {
+		return PriceList{}, ErrMissingActor
+	}
+	if err := l.Validate(); err != nil {
+		return PriceList{}, err
+	}
+	l.ID = ""
+	l.CreatedAt = time.Now().UTC()
+	if err := s.r.CreatePriceList(&l); err != nil {
+		return PriceList{}, err
+	}
+	return l, nil
+}
+
+func (s basicService) PriceLists(ctx context.Context, market string) ([]PriceList, error) {
+	return s.r.ListPriceLists(market)
 }
 
 // Middleware is a service middleware that takes service return service
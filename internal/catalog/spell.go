@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Correction is a spelling corrected query suggested for a search with
+// few or no results.
+type Correction struct {
+	Query   string `json:"query"`
+	Results int    `json:"results"`
+}
+
+// Correct replaces the words of query missing from the indexed titles and
+// authors with their closest known word, stopwords are left alone. Short words tolerate one edit,
+// longer ones two. It returns false if there's nothing to correct.
+func (x *Index) Correct(query string) (string, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	words := strings.Fields(fold(query))
+	corrected := false
+	for i, w := range words {
+		if _, ok := x.words[w]; ok || x.analyzer.stopwords[w] {
+			continue
+		}
+		if c, ok := x.closest(w); ok {
+			words[i] = c
+			corrected = true
+		}
+	}
+	if !corrected {
+		return "", false
+	}
+	return strings.Join(words, " "), true
+}
+
+// closest returns the known word with the least edits from w, the most
+// used one on ties.
+func (x *Index) closest(w string) (string, bool) {
+	n := utf8.RuneCountInString(w)
+	max := 2
+	if n <= 4 {
+		max = 1
+	}
+	best, bestDist, bestFreq := "", max+1, 0
+	for k, freq := range x.words {
+		if d := utf8.RuneCountInString(k) - n; d > max || -d > max {
+			continue
+		}
+		d := distance(w, k, max)
+		if d > max {
+			continue
+		}
+		if d < bestDist || (d == bestDist && (freq > bestFreq || freq == bestFreq && k < best)) {
+			best, bestDist, bestFreq = k, d, freq
+		}
+	}
+	return best, best != ""
+}
+
+// distance returns the Damerau-Levenshtein (optimal string alignment)
+// distance of a and b, or max+1 as soon as it's known to exceed max.
+func distance(a, b string, max int) int {
+	s, t := []rune(a), []rune(b)
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && prev2[j-2]+1 < cur[j] {
+				cur[j] = prev2[j-2] + 1
+			}
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,36 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+func TestIndexCorrect(t *testing.T) {
+	x := catalog.NewIndex()
+	x.Build([]catalog.Book{
+		{ID: "1", Title: "Harry Potter and the Philosopher's Stone", Authors: []catalog.Author{{FirstName: "J.K.", LastName: "Rowling"}}},
+		{ID: "2", Title: "The Hobbit"},
+		{ID: "3", Title: "Dune", Series: "Dune Chronicles"},
+	}, catalog.SearchConfig{})
+
+	cases := []struct {
+		q         string
+		expected  string
+		corrected bool
+	}{
+		{"hary poter", "harry potter", true},
+		{"philsopher stoen", "philosophers stone", true},
+		{"rowlnig", "rowling", true},
+		{"hobit", "hobbit", true},
+		{"the hobbit", "", false},
+		{"dune chronicels", "", false},
+		{"zzzzzz", "", false},
+	}
+	for _, c := range cases {
+		got, ok := x.Correct(c.q)
+		if ok != c.corrected || got != c.expected {
+			t.Errorf("Correct(%q): expected %q %v, got %q %v", c.q, c.expected, c.corrected, got, ok)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Kinds of completions returned by the suggester.
+const (
+	SuggestTitle  = "title"
+	SuggestAuthor = "author"
+	SuggestSeries = "series"
+)
+
+// Suggestion is a single typeahead completion.
+type Suggestion struct {
+	Text   string `json:"text"`
+	Kind   string `json:"kind"`
+	BookID string `json:"book_id,omitempty"`
+	Books  int    `json:"books"`
+}
+
+type suggestKey struct {
+	key   string
+	full  bool // key is the start of the text, not one of its inner words
+	entry int
+}
+
+// Index is an in-memory prefix index of titles, authors and series.
+// Every word start of a completion is indexed, so "rings" completes
+// "The Lord of the Rings". The index is replaced as a whole on Build,
+// lookups never see a partially built index.
+type Index struct {
+	mu       sync.RWMutex
+	entries  []Suggestion
+	keys     []suggestKey
+	words    map[string]int
+	analyzer analyzer
+	version  int
+}
+
+// NewIndex returns an empty suggestion index.
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Build replaces the index content with the completions of books, analyzed
+// with the search config c. Stopwords are only indexed at the start of
+// a completion.
+func (x *Index) Build(books []Book, c SearchConfig) {
+	a := newAnalyzer(c)
+
+	var entries []Suggestion
+	seen := make(map[string]int)
+	add := func(kind, text, bookID string) {
+		text = strings.Join(strings.Fields(text), " ")
+		if fold(text) == "" {
+			return
+		}
+		id := kind + "\x00" + fold(text)
+		if i, ok := seen[id]; ok {
+			entries[i].Books++
+			// Same title of different editions, don't point to either.
+			entries[i].BookID = ""
+			return
+		}
+		seen[id] = len(entries)
+		entries = append(entries, Suggestion{Text: text, Kind: kind, BookID: bookID, Books: 1})
+	}
+	for _, b := range books {
+		add(SuggestTitle, b.Title, b.ID)
+		add(SuggestSeries, b.Series, "")
+		for _, a := range b.Authors {
+			add(SuggestAuthor, a.FirstName+" "+a.LastName, "")
+		}
+	}
+
+	var keys []suggestKey
+	vocabulary := make(map[string]int)
+	for i, e := range entries {
+		words := strings.Fields(fold(e.Text))
+		for j := range words {
+			if a.stopwords[words[j]] {
+				if j > 0 {
+					continue
+				}
+			} else if e.Kind != SuggestSeries {
+				vocabulary[words[j]] += e.Books
+			}
+			keys = append(keys, suggestKey{
+				key:   strings.Join(words[j:], " "),
+				full:  j == 0,
+				entry: i,
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+
+	x.mu.Lock()
+	x.entries, x.keys, x.words = entries, keys, vocabulary
+	x.analyzer, x.version = a, c.Version
+	x.mu.Unlock()
+}
+
+// Version returns the version of the search config the index is built with.
+func (x *Index) Version() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.version
+}
+
+// Expand returns query along with its synonym variants, stopwords removed.
+func (x *Index) Expand(query string) []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.analyzer.expand(query)
+}
+
+// Len returns the number of completions in the index.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Suggest returns up to limit completions of prefix. Completions starting
+// with prefix rank before the ones matching an inner word, then the ones
+// shared by more books, then the shorter ones.
+func (x *Index) Suggest(prefix string, limit int) []Suggestion {
+	p := fold(prefix)
+	if p == "" || limit <= 0 {
+		return []Suggestion{}
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	type match struct {
+		entry int
+		full  bool
+	}
+	found := make(map[int]int)
+	var matches []match
+	i := sort.Search(len(x.keys), func(i int) bool { return x.keys[i].key >= p })
+	for ; i < len(x.keys) && strings.HasPrefix(x.keys[i].key, p); i++ {
+		k := x.keys[i]
+		if j, ok := found[k.entry]; ok {
+			matches[j].full = matches[j].full || k.full
+			continue
+		}
+		found[k.entry] = len(matches)
+		matches = append(matches, match{entry: k.entry, full: k.full})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.full != b.full {
+			return a.full
+		}
+		ea, eb := x.entries[a.entry], x.entries[b.entry]
+		if ea.Books != eb.Books {
+			return ea.Books > eb.Books
+		}
+		if len(ea.Text) != len(eb.Text) {
+			return len(ea.Text) < len(eb.Text)
+		}
+		return ea.Text < eb.Text
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	out := make([]Suggestion, len(matches))
+	for i, m := range matches {
+		out[i] = x.entries[m.entry]
+	}
+	return out
+}
+
+// fold lower cases s and replaces punctuation with spaces, so "Harry
+// Potter: Vol. 1" and "harry potter vol 1" give the same key.
+func fold(s string) string {
+	f := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		if r == '\'' {
+			return -1
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(f), " ")
+}
@@ -0,0 +1,91 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+func TestIndexSuggest(t *testing.T) {
+	tolkien := catalog.Author{FirstName: "J.R.R.", LastName: "Tolkien"}
+	x := catalog.NewIndex()
+	x.Build([]catalog.Book{
+		{ID: "1", Title: "The Fellowship of the Ring", Series: "The Lord of the Rings", Authors: []catalog.Author{tolkien}},
+		{ID: "2", Title: "The Two Towers", Series: "The Lord of the Rings", Authors: []catalog.Author{tolkien}},
+		{ID: "3", Title: "The Hobbit", Authors: []catalog.Author{tolkien}},
+		{ID: "4", Title: "Lord of the Flies"},
+		{ID: "5", Title: "Lord of the Flies"},
+	}, catalog.SearchConfig{})
+
+	cases := []struct {
+		q        string
+		expected []string
+	}{
+		{"lord", []string{"Lord of the Flies", "The Lord of the Rings"}},
+		{"LORD OF THE F", []string{"Lord of the Flies"}},
+		{"tolk", []string{"J.R.R. Tolkien"}},
+		{"the", []string{"The Lord of the Rings", "The Hobbit", "The Two Towers", "The Fellowship of the Ring", "Lord of the Flies"}},
+		{"  ", []string{}},
+		{"xyz", []string{}},
+	}
+	for _, c := range cases {
+		got := x.Suggest(c.q, 10)
+		if len(got) != len(c.expected) {
+			t.Errorf("Suggest(%q): expected %d, got %+v", c.q, len(c.expected), got)
+			continue
+		}
+		for i := range got {
+			if got[i].Text != c.expected[i] {
+				t.Errorf("Suggest(%q)[%d]: expected %q, got %q", c.q, i, c.expected[i], got[i].Text)
+			}
+		}
+	}
+
+	flies := x.Suggest("flies", 1)
+	if flies[0].Books != 2 || flies[0].BookID != "" {
+		t.Errorf("expected title shared by two books without book id, got %+v", flies[0])
+	}
+	if got := x.Suggest("the", 2); len(got) != 2 {
+		t.Errorf("expected limit of 2, got %d", len(got))
+	}
+}
+
+func TestIndexSearchConfig(t *testing.T) {
+	c := catalog.SearchConfig{
+		Synonyms:  [][]string{{"Sci-Fi", "science fiction", "SF"}},
+		Stopwords: []string{"the", "of", "THE"},
+	}
+	if err := c.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Stopwords) != 2 || c.Synonyms[0][0] != "sci fi" {
+		t.Fatalf("unexpected normalized config %+v", c)
+	}
+	if err := (&catalog.SearchConfig{Synonyms: [][]string{{"sf", "SF"}}}).Normalize(); err != catalog.ErrInvalidSynonyms {
+		t.Errorf("expected %v, got %v", catalog.ErrInvalidSynonyms, err)
+	}
+
+	x := catalog.NewIndex()
+	x.Build([]catalog.Book{
+		{ID: "1", Title: "The Lord of the Rings"},
+		{ID: "2", Title: "Best Sci-Fi of the Year"},
+	}, c)
+
+	if got := x.Suggest("the", 10); len(got) != 1 || got[0].Text != "The Lord of the Rings" {
+		t.Errorf("expected stopwords to only complete at the start, got %+v", got)
+	}
+	if got, ok := x.Correct("lord of teh rings"); ok {
+		t.Errorf("expected stopwords to stay out of the vocabulary, got %q", got)
+	}
+
+	expected := []string{"best sci fi year", "best science fiction year", "best sf year"}
+	got := x.Expand("Best Sci-Fi of the Year")
+	if len(got) != len(expected) {
+		t.Fatalf("Expand: expected %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Expand[%d]: expected %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
@@ -3,26 +3,67 @@ package catalog
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"context"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
 	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
 	"github.com/pkg/errors"
 )
 
 var (
 	ErrEmptyQuery = errors.New("empty query")
 	ErrBadRouting = errors.New("bad routing")
+	ErrBadLimit   = errors.New("bad limit")
+	ErrBadVersion = errors.New("bad config version")
 )
 
-func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
-	e := MakeEndpoints(s)
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+	defaultPageLimit    = 20
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrBookNotFound: "catalog.book_not_found",
+		ErrEmptyQuery:   "catalog.empty_query",
+		ErrBadLimit:     "catalog.bad_limit",
+
+		ErrPriceChangeNotFound: "catalog.price_change_not_found",
+		ErrPriceOverlap:        "catalog.price_overlap",
+		ErrPriceNotScheduled:   "catalog.price_not_scheduled",
+	})
+}
+
+// MakeHTTPHandler mounts the catalog endpoints, the shop admin ones served to
+// the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
 	}
 	searchHandler := httptransport.NewServer(
 		e.SearchEndpoint,
@@ -33,14 +74,152 @@ func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Han
 	getHandler := httptransport.NewServer(
 		e.GetEndpoint,
 		decodeGetRequest,
+		encodeBookResponse,
+		options...,
+	)
+	suggestHandler := httptransport.NewServer(
+		e.SuggestEndpoint,
+		decodeSuggestRequest,
+		encodeResponse,
+		options...,
+	)
+	ogHandler := httptransport.NewServer(
+		e.OGEndpoint,
+		decodeGetRequest,
+		encodeResponse,
+		options...,
+	)
+	patchHandler := httptransport.NewServer(
+		e.PatchEndpoint,
+		decodePatchRequest,
+		encodeBookResponse,
+		options...,
+	)
+	searchConfigHandler := httptransport.NewServer(
+		e.SearchConfigEndpoint,
+		decodeEmptyRequest,
 		encodeResponse,
 		options...,
 	)
+	searchConfigsHandler := httptransport.NewServer(
+		e.SearchConfigsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	createSearchConfigHandler := httptransport.NewServer(
+		e.CreateSearchConfigEndpoint,
+		decodeCreateSearchConfigRequest,
+		encodeResponse,
+		options...,
+	)
+	activateSearchConfigHandler := httptransport.NewServer(
+		e.ActivateSearchConfigEndpoint,
+		decodeActivateSearchConfigRequest,
+		encodeResponse,
+		options...,
+	)
+	reindexHandler := httptransport.NewServer(
+		e.ReindexEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	rulesHandler := httptransport.NewServer(
+		e.RulesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	createRuleHandler := httptransport.NewServer(
+		e.CreateRuleEndpoint,
+		decodeCreateRuleRequest,
+		encodeResponse,
+		options...,
+	)
+	updateRuleHandler := httptransport.NewServer(
+		e.UpdateRuleEndpoint,
+		decodeUpdateRuleRequest,
+		encodeResponse,
+		options...,
+	)
+	deleteRuleHandler := httptransport.NewServer(
+		e.DeleteRuleEndpoint,
+		decodeDeleteRuleRequest,
+		encodeResponse,
+		options...,
+	)
+	ruleChangesHandler := httptransport.NewServer(
+		e.RuleChangesEndpoint,
+		decodeRuleChangesRequest,
+		encodeResponse,
+		options...,
+	)
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeListRequest,
+		encodeResponse,
+		options...,
+	)
+	pricesHandler := httptransport.NewServer(
+		e.PricesEndpoint,
+		decodePricesRequest,
+		encodeResponse,
+		options...,
+	)
+	schedulePriceHandler := httptransport.NewServer(
+		e.SchedulePriceEndpoint,
+		decodeSchedulePriceRequest,
+		encodeResponse,
+		options...,
+	)
+	cancelPriceHandler := httptransport.NewServer(
+		e.CancelPriceEndpoint,
+		decodeCancelPriceRequest,
+		encodeResponse,
+		options...,
+	)
+	priceListsHandler := httptransport.NewServer(
+		e.PriceListsEndpoint,
+		decodePriceListsRequest,
+		encodeResponse,
+		options...,
+	)
+	createPriceListHandler := httptransport.NewServer(
+		e.CreatePriceListEndpoint,
+		decodeCreatePriceListRequest,
+		encodeResponse,
+		options...,
+	)
+
 	r := mux.NewRouter()
 
+	// Shop admin endpoints
+	r.Handle("/catalog/v1/admin/search/config", searchConfigHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/search/configs", searchConfigsHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/search/configs", createSearchConfigHandler).Methods("POST")
+	r.Handle("/catalog/v1/admin/search/configs/{version}/activate", activateSearchConfigHandler).Methods("POST")
+	r.Handle("/catalog/v1/admin/search/reindex", reindexHandler).Methods("POST")
+	r.Handle("/catalog/v1/admin/books", listHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/books/{id}", patchHandler).Methods("PATCH")
+	r.Handle("/catalog/v1/admin/merchandising", rulesHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/merchandising", createRuleHandler).Methods("POST")
+	r.Handle("/catalog/v1/admin/merchandising/changes", ruleChangesHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/merchandising/{rule-id}", updateRuleHandler).Methods("PUT")
+	r.Handle("/catalog/v1/admin/merchandising/{rule-id}", deleteRuleHandler).Methods("DELETE")
+	r.Handle("/catalog/v1/admin/prices", pricesHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/prices", schedulePriceHandler).Methods("POST")
+	r.Handle("/catalog/v1/admin/prices/{price-id}", cancelPriceHandler).Methods("DELETE")
+	r.Handle("/catalog/v1/admin/price-lists", priceListsHandler).Methods("GET")
+	r.Handle("/catalog/v1/admin/price-lists", createPriceListHandler).Methods("POST")
+
+	r.Handle("/books/v1/suggest", suggestHandler).Methods("GET")
+	r.Handle("/books/v1/{id}/og", ogHandler).Methods("GET")
 	r.Handle("/catalog/v1/search", searchHandler).Methods("GET")
 	r.Handle("/catalog/v1/{id}", getHandler).Methods("GET")
 
+	allow.Methods(r)
+
 	return r
 }
 func decodeSearchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
@@ -53,6 +232,113 @@ func decodeSearchRequest(ctx context.Context, req *http.Request) (interface{}, e
 	}, nil
 }
 
+func decodeSuggestRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	q := req.FormValue("q")
+	if strings.TrimSpace(q) == "" {
+		return nil, ErrEmptyQuery
+	}
+	limit := defaultSuggestLimit
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return nil, ErrBadLimit
+		}
+		limit = n
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+	return suggestRequest{
+		Q:     q,
+		Limit: limit,
+	}, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeCreateSearchConfigRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createSearchConfigRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeActivateSearchConfigRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	v, ok := mux.Vars(req)["version"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "version")
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, ErrBadVersion
+	}
+	return activateSearchConfigRequest{Version: version}, nil
+}
+
+func decodeCreateRuleRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r ruleRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeUpdateRuleRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r ruleRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	ruleID, ok := mux.Vars(req)["rule-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "rule-id")
+	}
+	r.ID = ruleID
+	return r, nil
+}
+
+func decodeDeleteRuleRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ruleID, ok := mux.Vars(req)["rule-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "rule-id")
+	}
+	return deleteRuleRequest{RuleID: ruleID, ChangedBy: req.FormValue("changed_by")}, nil
+}
+
+func decodeRuleChangesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return ruleChangesRequest{RuleID: req.FormValue("rule_id")}, nil
+}
+
+// decodePricesRequest takes the book to return the changes of from
+// ?book_id, all the books if none.
+func decodePricesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return pricesRequest{BookID: req.FormValue("book_id")}, nil
+}
+
+func decodeSchedulePriceRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var c PriceChange
+	err := schema.Decode(req.Body, &c)
+	return c, err
+}
+
+func decodeCancelPriceRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["price-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "price-id")
+	}
+	return cancelPriceRequest{ID: id}, nil
+}
+
+// decodePriceListsRequest takes the market to return the lists of from
+// ?market, all the markets if none.
+func decodePriceListsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return priceListsRequest{Market: req.FormValue("market")}, nil
+}
+
+func decodeCreatePriceListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var l PriceList
+	err := schema.Decode(req.Body, &l)
+	return l, err
+}
+
 func decodeGetRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	vars := mux.Vars(req)
 	id, ok := vars["id"]
@@ -64,6 +350,61 @@ func decodeGetRequest(ctx context.Context, req *http.Request) (interface{}, erro
 	}, nil
 }
 
+func decodePatchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "id")
+	}
+	p, err := patch.Read(req)
+	if err != nil {
+		return nil, err
+	}
+	match, err := etag.IfMatch(req)
+	if err != nil {
+		return nil, err
+	}
+	return patchRequest{
+		ID:    id,
+		Match: match,
+		Patch: p,
+	}, nil
+}
+
+// encodeBookResponse sets the ETag of the book, which clients send back in
+// the If-Match header of their updates.
+func encodeBookResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if res, ok := d.(getResponse); ok && res.Book != nil {
+		w.Header().Set("ETag", etag.Tag(res.Book.Version))
+	}
+	return encodeResponse(ctx, w, d)
+}
+
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	lreq := listRequest{URL: req.URL}
+
+	// Ignoring errors since zero values makes sense for limit and offset
+	lreq.Limit, _ = strconv.Atoi(req.FormValue("limit"))
+	if lreq.Limit <= 0 {
+		lreq.Limit = defaultPageLimit
+	}
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+
+	count, err := db.ParseCount(req.FormValue("count"))
+	if err != nil {
+		return nil, err
+	}
+	lreq.Count = count
+
+	// filter=stock eq 0 and delisted eq false&order=updated_at desc
+	if lreq.Filter, err = filter.Parse(req.FormValue("filter"), ListFields); err != nil {
+		return nil, err
+	}
+	if lreq.Order, err = filter.Order(req.FormValue("order"), ListFields); err != nil {
+		return nil, err
+	}
+	return lreq, nil
+}
+
 // errorer interface should be implemented by all the doman specific errors.
 // easy to set different status code in case of different errors.
 type errorer interface {
@@ -107,10 +448,10 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) e
 		f.Meta.Next = n
 	}
 
-	return json.NewEncoder(w).Encode(f)
+	return transport.Encode(ctx, w, f)
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
@@ -119,15 +460,29 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	// root error which is domain specific
 	code := codeFrom(errors.Cause(err))
 	w.WriteHeader(code)
-	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Error: err.Error()}}
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
 	json.NewEncoder(w).Encode(f)
 }
 
 func codeFrom(err error) int {
 	switch err {
-	case ErrBookNotFound:
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrBookNotFound, ErrConfigNotFound, ErrRuleNotFound, ErrPriceChangeNotFound:
 		return http.StatusNotFound
-	case ErrEmptyQuery, ErrBadRouting:
+	case ErrPriceOverlap, ErrPriceNotScheduled:
+		return http.StatusConflict
+	case patch.ErrContentType:
+		return http.StatusUnsupportedMediaType
+	case etag.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case etag.ErrPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case ErrEmptyQuery, ErrBadRouting, ErrBadLimit, ErrBadVersion, ErrInvalidSynonyms,
+		ErrInvalidRule, ErrMissingActor, validate.ErrInvalid, patch.ErrNotObject, db.ErrBadCount,
+		filter.ErrInvalid:
 		return http.StatusBadRequest
 	default:
 		return http.StatusInternalServerError
@@ -0,0 +1,37 @@
+package catalog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+func TestTransition(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	cases := []struct {
+		name string
+		book catalog.Book
+		live bool
+		want string
+	}{
+		{"draft", catalog.Book{Visibility: catalog.VisibilityDraft, PublishAt: &before}, false, catalog.VisibilityDraft},
+		{"scheduled later", catalog.Book{Visibility: catalog.VisibilityScheduled, PublishAt: &after}, false, catalog.VisibilityScheduled},
+		{"scheduled due", catalog.Book{Visibility: catalog.VisibilityScheduled, PublishAt: &before}, true, catalog.VisibilityLive},
+		{"scheduled at now", catalog.Book{Visibility: catalog.VisibilityScheduled, PublishAt: &now}, true, catalog.VisibilityLive},
+		{"live", catalog.Book{Visibility: catalog.VisibilityLive, RetireAt: &after}, true, catalog.VisibilityLive},
+		{"live retiring", catalog.Book{Visibility: catalog.VisibilityLive, RetireAt: &before}, false, catalog.VisibilityRetired},
+		{"scheduled past both", catalog.Book{Visibility: catalog.VisibilityScheduled, PublishAt: &before, RetireAt: &now}, false, catalog.VisibilityRetired},
+		{"retired", catalog.Book{Visibility: catalog.VisibilityRetired}, false, catalog.VisibilityRetired},
+		{"before visibility", catalog.Book{}, true, ""},
+	}
+	for _, c := range cases {
+		if got := c.book.LiveAt(now); got != c.live {
+			t.Errorf("%s: expected live %v, got %v", c.name, c.live, got)
+		}
+		if got := c.book.Transition(now); got != c.want {
+			t.Errorf("%s: expected %q, got %q", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package consent
+
+import "time"
+
+// Purposes a user consents to, each separately.
+const (
+	PurposeMarketing = "marketing" // promotional emails and referral tracking
+	PurposeAnalytics = "analytics" // usage measurement
+	PurposeProfiling = "profiling" // personalization from the user's history
+)
+
+// Purposes lists every purpose, in display order.
+var Purposes = []string{PurposeMarketing, PurposeAnalytics, PurposeProfiling}
+
+// ValidPurpose tells whether purpose is one of the purposes.
+func ValidPurpose(purpose string) bool {
+	for _, p := range Purposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+// Consent is the current choice of a user for a purpose. Version is the
+// version of the consent text the choice was made on, a grant on an older
+// version than the current one has to be renewed.
+type Consent struct {
+	UserID    string     `json:"-" gorm:"primary_key"`
+	Purpose   string     `json:"purpose" gorm:"primary_key"`
+	Granted   bool       `json:"granted"`
+	Version   string     `json:"version"`
+	GrantedAt *time.Time `json:"granted_at,omitempty"`
+	// WithdrawnAt is set once a grant is withdrawn.
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Current tells whether Version is the current version, filled when
+	// read.
+	Current bool `json:"current" sql:"-"`
+}
+
+func (Consent) TableName() string {
+	return "consents"
+}
+
+// Record is an entry of the history of the consents of a user, kept as
+// proof of every grant and withdrawal.
+type Record struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Purpose   string    `json:"purpose"`
+	Granted   bool      `json:"granted"`
+	Version   string    `json:"version"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+func (Record) TableName() string {
+	return "consent_records"
+}
+
+// Change is the choice of a user for a purpose, made on the current
+// version of its consent text.
+type Change struct {
+	Purpose string `json:"purpose"`
+	Granted bool   `json:"granted"`
+}
+
+// Source is where a change was made from, recorded along with it.
+type Source struct {
+	IP        string
+	UserAgent string
+}
+
+// Config sets the current version of the consent text of each purpose.
+type Config struct {
+	Versions map[string]string
+}
+
+// DefaultConfig has the first version of every consent text.
+func DefaultConfig() Config {
+	versions := make(map[string]string, len(Purposes))
+	for _, p := range Purposes {
+		versions[p] = "1"
+	}
+	return Config{Versions: versions}
+}
@@ -0,0 +1,94 @@
+package consent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/db"
+)
+
+// memRepo keeps the consents in memory.
+type memRepo struct {
+	consents map[string]consent.Consent
+	records  []consent.Record
+}
+
+func (r *memRepo) List(userID string) ([]consent.Consent, error) {
+	var cs []consent.Consent
+	for _, c := range r.consents {
+		if c.UserID == userID {
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+func (r *memRepo) Get(userID, purpose string) (consent.Consent, error) {
+	c, ok := r.consents[userID+"/"+purpose]
+	if !ok {
+		return consent.Consent{}, db.ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *memRepo) Save(c *consent.Consent, rec *consent.Record) error {
+	r.consents[c.UserID+"/"+c.Purpose] = *c
+	r.records = append(r.records, *rec)
+	return nil
+}
+
+func (r *memRepo) ListRecords(userID string) ([]consent.Record, error) {
+	return r.records, nil
+}
+
+func (r *memRepo) EraseRecords(userID string) error {
+	return nil
+}
+
+func (r *memRepo) Drop() error {
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	r := &memRepo{consents: make(map[string]consent.Consent)}
+	cfg := consent.DefaultConfig()
+	s := consent.NewService(r, cfg)
+
+	if _, err := s.Update(ctx, "1", []consent.Change{{Purpose: "newsletter", Granted: true}}, consent.Source{}); err != consent.ErrInvalidPurpose {
+		t.Errorf("unknown purpose: expected ErrInvalidPurpose, got %v", err)
+	}
+	if ok, _ := s.Allowed(ctx, "1", consent.PurposeMarketing); ok {
+		t.Errorf("never chosen: expected not allowed")
+	}
+
+	grant := []consent.Change{{Purpose: consent.PurposeMarketing, Granted: true}}
+	if _, err := s.Update(ctx, "1", grant, consent.Source{IP: "10.0.0.1"}); err != nil {
+		t.Fatalf("grant: unexpected error %v", err)
+	}
+	if ok, _ := s.Allowed(ctx, "1", consent.PurposeMarketing); !ok {
+		t.Errorf("granted: expected allowed")
+	}
+	if _, err := s.Update(ctx, "1", grant, consent.Source{}); err != nil || len(r.records) != 1 {
+		t.Errorf("same grant: expected no new record, got %d %v", len(r.records), err)
+	}
+
+	cfg.Versions[consent.PurposeMarketing] = "2"
+	if ok, _ := s.Allowed(ctx, "1", consent.PurposeMarketing); ok {
+		t.Errorf("new version: expected the grant to be renewed first")
+	}
+	cs, _ := s.Consents(ctx, "1")
+	if len(cs) != len(consent.Purposes) || cs[0].Current {
+		t.Errorf("consents: expected every purpose, marketing outdated, got %+v", cs)
+	}
+
+	withdraw := []consent.Change{{Purpose: consent.PurposeMarketing, Granted: false}}
+	cs, err := s.Update(ctx, "1", withdraw, consent.Source{})
+	if err != nil || cs[0].Granted || cs[0].WithdrawnAt == nil {
+		t.Errorf("withdraw: expected withdrawn, got %+v %v", cs, err)
+	}
+	if len(r.records) != 2 {
+		t.Errorf("history: expected 2 records, got %d", len(r.records))
+	}
+}
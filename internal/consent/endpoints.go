@@ -0,0 +1,127 @@
+package consent
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Authenticator resolves the token of a request into its user,
+// user.Service implements it.
+type Authenticator interface {
+	AuthToken(ctx context.Context, token string) (user.User, error)
+}
+
+// Endpoints combine all the consent service endpoints under single type.
+type Endpoints struct {
+	ConsentsEndpoint endpoint.Endpoint
+	UpdateEndpoint   endpoint.Endpoint
+	WithdrawEndpoint endpoint.Endpoint
+	HistoryEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the consent service endpoints.
+func MakeEndpoints(s Service, auth Authenticator) Endpoints {
+	return Endpoints{
+		ConsentsEndpoint: MakeConsentsEndpoint(s, auth),
+		UpdateEndpoint:   MakeUpdateEndpoint(s, auth),
+		WithdrawEndpoint: MakeWithdrawEndpoint(s, auth),
+		HistoryEndpoint:  MakeHistoryEndpoint(s, auth),
+	}
+}
+
+func MakeConsentsEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(meRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		consents, e := s.Consents(ctx, u.ID)
+		if e != nil {
+			return consentsResponse{Consents: make([]Consent, 0), Error: e}, nil
+		}
+		return consentsResponse{Consents: consents}, nil
+	}
+}
+
+func MakeUpdateEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		consents, e := s.Update(ctx, u.ID, req.Consents, req.Source)
+		if e != nil {
+			return consentsResponse{Consents: make([]Consent, 0), Error: e}, nil
+		}
+		return consentsResponse{Consents: consents}, nil
+	}
+}
+
+func MakeWithdrawEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(withdrawRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		consents, e := s.Update(ctx, u.ID, []Change{{Purpose: req.Purpose, Granted: false}}, req.Source)
+		if e != nil {
+			return consentsResponse{Consents: make([]Consent, 0), Error: e}, nil
+		}
+		return consentsResponse{Consents: consents}, nil
+	}
+}
+
+func MakeHistoryEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(meRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		records, e := s.History(ctx, u.ID)
+		if e != nil {
+			return historyResponse{Records: make([]Record, 0), Error: e}, nil
+		}
+		return historyResponse{Records: records}, nil
+	}
+}
+
+type meRequest struct {
+	Token string
+}
+
+type consentsResponse struct {
+	Consents []Consent `json:"consents"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r consentsResponse) error() error {
+	return r.Error
+}
+
+type updateRequest struct {
+	Token    string   `json:"-"`
+	Consents []Change `json:"consents"`
+	Source   Source   `json:"-"`
+}
+
+type withdrawRequest struct {
+	Token   string
+	Purpose string
+	Source  Source
+}
+
+type historyResponse struct {
+	Records []Record `json:"records"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r historyResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,70 @@
+package consent
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Consents(ctx context.Context, userID string) (consents []Consent, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "consents", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	consents, err = mw.next.Consents(ctx, userID)
+	return
+}
+
+func (mw instrmw) Update(ctx context.Context, userID string, changes []Change, src Source) (consents []Consent, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "update", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	consents, err = mw.next.Update(ctx, userID, changes, src)
+	return
+}
+
+func (mw instrmw) History(ctx context.Context, userID string) (records []Record, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "history", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	records, err = mw.next.History(ctx, userID)
+	return
+}
+
+func (mw instrmw) Allowed(ctx context.Context, userID, purpose string) (ok bool, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "allowed", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	ok, err = mw.next.Allowed(ctx, userID, purpose)
+	return
+}
@@ -0,0 +1,67 @@
+package consent
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Consents(ctx context.Context, userID string) (consents []Consent, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "consents",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Consents(ctx, userID)
+}
+
+func (s loggingService) Update(ctx context.Context, userID string, changes []Change, src Source) (consents []Consent, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "update",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Update(ctx, userID, changes, src)
+}
+
+func (s loggingService) History(ctx context.Context, userID string) (records []Record, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "history",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.History(ctx, userID)
+}
+
+func (s loggingService) Allowed(ctx context.Context, userID, purpose string) (ok bool, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "allowed",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Allowed(ctx, userID, purpose)
+}
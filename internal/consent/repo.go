@@ -0,0 +1,18 @@
+package consent
+
+// Repo abstracts all the persistant storage operations of Consent service.
+type Repo interface {
+	// List returns the consents of a user.
+	List(userID string) ([]Consent, error)
+	Get(userID, purpose string) (Consent, error)
+	// Save stores c along with its history record r.
+	Save(c *Consent, r *Record) error
+	// ListRecords returns the history of the consents of a user, oldest
+	// first.
+	ListRecords(userID string) ([]Record, error)
+	// EraseRecords blanks the client of the history of a user, the IP
+	// address and the user agent, on the erasure of the user. The records
+	// are kept as proof of the consents.
+	EraseRecords(userID string) error
+	Drop() error
+}
@@ -0,0 +1,129 @@
+package consent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrInvalidPurpose = errors.New("purpose must be marketing, analytics or profiling")
+	ErrNoChanges      = errors.New("no consent changes")
+)
+
+type Service interface {
+	// Consents returns the consents of a user for every purpose, the
+	// purposes never chosen as not granted.
+	Consents(ctx context.Context, userID string) ([]Consent, error)
+
+	// Update grants or withdraws the consents of changes, on the current
+	// version of their text. Every change is recorded with src.
+	Update(ctx context.Context, userID string, changes []Change, src Source) ([]Consent, error)
+
+	// History returns the record of every grant and withdrawal of a user,
+	// oldest first.
+	History(ctx context.Context, userID string) ([]Record, error)
+
+	// Allowed tells whether a user granted purpose on the current version of
+	// its text. The processing for the purpose checks it first.
+	Allowed(ctx context.Context, userID, purpose string) (bool, error)
+}
+
+type basicService struct {
+	r   Repo
+	cfg Config
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, cfg Config) Service {
+	return basicService{r: r, cfg: cfg}
+}
+
+// Consents returns the consents of a user for every purpose.
+func (s basicService) Consents(ctx context.Context, userID string) ([]Consent, error) {
+	stored, err := s.r.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	byPurpose := make(map[string]Consent, len(stored))
+	for _, c := range stored {
+		byPurpose[c.Purpose] = c
+	}
+	consents := make([]Consent, 0, len(Purposes))
+	for _, p := range Purposes {
+		c, ok := byPurpose[p]
+		if !ok {
+			c = Consent{UserID: userID, Purpose: p}
+		}
+		c.Current = c.Version == s.cfg.Versions[p]
+		consents = append(consents, c)
+	}
+	return consents, nil
+}
+
+// Update skips the changes matching the stored consent already, so that
+// the history only holds actual changes.
+func (s basicService) Update(ctx context.Context, userID string, changes []Change, src Source) ([]Consent, error) {
+	if len(changes) == 0 {
+		return nil, ErrNoChanges
+	}
+	for _, ch := range changes {
+		if !ValidPurpose(ch.Purpose) {
+			return nil, ErrInvalidPurpose
+		}
+	}
+	now := time.Now().UTC()
+	for _, ch := range changes {
+		version := s.cfg.Versions[ch.Purpose]
+		c, err := s.r.Get(userID, ch.Purpose)
+		switch {
+		case err == db.ErrNotFound:
+			c = Consent{UserID: userID, Purpose: ch.Purpose}
+		case err != nil:
+			return nil, err
+		case c.Granted == ch.Granted && (!ch.Granted || c.Version == version):
+			continue
+		}
+		if ch.Granted {
+			c.GrantedAt, c.WithdrawnAt = &now, nil
+		} else if c.Granted {
+			c.WithdrawnAt = &now
+		}
+		c.Granted, c.Version, c.UpdatedAt = ch.Granted, version, now
+		r := Record{
+			UserID:    userID,
+			Purpose:   ch.Purpose,
+			Granted:   ch.Granted,
+			Version:   version,
+			IP:        src.IP,
+			UserAgent: src.UserAgent,
+			At:        now,
+		}
+		if err := s.r.Save(&c, &r); err != nil {
+			return nil, err
+		}
+	}
+	return s.Consents(ctx, userID)
+}
+
+// History returns the consent records of a user, oldest first.
+func (s basicService) History(ctx context.Context, userID string) ([]Record, error) {
+	return s.r.ListRecords(userID)
+}
+
+// Allowed is false for the purposes never chosen.
+func (s basicService) Allowed(ctx context.Context, userID, purpose string) (bool, error) {
+	c, err := s.r.Get(userID, purpose)
+	if err == db.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return c.Granted && c.Version == s.cfg.Versions[purpose], nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,162 @@
+package consent
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrInvalidPurpose: "consent.invalid_purpose",
+		ErrNoChanges:      "consent.no_changes",
+	})
+}
+
+// MakeHTTPHandler serves the consents of the user of the bearer token of
+// the requests, under /users/v1/me/consents.
+func MakeHTTPHandler(ctx context.Context, s Service, auth Authenticator, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, auth)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	consentsHandler := httptransport.NewServer(
+		e.ConsentsEndpoint,
+		decodeMeRequest,
+		encodeResponse,
+		options...,
+	)
+	updateHandler := httptransport.NewServer(
+		e.UpdateEndpoint,
+		decodeUpdateRequest,
+		encodeResponse,
+		options...,
+	)
+	withdrawHandler := httptransport.NewServer(
+		e.WithdrawEndpoint,
+		decodeWithdrawRequest,
+		encodeResponse,
+		options...,
+	)
+	historyHandler := httptransport.NewServer(
+		e.HistoryEndpoint,
+		decodeMeRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/users/v1/me/consents", consentsHandler).Methods("GET")
+	r.Handle("/users/v1/me/consents", updateHandler).Methods("PUT")
+	r.Handle("/users/v1/me/consents/history", historyHandler).Methods("GET")
+	r.Handle("/users/v1/me/consents/{purpose}", withdrawHandler).Methods("DELETE")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// tokenFrom reads the user token of the Authorization header.
+func tokenFrom(req *http.Request) string {
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// sourceOf returns where a consent change is made from. The remote address
+// is the client one, resolved by realip.Handler.
+func sourceOf(req *http.Request) Source {
+	ip := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return Source{IP: ip, UserAgent: req.UserAgent()}
+}
+
+func decodeMeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return meRequest{Token: tokenFrom(req)}, nil
+}
+
+func decodeUpdateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r updateRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	r.Token, r.Source = tokenFrom(req), sourceOf(req)
+	return r, nil
+}
+
+func decodeWithdrawRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	purpose, ok := mux.Vars(req)["purpose"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "purpose")
+	}
+	return withdrawRequest{Token: tokenFrom(req), Purpose: purpose, Source: sourceOf(req)}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case user.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrBadRouting, ErrInvalidPurpose, ErrNoChanges, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
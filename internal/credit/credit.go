@@ -0,0 +1,191 @@
+package credit
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Kinds of the transactions.
+const (
+	KindRefund    = "refund"    // an order refunded as credit
+	KindPromotion = "promotion" // credit granted by a promotion
+	KindPayment   = "payment"   // an order paid with credit
+	KindExpiry    = "expiry"    // credit lapsed unspent
+)
+
+// Transaction is a movement of the store credit of a user. The credits, of
+// the refunds and the promotions, are lots the payments spend from, the
+// earliest expiry first; Remaining is what is left of a lot. Once expired,
+// what is left of a lot is written off by an expiry.
+type Transaction struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id" sql:"index"`
+	Kind   string `json:"kind"`
+	// Amount is positive for the credits, negative for the debits.
+	Amount    float64    `json:"amount"`
+	Currency  string     `json:"currency"`
+	Remaining float64    `json:"remaining,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" sql:"index"`
+	// OrderID is the order refunded or paid.
+	OrderID string `json:"order_id,omitempty" sql:"index"`
+	// LotID is the lot an expiry writes off.
+	LotID string `json:"lot_id,omitempty"`
+	// Reference is a refund or a promotion code, for the staff.
+	Reference string    `json:"reference,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	IssuedBy  string    `json:"issued_by,omitempty"`
+	CreatedAt time.Time `json:"created_at" sql:"index"`
+}
+
+func (Transaction) TableName() string {
+	return "credit_transactions"
+}
+
+// Credit tells whether t is a lot.
+func (t Transaction) Credit() bool {
+	return t.Kind == KindRefund || t.Kind == KindPromotion
+}
+
+// Expired tells whether the lot t is expired at now.
+func (t Transaction) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Balance is the credit of a user in a currency.
+type Balance struct {
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	// NextExpiry is when the next lot expires, Expiring being what is
+	// left of it.
+	NextExpiry *time.Time `json:"next_expiry,omitempty"`
+	Expiring   float64    `json:"expiring,omitempty"`
+}
+
+// Wallet is the store credit of a user, a balance per currency.
+type Wallet struct {
+	UserID   string    `json:"user_id"`
+	Balances []Balance `json:"balances"`
+}
+
+// NewWallet sums up the lots of a user left at now.
+func NewWallet(userID string, lots []Transaction, now time.Time) Wallet {
+	w := Wallet{UserID: userID, Balances: make([]Balance, 0)}
+	byCurrency := make(map[string]int)
+	for _, l := range lots {
+		if !l.Credit() || l.Expired(now) || cents(l.Remaining) <= 0 {
+			continue
+		}
+		i, ok := byCurrency[l.Currency]
+		if !ok {
+			i = len(w.Balances)
+			byCurrency[l.Currency] = i
+			w.Balances = append(w.Balances, Balance{Currency: l.Currency})
+		}
+		b := &w.Balances[i]
+		b.Amount = float64(cents(b.Amount)+cents(l.Remaining)) / 100
+		switch {
+		case l.ExpiresAt == nil:
+		case b.NextExpiry == nil || l.ExpiresAt.Before(*b.NextExpiry):
+			b.NextExpiry, b.Expiring = l.ExpiresAt, l.Remaining
+		case l.ExpiresAt.Equal(*b.NextExpiry):
+			b.Expiring = float64(cents(b.Expiring)+cents(l.Remaining)) / 100
+		}
+	}
+	sort.Slice(w.Balances, func(i, j int) bool {
+		return w.Balances[i].Currency < w.Balances[j].Currency
+	})
+	return w
+}
+
+// Spend takes amount from lots, the earliest expiry first and the lots
+// which never expire last, returning the lots taken from. ok is false,
+// and no lot changed, if the lots don't cover amount.
+func Spend(lots []Transaction, amount float64) (spent []Transaction, ok bool) {
+	sorted := append([]Transaction(nil), lots...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].ExpiresAt, sorted[j].ExpiresAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+	left := cents(amount)
+	for _, l := range sorted {
+		if left <= 0 {
+			break
+		}
+		take := cents(l.Remaining)
+		if take <= 0 {
+			continue
+		}
+		if take > left {
+			take = left
+		}
+		l.Remaining = float64(cents(l.Remaining)-take) / 100
+		left -= take
+		spent = append(spent, l)
+	}
+	if left > 0 {
+		return nil, false
+	}
+	return spent, true
+}
+
+// Grant is the request to credit a user.
+type Grant struct {
+	// Kind is refund or promotion.
+	Kind     string  `json:"kind"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	// OrderID is the order refunded.
+	OrderID   string `json:"order_id,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Note      string `json:"note,omitempty"`
+	// ExpiresAt overrides the expiry the Config sets for the kind.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	IssuedBy  string     `json:"issued_by"`
+}
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Validate checks g credits a positive amount at now.
+func (g Grant) Validate(now time.Time) error {
+	var v validate.Validator
+	v.Check(g.Kind == KindRefund || g.Kind == KindPromotion, "kind", validate.CodeInvalid, "kind must be refund or promotion")
+	v.Check(g.Amount > 0 && !math.IsInf(g.Amount, 0) && cents(g.Amount) > 0, "amount", validate.CodeInvalid, "amount must be positive")
+	v.Check(currencyRe.MatchString(g.Currency), "currency", validate.CodeInvalid, "currency must be an ISO 4217 code")
+	if g.ExpiresAt != nil {
+		v.Check(g.ExpiresAt.After(now), "expires_at", validate.CodeInvalid, "expires_at must be in the future")
+	}
+	v.Required("issued_by", g.IssuedBy)
+	return v.Err()
+}
+
+// Config sets the expiry rules of the credit, in days from the grant, 0
+// never expiring.
+type Config struct {
+	RefundExpiryDays    int
+	PromotionExpiryDays int
+}
+
+// expiry returns when the credit of kind granted at now expires, nil if
+// never.
+func (c Config) expiry(kind string, now time.Time) *time.Time {
+	days := c.PromotionExpiryDays
+	if kind == KindRefund {
+		days = c.RefundExpiryDays
+	}
+	if days <= 0 {
+		return nil
+	}
+	at := now.AddDate(0, 0, days)
+	return &at
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
@@ -0,0 +1,285 @@
+package credit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/credit"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// creditRepo keeps the transactions in memory, the other methods of the
+// Repo aren't used.
+type creditRepo struct {
+	credit.Repo
+	ts []credit.Transaction
+}
+
+func (r *creditRepo) Create(t *credit.Transaction) error {
+	t.ID = string(rune('a' + len(r.ts)))
+	r.ts = append(r.ts, *t)
+	return nil
+}
+
+func (r *creditRepo) Get(ID string) (credit.Transaction, error) {
+	for _, t := range r.ts {
+		if t.ID == ID {
+			return t, nil
+		}
+	}
+	return credit.Transaction{}, db.ErrNotFound
+}
+
+func (r *creditRepo) lots(userID, currency string, now time.Time) []credit.Transaction {
+	lots := make([]credit.Transaction, 0)
+	for _, t := range r.ts {
+		if t.UserID == userID && (currency == "" || t.Currency == currency) && t.Credit() && t.Remaining > 0 && !t.Expired(now) {
+			lots = append(lots, t)
+		}
+	}
+	return lots
+}
+
+func (r *creditRepo) ListLots(userID string, now time.Time) ([]credit.Transaction, error) {
+	return r.lots(userID, "", now), nil
+}
+
+func (r *creditRepo) Spend(t *credit.Transaction, now time.Time, spend func(lots []credit.Transaction) ([]credit.Transaction, error)) error {
+	spent, err := spend(r.lots(t.UserID, t.Currency, now))
+	if err != nil {
+		return err
+	}
+	for _, l := range spent {
+		for i := range r.ts {
+			if r.ts[i].ID == l.ID {
+				r.ts[i].Remaining = l.Remaining
+			}
+		}
+	}
+	return r.Create(t)
+}
+
+func (r *creditRepo) ListExpired(now time.Time) ([]credit.Transaction, error) {
+	expired := make([]credit.Transaction, 0)
+	for _, t := range r.ts {
+		if t.Credit() && t.Remaining > 0 && t.Expired(now) {
+			expired = append(expired, t)
+		}
+	}
+	return expired, nil
+}
+
+func (r *creditRepo) Expire(t *credit.Transaction) error {
+	for i := range r.ts {
+		if r.ts[i].ID == t.LotID {
+			t.Amount, r.ts[i].Remaining = -r.ts[i].Remaining, 0
+			return r.Create(t)
+		}
+	}
+	return db.ErrNotFound
+}
+
+// orderRepo keeps the orders in memory.
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+func (r orderRepo) Save(o *order.Order) error {
+	r.orders[o.ID] = *o
+	return nil
+}
+
+func TestPay(t *testing.T) {
+	ctx := context.Background()
+	r := &creditRepo{}
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 12.5, Currency: "EUR"},
+		"o2": {ID: "o2", CreatedByID: "u1", TotalPrice: 10, Currency: "EUR"},
+		"o3": {ID: "o3", CreatedByID: "u2", TotalPrice: 1, Currency: "EUR"},
+	}}
+	s := credit.NewService(r, orders, nil, credit.Config{PromotionExpiryDays: 30})
+
+	refund, err := s.Grant(ctx, "u1", credit.Grant{Kind: credit.KindRefund, Amount: 10, Currency: "EUR", OrderID: "o3", IssuedBy: "u9"})
+	if err != order.ErrOrderNotFound {
+		t.Errorf("refund of another user's order: expected ErrOrderNotFound, got %+v, %v", refund, err)
+	}
+	if refund, err = s.Grant(ctx, "u1", credit.Grant{Kind: credit.KindRefund, Amount: 10, Currency: "EUR", IssuedBy: "u9"}); err != nil || refund.ExpiresAt != nil {
+		t.Fatalf("refund: expected credit never expiring, got %+v, %v", refund, err)
+	}
+	promo, err := s.Grant(ctx, "u1", credit.Grant{Kind: credit.KindPromotion, Amount: 5, Currency: "EUR", Reference: "SPRING", IssuedBy: "u9"})
+	if err != nil || promo.ExpiresAt == nil || promo.ExpiresAt.Sub(promo.CreatedAt) != 30*24*time.Hour {
+		t.Fatalf("promotion: expected credit expiring in 30 days, got %+v, %v", promo, err)
+	}
+	if _, err := s.Grant(ctx, "u1", credit.Grant{Kind: credit.KindPayment, Amount: -5, Currency: "eur"}); err == nil || len(validate.Fields(err)) != 4 {
+		t.Errorf("invalid: expected 4 invalid fields, got %v", err)
+	}
+
+	w, err := s.Wallet(ctx, "u1")
+	if err != nil || len(w.Balances) != 1 || w.Balances[0].Amount != 15 || w.Balances[0].Expiring != 5 {
+		t.Errorf("wallet: expected 15 EUR with 5 expiring, got %+v, %v", w, err)
+	}
+
+	p, err := s.Pay(ctx, "u1", "o1")
+	if err != nil || p.Amount != -12.5 || p.Kind != credit.KindPayment {
+		t.Fatalf("pay: expected a payment of 12.5, got %+v, %v", p, err)
+	}
+	// The promotion expires first, it's spent first.
+	if r.ts[1].Remaining != 0 || r.ts[0].Remaining != 2.5 {
+		t.Errorf("pay: expected the promotion spent then the refund, got %+v", r.ts)
+	}
+	if o := orders.orders["o1"]; o.PaidAt == nil || o.PaymentRef != credit.PaymentPrefix+p.ID {
+		t.Errorf("pay: expected the order paid by %s, got %+v", p.ID, o)
+	}
+	if _, err := s.Pay(ctx, "u1", "o1"); err != credit.ErrAlreadyPaid {
+		t.Errorf("again: expected ErrAlreadyPaid, got %v", err)
+	}
+	if _, err := s.Pay(ctx, "u1", "o2"); err != credit.ErrInsufficientCredit || r.ts[0].Remaining != 2.5 {
+		t.Errorf("insufficient: expected ErrInsufficientCredit and no credit spent, got %v", err)
+	}
+	if _, err := s.Pay(ctx, "u1", "o3"); err != order.ErrOrderNotFound {
+		t.Errorf("another user's order: expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+// payments records the amounts moved on the other payments.
+type payments struct {
+	moved []string
+}
+
+func (p *payments) Charge(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	p.moved = append(p.moved, "charge "+paymentRef)
+	return "ch1", nil
+}
+
+func (p *payments) Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	p.moved = append(p.moved, "refund "+paymentRef)
+	return "rf1", nil
+}
+
+func TestPayments(t *testing.T) {
+	ctx := context.Background()
+	r := &creditRepo{ts: []credit.Transaction{
+		{ID: "l1", UserID: "u1", Kind: credit.KindRefund, Amount: 10, Remaining: 4, Currency: "EUR"},
+		{ID: "p1", UserID: "u1", Kind: credit.KindPayment, Amount: -6, Currency: "EUR", OrderID: "o1"},
+	}}
+	next := &payments{}
+	p := credit.NewPayments(r, next, credit.Config{RefundExpiryDays: 30})
+
+	if ref, err := p.Refund(ctx, "pay_o2", 5, "EUR"); err != nil || ref != "rf1" {
+		t.Errorf("card: expected the refund on the provider, got %q, %v", ref, err)
+	}
+	if ref, err := p.Charge(ctx, "pay_o2", 5, "EUR"); err != nil || ref != "ch1" || len(next.moved) != 2 {
+		t.Errorf("card: expected the charge on the provider, got %q, %v", ref, err)
+	}
+
+	ref, err := p.Refund(ctx, credit.PaymentPrefix+"p1", 2.5, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := r.ts[len(r.ts)-1]
+	if ref != credit.PaymentPrefix+l.ID || l.UserID != "u1" || l.Kind != credit.KindRefund || l.Remaining != 2.5 || l.OrderID != "o1" || l.ExpiresAt == nil {
+		t.Errorf("refund: expected 2.5 credited to u1 for 30 days, got %q, %+v", ref, l)
+	}
+	if _, err := p.Charge(ctx, credit.PaymentPrefix+"p1", 7, "EUR"); err != credit.ErrInsufficientCredit {
+		t.Errorf("charge: expected ErrInsufficientCredit, got %v", err)
+	}
+	if _, err := p.Charge(ctx, credit.PaymentPrefix+"p1", 5, "EUR"); err != nil {
+		t.Fatal(err)
+	}
+	// The refund expires first, it's spent first.
+	if c := r.ts[len(r.ts)-1]; c.Kind != credit.KindPayment || c.Amount != -5 || c.OrderID != "o1" || r.ts[0].Remaining != 1.5 || r.ts[2].Remaining != 0 {
+		t.Errorf("charge: expected 5 spent of the credit of u1, got %+v", r.ts)
+	}
+	if _, err := p.Refund(ctx, credit.PaymentPrefix+"l1", 1, "EUR"); err == nil {
+		t.Error("lot: expected the reference refused")
+	}
+	if len(next.moved) != 2 {
+		t.Errorf("expected the credit payments kept off the provider, got %v", next.moved)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	r := &creditRepo{ts: []credit.Transaction{
+		{ID: "l1", UserID: "u1", Kind: credit.KindPromotion, Amount: 5, Remaining: 3, Currency: "EUR", ExpiresAt: &past},
+		{ID: "l2", UserID: "u1", Kind: credit.KindRefund, Amount: 4, Remaining: 4, Currency: "EUR"},
+		{ID: "l3", UserID: "u1", Kind: credit.KindPromotion, Amount: 5, Remaining: 0, Currency: "EUR", ExpiresAt: &past},
+	}}
+	s := credit.NewService(r, orderRepo{}, nil, credit.Config{})
+
+	n, err := s.Expire(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 lot expired, got %d, %v", n, err)
+	}
+	if e := r.ts[len(r.ts)-1]; e.Kind != credit.KindExpiry || e.LotID != "l1" || e.Amount != -3 || r.ts[0].Remaining != 0 {
+		t.Errorf("expected the 3 left of l1 written off, got %+v", e)
+	}
+	if w, _ := s.Wallet(context.Background(), "u1"); len(w.Balances) != 1 || w.Balances[0].Amount != 4 {
+		t.Errorf("wallet: expected 4 EUR left, got %+v", w)
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	orders := orderRepo{orders: map[string]order.Order{"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 1, Currency: "EUR"}}}
+	s := credit.NewService(&creditRepo{}, orders, nil, credit.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := auth.NewMiddleware(tokens)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := credit.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	other, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	grant := `{"kind":"promotion","amount":5,"currency":"EUR","reference":"SPRING","issued_by":"u9"}`
+	for _, c := range []struct {
+		name, method, path, body, token string
+		// status is the one of the rejected requests, zero for the ones
+		// served.
+		status int
+	}{
+		{"grant without token", "POST", "/credit/v1/users/u1/grants", grant, "", http.StatusUnauthorized},
+		{"grant by customer", "POST", "/credit/v1/users/u1/grants", grant, customer, http.StatusForbidden},
+		{"grant by admin", "POST", "/credit/v1/users/u1/grants", grant, staff, 0},
+		{"wallet without token", "GET", "/credit/v1/users/u1", "", "", http.StatusUnauthorized},
+		{"wallet of another user", "GET", "/credit/v1/users/u1", "", other, http.StatusForbidden},
+		{"own wallet", "GET", "/credit/v1/users/u1", "", customer, 0},
+		{"wallet by admin", "GET", "/credit/v1/users/u1", "", staff, 0},
+		{"transactions of another user", "GET", "/credit/v1/users/u1/transactions", "", other, http.StatusForbidden},
+		{"pay by another user", "POST", "/credit/v1/users/u1/orders/o1/pay", "", other, http.StatusForbidden},
+		{"pay by admin", "POST", "/credit/v1/users/u1/orders/o1/pay", "", staff, http.StatusForbidden},
+		{"own payment", "POST", "/credit/v1/users/u1/orders/o1/pay", "", customer, 0},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		switch {
+		case c.status == 0 && w.Code >= 300:
+			t.Errorf("%s: expected the request served, got %d: %s", c.name, w.Code, w.Body)
+		case c.status != 0 && w.Code != c.status:
+			t.Errorf("%s: expected %d, got %d", c.name, c.status, w.Code)
+		}
+	}
+}
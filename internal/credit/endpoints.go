@@ -0,0 +1,172 @@
+package credit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Endpoints combine all the credit service endpoints under single type.
+type Endpoints struct {
+	WalletEndpoint       endpoint.Endpoint
+	TransactionsEndpoint endpoint.Endpoint
+	GrantEndpoint        endpoint.Endpoint
+	PayEndpoint          endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the credit service endpoints. The customer endpoints are restricted
+// by account, e.g. to the unscoped tokens of the users, and to the wallet
+// of the user of the request, the grants by admin.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		WalletEndpoint:       account(MakeWalletEndpoint(s)),
+		TransactionsEndpoint: account(MakeTransactionsEndpoint(s)),
+		GrantEndpoint:        admin(MakeGrantEndpoint(s)),
+		PayEndpoint:          account(MakePayEndpoint(s)),
+	}
+}
+
+// owner checks the request reaches the wallet of userID: the user of the
+// request, or an admin if admins.
+func owner(ctx context.Context, userID string, admins bool) error {
+	c, ok := auth.ClaimsFrom(ctx)
+	if !ok {
+		return auth.ErrMissingToken
+	}
+	if c.Subject != userID && (!admins || c.Role != user.RoleAdmin) {
+		return rbac.ErrForbidden
+	}
+	return nil
+}
+
+// MakeWalletEndpoint returns the wallet of a user to the user, and to the
+// admins.
+func MakeWalletEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRequest)
+		if err := owner(ctx, req.UserID, true); err != nil {
+			return nil, err
+		}
+		w, e := s.Wallet(ctx, req.UserID)
+		if e != nil {
+			return walletResponse{Wallet: nil, Error: e}, nil
+		}
+		return walletResponse{Wallet: &w}, nil
+	}
+}
+
+func MakeTransactionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(transactionsRequest)
+		if err := owner(ctx, req.UserID, true); err != nil {
+			return nil, err
+		}
+		transactions, total, e := s.Transactions(ctx, req.UserID, req.Limit, req.Offset, req.Count)
+		if e != nil {
+			return transactionsResponse{Transactions: make([]Transaction, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		if req.Count == db.CountNone {
+			// total only tells about the next page, leave it out.
+			total = 0
+		}
+		return transactionsResponse{Transactions: transactions, Total: total, Prev: prev, Next: next}, nil
+	}
+}
+
+func MakeGrantEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(grantRequest)
+		t, e := s.Grant(ctx, req.UserID, req.Grant)
+		if e != nil {
+			return transactionResponse{Transaction: nil, Error: e}, nil
+		}
+		return transactionResponse{Transaction: &t, Status: http.StatusCreated}, nil
+	}
+}
+
+// MakePayEndpoint pays an order with the credit of the user of the
+// request, nobody else spends it.
+func MakePayEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(payRequest)
+		if err := owner(ctx, req.UserID, false); err != nil {
+			return nil, err
+		}
+		t, e := s.Pay(ctx, req.UserID, req.OrderID)
+		if e != nil {
+			return transactionResponse{Transaction: nil, Error: e}, nil
+		}
+		return transactionResponse{Transaction: &t, Status: http.StatusCreated}, nil
+	}
+}
+
+type userRequest struct {
+	UserID string
+}
+
+type transactionsRequest struct {
+	UserID string
+	Limit  int
+	Offset int
+	Count  db.Count
+	URL    *url.URL
+}
+
+type grantRequest struct {
+	UserID string `json:"-"`
+	Grant
+}
+
+type payRequest struct {
+	UserID  string
+	OrderID string
+}
+
+type walletResponse struct {
+	Wallet *Wallet `json:"wallet,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r walletResponse) error() error {
+	return r.Error
+}
+
+type transactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Error        error         `json:"error,omitempty"`
+
+	Total int    `json:"-"`
+	Prev  string `json:"-"`
+	Next  string `json:"-"`
+}
+
+func (r transactionsResponse) error() error {
+	return r.Error
+}
+
+func (r transactionsResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
+
+type transactionResponse struct {
+	Status      int          `json:"-"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r transactionResponse) status() int {
+	return r.Status
+}
+
+func (r transactionResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,25 @@
+package credit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunExpirer writes off the expired credit every interval, until ctx is
+// done.
+func RunExpirer(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Expire(ctx); err != nil {
+				logger.Log("expirer", "credit", "err", err)
+			}
+		}
+	}
+}
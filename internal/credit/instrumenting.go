@@ -0,0 +1,82 @@
+package credit
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/db"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Wallet(ctx context.Context, userID string) (w Wallet, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "wallet", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	w, err = mw.next.Wallet(ctx, userID)
+	return
+}
+
+func (mw instrmw) Transactions(ctx context.Context, userID string, limit, offset int, count db.Count) (transactions []Transaction, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "transactions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	transactions, total, err = mw.next.Transactions(ctx, userID, limit, offset, count)
+	return
+}
+
+func (mw instrmw) Grant(ctx context.Context, userID string, g Grant) (t Transaction, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "grant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	t, err = mw.next.Grant(ctx, userID, g)
+	return
+}
+
+func (mw instrmw) Pay(ctx context.Context, userID, orderID string) (t Transaction, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "pay", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	t, err = mw.next.Pay(ctx, userID, orderID)
+	return
+}
+
+func (mw instrmw) Expire(ctx context.Context) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "expire", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	n, err = mw.next.Expire(ctx)
+	return
+}
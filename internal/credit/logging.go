@@ -0,0 +1,89 @@
+package credit
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/db"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Wallet(ctx context.Context, userID string) (w Wallet, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "wallet",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Wallet(ctx, userID)
+}
+
+func (s loggingService) Transactions(ctx context.Context, userID string, limit, offset int, count db.Count) (transactions []Transaction, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "transactions",
+			"user_id", userID,
+			"limit", limit,
+			"offset", offset,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Transactions(ctx, userID, limit, offset, count)
+}
+
+func (s loggingService) Grant(ctx context.Context, userID string, g Grant) (t Transaction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "grant",
+			"user_id", userID,
+			"kind", g.Kind,
+			"amount", g.Amount,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Grant(ctx, userID, g)
+}
+
+func (s loggingService) Pay(ctx context.Context, userID, orderID string) (t Transaction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "pay",
+			"user_id", userID,
+			"order_id", orderID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Pay(ctx, userID, orderID)
+}
+
+func (s loggingService) Expire(ctx context.Context) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "expire",
+			"expired", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Expire(ctx)
+}
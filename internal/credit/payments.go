@@ -0,0 +1,97 @@
+package credit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+type payments struct {
+	r    Repo
+	next order.Payments
+	cfg  Config
+}
+
+// NewPayments returns the order.Payments moving the difference of the
+// orders paid with store credit, their payment reference starting with
+// PaymentPrefix, on the credit ledger of their user; the other payments go
+// to next. A refund is credited as a lot expiring as the Config tells, a
+// charge spends the credit of the user, ErrInsufficientCredit if short.
+func NewPayments(r Repo, next order.Payments, cfg Config) order.Payments {
+	return payments{r: r, next: next, cfg: cfg}
+}
+
+func (p payments) Charge(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	if !strings.HasPrefix(paymentRef, PaymentPrefix) {
+		return p.next.Charge(ctx, paymentRef, amount, currency)
+	}
+	paid, err := p.payment(paymentRef)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	t := Transaction{
+		UserID:    paid.UserID,
+		Kind:      KindPayment,
+		Amount:    -float64(cents(amount)) / 100,
+		Currency:  currency,
+		OrderID:   paid.OrderID,
+		CreatedAt: now,
+	}
+	err = p.r.Spend(&t, now, func(lots []Transaction) ([]Transaction, error) {
+		spent, ok := Spend(lots, amount)
+		if !ok {
+			return nil, ErrInsufficientCredit
+		}
+		return spent, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return PaymentPrefix + t.ID, nil
+}
+
+func (p payments) Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	if !strings.HasPrefix(paymentRef, PaymentPrefix) {
+		return p.next.Refund(ctx, paymentRef, amount, currency)
+	}
+	paid, err := p.payment(paymentRef)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	t := Transaction{
+		UserID:    paid.UserID,
+		Kind:      KindRefund,
+		Amount:    float64(cents(amount)) / 100,
+		Currency:  currency,
+		ExpiresAt: p.cfg.expiry(KindRefund, now),
+		OrderID:   paid.OrderID,
+		Reference: paymentRef,
+		CreatedAt: now,
+	}
+	t.Remaining = t.Amount
+	if err := p.r.Create(&t); err != nil {
+		return "", err
+	}
+	return PaymentPrefix + t.ID, nil
+}
+
+// payment returns the payment transaction of the order paid with credit.
+func (p payments) payment(paymentRef string) (Transaction, error) {
+	t, err := p.r.Get(strings.TrimPrefix(paymentRef, PaymentPrefix))
+	if err == db.ErrNotFound {
+		return Transaction{}, errors.Errorf("unknown credit payment %s", paymentRef)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if t.Kind != KindPayment {
+		return Transaction{}, errors.Errorf("unknown credit payment %s", paymentRef)
+	}
+	return t, nil
+}
@@ -0,0 +1,29 @@
+package credit
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+)
+
+// Repo abstracts all the persistant storage operations of Credit service.
+type Repo interface {
+	Create(t *Transaction) error
+	Get(ID string) (Transaction, error)
+	// ListLots returns the lots of the user with credit left at now.
+	ListLots(userID string, now time.Time) ([]Transaction, error)
+	// Spend stores the debit t along with the lots spend takes it from, at
+	// once. spend is given the lots of the user in the currency of t left
+	// at now, which are locked meanwhile so that two payments don't spend
+	// them twice.
+	Spend(t *Transaction, now time.Time, spend func(lots []Transaction) ([]Transaction, error)) error
+	// List returns the transactions of the user, latest first.
+	List(userID string, limit, offset int, count db.Count) ([]Transaction, int, error)
+	// ListExpired returns the lots with credit left expired at now.
+	ListExpired(now time.Time) ([]Transaction, error)
+	// Expire writes off what is left of the lot t.LotID as the expiry t,
+	// emptying the lot at once. t.Amount is set to the credit written
+	// off, t isn't stored if none is left.
+	Expire(t *Transaction) error
+	Drop() error
+}
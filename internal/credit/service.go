@@ -0,0 +1,177 @@
+package credit
+
+import (
+	"context"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInsufficientCredit = errors.New("store credit doesn't cover the order total")
+	ErrAlreadyPaid        = errors.New("order is already paid")
+)
+
+// PaymentPrefix prefixes the payment reference of the orders paid with
+// store credit, followed by the ID of the payment transaction.
+const PaymentPrefix = "credit:"
+
+type Service interface {
+	// Wallet returns the store credit of a user.
+	Wallet(ctx context.Context, userID string) (Wallet, error)
+
+	// Transactions returns the history of the store credit of a user,
+	// latest first.
+	Transactions(ctx context.Context, userID string, limit, offset int, count db.Count) ([]Transaction, int, error)
+
+	// Grant credits a user, with a refund paid back as credit or a
+	// promotion, expiring as the Config tells.
+	Grant(ctx context.Context, userID string, g Grant) (Transaction, error)
+
+	// Pay pays an order of the user in full with store credit.
+	Pay(ctx context.Context, userID, orderID string) (Transaction, error)
+
+	// Expire writes off the credit left of the expired lots, returning the
+	// number of lots written off.
+	Expire(ctx context.Context) (int, error)
+}
+
+// Fulfiller submits the paid orders for fulfillment, e.g. the
+// fulfillment.Service.
+type Fulfiller interface {
+	Submit(ctx context.Context, orderID string) ([]fulfillment.Job, error)
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	fulfiller Fulfiller
+	cfg       Config
+}
+
+// NewService return basic Service implementation. The orders paid with
+// credit are submitted to fulfiller, unless nil.
+func NewService(r Repo, orders order.Repo, fulfiller Fulfiller, cfg Config) Service {
+	return basicService{r: r, orders: orders, fulfiller: fulfiller, cfg: cfg}
+}
+
+func (s basicService) Wallet(ctx context.Context, userID string) (Wallet, error) {
+	now := time.Now().UTC()
+	lots, err := s.r.ListLots(userID, now)
+	if err != nil {
+		return Wallet{}, err
+	}
+	return NewWallet(userID, lots, now), nil
+}
+
+func (s basicService) Transactions(ctx context.Context, userID string, limit, offset int, count db.Count) ([]Transaction, int, error) {
+	return s.r.List(userID, limit, offset, count)
+}
+
+func (s basicService) Grant(ctx context.Context, userID string, g Grant) (Transaction, error) {
+	now := time.Now().UTC()
+	if err := g.Validate(now); err != nil {
+		return Transaction{}, err
+	}
+	if g.OrderID != "" {
+		o, err := s.orders.GetByID(g.OrderID)
+		if err != nil || o.CreatedByID != userID {
+			return Transaction{}, order.ErrOrderNotFound
+		}
+	}
+	t := Transaction{
+		UserID:    userID,
+		Kind:      g.Kind,
+		Amount:    float64(cents(g.Amount)) / 100,
+		Currency:  g.Currency,
+		ExpiresAt: g.ExpiresAt,
+		OrderID:   g.OrderID,
+		Reference: g.Reference,
+		Note:      g.Note,
+		IssuedBy:  g.IssuedBy,
+		CreatedAt: now,
+	}
+	t.Remaining = t.Amount
+	if t.ExpiresAt == nil {
+		t.ExpiresAt = s.cfg.expiry(g.Kind, now)
+	}
+	if err := s.r.Create(&t); err != nil {
+		return Transaction{}, err
+	}
+	return t, nil
+}
+
+// Pay spends the credit before marking the order paid, an order is never
+// paid with credit not spent.
+func (s basicService) Pay(ctx context.Context, userID, orderID string) (Transaction, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Transaction{}, order.ErrOrderNotFound
+	}
+	if o.PaidAt != nil {
+		return Transaction{}, ErrAlreadyPaid
+	}
+	now := time.Now().UTC()
+	t := Transaction{
+		UserID:    userID,
+		Kind:      KindPayment,
+		Amount:    -o.TotalPrice,
+		Currency:  o.Currency,
+		OrderID:   o.ID,
+		CreatedAt: now,
+	}
+	err = s.r.Spend(&t, now, func(lots []Transaction) ([]Transaction, error) {
+		spent, ok := Spend(lots, o.TotalPrice)
+		if !ok {
+			return nil, ErrInsufficientCredit
+		}
+		return spent, nil
+	})
+	if err != nil {
+		return Transaction{}, err
+	}
+	o.PaymentRef, o.PaidAt = PaymentPrefix+t.ID, &now
+	if err := s.orders.Save(&o); err != nil {
+		return Transaction{}, errors.Wrapf(err, "order %s not saved after credit payment %s", o.ID, t.ID)
+	}
+	if s.fulfiller != nil {
+		if _, err := s.fulfiller.Submit(ctx, o.ID); err != nil {
+			return Transaction{}, errors.Wrapf(err, "order %s paid, not submitted for fulfillment", o.ID)
+		}
+	}
+	return t, nil
+}
+
+// Expire goes on with the other lots when one fails, returning the last
+// error.
+func (s basicService) Expire(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	lots, err := s.r.ListExpired(now)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, l := range lots {
+		t := Transaction{
+			UserID:    l.UserID,
+			Kind:      KindExpiry,
+			Currency:  l.Currency,
+			LotID:     l.ID,
+			CreatedAt: now,
+		}
+		if e := s.r.Expire(&t); e != nil {
+			err = e
+			continue
+		}
+		if t.Amount != 0 {
+			n++
+		}
+	}
+	return n, err
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,225 @@
+package credit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+const defaultPageLimit = 20
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrInsufficientCredit: "credit.insufficient",
+		ErrAlreadyPaid:        "credit.already_paid",
+	})
+}
+
+// MakeHTTPHandler serves the wallets to the requests account lets through,
+// e.g. auth.NewMiddleware chained with rbac.RequireUnscoped, and the grants
+// to the ones of admin, e.g. with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	walletHandler := httptransport.NewServer(
+		e.WalletEndpoint,
+		decodeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	transactionsHandler := httptransport.NewServer(
+		e.TransactionsEndpoint,
+		decodeTransactionsRequest,
+		encodeResponse,
+		options...,
+	)
+	grantHandler := httptransport.NewServer(
+		e.GrantEndpoint,
+		decodeGrantRequest,
+		encodeResponse,
+		options...,
+	)
+	payHandler := httptransport.NewServer(
+		e.PayEndpoint,
+		decodePayRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Customer endpoints
+	r.Handle("/credit/v1/users/{user-id}", walletHandler).Methods("GET")
+	r.Handle("/credit/v1/users/{user-id}/transactions", transactionsHandler).Methods("GET")
+	r.Handle("/credit/v1/users/{user-id}/orders/{order-id}/pay", payHandler).Methods("POST")
+
+	// Shop admin endpoints
+	r.Handle("/credit/v1/users/{user-id}/grants", grantHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeUserRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	return userRequest{UserID: userID}, nil
+}
+
+func decodeTransactionsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	lreq := transactionsRequest{UserID: userID, URL: req.URL}
+
+	// Ignoring errors since zero values makes sense for limit and offset
+	lreq.Limit, _ = strconv.Atoi(req.FormValue("limit"))
+	if lreq.Limit <= 0 {
+		lreq.Limit = defaultPageLimit
+	}
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+
+	count, err := db.ParseCount(req.FormValue("count"))
+	if err != nil {
+		return nil, err
+	}
+	lreq.Count = count
+	return lreq, nil
+}
+
+func decodeGrantRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r grantRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	r.UserID = userID
+	return r, nil
+}
+
+func decodePayRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	userID, ok := vars["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	orderID, ok := vars["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return payRequest{UserID: userID, OrderID: orderID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+// pager used to paginate any transport response.
+type pager interface {
+	page() (total int, previous, next string)
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	if page, ok := d.(pager); ok {
+		t, p, n := page.page()
+		f.Meta.Total = t
+		f.Meta.Previous = p
+		f.Meta.Next = n
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyPaid:
+		return http.StatusConflict
+	case ErrInsufficientCredit:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, db.ErrBadCount, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
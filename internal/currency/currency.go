@@ -0,0 +1,103 @@
+package currency
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// Rates are the exchange rates of a day against the base currency, i.e
+// 1 Base = Rates[X] X.
+type Rates struct {
+	Base      string             `json:"base"`
+	Date      time.Time          `json:"date"`
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// Rate returns the rate to convert from one currency to other, crossing
+// through the base currency when neither of them is the base.
+func (r Rates) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	f, ok := r.rate(from)
+	if !ok {
+		return 0, errors.Wrap(ErrUnknownCurrency, from)
+	}
+	t, ok := r.rate(to)
+	if !ok {
+		return 0, errors.Wrap(ErrUnknownCurrency, to)
+	}
+	return t / f, nil
+}
+
+func (r Rates) rate(code string) (float64, bool) {
+	if code == r.Base {
+		return 1, true
+	}
+	v, ok := r.Rates[code]
+	return v, ok && v > 0
+}
+
+// Convert converts the amount between currencies, rounded to cents.
+func (r Rates) Convert(amount float64, from, to string) (float64, error) {
+	rate, err := r.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return Round(amount * rate), nil
+}
+
+// Round rounds the amount to cents.
+func Round(amount float64) float64 {
+	return math.Floor(amount*100+0.5) / 100
+}
+
+// Quote is a single stored rate of a day.
+type Quote struct {
+	ID        string    `json:"-"`
+	Base      string    `json:"base"`
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"`
+	Rate      float64   `json:"rate"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+func (Quote) TableName() string {
+	return "fx_rates"
+}
+
+// Conversion is the result of converting an amount.
+type Conversion struct {
+	Amount    float64   `json:"amount"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      float64   `json:"rate"`
+	Converted float64   `json:"converted"`
+	RateDate  time.Time `json:"rate_date"`
+}
+
+// OrderRate is the rate locked for an order placed in a currency other
+// than the base currency of the catalog.
+type OrderRate struct {
+	OrderID  string    `json:"order_id" gorm:"primary_key"`
+	Base     string    `json:"base"`
+	Currency string    `json:"currency"`
+	Rate     float64   `json:"rate"`
+	RateDate time.Time `json:"rate_date"`
+	// Total is the order total converted to Currency with Rate.
+	Total     float64   `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (OrderRate) TableName() string {
+	return "order_fx_rates"
+}
@@ -0,0 +1,39 @@
+package currency_test
+
+import (
+	"testing"
+
+	"github.com/kavirajk/bookshop/currency"
+	"github.com/pkg/errors"
+)
+
+func TestRatesConvert(t *testing.T) {
+	rates := currency.Rates{
+		Base:  "EUR",
+		Rates: map[string]float64{"USD": 1.25, "GBP": 0.8, "JPY": 125},
+	}
+	cases := []struct {
+		amount   float64
+		from, to string
+		expected float64
+	}{
+		{10, "EUR", "USD", 12.5},
+		{12.5, "usd", "eur", 10},
+		{10, "USD", "GBP", 6.4}, // crossed through EUR
+		{19.99, "EUR", "JPY", 2498.75},
+		{7.77, "GBP", "GBP", 7.77},
+	}
+	for _, c := range cases {
+		got, err := rates.Convert(c.amount, c.from, c.to)
+		if err != nil {
+			t.Fatalf("Convert(%v, %s, %s): %v", c.amount, c.from, c.to, err)
+		}
+		if got != c.expected {
+			t.Errorf("Convert(%v, %s, %s): expected %v, got %v", c.amount, c.from, c.to, c.expected, got)
+		}
+	}
+
+	if _, err := rates.Convert(1, "EUR", "XXX"); errors.Cause(err) != currency.ErrUnknownCurrency {
+		t.Errorf("expected %v, got %v", currency.ErrUnknownCurrency, err)
+	}
+}
@@ -0,0 +1,108 @@
+package currency
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the currency service endpoints under single type.
+type Endpoints struct {
+	RatesEndpoint         endpoint.Endpoint
+	ConvertEndpoint       endpoint.Endpoint
+	LockOrderRateEndpoint endpoint.Endpoint
+	OrderRateEndpoint     endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the currency service endpoints.
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		RatesEndpoint:         MakeRatesEndpoint(s),
+		ConvertEndpoint:       MakeConvertEndpoint(s),
+		LockOrderRateEndpoint: MakeLockOrderRateEndpoint(s),
+		OrderRateEndpoint:     MakeOrderRateEndpoint(s),
+	}
+}
+
+func MakeRatesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rates, e := s.Rates(ctx)
+		if e != nil {
+			return ratesResponse{Rates: nil, Error: e}, nil
+		}
+		return ratesResponse{Rates: &rates}, nil
+	}
+}
+
+func MakeConvertEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(convertRequest)
+		c, e := s.Convert(ctx, req.Amount, req.From, req.To)
+		if e != nil {
+			return convertResponse{Conversion: nil, Error: e}, nil
+		}
+		return convertResponse{Conversion: &c}, nil
+	}
+}
+
+func MakeLockOrderRateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRateRequest)
+		rate, e := s.LockOrderRate(ctx, req.OrderID)
+		if e != nil {
+			return orderRateResponse{OrderRate: nil, Error: e}, nil
+		}
+		return orderRateResponse{OrderRate: &rate}, nil
+	}
+}
+
+func MakeOrderRateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRateRequest)
+		rate, e := s.OrderRate(ctx, req.OrderID)
+		if e != nil {
+			return orderRateResponse{OrderRate: nil, Error: e}, nil
+		}
+		return orderRateResponse{OrderRate: &rate}, nil
+	}
+}
+
+type ratesRequest struct{}
+
+type ratesResponse struct {
+	Rates *Rates `json:"rates,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r ratesResponse) error() error {
+	return r.Error
+}
+
+type convertRequest struct {
+	Amount float64
+	From   string
+	To     string
+}
+
+type convertResponse struct {
+	Conversion *Conversion `json:"conversion,omitempty"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r convertResponse) error() error {
+	return r.Error
+}
+
+type orderRateRequest struct {
+	OrderID string
+}
+
+type orderRateResponse struct {
+	OrderRate *OrderRate `json:"order_rate,omitempty"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r orderRateResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package currency
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Rates(ctx context.Context) (rates Rates, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "rates", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rates, err = mw.next.Rates(ctx)
+	return
+}
+
+func (mw instrmw) Convert(ctx context.Context, amount float64, from, to string) (conversion Conversion, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "convert", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	conversion, err = mw.next.Convert(ctx, amount, from, to)
+	return
+}
+
+func (mw instrmw) Refresh(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "refresh", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Refresh(ctx)
+	return
+}
+
+func (mw instrmw) LockOrderRate(ctx context.Context, orderID string) (orderRate OrderRate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "lock_order_rate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orderRate, err = mw.next.LockOrderRate(ctx, orderID)
+	return
+}
+
+func (mw instrmw) OrderRate(ctx context.Context, orderID string) (orderRate OrderRate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_rate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orderRate, err = mw.next.OrderRate(ctx, orderID)
+	return
+}
@@ -0,0 +1,78 @@
+package currency
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Rates(ctx context.Context) (rates Rates, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "rates",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Rates(ctx)
+}
+
+func (s loggingService) Convert(ctx context.Context, amount float64, from, to string) (conversion Conversion, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "convert",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Convert(ctx, amount, from, to)
+}
+
+func (s loggingService) Refresh(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "refresh",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Refresh(ctx)
+}
+
+func (s loggingService) LockOrderRate(ctx context.Context, orderID string) (orderRate OrderRate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "lock_order_rate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.LockOrderRate(ctx, orderID)
+}
+
+func (s loggingService) OrderRate(ctx context.Context, orderID string) (orderRate OrderRate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_rate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderRate(ctx, orderID)
+}
@@ -0,0 +1,85 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the third party publishing exchange rates.
+type Provider interface {
+	// Latest returns the latest rates against base.
+	Latest(ctx context.Context, base string) (Rates, error)
+}
+
+type httpProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPProvider returns Provider reading rates from the REST API at
+// baseURL, in the widely used format
+//
+//	GET <baseURL>/latest?base=EUR
+//	{"base": "EUR", "date": "2017-06-01", "rates": {"USD": 1.12, ...}}
+func NewHTTPProvider(baseURL, apiKey string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client}
+}
+
+type latestResponse struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func (p httpProvider) Latest(ctx context.Context, base string) (Rates, error) {
+	req, err := http.NewRequest("GET", p.baseURL+"/latest?base="+url.QueryEscape(base), nil)
+	if err != nil {
+		return Rates{}, err
+	}
+	req = req.WithContext(ctx)
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Rates{}, errors.Wrap(err, "fx latest")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return Rates{}, fmt.Errorf("fx latest: unexpected status %d", resp.StatusCode)
+	}
+
+	var res latestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Rates{}, errors.Wrap(err, "fx latest")
+	}
+	if !strings.EqualFold(res.Base, base) {
+		return Rates{}, fmt.Errorf("fx latest: asked base %s, got %s", base, res.Base)
+	}
+	date, err := time.Parse("2006-01-02", res.Date)
+	if err != nil {
+		return Rates{}, errors.Wrap(err, "fx latest date")
+	}
+	rates := make(map[string]float64, len(res.Rates))
+	for code, rate := range res.Rates {
+		rates[strings.ToUpper(code)] = rate
+	}
+	return Rates{
+		Base:      strings.ToUpper(res.Base),
+		Date:      date,
+		Rates:     rates,
+		FetchedAt: time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package currency
+
+// Repo abstracts all the persistant storage operations of Currency Service
+type Repo interface {
+	// SaveRates stores the rates of the day, replacing the ones already
+	// stored for the same day.
+	SaveRates(r Rates) error
+	// LatestRates returns the most recent rates stored for base.
+	LatestRates(base string) (Rates, error)
+	CreateOrderRate(o *OrderRate) error
+	GetOrderRate(orderID string) (OrderRate, error)
+	Drop() error
+}
@@ -0,0 +1,170 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrNoRates       = errors.New("exchange rates are not available")
+	ErrStaleRates    = errors.New("exchange rates are stale")
+	ErrBaseCurrency  = errors.New("order is in the base currency")
+	ErrRateNotLocked = errors.New("no exchange rate recorded for the order")
+)
+
+type Service interface {
+	// Rates returns the current rates against the base currency.
+	Rates(ctx context.Context) (Rates, error)
+
+	// Convert converts the amount between currencies with the current rates.
+	Convert(ctx context.Context, amount float64, from, to string) (Conversion, error)
+
+	// Refresh fetches the latest rates from the provider.
+	Refresh(ctx context.Context) error
+
+	// LockOrderRate records the current rate for an order placed in a currency
+	// other than the base currency. The first recorded rate is kept, so
+	// calling it again returns the same rate.
+	LockOrderRate(ctx context.Context, orderID string) (OrderRate, error)
+
+	// OrderRate returns the rate recorded for the order.
+	OrderRate(ctx context.Context, orderID string) (OrderRate, error)
+}
+
+// Config controls the currency service.
+type Config struct {
+	Base   string        // currency of the catalog prices
+	MaxAge time.Duration // rates fetched earlier than MaxAge are not used
+}
+
+// rateCache holds the current rates in memory, shared by the copies of the
+// service.
+type rateCache struct {
+	mu    sync.RWMutex
+	rates *Rates
+}
+
+type basicService struct {
+	r        Repo
+	orders   order.Repo
+	provider Provider
+	cfg      Config
+	cache    *rateCache
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, provider Provider, cfg Config) Service {
+	cfg.Base = strings.ToUpper(cfg.Base)
+	return basicService{r: r, orders: orders, provider: provider, cfg: cfg, cache: &rateCache{}}
+}
+
+// Rates returns the current rates against the base currency. Rates are
+// loaded from the repo when the cache is empty, e.g after a restart while
+// the provider is down.
+func (s basicService) Rates(ctx context.Context) (Rates, error) {
+	s.cache.mu.RLock()
+	cached := s.cache.rates
+	s.cache.mu.RUnlock()
+
+	if cached == nil {
+		rates, err := s.r.LatestRates(s.cfg.Base)
+		if err != nil {
+			return Rates{}, ErrNoRates
+		}
+		s.cache.mu.Lock()
+		if s.cache.rates == nil {
+			s.cache.rates = &rates
+		}
+		cached = s.cache.rates
+		s.cache.mu.Unlock()
+	}
+
+	if s.cfg.MaxAge > 0 && time.Since(cached.FetchedAt) > s.cfg.MaxAge {
+		return Rates{}, ErrStaleRates
+	}
+	return *cached, nil
+}
+
+// Convert converts the amount between currencies with the current rates.
+func (s basicService) Convert(ctx context.Context, amount float64, from, to string) (Conversion, error) {
+	rates, err := s.Rates(ctx)
+	if err != nil {
+		return Conversion{}, err
+	}
+	rate, err := rates.Rate(from, to)
+	if err != nil {
+		return Conversion{}, err
+	}
+	return Conversion{
+		Amount:    amount,
+		From:      strings.ToUpper(from),
+		To:        strings.ToUpper(to),
+		Rate:      rate,
+		Converted: Round(amount * rate),
+		RateDate:  rates.Date,
+	}, nil
+}
+
+// Refresh fetches the latest rates from the provider, stores and caches them.
+func (s basicService) Refresh(ctx context.Context) error {
+	rates, err := s.provider.Latest(ctx, s.cfg.Base)
+	if err != nil {
+		return err
+	}
+	if err := s.r.SaveRates(rates); err != nil {
+		return err
+	}
+	s.cache.mu.Lock()
+	s.cache.rates = &rates
+	s.cache.mu.Unlock()
+	return nil
+}
+
+// LockOrderRate records the current rate for the order. Order totals are
+// computed from catalog prices, so they are in the base currency.
+func (s basicService) LockOrderRate(ctx context.Context, orderID string) (OrderRate, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return OrderRate{}, order.ErrOrderNotFound
+	}
+	if o.Currency == "" || strings.EqualFold(o.Currency, s.cfg.Base) {
+		return OrderRate{}, ErrBaseCurrency
+	}
+	if locked, err := s.r.GetOrderRate(orderID); err == nil {
+		return locked, nil
+	}
+	c, err := s.Convert(ctx, o.TotalPrice, s.cfg.Base, o.Currency)
+	if err != nil {
+		return OrderRate{}, err
+	}
+	rate := OrderRate{
+		OrderID:   o.ID,
+		Base:      s.cfg.Base,
+		Currency:  c.To,
+		Rate:      c.Rate,
+		RateDate:  c.RateDate,
+		Total:     c.Converted,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.CreateOrderRate(&rate); err != nil {
+		return OrderRate{}, err
+	}
+	return rate, nil
+}
+
+// OrderRate returns the rate recorded for the order.
+func (s basicService) OrderRate(ctx context.Context, orderID string) (OrderRate, error) {
+	rate, err := s.r.GetOrderRate(orderID)
+	if err != nil {
+		return OrderRate{}, ErrRateNotLocked
+	}
+	return rate, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,157 @@
+package currency
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting    = errors.New("bad routing")
+	ErrInvalidAmount = errors.New("invalid amount")
+)
+
+func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	ratesHandler := httptransport.NewServer(
+		e.RatesEndpoint,
+		decodeRatesRequest,
+		encodeResponse,
+		options...,
+	)
+	convertHandler := httptransport.NewServer(
+		e.ConvertEndpoint,
+		decodeConvertRequest,
+		encodeResponse,
+		options...,
+	)
+	lockOrderRateHandler := httptransport.NewServer(
+		e.LockOrderRateEndpoint,
+		decodeOrderRateRequest,
+		encodeResponse,
+		options...,
+	)
+	orderRateHandler := httptransport.NewServer(
+		e.OrderRateEndpoint,
+		decodeOrderRateRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/currency/v1/rates", ratesHandler).Methods("GET")
+	r.Handle("/currency/v1/convert", convertHandler).Methods("GET")
+	r.Handle("/currency/v1/orders/{order-id}/rate", lockOrderRateHandler).Methods("POST")
+	r.Handle("/currency/v1/orders/{order-id}/rate", orderRateHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeRatesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return ratesRequest{}, nil
+}
+
+func decodeConvertRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	amount, err := strconv.ParseFloat(req.FormValue("amount"), 64)
+	if err != nil {
+		return nil, ErrInvalidAmount
+	}
+	to := req.FormValue("to")
+	if c, ok := shopctx.FromContext(ctx); ok && to == "" {
+		// the currency of the session.
+		to = c.Currency
+	}
+	return convertRequest{Amount: amount, From: req.FormValue("from"), To: to}, nil
+}
+
+func decodeOrderRateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderRateRequest{OrderID: orderID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrRateNotLocked, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrBaseCurrency:
+		return http.StatusConflict
+	case ErrNoRates, ErrStaleRates:
+		return http.StatusServiceUnavailable
+	case ErrBadRouting, ErrInvalidAmount, ErrUnknownCurrency:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,28 @@
+package currency
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunUpdater refreshes the rates right away and then every interval until
+// ctx is done.
+func RunUpdater(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	if err := s.Refresh(ctx); err != nil {
+		logger.Log("updater", "currency", "err", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				logger.Log("updater", "currency", "err", err)
+			}
+		}
+	}
+}
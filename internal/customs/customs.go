@@ -0,0 +1,101 @@
+package customs
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/kavirajk/bookshop/vat"
+)
+
+// Forms of a declaration.
+const (
+	// FormCN22 is the postal declaration of the parcels worth up to the
+	// CN22 limit.
+	FormCN22 = "cn22"
+	// FormInvoice is the commercial invoice of the parcels worth more.
+	FormInvoice = "commercial_invoice"
+)
+
+// AnyGenre is the genre of the HS code of the books whose genres have none.
+const AnyGenre = "*"
+
+// DefaultHSCode is the heading of the printed books, declared when no HS
+// code is set.
+const DefaultHSCode = "490199"
+
+// DefaultCN22Limit is the value above which a commercial invoice is
+// declared instead of a CN22.
+const DefaultCN22Limit = 425
+
+// HSCode is the Harmonized System code the books of a genre are declared
+// under, e.g. 490300 for the children's picture books.
+type HSCode struct {
+	Genre       string    `json:"genre" gorm:"primary_key"` // genre ID or AnyGenre
+	Code        string    `json:"code"`
+	Description string    `json:"description"` // as declared, e.g. "Printed books"
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (HSCode) TableName() string {
+	return "hs_codes"
+}
+
+var hsCodeRe = regexp.MustCompile(`^[0-9]{6}([0-9]{2}){0,2}$`)
+
+// Validate checks the code is made of 6, 8 or 10 digits once its dots and
+// spaces stripped.
+func (c *HSCode) Validate() error {
+	var v validate.Validator
+	c.Code = strings.NewReplacer(".", "", " ", "").Replace(c.Code)
+	v.Required("genre", c.Genre)
+	v.Check(hsCodeRe.MatchString(c.Code), "code", validate.CodeInvalid, "code must be 6, 8 or 10 digits")
+	if v.Required("description", c.Description) {
+		v.MaxLength("description", c.Description, 50)
+	}
+	return v.Err()
+}
+
+// Declaration is the customs declaration of a cross-border shipment.
+type Declaration struct {
+	ID         string `json:"id"`
+	OrderID    string `json:"order_id" sql:"index"`
+	ShipmentID string `json:"shipment_id" sql:"unique_index"`
+	Form       string `json:"form"`
+	Origin     string `json:"origin"` // country the parcel is sent from
+	// Address is the one the parcel is sent to.
+	address.Address
+	Items     []Item    `json:"items" gorm:"ForeignKey:DeclarationID"`
+	Total     float64   `json:"total"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Declaration) TableName() string {
+	return "customs_declarations"
+}
+
+// Item is a line of a declaration.
+type Item struct {
+	DeclarationID string  `json:"-" gorm:"primary_key"`
+	BookID        string  `json:"book_id" gorm:"primary_key"`
+	Description   string  `json:"description"`
+	Quantity      int     `json:"quantity"`
+	HSCode        string  `json:"hs_code"`
+	Value         float64 `json:"value"` // of all the quantity
+}
+
+func (Item) TableName() string {
+	return "customs_items"
+}
+
+// CrossBorder tells whether a parcel from origin to country clears customs,
+// the EU being a customs union.
+func CrossBorder(origin, country string) bool {
+	if strings.EqualFold(origin, country) {
+		return false
+	}
+	return !(vat.InEU(origin) && vat.InEU(country))
+}
@@ -0,0 +1,224 @@
+package customs_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the HS codes and the declarations in memory.
+type repo struct {
+	customs.Repo
+	codes        []customs.HSCode
+	declarations []customs.Declaration
+}
+
+func (r *repo) ListHSCodes() ([]customs.HSCode, error) {
+	return r.codes, nil
+}
+
+func (r *repo) SaveHSCode(c *customs.HSCode) error {
+	r.codes = append(r.codes, *c)
+	return nil
+}
+
+func (r *repo) DeleteHSCode(genre string) error {
+	for i, c := range r.codes {
+		if c.Genre == genre {
+			r.codes = append(r.codes[:i], r.codes[i+1:]...)
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *repo) CreateDeclaration(d *customs.Declaration) error {
+	d.ID = "d" + string(rune('1'+len(r.declarations)))
+	r.declarations = append(r.declarations, *d)
+	return nil
+}
+
+func (r *repo) GetDeclaration(ID string) (customs.Declaration, error) {
+	for _, d := range r.declarations {
+		if d.ID == ID {
+			return d, nil
+		}
+	}
+	return customs.Declaration{}, db.ErrNotFound
+}
+
+func (r *repo) GetByShipment(shipmentID string) (customs.Declaration, error) {
+	for _, d := range r.declarations {
+		if d.ShipmentID == shipmentID {
+			return d, nil
+		}
+	}
+	return customs.Declaration{}, db.ErrNotFound
+}
+
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, order.ErrOrderNotFound
+	}
+	return o, nil
+}
+
+type catalogRepo struct {
+	catalog.Repo
+	books map[string]catalog.Book
+}
+
+func (r catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	b, ok := r.books[ID]
+	if !ok {
+		return catalog.Book{}, db.ErrNotFound
+	}
+	return b, nil
+}
+
+func TestCrossBorder(t *testing.T) {
+	for _, c := range []struct {
+		origin, country string
+		expected        bool
+	}{
+		{"DE", "de", false},
+		{"DE", "FR", false},
+		{"DE", "CH", true},
+		{"GB", "FR", true},
+		{"US", "CA", true},
+	} {
+		if got := customs.CrossBorder(c.origin, c.country); got != c.expected {
+			t.Errorf("CrossBorder(%s, %s): expected %v, got %v", c.origin, c.country, c.expected, got)
+		}
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{codes: []customs.HSCode{
+		{Genre: "kids", Code: "490300", Description: "Children's picture books"},
+		{Genre: customs.AnyGenre, Code: "490199", Description: "Printed books"},
+	}}
+	books := catalogRepo{books: map[string]catalog.Book{
+		"b1": {ID: "b1", Title: "Gruffalo", Genres: []catalog.Genre{{ID: "kids"}}},
+		"b2": {ID: "b2", Title: "Dune", Genres: []catalog.Genre{{ID: "scifi"}}},
+		"b3": {ID: "b3", Title: "Zine", PrintOnDemand: true},
+	}}
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", Currency: "EUR", Lines: []order.Line{
+			{BookID: "b1", Quantity: 2, Price: 7.99},
+			{BookID: "b2", Quantity: 1, Price: 12.5},
+			{BookID: "b3", Quantity: 1, Price: 5},
+		}},
+		"o2": {ID: "o2", Currency: "EUR", Lines: []order.Line{{BookID: "b2", Quantity: 40, Price: 12.5}}},
+	}}
+	s := customs.NewService(r, orders, books, customs.Config{Origin: "de", Embargo: address.ParseEmbargo("KP")})
+
+	if d, err := s.Declare(ctx, "o1", "s1", address.Address{Country: "FR"}); d != nil || err != nil {
+		t.Errorf("within the EU: expected no declaration, got %+v, %v", d, err)
+	}
+	if _, err := s.Declare(ctx, "o1", "s1", address.Address{Country: "KP"}); err != customs.ErrEmbargoed {
+		t.Errorf("expected ErrEmbargoed, got %v", err)
+	}
+
+	d, err := s.Declare(ctx, "o1", "s1", address.Address{Name: "Heidi", City: "Zürich", Country: "CH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Form != customs.FormCN22 || d.Origin != "DE" || d.Total != 28.48 || len(d.Items) != 2 {
+		t.Fatalf("expected a CN22 of the 2 stocked books, got %+v", d)
+	}
+	if d.Items[0].HSCode != "490300" || d.Items[0].Value != 15.98 || d.Items[1].HSCode != "490199" {
+		t.Errorf("expected the codes of the genres, else the default, got %+v", d.Items)
+	}
+	if again, _ := s.Declare(ctx, "o1", "s1", address.Address{Country: "CH"}); again.ID != d.ID || len(r.declarations) != 1 {
+		t.Errorf("expected the shipment declared once, got %+v", r.declarations)
+	}
+
+	big, err := s.Declare(ctx, "o2", "s2", address.Address{Country: "US"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if big.Form != customs.FormInvoice || big.Total != 500 {
+		t.Errorf("over the CN22 limit: expected a commercial invoice, got %+v", big)
+	}
+
+	for id, expected := range map[string]string{d.ID: "(CUSTOMS DECLARATION CN 22)", big.ID: "(Commercial invoice)"} {
+		f, err := s.DeclarationPDF(ctx, id)
+		if err != nil || !bytes.Contains(f.Data, []byte(expected)) {
+			t.Errorf("%s: expected %s in the PDF, got %v", id, expected, err)
+		}
+	}
+	if _, err := s.DeclarationPDF(ctx, "d9"); err != customs.ErrDeclarationNotFound {
+		t.Errorf("expected ErrDeclarationNotFound, got %v", err)
+	}
+}
+
+func TestHSCodeValidate(t *testing.T) {
+	c := customs.HSCode{Genre: "kids", Code: "4903.00", Description: "Children's picture books"}
+	if err := c.Validate(); err != nil || c.Code != "490300" {
+		t.Errorf("expected the dots stripped, got %q, %v", c.Code, err)
+	}
+	if err := (&customs.HSCode{Genre: "kids", Code: "4903", Description: "x"}).Validate(); err == nil {
+		t.Error("expected a 4 digits code invalid")
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	r := &repo{}
+	s := customs.NewService(r, orderRepo{}, catalogRepo{}, customs.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := customs.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	code := `{"code":"4901.99","description":"Printed books"}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"set without token", "PUT", "/customs/v1/hs-codes/fiction", code, "", http.StatusUnauthorized},
+		{"set by customer", "PUT", "/customs/v1/hs-codes/fiction", code, customer, http.StatusForbidden},
+		{"delete by customer", "DELETE", "/customs/v1/hs-codes/fiction", "", customer, http.StatusForbidden},
+		{"list by customer", "GET", "/customs/v1/hs-codes", "", customer, http.StatusForbidden},
+		{"embargo by customer", "GET", "/customs/v1/embargo", "", customer, http.StatusForbidden},
+		{"set by admin", "PUT", "/customs/v1/hs-codes/fiction", code, staff, http.StatusOK},
+		{"list by admin", "GET", "/customs/v1/hs-codes", "", staff, http.StatusOK},
+		{"delete by admin", "DELETE", "/customs/v1/hs-codes/fiction", "", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if len(r.codes) != 0 {
+		t.Errorf("expected the HS code set then deleted by the admin only, got %+v", r.codes)
+	}
+}
@@ -0,0 +1,134 @@
+package customs
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the customs service endpoints under single type.
+type Endpoints struct {
+	DeclarationsEndpoint   endpoint.Endpoint
+	DeclarationPDFEndpoint endpoint.Endpoint
+	EmbargoEndpoint        endpoint.Endpoint
+	HSCodesEndpoint        endpoint.Endpoint
+	SetHSCodeEndpoint      endpoint.Endpoint
+	DeleteHSCodeEndpoint   endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the customs service endpoints. The embargo and the HS codes are
+// restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		DeclarationsEndpoint:   MakeDeclarationsEndpoint(s),
+		DeclarationPDFEndpoint: MakeDeclarationPDFEndpoint(s),
+		EmbargoEndpoint:        admin(MakeEmbargoEndpoint(s)),
+		HSCodesEndpoint:        admin(MakeHSCodesEndpoint(s)),
+		SetHSCodeEndpoint:      admin(MakeSetHSCodeEndpoint(s)),
+		DeleteHSCodeEndpoint:   admin(MakeDeleteHSCodeEndpoint(s)),
+	}
+}
+
+func MakeDeclarationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		list, e := s.Declarations(ctx, req.ID)
+		if e != nil {
+			return declarationsResponse{Declarations: make([]Declaration, 0), Error: e}, nil
+		}
+		return declarationsResponse{Declarations: list}, nil
+	}
+}
+
+func MakeDeclarationPDFEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.DeclarationPDF(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakeEmbargoEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		countries, e := s.Embargo(ctx)
+		if e != nil {
+			return embargoResponse{Countries: make([]string, 0), Error: e}, nil
+		}
+		return embargoResponse{Countries: countries}, nil
+	}
+}
+
+func MakeHSCodesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		codes, e := s.HSCodes(ctx)
+		if e != nil {
+			return hsCodesResponse{HSCodes: make([]HSCode, 0), Error: e}, nil
+		}
+		return hsCodesResponse{HSCodes: codes}, nil
+	}
+}
+
+func MakeSetHSCodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(HSCode)
+		c, e := s.SetHSCode(ctx, req)
+		if e != nil {
+			return hsCodeResponse{HSCode: nil, Error: e}, nil
+		}
+		return hsCodeResponse{HSCode: &c}, nil
+	}
+}
+
+func MakeDeleteHSCodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		e := s.DeleteHSCode(ctx, req.ID)
+		return hsCodeResponse{Error: e}, nil
+	}
+}
+
+type idRequest struct {
+	ID string
+}
+
+type declarationsResponse struct {
+	Declarations []Declaration `json:"declarations"`
+	Error        error         `json:"error,omitempty"`
+}
+
+func (r declarationsResponse) error() error {
+	return r.Error
+}
+
+type fileResponse struct {
+	File
+	Error error
+}
+
+type embargoResponse struct {
+	Countries []string `json:"countries"`
+	Error     error    `json:"error,omitempty"`
+}
+
+func (r embargoResponse) error() error {
+	return r.Error
+}
+
+type hsCodesResponse struct {
+	HSCodes []HSCode `json:"hs_codes"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r hsCodesResponse) error() error {
+	return r.Error
+}
+
+type hsCodeResponse struct {
+	HSCode *HSCode `json:"hs_code,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r hsCodeResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,104 @@
+package customs
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/address"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Declare(ctx context.Context, orderID, shipmentID string, to address.Address) (d *Declaration, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "declare", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	d, err = mw.next.Declare(ctx, orderID, shipmentID, to)
+	return
+}
+
+func (mw instrmw) Declarations(ctx context.Context, orderID string) (list []Declaration, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "declarations", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Declarations(ctx, orderID)
+	return
+}
+
+func (mw instrmw) DeclarationPDF(ctx context.Context, ID string) (f File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "declaration_pdf", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	f, err = mw.next.DeclarationPDF(ctx, ID)
+	return
+}
+
+func (mw instrmw) Embargo(ctx context.Context) (countries []string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "embargo", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	countries, err = mw.next.Embargo(ctx)
+	return
+}
+
+func (mw instrmw) HSCodes(ctx context.Context) (codes []HSCode, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "hs_codes", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	codes, err = mw.next.HSCodes(ctx)
+	return
+}
+
+func (mw instrmw) SetHSCode(ctx context.Context, c HSCode) (saved HSCode, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_hs_code", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	saved, err = mw.next.SetHSCode(ctx, c)
+	return
+}
+
+func (mw instrmw) DeleteHSCode(ctx context.Context, genre string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete_hs_code", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DeleteHSCode(ctx, genre)
+	return
+}
@@ -0,0 +1,110 @@
+package customs
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/address"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Declare(ctx context.Context, orderID, shipmentID string, to address.Address) (d *Declaration, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "declare",
+			"order_id", orderID,
+			"shipment_id", shipmentID,
+			"country", to.Country,
+			"declared", d != nil,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Declare(ctx, orderID, shipmentID, to)
+}
+
+func (s loggingService) Declarations(ctx context.Context, orderID string) (list []Declaration, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "declarations",
+			"order_id", orderID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Declarations(ctx, orderID)
+}
+
+func (s loggingService) DeclarationPDF(ctx context.Context, ID string) (f File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "declaration_pdf",
+			"id", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeclarationPDF(ctx, ID)
+}
+
+func (s loggingService) Embargo(ctx context.Context) (countries []string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "embargo",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Embargo(ctx)
+}
+
+func (s loggingService) HSCodes(ctx context.Context) (codes []HSCode, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "hs_codes",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.HSCodes(ctx)
+}
+
+func (s loggingService) SetHSCode(ctx context.Context, c HSCode) (saved HSCode, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_hs_code",
+			"genre", c.Genre,
+			"code", c.Code,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetHSCode(ctx, c)
+}
+
+func (s loggingService) DeleteHSCode(ctx context.Context, genre string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_hs_code",
+			"genre", genre,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeleteHSCode(ctx, genre)
+}
@@ -0,0 +1,114 @@
+package customs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/pdf"
+)
+
+// Sizes of the CN22, a 105 by 74 mm label stuck on the parcel.
+const (
+	cn22Width  = 105 * pdf.MM
+	cn22Height = 74 * pdf.MM
+	// cn22Items is the number of items listed on a CN22, the others
+	// summed up on its last line.
+	cn22Items = 4
+)
+
+// CN22PDF returns the CN22 of a declaration.
+func CN22PDF(d Declaration) File {
+	doc := pdf.New(cn22Width, cn22Height)
+	p := doc.AddPage()
+	const x = 3 * pdf.MM
+	p.Text(x, 6*pdf.MM, 9, true, "CUSTOMS DECLARATION CN 22")
+	p.Text(cn22Width-30*pdf.MM, 6*pdf.MM, 7, false, "Gift   [ ]")
+	p.Text(cn22Width-30*pdf.MM, 10*pdf.MM, 7, false, "Sale of goods   [X]")
+	p.Text(x, 10*pdf.MM, 6, false, "Order "+d.OrderID)
+	p.Line(x, cn22Width-x, 12*pdf.MM)
+
+	y := 16 * pdf.MM
+	p.Text(x, y, 6, true, "Detailed description")
+	p.Text(58*pdf.MM, y, 6, true, "Qty")
+	p.Text(66*pdf.MM, y, 6, true, "HS code")
+	p.Text(84*pdf.MM, y, 6, true, "Value")
+	items, rest := d.Items, 0
+	if len(items) > cn22Items {
+		items, rest = items[:cn22Items-1], len(items)-cn22Items+1
+	}
+	for _, it := range items {
+		y += 4 * pdf.MM
+		p.Text(x, y, 6, false, pdf.Truncate(it.Description, 44))
+		p.Text(58*pdf.MM, y, 6, false, fmt.Sprint(it.Quantity))
+		p.Text(66*pdf.MM, y, 6, false, it.HSCode)
+		p.Text(84*pdf.MM, y, 6, false, fmt.Sprintf("%.2f", it.Value))
+	}
+	if rest > 0 {
+		value, quantity := 0.0, 0
+		for _, it := range d.Items[len(items):] {
+			value += it.Value
+			quantity += it.Quantity
+		}
+		y += 4 * pdf.MM
+		p.Text(x, y, 6, false, fmt.Sprintf("and %d more books", rest))
+		p.Text(58*pdf.MM, y, 6, false, fmt.Sprint(quantity))
+		p.Text(84*pdf.MM, y, 6, false, fmt.Sprintf("%.2f", value))
+	}
+	p.Line(x, cn22Width-x, y+2*pdf.MM)
+
+	y += 6 * pdf.MM
+	p.Text(x, y, 7, true, fmt.Sprintf("Total value %.2f %s", d.Total, d.Currency))
+	p.Text(x, y+4*pdf.MM, 6, false, "Country of origin of goods "+d.Origin)
+	p.Text(x, cn22Height-6*pdf.MM, 5, false, "I certify that the particulars given in this declaration are correct")
+	p.Text(x, cn22Height-3*pdf.MM, 5, false, "and that this item does not contain any dangerous article.")
+	return File{Name: "cn22-" + d.ShipmentID + ".pdf", ContentType: "application/pdf", Data: doc.Bytes()}
+}
+
+// InvoicePDF returns the commercial invoice of a declaration.
+func InvoicePDF(d Declaration) File {
+	const margin, rowHeight, rowsPerPage = 50, 16, 32
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	var p *pdf.Page
+	y := 0.0
+	for i, it := range d.Items {
+		if i%rowsPerPage == 0 {
+			p = doc.AddPage()
+			p.Text(margin, 60, 18, true, "Commercial invoice")
+			p.Text(margin, 80, 10, false, "Order "+d.OrderID+", shipment "+d.ShipmentID)
+			p.Text(margin, 94, 10, false, "Date "+d.CreatedAt.Format("2006-01-02"))
+			p.Text(margin, 120, 9, true, "Consignee")
+			for j, l := range addressLines(d.Address) {
+				p.Text(margin, 134+float64(j)*12, 9, false, l)
+			}
+			y = 220
+			p.Text(margin, y, 9, true, "Description")
+			p.Text(margin+280, y, 9, true, "HS code")
+			p.Text(margin+350, y, 9, true, "Qty")
+			p.Text(margin+400, y, 9, true, "Value")
+			p.Line(margin, pdf.A4Width-margin, y+5)
+			y += rowHeight
+		}
+		p.Text(margin, y, 9, false, pdf.Truncate(it.Description, 52))
+		p.Text(margin+280, y, 9, false, it.HSCode)
+		p.Text(margin+350, y, 9, false, fmt.Sprint(it.Quantity))
+		p.Text(margin+400, y, 9, false, fmt.Sprintf("%.2f %s", it.Value, d.Currency))
+		y += rowHeight
+	}
+	p.Line(margin, pdf.A4Width-margin, y-rowHeight+5)
+	p.Text(margin+280, y+10, 10, true, "Total")
+	p.Text(margin+400, y+10, 10, true, fmt.Sprintf("%.2f %s", d.Total, d.Currency))
+	p.Text(margin, y+40, 9, false, "Reason for export: sale of goods. Country of origin: "+d.Origin+".")
+	p.Text(margin, y+54, 9, false, "I declare that the information on this invoice is true and correct.")
+	return File{Name: "invoice-" + d.ShipmentID + ".pdf", ContentType: "application/pdf", Data: doc.Bytes()}
+}
+
+func addressLines(a address.Address) []string {
+	var lines []string
+	for _, l := range []string{a.Name, a.Line1, a.Line2, strings.TrimSpace(a.PostalCode + " " + a.City), a.Region, a.Country} {
+		if l != "" {
+			lines = append(lines, pdf.Truncate(l, 60))
+		}
+	}
+	return lines
+}
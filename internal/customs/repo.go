@@ -0,0 +1,16 @@
+package customs
+
+// Repo abstracts all the persistant storage operations of Customs Service
+type Repo interface {
+	ListHSCodes() ([]HSCode, error)
+	// SaveHSCode creates or replaces the HS code of the genre.
+	SaveHSCode(c *HSCode) error
+	DeleteHSCode(genre string) error
+
+	// CreateDeclaration stores the declaration with its items.
+	CreateDeclaration(d *Declaration) error
+	GetDeclaration(ID string) (Declaration, error)
+	GetByShipment(shipmentID string) (Declaration, error)
+	ListByOrder(orderID string) ([]Declaration, error)
+	Drop() error
+}
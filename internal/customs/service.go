@@ -0,0 +1,227 @@
+package customs
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrEmbargoed           = errors.New("shipping to the country is embargoed")
+	ErrHSCodeNotFound      = errors.New("hs code not found")
+	ErrDeclarationNotFound = errors.New("customs declaration not found")
+)
+
+// File is a printable document.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Config controls the customs service.
+type Config struct {
+	Origin string // country the parcels are sent from
+	// CN22Limit is the value, in the currency of the order, above which a
+	// commercial invoice is declared. DefaultCN22Limit if zero.
+	CN22Limit float64
+	// Embargo lists the countries no parcel is sent to.
+	Embargo address.Embargo
+}
+
+type Service interface {
+	// Declare returns the declaration of a shipment of the order to the
+	// address, nil if the parcel doesn't clear customs. It declares the
+	// books the shop ships itself, once per shipment, and fails with
+	// ErrEmbargoed for the embargoed countries.
+	Declare(ctx context.Context, orderID, shipmentID string, to address.Address) (*Declaration, error)
+
+	// Declarations returns the declarations of the shipments of an order.
+	Declarations(ctx context.Context, orderID string) ([]Declaration, error)
+
+	// DeclarationPDF returns the CN22 or the commercial invoice of a
+	// declaration, to print with the shipping label.
+	DeclarationPDF(ctx context.Context, ID string) (File, error)
+
+	// Embargo returns the embargoed countries.
+	Embargo(ctx context.Context) ([]string, error)
+
+	// HSCodes returns the HS codes of the genres.
+	HSCodes(ctx context.Context) ([]HSCode, error)
+
+	// SetHSCode creates or replaces the HS code of a genre, AnyGenre for
+	// the books whose genres have none.
+	SetHSCode(ctx context.Context, c HSCode) (HSCode, error)
+
+	// DeleteHSCode removes the HS code of a genre.
+	DeleteHSCode(ctx context.Context, genre string) error
+}
+
+type basicService struct {
+	r       Repo
+	orders  order.Repo
+	catalog catalog.Repo
+	config  Config
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, catalog catalog.Repo, config Config) Service {
+	config.Origin = strings.ToUpper(config.Origin)
+	if config.CN22Limit <= 0 {
+		config.CN22Limit = DefaultCN22Limit
+	}
+	return basicService{r: r, orders: orders, catalog: catalog, config: config}
+}
+
+func (s basicService) Declare(ctx context.Context, orderID, shipmentID string, to address.Address) (*Declaration, error) {
+	if s.config.Embargo.Blocks(to.Country) {
+		return nil, ErrEmbargoed
+	}
+	if !CrossBorder(s.config.Origin, to.Country) {
+		return nil, nil
+	}
+	if d, err := s.r.GetByShipment(shipmentID); err == nil {
+		return &d, nil
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	lines := o.Lines
+	if len(lines) == 0 {
+		for _, b := range o.Items {
+			lines = append(lines, order.Line{OrderID: o.ID, BookID: b.ID, Quantity: 1, Price: b.Price})
+		}
+	}
+	codes, err := s.codes()
+	if err != nil {
+		return nil, err
+	}
+
+	d := Declaration{
+		OrderID:    o.ID,
+		ShipmentID: shipmentID,
+		Origin:     s.config.Origin,
+		Address:    to,
+		Currency:   o.Currency,
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, l := range lines {
+		b, err := s.catalog.GetByID(l.BookID)
+		if err != nil {
+			return nil, catalog.ErrBookNotFound
+		}
+		if b.PrintOnDemand {
+			continue
+		}
+		c := hsCode(b, codes)
+		value := math.Round(l.Price*100*float64(l.Quantity)) / 100
+		d.Items = append(d.Items, Item{
+			BookID:      b.ID,
+			Description: c.Description + ", " + b.Title,
+			Quantity:    l.Quantity,
+			HSCode:      c.Code,
+			Value:       value,
+		})
+		d.Total = math.Round((d.Total+value)*100) / 100
+	}
+	if len(d.Items) == 0 {
+		return nil, nil
+	}
+	d.Form = FormCN22
+	if d.Total > s.config.CN22Limit {
+		d.Form = FormInvoice
+	}
+	if err := s.r.CreateDeclaration(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// codes returns the HS codes by genre.
+func (s basicService) codes() (map[string]HSCode, error) {
+	list, err := s.r.ListHSCodes()
+	if err != nil {
+		return nil, err
+	}
+	codes := make(map[string]HSCode, len(list))
+	for _, c := range list {
+		codes[c.Genre] = c
+	}
+	return codes, nil
+}
+
+// hsCode returns the code of the first genre of b, by ID, that has one,
+// else the code of AnyGenre, else DefaultHSCode.
+func hsCode(b catalog.Book, codes map[string]HSCode) HSCode {
+	genres := make([]string, 0, len(b.Genres))
+	for _, g := range b.Genres {
+		genres = append(genres, g.ID)
+	}
+	sort.Strings(genres)
+	for _, g := range genres {
+		if c, ok := codes[g]; ok {
+			return c
+		}
+	}
+	if c, ok := codes[AnyGenre]; ok {
+		return c
+	}
+	return HSCode{Genre: AnyGenre, Code: DefaultHSCode, Description: "Printed books"}
+}
+
+func (s basicService) Declarations(ctx context.Context, orderID string) ([]Declaration, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	return s.r.ListByOrder(orderID)
+}
+
+func (s basicService) DeclarationPDF(ctx context.Context, ID string) (File, error) {
+	d, err := s.r.GetDeclaration(ID)
+	if err != nil {
+		return File{}, ErrDeclarationNotFound
+	}
+	if d.Form == FormInvoice {
+		return InvoicePDF(d), nil
+	}
+	return CN22PDF(d), nil
+}
+
+func (s basicService) Embargo(ctx context.Context) ([]string, error) {
+	return s.config.Embargo.Countries(), nil
+}
+
+func (s basicService) HSCodes(ctx context.Context) ([]HSCode, error) {
+	return s.r.ListHSCodes()
+}
+
+func (s basicService) SetHSCode(ctx context.Context, c HSCode) (HSCode, error) {
+	if err := c.Validate(); err != nil {
+		return HSCode{}, err
+	}
+	c.UpdatedAt = time.Now().UTC()
+	if err := s.r.SaveHSCode(&c); err != nil {
+		return HSCode{}, err
+	}
+	return c, nil
+}
+
+func (s basicService) DeleteHSCode(ctx context.Context, genre string) error {
+	err := s.r.DeleteHSCode(genre)
+	if err == db.ErrNotFound {
+		return ErrHSCodeNotFound
+	}
+	return err
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,197 @@
+package customs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrEmbargoed:           "customs.embargoed",
+		ErrHSCodeNotFound:      "customs.hs_code_not_found",
+		ErrDeclarationNotFound: "customs.declaration_not_found",
+	})
+}
+
+// MakeHTTPHandler mounts the customs endpoints, the embargo and the HS
+// codes served to the requests admin lets through, e.g. auth.NewMiddleware
+// chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	declarationsHandler := httptransport.NewServer(
+		e.DeclarationsEndpoint,
+		decodeIDRequest("order-id"),
+		encodeResponse,
+		options...,
+	)
+	declarationPDFHandler := httptransport.NewServer(
+		e.DeclarationPDFEndpoint,
+		decodeIDRequest("declaration-id"),
+		encodeFile,
+		options...,
+	)
+	embargoHandler := httptransport.NewServer(
+		e.EmbargoEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	hsCodesHandler := httptransport.NewServer(
+		e.HSCodesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	setHSCodeHandler := httptransport.NewServer(
+		e.SetHSCodeEndpoint,
+		decodeSetHSCodeRequest,
+		encodeResponse,
+		options...,
+	)
+	deleteHSCodeHandler := httptransport.NewServer(
+		e.DeleteHSCodeEndpoint,
+		decodeIDRequest("genre"),
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Warehouse endpoints
+	r.Handle("/customs/v1/orders/{order-id}/declarations", declarationsHandler).Methods("GET")
+	r.Handle("/customs/v1/declarations/{declaration-id}/pdf", declarationPDFHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/customs/v1/embargo", embargoHandler).Methods("GET")
+	r.Handle("/customs/v1/hs-codes", hsCodesHandler).Methods("GET")
+	r.Handle("/customs/v1/hs-codes/{genre}", setHSCodeHandler).Methods("PUT")
+	r.Handle("/customs/v1/hs-codes/{genre}", deleteHSCodeHandler).Methods("DELETE")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeIDRequest(name string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		id, ok := mux.Vars(req)[name]
+		if !ok {
+			return nil, errors.Wrap(ErrBadRouting, name)
+		}
+		return idRequest{ID: id}, nil
+	}
+}
+
+func decodeSetHSCodeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var c HSCode
+	if err := schema.Decode(req.Body, &c); err != nil {
+		return nil, err
+	}
+	genre, ok := mux.Vars(req)["genre"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "genre")
+	}
+	c.Genre = genre
+	return c, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+// encodeFile writes the generated file inline, with its name for saving.
+func encodeFile(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(fileResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `inline; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound, ErrHSCodeNotFound, ErrDeclarationNotFound:
+		return http.StatusNotFound
+	case ErrEmbargoed:
+		return http.StatusConflict
+	case ErrBadRouting, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,39 @@
+package dashboard
+
+import "time"
+
+// Summary is the back-office landing page at a glance. Today is the day of
+// the time zone of the request.
+type Summary struct {
+	Date     string    `json:"date"`
+	Orders   int       `json:"orders"`
+	Revenue  []Revenue `json:"revenue"`
+	Reviews  Reviews   `json:"pending_reviews"`
+	LowStock int       `json:"low_stock"`
+	// FailedJobs counts the background exports which failed and weren't
+	// cleaned up yet.
+	FailedJobs  int       `json:"failed_jobs"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Revenue is the total of the orders of the day in a currency.
+type Revenue struct {
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+}
+
+// Reviews counts what waits for a decision of the staff.
+type Reviews struct {
+	Fraud          int `json:"fraud"`
+	Rectifications int `json:"rectifications"`
+	Vendors        int `json:"vendors"`
+	Total          int `json:"total"`
+}
+
+// Config controls the dashboard.
+type Config struct {
+	// LowStock is the stock at or below which a stocked book is low.
+	LowStock int
+	// TTL is how long a summary is served from the cache.
+	TTL time.Duration
+}
@@ -0,0 +1,141 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/dashboard"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/fraud"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+// The repos below return fixed results, the other methods of their Repo
+// aren't used.
+
+type orderRepo struct {
+	order.Repo
+	orders []order.Order
+}
+
+func (r orderRepo) List(f filter.Expr, limit, offset int, count db.Count) ([]order.Order, int, error) {
+	if offset >= len(r.orders) {
+		return nil, 0, nil
+	}
+	return r.orders[offset:], 0, nil
+}
+
+type bookRepo struct {
+	catalog.Repo
+	low int
+}
+
+func (r bookRepo) List(f filter.Expr, order string, limit, offset int, count db.Count) ([]catalog.Book, int, error) {
+	return nil, r.low, nil
+}
+
+type fraudRepo struct{ fraud.Repo }
+
+func (fraudRepo) ListByStatus(status string) ([]fraud.Assessment, error) {
+	return []fraud.Assessment{{}}, nil
+}
+
+type rectificationRepo struct{ rectification.Repo }
+
+func (rectificationRepo) ListByStatus(status string) ([]rectification.Request, error) {
+	return []rectification.Request{{}, {}}, nil
+}
+
+type vendorRepo struct{ vendors.Repo }
+
+func (vendorRepo) List(status string, f filter.Expr) ([]vendors.Vendor, error) {
+	return nil, nil
+}
+
+type exportRepo struct{ export.Repo }
+
+func (exportRepo) ListJobsByStatus(status ...string) ([]export.Job, error) {
+	return []export.Job{{}}, nil
+}
+
+func newService() dashboard.Service {
+	return dashboard.NewService(dashboard.Repos{
+		Orders: orderRepo{orders: []order.Order{
+			{Currency: "EUR", TotalPrice: 10.10},
+			{Currency: "EUR", TotalPrice: 0.20},
+			{Currency: "USD", TotalPrice: 5},
+		}},
+		Books:          bookRepo{low: 4},
+		Fraud:          fraudRepo{},
+		Rectifications: rectificationRepo{},
+		Vendors:        vendorRepo{},
+		Exports:        exportRepo{},
+	}, dashboard.Config{LowStock: 2, TTL: time.Minute})
+}
+
+func TestSummary(t *testing.T) {
+	sum, err := newService().Summary(context.Background(), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Orders != 3 || sum.LowStock != 4 || sum.FailedJobs != 1 {
+		t.Errorf("unexpected counts: %+v", sum)
+	}
+	if sum.Reviews != (dashboard.Reviews{Fraud: 1, Rectifications: 2, Total: 3}) {
+		t.Errorf("unexpected reviews: %+v", sum.Reviews)
+	}
+	expected := []dashboard.Revenue{{Currency: "EUR", Amount: 10.30}, {Currency: "USD", Amount: 5}}
+	if len(sum.Revenue) != len(expected) || sum.Revenue[0] != expected[0] || sum.Revenue[1] != expected[1] {
+		t.Errorf("expected revenue %+v, got %+v", expected, sum.Revenue)
+	}
+}
+
+func TestSummaryRequiresAdmin(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := dashboard.MakeHTTPHandler(context.Background(), newService(), admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/admin/v1/dashboard", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Data struct{ Summary dashboard.Summary }
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Data.Summary.Orders != 3 {
+			t.Errorf("%s: unexpected summary %+v: %v", c.name, resp.Data.Summary, err)
+		}
+	}
+}
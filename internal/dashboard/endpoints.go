@@ -0,0 +1,45 @@
+package dashboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the dashboard service endpoints under single type.
+type Endpoints struct {
+	SummaryEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the dashboard service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		SummaryEndpoint: admin(MakeSummaryEndpoint(s)),
+	}
+}
+
+func MakeSummaryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(summaryRequest)
+		sum, e := s.Summary(ctx, req.Location)
+		if e != nil {
+			return summaryResponse{Summary: nil, Error: e}, nil
+		}
+		return summaryResponse{Summary: &sum}, nil
+	}
+}
+
+type summaryRequest struct {
+	Location *time.Location
+}
+
+type summaryResponse struct {
+	Summary *Summary `json:"summary,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r summaryResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,34 @@
+package dashboard
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Summary(ctx context.Context, loc *time.Location) (summary Summary, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "summary",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Summary(ctx, loc)
+}
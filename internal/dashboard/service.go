@@ -0,0 +1,150 @@
+package dashboard
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/fraud"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+// pageSize is the orders read at once.
+const pageSize = 500
+
+type Service interface {
+	// Summary returns the summary of today in loc, computed at most once
+	// per Config.TTL for each time zone.
+	Summary(ctx context.Context, loc *time.Location) (Summary, error)
+}
+
+// Repos are the repos the summary is read from.
+type Repos struct {
+	Orders         order.Repo
+	Books          catalog.Repo
+	Fraud          fraud.Repo
+	Rectifications rectification.Repo
+	Vendors        vendors.Repo
+	Exports        export.Repo
+}
+
+// summaryCache holds the latest summary of each time zone, shared by the
+// copies of the service.
+type summaryCache struct {
+	mu        sync.Mutex
+	summaries map[string]Summary
+}
+
+type basicService struct {
+	repos Repos
+	cfg   Config
+	cache *summaryCache
+}
+
+// NewService return basic Service implementation.
+func NewService(repos Repos, cfg Config) Service {
+	return basicService{repos: repos, cfg: cfg, cache: &summaryCache{summaries: make(map[string]Summary)}}
+}
+
+// Summary serves the cached summary of loc while it is fresh. Concurrent
+// requests with a stale cache may each compute it, the last one is kept.
+func (s basicService) Summary(ctx context.Context, loc *time.Location) (Summary, error) {
+	s.cache.mu.Lock()
+	cached, ok := s.cache.summaries[loc.String()]
+	s.cache.mu.Unlock()
+	if ok && time.Since(cached.GeneratedAt) < s.cfg.TTL {
+		return cached, nil
+	}
+
+	sum, err := s.summary(loc)
+	if err != nil {
+		return Summary{}, err
+	}
+	s.cache.mu.Lock()
+	s.cache.summaries[loc.String()] = sum
+	s.cache.mu.Unlock()
+	return sum, nil
+}
+
+func (s basicService) summary(loc *time.Location) (Summary, error) {
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	sum := Summary{Date: from.Format("2006-01-02"), GeneratedAt: now.UTC()}
+
+	var err error
+	if sum.Orders, sum.Revenue, err = s.orders(from, from.AddDate(0, 0, 1)); err != nil {
+		return Summary{}, err
+	}
+
+	frauds, err := s.repos.Fraud.ListByStatus(fraud.StatusPending)
+	if err != nil {
+		return Summary{}, err
+	}
+	rectifications, err := s.repos.Rectifications.ListByStatus(rectification.StatusPending)
+	if err != nil {
+		return Summary{}, err
+	}
+	applications, err := s.repos.Vendors.List(vendors.StatusPending, filter.Expr{})
+	if err != nil {
+		return Summary{}, err
+	}
+	sum.Reviews = Reviews{
+		Fraud:          len(frauds),
+		Rectifications: len(rectifications),
+		Vendors:        len(applications),
+		Total:          len(frauds) + len(rectifications) + len(applications),
+	}
+
+	low := filter.Expr{
+		SQL:  "stock <= ? AND print_on_demand = ? AND delisted = ?",
+		Args: []interface{}{s.cfg.LowStock, false, false},
+	}
+	if _, sum.LowStock, err = s.repos.Books.List(low, "id", 1, 0, db.CountExact); err != nil {
+		return Summary{}, err
+	}
+
+	failed, err := s.repos.Exports.ListJobsByStatus(export.JobFailed)
+	if err != nil {
+		return Summary{}, err
+	}
+	sum.FailedJobs = len(failed)
+	return sum, nil
+}
+
+// orders counts the orders placed between from and to and sums them per
+// currency, in cents.
+func (s basicService) orders(from, to time.Time) (int, []Revenue, error) {
+	f := filter.Expr{SQL: "created_at >= ? AND created_at < ?", Args: []interface{}{from, to}}
+	n := 0
+	cents := make(map[string]int64)
+	for offset := 0; ; offset += pageSize {
+		orders, _, err := s.repos.Orders.List(f, pageSize, offset, db.CountNone)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, o := range orders {
+			cents[o.Currency] += int64(math.Floor(o.TotalPrice*100 + 0.5))
+		}
+		n += len(orders)
+		if len(orders) < pageSize {
+			break
+		}
+	}
+	revenue := make([]Revenue, 0, len(cents))
+	for currency, c := range cents {
+		revenue = append(revenue, Revenue{Currency: currency, Amount: float64(c) / 100})
+	}
+	sort.Slice(revenue, func(i, j int) bool { return revenue[i].Currency < revenue[j].Currency })
+	return n, revenue, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,311 @@
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Kinds of the records merged.
+const (
+	KindBook   = "book"
+	KindAuthor = "author"
+)
+
+// Reasons a pair of records is taken as duplicates.
+const (
+	ReasonISBN     = "isbn"     // the same ISBN, as ISBN-10 or ISBN-13, hyphenated or not
+	ReasonTitle    = "title"    // a similar title, by similar authors if both have some
+	ReasonName     = "name"     // a similar author name
+	ReasonInitials = "initials" // the same last name, the first names matching by their initials
+)
+
+// DefaultMinScore is the similarity from which a pair is a candidate.
+const DefaultMinScore = 0.85
+
+// Candidate is a pair of records likely to be duplicates, the one to keep
+// being the one with the most stock, or books for an author.
+type Candidate struct {
+	Kind          string  `json:"kind"`
+	KeepID        string  `json:"keep_id"`
+	KeepName      string  `json:"keep_name"`
+	DuplicateID   string  `json:"duplicate_id"`
+	DuplicateName string  `json:"duplicate_name"`
+	Score         float64 `json:"score"`
+	Reason        string  `json:"reason"`
+}
+
+// Merge is the request of an admin to merge a duplicate into the record
+// kept.
+type Merge struct {
+	Kind        string `json:"kind"`
+	KeepID      string `json:"keep_id"`
+	DuplicateID string `json:"duplicate_id"`
+	MergedBy    string `json:"merged_by"`
+}
+
+// Validate checks the records to merge are set.
+func (m Merge) Validate() error {
+	var v validate.Validator
+	v.Required("keep_id", m.KeepID)
+	if v.Required("duplicate_id", m.DuplicateID) {
+		v.Check(m.DuplicateID != m.KeepID, "duplicate_id", validate.CodeInvalid, "duplicate_id must differ from keep_id")
+	}
+	v.Required("merged_by", m.MergedBy)
+	return v.Err()
+}
+
+// Redirect is left behind by a merge, sending the requests of the merged
+// record to the one kept.
+type Redirect struct {
+	Kind      string    `json:"kind" gorm:"primary_key"`
+	FromID    string    `json:"from_id" gorm:"primary_key"`
+	ToID      string    `json:"to_id"`
+	MergedBy  string    `json:"merged_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Redirect) TableName() string {
+	return "dedupe_redirects"
+}
+
+// Books returns the pairs of books of score min or more, the most likely
+// first. The delisted and retired books are left out, the merged ones
+// among them. Similar titles are only compared within the books sharing
+// the first letters of their title, which a typo there misses.
+func Books(books []catalog.Book, min float64) []Candidate {
+	var listed []catalog.Book
+	for _, b := range books {
+		if !b.Delisted && b.Visibility != catalog.VisibilityRetired {
+			listed = append(listed, b)
+		}
+	}
+	seen := make(map[[2]string]bool)
+	var list []Candidate
+	add := func(a, b catalog.Book, score float64, reason string) {
+		keep, dup := a, b
+		if b.Stock > a.Stock || (b.Stock == a.Stock && b.ID < a.ID) {
+			keep, dup = b, a
+		}
+		k := [2]string{keep.ID, dup.ID}
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		list = append(list, Candidate{Kind: KindBook, KeepID: keep.ID, KeepName: keep.Title, DuplicateID: dup.ID, DuplicateName: dup.Title, Score: score, Reason: reason})
+	}
+
+	byISBN := make(map[string][]catalog.Book)
+	byPrefix := make(map[string][]catalog.Book)
+	for _, b := range listed {
+		if isbn := ISBN13(b.ISBN); isbn != "" {
+			byISBN[isbn] = append(byISBN[isbn], b)
+		}
+		p := []rune(fold(b.Title))
+		if len(p) > 3 {
+			p = p[:3]
+		}
+		byPrefix[string(p)] = append(byPrefix[string(p)], b)
+	}
+	for _, group := range byISBN {
+		for i := range group {
+			for _, b := range group[i+1:] {
+				add(group[i], b, 1, ReasonISBN)
+			}
+		}
+	}
+	for _, group := range byPrefix {
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				score := similarity(fold(a.Title), fold(b.Title))
+				if len(a.Authors) > 0 && len(b.Authors) > 0 {
+					score = 0.7*score + 0.3*sameAuthors(a, b)
+				}
+				if score >= min {
+					add(a, b, score, ReasonTitle)
+				}
+			}
+		}
+	}
+	sortCandidates(list)
+	return list
+}
+
+// Authors returns the pairs of authors of the books of score min or more,
+// the most likely first.
+func Authors(books []catalog.Book, min float64) []Candidate {
+	count := make(map[string]int)
+	var authors []catalog.Author
+	for _, b := range books {
+		for _, a := range b.Authors {
+			if count[a.ID] == 0 {
+				authors = append(authors, a)
+			}
+			count[a.ID]++
+		}
+	}
+	var list []Candidate
+	for i, a := range authors {
+		for _, b := range authors[i+1:] {
+			score, reason := similarity(fold(name(a)), fold(name(b))), ReasonName
+			if score < DefaultMinScore && initials(a, b) {
+				score, reason = DefaultMinScore, ReasonInitials
+			}
+			if score < min {
+				continue
+			}
+			keep, dup := a, b
+			if count[b.ID] > count[a.ID] || (count[b.ID] == count[a.ID] && b.ID < a.ID) {
+				keep, dup = b, a
+			}
+			list = append(list, Candidate{Kind: KindAuthor, KeepID: keep.ID, KeepName: name(keep), DuplicateID: dup.ID, DuplicateName: name(dup), Score: score, Reason: reason})
+		}
+	}
+	sortCandidates(list)
+	return list
+}
+
+func sortCandidates(list []Candidate) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].KeepName < list[j].KeepName
+	})
+}
+
+// ISBN13 returns the ISBN-13 of an ISBN-10 or ISBN-13, hyphens and spaces
+// stripped, or "" if it's neither.
+func ISBN13(isbn string) string {
+	isbn = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+	switch len(isbn) {
+	case 13:
+		if strings.Trim(isbn, "0123456789") != "" {
+			return ""
+		}
+		return isbn
+	case 10:
+		if strings.Trim(isbn[:9], "0123456789") != "" {
+			return ""
+		}
+		s := "978" + isbn[:9]
+		sum := 0
+		for i, r := range s {
+			d := int(r - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return s + string(rune('0'+(10-sum%10)%10))
+	}
+	return ""
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the
+// longest of them, 1 for the same strings.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	s, t := []rune(a), []rune(b)
+	n := len(s)
+	if len(t) > n {
+		n = len(t)
+	}
+	return 1 - float64(distance(s, t))/float64(n)
+}
+
+// distance returns the Levenshtein distance of s and t.
+func distance(s, t []rune) int {
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
+
+// sameAuthors returns 1 if a and b share an author, the same one or
+// one matching by the initials, else the similarity of their authors.
+func sameAuthors(a, b catalog.Book) float64 {
+	for _, x := range a.Authors {
+		for _, y := range b.Authors {
+			if x.ID == y.ID || initials(x, y) {
+				return 1
+			}
+		}
+	}
+	return similarity(authorNames(a), authorNames(b))
+}
+
+// authorNames returns the folded names of the authors of b, sorted, so
+// that their order doesn't matter.
+func authorNames(b catalog.Book) string {
+	names := make([]string, 0, len(b.Authors))
+	for _, a := range b.Authors {
+		names = append(names, fold(name(a)))
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func name(a catalog.Author) string {
+	return strings.TrimSpace(a.FirstName + " " + a.LastName)
+}
+
+// initials tells whether a and b share their last name, and the words of
+// their first names match one for one, an initial matching any word it
+// starts, e.g. "J. K." and "Joanne Kathleen".
+func initials(a, b catalog.Author) bool {
+	if fold(a.LastName) == "" || fold(a.LastName) != fold(b.LastName) {
+		return false
+	}
+	x, y := strings.Fields(fold(a.FirstName)), strings.Fields(fold(b.FirstName))
+	if len(x) == 0 || len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] && !(len(x[i]) == 1 && strings.HasPrefix(y[i], x[i])) && !(len(y[i]) == 1 && strings.HasPrefix(x[i], y[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// fold lower cases s and replaces punctuation with spaces, so that "The
+// Hobbit: Or There and Back Again" and "the hobbit or there and back
+// again" compare equal.
+func fold(s string) string {
+	f := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		if r == '\'' {
+			return -1
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(f), " ")
+}
@@ -0,0 +1,193 @@
+package dedupe_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/dedupe"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the redirects and merged books in memory.
+type repo struct {
+	dedupe.Repo
+	redirects []dedupe.Redirect
+	books     *catalogRepo
+}
+
+func (r *repo) MergeBooks(keep, dup *catalog.Book, red *dedupe.Redirect) error {
+	r.books.save(*keep)
+	r.books.save(*dup)
+	r.redirects = append(r.redirects, *red)
+	return nil
+}
+
+func (r *repo) Redirect(kind, fromID string) (dedupe.Redirect, error) {
+	for _, red := range r.redirects {
+		if red.Kind == kind && red.FromID == fromID {
+			return red, nil
+		}
+	}
+	return dedupe.Redirect{}, db.ErrNotFound
+}
+
+// catalogRepo keeps the books in memory.
+type catalogRepo struct {
+	catalog.Repo
+	books []catalog.Book
+}
+
+func (r *catalogRepo) ListAll() ([]catalog.Book, error) {
+	return r.books, nil
+}
+
+func (r *catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	for _, b := range r.books {
+		if b.ID == ID {
+			return b, nil
+		}
+	}
+	return catalog.Book{}, db.ErrNotFound
+}
+
+func (r *catalogRepo) save(b catalog.Book) {
+	for i := range r.books {
+		if r.books[i].ID == b.ID {
+			r.books[i] = b
+		}
+	}
+}
+
+func TestISBN13(t *testing.T) {
+	for isbn, want := range map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"978-0-306-40615-7": "9780306406157",
+		"080442957X":        "9780804429573",
+		"12345":             "",
+		"97803064061AB":     "",
+	} {
+		if got := dedupe.ISBN13(isbn); got != want {
+			t.Errorf("%s: expected %q, got %q", isbn, want, got)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	tolkien := catalog.Author{ID: "a1", FirstName: "J. R. R.", LastName: "Tolkien"}
+	books := []catalog.Book{
+		{ID: "b1", ISBN: "0-261-10221-4", Title: "The Fellowship of the Ring", Stock: 2, Authors: []catalog.Author{tolkien}},
+		{ID: "b2", ISBN: "9780261102217", Title: "Fellowship of the Ring", Stock: 5},
+		{ID: "b3", ISBN: "9780000000003", Title: "The Hobbit", Authors: []catalog.Author{tolkien}},
+		{ID: "b4", ISBN: "9780000000004", Title: "The Hobit", Authors: []catalog.Author{{ID: "a2", FirstName: "John Ronald Reuel", LastName: "Tolkien"}}},
+		{ID: "b5", ISBN: "9780000000005", Title: "The Hobbit", Delisted: true},
+		{ID: "b6", ISBN: "9780000000006", Title: "The Silmarillion"},
+	}
+	s := dedupe.NewService(&repo{}, &catalogRepo{books: books})
+
+	list, err := s.Candidates(context.Background(), dedupe.KindBook, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 candidates, got %+v", list)
+	}
+	if c := list[0]; c.KeepID != "b2" || c.DuplicateID != "b1" || c.Reason != dedupe.ReasonISBN || c.Score != 1 {
+		t.Errorf("expected the ISBN variants with b2 kept for its stock, got %+v", c)
+	}
+	if c := list[1]; c.KeepID != "b3" || c.DuplicateID != "b4" || c.Reason != dedupe.ReasonTitle {
+		t.Errorf("expected the misspelt title, got %+v", c)
+	}
+
+	list, err = s.Candidates(context.Background(), dedupe.KindAuthor, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].KeepID != "a1" || list[0].DuplicateID != "a2" || list[0].Reason != dedupe.ReasonInitials {
+		t.Errorf("expected the authors matching by their initials, got %+v", list)
+	}
+	if _, err := s.Candidates(context.Background(), "genre", 0); err != dedupe.ErrUnknownKind {
+		t.Errorf("expected ErrUnknownKind, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	books := &catalogRepo{books: []catalog.Book{
+		{ID: "b1", Title: "Dune", Stock: 3, Visibility: catalog.VisibilityLive},
+		{ID: "b2", Title: "Dune", Stock: 2, CoverURL: "https://covers.example.com/dune.jpg", Visibility: catalog.VisibilityLive},
+	}}
+	r := &repo{books: books}
+	s := dedupe.NewService(r, books)
+
+	red, err := s.Merge(ctx, dedupe.Merge{Kind: dedupe.KindBook, KeepID: "b1", DuplicateID: "b2", MergedBy: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if red.FromID != "b2" || red.ToID != "b1" {
+		t.Errorf("expected a redirect from b2 to b1, got %+v", red)
+	}
+	keep, dup := books.books[0], books.books[1]
+	if keep.Stock != 5 || keep.CoverURL == "" {
+		t.Errorf("expected the stock and the cover moved to b1, got %+v", keep)
+	}
+	if dup.Stock != 0 || !dup.Delisted || dup.Visibility != catalog.VisibilityRetired {
+		t.Errorf("expected b2 retired, got %+v", dup)
+	}
+	if _, err := s.Merge(ctx, dedupe.Merge{Kind: dedupe.KindBook, KeepID: "b2", DuplicateID: "b1", MergedBy: "admin"}); err != dedupe.ErrAlreadyMerged {
+		t.Errorf("merge into a merged book: expected ErrAlreadyMerged, got %v", err)
+	}
+	if _, err := s.Merge(ctx, dedupe.Merge{Kind: dedupe.KindBook, KeepID: "b1", DuplicateID: "b9", MergedBy: "admin"}); err != catalog.ErrBookNotFound {
+		t.Errorf("unknown book: expected ErrBookNotFound, got %v", err)
+	}
+
+	h := dedupe.Handler(s, http.NotFoundHandler())
+	for path, want := range map[string]string{
+		"/catalog/v1/b2?currency=EUR": "/catalog/v1/b1?currency=EUR",
+		"/books/v1/b2/og":             "/books/v1/b1/og",
+		"/catalog/v1/b1":              "",
+		"/catalog/v1/admin/books/b2":  "",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if got := w.Header().Get("Location"); got != want {
+			t.Errorf("%s: expected a redirect to %q, got %d %q", path, want, w.Code, got)
+		}
+	}
+}
+
+func TestCandidatesRequireAdmin(t *testing.T) {
+	s := dedupe.NewService(&repo{}, &catalogRepo{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := dedupe.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/dedupe/v1/candidates", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package dedupe
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the dedupe service endpoints under single type.
+type Endpoints struct {
+	CandidatesEndpoint endpoint.Endpoint
+	MergeEndpoint      endpoint.Endpoint
+	RedirectsEndpoint  endpoint.Endpoint
+	ResolveEndpoint    endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the dedupe service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CandidatesEndpoint: admin(MakeCandidatesEndpoint(s)),
+		MergeEndpoint:      admin(MakeMergeEndpoint(s)),
+		RedirectsEndpoint:  admin(MakeRedirectsEndpoint(s)),
+		ResolveEndpoint:    admin(MakeResolveEndpoint(s)),
+	}
+}
+
+func MakeCandidatesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(candidatesRequest)
+		list, e := s.Candidates(ctx, req.Kind, req.MinScore)
+		if e != nil {
+			return candidatesResponse{Candidates: make([]Candidate, 0), Error: e}, nil
+		}
+		return candidatesResponse{Candidates: list}, nil
+	}
+}
+
+func MakeMergeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(Merge)
+		r, e := s.Merge(ctx, req)
+		if e != nil {
+			return redirectResponse{Redirect: nil, Error: e}, nil
+		}
+		return redirectResponse{Redirect: &r, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeRedirectsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(redirectsRequest)
+		list, e := s.Redirects(ctx, req.Kind)
+		if e != nil {
+			return redirectsResponse{Redirects: make([]Redirect, 0), Error: e}, nil
+		}
+		return redirectsResponse{Redirects: list}, nil
+	}
+}
+
+func MakeResolveEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resolveRequest)
+		r, e := s.Resolve(ctx, req.Kind, req.ID)
+		if e != nil {
+			return redirectResponse{Redirect: nil, Error: e}, nil
+		}
+		return redirectResponse{Redirect: &r}, nil
+	}
+}
+
+type candidatesRequest struct {
+	Kind     string
+	MinScore float64
+}
+
+type candidatesResponse struct {
+	Candidates []Candidate `json:"candidates"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r candidatesResponse) error() error {
+	return r.Error
+}
+
+type redirectsRequest struct {
+	Kind string
+}
+
+type redirectsResponse struct {
+	Redirects []Redirect `json:"redirects"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r redirectsResponse) error() error {
+	return r.Error
+}
+
+type resolveRequest struct {
+	Kind string
+	ID   string
+}
+
+type redirectResponse struct {
+	Status   int       `json:"-"`
+	Redirect *Redirect `json:"redirect,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r redirectResponse) status() int {
+	return r.Status
+}
+
+func (r redirectResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,70 @@
+package dedupe
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Candidates(ctx context.Context, kind string, minScore float64) (list []Candidate, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "candidates", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Candidates(ctx, kind, minScore)
+	return
+}
+
+func (mw instrmw) Merge(ctx context.Context, m Merge) (r Redirect, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "merge", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	r, err = mw.next.Merge(ctx, m)
+	return
+}
+
+func (mw instrmw) Redirects(ctx context.Context, kind string) (list []Redirect, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "redirects", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Redirects(ctx, kind)
+	return
+}
+
+func (mw instrmw) Resolve(ctx context.Context, kind, ID string) (r Redirect, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "resolve", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	r, err = mw.next.Resolve(ctx, kind, ID)
+	return
+}
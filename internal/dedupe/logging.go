@@ -0,0 +1,77 @@
+package dedupe
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Candidates(ctx context.Context, kind string, minScore float64) (list []Candidate, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "candidates",
+			"kind", kind,
+			"min_score", minScore,
+			"candidates", len(list),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Candidates(ctx, kind, minScore)
+}
+
+func (s loggingService) Merge(ctx context.Context, m Merge) (r Redirect, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "merge",
+			"kind", m.Kind,
+			"keep_id", m.KeepID,
+			"duplicate_id", m.DuplicateID,
+			"merged_by", m.MergedBy,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Merge(ctx, m)
+}
+
+func (s loggingService) Redirects(ctx context.Context, kind string) (list []Redirect, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "redirects",
+			"kind", kind,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Redirects(ctx, kind)
+}
+
+func (s loggingService) Resolve(ctx context.Context, kind, ID string) (r Redirect, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "resolve",
+			"kind", kind,
+			"id", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Resolve(ctx, kind, ID)
+}
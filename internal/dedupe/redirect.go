@@ -0,0 +1,51 @@
+package dedupe
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Handler permanently redirects the GET requests of the pages of merged
+// books, /catalog/v1/{id} and /books/v1/{id}/og, to the ones of the books
+// kept, the links and bookmarks to a duplicate still landing on the book.
+func Handler(s Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" && req.Method != "HEAD" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		prefix, id, suffix := bookPage(req.URL.Path)
+		if id == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		r, err := s.Resolve(req.Context(), KindBook, id)
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		u := *req.URL
+		u.Path, u.RawPath = prefix+r.ToID+suffix, ""
+		http.Redirect(w, req, u.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// bookPage splits the path of a book page around the ID of the book, the
+// ID empty if path isn't one.
+func bookPage(path string) (prefix, id, suffix string) {
+	switch {
+	case strings.HasPrefix(path, "/catalog/v1/"):
+		id = strings.TrimPrefix(path, "/catalog/v1/")
+		if strings.Contains(id, "/") || id == "search" {
+			return "", "", ""
+		}
+		return "/catalog/v1/", id, ""
+	case strings.HasPrefix(path, "/books/v1/") && strings.HasSuffix(path, "/og"):
+		id = strings.TrimSuffix(strings.TrimPrefix(path, "/books/v1/"), "/og")
+		if strings.Contains(id, "/") {
+			return "", "", ""
+		}
+		return "/books/v1/", id, "/og"
+	}
+	return "", "", ""
+}
@@ -0,0 +1,27 @@
+package dedupe
+
+import "github.com/kavirajk/bookshop/catalog"
+
+// Repo abstracts all the persistant storage operations of Dedupe Service
+type Repo interface {
+	// MergeBooks saves keep and dup, points the order lines, cart lines
+	// and shelf entries of dup to keep and stores the redirect, in one go.
+	// The redirects to dup of its own earlier merges are pointed to keep.
+	// The lines of an order or cart already holding keep stay on dup, and
+	// so does the shelf entry of a user already shelving keep, its rating
+	// carried over if keep is unrated. It fails with db.ErrConflict if
+	// either book has another version stored.
+	MergeBooks(keep, dup *catalog.Book, r *Redirect) error
+	// MergeAuthors points the books and the redirects of dup to keep,
+	// deletes dup and stores the redirect, in one go.
+	MergeAuthors(keepID, dupID string, r *Redirect) error
+	// Author returns an author, db.ErrNotFound if none.
+	Author(ID string) (catalog.Author, error)
+	// Redirect returns the redirect of a merged record, db.ErrNotFound if
+	// none.
+	Redirect(kind, fromID string) (Redirect, error)
+	// ListRedirects returns the redirects of kind, all if empty, latest
+	// first.
+	ListRedirects(kind string) ([]Redirect, error)
+	Drop() error
+}
@@ -0,0 +1,173 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrUnknownKind    = errors.New("kind must be book or author")
+	ErrInvalidScore   = errors.New("min_score must be between 0 and 1")
+	ErrAuthorNotFound = errors.New("author not found")
+	ErrAlreadyMerged  = errors.New("record is already merged")
+	ErrNoRedirect     = errors.New("record is not merged")
+	ErrConflict       = errors.New("books changed during the merge, merge them again")
+)
+
+type Service interface {
+	// Candidates returns the likely duplicate books or authors of score
+	// minScore or more, DefaultMinScore if 0, the most likely first.
+	Candidates(ctx context.Context, kind string, minScore float64) ([]Candidate, error)
+
+	// Merge merges the duplicate into the record kept, leaving a redirect
+	// from the duplicate. A merged book is delisted and retired, its stock
+	// added to the kept one, a merged author deleted.
+	Merge(ctx context.Context, m Merge) (Redirect, error)
+
+	// Redirects returns the merges of kind, all if empty, latest first.
+	Redirects(ctx context.Context, kind string) ([]Redirect, error)
+
+	// Resolve returns the redirect of a merged record, ErrNoRedirect if it
+	// isn't merged.
+	Resolve(ctx context.Context, kind, ID string) (Redirect, error)
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo) Service {
+	return basicService{r: r, catalog: catalog}
+}
+
+func (s basicService) Candidates(ctx context.Context, kind string, minScore float64) ([]Candidate, error) {
+	if minScore == 0 {
+		minScore = DefaultMinScore
+	}
+	if minScore < 0 || minScore > 1 {
+		return nil, ErrInvalidScore
+	}
+	books, err := s.catalog.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case KindBook:
+		return Books(books, minScore), nil
+	case KindAuthor:
+		return Authors(books, minScore), nil
+	}
+	return nil, ErrUnknownKind
+}
+
+func (s basicService) Merge(ctx context.Context, m Merge) (Redirect, error) {
+	if err := m.Validate(); err != nil {
+		return Redirect{}, err
+	}
+	switch m.Kind {
+	case KindBook, KindAuthor:
+	default:
+		return Redirect{}, ErrUnknownKind
+	}
+	// Neither record may be merged already, merging into a merged record
+	// would leave a redirect to a retired one.
+	for _, ID := range []string{m.KeepID, m.DuplicateID} {
+		_, err := s.r.Redirect(m.Kind, ID)
+		if err == nil {
+			return Redirect{}, ErrAlreadyMerged
+		}
+		if err != db.ErrNotFound {
+			return Redirect{}, err
+		}
+	}
+	r := Redirect{Kind: m.Kind, FromID: m.DuplicateID, ToID: m.KeepID, MergedBy: m.MergedBy, CreatedAt: time.Now().UTC()}
+	merge := s.mergeBooks
+	if m.Kind == KindAuthor {
+		merge = s.mergeAuthors
+	}
+	if err := merge(r); err != nil {
+		return Redirect{}, err
+	}
+	return r, nil
+}
+
+func (s basicService) mergeBooks(r Redirect) error {
+	keep, err := s.book(r.ToID)
+	if err != nil {
+		return err
+	}
+	dup, err := s.book(r.FromID)
+	if err != nil {
+		return err
+	}
+	// The kept book takes what it misses from the duplicate.
+	if strings.TrimSpace(keep.Description) == "" {
+		keep.Description = dup.Description
+	}
+	if strings.TrimSpace(keep.CoverURL) == "" {
+		keep.CoverURL = dup.CoverURL
+	}
+	if keep.Series == "" {
+		keep.Series = dup.Series
+	}
+	if keep.Location == "" {
+		keep.Location = dup.Location
+	}
+	keep.Stock += dup.Stock
+	dup.Stock, dup.Delisted, dup.Visibility = 0, true, catalog.VisibilityRetired
+	if err := s.r.MergeBooks(&keep, &dup, &r); err != nil {
+		if err == db.ErrConflict {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (s basicService) book(ID string) (catalog.Book, error) {
+	b, err := s.catalog.GetByID(ID)
+	if err == db.ErrNotFound {
+		return catalog.Book{}, catalog.ErrBookNotFound
+	}
+	return b, err
+}
+
+func (s basicService) mergeAuthors(r Redirect) error {
+	for _, ID := range []string{r.ToID, r.FromID} {
+		_, err := s.r.Author(ID)
+		if err == db.ErrNotFound {
+			return ErrAuthorNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.r.MergeAuthors(r.ToID, r.FromID, &r)
+}
+
+func (s basicService) Redirects(ctx context.Context, kind string) ([]Redirect, error) {
+	switch kind {
+	case "", KindBook, KindAuthor:
+	default:
+		return nil, ErrUnknownKind
+	}
+	return s.r.ListRedirects(kind)
+}
+
+func (s basicService) Resolve(ctx context.Context, kind, ID string) (Redirect, error) {
+	r, err := s.r.Redirect(kind, ID)
+	if err == db.ErrNotFound {
+		return Redirect{}, ErrNoRedirect
+	}
+	return r, err
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
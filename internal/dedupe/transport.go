@@ -0,0 +1,196 @@
+package dedupe
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrUnknownKind:    "dedupe.unknown_kind",
+		ErrInvalidScore:   "dedupe.invalid_score",
+		ErrAuthorNotFound: "dedupe.author_not_found",
+		ErrAlreadyMerged:  "dedupe.already_merged",
+		ErrNoRedirect:     "dedupe.no_redirect",
+		ErrConflict:       "dedupe.conflict",
+	})
+}
+
+// MakeHTTPHandler mounts the dedupe endpoints, served to the requests admin
+// lets through, e.g. auth.NewMiddleware chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	candidatesHandler := httptransport.NewServer(
+		e.CandidatesEndpoint,
+		decodeCandidatesRequest,
+		encodeResponse,
+		options...,
+	)
+	mergeHandler := httptransport.NewServer(
+		e.MergeEndpoint,
+		decodeMergeRequest,
+		encodeResponse,
+		options...,
+	)
+	redirectsHandler := httptransport.NewServer(
+		e.RedirectsEndpoint,
+		decodeRedirectsRequest,
+		encodeResponse,
+		options...,
+	)
+	resolveHandler := httptransport.NewServer(
+		e.ResolveEndpoint,
+		decodeResolveRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Admin endpoints
+	r.Handle("/dedupe/v1/candidates", candidatesHandler).Methods("GET")
+	r.Handle("/dedupe/v1/merges", mergeHandler).Methods("POST")
+	r.Handle("/dedupe/v1/merges", redirectsHandler).Methods("GET")
+	r.Handle("/dedupe/v1/redirects/{kind}/{id}", resolveHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// decodeCandidatesRequest defaults to the books, e.g.
+// /dedupe/v1/candidates?kind=author&min_score=0.9
+func decodeCandidatesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	q := req.URL.Query()
+	r := candidatesRequest{Kind: q.Get("kind")}
+	if r.Kind == "" {
+		r.Kind = KindBook
+	}
+	if v := q.Get("min_score"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, ErrInvalidScore
+		}
+		r.MinScore = score
+	}
+	return r, nil
+}
+
+func decodeMergeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var m Merge
+	if err := schema.Decode(req.Body, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func decodeRedirectsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return redirectsRequest{Kind: req.URL.Query().Get("kind")}, nil
+}
+
+func decodeResolveRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	kind, ok := vars["kind"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "kind")
+	}
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "id")
+	}
+	return resolveRequest{Kind: kind, ID: id}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrAuthorNotFound, ErrNoRedirect, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyMerged, ErrConflict:
+		return http.StatusConflict
+	case ErrBadRouting, ErrUnknownKind, ErrInvalidScore, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
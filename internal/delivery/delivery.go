@@ -0,0 +1,123 @@
+package delivery
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// AnyCountry is the destination of the transit times of the countries
+// without one of their own.
+const AnyCountry = "*"
+
+// maxTransitDays bounds the transit times.
+const maxTransitDays = 60
+
+// Transit is the carrier transit time of a shipping option to a country,
+// in working days after the parcel leaves the warehouse.
+type Transit struct {
+	Option    string    `json:"option" gorm:"primary_key"` // e.g. "standard", "express"
+	Country   string    `json:"country" gorm:"primary_key"`
+	Carrier   string    `json:"carrier"`
+	MinDays   int       `json:"min_days"`
+	MaxDays   int       `json:"max_days"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Transit) TableName() string {
+	return "delivery_transits"
+}
+
+var (
+	countryRe = regexp.MustCompile(`^[A-Z]{2}$`)
+	optionRe  = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+// Validate checks t once its country upper cased, the transit times being
+// a range.
+func (t *Transit) Validate() error {
+	var v validate.Validator
+	t.Country = strings.ToUpper(strings.TrimSpace(t.Country))
+	v.Check(optionRe.MatchString(t.Option), "option", validate.CodeInvalid, "option must be lower case letters, digits, - or _")
+	v.Check(t.Country == AnyCountry || countryRe.MatchString(t.Country), "country", validate.CodeInvalid, "country must be an ISO 3166 code or *")
+	v.Required("carrier", t.Carrier)
+	if v.Range("min_days", t.MinDays, 0, maxTransitDays) {
+		v.Range("max_days", t.MaxDays, t.MinDays, maxTransitDays)
+	}
+	return v.Err()
+}
+
+// Window is when a parcel of a shipping option is expected, the dates at
+// midnight in the time zone of the warehouse.
+type Window struct {
+	Option  string    `json:"option"`
+	Carrier string    `json:"carrier"`
+	ShipsOn time.Time `json:"ships_on"`
+	// Earliest and Latest are the first and last days of the delivery.
+	Earliest time.Time `json:"earliest"`
+	Latest   time.Time `json:"latest"`
+}
+
+// Promise is the delivery window of the shipping option chosen for an
+// order at checkout, kept to report the promises against the deliveries.
+type Promise struct {
+	OrderID string `json:"order_id" gorm:"primary_key"`
+	Country string `json:"country"`
+	Window
+	PromisedAt time.Time `json:"promised_at"`
+}
+
+func (Promise) TableName() string {
+	return "delivery_promises"
+}
+
+// Warehouse is when the orders leave the warehouse, Monday to Friday.
+type Warehouse struct {
+	// CutOff is the time of day, in Location, the orders are to be placed
+	// before to be handled that day.
+	CutOff   time.Duration
+	Location *time.Location
+	// HandlingDays are the working days to pick and pack an order, zero
+	// if it ships the day it is handled.
+	HandlingDays int
+}
+
+// Estimate returns the window of an order placed at, shipped by the option
+// of t.
+func (w Warehouse) Estimate(t Transit, at time.Time) Window {
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := at.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if !workday(day) || local.Sub(day) >= w.CutOff {
+		day = addWorkdays(day, 1)
+	}
+	ships := addWorkdays(day, w.HandlingDays)
+	return Window{
+		Option:   t.Option,
+		Carrier:  t.Carrier,
+		ShipsOn:  ships,
+		Earliest: addWorkdays(ships, t.MinDays),
+		Latest:   addWorkdays(ships, t.MaxDays),
+	}
+}
+
+func workday(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// addWorkdays returns the n-th working day after day, day itself if n is
+// zero. AddDate keeps midnight across the daylight saving changes.
+func addWorkdays(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, 1)
+		if workday(day) {
+			n--
+		}
+	}
+	return day
+}
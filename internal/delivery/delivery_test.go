@@ -0,0 +1,182 @@
+package delivery_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/delivery"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestEstimate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	w := delivery.Warehouse{CutOff: 14 * time.Hour, Location: berlin}
+	standard := delivery.Transit{Option: "standard", Carrier: "dhl", MinDays: 1, MaxDays: 3}
+
+	for name, c := range map[string]struct {
+		at                      string
+		ships, earliest, latest string
+	}{
+		"before the cut-off": {"2026-10-14T11:59:00Z", "2026-10-14", "2026-10-15", "2026-10-19"},
+		"after the cut-off":  {"2026-10-14T12:00:00Z", "2026-10-15", "2026-10-16", "2026-10-20"},
+		"friday late":        {"2026-10-16T15:00:00Z", "2026-10-19", "2026-10-20", "2026-10-22"},
+		"saturday":           {"2026-10-17T08:00:00Z", "2026-10-19", "2026-10-20", "2026-10-22"},
+		"summer time ends":   {"2026-10-23T13:00:00Z", "2026-10-26", "2026-10-27", "2026-10-29"},
+	} {
+		at, _ := time.Parse(time.RFC3339, c.at)
+		win := w.Estimate(standard, at)
+		got := []string{win.ShipsOn.Format("2006-01-02"), win.Earliest.Format("2006-01-02"), win.Latest.Format("2006-01-02")}
+		if got[0] != c.ships || got[1] != c.earliest || got[2] != c.latest {
+			t.Errorf("%s: expected %s, %s to %s, got %v", name, c.ships, c.earliest, c.latest, got)
+		}
+		if win.Latest.Hour() != 0 {
+			t.Errorf("%s: expected the dates at midnight, got %v", name, win.Latest)
+		}
+	}
+
+	w.HandlingDays = 2
+	if win := w.Estimate(standard, time.Date(2026, 10, 15, 9, 0, 0, 0, berlin)); win.ShipsOn.Day() != 19 {
+		t.Errorf("handling days: expected to ship monday, got %v", win.ShipsOn)
+	}
+}
+
+// repo keeps the transits and the promises in memory.
+type repo struct {
+	delivery.Repo
+	transits []delivery.Transit
+	promises map[string]delivery.Promise
+}
+
+func (r *repo) ListTransits() ([]delivery.Transit, error) {
+	return r.transits, nil
+}
+
+func (r *repo) SaveTransit(t *delivery.Transit) error {
+	r.transits = append(r.transits, *t)
+	return nil
+}
+
+func (r *repo) DeleteTransit(option, country string) error {
+	for i, t := range r.transits {
+		if t.Option == option && t.Country == country {
+			r.transits = append(r.transits[:i], r.transits[i+1:]...)
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *repo) SavePromise(p *delivery.Promise) error {
+	r.promises[p.OrderID] = *p
+	return nil
+}
+
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, order.ErrOrderNotFound
+	}
+	return o, nil
+}
+
+type addressRepo struct {
+	address.Repo
+}
+
+func (addressRepo) GetCheck(orderID string) (address.Check, error) {
+	return address.Check{OrderID: orderID, Address: address.Address{Country: "FR"}}, nil
+}
+
+func TestPromise(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{
+		transits: []delivery.Transit{
+			{Option: "express", Country: delivery.AnyCountry, Carrier: "ups", MinDays: 1, MaxDays: 1},
+			{Option: "standard", Country: delivery.AnyCountry, Carrier: "post", MinDays: 3, MaxDays: 6},
+			{Option: "standard", Country: "FR", Carrier: "colissimo", MinDays: 2, MaxDays: 4},
+			{Option: "economy", Country: "DE", Carrier: "dhl", MinDays: 4, MaxDays: 8},
+		},
+		promises: make(map[string]delivery.Promise),
+	}
+	paid := time.Now()
+	orders := orderRepo{orders: map[string]order.Order{"o1": {ID: "o1"}, "o2": {ID: "o2", PaidAt: &paid}}}
+	s := delivery.NewService(r, orders, addressRepo{}, delivery.Warehouse{CutOff: 14 * time.Hour})
+
+	options, err := s.OrderOptions(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 || options[0].Option != "express" || options[1].Carrier != "colissimo" {
+		t.Errorf("expected express then the standard of FR, got %+v", options)
+	}
+
+	p, err := s.Promise(ctx, "o1", "standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Country != "FR" || p.Carrier != "colissimo" || !p.Latest.Equal(options[1].Latest) || r.promises["o1"].Option != "standard" {
+		t.Errorf("expected the standard window recorded, got %+v", p)
+	}
+	if _, err := s.Promise(ctx, "o1", "economy"); err != delivery.ErrUnknownOption {
+		t.Errorf("option of another country: expected ErrUnknownOption, got %v", err)
+	}
+	if _, err := s.Promise(ctx, "o2", "standard"); err != delivery.ErrPromiseFinal {
+		t.Errorf("paid order: expected ErrPromiseFinal, got %v", err)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	r := &repo{}
+	s := delivery.NewService(r, orderRepo{}, addressRepo{}, delivery.Warehouse{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := delivery.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	transit := `{"carrier":"dhl","min_days":1,"max_days":3}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"set without token", "PUT", "/delivery/v1/transits/standard/DE", transit, "", http.StatusUnauthorized},
+		{"set by customer", "PUT", "/delivery/v1/transits/standard/DE", transit, customer, http.StatusForbidden},
+		{"delete by customer", "DELETE", "/delivery/v1/transits/standard/DE", "", customer, http.StatusForbidden},
+		{"list by customer", "GET", "/delivery/v1/transits", "", customer, http.StatusForbidden},
+		{"set by admin", "PUT", "/delivery/v1/transits/standard/DE", transit, staff, http.StatusOK},
+		{"list by admin", "GET", "/delivery/v1/transits", "", staff, http.StatusOK},
+		{"delete by admin", "DELETE", "/delivery/v1/transits/standard/DE", "", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if len(r.transits) != 0 {
+		t.Errorf("expected the transit set then deleted by the admin only, got %+v", r.transits)
+	}
+}
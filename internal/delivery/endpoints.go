@@ -0,0 +1,162 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the delivery service endpoints under single type.
+type Endpoints struct {
+	OptionsEndpoint       endpoint.Endpoint
+	OrderOptionsEndpoint  endpoint.Endpoint
+	PromiseEndpoint       endpoint.Endpoint
+	OrderPromiseEndpoint  endpoint.Endpoint
+	TransitsEndpoint      endpoint.Endpoint
+	SetTransitEndpoint    endpoint.Endpoint
+	DeleteTransitEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the delivery service endpoints. The transit times are restricted by
+// admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		OptionsEndpoint:       MakeOptionsEndpoint(s),
+		OrderOptionsEndpoint:  MakeOrderOptionsEndpoint(s),
+		PromiseEndpoint:       MakePromiseEndpoint(s),
+		OrderPromiseEndpoint:  MakeOrderPromiseEndpoint(s),
+		TransitsEndpoint:      admin(MakeTransitsEndpoint(s)),
+		SetTransitEndpoint:    admin(MakeSetTransitEndpoint(s)),
+		DeleteTransitEndpoint: admin(MakeDeleteTransitEndpoint(s)),
+	}
+}
+
+func MakeOptionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(optionsRequest)
+		windows, e := s.Options(ctx, req.Country)
+		if e != nil {
+			return optionsResponse{Options: make([]Window, 0), Error: e}, nil
+		}
+		return optionsResponse{Options: windows}, nil
+	}
+}
+
+func MakeOrderOptionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		windows, e := s.OrderOptions(ctx, req.OrderID)
+		if e != nil {
+			return optionsResponse{Options: make([]Window, 0), Error: e}, nil
+		}
+		return optionsResponse{Options: windows}, nil
+	}
+}
+
+func MakePromiseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		p, e := s.Promise(ctx, req.OrderID, req.Option)
+		if e != nil {
+			return promiseResponse{Promise: nil, Error: e}, nil
+		}
+		return promiseResponse{Promise: &p, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeOrderPromiseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		p, e := s.OrderPromise(ctx, req.OrderID)
+		if e != nil {
+			return promiseResponse{Promise: nil, Error: e}, nil
+		}
+		return promiseResponse{Promise: &p}, nil
+	}
+}
+
+func MakeTransitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		transits, e := s.Transits(ctx)
+		if e != nil {
+			return transitsResponse{Transits: make([]Transit, 0), Error: e}, nil
+		}
+		return transitsResponse{Transits: transits}, nil
+	}
+}
+
+func MakeSetTransitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(Transit)
+		t, e := s.SetTransit(ctx, req)
+		if e != nil {
+			return transitResponse{Transit: nil, Error: e}, nil
+		}
+		return transitResponse{Transit: &t}, nil
+	}
+}
+
+func MakeDeleteTransitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(transitRequest)
+		e := s.DeleteTransit(ctx, req.Option, req.Country)
+		return transitResponse{Error: e}, nil
+	}
+}
+
+type optionsRequest struct {
+	Country string
+}
+
+type orderRequest struct {
+	OrderID string `json:"-"`
+	Option  string `json:"option"`
+}
+
+type transitRequest struct {
+	Option  string
+	Country string
+}
+
+type optionsResponse struct {
+	Options []Window `json:"options"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r optionsResponse) error() error {
+	return r.Error
+}
+
+type promiseResponse struct {
+	Status  int      `json:"-"`
+	Promise *Promise `json:"promise,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r promiseResponse) status() int {
+	return r.Status
+}
+
+func (r promiseResponse) error() error {
+	return r.Error
+}
+
+type transitsResponse struct {
+	Transits []Transit `json:"transits"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r transitsResponse) error() error {
+	return r.Error
+}
+
+type transitResponse struct {
+	Transit *Transit `json:"transit,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r transitResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,103 @@
+package delivery
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Options(ctx context.Context, country string) (windows []Window, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "options", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	windows, err = mw.next.Options(ctx, country)
+	return
+}
+
+func (mw instrmw) OrderOptions(ctx context.Context, orderID string) (windows []Window, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_options", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	windows, err = mw.next.OrderOptions(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Promise(ctx context.Context, orderID, option string) (p Promise, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "promise", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	p, err = mw.next.Promise(ctx, orderID, option)
+	return
+}
+
+func (mw instrmw) OrderPromise(ctx context.Context, orderID string) (p Promise, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_promise", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	p, err = mw.next.OrderPromise(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Transits(ctx context.Context) (transits []Transit, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "transits", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	transits, err = mw.next.Transits(ctx)
+	return
+}
+
+func (mw instrmw) SetTransit(ctx context.Context, t Transit) (saved Transit, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_transit", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	saved, err = mw.next.SetTransit(ctx, t)
+	return
+}
+
+func (mw instrmw) DeleteTransit(ctx context.Context, option, country string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete_transit", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DeleteTransit(ctx, option, country)
+	return
+}
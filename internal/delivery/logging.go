@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Options(ctx context.Context, country string) (windows []Window, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "options",
+			"country", country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Options(ctx, country)
+}
+
+func (s loggingService) OrderOptions(ctx context.Context, orderID string) (windows []Window, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_options",
+			"order_id", orderID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderOptions(ctx, orderID)
+}
+
+func (s loggingService) Promise(ctx context.Context, orderID, option string) (p Promise, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "promise",
+			"order_id", orderID,
+			"option", option,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Promise(ctx, orderID, option)
+}
+
+func (s loggingService) OrderPromise(ctx context.Context, orderID string) (p Promise, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_promise",
+			"order_id", orderID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderPromise(ctx, orderID)
+}
+
+func (s loggingService) Transits(ctx context.Context) (transits []Transit, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "transits",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Transits(ctx)
+}
+
+func (s loggingService) SetTransit(ctx context.Context, t Transit) (saved Transit, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_transit",
+			"option", t.Option,
+			"country", t.Country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetTransit(ctx, t)
+}
+
+func (s loggingService) DeleteTransit(ctx context.Context, option, country string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_transit",
+			"option", option,
+			"country", country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeleteTransit(ctx, option, country)
+}
@@ -0,0 +1,15 @@
+package delivery
+
+// Repo abstracts all the persistant storage operations of Delivery Service
+type Repo interface {
+	ListTransits() ([]Transit, error)
+	// SaveTransit creates or replaces the transit of the option to the
+	// country.
+	SaveTransit(t *Transit) error
+	DeleteTransit(option, country string) error
+
+	// SavePromise creates or replaces the promise of the order.
+	SavePromise(p *Promise) error
+	GetPromise(orderID string) (Promise, error)
+	Drop() error
+}
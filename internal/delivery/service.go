@@ -0,0 +1,172 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrUnknownOption   = errors.New("shipping option is not offered to the country")
+	ErrTransitNotFound = errors.New("transit time not found")
+	ErrPromiseNotFound = errors.New("delivery promise not found")
+	ErrPromiseFinal    = errors.New("delivery promise of a paid order can't change")
+)
+
+type Service interface {
+	// Options returns the delivery window of every shipping option to the
+	// country of an order placed now, earliest first.
+	Options(ctx context.Context, country string) ([]Window, error)
+
+	// OrderOptions returns the options to the checked shipping address of
+	// the order.
+	OrderOptions(ctx context.Context, orderID string) ([]Window, error)
+
+	// Promise records the window of the option chosen for the order, until
+	// the order is paid. Choosing again replaces the promise.
+	Promise(ctx context.Context, orderID, option string) (Promise, error)
+
+	// OrderPromise returns the recorded promise of the order.
+	OrderPromise(ctx context.Context, orderID string) (Promise, error)
+
+	// Transits returns the transit table of the carriers.
+	Transits(ctx context.Context) ([]Transit, error)
+
+	// SetTransit creates or replaces the transit time of an option to a
+	// country, AnyCountry for the countries without their own.
+	SetTransit(ctx context.Context, t Transit) (Transit, error)
+
+	// DeleteTransit removes the transit time of an option to a country.
+	DeleteTransit(ctx context.Context, option, country string) error
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	addresses address.Repo
+	warehouse Warehouse
+	now       func() time.Time
+}
+
+// NewService return basic Service implementation. The windows are
+// estimated from the cut-off of warehouse and the transit table of r.
+func NewService(r Repo, orders order.Repo, addresses address.Repo, warehouse Warehouse) Service {
+	return basicService{r: r, orders: orders, addresses: addresses, warehouse: warehouse, now: time.Now}
+}
+
+// Options picks for every option the transit time to the country, else
+// the one to AnyCountry.
+func (s basicService) Options(ctx context.Context, country string) ([]Window, error) {
+	country = strings.ToUpper(country)
+	transits, err := s.r.ListTransits()
+	if err != nil {
+		return nil, err
+	}
+	byOption := make(map[string]Transit)
+	for _, t := range transits {
+		if t.Country == country || (t.Country == AnyCountry && byOption[t.Option].Country != country) {
+			byOption[t.Option] = t
+		}
+	}
+	now := s.now()
+	windows := make([]Window, 0, len(byOption))
+	for _, t := range byOption {
+		windows = append(windows, s.warehouse.Estimate(t, now))
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].Earliest.Equal(windows[j].Earliest) {
+			return windows[i].Earliest.Before(windows[j].Earliest)
+		}
+		return windows[i].Option < windows[j].Option
+	})
+	return windows, nil
+}
+
+func (s basicService) OrderOptions(ctx context.Context, orderID string) ([]Window, error) {
+	c, err := s.check(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Options(ctx, c.Country)
+}
+
+func (s basicService) Promise(ctx context.Context, orderID, option string) (Promise, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return Promise{}, order.ErrOrderNotFound
+	}
+	if o.PaidAt != nil {
+		return Promise{}, ErrPromiseFinal
+	}
+	c, err := s.check(orderID)
+	if err != nil {
+		return Promise{}, err
+	}
+	windows, err := s.Options(ctx, c.Country)
+	if err != nil {
+		return Promise{}, err
+	}
+	for _, w := range windows {
+		if w.Option != option {
+			continue
+		}
+		p := Promise{OrderID: orderID, Country: c.Country, Window: w, PromisedAt: s.now().UTC()}
+		if err := s.r.SavePromise(&p); err != nil {
+			return Promise{}, err
+		}
+		return p, nil
+	}
+	return Promise{}, ErrUnknownOption
+}
+
+func (s basicService) OrderPromise(ctx context.Context, orderID string) (Promise, error) {
+	p, err := s.r.GetPromise(orderID)
+	if err != nil {
+		return Promise{}, ErrPromiseNotFound
+	}
+	return p, nil
+}
+
+// check returns the checked shipping address of the order.
+func (s basicService) check(orderID string) (address.Check, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return address.Check{}, order.ErrOrderNotFound
+	}
+	c, err := s.addresses.GetCheck(orderID)
+	if err != nil {
+		return address.Check{}, address.ErrCheckNotFound
+	}
+	return c, nil
+}
+
+func (s basicService) Transits(ctx context.Context) ([]Transit, error) {
+	return s.r.ListTransits()
+}
+
+func (s basicService) SetTransit(ctx context.Context, t Transit) (Transit, error) {
+	if err := t.Validate(); err != nil {
+		return Transit{}, err
+	}
+	t.UpdatedAt = s.now().UTC()
+	if err := s.r.SaveTransit(&t); err != nil {
+		return Transit{}, err
+	}
+	return t, nil
+}
+
+func (s basicService) DeleteTransit(ctx context.Context, option, country string) error {
+	err := s.r.DeleteTransit(option, strings.ToUpper(country))
+	if err == db.ErrNotFound {
+		return ErrTransitNotFound
+	}
+	return err
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
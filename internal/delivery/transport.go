@@ -0,0 +1,236 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+	ErrNoCountry  = errors.New("country is required")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrUnknownOption:   "delivery.unknown_option",
+		ErrTransitNotFound: "delivery.transit_not_found",
+		ErrPromiseNotFound: "delivery.promise_not_found",
+		ErrPromiseFinal:    "delivery.promise_final",
+		ErrNoCountry:       "delivery.no_country",
+	})
+}
+
+// MakeHTTPHandler mounts the delivery endpoints, the transit times served
+// to the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	optionsHandler := httptransport.NewServer(
+		e.OptionsEndpoint,
+		decodeOptionsRequest,
+		encodeResponse,
+		options...,
+	)
+	orderOptionsHandler := httptransport.NewServer(
+		e.OrderOptionsEndpoint,
+		decodeOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	promiseHandler := httptransport.NewServer(
+		e.PromiseEndpoint,
+		decodePromiseRequest,
+		encodeResponse,
+		options...,
+	)
+	orderPromiseHandler := httptransport.NewServer(
+		e.OrderPromiseEndpoint,
+		decodeOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	transitsHandler := httptransport.NewServer(
+		e.TransitsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	setTransitHandler := httptransport.NewServer(
+		e.SetTransitEndpoint,
+		decodeSetTransitRequest,
+		encodeResponse,
+		options...,
+	)
+	deleteTransitHandler := httptransport.NewServer(
+		e.DeleteTransitEndpoint,
+		decodeTransitRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Checkout endpoints
+	r.Handle("/delivery/v1/options", optionsHandler).Methods("GET")
+	r.Handle("/delivery/v1/orders/{order-id}/options", orderOptionsHandler).Methods("GET")
+	r.Handle("/delivery/v1/orders/{order-id}/promise", promiseHandler).Methods("POST")
+	r.Handle("/delivery/v1/orders/{order-id}/promise", orderPromiseHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/delivery/v1/transits", transitsHandler).Methods("GET")
+	r.Handle("/delivery/v1/transits/{option}/{country}", setTransitHandler).Methods("PUT")
+	r.Handle("/delivery/v1/transits/{option}/{country}", deleteTransitHandler).Methods("DELETE")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeOptionsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	country := req.URL.Query().Get("country")
+	if country == "" {
+		return nil, ErrNoCountry
+	}
+	return optionsRequest{Country: country}, nil
+}
+
+func decodeOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderRequest{OrderID: orderID}, nil
+}
+
+func decodePromiseRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r orderRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeSetTransitRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var t Transit
+	if err := schema.Decode(req.Body, &t); err != nil {
+		return nil, err
+	}
+	r, err := decodeTransitRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	t.Option, t.Country = r.(transitRequest).Option, r.(transitRequest).Country
+	return t, nil
+}
+
+func decodeTransitRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	option, ok := vars["option"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "option")
+	}
+	country, ok := vars["country"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "country")
+	}
+	return transitRequest{Option: option, Country: country}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound, address.ErrCheckNotFound, ErrTransitNotFound, ErrPromiseNotFound:
+		return http.StatusNotFound
+	case ErrPromiseFinal:
+		return http.StatusConflict
+	case ErrBadRouting, ErrNoCountry, ErrUnknownOption, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,56 @@
+package denylist
+
+import (
+	"context"
+
+	"github.com/kavirajk/bookshop/fraud"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+)
+
+type checkoutList struct {
+	s Service
+}
+
+// Checkout returns the lists as fraud.Denylist consulted at checkout.
+func Checkout(s Service) fraud.Denylist {
+	return checkoutList{s: s}
+}
+
+func (l checkoutList) Denied(ctx context.Context, kind, value string) (bool, error) {
+	r, err := l.s.Check(ctx, kind, value, SourceCheckout)
+	if err != nil {
+		return false, err
+	}
+	return r.Denied, nil
+}
+
+// UserMiddleware rejects registrations with denied email or client IP.
+// Client IP is read from the request context, see transport.ClientIP.
+func UserMiddleware(s Service) user.Middleware {
+	return func(next user.Service) user.Service {
+		return registrationGuard{Service: next, lists: s}
+	}
+}
+
+type registrationGuard struct {
+	user.Service
+	lists Service
+}
+
+func (g registrationGuard) Register(ctx context.Context, nuser user.NewUser) (user.User, error) {
+	checks := []struct{ kind, value string }{
+		{KindEmail, nuser.Email},
+		{KindIP, transport.ClientIP(ctx)},
+	}
+	for _, c := range checks {
+		r, err := g.lists.Check(ctx, c.kind, c.value, SourceRegistration)
+		if err != nil {
+			return user.User{}, err
+		}
+		if r.Denied {
+			return user.User{}, user.ErrRegistrationDenied
+		}
+	}
+	return g.Service.Register(ctx, nuser)
+}
@@ -0,0 +1,158 @@
+package denylist
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidList    = errors.New("list must be deny or allow")
+	ErrInvalidKind    = errors.New("kind must be email, ip or bin")
+	ErrInvalidPattern = errors.New("invalid pattern")
+)
+
+const (
+	ListDeny  = "deny"
+	ListAllow = "allow"
+)
+
+// Kinds of values the lists hold.
+const (
+	KindEmail = "email"
+	KindIP    = "ip"
+	KindBIN   = "bin"
+)
+
+// Sources consulting the lists, recorded on the hits.
+const (
+	SourceRegistration = "registration"
+	SourceCheckout     = "checkout"
+)
+
+// Entry is a pattern in the deny or allow list. Patterns are
+//
+//	email: exact address or with * wildcards, e.g "*@mailinator.com"
+//	ip:    exact address or CIDR, e.g "203.0.113.0/24"
+//	bin:   card number prefix, exact or with trailing *, e.g "4111*"
+//
+// Entries with ExpiresAt stop matching once it has passed.
+type Entry struct {
+	ID        string     `json:"id"`
+	List      string     `json:"list"`
+	Kind      string     `json:"kind"`
+	Pattern   string     `json:"pattern"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (Entry) TableName() string {
+	return "denylist_entries"
+}
+
+// Hit records a value matched by an entry.
+type Hit struct {
+	ID        string    `json:"id"`
+	EntryID   string    `json:"entry_id"`
+	List      string    `json:"list"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Hit) TableName() string {
+	return "denylist_hits"
+}
+
+// Result of checking a value against the lists.
+type Result struct {
+	Denied bool   `json:"denied"`
+	Entry  *Entry `json:"entry,omitempty"`
+}
+
+// Validate does basic validation before saving into db.
+func (e *Entry) Validate() error {
+	if e.List != ListDeny && e.List != ListAllow {
+		return ErrInvalidList
+	}
+	e.Pattern = strings.TrimSpace(e.Pattern)
+	if e.Pattern == "" {
+		return errors.Wrap(ErrInvalidPattern, "empty")
+	}
+	switch e.Kind {
+	case KindEmail:
+		if !strings.Contains(e.Pattern, "@") {
+			return errors.Wrap(ErrInvalidPattern, "email without @")
+		}
+		e.Pattern = strings.ToLower(e.Pattern)
+	case KindIP:
+		if strings.Contains(e.Pattern, "/") {
+			if _, _, err := net.ParseCIDR(e.Pattern); err != nil {
+				return errors.Wrap(ErrInvalidPattern, err.Error())
+			}
+		} else if net.ParseIP(e.Pattern) == nil {
+			return errors.Wrap(ErrInvalidPattern, "ip")
+		}
+	case KindBIN:
+		digits := strings.TrimSuffix(e.Pattern, "*")
+		if digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return errors.Wrap(ErrInvalidPattern, "bin must be digits with optional trailing *")
+		}
+	default:
+		return ErrInvalidKind
+	}
+	return nil
+}
+
+// Active tells whether the entry is not expired at t.
+func (e Entry) Active(t time.Time) bool {
+	return e.ExpiresAt == nil || t.Before(*e.ExpiresAt)
+}
+
+// Match tells whether the value matches the entry's pattern.
+func (e Entry) Match(value string) bool {
+	switch e.Kind {
+	case KindEmail:
+		return glob(e.Pattern, strings.ToLower(strings.TrimSpace(value)))
+	case KindIP:
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil {
+			return false
+		}
+		if _, n, err := net.ParseCIDR(e.Pattern); err == nil {
+			return n.Contains(ip)
+		}
+		return ip.Equal(net.ParseIP(e.Pattern))
+	case KindBIN:
+		if strings.HasSuffix(e.Pattern, "*") {
+			return strings.HasPrefix(value, strings.TrimSuffix(e.Pattern, "*"))
+		}
+		return value == e.Pattern
+	}
+	return false
+}
+
+// glob matches s against pattern where * matches any run of characters.
+func glob(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return strings.HasSuffix(s, last)
+}
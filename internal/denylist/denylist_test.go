@@ -0,0 +1,84 @@
+package denylist_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/denylist"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the entries in memory, the other methods of the Repo aren't
+// used.
+type repo struct {
+	denylist.Repo
+	entries []denylist.Entry
+}
+
+func (r *repo) List(list, kind string) ([]denylist.Entry, error) {
+	return r.entries, nil
+}
+
+func TestEntryMatch(t *testing.T) {
+	cases := []struct {
+		kind, pattern, value string
+		expected             bool
+	}{
+		{denylist.KindEmail, "bad@example.com", "BAD@example.com", true},
+		{denylist.KindEmail, "*@mailinator.com", "anyone@mailinator.com", true},
+		{denylist.KindEmail, "*@mailinator.com", "anyone@mailinator.com.au", false},
+		{denylist.KindEmail, "spam*@*.ru", "spammer@mail.ru", true},
+		{denylist.KindEmail, "spam*@*.ru", "reader@mail.ru", false},
+		{denylist.KindIP, "203.0.113.7", "203.0.113.7", true},
+		{denylist.KindIP, "203.0.113.0/24", "203.0.113.250", true},
+		{denylist.KindIP, "203.0.113.0/24", "203.0.114.1", false},
+		{denylist.KindIP, "2001:db8::/32", "2001:db8::1", true},
+		{denylist.KindIP, "203.0.113.0/24", "not-an-ip", false},
+		{denylist.KindBIN, "4111*", "411111", true},
+		{denylist.KindBIN, "411111", "411112", false},
+	}
+	for _, c := range cases {
+		e := denylist.Entry{List: denylist.ListDeny, Kind: c.kind, Pattern: c.pattern}
+		if err := e.Validate(); err != nil {
+			t.Fatalf("Validate(%q): %v", c.pattern, err)
+		}
+		if got := e.Match(c.value); got != c.expected {
+			t.Errorf("%s %q match %q: expected %v, got %v", c.kind, c.pattern, c.value, c.expected, got)
+		}
+	}
+}
+
+func TestEntriesRequireAdmin(t *testing.T) {
+	s := denylist.NewService(&repo{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := denylist.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/denylist/v1/entries", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package denylist
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the denylist service endpoints under single type.
+type Endpoints struct {
+	AddEndpoint    endpoint.Endpoint
+	RemoveEndpoint endpoint.Endpoint
+	ListEndpoint   endpoint.Endpoint
+	HitsEndpoint   endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the denylist service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		AddEndpoint:    admin(MakeAddEndpoint(s)),
+		RemoveEndpoint: admin(MakeRemoveEndpoint(s)),
+		ListEndpoint:   admin(MakeListEndpoint(s)),
+		HitsEndpoint:   admin(MakeHitsEndpoint(s)),
+	}
+}
+
+func MakeAddEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addRequest)
+		e, err := s.Add(ctx, req.Entry)
+		if err != nil {
+			return entryResponse{Entry: nil, Error: err}, nil
+		}
+		return entryResponse{Entry: &e, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeRemoveEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entryRequest)
+		err := s.Remove(ctx, req.ID)
+		return removeResponse{Error: err}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		entries, err := s.List(ctx, req.List, req.Kind)
+		if err != nil {
+			return listResponse{Entries: make([]Entry, 0), Error: err}, nil
+		}
+		return listResponse{Entries: entries}, nil
+	}
+}
+
+func MakeHitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entryRequest)
+		hits, err := s.Hits(ctx, req.ID)
+		if err != nil {
+			return hitsResponse{Hits: make([]Hit, 0), Error: err}, nil
+		}
+		return hitsResponse{Hits: hits}, nil
+	}
+}
+
+type addRequest struct {
+	Entry
+}
+
+type entryRequest struct {
+	ID string
+}
+
+type entryResponse struct {
+	Status int    `json:"-"`
+	Entry  *Entry `json:"entry,omitempty"`
+	Error  error  `json:"error,omitempty"`
+}
+
+func (r entryResponse) status() int {
+	return r.Status
+}
+
+func (r entryResponse) error() error {
+	return r.Error
+}
+
+type removeResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r removeResponse) error() error {
+	return r.Error
+}
+
+type listRequest struct {
+	List string
+	Kind string
+}
+
+type listResponse struct {
+	Entries []Entry `json:"entries"`
+	Error   error   `json:"error,omitempty"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+type hitsResponse struct {
+	Hits  []Hit `json:"hits"`
+	Error error `json:"error,omitempty"`
+}
+
+func (r hitsResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package denylist
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Add(ctx context.Context, e Entry) (entry Entry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "add", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	entry, err = mw.next.Add(ctx, e)
+	return
+}
+
+func (mw instrmw) Remove(ctx context.Context, ID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "remove", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Remove(ctx, ID)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context, list, kind string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	entries, err = mw.next.List(ctx, list, kind)
+	return
+}
+
+func (mw instrmw) Check(ctx context.Context, kind, value, source string) (result Result, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "check", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	result, err = mw.next.Check(ctx, kind, value, source)
+	return
+}
+
+func (mw instrmw) Hits(ctx context.Context, entryID string) (hits []Hit, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "hits", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	hits, err = mw.next.Hits(ctx, entryID)
+	return
+}
@@ -0,0 +1,78 @@
+package denylist
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Add(ctx context.Context, e Entry) (entry Entry, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "add",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Add(ctx, e)
+}
+
+func (s loggingService) Remove(ctx context.Context, ID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "remove",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Remove(ctx, ID)
+}
+
+func (s loggingService) List(ctx context.Context, list, kind string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx, list, kind)
+}
+
+func (s loggingService) Check(ctx context.Context, kind, value, source string) (result Result, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "check",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Check(ctx, kind, value, source)
+}
+
+func (s loggingService) Hits(ctx context.Context, entryID string) (hits []Hit, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "hits",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Hits(ctx, entryID)
+}
@@ -0,0 +1,17 @@
+package denylist
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Denylist Service
+type Repo interface {
+	Create(e *Entry) error
+	Delete(ID string) error
+	GetByID(ID string) (Entry, error)
+	// List returns entries filtered by list and kind, empty matches all.
+	List(list, kind string) ([]Entry, error)
+	// ListActive returns the entries of the kind not expired at t.
+	ListActive(kind string, t time.Time) ([]Entry, error)
+	CreateHit(h *Hit) error
+	ListHits(entryID string, limit int) ([]Hit, error)
+	Drop() error
+}
@@ -0,0 +1,125 @@
+package denylist
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrEntryNotFound = errors.New("denylist entry not found")
+	ErrExpired       = errors.New("expires_at is in the past")
+)
+
+type Service interface {
+	// Add adds an entry to the deny or allow list.
+	Add(ctx context.Context, e Entry) (Entry, error)
+
+	// Remove removes the entry from its list.
+	Remove(ctx context.Context, ID string) error
+
+	// List returns the entries filtered by list and kind, empty matches all.
+	// Expired entries are included so they can be cleaned up.
+	List(ctx context.Context, list, kind string) ([]Entry, error)
+
+	// Check matches the value against the lists and logs the hit. Allowlist
+	// takes precedence over denylist.
+	Check(ctx context.Context, kind, value, source string) (Result, error)
+
+	// Hits returns the latest hits of the entry.
+	Hits(ctx context.Context, entryID string) ([]Hit, error)
+}
+
+const hitsLimit = 100
+
+type basicService struct {
+	r Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo) Service {
+	return basicService{r: r}
+}
+
+// Add adds an entry to the deny or allow list.
+func (s basicService) Add(ctx context.Context, e Entry) (Entry, error) {
+	if err := e.Validate(); err != nil {
+		return Entry{}, err
+	}
+	now := time.Now().UTC()
+	if e.ExpiresAt != nil && !e.ExpiresAt.After(now) {
+		return Entry{}, ErrExpired
+	}
+	e.ID = ""
+	e.CreatedAt = now
+	if err := s.r.Create(&e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Remove removes the entry from its list.
+func (s basicService) Remove(ctx context.Context, ID string) error {
+	if _, err := s.r.GetByID(ID); err != nil {
+		return ErrEntryNotFound
+	}
+	return s.r.Delete(ID)
+}
+
+// List returns the entries filtered by list and kind.
+func (s basicService) List(ctx context.Context, list, kind string) ([]Entry, error) {
+	return s.r.List(list, kind)
+}
+
+// Check matches the value against the lists and logs the hit.
+func (s basicService) Check(ctx context.Context, kind, value, source string) (Result, error) {
+	if value == "" {
+		return Result{}, nil
+	}
+	now := time.Now().UTC()
+	entries, err := s.r.ListActive(kind, now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var hit *Entry
+	for i, e := range entries {
+		if !e.Match(value) {
+			continue
+		}
+		if e.List == ListAllow {
+			hit = &entries[i]
+			break
+		}
+		if hit == nil {
+			hit = &entries[i]
+		}
+	}
+	if hit == nil {
+		return Result{}, nil
+	}
+
+	h := Hit{
+		EntryID:   hit.ID,
+		List:      hit.List,
+		Kind:      kind,
+		Value:     value,
+		Source:    source,
+		CreatedAt: now,
+	}
+	if err := s.r.CreateHit(&h); err != nil {
+		return Result{}, err
+	}
+	return Result{Denied: hit.List == ListDeny, Entry: hit}, nil
+}
+
+// Hits returns the latest hits of the entry.
+func (s basicService) Hits(ctx context.Context, entryID string) ([]Hit, error) {
+	if _, err := s.r.GetByID(entryID); err != nil {
+		return nil, ErrEntryNotFound
+	}
+	return s.r.ListHits(entryID, hitsLimit)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,156 @@
+package denylist
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// MakeHTTPHandler mounts the denylist endpoints, served to the requests admin
+// lets through, e.g. auth.NewMiddleware chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	addHandler := httptransport.NewServer(
+		e.AddEndpoint,
+		decodeAddRequest,
+		encodeResponse,
+		options...,
+	)
+	removeHandler := httptransport.NewServer(
+		e.RemoveEndpoint,
+		decodeEntryRequest,
+		encodeResponse,
+		options...,
+	)
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeListRequest,
+		encodeResponse,
+		options...,
+	)
+	hitsHandler := httptransport.NewServer(
+		e.HitsEndpoint,
+		decodeEntryRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/denylist/v1/entries", listHandler).Methods("GET")
+	r.Handle("/denylist/v1/entries", addHandler).Methods("POST")
+	r.Handle("/denylist/v1/entries/{entry-id}", removeHandler).Methods("DELETE")
+	r.Handle("/denylist/v1/entries/{entry-id}/hits", hitsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeAddRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r addRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeEntryRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["entry-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "entry-id")
+	}
+	return entryRequest{ID: ID}, nil
+}
+
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return listRequest{List: req.FormValue("list"), Kind: req.FormValue("kind")}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrEntryNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrInvalidList, ErrInvalidKind, ErrInvalidPattern, ErrExpired, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,117 @@
+package deprecation
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client kinds.
+const (
+	ClientAPIKey    = "api_key"
+	ClientUserAgent = "user_agent"
+)
+
+// Route is a deprecated route of the API. Calls to it get the Deprecation,
+// Sunset and Link headers.
+type Route struct {
+	ID string `json:"id"`
+	// Method is the HTTP method of the route, any if empty.
+	Method string `json:"method,omitempty"`
+	// Path is the route template, "{name}" matches any single segment,
+	// e.g. "/orders/v1/{user-id}/cancel/{id}".
+	Path         string     `json:"path"`
+	DeprecatedAt time.Time  `json:"deprecated_at"`
+	Sunset       *time.Time `json:"sunset,omitempty"`
+	// Replacement is the URL of the route or the docs to move to.
+	Replacement string    `json:"replacement,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Route) TableName() string {
+	return "deprecated_routes"
+}
+
+// Match tells whether a request of method to path calls the route.
+func (r Route) Match(method, path string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	want := strings.Split(strings.Trim(r.Path, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i, seg := range want {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Headers sets the Deprecation (RFC 9745), Sunset (RFC 8594) and
+// successor Link headers of the route.
+func (r Route) Headers(h http.Header) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(r.DeprecatedAt.Unix(), 10))
+	if r.Sunset != nil {
+		h.Set("Sunset", r.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if r.Replacement != "" {
+		h.Add("Link", "<"+r.Replacement+`>; rel="successor-version"`)
+	}
+}
+
+// Call counts the calls of a client to a deprecated route.
+type Call struct {
+	RouteID    string    `json:"-" sql:"unique_index:idx_deprecated_call"`
+	ClientKind string    `json:"client_kind" sql:"unique_index:idx_deprecated_call"`
+	Client     string    `json:"client" sql:"unique_index:idx_deprecated_call"`
+	Calls      int       `json:"calls"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+func (Call) TableName() string {
+	return "deprecated_route_calls"
+}
+
+// Usage is the report of a deprecated route, with the clients still
+// calling it, most calls first.
+type Usage struct {
+	Route   Route  `json:"route"`
+	Calls   int    `json:"calls"`
+	Clients []Call `json:"clients"`
+}
+
+// maxClientLength caps the user agents recorded.
+const maxClientLength = 200
+
+// apiKeyPrefix is the start of the vendor API keys, followed by a secret
+// whose first 8 characters are the lookup prefix shown to the shop admins.
+const apiKeyPrefix = "vk_"
+
+// clientOf identifies the caller of req by its API key, of which only the
+// lookup prefix is kept, or else by its user agent.
+func clientOf(req *http.Request) (kind, client string) {
+	key := req.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	}
+	if strings.HasPrefix(key, apiKeyPrefix) && len(key) >= len(apiKeyPrefix)+8 {
+		return ClientAPIKey, key[len(apiKeyPrefix) : len(apiKeyPrefix)+8]
+	}
+	ua := req.UserAgent()
+	if len(ua) > maxClientLength {
+		ua = ua[:maxClientLength]
+	}
+	return ClientUserAgent, ua
+}
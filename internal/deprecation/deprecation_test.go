@@ -0,0 +1,132 @@
+package deprecation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/deprecation"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo has no deprecated route nor call, the other methods of the Repo
+// aren't used.
+type repo struct {
+	deprecation.Repo
+}
+
+func (repo) ListRoutes() ([]deprecation.Route, error) {
+	return nil, nil
+}
+
+func (repo) ListCalls(routeID string) ([]deprecation.Call, error) {
+	return nil, nil
+}
+
+func TestMatch(t *testing.T) {
+	r := deprecation.Route{Method: "POST", Path: "/orders/v1/{user-id}/cancel/{id}"}
+	cases := []struct {
+		method, path string
+		expected     bool
+	}{
+		{"POST", "/orders/v1/u1/cancel/o1", true},
+		{"post", "/orders/v1/u1/cancel/o1/", true},
+		{"GET", "/orders/v1/u1/cancel/o1", false},
+		{"POST", "/orders/v1/u1/cancel", false},
+		{"POST", "/orders/v1/u1/refund/o1", false},
+		{"POST", "/orders/v1//cancel/o1", false},
+	}
+	for _, c := range cases {
+		if got := r.Match(c.method, c.path); got != c.expected {
+			t.Errorf("%s %s: expected %v, got %v", c.method, c.path, c.expected, got)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	reg := deprecation.NewRegistry()
+	reg.Load([]deprecation.Route{{
+		ID:           "r1",
+		Path:         "/users/v1/list",
+		DeprecatedAt: time.Unix(1700000000, 0),
+		Sunset:       &sunset,
+		Replacement:  "/users/v2/list",
+	}})
+	h := reg.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	for _, ua := range []string{"shop-app/1.0", "shop-app/1.0"} {
+		req := httptest.NewRequest("GET", "/users/v1/list", nil)
+		req.Header.Set("User-Agent", ua)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req := httptest.NewRequest("GET", "/users/v1/list", nil)
+	req.Header.Set("X-API-Key", "vk_abcdefgh-secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if d := w.Header().Get("Deprecation"); d != "@1700000000" {
+		t.Errorf("deprecation: expected @1700000000, got %q", d)
+	}
+	if s := w.Header().Get("Sunset"); s != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("sunset: expected the http date, got %q", s)
+	}
+	if l := w.Header().Get("Link"); l != `</users/v2/list>; rel="successor-version"` {
+		t.Errorf("link: expected the replacement, got %q", l)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/users/v1/login", nil))
+	if d := w.Header().Get("Deprecation"); d != "" {
+		t.Errorf("deprecation: expected none for other routes, got %q", d)
+	}
+
+	calls := make(map[string]int)
+	for _, c := range reg.Flush() {
+		calls[c.ClientKind+":"+c.Client] = c.Calls
+	}
+	if calls["user_agent:shop-app/1.0"] != 2 || calls["api_key:abcdefgh"] != 1 || len(calls) != 2 {
+		t.Errorf("flush: expected 2 calls of the app and 1 of the key prefix, got %v", calls)
+	}
+	if left := reg.Flush(); len(left) != 0 {
+		t.Errorf("flush: expected no calls after a flush, got %v", left)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := deprecation.MakeHTTPHandler(context.Background(), deprecation.NewService(repo{}), admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"routes without token", "GET", "/deprecations/v1/admin/routes", "", http.StatusUnauthorized},
+		{"routes by customer", "GET", "/deprecations/v1/admin/routes", customer, http.StatusForbidden},
+		{"deprecate by customer", "POST", "/deprecations/v1/admin/routes", customer, http.StatusForbidden},
+		{"undeprecate by customer", "DELETE", "/deprecations/v1/admin/routes/r1", customer, http.StatusForbidden},
+		{"report by customer", "GET", "/deprecations/v1/admin/report", customer, http.StatusForbidden},
+		{"routes by admin", "GET", "/deprecations/v1/admin/routes", staff, http.StatusOK},
+		{"report by admin", "GET", "/deprecations/v1/admin/report", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
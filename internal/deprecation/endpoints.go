@@ -0,0 +1,114 @@
+package deprecation
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the deprecation service endpoints under single type.
+type Endpoints struct {
+	RoutesEndpoint      endpoint.Endpoint
+	DeprecateEndpoint   endpoint.Endpoint
+	UndeprecateEndpoint endpoint.Endpoint
+	ReportEndpoint      endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the deprecation service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		RoutesEndpoint:      admin(MakeRoutesEndpoint(s)),
+		DeprecateEndpoint:   admin(MakeDeprecateEndpoint(s)),
+		UndeprecateEndpoint: admin(MakeUndeprecateEndpoint(s)),
+		ReportEndpoint:      admin(MakeReportEndpoint(s)),
+	}
+}
+
+func MakeRoutesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		routes, e := s.Routes(ctx)
+		if e != nil {
+			return routesResponse{Routes: make([]Route, 0), Error: e}, nil
+		}
+		return routesResponse{Routes: routes}, nil
+	}
+}
+
+func MakeDeprecateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deprecateRequest)
+		r, e := s.Deprecate(ctx, req.Route)
+		if e != nil {
+			return routeResponse{Route: nil, Error: e}, nil
+		}
+		return routeResponse{Route: &r, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeUndeprecateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(undeprecateRequest)
+		e := s.Undeprecate(ctx, req.RouteID)
+		return undeprecateResponse{Error: e}, nil
+	}
+}
+
+func MakeReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		report, e := s.Report(ctx)
+		if e != nil {
+			return reportResponse{Report: make([]Usage, 0), Error: e}, nil
+		}
+		return reportResponse{Report: report}, nil
+	}
+}
+
+type routesResponse struct {
+	Routes []Route `json:"routes"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r routesResponse) error() error {
+	return r.Error
+}
+
+type deprecateRequest struct {
+	Route
+}
+
+type routeResponse struct {
+	Status int    `json:"-"`
+	Route  *Route `json:"route,omitempty"`
+	Error  error  `json:"error,omitempty"`
+}
+
+func (r routeResponse) status() int {
+	return r.Status
+}
+
+func (r routeResponse) error() error {
+	return r.Error
+}
+
+type undeprecateRequest struct {
+	RouteID string
+}
+
+type undeprecateResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r undeprecateResponse) error() error {
+	return r.Error
+}
+
+type reportResponse struct {
+	Report []Usage `json:"report"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r reportResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package deprecation
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Routes(ctx context.Context) (routes []Route, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "routes", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	routes, err = mw.next.Routes(ctx)
+	return
+}
+
+func (mw instrmw) Deprecate(ctx context.Context, r Route) (route Route, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "deprecate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	route, err = mw.next.Deprecate(ctx, r)
+	return
+}
+
+func (mw instrmw) Undeprecate(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "undeprecate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Undeprecate(ctx, id)
+	return
+}
+
+func (mw instrmw) Record(ctx context.Context, calls []Call) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "record", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Record(ctx, calls)
+	return
+}
+
+func (mw instrmw) Report(ctx context.Context) (report []Usage, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	report, err = mw.next.Report(ctx)
+	return
+}
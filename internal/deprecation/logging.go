@@ -0,0 +1,78 @@
+package deprecation
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Routes(ctx context.Context) (routes []Route, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "routes",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Routes(ctx)
+}
+
+func (s loggingService) Deprecate(ctx context.Context, r Route) (route Route, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "deprecate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Deprecate(ctx, r)
+}
+
+func (s loggingService) Undeprecate(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "undeprecate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Undeprecate(ctx, id)
+}
+
+func (s loggingService) Record(ctx context.Context, calls []Call) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "record",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Record(ctx, calls)
+}
+
+func (s loggingService) Report(ctx context.Context) (report []Usage, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "report",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Report(ctx)
+}
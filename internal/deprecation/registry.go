@@ -0,0 +1,115 @@
+package deprecation
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Registry holds the deprecated routes in memory for the HTTP handlers,
+// and counts their calls until the next Flush.
+type Registry struct {
+	mu     sync.RWMutex
+	routes []Route
+
+	callsMu sync.Mutex
+	calls   map[callKey]*Call
+}
+
+type callKey struct {
+	routeID, kind, client string
+}
+
+// NewRegistry returns an empty registry, see Run to keep it in sync with
+// the service.
+func NewRegistry() *Registry {
+	return &Registry{calls: make(map[callKey]*Call)}
+}
+
+// Load replaces the deprecated routes.
+func (r *Registry) Load(routes []Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = routes
+}
+
+// Lookup returns the deprecated route a request of method to path calls.
+func (r *Registry) Lookup(method, path string) (Route, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, route := range r.routes {
+		if route.Match(method, path) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// Handler sets the deprecation headers on the responses of the deprecated
+// routes served by next, and counts their calls by client.
+func (r *Registry) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if route, ok := r.Lookup(req.Method, req.URL.Path); ok {
+			route.Headers(w.Header())
+			kind, client := clientOf(req)
+			r.count(route.ID, kind, client, time.Now().UTC())
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (r *Registry) count(routeID, kind, client string, now time.Time) {
+	r.callsMu.Lock()
+	defer r.callsMu.Unlock()
+	k := callKey{routeID, kind, client}
+	c, ok := r.calls[k]
+	if !ok {
+		c = &Call{RouteID: routeID, ClientKind: kind, Client: client, FirstSeen: now}
+		r.calls[k] = c
+	}
+	c.Calls++
+	c.LastSeen = now
+}
+
+// Flush returns the calls counted since the last flush.
+func (r *Registry) Flush() []Call {
+	r.callsMu.Lock()
+	defer r.callsMu.Unlock()
+	calls := make([]Call, 0, len(r.calls))
+	for _, c := range r.calls {
+		calls = append(calls, *c)
+	}
+	r.calls = make(map[callKey]*Call)
+	return calls
+}
+
+// Run loads the deprecated routes into reg right away, then every interval
+// records the calls counted by reg and reloads the routes, until ctx is
+// done.
+func Run(ctx context.Context, s Service, reg *Registry, interval time.Duration, logger log.Logger) {
+	load := func() {
+		routes, err := s.Routes(ctx)
+		if err != nil {
+			logger.Log("registry", "deprecation", "err", err)
+			return
+		}
+		reg.Load(routes)
+	}
+	load()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Record(ctx, reg.Flush()); err != nil {
+				logger.Log("registry", "deprecation", "err", err)
+			}
+			load()
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package deprecation
+
+// Repo abstracts all the persistant storage operations of Deprecation Service
+type Repo interface {
+	CreateRoute(r *Route) error
+	ListRoutes() ([]Route, error)
+	DeleteRoute(id string) error
+	// AddCalls adds the calls to the counts of their route and client.
+	AddCalls(calls []Call) error
+	// ListCalls returns the calls to a route, all the routes if empty,
+	// most calls first.
+	ListCalls(routeID string) ([]Call, error)
+	Drop() error
+}
@@ -0,0 +1,125 @@
+package deprecation
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrRouteNotFound = errors.New("deprecated route not found")
+	ErrInvalidRoute  = errors.New("route needs a path, a sunset after its deprecation and a valid replacement url")
+)
+
+type Service interface {
+	// Routes returns all the deprecated routes.
+	Routes(ctx context.Context) ([]Route, error)
+
+	// Deprecate adds a route to the deprecation registry.
+	Deprecate(ctx context.Context, r Route) (Route, error)
+
+	// Undeprecate removes a route from the registry, its calls are kept.
+	Undeprecate(ctx context.Context, id string) error
+
+	// Record adds the calls counted by a Registry.
+	Record(ctx context.Context, calls []Call) error
+
+	// Report returns the usage of every deprecated route.
+	Report(ctx context.Context) ([]Usage, error)
+}
+
+type basicService struct {
+	r Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo) Service {
+	return basicService{r: r}
+}
+
+// Routes returns all the deprecated routes.
+func (s basicService) Routes(ctx context.Context) ([]Route, error) {
+	return s.r.ListRoutes()
+}
+
+// Deprecate validates and stores a deprecated route, deprecated now if no
+// time is given.
+func (s basicService) Deprecate(ctx context.Context, r Route) (Route, error) {
+	now := time.Now().UTC()
+	r.ID = ""
+	r.Method = strings.ToUpper(r.Method)
+	r.CreatedAt = now
+	if r.DeprecatedAt.IsZero() {
+		r.DeprecatedAt = now
+	}
+	if !strings.HasPrefix(r.Path, "/") {
+		return Route{}, ErrInvalidRoute
+	}
+	if r.Sunset != nil && !r.Sunset.After(r.DeprecatedAt) {
+		return Route{}, ErrInvalidRoute
+	}
+	if r.Replacement != "" {
+		u, err := url.Parse(r.Replacement)
+		if err != nil || (!u.IsAbs() && !strings.HasPrefix(r.Replacement, "/")) {
+			return Route{}, ErrInvalidRoute
+		}
+	}
+	if err := s.r.CreateRoute(&r); err != nil {
+		return Route{}, err
+	}
+	return r, nil
+}
+
+// Undeprecate removes a route from the registry.
+func (s basicService) Undeprecate(ctx context.Context, id string) error {
+	if err := s.r.DeleteRoute(id); err != nil {
+		if err == db.ErrNotFound {
+			return ErrRouteNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Record adds the calls to the stored counts.
+func (s basicService) Record(ctx context.Context, calls []Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	return s.r.AddCalls(calls)
+}
+
+// Report returns the deprecated routes with the clients still calling
+// them.
+func (s basicService) Report(ctx context.Context) ([]Usage, error) {
+	routes, err := s.r.ListRoutes()
+	if err != nil {
+		return nil, err
+	}
+	calls, err := s.r.ListCalls("")
+	if err != nil {
+		return nil, err
+	}
+	byRoute := make(map[string][]Call)
+	for _, c := range calls {
+		byRoute[c.RouteID] = append(byRoute[c.RouteID], c)
+	}
+	report := make([]Usage, 0, len(routes))
+	for _, r := range routes {
+		u := Usage{Route: r, Clients: byRoute[r.ID]}
+		if u.Clients == nil {
+			u.Clients = make([]Call, 0)
+		}
+		for _, c := range u.Clients {
+			u.Calls += c.Calls
+		}
+		report = append(report, u)
+	}
+	return report, nil
+}
+
+type Middleware func(Service) Service
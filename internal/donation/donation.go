@@ -0,0 +1,47 @@
+package donation
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	KindRoundUp = "round_up"
+	KindFixed   = "fixed"
+)
+
+// Donation is a charity line added to an order at checkout. Donations are
+// not part of the order total, they are kept apart for accounting.
+type Donation struct {
+	ID        string    `json:"id"`
+	OrderID   string    `json:"order_id"`
+	UserID    string    `json:"user_id"`
+	Charity   string    `json:"charity"`
+	Kind      string    `json:"kind"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Config controls how donations are offered at checkout.
+type Config struct {
+	Charity   string
+	RoundUp   bool    // offer rounding the total up to the next whole unit
+	MaxAmount float64 // upper limit for fixed donations
+}
+
+// Summary is the total of the donations collected in a period.
+type Summary struct {
+	Period   time.Time `json:"period"`
+	Charity  string    `json:"charity"`
+	Currency string    `json:"currency"`
+	Count    int       `json:"count"`
+	Total    float64   `json:"total"`
+}
+
+// RoundUp returns the amount needed to round total up to the next whole unit.
+// Calculation is done in cents to avoid floating point surprises.
+func RoundUp(total float64) float64 {
+	cents := int64(math.Floor(total*100 + 0.5))
+	return float64((100-cents%100)%100) / 100
+}
@@ -0,0 +1,137 @@
+package donation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/donation"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestRoundUp(t *testing.T) {
+	cases := []struct {
+		total, expected float64
+	}{
+		{12.30, 0.70},
+		{12.99, 0.01},
+		{12.00, 0},
+		{0.10, 0.90},
+		{19.995, 0}, // rounded to 20.00 cents first
+	}
+	for _, c := range cases {
+		if got := donation.RoundUp(c.total); got != c.expected {
+			t.Errorf("RoundUp(%v): expected %v, got %v", c.total, c.expected, got)
+		}
+	}
+}
+
+// donationRepo keeps the donations in memory.
+type donationRepo struct {
+	donation.Repo
+	donations []donation.Donation
+}
+
+func (r *donationRepo) Create(d *donation.Donation) error {
+	d.ID = "d1"
+	r.donations = append(r.donations, *d)
+	return nil
+}
+
+func (r *donationRepo) ListByOrder(orderID string) ([]donation.Donation, error) {
+	var donations []donation.Donation
+	for _, d := range r.donations {
+		if d.OrderID == orderID {
+			donations = append(donations, d)
+		}
+	}
+	return donations, nil
+}
+
+func (r *donationRepo) ListBetween(from, to time.Time) ([]donation.Donation, error) {
+	return r.donations, nil
+}
+
+// orderRepo keeps the orders in memory.
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r *orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+func (r *orderRepo) Save(o *order.Order) error {
+	r.orders[o.ID] = *o
+	return nil
+}
+
+func TestDonate(t *testing.T) {
+	ctx := context.Background()
+	paid := time.Now().UTC()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 12.30, Currency: "EUR"},
+		"o2": {ID: "o2", CreatedByID: "u1", TotalPrice: 20, Currency: "EUR", PaidAt: &paid},
+	}}
+	r := &donationRepo{}
+	s := donation.NewService(r, orders, donation.Config{Charity: "Reading Fund", RoundUp: true})
+
+	if _, err := s.Donate(ctx, "u2", "o1", donation.KindRoundUp, 0); err != order.ErrOrderNotFound {
+		t.Errorf("other user: expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := s.Donate(ctx, "u1", "o2", donation.KindFixed, 1); err != donation.ErrAlreadyPaid || len(r.donations) != 0 {
+		t.Errorf("paid: expected ErrAlreadyPaid, got %v", err)
+	}
+	d, err := s.Donate(ctx, "u1", "o1", donation.KindRoundUp, 0)
+	if err != nil || d.Amount != 0.7 || d.UserID != "u1" {
+		t.Fatalf("expected a round-up of 0.70, got %+v, %v", d, err)
+	}
+	if o := orders.orders["o1"]; o.TotalPrice != 13 {
+		t.Errorf("expected the donation added to the total, got %v", o.TotalPrice)
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	s := donation.NewService(&donationRepo{}, &orderRepo{orders: make(map[string]order.Order)}, donation.Config{Charity: "Reading Fund"})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := donation.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"donate without token", "POST", "/donations/v1/orders/o1", "", http.StatusUnauthorized},
+		{"donate unknown order", "POST", "/donations/v1/orders/o1", customer, http.StatusNotFound},
+		{"report without token", "GET", "/donations/v1/report?period=day", "", http.StatusUnauthorized},
+		{"report by customer", "GET", "/donations/v1/report?period=day", customer, http.StatusForbidden},
+		{"report by admin", "GET", "/donations/v1/report?period=day", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{"kind":"fixed","amount":1}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
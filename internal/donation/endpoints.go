@@ -0,0 +1,115 @@
+package donation
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the donation service endpoints under single type.
+type Endpoints struct {
+	QuoteEndpoint  endpoint.Endpoint
+	DonateEndpoint endpoint.Endpoint
+	ReportEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the donation service endpoints. The donations are restricted by
+// account, e.g. to the unscoped tokens of the buyers, the report by admin.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		QuoteEndpoint:  MakeQuoteEndpoint(s),
+		DonateEndpoint: account(MakeDonateEndpoint(s)),
+		ReportEndpoint: admin(MakeReportEndpoint(s)),
+	}
+}
+
+func MakeQuoteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(quoteRequest)
+		amount, e := s.Quote(ctx, req.Total)
+		if e != nil {
+			return quoteResponse{Error: e}, nil
+		}
+		return quoteResponse{RoundUp: amount}, nil
+	}
+}
+
+// MakeDonateEndpoint adds a donation to an order of the user of the
+// request.
+func MakeDonateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(donateRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		d, e := s.Donate(ctx, userID, req.OrderID, req.Kind, req.Amount)
+		if e != nil {
+			return donateResponse{Donation: nil, Error: e}, nil
+		}
+		return donateResponse{Donation: &d, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reportRequest)
+		report, e := s.Report(ctx, req.From, req.To, req.Period)
+		if e != nil {
+			return reportResponse{Summaries: make([]Summary, 0), Error: e}, nil
+		}
+		return reportResponse{Summaries: report}, nil
+	}
+}
+
+type quoteRequest struct {
+	Total float64 `json:"total"`
+}
+
+type quoteResponse struct {
+	RoundUp float64 `json:"round_up"`
+	Error   error   `json:"error,omitempty"`
+}
+
+func (r quoteResponse) error() error {
+	return r.Error
+}
+
+type donateRequest struct {
+	OrderID string  `json:"-"`
+	Kind    string  `json:"kind"`
+	Amount  float64 `json:"amount"`
+}
+
+type donateResponse struct {
+	Status   int       `json:"-"`
+	Donation *Donation `json:"donation,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r donateResponse) status() int {
+	return r.Status
+}
+
+func (r donateResponse) error() error {
+	return r.Error
+}
+
+type reportRequest struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Period string    `json:"period"`
+}
+
+type reportResponse struct {
+	Summaries []Summary `json:"summaries"`
+	Error     error     `json:"error,omitempty"`
+}
+
+func (r reportResponse) error() error {
+	return r.Error
+}
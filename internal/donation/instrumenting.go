@@ -0,0 +1,59 @@
+package donation
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Quote(ctx context.Context, total float64) (amount float64, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "quote", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	amount, err = mw.next.Quote(ctx, total)
+	return
+}
+
+func (mw instrmw) Donate(ctx context.Context, userID, orderID, kind string, amount float64) (donation Donation, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "donate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	donation, err = mw.next.Donate(ctx, userID, orderID, kind, amount)
+	return
+}
+
+func (mw instrmw) Report(ctx context.Context, from, to time.Time, period string) (summaries []Summary, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	summaries, err = mw.next.Report(ctx, from, to, period)
+	return
+}
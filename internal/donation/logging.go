@@ -0,0 +1,56 @@
+package donation
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Quote(ctx context.Context, total float64) (amount float64, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "quote",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Quote(ctx, total)
+}
+
+func (s loggingService) Donate(ctx context.Context, userID, orderID, kind string, amount float64) (donation Donation, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "donate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Donate(ctx, userID, orderID, kind, amount)
+}
+
+func (s loggingService) Report(ctx context.Context, from, to time.Time, period string) (summaries []Summary, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "report",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Report(ctx, from, to, period)
+}
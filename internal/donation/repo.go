@@ -0,0 +1,11 @@
+package donation
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Donation Service
+type Repo interface {
+	Create(d *Donation) error
+	ListByOrder(orderID string) ([]Donation, error)
+	ListBetween(from, to time.Time) ([]Donation, error)
+	Drop() error
+}
@@ -0,0 +1,180 @@
+package donation
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDonationsDisabled = errors.New("donations are disabled")
+	ErrNothingToRoundUp  = errors.New("order total is already a whole amount")
+	ErrInvalidAmount     = errors.New("invalid donation amount")
+	ErrInvalidKind       = errors.New("invalid donation kind")
+	ErrAlreadyDonated    = errors.New("order already has a donation")
+	ErrAlreadyPaid       = errors.New("order is already paid")
+	ErrInvalidPeriod     = errors.New("invalid period")
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+type Service interface {
+	// Quote returns the round-up donation offered for the order total.
+	Quote(ctx context.Context, total float64) (float64, error)
+
+	// Donate adds a donation line to an unpaid order of the user, charged
+	// along with its total. amount is ignored for round-up donations, it
+	// is calculated from the order total.
+	Donate(ctx context.Context, userID, orderID, kind string, amount float64) (Donation, error)
+
+	// Report summarizes collected donations between from and to per period.
+	Report(ctx context.Context, from, to time.Time, period string) ([]Summary, error)
+}
+
+type basicService struct {
+	r      Repo
+	orders order.Repo
+	cfg    Config
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, cfg Config) Service {
+	return basicService{r: r, orders: orders, cfg: cfg}
+}
+
+// Quote returns the round-up donation offered for the order total.
+func (s basicService) Quote(ctx context.Context, total float64) (float64, error) {
+	if s.cfg.Charity == "" || !s.cfg.RoundUp {
+		return 0, ErrDonationsDisabled
+	}
+	return RoundUp(total), nil
+}
+
+// Donate adds a donation line to the order before its payment, the
+// orders of the other users aren't found.
+func (s basicService) Donate(ctx context.Context, userID, orderID, kind string, amount float64) (Donation, error) {
+	if s.cfg.Charity == "" {
+		return Donation{}, ErrDonationsDisabled
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Donation{}, order.ErrOrderNotFound
+	}
+	if o.PaidAt != nil {
+		return Donation{}, ErrAlreadyPaid
+	}
+	existing, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return Donation{}, err
+	}
+	if len(existing) > 0 {
+		return Donation{}, ErrAlreadyDonated
+	}
+
+	switch kind {
+	case KindRoundUp:
+		if !s.cfg.RoundUp {
+			return Donation{}, ErrDonationsDisabled
+		}
+		amount = RoundUp(o.TotalPrice)
+		if amount == 0 {
+			return Donation{}, ErrNothingToRoundUp
+		}
+	case KindFixed:
+		if amount <= 0 || (s.cfg.MaxAmount > 0 && amount > s.cfg.MaxAmount) {
+			return Donation{}, ErrInvalidAmount
+		}
+	default:
+		return Donation{}, ErrInvalidKind
+	}
+
+	d := Donation{
+		OrderID:   o.ID,
+		UserID:    o.CreatedByID,
+		Charity:   s.cfg.Charity,
+		Kind:      kind,
+		Amount:    amount,
+		Currency:  o.Currency,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.Create(&d); err != nil {
+		return Donation{}, err
+	}
+	o.TotalPrice = math.Round((o.TotalPrice+d.Amount)*100) / 100
+	if err := s.orders.Save(&o); err != nil {
+		return Donation{}, errors.Wrapf(err, "donation %s recorded, order %s total not updated", d.ID, o.ID)
+	}
+	return d, nil
+}
+
+// Report summarizes collected donations between from and to per period.
+// Summaries are grouped by charity and currency as well, as they can't be
+// added up. The periods start in the time zone of from.
+func (s basicService) Report(ctx context.Context, from, to time.Time, period string) ([]Summary, error) {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+	donations, err := s.r.ListBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		period            time.Time
+		charity, currency string
+	}
+	sums := make(map[key]*Summary)
+	for _, d := range donations {
+		k := key{truncate(d.CreatedAt.In(from.Location()), period), d.Charity, d.Currency}
+		sum, ok := sums[k]
+		if !ok {
+			sum = &Summary{Period: k.period, Charity: d.Charity, Currency: d.Currency}
+			sums[k] = sum
+		}
+		sum.Count++
+		sum.Total += d.Amount
+	}
+
+	report := make([]Summary, 0, len(sums))
+	for _, sum := range sums {
+		report = append(report, *sum)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if !report[i].Period.Equal(report[j].Period) {
+			return report[i].Period.Before(report[j].Period)
+		}
+		if report[i].Charity != report[j].Charity {
+			return report[i].Charity < report[j].Charity
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	return report, nil
+}
+
+// truncate returns the start of the period t falls into, in the location
+// of t.
+// Weeks start on Monday.
+func truncate(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
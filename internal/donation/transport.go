@@ -0,0 +1,210 @@
+package donation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting  = errors.New("bad routing")
+	ErrInvalidDate = errors.New("invalid date, expected format 2006-01-02 or RFC 3339")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrAlreadyDonated:    "donation.already_donated",
+		ErrAlreadyPaid:       "donation.already_paid",
+		ErrDonationsDisabled: "donation.disabled",
+		ErrInvalidAmount:     "donation.invalid_amount",
+		ErrInvalidKind:       "donation.invalid_kind",
+		ErrNothingToRoundUp:  "donation.nothing_to_round_up",
+	})
+}
+
+// MakeHTTPHandler mounts the donation endpoints, the donations served to
+// the requests account lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireUnscoped, the report to the ones of admin, e.g. with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	quoteHandler := httptransport.NewServer(
+		e.QuoteEndpoint,
+		decodeQuoteRequest,
+		encodeResponse,
+		options...,
+	)
+	donateHandler := httptransport.NewServer(
+		e.DonateEndpoint,
+		decodeDonateRequest,
+		encodeResponse,
+		options...,
+	)
+	reportHandler := httptransport.NewServer(
+		e.ReportEndpoint,
+		decodeReportRequest,
+		encodeResponse,
+		append(options, httptransport.ServerBefore(transport.PopulateTimezone))...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/donations/v1/quote", quoteHandler).Methods("GET")
+	r.Handle("/donations/v1/report", reportHandler).Methods("GET")
+	r.Handle("/donations/v1/orders/{order-id}", donateHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeQuoteRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	total, err := strconv.ParseFloat(req.FormValue("total"), 64)
+	if err != nil {
+		return nil, ErrInvalidAmount
+	}
+	return quoteRequest{Total: total}, nil
+}
+
+func decodeDonateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r donateRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	vars := mux.Vars(req)
+	orderID, ok := vars["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+// decodeReportRequest defaults to the current month per day. The dates and
+// periods are those of the time zone of the request.
+func decodeReportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	loc, err := transport.Timezone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	r := reportRequest{
+		From:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc),
+		To:     now,
+		Period: req.FormValue("period"),
+	}
+	if r.Period == "" {
+		r.Period = PeriodDay
+	}
+	if v := req.FormValue("from"); v != "" {
+		from, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		r.From = from.In(loc)
+	}
+	if v := req.FormValue("to"); v != "" {
+		to, err := transport.ParseDate(v, loc)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		// a to date is inclusive
+		if len(v) == len(transport.DateLayout) {
+			to = to.AddDate(0, 0, 1)
+		}
+		r.To = to.In(loc)
+	}
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyDonated, ErrAlreadyPaid:
+		return http.StatusConflict
+	case ErrDonationsDisabled, ErrNothingToRoundUp:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrInvalidAmount, ErrInvalidKind, ErrInvalidPeriod, ErrInvalidDate, transport.ErrBadTimezone, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,100 @@
+package ebook_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/ebook"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo has the watermarked copies already generated.
+type repo struct {
+	ebook.Repo
+}
+
+func (repo) GetArtifact(orderID, bookID, format string) (ebook.Artifact, error) {
+	return ebook.Artifact{OrderID: orderID, BookID: bookID, Format: format, Path: orderID + "/" + bookID}, nil
+}
+
+type content struct {
+	*strings.Reader
+}
+
+func (content) Close() error {
+	return nil
+}
+
+type store struct {
+	ebook.Store
+}
+
+func (store) Open(path string) (ebook.Content, time.Time, error) {
+	return content{strings.NewReader("ebook " + path)}, time.Now(), nil
+}
+
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+func TestDownload(t *testing.T) {
+	paid := time.Now()
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", Items: []catalog.Book{{ID: "b1"}}, PaidAt: &paid},
+		"o2": {ID: "o2", CreatedByID: "u1", Items: []catalog.Book{{ID: "b1"}}},
+	}}
+	s := ebook.NewService(repo{}, orders, nil, store{}, nil)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	h := ebook.MakeHTTPHandler(context.Background(), s, account, log.NewNopLogger())
+	u1, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	u2, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+
+	for _, c := range []struct {
+		name, path, token string
+		status            int
+	}{
+		{"without token", "/ebooks/v1/o1/b1/download", "", http.StatusUnauthorized},
+		{"paid", "/ebooks/v1/o1/b1/download", u1, http.StatusOK},
+		{"unpaid", "/ebooks/v1/o2/b1/download", u1, http.StatusConflict},
+		{"not in the order", "/ebooks/v1/o1/b2/download", u1, http.StatusForbidden},
+		{"another user", "/ebooks/v1/o1/b1/download", u2, http.StatusNotFound},
+		{"at the user route", "/ebooks/v1/u1/o1/b1/download", u1, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+		if c.status == http.StatusOK {
+			if b, _ := ioutil.ReadAll(w.Body); string(b) != "ebook o1/b1" {
+				t.Errorf("%s: expected the copy of the order, got %q", c.name, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package ebook
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	FormatEPUB = "epub"
+	FormatPDF  = "pdf"
+)
+
+// File is the master copy of an ebook in particular format. Master copies
+// are never served directly, every download gets watermarked copy.
+type File struct {
+	ID     string `json:"id"`
+	BookID string `json:"book_id"`
+	Format string `json:"format"`
+	Path   string `json:"-"`
+}
+
+// Artifact is a watermarked copy generated for particular purchase.
+// Artifacts are cached so that repeated downloads of the same purchase
+// don't go through the processing pipeline again.
+type Artifact struct {
+	ID        string
+	OrderID   string
+	BookID    string
+	Format    string
+	Path      string
+	CreatedAt time.Time
+}
+
+// Mark is what gets stamped into the ebook.
+type Mark struct {
+	Name    string
+	Email   string
+	OrderID string
+}
+
+// Content is an opened ebook file.
+type Content interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// Download is a watermarked ebook ready to be served.
+type Download struct {
+	Name    string
+	ModTime time.Time
+	Content Content
+}
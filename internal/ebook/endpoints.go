@@ -0,0 +1,74 @@
+package ebook
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the ebook service endpoints under single type.
+type Endpoints struct {
+	FormatsEndpoint  endpoint.Endpoint
+	DownloadEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the ebook service endpoints. The downloads are restricted by
+// account, e.g. to the unscoped tokens of the users.
+func MakeEndpoints(s Service, account endpoint.Middleware) Endpoints {
+	return Endpoints{
+		FormatsEndpoint:  MakeFormatsEndpoint(s),
+		DownloadEndpoint: account(MakeDownloadEndpoint(s)),
+	}
+}
+
+func MakeFormatsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(formatsRequest)
+		files, e := s.Formats(ctx, req.BookID)
+		if e != nil {
+			return formatsResponse{Formats: make([]File, 0), Error: e}, nil
+		}
+		return formatsResponse{Formats: files}, nil
+	}
+}
+
+// MakeDownloadEndpoint opens an ebook bought by the user of the request.
+func MakeDownloadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(downloadRequest)
+		d, e := s.Download(ctx, userID, req.OrderID, req.BookID, req.Format)
+		return downloadResponse{Download: d, Error: e}, nil
+	}
+}
+
+type formatsRequest struct {
+	BookID string `json:"book_id"`
+}
+
+type formatsResponse struct {
+	Formats []File `json:"formats"`
+	Error   error  `json:"error,omitempty"`
+}
+
+func (r formatsResponse) error() error {
+	return r.Error
+}
+
+type downloadRequest struct {
+	OrderID string
+	BookID  string
+	Format  string
+}
+
+// downloadResponse is written by the downloadHandler, the Content of the
+// Download being closed once served.
+type downloadResponse struct {
+	Download Download
+	Error    error
+}
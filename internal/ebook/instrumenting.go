@@ -0,0 +1,48 @@
+package ebook
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Formats(ctx context.Context, bookID string) (files []File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "formats", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	files, err = mw.next.Formats(ctx, bookID)
+	return
+}
+
+func (mw instrmw) Download(ctx context.Context, userID, orderID, bookID, format string) (d Download, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "download", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	d, err = mw.next.Download(ctx, userID, orderID, bookID, format)
+	return
+}
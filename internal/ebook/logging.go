@@ -0,0 +1,47 @@
+package ebook
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Formats(ctx context.Context, bookID string) (files []File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "formats",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Formats(ctx, bookID)
+}
+
+func (s loggingService) Download(ctx context.Context, userID, orderID, bookID, format string) (d Download, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "download",
+			"order_id", orderID,
+			"format", format,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Download(ctx, userID, orderID, bookID, format)
+}
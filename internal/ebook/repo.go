@@ -0,0 +1,10 @@
+package ebook
+
+// Repo abstracts all the persistant storage operations of Ebook Service
+type Repo interface {
+	CreateFile(f *File) error
+	ListFiles(bookID string) ([]File, error)
+	GetArtifact(orderID, bookID, format string) (Artifact, error)
+	CreateArtifact(a *Artifact) error
+	Drop() error
+}
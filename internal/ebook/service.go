@@ -0,0 +1,168 @@
+package ebook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrNotPurchased   = errors.New("book is not purchased in the order")
+	ErrUnpaid         = errors.New("order is not paid")
+	ErrFormatNotFound = errors.New("ebook format not found")
+)
+
+type Service interface {
+	// Formats lists all the formats the ebook is available in.
+	Formats(ctx context.Context, bookID string) ([]File, error)
+
+	// Download returns the watermarked copy of the ebook purchased by the
+	// user in the paid order. Copy is generated on first download and cached
+	// for the following ones.
+	Download(ctx context.Context, userID, orderID, bookID, format string) (Download, error)
+}
+
+type basicService struct {
+	r        Repo
+	orders   order.Repo
+	users    user.Repo
+	store    Store
+	pipeline Pipeline
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, users user.Repo, store Store, pipeline Pipeline) Service {
+	return basicService{
+		r:        r,
+		orders:   orders,
+		users:    users,
+		store:    store,
+		pipeline: pipeline,
+	}
+}
+
+// Formats lists all the formats the ebook is available in.
+func (s basicService) Formats(ctx context.Context, bookID string) ([]File, error) {
+	return s.r.ListFiles(bookID)
+}
+
+// Download returns the watermarked copy of the ebook. Caller is responsible
+// to close the Download content.
+func (s basicService) Download(ctx context.Context, userID, orderID, bookID, format string) (Download, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Download{}, order.ErrOrderNotFound
+	}
+	if !hasItem(o, bookID) {
+		return Download{}, ErrNotPurchased
+	}
+	if o.PaidAt == nil {
+		return Download{}, ErrUnpaid
+	}
+	name := fmt.Sprintf("%s-%s.%s", bookID, orderID, format)
+
+	if a, err := s.r.GetArtifact(orderID, bookID, format); err == nil {
+		content, modtime, err := s.store.Open(a.Path)
+		if err == nil {
+			return Download{Name: name, ModTime: modtime, Content: content}, nil
+		}
+		// Cached file is gone, generate it again.
+	}
+
+	a, err := s.generate(o, bookID, format)
+	if err != nil {
+		return Download{}, err
+	}
+	content, modtime, err := s.store.Open(a.Path)
+	if err != nil {
+		return Download{}, err
+	}
+	return Download{Name: name, ModTime: modtime, Content: content}, nil
+}
+
+// generate runs the master copy through the watermark pipeline and
+// caches the result as an Artifact of the purchase.
+func (s basicService) generate(o order.Order, bookID, format string) (Artifact, error) {
+	f, err := s.file(bookID, format)
+	if err != nil {
+		return Artifact{}, err
+	}
+	u, err := s.users.GetByID(o.CreatedByID)
+	if err != nil {
+		return Artifact{}, err
+	}
+	mark := Mark{
+		Name:    u.FirstName + " " + u.LastName,
+		Email:   u.Email,
+		OrderID: o.ID,
+	}
+
+	master, _, err := s.store.Open(f.Path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer master.Close()
+	size, err := master.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := s.pipeline.Watermark(format, &buf, master, size, mark); err != nil {
+		return Artifact{}, err
+	}
+
+	a := Artifact{
+		OrderID:   o.ID,
+		BookID:    bookID,
+		Format:    format,
+		Path:      fmt.Sprintf("artifacts/%s/%s.%s", o.ID, bookID, format),
+		CreatedAt: time.Now().UTC(),
+	}
+	w, err := s.store.Create(a.Path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		w.Close()
+		return Artifact{}, err
+	}
+	if err := w.Close(); err != nil {
+		return Artifact{}, err
+	}
+	if err := s.r.CreateArtifact(&a); err != nil {
+		return Artifact{}, err
+	}
+	return a, nil
+}
+
+func (s basicService) file(bookID, format string) (File, error) {
+	files, err := s.r.ListFiles(bookID)
+	if err != nil {
+		return File{}, err
+	}
+	for _, f := range files {
+		if f.Format == format {
+			return f, nil
+		}
+	}
+	return File{}, ErrFormatNotFound
+}
+
+func hasItem(o order.Order, bookID string) bool {
+	for _, b := range o.Items {
+		if b.ID == bookID {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
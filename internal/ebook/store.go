@@ -0,0 +1,69 @@
+package ebook
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store abstracts where the master copies and generated artifacts are kept.
+type Store interface {
+	Open(path string) (Content, time.Time, error)
+	Create(path string) (io.WriteCloser, error)
+}
+
+type dirStore struct {
+	root string
+}
+
+// NewDirStore returns a Store that keeps ebook files under root directory.
+func NewDirStore(root string) Store {
+	return dirStore{root: root}
+}
+
+func (s dirStore) path(path string) string {
+	return filepath.Join(s.root, filepath.Clean("/"+path))
+}
+
+func (s dirStore) Open(path string) (Content, time.Time, error) {
+	f, err := os.Open(s.path(path))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, time.Time{}, err
+	}
+	return f, fi.ModTime(), nil
+}
+
+// Create returns a writer for the file at path. Content is written to a
+// temporary file and moved in place on Close, so that concurrent readers
+// never see half written file.
+func (s dirStore) Create(path string) (io.WriteCloser, error) {
+	dst := s.path(path)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(dst), ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, dst: dst}, nil
+}
+
+type atomicFile struct {
+	*os.File
+	dst string
+}
+
+func (f *atomicFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), f.dst)
+}
@@ -0,0 +1,170 @@
+package ebook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrFormatNotFound:    "ebook.format_not_found",
+		ErrNotPurchased:      "ebook.not_purchased",
+		ErrUnpaid:            "ebook.unpaid",
+		ErrUnsupportedFormat: "ebook.unsupported_format",
+	})
+}
+
+// MakeHTTPHandler mounts the ebook endpoints, the downloads of the user
+// served to the requests account lets through, e.g. auth.NewMiddleware
+// chained with rbac.RequireUnscoped.
+func MakeHTTPHandler(ctx context.Context, s Service, account endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	formatsHandler := httptransport.NewServer(
+		e.FormatsEndpoint,
+		decodeFormatsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/ebooks/v1/{book-id}/formats", formatsHandler).Methods("GET")
+	r.Handle("/ebooks/v1/{order-id}/{book-id}/download", downloadHandler{e.DownloadEndpoint}).Methods("GET", "HEAD")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// downloadHandler serves the watermarked file directly instead of going
+// through go-kit transport, since the response is not json. The download
+// goes through the endpoint e all the same, for its middlewares.
+type downloadHandler struct {
+	e endpoint.Endpoint
+}
+
+func (h downloadHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	for _, f := range []httptransport.RequestFunc{i18n.PopulateLocale, auth.PopulateToken, csrf.Populate} {
+		ctx = f(ctx, req)
+	}
+	vars := mux.Vars(req)
+	for _, v := range []string{"order-id", "book-id"} {
+		if _, ok := vars[v]; !ok {
+			encodeError(ctx, errors.Wrap(ErrBadRouting, v), w)
+			return
+		}
+	}
+	format := req.FormValue("format")
+	if format == "" {
+		format = FormatEPUB
+	}
+
+	resp, err := h.e(ctx, downloadRequest{OrderID: vars["order-id"], BookID: vars["book-id"], Format: format})
+	if err != nil {
+		encodeError(ctx, err, w)
+		return
+	}
+	r := resp.(downloadResponse)
+	if r.Error != nil {
+		encodeError(ctx, r.Error, w)
+		return
+	}
+	d := r.Download
+	defer d.Content.Close()
+
+	w.Header().Set("Content-Disposition", `attachment; filename="`+d.Name+`"`)
+	http.ServeContent(w, req, d.Name, d.ModTime, d.Content)
+}
+
+func decodeFormatsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	bookID, ok := vars["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	return formatsRequest{
+		BookID: bookID,
+	}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound, ErrFormatNotFound:
+		return http.StatusNotFound
+	case ErrNotPurchased:
+		return http.StatusForbidden
+	case ErrUnpaid:
+		return http.StatusConflict
+	case ErrBadRouting, ErrUnsupportedFormat:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
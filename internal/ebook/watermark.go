@@ -0,0 +1,168 @@
+package ebook
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported ebook format")
+	ErrMalformedFile     = errors.New("malformed ebook file")
+)
+
+// Watermarker stamps the Mark into an ebook of particular format.
+type Watermarker interface {
+	Watermark(w io.Writer, src io.ReaderAt, size int64, m Mark) error
+}
+
+// Pipeline maps ebook format to its Watermarker.
+type Pipeline map[string]Watermarker
+
+// NewPipeline returns Pipeline with watermarkers for all the supported formats.
+func NewPipeline() Pipeline {
+	return Pipeline{
+		FormatEPUB: EPUBWatermarker{},
+		FormatPDF:  PDFWatermarker{},
+	}
+}
+
+// Watermark runs the Watermarker registered for the format.
+func (p Pipeline) Watermark(format string, w io.Writer, src io.ReaderAt, size int64, m Mark) error {
+	wm, ok := p[format]
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+	return wm.Watermark(w, src, size, m)
+}
+
+// String returns single line text of the mark.
+func (m Mark) String() string {
+	s := fmt.Sprintf("Licensed to %s <%s>, order %s", m.Name, m.Email, m.OrderID)
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// EPUBWatermarker appends a visible footer to every content document
+// and adds META-INF/watermark.txt to the container.
+type EPUBWatermarker struct{}
+
+func (EPUBWatermarker) Watermark(w io.Writer, src io.ReaderAt, size int64, m Mark) error {
+	zr, err := zip.NewReader(src, size)
+	if err != nil {
+		return ErrMalformedFile
+	}
+	zw := zip.NewWriter(w)
+	footer := []byte(`<p class="watermark">` + html.EscapeString(m.String()) + `</p>`)
+
+	for _, f := range zr.File {
+		// mimetype entry must stay as it is (first and uncompressed),
+		// so only the content documents are touched.
+		hdr := f.FileHeader
+		dst, err := zw.CreateHeader(&hdr)
+		if err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		if isContentDocument(f.Name) {
+			err = copyWithFooter(dst, rc, footer)
+		} else {
+			_, err = io.Copy(dst, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	dst, err := zw.Create("META-INF/watermark.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(dst, m.String()+"\n"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func isContentDocument(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xhtml", ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+func copyWithFooter(w io.Writer, r io.Reader, footer []byte) error {
+	doc, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	i := bytes.LastIndex(doc, []byte("</body>"))
+	if i < 0 {
+		_, err = w.Write(doc)
+		return err
+	}
+	for _, b := range [][]byte{doc[:i], footer, doc[i:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PDFWatermarker stamps the mark as a comment at the end of the file.
+// Trailer is repeated after the comment so that readers still find it
+// at the end of the file.
+type PDFWatermarker struct{}
+
+func (PDFWatermarker) Watermark(w io.Writer, src io.ReaderAt, size int64, m Mark) error {
+	// startxref is always within last few bytes of the file.
+	tailSize := int64(1024)
+	if size < tailSize {
+		tailSize = size
+	}
+	tail := make([]byte, tailSize)
+	if _, err := src.ReadAt(tail, size-tailSize); err != nil && err != io.EOF {
+		return err
+	}
+	xref, err := lastStartXref(tail)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(src, 0, size)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%% %s\nstartxref\n%d\n%%%%EOF\n", m.String(), xref)
+	return err
+}
+
+func lastStartXref(tail []byte) (int64, error) {
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return 0, ErrMalformedFile
+	}
+	fields := strings.Fields(string(tail[i+len("startxref"):]))
+	if len(fields) == 0 {
+		return 0, ErrMalformedFile
+	}
+	xref, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, ErrMalformedFile
+	}
+	return xref, nil
+}
@@ -0,0 +1,90 @@
+package ebook_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/ebook"
+)
+
+var mark = ebook.Mark{Name: "Chandler Bing", Email: "chandler@golang.org", OrderID: "o1"}
+
+func TestEPUBWatermark(t *testing.T) {
+	var src bytes.Buffer
+	zw := zip.NewWriter(&src)
+	files := map[string]string{
+		"mimetype":          "application/epub+zip",
+		"OEBPS/ch1.xhtml":   "<html><body><p>Once upon a time</p></body></html>",
+		"OEBPS/style.css":   "body {}",
+		"META-INF/cont.xml": "<container/>",
+	}
+	for _, name := range []string{"mimetype", "OEBPS/ch1.xhtml", "OEBPS/style.css", "META-INF/cont.xml"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(files[name]))
+	}
+	zw.Close()
+
+	var dst bytes.Buffer
+	err := ebook.EPUBWatermarker{}.Watermark(&dst, bytes.NewReader(src.Bytes()), int64(src.Len()), mark)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(dst.Bytes()), int64(dst.Len()))
+	if err != nil {
+		t.Fatalf("expected valid zip, got %v", err)
+	}
+	if zr.File[0].Name != "mimetype" {
+		t.Errorf("expected mimetype as first entry, got %v", zr.File[0].Name)
+	}
+	got := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		got[f.Name] = string(b)
+	}
+	if !strings.Contains(got["OEBPS/ch1.xhtml"], "Licensed to Chandler Bing") {
+		t.Errorf("expected watermark in content document, got %v", got["OEBPS/ch1.xhtml"])
+	}
+	if !strings.HasSuffix(got["OEBPS/ch1.xhtml"], "</body></html>") {
+		t.Errorf("expected watermark inside body, got %v", got["OEBPS/ch1.xhtml"])
+	}
+	if got["OEBPS/style.css"] != files["OEBPS/style.css"] {
+		t.Errorf("expected untouched stylesheet, got %v", got["OEBPS/style.css"])
+	}
+	if _, ok := got["META-INF/watermark.txt"]; !ok {
+		t.Errorf("expected META-INF/watermark.txt, got none")
+	}
+}
+
+func TestPDFWatermark(t *testing.T) {
+	src := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\nxref\n0 1\ntrailer\n<<>>\nstartxref\n27\n%%EOF\n")
+
+	var dst bytes.Buffer
+	err := ebook.PDFWatermarker{}.Watermark(&dst, bytes.NewReader(src), int64(len(src)), mark)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	out := dst.String()
+	if !strings.HasPrefix(out, string(src)) {
+		t.Errorf("expected original content to be kept")
+	}
+	if !strings.Contains(out, "% Licensed to Chandler Bing") {
+		t.Errorf("expected watermark comment, got %v", out)
+	}
+	if !strings.HasSuffix(out, "startxref\n27\n%%EOF\n") {
+		t.Errorf("expected trailer at the end, got %v", out)
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		src := []byte("not a pdf")
+		err := ebook.PDFWatermarker{}.Watermark(&dst, bytes.NewReader(src), int64(len(src)), mark)
+		if err != ebook.ErrMalformedFile {
+			t.Errorf("expected ErrMalformedFile, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package erasure
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the erasure service endpoints under single type.
+type Endpoints struct {
+	SubmitEndpoint   endpoint.Endpoint
+	RequestsEndpoint endpoint.Endpoint
+	RequestEndpoint  endpoint.Endpoint
+	QueueEndpoint    endpoint.Endpoint
+	ResolveEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the erasure service endpoints. The user endpoints are restricted by
+// account, e.g. to the unscoped tokens of the users themselves, the review
+// ones by admin. The submissions and the reviews are recorded by audited.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware, audited audit.AuditLogger) Endpoints {
+	record := func(describe audit.Describer) endpoint.Middleware {
+		return audit.NewMiddleware(audited, describe)
+	}
+	return Endpoints{
+		SubmitEndpoint:   account(record(describeSubmit)(MakeSubmitEndpoint(s))),
+		RequestsEndpoint: account(MakeRequestsEndpoint(s)),
+		RequestEndpoint:  admin(MakeRequestEndpoint(s)),
+		QueueEndpoint:    admin(MakeQueueEndpoint(s)),
+		ResolveEndpoint:  admin(record(describeResolve)(MakeResolveEndpoint(s))),
+	}
+}
+
+// MakeSubmitEndpoint requests the erasure of the user of the request.
+func MakeSubmitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(NewRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		r, e := s.Submit(ctx, userID, req)
+		if e != nil {
+			return requestResponse{Request: nil, Error: e}, nil
+		}
+		return requestResponse{Request: &r, Status: http.StatusAccepted}, nil
+	}
+}
+
+// MakeRequestsEndpoint lists the requests of the user of the request.
+func MakeRequestsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		requests, e := s.Requests(ctx, userID)
+		if e != nil {
+			return requestsResponse{Requests: make([]Request, 0), Error: e}, nil
+		}
+		return requestsResponse{Requests: requests}, nil
+	}
+}
+
+func MakeRequestEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(requestRequest)
+		r, events, e := s.Request(ctx, req.ID)
+		if e != nil {
+			return requestResponse{Request: nil, Error: e}, nil
+		}
+		return requestResponse{Request: &r, Events: events}, nil
+	}
+}
+
+func MakeQueueEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		queue, e := s.Queue(ctx)
+		if e != nil {
+			return requestsResponse{Requests: make([]Request, 0), Error: e}, nil
+		}
+		return requestsResponse{Requests: queue}, nil
+	}
+}
+
+// MakeResolveEndpoint resolves a request, reviewed by the admin of the
+// request.
+func MakeResolveEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resolveRequest)
+		reviewer, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		r, e := s.Resolve(ctx, req.ID, req.Approve, reviewer, req.Note)
+		if e != nil {
+			return requestResponse{Request: nil, Error: e}, nil
+		}
+		return requestResponse{Request: &r}, nil
+	}
+}
+
+// describeSubmit records the user of the request asking for their erasure.
+func describeSubmit(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionErasureRequest}
+	res, _ := response.(requestResponse)
+	return e, res.Error
+}
+
+// describeResolve records the admin of the request approving or rejecting
+// the erasure request.
+func describeResolve(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionErasureReview, Subject: request.(resolveRequest).ID}
+	res, _ := response.(requestResponse)
+	return e, res.Error
+}
+
+type requestRequest struct {
+	ID string
+}
+
+type requestResponse struct {
+	Status  int      `json:"-"`
+	Request *Request `json:"request,omitempty"`
+	// Events is the audit trail of the request, for the admins.
+	Events []Event `json:"events,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r requestResponse) status() int {
+	return r.Status
+}
+
+func (r requestResponse) error() error {
+	return r.Error
+}
+
+type requestsResponse struct {
+	Requests []Request `json:"requests"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r requestsResponse) error() error {
+	return r.Error
+}
+
+type resolveRequest struct {
+	ID      string `json:"-"`
+	Approve bool   `json:"approve"`
+	Note    string `json:"note"`
+}
@@ -0,0 +1,83 @@
+package erasure
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Statuses of a request. A pending request is a review task for the admins,
+// an approved one is queued for the erasure until completed.
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusCompleted = "completed"
+)
+
+// Actions recorded in the audit trail of a request. A step of the erasure
+// is recorded once done, or failed with its error as the note.
+const (
+	ActionSubmit   = "submit"
+	ActionApprove  = "approve"
+	ActionReject   = "reject"
+	ActionErase    = "erase"
+	ActionFail     = "fail"
+	ActionComplete = "complete"
+)
+
+// Request is the request of a user to have their personal data erased,
+// carried out once approved by an admin. The request only refers to the
+// user by ID, which is kept by the records the shop must keep, e.g. the
+// orders, with the user anonymized.
+type Request struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id" sql:"index"`
+	Reason string `json:"reason,omitempty" sql:"type:text"`
+	Status string `json:"status" sql:"index"`
+	// Attempts counts the runs of an approved request, a failed step
+	// leaving it approved to run again.
+	Attempts    int        `json:"attempts"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	Note        string     `json:"note,omitempty" sql:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (Request) TableName() string {
+	return "erasure_requests"
+}
+
+// NewRequest is an erasure a user asks for.
+type NewRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Validate checks the reason, which is optional.
+func (n *NewRequest) Validate() error {
+	var v validate.Validator
+	v.MaxLength("reason", n.Reason, 2000)
+	return v.Err()
+}
+
+// clean trims the submitted values.
+func (n *NewRequest) clean() {
+	n.Reason = strings.TrimSpace(n.Reason)
+}
+
+// Event is an entry of the audit trail of a request, recording who did
+// what to it and when.
+type Event struct {
+	ID        string    `json:"id"`
+	RequestID string    `json:"request_id" sql:"index"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty" sql:"type:text"`
+	At        time.Time `json:"at"`
+}
+
+func (Event) TableName() string {
+	return "erasure_events"
+}
@@ -0,0 +1,230 @@
+package erasure_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/browsing"
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/erasure"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/restock"
+	"github.com/kavirajk/bookshop/support"
+	"github.com/kavirajk/bookshop/user"
+)
+
+type memRepo struct {
+	requests map[string]erasure.Request
+	events   []erasure.Event
+}
+
+func (r *memRepo) Create(req *erasure.Request) error {
+	req.ID = "r" + string(rune('0'+len(r.requests)))
+	r.requests[req.ID] = *req
+	return nil
+}
+
+func (r *memRepo) Save(req *erasure.Request) error {
+	r.requests[req.ID] = *req
+	return nil
+}
+
+func (r *memRepo) GetByID(ID string) (erasure.Request, error) {
+	req, ok := r.requests[ID]
+	if !ok {
+		return erasure.Request{}, db.ErrNotFound
+	}
+	return req, nil
+}
+
+func (r *memRepo) ListByUser(userID string) ([]erasure.Request, error) {
+	var requests []erasure.Request
+	for _, req := range r.requests {
+		if req.UserID == userID {
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
+
+func (r *memRepo) ListByStatus(status string) ([]erasure.Request, error) {
+	var requests []erasure.Request
+	for _, req := range r.requests {
+		if req.Status == status {
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
+
+func (r *memRepo) AddEvent(e *erasure.Event) error {
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func (r *memRepo) ListEvents(requestID string) ([]erasure.Event, error) {
+	return r.events, nil
+}
+
+func (r *memRepo) Drop() error {
+	return nil
+}
+
+// userRepo keeps the users in memory, recording whether one was
+// forgotten.
+type userRepo struct {
+	user.Repo
+	forgotten bool
+}
+
+func (r *userRepo) Forget(userID string) error {
+	r.forgotten = true
+	return r.Repo.Forget(userID)
+}
+
+type orderRepo struct {
+	order.Repo
+	o order.Order
+}
+
+func (r *orderRepo) ListByUser(userID string) ([]order.Order, error) {
+	return []order.Order{r.o}, nil
+}
+
+func (r *orderRepo) Save(o *order.Order) error {
+	r.o = *o
+	return nil
+}
+
+func (r *orderRepo) ListCartLines(userID string) ([]order.CartLine, error) {
+	return nil, nil
+}
+
+type supportRepo struct{ support.Repo }
+
+func (supportRepo) ListByUser(userID string) ([]support.Ticket, error) {
+	return nil, nil
+}
+
+type browsingRepo struct{ browsing.Repo }
+
+func (browsingRepo) UserViews(userID string) ([]browsing.View, error) {
+	return nil, nil
+}
+
+// consentRepo fails to erase the records as many times as fail.
+type consentRepo struct {
+	consent.Repo
+	fail int
+}
+
+func (r *consentRepo) EraseRecords(userID string) error {
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+type rectificationRepo struct{ rectification.Repo }
+
+func (rectificationRepo) Erase(userID string) error {
+	return nil
+}
+
+type restockRepo struct {
+	restock.Repo
+	deleted string
+}
+
+func (r *restockRepo) DeleteSubscriptions(email string) error {
+	r.deleted = email
+	return nil
+}
+
+func TestErasure(t *testing.T) {
+	ctx := context.Background()
+	r := &memRepo{requests: make(map[string]erasure.Request)}
+	users := &userRepo{Repo: inmem.NewUserRepo()}
+	users.Create(&user.User{ID: "u1", FirstName: "Ada", Email: "ada@example.com", Username: "ada", Password: "hash"})
+	orders := &orderRepo{o: order.Order{ID: "o1", CreatedByID: "u1", Note: "leave at the door of Ada"}}
+	restocks := &restockRepo{}
+	s := erasure.NewService(r, erasure.Repos{
+		Users:          users,
+		Orders:         orders,
+		Support:        supportRepo{},
+		Browsing:       browsingRepo{},
+		Consents:       &consentRepo{fail: 1},
+		Rectifications: rectificationRepo{},
+		Restock:        restocks,
+	})
+
+	req, err := s.Submit(ctx, "u1", erasure.NewRequest{Reason: " moving away "})
+	if err != nil || req.Status != erasure.StatusPending || req.Reason != "moving away" {
+		t.Fatalf("submit: expected a pending request, got %+v, %v", req, err)
+	}
+	if _, err := s.Submit(ctx, "u1", erasure.NewRequest{}); err != erasure.ErrAlreadyRequested {
+		t.Errorf("submit again: expected ErrAlreadyRequested, got %v", err)
+	}
+	if _, err := s.Resolve(ctx, req.ID, true, "u1", ""); err != erasure.ErrSelfReview {
+		t.Errorf("self review: expected ErrSelfReview, got %v", err)
+	}
+	if _, err := s.Resolve(ctx, req.ID, false, "admin", ""); err != erasure.ErrNoteRequired {
+		t.Errorf("reject without note: expected ErrNoteRequired, got %v", err)
+	}
+	if req, err = s.Resolve(ctx, req.ID, true, "admin", ""); err != nil || req.Status != erasure.StatusApproved {
+		t.Fatalf("approve: expected an approved request, got %+v, %v", req, err)
+	}
+
+	// A failing step leaves the request approved, run again next time.
+	if err := s.ProcessRequests(ctx); err == nil {
+		t.Errorf("failing step: expected an error")
+	}
+	if req, _, _ = s.Request(ctx, req.ID); req.Status != erasure.StatusApproved || req.Attempts != 1 {
+		t.Errorf("failing step: expected the request approved after 1 attempt, got %+v", req)
+	}
+	if users.forgotten {
+		t.Errorf("failing step: expected the account erased last")
+	}
+	if err := s.ProcessRequests(ctx); err != nil {
+		t.Fatalf("retry: unexpected error %v", err)
+	}
+	req, events, _ := s.Request(ctx, req.ID)
+	if req.Status != erasure.StatusCompleted || req.CompletedAt == nil {
+		t.Errorf("retry: expected the request completed, got %+v", req)
+	}
+	var fails int
+	for _, e := range events {
+		if e.Action == erasure.ActionFail {
+			fails++
+			if !strings.HasPrefix(e.Note, "consents: ") {
+				t.Errorf("expected the failed step in the note, got %q", e.Note)
+			}
+		}
+	}
+	if fails != 1 || events[len(events)-1].Action != erasure.ActionComplete {
+		t.Errorf("expected one failure then the completion in the trail, got %+v", events)
+	}
+
+	u, _ := users.GetByID("u1")
+	if !users.forgotten || u.ID != "u1" || u.FirstName != "" || u.Password != "" || !u.Deactivated || u.DeletedAt == nil {
+		t.Errorf("expected the account anonymized, got %+v", u)
+	}
+	if strings.Contains(u.Email, "ada") || strings.Contains(u.Username, "ada") {
+		t.Errorf("expected the email and username replaced, got %q %q", u.Email, u.Username)
+	}
+	if orders.o.Note != "" || orders.o.CreatedByID != "u1" {
+		t.Errorf("expected the order kept without its note, got %+v", orders.o)
+	}
+	if restocks.deleted != "ada@example.com" {
+		t.Errorf("expected the subscriptions of the former email deleted, got %q", restocks.deleted)
+	}
+	if _, err := s.Submit(ctx, "u1", erasure.NewRequest{}); err != user.ErrDeactivated {
+		t.Errorf("submit erased: expected ErrDeactivated, got %v", err)
+	}
+}
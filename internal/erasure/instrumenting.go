@@ -0,0 +1,92 @@
+package erasure
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Submit(ctx context.Context, userID string, n NewRequest) (request Request, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "submit", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	request, err = mw.next.Submit(ctx, userID, n)
+	return
+}
+
+func (mw instrmw) Requests(ctx context.Context, userID string) (requests []Request, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "requests", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	requests, err = mw.next.Requests(ctx, userID)
+	return
+}
+
+func (mw instrmw) Request(ctx context.Context, ID string) (request Request, events []Event, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "request", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	request, events, err = mw.next.Request(ctx, ID)
+	return
+}
+
+func (mw instrmw) Queue(ctx context.Context) (requests []Request, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "queue", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	requests, err = mw.next.Queue(ctx)
+	return
+}
+
+func (mw instrmw) Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (request Request, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "resolve", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	request, err = mw.next.Resolve(ctx, ID, approve, reviewer, note)
+	return
+}
+
+func (mw instrmw) ProcessRequests(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "process_requests", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ProcessRequests(ctx)
+	return
+}
@@ -0,0 +1,25 @@
+package erasure
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunRequests erases the users of the approved requests every interval
+// until ctx is done.
+func RunRequests(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessRequests(ctx); err != nil {
+				logger.Log("jobs", "erasure", "err", err)
+			}
+		}
+	}
+}
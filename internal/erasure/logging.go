@@ -0,0 +1,89 @@
+package erasure
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Submit(ctx context.Context, userID string, n NewRequest) (request Request, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "submit",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Submit(ctx, userID, n)
+}
+
+func (s loggingService) Requests(ctx context.Context, userID string) (requests []Request, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "requests",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Requests(ctx, userID)
+}
+
+func (s loggingService) Request(ctx context.Context, ID string) (request Request, events []Event, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "request",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Request(ctx, ID)
+}
+
+func (s loggingService) Queue(ctx context.Context) (requests []Request, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "queue",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Queue(ctx)
+}
+
+func (s loggingService) Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (request Request, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "resolve",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Resolve(ctx, ID, approve, reviewer, note)
+}
+
+func (s loggingService) ProcessRequests(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "process_requests",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ProcessRequests(ctx)
+}
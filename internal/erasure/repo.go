@@ -0,0 +1,17 @@
+package erasure
+
+// Repo abstracts all the persistant storage operations of Erasure service.
+type Repo interface {
+	Create(r *Request) error
+	Save(r *Request) error
+	GetByID(ID string) (Request, error)
+	// ListByUser returns the requests of a user, latest first.
+	ListByUser(userID string) ([]Request, error)
+	// ListByStatus returns the requests in status, oldest first.
+	ListByStatus(status string) ([]Request, error)
+	// AddEvent appends to the audit trail of a request.
+	AddEvent(e *Event) error
+	// ListEvents returns the audit trail of a request, oldest first.
+	ListEvents(requestID string) ([]Event, error)
+	Drop() error
+}
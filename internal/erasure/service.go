@@ -0,0 +1,213 @@
+package erasure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrRequestNotFound  = errors.New("erasure request not found")
+	ErrAlreadyRequested = errors.New("an erasure is already requested")
+	ErrAlreadyResolved  = errors.New("erasure request is already resolved")
+	ErrSelfReview       = errors.New("an erasure can't be reviewed by its own user")
+	ErrNoteRequired     = errors.New("a rejection needs a note for the user")
+)
+
+// actor is who the erasure steps are recorded by.
+const actor = "erasure"
+
+type Service interface {
+	// Submit requests the erasure of the personal data of a user, pending
+	// until reviewed by an admin.
+	Submit(ctx context.Context, userID string, n NewRequest) (Request, error)
+
+	// Requests lists the requests of a user, latest first.
+	Requests(ctx context.Context, userID string) ([]Request, error)
+
+	// Request returns a request along with its audit trail.
+	Request(ctx context.Context, ID string) (Request, []Event, error)
+
+	// Queue lists the requests waiting for review, oldest first.
+	Queue(ctx context.Context) ([]Request, error)
+
+	// Resolve approves or rejects a pending request. An approved request
+	// is queued for the erasure.
+	Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (Request, error)
+
+	// ProcessRequests erases the users of the approved requests.
+	ProcessRequests(ctx context.Context) error
+}
+
+type basicService struct {
+	r     Repo
+	users user.Repo
+	steps []Step
+}
+
+// NewService return basic Service implementation, erasing the users from
+// repos.
+func NewService(r Repo, repos Repos) Service {
+	return basicService{r: r, users: repos.Users, steps: steps(repos)}
+}
+
+// Submit fails with ErrAlreadyRequested while another request of the user
+// is pending or approved.
+func (s basicService) Submit(ctx context.Context, userID string, n NewRequest) (Request, error) {
+	n.clean()
+	if err := n.Validate(); err != nil {
+		return Request{}, err
+	}
+	u, err := s.users.GetByID(userID)
+	if err != nil {
+		return Request{}, user.ErrUserNotFound
+	}
+	if u.DeletedAt != nil {
+		return Request{}, user.ErrDeactivated
+	}
+	requests, err := s.r.ListByUser(userID)
+	if err != nil {
+		return Request{}, err
+	}
+	for _, r := range requests {
+		if r.Status == StatusPending || r.Status == StatusApproved {
+			return Request{}, ErrAlreadyRequested
+		}
+	}
+
+	r := Request{
+		UserID:    userID,
+		Reason:    n.Reason,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.Create(&r); err != nil {
+		return Request{}, err
+	}
+	if err := s.event(r, ActionSubmit, userID, "", r.CreatedAt); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// Requests lists the requests of a user, latest first.
+func (s basicService) Requests(ctx context.Context, userID string) ([]Request, error) {
+	return s.r.ListByUser(userID)
+}
+
+// Request returns a request along with its audit trail.
+func (s basicService) Request(ctx context.Context, ID string) (Request, []Event, error) {
+	r, err := s.get(ID)
+	if err != nil {
+		return Request{}, nil, err
+	}
+	events, err := s.r.ListEvents(ID)
+	if err != nil {
+		return Request{}, nil, err
+	}
+	return r, events, nil
+}
+
+// Queue lists the requests waiting for review, oldest first.
+func (s basicService) Queue(ctx context.Context) ([]Request, error) {
+	return s.r.ListByStatus(StatusPending)
+}
+
+// Resolve leaves the erasure to ProcessRequests, so that a slow or failing
+// step doesn't fail the review.
+func (s basicService) Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (Request, error) {
+	if !approve && note == "" {
+		return Request{}, ErrNoteRequired
+	}
+	r, err := s.get(ID)
+	if err != nil {
+		return Request{}, err
+	}
+	if r.Status != StatusPending {
+		return Request{}, ErrAlreadyResolved
+	}
+	if reviewer == r.UserID {
+		return Request{}, ErrSelfReview
+	}
+
+	action := ActionReject
+	r.Status = StatusRejected
+	if approve {
+		action = ActionApprove
+		r.Status = StatusApproved
+	}
+	now := time.Now().UTC()
+	r.ReviewedBy, r.Note, r.ResolvedAt = reviewer, note, &now
+	if err := s.r.Save(&r); err != nil {
+		return Request{}, err
+	}
+	if err := s.event(r, action, reviewer, note, now); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// ProcessRequests runs the steps of the approved requests oldest first. A
+// request failing at a step is left approved and run again from the first
+// step next time, the error of the step recorded in its trail.
+func (s basicService) ProcessRequests(ctx context.Context) error {
+	requests, err := s.r.ListByStatus(StatusApproved)
+	if err != nil {
+		return err
+	}
+	var failed error
+	for _, r := range requests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.erase(ctx, r); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+func (s basicService) erase(ctx context.Context, r Request) error {
+	r.Attempts++
+	if err := s.r.Save(&r); err != nil {
+		return err
+	}
+	u, err := s.users.GetByID(r.UserID)
+	if err != nil {
+		return err
+	}
+	subject := Subject{UserID: u.ID, Email: u.Email}
+	for _, step := range s.steps {
+		if err := step.Erase(ctx, subject); err != nil {
+			s.event(r, ActionFail, actor, step.Name+": "+err.Error(), time.Now().UTC())
+			return err
+		}
+		if err := s.event(r, ActionErase, actor, step.Name, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	r.Status, r.CompletedAt = StatusCompleted, &now
+	if err := s.r.Save(&r); err != nil {
+		return err
+	}
+	return s.event(r, ActionComplete, actor, "", now)
+}
+
+func (s basicService) get(ID string) (Request, error) {
+	r, err := s.r.GetByID(ID)
+	if err == db.ErrNotFound {
+		return Request{}, ErrRequestNotFound
+	}
+	return r, err
+}
+
+func (s basicService) event(r Request, action, actor, note string, at time.Time) error {
+	return s.r.AddEvent(&Event{RequestID: r.ID, Action: action, Actor: actor, Note: note, At: at})
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,147 @@
+package erasure
+
+import (
+	"context"
+	"time"
+
+	"github.com/kavirajk/bookshop/browsing"
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/restock"
+	"github.com/kavirajk/bookshop/support"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Subject is the user being erased, as stored before the erasure.
+type Subject struct {
+	UserID string
+	Email  string
+}
+
+// Step erases the personal data of a subject from a service. The steps are
+// all run again when one fails, a step must be a no-op once done.
+type Step struct {
+	Name  string
+	Erase func(ctx context.Context, s Subject) error
+}
+
+// Repos are the stores of the services holding personal data. The audit
+// log, the fraud checks and the archived invoices are kept, the shop
+// having to keep them, as are the copies of the tickets in the support
+// desk.
+type Repos struct {
+	Users          user.Repo
+	Orders         order.Repo
+	Support        support.Repo
+	Browsing       browsing.Repo
+	Consents       consent.Repo
+	Rectifications rectification.Repo
+	Restock        restock.Repo
+}
+
+// steps returns the steps erasing a subject from repos, the account last
+// so that the steps before still find the email of the subject on a retry.
+func steps(repos Repos) []Step {
+	return []Step{
+		{Name: "orders", Erase: func(_ context.Context, s Subject) error {
+			// The orders are kept for the bookkeeping, referencing the
+			// anonymized user, only the delivery instructions are dropped.
+			orders, err := repos.Orders.ListByUser(s.UserID)
+			if err != nil {
+				return err
+			}
+			for _, o := range orders {
+				if o.Note == "" {
+					continue
+				}
+				o.Note = ""
+				if err := repos.Orders.Save(&o); err != nil {
+					return err
+				}
+			}
+			lines, err := repos.Orders.ListCartLines(s.UserID)
+			if err != nil {
+				return err
+			}
+			for _, l := range lines {
+				if err := repos.Orders.DeleteCartLine(s.UserID, l.BookID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{Name: "support", Erase: func(_ context.Context, s Subject) error {
+			tickets, err := repos.Support.ListByUser(s.UserID)
+			if err != nil {
+				return err
+			}
+			for _, t := range tickets {
+				if t.Subject == "" && t.Description == "" && t.OpenedBy != s.Email {
+					continue
+				}
+				t.Subject, t.Description = "", ""
+				if t.OpenedBy == s.Email {
+					t.OpenedBy = ""
+				}
+				if err := repos.Support.Save(&t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{Name: "browsing", Erase: func(_ context.Context, s Subject) error {
+			views, err := repos.Browsing.UserViews(s.UserID)
+			if err != nil || len(views) == 0 {
+				return err
+			}
+			ids := make([]string, len(views))
+			for i, v := range views {
+				ids[i] = v.ID
+			}
+			return repos.Browsing.DeleteViews(ids)
+		}},
+		{Name: "consents", Erase: func(_ context.Context, s Subject) error {
+			return repos.Consents.EraseRecords(s.UserID)
+		}},
+		{Name: "rectifications", Erase: func(_ context.Context, s Subject) error {
+			return repos.Rectifications.Erase(s.UserID)
+		}},
+		{Name: "restock", Erase: func(_ context.Context, s Subject) error {
+			if s.Email == "" {
+				return nil
+			}
+			return repos.Restock.DeleteSubscriptions(s.Email)
+		}},
+		{Name: "account", Erase: func(_ context.Context, s Subject) error {
+			if err := repos.Users.Forget(s.UserID); err != nil {
+				return err
+			}
+			u, err := repos.Users.GetByID(s.UserID)
+			if err != nil {
+				return err
+			}
+			anonymize(&u, time.Now().UTC())
+			return repos.Users.Save(&u)
+		}},
+	}
+}
+
+// anonymize blanks the personal data and the credentials of u, keeping its
+// ID and role, and deletes the account if it isn't yet. The email and the
+// username are replaced by ones of the ID, so that they stay unique.
+func anonymize(u *user.User, now time.Time) {
+	u.FirstName, u.LastName = "", ""
+	u.Email = "erased+" + u.ID + "@erased.invalid"
+	u.Username = "erased-" + u.ID
+	u.Password, u.Salt, u.AuthToken = "", "", ""
+	u.TwoFactorEnabled, u.TOTPSecret, u.TOTPStep = false, "", 0
+	u.Avatar, u.Bio = "", ""
+	u.Timezone, u.Locale = "", ""
+	u.SegmentString, u.PreferenceString = "", ""
+	u.LockedUntil = nil
+	u.Deactivated = true
+	if u.DeletedAt == nil {
+		u.DeletedAt = &now
+	}
+}
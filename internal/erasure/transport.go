@@ -0,0 +1,212 @@
+package erasure
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrRequestNotFound:  "erasure.request_not_found",
+		ErrAlreadyRequested: "erasure.already_requested",
+		ErrAlreadyResolved:  "erasure.already_resolved",
+		ErrSelfReview:       "erasure.self_review",
+		ErrNoteRequired:     "erasure.note_required",
+	})
+}
+
+// MakeHTTPHandler serves the erasure of the user of the request at
+// /users/v1/me to the requests account lets through, e.g. auth.NewMiddleware
+// chained with rbac.RequireUnscoped, and its review to the ones of admin,
+// e.g. with rbac.RequireRole. Either checks the CSRF token of the cookie
+// sessions if chained with csrf.NewMiddleware.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, audited audit.AuditLogger, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin, audited)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	submitHandler := httptransport.NewServer(
+		e.SubmitEndpoint,
+		decodeSubmitRequest,
+		encodeResponse,
+		options...,
+	)
+	requestsHandler := httptransport.NewServer(
+		e.RequestsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	requestHandler := httptransport.NewServer(
+		e.RequestEndpoint,
+		decodeRequestRequest,
+		encodeResponse,
+		options...,
+	)
+	queueHandler := httptransport.NewServer(
+		e.QueueEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	resolveHandler := httptransport.NewServer(
+		e.ResolveEndpoint,
+		decodeResolveRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/users/v1/me", submitHandler).Methods("DELETE")
+	r.Handle("/users/v1/me/erasure", requestsHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/erasures/v1/admin/queue", queueHandler).Methods("GET")
+	r.Handle("/erasures/v1/admin/{request-id}", requestHandler).Methods("GET")
+	r.Handle("/erasures/v1/admin/{request-id}/resolve", resolveHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// decodeSubmitRequest reads the optional reason of the erasure, a DELETE
+// having no body usually.
+func decodeSubmitRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var n NewRequest
+	if len(bytes.TrimSpace(b)) == 0 {
+		return n, nil
+	}
+	if err := schema.Check(b, reflect.TypeOf(n), true); err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &n)
+	return n, err
+}
+
+func decodeRequestRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["request-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "request-id")
+	}
+	return requestRequest{ID: ID}, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeResolveRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r resolveRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	ID, ok := mux.Vars(req)["request-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "request-id")
+	}
+	r.ID = ID
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 202 for an accepted request.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken, ErrSelfReview:
+		return http.StatusForbidden
+	case ErrRequestNotFound, user.ErrUserNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyRequested, ErrAlreadyResolved, db.ErrConflict:
+		return http.StatusConflict
+	case user.ErrDeactivated:
+		return http.StatusGone
+	case ErrBadRouting, ErrNoteRequired, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
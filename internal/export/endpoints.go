@@ -0,0 +1,125 @@
+package export
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the export service endpoints under single type.
+type Endpoints struct {
+	JobEndpoint      endpoint.Endpoint
+	DownloadEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the export service endpoints.
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		JobEndpoint:      MakeJobEndpoint(s),
+		DownloadEndpoint: MakeDownloadEndpoint(s),
+	}
+}
+
+func MakeJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobRequest)
+		j, e := s.Job(ctx, req.JobID)
+		if e != nil {
+			return jobResponse{Job: nil, Error: e}, nil
+		}
+		return jobResponse{Job: &j}, nil
+	}
+}
+
+func MakeDownloadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(downloadRequest)
+		j, e := s.Download(ctx, req.JobID, req.Token)
+		if e != nil {
+			return downloadResponse{Error: e}, nil
+		}
+		return downloadResponse{Name: j.FileName(), Data: j.Data}, nil
+	}
+}
+
+// MakeExportEndpoint exports src, queueing the async exports for the user
+// of the request.
+func MakeExportEndpoint(s Service, src Source, requester Requester) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(exportRequest)
+		if req.Async {
+			userID, ok := auth.UserID(ctx)
+			if !ok {
+				return nil, auth.ErrMissingToken
+			}
+			email, err := requester(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			j, e := s.Start(ctx, src.Name, req.Query, email)
+			if e != nil {
+				return exportResponse{Error: e}, nil
+			}
+			return exportResponse{Job: &j}, nil
+		}
+		cols, e := src.Columns.Select(req.Query.Get("columns"))
+		if e != nil {
+			return exportResponse{Error: e}, nil
+		}
+		rows, e := src.Rows(ctx, req.Query)
+		if e != nil {
+			return exportResponse{Error: e}, nil
+		}
+		return exportResponse{Columns: cols, Rows: rows}, nil
+	}
+}
+
+type exportRequest struct {
+	Query url.Values
+	Async bool
+}
+
+// exportResponse is the queued job of an async export, or the rows of the
+// export streamed by Handler.
+type exportResponse struct {
+	Job     *Job
+	Columns Columns
+	Rows    Rows
+	Error   error
+}
+
+type jobRequest struct {
+	JobID string
+}
+
+type jobResponse struct {
+	Status int   `json:"-"`
+	Job    *Job  `json:"job,omitempty"`
+	Error  error `json:"error,omitempty"`
+}
+
+func (r jobResponse) status() int {
+	return r.Status
+}
+
+func (r jobResponse) error() error {
+	return r.Error
+}
+
+type downloadRequest struct {
+	JobID string
+	Token string
+}
+
+type downloadResponse struct {
+	Name  string
+	Data  []byte
+	Error error
+}
+
+func (r downloadResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+var (
+	ErrUnknownColumn = errors.New("unknown export column")
+	ErrUnknownSource = errors.New("unknown export source")
+)
+
+// ContentType is the media type of the exports.
+const ContentType = "text/csv"
+
+// pageSize is the rows read from a source at once.
+const pageSize = 500
+
+// Column is a column of an export, Value formats it for a row of the
+// source.
+type Column struct {
+	Name  string
+	Value func(row interface{}) string
+}
+
+// Columns lists the columns of a source, in export order.
+type Columns []Column
+
+// Select returns the columns named in the comma separated list names, in
+// that order, or every column if names is empty.
+func (c Columns) Select(names string) (Columns, error) {
+	if strings.TrimSpace(names) == "" {
+		return c, nil
+	}
+	byName := make(map[string]Column, len(c))
+	for _, col := range c {
+		byName[col.Name] = col
+	}
+	var selected Columns
+	for _, name := range strings.Split(names, ",") {
+		col, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, ErrUnknownColumn
+		}
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+// Rows returns the page of rows at offset, an empty page ends the export.
+type Rows func(limit, offset int) ([]interface{}, error)
+
+// Source is an admin list which can be exported, as rows of Columns.
+type Source struct {
+	// Name names the export files and jobs of the source, e.g. "users".
+	Name string
+	// Path is the list route, answered with a CSV export if the request
+	// accepts text/csv.
+	Path    string
+	Columns Columns
+	// Rows reads the rows matching the query of the list request, e.g.
+	// its filter and order.
+	Rows func(ctx context.Context, query url.Values) (Rows, error)
+	// Access lets through the exports the list route would answer, e.g.
+	// auth.NewMiddleware chained with the rbac.RequireRole of the route.
+	Access endpoint.Middleware
+}
+
+// Accepts tells whether req asks for a CSV export.
+func Accepts(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		if t, _, err := mime.ParseMediaType(accept); err == nil && t == ContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the header and the rows of an export to w. The rows are
+// read and flushed a page at a time, a w implementing http.Flusher is
+// flushed along.
+func Write(ctx context.Context, w io.Writer, cols Columns, rows Rows) (n int, err error) {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Name
+	}
+	cw.Write(header)
+	record := make([]string, len(cols))
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		page, err := rows(pageSize, offset)
+		if err != nil {
+			return n, err
+		}
+		for _, row := range page {
+			for i, col := range cols {
+				record[i] = escape(col.Value(row))
+			}
+			cw.Write(record)
+		}
+		n += len(page)
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return n, err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if len(page) < pageSize {
+			return n, nil
+		}
+	}
+}
+
+// escape keeps a spreadsheet from running a value as a formula, quoting
+// the values other than numbers starting like one. The CSV quoting is left
+// to encoding/csv.
+func escape(v string) string {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// Job statuses.
+const (
+	JobQueued = "queued"
+	JobDone   = "done"
+	JobFailed = "failed"
+)
+
+// Job is an export run in the background, for lists too large to export
+// within a request. The requester gets a download link by email once it is
+// done.
+type Job struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	// Query is the query string of the list request.
+	Query string `json:"query,omitempty" sql:"type:text"`
+	Email string `json:"email"`
+	// Token authorizes the download, it is only sent by email.
+	Token     string     `json:"-"`
+	Status    string     `json:"status"`
+	Rows      int        `json:"rows"`
+	Error     string     `json:"error,omitempty"`
+	Data      []byte     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (Job) TableName() string {
+	return "export_jobs"
+}
+
+// FileName is the name of the file of the export.
+func (j Job) FileName() string {
+	return j.Source + "-" + j.CreatedAt.UTC().Format("20060102-150405") + ".csv"
+}
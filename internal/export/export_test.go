@@ -0,0 +1,157 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var columns = export.Columns{
+	{Name: "n", Value: func(r interface{}) string { return strconv.Itoa(r.(int)) }},
+	{Name: "text", Value: func(r interface{}) string {
+		if r.(int)%2 == 0 {
+			return "=SUM(A1:A2)"
+		}
+		return `say "hi", -5`
+	}},
+}
+
+// count returns the rows 0 to n-1.
+func count(n int) export.Rows {
+	return func(limit, offset int) ([]interface{}, error) {
+		var rows []interface{}
+		for i := offset; i < n && i < offset+limit; i++ {
+			rows = append(rows, i)
+		}
+		return rows, nil
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	n, err := export.Write(context.Background(), &b, columns, count(1201))
+	if err != nil {
+		t.Fatalf("write: unexpected error %v", err)
+	}
+	if n != 1201 {
+		t.Errorf("write: expected 1201 rows, got %d", n)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 1202 {
+		t.Fatalf("write: expected the header and 1201 lines, got %d", len(lines))
+	}
+	if lines[0] != "n,text" {
+		t.Errorf("header: expected n,text, got %s", lines[0])
+	}
+	if lines[1] != "0,'=SUM(A1:A2)" {
+		t.Errorf("formula: expected a quoted formula, got %s", lines[1])
+	}
+	if lines[2] != `1,"say ""hi"", -5"` {
+		t.Errorf("quoting: expected the value quoted, got %s", lines[2])
+	}
+}
+
+func TestSelect(t *testing.T) {
+	cols, err := columns.Select("text, n")
+	if err != nil || len(cols) != 2 || cols[0].Name != "text" || cols[1].Name != "n" {
+		t.Errorf("select: expected text and n, got %v %v", cols, err)
+	}
+	if cols, _ := columns.Select(""); len(cols) != len(columns) {
+		t.Errorf("select: expected every column, got %d", len(cols))
+	}
+	if _, err := columns.Select("n,password"); err != export.ErrUnknownColumn {
+		t.Errorf("select: expected ErrUnknownColumn, got %v", err)
+	}
+}
+
+// starter queues the async exports, recording the email of the last one.
+type starter struct {
+	export.Service
+	email string
+}
+
+func (s *starter) Start(ctx context.Context, source string, query url.Values, email string) (export.Job, error) {
+	s.email = email
+	return export.Job{ID: "j1", Source: source, Email: email, Status: export.JobQueued}, nil
+}
+
+func TestHandler(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	src := export.Source{
+		Name:    "numbers",
+		Path:    "/numbers/v1/list",
+		Columns: columns,
+		Rows: func(ctx context.Context, query url.Values) (export.Rows, error) {
+			n, _ := strconv.Atoi(query.Get("n"))
+			return count(n), nil
+		},
+		Access: endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin)),
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("json"))
+	})
+	s := &starter{}
+	emails := func(ctx context.Context, userID string) (string, error) {
+		return userID + "@example.com", nil
+	}
+	h := export.Handler(s, []export.Source{src}, emails, log.NewNopLogger(), next)
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	get := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/numbers/v1/list?n=3", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: expected 401, got %d: %s", w.Code, w.Body)
+	}
+	if w := get("/numbers/v1/list?n=3", customer); w.Code != http.StatusForbidden {
+		t.Errorf("customer: expected 403, got %d: %s", w.Code, w.Body)
+	}
+
+	w := get("/numbers/v1/list?n=3&columns=n", staff)
+	if body := w.Body.String(); body != "n\n0\n1\n2\n" {
+		t.Errorf("export: expected the n column of 3 rows, got %q", body)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="numbers.csv"` {
+		t.Errorf("export: expected an attachment, got %q", cd)
+	}
+
+	if w := get("/numbers/v1/list?columns=x", staff); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown column: expected 400, got %d", w.Code)
+	}
+
+	// The link goes to the admin, whatever the query says.
+	w = get("/numbers/v1/list?async=1&email=attacker@example.com", staff)
+	if w.Code != http.StatusOK || w.Header().Get("Location") != "/exports/v1/j1" || s.email != "u9@example.com" {
+		t.Errorf("async: expected a job for u9@example.com, got %d, %q: %s", w.Code, s.email, w.Body)
+	}
+	if w := get("/numbers/v1/list?async=1", customer); w.Code != http.StatusForbidden || s.email != "u9@example.com" {
+		t.Errorf("async customer: expected 403 and no job, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/numbers/v1/list", nil))
+	if body := w.Body.String(); body != "json" {
+		t.Errorf("json: expected the list handler, got %q", body)
+	}
+}
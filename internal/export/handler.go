@@ -0,0 +1,85 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+// Requester returns the email of the user of an export request, the
+// download link of an async export is emailed to it.
+type Requester func(ctx context.Context, userID string) (string, error)
+
+// Handler answers the GET requests to the list routes of sources which
+// accept text/csv with their export, and hands the others to next. An
+// export goes through the Access of its source, as the list would.
+//
+// The export is streamed within the request, unless it asks for an async
+// export with "async=true" or "Prefer: respond-async". An async export is
+// queued with Start and answered 202 Accepted, its download link is then
+// emailed to the address requester returns for the user of the token.
+func Handler(s Service, sources []Source, requester Requester, logger log.Logger, next http.Handler) http.Handler {
+	byPath := make(map[string]Source, len(sources))
+	exports := make(map[string]endpoint.Endpoint, len(sources))
+	for _, src := range sources {
+		byPath[src.Path] = src
+		exports[src.Path] = src.Access(MakeExportEndpoint(s, src, requester))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path := strings.TrimSuffix(req.URL.Path, "/")
+		src, ok := byPath[path]
+		if !ok || req.Method != "GET" || !Accepts(req) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		ctx := i18n.PopulateLocale(req.Context(), req)
+		ctx = transport.PopulateFieldCase(ctx, req)
+		ctx = auth.PopulateToken(ctx, req)
+		ctx = csrf.Populate(ctx, req)
+		query := req.URL.Query()
+		query.Del("async")
+
+		response, err := exports[path](ctx, exportRequest{Query: query, Async: async(req)})
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+		res := response.(exportResponse)
+		if res.Error != nil {
+			encodeError(ctx, res.Error, w)
+			return
+		}
+		if res.Job != nil {
+			w.Header().Set("Location", "/exports/v1/"+res.Job.ID)
+			encodeResponse(ctx, w, jobResponse{Job: res.Job, Status: http.StatusAccepted})
+			return
+		}
+		w.Header().Set("Content-Type", ContentType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+src.Name+`.csv"`)
+		if _, err := Write(ctx, w, res.Columns, res.Rows); err != nil {
+			// The rows written so far are sent already, the export is cut
+			// short.
+			logger.Log("export", src.Name, "err", err)
+		}
+	})
+}
+
+func async(req *http.Request) bool {
+	if ok, _ := strconv.ParseBool(req.URL.Query().Get("async")); ok {
+		return true
+	}
+	for _, pref := range strings.Split(req.Header.Get("Prefer"), ",") {
+		if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+			return true
+		}
+	}
+	return false
+}
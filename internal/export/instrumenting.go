@@ -0,0 +1,71 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Start(ctx context.Context, source string, query url.Values, email string) (job Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	job, err = mw.next.Start(ctx, source, query, email)
+	return
+}
+
+func (mw instrmw) Job(ctx context.Context, id string) (job Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "job", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	job, err = mw.next.Job(ctx, id)
+	return
+}
+
+func (mw instrmw) Download(ctx context.Context, id, token string) (job Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "download", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	job, err = mw.next.Download(ctx, id, token)
+	return
+}
+
+func (mw instrmw) ProcessJobs(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "process_jobs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ProcessJobs(ctx)
+	return
+}
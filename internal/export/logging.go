@@ -0,0 +1,68 @@
+package export
+
+import (
+	"net/url"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Start(ctx context.Context, source string, query url.Values, email string) (job Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Start(ctx, source, query, email)
+}
+
+func (s loggingService) Job(ctx context.Context, id string) (job Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "job",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Job(ctx, id)
+}
+
+func (s loggingService) Download(ctx context.Context, id, token string) (job Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "download",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Download(ctx, id, token)
+}
+
+func (s loggingService) ProcessJobs(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "process_jobs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ProcessJobs(ctx)
+}
@@ -0,0 +1,23 @@
+package export
+
+import "github.com/kavirajk/bookshop/notification/email"
+
+// Notifier tells the requester of an export job it is done.
+type Notifier interface {
+	// Ready sends the download link of the done job j.
+	Ready(j Job, link string) error
+}
+
+type emailNotifier struct{}
+
+// NewEmailNotifier returns a Notifier sending emails.
+func NewEmailNotifier() Notifier {
+	return emailNotifier{}
+}
+
+func (emailNotifier) Ready(j Job, link string) error {
+	return email.ExportReady([]string{j.Email}, map[string]interface{}{
+		"job":  j,
+		"link": link,
+	})
+}
@@ -0,0 +1,15 @@
+package export
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Export Service
+type Repo interface {
+	CreateJob(j *Job) error
+	GetJob(id string) (Job, error)
+	SaveJob(j *Job) error
+	// ListJobsByStatus returns the jobs in any of status, oldest first.
+	ListJobsByStatus(status ...string) ([]Job, error)
+	// DeleteExpired deletes the jobs whose download expired before t.
+	DeleteExpired(t time.Time) error
+	Drop() error
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrJobNotFound = errors.New("export job not found")
+	ErrNotReady    = errors.New("export is not ready")
+	ErrExpired     = errors.New("export download has expired")
+	ErrBadToken    = errors.New("bad export download token")
+)
+
+// Retention is how long a done export can be downloaded.
+const Retention = 7 * 24 * time.Hour
+
+type Service interface {
+	// Start queues the export of the list of source matching query, its
+	// download link is emailed to email once done.
+	Start(ctx context.Context, source string, query url.Values, email string) (Job, error)
+
+	// Job returns an export job.
+	Job(ctx context.Context, id string) (Job, error)
+
+	// Download returns a done export job along with its data, if token is
+	// its download token.
+	Download(ctx context.Context, id, token string) (Job, error)
+
+	// ProcessJobs runs the queued export jobs and drops the expired ones.
+	ProcessJobs(ctx context.Context) error
+}
+
+type basicService struct {
+	r        Repo
+	sources  map[string]Source
+	notifier Notifier
+	storeURL string
+}
+
+// NewService return basic Service implementation. The download links point
+// to storeURL.
+func NewService(r Repo, sources []Source, n Notifier, storeURL string) Service {
+	byName := make(map[string]Source, len(sources))
+	for _, src := range sources {
+		byName[src.Name] = src
+	}
+	return basicService{r: r, sources: byName, notifier: n, storeURL: storeURL}
+}
+
+// Start checks the query against the source before queueing the job, so
+// that a bad filter fails the request rather than the job.
+func (s basicService) Start(ctx context.Context, source string, query url.Values, email string) (Job, error) {
+	src, ok := s.sources[source]
+	if !ok {
+		return Job{}, ErrUnknownSource
+	}
+	var v validate.Validator
+	v.Email("email", email)
+	if err := v.Err(); err != nil {
+		return Job{}, err
+	}
+	if _, err := src.Columns.Select(query.Get("columns")); err != nil {
+		return Job{}, err
+	}
+	if _, err := src.Rows(ctx, query); err != nil {
+		return Job{}, err
+	}
+	j := Job{
+		Source:    source,
+		Query:     query.Encode(),
+		Email:     email,
+		Status:    JobQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.CreateJob(&j); err != nil {
+		return Job{}, err
+	}
+	return j, nil
+}
+
+// Job returns an export job.
+func (s basicService) Job(ctx context.Context, id string) (Job, error) {
+	j, err := s.r.GetJob(id)
+	if err == db.ErrNotFound {
+		return Job{}, ErrJobNotFound
+	}
+	return j, err
+}
+
+// Download returns a done, unexpired export if token matches.
+func (s basicService) Download(ctx context.Context, id, token string) (Job, error) {
+	j, err := s.Job(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+	if j.Token == "" || subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(j.Token)) != 1 {
+		return Job{}, ErrBadToken
+	}
+	if j.Status != JobDone {
+		return Job{}, ErrNotReady
+	}
+	if j.ExpiresAt != nil && time.Now().After(*j.ExpiresAt) {
+		return Job{}, ErrExpired
+	}
+	return j, nil
+}
+
+// ProcessJobs runs the queued jobs oldest first.
+func (s basicService) ProcessJobs(ctx context.Context) error {
+	if err := s.r.DeleteExpired(time.Now().UTC()); err != nil {
+		return err
+	}
+	jobs, err := s.r.ListJobsByStatus(JobQueued)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if err := s.process(ctx, &j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process writes the export of j and emails its download link. An export
+// failing on the source fails the job, other errors leave it queued.
+func (s basicService) process(ctx context.Context, j *Job) error {
+	query, err := url.ParseQuery(j.Query)
+	if err != nil {
+		return s.fail(j, err)
+	}
+	src, ok := s.sources[j.Source]
+	if !ok {
+		return s.fail(j, ErrUnknownSource)
+	}
+	cols, err := src.Columns.Select(query.Get("columns"))
+	if err != nil {
+		return s.fail(j, err)
+	}
+	rows, err := src.Rows(ctx, query)
+	if err != nil {
+		return s.fail(j, err)
+	}
+	var b bytes.Buffer
+	n, err := Write(ctx, &b, cols, rows)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return s.fail(j, err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	expires := now.Add(Retention)
+	j.Data, j.Rows, j.Token = b.Bytes(), n, hashToken(token)
+	j.Status, j.DoneAt, j.ExpiresAt = JobDone, &now, &expires
+	if err := s.r.SaveJob(j); err != nil {
+		return err
+	}
+	link := s.storeURL + "/exports/v1/" + j.ID + "/download?" + url.Values{"token": {token}}.Encode()
+	return s.notifier.Ready(*j, link)
+}
+
+func (s basicService) fail(j *Job, err error) error {
+	now := time.Now().UTC()
+	j.Status, j.Error, j.DoneAt = JobFailed, err.Error(), &now
+	return s.r.SaveJob(j)
+}
+
+// newToken generates a random download token, only its hash is stored.
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+type Middleware func(Service) Service
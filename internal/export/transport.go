@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	jobHandler := httptransport.NewServer(
+		e.JobEndpoint,
+		decodeJobRequest,
+		encodeResponse,
+		options...,
+	)
+	downloadHandler := httptransport.NewServer(
+		e.DownloadEndpoint,
+		decodeDownloadRequest,
+		encodeDownload,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/exports/v1/{job-id}", jobHandler).Methods("GET")
+	r.Handle("/exports/v1/{job-id}/download", downloadHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeJobRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	jobID, ok := mux.Vars(req)["job-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "job-id")
+	}
+	return jobRequest{JobID: jobID}, nil
+}
+
+func decodeDownloadRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	jobID, ok := mux.Vars(req)["job-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "job-id")
+	}
+	return downloadRequest{JobID: jobID, Token: req.FormValue("token")}, nil
+}
+
+// encodeDownload sends the done export as a CSV attachment.
+func encodeDownload(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(downloadResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", ContentType+"; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrJobNotFound:
+		return http.StatusNotFound
+	case ErrBadToken:
+		return http.StatusForbidden
+	case ErrNotReady:
+		return http.StatusConflict
+	case ErrExpired:
+		return http.StatusGone
+	case ErrBadRouting, ErrUnknownColumn, ErrUnknownSource, validate.ErrInvalid, filter.ErrInvalid,
+		db.ErrBadCount:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
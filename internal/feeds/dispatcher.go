@@ -0,0 +1,25 @@
+package feeds
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunDispatcher pushes the pending changes to the partner webhooks every
+// interval until ctx is done.
+func RunDispatcher(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Deliver(ctx); err != nil {
+				logger.Log("dispatcher", "feeds", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package feeds
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the feeds service endpoints under single type.
+type Endpoints struct {
+	ChangesEndpoint     endpoint.Endpoint
+	SubscribeEndpoint   endpoint.Endpoint
+	WebhooksEndpoint    endpoint.Endpoint
+	UnsubscribeEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the feeds service endpoints. The webhooks of the partners are
+// restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ChangesEndpoint:     MakeChangesEndpoint(s),
+		SubscribeEndpoint:   admin(MakeSubscribeEndpoint(s)),
+		WebhooksEndpoint:    admin(MakeWebhooksEndpoint(s)),
+		UnsubscribeEndpoint: admin(MakeUnsubscribeEndpoint(s)),
+	}
+}
+
+func MakeChangesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changesRequest)
+		p, e := s.Changes(ctx, req.Since, req.Limit)
+		if e != nil {
+			return changesResponse{Page: Page{Changes: make([]Record, 0)}, Error: e}, nil
+		}
+		return changesResponse{Page: p}, nil
+	}
+}
+
+func MakeSubscribeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(subscribeRequest)
+		w, e := s.Subscribe(ctx, Webhook{
+			Partner: req.Partner,
+			URL:     req.URL,
+			Secret:  req.Secret,
+		}, req.Cursor)
+		if e != nil {
+			return webhookResponse{Webhook: nil, Error: e}, nil
+		}
+		return webhookResponse{Webhook: &w, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		hooks, e := s.Webhooks(ctx)
+		if e != nil {
+			return webhooksResponse{Webhooks: make([]Webhook, 0), Error: e}, nil
+		}
+		return webhooksResponse{Webhooks: hooks}, nil
+	}
+}
+
+func MakeUnsubscribeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(unsubscribeRequest)
+		e := s.Unsubscribe(ctx, req.WebhookID)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+type changesRequest struct {
+	Since string
+	Limit int
+}
+
+type changesResponse struct {
+	Page
+	Error error `json:"error,omitempty"`
+}
+
+func (r changesResponse) error() error {
+	return r.Error
+}
+
+type subscribeRequest struct {
+	Partner string `json:"partner"`
+	URL     string `json:"url"`
+	Secret  string `json:"secret"`
+	Cursor  string `json:"cursor"`
+}
+
+type webhookResponse struct {
+	Status  int      `json:"-"`
+	Webhook *Webhook `json:"webhook,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r webhookResponse) status() int {
+	return r.Status
+}
+
+func (r webhookResponse) error() error {
+	return r.Error
+}
+
+type webhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r webhooksResponse) error() error {
+	return r.Error
+}
+
+type unsubscribeRequest struct {
+	WebhookID string
+}
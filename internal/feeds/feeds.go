@@ -0,0 +1,129 @@
+package feeds
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+var (
+	ErrBadCursor       = errors.New("bad cursor")
+	ErrInvalidWebhook  = errors.New("webhook url must be an absolute http or https url")
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+const (
+	// DefaultPageSize is the number of changes returned or pushed at once.
+	DefaultPageSize = 100
+
+	// MaxPageSize is the largest page a partner can ask for.
+	MaxPageSize = 1000
+
+	// MaxFailures is the number of failed deliveries in a row after which
+	// a webhook is disabled.
+	MaxFailures = 10
+)
+
+// Record is a book change as seen by partners. Cursor points right after
+// the change, resuming from it returns the changes that followed.
+type Record struct {
+	Cursor    string       `json:"cursor"`
+	Type      string       `json:"type"`
+	BookID    string       `json:"book_id"`
+	Book      catalog.Book `json:"book"`
+	ChangedAt time.Time    `json:"changed_at"`
+}
+
+// Page is a batch of changes, in order. NextCursor is passed as since to
+// get the following page, or to poll for new changes when HasMore is false.
+type Page struct {
+	Changes    []Record `json:"changes"`
+	NextCursor string   `json:"next_cursor"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// Webhook is a partner endpoint the changes are pushed to. Payloads are
+// pages signed with the hex encoded HMAC-SHA256 of the body, using Secret,
+// in the X-Signature header.
+type Webhook struct {
+	ID          string     `json:"id"`
+	Partner     string     `json:"partner"`
+	URL         string     `json:"url"`
+	Secret      string     `json:"-"`
+	LastSeq     int64      `json:"-"`
+	Active      bool       `json:"active"`
+	Failures    int        `json:"failures"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (Webhook) TableName() string {
+	return "feed_webhooks"
+}
+
+// Cursor returns the cursor of the last change delivered to the webhook.
+func (w Webhook) Cursor() string {
+	return EncodeCursor(w.LastSeq)
+}
+
+// MarshalJSON adds the delivery cursor to the webhook.
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type webhook Webhook
+	return json.Marshal(struct {
+		webhook
+		Cursor string `json:"cursor"`
+	}{webhook(w), w.Cursor()})
+}
+
+const cursorPrefix = "c1:"
+
+// EncodeCursor returns the opaque cursor token of a change log position.
+func EncodeCursor(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(seq, 10)))
+}
+
+// DecodeCursor returns the change log position of a cursor token.
+func DecodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0, ErrBadCursor
+	}
+	seq, err := strconv.ParseInt(strings.TrimPrefix(string(b), cursorPrefix), 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrBadCursor
+	}
+	return seq, nil
+}
+
+// parseSince reads since, which is either empty for the whole change log,
+// a RFC 3339 time for a first sync, or a cursor.
+func parseSince(since string) (int64, time.Time, error) {
+	if since == "" {
+		return 0, time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return 0, t, nil
+	}
+	seq, err := DecodeCursor(since)
+	return seq, time.Time{}, err
+}
+
+func newRecord(c catalog.Change) (Record, error) {
+	b, err := c.Book()
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{
+		Cursor:    EncodeCursor(c.Seq),
+		Type:      c.Type,
+		BookID:    c.BookID,
+		Book:      b,
+		ChangedAt: c.ChangedAt,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package feeds_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/feeds"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the webhooks in memory.
+type repo struct {
+	feeds.Repo
+	webhooks []feeds.Webhook
+}
+
+func (r *repo) CreateWebhook(w *feeds.Webhook) error {
+	w.ID = "w1"
+	r.webhooks = append(r.webhooks, *w)
+	return nil
+}
+
+func (r *repo) GetWebhook(id string) (feeds.Webhook, error) {
+	for _, w := range r.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return feeds.Webhook{}, db.ErrNotFound
+}
+
+func (r *repo) ListWebhooks() ([]feeds.Webhook, error) {
+	return r.webhooks, nil
+}
+
+func (r *repo) DeleteWebhook(id string) error {
+	r.webhooks = nil
+	return nil
+}
+
+func TestCursor(t *testing.T) {
+	for _, seq := range []int64{0, 1, 42, 1 << 40} {
+		got, err := feeds.DecodeCursor(feeds.EncodeCursor(seq))
+		if err != nil || got != seq {
+			t.Errorf("cursor of %d: got %d, %v", seq, got, err)
+		}
+	}
+	for _, c := range []string{"", "42", "!!", feeds.EncodeCursor(1) + "x"} {
+		if _, err := feeds.DecodeCursor(c); err != feeds.ErrBadCursor {
+			t.Errorf("DecodeCursor(%q): expected %v, got %v", c, feeds.ErrBadCursor, err)
+		}
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	r := &repo{}
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := feeds.MakeHTTPHandler(context.Background(), feeds.NewService(r, nil, nil), admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	webhook := `{"partner":"p1","url":"https://partner.example.com/hook"}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"subscribe without token", "POST", "/feeds/v1/admin/webhooks", webhook, "", http.StatusUnauthorized},
+		{"subscribe by customer", "POST", "/feeds/v1/admin/webhooks", webhook, customer, http.StatusForbidden},
+		{"webhooks by customer", "GET", "/feeds/v1/admin/webhooks", "", customer, http.StatusForbidden},
+		{"unsubscribe by customer", "DELETE", "/feeds/v1/admin/webhooks/w1", "", customer, http.StatusForbidden},
+		{"subscribe by admin", "POST", "/feeds/v1/admin/webhooks", webhook, staff, http.StatusOK},
+		{"webhooks by admin", "GET", "/feeds/v1/admin/webhooks", "", staff, http.StatusOK},
+		{"unsubscribe by admin", "DELETE", "/feeds/v1/admin/webhooks/w1", "", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package feeds
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Changes(ctx context.Context, since string, limit int) (page Page, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "changes", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	page, err = mw.next.Changes(ctx, since, limit)
+	return
+}
+
+func (mw instrmw) Subscribe(ctx context.Context, w Webhook, cursor string) (webhook Webhook, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "subscribe", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	webhook, err = mw.next.Subscribe(ctx, w, cursor)
+	return
+}
+
+func (mw instrmw) Webhooks(ctx context.Context) (webhooks []Webhook, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "webhooks", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	webhooks, err = mw.next.Webhooks(ctx)
+	return
+}
+
+func (mw instrmw) Unsubscribe(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "unsubscribe", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Unsubscribe(ctx, id)
+	return
+}
+
+func (mw instrmw) Deliver(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "deliver", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Deliver(ctx)
+	return
+}
@@ -0,0 +1,78 @@
+package feeds
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Changes(ctx context.Context, since string, limit int) (page Page, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "changes",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Changes(ctx, since, limit)
+}
+
+func (s loggingService) Subscribe(ctx context.Context, w Webhook, cursor string) (webhook Webhook, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "subscribe",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Subscribe(ctx, w, cursor)
+}
+
+func (s loggingService) Webhooks(ctx context.Context) (webhooks []Webhook, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "webhooks",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Webhooks(ctx)
+}
+
+func (s loggingService) Unsubscribe(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "unsubscribe",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Unsubscribe(ctx, id)
+}
+
+func (s loggingService) Deliver(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "deliver",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Deliver(ctx)
+}
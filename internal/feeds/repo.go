@@ -0,0 +1,12 @@
+package feeds
+
+// Repo abstracts all the persistant storage operations of Feeds Service
+type Repo interface {
+	CreateWebhook(w *Webhook) error
+	SaveWebhook(w *Webhook) error
+	GetWebhook(id string) (Webhook, error)
+	ListWebhooks() ([]Webhook, error)
+	ListActiveWebhooks() ([]Webhook, error)
+	DeleteWebhook(id string) error
+	Drop() error
+}
@@ -0,0 +1,218 @@
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+type Service interface {
+	// Changes returns the catalog changes since a cursor or a time.
+	Changes(ctx context.Context, since string, limit int) (Page, error)
+
+	// Subscribe registers a partner webhook, changes after cursor are
+	// pushed to it. An empty cursor pushes the whole change log.
+	Subscribe(ctx context.Context, w Webhook, cursor string) (Webhook, error)
+
+	// Webhooks returns all the partner webhooks.
+	Webhooks(ctx context.Context) ([]Webhook, error)
+
+	// Unsubscribe removes a partner webhook.
+	Unsubscribe(ctx context.Context, id string) error
+
+	// Deliver pushes the pending changes to all the active webhooks.
+	Deliver(ctx context.Context) error
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+	client  *http.Client
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo, client *http.Client) Service {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return basicService{r: r, catalog: catalog, client: client}
+}
+
+// Changes returns up to limit changes since a cursor or a time.
+func (s basicService) Changes(ctx context.Context, since string, limit int) (Page, error) {
+	seq, t, err := parseSince(since)
+	if err != nil {
+		return Page{}, err
+	}
+	p, err := s.page(seq, t, limit)
+	if err != nil {
+		return Page{}, err
+	}
+	if p.NextCursor == "" {
+		p.NextCursor = since
+	}
+	return p, nil
+}
+
+func (s basicService) page(seq int64, since time.Time, limit int) (Page, error) {
+	// One more to tell if there are more pages.
+	changes, err := s.catalog.ListChanges(seq, since, limit+1)
+	if err != nil {
+		return Page{}, err
+	}
+	p := Page{Changes: make([]Record, 0, len(changes))}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		p.HasMore = true
+	}
+	for _, c := range changes {
+		r, err := newRecord(c)
+		if err != nil {
+			return Page{}, err
+		}
+		p.NextCursor = r.Cursor
+		// Partners only see the books once live, and their retirement.
+		if !r.Book.LiveAt(c.ChangedAt) && c.Type != catalog.ChangeRetired {
+			continue
+		}
+		p.Changes = append(p.Changes, r)
+	}
+	return p, nil
+}
+
+// Subscribe registers a partner webhook from cursor on.
+func (s basicService) Subscribe(ctx context.Context, w Webhook, cursor string) (Webhook, error) {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Webhook{}, ErrInvalidWebhook
+	}
+	w.LastSeq = 0
+	if cursor != "" {
+		if w.LastSeq, err = DecodeCursor(cursor); err != nil {
+			return Webhook{}, err
+		}
+	}
+	w.ID = ""
+	w.Active = true
+	w.Failures = 0
+	w.CreatedAt = time.Now().UTC()
+	if err := s.r.CreateWebhook(&w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// Webhooks returns all the partner webhooks.
+func (s basicService) Webhooks(ctx context.Context) ([]Webhook, error) {
+	return s.r.ListWebhooks()
+}
+
+// Unsubscribe removes a partner webhook.
+func (s basicService) Unsubscribe(ctx context.Context, id string) error {
+	if _, err := s.r.GetWebhook(id); err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.r.DeleteWebhook(id)
+}
+
+// Deliver pushes the pending changes to every active webhook a page at
+// a time. A webhook only moves past a page once it answers 2xx, failing
+// MaxFailures times in a row disables it.
+func (s basicService) Deliver(ctx context.Context) error {
+	hooks, err := s.r.ListActiveWebhooks()
+	if err != nil {
+		return err
+	}
+	for _, w := range hooks {
+		if err := s.deliver(ctx, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s basicService) deliver(ctx context.Context, w Webhook) error {
+	for {
+		p, err := s.page(w.LastSeq, time.Time{}, DefaultPageSize)
+		if err != nil {
+			return err
+		}
+		if p.NextCursor == "" {
+			return nil
+		}
+		if len(p.Changes) == 0 {
+			// Only changes of books not live yet, skip them.
+			w.LastSeq, _ = DecodeCursor(p.NextCursor)
+			if err := s.r.SaveWebhook(&w); err != nil {
+				return err
+			}
+			if !p.HasMore {
+				return nil
+			}
+			continue
+		}
+		if err := s.push(ctx, w, p); err != nil {
+			w.Failures++
+			w.LastError = err.Error()
+			if w.Failures >= MaxFailures {
+				w.Active = false
+			}
+			return s.r.SaveWebhook(&w)
+		}
+		// Cursors were just encoded from the change log.
+		w.LastSeq, _ = DecodeCursor(p.NextCursor)
+		now := time.Now().UTC()
+		w.Failures = 0
+		w.LastError = ""
+		w.DeliveredAt = &now
+		if err := s.r.SaveWebhook(&w); err != nil {
+			return err
+		}
+		if !p.HasMore {
+			return nil
+		}
+	}
+}
+
+func (s basicService) push(ctx context.Context, w Webhook, p Page) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", Sign(w.Secret, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,175 @@
+package feeds
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+	ErrBadLimit   = errors.New("bad limit")
+)
+
+// MakeHTTPHandler mounts the feeds endpoints, the webhooks of the partners
+// served to the requests admin lets through, e.g. auth.NewMiddleware
+// chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	changesHandler := httptransport.NewServer(
+		e.ChangesEndpoint,
+		decodeChangesRequest,
+		encodeResponse,
+		options...,
+	)
+	subscribeHandler := httptransport.NewServer(
+		e.SubscribeEndpoint,
+		decodeSubscribeRequest,
+		encodeResponse,
+		options...,
+	)
+	webhooksHandler := httptransport.NewServer(
+		e.WebhooksEndpoint,
+		decodeWebhooksRequest,
+		encodeResponse,
+		options...,
+	)
+	unsubscribeHandler := httptransport.NewServer(
+		e.UnsubscribeEndpoint,
+		decodeUnsubscribeRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/feeds/v1/catalog/changes", changesHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/feeds/v1/admin/webhooks", webhooksHandler).Methods("GET")
+	r.Handle("/feeds/v1/admin/webhooks", subscribeHandler).Methods("POST")
+	r.Handle("/feeds/v1/admin/webhooks/{webhook-id}", unsubscribeHandler).Methods("DELETE")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeChangesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	limit := DefaultPageSize
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return nil, ErrBadLimit
+		}
+		limit = n
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return changesRequest{Since: req.FormValue("since"), Limit: limit}, nil
+}
+
+func decodeSubscribeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r subscribeRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeWebhooksRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeUnsubscribeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	webhookID, ok := mux.Vars(req)["webhook-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "webhook-id")
+	}
+	return unsubscribeRequest{WebhookID: webhookID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrWebhookNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrBadLimit, ErrBadCursor, ErrInvalidWebhook, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,109 @@
+package fraud
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the fraud service endpoints under single type.
+type Endpoints struct {
+	ScoreEndpoint      endpoint.Endpoint
+	AssessmentEndpoint endpoint.Endpoint
+	QueueEndpoint      endpoint.Endpoint
+	ReviewEndpoint     endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the fraud service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ScoreEndpoint:      admin(MakeScoreEndpoint(s)),
+		AssessmentEndpoint: admin(MakeAssessmentEndpoint(s)),
+		QueueEndpoint:      admin(MakeQueueEndpoint(s)),
+		ReviewEndpoint:     admin(MakeReviewEndpoint(s)),
+	}
+}
+
+func MakeScoreEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(scoreRequest)
+		a, e := s.Score(ctx, req.Checkout)
+		if e != nil {
+			return assessmentResponse{Assessment: nil, Error: e}, nil
+		}
+		return assessmentResponse{Assessment: &a, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeAssessmentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(assessmentRequest)
+		a, e := s.Assessment(ctx, req.OrderID)
+		if e != nil {
+			return assessmentResponse{Assessment: nil, Error: e}, nil
+		}
+		return assessmentResponse{Assessment: &a}, nil
+	}
+}
+
+func MakeQueueEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		queue, e := s.Queue(ctx)
+		if e != nil {
+			return queueResponse{Assessments: make([]Assessment, 0), Error: e}, nil
+		}
+		return queueResponse{Assessments: queue}, nil
+	}
+}
+
+func MakeReviewEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reviewRequest)
+		a, e := s.Review(ctx, req.ID, req.Approve, req.Note)
+		if e != nil {
+			return assessmentResponse{Assessment: nil, Error: e}, nil
+		}
+		return assessmentResponse{Assessment: &a}, nil
+	}
+}
+
+type scoreRequest struct {
+	Checkout
+}
+
+type assessmentRequest struct {
+	OrderID string
+}
+
+type assessmentResponse struct {
+	Status     int         `json:"-"`
+	Assessment *Assessment `json:"assessment,omitempty"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r assessmentResponse) status() int {
+	return r.Status
+}
+
+func (r assessmentResponse) error() error {
+	return r.Error
+}
+
+type queueRequest struct{}
+
+type queueResponse struct {
+	Assessments []Assessment `json:"assessments"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r queueResponse) error() error {
+	return r.Error
+}
+
+type reviewRequest struct {
+	ID      string `json:"-"`
+	Approve bool   `json:"approve"`
+	Note    string `json:"note"`
+}
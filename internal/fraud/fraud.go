@@ -0,0 +1,94 @@
+package fraud
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Decisions made by the scorer.
+const (
+	DecisionApprove = "approve"
+	DecisionReview  = "review"
+	DecisionDecline = "decline"
+)
+
+// Status of an assessment. Orders flagged for review stay pending until an
+// admin approves or declines them.
+const (
+	StatusApproved = "approved"
+	StatusPending  = "pending"
+	StatusDeclined = "declined"
+)
+
+// Checkout carries the details of an order being placed which are relevant
+// for fraud scoring.
+type Checkout struct {
+	OrderID         string  `json:"-"`
+	UserID          string  `json:"user_id"`
+	Email           string  `json:"email"`
+	IP              string  `json:"ip"`
+	BillingCountry  string  `json:"billing_country"`
+	ShippingCountry string  `json:"shipping_country"`
+	CardBIN         string  `json:"card_bin"`
+	Total           float64 `json:"total"`
+	Currency        string  `json:"currency"`
+}
+
+// Assessment is the fraud score of an order and the rules which hit.
+type Assessment struct {
+	ID         string     `json:"id"`
+	OrderID    string     `json:"order_id"`
+	UserID     string     `json:"user_id"`
+	Email      string     `json:"email"`
+	IP         string     `json:"ip"`
+	Score      int        `json:"score"`
+	ReasonList string     `json:"-"`
+	Decision   string     `json:"decision"`
+	Status     string     `json:"status"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+func (Assessment) TableName() string {
+	return "fraud_assessments"
+}
+
+// Reasons returns the rules hit by the order.
+func (a Assessment) Reasons() []string {
+	if a.ReasonList == "" {
+		return nil
+	}
+	return strings.Split(a.ReasonList, ",")
+}
+
+// MarshalJSON adds the reasons to the assessment.
+func (a Assessment) MarshalJSON() ([]byte, error) {
+	type assessment Assessment
+	return json.Marshal(struct {
+		assessment
+		Reasons []string `json:"reasons"`
+	}{assessment(a), a.Reasons()})
+}
+
+// Config controls the rules and the thresholds of the scorer.
+type Config struct {
+	ReviewScore  int // orders scoring at least ReviewScore go to manual review
+	DeclineScore int // orders scoring at least DeclineScore are declined
+
+	VelocityWindow time.Duration // window to count the orders of a user or IP in
+	VelocityLimit  int           // orders allowed in the window
+
+	DisposableDomains []string // extra disposable email domains
+}
+
+// DefaultConfig returns the config the scorer starts with.
+func DefaultConfig() Config {
+	return Config{
+		ReviewScore:    50,
+		DeclineScore:   90,
+		VelocityWindow: time.Hour,
+		VelocityLimit:  3,
+	}
+}
@@ -0,0 +1,70 @@
+package fraud
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Score(ctx context.Context, c Checkout) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "score", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	assessment, err = mw.next.Score(ctx, c)
+	return
+}
+
+func (mw instrmw) Assessment(ctx context.Context, orderID string) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "assessment", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	assessment, err = mw.next.Assessment(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Queue(ctx context.Context) (assessments []Assessment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "queue", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	assessments, err = mw.next.Queue(ctx)
+	return
+}
+
+func (mw instrmw) Review(ctx context.Context, ID string, approve bool, note string) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "review", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	assessment, err = mw.next.Review(ctx, ID, approve, note)
+	return
+}
@@ -0,0 +1,67 @@
+package fraud
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Score(ctx context.Context, c Checkout) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "score",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Score(ctx, c)
+}
+
+func (s loggingService) Assessment(ctx context.Context, orderID string) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "assessment",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Assessment(ctx, orderID)
+}
+
+func (s loggingService) Queue(ctx context.Context) (assessments []Assessment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "queue",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Queue(ctx)
+}
+
+func (s loggingService) Review(ctx context.Context, ID string, approve bool, note string) (assessment Assessment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "review",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Review(ctx, ID, approve, note)
+}
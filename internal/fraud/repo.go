@@ -0,0 +1,15 @@
+package fraud
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Fraud Service
+type Repo interface {
+	Create(a *Assessment) error
+	Save(a *Assessment) error
+	GetByID(ID string) (Assessment, error)
+	GetByOrder(orderID string) (Assessment, error)
+	ListByStatus(status string) ([]Assessment, error)
+	// CountSince counts the assessments with the user id or IP since the time.
+	CountSince(userID, ip string, since time.Time) (int, error)
+	Drop() error
+}
@@ -0,0 +1,137 @@
+package fraud
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Rule scores a single signal of the checkout. reason is empty when the rule
+// doesn't hit.
+type Rule interface {
+	Check(ctx context.Context, c Checkout) (score int, reason string, err error)
+}
+
+// Denylist tells whether a value is denied. kind is one of "email", "ip"
+// and "bin".
+type Denylist interface {
+	Denied(ctx context.Context, kind, value string) (bool, error)
+}
+
+// Kinds of values looked up in the denylist.
+const (
+	KindEmail = "email"
+	KindIP    = "ip"
+	KindBIN   = "bin"
+)
+
+type velocityRule struct {
+	r      Repo
+	window time.Duration
+	limit  int
+}
+
+// VelocityRule hits when the user or IP placed more than limit orders in the
+// window.
+func VelocityRule(r Repo, window time.Duration, limit int) Rule {
+	return velocityRule{r: r, window: window, limit: limit}
+}
+
+func (v velocityRule) Check(ctx context.Context, c Checkout) (int, string, error) {
+	n, err := v.r.CountSince(c.UserID, c.IP, time.Now().UTC().Add(-v.window))
+	if err != nil {
+		return 0, "", err
+	}
+	if n >= v.limit {
+		return 40, "velocity", nil
+	}
+	return 0, "", nil
+}
+
+type countryMismatchRule struct{}
+
+// CountryMismatchRule hits when billing and shipping countries differ.
+// Digital orders without a shipping country never hit.
+func CountryMismatchRule() Rule {
+	return countryMismatchRule{}
+}
+
+func (countryMismatchRule) Check(ctx context.Context, c Checkout) (int, string, error) {
+	if c.ShippingCountry == "" || c.BillingCountry == "" {
+		return 0, "", nil
+	}
+	if !strings.EqualFold(c.BillingCountry, c.ShippingCountry) {
+		return 30, "country_mismatch", nil
+	}
+	return 0, "", nil
+}
+
+// disposableDomains are well known throwaway email providers.
+var disposableDomains = []string{
+	"mailinator.com",
+	"guerrillamail.com",
+	"10minutemail.com",
+	"tempmail.com",
+	"temp-mail.org",
+	"yopmail.com",
+	"trashmail.com",
+	"sharklasers.com",
+	"getnada.com",
+	"dispostable.com",
+}
+
+type disposableEmailRule struct {
+	domains map[string]bool
+}
+
+// DisposableEmailRule hits when the email belongs to a disposable email
+// provider. extra domains are checked along with the built-in ones.
+func DisposableEmailRule(extra []string) Rule {
+	domains := make(map[string]bool)
+	for _, d := range append(disposableDomains, extra...) {
+		domains[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+	return disposableEmailRule{domains: domains}
+}
+
+func (d disposableEmailRule) Check(ctx context.Context, c Checkout) (int, string, error) {
+	at := strings.LastIndex(c.Email, "@")
+	if at < 0 {
+		return 0, "", nil
+	}
+	if d.domains[strings.ToLower(c.Email[at+1:])] {
+		return 30, "disposable_email", nil
+	}
+	return 0, "", nil
+}
+
+type denylistRule struct {
+	list Denylist
+}
+
+// DenylistRule hits when the email, IP or card BIN is denied. A denylist hit
+// alone is enough to decline the order.
+func DenylistRule(list Denylist) Rule {
+	return denylistRule{list: list}
+}
+
+func (d denylistRule) Check(ctx context.Context, c Checkout) (int, string, error) {
+	values := []struct{ kind, value string }{
+		{KindEmail, c.Email},
+		{KindIP, c.IP},
+		{KindBIN, c.CardBIN},
+	}
+	for _, v := range values {
+		if v.value == "" {
+			continue
+		}
+		denied, err := d.list.Denied(ctx, v.kind, v.value)
+		if err != nil {
+			return 0, "", err
+		}
+		if denied {
+			return 100, "denylist_" + v.kind, nil
+		}
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,98 @@
+package fraud_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fraud"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+type denylist map[string]string
+
+func (l denylist) Denied(ctx context.Context, kind, value string) (bool, error) {
+	return l[kind] == value, nil
+}
+
+// repo keeps the assessments in memory, the other methods of the Repo
+// aren't used.
+type repo struct {
+	fraud.Repo
+	assessments []fraud.Assessment
+}
+
+func (r *repo) GetByOrder(orderID string) (fraud.Assessment, error) {
+	for _, a := range r.assessments {
+		if a.OrderID == orderID {
+			return a, nil
+		}
+	}
+	return fraud.Assessment{}, db.ErrNotFound
+}
+
+func TestRules(t *testing.T) {
+	deny := denylist{"email": "bad@example.com", "ip": "203.0.113.7", "bin": "411111"}
+	cases := []struct {
+		name     string
+		rule     fraud.Rule
+		checkout fraud.Checkout
+		expected string
+	}{
+		{"same country", fraud.CountryMismatchRule(), fraud.Checkout{BillingCountry: "DE", ShippingCountry: "de"}, ""},
+		{"digital order", fraud.CountryMismatchRule(), fraud.Checkout{BillingCountry: "DE"}, ""},
+		{"country mismatch", fraud.CountryMismatchRule(), fraud.Checkout{BillingCountry: "DE", ShippingCountry: "NG"}, "country_mismatch"},
+		{"regular email", fraud.DisposableEmailRule(nil), fraud.Checkout{Email: "reader@example.com"}, ""},
+		{"disposable email", fraud.DisposableEmailRule(nil), fraud.Checkout{Email: "x@Mailinator.com"}, "disposable_email"},
+		{"extra disposable domain", fraud.DisposableEmailRule([]string{"throwaway.io"}), fraud.Checkout{Email: "x@throwaway.io"}, "disposable_email"},
+		{"not denied", fraud.DenylistRule(deny), fraud.Checkout{Email: "reader@example.com", IP: "198.51.100.1"}, ""},
+		{"denied email", fraud.DenylistRule(deny), fraud.Checkout{Email: "bad@example.com"}, "denylist_email"},
+		{"denied ip", fraud.DenylistRule(deny), fraud.Checkout{IP: "203.0.113.7"}, "denylist_ip"},
+		{"denied bin", fraud.DenylistRule(deny), fraud.Checkout{CardBIN: "411111"}, "denylist_bin"},
+	}
+	for _, c := range cases {
+		_, reason, err := c.rule.Check(context.Background(), c.checkout)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if reason != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, reason)
+		}
+	}
+}
+
+func TestAssessmentRequiresAdmin(t *testing.T) {
+	r := &repo{assessments: []fraud.Assessment{{ID: "a1", OrderID: "o1"}}}
+	s := fraud.NewService(r, nil, fraud.Config{}, denylist{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := fraud.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/fraud/v1/orders/o1", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
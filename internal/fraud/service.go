@@ -0,0 +1,134 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrAssessmentNotFound = errors.New("fraud assessment not found")
+	ErrAlreadyReviewed    = errors.New("fraud assessment is already reviewed")
+)
+
+type Service interface {
+	// Score assesses the order at checkout. An order is scored only once,
+	// scoring it again returns the first assessment.
+	Score(ctx context.Context, c Checkout) (Assessment, error)
+
+	// Assessment returns the assessment of the order.
+	Assessment(ctx context.Context, orderID string) (Assessment, error)
+
+	// Queue lists the assessments waiting for manual review, oldest first.
+	Queue(ctx context.Context) ([]Assessment, error)
+
+	// Review approves or declines an order waiting for manual review.
+	Review(ctx context.Context, ID string, approve bool, note string) (Assessment, error)
+}
+
+type basicService struct {
+	r      Repo
+	orders order.Repo
+	cfg    Config
+	rules  []Rule
+}
+
+// NewService return basic Service implementation. denylist is optional.
+func NewService(r Repo, orders order.Repo, cfg Config, denylist Denylist) Service {
+	rules := []Rule{
+		VelocityRule(r, cfg.VelocityWindow, cfg.VelocityLimit),
+		CountryMismatchRule(),
+		DisposableEmailRule(cfg.DisposableDomains),
+	}
+	if denylist != nil {
+		rules = append(rules, DenylistRule(denylist))
+	}
+	return basicService{r: r, orders: orders, cfg: cfg, rules: rules}
+}
+
+// Score assesses the order at checkout.
+func (s basicService) Score(ctx context.Context, c Checkout) (Assessment, error) {
+	if _, err := s.orders.GetByID(c.OrderID); err != nil {
+		return Assessment{}, order.ErrOrderNotFound
+	}
+	if a, err := s.r.GetByOrder(c.OrderID); err == nil {
+		return a, nil
+	}
+
+	score := 0
+	reasons := make([]string, 0)
+	for _, rule := range s.rules {
+		n, reason, err := rule.Check(ctx, c)
+		if err != nil {
+			return Assessment{}, err
+		}
+		if reason != "" {
+			score += n
+			reasons = append(reasons, reason)
+		}
+	}
+
+	a := Assessment{
+		OrderID:    c.OrderID,
+		UserID:     c.UserID,
+		Email:      c.Email,
+		IP:         c.IP,
+		Score:      score,
+		ReasonList: strings.Join(reasons, ","),
+		CreatedAt:  time.Now().UTC(),
+	}
+	switch {
+	case score >= s.cfg.DeclineScore:
+		a.Decision, a.Status = DecisionDecline, StatusDeclined
+	case score >= s.cfg.ReviewScore:
+		a.Decision, a.Status = DecisionReview, StatusPending
+	default:
+		a.Decision, a.Status = DecisionApprove, StatusApproved
+	}
+	if err := s.r.Create(&a); err != nil {
+		return Assessment{}, err
+	}
+	return a, nil
+}
+
+// Assessment returns the assessment of the order.
+func (s basicService) Assessment(ctx context.Context, orderID string) (Assessment, error) {
+	a, err := s.r.GetByOrder(orderID)
+	if err != nil {
+		return Assessment{}, ErrAssessmentNotFound
+	}
+	return a, nil
+}
+
+// Queue lists the assessments waiting for manual review.
+func (s basicService) Queue(ctx context.Context) ([]Assessment, error) {
+	return s.r.ListByStatus(StatusPending)
+}
+
+// Review approves or declines an order waiting for manual review.
+func (s basicService) Review(ctx context.Context, ID string, approve bool, note string) (Assessment, error) {
+	a, err := s.r.GetByID(ID)
+	if err != nil {
+		return Assessment{}, ErrAssessmentNotFound
+	}
+	if a.Status != StatusPending {
+		return Assessment{}, ErrAlreadyReviewed
+	}
+	now := time.Now().UTC()
+	a.Status = StatusDeclined
+	if approve {
+		a.Status = StatusApproved
+	}
+	a.ReviewNote = note
+	a.ReviewedAt = &now
+	if err := s.r.Save(&a); err != nil {
+		return Assessment{}, err
+	}
+	return a, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
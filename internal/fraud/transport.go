@@ -0,0 +1,179 @@
+package fraud
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// MakeHTTPHandler mounts the fraud endpoints, served to the requests admin
+// lets through, e.g. auth.NewMiddleware chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	scoreHandler := httptransport.NewServer(
+		e.ScoreEndpoint,
+		decodeScoreRequest,
+		encodeResponse,
+		options...,
+	)
+	assessmentHandler := httptransport.NewServer(
+		e.AssessmentEndpoint,
+		decodeAssessmentRequest,
+		encodeResponse,
+		options...,
+	)
+	queueHandler := httptransport.NewServer(
+		e.QueueEndpoint,
+		decodeQueueRequest,
+		encodeResponse,
+		options...,
+	)
+	reviewHandler := httptransport.NewServer(
+		e.ReviewEndpoint,
+		decodeReviewRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/fraud/v1/orders/{order-id}/score", scoreHandler).Methods("POST")
+	r.Handle("/fraud/v1/orders/{order-id}", assessmentHandler).Methods("GET")
+	r.Handle("/fraud/v1/admin/queue", queueHandler).Methods("GET")
+	r.Handle("/fraud/v1/admin/{assessment-id}/review", reviewHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeScoreRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r scoreRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeAssessmentRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return assessmentRequest{OrderID: orderID}, nil
+}
+
+func decodeQueueRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return queueRequest{}, nil
+}
+
+func decodeReviewRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r reviewRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	ID, ok := mux.Vars(req)["assessment-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "assessment-id")
+	}
+	r.ID = ID
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrAssessmentNotFound, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyReviewed:
+		return http.StatusConflict
+	case ErrBadRouting, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,153 @@
+package fulfillment
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the fulfillment service endpoints under single type.
+type Endpoints struct {
+	SubmitEndpoint  endpoint.Endpoint
+	JobsEndpoint    endpoint.Endpoint
+	WebhookEndpoint endpoint.Endpoint
+
+	ShipmentsEndpoint    endpoint.Endpoint
+	ReprintLabelEndpoint endpoint.Endpoint
+	CostsEndpoint        endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the fulfillment service endpoints. The manual resubmit of an order
+// and its jobs are restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		SubmitEndpoint:  admin(MakeSubmitEndpoint(s)),
+		JobsEndpoint:    admin(MakeJobsEndpoint(s)),
+		WebhookEndpoint: MakeWebhookEndpoint(s),
+
+		ShipmentsEndpoint:    MakeShipmentsEndpoint(s),
+		ReprintLabelEndpoint: MakeReprintLabelEndpoint(s),
+		CostsEndpoint:        MakeCostsEndpoint(s),
+	}
+}
+
+func MakeSubmitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobsRequest)
+		jobs, e := s.Submit(ctx, req.OrderID)
+		if e != nil {
+			return jobsResponse{Jobs: make([]Job, 0), Error: e}, nil
+		}
+		return jobsResponse{Jobs: jobs}, nil
+	}
+}
+
+func MakeJobsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobsRequest)
+		jobs, e := s.Jobs(ctx, req.OrderID)
+		if e != nil {
+			return jobsResponse{Jobs: make([]Job, 0), Error: e}, nil
+		}
+		return jobsResponse{Jobs: jobs}, nil
+	}
+}
+
+func MakeWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.Webhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+func MakeShipmentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobsRequest)
+		shipments, e := s.Shipments(ctx, req.OrderID)
+		if e != nil {
+			return shipmentsResponse{Shipments: make([]Shipment, 0), Error: e}, nil
+		}
+		return shipmentsResponse{Shipments: shipments}, nil
+	}
+}
+
+func MakeReprintLabelEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(shipmentRequest)
+		f, e := s.ReprintLabel(ctx, req.ShipmentID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakeCostsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobsRequest)
+		costs, e := s.Costs(ctx, req.OrderID)
+		if e != nil {
+			return costsResponse{Costs: make([]Cost, 0), Error: e}, nil
+		}
+		totals := make(map[string]float64)
+		for _, c := range costs {
+			totals[c.Currency] = math.Round((totals[c.Currency]+c.Amount)*100) / 100
+		}
+		return costsResponse{Costs: costs, Totals: totals}, nil
+	}
+}
+
+type jobsRequest struct {
+	OrderID string `json:"order_id"`
+}
+
+type jobsResponse struct {
+	Jobs  []Job `json:"jobs"`
+	Error error `json:"error,omitempty"`
+}
+
+func (r jobsResponse) error() error {
+	return r.Error
+}
+
+type webhookRequest struct {
+	Signature string
+	Body      []byte
+}
+
+type webhookResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r webhookResponse) error() error {
+	return r.Error
+}
+
+type shipmentsResponse struct {
+	Shipments []Shipment `json:"shipments"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r shipmentsResponse) error() error {
+	return r.Error
+}
+
+type shipmentRequest struct {
+	ShipmentID string
+}
+
+type fileResponse struct {
+	File
+	Error error
+}
+
+type costsResponse struct {
+	Costs []Cost `json:"costs"`
+	// Totals are the sums of the costs by currency.
+	Totals map[string]float64 `json:"totals,omitempty"`
+	Error  error              `json:"error,omitempty"`
+}
+
+func (r costsResponse) error() error {
+	return r.Error
+}
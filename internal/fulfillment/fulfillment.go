@@ -0,0 +1,45 @@
+package fulfillment
+
+import "time"
+
+const (
+	StatusPending      = "pending"
+	StatusSubmitted    = "submitted"
+	StatusInProduction = "in_production"
+	StatusShipped      = "shipped"
+	StatusFailed       = "failed"
+)
+
+// Job is a request to the fulfillment provider to produce and ship
+// a book of an order.
+type Job struct {
+	ID             string    `json:"id"`
+	OrderID        string    `json:"order_id"`
+	BookID         string    `json:"book_id"`
+	ISBN           string    `json:"isbn"`
+	Provider       string    `json:"provider"`
+	Reference      string    `json:"reference"` // job id at the provider
+	Status         string    `json:"status"`
+	Carrier        string    `json:"carrier,omitempty"`
+	TrackingNumber string    `json:"tracking_number,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName keeps fulfillment jobs apart from any other kind of job.
+func (Job) TableName() string {
+	return "fulfillment_jobs"
+}
+
+// Done tells whether the job reached a final status.
+func (j Job) Done() bool {
+	return j.Status == StatusShipped || j.Status == StatusFailed
+}
+
+// Update is the status of a job reported by the provider.
+type Update struct {
+	Reference      string `json:"reference"`
+	Status         string `json:"status"`
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
@@ -0,0 +1,62 @@
+package fulfillment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestSubmitUnpaid(t *testing.T) {
+	orders := orderRepo{o: order.Order{ID: "o1", Items: []catalog.Book{{ID: "b1"}}}}
+	labels := &labelProvider{}
+	s := fulfillment.NewService(&repo{}, orders, addressRepo{}, nil, labels, nil)
+	if _, err := s.Submit(context.Background(), "o1"); err != fulfillment.ErrUnpaid {
+		t.Errorf("expected ErrUnpaid, got %v", err)
+	}
+	if len(labels.requests) != 0 {
+		t.Errorf("expected no label bought, got %+v", labels.requests)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	orders := orderRepo{o: order.Order{ID: "o1", Items: []catalog.Book{{ID: "b1"}}, PaidAt: &paid}}
+	s := fulfillment.NewService(&repo{}, orders, addressRepo{}, nil, nil, nil)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := fulfillment.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"submit without token", "POST", "/fulfillment/v1/admin/orders/o1/submit", "", http.StatusUnauthorized},
+		{"submit by customer", "POST", "/fulfillment/v1/admin/orders/o1/submit", customer, http.StatusForbidden},
+		{"jobs by customer", "GET", "/fulfillment/v1/admin/orders/o1/jobs", customer, http.StatusForbidden},
+		{"submit by admin", "POST", "/fulfillment/v1/admin/orders/o1/submit", staff, http.StatusOK},
+		{"jobs by admin", "GET", "/fulfillment/v1/admin/orders/o1/jobs", staff, http.StatusOK},
+		{"submit at the customer route", "POST", "/fulfillment/v1/orders/o1/submit", staff, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package fulfillment
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Submit(ctx context.Context, orderID string) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "submit", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	jobs, err = mw.next.Submit(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Jobs(ctx context.Context, orderID string) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "jobs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	jobs, err = mw.next.Jobs(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Poll(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "poll", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Poll(ctx)
+	return
+}
+
+func (mw instrmw) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Webhook(ctx, signature, body)
+	return
+}
+
+func (mw instrmw) Shipments(ctx context.Context, orderID string) (shipments []Shipment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "shipments", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	shipments, err = mw.next.Shipments(ctx, orderID)
+	return
+}
+
+func (mw instrmw) ReprintLabel(ctx context.Context, shipmentID string) (f File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reprint_label", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	f, err = mw.next.ReprintLabel(ctx, shipmentID)
+	return
+}
+
+func (mw instrmw) Costs(ctx context.Context, orderID string) (costs []Cost, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "costs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	costs, err = mw.next.Costs(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Pack(ctx context.Context, orderID string) (shipments []Shipment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "pack", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	shipments, err = mw.next.Pack(ctx, orderID)
+	return
+}
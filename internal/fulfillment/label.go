@@ -0,0 +1,96 @@
+package fulfillment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/pkg/errors"
+)
+
+// LabelProvider abstracts the third party the shipping labels are bought
+// from, which picks the carrier.
+type LabelProvider interface {
+	// Name uniquely identifies the provider.
+	Name() string
+
+	// Buy purchases the label of a shipment. The provider is expected to
+	// return the same label when asked again for the same shipment.
+	Buy(ctx context.Context, r LabelRequest) (Label, error)
+}
+
+// LabelRequest is the parcel a label is bought for.
+type LabelRequest struct {
+	ShipmentID string          `json:"shipment_id"`
+	OrderID    string          `json:"order_id"`
+	To         address.Address `json:"to"`
+	Items      int             `json:"items"`
+	// Customs is the declaration of a cross-border parcel, nil for the
+	// others.
+	Customs *customs.Declaration `json:"customs,omitempty"`
+}
+
+// Label is a purchased shipping label.
+type Label struct {
+	Reference      string  `json:"id"`
+	Carrier        string  `json:"carrier"`
+	Service        string  `json:"service"`
+	TrackingNumber string  `json:"tracking_number"`
+	Postage        float64 `json:"postage"`
+	Currency       string  `json:"currency"`
+	PDF            []byte  `json:"label_pdf"` // base64 in JSON
+}
+
+type httpLabelProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPLabelProvider returns LabelProvider posting the requests to
+// <baseURL>/labels and reading back the Label as JSON.
+func NewHTTPLabelProvider(baseURL, apiKey string, client *http.Client) LabelProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpLabelProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client}
+}
+
+func (p httpLabelProvider) Name() string {
+	return "labels"
+}
+
+func (p httpLabelProvider) Buy(ctx context.Context, r LabelRequest) (Label, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return Label{}, err
+	}
+	req, err := http.NewRequest("POST", p.baseURL+"/labels", bytes.NewReader(body))
+	if err != nil {
+		return Label{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	// The shipment ID makes a retried purchase return the same label.
+	req.Header.Set("Idempotency-Key", r.ShipmentID)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Label{}, errors.Wrap(err, "label buy")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return Label{}, fmt.Errorf("label buy: unexpected status %d", resp.StatusCode)
+	}
+	var l Label
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return Label{}, errors.Wrap(err, "label buy")
+	}
+	return l, nil
+}
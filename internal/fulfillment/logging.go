@@ -0,0 +1,114 @@
+package fulfillment
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Submit(ctx context.Context, orderID string) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "submit",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Submit(ctx, orderID)
+}
+
+func (s loggingService) Jobs(ctx context.Context, orderID string) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "jobs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Jobs(ctx, orderID)
+}
+
+func (s loggingService) Poll(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "poll",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Poll(ctx)
+}
+
+func (s loggingService) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Webhook(ctx, signature, body)
+}
+
+func (s loggingService) Shipments(ctx context.Context, orderID string) (shipments []Shipment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "shipments",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Shipments(ctx, orderID)
+}
+
+func (s loggingService) ReprintLabel(ctx context.Context, shipmentID string) (f File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reprint_label",
+			"shipment_id", shipmentID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ReprintLabel(ctx, shipmentID)
+}
+
+func (s loggingService) Costs(ctx context.Context, orderID string) (costs []Cost, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "costs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Costs(ctx, orderID)
+}
+
+func (s loggingService) Pack(ctx context.Context, orderID string) (shipments []Shipment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "pack",
+			"order_id", orderID,
+			"shipments", len(shipments),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Pack(ctx, orderID)
+}
@@ -0,0 +1,24 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunPoller polls the provider for job status every interval until ctx is done.
+func RunPoller(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Poll(ctx); err != nil {
+				logger.Log("poller", "fulfillment", "err", err)
+			}
+		}
+	}
+}
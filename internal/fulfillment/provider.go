@@ -0,0 +1,112 @@
+package fulfillment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the third party that produces and ships the books.
+type Provider interface {
+	// Name uniquely identifies the provider.
+	Name() string
+
+	// Submit sends the job to the provider and returns the provider's reference.
+	Submit(ctx context.Context, job Job) (string, error)
+
+	// Status polls current status of the job from the provider.
+	Status(ctx context.Context, ref string) (Update, error)
+
+	// VerifyWebhook tells whether the webhook body is signed by the provider.
+	VerifyWebhook(signature string, body []byte) bool
+}
+
+type podProvider struct {
+	baseURL string
+	apiKey  string
+	secret  string
+	client  *http.Client
+}
+
+// NewPODProvider returns print-on-demand Provider talking to the REST API
+// at baseURL. secret is used to verify the webhook signatures.
+func NewPODProvider(baseURL, apiKey, secret string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return podProvider{baseURL: baseURL, apiKey: apiKey, secret: secret, client: client}
+}
+
+func (p podProvider) Name() string {
+	return "pod"
+}
+
+type podJob struct {
+	ID             string `json:"id,omitempty"`
+	ExternalID     string `json:"external_id"`
+	OrderID        string `json:"order_id"`
+	ISBN           string `json:"isbn"`
+	Status         string `json:"status,omitempty"`
+	Carrier        string `json:"carrier,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+}
+
+func (p podProvider) Submit(ctx context.Context, job Job) (string, error) {
+	body, err := json.Marshal(podJob{ExternalID: job.ID, OrderID: job.OrderID, ISBN: job.ISBN})
+	if err != nil {
+		return "", err
+	}
+	var res podJob
+	if err := p.do(ctx, "POST", "/jobs", body, &res); err != nil {
+		return "", errors.Wrap(err, "pod submit")
+	}
+	return res.ID, nil
+}
+
+func (p podProvider) Status(ctx context.Context, ref string) (Update, error) {
+	var res podJob
+	if err := p.do(ctx, "GET", "/jobs/"+ref, nil, &res); err != nil {
+		return Update{}, errors.Wrap(err, "pod status")
+	}
+	return Update{
+		Reference:      res.ID,
+		Status:         res.Status,
+		Carrier:        res.Carrier,
+		TrackingNumber: res.TrackingNumber,
+	}, nil
+}
+
+// VerifyWebhook checks the hex encoded HMAC-SHA256 of the body.
+func (p podProvider) VerifyWebhook(signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (p podProvider) do(ctx context.Context, method, path string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
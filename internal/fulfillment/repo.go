@@ -0,0 +1,21 @@
+package fulfillment
+
+// Repo abstracts all the persistant storage operations of Fulfillment Service
+type Repo interface {
+	Create(job *Job) error
+	Save(job *Job) error
+	GetByReference(provider, ref string) (Job, error)
+	ListByOrder(orderID string) ([]Job, error)
+	ListOpen() ([]Job, error)
+
+	CreateShipment(sh *Shipment) error
+	SaveShipment(sh *Shipment) error
+	GetShipment(ID string) (Shipment, error)
+	ListShipments(orderID string) ([]Shipment, error)
+	// SaveLabel saves the shipment with its purchased label and appends the
+	// postage to the cost ledger, both or neither.
+	SaveLabel(sh *Shipment, postage *Cost) error
+	ListCosts(orderID string) ([]Cost, error)
+
+	Drop() error
+}
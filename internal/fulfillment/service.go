@@ -0,0 +1,338 @@
+package fulfillment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrJobNotFound      = errors.New("fulfillment job not found")
+	ErrShipmentNotFound = errors.New("shipment not found")
+	ErrNoLabel          = errors.New("shipment label is not purchased yet")
+	ErrUndeliverable    = errors.New("shipping address is undeliverable")
+	ErrUnpaid           = errors.New("order is not paid")
+)
+
+type Service interface {
+	// Submit creates print-on-demand jobs for the items of a paid order,
+	// and buys the shipping label of the other items, shipped by the shop.
+	// It is safe to call multiple times, items already submitted are skipped.
+	Submit(ctx context.Context, orderID string) ([]Job, error)
+
+	// Jobs returns all the fulfillment jobs of an order.
+	Jobs(ctx context.Context, orderID string) ([]Job, error)
+
+	// Poll refreshes the status of all the open jobs from the provider.
+	Poll(ctx context.Context) error
+
+	// Webhook applies the status update pushed by the provider.
+	Webhook(ctx context.Context, signature string, body []byte) error
+
+	// Shipments returns the shipments of an order.
+	Shipments(ctx context.Context, orderID string) ([]Shipment, error)
+
+	// ReprintLabel returns the label of a shipment again, as purchased,
+	// counting the prints.
+	ReprintLabel(ctx context.Context, shipmentID string) (File, error)
+
+	// Costs returns the fulfillment cost ledger of an order.
+	Costs(ctx context.Context, orderID string) ([]Cost, error)
+
+	// Pack marks the labelled shipments of an order packed once the
+	// warehouse scanned its books, returning the shipments of the order.
+	Pack(ctx context.Context, orderID string) ([]Shipment, error)
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	addresses address.Repo
+	provider  Provider
+	labels    LabelProvider
+	customs   customs.Service
+}
+
+// NewService return basic Service implementation. The shipping labels are
+// bought from labels, to the checked addresses of the orders. A nil labels
+// leaves the shipping of the items of the shop to the warehouse. The
+// cross-border parcels are declared to customs before their label is
+// bought, unless customs is nil.
+func NewService(r Repo, orders order.Repo, addresses address.Repo, provider Provider, labels LabelProvider, customs customs.Service) Service {
+	return basicService{r: r, orders: orders, addresses: addresses, provider: provider, labels: labels, customs: customs}
+}
+
+// Submit creates print-on-demand jobs for the items of a paid order, the
+// unpaid orders being refused.
+func (s basicService) Submit(ctx context.Context, orderID string) ([]Job, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	if o.PaidAt == nil {
+		return nil, ErrUnpaid
+	}
+	jobs, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]int)
+	for i, j := range jobs {
+		existing[j.BookID] = i
+	}
+
+	shipped := 0
+	for _, b := range o.Items {
+		if !b.PrintOnDemand {
+			shipped++
+			continue
+		}
+		i, ok := existing[b.ID]
+		if !ok {
+			now := time.Now().UTC()
+			j := Job{
+				OrderID:   o.ID,
+				BookID:    b.ID,
+				ISBN:      b.ISBN,
+				Provider:  s.provider.Name(),
+				Status:    StatusPending,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := s.r.Create(&j); err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, j)
+			i = len(jobs) - 1
+			existing[b.ID] = i
+		}
+		if jobs[i].Status != StatusPending {
+			continue
+		}
+		// Job is already persisted as pending, so a failed submit is
+		// retried on the next call.
+		ref, err := s.provider.Submit(ctx, jobs[i])
+		if err != nil {
+			return nil, err
+		}
+		jobs[i].Reference = ref
+		jobs[i].Status = StatusSubmitted
+		jobs[i].UpdatedAt = time.Now().UTC()
+		if err := s.r.Save(&jobs[i]); err != nil {
+			return nil, err
+		}
+	}
+	if shipped > 0 && s.labels != nil {
+		if err := s.ship(ctx, o.ID, shipped); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// ship buys the label of the items of the order shipped by the shop, once,
+// recording its postage in the cost ledger of the order.
+func (s basicService) ship(ctx context.Context, orderID string, items int) error {
+	shipments, err := s.r.ListShipments(orderID)
+	if err != nil {
+		return err
+	}
+	var sh Shipment
+	for _, x := range shipments {
+		if x.Status != ShipmentPending {
+			return nil
+		}
+		sh = x
+	}
+	c, err := s.addresses.GetCheck(orderID)
+	if err != nil {
+		return address.ErrCheckNotFound
+	}
+	if !c.Deliverable {
+		return ErrUndeliverable
+	}
+	if sh.ID == "" {
+		now := time.Now().UTC()
+		sh = Shipment{
+			OrderID:   orderID,
+			Provider:  s.labels.Name(),
+			Status:    ShipmentPending,
+			Items:     items,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.r.CreateShipment(&sh); err != nil {
+			return err
+		}
+	}
+	req := LabelRequest{ShipmentID: sh.ID, OrderID: orderID, To: c.Address, Items: sh.Items}
+	if s.customs != nil {
+		if req.Customs, err = s.customs.Declare(ctx, orderID, sh.ID, c.Address); err != nil {
+			return err
+		}
+	}
+	// The shipment is already persisted as pending, so a failed purchase
+	// is retried with the same shipment, not bought twice.
+	l, err := s.labels.Buy(ctx, req)
+	if err != nil {
+		return err
+	}
+	sh.Status = ShipmentLabelled
+	sh.Reference = l.Reference
+	sh.Carrier = l.Carrier
+	sh.Service = l.Service
+	sh.TrackingNumber = l.TrackingNumber
+	sh.Postage = math.Round(l.Postage*100) / 100
+	sh.Currency = l.Currency
+	sh.Label = l.PDF
+	sh.UpdatedAt = time.Now().UTC()
+	cost := Cost{
+		OrderID:    orderID,
+		ShipmentID: sh.ID,
+		Kind:       CostPostage,
+		Amount:     sh.Postage,
+		Currency:   sh.Currency,
+		CreatedAt:  sh.UpdatedAt,
+	}
+	return s.r.SaveLabel(&sh, &cost)
+}
+
+// Jobs returns all the fulfillment jobs of an order.
+func (s basicService) Jobs(ctx context.Context, orderID string) ([]Job, error) {
+	return s.r.ListByOrder(orderID)
+}
+
+// Poll refreshes the status of all the open jobs from the provider.
+// Pending jobs, which failed to submit earlier, are submitted again.
+func (s basicService) Poll(ctx context.Context) error {
+	jobs, err := s.r.ListOpen()
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if j.Status == StatusPending {
+			if _, err := s.Submit(ctx, j.OrderID); err != nil {
+				return err
+			}
+			continue
+		}
+		u, err := s.provider.Status(ctx, j.Reference)
+		if err != nil {
+			return err
+		}
+		if err := s.apply(j, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Webhook applies the status update pushed by the provider.
+func (s basicService) Webhook(ctx context.Context, signature string, body []byte) error {
+	if !s.provider.VerifyWebhook(signature, body) {
+		return ErrInvalidSignature
+	}
+	var u Update
+	if err := json.Unmarshal(body, &u); err != nil {
+		return err
+	}
+	j, err := s.r.GetByReference(s.provider.Name(), u.Reference)
+	if err != nil {
+		return ErrJobNotFound
+	}
+	return s.apply(j, u)
+}
+
+func (s basicService) apply(j Job, u Update) error {
+	if u.Status == "" || (u.Status == j.Status && u.TrackingNumber == j.TrackingNumber) {
+		return nil
+	}
+	j.Status = u.Status
+	if u.TrackingNumber != "" {
+		j.Carrier = u.Carrier
+		j.TrackingNumber = u.TrackingNumber
+	}
+	j.UpdatedAt = time.Now().UTC()
+	if err := s.r.Save(&j); err != nil {
+		return err
+	}
+	if j.Status != StatusShipped {
+		return nil
+	}
+	// The order is shipped with its first job, it can't be edited anymore.
+	o, err := s.orders.GetByID(j.OrderID)
+	if err != nil || o.ShippedAt != nil {
+		return err
+	}
+	o.ShippedAt = &j.UpdatedAt
+	return s.orders.Save(&o)
+}
+
+// Shipments returns the shipments of an order.
+func (s basicService) Shipments(ctx context.Context, orderID string) ([]Shipment, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	return s.r.ListShipments(orderID)
+}
+
+// ReprintLabel returns the stored label of a shipment, a reprint never
+// buying the label again.
+func (s basicService) ReprintLabel(ctx context.Context, shipmentID string) (File, error) {
+	sh, err := s.r.GetShipment(shipmentID)
+	if err != nil {
+		return File{}, ErrShipmentNotFound
+	}
+	if sh.Status == ShipmentPending || len(sh.Label) == 0 {
+		return File{}, ErrNoLabel
+	}
+	now := time.Now().UTC()
+	sh.Prints++
+	sh.PrintedAt = &now
+	if err := s.r.SaveShipment(&sh); err != nil {
+		return File{}, err
+	}
+	return File{Name: "label-" + sh.OrderID + "-" + sh.ID + ".pdf", ContentType: "application/pdf", Data: sh.Label}, nil
+}
+
+// Costs returns the fulfillment cost ledger of an order.
+func (s basicService) Costs(ctx context.Context, orderID string) ([]Cost, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	return s.r.ListCosts(orderID)
+}
+
+// Pack leaves the pending shipments as they are, their label is still to
+// be bought.
+func (s basicService) Pack(ctx context.Context, orderID string) ([]Shipment, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	shipments, err := s.r.ListShipments(orderID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i := range shipments {
+		sh := &shipments[i]
+		if sh.Status != ShipmentLabelled {
+			continue
+		}
+		sh.Status, sh.PackedAt, sh.UpdatedAt = ShipmentPacked, &now, now
+		if err := s.r.SaveShipment(sh); err != nil {
+			return nil, err
+		}
+	}
+	return shipments, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,65 @@
+package fulfillment
+
+import "time"
+
+// Statuses of a shipment.
+const (
+	ShipmentPending  = "pending"  // label not purchased yet
+	ShipmentLabelled = "labelled" // label purchased, ready to hand to the carrier
+	ShipmentPacked   = "packed"   // books scanned into the parcel, label stuck on
+)
+
+// Kinds of the fulfillment costs.
+const (
+	CostPostage = "postage"
+)
+
+// Shipment is the parcel of the books of an order shipped by the shop
+// itself, the print-on-demand ones being shipped by their provider.
+type Shipment struct {
+	ID             string  `json:"id"`
+	OrderID        string  `json:"order_id"`
+	Provider       string  `json:"provider"` // label provider the label is bought from
+	Reference      string  `json:"reference,omitempty"`
+	Status         string  `json:"status"`
+	Items          int     `json:"items"`
+	Carrier        string  `json:"carrier,omitempty"`
+	Service        string  `json:"service,omitempty"`
+	TrackingNumber string  `json:"tracking_number,omitempty"`
+	Postage        float64 `json:"postage,omitempty"`
+	Currency       string  `json:"currency,omitempty"`
+	// Label is the PDF bought, kept for the reprints.
+	Label     []byte     `json:"-"`
+	Prints    int        `json:"prints"`
+	PrintedAt *time.Time `json:"printed_at,omitempty"`
+	PackedAt  *time.Time `json:"packed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// Cost is an entry of the fulfillment cost ledger of an order, e.g. the
+// postage of a shipment.
+type Cost struct {
+	ID         string    `json:"id"`
+	OrderID    string    `json:"order_id"`
+	ShipmentID string    `json:"shipment_id,omitempty"`
+	Kind       string    `json:"kind"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Cost) TableName() string {
+	return "fulfillment_costs"
+}
+
+// File is a printable document.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,143 @@
+package fulfillment_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+)
+
+// repo keeps the shipments and the costs in memory.
+type repo struct {
+	fulfillment.Repo
+	shipments []fulfillment.Shipment
+	costs     []fulfillment.Cost
+}
+
+func (r *repo) ListByOrder(orderID string) ([]fulfillment.Job, error) {
+	return nil, nil
+}
+
+func (r *repo) CreateShipment(sh *fulfillment.Shipment) error {
+	sh.ID = "s1"
+	r.shipments = append(r.shipments, *sh)
+	return nil
+}
+
+func (r *repo) SaveShipment(sh *fulfillment.Shipment) error {
+	for i := range r.shipments {
+		if r.shipments[i].ID == sh.ID {
+			r.shipments[i] = *sh
+		}
+	}
+	return nil
+}
+
+func (r *repo) GetShipment(ID string) (fulfillment.Shipment, error) {
+	for _, sh := range r.shipments {
+		if sh.ID == ID {
+			return sh, nil
+		}
+	}
+	return fulfillment.Shipment{}, db.ErrNotFound
+}
+
+func (r *repo) ListShipments(orderID string) ([]fulfillment.Shipment, error) {
+	return r.shipments, nil
+}
+
+func (r *repo) SaveLabel(sh *fulfillment.Shipment, postage *fulfillment.Cost) error {
+	r.SaveShipment(sh)
+	r.costs = append(r.costs, *postage)
+	return nil
+}
+
+type orderRepo struct {
+	order.Repo
+	o order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	return r.o, nil
+}
+
+type addressRepo struct {
+	address.Repo
+	c address.Check
+}
+
+func (r addressRepo) GetCheck(orderID string) (address.Check, error) {
+	return r.c, nil
+}
+
+// paid is the payment time of the paid orders.
+var paid = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+// labelProvider fails the first purchase.
+type labelProvider struct {
+	requests []fulfillment.LabelRequest
+}
+
+func (p *labelProvider) Name() string {
+	return "test"
+}
+
+func (p *labelProvider) Buy(ctx context.Context, r fulfillment.LabelRequest) (fulfillment.Label, error) {
+	p.requests = append(p.requests, r)
+	if len(p.requests) == 1 {
+		return fulfillment.Label{}, errors.New("timeout")
+	}
+	return fulfillment.Label{Reference: "l1", Carrier: "dhl", TrackingNumber: "T1", Postage: 4.904, Currency: "EUR", PDF: []byte("%PDF")}, nil
+}
+
+func TestShipmentLabel(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{}
+	orders := orderRepo{o: order.Order{ID: "o1", Items: []catalog.Book{{ID: "b1"}, {ID: "b2"}}, PaidAt: &paid}}
+	addresses := addressRepo{c: address.Check{OrderID: "o1", Address: address.Address{Line1: "1 Main St", City: "Berlin", Country: "DE"}, Deliverable: true}}
+	labels := &labelProvider{}
+	s := fulfillment.NewService(r, orders, addresses, nil, labels, nil)
+
+	if _, err := s.Submit(ctx, "o1"); err == nil {
+		t.Fatal("expected the failed purchase to fail the submit")
+	}
+	if _, err := s.ReprintLabel(ctx, "s1"); err != fulfillment.ErrNoLabel {
+		t.Errorf("pending shipment: expected ErrNoLabel, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.Submit(ctx, "o1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(labels.requests) != 2 || labels.requests[1].ShipmentID != "s1" || labels.requests[1].Items != 2 {
+		t.Errorf("expected the purchase retried once for the same shipment, got %+v", labels.requests)
+	}
+	if len(r.costs) != 1 || r.costs[0].Kind != fulfillment.CostPostage || r.costs[0].Amount != 4.9 || r.costs[0].ShipmentID != "s1" {
+		t.Errorf("expected the postage in the ledger once, got %+v", r.costs)
+	}
+
+	f, err := s.ReprintLabel(ctx, "s1")
+	if err != nil || string(f.Data) != "%PDF" {
+		t.Fatalf("expected the purchased label, got %q, %v", f.Data, err)
+	}
+	if sh := r.shipments[0]; sh.Prints != 1 || sh.PrintedAt == nil || sh.TrackingNumber != "T1" {
+		t.Errorf("expected the reprint counted, got %+v", sh)
+	}
+	if _, err := s.ReprintLabel(ctx, "s2"); err != fulfillment.ErrShipmentNotFound {
+		t.Errorf("expected ErrShipmentNotFound, got %v", err)
+	}
+}
+
+func TestShipmentUndeliverable(t *testing.T) {
+	orders := orderRepo{o: order.Order{ID: "o1", Items: []catalog.Book{{ID: "b1"}}, PaidAt: &paid}}
+	s := fulfillment.NewService(&repo{}, orders, addressRepo{c: address.Check{OrderID: "o1"}}, nil, &labelProvider{}, nil)
+	if _, err := s.Submit(context.Background(), "o1"); err != fulfillment.ErrUndeliverable {
+		t.Errorf("expected ErrUndeliverable, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package fulfillment
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrShipmentNotFound: "fulfillment.shipment_not_found",
+		ErrNoLabel:          "fulfillment.no_label",
+		ErrUndeliverable:    "fulfillment.undeliverable",
+		ErrUnpaid:           "fulfillment.unpaid",
+	})
+}
+
+// maxWebhookSize limits the webhook body read into memory.
+const maxWebhookSize = 1 << 20
+
+// MakeHTTPHandler mounts the fulfillment endpoints, the manual resubmit of
+// the paid orders and their jobs served to the requests admin lets
+// through, e.g. auth.NewMiddleware chained with rbac.RequireRole. The
+// orders are submitted on their payment.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	submitHandler := httptransport.NewServer(
+		e.SubmitEndpoint,
+		decodeJobsRequest,
+		encodeResponse,
+		options...,
+	)
+	jobsHandler := httptransport.NewServer(
+		e.JobsEndpoint,
+		decodeJobsRequest,
+		encodeResponse,
+		options...,
+	)
+	webhookHandler := httptransport.NewServer(
+		e.WebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+	shipmentsHandler := httptransport.NewServer(
+		e.ShipmentsEndpoint,
+		decodeJobsRequest,
+		encodeResponse,
+		options...,
+	)
+	reprintLabelHandler := httptransport.NewServer(
+		e.ReprintLabelEndpoint,
+		decodeShipmentRequest,
+		encodeFile,
+		options...,
+	)
+	costsHandler := httptransport.NewServer(
+		e.CostsEndpoint,
+		decodeJobsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/fulfillment/v1/webhook", webhookHandler).Methods("POST")
+	r.Handle("/fulfillment/v1/orders/{order-id}/shipments", shipmentsHandler).Methods("GET")
+	r.Handle("/fulfillment/v1/orders/{order-id}/costs", costsHandler).Methods("GET")
+	// Reprints the purchased label, the label is bought at submit.
+	r.Handle("/fulfillment/v1/shipments/{shipment-id}/label", reprintLabelHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/fulfillment/v1/admin/orders/{order-id}/jobs", jobsHandler).Methods("GET")
+	r.Handle("/fulfillment/v1/admin/orders/{order-id}/submit", submitHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeJobsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	orderID, ok := vars["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return jobsRequest{
+		OrderID: orderID,
+	}, nil
+}
+
+func decodeShipmentRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	shipmentID, ok := mux.Vars(req)["shipment-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "shipment-id")
+	}
+	return shipmentRequest{ShipmentID: shipmentID}, nil
+}
+
+func decodeWebhookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxWebhookSize))
+	if err != nil {
+		return nil, err
+	}
+	return webhookRequest{
+		Signature: req.Header.Get("X-Signature"),
+		Body:      body,
+	}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+// encodeFile writes the label inline, with its name for saving.
+func encodeFile(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(fileResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `inline; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound, ErrJobNotFound, ErrShipmentNotFound:
+		return http.StatusNotFound
+	case ErrNoLabel, ErrUndeliverable, ErrUnpaid, address.ErrCheckNotFound, customs.ErrEmbargoed:
+		return http.StatusConflict
+	case ErrInvalidSignature:
+		return http.StatusUnauthorized
+	case ErrBadRouting:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
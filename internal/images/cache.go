@@ -0,0 +1,60 @@
+package images
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache keeps the rendered images up to max bytes, evicting the least
+// recently used ones.
+type cache struct {
+	mu    sync.Mutex
+	max   int
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key string
+	img Image
+}
+
+func newCache(max int) *cache {
+	return &cache{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *cache) get(key string) (Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Image{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).img, true
+}
+
+// put adds the image, unless it alone is larger than the cache.
+func (c *cache) put(key string, img Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(img.Content) > c.max {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		c.size -= len(el.Value.(*entry).img.Content)
+		c.order.Remove(el)
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, img: img})
+	c.size += len(img.Content)
+	for c.size > c.max {
+		el := c.order.Back()
+		e := el.Value.(*entry)
+		c.order.Remove(el)
+		delete(c.items, e.key)
+		c.size -= len(e.img.Content)
+	}
+}
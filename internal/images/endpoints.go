@@ -0,0 +1,45 @@
+package images
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the images service endpoints under single type.
+type Endpoints struct {
+	VariantsEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the images service endpoints.
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		VariantsEndpoint: MakeVariantsEndpoint(s),
+	}
+}
+
+func MakeVariantsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(variantsRequest)
+		list, e := s.Variants(ctx, req.Kind, req.ID)
+		if e != nil {
+			return variantsResponse{Variants: make([]Variant, 0), Error: e}, nil
+		}
+		return variantsResponse{Variants: list}, nil
+	}
+}
+
+type variantsRequest struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+type variantsResponse struct {
+	Variants []Variant `json:"variants"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r variantsResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,210 @@
+package images
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"net/url"
+	"strconv"
+
+	// The decoders of the source images.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrInvalidSignature = errors.New("image URL signature is invalid")
+	ErrInvalidImage     = errors.New("source image is not a JPEG, PNG or GIF image")
+	ErrSourceTooLarge   = errors.New("source image is too large")
+)
+
+// Kinds of the images served.
+const (
+	KindCover  = "cover"  // the cover of a book, by book ID
+	KindAvatar = "avatar" // the avatar of a user, by user ID
+)
+
+// Fits of an image to the width and height asked for.
+const (
+	FitCover   = "cover"   // cropped to the center to fill the width and height
+	FitContain = "contain" // scaled down to fit in the width and height, the aspect kept
+)
+
+// Limits of the images.
+const (
+	MaxSide         = 2000     // pixels of the width or height asked for
+	MaxSourceSize   = 10 << 20 // bytes of a source image
+	MaxSourcePixels = 40000000 // pixels of a source image
+)
+
+// quality is the JPEG quality the images are served at.
+const quality = 85
+
+// Options are the width, height and fit asked for. Only one of the width
+// and the height may be set, the other one following the aspect of the
+// source.
+type Options struct {
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Fit    string `json:"fit"`
+}
+
+// Validate checks the options are within MaxSide.
+func (o Options) Validate() error {
+	var v validate.Validator
+	v.Range("w", o.Width, 0, MaxSide)
+	v.Range("h", o.Height, 0, MaxSide)
+	v.Check(o.Width > 0 || o.Height > 0, "w", validate.CodeRequired, "w or h is required")
+	v.Check(o.Fit == FitCover || o.Fit == FitContain, "fit", validate.CodeInvalid, "fit must be cover or contain")
+	return v.Err()
+}
+
+// query returns the options and the version of the source as the query of
+// the image URL, without the signature.
+func (o Options) query(version string) url.Values {
+	q := url.Values{}
+	if o.Width > 0 {
+		q.Set("w", strconv.Itoa(o.Width))
+	}
+	if o.Height > 0 {
+		q.Set("h", strconv.Itoa(o.Height))
+	}
+	q.Set("fit", o.Fit)
+	q.Set("v", version)
+	return q
+}
+
+// Preset is a standard size the clients are given the URLs of.
+type Preset struct {
+	Name string `json:"name"`
+	Options
+}
+
+// Presets are the standard sizes of the images of each kind. The covers
+// are never cropped, the avatars are the squares of the uploads.
+var Presets = map[string][]Preset{
+	KindCover: {
+		{"thumbnail", Options{Width: 120, Height: 180, Fit: FitContain}},
+		{"medium", Options{Width: 300, Height: 450, Fit: FitContain}},
+		{"large", Options{Width: 600, Height: 900, Fit: FitContain}},
+	},
+	KindAvatar: {
+		{"small", Options{Width: 64, Height: 64, Fit: FitCover}},
+		{"medium", Options{Width: 128, Height: 128, Fit: FitCover}},
+		{"large", Options{Width: 256, Height: 256, Fit: FitCover}},
+	},
+}
+
+// Version returns the version of the source URL, which changes with the
+// source and so the URLs of an image. Their responses are then cached for
+// good.
+func Version(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Sign returns the signature of the URL of the image of kind and id, so
+// that only the URLs the shop hands out are rendered.
+func Sign(secret []byte, kind, id, version string, o Options) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(kind + "/" + id + "?" + o.query(version).Encode()))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Path returns the signed path and query of the image of kind and id.
+func Path(secret []byte, kind, id, version string, o Options) string {
+	q := o.query(version)
+	q.Set("sig", Sign(secret, kind, id, version, o))
+	return "/images/v1/" + url.PathEscape(kind) + "/" + url.PathEscape(id) + "?" + q.Encode()
+}
+
+// decode decodes the source image, checking its dimensions before decoding
+// its pixels, so that a small file can't claim a huge image.
+func decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > MaxSourcePixels {
+		return nil, ErrSourceTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// Resize fits img to the options, each pixel averaging the pixels of the
+// source it covers, the transparent pixels laid over white as JPEG has no
+// transparency.
+func Resize(img image.Image, o Options) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	w, h := o.Width, o.Height
+	switch {
+	case w == 0:
+		w = atLeast1(h * sw / sh)
+	case h == 0:
+		h = atLeast1(w * sh / sw)
+	}
+
+	// The region of the source to scale to w by h.
+	x0, y0, cw, ch := b.Min.X, b.Min.Y, sw, sh
+	if o.Fit == FitCover {
+		if sw*h > sh*w {
+			cw = atLeast1(sh * w / h)
+		} else {
+			ch = atLeast1(sw * h / w)
+		}
+		x0, y0 = x0+(sw-cw)/2, y0+(sh-ch)/2
+	} else if sw*h > sh*w {
+		h = atLeast1(w * sh / sw)
+	} else {
+		w = atLeast1(h * sw / sh)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy0, sy1 := span(y, ch, h)
+		for x := 0; x < w; x++ {
+			sx0, sx1 := span(x, cw, w)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(x0+sx, y0+sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			// The colors are alpha premultiplied, adding the missing
+			// alpha lays them over white.
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{R: uint16(r/n + white), G: uint16(g/n + white), B: uint16(bl/n + white), A: 0xffff})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) the pixel i of size covers in
+// side, at least one when enlarging.
+func span(i, side, size int) (from, to int) {
+	from, to = i*side/size, (i+1)*side/size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+func atLeast1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
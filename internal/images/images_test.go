@@ -0,0 +1,145 @@
+package images_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/images"
+)
+
+// catalogRepo keeps the books in memory.
+type catalogRepo struct {
+	catalog.Repo
+	books map[string]catalog.Book
+}
+
+func (r *catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	b, ok := r.books[ID]
+	if !ok {
+		return catalog.Book{}, db.ErrNotFound
+	}
+	return b, nil
+}
+
+// cover returns a 200x300 PNG, red above a transparent bottom half.
+func cover(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 300))
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 200; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 200, 300))
+	for _, c := range []struct {
+		o    images.Options
+		w, h int
+	}{
+		{images.Options{Width: 100, Height: 100, Fit: images.FitCover}, 100, 100},
+		{images.Options{Width: 100, Height: 100, Fit: images.FitContain}, 66, 100},
+		{images.Options{Width: 100, Fit: images.FitContain}, 100, 150},
+		{images.Options{Height: 600, Fit: images.FitCover}, 400, 600},
+	} {
+		if b := images.Resize(src, c.o).Bounds(); b.Dx() != c.w || b.Dy() != c.h {
+			t.Errorf("%+v: expected %dx%d, got %v", c.o, c.w, c.h, b)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	ctx := context.Background()
+	fetched := 0
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		w.Write(cover(t))
+	}))
+	defer origin.Close()
+
+	books := &catalogRepo{books: map[string]catalog.Book{
+		"b1": {ID: "b1", CoverURL: "/covers/b1.png"},
+		"b2": {ID: "b2"},
+	}}
+	s := images.NewService(books, inmem.NewUserRepo(), images.Config{Secret: []byte("secret"), BaseURL: "https://cdn.example.com/", StoreURL: origin.URL})
+
+	if _, err := s.Variants(ctx, images.KindCover, "b2"); err != images.ErrImageNotFound {
+		t.Errorf("book without a cover: expected ErrImageNotFound, got %v", err)
+	}
+	if _, err := s.Variants(ctx, images.KindAvatar, "u1"); err != images.ErrImageNotFound {
+		t.Errorf("unknown user: expected ErrImageNotFound, got %v", err)
+	}
+	variants, err := s.Variants(ctx, images.KindCover, "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 3 || !strings.HasPrefix(variants[0].URL, "https://cdn.example.com/images/v1/cover/b1?") {
+		t.Fatalf("expected the signed URLs of the cover presets, got %+v", variants)
+	}
+
+	h := images.MakeHTTPHandler(ctx, s, nil)
+	get := func(u, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", strings.TrimPrefix(u, "https://cdn.example.com"), nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(variants[0].URL, "")
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Fatalf("expected the thumbnail cached for good, got %d %v", w.Code, w.Header())
+	}
+	img, err := jpeg.Decode(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 180 {
+		t.Errorf("expected a 120x180 thumbnail, got %v", b)
+	}
+	if r, g, _, _ := img.At(60, 170).RGBA(); r>>8 < 230 || g>>8 < 230 {
+		t.Errorf("expected the transparent half white, got %v", img.At(60, 170))
+	}
+	if w := get(variants[0].URL, w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("same ETag: expected 304, got %d", w.Code)
+	}
+	if fetched != 1 {
+		t.Errorf("expected the source fetched once, got %d", fetched)
+	}
+
+	u, _ := url.Parse(variants[0].URL)
+	q := u.Query()
+	q.Set("w", "2000")
+	u.RawQuery = q.Encode()
+	if w := get(u.String(), ""); w.Code != http.StatusForbidden {
+		t.Errorf("changed width: expected 403, got %d", w.Code)
+	}
+
+	// The cover changes, the URLs handed out before are stale.
+	books.books["b1"] = catalog.Book{ID: "b1", CoverURL: origin.URL + "/covers/b1-v2.png"}
+	if w := get(variants[0].URL, ""); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Errorf("stale URL: expected the new cover cached shortly, got %d %v", w.Code, w.Header())
+	}
+	if fetched != 2 {
+		t.Errorf("expected the new cover fetched, got %d fetches", fetched)
+	}
+}
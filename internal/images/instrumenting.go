@@ -0,0 +1,48 @@
+package images
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Variants(ctx context.Context, kind, id string) (list []Variant, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "variants", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Variants(ctx, kind, id)
+	return
+}
+
+func (mw instrmw) Render(ctx context.Context, r Request) (img Image, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "render", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	img, err = mw.next.Render(ctx, r)
+	return
+}
@@ -0,0 +1,52 @@
+package images
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Variants(ctx context.Context, kind, id string) (list []Variant, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "variants",
+			"kind", kind,
+			"id", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Variants(ctx, kind, id)
+}
+
+func (s loggingService) Render(ctx context.Context, r Request) (img Image, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "render",
+			"kind", r.Kind,
+			"id", r.ID,
+			"width", r.Width,
+			"height", r.Height,
+			"fit", r.Fit,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Render(ctx, r)
+}
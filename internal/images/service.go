@@ -0,0 +1,203 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrUnknownKind       = errors.New("unknown image kind")
+	ErrImageNotFound     = errors.New("image not found")
+	ErrSourceUnavailable = errors.New("source image unavailable")
+)
+
+// DefaultCacheSize is the bytes of rendered images kept in memory.
+const DefaultCacheSize = 64 << 20
+
+type Service interface {
+	// Variants returns the signed URLs of the presets of the image of kind
+	// and id.
+	Variants(ctx context.Context, kind, id string) ([]Variant, error)
+
+	// Render returns the image of the signed request resized to its
+	// options, fetching the source the first time.
+	Render(ctx context.Context, r Request) (Image, error)
+}
+
+// Variant is the signed URL of a preset of an image.
+type Variant struct {
+	Preset
+	URL string `json:"url"`
+}
+
+// Request is a signed request for an image.
+type Request struct {
+	Kind      string
+	ID        string
+	Version   string
+	Signature string
+	Options
+}
+
+// Image is a rendered image. Stale tells the request was for a previous
+// version of the source, the image is the one of the current version.
+type Image struct {
+	Content     []byte
+	ContentType string
+	ETag        string
+	Stale       bool
+}
+
+// Config controls the images served.
+type Config struct {
+	// Secret signs the image URLs.
+	Secret []byte
+
+	// BaseURL is the public URL the images are served from, e.g. the CDN
+	// in front of the shop.
+	BaseURL string
+
+	// StoreURL resolves the relative URLs of the covers.
+	StoreURL string
+
+	// Client fetches the source images, a client of a 10 seconds timeout
+	// if nil.
+	Client *http.Client
+
+	// CacheSize is the bytes of rendered images kept in memory,
+	// DefaultCacheSize if 0.
+	CacheSize int
+}
+
+type service struct {
+	books catalog.Repo
+	users user.Repo
+	cfg   Config
+	cache *cache
+}
+
+// NewService return basic Service implementation.
+func NewService(books catalog.Repo, users user.Repo, c Config) Service {
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if c.CacheSize == 0 {
+		c.CacheSize = DefaultCacheSize
+	}
+	c.BaseURL = strings.TrimRight(c.BaseURL, "/")
+	return service{books: books, users: users, cfg: c, cache: newCache(c.CacheSize)}
+}
+
+func (s service) Variants(_ context.Context, kind, id string) ([]Variant, error) {
+	src, err := s.source(kind, id)
+	if err != nil {
+		return nil, err
+	}
+	v := Version(src)
+	var list []Variant
+	for _, p := range Presets[kind] {
+		list = append(list, Variant{Preset: p, URL: s.cfg.BaseURL + Path(s.cfg.Secret, kind, id, v, p.Options)})
+	}
+	return list, nil
+}
+
+func (s service) Render(ctx context.Context, r Request) (Image, error) {
+	want := Sign(s.cfg.Secret, r.Kind, r.ID, r.Version, r.Options)
+	if !hmac.Equal([]byte(r.Signature), []byte(want)) {
+		return Image{}, ErrInvalidSignature
+	}
+	if err := r.Options.Validate(); err != nil {
+		return Image{}, err
+	}
+	src, err := s.source(r.Kind, r.ID)
+	if err != nil {
+		return Image{}, err
+	}
+	key := fmt.Sprintf("%s|%d|%d|%s", src, r.Width, r.Height, r.Fit)
+	img, ok := s.cache.get(key)
+	if !ok {
+		if img, err = s.render(ctx, src, r.Options); err != nil {
+			return Image{}, err
+		}
+		s.cache.put(key, img)
+	}
+	img.Stale = r.Version != Version(src)
+	return img, nil
+}
+
+// source returns the URL of the source image of kind and id.
+func (s service) source(kind, id string) (string, error) {
+	var src string
+	switch kind {
+	case KindCover:
+		b, err := s.books.GetByID(id)
+		if err != nil {
+			return "", ErrImageNotFound
+		}
+		src = b.CoverURL
+		if src != "" && !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+			src = strings.TrimRight(s.cfg.StoreURL, "/") + "/" + strings.TrimLeft(src, "/")
+		}
+	case KindAvatar:
+		u, err := s.users.GetByID(id)
+		if err != nil || u.DeletedAt != nil {
+			return "", ErrImageNotFound
+		}
+		src = u.Avatar
+	default:
+		return "", ErrUnknownKind
+	}
+	if src == "" {
+		return "", ErrImageNotFound
+	}
+	return src, nil
+}
+
+func (s service) render(ctx context.Context, src string, o Options) (Image, error) {
+	req, err := http.NewRequest("GET", src, nil)
+	if err != nil {
+		return Image{}, ErrSourceUnavailable
+	}
+	resp, err := s.cfg.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return Image{}, ErrSourceUnavailable
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Image{}, ErrSourceUnavailable
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxSourceSize+1))
+	if err != nil {
+		return Image{}, ErrSourceUnavailable
+	}
+	if len(data) > MaxSourceSize {
+		return Image{}, ErrSourceTooLarge
+	}
+	img, err := decode(data)
+	if err != nil {
+		return Image{}, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Resize(img, o), &jpeg.Options{Quality: quality}); err != nil {
+		return Image{}, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return Image{Content: buf.Bytes(), ContentType: "image/jpeg", ETag: `"` + hex.EncodeToString(sum[:8]) + `"`}, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,182 @@
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// Cache-Control of the images. The URLs change with the source, so the
+// responses are cached for good but the ones of a stale version.
+const (
+	cacheImmutable = "public, max-age=31536000, immutable"
+	cacheStale     = "public, max-age=300"
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrInvalidSignature:  "images.invalid_signature",
+		ErrInvalidImage:      "images.invalid_image",
+		ErrSourceTooLarge:    "images.source_too_large",
+		ErrUnknownKind:       "images.unknown_kind",
+		ErrImageNotFound:     "images.image_not_found",
+		ErrSourceUnavailable: "images.source_unavailable",
+	})
+}
+
+func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	variantsHandler := httptransport.NewServer(
+		e.VariantsEndpoint,
+		decodeVariantsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/images/v1/{kind}/{id}", renderHandler{s}).Methods("GET", "HEAD")
+	r.Handle("/images/v1/{kind}/{id}/variants", variantsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// renderHandler serves the image directly instead of going through go-kit
+// transport, since the response is not json.
+type renderHandler struct {
+	s Service
+}
+
+func (h renderHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := i18n.PopulateLocale(req.Context(), req)
+	r, err := decodeRenderRequest(req)
+	if err != nil {
+		encodeError(ctx, err, w)
+		return
+	}
+	img, err := h.s.Render(ctx, r)
+	if err != nil {
+		encodeError(ctx, err, w)
+		return
+	}
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("ETag", img.ETag)
+	if img.Stale {
+		w.Header().Set("Cache-Control", cacheStale)
+	} else {
+		w.Header().Set("Cache-Control", cacheImmutable)
+	}
+	// ServeContent answers the If-None-Match requests of the ETag.
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(img.Content))
+}
+
+func decodeRenderRequest(req *http.Request) (Request, error) {
+	vars := mux.Vars(req)
+	for _, v := range []string{"kind", "id"} {
+		if _, ok := vars[v]; !ok {
+			return Request{}, errors.Wrap(ErrBadRouting, v)
+		}
+	}
+	q := req.URL.Query()
+	r := Request{Kind: vars["kind"], ID: vars["id"], Version: q.Get("v"), Signature: q.Get("sig")}
+	r.Fit = q.Get("fit")
+
+	var v validate.Validator
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"w", &r.Width}, {"h", &r.Height}} {
+		if s := q.Get(p.name); s != "" {
+			n, err := strconv.Atoi(s)
+			v.Check(err == nil, p.name, validate.CodeType, p.name+" must be an integer")
+			*p.value = n
+		}
+	}
+	return r, v.Err()
+}
+
+func decodeVariantsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	for _, v := range []string{"kind", "id"} {
+		if _, ok := vars[v]; !ok {
+			return nil, errors.Wrap(ErrBadRouting, v)
+		}
+	}
+	return variantsRequest{Kind: vars["kind"], ID: vars["id"]}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrImageNotFound, ErrUnknownKind:
+		return http.StatusNotFound
+	case ErrInvalidSignature:
+		return http.StatusForbidden
+	case ErrBadRouting, validate.ErrInvalid:
+		return http.StatusBadRequest
+	case ErrInvalidImage, ErrSourceTooLarge, ErrSourceUnavailable:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,94 @@
+package labels
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the labels service endpoints under single type.
+type Endpoints struct {
+	BookQREndpoint              endpoint.Endpoint
+	ShelfLabelEndpoint          endpoint.Endpoint
+	PackingSlipEndpoint         endpoint.Endpoint
+	PurchaseOrderLabelsEndpoint endpoint.Endpoint
+	PickListEndpoint            endpoint.Endpoint
+	PackingSlipsEndpoint        endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the labels service endpoints, restricted by staff, e.g. to the
+// shop staff.
+func MakeEndpoints(s Service, staff endpoint.Middleware) Endpoints {
+	return Endpoints{
+		BookQREndpoint:              staff(MakeBookQREndpoint(s)),
+		ShelfLabelEndpoint:          staff(MakeShelfLabelEndpoint(s)),
+		PackingSlipEndpoint:         staff(MakePackingSlipEndpoint(s)),
+		PurchaseOrderLabelsEndpoint: staff(MakePurchaseOrderLabelsEndpoint(s)),
+		PickListEndpoint:            staff(MakePickListEndpoint(s)),
+		PackingSlipsEndpoint:        staff(MakePackingSlipsEndpoint(s)),
+	}
+}
+
+func MakeBookQREndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(bookQRRequest)
+		f, e := s.BookQR(ctx, req.BookID, req.Format, req.Scale)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakeShelfLabelEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.ShelfLabel(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakePackingSlipEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.PackingSlip(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakePurchaseOrderLabelsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.PurchaseOrderLabels(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakePickListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.PickListPDF(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakePackingSlipsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		f, e := s.PackingSlips(ctx, req.ID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+type bookQRRequest struct {
+	BookID string
+	Format string
+	Scale  int
+}
+
+type idRequest struct {
+	ID string
+}
+
+type fileResponse struct {
+	File
+	Error error
+}
@@ -0,0 +1,92 @@
+package labels
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) BookQR(ctx context.Context, bookID, format string, scale int) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "book_qr", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.BookQR(ctx, bookID, format, scale)
+	return
+}
+
+func (mw instrmw) ShelfLabel(ctx context.Context, bookID string) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "shelf_label", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.ShelfLabel(ctx, bookID)
+	return
+}
+
+func (mw instrmw) PackingSlip(ctx context.Context, orderID string) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "packing_slip", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.PackingSlip(ctx, orderID)
+	return
+}
+
+func (mw instrmw) PurchaseOrderLabels(ctx context.Context, poID string) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "purchase_order_labels", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.PurchaseOrderLabels(ctx, poID)
+	return
+}
+
+func (mw instrmw) PickListPDF(ctx context.Context, listID string) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "pick_list_pdf", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.PickListPDF(ctx, listID)
+	return
+}
+
+func (mw instrmw) PackingSlips(ctx context.Context, listID string) (file File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "packing_slips", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	file, err = mw.next.PackingSlips(ctx, listID)
+	return
+}
@@ -0,0 +1,63 @@
+package labels_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/labels"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// catalogRepo has no books.
+type catalogRepo struct {
+	catalog.Repo
+}
+
+func (catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	return catalog.Book{}, db.ErrNotFound
+}
+
+func TestStaffRoutes(t *testing.T) {
+	s := labels.NewService(catalogRepo{}, nil, nil, nil, labels.Config{StoreURL: "https://shop.example"})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	staff := rbac.Staff(tokens, user.RoleAdmin, user.RoleSupport)
+	h := labels.MakeHTTPHandler(context.Background(), s, staff, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	support, _, _ := tokens.Sign("u8", user.RoleSupport, "")
+	admin, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, path, token string
+		status            int
+	}{
+		{"qr without token", "/labels/v1/books/b9/qr", "", http.StatusUnauthorized},
+		{"qr by customer", "/labels/v1/books/b9/qr", customer, http.StatusForbidden},
+		{"shelf label by customer", "/labels/v1/books/b9/shelf-label", customer, http.StatusForbidden},
+		{"packing slip by customer", "/labels/v1/orders/o1/packing-slip", customer, http.StatusForbidden},
+		{"purchase order by customer", "/labels/v1/purchase-orders/po1/labels", customer, http.StatusForbidden},
+		{"pick list by customer", "/labels/v1/pick-lists/l1", customer, http.StatusForbidden},
+		{"packing slips by customer", "/labels/v1/pick-lists/l1/packing-slips", customer, http.StatusForbidden},
+		{"qr by scoped token", "/labels/v1/books/b9/qr", scoped, http.StatusForbidden},
+		{"qr by support", "/labels/v1/books/b9/qr", support, http.StatusNotFound},
+		{"shelf label by admin", "/labels/v1/books/b9/shelf-label", admin, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package labels
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) BookQR(ctx context.Context, bookID, format string, scale int) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "book_qr",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.BookQR(ctx, bookID, format, scale)
+}
+
+func (s loggingService) ShelfLabel(ctx context.Context, bookID string) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "shelf_label",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ShelfLabel(ctx, bookID)
+}
+
+func (s loggingService) PackingSlip(ctx context.Context, orderID string) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "packing_slip",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PackingSlip(ctx, orderID)
+}
+
+func (s loggingService) PurchaseOrderLabels(ctx context.Context, poID string) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "purchase_order_labels",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PurchaseOrderLabels(ctx, poID)
+}
+
+func (s loggingService) PickListPDF(ctx context.Context, listID string) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "pick_list_pdf",
+			"list", listID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PickListPDF(ctx, listID)
+}
+
+func (s loggingService) PackingSlips(ctx context.Context, listID string) (file File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "packing_slips",
+			"list", listID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PackingSlips(ctx, listID)
+}
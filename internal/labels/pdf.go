@@ -0,0 +1,35 @@
+package labels
+
+import (
+	"github.com/kavirajk/bookshop/labels/qr"
+	"github.com/kavirajk/bookshop/pdf"
+)
+
+// Page sizes in points.
+const (
+	mm = pdf.MM
+
+	labelWidth  = 62 * mm // common thermal label roll
+	labelHeight = 40 * mm
+	a4Width     = pdf.A4Width
+	a4Height    = pdf.A4Height
+)
+
+// drawQR draws c, without its quiet zone, side points wide at x, y.
+func drawQR(p *pdf.Page, x, y, side float64, c *qr.Code) {
+	m := side / float64(c.Size)
+	for my := 0; my < c.Size; my++ {
+		for mx := 0; mx < c.Size; {
+			if !c.Dark(mx, my) {
+				mx++
+				continue
+			}
+			// Merge runs of dark modules into one rectangle.
+			start := mx
+			for mx < c.Size && c.Dark(mx, my) {
+				mx++
+			}
+			p.Rect(x+float64(start)*m, y+float64(my)*m, float64(mx-start)*m, m)
+		}
+	}
+}
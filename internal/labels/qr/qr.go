@@ -0,0 +1,442 @@
+// Package qr encodes QR codes in byte mode at error correction level M,
+// versions 1 to 10, which fits up to 213 bytes. It's enough for the links
+// and IDs printed on labels, without pulling an imaging dependency.
+package qr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+var (
+	ErrTooLong = errors.New("qr: data too long")
+)
+
+// QuietZone is the number of light modules required around a code.
+const QuietZone = 4
+
+// Code is an encoded QR code.
+type Code struct {
+	Size    int // modules per side
+	Version int
+	modules [][]bool
+	fn      [][]bool // function modules untouched by data and masks
+}
+
+// Dark tells if the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// block structure per version at level M: EC codewords per block, then
+// blocks and data codewords of the two groups.
+var blocks = [...]struct {
+	ec, n1, d1, n2, d2 int
+}{
+	1:  {10, 1, 16, 0, 0},
+	2:  {16, 1, 28, 0, 0},
+	3:  {26, 1, 44, 0, 0},
+	4:  {18, 2, 32, 0, 0},
+	5:  {24, 2, 43, 0, 0},
+	6:  {16, 4, 27, 0, 0},
+	7:  {18, 4, 31, 0, 0},
+	8:  {22, 2, 38, 2, 39},
+	9:  {22, 3, 36, 2, 37},
+	10: {26, 4, 43, 1, 44},
+}
+
+var alignment = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+const maxVersion = 10
+
+func dataCapacity(version int) int {
+	b := blocks[version]
+	return b.n1*b.d1 + b.n2*b.d2
+}
+
+// Encode returns the smallest QR code holding data.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*dataCapacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(interleave(version, codewords(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // XOR again to undo
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+func newCode(version int) *Code {
+	size := version*4 + 17
+	c := &Code{Size: size, Version: version}
+	c.modules = make([][]bool, size)
+	c.fn = make([][]bool, size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.fn[i] = make([]bool, size)
+	}
+	return c
+}
+
+// codewords returns the data codewords of data in byte mode, terminated
+// and padded to the capacity of version.
+func codewords(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * dataCapacity(version)
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// interleave splits data in the blocks of version, computes their error
+// correction codewords and interleaves them all.
+func interleave(version int, data []byte) []byte {
+	b := blocks[version]
+	var dataBlocks, ecBlocks [][]byte
+	divisor := rsDivisor(b.ec)
+	for i := 0; i < b.n1+b.n2; i++ {
+		n := b.d1
+		if i >= b.n1 {
+			n = b.d2
+		}
+		dataBlocks = append(dataBlocks, data[:n])
+		ecBlocks = append(ecBlocks, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+	var out []byte
+	for i := 0; i < b.d1 || i < b.d2; i++ {
+		for _, d := range dataBlocks {
+			if i < len(d) {
+				out = append(out, d[i])
+			}
+		}
+	}
+	for i := 0; i < b.ec; i++ {
+		for _, e := range ecBlocks {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.fn[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	if c.Version >= 2 {
+		pos := alignment[c.Version]
+		last := len(pos) - 1
+		for i := range pos {
+			for j := range pos {
+				if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+					continue
+				}
+				for dy := -2; dy <= 2; dy++ {
+					for dx := -2; dx <= 2; dx++ {
+						c.set(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+					}
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas before placing data.
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// formatBits returns the BCH protected format information of mask at
+// level M.
+func formatBits(mask int) int {
+	data := 0<<3 | mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// versionBits returns the BCH protected version information.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionBits(c.Version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 != 0
+		a, b := c.Size-11+i%3, i/3
+		c.set(a, b, dark)
+		c.set(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order, two columns at
+// a time from the bottom right, skipping the function modules.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if c.fn[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = (data[i>>3]>>uint(7-i&7))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.fn[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the masked code on the four rules of the spec, lower is
+// easier to scan.
+func (c *Code) penalty() int {
+	n := c.Size
+	p := 0
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= n; i++ {
+			if i < n && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				p += 3 + run - 5
+			}
+			run = 1
+		}
+		// Finder-like 1:1:3:1:1 with four light modules on either side.
+		for i := 0; i+7 <= n; i++ {
+			if !(get(i) && !get(i+1) && get(i+2) && get(i+3) && get(i+4) && !get(i+5) && get(i+6)) {
+				continue
+			}
+			before, after := true, true
+			for k := 1; k <= 4; k++ {
+				if i-k >= 0 && get(i-k) {
+					before = false
+				}
+				if i+6+k < n && get(i+6+k) {
+					after = false
+				}
+			}
+			if before || after {
+				p += 40
+			}
+		}
+	}
+	for y := 0; y < n; y++ {
+		line(func(i int) bool { return c.modules[y][i] })
+	}
+	for x := 0; x < n; x++ {
+		line(func(i int) bool { return c.modules[i][x] })
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			m := c.modules[y][x]
+			if m {
+				dark++
+			}
+			if x+1 < n && y+1 < n && m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+				p += 3
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	p += k * 10
+	return p
+}
+
+// Image renders the code with scale pixels per module and the quiet zone.
+func (c *Code) Image(scale int) *image.Gray {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			mx, my := x/scale-QuietZone, y/scale-QuietZone
+			v := color.Gray{Y: 0xFF}
+			if mx >= 0 && mx < c.Size && my >= 0 && my < c.Size && c.modules[my][mx] {
+				v = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, v)
+		}
+	}
+	return img
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (v>>uint(i))&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+	return out
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" at 1-M.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	format := []int{
+		0x5412, // 101010000010010
+		0x5125, // 101000100100101
+		0x5e7c, // 101111001111100
+		0x5b4b, // 101101101001011
+		0x45f9, // 100010111111001
+		0x40ce, // 100000011001110
+		0x4f97, // 100111110010111
+		0x4aa0, // 100101010100000
+	}
+	for mask, expected := range format {
+		if got := formatBits(mask); got != expected {
+			t.Errorf("format of mask %d: expected %015b, got %015b", mask, expected, got)
+		}
+	}
+	if got := versionBits(7); got != 0x07c94 {
+		t.Errorf("version 7: expected %018b, got %018b", 0x07c94, got)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		n       int
+		version int
+	}{
+		{14, 1},
+		{15, 2},
+		{100, 6},
+		{213, 10},
+	}
+	for _, c := range cases {
+		code, err := Encode(bytes.Repeat([]byte("a"), c.n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.Version != c.version || code.Size != c.version*4+17 {
+			t.Errorf("%d bytes: expected version %d, got %d", c.n, c.version, code.Version)
+		}
+		// Top left finder and timing patterns.
+		if !code.Dark(0, 0) || code.Dark(1, 1) || !code.Dark(3, 3) || code.Dark(7, 0) {
+			t.Errorf("%d bytes: bad finder pattern", c.n)
+		}
+		if !code.Dark(8, 6) || code.Dark(9, 6) || !code.Dark(8, code.Size-8) {
+			t.Errorf("%d bytes: bad timing pattern or dark module", c.n)
+		}
+	}
+	if _, err := Encode(make([]byte, 214)); err != ErrTooLong {
+		t.Errorf("expected %v, got %v", ErrTooLong, err)
+	}
+}
@@ -0,0 +1,356 @@
+package labels
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/labels/qr"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/pdf"
+	"github.com/kavirajk/bookshop/picking"
+	"github.com/kavirajk/bookshop/restock"
+)
+
+var (
+	ErrBadFormat      = errors.New("format must be png or pdf")
+	ErrNotReceived    = errors.New("purchase order is not received")
+	ErrTooManyLabels  = errors.New("too many labels in one batch")
+	ErrPONotFound     = errors.New("purchase order not found")
+	ErrNothingToPrint = errors.New("nothing to print")
+)
+
+// File formats.
+const (
+	FormatPNG = "png"
+	FormatPDF = "pdf"
+)
+
+// MaxBatchLabels caps the labels of a purchase order batch.
+const MaxBatchLabels = 2000
+
+// File is a generated printable document.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Config is the shop information printed on the labels.
+type Config struct {
+	StoreURL string // book QR codes link to StoreURL/books/{id}
+	Currency string
+}
+
+type Service interface {
+	// BookQR returns the QR code linking to the book page, as a PNG of
+	// scale pixels per module or a PDF.
+	BookQR(ctx context.Context, bookID, format string, scale int) (File, error)
+
+	// ShelfLabel returns the PDF shelf label of a book.
+	ShelfLabel(ctx context.Context, bookID string) (File, error)
+
+	// PackingSlip returns the PDF packing slip of an order.
+	PackingSlip(ctx context.Context, orderID string) (File, error)
+
+	// PurchaseOrderLabels returns a PDF with a shelf label for every unit
+	// of a received purchase order.
+	PurchaseOrderLabels(ctx context.Context, poID string) (File, error)
+
+	// PickListPDF returns the PDF of a pick list.
+	PickListPDF(ctx context.Context, listID string) (File, error)
+
+	// PackingSlips returns a PDF with the packing slip of every order of a
+	// pick list.
+	PackingSlips(ctx context.Context, listID string) (File, error)
+}
+
+type basicService struct {
+	catalog   catalog.Repo
+	orders    order.Repo
+	purchases restock.Repo
+	picks     picking.Repo
+	config    Config
+}
+
+// NewService return basic Service implementation.
+func NewService(catalog catalog.Repo, orders order.Repo, purchases restock.Repo, picks picking.Repo, config Config) Service {
+	config.StoreURL = strings.TrimRight(config.StoreURL, "/")
+	return basicService{catalog: catalog, orders: orders, purchases: purchases, picks: picks, config: config}
+}
+
+func (s basicService) bookURL(b catalog.Book) string {
+	return s.config.StoreURL + "/books/" + b.ID
+}
+
+// BookQR returns the QR code of a book page.
+func (s basicService) BookQR(ctx context.Context, bookID, format string, scale int) (File, error) {
+	b, err := s.catalog.GetByID(bookID)
+	if err != nil {
+		return File{}, catalog.ErrBookNotFound
+	}
+	code, err := qr.Encode([]byte(s.bookURL(b)))
+	if err != nil {
+		return File{}, err
+	}
+	switch format {
+	case FormatPNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, code.Image(scale)); err != nil {
+			return File{}, err
+		}
+		return File{Name: b.ISBN + ".png", ContentType: "image/png", Data: buf.Bytes()}, nil
+	case FormatPDF:
+		side := 40 * mm
+		d := pdf.New(side, side)
+		quiet := side * qr.QuietZone / float64(code.Size+2*qr.QuietZone)
+		drawQR(d.AddPage(), quiet, quiet, side-2*quiet, code)
+		return File{Name: b.ISBN + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+	default:
+		return File{}, ErrBadFormat
+	}
+}
+
+// ShelfLabel returns the shelf label of a book.
+func (s basicService) ShelfLabel(ctx context.Context, bookID string) (File, error) {
+	b, err := s.catalog.GetByID(bookID)
+	if err != nil {
+		return File{}, catalog.ErrBookNotFound
+	}
+	d := pdf.New(labelWidth, labelHeight)
+	if err := s.drawShelfLabel(d.AddPage(), b); err != nil {
+		return File{}, err
+	}
+	return File{Name: "label-" + b.ISBN + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+}
+
+func (s basicService) drawShelfLabel(p *pdf.Page, b catalog.Book) error {
+	code, err := qr.Encode([]byte(s.bookURL(b)))
+	if err != nil {
+		return err
+	}
+	side := labelHeight - 6*mm
+	drawQR(p, labelWidth-side-3*mm, 3*mm, side, code)
+
+	p.Text(3*mm, 7*mm, 8, true, pdf.Truncate(b.Title, 26))
+	if b.Series != "" {
+		p.Text(3*mm, 11*mm, 6, false, pdf.Truncate(b.Series, 34))
+	}
+	p.Text(3*mm, 18*mm, 7, false, "ISBN "+b.ISBN)
+	p.Text(3*mm, 30*mm, 14, true, fmt.Sprintf("%.2f %s", b.Price, s.config.Currency))
+	return nil
+}
+
+// PackingSlip returns the packing slip of an order, one line per book with
+// its quantity, and a QR code of the order ID to scan at packing.
+func (s basicService) PackingSlip(ctx context.Context, orderID string) (File, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return File{}, order.ErrOrderNotFound
+	}
+	if len(o.Items) == 0 {
+		return File{}, ErrNothingToPrint
+	}
+	var lines []*slipLine
+	byBook := make(map[string]*slipLine)
+	for _, b := range o.Items {
+		if l, ok := byBook[b.ID]; ok {
+			l.quantity++
+			continue
+		}
+		l := &slipLine{isbn: b.ISBN, title: b.Title, quantity: 1}
+		byBook[b.ID] = l
+		lines = append(lines, l)
+	}
+
+	d := pdf.New(a4Width, a4Height)
+	if err := drawPackingSlip(d, o, 0, lines); err != nil {
+		return File{}, err
+	}
+	return File{Name: "packing-slip-" + o.ID + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+}
+
+// slipLine is a book of a packing slip.
+type slipLine struct {
+	isbn     string
+	title    string
+	quantity int
+}
+
+// drawPackingSlip adds the pages of the packing slip of o to d, with the
+// tote of the order if not 0.
+func drawPackingSlip(d *pdf.Document, o order.Order, tote int, lines []*slipLine) error {
+	code, err := qr.Encode([]byte(o.ID))
+	if err != nil {
+		return err
+	}
+	const margin, rowHeight, rowsPerPage = 40, 18, 30
+	var p *pdf.Page
+	y, items := 0.0, 0
+	for i, l := range lines {
+		if i%rowsPerPage == 0 {
+			p = d.AddPage()
+			p.Text(margin, 60, 20, true, "Packing slip")
+			p.Text(margin, 82, 10, false, "Order "+o.ID)
+			if o.CreatedBy != nil {
+				p.Text(margin, 96, 10, false, "Customer "+o.CreatedBy.Email)
+			}
+			if tote != 0 {
+				p.Text(margin, 110, 10, true, fmt.Sprintf("Tote %d", tote))
+			}
+			drawQR(p, a4Width-margin-80, 30, 80, code)
+			y = 140
+			p.Text(margin, y, 9, true, "Qty")
+			p.Text(margin+40, y, 9, true, "ISBN")
+			p.Text(margin+150, y, 9, true, "Title")
+			p.Line(margin, a4Width-margin, y+5)
+			y += rowHeight
+		}
+		p.Text(margin, y, 9, false, fmt.Sprintf("%d", l.quantity))
+		p.Text(margin+40, y, 9, false, l.isbn)
+		p.Text(margin+150, y, 9, false, pdf.Truncate(l.title, 70))
+		y += rowHeight
+		items += l.quantity
+	}
+	p.Line(margin, a4Width-margin, y-rowHeight+5)
+	p.Text(margin, y+10, 9, false, fmt.Sprintf("%d items", items))
+	return nil
+}
+
+// PickListPDF returns the pick list to walk the warehouse with, one row
+// per book in the order of the shelves, and the order of every tote.
+func (s basicService) PickListPDF(ctx context.Context, listID string) (File, error) {
+	l, err := s.picks.Get(listID)
+	if err != nil {
+		return File{}, picking.ErrListNotFound
+	}
+	if len(l.Lines) == 0 {
+		return File{}, ErrNothingToPrint
+	}
+
+	const margin, rowHeight, rowsPerPage = 40, 18, 36
+	d := pdf.New(a4Width, a4Height)
+	var p *pdf.Page
+	y := 0.0
+	header := func(title string) {
+		p = d.AddPage()
+		p.Text(margin, 60, 20, true, title)
+		p.Text(margin, 82, 10, false, fmt.Sprintf("Pick list %s, %d orders, created %s", l.ID, len(l.Orders), l.CreatedAt.Format("2006-01-02 15:04")))
+		y = 120
+	}
+	for i, ln := range l.Lines {
+		if i%rowsPerPage == 0 {
+			header("Pick list")
+			p.Text(margin, y, 9, true, "Location")
+			p.Text(margin+70, y, 9, true, "ISBN")
+			p.Text(margin+170, y, 9, true, "Title")
+			p.Text(margin+400, y, 9, true, "Qty")
+			p.Text(margin+430, y, 9, true, "Totes")
+			p.Line(margin, a4Width-margin, y+5)
+			y += rowHeight
+		}
+		location := ln.Location
+		if location == "" {
+			location = "-"
+		}
+		p.Text(margin, y, 9, true, location)
+		p.Text(margin+70, y, 9, false, ln.ISBN)
+		p.Text(margin+170, y, 9, false, pdf.Truncate(ln.Title, 45))
+		p.Text(margin+400, y, 9, false, fmt.Sprintf("%d", ln.Quantity))
+		p.Text(margin+430, y, 9, false, pdf.Truncate(ln.Totes, 16))
+		y += rowHeight
+	}
+
+	for i, o := range l.Orders {
+		if i == 0 || y > a4Height-2*margin {
+			header("Totes")
+			p.Text(margin, y, 9, true, "Tote")
+			p.Text(margin+50, y, 9, true, "Order")
+			p.Text(margin+250, y, 9, true, "Books")
+			p.Line(margin, a4Width-margin, y+5)
+			y += rowHeight
+		}
+		books := 0
+		for _, it := range o.Items {
+			books += it.Quantity
+		}
+		p.Text(margin, y, 9, true, fmt.Sprintf("%d", o.Tote))
+		p.Text(margin+50, y, 9, false, o.OrderID)
+		p.Text(margin+250, y, 9, false, fmt.Sprintf("%d", books))
+		y += rowHeight
+	}
+	return File{Name: "pick-list-" + l.ID + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+}
+
+// PackingSlips returns the packing slips of the orders of a pick list, in
+// the order of their totes, with the books picked for them.
+func (s basicService) PackingSlips(ctx context.Context, listID string) (File, error) {
+	l, err := s.picks.Get(listID)
+	if err != nil {
+		return File{}, picking.ErrListNotFound
+	}
+	d := pdf.New(a4Width, a4Height)
+	for _, po := range l.Orders {
+		o, err := s.orders.GetByID(po.OrderID)
+		if err != nil {
+			return File{}, order.ErrOrderNotFound
+		}
+		var lines []*slipLine
+		for _, it := range po.Items {
+			lines = append(lines, &slipLine{isbn: it.ISBN, title: it.Title, quantity: it.Quantity})
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if err := drawPackingSlip(d, o, po.Tote, lines); err != nil {
+			return File{}, err
+		}
+	}
+	if d.Pages() == 0 {
+		return File{}, ErrNothingToPrint
+	}
+	return File{Name: "packing-slips-" + l.ID + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+}
+
+// PurchaseOrderLabels returns a shelf label page for every unit received
+// on a purchase order.
+func (s basicService) PurchaseOrderLabels(ctx context.Context, poID string) (File, error) {
+	po, err := s.purchases.GetPurchaseOrder(poID)
+	if err != nil {
+		return File{}, ErrPONotFound
+	}
+	if po.Status != restock.StatusReceived {
+		return File{}, ErrNotReceived
+	}
+	total := 0
+	for _, l := range po.Lines {
+		total += l.Quantity
+	}
+	if total > MaxBatchLabels {
+		return File{}, ErrTooManyLabels
+	}
+
+	d := pdf.New(labelWidth, labelHeight)
+	for _, l := range po.Lines {
+		b, err := s.catalog.GetByID(l.BookID)
+		if err != nil {
+			return File{}, catalog.ErrBookNotFound
+		}
+		for i := 0; i < l.Quantity; i++ {
+			if err := s.drawShelfLabel(d.AddPage(), b); err != nil {
+				return File{}, err
+			}
+		}
+	}
+	if d.Pages() == 0 {
+		return File{}, ErrNothingToPrint
+	}
+	return File{Name: "labels-" + po.ID + ".pdf", ContentType: "application/pdf", Data: d.Bytes()}, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,170 @@
+package labels
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/labels/qr"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/picking"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+	ErrBadScale   = errors.New("scale must be between 1 and 32")
+)
+
+const defaultScale = 8
+
+// MakeHTTPHandler mounts the labels endpoints, served to the requests
+// staff lets through, e.g. rbac.Staff with user.RoleAdmin and
+// user.RoleSupport.
+func MakeHTTPHandler(ctx context.Context, s Service, staff endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, staff)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	bookQRHandler := httptransport.NewServer(
+		e.BookQREndpoint,
+		decodeBookQRRequest,
+		encodeFile,
+		options...,
+	)
+	shelfLabelHandler := httptransport.NewServer(
+		e.ShelfLabelEndpoint,
+		decodeIDRequest("book-id"),
+		encodeFile,
+		options...,
+	)
+	packingSlipHandler := httptransport.NewServer(
+		e.PackingSlipEndpoint,
+		decodeIDRequest("order-id"),
+		encodeFile,
+		options...,
+	)
+	purchaseOrderLabelsHandler := httptransport.NewServer(
+		e.PurchaseOrderLabelsEndpoint,
+		decodeIDRequest("po-id"),
+		encodeFile,
+		options...,
+	)
+	pickListHandler := httptransport.NewServer(
+		e.PickListEndpoint,
+		decodeIDRequest("list-id"),
+		encodeFile,
+		options...,
+	)
+	packingSlipsHandler := httptransport.NewServer(
+		e.PackingSlipsEndpoint,
+		decodeIDRequest("list-id"),
+		encodeFile,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Warehouse endpoints
+	r.Handle("/labels/v1/books/{book-id}/qr", bookQRHandler).Methods("GET")
+	r.Handle("/labels/v1/books/{book-id}/shelf-label", shelfLabelHandler).Methods("GET")
+	r.Handle("/labels/v1/orders/{order-id}/packing-slip", packingSlipHandler).Methods("GET")
+	r.Handle("/labels/v1/purchase-orders/{po-id}/labels", purchaseOrderLabelsHandler).Methods("GET")
+	r.Handle("/labels/v1/pick-lists/{list-id}", pickListHandler).Methods("GET")
+	r.Handle("/labels/v1/pick-lists/{list-id}/packing-slips", packingSlipsHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeBookQRRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	format := req.FormValue("format")
+	if format == "" {
+		format = FormatPNG
+	}
+	scale := defaultScale
+	if v := req.FormValue("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 32 {
+			return nil, ErrBadScale
+		}
+		scale = n
+	}
+	return bookQRRequest{BookID: bookID, Format: format, Scale: scale}, nil
+}
+
+func decodeIDRequest(name string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		id, ok := mux.Vars(req)[name]
+		if !ok {
+			return nil, errors.Wrap(ErrBadRouting, name)
+		}
+		return idRequest{ID: id}, nil
+	}
+}
+
+// encodeFile writes the generated file inline, with its name for saving.
+func encodeFile(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(fileResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `inline; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case catalog.ErrBookNotFound, order.ErrOrderNotFound, ErrPONotFound, picking.ErrListNotFound:
+		return http.StatusNotFound
+	case ErrNotReceived, ErrNothingToPrint:
+		return http.StatusConflict
+	case ErrBadRouting, ErrBadFormat, ErrBadScale, ErrTooManyLabels, qr.ErrTooLong:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
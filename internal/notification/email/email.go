@@ -7,3 +7,27 @@ func Welcome(to []string, ctx map[string]interface{}) error {
 func ResetPassword(to []string, ctx map[string]interface{}) error {
 	return nil
 }
+
+func RestockDelayed(to []string, ctx map[string]interface{}) error {
+	return nil
+}
+
+func BackInStock(to []string, ctx map[string]interface{}) error {
+	return nil
+}
+
+func ExportReady(to []string, ctx map[string]interface{}) error {
+	return nil
+}
+
+func DisputeOpened(to []string, ctx map[string]interface{}) error {
+	return nil
+}
+
+func Receipt(to []string, ctx map[string]interface{}) error {
+	return nil
+}
+
+func CreditNote(to []string, ctx map[string]interface{}) error {
+	return nil
+}
@@ -0,0 +1,167 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrCartEmpty    = errors.New("cart is empty")
+	ErrCartChanged  = errors.New("cart prices or availability changed, confirm the cart first")
+	ErrCartCurrency = errors.New("cart lines are priced in different currencies")
+)
+
+// Statuses of a cart line, set when the cart is loaded.
+const (
+	LineOK           = "ok"
+	LinePriceChanged = "price_changed"
+	LineUnavailable  = "unavailable"
+)
+
+// CartLine is a book in the cart of a user. Price and Currency are the
+// ones the customer agreed to, when the book was added or the cart last
+// confirmed.
+type CartLine struct {
+	UserID   string    `json:"-" sql:"primary_key"`
+	BookID   string    `json:"book_id" sql:"primary_key"`
+	Price    float64   `json:"price"`
+	Currency string    `json:"currency"`
+	AddedAt  time.Time `json:"added_at"`
+
+	// Status tells how the line compares to the catalog, with the price
+	// the catalog shows now if it changed.
+	Status          string  `json:"status" sql:"-"`
+	CurrentPrice    float64 `json:"current_price,omitempty" sql:"-"`
+	CurrentCurrency string  `json:"current_currency,omitempty" sql:"-"`
+}
+
+func (CartLine) TableName() string {
+	return "order_cart_lines"
+}
+
+// Cart is the lines of a user revalidated against the catalog. Changed
+// carts need a confirmation before checkout.
+type Cart struct {
+	UserID  string     `json:"user_id"`
+	Lines   []CartLine `json:"lines"`
+	Total   float64    `json:"total"`
+	Changed bool       `json:"changed"`
+}
+
+// revalidate loads the cart of the user, comparing every line with the
+// book the catalog shows now. The total is of the agreed prices.
+func (s basicService) revalidate(ctx context.Context, userID string) (Cart, []catalog.Book, error) {
+	lines, err := s.r.ListCartLines(userID)
+	if err != nil {
+		return Cart{}, nil, err
+	}
+	c := Cart{UserID: userID, Lines: lines}
+	books := make([]catalog.Book, len(lines))
+	for i, l := range lines {
+		b, err := s.books.Get(ctx, l.BookID)
+		switch {
+		case err == db.ErrNotFound || err == catalog.ErrBookNotFound:
+			c.Lines[i].Status = LineUnavailable
+		case err != nil:
+			return Cart{}, nil, err
+		case b.Price != l.Price || b.Currency != l.Currency:
+			c.Lines[i].Status = LinePriceChanged
+			c.Lines[i].CurrentPrice, c.Lines[i].CurrentCurrency = b.Price, b.Currency
+		default:
+			c.Lines[i].Status = LineOK
+		}
+		c.Changed = c.Changed || c.Lines[i].Status != LineOK
+		c.Total += l.Price
+		books[i] = b
+	}
+	return c, books, nil
+}
+
+func (s basicService) Cart(ctx context.Context, userID string) (Cart, error) {
+	c, _, err := s.revalidate(ctx, userID)
+	return c, err
+}
+
+// AddToCart adds the book at the price the catalog shows, a book already
+// in the cart keeps its line.
+func (s basicService) AddToCart(ctx context.Context, userID, bookID string) (Cart, error) {
+	b, err := s.books.Get(ctx, bookID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return Cart{}, catalog.ErrBookNotFound
+		}
+		return Cart{}, err
+	}
+	if _, err := s.r.GetCartLine(userID, bookID); err == db.ErrNotFound {
+		l := CartLine{UserID: userID, BookID: b.ID, Price: b.Price, Currency: b.Currency, AddedAt: time.Now().UTC()}
+		if err := s.r.SaveCartLine(&l); err != nil {
+			return Cart{}, err
+		}
+	} else if err != nil {
+		return Cart{}, err
+	}
+	return s.Cart(ctx, userID)
+}
+
+func (s basicService) RemoveFromCart(ctx context.Context, userID, bookID string) (Cart, error) {
+	if err := s.r.DeleteCartLine(userID, bookID); err != nil {
+		return Cart{}, err
+	}
+	return s.Cart(ctx, userID)
+}
+
+// ConfirmCart accepts the current prices of the changed lines and drops the
+// unavailable ones.
+func (s basicService) ConfirmCart(ctx context.Context, userID string) (Cart, error) {
+	c, _, err := s.revalidate(ctx, userID)
+	if err != nil {
+		return Cart{}, err
+	}
+	for _, l := range c.Lines {
+		switch l.Status {
+		case LineUnavailable:
+			err = s.r.DeleteCartLine(userID, l.BookID)
+		case LinePriceChanged:
+			l.Price, l.Currency = l.CurrentPrice, l.CurrentCurrency
+			err = s.r.SaveCartLine(&l)
+		}
+		if err != nil {
+			return Cart{}, err
+		}
+	}
+	return s.Cart(ctx, userID)
+}
+
+// Checkout prices the order of the cart, as PlaceOrder does, refusing a
+// cart whose prices or availability changed since they were agreed to.
+func (s basicService) Checkout(ctx context.Context, userID string) (Order, error) {
+	c, books, err := s.revalidate(ctx, userID)
+	if err != nil {
+		return Order{}, err
+	}
+	if len(c.Lines) == 0 {
+		return Order{}, ErrCartEmpty
+	}
+	if c.Changed {
+		return Order{}, ErrCartChanged
+	}
+	lines := make([]Line, len(c.Lines))
+	for i, l := range c.Lines {
+		if l.Currency != c.Lines[0].Currency {
+			return Order{}, ErrCartCurrency
+		}
+		lines[i] = Line{BookID: l.BookID, Quantity: 1, Price: l.Price}
+	}
+	return Order{
+		CreatedByID: userID,
+		Items:       books,
+		Lines:       lines,
+		TotalPrice:  c.Total,
+		Currency:    c.Lines[0].Currency,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
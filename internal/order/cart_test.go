@@ -0,0 +1,117 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+)
+
+// cartRepo keeps the cart lines in memory, the other methods of the Repo
+// aren't used.
+type cartRepo struct {
+	order.Repo
+	lines []order.CartLine
+}
+
+func (r *cartRepo) ListCartLines(userID string) ([]order.CartLine, error) {
+	lines := make([]order.CartLine, 0)
+	for _, l := range r.lines {
+		if l.UserID == userID {
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
+
+func (r *cartRepo) GetCartLine(userID, bookID string) (order.CartLine, error) {
+	for _, l := range r.lines {
+		if l.UserID == userID && l.BookID == bookID {
+			return l, nil
+		}
+	}
+	return order.CartLine{}, db.ErrNotFound
+}
+
+func (r *cartRepo) SaveCartLine(l *order.CartLine) error {
+	for i := range r.lines {
+		if r.lines[i].UserID == l.UserID && r.lines[i].BookID == l.BookID {
+			r.lines[i] = *l
+			return nil
+		}
+	}
+	r.lines = append(r.lines, *l)
+	return nil
+}
+
+func (r *cartRepo) DeleteCartLine(userID, bookID string) error {
+	for i, l := range r.lines {
+		if l.UserID == userID && l.BookID == bookID {
+			r.lines = append(r.lines[:i], r.lines[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// books is the catalog as the customer sees it.
+type books map[string]catalog.Book
+
+func (b books) Get(ctx context.Context, id string) (catalog.Book, error) {
+	book, ok := b[id]
+	if !ok {
+		return catalog.Book{}, catalog.ErrBookNotFound
+	}
+	return book, nil
+}
+
+func TestCartDrift(t *testing.T) {
+	ctx := context.Background()
+	catalogue := books{
+		"b1": {ID: "b1", Price: 10, Currency: "EUR"},
+		"b2": {ID: "b2", Price: 20, Currency: "EUR"},
+		"b3": {ID: "b3", Price: 5, Currency: "EUR"},
+	}
+	s := order.NewService(&cartRepo{}, catalogue, nil)
+
+	for _, id := range []string{"b1", "b2", "b3"} {
+		if _, err := s.AddToCart(ctx, "u1", id); err != nil {
+			t.Fatalf("add %s: unexpected error %v", id, err)
+		}
+	}
+	if o, err := s.Checkout(ctx, "u1"); err != nil || o.TotalPrice != 35 {
+		t.Errorf("checkout: expected a total of 35, got %+v, %v", o, err)
+	}
+
+	catalogue["b1"] = catalog.Book{ID: "b1", Price: 12, Currency: "EUR"}
+	delete(catalogue, "b3")
+	c, err := s.Cart(ctx, "u1")
+	if err != nil {
+		t.Fatalf("cart: unexpected error %v", err)
+	}
+	for i, want := range []string{order.LinePriceChanged, order.LineOK, order.LineUnavailable} {
+		if c.Lines[i].Status != want {
+			t.Errorf("%s: expected %s, got %s", c.Lines[i].BookID, want, c.Lines[i].Status)
+		}
+	}
+	if !c.Changed || c.Total != 35 || c.Lines[0].CurrentPrice != 12 {
+		t.Errorf("cart: expected a changed cart at the agreed total 35, got %+v", c)
+	}
+	if _, err := s.Checkout(ctx, "u1"); err != order.ErrCartChanged {
+		t.Errorf("checkout: expected ErrCartChanged, got %v", err)
+	}
+
+	c, err = s.ConfirmCart(ctx, "u1")
+	if err != nil || c.Changed || len(c.Lines) != 2 || c.Total != 32 {
+		t.Errorf("confirm: expected 2 unchanged lines at 32, got %+v, %v", c, err)
+	}
+	if o, err := s.Checkout(ctx, "u1"); err != nil || o.TotalPrice != 32 || len(o.Items) != 2 {
+		t.Errorf("checkout: expected a total of 32, got %+v, %v", o, err)
+	}
+
+	if _, err := s.Checkout(ctx, "u2"); err != order.ErrCartEmpty {
+		t.Errorf("empty: expected ErrCartEmpty, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package order
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotPaid          = errors.New("order is not paid")
+	ErrShipped          = errors.New("order is already shipped")
+	ErrNoLines          = errors.New("order has no lines to edit")
+	ErrLineNotFound     = errors.New("order line not found")
+	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrEmptyOrder       = errors.New("order must keep a line, cancel it instead")
+	ErrCurrencyMismatch = errors.New("book is priced in another currency than the order")
+)
+
+// MaxQuantity caps the quantity of a line.
+const MaxQuantity = 99
+
+// Kinds of the adjustments.
+const (
+	AdjustmentCharge = "charge"
+	AdjustmentRefund = "refund"
+)
+
+// Line is a book of an order with its quantity, at the unit price the
+// customer agreed to.
+type Line struct {
+	OrderID  string  `json:"-" sql:"primary_key"`
+	BookID   string  `json:"book_id" sql:"primary_key"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+func (Line) TableName() string {
+	return "order_lines"
+}
+
+// Adjustment is the difference charged or refunded through the payment
+// provider after an edit of the order.
+type Adjustment struct {
+	ID        string    `json:"id"`
+	OrderID   string    `json:"order_id" sql:"index"`
+	Kind      string    `json:"kind"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	Reference string    `json:"reference"` // charge or refund at the provider
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Adjustment) TableName() string {
+	return "order_adjustments"
+}
+
+// Edit is an edited order with the adjustment of its payment, none if the
+// total didn't change.
+type Edit struct {
+	Order      Order       `json:"order"`
+	Adjustment *Adjustment `json:"adjustment,omitempty"`
+}
+
+// Payments charges and refunds the payments of the orders, e.g.
+// payment.Provider wrapped with credit.NewPayments for the orders paid with
+// store credit.
+type Payments interface {
+	Charge(ctx context.Context, paymentRef string, amount float64, currency string) (string, error)
+	Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error)
+}
+
+// AddLine adds quantity copies of the book, at the price the catalog shows
+// now, a book already ordered keeping its price.
+func (s basicService) AddLine(ctx context.Context, orderID, bookID string, quantity int) (Edit, error) {
+	if quantity < 1 || quantity > MaxQuantity {
+		return Edit{}, ErrInvalidQuantity
+	}
+	return s.edit(ctx, orderID, func(o *Order) error {
+		for i := range o.Lines {
+			if o.Lines[i].BookID == bookID {
+				if o.Lines[i].Quantity+quantity > MaxQuantity {
+					return ErrInvalidQuantity
+				}
+				o.Lines[i].Quantity += quantity
+				return nil
+			}
+		}
+		b, err := s.books.Get(ctx, bookID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				return catalog.ErrBookNotFound
+			}
+			return err
+		}
+		if b.Currency != o.Currency {
+			return ErrCurrencyMismatch
+		}
+		o.Lines = append(o.Lines, Line{OrderID: o.ID, BookID: b.ID, Quantity: quantity, Price: b.Price})
+		return nil
+	})
+}
+
+// SetQuantity changes the quantity of a line, removing it at 0.
+func (s basicService) SetQuantity(ctx context.Context, orderID, bookID string, quantity int) (Edit, error) {
+	if quantity < 0 || quantity > MaxQuantity {
+		return Edit{}, ErrInvalidQuantity
+	}
+	return s.edit(ctx, orderID, func(o *Order) error {
+		for i, l := range o.Lines {
+			if l.BookID != bookID {
+				continue
+			}
+			if quantity > 0 {
+				o.Lines[i].Quantity = quantity
+				return nil
+			}
+			if len(o.Lines) == 1 {
+				return ErrEmptyOrder
+			}
+			o.Lines = append(o.Lines[:i], o.Lines[i+1:]...)
+			return nil
+		}
+		return ErrLineNotFound
+	})
+}
+
+// edit applies change to the lines of a paid order not shipped yet, and
+// charges or refunds the difference of the totals before saving it. The
+// payment goes first, an edit is never saved unpaid.
+func (s basicService) edit(ctx context.Context, orderID string, change func(o *Order) error) (Edit, error) {
+	o, err := s.r.GetByID(orderID)
+	if err != nil {
+		return Edit{}, ErrOrderNotFound
+	}
+	switch {
+	case o.PaidAt == nil:
+		return Edit{}, ErrNotPaid
+	case o.ShippedAt != nil:
+		return Edit{}, ErrShipped
+	case len(o.Lines) == 0:
+		// Orders placed before the lines were kept.
+		return Edit{}, ErrNoLines
+	}
+	before := cents(o.TotalPrice)
+	if err := change(&o); err != nil {
+		return Edit{}, err
+	}
+	var total int64
+	for _, l := range o.Lines {
+		total += cents(l.Price) * int64(l.Quantity)
+	}
+	o.TotalPrice = float64(total) / 100
+
+	e := Edit{Order: o}
+	if diff := total - before; diff != 0 {
+		a := Adjustment{OrderID: o.ID, Kind: AdjustmentCharge, Amount: float64(diff) / 100, Currency: o.Currency, CreatedAt: time.Now().UTC()}
+		move := s.payments.Charge
+		if diff < 0 {
+			a.Kind, a.Amount, move = AdjustmentRefund, -a.Amount, s.payments.Refund
+		}
+		if a.Reference, err = move(ctx, o.PaymentRef, a.Amount, a.Currency); err != nil {
+			return Edit{}, errors.Wrap(err, a.Kind)
+		}
+		e.Adjustment = &a
+	}
+	if err := s.r.SaveLines(&e.Order, e.Adjustment); err != nil {
+		if e.Adjustment != nil {
+			return Edit{}, errors.Wrapf(err, "order %s not saved after %s %s", o.ID, e.Adjustment.Kind, e.Adjustment.Reference)
+		}
+		return Edit{}, err
+	}
+	return e, nil
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
@@ -0,0 +1,179 @@
+package order_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// editRepo keeps an order in memory with its adjustments, the other methods
+// of the Repo aren't used.
+type editRepo struct {
+	order.Repo
+	order       order.Order
+	adjustments []order.Adjustment
+}
+
+func (r *editRepo) GetByID(id string) (order.Order, error) {
+	if id != r.order.ID {
+		return order.Order{}, db.ErrNotFound
+	}
+	o := r.order
+	o.Lines = append([]order.Line(nil), r.order.Lines...)
+	return o, nil
+}
+
+func (r *editRepo) SaveLines(o *order.Order, a *order.Adjustment) error {
+	r.order = *o
+	if a != nil {
+		r.adjustments = append(r.adjustments, *a)
+	}
+	return nil
+}
+
+// payments records the amounts moved, refunds being negative.
+type payments []float64
+
+func (p *payments) Charge(ctx context.Context, ref string, amount float64, currency string) (string, error) {
+	*p = append(*p, amount)
+	return "ch1", nil
+}
+
+func (p *payments) Refund(ctx context.Context, ref string, amount float64, currency string) (string, error) {
+	*p = append(*p, -amount)
+	return "re1", nil
+}
+
+func TestEdit(t *testing.T) {
+	ctx := context.Background()
+	paid := time.Now().UTC()
+	r := &editRepo{order: order.Order{
+		ID:         "o1",
+		TotalPrice: 10,
+		Currency:   "EUR",
+		Lines:      []order.Line{{OrderID: "o1", BookID: "b1", Quantity: 1, Price: 10}},
+		PaymentRef: "pay1",
+	}}
+	catalogue := books{
+		"b1": {ID: "b1", Price: 12, Currency: "EUR"},
+		"b2": {ID: "b2", Price: 4.5, Currency: "EUR"},
+		"b3": {ID: "b3", Price: 7, Currency: "USD"},
+	}
+	moved := &payments{}
+	s := order.NewService(r, catalogue, moved)
+
+	if _, err := s.AddLine(ctx, "o1", "b2", 1); err != order.ErrNotPaid {
+		t.Errorf("unpaid: expected ErrNotPaid, got %v", err)
+	}
+	r.order.PaidAt = &paid
+
+	e, err := s.AddLine(ctx, "o1", "b2", 2)
+	if err != nil || e.Order.TotalPrice != 19 || e.Adjustment == nil || e.Adjustment.Kind != order.AdjustmentCharge {
+		t.Fatalf("add: expected a charge to a total of 19, got %+v, %v", e, err)
+	}
+	// b1 keeps the price it was ordered at.
+	if e, err = s.SetQuantity(ctx, "o1", "b1", 2); err != nil || e.Order.TotalPrice != 29 {
+		t.Errorf("quantity: expected a total of 29, got %+v, %v", e, err)
+	}
+	if e, err = s.SetQuantity(ctx, "o1", "b2", 0); err != nil || e.Order.TotalPrice != 20 || len(e.Order.Lines) != 1 {
+		t.Errorf("remove: expected the line of b1 left at 20, got %+v, %v", e, err)
+	}
+	if e.Adjustment == nil || e.Adjustment.Kind != order.AdjustmentRefund || e.Adjustment.Reference != "re1" {
+		t.Errorf("remove: expected a refund, got %+v", e.Adjustment)
+	}
+	if want := []float64{9, 10, -9}; len(*moved) != len(want) || len(r.adjustments) != len(want) {
+		t.Errorf("payments: expected %v, got %v", want, *moved)
+	} else {
+		for i := range want {
+			if (*moved)[i] != want[i] {
+				t.Errorf("payment %d: expected %v, got %v", i, want[i], (*moved)[i])
+			}
+		}
+	}
+
+	if e, err = s.SetQuantity(ctx, "o1", "b1", 2); err != nil || e.Adjustment != nil {
+		t.Errorf("unchanged: expected no adjustment, got %+v, %v", e.Adjustment, err)
+	}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"last line", second(s.SetQuantity(ctx, "o1", "b1", 0)), order.ErrEmptyOrder},
+		{"no line", second(s.SetQuantity(ctx, "o1", "b2", 1)), order.ErrLineNotFound},
+		{"currency", second(s.AddLine(ctx, "o1", "b3", 1)), order.ErrCurrencyMismatch},
+		{"quantity", second(s.AddLine(ctx, "o1", "b1", order.MaxQuantity)), order.ErrInvalidQuantity},
+		{"unknown", second(s.AddLine(ctx, "o1", "b4", 1)), catalog.ErrBookNotFound},
+	} {
+		if tc.err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, tc.err)
+		}
+	}
+
+	r.order.ShippedAt = &paid
+	if _, err := s.SetQuantity(ctx, "o1", "b1", 1); err != order.ErrShipped {
+		t.Errorf("shipped: expected ErrShipped, got %v", err)
+	}
+}
+
+// second returns the error of an edit.
+func second(_ order.Edit, err error) error {
+	return err
+}
+
+func TestEditRequiresAdmin(t *testing.T) {
+	paid := time.Now().UTC()
+	r := &editRepo{order: order.Order{
+		ID:         "o1",
+		TotalPrice: 10,
+		Currency:   "EUR",
+		Lines:      []order.Line{{OrderID: "o1", BookID: "b1", Quantity: 1, Price: 10}},
+		PaymentRef: "pay1",
+		PaidAt:     &paid,
+	}}
+	moved := &payments{}
+	s := order.NewService(r, books{"b2": {ID: "b2", Price: 4.5, Currency: "EUR"}}, moved)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := order.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest("POST", "/orders/v1/admin/o1/lines", strings.NewReader(`{"book_id":"b2"}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status || len(*moved) != 0 {
+			t.Errorf("%s: expected %d and no payment, got %d, %v", c.name, c.status, w.Code, *moved)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/orders/v1/admin/o1/lines", strings.NewReader(`{"book_id":"b2"}`))
+	req.Header.Set("Authorization", "Bearer "+staff)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code >= 300 || len(*moved) != 1 {
+		t.Errorf("admin: expected the line added and charged, got %d, %v: %s", w.Code, *moved, w.Body)
+	}
+}
@@ -2,10 +2,14 @@ package order
 
 import (
 	"net/http"
+	"net/url"
 
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/transport"
 )
 
 // Endpoints combine all the order service endpoints under single type.
@@ -13,15 +17,40 @@ type Endpoints struct {
 	PlaceOrderEndpoint    endpoint.Endpoint
 	GetUserOrdersEndpoint endpoint.Endpoint
 	CancelOrderEndpoint   endpoint.Endpoint
+	PatchEndpoint         endpoint.Endpoint
+	ListEndpoint          endpoint.Endpoint
+
+	CartEndpoint           endpoint.Endpoint
+	AddToCartEndpoint      endpoint.Endpoint
+	RemoveFromCartEndpoint endpoint.Endpoint
+	ConfirmCartEndpoint    endpoint.Endpoint
+	CheckoutEndpoint       endpoint.Endpoint
+
+	AddLineEndpoint     endpoint.Endpoint
+	SetQuantityEndpoint endpoint.Endpoint
+	RemoveLineEndpoint  endpoint.Endpoint
 }
 
 // MakeEndpoints returns Endpoints type which is the combination of
-// all the order service endpoints.
-func MakeEndpoints(s Service) Endpoints {
+// all the order service endpoints. The shop admin endpoints are restricted
+// by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
 	return Endpoints{
 		PlaceOrderEndpoint:    MakePlaceOrderEndpoint(s),
 		GetUserOrdersEndpoint: MakeGetUserOdersEndpoint(s),
 		CancelOrderEndpoint:   MakeCancelOrderEndpoint(s),
+		PatchEndpoint:         MakePatchEndpoint(s),
+		ListEndpoint:          admin(MakeListEndpoint(s)),
+
+		CartEndpoint:           MakeCartEndpoint(s),
+		AddToCartEndpoint:      MakeAddToCartEndpoint(s),
+		RemoveFromCartEndpoint: MakeRemoveFromCartEndpoint(s),
+		ConfirmCartEndpoint:    MakeConfirmCartEndpoint(s),
+		CheckoutEndpoint:       MakeCheckoutEndpoint(s),
+
+		AddLineEndpoint:     admin(MakeAddLineEndpoint(s)),
+		SetQuantityEndpoint: admin(MakeSetQuantityEndpoint(s)),
+		RemoveLineEndpoint:  admin(MakeRemoveLineEndpoint(s)),
 	}
 }
 
@@ -58,6 +87,121 @@ func MakeCancelOrderEndpoint(s Service) endpoint.Endpoint {
 	}
 }
 
+func MakePatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(patchRequest)
+		order, e := s.Patch(ctx, req.UserID, req.OrderID, req.Patch)
+		if e != nil {
+			return patchResponse{Order: nil, Error: e}, nil
+		}
+		return patchResponse{Order: &order}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		orders, total, e := s.List(ctx, req.Filter, req.Limit, req.Offset, req.Count)
+		if e != nil {
+			return listResponse{Orders: make([]Order, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		if req.Count == db.CountNone {
+			// total only tells about the next page, leave it out.
+			total = 0
+		}
+		return listResponse{Orders: orders, Total: total, Prev: prev, Next: next}, nil
+	}
+}
+
+func MakeCartEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cartRequest)
+		cart, e := s.Cart(ctx, req.UserID)
+		if e != nil {
+			return cartResponse{Cart: nil, Error: e}, nil
+		}
+		return cartResponse{Cart: &cart}, nil
+	}
+}
+
+func MakeAddToCartEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cartRequest)
+		cart, e := s.AddToCart(ctx, req.UserID, req.BookID)
+		if e != nil {
+			return cartResponse{Cart: nil, Error: e}, nil
+		}
+		return cartResponse{Cart: &cart}, nil
+	}
+}
+
+func MakeRemoveFromCartEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cartRequest)
+		cart, e := s.RemoveFromCart(ctx, req.UserID, req.BookID)
+		if e != nil {
+			return cartResponse{Cart: nil, Error: e}, nil
+		}
+		return cartResponse{Cart: &cart}, nil
+	}
+}
+
+func MakeConfirmCartEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cartRequest)
+		cart, e := s.ConfirmCart(ctx, req.UserID)
+		if e != nil {
+			return cartResponse{Cart: nil, Error: e}, nil
+		}
+		return cartResponse{Cart: &cart}, nil
+	}
+}
+
+func MakeCheckoutEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cartRequest)
+		order, e := s.Checkout(ctx, req.UserID)
+		if e != nil {
+			return placeOrderResponse{Order: nil, Error: e}, nil
+		}
+		return placeOrderResponse{Order: &order, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeAddLineEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(lineRequest)
+		edit, e := s.AddLine(ctx, req.OrderID, req.BookID, req.Quantity)
+		if e != nil {
+			return editResponse{Error: e}, nil
+		}
+		return editResponse{Order: &edit.Order, Adjustment: edit.Adjustment}, nil
+	}
+}
+
+func MakeSetQuantityEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(lineRequest)
+		edit, e := s.SetQuantity(ctx, req.OrderID, req.BookID, req.Quantity)
+		if e != nil {
+			return editResponse{Error: e}, nil
+		}
+		return editResponse{Order: &edit.Order, Adjustment: edit.Adjustment}, nil
+	}
+}
+
+func MakeRemoveLineEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(lineRequest)
+		edit, e := s.SetQuantity(ctx, req.OrderID, req.BookID, 0)
+		if e != nil {
+			return editResponse{Error: e}, nil
+		}
+		return editResponse{Order: &edit.Order, Adjustment: edit.Adjustment}, nil
+	}
+}
+
 type placeOrderRequest struct {
 	BookID string `json:"book_id"`
 }
@@ -97,3 +241,73 @@ type cancelOrderRequest struct {
 type cancelOrderResponse struct {
 	Error error `json:"error,omitempty"`
 }
+
+type patchRequest struct {
+	UserID  string
+	OrderID string
+	Patch   []byte
+}
+
+type patchResponse struct {
+	Order *Order `json:"order,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r patchResponse) error() error {
+	return r.Error
+}
+
+type listRequest struct {
+	Filter filter.Expr
+	Limit  int
+	Offset int
+	Count  db.Count
+	URL    *url.URL
+}
+
+type listResponse struct {
+	Orders []Order `json:"orders"`
+	Error  error   `json:"error,omitempty"`
+
+	Total int    `json:"-"`
+	Prev  string `json:"-"`
+	Next  string `json:"-"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+func (r listResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
+
+type cartRequest struct {
+	UserID string `json:"-"`
+	BookID string `json:"book_id"`
+}
+
+type cartResponse struct {
+	Cart  *Cart `json:"cart,omitempty"`
+	Error error `json:"error,omitempty"`
+}
+
+func (r cartResponse) error() error {
+	return r.Error
+}
+
+type lineRequest struct {
+	OrderID  string `json:"-"`
+	BookID   string `json:"book_id"`
+	Quantity int    `json:"quantity"`
+}
+
+type editResponse struct {
+	Order      *Order      `json:"order,omitempty"`
+	Adjustment *Adjustment `json:"adjustment,omitempty"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r editResponse) error() error {
+	return r.Error
+}
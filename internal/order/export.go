@@ -0,0 +1,47 @@
+package order
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var exportColumns = export.Columns{
+	{Name: "id", Value: func(r interface{}) string { return r.(Order).ID }},
+	{Name: "created_by", Value: func(r interface{}) string { return r.(Order).CreatedByID }},
+	{Name: "total", Value: func(r interface{}) string { return strconv.FormatFloat(r.(Order).TotalPrice, 'f', 2, 64) }},
+	{Name: "currency", Value: func(r interface{}) string { return r.(Order).Currency }},
+	{Name: "note", Value: func(r interface{}) string { return r.(Order).Note }},
+	{Name: "created_at", Value: func(r interface{}) string { return r.(Order).CreatedAt.UTC().Format(time.RFC3339) }},
+}
+
+// ExportSource exports the admin order list as CSV, filtered by the same
+// query parameters, to the requests access lets through.
+func ExportSource(s Service, access endpoint.Middleware) export.Source {
+	return export.Source{
+		Name:    "orders",
+		Path:    "/orders/v1/admin/list",
+		Columns: exportColumns,
+		Access:  access,
+		Rows: func(ctx context.Context, query url.Values) (export.Rows, error) {
+			f, err := filter.Parse(query.Get("filter"), ListFields)
+			if err != nil {
+				return nil, err
+			}
+			return func(limit, offset int) ([]interface{}, error) {
+				orders, _, err := s.List(ctx, f, limit, offset, db.CountNone)
+				rows := make([]interface{}, len(orders))
+				for i, o := range orders {
+					rows[i] = o
+				}
+				return rows, err
+			}, nil
+		},
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type instrmw struct {
@@ -57,3 +59,102 @@ func (mw instrmw) CancelOrder(ctx context.Context, userID string, orderID string
 	err = mw.next.CancelOrder(ctx, userID, orderID)
 	return
 }
+
+func (mw instrmw) Patch(ctx context.Context, userID, orderID string, p []byte) (order2 Order, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "patch", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	order2, err = mw.next.Patch(ctx, userID, orderID, p)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (orders []Order, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orders, total, err = mw.next.List(ctx, f, limit, offset, count)
+	return
+}
+
+func (mw instrmw) Cart(ctx context.Context, userID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "cart", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	cart, err = mw.next.Cart(ctx, userID)
+	return
+}
+
+func (mw instrmw) AddToCart(ctx context.Context, userID, bookID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "add_to_cart", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	cart, err = mw.next.AddToCart(ctx, userID, bookID)
+	return
+}
+
+func (mw instrmw) RemoveFromCart(ctx context.Context, userID, bookID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "remove_from_cart", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	cart, err = mw.next.RemoveFromCart(ctx, userID, bookID)
+	return
+}
+
+func (mw instrmw) ConfirmCart(ctx context.Context, userID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "confirm_cart", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	cart, err = mw.next.ConfirmCart(ctx, userID)
+	return
+}
+
+func (mw instrmw) Checkout(ctx context.Context, userID string) (order Order, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "checkout", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	order, err = mw.next.Checkout(ctx, userID)
+	return
+}
+
+func (mw instrmw) AddLine(ctx context.Context, orderID, bookID string, quantity int) (edit Edit, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "add_line", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	edit, err = mw.next.AddLine(ctx, orderID, bookID, quantity)
+	return
+}
+
+func (mw instrmw) SetQuantity(ctx context.Context, orderID, bookID string, quantity int) (edit Edit, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_quantity", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	edit, err = mw.next.SetQuantity(ctx, orderID, bookID, quantity)
+	return
+}
@@ -6,6 +6,8 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type loggingService struct {
@@ -54,3 +56,113 @@ func (s loggingService) CancelOrder(ctx context.Context, userID string, orderID
 	}(time.Now())
 	return s.next.CancelOrder(ctx, userID, orderID)
 }
+
+func (s loggingService) Patch(ctx context.Context, userID, orderID string, p []byte) (order2 Order, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "patch",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Patch(ctx, userID, orderID, p)
+}
+
+func (s loggingService) List(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (orders []Order, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx, f, limit, offset, count)
+}
+
+func (s loggingService) Cart(ctx context.Context, userID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "cart",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Cart(ctx, userID)
+}
+
+func (s loggingService) AddToCart(ctx context.Context, userID, bookID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "add_to_cart",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.AddToCart(ctx, userID, bookID)
+}
+
+func (s loggingService) RemoveFromCart(ctx context.Context, userID, bookID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "remove_from_cart",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RemoveFromCart(ctx, userID, bookID)
+}
+
+func (s loggingService) ConfirmCart(ctx context.Context, userID string) (cart Cart, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "confirm_cart",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ConfirmCart(ctx, userID)
+}
+
+func (s loggingService) Checkout(ctx context.Context, userID string) (order Order, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "checkout",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Checkout(ctx, userID)
+}
+
+func (s loggingService) AddLine(ctx context.Context, orderID, bookID string, quantity int) (edit Edit, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "add_line",
+			"order_id", orderID,
+			"book_id", bookID,
+			"quantity", quantity,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.AddLine(ctx, orderID, bookID, quantity)
+}
+
+func (s loggingService) SetQuantity(ctx context.Context, orderID, bookID string, quantity int) (edit Edit, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_quantity",
+			"order_id", orderID,
+			"book_id", bookID,
+			"quantity", quantity,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetQuantity(ctx, orderID, bookID, quantity)
+}
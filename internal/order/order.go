@@ -1,7 +1,10 @@
 package order
 
 import (
+	"time"
+
 	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
 	"github.com/kavirajk/bookshop/user"
 )
 
@@ -9,7 +12,26 @@ type Order struct {
 	ID          string         `json:"id"`
 	CreatedBy   *user.User     `json:"created_by"`
 	CreatedByID string         `json:"-"`
-	Items       []catalog.Book `json:"items" gorm:"many_to_many"`
+	Items       []catalog.Book `json:"items" gorm:"many2many:order_items"`
 	TotalPrice  float64        `json:"total_price"`
 	Currency    string         `json:"currency"`
+	Note        string         `json:"note,omitempty"` // delivery instructions of the customer
+	// Lines are the books with their quantity and agreed price, Items the
+	// books alone.
+	Lines []Line `json:"lines,omitempty" gorm:"ForeignKey:OrderID"`
+	// PaymentRef is the payment at the payment provider, set when the
+	// order is paid.
+	PaymentRef string     `json:"-"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	ShippedAt  *time.Time `json:"shipped_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ListFields are the fields the admin order list can be filtered on.
+var ListFields = filter.Fields{
+	"id":         {Column: "id", Type: filter.String},
+	"created_by": {Column: "created_by_id", Type: filter.String},
+	"total":      {Column: "total_price", Type: filter.Number},
+	"currency":   {Column: "currency", Type: filter.String},
+	"created_at": {Column: "created_at", Type: filter.Time},
 }
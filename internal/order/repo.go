@@ -1,10 +1,28 @@
 package order
 
+import (
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
 // Repo abstracts all the persistant storage operations of Order Service
 type Repo interface {
 	Create(order *Order) error
 	Save(order *Order) error
 	GetByID(ID string) (Order, error)
 	ListByUser(userID string) ([]Order, error)
+	ListByVendor(vendorID string) ([]Order, error)
+	// List returns a page of the orders matching f, newest first.
+	List(f filter.Expr, limit, offset int, count db.Count) (orders []Order, total int, err error)
+
+	// ListCartLines returns the cart of the user, oldest line first.
+	ListCartLines(userID string) ([]CartLine, error)
+	GetCartLine(userID, bookID string) (CartLine, error)
+	SaveCartLine(l *CartLine) error
+	DeleteCartLine(userID, bookID string) error
+
+	// SaveLines replaces the lines of the order, and its items with the
+	// books of the lines, saving its total and the adjustment a if not nil.
+	SaveLines(o *Order, a *Adjustment) error
 	Drop() error
 }
@@ -3,12 +3,26 @@ package order
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/validate"
 )
 
 var (
 	ErrOrderNotFound = errors.New("order not found")
 )
 
+// PatchFields are the JSON names of the order fields Patch can change, the
+// items and the prices are set at checkout.
+var PatchFields = []string{"note"}
+
+// MaxNoteLength caps the customer note of an order.
+const MaxNoteLength = 500
+
 type Service interface {
 	// PlaceOrder creates an order for particular book.
 	PlaceOrder(ctx context.Context, bookID string) (Order, error)
@@ -18,20 +32,75 @@ type Service interface {
 
 	// CancelOrder cancels the particular order of an user.
 	CancelOrder(ctx context.Context, userID string, orderID string) error
+
+	// Patch applies a JSON merge patch to an order of an user.
+	Patch(ctx context.Context, userID, orderID string, p []byte) (Order, error)
+
+	// List returns a page of all the orders matching the filter f on
+	// ListFields, newest first, for the shop admins. count tells how to
+	// compute the total.
+	List(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) (orders []Order, total int, err error)
+
+	// Cart returns the cart of the user, its lines revalidated against the
+	// catalog.
+	Cart(ctx context.Context, userID string) (Cart, error)
+
+	// AddToCart adds a book to the cart of the user.
+	AddToCart(ctx context.Context, userID, bookID string) (Cart, error)
+
+	// RemoveFromCart removes a book from the cart of the user.
+	RemoveFromCart(ctx context.Context, userID, bookID string) (Cart, error)
+
+	// ConfirmCart accepts the changes of the cart since it was loaded.
+	ConfirmCart(ctx context.Context, userID string) (Cart, error)
+
+	// Checkout returns the order of the cart, which must be unchanged.
+	Checkout(ctx context.Context, userID string) (Order, error)
+
+	// AddLine adds a book to a paid order not shipped yet, for the admins,
+	// charging the difference.
+	AddLine(ctx context.Context, orderID, bookID string, quantity int) (Edit, error)
+
+	// SetQuantity changes the quantity of a line of a paid order not
+	// shipped yet, for the admins, removing the line at 0. The difference
+	// is charged or refunded.
+	SetQuantity(ctx context.Context, orderID, bookID string, quantity int) (Edit, error)
+}
+
+// Books reads the books ordered as the customer sees them in the catalog,
+// priced in the market of the shop context, e.g. catalog.Service.
+type Books interface {
+	Get(ctx context.Context, id string) (catalog.Book, error)
 }
 
 type basicService struct {
-	r Repo
+	r        Repo
+	books    Books
+	payments Payments
 }
 
 // NewOrderService return basic Service implementation.
-func NewService(r Repo) Service {
-	return basicService{r}
+func NewService(r Repo, books Books, payments Payments) Service {
+	return basicService{r: r, books: books, payments: payments}
 }
 
-// PlaceOrder creates an order for particular book.
+// PlaceOrder creates an order for particular book, at the price and in the
+// currency the catalog shows it at.
 func (s basicService) PlaceOrder(ctx context.Context, bookID string) (Order, error) {
-	return Order{}, nil
+	b, err := s.books.Get(ctx, bookID)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return Order{}, catalog.ErrBookNotFound
+		}
+		return Order{}, err
+	}
+	return Order{
+		Items:      []catalog.Book{b},
+		Lines:      []Line{{BookID: b.ID, Quantity: 1, Price: b.Price}},
+		TotalPrice: b.Price,
+		Currency:   b.Currency,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
 }
 
 // GetUserOrders return all the orders placed by particular user.
@@ -45,4 +114,30 @@ func (s basicService) CancelOrder(ctx context.Context, userID string, orderID st
 	return nil
 }
 
+// Patch applies a JSON merge patch to the PatchFields of an order placed by
+// the user.
+func (s basicService) Patch(ctx context.Context, userID, orderID string, p []byte) (Order, error) {
+	o, err := s.r.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Order{}, ErrOrderNotFound
+	}
+	if err := patch.Apply(&o, p, PatchFields...); err != nil {
+		return Order{}, err
+	}
+	var v validate.Validator
+	v.MaxLength("note", o.Note, MaxNoteLength)
+	if err := v.Err(); err != nil {
+		return Order{}, err
+	}
+	if err := s.r.Save(&o); err != nil {
+		return Order{}, err
+	}
+	return o, nil
+}
+
+// List returns a page of the orders matching f.
+func (s basicService) List(ctx context.Context, f filter.Expr, limit, offset int, count db.Count) ([]Order, int, error) {
+	return s.r.List(f, limit, offset, count)
+}
+
 type Middleware func(Service) Service
@@ -3,13 +3,26 @@ package order
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"context"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
 	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
 	"github.com/pkg/errors"
 )
 
@@ -17,10 +30,37 @@ var (
 	ErrBadRouting = errors.New("bad routing")
 )
 
-func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
-	e := MakeEndpoints(s)
+const defaultPageLimit = 20
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrOrderNotFound: "order.not_found",
+		ErrCartEmpty:     "order.cart_empty",
+		ErrCartChanged:   "order.cart_changed",
+		ErrCartCurrency:  "order.cart_currency",
+
+		ErrNotPaid:          "order.not_paid",
+		ErrShipped:          "order.shipped",
+		ErrNoLines:          "order.no_lines",
+		ErrLineNotFound:     "order.line_not_found",
+		ErrInvalidQuantity:  "order.invalid_quantity",
+		ErrEmptyOrder:       "order.empty_order",
+		ErrCurrencyMismatch: "order.currency_mismatch",
+	})
+}
+
+// MakeHTTPHandler mounts the order endpoints, the shop admin ones served to
+// the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
 	}
 	placeOrderHandler := httptransport.NewServer(
 		e.PlaceOrderEndpoint,
@@ -41,20 +81,149 @@ func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Han
 		options...,
 	)
 
+	patchHandler := httptransport.NewServer(
+		e.PatchEndpoint,
+		decodePatchRequest,
+		encodeResponse,
+		options...,
+	)
+
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeListRequest,
+		encodeResponse,
+		options...,
+	)
+	cartHandler := httptransport.NewServer(
+		e.CartEndpoint,
+		decodeCartRequest,
+		encodeResponse,
+		options...,
+	)
+	addToCartHandler := httptransport.NewServer(
+		e.AddToCartEndpoint,
+		decodeAddToCartRequest,
+		encodeResponse,
+		options...,
+	)
+	removeFromCartHandler := httptransport.NewServer(
+		e.RemoveFromCartEndpoint,
+		decodeCartRequest,
+		encodeResponse,
+		options...,
+	)
+	confirmCartHandler := httptransport.NewServer(
+		e.ConfirmCartEndpoint,
+		decodeCartRequest,
+		encodeResponse,
+		options...,
+	)
+	checkoutHandler := httptransport.NewServer(
+		e.CheckoutEndpoint,
+		decodeCartRequest,
+		encodeResponse,
+		options...,
+	)
+	addLineHandler := httptransport.NewServer(
+		e.AddLineEndpoint,
+		decodeLineRequest,
+		encodeResponse,
+		options...,
+	)
+	setQuantityHandler := httptransport.NewServer(
+		e.SetQuantityEndpoint,
+		decodeLineRequest,
+		encodeResponse,
+		options...,
+	)
+	removeLineHandler := httptransport.NewServer(
+		e.RemoveLineEndpoint,
+		decodeLineRequest,
+		encodeResponse,
+		options...,
+	)
+
 	r := mux.NewRouter()
 
+	r.Handle("/orders/v1/admin/list", listHandler).Methods("GET")
+	r.Handle("/orders/v1/admin/{id}/lines", addLineHandler).Methods("POST")
+	r.Handle("/orders/v1/admin/{id}/lines/{book-id}", setQuantityHandler).Methods("PUT")
+	r.Handle("/orders/v1/admin/{id}/lines/{book-id}", removeLineHandler).Methods("DELETE")
 	r.Handle("/orders/v1/place", placeOrderHandler).Methods("POST")
 	r.Handle("/orders/v1/{user-id}", getUserOrdersHandler).Methods("GET")
 	r.Handle("/orders/v1/{user-id}/cancel/{id}", cancelOrdersHandler).Methods("POST")
+	r.Handle("/orders/v1/{user-id}/cart", cartHandler).Methods("GET")
+	r.Handle("/orders/v1/{user-id}/cart/items", addToCartHandler).Methods("POST")
+	r.Handle("/orders/v1/{user-id}/cart/items/{book-id}", removeFromCartHandler).Methods("DELETE")
+	r.Handle("/orders/v1/{user-id}/cart/confirm", confirmCartHandler).Methods("POST")
+	r.Handle("/orders/v1/{user-id}/cart/checkout", checkoutHandler).Methods("POST")
+	r.Handle("/orders/v1/{user-id}/{id}", patchHandler).Methods("PATCH")
+
+	allow.Methods(r)
 
 	return r
 }
 func decodePlaceOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	var r placeOrderRequest
-	err := json.NewDecoder(req.Body).Decode(&r)
+	err := schema.Decode(req.Body, &r)
 	return r, err
 }
 
+// decodeCartRequest reads the user of the cart and the book of the line, if
+// any, from the path.
+func decodeCartRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	userID, ok := vars["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	return cartRequest{UserID: userID, BookID: vars["book-id"]}, nil
+}
+
+func decodeAddToCartRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r cartRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	r.UserID = userID
+	return r, nil
+}
+
+// decodeLineRequest reads the order and the book of the line, if any, from
+// the path, and the quantity from the body. A line is added once unless
+// told otherwise, a quantity is required to change one.
+func decodeLineRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var body struct {
+		BookID   string `json:"book_id"`
+		Quantity *int   `json:"quantity"`
+	}
+	if req.Method != "DELETE" {
+		if err := schema.Decode(req.Body, &body); err != nil {
+			return nil, err
+		}
+	}
+	vars := mux.Vars(req)
+	orderID, ok := vars["id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "id")
+	}
+	r := lineRequest{OrderID: orderID, BookID: body.BookID, Quantity: 1}
+	if bookID, ok := vars["book-id"]; ok {
+		r.BookID = bookID
+		if req.Method == "PUT" && body.Quantity == nil {
+			return nil, ErrInvalidQuantity
+		}
+	}
+	if body.Quantity != nil {
+		r.Quantity = *body.Quantity
+	}
+	return r, nil
+}
+
 func decodeGetUserOrdersRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	vars := mux.Vars(req)
 	userID, ok := vars["user-id"]
@@ -83,6 +252,50 @@ func decodeCancelOrderRequest(ctx context.Context, req *http.Request) (interface
 	}, nil
 }
 
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	lreq := listRequest{URL: req.URL}
+
+	// Ignoring errors since zero values makes sense for limit and offset
+	lreq.Limit, _ = strconv.Atoi(req.FormValue("limit"))
+	if lreq.Limit <= 0 {
+		lreq.Limit = defaultPageLimit
+	}
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+
+	count, err := db.ParseCount(req.FormValue("count"))
+	if err != nil {
+		return nil, err
+	}
+	lreq.Count = count
+
+	// filter=total gt 50 and currency eq "EUR"
+	if lreq.Filter, err = filter.Parse(req.FormValue("filter"), ListFields); err != nil {
+		return nil, err
+	}
+	return lreq, nil
+}
+
+func decodePatchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	userID, ok := vars["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	ID, ok := vars["id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "id")
+	}
+	p, err := patch.Read(req)
+	if err != nil {
+		return nil, err
+	}
+	return patchRequest{
+		UserID:  userID,
+		OrderID: ID,
+		Patch:   p,
+	}, nil
+}
+
 // errorer interface should be implemented by all the doman specific errors.
 // easy to set different status code in case of different errors.
 type errorer interface {
@@ -126,10 +339,10 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) e
 		f.Meta.Next = n
 	}
 
-	return json.NewEncoder(w).Encode(f)
+	return transport.Encode(ctx, w, f)
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
@@ -138,15 +351,23 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	// root error which is domain specific
 	code := codeFrom(errors.Cause(err))
 	w.WriteHeader(code)
-	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Error: err.Error()}}
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
 	json.NewEncoder(w).Encode(f)
 }
 
 func codeFrom(err error) int {
 	switch err {
-	case ErrOrderNotFound:
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrOrderNotFound, ErrLineNotFound, catalog.ErrBookNotFound:
 		return http.StatusNotFound
-	case ErrBadRouting:
+	case ErrCartChanged, ErrCartCurrency, ErrNotPaid, ErrShipped, ErrNoLines, ErrEmptyOrder, ErrCurrencyMismatch:
+		return http.StatusConflict
+	case patch.ErrContentType:
+		return http.StatusUnsupportedMediaType
+	case ErrBadRouting, ErrCartEmpty, ErrInvalidQuantity, validate.ErrInvalid, patch.ErrNotObject, db.ErrBadCount, filter.ErrInvalid:
 		return http.StatusBadRequest
 	default:
 		return http.StatusInternalServerError
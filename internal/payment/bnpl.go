@@ -0,0 +1,193 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBNPLDisabled          = errors.New("buy now pay later is disabled")
+	ErrNotEligible           = errors.New("order is not eligible to buy now pay later")
+	ErrAuthorizationNotFound = errors.New("buy now pay later authorization not found")
+	ErrInvalidSignature      = errors.New("invalid webhook signature")
+)
+
+// Statuses of an authorization. The customer approves or declines it at the
+// provider, it is captured once the order ships.
+const (
+	AuthPending  = "pending"
+	AuthApproved = "approved"
+	AuthDeclined = "declined"
+	AuthCaptured = "captured"
+)
+
+// transitions are the statuses an authorization moves to from a status.
+var transitions = map[string][]string{
+	AuthPending:  {AuthApproved, AuthDeclined},
+	AuthApproved: {AuthCaptured},
+}
+
+// Reasons of the ineligible orders.
+const (
+	ReasonBelowMinimum = "below_minimum"
+	ReasonAboveMaximum = "above_maximum"
+	ReasonProvider     = "declined_by_provider"
+)
+
+// Authorization is an order paid later through the buy now pay later
+// provider, e.g. in 4 instalments.
+type Authorization struct {
+	ID        string `json:"id"`
+	OrderID   string `json:"order_id" sql:"index"`
+	Provider  string `json:"provider"`
+	Reference string `json:"reference"` // session at the provider
+	Status    string `json:"status"`
+	// RedirectURL is where the customer approves the payment.
+	RedirectURL string     `json:"redirect_url,omitempty"`
+	Amount      float64    `json:"amount"`
+	Currency    string     `json:"currency"`
+	CapturedAt  *time.Time `json:"captured_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Authorization) TableName() string {
+	return "payment_authorizations"
+}
+
+// Move tells whether the authorization can move to status, moving it if so.
+func (a *Authorization) Move(status string) bool {
+	for _, s := range transitions[a.Status] {
+		if s == status {
+			a.Status = status
+			a.UpdatedAt = time.Now().UTC()
+			return true
+		}
+	}
+	return false
+}
+
+// Eligibility tells whether an order can be paid later, the reason being
+// set otherwise.
+type Eligibility struct {
+	Eligible bool   `json:"eligible"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Session is a payment started at the provider.
+type Session struct {
+	Reference   string `json:"id"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+// AuthUpdate is the status of a session pushed by the provider.
+type AuthUpdate struct {
+	Reference string `json:"id"`
+	Status    string `json:"status"`
+}
+
+// BNPLProvider abstracts the buy now pay later provider, e.g. Klarna or
+// Afterpay.
+type BNPLProvider interface {
+	// Name uniquely identifies the provider.
+	Name() string
+
+	// Eligible tells whether the provider would finance amount for a
+	// customer of country.
+	Eligible(ctx context.Context, amount float64, currency, country string) (bool, error)
+
+	// CreateSession starts the payment of an order, the customer approving
+	// it at the redirect URL of the session.
+	CreateSession(ctx context.Context, orderID string, amount float64, currency, country string) (Session, error)
+
+	// Capture captures amount of an approved session.
+	Capture(ctx context.Context, ref string, amount float64, currency string) error
+
+	// VerifyWebhook tells whether the webhook body is signed by the provider.
+	VerifyWebhook(signature string, body []byte) bool
+}
+
+type bnplProvider struct {
+	name   string
+	secret string
+	httpProvider
+}
+
+// NewBNPLProvider returns BNPLProvider talking to the REST API at baseURL,
+// amounts being sent in cents
+//
+//	POST <baseURL>/eligibility {"amount": 9900, "currency": "EUR", "country": "DE"}
+//	{"eligible": true}
+//	POST <baseURL>/sessions {"reference": "<order>", "amount": 9900, ...}
+//	{"id": "...", "redirect_url": "..."}
+//	POST <baseURL>/sessions/<id>/capture {"amount": 9900, "currency": "EUR"}
+//
+// secret is used to verify the webhook signatures, a hex encoded
+// HMAC-SHA256 of the body.
+func NewBNPLProvider(name, baseURL, apiKey, secret string, client *http.Client) BNPLProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return bnplProvider{
+		name:         name,
+		secret:       secret,
+		httpProvider: httpProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client},
+	}
+}
+
+func (p bnplProvider) Name() string {
+	return p.name
+}
+
+type bnplRequest struct {
+	Reference string `json:"reference,omitempty"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Country   string `json:"country,omitempty"`
+}
+
+func (p bnplProvider) Eligible(ctx context.Context, amount float64, currency, country string) (bool, error) {
+	var res struct {
+		Eligible bool `json:"eligible"`
+	}
+	err := p.post(ctx, p.name+" eligibility", "/eligibility", "",
+		bnplRequest{Amount: cents(amount), Currency: currency, Country: country}, &res)
+	return res.Eligible, err
+}
+
+func (p bnplProvider) CreateSession(ctx context.Context, orderID string, amount float64, currency, country string) (Session, error) {
+	var s Session
+	err := p.post(ctx, p.name+" session", "/sessions", "",
+		bnplRequest{Reference: orderID, Amount: cents(amount), Currency: currency, Country: country}, &s)
+	return s, err
+}
+
+func (p bnplProvider) Capture(ctx context.Context, ref string, amount float64, currency string) error {
+	var res struct{}
+	// The session keeps a retried capture from capturing twice.
+	return p.post(ctx, p.name+" capture", "/sessions/"+url.PathEscape(ref)+"/capture", ref,
+		bnplRequest{Amount: cents(amount), Currency: currency}, &res)
+}
+
+func (p bnplProvider) VerifyWebhook(signature string, body []byte) bool {
+	return signed(p.secret, signature, body)
+}
+
+// signed tells whether signature is the hex encoded HMAC-SHA256 of body
+// with secret, refusing all the bodies without a secret.
+func signed(secret, signature string, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
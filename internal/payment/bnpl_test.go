@@ -0,0 +1,205 @@
+package payment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payment"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// paymentRepo keeps the authorizations and intents in memory, in order of
+// creation.
+type paymentRepo struct {
+	payment.Repo
+	auths    []payment.Authorization
+	intents  []payment.Intent
+	disputes []payment.Dispute
+}
+
+func (r *paymentRepo) CreateAuthorization(a *payment.Authorization) error {
+	a.ID = a.Reference
+	r.auths = append(r.auths, *a)
+	return nil
+}
+
+func (r *paymentRepo) SaveAuthorization(a *payment.Authorization) error {
+	for i := range r.auths {
+		if r.auths[i].ID == a.ID {
+			r.auths[i] = *a
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *paymentRepo) GetAuthorization(orderID string) (payment.Authorization, error) {
+	for i := len(r.auths) - 1; i >= 0; i-- {
+		if r.auths[i].OrderID == orderID {
+			return r.auths[i], nil
+		}
+	}
+	return payment.Authorization{}, db.ErrNotFound
+}
+
+func (r *paymentRepo) GetAuthorizationByReference(provider, ref string) (payment.Authorization, error) {
+	for _, a := range r.auths {
+		if a.Provider == provider && a.Reference == ref {
+			return a, nil
+		}
+	}
+	return payment.Authorization{}, db.ErrNotFound
+}
+
+func (r *paymentRepo) ListAuthorizations(status string) ([]payment.Authorization, error) {
+	auths := make([]payment.Authorization, 0)
+	for _, a := range r.auths {
+		if a.Status == status {
+			auths = append(auths, a)
+		}
+	}
+	return auths, nil
+}
+
+// bnpl finances the customers of DE, recording the sessions and captures.
+type bnpl struct {
+	sessions int
+	captured []float64
+}
+
+func (p *bnpl) Name() string {
+	return "later"
+}
+
+func (p *bnpl) Eligible(ctx context.Context, amount float64, currency, country string) (bool, error) {
+	return country == "DE", nil
+}
+
+func (p *bnpl) CreateSession(ctx context.Context, orderID string, amount float64, currency, country string) (payment.Session, error) {
+	p.sessions++
+	return payment.Session{Reference: "ses_" + orderID, RedirectURL: "https://later.example/ses_" + orderID}, nil
+}
+
+func (p *bnpl) Capture(ctx context.Context, ref string, amount float64, currency string) error {
+	p.captured = append(p.captured, amount)
+	return nil
+}
+
+func (p *bnpl) VerifyWebhook(signature string, body []byte) bool {
+	return signature == "ok"
+}
+
+func TestBNPL(t *testing.T) {
+	ctx := context.Background()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", TotalPrice: 50, Currency: "EUR"},
+		"o2": {ID: "o2", TotalPrice: 10, Currency: "EUR"},
+	}}
+	r, p := &paymentRepo{}, &bnpl{}
+	s := payment.NewService(r, orders, &provider{}, p, nil, nil, payment.Config{BNPLMinAmount: 35, BNPLMaxAmount: 1000})
+
+	for _, tc := range []struct {
+		order, country string
+		want           payment.Eligibility
+	}{
+		{"o1", "DE", payment.Eligibility{Eligible: true}},
+		{"o1", "US", payment.Eligibility{Reason: payment.ReasonProvider}},
+		{"o2", "DE", payment.Eligibility{Reason: payment.ReasonBelowMinimum}},
+	} {
+		if el, err := s.BNPLEligibility(ctx, tc.order, tc.country); err != nil || el != tc.want {
+			t.Errorf("%s %s: expected %+v, got %+v, %v", tc.order, tc.country, tc.want, el, err)
+		}
+	}
+	if _, err := s.StartBNPL(ctx, "o2", "DE"); err != payment.ErrNotEligible {
+		t.Errorf("start o2: expected ErrNotEligible, got %v", err)
+	}
+
+	a, err := s.StartBNPL(ctx, "o1", "DE")
+	if err != nil || a.Status != payment.AuthPending || a.RedirectURL == "" {
+		t.Fatalf("start: expected a pending authorization, got %+v, %v", a, err)
+	}
+	if again, err := s.StartBNPL(ctx, "o1", "DE"); err != nil || again.ID != a.ID || p.sessions != 1 {
+		t.Errorf("again: expected the pending authorization, got %+v, %v", again, err)
+	}
+
+	approved := []byte(`{"id":"ses_o1","status":"approved"}`)
+	if err := s.BNPLWebhook(ctx, "forged", approved); err != payment.ErrInvalidSignature {
+		t.Errorf("forged: expected ErrInvalidSignature, got %v", err)
+	}
+	if err := s.BNPLWebhook(ctx, "ok", []byte(`{"id":"ses_o9","status":"approved"}`)); err != payment.ErrAuthorizationNotFound {
+		t.Errorf("unknown: expected ErrAuthorizationNotFound, got %v", err)
+	}
+	if err := s.BNPLWebhook(ctx, "ok", approved); err != nil || orders.orders["o1"].PaidAt == nil {
+		t.Fatalf("approve: expected the order paid, got %+v, %v", orders.orders["o1"], err)
+	}
+	if err := s.BNPLWebhook(ctx, "ok", []byte(`{"id":"ses_o1","status":"declined"}`)); err != nil || r.auths[0].Status != payment.AuthApproved {
+		t.Errorf("decline: expected the approval kept, got %s, %v", r.auths[0].Status, err)
+	}
+
+	if n, err := s.CaptureShipped(ctx); err != nil || n != 0 {
+		t.Errorf("unshipped: expected no capture, got %d, %v", n, err)
+	}
+	o := orders.orders["o1"]
+	shipped := time.Now().UTC()
+	o.ShippedAt, o.TotalPrice = &shipped, 45
+	orders.orders["o1"] = o
+	if n, err := s.CaptureShipped(ctx); err != nil || n != 1 || len(p.captured) != 1 || p.captured[0] != 45 {
+		t.Errorf("shipped: expected 45 captured, got %d, %v, %v", n, p.captured, err)
+	}
+	if r.auths[0].Status != payment.AuthCaptured || r.auths[0].CapturedAt == nil {
+		t.Errorf("shipped: expected the authorization captured, got %+v", r.auths[0])
+	}
+	if n, _ := s.CaptureShipped(ctx); n != 0 {
+		t.Errorf("again: expected no capture, got %d", n)
+	}
+}
+
+func TestCaptureRequiresAdmin(t *testing.T) {
+	shipped := time.Now().UTC()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", TotalPrice: 50, Currency: "EUR", ShippedAt: &shipped},
+	}}
+	r, p := &paymentRepo{}, &bnpl{}
+	r.auths = []payment.Authorization{{ID: "a1", OrderID: "o1", Provider: "later", Reference: "ses_o1", Status: payment.AuthApproved}}
+	s := payment.NewService(r, orders, &provider{}, p, nil, nil, payment.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := payment.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest("POST", "/payments/v1/admin/bnpl/capture", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status || len(p.captured) != 0 {
+			t.Errorf("%s: expected %d and no capture, got %d, %v", c.name, c.status, w.Code, p.captured)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/payments/v1/admin/bnpl/capture", nil)
+	req.Header.Set("Authorization", "Bearer "+staff)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code >= 300 || len(p.captured) != 1 {
+		t.Errorf("admin: expected the order captured, got %d, %v: %s", w.Code, p.captured, w.Body)
+	}
+}
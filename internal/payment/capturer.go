@@ -0,0 +1,25 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunCapturer captures the buy now pay later payments of the shipped orders
+// every interval until ctx is done.
+func RunCapturer(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CaptureShipped(ctx); err != nil {
+				logger.Log("capturer", "payment", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/notification/email"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrDisputeNotFound = errors.New("dispute not found")
+)
+
+// Statuses of a dispute, as told by the provider. A dispute needing a
+// response waits for the evidence of the shop until EvidenceDueBy, then is
+// reviewed by the bank of the customer.
+const (
+	DisputeNeedsResponse = "needs_response"
+	DisputeUnderReview   = "under_review"
+	DisputeWon           = "won"
+	DisputeLost          = "lost"
+)
+
+// Dispute is a chargeback of a payment asked by the customer at their
+// bank. A lost dispute reverses the revenue of the order, booked as a
+// chargeback by the accounting export.
+type Dispute struct {
+	ID        string  `json:"id"`
+	OrderID   string  `json:"order_id" sql:"index"`
+	Reference string  `json:"reference" sql:"unique_index"` // dispute at the provider
+	Payment   string  `json:"payment"`                      // payment disputed at the provider
+	Reason    string  `json:"reason"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	Status    string  `json:"status" sql:"index"`
+	// EvidenceDueBy is the deadline of the evidence, while the dispute
+	// needs a response.
+	EvidenceDueBy *time.Time `json:"evidence_due_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// ClosedAt is set once the dispute is won or lost.
+	ClosedAt *time.Time `json:"closed_at,omitempty" sql:"index"`
+}
+
+func (Dispute) TableName() string {
+	return "payment_disputes"
+}
+
+// Closed tells whether the dispute was decided.
+func (d Dispute) Closed() bool {
+	return d.Status == DisputeWon || d.Status == DisputeLost
+}
+
+// disputeEvent is a dispute pushed by the provider.
+type disputeEvent struct {
+	Reference     string     `json:"id"`
+	Payment       string     `json:"payment"`
+	Reason        string     `json:"reason"`
+	Status        string     `json:"status"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	EvidenceDueBy *time.Time `json:"evidence_due_by"`
+}
+
+// Notifier tells the staff about the disputes.
+type Notifier interface {
+	// DisputeOpened tells a customer disputes the payment of o.
+	DisputeOpened(d Dispute, o order.Order) error
+}
+
+type emailNotifier struct {
+	staff []string
+}
+
+// NewEmailNotifier returns a Notifier emailing the staff addresses.
+func NewEmailNotifier(staff []string) Notifier {
+	return emailNotifier{staff: staff}
+}
+
+func (n emailNotifier) DisputeOpened(d Dispute, o order.Order) error {
+	if len(n.staff) == 0 {
+		return nil
+	}
+	return email.DisputeOpened(n.staff, map[string]interface{}{
+		"dispute": d,
+		"order":   o,
+	})
+}
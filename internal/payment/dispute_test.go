@@ -0,0 +1,133 @@
+package payment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payment"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func (r *paymentRepo) CreateDispute(d *payment.Dispute) error {
+	d.ID = d.Reference
+	r.disputes = append(r.disputes, *d)
+	return nil
+}
+
+func (r *paymentRepo) SaveDispute(d *payment.Dispute) error {
+	for i := range r.disputes {
+		if r.disputes[i].ID == d.ID {
+			r.disputes[i] = *d
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *paymentRepo) GetDisputeByReference(ref string) (payment.Dispute, error) {
+	for _, d := range r.disputes {
+		if d.Reference == ref {
+			return d, nil
+		}
+	}
+	return payment.Dispute{}, db.ErrNotFound
+}
+
+func (r *paymentRepo) ListDisputes(status string) ([]payment.Dispute, error) {
+	disputes := make([]payment.Dispute, 0)
+	for _, d := range r.disputes {
+		if status == "" || d.Status == status {
+			disputes = append(disputes, d)
+		}
+	}
+	return disputes, nil
+}
+
+// notifier records the disputes the staff is told about.
+type notifier struct {
+	opened []string
+}
+
+func (n *notifier) DisputeOpened(d payment.Dispute, o order.Order) error {
+	n.opened = append(n.opened, d.ID+":"+o.ID)
+	return nil
+}
+
+func TestDisputes(t *testing.T) {
+	ctx := context.Background()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", TotalPrice: 20, Currency: "EUR"},
+	}}
+	r := &paymentRepo{intents: []payment.Intent{{ID: "pay_o1", OrderID: "o1", Reference: "pay_o1", Status: payment.IntentSucceeded}}}
+	n := &notifier{}
+	s := payment.NewService(r, orders, &provider{}, nil, n, nil, payment.Config{WebhookSecret: "secret"})
+	webhook := func(body string) error {
+		return s.DisputeWebhook(ctx, sign("secret", []byte(body)), []byte(body))
+	}
+
+	opened := `{"id":"dp_1","payment":"pay_o1","reason":"fraudulent","status":"needs_response","amount":20,"currency":"EUR","evidence_due_by":"2026-03-10T00:00:00Z"}`
+	if err := s.DisputeWebhook(ctx, "forged", []byte(opened)); err != payment.ErrInvalidSignature {
+		t.Errorf("forged: expected ErrInvalidSignature, got %v", err)
+	}
+	if err := webhook(opened); err != nil {
+		t.Fatalf("opened: unexpected error %v", err)
+	}
+	if len(r.disputes) != 1 || r.disputes[0].OrderID != "o1" || r.disputes[0].EvidenceDueBy == nil || r.disputes[0].ClosedAt != nil {
+		t.Fatalf("opened: expected an open dispute of o1, got %+v", r.disputes)
+	}
+	if len(n.opened) != 1 || n.opened[0] != "dp_1:o1" {
+		t.Errorf("opened: expected the staff told, got %v", n.opened)
+	}
+
+	if err := webhook(`{"id":"dp_1","payment":"pay_o1","status":"lost"}`); err != nil {
+		t.Fatalf("lost: unexpected error %v", err)
+	}
+	if d := r.disputes[0]; d.Status != payment.DisputeLost || d.ClosedAt == nil || d.EvidenceDueBy != nil || len(n.opened) != 1 {
+		t.Errorf("lost: expected the dispute closed, got %+v", d)
+	}
+	if err := webhook(`{"id":"dp_1","payment":"pay_o1","status":"under_review"}`); err != nil || r.disputes[0].Status != payment.DisputeLost {
+		t.Errorf("late: expected the loss kept, got %s, %v", r.disputes[0].Status, err)
+	}
+
+	if err := webhook(`{"id":"dp_2","payment":"pay_o9","status":"needs_response"}`); err != payment.ErrIntentNotFound {
+		t.Errorf("unknown payment: expected ErrIntentNotFound, got %v", err)
+	}
+}
+
+func TestDisputesRequireAdmin(t *testing.T) {
+	r := &paymentRepo{disputes: []payment.Dispute{{ID: "dp_1", Reference: "dp_1", OrderID: "o1"}}}
+	s := payment.NewService(r, &orderRepo{}, &provider{}, nil, nil, nil, payment.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := payment.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/payments/v1/admin/disputes", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
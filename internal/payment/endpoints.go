@@ -0,0 +1,280 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the payment service endpoints under single type.
+type Endpoints struct {
+	WalletsEndpoint          endpoint.Endpoint
+	ValidateMerchantEndpoint endpoint.Endpoint
+	PayWithWalletEndpoint    endpoint.Endpoint
+	ConfirmPaymentEndpoint   endpoint.Endpoint
+	PaymentWebhookEndpoint   endpoint.Endpoint
+
+	BNPLEligibilityEndpoint endpoint.Endpoint
+	StartBNPLEndpoint       endpoint.Endpoint
+	BNPLWebhookEndpoint     endpoint.Endpoint
+	CaptureShippedEndpoint  endpoint.Endpoint
+
+	DisputeWebhookEndpoint endpoint.Endpoint
+	DisputesEndpoint       endpoint.Endpoint
+	DisputeEndpoint        endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the payment service endpoints. The shop admin endpoints are
+// restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		WalletsEndpoint:          MakeWalletsEndpoint(s),
+		ValidateMerchantEndpoint: MakeValidateMerchantEndpoint(s),
+		PayWithWalletEndpoint:    MakePayWithWalletEndpoint(s),
+		ConfirmPaymentEndpoint:   MakeConfirmPaymentEndpoint(s),
+		PaymentWebhookEndpoint:   MakePaymentWebhookEndpoint(s),
+
+		BNPLEligibilityEndpoint: MakeBNPLEligibilityEndpoint(s),
+		StartBNPLEndpoint:       MakeStartBNPLEndpoint(s),
+		BNPLWebhookEndpoint:     MakeBNPLWebhookEndpoint(s),
+		CaptureShippedEndpoint:  admin(MakeCaptureShippedEndpoint(s)),
+
+		DisputeWebhookEndpoint: MakeDisputeWebhookEndpoint(s),
+		DisputesEndpoint:       admin(MakeDisputesEndpoint(s)),
+		DisputeEndpoint:        admin(MakeDisputeEndpoint(s)),
+	}
+}
+
+func MakeWalletsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		c, e := s.Wallets(ctx)
+		if e != nil {
+			return walletsResponse{Error: e}, nil
+		}
+		return walletsResponse{Wallets: &c}, nil
+	}
+}
+
+func MakeValidateMerchantEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(validateMerchantRequest)
+		session, e := s.ValidateMerchant(ctx, req.ValidationURL)
+		if e != nil {
+			return validateMerchantResponse{Error: e}, nil
+		}
+		return validateMerchantResponse{Session: session}, nil
+	}
+}
+
+func MakePayWithWalletEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(payWithWalletRequest)
+		i, e := s.PayWithWallet(ctx, req.OrderID, req.WalletPayment)
+		if e != nil {
+			return intentResponse{Error: e}, nil
+		}
+		return intentResponse{Intent: &i}, nil
+	}
+}
+
+func MakeConfirmPaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderRequest)
+		i, e := s.ConfirmPayment(ctx, req.OrderID)
+		if e != nil {
+			return intentResponse{Error: e}, nil
+		}
+		return intentResponse{Intent: &i}, nil
+	}
+}
+
+func MakePaymentWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.PaymentWebhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+func MakeBNPLEligibilityEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(startBNPLRequest)
+		el, e := s.BNPLEligibility(ctx, req.OrderID, req.Country)
+		if e != nil {
+			return eligibilityResponse{Error: e}, nil
+		}
+		return eligibilityResponse{Eligibility: &el}, nil
+	}
+}
+
+func MakeStartBNPLEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(startBNPLRequest)
+		a, e := s.StartBNPL(ctx, req.OrderID, req.Country)
+		if e != nil {
+			return authorizationResponse{Error: e}, nil
+		}
+		return authorizationResponse{Authorization: &a, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeBNPLWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.BNPLWebhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+func MakeCaptureShippedEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		n, e := s.CaptureShipped(ctx)
+		return captureResponse{Captured: n, Error: e}, nil
+	}
+}
+
+func MakeDisputeWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.DisputeWebhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+func MakeDisputesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(disputesRequest)
+		disputes, e := s.Disputes(ctx, req.Status)
+		return disputesResponse{Disputes: disputes, Error: e}, nil
+	}
+}
+
+func MakeDisputeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(disputeRequest)
+		d, e := s.Dispute(ctx, req.ID)
+		if e != nil {
+			return disputeResponse{Error: e}, nil
+		}
+		return disputeResponse{Dispute: &d}, nil
+	}
+}
+
+type walletsResponse struct {
+	Wallets *WalletConfig `json:"wallets,omitempty"`
+	Error   error         `json:"error,omitempty"`
+}
+
+func (r walletsResponse) error() error {
+	return r.Error
+}
+
+type validateMerchantRequest struct {
+	ValidationURL string `json:"validation_url"`
+}
+
+type validateMerchantResponse struct {
+	Session json.RawMessage `json:"session,omitempty"`
+	Error   error           `json:"error,omitempty"`
+}
+
+func (r validateMerchantResponse) error() error {
+	return r.Error
+}
+
+type payWithWalletRequest struct {
+	OrderID string `json:"-"`
+	WalletPayment
+}
+
+type orderRequest struct {
+	OrderID string
+}
+
+type intentResponse struct {
+	Intent *Intent `json:"intent,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r intentResponse) error() error {
+	return r.Error
+}
+
+type startBNPLRequest struct {
+	OrderID string `json:"-"`
+	Country string `json:"country"`
+}
+
+type eligibilityResponse struct {
+	Eligibility *Eligibility `json:"eligibility,omitempty"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r eligibilityResponse) error() error {
+	return r.Error
+}
+
+type authorizationResponse struct {
+	Status        int            `json:"-"`
+	Authorization *Authorization `json:"authorization,omitempty"`
+	Error         error          `json:"error,omitempty"`
+}
+
+func (r authorizationResponse) status() int {
+	return r.Status
+}
+
+func (r authorizationResponse) error() error {
+	return r.Error
+}
+
+type webhookRequest struct {
+	Signature string
+	Body      []byte
+}
+
+type webhookResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r webhookResponse) error() error {
+	return r.Error
+}
+
+type captureResponse struct {
+	Captured int   `json:"captured"`
+	Error    error `json:"error,omitempty"`
+}
+
+func (r captureResponse) error() error {
+	return r.Error
+}
+
+type disputesRequest struct {
+	Status string
+}
+
+type disputesResponse struct {
+	Disputes []Dispute `json:"disputes,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r disputesResponse) error() error {
+	return r.Error
+}
+
+type disputeRequest struct {
+	ID string
+}
+
+type disputeResponse struct {
+	Dispute *Dispute `json:"dispute,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r disputeResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,159 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Wallets(ctx context.Context) (c WalletConfig, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "wallets", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	c, err = mw.next.Wallets(ctx)
+	return
+}
+
+func (mw instrmw) ValidateMerchant(ctx context.Context, validationURL string) (session json.RawMessage, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "validate_merchant", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	session, err = mw.next.ValidateMerchant(ctx, validationURL)
+	return
+}
+
+func (mw instrmw) PayWithWallet(ctx context.Context, orderID string, p WalletPayment) (i Intent, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "pay_with_wallet", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	i, err = mw.next.PayWithWallet(ctx, orderID, p)
+	return
+}
+
+func (mw instrmw) ConfirmPayment(ctx context.Context, orderID string) (i Intent, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "confirm_payment", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	i, err = mw.next.ConfirmPayment(ctx, orderID)
+	return
+}
+
+func (mw instrmw) PaymentWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "payment_webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.PaymentWebhook(ctx, signature, body)
+	return
+}
+
+func (mw instrmw) BNPLEligibility(ctx context.Context, orderID, country string) (el Eligibility, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "bnpl_eligibility", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	el, err = mw.next.BNPLEligibility(ctx, orderID, country)
+	return
+}
+
+func (mw instrmw) StartBNPL(ctx context.Context, orderID, country string) (a Authorization, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start_bnpl", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	a, err = mw.next.StartBNPL(ctx, orderID, country)
+	return
+}
+
+func (mw instrmw) BNPLWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "bnpl_webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.BNPLWebhook(ctx, signature, body)
+	return
+}
+
+func (mw instrmw) CaptureShipped(ctx context.Context) (n int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "capture_shipped", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	n, err = mw.next.CaptureShipped(ctx)
+	return
+}
+
+func (mw instrmw) DisputeWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "dispute_webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DisputeWebhook(ctx, signature, body)
+	return
+}
+
+func (mw instrmw) Disputes(ctx context.Context, status string) (disputes []Dispute, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "disputes", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	disputes, err = mw.next.Disputes(ctx, status)
+	return
+}
+
+func (mw instrmw) Dispute(ctx context.Context, ID string) (d Dispute, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "dispute", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	d, err = mw.next.Dispute(ctx, ID)
+	return
+}
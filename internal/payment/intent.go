@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIntentNotFound = errors.New("payment intent not found")
+)
+
+// Statuses of an intent. An intent requiring action waits for the customer
+// to complete a 3-D Secure challenge of the bank.
+const (
+	IntentRequiresAction = "requires_action"
+	IntentSucceeded      = "succeeded"
+	IntentFailed         = "failed"
+)
+
+// intentTransitions are the statuses an intent moves to from a status.
+var intentTransitions = map[string][]string{
+	IntentRequiresAction: {IntentSucceeded, IntentFailed},
+}
+
+// Intent is a payment of an order at the provider. Strong customer
+// authentication may require the customer to confirm it at the bank first,
+// the order being paid once the intent succeeds.
+type Intent struct {
+	ID        string `json:"id"`
+	OrderID   string `json:"order_id" sql:"index"`
+	Reference string `json:"reference" sql:"index"` // payment at the provider
+	Status    string `json:"status"`
+	// RedirectURL is where the customer completes the challenge, while the
+	// intent requires action.
+	RedirectURL string    `json:"redirect_url,omitempty"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Intent) TableName() string {
+	return "payment_intents"
+}
+
+// Move tells whether the intent can move to status, moving it if so.
+func (i *Intent) Move(status string) bool {
+	for _, s := range intentTransitions[i.Status] {
+		if s == status {
+			i.Status = status
+			i.UpdatedAt = time.Now().UTC()
+			if status != IntentRequiresAction {
+				i.RedirectURL = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentResult is the status of a payment told by the provider.
+type PaymentResult struct {
+	Reference string `json:"id"`
+	Status    string `json:"status"`
+	// RedirectURL is set when the payment requires action.
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
@@ -0,0 +1,130 @@
+package payment_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payment"
+)
+
+func (r *paymentRepo) CreateIntent(i *payment.Intent) error {
+	i.ID = i.Reference
+	r.intents = append(r.intents, *i)
+	return nil
+}
+
+func (r *paymentRepo) SaveIntent(i *payment.Intent) error {
+	for j := range r.intents {
+		if r.intents[j].ID == i.ID {
+			r.intents[j] = *i
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *paymentRepo) GetIntent(orderID string) (payment.Intent, error) {
+	for j := len(r.intents) - 1; j >= 0; j-- {
+		if r.intents[j].OrderID == orderID {
+			return r.intents[j], nil
+		}
+	}
+	return payment.Intent{}, db.ErrNotFound
+}
+
+func (r *paymentRepo) GetIntentByReference(ref string) (payment.Intent, error) {
+	for _, i := range r.intents {
+		if i.Reference == ref {
+			return i, nil
+		}
+	}
+	return payment.Intent{}, db.ErrNotFound
+}
+
+// fulfiller records the orders submitted.
+type fulfiller struct {
+	orders []string
+}
+
+func (f *fulfiller) Submit(ctx context.Context, orderID string) ([]fulfillment.Job, error) {
+	f.orders = append(f.orders, orderID)
+	return nil, nil
+}
+
+// sign signs body as the provider does.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestChallenge(t *testing.T) {
+	ctx := context.Background()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", TotalPrice: 20, Currency: "EUR"},
+		"o2": {ID: "o2", TotalPrice: 30, Currency: "EUR"},
+		"o3": {ID: "o3", TotalPrice: 40, Currency: "EUR"},
+	}}
+	r := &paymentRepo{}
+	p := &provider{statuses: map[string]string{
+		"pay_o1": payment.IntentRequiresAction,
+		"pay_o2": payment.IntentRequiresAction,
+		"pay_o3": payment.IntentFailed,
+	}}
+	f := &fulfiller{}
+	s := payment.NewService(r, orders, p, nil, nil, f, payment.Config{ApplePayMerchantID: "merchant.shop", WebhookSecret: "secret"})
+	pay := payment.WalletPayment{Wallet: payment.WalletApplePay, Token: json.RawMessage(`{}`)}
+
+	i, err := s.PayWithWallet(ctx, "o1", pay)
+	if err != nil || i.Status != payment.IntentRequiresAction || i.RedirectURL == "" || orders.orders["o1"].PaidAt != nil {
+		t.Fatalf("pay: expected a challenge, got %+v, %v", i, err)
+	}
+	if i, err := s.ConfirmPayment(ctx, "o1"); err != nil || i.Status != payment.IntentRequiresAction {
+		t.Errorf("early: expected a challenge still, got %+v, %v", i, err)
+	}
+	p.statuses["pay_o1"] = payment.IntentSucceeded
+	if i, err := s.ConfirmPayment(ctx, "o1"); err != nil || i.Status != payment.IntentSucceeded || i.RedirectURL != "" {
+		t.Errorf("confirm: expected the intent succeeded, got %+v, %v", i, err)
+	}
+	if o := orders.orders["o1"]; o.PaidAt == nil || o.PaymentRef != "pay_o1" {
+		t.Errorf("confirm: expected the order paid, got %+v", o)
+	}
+	if len(f.orders) != 1 || f.orders[0] != "o1" {
+		t.Errorf("confirm: expected the order submitted for fulfillment, got %v", f.orders)
+	}
+	if _, err := s.ConfirmPayment(ctx, "o9"); err != payment.ErrIntentNotFound {
+		t.Errorf("unknown: expected ErrIntentNotFound, got %v", err)
+	}
+
+	if _, err := s.PayWithWallet(ctx, "o2", pay); err != nil {
+		t.Fatalf("pay o2: unexpected error %v", err)
+	}
+	succeeded := []byte(`{"id":"pay_o2","status":"succeeded"}`)
+	if err := s.PaymentWebhook(ctx, "forged", succeeded); err != payment.ErrInvalidSignature {
+		t.Errorf("forged: expected ErrInvalidSignature, got %v", err)
+	}
+	if err := s.PaymentWebhook(ctx, sign("secret", succeeded), succeeded); err != nil || orders.orders["o2"].PaidAt == nil {
+		t.Errorf("webhook: expected the order paid, got %+v, %v", orders.orders["o2"], err)
+	}
+	failed := []byte(`{"id":"pay_o2","status":"failed"}`)
+	if err := s.PaymentWebhook(ctx, sign("secret", failed), failed); err != nil || r.intents[1].Status != payment.IntentSucceeded {
+		t.Errorf("late: expected the success kept, got %s, %v", r.intents[1].Status, err)
+	}
+	if len(f.orders) != 2 || f.orders[1] != "o2" {
+		t.Errorf("webhook: expected the order submitted once, got %v", f.orders)
+	}
+
+	if i, err := s.PayWithWallet(ctx, "o3", pay); err != payment.ErrDeclined || i.Status != payment.IntentFailed {
+		t.Errorf("o3: expected ErrDeclined, got %+v, %v", i, err)
+	}
+	if len(f.orders) != 2 {
+		t.Errorf("o3: expected the declined order not submitted, got %v", f.orders)
+	}
+}
@@ -0,0 +1,171 @@
+package payment
+
+import (
+	"encoding/json"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Wallets(ctx context.Context) (c WalletConfig, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "wallets",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Wallets(ctx)
+}
+
+func (s loggingService) ValidateMerchant(ctx context.Context, validationURL string) (session json.RawMessage, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "validate_merchant",
+			"validation_url", validationURL,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ValidateMerchant(ctx, validationURL)
+}
+
+// PayWithWallet doesn't log the token.
+func (s loggingService) PayWithWallet(ctx context.Context, orderID string, p WalletPayment) (i Intent, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "pay_with_wallet",
+			"order_id", orderID,
+			"wallet", p.Wallet,
+			"status", i.Status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PayWithWallet(ctx, orderID, p)
+}
+
+func (s loggingService) ConfirmPayment(ctx context.Context, orderID string) (i Intent, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "confirm_payment",
+			"order_id", orderID,
+			"status", i.Status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ConfirmPayment(ctx, orderID)
+}
+
+func (s loggingService) PaymentWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "payment_webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PaymentWebhook(ctx, signature, body)
+}
+
+func (s loggingService) BNPLEligibility(ctx context.Context, orderID, country string) (el Eligibility, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "bnpl_eligibility",
+			"order_id", orderID,
+			"country", country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.BNPLEligibility(ctx, orderID, country)
+}
+
+func (s loggingService) StartBNPL(ctx context.Context, orderID, country string) (a Authorization, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start_bnpl",
+			"order_id", orderID,
+			"country", country,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.StartBNPL(ctx, orderID, country)
+}
+
+func (s loggingService) BNPLWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "bnpl_webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.BNPLWebhook(ctx, signature, body)
+}
+
+func (s loggingService) CaptureShipped(ctx context.Context) (n int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "capture_shipped",
+			"captured", n,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CaptureShipped(ctx)
+}
+
+func (s loggingService) DisputeWebhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "dispute_webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DisputeWebhook(ctx, signature, body)
+}
+
+func (s loggingService) Disputes(ctx context.Context, status string) (disputes []Dispute, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "disputes",
+			"status", status,
+			"disputes", len(disputes),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Disputes(ctx, status)
+}
+
+func (s loggingService) Dispute(ctx context.Context, ID string) (d Dispute, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "dispute",
+			"id", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Dispute(ctx, ID)
+}
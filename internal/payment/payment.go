@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrUnknownWallet        = errors.New("wallet must be apple_pay or google_pay")
+	ErrWalletDisabled       = errors.New("wallet payments are disabled")
+	ErrInvalidValidationURL = errors.New("validation URL is not an Apple Pay one")
+	ErrMissingToken         = errors.New("wallet token is required")
+	ErrAlreadyPaid          = errors.New("order is already paid")
+	ErrDeclined             = errors.New("payment declined")
+)
+
+// Wallets the orders can be paid with.
+const (
+	WalletApplePay  = "apple_pay"
+	WalletGooglePay = "google_pay"
+)
+
+// Config sets up the wallets. A wallet without a merchant ID is disabled.
+type Config struct {
+	// ApplePayMerchantID is the merchant identifier registered at Apple,
+	// Domain the domain the shop is verified on.
+	ApplePayMerchantID string
+	Domain             string
+	DisplayName        string
+	// GooglePayMerchantID is the merchant ID of the Google Pay console,
+	// Gateway and GatewayMerchantID the tokenization parameters of the
+	// provider.
+	GooglePayMerchantID string
+	Gateway             string
+	GatewayMerchantID   string
+	// The orders of BNPLMinAmount to BNPLMaxAmount can be paid later, no
+	// maximum if 0.
+	BNPLMinAmount float64
+	BNPLMaxAmount float64
+	// WebhookSecret verifies the signatures of the payment webhooks of the
+	// provider, none being accepted without it.
+	WebhookSecret string
+}
+
+// WalletConfig is what the payment sheets of the apps need to know of the
+// merchant, the wallets not configured being left out.
+type WalletConfig struct {
+	ApplePay  *ApplePayConfig  `json:"apple_pay,omitempty"`
+	GooglePay *GooglePayConfig `json:"google_pay,omitempty"`
+}
+
+type ApplePayConfig struct {
+	MerchantID  string `json:"merchant_id"`
+	DisplayName string `json:"display_name"`
+}
+
+type GooglePayConfig struct {
+	MerchantID        string `json:"merchant_id"`
+	MerchantName      string `json:"merchant_name"`
+	Gateway           string `json:"gateway"`
+	GatewayMerchantID string `json:"gateway_merchant_id"`
+}
+
+// WalletPayment is the token of a payment sheet paying an order, encrypted
+// for the provider.
+type WalletPayment struct {
+	Wallet string          `json:"wallet"`
+	Token  json.RawMessage `json:"token"`
+}
+
+// appleValidationURL tells whether u is an Apple Pay validation URL, the
+// merchant session being requested there with the certificate of the
+// provider.
+func appleValidationURL(u string) bool {
+	p, err := url.Parse(u)
+	return err == nil && p.Scheme == "https" &&
+		(p.Hostname() == "apple.com" || strings.HasSuffix(p.Hostname(), ".apple.com"))
+}
@@ -0,0 +1,110 @@
+package payment_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payment"
+)
+
+// orderRepo keeps the orders in memory, the other methods of the Repo
+// aren't used.
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r *orderRepo) GetByID(id string) (order.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+func (r *orderRepo) Save(o *order.Order) error {
+	r.orders[o.ID] = *o
+	return nil
+}
+
+// provider records the wallet payments, the other methods of the Provider
+// aren't used. The payments succeed unless statuses tells otherwise.
+type provider struct {
+	payment.Provider
+	paid       []float64
+	validation string
+	statuses   map[string]string
+}
+
+func (p *provider) PayWallet(ctx context.Context, wallet string, token json.RawMessage, amount float64, currency, reference string) (payment.PaymentResult, error) {
+	p.paid = append(p.paid, amount)
+	return p.Payment(ctx, "pay_"+reference)
+}
+
+func (p *provider) Payment(ctx context.Context, ref string) (payment.PaymentResult, error) {
+	res := payment.PaymentResult{Reference: ref, Status: p.statuses[ref]}
+	switch res.Status {
+	case "":
+		res.Status = payment.IntentSucceeded
+	case payment.IntentRequiresAction:
+		res.RedirectURL = "https://bank.example/3ds/" + ref
+	}
+	return res, nil
+}
+
+func (p *provider) ValidateMerchant(ctx context.Context, validationURL, domain, displayName string) (json.RawMessage, error) {
+	p.validation = validationURL
+	return json.RawMessage(`{"merchantSessionIdentifier":"s1"}`), nil
+}
+
+func TestPayWithWallet(t *testing.T) {
+	ctx := context.Background()
+	r := &orderRepo{orders: map[string]order.Order{"o1": {ID: "o1", TotalPrice: 12.5, Currency: "EUR"}}}
+	p := &provider{}
+	s := payment.NewService(&paymentRepo{}, r, p, nil, nil, nil, payment.Config{ApplePayMerchantID: "merchant.shop", Domain: "shop.example"})
+	token := json.RawMessage(`{"paymentData":{}}`)
+
+	for _, tc := range []struct {
+		name string
+		p    payment.WalletPayment
+		want error
+	}{
+		{"disabled", payment.WalletPayment{Wallet: payment.WalletGooglePay, Token: token}, payment.ErrWalletDisabled},
+		{"unknown", payment.WalletPayment{Wallet: "paypal", Token: token}, payment.ErrUnknownWallet},
+		{"no token", payment.WalletPayment{Wallet: payment.WalletApplePay}, payment.ErrMissingToken},
+	} {
+		if _, err := s.PayWithWallet(ctx, "o1", tc.p); err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	i, err := s.PayWithWallet(ctx, "o1", payment.WalletPayment{Wallet: payment.WalletApplePay, Token: token})
+	if err != nil || i.Status != payment.IntentSucceeded || r.orders["o1"].PaidAt == nil || r.orders["o1"].PaymentRef != "pay_o1" {
+		t.Fatalf("pay: expected the order paid, got %+v, %v", i, err)
+	}
+	if _, err := s.PayWithWallet(ctx, "o1", payment.WalletPayment{Wallet: payment.WalletApplePay, Token: token}); err != payment.ErrAlreadyPaid {
+		t.Errorf("again: expected ErrAlreadyPaid, got %v", err)
+	}
+	if len(p.paid) != 1 || p.paid[0] != 12.5 {
+		t.Errorf("paid: expected 12.5 once, got %v", p.paid)
+	}
+}
+
+func TestValidateMerchant(t *testing.T) {
+	ctx := context.Background()
+	p := &provider{}
+	s := payment.NewService(&paymentRepo{}, &orderRepo{}, p, nil, nil, nil, payment.Config{ApplePayMerchantID: "merchant.shop"})
+
+	for _, u := range []string{"http://apple-pay-gateway.apple.com/paymentservices/startSession", "https://apple.com.evil.example/", "https://example.com"} {
+		if _, err := s.ValidateMerchant(ctx, u); err != payment.ErrInvalidValidationURL {
+			t.Errorf("%s: expected ErrInvalidValidationURL, got %v", u, err)
+		}
+	}
+	u := "https://apple-pay-gateway.apple.com/paymentservices/startSession"
+	if session, err := s.ValidateMerchant(ctx, u); err != nil || len(session) == 0 || p.validation != u {
+		t.Errorf("validate: expected a session, got %s, %v", session, err)
+	}
+}
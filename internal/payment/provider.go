@@ -0,0 +1,180 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the payment service provider the orders are paid
+// with. Amounts are in the currency units, e.g. 12.50 EUR.
+type Provider interface {
+	// Charge charges the customer of the payment an extra amount, returning
+	// the reference of the charge.
+	Charge(ctx context.Context, paymentRef string, amount float64, currency string) (string, error)
+
+	// Refund refunds part of the payment, returning the reference of the
+	// refund.
+	Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error)
+
+	// ValidateMerchant requests an Apple Pay merchant session at the
+	// validation URL of the payment sheet, the provider holding the merchant
+	// certificate.
+	ValidateMerchant(ctx context.Context, validationURL, domain, displayName string) (json.RawMessage, error)
+
+	// PayWallet pays amount with the token of an Apple Pay or Google Pay
+	// sheet, which the provider decrypts. reference identifies the payment
+	// to the provider, a retry with the same one paying once. The payment
+	// may require a 3-D Secure challenge before it succeeds.
+	PayWallet(ctx context.Context, wallet string, token json.RawMessage, amount float64, currency, reference string) (PaymentResult, error)
+
+	// Payment returns the current status of the payment of ref.
+	Payment(ctx context.Context, ref string) (PaymentResult, error)
+}
+
+type httpProvider struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewHTTPProvider returns Provider talking to the REST API at baseURL,
+// amounts being sent in cents
+//
+//	POST <baseURL>/payments/<ref>/charges {"amount": 250, "currency": "EUR"}
+//	POST <baseURL>/payments/<ref>/refunds {"amount": 250, "currency": "EUR"}
+//	{"id": "..."}
+//
+//	POST <baseURL>/payments {"source": {"type": "apple_pay", "token": {...}}, "amount": 250, ...}
+//	GET <baseURL>/payments/<ref>
+//	{"id": "...", "status": "requires_action", "redirect_url": "..."}
+//
+//	POST <baseURL>/wallets/apple_pay/sessions {"validation_url": "...", "domain": "...", "display_name": "..."}
+//	{... the merchant session of Apple ...}
+func NewHTTPProvider(baseURL, apiKey string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, client: client}
+}
+
+type moveRequest struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type moveResponse struct {
+	ID string `json:"id"`
+}
+
+func (p httpProvider) Charge(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	return p.move(ctx, "charges", paymentRef, amount, currency)
+}
+
+func (p httpProvider) Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	return p.move(ctx, "refunds", paymentRef, amount, currency)
+}
+
+func (p httpProvider) move(ctx context.Context, kind, paymentRef string, amount float64, currency string) (string, error) {
+	var res moveResponse
+	err := p.post(ctx, "payment "+kind, "/payments/"+url.PathEscape(paymentRef)+"/"+kind, "",
+		moveRequest{Amount: cents(amount), Currency: currency}, &res)
+	return res.ID, err
+}
+
+type sessionRequest struct {
+	ValidationURL string `json:"validation_url"`
+	Domain        string `json:"domain"`
+	DisplayName   string `json:"display_name"`
+}
+
+func (p httpProvider) ValidateMerchant(ctx context.Context, validationURL, domain, displayName string) (json.RawMessage, error) {
+	var res json.RawMessage
+	err := p.post(ctx, "payment merchant validation", "/wallets/"+WalletApplePay+"/sessions", "",
+		sessionRequest{ValidationURL: validationURL, Domain: domain, DisplayName: displayName}, &res)
+	return res, err
+}
+
+type walletSource struct {
+	Type  string          `json:"type"`
+	Token json.RawMessage `json:"token"`
+}
+
+type walletRequest struct {
+	Source    walletSource `json:"source"`
+	Amount    int64        `json:"amount"`
+	Currency  string       `json:"currency"`
+	Reference string       `json:"reference"`
+}
+
+func (p httpProvider) PayWallet(ctx context.Context, wallet string, token json.RawMessage, amount float64, currency, reference string) (PaymentResult, error) {
+	var res PaymentResult
+	err := p.post(ctx, "payment "+wallet, "/payments", reference, walletRequest{
+		Source:    walletSource{Type: wallet, Token: token},
+		Amount:    cents(amount),
+		Currency:  currency,
+		Reference: reference,
+	}, &res)
+	return res, err
+}
+
+func (p httpProvider) Payment(ctx context.Context, ref string) (PaymentResult, error) {
+	var res PaymentResult
+	req, err := http.NewRequest("GET", p.baseURL+"/payments/"+url.PathEscape(ref), nil)
+	if err != nil {
+		return res, err
+	}
+	err = p.do(req.WithContext(ctx), "payment status", "", &res)
+	return res, err
+}
+
+// post posts body as JSON to path, decoding the response into res. The
+// idempotency key, if any, makes the provider process a retry once.
+func (p httpProvider) post(ctx context.Context, op, path, idempotencyKey string, body, res interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", p.baseURL+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return p.do(req.WithContext(ctx), op, idempotencyKey, res)
+}
+
+func (p httpProvider) do(req *http.Request, op, idempotencyKey string, res interface{}) error {
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusPaymentRequired {
+		return ErrDeclined
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
@@ -0,0 +1,33 @@
+package payment
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Payment Service
+type Repo interface {
+	CreateAuthorization(a *Authorization) error
+	SaveAuthorization(a *Authorization) error
+	// GetAuthorization returns the latest authorization of the order.
+	GetAuthorization(orderID string) (Authorization, error)
+	GetAuthorizationByReference(provider, ref string) (Authorization, error)
+	// ListAuthorizations returns the authorizations in status, oldest
+	// first.
+	ListAuthorizations(status string) ([]Authorization, error)
+
+	CreateIntent(i *Intent) error
+	SaveIntent(i *Intent) error
+	// GetIntent returns the latest intent of the order.
+	GetIntent(orderID string) (Intent, error)
+	GetIntentByReference(ref string) (Intent, error)
+
+	CreateDispute(d *Dispute) error
+	SaveDispute(d *Dispute) error
+	GetDispute(ID string) (Dispute, error)
+	GetDisputeByReference(ref string) (Dispute, error)
+	// ListDisputes returns the disputes in status, all of them if empty,
+	// the earliest evidence deadline first.
+	ListDisputes(status string) ([]Dispute, error)
+	// ListLostDisputes returns the disputes lost between from and to,
+	// oldest first.
+	ListLostDisputes(from, to time.Time) ([]Dispute, error)
+	Drop() error
+}
@@ -1,4 +1,467 @@
 package payment
 
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+// Fulfiller submits the paid orders for fulfillment, e.g. the
+// fulfillment.Service.
+type Fulfiller interface {
+	Submit(ctx context.Context, orderID string) ([]fulfillment.Job, error)
+}
+
 type Service interface {
+	// Wallets returns the configuration of the payment sheets.
+	Wallets(ctx context.Context) (WalletConfig, error)
+
+	// ValidateMerchant returns the Apple Pay merchant session the payment
+	// sheet asks for at validationURL.
+	ValidateMerchant(ctx context.Context, validationURL string) (json.RawMessage, error)
+
+	// PayWithWallet pays an order with the token of an Apple Pay or Google
+	// Pay sheet, in one tap rather than filling the card fields. The intent
+	// returned may require the customer to complete a 3-D Secure challenge
+	// at its redirect URL, then to confirm the payment.
+	PayWithWallet(ctx context.Context, orderID string, p WalletPayment) (Intent, error)
+
+	// ConfirmPayment continues the payment of the order after the challenge,
+	// returning its latest intent with the status told by the provider.
+	ConfirmPayment(ctx context.Context, orderID string) (Intent, error)
+
+	// PaymentWebhook applies the status of a payment pushed by the
+	// provider, a succeeded intent paying its order.
+	PaymentWebhook(ctx context.Context, signature string, body []byte) error
+
+	// BNPLEligibility tells whether the order can be paid later by a
+	// customer of country.
+	BNPLEligibility(ctx context.Context, orderID, country string) (Eligibility, error)
+
+	// StartBNPL starts paying the order later, the customer approving the
+	// payment at the redirect URL of the authorization.
+	StartBNPL(ctx context.Context, orderID, country string) (Authorization, error)
+
+	// BNPLWebhook applies the approval or decline pushed by the provider,
+	// an approved order being paid.
+	BNPLWebhook(ctx context.Context, signature string, body []byte) error
+
+	// CaptureShipped captures the approved authorizations of the shipped
+	// orders, returning the number captured.
+	CaptureShipped(ctx context.Context) (int, error)
+
+	// DisputeWebhook records a dispute pushed by the provider against the
+	// order of the payment disputed, telling the staff of a new one.
+	DisputeWebhook(ctx context.Context, signature string, body []byte) error
+
+	// Disputes returns the disputes in status, all of them if empty, the
+	// earliest evidence deadline first.
+	Disputes(ctx context.Context, status string) ([]Dispute, error)
+
+	// Dispute returns a dispute by its ID.
+	Dispute(ctx context.Context, ID string) (Dispute, error)
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	provider  Provider
+	bnpl      BNPLProvider
+	notifier  Notifier
+	fulfiller Fulfiller
+	cfg       Config
+}
+
+// NewService return basic Service implementation. A nil bnpl disables
+// buy now pay later, a nil notifier tells no one about the disputes. The
+// paid orders are submitted to fulfiller, unless nil.
+func NewService(r Repo, orders order.Repo, provider Provider, bnpl BNPLProvider, notifier Notifier, fulfiller Fulfiller, cfg Config) Service {
+	return basicService{r: r, orders: orders, provider: provider, bnpl: bnpl, notifier: notifier, fulfiller: fulfiller, cfg: cfg}
+}
+
+func (s basicService) Wallets(ctx context.Context) (WalletConfig, error) {
+	var c WalletConfig
+	if s.cfg.ApplePayMerchantID != "" {
+		c.ApplePay = &ApplePayConfig{MerchantID: s.cfg.ApplePayMerchantID, DisplayName: s.cfg.DisplayName}
+	}
+	if s.cfg.GooglePayMerchantID != "" {
+		c.GooglePay = &GooglePayConfig{
+			MerchantID:        s.cfg.GooglePayMerchantID,
+			MerchantName:      s.cfg.DisplayName,
+			Gateway:           s.cfg.Gateway,
+			GatewayMerchantID: s.cfg.GatewayMerchantID,
+		}
+	}
+	return c, nil
+}
+
+// ValidateMerchant only asks Apple, the validation URL coming from the
+// client.
+func (s basicService) ValidateMerchant(ctx context.Context, validationURL string) (json.RawMessage, error) {
+	if s.cfg.ApplePayMerchantID == "" {
+		return nil, ErrWalletDisabled
+	}
+	if !appleValidationURL(validationURL) {
+		return nil, ErrInvalidValidationURL
+	}
+	return s.provider.ValidateMerchant(ctx, validationURL, s.cfg.Domain, s.cfg.DisplayName)
+}
+
+// PayWithWallet charges the order total, the order ID keeping a retry from
+// paying twice.
+func (s basicService) PayWithWallet(ctx context.Context, orderID string, p WalletPayment) (Intent, error) {
+	switch {
+	case p.Wallet == WalletApplePay && s.cfg.ApplePayMerchantID == "",
+		p.Wallet == WalletGooglePay && s.cfg.GooglePayMerchantID == "":
+		return Intent{}, ErrWalletDisabled
+	case p.Wallet != WalletApplePay && p.Wallet != WalletGooglePay:
+		return Intent{}, ErrUnknownWallet
+	case len(p.Token) == 0 || string(p.Token) == "null":
+		return Intent{}, ErrMissingToken
+	}
+	o, err := s.unpaid(orderID)
+	if err != nil {
+		return Intent{}, err
+	}
+	res, err := s.provider.PayWallet(ctx, p.Wallet, p.Token, o.TotalPrice, o.Currency, o.ID)
+	if err != nil {
+		return Intent{}, err
+	}
+
+	// A retry gets the payment of the order again.
+	i, err := s.r.GetIntentByReference(res.Reference)
+	switch {
+	case err == nil:
+		err = s.apply(ctx, &i, res.Status)
+	case err == db.ErrNotFound:
+		now := time.Now().UTC()
+		i = Intent{
+			OrderID:     o.ID,
+			Reference:   res.Reference,
+			Status:      res.Status,
+			RedirectURL: res.RedirectURL,
+			Amount:      o.TotalPrice,
+			Currency:    o.Currency,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err = s.r.CreateIntent(&i); err == nil && i.Status == IntentSucceeded {
+			err = s.paid(ctx, i)
+		}
+	}
+	if err != nil {
+		return Intent{}, err
+	}
+	if i.Status == IntentFailed {
+		return i, ErrDeclined
+	}
+	return i, nil
+}
+
+// ConfirmPayment asks the provider rather than trusting the client, which
+// may come back before the challenge is completed.
+func (s basicService) ConfirmPayment(ctx context.Context, orderID string) (Intent, error) {
+	i, err := s.r.GetIntent(orderID)
+	if err != nil {
+		return Intent{}, ErrIntentNotFound
+	}
+	if i.Status != IntentRequiresAction {
+		return i, nil
+	}
+	res, err := s.provider.Payment(ctx, i.Reference)
+	if err != nil {
+		return Intent{}, err
+	}
+	if err := s.apply(ctx, &i, res.Status); err != nil {
+		return Intent{}, err
+	}
+	return i, nil
 }
+
+// PaymentWebhook ignores the statuses the intent can't move to, e.g. a
+// retried success, so that it reconciles the confirmations missed.
+func (s basicService) PaymentWebhook(ctx context.Context, signature string, body []byte) error {
+	if !signed(s.cfg.WebhookSecret, signature, body) {
+		return ErrInvalidSignature
+	}
+	var res PaymentResult
+	if err := json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+	i, err := s.r.GetIntentByReference(res.Reference)
+	if err != nil {
+		return ErrIntentNotFound
+	}
+	return s.apply(ctx, &i, res.Status)
+}
+
+// apply moves the intent to status, paying its order on success.
+func (s basicService) apply(ctx context.Context, i *Intent, status string) error {
+	if !i.Move(status) {
+		return nil
+	}
+	if err := s.r.SaveIntent(i); err != nil {
+		return err
+	}
+	if i.Status != IntentSucceeded {
+		return nil
+	}
+	return s.paid(ctx, *i)
+}
+
+// paid records the succeeded intent as the payment of its order, unless
+// the order is paid already.
+func (s basicService) paid(ctx context.Context, i Intent) error {
+	o, err := s.orders.GetByID(i.OrderID)
+	if err != nil {
+		return order.ErrOrderNotFound
+	}
+	if o.PaidAt != nil {
+		return nil
+	}
+	o.PaymentRef, o.PaidAt = i.Reference, &i.UpdatedAt
+	if err := s.orders.Save(&o); err != nil {
+		return err
+	}
+	return s.fulfill(ctx, o.ID)
+}
+
+// fulfill submits the paid order for fulfillment. A failed submit leaves
+// the order paid, to be submitted again by the staff.
+func (s basicService) fulfill(ctx context.Context, orderID string) error {
+	if s.fulfiller == nil {
+		return nil
+	}
+	if _, err := s.fulfiller.Submit(ctx, orderID); err != nil {
+		return errors.Wrapf(err, "order %s paid, not submitted for fulfillment", orderID)
+	}
+	return nil
+}
+
+func (s basicService) BNPLEligibility(ctx context.Context, orderID, country string) (Eligibility, error) {
+	o, err := s.unpaid(orderID)
+	if err != nil {
+		return Eligibility{}, err
+	}
+	return s.eligibility(ctx, o, country)
+}
+
+// eligibility checks the shop limits before asking the provider.
+func (s basicService) eligibility(ctx context.Context, o order.Order, country string) (Eligibility, error) {
+	if s.bnpl == nil {
+		return Eligibility{}, ErrBNPLDisabled
+	}
+	switch {
+	case o.TotalPrice < s.cfg.BNPLMinAmount:
+		return Eligibility{Reason: ReasonBelowMinimum}, nil
+	case s.cfg.BNPLMaxAmount > 0 && o.TotalPrice > s.cfg.BNPLMaxAmount:
+		return Eligibility{Reason: ReasonAboveMaximum}, nil
+	}
+	ok, err := s.bnpl.Eligible(ctx, o.TotalPrice, o.Currency, country)
+	if err != nil {
+		return Eligibility{}, err
+	}
+	if !ok {
+		return Eligibility{Reason: ReasonProvider}, nil
+	}
+	return Eligibility{Eligible: true}, nil
+}
+
+// StartBNPL returns the pending authorization of the order, if any, so that
+// a customer coming back doesn't start another one.
+func (s basicService) StartBNPL(ctx context.Context, orderID, country string) (Authorization, error) {
+	o, err := s.unpaid(orderID)
+	if err != nil {
+		return Authorization{}, err
+	}
+	el, err := s.eligibility(ctx, o, country)
+	if err != nil {
+		return Authorization{}, err
+	}
+	if !el.Eligible {
+		return Authorization{}, ErrNotEligible
+	}
+	if a, err := s.r.GetAuthorization(o.ID); err == nil && a.Status == AuthPending &&
+		a.Amount == o.TotalPrice && a.Currency == o.Currency {
+		return a, nil
+	}
+	session, err := s.bnpl.CreateSession(ctx, o.ID, o.TotalPrice, o.Currency, country)
+	if err != nil {
+		return Authorization{}, err
+	}
+	now := time.Now().UTC()
+	a := Authorization{
+		OrderID:     o.ID,
+		Provider:    s.bnpl.Name(),
+		Reference:   session.Reference,
+		Status:      AuthPending,
+		RedirectURL: session.RedirectURL,
+		Amount:      o.TotalPrice,
+		Currency:    o.Currency,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.r.CreateAuthorization(&a); err != nil {
+		return Authorization{}, err
+	}
+	return a, nil
+}
+
+// BNPLWebhook ignores the updates the authorization can't move to, e.g. a
+// retried approval.
+func (s basicService) BNPLWebhook(ctx context.Context, signature string, body []byte) error {
+	if s.bnpl == nil {
+		return ErrBNPLDisabled
+	}
+	if !s.bnpl.VerifyWebhook(signature, body) {
+		return ErrInvalidSignature
+	}
+	var u AuthUpdate
+	if err := json.Unmarshal(body, &u); err != nil {
+		return err
+	}
+	a, err := s.r.GetAuthorizationByReference(s.bnpl.Name(), u.Reference)
+	if err != nil {
+		return ErrAuthorizationNotFound
+	}
+	if u.Status == AuthCaptured || !a.Move(u.Status) {
+		return nil
+	}
+	if err := s.r.SaveAuthorization(&a); err != nil {
+		return err
+	}
+	if a.Status != AuthApproved {
+		return nil
+	}
+	o, err := s.orders.GetByID(a.OrderID)
+	if err != nil {
+		return order.ErrOrderNotFound
+	}
+	o.PaymentRef, o.PaidAt = a.Reference, &a.UpdatedAt
+	if err := s.orders.Save(&o); err != nil {
+		return err
+	}
+	return s.fulfill(ctx, o.ID)
+}
+
+// CaptureShipped captures the total of the order, which may have been
+// edited since the approval.
+func (s basicService) CaptureShipped(ctx context.Context) (int, error) {
+	if s.bnpl == nil {
+		return 0, nil
+	}
+	auths, err := s.r.ListAuthorizations(AuthApproved)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, a := range auths {
+		o, err := s.orders.GetByID(a.OrderID)
+		if err != nil {
+			return n, err
+		}
+		if o.ShippedAt == nil {
+			continue
+		}
+		if err := s.bnpl.Capture(ctx, a.Reference, o.TotalPrice, o.Currency); err != nil {
+			return n, err
+		}
+		a.Move(AuthCaptured)
+		a.Amount, a.CapturedAt = o.TotalPrice, &a.UpdatedAt
+		if err := s.r.SaveAuthorization(&a); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// DisputeWebhook keeps the status a closed dispute was decided with, the
+// provider retrying the events out of order.
+func (s basicService) DisputeWebhook(ctx context.Context, signature string, body []byte) error {
+	if !signed(s.cfg.WebhookSecret, signature, body) {
+		return ErrInvalidSignature
+	}
+	var e disputeEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	d, err := s.r.GetDisputeByReference(e.Reference)
+	switch {
+	case err == nil:
+		if d.Closed() {
+			return nil
+		}
+		d.Status, d.EvidenceDueBy, d.UpdatedAt = e.Status, e.EvidenceDueBy, now
+		if d.Closed() {
+			d.ClosedAt = &now
+		}
+		return s.r.SaveDispute(&d)
+	case err != db.ErrNotFound:
+		return err
+	}
+
+	i, err := s.r.GetIntentByReference(e.Payment)
+	if err != nil {
+		return ErrIntentNotFound
+	}
+	o, err := s.orders.GetByID(i.OrderID)
+	if err != nil {
+		return order.ErrOrderNotFound
+	}
+	d = Dispute{
+		OrderID:       o.ID,
+		Reference:     e.Reference,
+		Payment:       e.Payment,
+		Reason:        e.Reason,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+		Status:        e.Status,
+		EvidenceDueBy: e.EvidenceDueBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if d.Closed() {
+		d.ClosedAt = &now
+	}
+	if err := s.r.CreateDispute(&d); err != nil {
+		return err
+	}
+	if s.notifier == nil {
+		return nil
+	}
+	return s.notifier.DisputeOpened(d, o)
+}
+
+func (s basicService) Disputes(ctx context.Context, status string) ([]Dispute, error) {
+	return s.r.ListDisputes(status)
+}
+
+// Dispute returns ErrDisputeNotFound for unknown IDs.
+func (s basicService) Dispute(ctx context.Context, ID string) (Dispute, error) {
+	d, err := s.r.GetDispute(ID)
+	if err == db.ErrNotFound {
+		return Dispute{}, ErrDisputeNotFound
+	}
+	return d, err
+}
+
+// unpaid returns the order, which must not be paid yet.
+func (s basicService) unpaid(orderID string) (order.Order, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return order.Order{}, order.ErrOrderNotFound
+	}
+	if o.PaidAt != nil {
+		return order.Order{}, ErrAlreadyPaid
+	}
+	return o, nil
+}
+
+type Middleware func(Service) Service
@@ -0,0 +1,298 @@
+package payment
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// maxWebhookSize limits the webhook body read into memory.
+const maxWebhookSize = 1 << 20
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrUnknownWallet:        "payment.unknown_wallet",
+		ErrWalletDisabled:       "payment.wallet_disabled",
+		ErrInvalidValidationURL: "payment.invalid_validation_url",
+		ErrMissingToken:         "payment.missing_token",
+		ErrAlreadyPaid:          "payment.already_paid",
+		ErrDeclined:             "payment.declined",
+
+		ErrBNPLDisabled:          "payment.bnpl_disabled",
+		ErrNotEligible:           "payment.not_eligible",
+		ErrAuthorizationNotFound: "payment.authorization_not_found",
+		ErrInvalidSignature:      "payment.invalid_signature",
+		ErrIntentNotFound:        "payment.intent_not_found",
+		ErrDisputeNotFound:       "payment.dispute_not_found",
+	})
+}
+
+// MakeHTTPHandler mounts the payment endpoints, the shop admin ones served
+// to the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	walletsHandler := httptransport.NewServer(
+		e.WalletsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	validateMerchantHandler := httptransport.NewServer(
+		e.ValidateMerchantEndpoint,
+		decodeValidateMerchantRequest,
+		encodeResponse,
+		options...,
+	)
+	payWithWalletHandler := httptransport.NewServer(
+		e.PayWithWalletEndpoint,
+		decodePayWithWalletRequest,
+		encodeResponse,
+		options...,
+	)
+	confirmPaymentHandler := httptransport.NewServer(
+		e.ConfirmPaymentEndpoint,
+		decodeOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	paymentWebhookHandler := httptransport.NewServer(
+		e.PaymentWebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+	bnplEligibilityHandler := httptransport.NewServer(
+		e.BNPLEligibilityEndpoint,
+		decodeBNPLRequest,
+		encodeResponse,
+		options...,
+	)
+	startBNPLHandler := httptransport.NewServer(
+		e.StartBNPLEndpoint,
+		decodeBNPLRequest,
+		encodeResponse,
+		options...,
+	)
+	bnplWebhookHandler := httptransport.NewServer(
+		e.BNPLWebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+	captureShippedHandler := httptransport.NewServer(
+		e.CaptureShippedEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	disputeWebhookHandler := httptransport.NewServer(
+		e.DisputeWebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+	disputesHandler := httptransport.NewServer(
+		e.DisputesEndpoint,
+		decodeDisputesRequest,
+		encodeResponse,
+		options...,
+	)
+	disputeHandler := httptransport.NewServer(
+		e.DisputeEndpoint,
+		decodeDisputeRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/payments/v1/wallets", walletsHandler).Methods("GET")
+	r.Handle("/payments/v1/wallets/apple_pay/validate", validateMerchantHandler).Methods("POST")
+	r.Handle("/payments/v1/orders/{order-id}/wallet", payWithWalletHandler).Methods("POST")
+	r.Handle("/payments/v1/orders/{order-id}/confirm", confirmPaymentHandler).Methods("POST")
+	r.Handle("/payments/v1/webhook", paymentWebhookHandler).Methods("POST")
+	r.Handle("/payments/v1/orders/{order-id}/bnpl", bnplEligibilityHandler).Methods("GET")
+	r.Handle("/payments/v1/orders/{order-id}/bnpl", startBNPLHandler).Methods("POST")
+	r.Handle("/payments/v1/bnpl/webhook", bnplWebhookHandler).Methods("POST")
+	r.Handle("/payments/v1/disputes/webhook", disputeWebhookHandler).Methods("POST")
+
+	// Shop admin endpoints
+	r.Handle("/payments/v1/admin/bnpl/capture", captureShippedHandler).Methods("POST")
+	r.Handle("/payments/v1/admin/disputes", disputesHandler).Methods("GET")
+	r.Handle("/payments/v1/admin/disputes/{dispute-id}", disputeHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeValidateMerchantRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r validateMerchantRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodePayWithWalletRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r payWithWalletRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderRequest{OrderID: orderID}, nil
+}
+
+// decodeBNPLRequest reads the country of the customer from the body of a
+// POST, the query otherwise.
+func decodeBNPLRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	r := startBNPLRequest{Country: req.URL.Query().Get("country")}
+	if req.Method == "POST" {
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeDisputesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return disputesRequest{Status: req.URL.Query().Get("status")}, nil
+}
+
+func decodeDisputeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["dispute-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "dispute-id")
+	}
+	return disputeRequest{ID: id}, nil
+}
+
+func decodeWebhookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxWebhookSize))
+	if err != nil {
+		return nil, err
+	}
+	return webhookRequest{
+		Signature: req.Header.Get("X-Signature"),
+		Body:      body,
+	}, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case order.ErrOrderNotFound, ErrAuthorizationNotFound, ErrIntentNotFound, ErrDisputeNotFound:
+		return http.StatusNotFound
+	case ErrInvalidSignature:
+		return http.StatusUnauthorized
+	case ErrAlreadyPaid:
+		return http.StatusConflict
+	case ErrDeclined:
+		return http.StatusPaymentRequired
+	case ErrWalletDisabled, ErrBNPLDisabled, ErrNotEligible:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrUnknownWallet, ErrInvalidValidationURL, ErrMissingToken, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,193 @@
+package payout
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+// Authenticator resolves a vendor API key into the vendor owning it.
+// vendors.Service implements it.
+type Authenticator interface {
+	Authenticate(ctx context.Context, key, scope string) (vendors.Vendor, error)
+}
+
+// Endpoints combine all the payout service endpoints under single type.
+type Endpoints struct {
+	AgreementEndpoint    endpoint.Endpoint
+	SetAgreementEndpoint endpoint.Endpoint
+	RecordEndpoint       endpoint.Endpoint
+	RunPayoutsEndpoint   endpoint.Endpoint
+	BalanceEndpoint      endpoint.Endpoint
+	PayoutsEndpoint      endpoint.Endpoint
+	StatementEndpoint    endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the payout service endpoints. The shop admin endpoints are
+// restricted by admin, the vendor ones to the API keys auth resolves.
+func MakeEndpoints(s Service, auth Authenticator, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		AgreementEndpoint:    admin(MakeAgreementEndpoint(s)),
+		SetAgreementEndpoint: admin(MakeSetAgreementEndpoint(s)),
+		RecordEndpoint:       admin(MakeRecordEndpoint(s)),
+		RunPayoutsEndpoint:   admin(MakeRunPayoutsEndpoint(s)),
+		BalanceEndpoint:      MakeBalanceEndpoint(s, auth),
+		PayoutsEndpoint:      MakePayoutsEndpoint(s, auth),
+		StatementEndpoint:    MakeStatementEndpoint(s, auth),
+	}
+}
+
+func MakeAgreementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(agreementRequest)
+		a, e := s.Agreement(ctx, req.VendorID)
+		if e != nil {
+			return agreementResponse{Agreement: nil, Error: e}, nil
+		}
+		return agreementResponse{Agreement: &a}, nil
+	}
+}
+
+func MakeSetAgreementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(agreementRequest)
+		a, e := s.SetAgreement(ctx, req.VendorID, req.Rate)
+		if e != nil {
+			return agreementResponse{Agreement: nil, Error: e}, nil
+		}
+		return agreementResponse{Agreement: &a}, nil
+	}
+}
+
+func MakeRecordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(recordRequest)
+		entries, e := s.Record(ctx, req.OrderID)
+		if e != nil {
+			return recordResponse{Entries: make([]Entry, 0), Error: e}, nil
+		}
+		return recordResponse{Entries: entries}, nil
+	}
+}
+
+func MakeRunPayoutsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		payouts, e := s.RunPayouts(ctx)
+		if e != nil {
+			return payoutsResponse{Payouts: make([]Payout, 0), Error: e}, nil
+		}
+		return payoutsResponse{Payouts: payouts}, nil
+	}
+}
+
+func MakeBalanceEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(vendorRequest)
+		v, e := auth.Authenticate(ctx, req.APIKey, vendors.ScopePayoutsRead)
+		if e != nil {
+			return nil, e
+		}
+		balances, e := s.Balance(ctx, v.ID)
+		if e != nil {
+			return balanceResponse{Balances: make([]Balance, 0), Error: e}, nil
+		}
+		return balanceResponse{Balances: balances}, nil
+	}
+}
+
+func MakePayoutsEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(vendorRequest)
+		v, e := auth.Authenticate(ctx, req.APIKey, vendors.ScopePayoutsRead)
+		if e != nil {
+			return nil, e
+		}
+		payouts, e := s.Payouts(ctx, v.ID)
+		if e != nil {
+			return payoutsResponse{Payouts: make([]Payout, 0), Error: e}, nil
+		}
+		return payoutsResponse{Payouts: payouts}, nil
+	}
+}
+
+func MakeStatementEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(statementRequest)
+		v, e := auth.Authenticate(ctx, req.APIKey, vendors.ScopePayoutsRead)
+		if e != nil {
+			return nil, e
+		}
+		st, e := s.Statement(ctx, v.ID, req.PayoutID)
+		if e != nil {
+			return statementResponse{Statement: nil, Error: e}, nil
+		}
+		return statementResponse{Statement: &st}, nil
+	}
+}
+
+type agreementRequest struct {
+	VendorID string  `json:"-"`
+	Rate     float64 `json:"rate"`
+}
+
+type agreementResponse struct {
+	Agreement *Agreement `json:"agreement"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r agreementResponse) error() error {
+	return r.Error
+}
+
+type recordRequest struct {
+	OrderID string
+}
+
+type recordResponse struct {
+	Entries []Entry `json:"entries"`
+	Error   error   `json:"error,omitempty"`
+}
+
+func (r recordResponse) error() error {
+	return r.Error
+}
+
+type runPayoutsRequest struct{}
+
+type vendorRequest struct {
+	APIKey string
+}
+
+type balanceResponse struct {
+	Balances []Balance `json:"balances"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r balanceResponse) error() error {
+	return r.Error
+}
+
+type payoutsResponse struct {
+	Payouts []Payout `json:"payouts"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r payoutsResponse) error() error {
+	return r.Error
+}
+
+type statementRequest struct {
+	APIKey   string
+	PayoutID string
+}
+
+type statementResponse struct {
+	Statement *Statement `json:"statement"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r statementResponse) error() error {
+	return r.Error
+}
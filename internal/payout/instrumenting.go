@@ -0,0 +1,103 @@
+package payout
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Agreement(ctx context.Context, vendorID string) (agreement Agreement, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "agreement", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	agreement, err = mw.next.Agreement(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) SetAgreement(ctx context.Context, vendorID string, rate float64) (agreement Agreement, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_agreement", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	agreement, err = mw.next.SetAgreement(ctx, vendorID, rate)
+	return
+}
+
+func (mw instrmw) Record(ctx context.Context, orderID string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "record", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	entries, err = mw.next.Record(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Balance(ctx context.Context, vendorID string) (balances []Balance, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "balance", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	balances, err = mw.next.Balance(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) RunPayouts(ctx context.Context) (payouts []Payout, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "run_payouts", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	payouts, err = mw.next.RunPayouts(ctx)
+	return
+}
+
+func (mw instrmw) Payouts(ctx context.Context, vendorID string) (payouts []Payout, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "payouts", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	payouts, err = mw.next.Payouts(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) Statement(ctx context.Context, vendorID, payoutID string) (statement Statement, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "statement", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	statement, err = mw.next.Statement(ctx, vendorID, payoutID)
+	return
+}
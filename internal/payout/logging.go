@@ -0,0 +1,100 @@
+package payout
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Agreement(ctx context.Context, vendorID string) (agreement Agreement, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "agreement",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Agreement(ctx, vendorID)
+}
+
+func (s loggingService) SetAgreement(ctx context.Context, vendorID string, rate float64) (agreement Agreement, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_agreement",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetAgreement(ctx, vendorID, rate)
+}
+
+func (s loggingService) Record(ctx context.Context, orderID string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "record",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Record(ctx, orderID)
+}
+
+func (s loggingService) Balance(ctx context.Context, vendorID string) (balances []Balance, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "balance",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Balance(ctx, vendorID)
+}
+
+func (s loggingService) RunPayouts(ctx context.Context) (payouts []Payout, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "run_payouts",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RunPayouts(ctx)
+}
+
+func (s loggingService) Payouts(ctx context.Context, vendorID string) (payouts []Payout, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "payouts",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Payouts(ctx, vendorID)
+}
+
+func (s loggingService) Statement(ctx context.Context, vendorID, payoutID string) (statement Statement, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "statement",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Statement(ctx, vendorID, payoutID)
+}
@@ -0,0 +1,80 @@
+package payout
+
+import (
+	"math"
+	"time"
+)
+
+// Agreement is the commission deal between the shop and a vendor. Rate is the
+// fraction of the sale kept by the shop, e.g 0.15 for 15%.
+type Agreement struct {
+	VendorID  string    `json:"vendor_id" gorm:"primary_key"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Entry is the vendor's earning on a single book of an order. Entries are
+// unpaid until they are included in a payout.
+type Entry struct {
+	ID         string    `json:"id"`
+	VendorID   string    `json:"vendor_id"`
+	OrderID    string    `json:"order_id"`
+	BookID     string    `json:"book_id"`
+	Currency   string    `json:"currency"`
+	Gross      float64   `json:"gross"`
+	Rate       float64   `json:"rate"`
+	Commission float64   `json:"commission"`
+	Net        float64   `json:"net"`
+	PayoutID   string    `json:"payout_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Entry) TableName() string {
+	return "payout_entries"
+}
+
+// Payout is the statement of the entries paid out to a vendor at once.
+type Payout struct {
+	ID          string    `json:"id"`
+	VendorID    string    `json:"vendor_id"`
+	Currency    string    `json:"currency"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	EntryCount  int       `json:"entry_count"`
+	Gross       float64   `json:"gross"`
+	Commission  float64   `json:"commission"`
+	Amount      float64   `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Statement is a payout along with the entries it pays.
+type Statement struct {
+	Payout
+	Entries []Entry `json:"entries"`
+}
+
+// Balance is the vendor's accumulated earnings not yet paid out.
+type Balance struct {
+	VendorID   string  `json:"vendor_id"`
+	Currency   string  `json:"currency"`
+	EntryCount int     `json:"entry_count"`
+	Gross      float64 `json:"gross"`
+	Commission float64 `json:"commission"`
+	Amount     float64 `json:"amount"`
+}
+
+// Config controls commission and payouts.
+type Config struct {
+	DefaultRate float64 // rate used for vendors without an agreement
+	MinAmount   float64 // balances below it are carried to the next payout
+}
+
+// Commission returns the shop's commission on gross for the rate, rounded to
+// cents.
+func Commission(gross, rate float64) float64 {
+	return float64(cents(gross*rate)) / 100
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
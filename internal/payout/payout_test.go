@@ -0,0 +1,148 @@
+package payout_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/payout"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+func TestCommission(t *testing.T) {
+	cases := []struct {
+		gross, rate, expected float64
+	}{
+		{10.00, 0.15, 1.50},
+		{19.99, 0.15, 3.00}, // 2.9985 rounded to cents
+		{9.99, 0.10, 1.00},
+		{4.99, 0, 0},
+		{12.50, 1, 12.50},
+	}
+	for _, c := range cases {
+		if got := payout.Commission(c.gross, c.rate); got != c.expected {
+			t.Errorf("Commission(%v, %v): expected %v, got %v", c.gross, c.rate, c.expected, got)
+		}
+	}
+}
+
+// payoutRepo keeps the entries in memory, the vendors having no agreement.
+type payoutRepo struct {
+	payout.Repo
+	entries []payout.Entry
+}
+
+func (r *payoutRepo) GetAgreement(vendorID string) (payout.Agreement, error) {
+	return payout.Agreement{}, db.ErrNotFound
+}
+
+func (r *payoutRepo) CreateEntry(e *payout.Entry) error {
+	r.entries = append(r.entries, *e)
+	return nil
+}
+
+func (r *payoutRepo) ListEntriesByOrder(orderID string) ([]payout.Entry, error) {
+	var entries []payout.Entry
+	for _, e := range r.entries {
+		if e.OrderID == orderID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(id string) (order.Order, error) {
+	o, ok := r.orders[id]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+type vendorRepo struct {
+	vendors.Repo
+}
+
+func (vendorRepo) GetByID(id string) (vendors.Vendor, error) {
+	return vendors.Vendor{ID: id}, nil
+}
+
+func newOrders() orderRepo {
+	now := time.Now()
+	books := []catalog.Book{{ID: "b1", VendorID: "v1", Price: 30}, {ID: "b2", Price: 8}}
+	return orderRepo{orders: map[string]order.Order{
+		"paid": {ID: "paid", Currency: "EUR", PaidAt: &now, Items: books, Lines: []order.Line{
+			{BookID: "b1", Quantity: 2, Price: 12.5},
+			{BookID: "b2", Quantity: 1, Price: 8},
+		}},
+		"unpaid": {ID: "unpaid", Currency: "EUR", Items: books, Lines: []order.Line{{BookID: "b1", Quantity: 1, Price: 12.5}}},
+	}}
+}
+
+func TestRecord(t *testing.T) {
+	r := &payoutRepo{}
+	s := payout.NewService(r, newOrders(), vendorRepo{}, payout.Config{DefaultRate: 0.2})
+
+	if _, err := s.Record(context.Background(), "unpaid"); err != payout.ErrUnpaid || len(r.entries) != 0 {
+		t.Errorf("unpaid: expected ErrUnpaid and no entry, got %v, %+v", err, r.entries)
+	}
+	entries, err := s.Record(context.Background(), "paid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 2 copies sold at 12.50, not at the catalog price of 30.
+	if len(entries) != 1 || entries[0].Gross != 25 || entries[0].Commission != 5 || entries[0].Net != 20 {
+		t.Errorf("paid: expected the line of v1 at the price paid, got %+v", entries)
+	}
+	if again, _ := s.Record(context.Background(), "paid"); len(again) != 1 || len(r.entries) != 1 {
+		t.Errorf("again: expected the order recorded once, got %+v", r.entries)
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	s := payout.NewService(&payoutRepo{}, newOrders(), vendorRepo{}, payout.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := payout.MakeHTTPHandler(context.Background(), s, nil, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"record without token", "POST", "/payouts/v1/admin/orders/paid/record", "", http.StatusUnauthorized},
+		{"record by customer", "POST", "/payouts/v1/admin/orders/paid/record", customer, http.StatusForbidden},
+		{"record by admin", "POST", "/payouts/v1/admin/orders/paid/record", staff, http.StatusOK},
+		{"agreement by customer", "GET", "/payouts/v1/admin/agreements/v1", customer, http.StatusForbidden},
+		{"set agreement by customer", "PUT", "/payouts/v1/admin/agreements/v1", customer, http.StatusForbidden},
+		{"run by customer", "POST", "/payouts/v1/admin/run", customer, http.StatusForbidden},
+		{"agreement by admin", "GET", "/payouts/v1/admin/agreements/v1", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{"rate":0.1}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
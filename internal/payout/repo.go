@@ -0,0 +1,18 @@
+package payout
+
+// Repo abstracts all the persistant storage operations of Payout Service
+type Repo interface {
+	GetAgreement(vendorID string) (Agreement, error)
+	SaveAgreement(a *Agreement) error
+	CreateEntry(e *Entry) error
+	ListEntriesByOrder(orderID string) ([]Entry, error)
+	ListEntriesByPayout(payoutID string) ([]Entry, error)
+	// ListUnpaid returns the unpaid entries of the vendor, of all the vendors
+	// if vendorID is empty.
+	ListUnpaid(vendorID string) ([]Entry, error)
+	// CreatePayout saves the payout and marks the entries as paid by it.
+	CreatePayout(p *Payout, entryIDs []string) error
+	GetPayout(ID string) (Payout, error)
+	ListPayouts(vendorID string) ([]Payout, error)
+	Drop() error
+}
@@ -0,0 +1,24 @@
+package payout
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunScheduler generates payouts every interval until ctx is done.
+func RunScheduler(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunPayouts(ctx); err != nil {
+				logger.Log("scheduler", "payout", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+var (
+	ErrInvalidRate    = errors.New("commission rate must be between 0 and 1")
+	ErrPayoutNotFound = errors.New("payout not found")
+	ErrUnpaid         = errors.New("order is not paid")
+)
+
+type Service interface {
+	// Agreement returns the commission agreement of the vendor. Vendors
+	// without an agreement get the default rate.
+	Agreement(ctx context.Context, vendorID string) (Agreement, error)
+
+	// SetAgreement sets the commission rate of the vendor.
+	SetAgreement(ctx context.Context, vendorID string, rate float64) (Agreement, error)
+
+	// Record computes the commission on the vendor books of a paid order
+	// and credits the rest to the vendors. It is safe to call multiple
+	// times, an order is recorded only once.
+	Record(ctx context.Context, orderID string) ([]Entry, error)
+
+	// Balance returns the unpaid earnings of the vendor per currency.
+	Balance(ctx context.Context, vendorID string) ([]Balance, error)
+
+	// RunPayouts generates payout statements for all the vendor balances
+	// reaching the minimum amount.
+	RunPayouts(ctx context.Context) ([]Payout, error)
+
+	// Payouts returns the payout history of the vendor.
+	Payouts(ctx context.Context, vendorID string) ([]Payout, error)
+
+	// Statement returns the payout with the entries it pays.
+	Statement(ctx context.Context, vendorID, payoutID string) (Statement, error)
+}
+
+type basicService struct {
+	r       Repo
+	orders  order.Repo
+	vendors vendors.Repo
+	cfg     Config
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, vendors vendors.Repo, cfg Config) Service {
+	return basicService{r: r, orders: orders, vendors: vendors, cfg: cfg}
+}
+
+// Agreement returns the commission agreement of the vendor.
+func (s basicService) Agreement(ctx context.Context, vendorID string) (Agreement, error) {
+	if _, err := s.vendors.GetByID(vendorID); err != nil {
+		return Agreement{}, vendors.ErrVendorNotFound
+	}
+	a, err := s.r.GetAgreement(vendorID)
+	if err == db.ErrNotFound {
+		return Agreement{VendorID: vendorID, Rate: s.cfg.DefaultRate}, nil
+	}
+	return a, err
+}
+
+// SetAgreement sets the commission rate of the vendor.
+// New rate applies only to the orders recorded afterwards.
+func (s basicService) SetAgreement(ctx context.Context, vendorID string, rate float64) (Agreement, error) {
+	if rate < 0 || rate > 1 {
+		return Agreement{}, ErrInvalidRate
+	}
+	if _, err := s.vendors.GetByID(vendorID); err != nil {
+		return Agreement{}, vendors.ErrVendorNotFound
+	}
+	a := Agreement{VendorID: vendorID, Rate: rate, UpdatedAt: time.Now().UTC()}
+	if err := s.r.SaveAgreement(&a); err != nil {
+		return Agreement{}, err
+	}
+	return a, nil
+}
+
+// Record computes the commission on the vendor lines of an order, at the
+// price the customer paid for them.
+func (s basicService) Record(ctx context.Context, orderID string) ([]Entry, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	if o.PaidAt == nil {
+		return nil, ErrUnpaid
+	}
+	entries, err := s.r.ListEntriesByOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return entries, nil
+	}
+
+	books := make(map[string]catalog.Book, len(o.Items))
+	for _, b := range o.Items {
+		books[b.ID] = b
+	}
+	lines := o.Lines
+	if len(lines) == 0 {
+		// Orders placed before the lines were kept.
+		for _, b := range o.Items {
+			lines = append(lines, order.Line{OrderID: o.ID, BookID: b.ID, Quantity: 1, Price: b.Price})
+		}
+	}
+	rates := make(map[string]float64)
+	now := time.Now().UTC()
+	for _, l := range lines {
+		vendorID := books[l.BookID].VendorID
+		// Books sold by the shop itself don't earn anything to anyone.
+		if vendorID == "" {
+			continue
+		}
+		rate, ok := rates[vendorID]
+		if !ok {
+			a, err := s.Agreement(ctx, vendorID)
+			if err != nil {
+				return nil, err
+			}
+			rate = a.Rate
+			rates[vendorID] = rate
+		}
+		gross := float64(cents(l.Price)*int64(l.Quantity)) / 100
+		commission := Commission(gross, rate)
+		e := Entry{
+			VendorID:   vendorID,
+			OrderID:    o.ID,
+			BookID:     l.BookID,
+			Currency:   o.Currency,
+			Gross:      gross,
+			Rate:       rate,
+			Commission: commission,
+			Net:        float64(cents(gross)-cents(commission)) / 100,
+			CreatedAt:  now,
+		}
+		if err := s.r.CreateEntry(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Balance returns the unpaid earnings of the vendor per currency.
+func (s basicService) Balance(ctx context.Context, vendorID string) ([]Balance, error) {
+	entries, err := s.r.ListUnpaid(vendorID)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]Balance, 0)
+	for _, g := range group(entries) {
+		balances = append(balances, g.balance())
+	}
+	return balances, nil
+}
+
+// RunPayouts generates payout statements for the vendor balances reaching
+// the minimum amount. Smaller balances are carried to the next run.
+func (s basicService) RunPayouts(ctx context.Context) ([]Payout, error) {
+	entries, err := s.r.ListUnpaid("")
+	if err != nil {
+		return nil, err
+	}
+	payouts := make([]Payout, 0)
+	now := time.Now().UTC()
+	for _, g := range group(entries) {
+		b := g.balance()
+		if b.Amount <= 0 || b.Amount < s.cfg.MinAmount {
+			continue
+		}
+		p := Payout{
+			VendorID:    b.VendorID,
+			Currency:    b.Currency,
+			PeriodStart: g.entries[0].CreatedAt,
+			PeriodEnd:   now,
+			EntryCount:  b.EntryCount,
+			Gross:       b.Gross,
+			Commission:  b.Commission,
+			Amount:      b.Amount,
+			CreatedAt:   now,
+		}
+		ids := make([]string, len(g.entries))
+		for i, e := range g.entries {
+			ids[i] = e.ID
+		}
+		if err := s.r.CreatePayout(&p, ids); err != nil {
+			return payouts, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, nil
+}
+
+// Payouts returns the payout history of the vendor.
+func (s basicService) Payouts(ctx context.Context, vendorID string) ([]Payout, error) {
+	return s.r.ListPayouts(vendorID)
+}
+
+// Statement returns the payout with the entries it pays.
+func (s basicService) Statement(ctx context.Context, vendorID, payoutID string) (Statement, error) {
+	p, err := s.r.GetPayout(payoutID)
+	if err != nil || p.VendorID != vendorID {
+		return Statement{}, ErrPayoutNotFound
+	}
+	entries, err := s.r.ListEntriesByPayout(payoutID)
+	if err != nil {
+		return Statement{}, err
+	}
+	return Statement{Payout: p, Entries: entries}, nil
+}
+
+// entryGroup is the unpaid entries of a vendor in a single currency.
+type entryGroup struct {
+	vendorID string
+	currency string
+	entries  []Entry
+}
+
+// balance sums the group in cents.
+func (g entryGroup) balance() Balance {
+	var gross, commission, net int64
+	for _, e := range g.entries {
+		gross += cents(e.Gross)
+		commission += cents(e.Commission)
+		net += cents(e.Net)
+	}
+	return Balance{
+		VendorID:   g.vendorID,
+		Currency:   g.currency,
+		EntryCount: len(g.entries),
+		Gross:      float64(gross) / 100,
+		Commission: float64(commission) / 100,
+		Amount:     float64(net) / 100,
+	}
+}
+
+// group groups the entries by vendor and currency, ordered by vendor and
+// currency, entries keep the oldest first.
+func group(entries []Entry) []entryGroup {
+	type key struct{ vendorID, currency string }
+	groups := make(map[key]*entryGroup)
+	for _, e := range entries {
+		k := key{e.VendorID, e.Currency}
+		g, ok := groups[k]
+		if !ok {
+			g = &entryGroup{vendorID: e.VendorID, currency: e.Currency}
+			groups[k] = g
+		}
+		g.entries = append(g.entries, e)
+	}
+
+	list := make([]entryGroup, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.entries, func(i, j int) bool {
+			return g.entries[i].CreatedAt.Before(g.entries[j].CreatedAt)
+		})
+		list = append(list, *g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].vendorID != list[j].vendorID {
+			return list[i].vendorID < list[j].vendorID
+		}
+		return list[i].currency < list[j].currency
+	})
+	return list
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,211 @@
+package payout
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/kavirajk/bookshop/vendors"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// MakeHTTPHandler mounts the payout endpoints, the shop admin ones served
+// to the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole, the vendor ones to the API keys keys resolves.
+func MakeHTTPHandler(ctx context.Context, s Service, keys Authenticator, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, keys, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	agreementHandler := httptransport.NewServer(
+		e.AgreementEndpoint,
+		decodeAgreementRequest,
+		encodeResponse,
+		options...,
+	)
+	setAgreementHandler := httptransport.NewServer(
+		e.SetAgreementEndpoint,
+		decodeSetAgreementRequest,
+		encodeResponse,
+		options...,
+	)
+	recordHandler := httptransport.NewServer(
+		e.RecordEndpoint,
+		decodeRecordRequest,
+		encodeResponse,
+		options...,
+	)
+	runPayoutsHandler := httptransport.NewServer(
+		e.RunPayoutsEndpoint,
+		decodeRunPayoutsRequest,
+		encodeResponse,
+		options...,
+	)
+	balanceHandler := httptransport.NewServer(
+		e.BalanceEndpoint,
+		decodeVendorRequest,
+		encodeResponse,
+		options...,
+	)
+	payoutsHandler := httptransport.NewServer(
+		e.PayoutsEndpoint,
+		decodeVendorRequest,
+		encodeResponse,
+		options...,
+	)
+	statementHandler := httptransport.NewServer(
+		e.StatementEndpoint,
+		decodeStatementRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Shop admin endpoints
+	r.Handle("/payouts/v1/admin/agreements/{vendor-id}", agreementHandler).Methods("GET")
+	r.Handle("/payouts/v1/admin/agreements/{vendor-id}", setAgreementHandler).Methods("PUT")
+	r.Handle("/payouts/v1/admin/orders/{order-id}/record", recordHandler).Methods("POST")
+	r.Handle("/payouts/v1/admin/run", runPayoutsHandler).Methods("POST")
+
+	// Vendor endpoints, authenticated by the vendor's API key
+	r.Handle("/payouts/v1/me/balance", balanceHandler).Methods("GET")
+	r.Handle("/payouts/v1/me/payouts", payoutsHandler).Methods("GET")
+	r.Handle("/payouts/v1/me/payouts/{payout-id}", statementHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeAgreementRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vendorID, ok := mux.Vars(req)["vendor-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "vendor-id")
+	}
+	return agreementRequest{VendorID: vendorID}, nil
+}
+
+func decodeSetAgreementRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r agreementRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	vendorID, ok := mux.Vars(req)["vendor-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "vendor-id")
+	}
+	r.VendorID = vendorID
+	return r, nil
+}
+
+func decodeRecordRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return recordRequest{OrderID: orderID}, nil
+}
+
+func decodeRunPayoutsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return runPayoutsRequest{}, nil
+}
+
+func decodeVendorRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return vendorRequest{APIKey: vendors.APIKeyFrom(req)}, nil
+}
+
+func decodeStatementRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	payoutID, ok := mux.Vars(req)["payout-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "payout-id")
+	}
+	return statementRequest{APIKey: vendors.APIKeyFrom(req), PayoutID: payoutID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrPayoutNotFound, vendors.ErrVendorNotFound, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case vendors.ErrUnauthorized, auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case vendors.ErrForbidden, vendors.ErrNotApproved, rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrUnpaid:
+		return http.StatusConflict
+	case ErrBadRouting, ErrInvalidRate, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,135 @@
+package picking
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the picking service endpoints under single type.
+type Endpoints struct {
+	CreateListEndpoint endpoint.Endpoint
+	ListsEndpoint      endpoint.Endpoint
+	ListEndpoint       endpoint.Endpoint
+	OrderEndpoint      endpoint.Endpoint
+	ScanEndpoint       endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the picking service endpoints, restricted by staff, e.g. to the
+// shop staff.
+func MakeEndpoints(s Service, staff endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateListEndpoint: staff(MakeCreateListEndpoint(s)),
+		ListsEndpoint:      staff(MakeListsEndpoint(s)),
+		ListEndpoint:       staff(MakeListEndpoint(s)),
+		OrderEndpoint:      staff(MakeOrderEndpoint(s)),
+		ScanEndpoint:       staff(MakeScanEndpoint(s)),
+	}
+}
+
+func MakeCreateListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createListRequest)
+		l, e := s.CreateList(ctx, req.Size)
+		if e != nil {
+			return listResponse{List: nil, Error: e}, nil
+		}
+		return listResponse{List: &l, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeListsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listsRequest)
+		lists, e := s.Lists(ctx, req.Status)
+		if e != nil {
+			return listsResponse{Lists: make([]PickList, 0), Error: e}, nil
+		}
+		return listsResponse{Lists: lists}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		l, e := s.List(ctx, req.ID)
+		if e != nil {
+			return listResponse{List: nil, Error: e}, nil
+		}
+		return listResponse{List: &l}, nil
+	}
+}
+
+func MakeOrderEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		o, e := s.Order(ctx, req.ID)
+		if e != nil {
+			return orderResponse{Order: nil, Error: e}, nil
+		}
+		return orderResponse{Order: &o}, nil
+	}
+}
+
+func MakeScanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(scanRequest)
+		o, e := s.Scan(ctx, req.OrderID, req.ISBN)
+		if e != nil {
+			return orderResponse{Order: nil, Error: e}, nil
+		}
+		return orderResponse{Order: &o}, nil
+	}
+}
+
+type createListRequest struct {
+	// Size is the number of orders to batch, DefaultBatchSize if 0.
+	Size int `json:"size"`
+}
+
+type listsRequest struct {
+	Status string
+}
+
+type idRequest struct {
+	ID string
+}
+
+type scanRequest struct {
+	OrderID string `json:"-"`
+	ISBN    string `json:"isbn"`
+}
+
+type listResponse struct {
+	Status int       `json:"-"`
+	List   *PickList `json:"pick_list,omitempty"`
+	Error  error     `json:"error,omitempty"`
+}
+
+func (r listResponse) status() int {
+	return r.Status
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+type listsResponse struct {
+	Lists []PickList `json:"pick_lists"`
+	Error error      `json:"error,omitempty"`
+}
+
+func (r listsResponse) error() error {
+	return r.Error
+}
+
+type orderResponse struct {
+	Order *PickOrder `json:"order,omitempty"`
+	Error error      `json:"error,omitempty"`
+}
+
+func (r orderResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package picking
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) CreateList(ctx context.Context, size int) (l PickList, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	l, err = mw.next.CreateList(ctx, size)
+	return
+}
+
+func (mw instrmw) Lists(ctx context.Context, status string) (lists []PickList, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "lists", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	lists, err = mw.next.Lists(ctx, status)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context, ID string) (l PickList, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	l, err = mw.next.List(ctx, ID)
+	return
+}
+
+func (mw instrmw) Order(ctx context.Context, orderID string) (o PickOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	o, err = mw.next.Order(ctx, orderID)
+	return
+}
+
+func (mw instrmw) Scan(ctx context.Context, orderID, isbn string) (o PickOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "scan", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	o, err = mw.next.Scan(ctx, orderID, isbn)
+	return
+}
@@ -0,0 +1,87 @@
+package picking
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) CreateList(ctx context.Context, size int) (l PickList, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_list",
+			"size", size,
+			"list", l.ID,
+			"orders", len(l.Orders),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreateList(ctx, size)
+}
+
+func (s loggingService) Lists(ctx context.Context, status string) (lists []PickList, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "lists",
+			"status", status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Lists(ctx, status)
+}
+
+func (s loggingService) List(ctx context.Context, ID string) (l PickList, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"list", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx, ID)
+}
+
+func (s loggingService) Order(ctx context.Context, orderID string) (o PickOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order",
+			"order", orderID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Order(ctx, orderID)
+}
+
+func (s loggingService) Scan(ctx context.Context, orderID, isbn string) (o PickOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "scan",
+			"order", orderID,
+			"isbn", isbn,
+			"status", o.Status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Scan(ctx, orderID, isbn)
+}
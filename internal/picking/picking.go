@@ -0,0 +1,144 @@
+package picking
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Statuses of a pick list.
+const (
+	StatusOpen = "open" // being picked and packed
+	StatusDone = "done" // all its orders packed
+)
+
+// Statuses of an order of a pick list.
+const (
+	OrderPicking = "picking"
+	OrderPacked  = "packed"
+)
+
+// Batch sizes of the pick lists, in orders.
+const (
+	DefaultBatchSize = 20
+	MaxBatchSize     = 100
+)
+
+// PickList is a batch of open orders picked in one walk of the warehouse,
+// each order into its own tote.
+type PickList struct {
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Orders []PickOrder `json:"orders" gorm:"ForeignKey:PickListID"`
+	// Lines are the books to pick, in the order of their shelf location.
+	Lines     []PickLine `json:"lines" gorm:"ForeignKey:PickListID"`
+	CreatedAt time.Time  `json:"created_at"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+}
+
+func (PickList) TableName() string {
+	return "pick_lists"
+}
+
+// PickOrder is an order of a pick list. An order is picked once, the
+// books to pack being the ones of the order when it was picked.
+type PickOrder struct {
+	OrderID    string `json:"order_id" gorm:"primary_key"`
+	PickListID string `json:"pick_list_id" sql:"index"`
+	// Tote is the number of the tote the books of the order are picked
+	// into, from 1.
+	Tote     int        `json:"tote"`
+	Status   string     `json:"status"`
+	Items    []PackItem `json:"items" gorm:"ForeignKey:OrderID"`
+	PackedAt *time.Time `json:"packed_at,omitempty"`
+}
+
+func (PickOrder) TableName() string {
+	return "pick_orders"
+}
+
+// Packed tells whether every book of the order was scanned.
+func (o PickOrder) Packed() bool {
+	for _, it := range o.Items {
+		if it.Scanned < it.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// PackItem is a book of an order to scan into its parcel.
+type PackItem struct {
+	OrderID  string `json:"-" gorm:"primary_key"`
+	BookID   string `json:"book_id" gorm:"primary_key"`
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+	Scanned  int    `json:"scanned"`
+}
+
+func (PackItem) TableName() string {
+	return "pick_pack_items"
+}
+
+// PickLine is a book to pick for the orders of a pick list.
+type PickLine struct {
+	PickListID string `json:"-" gorm:"primary_key"`
+	BookID     string `json:"book_id" gorm:"primary_key"`
+	// Seq is the rank of the line in the walk, from 1.
+	Seq      int    `json:"seq"`
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Location string `json:"location"`
+	Quantity int    `json:"quantity"`
+	// Totes tells how many to put in which tote, e.g. "1x2 4x1".
+	Totes string `json:"totes"`
+}
+
+func (PickLine) TableName() string {
+	return "pick_lines"
+}
+
+// LocationLess orders the shelf locations the way the warehouse is walked,
+// their numbers by value so that A-9 comes before A-10. The books without
+// a location come last.
+func LocationLess(a, b string) bool {
+	if a == "" || b == "" {
+		return a != "" && b == ""
+	}
+	x, y := chunks(strings.ToUpper(a)), chunks(strings.ToUpper(b))
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] == y[i] {
+			continue
+		}
+		n, errX := strconv.Atoi(x[i])
+		m, errY := strconv.Atoi(y[i])
+		if errX == nil && errY == nil && n != m {
+			return n < m
+		}
+		return x[i] < y[i]
+	}
+	return len(x) < len(y)
+}
+
+// chunks splits s into its runs of digits and of letters, the other runes
+// separating them.
+func chunks(s string) []string {
+	var list []string
+	var run []rune
+	for _, r := range s {
+		alnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if len(run) > 0 && (!alnum || unicode.IsDigit(r) != unicode.IsDigit(run[0])) {
+			list = append(list, string(run))
+			run = run[:0]
+		}
+		if alnum {
+			run = append(run, r)
+		}
+	}
+	if len(run) > 0 {
+		list = append(list, string(run))
+	}
+	return list
+}
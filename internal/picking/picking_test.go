@@ -0,0 +1,226 @@
+package picking_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/picking"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestLocationLess(t *testing.T) {
+	locations := []string{"", "B-2-1", "a-10", "A-9-2", "A-9", "B-10", "A-9-10"}
+	sort.Slice(locations, func(i, j int) bool { return picking.LocationLess(locations[i], locations[j]) })
+	expected := []string{"A-9", "A-9-2", "A-9-10", "a-10", "B-2-1", "B-10", ""}
+	for i := range expected {
+		if locations[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected, locations)
+		}
+	}
+}
+
+// repo keeps the pick lists in memory.
+type repo struct {
+	picking.Repo
+	lists map[string]picking.PickList
+}
+
+func (r *repo) Create(l *picking.PickList) error {
+	l.ID = "p1"
+	for i := range l.Orders {
+		l.Orders[i].PickListID = l.ID
+	}
+	r.lists[l.ID] = *l
+	return nil
+}
+
+func (r *repo) Get(ID string) (picking.PickList, error) {
+	l, ok := r.lists[ID]
+	if !ok {
+		return picking.PickList{}, db.ErrNotFound
+	}
+	return l, nil
+}
+
+func (r *repo) Done(l *picking.PickList) error {
+	r.lists[l.ID] = *l
+	return nil
+}
+
+func (r *repo) GetOrder(orderID string) (picking.PickOrder, error) {
+	for _, l := range r.lists {
+		for _, o := range l.Orders {
+			if o.OrderID == orderID {
+				o.Items = append([]picking.PackItem(nil), o.Items...)
+				return o, nil
+			}
+		}
+	}
+	return picking.PickOrder{}, db.ErrNotFound
+}
+
+func (r *repo) SaveOrder(o *picking.PickOrder) error {
+	l := r.lists[o.PickListID]
+	for i := range l.Orders {
+		if l.Orders[i].OrderID == o.OrderID {
+			l.Orders[i] = *o
+		}
+	}
+	return nil
+}
+
+// orderRepo lists the orders newest first.
+type orderRepo struct {
+	order.Repo
+	orders []order.Order
+}
+
+func (r orderRepo) List(f filter.Expr, limit, offset int, count db.Count) ([]order.Order, int, error) {
+	if offset >= len(r.orders) {
+		return nil, 0, nil
+	}
+	return r.orders[offset:], 0, nil
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	for _, o := range r.orders {
+		if o.ID == ID {
+			return o, nil
+		}
+	}
+	return order.Order{}, order.ErrOrderNotFound
+}
+
+type catalogRepo struct {
+	catalog.Repo
+	books map[string]catalog.Book
+}
+
+func (r catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	b, ok := r.books[ID]
+	if !ok {
+		return catalog.Book{}, db.ErrNotFound
+	}
+	return b, nil
+}
+
+// fulfillmentService records the orders packed.
+type fulfillmentService struct {
+	fulfillment.Service
+	packed []string
+}
+
+func (s *fulfillmentService) Pack(ctx context.Context, orderID string) ([]fulfillment.Shipment, error) {
+	s.packed = append(s.packed, orderID)
+	return nil, nil
+}
+
+func TestPickAndPack(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{lists: make(map[string]picking.PickList)}
+	orders := orderRepo{orders: []order.Order{
+		{ID: "o3", Lines: []order.Line{{BookID: "pod", Quantity: 1}}},
+		{ID: "o2", Lines: []order.Line{{BookID: "b1", Quantity: 2}}},
+		{ID: "o1", Items: []catalog.Book{{ID: "b2"}, {ID: "b1"}}},
+	}}
+	books := catalogRepo{books: map[string]catalog.Book{
+		"b1":  {ID: "b1", ISBN: "9780000000011", Title: "Dune", Location: "B-10"},
+		"b2":  {ID: "b2", ISBN: "9780000000028", Title: "Emma", Location: "B-9"},
+		"pod": {ID: "pod", ISBN: "9780000000035", Title: "Ulysses", PrintOnDemand: true},
+	}}
+	f := &fulfillmentService{}
+	s := picking.NewService(r, orders, books, f)
+
+	l, err := s.CreateList(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Orders) != 2 || l.Orders[0].OrderID != "o1" || l.Orders[0].Tote != 1 || l.Orders[1].OrderID != "o2" {
+		t.Errorf("expected the oldest order in the first tote, the print-on-demand one left out, got %+v", l.Orders)
+	}
+	if len(l.Lines) != 2 || l.Lines[0].BookID != "b2" || l.Lines[1].Quantity != 3 || l.Lines[1].Totes != "1x1 2x2" || l.Lines[1].Seq != 2 {
+		t.Errorf("expected the lines in the order of the shelves, got %+v", l.Lines)
+	}
+	if _, err := s.CreateList(ctx, 0); err != picking.ErrNothingToPick {
+		t.Errorf("picked orders: expected ErrNothingToPick, got %v", err)
+	}
+
+	if _, err := s.Scan(ctx, "o2", "978-0-00-000002-8"); err != picking.ErrNotInOrder {
+		t.Errorf("expected ErrNotInOrder, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.Scan(ctx, "o2", "978-0-00-000001-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Scan(ctx, "o2", "9780000000011"); err != picking.ErrPacked {
+		t.Errorf("expected ErrPacked, got %v", err)
+	}
+	if o, _ := s.Order(ctx, "o2"); o.Status != picking.OrderPacked || o.PackedAt == nil || len(f.packed) != 1 {
+		t.Errorf("expected the order and its shipments packed, got %+v, %v", o, f.packed)
+	}
+	if l, _ := s.List(ctx, "p1"); l.Status != picking.StatusOpen {
+		t.Errorf("expected the list open until its last order, got %s", l.Status)
+	}
+
+	if _, err := s.Scan(ctx, "o1", "9780000000028"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Scan(ctx, "o1", "9780000000028"); err != picking.ErrAlreadyScanned {
+		t.Errorf("expected ErrAlreadyScanned, got %v", err)
+	}
+	if _, err := s.Scan(ctx, "o1", "9780000000011"); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := s.List(ctx, "p1"); l.Status != picking.StatusDone || l.DoneAt == nil {
+		t.Errorf("expected the list done, got %+v", l)
+	}
+}
+
+func TestStaffRoutes(t *testing.T) {
+	s := picking.NewService(&repo{lists: make(map[string]picking.PickList)}, orderRepo{}, catalogRepo{}, &fulfillmentService{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	staff := rbac.Staff(tokens, user.RoleAdmin, user.RoleSupport)
+	h := picking.MakeHTTPHandler(context.Background(), s, staff, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	support, _, _ := tokens.Sign("u8", user.RoleSupport, "")
+	admin, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"create without token", "POST", "/picking/v1/lists", "", http.StatusUnauthorized},
+		{"create by customer", "POST", "/picking/v1/lists", customer, http.StatusForbidden},
+		{"list by customer", "GET", "/picking/v1/lists/l9", customer, http.StatusForbidden},
+		{"order by customer", "GET", "/picking/v1/orders/o1", customer, http.StatusForbidden},
+		{"scan by customer", "POST", "/picking/v1/orders/o1/scan", customer, http.StatusForbidden},
+		{"list by scoped token", "GET", "/picking/v1/lists/l9", scoped, http.StatusForbidden},
+		{"list by support", "GET", "/picking/v1/lists/l9", support, http.StatusNotFound},
+		{"list by admin", "GET", "/picking/v1/lists/l9", admin, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package picking
+
+// Repo abstracts all the persistant storage operations of Picking Service
+type Repo interface {
+	// Create stores the pick list with its orders, their items and its
+	// lines in one go.
+	Create(l *PickList) error
+	// Get returns the pick list with its orders, their items and its lines.
+	Get(ID string) (PickList, error)
+	// List returns the pick lists of status, all if empty, latest first,
+	// without their orders and lines.
+	List(status string) ([]PickList, error)
+	// Done marks the pick list done.
+	Done(l *PickList) error
+
+	// GetOrder returns the order of a pick list with its items.
+	GetOrder(orderID string) (PickOrder, error)
+	// SaveOrder saves the order and its items.
+	SaveOrder(o *PickOrder) error
+	Drop() error
+}
@@ -0,0 +1,293 @@
+package picking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrListNotFound   = errors.New("pick list not found")
+	ErrNothingToPick  = errors.New("no open order to pick")
+	ErrNotPicked      = errors.New("order is not on a pick list")
+	ErrNotInOrder     = errors.New("book is not in the order")
+	ErrAlreadyScanned = errors.New("every copy of the book is already scanned")
+	ErrPacked         = errors.New("order is already packed")
+	ErrUnknownStatus  = errors.New("status must be open or done")
+)
+
+// pageSize is the orders read at once.
+const pageSize = 500
+
+// open selects the orders to pick, paid and not shipped yet.
+var open = filter.Expr{SQL: "paid_at IS NOT NULL AND shipped_at IS NULL"}
+
+type Service interface {
+	// CreateList batches up to size of the oldest open orders not picked
+	// yet, DefaultBatchSize if 0, leaving out the print-on-demand books.
+	CreateList(ctx context.Context, size int) (PickList, error)
+
+	// Lists returns the pick lists of status, all if empty.
+	Lists(ctx context.Context, status string) ([]PickList, error)
+
+	// List returns a pick list with its orders and its lines.
+	List(ctx context.Context, ID string) (PickList, error)
+
+	// Order returns the packing progress of a picked order.
+	Order(ctx context.Context, orderID string) (PickOrder, error)
+
+	// Scan confirms a book packed into the parcel of an order by its ISBN.
+	// Once every book is scanned the order is packed, its shipments along,
+	// and its pick list is done with its last order.
+	Scan(ctx context.Context, orderID, isbn string) (PickOrder, error)
+}
+
+type basicService struct {
+	r           Repo
+	orders      order.Repo
+	catalog     catalog.Repo
+	fulfillment fulfillment.Service
+}
+
+// NewService return basic Service implementation. The shipments of the
+// packed orders are marked packed by fulfillment, unless nil.
+func NewService(r Repo, orders order.Repo, catalog catalog.Repo, fulfillment fulfillment.Service) Service {
+	return basicService{r: r, orders: orders, catalog: catalog, fulfillment: fulfillment}
+}
+
+func (s basicService) CreateList(ctx context.Context, size int) (PickList, error) {
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+	var v validate.Validator
+	v.Range("size", size, 1, MaxBatchSize)
+	if err := v.Err(); err != nil {
+		return PickList{}, err
+	}
+	candidates, err := s.candidates()
+	if err != nil {
+		return PickList{}, err
+	}
+
+	l := PickList{Status: StatusOpen, CreatedAt: time.Now().UTC()}
+	lines := make(map[string]*PickLine)
+	totes := make(map[string][]string)
+	for _, id := range candidates {
+		if len(l.Orders) == size {
+			break
+		}
+		po, books, err := s.pickOrder(id, len(l.Orders)+1)
+		if err != nil {
+			return PickList{}, err
+		}
+		if len(po.Items) == 0 {
+			continue
+		}
+		for _, it := range po.Items {
+			pl, ok := lines[it.BookID]
+			if !ok {
+				pl = &PickLine{BookID: it.BookID, ISBN: it.ISBN, Title: it.Title, Location: books[it.BookID].Location}
+				lines[it.BookID] = pl
+			}
+			pl.Quantity += it.Quantity
+			totes[it.BookID] = append(totes[it.BookID], fmt.Sprintf("%dx%d", po.Tote, it.Quantity))
+		}
+		l.Orders = append(l.Orders, po)
+	}
+	if len(l.Orders) == 0 {
+		return PickList{}, ErrNothingToPick
+	}
+	for id, pl := range lines {
+		pl.Totes = strings.Join(totes[id], " ")
+		l.Lines = append(l.Lines, *pl)
+	}
+	sort.Slice(l.Lines, func(i, j int) bool {
+		a, b := l.Lines[i], l.Lines[j]
+		if a.Location != b.Location {
+			return LocationLess(a.Location, b.Location)
+		}
+		return a.Title < b.Title
+	})
+	for i := range l.Lines {
+		l.Lines[i].Seq = i + 1
+	}
+	if err := s.r.Create(&l); err != nil {
+		return PickList{}, err
+	}
+	return l, nil
+}
+
+// candidates returns the IDs of the open orders, oldest first, the picked
+// ones left out.
+func (s basicService) candidates() ([]string, error) {
+	var ids []string
+	for offset := 0; ; offset += pageSize {
+		orders, _, err := s.orders.List(open, pageSize, offset, db.CountNone)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			ids = append(ids, o.ID)
+		}
+		if len(orders) < pageSize {
+			break
+		}
+	}
+	var candidates []string
+	for i := len(ids) - 1; i >= 0; i-- {
+		_, err := s.r.GetOrder(ids[i])
+		if err == nil {
+			continue
+		}
+		if err != db.ErrNotFound {
+			return nil, err
+		}
+		candidates = append(candidates, ids[i])
+	}
+	return candidates, nil
+}
+
+// pickOrder returns the books of an order the shop ships itself, to pick
+// into tote, along with the books by ID.
+func (s basicService) pickOrder(orderID string, tote int) (PickOrder, map[string]catalog.Book, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return PickOrder{}, nil, err
+	}
+	lines := o.Lines
+	if len(lines) == 0 {
+		// Orders placed before the lines were kept.
+		for _, b := range o.Items {
+			lines = append(lines, order.Line{OrderID: o.ID, BookID: b.ID, Quantity: 1})
+		}
+	}
+	po := PickOrder{OrderID: o.ID, Tote: tote, Status: OrderPicking}
+	books := make(map[string]catalog.Book)
+	byBook := make(map[string]int)
+	for _, ln := range lines {
+		b, err := s.catalog.GetByID(ln.BookID)
+		if err == db.ErrNotFound {
+			return PickOrder{}, nil, catalog.ErrBookNotFound
+		}
+		if err != nil {
+			return PickOrder{}, nil, err
+		}
+		if b.PrintOnDemand {
+			continue
+		}
+		books[b.ID] = b
+		if i, ok := byBook[b.ID]; ok {
+			po.Items[i].Quantity += ln.Quantity
+			continue
+		}
+		byBook[b.ID] = len(po.Items)
+		po.Items = append(po.Items, PackItem{OrderID: o.ID, BookID: b.ID, ISBN: b.ISBN, Title: b.Title, Quantity: ln.Quantity})
+	}
+	return po, books, nil
+}
+
+func (s basicService) Lists(ctx context.Context, status string) ([]PickList, error) {
+	switch status {
+	case "", StatusOpen, StatusDone:
+	default:
+		return nil, ErrUnknownStatus
+	}
+	return s.r.List(status)
+}
+
+func (s basicService) List(ctx context.Context, ID string) (PickList, error) {
+	l, err := s.r.Get(ID)
+	if err == db.ErrNotFound {
+		return PickList{}, ErrListNotFound
+	}
+	return l, err
+}
+
+func (s basicService) Order(ctx context.Context, orderID string) (PickOrder, error) {
+	o, err := s.r.GetOrder(orderID)
+	if err == db.ErrNotFound {
+		return PickOrder{}, ErrNotPicked
+	}
+	return o, err
+}
+
+func (s basicService) Scan(ctx context.Context, orderID, isbn string) (PickOrder, error) {
+	o, err := s.Order(ctx, orderID)
+	if err != nil {
+		return PickOrder{}, err
+	}
+	if o.Status == OrderPacked {
+		return PickOrder{}, ErrPacked
+	}
+	isbn = normalizeISBN(isbn)
+	i := -1
+	for j, it := range o.Items {
+		if normalizeISBN(it.ISBN) == isbn {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return PickOrder{}, ErrNotInOrder
+	}
+	if o.Items[i].Scanned >= o.Items[i].Quantity {
+		return PickOrder{}, ErrAlreadyScanned
+	}
+	o.Items[i].Scanned++
+
+	if o.Packed() {
+		// The shipments go first, a failure leaves the last book to scan
+		// again.
+		if s.fulfillment != nil {
+			if _, err := s.fulfillment.Pack(ctx, orderID); err != nil {
+				return PickOrder{}, err
+			}
+		}
+		now := time.Now().UTC()
+		o.Status, o.PackedAt = OrderPacked, &now
+	}
+	if err := s.r.SaveOrder(&o); err != nil {
+		return PickOrder{}, err
+	}
+	if o.Status == OrderPacked {
+		if err := s.done(o.PickListID); err != nil {
+			return PickOrder{}, err
+		}
+	}
+	return o, nil
+}
+
+// done marks the pick list done if all its orders are packed.
+func (s basicService) done(ID string) error {
+	l, err := s.r.Get(ID)
+	if err != nil {
+		return err
+	}
+	for _, o := range l.Orders {
+		if o.Status != OrderPacked {
+			return nil
+		}
+	}
+	now := time.Now().UTC()
+	l.Status, l.DoneAt = StatusDone, &now
+	return s.r.Done(&l)
+}
+
+// normalizeISBN strips the hyphens and spaces some scanners and labels
+// keep.
+func normalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,200 @@
+package picking
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrListNotFound:   "picking.list_not_found",
+		ErrNothingToPick:  "picking.nothing_to_pick",
+		ErrNotPicked:      "picking.not_picked",
+		ErrNotInOrder:     "picking.not_in_order",
+		ErrAlreadyScanned: "picking.already_scanned",
+		ErrPacked:         "picking.packed",
+		ErrUnknownStatus:  "picking.unknown_status",
+	})
+}
+
+// MakeHTTPHandler mounts the picking endpoints, served to the requests
+// staff lets through, e.g. rbac.Staff with user.RoleAdmin and
+// user.RoleSupport.
+func MakeHTTPHandler(ctx context.Context, s Service, staff endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, staff)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	createListHandler := httptransport.NewServer(
+		e.CreateListEndpoint,
+		decodeCreateListRequest,
+		encodeResponse,
+		options...,
+	)
+	listsHandler := httptransport.NewServer(
+		e.ListsEndpoint,
+		decodeListsRequest,
+		encodeResponse,
+		options...,
+	)
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeIDRequest("list-id"),
+		encodeResponse,
+		options...,
+	)
+	orderHandler := httptransport.NewServer(
+		e.OrderEndpoint,
+		decodeIDRequest("order-id"),
+		encodeResponse,
+		options...,
+	)
+	scanHandler := httptransport.NewServer(
+		e.ScanEndpoint,
+		decodeScanRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Warehouse endpoints
+	r.Handle("/picking/v1/lists", createListHandler).Methods("POST")
+	r.Handle("/picking/v1/lists", listsHandler).Methods("GET")
+	r.Handle("/picking/v1/lists/{list-id}", listHandler).Methods("GET")
+	r.Handle("/picking/v1/orders/{order-id}", orderHandler).Methods("GET")
+	r.Handle("/picking/v1/orders/{order-id}/scan", scanHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// decodeCreateListRequest takes the default batch size without a body.
+func decodeCreateListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createListRequest
+	if req.ContentLength != 0 {
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func decodeListsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return listsRequest{Status: req.URL.Query().Get("status")}, nil
+}
+
+func decodeIDRequest(name string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		id, ok := mux.Vars(req)[name]
+		if !ok {
+			return nil, errors.Wrap(ErrBadRouting, name)
+		}
+		return idRequest{ID: id}, nil
+	}
+}
+
+func decodeScanRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r scanRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrListNotFound, ErrNotPicked, order.ErrOrderNotFound, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrNothingToPick, ErrAlreadyScanned, ErrPacked:
+		return http.StatusConflict
+	case ErrBadRouting, ErrNotInOrder, ErrUnknownStatus, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,25 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunChecker checks the catalog every interval, nightly by default, until
+// ctx is done.
+func RunChecker(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Run(ctx, TriggerNightly); err != nil {
+				logger.Log("checker", "quality", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package quality
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the quality service endpoints under single type.
+type Endpoints struct {
+	RunEndpoint     endpoint.Endpoint
+	RulesEndpoint   endpoint.Endpoint
+	ReportsEndpoint endpoint.Endpoint
+	ReportEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the quality service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		RunEndpoint:     admin(MakeRunEndpoint(s)),
+		RulesEndpoint:   admin(MakeRulesEndpoint(s)),
+		ReportsEndpoint: admin(MakeReportsEndpoint(s)),
+		ReportEndpoint:  admin(MakeReportEndpoint(s)),
+	}
+}
+
+// MakeRunEndpoint checks the catalog on demand.
+func MakeRunEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rep, e := s.Run(ctx, TriggerManual)
+		if e != nil {
+			return reportResponse{Report: nil, Error: e}, nil
+		}
+		return reportResponse{Report: &rep, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeRulesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rules, e := s.Rules(ctx)
+		if e != nil {
+			return rulesResponse{Rules: make([]Rule, 0), Error: e}, nil
+		}
+		return rulesResponse{Rules: rules}, nil
+	}
+}
+
+func MakeReportsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		reports, e := s.Reports(ctx)
+		if e != nil {
+			return reportsResponse{Reports: make([]Report, 0), Error: e}, nil
+		}
+		return reportsResponse{Reports: reports}, nil
+	}
+}
+
+func MakeReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reportRequest)
+		rep, e := s.Report(ctx, req.ID, req.Severity, req.Rule)
+		if e != nil {
+			return reportResponse{Report: nil, Error: e}, nil
+		}
+		return reportResponse{Report: &rep}, nil
+	}
+}
+
+type reportRequest struct {
+	ID       string
+	Severity string
+	Rule     string
+}
+
+type reportResponse struct {
+	Status int     `json:"-"`
+	Report *Report `json:"report,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r reportResponse) status() int {
+	return r.Status
+}
+
+func (r reportResponse) error() error {
+	return r.Error
+}
+
+type rulesResponse struct {
+	Rules []Rule `json:"rules"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r rulesResponse) error() error {
+	return r.Error
+}
+
+type reportsResponse struct {
+	Reports []Report `json:"reports"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r reportsResponse) error() error {
+	return r.Error
+}
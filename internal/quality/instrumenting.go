@@ -0,0 +1,70 @@
+package quality
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Run(ctx context.Context, trigger string) (rep Report, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "run", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rep, err = mw.next.Run(ctx, trigger)
+	return
+}
+
+func (mw instrmw) Rules(ctx context.Context) (rules []Rule, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "rules", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rules, err = mw.next.Rules(ctx)
+	return
+}
+
+func (mw instrmw) Reports(ctx context.Context) (reports []Report, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reports", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	reports, err = mw.next.Reports(ctx)
+	return
+}
+
+func (mw instrmw) Report(ctx context.Context, ID, severity, rule string) (rep Report, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rep, err = mw.next.Report(ctx, ID, severity, rule)
+	return
+}
@@ -0,0 +1,75 @@
+package quality
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Run(ctx context.Context, trigger string) (rep Report, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "run",
+			"trigger", trigger,
+			"report", rep.ID,
+			"books", rep.Books,
+			"errors", rep.Errors,
+			"warnings", rep.Warnings,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Run(ctx, trigger)
+}
+
+func (s loggingService) Rules(ctx context.Context) (rules []Rule, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "rules",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Rules(ctx)
+}
+
+func (s loggingService) Reports(ctx context.Context) (reports []Report, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reports",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Reports(ctx)
+}
+
+func (s loggingService) Report(ctx context.Context, ID, severity, rule string) (rep Report, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "report",
+			"report", ID,
+			"severity", severity,
+			"rule", rule,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Report(ctx, ID, severity, rule)
+}
@@ -0,0 +1,237 @@
+package quality
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+// Severities of an issue, the most severe first.
+const (
+	SeverityError   = "error"   // the book can't be sold as is
+	SeverityWarning = "warning" // the book sells poorly as is
+)
+
+// Codes of the rules.
+const (
+	RuleMissingCover       = "missing_cover"
+	RuleMissingDescription = "missing_description"
+	RuleZeroPrice          = "zero_price"
+	RuleDuplicateISBN      = "duplicate_isbn"
+)
+
+// Triggers of a report.
+const (
+	TriggerNightly = "nightly"
+	TriggerManual  = "manual"
+)
+
+// Report is the outcome of a run of the rules over the catalog.
+type Report struct {
+	ID      string `json:"id"`
+	Trigger string `json:"trigger"`
+	// Books is the number of books checked, the delisted and retired ones
+	// left out.
+	Books    int     `json:"books"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []Issue `json:"issues,omitempty" gorm:"ForeignKey:ReportID"`
+	// Suggestions group the issues of each rule into their bulk fix.
+	Suggestions []Suggestion `json:"suggestions,omitempty" sql:"-"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+func (Report) TableName() string {
+	return "quality_reports"
+}
+
+// Issue is a book a rule flags.
+type Issue struct {
+	ReportID string `json:"-" gorm:"primary_key"`
+	BookID   string `json:"book_id" gorm:"primary_key"`
+	Rule     string `json:"rule" gorm:"primary_key"`
+	Severity string `json:"severity"`
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	// Patch is the JSON merge patch of the book fixing the issue, for
+	// PATCH /catalog/v1/admin/books/{id}, empty if it takes an editor.
+	Patch string `json:"patch,omitempty" gorm:"type:text"`
+}
+
+func (Issue) TableName() string {
+	return "quality_issues"
+}
+
+// Suggestion is the bulk fix of the issues of a rule.
+type Suggestion struct {
+	Rule     string   `json:"rule"`
+	Severity string   `json:"severity"`
+	Fix      string   `json:"fix"`
+	BookIDs  []string `json:"book_ids"`
+	// Patchable is the number of books of BookIDs with a patch to apply.
+	Patchable int `json:"patchable"`
+}
+
+// Rule flags the books of an issue. Check sees every book checked at once,
+// for the rules across books such as the duplicate ISBNs.
+type Rule struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	// Fix tells how to fix the issues of the rule in bulk.
+	Fix   string                             `json:"fix"`
+	Check func(books []catalog.Book) []Issue `json:"-"`
+}
+
+// Config controls the rules.
+type Config struct {
+	// CoverURL is the URL of the cover of a book by its ISBN, e.g.
+	// "https://covers.example.com/{isbn}.jpg", suggested for the books
+	// missing one. No cover is suggested if empty.
+	CoverURL string
+}
+
+// Rules returns the rules the catalog is checked against.
+func Rules(c Config) []Rule {
+	return []Rule{
+		{
+			Code:     RuleZeroPrice,
+			Severity: SeverityError,
+			Fix:      "set the price of the books, or delist them",
+			Check: each(func(b catalog.Book) (string, string, bool) {
+				return "the book has no price", "", b.Price <= 0
+			}),
+		},
+		{
+			Code:     RuleDuplicateISBN,
+			Severity: SeverityError,
+			Fix:      "delist the duplicates, keeping the book with the most stock",
+			Check:    duplicates,
+		},
+		{
+			Code:     RuleMissingCover,
+			Severity: SeverityWarning,
+			Fix:      "upload the covers, or apply the suggested cover URLs",
+			Check: each(func(b catalog.Book) (string, string, bool) {
+				p := ""
+				if c.CoverURL != "" && b.ISBN != "" {
+					p = patch(map[string]interface{}{"cover_url": strings.Replace(c.CoverURL, "{isbn}", normalizeISBN(b.ISBN), -1)})
+				}
+				return "the book has no cover", p, strings.TrimSpace(b.CoverURL) == ""
+			}),
+		},
+		{
+			Code:     RuleMissingDescription,
+			Severity: SeverityWarning,
+			Fix:      "write the descriptions of the books",
+			Check: each(func(b catalog.Book) (string, string, bool) {
+				return "the book has no description", "", strings.TrimSpace(b.Description) == ""
+			}),
+		},
+	}
+}
+
+// each returns a Check flagging each book of which flag tells so, along
+// with its message and patch.
+func each(flag func(b catalog.Book) (message, patch string, flagged bool)) func([]catalog.Book) []Issue {
+	return func(books []catalog.Book) []Issue {
+		var issues []Issue
+		for _, b := range books {
+			if msg, p, ok := flag(b); ok {
+				issues = append(issues, Issue{BookID: b.ID, ISBN: b.ISBN, Title: b.Title, Message: msg, Patch: p})
+			}
+		}
+		return issues
+	}
+}
+
+// duplicates flags the books sharing their ISBN. The one with the most
+// stock, then the lowest ID, is kept, the others are to delist.
+func duplicates(books []catalog.Book) []Issue {
+	byISBN := make(map[string][]catalog.Book)
+	var isbns []string
+	for _, b := range books {
+		isbn := normalizeISBN(b.ISBN)
+		if isbn == "" {
+			continue
+		}
+		if _, ok := byISBN[isbn]; !ok {
+			isbns = append(isbns, isbn)
+		}
+		byISBN[isbn] = append(byISBN[isbn], b)
+	}
+	var issues []Issue
+	for _, isbn := range isbns {
+		list := byISBN[isbn]
+		if len(list) < 2 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Stock != list[j].Stock {
+				return list[i].Stock > list[j].Stock
+			}
+			return list[i].ID < list[j].ID
+		})
+		for _, b := range list[1:] {
+			issues = append(issues, Issue{
+				BookID:  b.ID,
+				ISBN:    b.ISBN,
+				Title:   b.Title,
+				Message: "the ISBN is the one of book " + list[0].ID,
+				Patch:   patch(map[string]interface{}{"delisted": true}),
+			})
+		}
+	}
+	return issues
+}
+
+// Check runs the rules over the books, leaving out the delisted and
+// retired ones, the issues in the order of the rules.
+func Check(rules []Rule, books []catalog.Book) (checked int, issues []Issue) {
+	var listed []catalog.Book
+	for _, b := range books {
+		if !b.Delisted && b.Visibility != catalog.VisibilityRetired {
+			listed = append(listed, b)
+		}
+	}
+	for _, r := range rules {
+		for _, is := range r.Check(listed) {
+			is.Rule, is.Severity = r.Code, r.Severity
+			issues = append(issues, is)
+		}
+	}
+	return len(listed), issues
+}
+
+// Suggest groups the issues of the report by rule, in the order of rules.
+func (r *Report) Suggest(rules []Rule) {
+	r.Suggestions = nil
+	for _, rule := range rules {
+		s := Suggestion{Rule: rule.Code, Severity: rule.Severity, Fix: rule.Fix}
+		for _, is := range r.Issues {
+			if is.Rule != rule.Code {
+				continue
+			}
+			s.BookIDs = append(s.BookIDs, is.BookID)
+			if is.Patch != "" {
+				s.Patchable++
+			}
+		}
+		if len(s.BookIDs) > 0 {
+			r.Suggestions = append(r.Suggestions, s)
+		}
+	}
+}
+
+func patch(fields map[string]interface{}) string {
+	p, _ := json.Marshal(fields)
+	return string(p)
+}
+
+// normalizeISBN strips the hyphens and spaces some ISBNs are entered with.
+func normalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+}
@@ -0,0 +1,126 @@
+package quality_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/quality"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the reports in memory.
+type repo struct {
+	quality.Repo
+	reports []quality.Report
+}
+
+func (r *repo) Create(rep *quality.Report) error {
+	rep.ID = "r1"
+	r.reports = append(r.reports, *rep)
+	return nil
+}
+
+func (r *repo) Latest() (quality.Report, error) {
+	if len(r.reports) == 0 {
+		return quality.Report{}, db.ErrNotFound
+	}
+	rep := r.reports[len(r.reports)-1]
+	rep.Issues = append([]quality.Issue(nil), rep.Issues...)
+	return rep, nil
+}
+
+type catalogRepo struct {
+	catalog.Repo
+	books []catalog.Book
+}
+
+func (r catalogRepo) ListAll() ([]catalog.Book, error) {
+	return r.books, nil
+}
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	books := catalogRepo{books: []catalog.Book{
+		{ID: "b1", ISBN: "978-0-00-000000-1", Title: "Complete", Description: "A book", CoverURL: "https://c/1.jpg", Price: 10, Stock: 1},
+		{ID: "b2", ISBN: "9780000000001", Title: "Reprint", Description: "A book", CoverURL: "https://c/2.jpg", Price: 10, Stock: 4},
+		{ID: "b3", ISBN: "9780000000003", Title: "Bare", Description: " "},
+		{ID: "b4", ISBN: "9780000000004", Title: "Gone", Delisted: true},
+		{ID: "b5", ISBN: "9780000000005", Title: "Retired", Visibility: catalog.VisibilityRetired},
+	}}
+	r := &repo{}
+	s := quality.NewService(r, books, quality.Config{CoverURL: "https://covers.example.com/{isbn}.jpg"})
+
+	if _, err := s.Report(ctx, quality.Latest, "", ""); err != quality.ErrReportNotFound {
+		t.Errorf("no report: expected ErrReportNotFound, got %v", err)
+	}
+	rep, err := s.Run(ctx, quality.TriggerManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Books != 3 || rep.Errors != 2 || rep.Warnings != 2 {
+		t.Fatalf("expected 3 books checked with 2 errors and 2 warnings, got %+v", rep)
+	}
+	for _, is := range rep.Issues {
+		switch is.Rule {
+		case quality.RuleDuplicateISBN:
+			if is.BookID != "b1" || is.Patch != `{"delisted":true}` {
+				t.Errorf("expected the duplicate with the least stock to delist, got %+v", is)
+			}
+		case quality.RuleMissingCover:
+			if is.Patch != `{"cover_url":"https://covers.example.com/9780000000003.jpg"}` {
+				t.Errorf("expected the cover suggested by ISBN, got %q", is.Patch)
+			}
+		}
+	}
+
+	errs, err := s.Report(ctx, quality.Latest, quality.SeverityError, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs.Issues) != 2 || errs.Issues[0].Rule != quality.RuleZeroPrice || errs.Issues[1].Rule != quality.RuleDuplicateISBN {
+		t.Errorf("expected the errors in the order of the rules, got %+v", errs.Issues)
+	}
+	if len(errs.Suggestions) != 2 || errs.Suggestions[1].Patchable != 1 || errs.Suggestions[0].BookIDs[0] != "b3" {
+		t.Errorf("expected a suggestion per rule, got %+v", errs.Suggestions)
+	}
+	if _, err := s.Report(ctx, quality.Latest, "", "typo"); err != quality.ErrUnknownRule {
+		t.Errorf("expected ErrUnknownRule, got %v", err)
+	}
+}
+
+func TestRulesRequireAdmin(t *testing.T) {
+	s := quality.NewService(&repo{}, catalogRepo{}, quality.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := quality.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/quality/v1/rules", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package quality
+
+// Repo abstracts all the persistant storage operations of Quality Service
+type Repo interface {
+	// Create stores the report with its issues in one go.
+	Create(r *Report) error
+	// Get returns the report with its issues.
+	Get(ID string) (Report, error)
+	// Latest returns the latest report with its issues, db.ErrNotFound if
+	// none.
+	Latest() (Report, error)
+	// List returns the reports, latest first, without their issues.
+	List() ([]Report, error)
+	Drop() error
+}
@@ -0,0 +1,137 @@
+package quality
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrReportNotFound  = errors.New("quality report not found")
+	ErrUnknownSeverity = errors.New("severity must be error or warning")
+	ErrUnknownRule     = errors.New("rule is unknown")
+)
+
+// Latest is the ID of the latest report.
+const Latest = "latest"
+
+type Service interface {
+	// Run checks the catalog against the rules and stores the report.
+	Run(ctx context.Context, trigger string) (Report, error)
+
+	// Rules returns the rules the catalog is checked against.
+	Rules(ctx context.Context) ([]Rule, error)
+
+	// Reports returns the reports, latest first, without their issues.
+	Reports(ctx context.Context) ([]Report, error)
+
+	// Report returns a report, or the latest one, with its issues of
+	// severity and rule, all of them if empty, and its suggestions.
+	Report(ctx context.Context, ID, severity, rule string) (Report, error)
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+	rules   []Rule
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo, c Config) Service {
+	return basicService{r: r, catalog: catalog, rules: Rules(c)}
+}
+
+func (s basicService) Run(ctx context.Context, trigger string) (Report, error) {
+	books, err := s.catalog.ListAll()
+	if err != nil {
+		return Report{}, err
+	}
+	rep := Report{Trigger: trigger, CreatedAt: time.Now().UTC()}
+	rep.Books, rep.Issues = Check(s.rules, books)
+	for _, is := range rep.Issues {
+		switch is.Severity {
+		case SeverityError:
+			rep.Errors++
+		case SeverityWarning:
+			rep.Warnings++
+		}
+	}
+	if err := s.r.Create(&rep); err != nil {
+		return Report{}, err
+	}
+	rep.Suggest(s.rules)
+	return rep, nil
+}
+
+func (s basicService) Rules(ctx context.Context) ([]Rule, error) {
+	return s.rules, nil
+}
+
+func (s basicService) Reports(ctx context.Context) ([]Report, error) {
+	return s.r.List()
+}
+
+func (s basicService) Report(ctx context.Context, ID, severity, rule string) (Report, error) {
+	switch severity {
+	case "", SeverityError, SeverityWarning:
+	default:
+		return Report{}, ErrUnknownSeverity
+	}
+	if rule != "" && !s.known(rule) {
+		return Report{}, ErrUnknownRule
+	}
+	var rep Report
+	var err error
+	if ID == Latest {
+		rep, err = s.r.Latest()
+	} else {
+		rep, err = s.r.Get(ID)
+	}
+	if err == db.ErrNotFound {
+		return Report{}, ErrReportNotFound
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	issues := rep.Issues[:0]
+	for _, is := range rep.Issues {
+		if (severity == "" || is.Severity == severity) && (rule == "" || is.Rule == rule) {
+			issues = append(issues, is)
+		}
+	}
+	rep.Issues = issues
+	s.sort(rep.Issues)
+	rep.Suggest(s.rules)
+	return rep, nil
+}
+
+func (s basicService) known(rule string) bool {
+	for _, r := range s.rules {
+		if r.Code == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// sort orders the issues the way the rules are, then by title.
+func (s basicService) sort(issues []Issue) {
+	rank := make(map[string]int)
+	for i, r := range s.rules {
+		rank[r.Code] = i
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Rule != b.Rule {
+			return rank[a.Rule] < rank[b.Rule]
+		}
+		return a.Title < b.Title
+	})
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
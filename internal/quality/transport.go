@@ -0,0 +1,160 @@
+package quality
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrReportNotFound:  "quality.report_not_found",
+		ErrUnknownSeverity: "quality.unknown_severity",
+		ErrUnknownRule:     "quality.unknown_rule",
+	})
+}
+
+// MakeHTTPHandler mounts the quality endpoints, served to the requests admin
+// lets through, e.g. auth.NewMiddleware chained with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	runHandler := httptransport.NewServer(
+		e.RunEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	rulesHandler := httptransport.NewServer(
+		e.RulesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	reportsHandler := httptransport.NewServer(
+		e.ReportsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	reportHandler := httptransport.NewServer(
+		e.ReportEndpoint,
+		decodeReportRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Admin endpoints
+	r.Handle("/quality/v1/rules", rulesHandler).Methods("GET")
+	r.Handle("/quality/v1/reports", runHandler).Methods("POST")
+	r.Handle("/quality/v1/reports", reportsHandler).Methods("GET")
+	r.Handle("/quality/v1/reports/{report-id}", reportHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeReportRequest takes "latest" for the latest report, e.g.
+// /quality/v1/reports/latest?severity=error&rule=duplicate_isbn
+func decodeReportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["report-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "report-id")
+	}
+	q := req.URL.Query()
+	return reportRequest{ID: id, Severity: q.Get("severity"), Rule: q.Get("rule")}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrReportNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrUnknownSeverity, ErrUnknownRule, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
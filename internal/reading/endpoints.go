@@ -0,0 +1,110 @@
+package reading
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the reading service endpoints under single type.
+type Endpoints struct {
+	ProgressEndpoint        endpoint.Endpoint
+	SaveProgressEndpoint    endpoint.Endpoint
+	SyncAnnotationsEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the reading service endpoints, restricted by account, e.g. to the
+// unscoped tokens of the users.
+func MakeEndpoints(s Service, account endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ProgressEndpoint:        account(MakeProgressEndpoint(s)),
+		SaveProgressEndpoint:    account(MakeSaveProgressEndpoint(s)),
+		SyncAnnotationsEndpoint: account(MakeSyncAnnotationsEndpoint(s)),
+	}
+}
+
+// MakeProgressEndpoint returns a progress of the user of the request.
+func MakeProgressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(progressRequest)
+		p, e := s.Progress(ctx, userID, req.BookID)
+		if e != nil {
+			return progressResponse{Progress: nil, Error: e}, nil
+		}
+		return progressResponse{Progress: &p}, nil
+	}
+}
+
+// MakeSaveProgressEndpoint stores a progress of the user of the request.
+func MakeSaveProgressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(saveProgressRequest)
+		req.UserID = userID
+		p, e := s.SaveProgress(ctx, req.Progress)
+		if e != nil {
+			return progressResponse{Progress: nil, Error: e}, nil
+		}
+		return progressResponse{Progress: &p}, nil
+	}
+}
+
+// MakeSyncAnnotationsEndpoint syncs the annotations of the user of the
+// request.
+func MakeSyncAnnotationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		req := request.(syncAnnotationsRequest)
+		annotations, syncedAt, e := s.SyncAnnotations(ctx, userID, req.BookID, req.Since, req.Annotations)
+		if e != nil {
+			return syncAnnotationsResponse{Annotations: make([]Annotation, 0), Error: e}, nil
+		}
+		return syncAnnotationsResponse{Annotations: annotations, SyncedAt: syncedAt}, nil
+	}
+}
+
+type progressRequest struct {
+	BookID string `json:"book_id"`
+}
+
+type saveProgressRequest struct {
+	Progress
+}
+
+type progressResponse struct {
+	Progress *Progress `json:"progress,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r progressResponse) error() error {
+	return r.Error
+}
+
+type syncAnnotationsRequest struct {
+	BookID      string       `json:"-"`
+	Since       time.Time    `json:"since"`
+	Annotations []Annotation `json:"annotations"`
+}
+
+type syncAnnotationsResponse struct {
+	Annotations []Annotation `json:"annotations"`
+	SyncedAt    time.Time    `json:"synced_at"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r syncAnnotationsResponse) error() error {
+	return r.Error
+}
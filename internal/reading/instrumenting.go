@@ -0,0 +1,59 @@
+package reading
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Progress(ctx context.Context, userID, bookID string) (p Progress, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "progress", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	p, err = mw.next.Progress(ctx, userID, bookID)
+	return
+}
+
+func (mw instrmw) SaveProgress(ctx context.Context, p Progress) (saved Progress, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "save_progress", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	saved, err = mw.next.SaveProgress(ctx, p)
+	return
+}
+
+func (mw instrmw) SyncAnnotations(ctx context.Context, userID, bookID string, since time.Time, changes []Annotation) (annotations []Annotation, syncedAt time.Time, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sync_annotations", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	annotations, syncedAt, err = mw.next.SyncAnnotations(ctx, userID, bookID, since, changes)
+	return
+}
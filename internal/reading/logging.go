@@ -0,0 +1,57 @@
+package reading
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Progress(ctx context.Context, userID, bookID string) (p Progress, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "progress",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Progress(ctx, userID, bookID)
+}
+
+func (s loggingService) SaveProgress(ctx context.Context, p Progress) (saved Progress, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "save_progress",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SaveProgress(ctx, p)
+}
+
+func (s loggingService) SyncAnnotations(ctx context.Context, userID, bookID string, since time.Time, changes []Annotation) (annotations []Annotation, syncedAt time.Time, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sync_annotations",
+			"changes", len(changes),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SyncAnnotations(ctx, userID, bookID, since, changes)
+}
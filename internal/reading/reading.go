@@ -0,0 +1,41 @@
+package reading
+
+import "time"
+
+// Progress is the reading position of an user in a book. There is only one
+// progress per user and book, devices sync by the latest UpdatedAt.
+type Progress struct {
+	ID        string    `json:"-"`
+	UserID    string    `json:"user_id"`
+	BookID    string    `json:"book_id"`
+	DeviceID  string    `json:"device_id"`
+	Locator   string    `json:"locator"` // e.g: EPUB CFI or page number
+	Percent   float64   `json:"percent"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	KindHighlight = "highlight"
+	KindNote      = "note"
+	KindBookmark  = "bookmark"
+)
+
+// Annotation is a highlight, note or bookmark made by an user in a book.
+// IDs are generated by the clients so that annotations created offline
+// can be synced later. Deleted annotations are kept as tombstones so
+// that the deletion reaches the other devices. UpdatedAt comes from the
+// client and resolves the conflicts, ChangedAt is set by the server on
+// every save and is the cursor of the syncs, an edit made offline reaching
+// the other devices however late it is synced.
+type Annotation struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	BookID    string    `json:"-"`
+	Kind      string    `json:"kind"`
+	Locator   string    `json:"locator"`
+	Text      string    `json:"text,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Deleted   bool      `json:"deleted"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ChangedAt time.Time `json:"-" sql:"index"`
+}
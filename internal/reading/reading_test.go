@@ -0,0 +1,169 @@
+package reading_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/reading"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the progresses and the annotations in memory.
+type repo struct {
+	reading.Repo
+	progresses  []reading.Progress
+	annotations []reading.Annotation
+}
+
+func (r *repo) GetProgress(userID, bookID string) (reading.Progress, error) {
+	for _, p := range r.progresses {
+		if p.UserID == userID && p.BookID == bookID {
+			return p, nil
+		}
+	}
+	return reading.Progress{}, db.ErrNotFound
+}
+
+func (r *repo) SaveProgress(p *reading.Progress) error {
+	for i := range r.progresses {
+		if r.progresses[i].ID == p.ID {
+			r.progresses[i] = *p
+			return nil
+		}
+	}
+	p.ID = "p" + string(rune('1'+len(r.progresses)))
+	r.progresses = append(r.progresses, *p)
+	return nil
+}
+
+func (r *repo) GetAnnotation(ID string) (reading.Annotation, error) {
+	for _, a := range r.annotations {
+		if a.ID == ID {
+			return a, nil
+		}
+	}
+	return reading.Annotation{}, db.ErrNotFound
+}
+
+func (r *repo) SaveAnnotation(a *reading.Annotation) error {
+	for i := range r.annotations {
+		if r.annotations[i].ID == a.ID {
+			r.annotations[i] = *a
+			return nil
+		}
+	}
+	r.annotations = append(r.annotations, *a)
+	return nil
+}
+
+func (r *repo) ListAnnotations(userID, bookID string, since time.Time) ([]reading.Annotation, error) {
+	annotations := make([]reading.Annotation, 0)
+	for _, a := range r.annotations {
+		if a.UserID == userID && a.BookID == bookID && a.ChangedAt.After(since) {
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations, nil
+}
+
+func TestSaveProgress(t *testing.T) {
+	ctx := context.Background()
+	s := reading.NewService(&repo{})
+
+	ahead := time.Now().Add(time.Hour)
+	p, err := s.SaveProgress(ctx, reading.Progress{UserID: "u1", BookID: "b1", Locator: "p10", UpdatedAt: ahead})
+	if err != nil || !p.UpdatedAt.Before(ahead) {
+		t.Fatalf("ahead: expected the progress taken as of now, got %+v, %v", p, err)
+	}
+	if p, err = s.SaveProgress(ctx, reading.Progress{UserID: "u1", BookID: "b1", Locator: "p12"}); err != nil || p.Locator != "p12" {
+		t.Errorf("later: expected the later progress to win over the clock ahead, got %+v, %v", p, err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if p, err = s.SaveProgress(ctx, reading.Progress{UserID: "u1", BookID: "b1", Locator: "p3", UpdatedAt: old}); err != nil || p.Locator != "p12" {
+		t.Errorf("older: expected the stored progress kept, got %+v, %v", p, err)
+	}
+}
+
+func TestSyncAnnotations(t *testing.T) {
+	ctx := context.Background()
+	s := reading.NewService(&repo{})
+	note := reading.Annotation{ID: "a1", Kind: reading.KindNote, Locator: "c1", Note: "first"}
+
+	// The phone syncs a note, the tablet then syncs from scratch.
+	_, phone, err := s.SyncAnnotations(ctx, "u1", "b1", time.Time{}, []reading.Annotation{note})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations, tablet, err := s.SyncAnnotations(ctx, "u1", "b1", time.Time{}, nil)
+	if err != nil || len(annotations) != 1 || annotations[0].Note != "first" {
+		t.Fatalf("tablet: expected the note of the phone, got %+v, %v", annotations, err)
+	}
+
+	// The phone edited the note offline before the tablet synced, the edit
+	// reaches the server after the tablet's sync.
+	edit := note
+	edit.Note, edit.UpdatedAt = "edited offline", phone
+	if _, _, err := s.SyncAnnotations(ctx, "u1", "b1", phone, []reading.Annotation{edit}); err != nil {
+		t.Fatal(err)
+	}
+	annotations, _, err = s.SyncAnnotations(ctx, "u1", "b1", tablet, nil)
+	if err != nil || len(annotations) != 1 || annotations[0].Note != "edited offline" {
+		t.Errorf("tablet: expected the offline edit synced late, got %+v, %v", annotations, err)
+	}
+
+	stale := note
+	stale.Note, stale.UpdatedAt = "stale", phone.Add(-time.Hour)
+	if annotations, _, err = s.SyncAnnotations(ctx, "u1", "b1", time.Time{}, []reading.Annotation{stale}); err != nil || annotations[0].Note != "edited offline" {
+		t.Errorf("stale: expected the latest edit kept, got %+v, %v", annotations, err)
+	}
+	if annotations, _, err = s.SyncAnnotations(ctx, "u2", "b1", time.Time{}, []reading.Annotation{stale}); err != nil || len(annotations) != 0 {
+		t.Errorf("another user: expected the annotation of u1 left alone, got %+v, %v", annotations, err)
+	}
+	if _, _, err := s.SyncAnnotations(ctx, "u1", "b1", time.Time{}, []reading.Annotation{{ID: "a2", Kind: "doodle"}}); err != reading.ErrInvalidKind {
+		t.Errorf("kind: expected ErrInvalidKind, got %v", err)
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	r := &repo{}
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	h := reading.MakeHTTPHandler(context.Background(), reading.NewService(r), account, log.NewNopLogger())
+	u1, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	progress := `{"device_id":"d1","locator":"p10","user_id":"u2"}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"progress without token", "GET", "/reading/v1/b1/progress", "", "", http.StatusUnauthorized},
+		{"save progress without token", "PUT", "/reading/v1/b1/progress", progress, "", http.StatusUnauthorized},
+		{"sync without token", "POST", "/reading/v1/b1/annotations/sync", `{}`, "", http.StatusUnauthorized},
+		{"save progress", "PUT", "/reading/v1/b1/progress", progress, u1, http.StatusOK},
+		{"progress", "GET", "/reading/v1/b1/progress", "", u1, http.StatusOK},
+		{"sync", "POST", "/reading/v1/b1/annotations/sync", `{}`, u1, http.StatusOK},
+		{"progress at the user route", "GET", "/reading/v1/u1/b1/progress", "", u1, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if len(r.progresses) != 1 || r.progresses[0].UserID != "u1" {
+		t.Errorf("expected the progress saved for the user of the token, got %+v", r.progresses)
+	}
+}
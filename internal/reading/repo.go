@@ -0,0 +1,15 @@
+package reading
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of Reading Service
+type Repo interface {
+	GetProgress(userID, bookID string) (Progress, error)
+	SaveProgress(p *Progress) error
+	GetAnnotation(ID string) (Annotation, error)
+	SaveAnnotation(a *Annotation) error
+	// ListAnnotations returns the annotations of the user in the book
+	// changed after since, by their ChangedAt.
+	ListAnnotations(userID, bookID string, since time.Time) ([]Annotation, error)
+	Drop() error
+}
@@ -0,0 +1,113 @@
+package reading
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrProgressNotFound = errors.New("reading progress not found")
+	ErrInvalidKind      = errors.New("invalid annotation kind")
+	ErrMissingID        = errors.New("missing annotation id")
+)
+
+type Service interface {
+	// Progress returns the latest reading position of the user in the book.
+	Progress(ctx context.Context, userID, bookID string) (Progress, error)
+
+	// SaveProgress stores the reading position if it is newer than the
+	// stored one, a position from the future being taken as of now.
+	// Returned Progress is always the winning one.
+	SaveProgress(ctx context.Context, p Progress) (Progress, error)
+
+	// SyncAnnotations applies the client changes and returns all the
+	// annotations changed on the server after since, along with the server
+	// time the client should use as since on the next sync.
+	SyncAnnotations(ctx context.Context, userID, bookID string, since time.Time, changes []Annotation) ([]Annotation, time.Time, error)
+}
+
+type basicService struct {
+	r Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo) Service {
+	return basicService{r}
+}
+
+// Progress returns the latest reading position of the user in the book.
+func (s basicService) Progress(ctx context.Context, userID, bookID string) (Progress, error) {
+	p, err := s.r.GetProgress(userID, bookID)
+	if err == db.ErrNotFound {
+		return Progress{}, ErrProgressNotFound
+	}
+	return p, err
+}
+
+// SaveProgress resolves conflicts by timestamp, the latest update wins.
+func (s basicService) SaveProgress(ctx context.Context, p Progress) (Progress, error) {
+	// A device clock ahead would win over every later update.
+	if now := time.Now().UTC(); p.UpdatedAt.IsZero() || p.UpdatedAt.After(now) {
+		p.UpdatedAt = now
+	}
+	cur, err := s.r.GetProgress(p.UserID, p.BookID)
+	switch err {
+	case nil:
+		if cur.UpdatedAt.After(p.UpdatedAt) {
+			return cur, nil
+		}
+		p.ID = cur.ID
+	case db.ErrNotFound:
+	default:
+		return Progress{}, err
+	}
+	if err := s.r.SaveProgress(&p); err != nil {
+		return Progress{}, err
+	}
+	return p, nil
+}
+
+// SyncAnnotations resolves conflicts per annotation by timestamp, the
+// latest update wins. The changes are listed by the time they reach the
+// server, their UpdatedAt being the time of the edit on the device.
+func (s basicService) SyncAnnotations(ctx context.Context, userID, bookID string, since time.Time, changes []Annotation) ([]Annotation, time.Time, error) {
+	now := time.Now().UTC()
+	for _, a := range changes {
+		if a.ID == "" {
+			return nil, time.Time{}, ErrMissingID
+		}
+		switch a.Kind {
+		case KindHighlight, KindNote, KindBookmark:
+		default:
+			return nil, time.Time{}, ErrInvalidKind
+		}
+		a.UserID, a.BookID, a.ChangedAt = userID, bookID, now
+		if a.UpdatedAt.IsZero() || a.UpdatedAt.After(now) {
+			a.UpdatedAt = now
+		}
+		cur, err := s.r.GetAnnotation(a.ID)
+		switch err {
+		case nil:
+			if cur.UserID != userID || cur.UpdatedAt.After(a.UpdatedAt) {
+				continue
+			}
+		case db.ErrNotFound:
+		default:
+			return nil, time.Time{}, err
+		}
+		if err := s.r.SaveAnnotation(&a); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	annotations, err := s.r.ListAnnotations(userID, bookID, since)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return annotations, now, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
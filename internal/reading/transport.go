@@ -0,0 +1,172 @@
+package reading
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrInvalidKind:      "reading.invalid_kind",
+		ErrMissingID:        "reading.missing_id",
+		ErrProgressNotFound: "reading.progress_not_found",
+	})
+}
+
+// MakeHTTPHandler mounts the reading endpoints, served to the requests
+// account lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireUnscoped.
+func MakeHTTPHandler(ctx context.Context, s Service, account endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	progressHandler := httptransport.NewServer(
+		e.ProgressEndpoint,
+		decodeProgressRequest,
+		encodeResponse,
+		options...,
+	)
+	saveProgressHandler := httptransport.NewServer(
+		e.SaveProgressEndpoint,
+		decodeSaveProgressRequest,
+		encodeResponse,
+		options...,
+	)
+	syncAnnotationsHandler := httptransport.NewServer(
+		e.SyncAnnotationsEndpoint,
+		decodeSyncAnnotationsRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/reading/v1/{book-id}/progress", progressHandler).Methods("GET")
+	r.Handle("/reading/v1/{book-id}/progress", saveProgressHandler).Methods("PUT")
+	r.Handle("/reading/v1/{book-id}/annotations/sync", syncAnnotationsHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeProgressRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	bookID, err := bookVar(req)
+	if err != nil {
+		return nil, err
+	}
+	return progressRequest{
+		BookID: bookID,
+	}, nil
+}
+
+func decodeSaveProgressRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r saveProgressRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, err := bookVar(req)
+	if err != nil {
+		return nil, err
+	}
+	r.BookID = bookID
+	return r, nil
+}
+
+func decodeSyncAnnotationsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r syncAnnotationsRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, err := bookVar(req)
+	if err != nil {
+		return nil, err
+	}
+	r.BookID = bookID
+	return r, nil
+}
+
+func bookVar(req *http.Request) (string, error) {
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return "", errors.Wrap(ErrBadRouting, "book-id")
+	}
+	return bookID, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrProgressNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrInvalidKind, ErrMissingID, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,115 @@
+package rectification
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Statuses of a request. A pending request is a review task for the staff.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Actions recorded in the audit trail of a request.
+const (
+	ActionSubmit  = "submit"
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+)
+
+// fields are the personal data which are only corrected once verified by
+// the staff, with how to read and set them on a user.
+var fields = map[string]struct {
+	get func(u user.User) string
+	set func(u *user.User, v string)
+}{
+	"first_name": {
+		func(u user.User) string { return u.FirstName },
+		func(u *user.User, v string) { u.FirstName = v },
+	},
+	"last_name": {
+		func(u user.User) string { return u.LastName },
+		func(u *user.User, v string) { u.LastName = v },
+	},
+	"email": {
+		func(u user.User) string { return u.Email },
+		func(u *user.User, v string) { u.Email, u.EmailVerifiedAt = v, nil },
+	},
+}
+
+// Request is the request of a user to correct a field of their personal
+// data, applied once approved by the staff.
+type Request struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Field  string `json:"field"`
+	// Current is the value stored when the correction was requested.
+	Current string `json:"current"`
+	Value   string `json:"value"`
+	Reason  string `json:"reason" sql:"type:text"`
+	// Evidence references the supporting document, e.g. the id of a
+	// scanned passport.
+	Evidence   string     `json:"evidence,omitempty"`
+	Status     string     `json:"status"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	Note       string     `json:"note,omitempty" sql:"type:text"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+func (Request) TableName() string {
+	return "rectification_requests"
+}
+
+// NewRequest is a correction a user submits.
+type NewRequest struct {
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	Reason   string `json:"reason"`
+	Evidence string `json:"evidence"`
+}
+
+// Validate checks the field can be rectified and the value is set,
+// reporting all the failing fields.
+func (n *NewRequest) Validate() error {
+	var v validate.Validator
+	if v.Required("field", n.Field) {
+		_, ok := fields[n.Field]
+		v.Check(ok, "field", validate.CodeInvalid, "field can't be rectified")
+	}
+	if v.Required("value", n.Value) && n.Field == "email" {
+		v.Email("value", n.Value)
+	}
+	v.MaxLength("value", n.Value, 255)
+	v.Required("reason", n.Reason)
+	v.MaxLength("reason", n.Reason, 2000)
+	return v.Err()
+}
+
+// Event is an entry of the audit trail of a request, recording who did
+// what to it and when.
+type Event struct {
+	ID        string    `json:"id"`
+	RequestID string    `json:"request_id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty" sql:"type:text"`
+	At        time.Time `json:"at"`
+}
+
+func (Event) TableName() string {
+	return "rectification_events"
+}
+
+// clean trims the submitted values.
+func (n *NewRequest) clean() {
+	n.Field = strings.TrimSpace(n.Field)
+	n.Value = strings.TrimSpace(n.Value)
+	n.Reason = strings.TrimSpace(n.Reason)
+	n.Evidence = strings.TrimSpace(n.Evidence)
+}
@@ -0,0 +1,165 @@
+package rectification_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/rectification"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		n      rectification.NewRequest
+		failed []string
+	}{
+		{rectification.NewRequest{Field: "last_name", Value: "Okafor", Reason: "married"}, nil},
+		{rectification.NewRequest{Field: "email", Value: "new@example.com", Reason: "typo"}, nil},
+		{rectification.NewRequest{Field: "email", Value: "not an email", Reason: "typo"}, []string{"value"}},
+		{rectification.NewRequest{Field: "role", Value: "admin", Reason: "please"}, []string{"field"}},
+		{rectification.NewRequest{}, []string{"field", "value", "reason"}},
+	}
+	for _, c := range cases {
+		var failed []string
+		for _, e := range validate.Fields(c.n.Validate()) {
+			failed = append(failed, e.Field)
+		}
+		if len(failed) != len(c.failed) {
+			t.Errorf("%+v: expected %v to fail, got %v", c.n, c.failed, failed)
+			continue
+		}
+		for i := range failed {
+			if failed[i] != c.failed[i] {
+				t.Errorf("%+v: expected %v to fail, got %v", c.n, c.failed, failed)
+			}
+		}
+	}
+}
+
+type rectificationRepo struct {
+	requests []rectification.Request
+	events   []rectification.Event
+}
+
+func (r *rectificationRepo) Create(req *rectification.Request) error {
+	req.ID = "r" + string(rune('1'+len(r.requests)))
+	r.requests = append(r.requests, *req)
+	return nil
+}
+
+func (r *rectificationRepo) Save(req *rectification.Request) error {
+	for i := range r.requests {
+		if r.requests[i].ID == req.ID {
+			r.requests[i] = *req
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *rectificationRepo) GetByID(ID string) (rectification.Request, error) {
+	for _, req := range r.requests {
+		if req.ID == ID {
+			return req, nil
+		}
+	}
+	return rectification.Request{}, db.ErrNotFound
+}
+
+func (r *rectificationRepo) ListByUser(userID string) ([]rectification.Request, error) {
+	var requests []rectification.Request
+	for _, req := range r.requests {
+		if req.UserID == userID {
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
+
+func (r *rectificationRepo) ListByStatus(status string) ([]rectification.Request, error) {
+	var requests []rectification.Request
+	for _, req := range r.requests {
+		if req.Status == status {
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
+
+func (r *rectificationRepo) AddEvent(e *rectification.Event) error {
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func (r *rectificationRepo) ListEvents(requestID string) ([]rectification.Event, error) {
+	var events []rectification.Event
+	for _, e := range r.events {
+		if e.RequestID == requestID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *rectificationRepo) Erase(userID string) error { return nil }
+func (r *rectificationRepo) Drop() error               { return nil }
+
+// TestHTTPAccess checks a correction goes to the user of the token and is
+// resolved by the admin of the token, whatever the body says.
+func TestHTTPAccess(t *testing.T) {
+	users := inmem.NewUserRepo()
+	users.Create(&user.User{ID: "u1", LastName: "Okonkwo", Email: "u1@example.com"})
+	users.Create(&user.User{ID: "u2", LastName: "Adeyemi", Email: "u2@example.com"})
+	r := &rectificationRepo{}
+	s := rectification.NewService(r, users)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := rectification.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	submit := `{"field":"last_name","value":"Okafor","reason":"married","user_id":"u2"}`
+	resolve := `{"approve":true,"reviewed_by":"u1"}`
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"submit without token", "POST", "/rectifications/v1/me", submit, "", http.StatusUnauthorized},
+		{"submit", "POST", "/rectifications/v1/me", submit, customer, http.StatusOK},
+		{"resolve without token", "POST", "/rectifications/v1/admin/r1/resolve", resolve, "", http.StatusUnauthorized},
+		{"resolve by customer", "POST", "/rectifications/v1/admin/r1/resolve", resolve, customer, http.StatusForbidden},
+		{"resolve by admin", "POST", "/rectifications/v1/admin/r1/resolve", resolve, staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+
+	if len(r.requests) != 1 || r.requests[0].UserID != "u1" || r.requests[0].ReviewedBy != "u9" {
+		t.Fatalf("expected one request of u1 reviewed by u9, got %+v", r.requests)
+	}
+	if u, _ := users.GetByID("u1"); u.LastName != "Okafor" {
+		t.Errorf("expected the last name of u1 corrected, got %q", u.LastName)
+	}
+	if u, _ := users.GetByID("u2"); u.LastName != "Adeyemi" {
+		t.Errorf("expected u2 left alone, got %q", u.LastName)
+	}
+}
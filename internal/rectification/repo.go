@@ -0,0 +1,21 @@
+package rectification
+
+// Repo abstracts all the persistant storage operations of Rectification
+// service.
+type Repo interface {
+	Create(r *Request) error
+	Save(r *Request) error
+	GetByID(ID string) (Request, error)
+	// ListByUser returns the requests of a user, latest first.
+	ListByUser(userID string) ([]Request, error)
+	// ListByStatus returns the requests in status, oldest first.
+	ListByStatus(status string) ([]Request, error)
+	// AddEvent appends to the audit trail of a request.
+	AddEvent(e *Event) error
+	// ListEvents returns the audit trail of a request, oldest first.
+	ListEvents(requestID string) ([]Event, error)
+	// Erase blanks the values, reasons and evidence of the requests of a
+	// user and the notes of their audit trail, on the erasure of the user.
+	Erase(userID string) error
+	Drop() error
+}
@@ -0,0 +1,184 @@
+package rectification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrRequestNotFound = errors.New("rectification request not found")
+	ErrAlreadyPending  = errors.New("a correction of the field is already pending")
+	ErrAlreadyResolved = errors.New("rectification request is already resolved")
+	ErrUnchanged       = errors.New("value is already stored")
+	ErrEmailTaken      = errors.New("email is taken")
+	ErrMissingReviewer = errors.New("reviewer is required")
+	ErrNoteRequired    = errors.New("a rejection needs a note for the user")
+)
+
+type Service interface {
+	// Submit requests the correction of a field of the personal data of a
+	// user, pending until reviewed by the staff.
+	Submit(ctx context.Context, userID string, n NewRequest) (Request, error)
+
+	// Requests lists the requests of a user, latest first.
+	Requests(ctx context.Context, userID string) ([]Request, error)
+
+	// Request returns a request along with its audit trail.
+	Request(ctx context.Context, ID string) (Request, []Event, error)
+
+	// Queue lists the requests waiting for review, oldest first.
+	Queue(ctx context.Context) ([]Request, error)
+
+	// Resolve approves or rejects a pending request. An approved correction
+	// is applied to the user.
+	Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (Request, error)
+}
+
+type basicService struct {
+	r     Repo
+	users user.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, users user.Repo) Service {
+	return basicService{r: r, users: users}
+}
+
+// Submit records the current value of the field along with the correction,
+// so that the reviewer sees both.
+func (s basicService) Submit(ctx context.Context, userID string, n NewRequest) (Request, error) {
+	n.clean()
+	if err := n.Validate(); err != nil {
+		return Request{}, err
+	}
+	u, err := s.users.GetByID(userID)
+	if err != nil {
+		return Request{}, user.ErrUserNotFound
+	}
+	current := fields[n.Field].get(u)
+	if current == n.Value {
+		return Request{}, ErrUnchanged
+	}
+	requests, err := s.r.ListByUser(userID)
+	if err != nil {
+		return Request{}, err
+	}
+	for _, r := range requests {
+		if r.Field == n.Field && r.Status == StatusPending {
+			return Request{}, ErrAlreadyPending
+		}
+	}
+
+	r := Request{
+		UserID:    userID,
+		Field:     n.Field,
+		Current:   current,
+		Value:     n.Value,
+		Reason:    n.Reason,
+		Evidence:  n.Evidence,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.Create(&r); err != nil {
+		return Request{}, err
+	}
+	if err := s.event(r, ActionSubmit, userID, n.Reason, r.CreatedAt); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// Requests lists the requests of a user, latest first.
+func (s basicService) Requests(ctx context.Context, userID string) ([]Request, error) {
+	return s.r.ListByUser(userID)
+}
+
+// Request returns a request along with its audit trail.
+func (s basicService) Request(ctx context.Context, ID string) (Request, []Event, error) {
+	r, err := s.get(ID)
+	if err != nil {
+		return Request{}, nil, err
+	}
+	events, err := s.r.ListEvents(ID)
+	if err != nil {
+		return Request{}, nil, err
+	}
+	return r, events, nil
+}
+
+// Queue lists the requests waiting for review, oldest first.
+func (s basicService) Queue(ctx context.Context) ([]Request, error) {
+	return s.r.ListByStatus(StatusPending)
+}
+
+// Resolve applies an approved correction to the user before resolving the
+// request, a correction which can't be applied leaves it pending.
+func (s basicService) Resolve(ctx context.Context, ID string, approve bool, reviewer, note string) (Request, error) {
+	if reviewer == "" {
+		return Request{}, ErrMissingReviewer
+	}
+	if !approve && note == "" {
+		return Request{}, ErrNoteRequired
+	}
+	r, err := s.get(ID)
+	if err != nil {
+		return Request{}, err
+	}
+	if r.Status != StatusPending {
+		return Request{}, ErrAlreadyResolved
+	}
+
+	action := ActionReject
+	r.Status = StatusRejected
+	if approve {
+		if err := s.apply(r); err != nil {
+			return Request{}, err
+		}
+		action = ActionApprove
+		r.Status = StatusApproved
+	}
+	now := time.Now().UTC()
+	r.ReviewedBy, r.Note, r.ResolvedAt = reviewer, note, &now
+	if err := s.r.Save(&r); err != nil {
+		return Request{}, err
+	}
+	if err := s.event(r, action, reviewer, note, now); err != nil {
+		return Request{}, err
+	}
+	return r, nil
+}
+
+// apply sets the corrected field of the user, an email must not be used
+// by another user.
+func (s basicService) apply(r Request) error {
+	u, err := s.users.GetByID(r.UserID)
+	if err != nil {
+		return user.ErrUserNotFound
+	}
+	if r.Field == "email" {
+		if other, err := s.users.GetByEmail(r.Value); err == nil && other.ID != u.ID {
+			return ErrEmailTaken
+		}
+	}
+	fields[r.Field].set(&u, r.Value)
+	return s.users.Save(&u)
+}
+
+func (s basicService) get(ID string) (Request, error) {
+	r, err := s.r.GetByID(ID)
+	if err == db.ErrNotFound {
+		return Request{}, ErrRequestNotFound
+	}
+	return r, err
+}
+
+func (s basicService) event(r Request, action, actor, note string, at time.Time) error {
+	return s.r.AddEvent(&Event{RequestID: r.ID, Action: action, Actor: actor, Note: note, At: at})
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,195 @@
+package rectification
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrRequestNotFound: "rectification.request_not_found",
+		ErrAlreadyPending:  "rectification.already_pending",
+		ErrUnchanged:       "rectification.unchanged",
+	})
+}
+
+// MakeHTTPHandler serves the corrections of the user of the request at
+// /rectifications/v1/me to the requests account lets through, e.g.
+// auth.NewMiddleware chained with rbac.RequireUnscoped, and their review to
+// the ones of admin, e.g. with rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	submitHandler := httptransport.NewServer(
+		e.SubmitEndpoint,
+		decodeSubmitRequest,
+		encodeResponse,
+		options...,
+	)
+	requestsHandler := httptransport.NewServer(
+		e.RequestsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	requestHandler := httptransport.NewServer(
+		e.RequestEndpoint,
+		decodeRequestRequest,
+		encodeResponse,
+		options...,
+	)
+	queueHandler := httptransport.NewServer(
+		e.QueueEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	resolveHandler := httptransport.NewServer(
+		e.ResolveEndpoint,
+		decodeResolveRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/rectifications/v1/me", submitHandler).Methods("POST")
+	r.Handle("/rectifications/v1/me", requestsHandler).Methods("GET")
+
+	// Shop admin endpoints
+	r.Handle("/rectifications/v1/admin/queue", queueHandler).Methods("GET")
+	r.Handle("/rectifications/v1/admin/{request-id}", requestHandler).Methods("GET")
+	r.Handle("/rectifications/v1/admin/{request-id}/resolve", resolveHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeSubmitRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r NewRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func decodeRequestRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["request-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "request-id")
+	}
+	return requestRequest{ID: ID}, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeResolveRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r resolveRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	ID, ok := mux.Vars(req)["request-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "request-id")
+	}
+	r.ID = ID
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrRequestNotFound, user.ErrUserNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyPending, ErrAlreadyResolved, ErrEmailTaken, db.ErrConflict:
+		return http.StatusConflict
+	case ErrUnchanged:
+		return http.StatusUnprocessableEntity
+	case ErrBadRouting, ErrMissingReviewer, ErrNoteRequired, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
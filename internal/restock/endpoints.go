@@ -0,0 +1,190 @@
+package restock
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the restock service endpoints under single type.
+type Endpoints struct {
+	AvailabilityEndpoint        endpoint.Endpoint
+	SubscribeEndpoint           endpoint.Endpoint
+	CreatePurchaseOrderEndpoint endpoint.Endpoint
+	PurchaseOrdersEndpoint      endpoint.Endpoint
+	RescheduleEndpoint          endpoint.Endpoint
+	CancelEndpoint              endpoint.Endpoint
+	ReceiveEndpoint             endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the restock service endpoints. The purchase orders are restricted by
+// admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		AvailabilityEndpoint:        MakeAvailabilityEndpoint(s),
+		SubscribeEndpoint:           MakeSubscribeEndpoint(s),
+		CreatePurchaseOrderEndpoint: admin(MakeCreatePurchaseOrderEndpoint(s)),
+		PurchaseOrdersEndpoint:      admin(MakePurchaseOrdersEndpoint(s)),
+		RescheduleEndpoint:          admin(MakeRescheduleEndpoint(s)),
+		CancelEndpoint:              admin(MakeCancelEndpoint(s)),
+		ReceiveEndpoint:             admin(MakeReceiveEndpoint(s)),
+	}
+}
+
+func MakeAvailabilityEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(bookRequest)
+		a, e := s.Availability(ctx, req.BookID)
+		if e != nil {
+			return availabilityResponse{Availability: nil, Error: e}, nil
+		}
+		return availabilityResponse{Availability: &a}, nil
+	}
+}
+
+func MakeSubscribeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(subscribeRequest)
+		sub, e := s.Subscribe(ctx, req.BookID, req.Email)
+		if e != nil {
+			return subscriptionResponse{Subscription: nil, Error: e}, nil
+		}
+		return subscriptionResponse{Subscription: &sub, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeCreatePurchaseOrderEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createPurchaseOrderRequest)
+		po, e := s.CreatePurchaseOrder(ctx, PurchaseOrder{
+			Supplier:   req.Supplier,
+			ExpectedAt: req.ExpectedAt,
+			Lines:      req.Lines,
+		})
+		if e != nil {
+			return purchaseOrderResponse{PurchaseOrder: nil, Error: e}, nil
+		}
+		return purchaseOrderResponse{PurchaseOrder: &po, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakePurchaseOrdersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(purchaseOrdersRequest)
+		pos, e := s.PurchaseOrders(ctx, req.Status)
+		if e != nil {
+			return purchaseOrdersResponse{PurchaseOrders: make([]PurchaseOrder, 0), Error: e}, nil
+		}
+		return purchaseOrdersResponse{PurchaseOrders: pos}, nil
+	}
+}
+
+func MakeRescheduleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rescheduleRequest)
+		po, e := s.Reschedule(ctx, req.PurchaseOrderID, req.ExpectedAt)
+		if e != nil {
+			return purchaseOrderResponse{PurchaseOrder: nil, Error: e}, nil
+		}
+		return purchaseOrderResponse{PurchaseOrder: &po}, nil
+	}
+}
+
+func MakeCancelEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(purchaseOrderRequest)
+		po, e := s.Cancel(ctx, req.PurchaseOrderID)
+		if e != nil {
+			return purchaseOrderResponse{PurchaseOrder: nil, Error: e}, nil
+		}
+		return purchaseOrderResponse{PurchaseOrder: &po}, nil
+	}
+}
+
+func MakeReceiveEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(purchaseOrderRequest)
+		po, e := s.Receive(ctx, req.PurchaseOrderID)
+		if e != nil {
+			return purchaseOrderResponse{PurchaseOrder: nil, Error: e}, nil
+		}
+		return purchaseOrderResponse{PurchaseOrder: &po}, nil
+	}
+}
+
+type bookRequest struct {
+	BookID string
+}
+
+type availabilityResponse struct {
+	Availability *Availability `json:"availability,omitempty"`
+	Error        error         `json:"error,omitempty"`
+}
+
+func (r availabilityResponse) error() error {
+	return r.Error
+}
+
+type subscribeRequest struct {
+	BookID string `json:"-"`
+	Email  string `json:"email"`
+}
+
+type subscriptionResponse struct {
+	Status       int           `json:"-"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        error         `json:"error,omitempty"`
+}
+
+func (r subscriptionResponse) status() int {
+	return r.Status
+}
+
+func (r subscriptionResponse) error() error {
+	return r.Error
+}
+
+type createPurchaseOrderRequest struct {
+	Supplier   string    `json:"supplier"`
+	ExpectedAt time.Time `json:"expected_at"`
+	Lines      []Line    `json:"lines"`
+}
+
+type purchaseOrdersRequest struct {
+	Status string
+}
+
+type rescheduleRequest struct {
+	PurchaseOrderID string    `json:"-"`
+	ExpectedAt      time.Time `json:"expected_at"`
+}
+
+type purchaseOrderRequest struct {
+	PurchaseOrderID string
+}
+
+type purchaseOrderResponse struct {
+	Status        int            `json:"-"`
+	PurchaseOrder *PurchaseOrder `json:"purchase_order,omitempty"`
+	Error         error          `json:"error,omitempty"`
+}
+
+func (r purchaseOrderResponse) status() int {
+	return r.Status
+}
+
+func (r purchaseOrderResponse) error() error {
+	return r.Error
+}
+
+type purchaseOrdersResponse struct {
+	PurchaseOrders []PurchaseOrder `json:"purchase_orders"`
+	Error          error           `json:"error,omitempty"`
+}
+
+func (r purchaseOrdersResponse) error() error {
+	return r.Error
+}
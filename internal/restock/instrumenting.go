@@ -0,0 +1,103 @@
+package restock
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) CreatePurchaseOrder(ctx context.Context, po PurchaseOrder) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_purchase_order", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purchaseOrder, err = mw.next.CreatePurchaseOrder(ctx, po)
+	return
+}
+
+func (mw instrmw) PurchaseOrders(ctx context.Context, status string) (purchaseOrders []PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "purchase_orders", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purchaseOrders, err = mw.next.PurchaseOrders(ctx, status)
+	return
+}
+
+func (mw instrmw) Reschedule(ctx context.Context, id string, expectedAt time.Time) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "reschedule", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purchaseOrder, err = mw.next.Reschedule(ctx, id, expectedAt)
+	return
+}
+
+func (mw instrmw) Cancel(ctx context.Context, id string) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "cancel", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purchaseOrder, err = mw.next.Cancel(ctx, id)
+	return
+}
+
+func (mw instrmw) Receive(ctx context.Context, id string) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "receive", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purchaseOrder, err = mw.next.Receive(ctx, id)
+	return
+}
+
+func (mw instrmw) Availability(ctx context.Context, bookID string) (availability Availability, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "availability", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	availability, err = mw.next.Availability(ctx, bookID)
+	return
+}
+
+func (mw instrmw) Subscribe(ctx context.Context, bookID, email string) (subscription Subscription, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "subscribe", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	subscription, err = mw.next.Subscribe(ctx, bookID, email)
+	return
+}
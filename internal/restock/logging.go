@@ -0,0 +1,100 @@
+package restock
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) CreatePurchaseOrder(ctx context.Context, po PurchaseOrder) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_purchase_order",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreatePurchaseOrder(ctx, po)
+}
+
+func (s loggingService) PurchaseOrders(ctx context.Context, status string) (purchaseOrders []PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "purchase_orders",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.PurchaseOrders(ctx, status)
+}
+
+func (s loggingService) Reschedule(ctx context.Context, id string, expectedAt time.Time) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reschedule",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Reschedule(ctx, id, expectedAt)
+}
+
+func (s loggingService) Cancel(ctx context.Context, id string) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "cancel",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Cancel(ctx, id)
+}
+
+func (s loggingService) Receive(ctx context.Context, id string) (purchaseOrder PurchaseOrder, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "receive",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Receive(ctx, id)
+}
+
+func (s loggingService) Availability(ctx context.Context, bookID string) (availability Availability, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "availability",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Availability(ctx, bookID)
+}
+
+func (s loggingService) Subscribe(ctx context.Context, bookID, email string) (subscription Subscription, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "subscribe",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Subscribe(ctx, bookID, email)
+}
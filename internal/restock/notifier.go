@@ -0,0 +1,37 @@
+package restock
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/notification/email"
+)
+
+// Notifier tells the subscribers about their books.
+type Notifier interface {
+	// Delayed tells the restock of b slipped to eta, nil if unknown.
+	Delayed(s Subscription, b catalog.Book, eta *time.Time) error
+
+	// BackInStock tells b can be ordered again.
+	BackInStock(s Subscription, b catalog.Book) error
+}
+
+type emailNotifier struct{}
+
+// NewEmailNotifier returns a Notifier sending emails.
+func NewEmailNotifier() Notifier {
+	return emailNotifier{}
+}
+
+func (emailNotifier) Delayed(s Subscription, b catalog.Book, eta *time.Time) error {
+	return email.RestockDelayed([]string{s.Email}, map[string]interface{}{
+		"book": b,
+		"eta":  eta,
+	})
+}
+
+func (emailNotifier) BackInStock(s Subscription, b catalog.Book) error {
+	return email.BackInStock([]string{s.Email}, map[string]interface{}{
+		"book": b,
+	})
+}
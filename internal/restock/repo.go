@@ -0,0 +1,19 @@
+package restock
+
+// Repo abstracts all the persistant storage operations of Restock Service
+type Repo interface {
+	CreatePurchaseOrder(po *PurchaseOrder) error
+	// SavePurchaseOrder saves the purchase order, not its lines.
+	SavePurchaseOrder(po *PurchaseOrder) error
+	GetPurchaseOrder(id string) (PurchaseOrder, error)
+	ListPurchaseOrders(status string) ([]PurchaseOrder, error)
+	// ListOpenByBook returns the open purchase orders with bookID.
+	ListOpenByBook(bookID string) ([]PurchaseOrder, error)
+	CreateSubscription(s *Subscription) error
+	SaveSubscription(s *Subscription) error
+	// ListSubscriptions returns the subscriptions of bookID not done yet.
+	ListSubscriptions(bookID string) ([]Subscription, error)
+	// DeleteSubscriptions deletes the subscriptions of email.
+	DeleteSubscriptions(email string) error
+	Drop() error
+}
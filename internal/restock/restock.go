@@ -0,0 +1,130 @@
+package restock
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidPurchaseOrder = errors.New("purchase order needs a supplier, an expected date and lines of positive quantity")
+)
+
+// Purchase order statuses.
+const (
+	StatusOpen      = "open"
+	StatusReceived  = "received"
+	StatusCancelled = "cancelled"
+)
+
+// PurchaseOrder is an order placed with a supplier to restock books.
+type PurchaseOrder struct {
+	ID         string     `json:"id"`
+	Supplier   string     `json:"supplier"`
+	Status     string     `json:"status"`
+	ExpectedAt time.Time  `json:"expected_at"`
+	Lines      []Line     `json:"lines"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// Validate checks the purchase order can be placed.
+func (po PurchaseOrder) Validate() error {
+	if po.Supplier == "" || po.ExpectedAt.IsZero() || len(po.Lines) == 0 {
+		return ErrInvalidPurchaseOrder
+	}
+	for _, l := range po.Lines {
+		if l.BookID == "" || l.Quantity <= 0 {
+			return ErrInvalidPurchaseOrder
+		}
+	}
+	return nil
+}
+
+// Books returns the IDs of the books on the purchase order.
+func (po PurchaseOrder) Books() []string {
+	ids := make([]string, 0, len(po.Lines))
+	seen := make(map[string]bool)
+	for _, l := range po.Lines {
+		if !seen[l.BookID] {
+			seen[l.BookID] = true
+			ids = append(ids, l.BookID)
+		}
+	}
+	return ids
+}
+
+// Line is the quantity of a book on a purchase order.
+type Line struct {
+	ID              string `json:"-"`
+	PurchaseOrderID string `json:"-"`
+	BookID          string `json:"book_id"`
+	Quantity        int    `json:"quantity"`
+}
+
+func (Line) TableName() string {
+	return "purchase_order_lines"
+}
+
+// Availability tells if a book can be ordered, and when it's expected back
+// if it's out of stock.
+type Availability struct {
+	BookID   string `json:"book_id"`
+	InStock  bool   `json:"in_stock"`
+	Stock    int    `json:"stock"`
+	Incoming int    `json:"incoming"`
+	// RestockETA is the expected date of the earliest open purchase order
+	// of the book, nil if none is open.
+	RestockETA *time.Time `json:"restock_eta,omitempty"`
+}
+
+// ETA returns the earliest expected date of the open purchase orders with
+// bookID, along with the total quantity incoming.
+func ETA(bookID string, open []PurchaseOrder) (*time.Time, int) {
+	var eta *time.Time
+	incoming := 0
+	for i, po := range open {
+		if po.Status != StatusOpen {
+			continue
+		}
+		for _, l := range po.Lines {
+			if l.BookID != bookID {
+				continue
+			}
+			incoming += l.Quantity
+			if eta == nil || po.ExpectedAt.Before(*eta) {
+				eta = &open[i].ExpectedAt
+			}
+		}
+	}
+	return eta, incoming
+}
+
+// Subscription asks to be told when an out of stock book is delayed past
+// its restock ETA, and when it's back in stock.
+type Subscription struct {
+	ID     string `json:"id"`
+	BookID string `json:"book_id"`
+	Email  string `json:"email"`
+	// ETA is the restock ETA the subscriber was last told about.
+	ETA        *time.Time `json:"eta,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	Done       bool       `json:"done"`
+}
+
+func (Subscription) TableName() string {
+	return "restock_subscriptions"
+}
+
+// Slipped tells if eta is later than the one the subscriber was told
+// about. No ETA anymore, e.g. a cancelled purchase order, is a slip too.
+func (s Subscription) Slipped(eta *time.Time) bool {
+	if s.ETA == nil {
+		return false
+	}
+	return eta == nil || eta.After(*s.ETA)
+}
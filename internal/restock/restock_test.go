@@ -0,0 +1,105 @@
+package restock_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/restock"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo has no purchase order, the other methods of the Repo aren't used.
+type repo struct {
+	restock.Repo
+}
+
+func (repo) GetPurchaseOrder(id string) (restock.PurchaseOrder, error) {
+	return restock.PurchaseOrder{}, db.ErrNotFound
+}
+
+func (repo) ListPurchaseOrders(status string) ([]restock.PurchaseOrder, error) {
+	return nil, nil
+}
+
+func TestETA(t *testing.T) {
+	may := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := may.AddDate(0, 1, 0)
+	open := []restock.PurchaseOrder{
+		{Status: restock.StatusOpen, ExpectedAt: june, Lines: []restock.Line{{BookID: "b1", Quantity: 5}}},
+		{Status: restock.StatusOpen, ExpectedAt: may, Lines: []restock.Line{{BookID: "b1", Quantity: 2}, {BookID: "b2", Quantity: 1}}},
+		{Status: restock.StatusCancelled, ExpectedAt: may.AddDate(0, 0, -7), Lines: []restock.Line{{BookID: "b1", Quantity: 9}}},
+	}
+
+	eta, incoming := restock.ETA("b1", open)
+	if eta == nil || !eta.Equal(may) || incoming != 7 {
+		t.Errorf("expected %v and 7 incoming, got %v and %d", may, eta, incoming)
+	}
+	if eta, incoming := restock.ETA("b3", open); eta != nil || incoming != 0 {
+		t.Errorf("expected no ETA, got %v and %d", eta, incoming)
+	}
+}
+
+func TestSubscriptionSlipped(t *testing.T) {
+	may := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := may.AddDate(0, 1, 0)
+	april := may.AddDate(0, -1, 0)
+
+	cases := []struct {
+		told, eta *time.Time
+		slipped   bool
+	}{
+		{&may, &june, true},
+		{&may, nil, true},
+		{&may, &may, false},
+		{&may, &april, false},
+		{nil, &june, false},
+	}
+	for _, c := range cases {
+		s := restock.Subscription{ETA: c.told}
+		if got := s.Slipped(c.eta); got != c.slipped {
+			t.Errorf("told %v, now %v: expected slipped %v, got %v", c.told, c.eta, c.slipped, got)
+		}
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := restock.MakeHTTPHandler(context.Background(), restock.NewService(repo{}, nil, nil), admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	po := `{"supplier":"s1","lines":[{"book_id":"b1","quantity":10}]}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"create without token", "POST", "/restock/v1/admin/purchase-orders", po, "", http.StatusUnauthorized},
+		{"create by customer", "POST", "/restock/v1/admin/purchase-orders", po, customer, http.StatusForbidden},
+		{"list by customer", "GET", "/restock/v1/admin/purchase-orders", "", customer, http.StatusForbidden},
+		{"reschedule by customer", "POST", "/restock/v1/admin/purchase-orders/po1/reschedule", `{}`, customer, http.StatusForbidden},
+		{"cancel by customer", "POST", "/restock/v1/admin/purchase-orders/po1/cancel", "", customer, http.StatusForbidden},
+		{"receive by customer", "POST", "/restock/v1/admin/purchase-orders/po1/receive", "", customer, http.StatusForbidden},
+		{"list by admin", "GET", "/restock/v1/admin/purchase-orders", "", staff, http.StatusOK},
+		{"receive by admin", "POST", "/restock/v1/admin/purchase-orders/po1/receive", "", staff, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package restock
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+var (
+	ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+	ErrPurchaseOrderClosed   = errors.New("purchase order is not open")
+	ErrInvalidEmail          = errors.New("invalid email")
+)
+
+type Service interface {
+	// CreatePurchaseOrder places an open purchase order.
+	CreatePurchaseOrder(ctx context.Context, po PurchaseOrder) (PurchaseOrder, error)
+
+	// PurchaseOrders returns the purchase orders of status, all if empty.
+	PurchaseOrders(ctx context.Context, status string) ([]PurchaseOrder, error)
+
+	// Reschedule changes the expected date of an open purchase order,
+	// telling the subscribers of its books if their ETA slipped.
+	Reschedule(ctx context.Context, id string, expectedAt time.Time) (PurchaseOrder, error)
+
+	// Cancel cancels an open purchase order, telling the subscribers of its
+	// books if their ETA slipped.
+	Cancel(ctx context.Context, id string) (PurchaseOrder, error)
+
+	// Receive adds the quantities of an open purchase order to the stock,
+	// telling the subscribers of its books they're back in stock.
+	Receive(ctx context.Context, id string) (PurchaseOrder, error)
+
+	// Availability returns the stock and the restock ETA of a book.
+	Availability(ctx context.Context, bookID string) (Availability, error)
+
+	// Subscribe asks to be notified about the restock of a book.
+	Subscribe(ctx context.Context, bookID, email string) (Subscription, error)
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+	notify  Notifier
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo, notify Notifier) Service {
+	return basicService{r: r, catalog: catalog, notify: notify}
+}
+
+// CreatePurchaseOrder places an open purchase order. Subscribers are told
+// about the new ETA the next time it changes.
+func (s basicService) CreatePurchaseOrder(ctx context.Context, po PurchaseOrder) (PurchaseOrder, error) {
+	if err := po.Validate(); err != nil {
+		return PurchaseOrder{}, err
+	}
+	for _, id := range po.Books() {
+		if _, err := s.catalog.GetByID(id); err != nil {
+			return PurchaseOrder{}, catalog.ErrBookNotFound
+		}
+	}
+	po.ID = ""
+	po.Status = StatusOpen
+	po.CreatedAt = time.Now().UTC()
+	po.ReceivedAt = nil
+	if err := s.r.CreatePurchaseOrder(&po); err != nil {
+		return PurchaseOrder{}, err
+	}
+	if err := s.refresh(po.Books()); err != nil {
+		return PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+// PurchaseOrders returns the purchase orders of status.
+func (s basicService) PurchaseOrders(ctx context.Context, status string) ([]PurchaseOrder, error) {
+	return s.r.ListPurchaseOrders(status)
+}
+
+func (s basicService) open(id string) (PurchaseOrder, error) {
+	po, err := s.r.GetPurchaseOrder(id)
+	if err != nil {
+		return PurchaseOrder{}, ErrPurchaseOrderNotFound
+	}
+	if po.Status != StatusOpen {
+		return PurchaseOrder{}, ErrPurchaseOrderClosed
+	}
+	return po, nil
+}
+
+// Reschedule changes the expected date of an open purchase order.
+func (s basicService) Reschedule(ctx context.Context, id string, expectedAt time.Time) (PurchaseOrder, error) {
+	if expectedAt.IsZero() {
+		return PurchaseOrder{}, ErrInvalidPurchaseOrder
+	}
+	po, err := s.open(id)
+	if err != nil {
+		return PurchaseOrder{}, err
+	}
+	po.ExpectedAt = expectedAt
+	if err := s.r.SavePurchaseOrder(&po); err != nil {
+		return PurchaseOrder{}, err
+	}
+	if err := s.refresh(po.Books()); err != nil {
+		return PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+// Cancel cancels an open purchase order.
+func (s basicService) Cancel(ctx context.Context, id string) (PurchaseOrder, error) {
+	po, err := s.open(id)
+	if err != nil {
+		return PurchaseOrder{}, err
+	}
+	po.Status = StatusCancelled
+	if err := s.r.SavePurchaseOrder(&po); err != nil {
+		return PurchaseOrder{}, err
+	}
+	if err := s.refresh(po.Books()); err != nil {
+		return PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+// Receive adds the quantities of an open purchase order to the stock of
+// its books.
+func (s basicService) Receive(ctx context.Context, id string) (PurchaseOrder, error) {
+	po, err := s.open(id)
+	if err != nil {
+		return PurchaseOrder{}, err
+	}
+	received := make(map[string]int)
+	for _, l := range po.Lines {
+		received[l.BookID] += l.Quantity
+	}
+	for _, bookID := range po.Books() {
+		b, err := s.catalog.GetByID(bookID)
+		if err != nil {
+			return PurchaseOrder{}, catalog.ErrBookNotFound
+		}
+		b.Stock += received[bookID]
+		if err := s.catalog.Save(&b); err != nil {
+			return PurchaseOrder{}, err
+		}
+	}
+	now := time.Now().UTC()
+	po.Status = StatusReceived
+	po.ReceivedAt = &now
+	if err := s.r.SavePurchaseOrder(&po); err != nil {
+		return PurchaseOrder{}, err
+	}
+	if err := s.refresh(po.Books()); err != nil {
+		return PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+// Availability returns the stock and the restock ETA of a book.
+func (s basicService) Availability(ctx context.Context, bookID string) (Availability, error) {
+	b, err := s.catalog.GetByID(bookID)
+	if err != nil {
+		return Availability{}, catalog.ErrBookNotFound
+	}
+	return s.availability(b)
+}
+
+func (s basicService) availability(b catalog.Book) (Availability, error) {
+	open, err := s.r.ListOpenByBook(b.ID)
+	if err != nil {
+		return Availability{}, err
+	}
+	eta, incoming := ETA(b.ID, open)
+	return Availability{
+		BookID:     b.ID,
+		InStock:    b.Stock > 0 && !b.Delisted,
+		Stock:      b.Stock,
+		Incoming:   incoming,
+		RestockETA: eta,
+	}, nil
+}
+
+// Subscribe asks to be notified about the restock of a book, starting from
+// its current ETA.
+func (s basicService) Subscribe(ctx context.Context, bookID, email string) (Subscription, error) {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return Subscription{}, ErrInvalidEmail
+	}
+	b, err := s.catalog.GetByID(bookID)
+	if err != nil {
+		return Subscription{}, catalog.ErrBookNotFound
+	}
+	a, err := s.availability(b)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub := Subscription{
+		BookID:    b.ID,
+		Email:     email,
+		ETA:       a.RestockETA,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.CreateSubscription(&sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// refresh compares the availability of books with what their subscribers
+// were told. In stock books close the subscriptions, a later ETA notifies
+// the delay and an earlier one is just remembered.
+func (s basicService) refresh(books []string) error {
+	for _, bookID := range books {
+		b, err := s.catalog.GetByID(bookID)
+		if err != nil {
+			return err
+		}
+		a, err := s.availability(b)
+		if err != nil {
+			return err
+		}
+		subs, err := s.r.ListSubscriptions(bookID)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			now := time.Now().UTC()
+			switch {
+			case a.InStock:
+				if err := s.notify.BackInStock(sub, b); err != nil {
+					return err
+				}
+				sub.Done = true
+				sub.NotifiedAt = &now
+			case sub.Slipped(a.RestockETA):
+				if err := s.notify.Delayed(sub, b, a.RestockETA); err != nil {
+					return err
+				}
+				sub.NotifiedAt = &now
+			}
+			sub.ETA = a.RestockETA
+			if err := s.r.SaveSubscription(&sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
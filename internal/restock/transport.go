@@ -0,0 +1,216 @@
+package restock
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// MakeHTTPHandler mounts the restock endpoints, the purchase orders served
+// to the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	availabilityHandler := httptransport.NewServer(
+		e.AvailabilityEndpoint,
+		decodeBookRequest,
+		encodeResponse,
+		options...,
+	)
+	subscribeHandler := httptransport.NewServer(
+		e.SubscribeEndpoint,
+		decodeSubscribeRequest,
+		encodeResponse,
+		options...,
+	)
+	createPurchaseOrderHandler := httptransport.NewServer(
+		e.CreatePurchaseOrderEndpoint,
+		decodeCreatePurchaseOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	purchaseOrdersHandler := httptransport.NewServer(
+		e.PurchaseOrdersEndpoint,
+		decodePurchaseOrdersRequest,
+		encodeResponse,
+		options...,
+	)
+	rescheduleHandler := httptransport.NewServer(
+		e.RescheduleEndpoint,
+		decodeRescheduleRequest,
+		encodeResponse,
+		options...,
+	)
+	cancelHandler := httptransport.NewServer(
+		e.CancelEndpoint,
+		decodePurchaseOrderRequest,
+		encodeResponse,
+		options...,
+	)
+	receiveHandler := httptransport.NewServer(
+		e.ReceiveEndpoint,
+		decodePurchaseOrderRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/restock/v1/books/{book-id}/availability", availabilityHandler).Methods("GET")
+	r.Handle("/restock/v1/books/{book-id}/subscribe", subscribeHandler).Methods("POST")
+
+	// Shop admin endpoints
+	r.Handle("/restock/v1/admin/purchase-orders", purchaseOrdersHandler).Methods("GET")
+	r.Handle("/restock/v1/admin/purchase-orders", createPurchaseOrderHandler).Methods("POST")
+	r.Handle("/restock/v1/admin/purchase-orders/{po-id}/reschedule", rescheduleHandler).Methods("POST")
+	r.Handle("/restock/v1/admin/purchase-orders/{po-id}/cancel", cancelHandler).Methods("POST")
+	r.Handle("/restock/v1/admin/purchase-orders/{po-id}/receive", receiveHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeBookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	return bookRequest{BookID: bookID}, nil
+}
+
+func decodeSubscribeRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r subscribeRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	r.BookID = bookID
+	return r, nil
+}
+
+func decodeCreatePurchaseOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createPurchaseOrderRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodePurchaseOrdersRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return purchaseOrdersRequest{Status: req.FormValue("status")}, nil
+}
+
+func decodeRescheduleRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r rescheduleRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	poID, ok := mux.Vars(req)["po-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "po-id")
+	}
+	r.PurchaseOrderID = poID
+	return r, nil
+}
+
+func decodePurchaseOrderRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	poID, ok := mux.Vars(req)["po-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "po-id")
+	}
+	return purchaseOrderRequest{PurchaseOrderID: poID}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrPurchaseOrderNotFound, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrPurchaseOrderClosed:
+		return http.StatusConflict
+	case ErrBadRouting, ErrInvalidPurchaseOrder, ErrInvalidEmail, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,154 @@
+package returns
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Authenticator resolves the token of a request into its user,
+// user.Service implements it.
+type Authenticator interface {
+	AuthToken(ctx context.Context, token string) (user.User, error)
+}
+
+// Endpoints combine all the returns service endpoints under single type.
+type Endpoints struct {
+	CreateEndpoint  endpoint.Endpoint
+	ReturnsEndpoint endpoint.Endpoint
+	ReturnEndpoint  endpoint.Endpoint
+	LabelEndpoint   endpoint.Endpoint
+	WebhookEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the returns service endpoints. The returns are the ones of the user
+// of the bearer token, the webhook is signed by the provider.
+func MakeEndpoints(s Service, auth Authenticator) Endpoints {
+	return Endpoints{
+		CreateEndpoint:  MakeCreateEndpoint(s, auth),
+		ReturnsEndpoint: MakeReturnsEndpoint(s, auth),
+		ReturnEndpoint:  MakeReturnEndpoint(s, auth),
+		LabelEndpoint:   MakeLabelEndpoint(s, auth),
+		WebhookEndpoint: MakeWebhookEndpoint(s),
+	}
+}
+
+func MakeCreateEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		rt, e := s.Create(ctx, u.ID, req.OrderID, req.NewReturn)
+		if e != nil {
+			return returnResponse{Return: nil, Error: e}, nil
+		}
+		return returnResponse{Return: &rt, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeReturnsEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(returnRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		returns, e := s.Returns(ctx, u.ID)
+		if e != nil {
+			return returnsResponse{Returns: make([]Return, 0), Error: e}, nil
+		}
+		return returnsResponse{Returns: returns}, nil
+	}
+}
+
+func MakeReturnEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(returnRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return nil, user.ErrUnauthorized
+		}
+		rt, e := s.Return(ctx, u.ID, req.ReturnID)
+		if e != nil {
+			return returnResponse{Return: nil, Error: e}, nil
+		}
+		return returnResponse{Return: &rt}, nil
+	}
+}
+
+func MakeLabelEndpoint(s Service, auth Authenticator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(returnRequest)
+		u, e := auth.AuthToken(ctx, req.Token)
+		if e != nil {
+			return fileResponse{Error: user.ErrUnauthorized}, nil
+		}
+		f, e := s.Label(ctx, u.ID, req.ReturnID)
+		return fileResponse{File: f, Error: e}, nil
+	}
+}
+
+func MakeWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.Webhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+type createRequest struct {
+	NewReturn
+	Token   string `json:"-"`
+	OrderID string `json:"-"`
+}
+
+type returnRequest struct {
+	Token    string
+	ReturnID string
+}
+
+type webhookRequest struct {
+	Signature string
+	Body      []byte
+}
+
+type returnResponse struct {
+	Status int     `json:"-"`
+	Return *Return `json:"return,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r returnResponse) status() int {
+	return r.Status
+}
+
+func (r returnResponse) error() error {
+	return r.Error
+}
+
+type returnsResponse struct {
+	Returns []Return `json:"returns"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r returnsResponse) error() error {
+	return r.Error
+}
+
+type fileResponse struct {
+	File
+	Error error
+}
+
+type webhookResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r webhookResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package returns
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Create(ctx context.Context, userID, orderID string, n NewReturn) (rt Return, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rt, err = mw.next.Create(ctx, userID, orderID, n)
+	return
+}
+
+func (mw instrmw) Returns(ctx context.Context, userID string) (returns []Return, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "returns", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	returns, err = mw.next.Returns(ctx, userID)
+	return
+}
+
+func (mw instrmw) Return(ctx context.Context, userID, ID string) (rt Return, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "return", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	rt, err = mw.next.Return(ctx, userID, ID)
+	return
+}
+
+func (mw instrmw) Label(ctx context.Context, userID, ID string) (f File, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "label", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	f, err = mw.next.Label(ctx, userID, ID)
+	return
+}
+
+func (mw instrmw) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Webhook(ctx, signature, body)
+	return
+}
@@ -0,0 +1,86 @@
+package returns
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Create(ctx context.Context, userID, orderID string, n NewReturn) (rt Return, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create",
+			"user_id", userID,
+			"order", orderID,
+			"return", rt.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Create(ctx, userID, orderID, n)
+}
+
+func (s loggingService) Returns(ctx context.Context, userID string) (returns []Return, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "returns",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Returns(ctx, userID)
+}
+
+func (s loggingService) Return(ctx context.Context, userID, ID string) (rt Return, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "return",
+			"user_id", userID,
+			"return", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Return(ctx, userID, ID)
+}
+
+func (s loggingService) Label(ctx context.Context, userID, ID string) (f File, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "label",
+			"user_id", userID,
+			"return", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Label(ctx, userID, ID)
+}
+
+func (s loggingService) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Webhook(ctx, signature, body)
+}
@@ -0,0 +1,99 @@
+package returns
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the shipping provider the prepaid return labels are
+// bought from, which tracks the parcels back to the warehouse.
+type Provider interface {
+	// Name uniquely identifies the provider.
+	Name() string
+
+	// BuyReturn purchases the prepaid label of a return, addressed to the
+	// return address of the shop account. The provider is expected to
+	// return the same label when asked again for the same return.
+	BuyReturn(ctx context.Context, r LabelRequest) (fulfillment.Label, error)
+
+	// VerifyWebhook tells whether the webhook body is signed by the provider.
+	VerifyWebhook(signature string, body []byte) bool
+}
+
+// LabelRequest is the parcel a return label is bought for.
+type LabelRequest struct {
+	ReturnID string          `json:"return_id"`
+	OrderID  string          `json:"order_id"`
+	From     address.Address `json:"from"`
+	Items    int             `json:"items"`
+}
+
+type httpProvider struct {
+	baseURL string
+	apiKey  string
+	secret  string
+	client  *http.Client
+}
+
+// NewHTTPProvider returns Provider posting the requests to
+// <baseURL>/returns and reading back the label as JSON. secret is used to
+// verify the webhook signatures.
+func NewHTTPProvider(baseURL, apiKey, secret string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, secret: secret, client: client}
+}
+
+func (p httpProvider) Name() string {
+	return "labels"
+}
+
+func (p httpProvider) BuyReturn(ctx context.Context, r LabelRequest) (fulfillment.Label, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fulfillment.Label{}, err
+	}
+	req, err := http.NewRequest("POST", p.baseURL+"/returns", bytes.NewReader(body))
+	if err != nil {
+		return fulfillment.Label{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	// The return ID makes a retried purchase return the same label.
+	req.Header.Set("Idempotency-Key", r.ReturnID)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fulfillment.Label{}, errors.Wrap(err, "return label buy")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fulfillment.Label{}, fmt.Errorf("return label buy: unexpected status %d", resp.StatusCode)
+	}
+	var l fulfillment.Label
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return fulfillment.Label{}, errors.Wrap(err, "return label buy")
+	}
+	return l, nil
+}
+
+// VerifyWebhook checks the hex encoded HMAC-SHA256 of the body.
+func (p httpProvider) VerifyWebhook(signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
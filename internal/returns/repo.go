@@ -0,0 +1,19 @@
+package returns
+
+// Repo abstracts all the persistant storage operations of Returns Service
+type Repo interface {
+	// Create stores the return with its lines in one go.
+	Create(r *Return) error
+	// Get returns the return with its lines.
+	Get(ID string) (Return, error)
+	// GetByTracking returns the return of the parcel of a provider.
+	GetByTracking(provider, trackingNumber string) (Return, error)
+	// ListByUser returns the returns of a user with their lines, latest
+	// first.
+	ListByUser(userID string) ([]Return, error)
+	// ListByOrder returns the returns of an order with their lines.
+	ListByOrder(orderID string) ([]Return, error)
+	// Save saves the return, not its lines.
+	Save(r *Return) error
+	Drop() error
+}
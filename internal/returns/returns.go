@@ -0,0 +1,118 @@
+package returns
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Statuses of a return.
+const (
+	StatusPending   = "pending"    // label not purchased yet
+	StatusLabelled  = "labelled"   // label ready for the customer to print
+	StatusInTransit = "in_transit" // parcel handed to the carrier
+	StatusDelivered = "delivered"  // parcel back at the warehouse, refund pending
+	StatusRefunded  = "refunded"
+)
+
+// DefaultWindowDays is the return window by default, counted from the
+// shipping of the order.
+const DefaultWindowDays = 30
+
+// MaxReasonLength caps the reason given by the customer.
+const MaxReasonLength = 500
+
+// Return is the return of books of a shipped order by its customer, sent
+// back with a prepaid label and refunded once delivered to the warehouse.
+type Return struct {
+	ID      string `json:"id"`
+	OrderID string `json:"order_id" sql:"index"`
+	UserID  string `json:"user_id" sql:"index"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Lines   []Line `json:"lines" gorm:"ForeignKey:ReturnID"`
+	// Amount is refunded on delivery, the price paid for the lines.
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	// Provider is the label provider the label is bought from, which
+	// pushes the tracking updates of the parcel.
+	Provider       string  `json:"provider"`
+	Reference      string  `json:"reference,omitempty"`
+	Carrier        string  `json:"carrier,omitempty"`
+	TrackingNumber string  `json:"tracking_number,omitempty" sql:"index"`
+	Postage        float64 `json:"postage,omitempty"`
+	// Label is the PDF bought, kept for the customer to print again.
+	Label []byte `json:"-"`
+	// RefundRef is the reference of the payment refund, set before the
+	// credit note is issued.
+	RefundRef   string     `json:"refund_ref,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	RefundedAt  *time.Time `json:"refunded_at,omitempty"`
+}
+
+func (Return) TableName() string {
+	return "returns"
+}
+
+// Line is a book sent back, at the price paid for it.
+type Line struct {
+	ReturnID string  `json:"-" gorm:"primary_key"`
+	BookID   string  `json:"book_id" gorm:"primary_key"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+func (Line) TableName() string {
+	return "return_lines"
+}
+
+// NewReturn is the return a customer starts.
+type NewReturn struct {
+	Lines  []NewLine `json:"lines"`
+	Reason string    `json:"reason"`
+}
+
+// NewLine is a book to send back.
+type NewLine struct {
+	BookID   string `json:"book_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// Validate checks the lines and the reason, the books being checked
+// against the order later.
+func (n NewReturn) Validate() error {
+	var v validate.Validator
+	v.Check(len(n.Lines) > 0, "lines", validate.CodeRequired, "lines is required")
+	seen := make(map[string]bool)
+	for _, l := range n.Lines {
+		if !v.Required("book_id", l.BookID) {
+			break
+		}
+		if !v.Check(!seen[l.BookID], "lines", validate.CodeInvalid, "book "+l.BookID+" is listed twice") {
+			break
+		}
+		seen[l.BookID] = true
+		if !v.Range("quantity", l.Quantity, 1, order.MaxQuantity) {
+			break
+		}
+	}
+	v.Required("reason", n.Reason)
+	v.MaxLength("reason", n.Reason, MaxReasonLength)
+	return v.Err()
+}
+
+// Update is a tracking update of a parcel pushed by the provider.
+type Update struct {
+	TrackingNumber string `json:"tracking_number"`
+	Status         string `json:"status"` // in_transit or delivered
+}
+
+// File is a printable document.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
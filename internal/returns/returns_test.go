@@ -0,0 +1,251 @@
+package returns_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/credit"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/returns"
+)
+
+// repo keeps the returns in memory.
+type repo struct {
+	returns.Repo
+	returns []returns.Return
+}
+
+func (r *repo) Create(rt *returns.Return) error {
+	rt.ID = "r1"
+	r.returns = append(r.returns, *rt)
+	return nil
+}
+
+func (r *repo) Save(rt *returns.Return) error {
+	for i := range r.returns {
+		if r.returns[i].ID == rt.ID {
+			r.returns[i] = *rt
+		}
+	}
+	return nil
+}
+
+func (r *repo) Get(ID string) (returns.Return, error) {
+	for _, rt := range r.returns {
+		if rt.ID == ID {
+			return rt, nil
+		}
+	}
+	return returns.Return{}, db.ErrNotFound
+}
+
+func (r *repo) GetByTracking(provider, trackingNumber string) (returns.Return, error) {
+	for _, rt := range r.returns {
+		if rt.Provider == provider && rt.TrackingNumber == trackingNumber {
+			return rt, nil
+		}
+	}
+	return returns.Return{}, db.ErrNotFound
+}
+
+func (r *repo) ListByOrder(orderID string) ([]returns.Return, error) {
+	return r.returns, nil
+}
+
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+type addressRepo struct {
+	address.Repo
+}
+
+func (addressRepo) GetCheck(orderID string) (address.Check, error) {
+	return address.Check{OrderID: orderID, Address: address.Address{Line1: "1 Main St", City: "Berlin", Country: "DE"}}, nil
+}
+
+const secret = "s3cr3t"
+
+// provider fails the first purchase.
+type provider struct {
+	requests []returns.LabelRequest
+}
+
+func (p *provider) Name() string {
+	return "test"
+}
+
+func (p *provider) BuyReturn(ctx context.Context, r returns.LabelRequest) (fulfillment.Label, error) {
+	p.requests = append(p.requests, r)
+	if len(p.requests) == 1 {
+		return fulfillment.Label{}, errors.New("timeout")
+	}
+	return fulfillment.Label{Reference: "l1", Carrier: "dhl", TrackingNumber: "T1", Postage: 5.5, PDF: []byte("%PDF")}, nil
+}
+
+func (p *provider) VerifyWebhook(signature string, body []byte) bool {
+	return signature == sign(body)
+}
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// payments fails the first refund.
+type payments struct {
+	order.Payments
+	refunds []float64
+}
+
+func (p *payments) Refund(ctx context.Context, paymentRef string, amount float64, currency string) (string, error) {
+	p.refunds = append(p.refunds, amount)
+	if len(p.refunds) == 1 {
+		return "", errors.New("timeout")
+	}
+	return "rf1", nil
+}
+
+type credits struct {
+	notes []archive.NewCreditNote
+}
+
+func (c *credits) CreditNote(ctx context.Context, orderID string, n archive.NewCreditNote) (archive.Document, error) {
+	c.notes = append(c.notes, n)
+	return archive.Document{}, nil
+}
+
+func TestReturn(t *testing.T) {
+	ctx := context.Background()
+	shipped, old := time.Now().AddDate(0, 0, -10), time.Now().AddDate(0, 0, -31)
+	lines := []order.Line{{BookID: "b1", Quantity: 2, Price: 12.5}, {BookID: "b2", Quantity: 1, Price: 8}}
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", ShippedAt: &shipped, Lines: lines, Currency: "EUR", PaymentRef: "p1"},
+		"o2": {ID: "o2", CreatedByID: "u1", ShippedAt: &old, Lines: lines},
+		"o3": {ID: "o3", CreatedByID: "u1", Lines: lines},
+	}}
+	r, p, pay, cn := &repo{}, &provider{}, &payments{}, &credits{}
+	s := returns.NewService(r, orders, addressRepo{}, p, pay, cn, returns.Config{})
+
+	books := returns.NewReturn{Lines: []returns.NewLine{{BookID: "b1", Quantity: 2}}, Reason: "damaged cover"}
+	for id, expected := range map[string]error{"o2": returns.ErrWindowClosed, "o3": returns.ErrNotShipped} {
+		if _, err := s.Create(ctx, "u1", id, books); err != expected {
+			t.Errorf("%s: expected %v, got %v", id, expected, err)
+		}
+	}
+	if _, err := s.Create(ctx, "u2", "o1", books); err != order.ErrOrderNotFound {
+		t.Errorf("order of another user: expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := s.Create(ctx, "u1", "o1", books); err == nil {
+		t.Fatal("expected the failed purchase to fail the return")
+	}
+	f, err := s.Label(ctx, "u1", "r1")
+	if err != nil || string(f.Data) != "%PDF" {
+		t.Fatalf("expected the label bought on download, got %q, %v", f.Data, err)
+	}
+	if len(p.requests) != 2 || p.requests[1].Items != 2 || p.requests[1].From.City != "Berlin" {
+		t.Errorf("expected the purchase retried from the shipping address, got %+v", p.requests)
+	}
+	if rt, _ := s.Return(ctx, "u1", "r1"); rt.Status != returns.StatusLabelled || rt.Amount != 25 || rt.TrackingNumber != "T1" {
+		t.Errorf("expected the return labelled, got %+v", rt)
+	}
+	if _, err := s.Create(ctx, "u1", "o1", returns.NewReturn{Lines: []returns.NewLine{{BookID: "b1", Quantity: 1}}, Reason: "x"}); err != returns.ErrTooMany {
+		t.Errorf("returned books: expected ErrTooMany, got %v", err)
+	}
+
+	delivered := []byte(`{"tracking_number":"T1","status":"delivered"}`)
+	if err := s.Webhook(ctx, "bad", delivered); err != returns.ErrInvalidSignature {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+	if err := s.Webhook(ctx, sign(delivered), delivered); err == nil {
+		t.Fatal("expected the failed refund to fail the webhook")
+	}
+	if rt, _ := s.Return(ctx, "u1", "r1"); rt.Status != returns.StatusDelivered || rt.DeliveredAt == nil {
+		t.Errorf("expected the return delivered, got %+v", rt)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Webhook(ctx, sign(delivered), delivered); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rt, _ := s.Return(ctx, "u1", "r1")
+	if rt.Status != returns.StatusRefunded || rt.RefundRef != "rf1" || len(pay.refunds) != 2 || pay.refunds[1] != 25 {
+		t.Errorf("expected the return refunded once, got %+v, %v", rt, pay.refunds)
+	}
+	if len(cn.notes) != 1 || cn.notes[0].ReasonCode != archive.ReasonReturned || cn.notes[0].Amount != 25 {
+		t.Errorf("expected one credit note of the return, got %+v", cn.notes)
+	}
+}
+
+// ledger keeps the credit transactions in memory.
+type ledger struct {
+	credit.Repo
+	ts []credit.Transaction
+}
+
+func (l *ledger) Create(t *credit.Transaction) error {
+	t.ID = "t" + string(rune('1'+len(l.ts)))
+	l.ts = append(l.ts, *t)
+	return nil
+}
+
+func (l *ledger) Get(ID string) (credit.Transaction, error) {
+	for _, t := range l.ts {
+		if t.ID == ID {
+			return t, nil
+		}
+	}
+	return credit.Transaction{}, db.ErrNotFound
+}
+
+func TestReturnPaidWithCredit(t *testing.T) {
+	ctx := context.Background()
+	shipped := time.Now().AddDate(0, 0, -10)
+	lines := []order.Line{{BookID: "b1", Quantity: 2, Price: 12.5}}
+	l := &ledger{}
+	l.Create(&credit.Transaction{UserID: "u1", Kind: credit.KindPayment, Amount: -25, Currency: "EUR", OrderID: "o1"})
+	orders := orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", ShippedAt: &shipped, Lines: lines, Currency: "EUR", PaymentRef: credit.PaymentPrefix + "t1"},
+	}}
+	r, p, pay, cn := &repo{}, &provider{}, &payments{}, &credits{}
+	s := returns.NewService(r, orders, addressRepo{}, p, credit.NewPayments(l, pay, credit.Config{}), cn, returns.Config{})
+
+	books := returns.NewReturn{Lines: []returns.NewLine{{BookID: "b1", Quantity: 1}}, Reason: "damaged cover"}
+	s.Create(ctx, "u1", "o1", books)
+	if _, err := s.Label(ctx, "u1", "r1"); err != nil {
+		t.Fatal(err)
+	}
+	delivered := []byte(`{"tracking_number":"T1","status":"delivered"}`)
+	if err := s.Webhook(ctx, sign(delivered), delivered); err != nil {
+		t.Fatal(err)
+	}
+	rt, _ := s.Return(ctx, "u1", "r1")
+	if rt.Status != returns.StatusRefunded || rt.RefundRef != credit.PaymentPrefix+"t2" || len(pay.refunds) != 0 {
+		t.Errorf("expected the return refunded as credit, got %+v, %v", rt, pay.refunds)
+	}
+	if len(l.ts) != 2 || l.ts[1].UserID != "u1" || l.ts[1].Kind != credit.KindRefund || l.ts[1].Remaining != 12.5 || l.ts[1].OrderID != "o1" {
+		t.Errorf("expected 12.5 credited back to u1, got %+v", l.ts)
+	}
+	if len(cn.notes) != 1 || cn.notes[0].Amount != 12.5 {
+		t.Errorf("expected the credit note of the return, got %+v", cn.notes)
+	}
+}
@@ -0,0 +1,284 @@
+package returns
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrReturnNotFound   = errors.New("return not found")
+	ErrNotShipped       = errors.New("order is not shipped yet")
+	ErrWindowClosed     = errors.New("return window of the order is closed")
+	ErrNoLines          = errors.New("order has no lines to return")
+	ErrNotInOrder       = errors.New("book is not in the order")
+	ErrTooMany          = errors.New("more copies returned than ordered")
+	ErrNoLabel          = errors.New("return label is not purchased yet")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// IssuedBy is the issuer of the credit notes of the returns.
+const IssuedBy = "returns"
+
+// CreditNotes issues the credit notes of the refunds, e.g. archive.Service.
+type CreditNotes interface {
+	CreditNote(ctx context.Context, orderID string, n archive.NewCreditNote) (archive.Document, error)
+}
+
+// Config controls the returns.
+type Config struct {
+	// WindowDays is how long after its shipping an order can be returned,
+	// DefaultWindowDays if 0.
+	WindowDays int
+}
+
+type Service interface {
+	// Create starts the return of books of a shipped order of the user,
+	// within the return window, and buys its prepaid label. A failed
+	// purchase leaves the return pending, the label being bought on its
+	// first download.
+	Create(ctx context.Context, userID, orderID string, n NewReturn) (Return, error)
+
+	// Returns returns the returns of the user.
+	Returns(ctx context.Context, userID string) ([]Return, error)
+
+	// Return returns a return of the user.
+	Return(ctx context.Context, userID, ID string) (Return, error)
+
+	// Label returns the PDF prepaid label of a return of the user, buying
+	// it if pending.
+	Label(ctx context.Context, userID, ID string) (File, error)
+
+	// Webhook applies the tracking update of a parcel pushed by the
+	// provider, refunding the return once delivered. A failed refund is
+	// retried with the next delivered update.
+	Webhook(ctx context.Context, signature string, body []byte) error
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	addresses address.Repo
+	provider  Provider
+	payments  order.Payments
+	credits   CreditNotes
+	config    Config
+}
+
+// NewService return basic Service implementation. The returns are refunded
+// on the payments of their orders, with a credit note.
+func NewService(r Repo, orders order.Repo, addresses address.Repo, provider Provider, payments order.Payments, credits CreditNotes, config Config) Service {
+	if config.WindowDays == 0 {
+		config.WindowDays = DefaultWindowDays
+	}
+	return basicService{r: r, orders: orders, addresses: addresses, provider: provider, payments: payments, credits: credits, config: config}
+}
+
+func (s basicService) Create(ctx context.Context, userID, orderID string, n NewReturn) (Return, error) {
+	n.Reason = strings.TrimSpace(n.Reason)
+	if err := n.Validate(); err != nil {
+		return Return{}, err
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return Return{}, order.ErrOrderNotFound
+	}
+	now := time.Now().UTC()
+	switch {
+	case o.ShippedAt == nil:
+		return Return{}, ErrNotShipped
+	case now.After(o.ShippedAt.AddDate(0, 0, s.config.WindowDays)):
+		return Return{}, ErrWindowClosed
+	case len(o.Lines) == 0:
+		// Orders placed before the lines were kept.
+		return Return{}, ErrNoLines
+	}
+
+	previous, err := s.r.ListByOrder(orderID)
+	if err != nil {
+		return Return{}, err
+	}
+	returned := make(map[string]int)
+	for _, p := range previous {
+		for _, l := range p.Lines {
+			returned[l.BookID] += l.Quantity
+		}
+	}
+	rt := Return{
+		OrderID:   o.ID,
+		UserID:    userID,
+		Status:    StatusPending,
+		Reason:    n.Reason,
+		Currency:  o.Currency,
+		Provider:  s.provider.Name(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	var total int64
+	for _, nl := range n.Lines {
+		var ordered *order.Line
+		for i := range o.Lines {
+			if o.Lines[i].BookID == nl.BookID {
+				ordered = &o.Lines[i]
+				break
+			}
+		}
+		if ordered == nil {
+			return Return{}, ErrNotInOrder
+		}
+		if returned[nl.BookID]+nl.Quantity > ordered.Quantity {
+			return Return{}, ErrTooMany
+		}
+		rt.Lines = append(rt.Lines, Line{BookID: nl.BookID, Quantity: nl.Quantity, Price: ordered.Price})
+		total += cents(ordered.Price) * int64(nl.Quantity)
+	}
+	rt.Amount = float64(total) / 100
+
+	if err := s.r.Create(&rt); err != nil {
+		return Return{}, err
+	}
+	if err := s.label(ctx, &rt); err != nil {
+		return Return{}, err
+	}
+	return rt, nil
+}
+
+// label buys the label of a pending return from the address the order was
+// shipped to.
+func (s basicService) label(ctx context.Context, rt *Return) error {
+	c, err := s.addresses.GetCheck(rt.OrderID)
+	if err == db.ErrNotFound {
+		return address.ErrCheckNotFound
+	}
+	if err != nil {
+		return err
+	}
+	items := 0
+	for _, l := range rt.Lines {
+		items += l.Quantity
+	}
+	l, err := s.provider.BuyReturn(ctx, LabelRequest{ReturnID: rt.ID, OrderID: rt.OrderID, From: c.Address, Items: items})
+	if err != nil {
+		return errors.Wrap(err, "return label")
+	}
+	rt.Status = StatusLabelled
+	rt.Reference, rt.Carrier, rt.TrackingNumber = l.Reference, l.Carrier, l.TrackingNumber
+	rt.Postage, rt.Label = math.Round(l.Postage*100)/100, l.PDF
+	rt.UpdatedAt = time.Now().UTC()
+	return s.r.Save(rt)
+}
+
+func (s basicService) Returns(ctx context.Context, userID string) ([]Return, error) {
+	return s.r.ListByUser(userID)
+}
+
+func (s basicService) Return(ctx context.Context, userID, ID string) (Return, error) {
+	rt, err := s.r.Get(ID)
+	if err == db.ErrNotFound || (err == nil && rt.UserID != userID) {
+		return Return{}, ErrReturnNotFound
+	}
+	return rt, err
+}
+
+func (s basicService) Label(ctx context.Context, userID, ID string) (File, error) {
+	rt, err := s.Return(ctx, userID, ID)
+	if err != nil {
+		return File{}, err
+	}
+	if rt.Status == StatusPending {
+		if err := s.label(ctx, &rt); err != nil {
+			return File{}, err
+		}
+	}
+	if len(rt.Label) == 0 {
+		return File{}, ErrNoLabel
+	}
+	return File{Name: "return-label-" + rt.ID + ".pdf", ContentType: "application/pdf", Data: rt.Label}, nil
+}
+
+func (s basicService) Webhook(ctx context.Context, signature string, body []byte) error {
+	if !s.provider.VerifyWebhook(signature, body) {
+		return ErrInvalidSignature
+	}
+	var u Update
+	if err := json.Unmarshal(body, &u); err != nil {
+		return err
+	}
+	rt, err := s.r.GetByTracking(s.provider.Name(), u.TrackingNumber)
+	if err == db.ErrNotFound {
+		return ErrReturnNotFound
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	switch u.Status {
+	case StatusInTransit:
+		if rt.Status != StatusLabelled {
+			return nil
+		}
+		rt.Status, rt.UpdatedAt = StatusInTransit, now
+		return s.r.Save(&rt)
+	case StatusDelivered:
+		if rt.Status == StatusRefunded {
+			return nil
+		}
+		if rt.Status != StatusDelivered {
+			rt.Status, rt.DeliveredAt, rt.UpdatedAt = StatusDelivered, &now, now
+			if err := s.r.Save(&rt); err != nil {
+				return err
+			}
+		}
+		return s.refund(ctx, &rt)
+	default:
+		// The other carrier statuses don't change the return.
+		return nil
+	}
+}
+
+// refund refunds a delivered return on the payment of its order, then
+// issues its credit note. The payment goes first and is recorded, a retry
+// only issuing the credit note.
+func (s basicService) refund(ctx context.Context, rt *Return) error {
+	if rt.RefundRef == "" {
+		o, err := s.orders.GetByID(rt.OrderID)
+		if err != nil {
+			return order.ErrOrderNotFound
+		}
+		ref, err := s.payments.Refund(ctx, o.PaymentRef, rt.Amount, rt.Currency)
+		if err != nil {
+			return errors.Wrap(err, "refund")
+		}
+		rt.RefundRef, rt.UpdatedAt = ref, time.Now().UTC()
+		if err := s.r.Save(rt); err != nil {
+			return errors.Wrapf(err, "return %s not saved after refund %s", rt.ID, ref)
+		}
+	}
+	_, err := s.credits.CreditNote(ctx, rt.OrderID, archive.NewCreditNote{
+		Amount:     rt.Amount,
+		ReasonCode: archive.ReasonReturned,
+		Reason:     "Return " + rt.ID,
+		IssuedBy:   IssuedBy,
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	rt.Status, rt.RefundedAt, rt.UpdatedAt = StatusRefunded, &now, now
+	return s.r.Save(rt)
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,207 @@
+package returns
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrReturnNotFound: "returns.return_not_found",
+		ErrNotShipped:     "returns.not_shipped",
+		ErrWindowClosed:   "returns.window_closed",
+		ErrNoLines:        "returns.no_lines",
+		ErrNotInOrder:     "returns.not_in_order",
+		ErrTooMany:        "returns.too_many",
+		ErrNoLabel:        "returns.no_label",
+	})
+}
+
+// maxWebhookSize limits the webhook body read into memory.
+const maxWebhookSize = 1 << 20
+
+func MakeHTTPHandler(ctx context.Context, s Service, auth Authenticator, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, auth)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	createHandler := httptransport.NewServer(
+		e.CreateEndpoint,
+		decodeCreateRequest,
+		encodeResponse,
+		options...,
+	)
+	returnsHandler := httptransport.NewServer(
+		e.ReturnsEndpoint,
+		decodeReturnRequest,
+		encodeResponse,
+		options...,
+	)
+	returnHandler := httptransport.NewServer(
+		e.ReturnEndpoint,
+		decodeReturnRequest,
+		encodeResponse,
+		options...,
+	)
+	labelHandler := httptransport.NewServer(
+		e.LabelEndpoint,
+		decodeReturnRequest,
+		encodeFile,
+		options...,
+	)
+	webhookHandler := httptransport.NewServer(
+		e.WebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Customer endpoints
+	r.Handle("/returns/v1/orders/{order-id}", createHandler).Methods("POST")
+	r.Handle("/returns/v1/returns", returnsHandler).Methods("GET")
+	r.Handle("/returns/v1/returns/{return-id}", returnHandler).Methods("GET")
+	r.Handle("/returns/v1/returns/{return-id}/label", labelHandler).Methods("GET")
+
+	// Shipping provider endpoints
+	r.Handle("/returns/v1/webhook", webhookHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func tokenFrom(req *http.Request) string {
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+func decodeCreateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.Token, r.OrderID = tokenFrom(req), orderID
+	return r, nil
+}
+
+// decodeReturnRequest leaves the return ID empty on the list route.
+func decodeReturnRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return returnRequest{Token: tokenFrom(req), ReturnID: mux.Vars(req)["return-id"]}, nil
+}
+
+func decodeWebhookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxWebhookSize))
+	if err != nil {
+		return nil, err
+	}
+	return webhookRequest{
+		Signature: req.Header.Get("X-Signature"),
+		Body:      body,
+	}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+// encodeFile writes the label inline, with its name for saving.
+func encodeFile(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(fileResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", res.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `inline; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case user.ErrUnauthorized, ErrInvalidSignature:
+		return http.StatusUnauthorized
+	case order.ErrOrderNotFound, ErrReturnNotFound, address.ErrCheckNotFound:
+		return http.StatusNotFound
+	case ErrNotShipped, ErrWindowClosed, ErrNoLines, ErrTooMany, ErrNoLabel:
+		return http.StatusConflict
+	case ErrBadRouting, ErrNotInOrder, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
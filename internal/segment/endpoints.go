@@ -0,0 +1,137 @@
+package segment
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// Endpoints combine all the segment service endpoints under single type.
+type Endpoints struct {
+	CreateEndpoint   endpoint.Endpoint
+	ListEndpoint     endpoint.Endpoint
+	DeleteEndpoint   endpoint.Endpoint
+	MembersEndpoint  endpoint.Endpoint
+	EvaluateEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the segment service endpoints, restricted by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		CreateEndpoint:   admin(MakeCreateEndpoint(s)),
+		ListEndpoint:     admin(MakeListEndpoint(s)),
+		DeleteEndpoint:   admin(MakeDeleteEndpoint(s)),
+		MembersEndpoint:  admin(MakeMembersEndpoint(s)),
+		EvaluateEndpoint: admin(MakeEvaluateEndpoint(s)),
+	}
+}
+
+func MakeCreateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createRequest)
+		seg, e := s.Create(ctx, req.Segment)
+		if e != nil {
+			return segmentResponse{Segment: nil, Error: e}, nil
+		}
+		return segmentResponse{Segment: &seg, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		segments, e := s.List(ctx)
+		if e != nil {
+			return listResponse{Segments: make([]Segment, 0), Error: e}, nil
+		}
+		return listResponse{Segments: segments}, nil
+	}
+}
+
+func MakeDeleteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(segmentRequest)
+		e := s.Delete(ctx, req.SegmentID)
+		return deleteResponse{Error: e}, nil
+	}
+}
+
+func MakeMembersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(segmentRequest)
+		users, e := s.Members(ctx, req.SegmentID)
+		if e != nil {
+			return membersResponse{Users: make([]user.User, 0), Error: e}, nil
+		}
+		return membersResponse{Users: users}, nil
+	}
+}
+
+func MakeEvaluateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ev, e := s.Evaluate(ctx)
+		if e != nil {
+			return evaluateResponse{Error: e}, nil
+		}
+		return evaluateResponse{Evaluation: &ev}, nil
+	}
+}
+
+type createRequest struct {
+	Segment
+}
+
+type segmentRequest struct {
+	SegmentID string
+}
+
+type segmentResponse struct {
+	Status  int      `json:"-"`
+	Segment *Segment `json:"segment,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r segmentResponse) status() int {
+	return r.Status
+}
+
+func (r segmentResponse) error() error {
+	return r.Error
+}
+
+type listResponse struct {
+	Segments []Segment `json:"segments"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+type deleteResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r deleteResponse) error() error {
+	return r.Error
+}
+
+type membersResponse struct {
+	Users []user.User `json:"users"`
+	Error error       `json:"error,omitempty"`
+}
+
+func (r membersResponse) error() error {
+	return r.Error
+}
+
+type evaluateResponse struct {
+	Evaluation *Evaluation `json:"evaluation,omitempty"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r evaluateResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,25 @@
+package segment
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunEvaluator evaluates the segments every interval, nightly by default,
+// until ctx is done.
+func RunEvaluator(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Evaluate(ctx); err != nil {
+				logger.Log("evaluator", "segment", "err", err)
+			}
+		}
+	}
+}
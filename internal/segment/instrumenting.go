@@ -0,0 +1,82 @@
+package segment
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/user"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Create(ctx context.Context, seg Segment) (created Segment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	created, err = mw.next.Create(ctx, seg)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context) (segments []Segment, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	segments, err = mw.next.List(ctx)
+	return
+}
+
+func (mw instrmw) Delete(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Delete(ctx, id)
+	return
+}
+
+func (mw instrmw) Members(ctx context.Context, id string) (users []user.User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "members", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	users, err = mw.next.Members(ctx, id)
+	return
+}
+
+func (mw instrmw) Evaluate(ctx context.Context) (ev Evaluation, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "evaluate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	ev, err = mw.next.Evaluate(ctx)
+	return
+}
@@ -0,0 +1,84 @@
+package segment
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/user"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Create(ctx context.Context, seg Segment) (created Segment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create",
+			"name", seg.Name,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Create(ctx, seg)
+}
+
+func (s loggingService) List(ctx context.Context) (segments []Segment, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx)
+}
+
+func (s loggingService) Delete(ctx context.Context, id string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete",
+			"segment_id", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Delete(ctx, id)
+}
+
+func (s loggingService) Members(ctx context.Context, id string) (users []user.User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "members",
+			"segment_id", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Members(ctx, id)
+}
+
+func (s loggingService) Evaluate(ctx context.Context) (ev Evaluation, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "evaluate",
+			"users", ev.Users,
+			"changed", ev.Changed,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Evaluate(ctx)
+}
@@ -0,0 +1,24 @@
+package segment
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+)
+
+// Repo abstracts all the persistant storage operations of Segment Service
+type Repo interface {
+	// Create stores s along with its rules.
+	Create(s *Segment) error
+	GetByID(id string) (Segment, error)
+	GetByName(name string) (Segment, error)
+	// List returns the segments with their rules, by name.
+	List() ([]Segment, error)
+	Delete(id string) error
+	// SetEvaluated records the members of the segment at an evaluation.
+	SetEvaluated(id string, members int, at time.Time) error
+	// ListOrders returns the orders of the user with their items and the
+	// genres of the items.
+	ListOrders(userID string) ([]order.Order, error)
+	Drop() error
+}
@@ -0,0 +1,167 @@
+package segment
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Fields a rule matches the customers on.
+const (
+	FieldOrders    = "orders"          // number of orders
+	FieldSpent     = "spent"           // total of the orders
+	FieldLastOrder = "last_order_days" // days since the last order
+	FieldSignup    = "signup_days"     // days since the signup
+	FieldGenre     = "genre"           // genre of a book bought
+)
+
+// Operators of a rule. The numeric fields compare with gte and lte, a genre
+// is bought (eq) or not (ne).
+const (
+	OpGTE = "gte"
+	OpLTE = "lte"
+	OpEq  = "eq"
+	OpNe  = "ne"
+)
+
+// Segment is a group of customers matching all its rules, e.g. the
+// customers with 3 orders or more who bought science fiction. The members
+// are evaluated nightly and recorded on the users, see user.User.Segments.
+type Segment struct {
+	ID          string `json:"id"`
+	Name        string `json:"name" sql:"unique_index"`
+	Description string `json:"description,omitempty"`
+	Rules       []Rule `json:"rules" sql:"-"`
+	// Members is the number of members at EvaluatedAt.
+	Members     int        `json:"members"`
+	EvaluatedAt *time.Time `json:"evaluated_at,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (Segment) TableName() string {
+	return "segments"
+}
+
+// Rule is a condition on a field of the purchase history or the account of
+// a customer.
+type Rule struct {
+	ID        string `json:"-"`
+	SegmentID string `json:"-" sql:"index"`
+	Field     string `json:"field"`
+	Op        string `json:"op"`
+	Value     string `json:"value"`
+}
+
+func (Rule) TableName() string {
+	return "segment_rules"
+}
+
+// nameRe keeps the names apart in user.User.SegmentString.
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Validate checks the segment has a valid name and rules.
+func (s *Segment) Validate() error {
+	var v validate.Validator
+	if v.Required("name", s.Name) {
+		v.Check(nameRe.MatchString(s.Name), "name", validate.CodeInvalid, "name must be lower case letters, digits or dashes")
+	}
+	v.Required("created_by", s.CreatedBy)
+	v.Check(len(s.Rules) > 0, "rules", validate.CodeRequired, "rules are required")
+	for _, r := range s.Rules {
+		switch r.Field {
+		case FieldOrders, FieldSpent, FieldLastOrder, FieldSignup:
+			v.Check(r.Op == OpGTE || r.Op == OpLTE, "rules.op", validate.CodeInvalid, "op must be gte or lte")
+			n, err := strconv.ParseFloat(r.Value, 64)
+			v.Check(err == nil && n >= 0, "rules.value", validate.CodeInvalid, "value must be a positive number")
+		case FieldGenre:
+			v.Check(r.Op == OpEq || r.Op == OpNe, "rules.op", validate.CodeInvalid, "op must be eq or ne")
+			v.Required("rules.value", r.Value)
+		default:
+			v.Check(false, "rules.field", validate.CodeUnknown, "unknown field")
+		}
+	}
+	return v.Err()
+}
+
+// Profile is what the rules know of a customer.
+type Profile struct {
+	UserID    string
+	SignupAt  time.Time
+	Orders    int
+	Spent     float64
+	LastOrder time.Time
+	// Genres are the IDs of the genres of the books bought.
+	Genres map[string]bool
+}
+
+// NewProfile sums up the orders of u, with their items.
+func NewProfile(u user.User, orders []order.Order) Profile {
+	p := Profile{UserID: u.ID, SignupAt: u.CreatedAt, Genres: make(map[string]bool)}
+	for _, o := range orders {
+		p.Orders++
+		p.Spent += o.TotalPrice
+		if o.CreatedAt.After(p.LastOrder) {
+			p.LastOrder = o.CreatedAt
+		}
+		for _, b := range o.Items {
+			for _, g := range b.Genres {
+				p.Genres[g.ID] = true
+			}
+		}
+	}
+	return p
+}
+
+// Match tells whether p matches all the rules of s at now.
+func (s Segment) Match(p Profile, now time.Time) bool {
+	for _, r := range s.Rules {
+		if !r.Match(p, now) {
+			return false
+		}
+	}
+	return len(s.Rules) > 0
+}
+
+// Match tells whether p matches r at now. A customer without orders doesn't
+// match the rules on the last order.
+func (r Rule) Match(p Profile, now time.Time) bool {
+	var n float64
+	switch r.Field {
+	case FieldOrders:
+		n = float64(p.Orders)
+	case FieldSpent:
+		n = p.Spent
+	case FieldLastOrder:
+		if p.Orders == 0 {
+			return false
+		}
+		n = now.Sub(p.LastOrder).Hours() / 24
+	case FieldSignup:
+		n = now.Sub(p.SignupAt).Hours() / 24
+	case FieldGenre:
+		return p.Genres[r.Value] == (r.Op == OpEq)
+	default:
+		return false
+	}
+	v, err := strconv.ParseFloat(r.Value, 64)
+	if err != nil {
+		return false
+	}
+	if r.Op == OpGTE {
+		return n >= v
+	}
+	return r.Op == OpLTE && n <= v
+}
+
+// Evaluation is the result of evaluating the segments of all the users.
+type Evaluation struct {
+	Segments int       `json:"segments"`
+	Users    int       `json:"users"`
+	Changed  int       `json:"changed"` // users whose segments changed
+	At       time.Time `json:"at"`
+}
@@ -0,0 +1,152 @@
+package segment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/segment"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// segmentRepo keeps the segments and the orders in memory, the other
+// methods of the Repo aren't used.
+type segmentRepo struct {
+	segment.Repo
+	segments []segment.Segment
+	orders   map[string][]order.Order
+}
+
+func (r *segmentRepo) List() ([]segment.Segment, error) {
+	return r.segments, nil
+}
+
+func (r *segmentRepo) SetEvaluated(id string, members int, at time.Time) error {
+	for i := range r.segments {
+		if r.segments[i].ID == id {
+			r.segments[i].Members, r.segments[i].EvaluatedAt = members, &at
+			return nil
+		}
+	}
+	return db.ErrNotFound
+}
+
+func (r *segmentRepo) ListOrders(userID string) ([]order.Order, error) {
+	return r.orders[userID], nil
+}
+
+// userRepo keeps the users in memory, counting the segment updates.
+type userRepo struct {
+	user.Repo
+	updates int
+}
+
+func newUserRepo(users ...user.User) *userRepo {
+	r := &userRepo{Repo: inmem.NewUserRepo()}
+	for _, u := range users {
+		r.Create(&u)
+	}
+	return r
+}
+
+func (r *userRepo) SetSegments(userID string, segments []string) error {
+	r.updates++
+	return r.Repo.SetSegments(userID, segments)
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now().UTC()
+	scifi := catalog.Book{ID: "b1", Genres: []catalog.Genre{{ID: "scifi"}}}
+	segments := &segmentRepo{
+		segments: []segment.Segment{
+			{ID: "s1", Name: "loyal-scifi", Rules: []segment.Rule{
+				{Field: segment.FieldOrders, Op: segment.OpGTE, Value: "2"},
+				{Field: segment.FieldGenre, Op: segment.OpEq, Value: "scifi"},
+			}},
+			{ID: "s2", Name: "lapsed", Rules: []segment.Rule{
+				{Field: segment.FieldLastOrder, Op: segment.OpGTE, Value: "90"},
+			}},
+			{ID: "s3", Name: "new", Rules: []segment.Rule{
+				{Field: segment.FieldSignup, Op: segment.OpLTE, Value: "30"},
+			}},
+		},
+		orders: map[string][]order.Order{
+			"u1": {
+				{TotalPrice: 10, CreatedAt: now.AddDate(0, 0, -200), Items: []catalog.Book{scifi}},
+				{TotalPrice: 20, CreatedAt: now.AddDate(0, 0, -100)},
+			},
+			"u2": {{TotalPrice: 5, CreatedAt: now.AddDate(0, 0, -1)}},
+			"u3": {{TotalPrice: 5, CreatedAt: now.AddDate(0, 0, -100)}},
+		},
+	}
+	users := newUserRepo(
+		user.User{ID: "u1", CreatedAt: now.AddDate(-1, 0, 0)},
+		user.User{ID: "u2", CreatedAt: now.AddDate(0, 0, -3), SegmentString: "lapsed"},
+		user.User{ID: "u3", CreatedAt: now.AddDate(-1, 0, 0), Deactivated: true, SegmentString: "lapsed"},
+		user.User{ID: "u4", CreatedAt: now.AddDate(-1, 0, 0)},
+	)
+	s := segment.NewService(segments, users)
+
+	ev, err := s.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("evaluate: unexpected error %v", err)
+	}
+	for id, want := range map[string]string{"u1": "loyal-scifi,lapsed", "u2": "new", "u3": "", "u4": ""} {
+		if u, _ := users.GetByID(id); u.SegmentString != want {
+			t.Errorf("%s: expected segments %q, got %q", id, want, u.SegmentString)
+		}
+	}
+	if ev.Users != 4 || ev.Changed != 3 || users.updates != 3 {
+		t.Errorf("evaluate: expected 3 of 4 users changed, got %+v and %d updates", ev, users.updates)
+	}
+	for i, want := range []int{1, 1, 1} {
+		if s := segments.segments[i]; s.Members != want || s.EvaluatedAt == nil {
+			t.Errorf("%s: expected %d members, got %d", s.Name, want, s.Members)
+		}
+	}
+	if u, _ := users.GetByID("u1"); !u.InSegment("lapsed") || u.InSegment("new") {
+		t.Errorf("u1: unexpected segments %v", u.Segments())
+	}
+
+	if _, err := s.Evaluate(context.Background()); err != nil || users.updates != 3 {
+		t.Errorf("again: expected no update, got %d updates, %v", users.updates, err)
+	}
+}
+
+func TestListRequiresAdmin(t *testing.T) {
+	s := segment.NewService(&segmentRepo{}, newUserRepo())
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := segment.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/segments/v1/admin/list", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
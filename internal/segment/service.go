@@ -0,0 +1,133 @@
+package segment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrSegmentNotFound = errors.New("segment not found")
+	ErrNameTaken       = errors.New("segment name already taken")
+)
+
+type Service interface {
+	// Create stores a segment, its members are known from the next
+	// evaluation.
+	Create(ctx context.Context, s Segment) (Segment, error)
+
+	// List returns the segments with their rules.
+	List(ctx context.Context) ([]Segment, error)
+
+	// Delete removes a segment, its members leave it at the next evaluation.
+	Delete(ctx context.Context, id string) error
+
+	// Members returns the users in the segment at its last evaluation.
+	Members(ctx context.Context, id string) ([]user.User, error)
+
+	// Evaluate matches every user against the segments, recording the
+	// segments of each on the user.
+	Evaluate(ctx context.Context) (Evaluation, error)
+}
+
+type basicService struct {
+	r     Repo
+	users user.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, users user.Repo) Service {
+	return basicService{r: r, users: users}
+}
+
+func (s basicService) Create(ctx context.Context, seg Segment) (Segment, error) {
+	if err := seg.Validate(); err != nil {
+		return Segment{}, err
+	}
+	if _, err := s.r.GetByName(seg.Name); err == nil {
+		return Segment{}, ErrNameTaken
+	}
+	seg.ID = ""
+	seg.Members, seg.EvaluatedAt = 0, nil
+	seg.CreatedAt = time.Now().UTC()
+	for i := range seg.Rules {
+		seg.Rules[i].ID = ""
+	}
+	if err := s.r.Create(&seg); err != nil {
+		return Segment{}, err
+	}
+	return seg, nil
+}
+
+func (s basicService) List(ctx context.Context) ([]Segment, error) {
+	return s.r.List()
+}
+
+func (s basicService) Delete(ctx context.Context, id string) error {
+	if _, err := s.r.GetByID(id); err != nil {
+		return ErrSegmentNotFound
+	}
+	return s.r.Delete(id)
+}
+
+func (s basicService) Members(ctx context.Context, id string) ([]user.User, error) {
+	seg, err := s.r.GetByID(id)
+	if err != nil {
+		return nil, ErrSegmentNotFound
+	}
+	return s.users.ListByFilter(user.Filter{Segment: seg.Name})
+}
+
+// Evaluate only writes the users whose segments changed. The deactivated
+// users leave all the segments.
+func (s basicService) Evaluate(ctx context.Context) (Evaluation, error) {
+	now := time.Now().UTC()
+	segments, err := s.r.List()
+	if err != nil {
+		return Evaluation{}, err
+	}
+	users, err := s.users.ListByFilter(user.Filter{})
+	if err != nil {
+		return Evaluation{}, err
+	}
+	ev := Evaluation{Segments: len(segments), Users: len(users), At: now}
+	members := make([]int, len(segments))
+	for _, u := range users {
+		if err := ctx.Err(); err != nil {
+			return ev, err
+		}
+		var names []string
+		if !u.Deactivated && len(segments) > 0 {
+			orders, err := s.r.ListOrders(u.ID)
+			if err != nil {
+				return ev, err
+			}
+			p := NewProfile(u, orders)
+			for i, seg := range segments {
+				if seg.Match(p, now) {
+					names = append(names, seg.Name)
+					members[i]++
+				}
+			}
+		}
+		if strings.Join(names, ",") == u.SegmentString {
+			continue
+		}
+		if err := s.users.SetSegments(u.ID, names); err != nil {
+			return ev, err
+		}
+		ev.Changed++
+	}
+	for i, seg := range segments {
+		if err := s.r.SetEvaluated(seg.ID, members[i], now); err != nil {
+			return ev, err
+		}
+	}
+	return ev, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,206 @@
+package shelf
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the shelf service endpoints under single type.
+type Endpoints struct {
+	EntriesEndpoint     endpoint.Endpoint
+	SaveEntryEndpoint   endpoint.Endpoint
+	StartImportEndpoint endpoint.Endpoint
+	ImportsEndpoint     endpoint.Endpoint
+	ImportEndpoint      endpoint.Endpoint
+	ResolveEndpoint     endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the shelf service endpoints, restricted by account, e.g. to the
+// unscoped tokens of the users.
+func MakeEndpoints(s Service, account endpoint.Middleware) Endpoints {
+	return Endpoints{
+		EntriesEndpoint:     account(MakeEntriesEndpoint(s)),
+		SaveEntryEndpoint:   account(MakeSaveEntryEndpoint(s)),
+		StartImportEndpoint: account(MakeStartImportEndpoint(s)),
+		ImportsEndpoint:     account(MakeImportsEndpoint(s)),
+		ImportEndpoint:      account(MakeImportEndpoint(s)),
+		ResolveEndpoint:     account(MakeResolveEndpoint(s)),
+	}
+}
+
+// MakeEntriesEndpoint lists the shelf of the user of the request.
+func MakeEntriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entriesRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		entries, e := s.Entries(ctx, userID, req.Shelf)
+		if e != nil {
+			return entriesResponse{Entries: make([]Entry, 0), Error: e}, nil
+		}
+		return entriesResponse{Entries: entries}, nil
+	}
+}
+
+// MakeSaveEntryEndpoint shelves a book for the user of the request.
+func MakeSaveEntryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(saveEntryRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		entry := Entry{
+			UserID: userID,
+			BookID: req.BookID,
+			Shelf:  req.Shelf,
+			Rating: req.Rating,
+			ReadAt: req.ReadAt,
+		}
+		entry.AddShelves(req.Shelves)
+		entry, e := s.SaveEntry(ctx, entry)
+		if e != nil {
+			return entryResponse{Entry: nil, Error: e}, nil
+		}
+		return entryResponse{Entry: &entry}, nil
+	}
+}
+
+// MakeStartImportEndpoint imports an export into the shelf of the user
+// of the request.
+func MakeStartImportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(startImportRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		i, e := s.StartImport(ctx, userID, req.Data)
+		if e != nil {
+			return importResponse{Import: nil, Error: e}, nil
+		}
+		return importResponse{Import: &i, Status: http.StatusAccepted}, nil
+	}
+}
+
+// MakeImportsEndpoint lists the imports of the user of the request.
+func MakeImportsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		imports, e := s.Imports(ctx, userID)
+		if e != nil {
+			return importsResponse{Imports: make([]Import, 0), Error: e}, nil
+		}
+		return importsResponse{Imports: imports}, nil
+	}
+}
+
+// MakeImportEndpoint returns an import of the user of the request.
+func MakeImportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		i, e := s.Import(ctx, userID, req.ImportID)
+		if e != nil {
+			return importResponse{Import: nil, Error: e}, nil
+		}
+		return importResponse{Import: &i}, nil
+	}
+}
+
+// MakeResolveEndpoint resolves a row of an import of the user of the
+// request.
+func MakeResolveEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resolveRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		i, e := s.Resolve(ctx, userID, req.ImportID, req.RowID, req.BookID)
+		if e != nil {
+			return importResponse{Import: nil, Error: e}, nil
+		}
+		return importResponse{Import: &i}, nil
+	}
+}
+
+type entriesRequest struct {
+	Shelf string
+}
+
+type entriesResponse struct {
+	Entries []Entry `json:"entries"`
+	Error   error   `json:"error,omitempty"`
+}
+
+func (r entriesResponse) error() error {
+	return r.Error
+}
+
+type saveEntryRequest struct {
+	BookID  string     `json:"-"`
+	Shelf   string     `json:"shelf"`
+	Shelves []string   `json:"shelves"`
+	Rating  int        `json:"rating"`
+	ReadAt  *time.Time `json:"read_at"`
+}
+
+type entryResponse struct {
+	Entry *Entry `json:"entry,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r entryResponse) error() error {
+	return r.Error
+}
+
+type startImportRequest struct {
+	Data []byte
+}
+
+type importRequest struct {
+	ImportID string
+}
+
+type importResponse struct {
+	Status int     `json:"-"`
+	Import *Import `json:"import,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r importResponse) status() int {
+	return r.Status
+}
+
+func (r importResponse) error() error {
+	return r.Error
+}
+
+type importsResponse struct {
+	Imports []Import `json:"imports"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r importsResponse) error() error {
+	return r.Error
+}
+
+type resolveRequest struct {
+	ImportID string `json:"-"`
+	RowID    string `json:"-"`
+	BookID   string `json:"book_id"` // empty skips the row
+}
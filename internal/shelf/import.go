@@ -0,0 +1,302 @@
+package shelf
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+// Import job status.
+const (
+	ImportQueued     = "queued"
+	ImportProcessing = "processing"
+	ImportReview     = "review" // waiting for ambiguous rows to be resolved
+	ImportDone       = "done"
+)
+
+// Import row status.
+const (
+	RowPending  = "pending"
+	RowImported = "imported"
+	RowReview   = "review"
+	RowNotFound = "not_found"
+	RowSkipped  = "skipped"
+)
+
+// Import sources.
+const (
+	SourceGoodreads = "goodreads"
+	SourceCSV       = "csv"
+)
+
+// MaxImportRows caps the rows of a single import.
+const MaxImportRows = 20000
+
+// Import is a background job putting the rows of an uploaded export on
+// the user shelves.
+type Import struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Source    string    `json:"source"`
+	Status    string    `json:"status"`
+	Total     int       `json:"total"`
+	Imported  int       `json:"imported"`
+	Review    int       `json:"review"`
+	NotFound  int       `json:"not_found"`
+	Skipped   int       `json:"skipped"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Rows      []Row     `json:"rows,omitempty" gorm:"-"`
+}
+
+func (Import) TableName() string {
+	return "shelf_imports"
+}
+
+// Count sets the totals of the import from its rows, and moves it to
+// review or done once none of them is pending.
+func (i *Import) Count(rows []Row) {
+	i.Total, i.Imported, i.Review, i.NotFound, i.Skipped = len(rows), 0, 0, 0, 0
+	pending := 0
+	for _, r := range rows {
+		switch r.Status {
+		case RowPending:
+			pending++
+		case RowImported:
+			i.Imported++
+		case RowReview:
+			i.Review++
+		case RowNotFound:
+			i.NotFound++
+		case RowSkipped:
+			i.Skipped++
+		}
+	}
+	switch {
+	case pending > 0:
+	case i.Review > 0:
+		i.Status = ImportReview
+	default:
+		i.Status = ImportDone
+	}
+}
+
+// Row is a book of an import along with the result of matching it to the
+// catalog. Rows in review carry the candidate books to pick from.
+type Row struct {
+	ID              string         `json:"id"`
+	ImportID        string         `json:"-"`
+	Line            int            `json:"line"`
+	Title           string         `json:"title"`
+	Author          string         `json:"author,omitempty"`
+	ISBN            string         `json:"isbn,omitempty"`
+	Shelf           string         `json:"shelf"`
+	ShelfString     string         `json:"-"`
+	Rating          int            `json:"rating,omitempty"`
+	ReadAt          *time.Time     `json:"read_at,omitempty"`
+	Status          string         `json:"status"`
+	BookID          string         `json:"book_id,omitempty"`
+	CandidateString string         `json:"-"`
+	Candidates      []catalog.Book `json:"candidates,omitempty" gorm:"-"`
+}
+
+func (Row) TableName() string {
+	return "shelf_import_rows"
+}
+
+// CandidateIDs returns the IDs of the books the row may be.
+func (r *Row) CandidateIDs() []string {
+	return split(r.CandidateString)
+}
+
+// columns maps the header names of Goodreads exports and of generic CSV
+// files to the row fields, the first present one wins.
+var columns = map[string][]string{
+	"title":   {"title", "book title", "name"},
+	"author":  {"author", "authors", "author name"},
+	"isbn":    {"isbn13", "isbn", "isbn10"},
+	"rating":  {"my rating", "rating", "stars"},
+	"shelf":   {"exclusive shelf", "shelf", "status"},
+	"shelves": {"bookshelves", "shelves", "tags"},
+	"read":    {"date read", "read at", "read_at", "date_read"},
+}
+
+var dateLayouts = []string{"2006/01/02", "2006-01-02", "2006/1/2", "01/02/2006"}
+
+// Parse reads the rows of a Goodreads export or of a CSV file with a
+// header having at least a title column. Rows without a title are left
+// out.
+func Parse(r io.Reader) (source string, rows []Row, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return "", nil, ErrEmptyImport
+	}
+	if err != nil {
+		return "", nil, ErrBadCSV
+	}
+	index := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := index[h]; !ok {
+			index[h] = i
+		}
+	}
+	col := make(map[string][]int)
+	for field, names := range columns {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				col[field] = append(col[field], i)
+			}
+		}
+	}
+	if len(col["title"]) == 0 {
+		return "", nil, ErrNoTitle
+	}
+	source = SourceCSV
+	if _, ok := index["exclusive shelf"]; ok {
+		source = SourceGoodreads
+	}
+
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, ErrBadCSV
+		}
+		get := func(field string) string {
+			for _, i := range col[field] {
+				if i < len(rec) {
+					if v := strings.TrimSpace(rec[i]); v != "" {
+						return v
+					}
+				}
+			}
+			return ""
+		}
+		row := Row{
+			Line:   line,
+			Title:  get("title"),
+			Author: get("author"),
+			ISBN:   CleanISBN(get("isbn")),
+			Status: RowPending,
+		}
+		if row.Title == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(get("rating")); err == nil && n > 0 && n <= MaxRating {
+			row.Rating = n
+		}
+		if v := get("read"); v != "" {
+			for _, l := range dateLayouts {
+				if t, err := time.Parse(l, v); err == nil {
+					row.ReadAt = &t
+					break
+				}
+			}
+		}
+		var custom []string
+		row.Shelf, custom = shelfOf(get("shelf"))
+		custom = append(custom, normalize(strings.Split(get("shelves"), ","))...)
+		if row.Shelf == "" {
+			row.Shelf = ShelfToRead
+			if row.Rating > 0 || row.ReadAt != nil {
+				row.Shelf = ShelfRead
+			}
+		}
+		row.ShelfString = merge("", custom)
+
+		if len(rows) == MaxImportRows {
+			return "", nil, ErrTooManyRows
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return "", nil, ErrEmptyImport
+	}
+	return source, rows, nil
+}
+
+// shelfOf maps a status value to the exclusive shelf. Values which aren't
+// a reading status are kept as a custom shelf.
+func shelfOf(v string) (string, []string) {
+	switch s := normalizeShelf(v); s {
+	case "":
+		return "", nil
+	case ShelfToRead, "want-to-read", "wishlist":
+		return ShelfToRead, nil
+	case ShelfReading, "reading":
+		return ShelfReading, nil
+	case ShelfRead, "finished":
+		return ShelfRead, nil
+	default:
+		return "", []string{s}
+	}
+}
+
+func normalizeShelf(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// CleanISBN strips the spreadsheet quoting and the dashes of an ISBN,
+// e.g. Goodreads exports ISBNs as ="0439023483".
+func CleanISBN(s string) string {
+	isbn := strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' || c == 'X' {
+			return c
+		}
+		return -1
+	}, strings.ToUpper(s))
+	switch {
+	case len(isbn) == 10 && !strings.Contains(isbn[:9], "X"):
+		return isbn
+	case len(isbn) == 13 && !strings.Contains(isbn, "X"):
+		return isbn
+	}
+	return ""
+}
+
+// AlternateISBN returns the ISBN-13 of an ISBN-10 and the other way
+// around, or empty if there is none.
+func AlternateISBN(isbn string) string {
+	switch {
+	case len(isbn) == 10:
+		s := "978" + isbn[:9]
+		return s + strconv.Itoa(isbn13Check(s))
+	case len(isbn) == 13 && strings.HasPrefix(isbn, "978"):
+		s := isbn[3:12]
+		sum := 0
+		for i, c := range s {
+			sum += (10 - i) * int(c-'0')
+		}
+		switch d := (11 - sum%11) % 11; d {
+		case 10:
+			return s + "X"
+		default:
+			return s + strconv.Itoa(d)
+		}
+	}
+	return ""
+}
+
+func isbn13Check(s string) int {
+	sum := 0
+	for i, c := range s {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += w * int(c-'0')
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,91 @@
+package shelf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/shelf"
+)
+
+const goodreads = `Book Id,Title,Author,Author l-f,Additional Authors,ISBN,ISBN13,My Rating,Average Rating,Publisher,Binding,Number of Pages,Year Published,Original Publication Year,Date Read,Date Added,Bookshelves,Bookshelves with positions,Exclusive Shelf,My Review,Spoiler,Private Notes,Read Count,Owned Copies
+2767052,"The Hunger Games (The Hunger Games, #1)",Suzanne Collins,"Collins, Suzanne",,"=""0439023483""","=""9780439023481""",4,4.33,Scholastic Press,Hardcover,374,2008,2008,2019/03/01,2019/02/11,"dystopia, favorites","dystopia (#2), favorites (#1)",read,,,,1,0
+5907,The Hobbit,J.R.R. Tolkien,"Tolkien, J.R.R.",,"=""""","=""""",0,4.28,Houghton Mifflin,Paperback,366,2002,1937,,2020/01/05,to-read,to-read (#3),to-read,,,,0,0
+,,Nobody,,,,,,,,,,,,,,,,,,,,,
+`
+
+func TestParseGoodreads(t *testing.T) {
+	source, rows, err := shelf.Parse(strings.NewReader(goodreads))
+	if err != nil {
+		t.Fatalf("parse: unexpected error %v", err)
+	}
+	if source != shelf.SourceGoodreads {
+		t.Errorf("source: expected goodreads, got %q", source)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows: expected 2 rows with a title, got %d", len(rows))
+	}
+
+	r := rows[0]
+	if r.Line != 2 || r.ISBN != "9780439023481" || r.Rating != 4 || r.Shelf != shelf.ShelfRead {
+		t.Errorf("row: unexpected %+v", r)
+	}
+	if r.ReadAt == nil || r.ReadAt.Format("2006-01-02") != "2019-03-01" {
+		t.Errorf("read at: expected 2019-03-01, got %v", r.ReadAt)
+	}
+	if r.ShelfString != "dystopia,favorites" {
+		t.Errorf("shelves: expected custom shelves only, got %q", r.ShelfString)
+	}
+	if rows[1].ISBN != "" || rows[1].Shelf != shelf.ShelfToRead || rows[1].ShelfString != "" {
+		t.Errorf("row: unexpected %+v", rows[1])
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	cases := []struct {
+		csv   string
+		err   error
+		shelf string
+	}{
+		{"title,author,rating\nDune,Frank Herbert,5\n", nil, shelf.ShelfRead},
+		{"Name;Author\n", shelf.ErrNoTitle, ""},
+		{"title,status\nDune,Currently Reading\n", nil, shelf.ShelfReading},
+		{"title,status\nDune,owned\n", nil, shelf.ShelfToRead},
+		{"title\n", shelf.ErrEmptyImport, ""},
+		{"", shelf.ErrEmptyImport, ""},
+	}
+	for _, c := range cases {
+		source, rows, err := shelf.Parse(strings.NewReader(c.csv))
+		if err != c.err {
+			t.Errorf("%q: expected error %v, got %v", c.csv, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if source != shelf.SourceCSV || rows[0].Shelf != c.shelf {
+			t.Errorf("%q: expected csv on %s, got %s on %s", c.csv, c.shelf, source, rows[0].Shelf)
+		}
+	}
+}
+
+func TestISBN(t *testing.T) {
+	cases := []struct {
+		in, clean, alternate string
+	}{
+		{`="0439023483"`, "0439023483", "9780439023481"},
+		{"978-0-439-02348-1", "9780439023481", "0439023483"},
+		{"080442957X", "080442957X", "9780804429573"},
+		{"9780804429573", "9780804429573", "080442957X"},
+		{"9791032305690", "9791032305690", ""},
+		{"12345", "", ""},
+	}
+	for _, c := range cases {
+		clean := shelf.CleanISBN(c.in)
+		if clean != c.clean {
+			t.Errorf("%s: expected clean %q, got %q", c.in, c.clean, clean)
+		}
+		if alt := shelf.AlternateISBN(clean); alt != c.alternate {
+			t.Errorf("%s: expected alternate %q, got %q", c.in, c.alternate, alt)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package shelf
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunImporter processes the queued shelf imports every interval until ctx
+// is done.
+func RunImporter(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Process(ctx); err != nil {
+				logger.Log("importer", "shelf", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package shelf
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Entries(ctx context.Context, userID, shelf string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "entries", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	entries, err = mw.next.Entries(ctx, userID, shelf)
+	return
+}
+
+func (mw instrmw) SaveEntry(ctx context.Context, e Entry) (entry Entry, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "save_entry", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	entry, err = mw.next.SaveEntry(ctx, e)
+	return
+}
+
+func (mw instrmw) StartImport(ctx context.Context, userID string, data []byte) (imp Import, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start_import", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	imp, err = mw.next.StartImport(ctx, userID, data)
+	return
+}
+
+func (mw instrmw) Imports(ctx context.Context, userID string) (imports []Import, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "imports", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	imports, err = mw.next.Imports(ctx, userID)
+	return
+}
+
+func (mw instrmw) Import(ctx context.Context, userID, id string) (imp Import, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "import", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	imp, err = mw.next.Import(ctx, userID, id)
+	return
+}
+
+func (mw instrmw) Resolve(ctx context.Context, userID, importID, rowID, bookID string) (imp Import, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "resolve", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	imp, err = mw.next.Resolve(ctx, userID, importID, rowID, bookID)
+	return
+}
+
+func (mw instrmw) Process(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "process", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Process(ctx)
+	return
+}
@@ -0,0 +1,100 @@
+package shelf
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Entries(ctx context.Context, userID, shelf string) (entries []Entry, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "entries",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Entries(ctx, userID, shelf)
+}
+
+func (s loggingService) SaveEntry(ctx context.Context, e Entry) (entry Entry, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "save_entry",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SaveEntry(ctx, e)
+}
+
+func (s loggingService) StartImport(ctx context.Context, userID string, data []byte) (imp Import, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start_import",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.StartImport(ctx, userID, data)
+}
+
+func (s loggingService) Imports(ctx context.Context, userID string) (imports []Import, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "imports",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Imports(ctx, userID)
+}
+
+func (s loggingService) Import(ctx context.Context, userID, id string) (imp Import, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "import",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Import(ctx, userID, id)
+}
+
+func (s loggingService) Resolve(ctx context.Context, userID, importID, rowID, bookID string) (imp Import, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "resolve",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Resolve(ctx, userID, importID, rowID, bookID)
+}
+
+func (s loggingService) Process(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "process",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Process(ctx)
+}
@@ -0,0 +1,88 @@
+package shelf
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+// maxCandidates caps the books offered to pick from when a row is
+// ambiguous.
+const maxCandidates = 5
+
+// SearchTitle returns the title to look a row up with, without the series
+// suffix Goodreads adds, e.g. "The Hunger Games (The Hunger Games, #1)",
+// and without the subtitle.
+func SearchTitle(title string) string {
+	if i := strings.LastIndex(title, " ("); i > 0 && strings.HasSuffix(title, ")") {
+		title = title[:i]
+	}
+	if i := strings.Index(title, ": "); i > 0 {
+		title = title[:i]
+	}
+	return strings.TrimSpace(title)
+}
+
+// Match picks the book of r among the books found by its title. A single
+// book with the same title, by the same author when there is one, is a
+// match. Otherwise the closest books are returned as candidates to review,
+// none means the row isn't in the catalog.
+func Match(r Row, books []catalog.Book) (bookID string, candidates []string) {
+	title := fold(SearchTitle(r.Title))
+	var same []catalog.Book
+	for _, b := range books {
+		if fold(SearchTitle(b.Title)) == title {
+			same = append(same, b)
+		}
+	}
+	if r.Author != "" && len(same) > 1 {
+		var by []catalog.Book
+		for _, b := range same {
+			if wrote(b, r.Author) {
+				by = append(by, b)
+			}
+		}
+		if len(by) > 0 {
+			same = by
+		}
+	}
+	if len(same) == 1 && (r.Author == "" || len(same[0].Authors) == 0 || wrote(same[0], r.Author)) {
+		return same[0].ID, nil
+	}
+	if len(same) == 0 {
+		same = books
+	}
+	for _, b := range same {
+		if len(candidates) == maxCandidates {
+			break
+		}
+		candidates = append(candidates, b.ID)
+	}
+	return "", candidates
+}
+
+// wrote tells if the last name of author is the one of an author of b.
+func wrote(b catalog.Book, author string) bool {
+	names := strings.Fields(fold(author))
+	if len(names) == 0 {
+		return false
+	}
+	last := names[len(names)-1]
+	for _, a := range b.Authors {
+		for _, n := range strings.Fields(fold(a.FirstName + " " + a.LastName)) {
+			if n == last {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fold lowercases s and keeps its letters and digits, words separated by
+// a single space.
+func fold(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}), " ")
+}
@@ -0,0 +1,46 @@
+package shelf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/shelf"
+)
+
+func TestMatch(t *testing.T) {
+	herbert := []catalog.Author{{FirstName: "Frank", LastName: "Herbert"}}
+	books := []catalog.Book{
+		{ID: "1", Title: "Dune", Authors: herbert},
+		{ID: "2", Title: "Dune: Deluxe Edition", Authors: herbert},
+		{ID: "3", Title: "Dune Messiah", Authors: herbert},
+		{ID: "4", Title: "Dune", Authors: []catalog.Author{{FirstName: "Other", LastName: "Writer"}}},
+	}
+
+	cases := []struct {
+		row        shelf.Row
+		books      []catalog.Book
+		id         string
+		candidates []string
+	}{
+		{shelf.Row{Title: "Dune (Dune, #1)", Author: "Frank Herbert"}, books[:1], "1", nil},
+		{shelf.Row{Title: "Dune", Author: "Frank Herbert"}, books[1:], "2", nil},
+		{shelf.Row{Title: "Dune"}, books, "", []string{"1", "2", "4"}},
+		{shelf.Row{Title: "Dune", Author: "Writer"}, books, "4", nil},
+		{shelf.Row{Title: "Dune", Author: "Someone Else"}, books[3:], "", []string{"4"}},
+		{shelf.Row{Title: "Dune World"}, books[2:3], "", []string{"3"}},
+		{shelf.Row{Title: "Hyperion"}, nil, "", nil},
+	}
+	for i, c := range cases {
+		id, candidates := shelf.Match(c.row, c.books)
+		if id != c.id {
+			t.Errorf("case %d: expected match %q, got %q", i, c.id, id)
+		}
+		if len(c.candidates) == 0 && len(candidates) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(candidates, c.candidates) {
+			t.Errorf("case %d: expected candidates %v, got %v", i, c.candidates, candidates)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package shelf
+
+// Repo abstracts all the persistant storage operations of Shelf Service
+type Repo interface {
+	GetEntry(userID, bookID string) (Entry, error)
+	SaveEntry(e *Entry) error
+	// ListEntries returns the entries of an user on shelf, all if empty.
+	ListEntries(userID, shelf string) ([]Entry, error)
+
+	// CreateImport stores the import along with its rows.
+	CreateImport(i *Import, rows []Row) error
+	GetImport(ID string) (Import, error)
+	SaveImport(i *Import) error
+	ListImports(userID string) ([]Import, error)
+	// ListImportsByStatus returns the imports of any of status, oldest first.
+	ListImportsByStatus(status ...string) ([]Import, error)
+
+	GetRow(ID string) (Row, error)
+	SaveRow(r *Row) error
+	// ListRows returns the rows of an import of any of status, all if
+	// none, by line.
+	ListRows(importID string, status ...string) ([]Row, error)
+	Drop() error
+}
@@ -0,0 +1,323 @@
+package shelf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrInvalidShelf   = errors.New("shelf must be to-read, currently-reading or read")
+	ErrInvalidRating  = errors.New("rating must be between 0 and 5")
+	ErrBadCSV         = errors.New("malformed csv")
+	ErrNoTitle        = errors.New("csv has no title column")
+	ErrEmptyImport    = errors.New("csv has no books")
+	ErrTooManyRows    = errors.New("too many rows in one import")
+	ErrImportNotFound = errors.New("import not found")
+	ErrRowNotFound    = errors.New("import row not found")
+	ErrRowResolved    = errors.New("import row is already resolved")
+)
+
+// progressEvery is the number of matched rows after which the totals of a
+// running import are saved.
+const progressEvery = 100
+
+type Service interface {
+	// Entries returns the books of an user on shelf, all if empty.
+	Entries(ctx context.Context, userID, shelf string) ([]Entry, error)
+
+	// SaveEntry puts a book on the user shelves and rates it.
+	SaveEntry(ctx context.Context, e Entry) (Entry, error)
+
+	// StartImport queues the import of a Goodreads export or CSV file to
+	// the shelves of an user.
+	StartImport(ctx context.Context, userID string, data []byte) (Import, error)
+
+	// Imports returns the imports of an user, latest first.
+	Imports(ctx context.Context, userID string) ([]Import, error)
+
+	// Import returns an import of the user along with the rows left to
+	// review and the ones not found.
+	Import(ctx context.Context, userID, id string) (Import, error)
+
+	// Resolve imports a row in review, or not found, as bookID. An empty
+	// bookID skips the row.
+	Resolve(ctx context.Context, userID, importID, rowID, bookID string) (Import, error)
+
+	// Process matches the rows of the queued imports to the catalog.
+	Process(ctx context.Context) error
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo) Service {
+	return basicService{r: r, catalog: catalog}
+}
+
+// Entries returns the books of an user on shelf.
+func (s basicService) Entries(ctx context.Context, userID, shelf string) ([]Entry, error) {
+	if shelf != "" && !exclusive(shelf) {
+		return nil, ErrInvalidShelf
+	}
+	return s.r.ListEntries(userID, shelf)
+}
+
+// SaveEntry puts a book on the user shelves, replacing its previous
+// shelves and rating.
+func (s basicService) SaveEntry(ctx context.Context, e Entry) (Entry, error) {
+	if err := e.Validate(); err != nil {
+		return Entry{}, err
+	}
+	if _, err := s.catalog.GetByID(e.BookID); err != nil {
+		return Entry{}, catalog.ErrBookNotFound
+	}
+	now := time.Now().UTC()
+	old, err := s.r.GetEntry(e.UserID, e.BookID)
+	switch err {
+	case nil:
+		e.ID, e.AddedAt = old.ID, old.AddedAt
+	case db.ErrNotFound:
+		e.ID, e.AddedAt = "", now
+	default:
+		return Entry{}, err
+	}
+	e.ShelfString = merge("", normalize(e.Shelves()))
+	e.UpdatedAt = now
+	if err := s.r.SaveEntry(&e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// StartImport parses the file right away so that malformed ones are
+// refused, the rows are matched in the background by Process.
+func (s basicService) StartImport(ctx context.Context, userID string, data []byte) (Import, error) {
+	source, rows, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return Import{}, err
+	}
+	now := time.Now().UTC()
+	i := Import{
+		UserID:    userID,
+		Source:    source,
+		Status:    ImportQueued,
+		Total:     len(rows),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.r.CreateImport(&i, rows); err != nil {
+		return Import{}, err
+	}
+	return i, nil
+}
+
+// Imports returns the imports of an user.
+func (s basicService) Imports(ctx context.Context, userID string) ([]Import, error) {
+	return s.r.ListImports(userID)
+}
+
+// Import returns an import of the user with the rows needing attention.
+// Rows in review come with their candidate books.
+func (s basicService) Import(ctx context.Context, userID, id string) (Import, error) {
+	i, err := s.r.GetImport(id)
+	if err != nil || i.UserID != userID {
+		return Import{}, ErrImportNotFound
+	}
+	rows, err := s.r.ListRows(i.ID, RowReview, RowNotFound)
+	if err != nil {
+		return Import{}, err
+	}
+	for n := range rows {
+		for _, id := range rows[n].CandidateIDs() {
+			b, err := s.catalog.GetByID(id)
+			if err != nil {
+				continue
+			}
+			rows[n].Candidates = append(rows[n].Candidates, b)
+		}
+	}
+	i.Rows = rows
+	return i, nil
+}
+
+// Resolve imports a row in review, or not found, as bookID, which may be
+// any book of the catalog and not only one of the candidates.
+func (s basicService) Resolve(ctx context.Context, userID, importID, rowID, bookID string) (Import, error) {
+	i, err := s.r.GetImport(importID)
+	if err != nil || i.UserID != userID {
+		return Import{}, ErrImportNotFound
+	}
+	row, err := s.r.GetRow(rowID)
+	if err != nil || row.ImportID != i.ID {
+		return Import{}, ErrRowNotFound
+	}
+	if row.Status != RowReview && row.Status != RowNotFound {
+		return Import{}, ErrRowResolved
+	}
+
+	if bookID == "" {
+		row.Status = RowSkipped
+	} else {
+		if _, err := s.catalog.GetByID(bookID); err != nil {
+			return Import{}, catalog.ErrBookNotFound
+		}
+		if err := s.apply(i.UserID, bookID, row); err != nil {
+			return Import{}, err
+		}
+		row.Status = RowImported
+		row.BookID = bookID
+	}
+	if err := s.r.SaveRow(&row); err != nil {
+		return Import{}, err
+	}
+	if err := s.count(&i); err != nil {
+		return Import{}, err
+	}
+	return s.Import(ctx, userID, importID)
+}
+
+// Process matches the pending rows of the queued imports, oldest first.
+// Rows are saved as they're matched, an interrupted import carries on
+// where it stopped on the next run.
+func (s basicService) Process(ctx context.Context) error {
+	imports, err := s.r.ListImportsByStatus(ImportQueued, ImportProcessing)
+	if err != nil {
+		return err
+	}
+	for _, i := range imports {
+		i.Status = ImportProcessing
+		i.UpdatedAt = time.Now().UTC()
+		if err := s.r.SaveImport(&i); err != nil {
+			return err
+		}
+		rows, err := s.r.ListRows(i.ID, RowPending)
+		if err != nil {
+			return err
+		}
+		for n, row := range rows {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := s.match(&row); err != nil {
+				return err
+			}
+			if row.Status == RowImported {
+				if err := s.apply(i.UserID, row.BookID, row); err != nil {
+					return err
+				}
+			}
+			if err := s.r.SaveRow(&row); err != nil {
+				return err
+			}
+			if (n+1)%progressEvery == 0 {
+				if err := s.count(&i); err != nil {
+					return err
+				}
+			}
+		}
+		if err := s.count(&i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match looks the row up by ISBN first, then by title. Delisted books are
+// never matched.
+func (s basicService) match(row *Row) error {
+	for _, isbn := range []string{row.ISBN, AlternateISBN(row.ISBN)} {
+		if isbn == "" {
+			continue
+		}
+		b, err := s.catalog.GetByISBN(isbn)
+		if err == db.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !b.Delisted {
+			row.Status, row.BookID = RowImported, b.ID
+			return nil
+		}
+	}
+
+	found, err := s.catalog.Search(SearchTitle(row.Title))
+	if err != nil {
+		return err
+	}
+	books := make([]catalog.Book, 0, len(found))
+	for _, b := range found {
+		if !b.Delisted {
+			books = append(books, b)
+		}
+	}
+	id, candidates := Match(*row, books)
+	switch {
+	case id != "":
+		row.Status, row.BookID = RowImported, id
+	case len(candidates) > 0:
+		row.Status = RowReview
+		row.CandidateString = merge("", candidates)
+	default:
+		row.Status = RowNotFound
+	}
+	return nil
+}
+
+// apply puts the book of an imported row on the user shelves. The shelf
+// of the row replaces the current one, custom shelves are added and the
+// rating and read date are only set if the row has them.
+func (s basicService) apply(userID, bookID string, row Row) error {
+	now := time.Now().UTC()
+	e, err := s.r.GetEntry(userID, bookID)
+	switch err {
+	case nil:
+	case db.ErrNotFound:
+		e = Entry{UserID: userID, BookID: bookID, AddedAt: now}
+	default:
+		return err
+	}
+	e.Shelf = row.Shelf
+	e.AddShelves(split(row.ShelfString))
+	if row.Rating > 0 {
+		e.Rating = row.Rating
+	}
+	if row.ReadAt != nil {
+		e.ReadAt = row.ReadAt
+	}
+	e.UpdatedAt = now
+	return s.r.SaveEntry(&e)
+}
+
+// count refreshes the totals and the status of an import from its rows.
+func (s basicService) count(i *Import) error {
+	rows, err := s.r.ListRows(i.ID)
+	if err != nil {
+		return err
+	}
+	i.Count(rows)
+	i.UpdatedAt = time.Now().UTC()
+	return s.r.SaveImport(i)
+}
+
+func normalize(shelves []string) []string {
+	out := make([]string, 0, len(shelves))
+	for _, s := range shelves {
+		if s = normalizeShelf(s); s != "" && !exclusive(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
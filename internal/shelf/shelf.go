@@ -0,0 +1,99 @@
+package shelf
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Exclusive shelves, a book is on only one of them at a time.
+const (
+	ShelfToRead  = "to-read"
+	ShelfReading = "currently-reading"
+	ShelfRead    = "read"
+)
+
+// MaxRating is the highest star rating of a book.
+const MaxRating = 5
+
+// Entry is a book on the shelves of an user. Besides the exclusive shelf
+// a book can be on any number of custom shelves.
+type Entry struct {
+	ID          string     `json:"-"`
+	UserID      string     `json:"user_id"`
+	BookID      string     `json:"book_id"`
+	Shelf       string     `json:"shelf"`
+	ShelfString string     `json:"-"`
+	Rating      int        `json:"rating,omitempty"` // 0 is not rated
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	AddedAt     time.Time  `json:"added_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Entry) TableName() string {
+	return "shelf_entries"
+}
+
+// Shelves returns the custom shelves of the entry.
+func (e *Entry) Shelves() []string {
+	return split(e.ShelfString)
+}
+
+// MarshalJSON adds the custom shelves to the entry.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	return json.Marshal(struct {
+		entry
+		Shelves []string `json:"shelves"`
+	}{entry(e), e.Shelves()})
+}
+
+// AddShelves puts the entry on the custom shelves it isn't on yet.
+func (e *Entry) AddShelves(shelves []string) {
+	e.ShelfString = merge(e.ShelfString, shelves)
+}
+
+// Validate checks the shelf and the rating of the entry.
+func (e Entry) Validate() error {
+	if !exclusive(e.Shelf) {
+		return ErrInvalidShelf
+	}
+	if e.Rating < 0 || e.Rating > MaxRating {
+		return ErrInvalidRating
+	}
+	return nil
+}
+
+func exclusive(shelf string) bool {
+	switch shelf {
+	case ShelfToRead, ShelfReading, ShelfRead:
+		return true
+	}
+	return false
+}
+
+// merge adds shelves missing from the comma separated list s.
+func merge(s string, shelves []string) string {
+	all := split(s)
+	seen := make(map[string]bool)
+	for _, v := range all {
+		seen[v] = true
+	}
+	for _, v := range shelves {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			all = append(all, v)
+		}
+	}
+	return strings.Join(all, ",")
+}
+
+func split(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package shelf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/shelf"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo keeps the entries in memory, the import i1 being one of u2.
+type repo struct {
+	shelf.Repo
+	entries []shelf.Entry
+}
+
+func (r *repo) GetEntry(userID, bookID string) (shelf.Entry, error) {
+	for _, e := range r.entries {
+		if e.UserID == userID && e.BookID == bookID {
+			return e, nil
+		}
+	}
+	return shelf.Entry{}, db.ErrNotFound
+}
+
+func (r *repo) SaveEntry(e *shelf.Entry) error {
+	r.entries = append(r.entries, *e)
+	return nil
+}
+
+func (r *repo) ListEntries(userID, shelf string) ([]shelf.Entry, error) {
+	return nil, nil
+}
+
+func (r *repo) GetImport(ID string) (shelf.Import, error) {
+	if ID != "i1" {
+		return shelf.Import{}, db.ErrNotFound
+	}
+	return shelf.Import{ID: "i1", UserID: "u2"}, nil
+}
+
+type catalogRepo struct {
+	catalog.Repo
+}
+
+func (catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	return catalog.Book{ID: ID}, nil
+}
+
+func TestHTTPAccess(t *testing.T) {
+	r := &repo{}
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	h := shelf.MakeHTTPHandler(context.Background(), shelf.NewService(r, catalogRepo{}), account, log.NewNopLogger())
+	u1, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "", []string{user.ScopeUsersRead}, time.Hour)
+	entry := `{"shelf":"read","rating":4}`
+
+	for _, c := range []struct {
+		name, method, path, body, token string
+		status                          int
+	}{
+		{"entries without token", "GET", "/shelves/v1/books", "", "", http.StatusUnauthorized},
+		{"save without token", "PUT", "/shelves/v1/books/b1", entry, "", http.StatusUnauthorized},
+		{"imports without token", "GET", "/shelves/v1/imports", "", "", http.StatusUnauthorized},
+		{"entries by scoped token", "GET", "/shelves/v1/books", "", scoped, http.StatusForbidden},
+		{"entries", "GET", "/shelves/v1/books", "", u1, http.StatusOK},
+		{"save", "PUT", "/shelves/v1/books/b1", entry, u1, http.StatusOK},
+		{"import of another user", "GET", "/shelves/v1/imports/i1", "", u1, http.StatusNotFound},
+		{"entries at the user route", "GET", "/shelves/v1/u2", "", u1, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+	if len(r.entries) != 1 || r.entries[0].UserID != "u1" {
+		t.Errorf("expected the entry saved for the user of the token, got %+v", r.entries)
+	}
+}
@@ -0,0 +1,243 @@
+package shelf
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting   = errors.New("bad routing")
+	ErrFileTooLarge = errors.New("import file is too large")
+)
+
+// maxImportSize limits the uploaded export read into memory.
+const maxImportSize = 10 << 20
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrBadCSV:         "shelf.bad_csv",
+		ErrEmptyImport:    "shelf.empty_import",
+		ErrFileTooLarge:   "shelf.file_too_large",
+		ErrImportNotFound: "shelf.import_not_found",
+		ErrInvalidRating:  "shelf.invalid_rating",
+		ErrInvalidShelf:   "shelf.invalid_shelf",
+		ErrNoTitle:        "shelf.no_title",
+		ErrRowNotFound:    "shelf.row_not_found",
+		ErrRowResolved:    "shelf.row_resolved",
+		ErrTooManyRows:    "shelf.too_many_rows",
+	})
+}
+
+// MakeHTTPHandler mounts the shelf endpoints, served to the requests
+// account lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireUnscoped.
+func MakeHTTPHandler(ctx context.Context, s Service, account endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	entriesHandler := httptransport.NewServer(
+		e.EntriesEndpoint,
+		decodeEntriesRequest,
+		encodeResponse,
+		options...,
+	)
+	saveEntryHandler := httptransport.NewServer(
+		e.SaveEntryEndpoint,
+		decodeSaveEntryRequest,
+		encodeResponse,
+		options...,
+	)
+	startImportHandler := httptransport.NewServer(
+		e.StartImportEndpoint,
+		decodeStartImportRequest,
+		encodeResponse,
+		options...,
+	)
+	importsHandler := httptransport.NewServer(
+		e.ImportsEndpoint,
+		decodeImportRequest,
+		encodeResponse,
+		options...,
+	)
+	importHandler := httptransport.NewServer(
+		e.ImportEndpoint,
+		decodeImportRequest,
+		encodeResponse,
+		options...,
+	)
+	resolveHandler := httptransport.NewServer(
+		e.ResolveEndpoint,
+		decodeResolveRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/shelves/v1/books", entriesHandler).Methods("GET")
+	r.Handle("/shelves/v1/books/{book-id}", saveEntryHandler).Methods("PUT")
+	r.Handle("/shelves/v1/imports", startImportHandler).Methods("POST")
+	r.Handle("/shelves/v1/imports", importsHandler).Methods("GET")
+	r.Handle("/shelves/v1/imports/{import-id}", importHandler).Methods("GET")
+	r.Handle("/shelves/v1/imports/{import-id}/rows/{row-id}", resolveHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeEntriesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return entriesRequest{Shelf: req.FormValue("shelf")}, nil
+}
+
+func decodeSaveEntryRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r saveEntryRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	r.BookID = bookID
+	return r, nil
+}
+
+// decodeStartImportRequest reads the export from the file field of a
+// multipart form, or from the request body.
+func decodeStartImportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var body io.Reader = http.MaxBytesReader(nil, req.Body, maxImportSize)
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		req.Body = http.MaxBytesReader(nil, req.Body, maxImportSize)
+		f, _, err := req.FormFile("file")
+		if err == http.ErrMissingFile {
+			return nil, ErrEmptyImport
+		}
+		if err != nil {
+			return nil, ErrFileTooLarge
+		}
+		defer f.Close()
+		body = f
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, ErrFileTooLarge
+	}
+	return startImportRequest{Data: data}, nil
+}
+
+func decodeImportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return importRequest{ImportID: mux.Vars(req)["import-id"]}, nil
+}
+
+func decodeResolveRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r resolveRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	vars := mux.Vars(req)
+	importID, ok := vars["import-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "import-id")
+	}
+	rowID, ok := vars["row-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "row-id")
+	}
+	r.ImportID = importID
+	r.RowID = rowID
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrImportNotFound, ErrRowNotFound, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrRowResolved:
+		return http.StatusConflict
+	case ErrFileTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrBadRouting, ErrInvalidShelf, ErrInvalidRating, ErrBadCSV, ErrNoTitle,
+		ErrEmptyImport, ErrTooManyRows, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
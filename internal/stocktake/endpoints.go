@@ -0,0 +1,144 @@
+package stocktake
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints combine all the stocktake service endpoints under single type.
+type Endpoints struct {
+	OpenEndpoint     endpoint.Endpoint
+	SessionsEndpoint endpoint.Endpoint
+	SessionEndpoint  endpoint.Endpoint
+	CountEndpoint    endpoint.Endpoint
+	SubmitEndpoint   endpoint.Endpoint
+	ReviewEndpoint   endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the stocktake service endpoints, restricted by staff, e.g. to the
+// shop staff.
+func MakeEndpoints(s Service, staff endpoint.Middleware) Endpoints {
+	return Endpoints{
+		OpenEndpoint:     staff(MakeOpenEndpoint(s)),
+		SessionsEndpoint: staff(MakeSessionsEndpoint(s)),
+		SessionEndpoint:  staff(MakeSessionEndpoint(s)),
+		CountEndpoint:    staff(MakeCountEndpoint(s)),
+		SubmitEndpoint:   staff(MakeSubmitEndpoint(s)),
+		ReviewEndpoint:   staff(MakeReviewEndpoint(s)),
+	}
+}
+
+func MakeOpenEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(NewSession)
+		sess, e := s.Open(ctx, req)
+		if e != nil {
+			return sessionResponse{Session: nil, Error: e}, nil
+		}
+		return sessionResponse{Session: &sess, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeSessionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionsRequest)
+		list, e := s.Sessions(ctx, req.Status)
+		if e != nil {
+			return sessionsResponse{Sessions: make([]Session, 0), Error: e}, nil
+		}
+		return sessionsResponse{Sessions: list}, nil
+	}
+}
+
+func MakeSessionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(idRequest)
+		sess, e := s.Session(ctx, req.ID)
+		if e != nil {
+			return sessionResponse{Session: nil, Error: e}, nil
+		}
+		return sessionResponse{Session: &sess}, nil
+	}
+}
+
+func MakeCountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(countRequest)
+		sess, e := s.Count(ctx, req.SessionID, req.Counts)
+		if e != nil {
+			return sessionResponse{Session: nil, Error: e}, nil
+		}
+		return sessionResponse{Session: &sess}, nil
+	}
+}
+
+func MakeSubmitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(submitRequest)
+		sess, e := s.Submit(ctx, req.SessionID, req.SubmittedBy)
+		if e != nil {
+			return sessionResponse{Session: nil, Error: e}, nil
+		}
+		return sessionResponse{Session: &sess}, nil
+	}
+}
+
+func MakeReviewEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reviewRequest)
+		sess, e := s.Review(ctx, req.SessionID, req.Review)
+		if e != nil {
+			return sessionResponse{Session: nil, Error: e}, nil
+		}
+		return sessionResponse{Session: &sess}, nil
+	}
+}
+
+type sessionsRequest struct {
+	Status string
+}
+
+type idRequest struct {
+	ID string
+}
+
+type countRequest struct {
+	SessionID string `json:"-"`
+	Counts
+}
+
+type submitRequest struct {
+	SessionID   string `json:"-"`
+	SubmittedBy string `json:"submitted_by"`
+}
+
+type reviewRequest struct {
+	SessionID string `json:"-"`
+	Review
+}
+
+type sessionResponse struct {
+	Status  int      `json:"-"`
+	Session *Session `json:"session,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r sessionResponse) status() int {
+	return r.Status
+}
+
+func (r sessionResponse) error() error {
+	return r.Error
+}
+
+type sessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r sessionsResponse) error() error {
+	return r.Error
+}
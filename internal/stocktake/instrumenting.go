@@ -0,0 +1,92 @@
+package stocktake
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Open(ctx context.Context, n NewSession) (sess Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "open", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sess, err = mw.next.Open(ctx, n)
+	return
+}
+
+func (mw instrmw) Sessions(ctx context.Context, status string) (list []Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sessions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Sessions(ctx, status)
+	return
+}
+
+func (mw instrmw) Session(ctx context.Context, ID string) (sess Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "session", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sess, err = mw.next.Session(ctx, ID)
+	return
+}
+
+func (mw instrmw) Count(ctx context.Context, ID string, c Counts) (sess Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "count", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sess, err = mw.next.Count(ctx, ID, c)
+	return
+}
+
+func (mw instrmw) Submit(ctx context.Context, ID, submittedBy string) (sess Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "submit", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sess, err = mw.next.Submit(ctx, ID, submittedBy)
+	return
+}
+
+func (mw instrmw) Review(ctx context.Context, ID string, r Review) (sess Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "review", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sess, err = mw.next.Review(ctx, ID, r)
+	return
+}
@@ -0,0 +1,105 @@
+package stocktake
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Open(ctx context.Context, n NewSession) (sess Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "open",
+			"location", n.Location,
+			"opened_by", n.OpenedBy,
+			"session", sess.ID,
+			"books", len(sess.Counts),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Open(ctx, n)
+}
+
+func (s loggingService) Sessions(ctx context.Context, status string) (list []Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sessions",
+			"status", status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Sessions(ctx, status)
+}
+
+func (s loggingService) Session(ctx context.Context, ID string) (sess Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "session",
+			"session", ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Session(ctx, ID)
+}
+
+func (s loggingService) Count(ctx context.Context, ID string, c Counts) (sess Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "count",
+			"session", ID,
+			"entries", len(c.Entries),
+			"replace", c.Replace,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Count(ctx, ID, c)
+}
+
+func (s loggingService) Submit(ctx context.Context, ID, submittedBy string) (sess Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "submit",
+			"session", ID,
+			"submitted_by", submittedBy,
+			"variances", len(sess.Variances()),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Submit(ctx, ID, submittedBy)
+}
+
+func (s loggingService) Review(ctx context.Context, ID string, r Review) (sess Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "review",
+			"session", ID,
+			"approve", r.Approve,
+			"reviewed_by", r.ReviewedBy,
+			"status", sess.Status,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Review(ctx, ID, r)
+}
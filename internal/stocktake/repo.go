@@ -0,0 +1,20 @@
+package stocktake
+
+// Repo abstracts all the persistant storage operations of Stocktake Service
+type Repo interface {
+	// Create stores the session with its counts in one go.
+	Create(s *Session) error
+	// Get returns the session with its counts in the order of the walk.
+	Get(ID string) (Session, error)
+	// List returns the sessions of status, all if empty, latest first,
+	// without their counts.
+	List(status string) ([]Session, error)
+	// Active returns the open or submitted session of location,
+	// db.ErrNotFound if none.
+	Active(location string) (Session, error)
+	// Save saves the session and its counts.
+	Save(s *Session) error
+	// SaveCount saves a count of a session.
+	SaveCount(c *Count) error
+	Drop() error
+}
@@ -0,0 +1,295 @@
+package stocktake
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/picking"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrSessionNotFound = errors.New("count session not found")
+	ErrSessionActive   = errors.New("location is already being counted")
+	ErrNoBooks         = errors.New("no book is shelved at the location")
+	ErrNotOpen         = errors.New("count session is not open")
+	ErrNotSubmitted    = errors.New("count session is not submitted")
+	ErrSelfReview      = errors.New("count session must be reviewed by another person than its submitter")
+	ErrPartlyPosted    = errors.New("count session is partly posted, approve it to post the rest")
+	ErrUnknownStatus   = errors.New("status must be open, submitted or posted")
+)
+
+// pageSize is the books read at once.
+const pageSize = 500
+
+type Service interface {
+	// Open starts the count of the books shelved at a location, leaving
+	// out the print-on-demand books. A location is counted by one session
+	// at a time.
+	Open(ctx context.Context, n NewSession) (Session, error)
+
+	// Sessions returns the sessions of status, all if empty.
+	Sessions(ctx context.Context, status string) ([]Session, error)
+
+	// Session returns a session with its counts.
+	Session(ctx context.Context, ID string) (Session, error)
+
+	// Count records a batch of counted books of an open session, all or
+	// none of them.
+	Count(ctx context.Context, ID string, c Counts) (Session, error)
+
+	// Submit closes the count and computes the variances of the books
+	// against their stock.
+	Submit(ctx context.Context, ID, submittedBy string) (Session, error)
+
+	// Review approves a submitted session, adding its variances to the
+	// book stock, or rejects it, opening it again to recount.
+	Review(ctx context.Context, ID string, r Review) (Session, error)
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo) Service {
+	return basicService{r: r, catalog: catalog}
+}
+
+func (s basicService) Open(ctx context.Context, n NewSession) (Session, error) {
+	n.Location = strings.ToUpper(strings.TrimSpace(n.Location))
+	if err := n.Validate(); err != nil {
+		return Session{}, err
+	}
+	_, err := s.r.Active(n.Location)
+	if err == nil {
+		return Session{}, ErrSessionActive
+	}
+	if err != db.ErrNotFound {
+		return Session{}, err
+	}
+	books, err := s.shelved(n.Location)
+	if err != nil {
+		return Session{}, err
+	}
+	if len(books) == 0 {
+		return Session{}, ErrNoBooks
+	}
+	sess := Session{Location: n.Location, Status: StatusOpen, OpenedBy: n.OpenedBy, OpenedAt: time.Now().UTC()}
+	for i, b := range books {
+		sess.Counts = append(sess.Counts, Count{BookID: b.ID, Seq: i + 1, ISBN: b.ISBN, Title: b.Title, Location: b.Location})
+	}
+	if err := s.r.Create(&sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+// shelved returns the books stocked at location in the order of the walk.
+func (s basicService) shelved(location string) ([]catalog.Book, error) {
+	prefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(location) + "%"
+	f := filter.Expr{SQL: "location ILIKE ?", Args: []interface{}{prefix}}
+	var books []catalog.Book
+	for offset := 0; ; offset += pageSize {
+		page, _, err := s.catalog.List(f, "location, title", pageSize, offset, db.CountNone)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range page {
+			if InLocation(b.Location, location) && !b.PrintOnDemand {
+				books = append(books, b)
+			}
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+	sort.SliceStable(books, func(i, j int) bool {
+		a, b := books[i], books[j]
+		if a.Location != b.Location {
+			return picking.LocationLess(a.Location, b.Location)
+		}
+		return a.Title < b.Title
+	})
+	return books, nil
+}
+
+func (s basicService) Sessions(ctx context.Context, status string) ([]Session, error) {
+	switch status {
+	case "", StatusOpen, StatusSubmitted, StatusPosted:
+	default:
+		return nil, ErrUnknownStatus
+	}
+	return s.r.List(status)
+}
+
+func (s basicService) Session(ctx context.Context, ID string) (Session, error) {
+	sess, err := s.r.Get(ID)
+	if err == db.ErrNotFound {
+		return Session{}, ErrSessionNotFound
+	}
+	return sess, err
+}
+
+func (s basicService) Count(ctx context.Context, ID string, c Counts) (Session, error) {
+	if err := c.Validate(); err != nil {
+		return Session{}, err
+	}
+	sess, err := s.Session(ctx, ID)
+	if err != nil {
+		return Session{}, err
+	}
+	if sess.Status != StatusOpen {
+		return Session{}, ErrNotOpen
+	}
+	byISBN := make(map[string]int)
+	for i, cnt := range sess.Counts {
+		byISBN[normalizeISBN(cnt.ISBN)] = i
+	}
+	var v validate.Validator
+	batch := make(map[int]int)
+	for _, e := range c.Entries {
+		i, ok := byISBN[normalizeISBN(e.ISBN)]
+		if !v.Check(ok, "counts", validate.CodeUnknown, "isbn "+e.ISBN+" is not shelved at "+sess.Location) {
+			break
+		}
+		q := e.Quantity
+		if q == 0 && !c.Replace {
+			q = 1
+		}
+		batch[i] += q
+	}
+	if err := v.Err(); err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now().UTC()
+	for i, q := range batch {
+		if c.Replace {
+			sess.Counts[i].Counted = q
+		} else {
+			sess.Counts[i].Counted += q
+		}
+		sess.Counts[i].CountedAt = &now
+	}
+	if err := s.r.Save(&sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+func (s basicService) Submit(ctx context.Context, ID, submittedBy string) (Session, error) {
+	var v validate.Validator
+	v.Required("submitted_by", submittedBy)
+	if err := v.Err(); err != nil {
+		return Session{}, err
+	}
+	sess, err := s.Session(ctx, ID)
+	if err != nil {
+		return Session{}, err
+	}
+	if sess.Status != StatusOpen {
+		return Session{}, ErrNotOpen
+	}
+	for i := range sess.Counts {
+		c := &sess.Counts[i]
+		b, err := s.catalog.GetByID(c.BookID)
+		if err == db.ErrNotFound {
+			return Session{}, catalog.ErrBookNotFound
+		}
+		if err != nil {
+			return Session{}, err
+		}
+		c.Expected = b.Stock
+		c.Variance = c.Counted - b.Stock
+	}
+	now := time.Now().UTC()
+	sess.Status, sess.SubmittedBy, sess.SubmittedAt = StatusSubmitted, submittedBy, &now
+	if err := s.r.Save(&sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+func (s basicService) Review(ctx context.Context, ID string, r Review) (Session, error) {
+	if err := r.Validate(); err != nil {
+		return Session{}, err
+	}
+	sess, err := s.Session(ctx, ID)
+	if err != nil {
+		return Session{}, err
+	}
+	if sess.Status != StatusSubmitted {
+		return Session{}, ErrNotSubmitted
+	}
+	if r.ReviewedBy == sess.SubmittedBy {
+		return Session{}, ErrSelfReview
+	}
+	sess.ReviewedBy, sess.ReviewNote = r.ReviewedBy, r.Note
+	if !r.Approve {
+		return s.reject(sess)
+	}
+
+	// Each variance is posted along with its book, a failure leaves the
+	// rest to post by approving again.
+	for i := range sess.Counts {
+		c := &sess.Counts[i]
+		if c.Variance == 0 || c.PostedAt != nil {
+			continue
+		}
+		b, err := s.catalog.GetByID(c.BookID)
+		if err == db.ErrNotFound {
+			return Session{}, catalog.ErrBookNotFound
+		}
+		if err != nil {
+			return Session{}, err
+		}
+		// The variance is added rather than the count set, so that the
+		// books sold since the submission stay sold.
+		b.Stock += c.Variance
+		if b.Stock < 0 {
+			b.Stock = 0
+		}
+		if err := s.catalog.Save(&b); err != nil {
+			return Session{}, err
+		}
+		now := time.Now().UTC()
+		c.PostedAt = &now
+		if err := s.r.SaveCount(c); err != nil {
+			return Session{}, err
+		}
+	}
+	now := time.Now().UTC()
+	sess.Status, sess.PostedAt = StatusPosted, &now
+	if err := s.r.Save(&sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+// reject opens the session again, keeping its counts to correct.
+func (s basicService) reject(sess Session) (Session, error) {
+	for _, c := range sess.Counts {
+		if c.PostedAt != nil {
+			return Session{}, ErrPartlyPosted
+		}
+	}
+	for i := range sess.Counts {
+		sess.Counts[i].Expected, sess.Counts[i].Variance = 0, 0
+	}
+	sess.Status, sess.SubmittedBy, sess.SubmittedAt = StatusOpen, "", nil
+	if err := s.r.Save(&sess); err != nil {
+		return Session{}, err
+	}
+	return sess, nil
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,164 @@
+package stocktake
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Statuses of a count session.
+const (
+	StatusOpen      = "open"      // being counted
+	StatusSubmitted = "submitted" // counted, its variances waiting for review
+	StatusPosted    = "posted"    // approved, its variances posted to the stock
+)
+
+// MaxQuantity is the most copies of a book counted in one entry.
+const MaxQuantity = 100000
+
+// Session is the count of the books shelved at a location. Its variances
+// are posted to the book stock once a reviewer other than the one who
+// submitted it approves it.
+type Session struct {
+	ID string `json:"id"`
+	// Location is a shelf location or a prefix of some, e.g. B-12 holds
+	// B-12 and B-12-3 but not B-120.
+	Location string `json:"location"`
+	Status   string `json:"status"`
+	// Counts are the books of the location when the session was opened,
+	// in the order of the walk.
+	Counts      []Count    `json:"counts" gorm:"ForeignKey:SessionID"`
+	OpenedBy    string     `json:"opened_by"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	SubmittedBy string     `json:"submitted_by,omitempty"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	// ReviewNote is the reason of the last rejection, or the note of the
+	// approval.
+	ReviewNote string     `json:"review_note,omitempty"`
+	PostedAt   *time.Time `json:"posted_at,omitempty"`
+}
+
+func (Session) TableName() string {
+	return "stocktake_sessions"
+}
+
+// Variances returns the counts differing from the book stock.
+func (s Session) Variances() []Count {
+	var list []Count
+	for _, c := range s.Counts {
+		if c.Variance != 0 {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Count is a book of a session. A book never scanned is counted as
+// missing.
+type Count struct {
+	SessionID string `json:"-" gorm:"primary_key"`
+	BookID    string `json:"book_id" gorm:"primary_key"`
+	// Seq is the rank of the book in the walk, from 1.
+	Seq      int    `json:"seq"`
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Location string `json:"location"`
+	Counted  int    `json:"counted"`
+	// Expected is the stock of the book when the session was submitted,
+	// Variance the copies counted above it, negative when missing.
+	Expected  int        `json:"expected"`
+	Variance  int        `json:"variance"`
+	CountedAt *time.Time `json:"counted_at,omitempty"`
+	// PostedAt is set once the variance is added to the book stock.
+	PostedAt *time.Time `json:"posted_at,omitempty"`
+}
+
+func (Count) TableName() string {
+	return "stocktake_counts"
+}
+
+// NewSession is the request to count a location.
+type NewSession struct {
+	Location string `json:"location"`
+	OpenedBy string `json:"opened_by"`
+}
+
+// Validate checks the location is an actual shelf location.
+func (n NewSession) Validate() error {
+	var v validate.Validator
+	if v.Required("location", n.Location) {
+		v.MaxLength("location", n.Location, 20)
+	}
+	v.Required("opened_by", n.OpenedBy)
+	return v.Err()
+}
+
+// Entry is a book counted, by its ISBN. Scanners send one entry per copy,
+// the quantity left out.
+type Entry struct {
+	ISBN string `json:"isbn"`
+	// Quantity is the copies counted, 1 if 0 unless replacing.
+	Quantity int `json:"quantity"`
+}
+
+// Counts is a batch of entries recorded at once.
+type Counts struct {
+	Entries []Entry `json:"counts"`
+	// Replace sets the counts of the books of the entries to the batch,
+	// recounting them, rather than adding to them.
+	Replace bool `json:"replace"`
+}
+
+// Validate checks the quantities of the entries.
+func (c Counts) Validate() error {
+	var v validate.Validator
+	v.Check(len(c.Entries) > 0, "counts", validate.CodeRequired, "counts is required")
+	for _, e := range c.Entries {
+		if !v.Required("isbn", e.ISBN) {
+			break
+		}
+		if !v.Range("quantity", e.Quantity, 0, MaxQuantity) {
+			break
+		}
+	}
+	return v.Err()
+}
+
+// Review approves or rejects a submitted session.
+type Review struct {
+	Approve    bool   `json:"approve"`
+	ReviewedBy string `json:"reviewed_by"`
+	Note       string `json:"note"`
+}
+
+// Validate checks the reviewer is known.
+func (r Review) Validate() error {
+	var v validate.Validator
+	v.Required("reviewed_by", r.ReviewedBy)
+	v.MaxLength("note", r.Note, 500)
+	return v.Err()
+}
+
+// InLocation tells whether the shelf location is location or within it,
+// ignoring the case.
+func InLocation(shelf, location string) bool {
+	shelf, location = strings.ToUpper(shelf), strings.ToUpper(location)
+	if !strings.HasPrefix(shelf, location) {
+		return false
+	}
+	if len(shelf) == len(location) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(shelf[len(location):])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// normalizeISBN strips the hyphens and spaces some scanners and labels
+// keep.
+func normalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+}
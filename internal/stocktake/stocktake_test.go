@@ -0,0 +1,248 @@
+package stocktake_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/stocktake"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// repo keeps the sessions in memory.
+type repo struct {
+	stocktake.Repo
+	sessions map[string]stocktake.Session
+}
+
+func (r *repo) Create(s *stocktake.Session) error {
+	s.ID = "s1"
+	for i := range s.Counts {
+		s.Counts[i].SessionID = s.ID
+	}
+	r.sessions[s.ID] = copySession(*s)
+	return nil
+}
+
+func (r *repo) Get(ID string) (stocktake.Session, error) {
+	s, ok := r.sessions[ID]
+	if !ok {
+		return stocktake.Session{}, db.ErrNotFound
+	}
+	return copySession(s), nil
+}
+
+func (r *repo) Active(location string) (stocktake.Session, error) {
+	for _, s := range r.sessions {
+		if s.Location == location && s.Status != stocktake.StatusPosted {
+			return s, nil
+		}
+	}
+	return stocktake.Session{}, db.ErrNotFound
+}
+
+func (r *repo) Save(s *stocktake.Session) error {
+	r.sessions[s.ID] = copySession(*s)
+	return nil
+}
+
+func (r *repo) SaveCount(c *stocktake.Count) error {
+	s := r.sessions[c.SessionID]
+	for i := range s.Counts {
+		if s.Counts[i].BookID == c.BookID {
+			s.Counts[i] = *c
+		}
+	}
+	return nil
+}
+
+func copySession(s stocktake.Session) stocktake.Session {
+	s.Counts = append([]stocktake.Count(nil), s.Counts...)
+	return s
+}
+
+// catalogRepo keeps the books in memory, ignoring the filters.
+type catalogRepo struct {
+	catalog.Repo
+	books []catalog.Book
+}
+
+func (r *catalogRepo) List(f filter.Expr, order string, limit, offset int, count db.Count) ([]catalog.Book, int, error) {
+	return r.books, 0, nil
+}
+
+func (r *catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	for _, b := range r.books {
+		if b.ID == ID {
+			return b, nil
+		}
+	}
+	return catalog.Book{}, db.ErrNotFound
+}
+
+func (r *catalogRepo) Save(b *catalog.Book) error {
+	for i := range r.books {
+		if r.books[i].ID == b.ID {
+			r.books[i] = *b
+		}
+	}
+	return nil
+}
+
+func TestInLocation(t *testing.T) {
+	for _, c := range []struct {
+		shelf, location string
+		in              bool
+	}{
+		{"B-12", "b-12", true},
+		{"B-12-3", "B-12", true},
+		{"B-120", "B-12", false},
+		{"B-12", "B", true},
+		{"BA-1", "B", false},
+	} {
+		if in := stocktake.InLocation(c.shelf, c.location); in != c.in {
+			t.Errorf("%s in %s: expected %v, got %v", c.shelf, c.location, c.in, in)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{sessions: make(map[string]stocktake.Session)}
+	books := &catalogRepo{books: []catalog.Book{
+		{ID: "b1", ISBN: "9780000000001", Title: "Zebra", Location: "B-12-10", Stock: 3},
+		{ID: "b2", ISBN: "9780000000002", Title: "Apple", Location: "B-12-9", Stock: 2},
+		{ID: "b3", ISBN: "9780000000003", Title: "Moon", Location: "B-12-9", Stock: 1},
+		{ID: "b4", ISBN: "9780000000004", Title: "Elsewhere", Location: "B-120", Stock: 5},
+		{ID: "b5", ISBN: "9780000000005", Title: "On demand", Location: "B-12", PrintOnDemand: true},
+	}}
+	s := stocktake.NewService(r, books)
+
+	sess, err := s.Open(ctx, stocktake.NewSession{Location: " b-12", OpenedBy: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.Counts) != 3 || sess.Counts[0].BookID != "b2" || sess.Counts[1].BookID != "b3" || sess.Counts[2].Seq != 3 {
+		t.Fatalf("expected the shelved books in the order of the walk, got %+v", sess.Counts)
+	}
+	if _, err := s.Open(ctx, stocktake.NewSession{Location: "B-12", OpenedBy: "u2"}); err != stocktake.ErrSessionActive {
+		t.Errorf("second session: expected ErrSessionActive, got %v", err)
+	}
+
+	scans := stocktake.Counts{Entries: []stocktake.Entry{{ISBN: "978-0-00-000000-2"}, {ISBN: "9780000000002"}, {ISBN: "9780000000001", Quantity: 4}}}
+	if _, err := s.Count(ctx, "s1", scans); err != nil {
+		t.Fatal(err)
+	}
+	unknown := stocktake.Counts{Entries: []stocktake.Entry{{ISBN: "9780000000003"}, {ISBN: "9780000000004"}}}
+	if _, err := s.Count(ctx, "s1", unknown); validate.Fields(err) == nil {
+		t.Errorf("book of another location: expected a field error, got %v", err)
+	}
+	recount := stocktake.Counts{Entries: []stocktake.Entry{{ISBN: "9780000000001", Quantity: 2}}, Replace: true}
+	if sess, err = s.Count(ctx, "s1", recount); err != nil {
+		t.Fatal(err)
+	}
+	if sess.Counts[0].Counted != 2 || sess.Counts[1].Counted != 0 || sess.Counts[2].Counted != 2 {
+		t.Errorf("expected the rejected batch left out and the recount replacing, got %+v", sess.Counts)
+	}
+
+	if sess, err = s.Submit(ctx, "s1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if v := sess.Variances(); len(v) != 2 || v[0].BookID != "b3" || v[0].Variance != -1 || v[1].BookID != "b1" || v[1].Variance != -1 {
+		t.Errorf("expected b3 and b1 missing a copy, got %+v", v)
+	}
+	if _, err := s.Count(ctx, "s1", scans); err != stocktake.ErrNotOpen {
+		t.Errorf("submitted session: expected ErrNotOpen, got %v", err)
+	}
+	if _, err := s.Review(ctx, "s1", stocktake.Review{Approve: true, ReviewedBy: "u1"}); err != stocktake.ErrSelfReview {
+		t.Errorf("submitter review: expected ErrSelfReview, got %v", err)
+	}
+
+	// A copy of b1 is sold before the review.
+	books.books[0].Stock = 2
+	if sess, err = s.Review(ctx, "s1", stocktake.Review{Approve: true, ReviewedBy: "u2"}); err != nil {
+		t.Fatal(err)
+	}
+	if sess.Status != stocktake.StatusPosted || sess.Counts[1].PostedAt == nil || sess.Counts[0].PostedAt != nil {
+		t.Errorf("expected the variances posted, got %+v", sess)
+	}
+	if b1, b2, b3 := books.books[0].Stock, books.books[1].Stock, books.books[2].Stock; b1 != 1 || b2 != 2 || b3 != 0 {
+		t.Errorf("expected the stock adjusted by the variances, got b1 %d, b2 %d, b3 %d", b1, b2, b3)
+	}
+	if _, err := s.Review(ctx, "s1", stocktake.Review{Approve: true, ReviewedBy: "u2"}); err != stocktake.ErrNotSubmitted {
+		t.Errorf("posted session: expected ErrNotSubmitted, got %v", err)
+	}
+}
+
+func TestReject(t *testing.T) {
+	ctx := context.Background()
+	r := &repo{sessions: make(map[string]stocktake.Session)}
+	books := &catalogRepo{books: []catalog.Book{{ID: "b1", ISBN: "9780000000001", Location: "A-1", Stock: 3}}}
+	s := stocktake.NewService(r, books)
+
+	if _, err := s.Open(ctx, stocktake.NewSession{Location: "C", OpenedBy: "u1"}); err != stocktake.ErrNoBooks {
+		t.Errorf("empty location: expected ErrNoBooks, got %v", err)
+	}
+	if _, err := s.Open(ctx, stocktake.NewSession{Location: "A-1", OpenedBy: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Submit(ctx, "s1", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := s.Review(ctx, "s1", stocktake.Review{ReviewedBy: "u2", Note: "recount the top shelf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Status != stocktake.StatusOpen || sess.SubmittedBy != "" || sess.Counts[0].Variance != 0 || sess.ReviewNote == "" {
+		t.Errorf("expected the session open again, got %+v", sess)
+	}
+	if books.books[0].Stock != 3 {
+		t.Errorf("expected the stock untouched, got %d", books.books[0].Stock)
+	}
+}
+
+func TestStaffRoutes(t *testing.T) {
+	s := stocktake.NewService(&repo{sessions: make(map[string]stocktake.Session)}, &catalogRepo{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	staff := rbac.Staff(tokens, user.RoleAdmin, user.RoleSupport)
+	h := stocktake.MakeHTTPHandler(context.Background(), s, staff, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	support, _, _ := tokens.Sign("u8", user.RoleSupport, "")
+	admin, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"review without token", "POST", "/stocktake/v1/sessions/s9/review", "", http.StatusUnauthorized},
+		{"open by customer", "POST", "/stocktake/v1/sessions", customer, http.StatusForbidden},
+		{"sessions by customer", "GET", "/stocktake/v1/sessions", customer, http.StatusForbidden},
+		{"count by customer", "POST", "/stocktake/v1/sessions/s9/counts", customer, http.StatusForbidden},
+		{"submit by customer", "POST", "/stocktake/v1/sessions/s9/submit", customer, http.StatusForbidden},
+		{"review by customer", "POST", "/stocktake/v1/sessions/s9/review", customer, http.StatusForbidden},
+		{"review by scoped token", "POST", "/stocktake/v1/sessions/s9/review", scoped, http.StatusForbidden},
+		{"session by support", "GET", "/stocktake/v1/sessions/s9", support, http.StatusNotFound},
+		{"session by admin", "GET", "/stocktake/v1/sessions/s9", admin, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
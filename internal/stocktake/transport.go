@@ -0,0 +1,231 @@
+package stocktake
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrSessionNotFound: "stocktake.session_not_found",
+		ErrSessionActive:   "stocktake.session_active",
+		ErrNoBooks:         "stocktake.no_books",
+		ErrNotOpen:         "stocktake.not_open",
+		ErrNotSubmitted:    "stocktake.not_submitted",
+		ErrSelfReview:      "stocktake.self_review",
+		ErrPartlyPosted:    "stocktake.partly_posted",
+		ErrUnknownStatus:   "stocktake.unknown_status",
+	})
+}
+
+// MakeHTTPHandler mounts the stocktake endpoints, served to the requests
+// staff lets through, e.g. rbac.Staff with user.RoleAdmin and
+// user.RoleSupport.
+func MakeHTTPHandler(ctx context.Context, s Service, staff endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, staff)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	openHandler := httptransport.NewServer(
+		e.OpenEndpoint,
+		decodeOpenRequest,
+		encodeResponse,
+		options...,
+	)
+	sessionsHandler := httptransport.NewServer(
+		e.SessionsEndpoint,
+		decodeSessionsRequest,
+		encodeResponse,
+		options...,
+	)
+	sessionHandler := httptransport.NewServer(
+		e.SessionEndpoint,
+		decodeIDRequest,
+		encodeResponse,
+		options...,
+	)
+	countHandler := httptransport.NewServer(
+		e.CountEndpoint,
+		decodeCountRequest,
+		encodeResponse,
+		options...,
+	)
+	submitHandler := httptransport.NewServer(
+		e.SubmitEndpoint,
+		decodeSubmitRequest,
+		encodeResponse,
+		options...,
+	)
+	reviewHandler := httptransport.NewServer(
+		e.ReviewEndpoint,
+		decodeReviewRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	// Warehouse endpoints
+	r.Handle("/stocktake/v1/sessions", openHandler).Methods("POST")
+	r.Handle("/stocktake/v1/sessions", sessionsHandler).Methods("GET")
+	r.Handle("/stocktake/v1/sessions/{session-id}", sessionHandler).Methods("GET")
+	r.Handle("/stocktake/v1/sessions/{session-id}/counts", countHandler).Methods("POST")
+	r.Handle("/stocktake/v1/sessions/{session-id}/submit", submitHandler).Methods("POST")
+	r.Handle("/stocktake/v1/sessions/{session-id}/review", reviewHandler).Methods("POST")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeOpenRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r NewSession
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func decodeSessionsRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return sessionsRequest{Status: req.URL.Query().Get("status")}, nil
+}
+
+func decodeIDRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["session-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "session-id")
+	}
+	return idRequest{ID: id}, nil
+}
+
+func decodeCountRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r countRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	id, ok := mux.Vars(req)["session-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "session-id")
+	}
+	r.SessionID = id
+	return r, nil
+}
+
+func decodeSubmitRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r submitRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	id, ok := mux.Vars(req)["session-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "session-id")
+	}
+	r.SessionID = id
+	return r, nil
+}
+
+func decodeReviewRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r reviewRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	id, ok := mux.Vars(req)["session-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "session-id")
+	}
+	r.SessionID = id
+	return r, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrSessionNotFound, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrSessionActive, ErrNoBooks, ErrNotOpen, ErrNotSubmitted, ErrPartlyPosted, db.ErrConflict:
+		return http.StatusConflict
+	case ErrSelfReview:
+		return http.StatusForbidden
+	case ErrBadRouting, ErrUnknownStatus, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,134 @@
+package storefront
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+// Endpoints combine all the storefront service endpoints under single type.
+type Endpoints struct {
+	BooksEndpoint         endpoint.Endpoint
+	BookEndpoint          endpoint.Endpoint
+	CategoriesEndpoint    endpoint.Endpoint
+	CategoryBooksEndpoint endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the storefront service endpoints.
+func MakeEndpoints(s Service) Endpoints {
+	return Endpoints{
+		BooksEndpoint:         MakeBooksEndpoint(s),
+		BookEndpoint:          MakeBookEndpoint(s),
+		CategoriesEndpoint:    MakeCategoriesEndpoint(s),
+		CategoryBooksEndpoint: MakeCategoryBooksEndpoint(s),
+	}
+}
+
+func MakeBooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		books, total, e := s.Books(ctx, req.Limit, req.Offset)
+		if e != nil {
+			return listResponse{Books: make([]Book, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		return listResponse{Books: books, Total: total, Prev: prev, Next: next, Keys: []string{KeyBooks}}, nil
+	}
+}
+
+func MakeBookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(bookRequest)
+		b, e := s.Book(ctx, req.ID)
+		if e != nil {
+			return bookResponse{Book: nil, Error: e}, nil
+		}
+		return bookResponse{Book: &b}, nil
+	}
+}
+
+func MakeCategoriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		list, e := s.Categories(ctx)
+		if e != nil {
+			return categoriesResponse{Categories: make([]Category, 0), Error: e}, nil
+		}
+		return categoriesResponse{Categories: list}, nil
+	}
+}
+
+// MakeCategoryBooksEndpoint is the books endpoint of a category, its
+// responses carrying the surrogate key of the category too.
+func MakeCategoryBooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		books, total, e := s.CategoryBooks(ctx, req.CategoryID, req.Limit, req.Offset)
+		if e != nil {
+			return listResponse{Books: make([]Book, 0), Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		return listResponse{Books: books, Total: total, Prev: prev, Next: next, Keys: []string{KeyBooks, CategoryKey(req.CategoryID)}}, nil
+	}
+}
+
+type listRequest struct {
+	CategoryID string
+	Limit      int
+	Offset     int
+	URL        *url.URL
+}
+
+type listResponse struct {
+	Books []Book `json:"books"`
+	Error error  `json:"error,omitempty"`
+
+	Total int      `json:"-"`
+	Prev  string   `json:"-"`
+	Next  string   `json:"-"`
+	Keys  []string `json:"-"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+func (r listResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
+
+func (r listResponse) surrogateKeys() []string {
+	return r.Keys
+}
+
+type bookRequest struct {
+	ID string `json:"id"`
+}
+
+type bookResponse struct {
+	Book  *Book `json:"book,omitempty"`
+	Error error `json:"error,omitempty"`
+}
+
+func (r bookResponse) error() error {
+	return r.Error
+}
+
+func (r bookResponse) surrogateKeys() []string {
+	return []string{BookKey(r.Book.ID)}
+}
+
+type categoriesResponse struct {
+	Categories []Category `json:"categories"`
+	Error      error      `json:"error,omitempty"`
+}
+
+func (r categoriesResponse) error() error {
+	return r.Error
+}
+
+func (r categoriesResponse) surrogateKeys() []string {
+	return []string{KeyCategories, KeyBooks}
+}
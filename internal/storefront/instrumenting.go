@@ -0,0 +1,81 @@
+package storefront
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Books(ctx context.Context, limit, offset int) (books []Book, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "books", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, total, err = mw.next.Books(ctx, limit, offset)
+	return
+}
+
+func (mw instrmw) Book(ctx context.Context, id string) (b Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "book", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	b, err = mw.next.Book(ctx, id)
+	return
+}
+
+func (mw instrmw) Categories(ctx context.Context) (list []Category, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "categories", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Categories(ctx)
+	return
+}
+
+func (mw instrmw) CategoryBooks(ctx context.Context, id string, limit, offset int) (books []Book, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "category_books", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, total, err = mw.next.CategoryBooks(ctx, id, limit, offset)
+	return
+}
+
+func (mw instrmw) Purge(ctx context.Context) (purged int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "purge", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	purged, err = mw.next.Purge(ctx)
+	return
+}
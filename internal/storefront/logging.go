@@ -0,0 +1,85 @@
+package storefront
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Books(ctx context.Context, limit, offset int) (books []Book, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "books",
+			"limit", limit,
+			"offset", offset,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Books(ctx, limit, offset)
+}
+
+func (s loggingService) Book(ctx context.Context, id string) (b Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "book",
+			"id", id,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Book(ctx, id)
+}
+
+func (s loggingService) Categories(ctx context.Context) (list []Category, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "categories",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Categories(ctx)
+}
+
+func (s loggingService) CategoryBooks(ctx context.Context, id string, limit, offset int) (books []Book, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "category_books",
+			"id", id,
+			"limit", limit,
+			"offset", offset,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CategoryBooks(ctx, id, limit, offset)
+}
+
+func (s loggingService) Purge(ctx context.Context) (purged int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "purge",
+			"purged", purged,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Purge(ctx)
+}
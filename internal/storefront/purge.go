@@ -0,0 +1,80 @@
+package storefront
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// Purger purges the responses of surrogate keys from the CDN.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// maxPurgeKeys is the most keys of a purge request, Fastly's limit.
+const maxPurgeKeys = 256
+
+type httpPurger struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPPurger returns a Purger of the Fastly API, posting the keys to
+// purge to url, e.g. "https://api.fastly.com/service/{id}/purge".
+func NewHTTPPurger(url, apiKey string, client *http.Client) Purger {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpPurger{url: url, apiKey: apiKey, client: client}
+}
+
+func (p httpPurger) Purge(ctx context.Context, keys []string) error {
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > maxPurgeKeys {
+			n = maxPurgeKeys
+		}
+		body, err := json.Marshal(map[string][]string{"surrogate_keys": keys[:n]})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest("POST", p.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Fastly-Key", p.apiKey)
+		resp, err := p.client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("purge: %s", resp.Status)
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
+
+// RunPurger purges the changed books every interval until ctx is done.
+func RunPurger(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil {
+				logger.Log("purger", "storefront", "err", err)
+			}
+		}
+	}
+}
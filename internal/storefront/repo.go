@@ -0,0 +1,9 @@
+package storefront
+
+// Repo abstracts all the persistant storage operations of Storefront Service
+type Repo interface {
+	// Cursor returns the seq of the cursor of name, 0 if it has none yet.
+	Cursor(name string) (int64, error)
+	SaveCursor(name string, seq int64) error
+	Drop() error
+}
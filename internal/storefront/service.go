@@ -0,0 +1,162 @@
+package storefront
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+var (
+	ErrBookNotFound     = errors.New("book not found")
+	ErrCategoryNotFound = errors.New("category not found")
+)
+
+// purgeCursor is the name of the cursor of the purger in the change log.
+const purgeCursor = "purge"
+
+// purgeBatch is the number of changes purged at once.
+const purgeBatch = 500
+
+type Service interface {
+	// Books returns a page of the live books by title, along with their
+	// total.
+	Books(ctx context.Context, limit, offset int) ([]Book, int, error)
+
+	// Book returns a live book.
+	Book(ctx context.Context, id string) (Book, error)
+
+	// Categories returns the categories of the live books by name.
+	Categories(ctx context.Context) ([]Category, error)
+
+	// CategoryBooks returns a page of the live books of a category by
+	// title, along with their total.
+	CategoryBooks(ctx context.Context, id string, limit, offset int) ([]Book, int, error)
+
+	// Purge purges the surrogate keys of the books changed since the last
+	// purge, returning the number of keys purged.
+	Purge(ctx context.Context) (int, error)
+}
+
+// Config controls the storefront.
+type Config struct {
+	// Currency is the one of the prices, the base one of the catalog.
+	Currency string
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+	purger  Purger
+	cfg     Config
+}
+
+// NewService return basic Service implementation. A nil purger purges
+// nothing, the CDN then relying on the cache expiry.
+func NewService(r Repo, books catalog.Repo, purger Purger, c Config) Service {
+	return basicService{r: r, catalog: books, purger: purger, cfg: c}
+}
+
+func (s basicService) live() ([]catalog.Book, error) {
+	books, err := s.catalog.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	return live(books, time.Now().UTC()), nil
+}
+
+func (s basicService) Books(_ context.Context, limit, offset int) ([]Book, int, error) {
+	books, err := s.live()
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.page(books, limit, offset), len(books), nil
+}
+
+func (s basicService) Book(_ context.Context, id string) (Book, error) {
+	b, err := s.catalog.GetByID(id)
+	if err != nil || b.Delisted || !b.LiveAt(time.Now().UTC()) {
+		return Book{}, ErrBookNotFound
+	}
+	return public(b, s.cfg.Currency), nil
+}
+
+func (s basicService) Categories(_ context.Context) ([]Category, error) {
+	books, err := s.live()
+	if err != nil {
+		return nil, err
+	}
+	return categories(books), nil
+}
+
+func (s basicService) CategoryBooks(_ context.Context, id string, limit, offset int) ([]Book, int, error) {
+	books, err := s.live()
+	if err != nil {
+		return nil, 0, err
+	}
+	var in []catalog.Book
+	for _, b := range books {
+		for _, g := range b.Genres {
+			if g.ID == id {
+				in = append(in, b)
+				break
+			}
+		}
+	}
+	if len(in) == 0 {
+		return nil, 0, ErrCategoryNotFound
+	}
+	return s.page(in, limit, offset), len(in), nil
+}
+
+func (s basicService) page(books []catalog.Book, limit, offset int) []Book {
+	list := make([]Book, 0, limit)
+	for i := offset; i < len(books) && i < offset+limit; i++ {
+		list = append(list, public(books[i], s.cfg.Currency))
+	}
+	return list
+}
+
+// Purge walks the change log from the cursor of the purger, saving the
+// cursor after each batch purged. A failed purge is retried from the same
+// changes on the next run.
+func (s basicService) Purge(ctx context.Context) (int, error) {
+	seq, err := s.r.Cursor(purgeCursor)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for {
+		changes, err := s.catalog.ListChanges(seq, time.Time{}, purgeBatch)
+		if err != nil || len(changes) == 0 {
+			return purged, err
+		}
+		seen := make(map[string]bool)
+		var keys []string
+		for _, c := range changes {
+			for _, k := range Keys(c.BookID) {
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+		if s.purger != nil {
+			if err := s.purger.Purge(ctx, keys); err != nil {
+				return purged, err
+			}
+			purged += len(keys)
+		}
+		seq = changes[len(changes)-1].Seq
+		if err := s.r.SaveCursor(purgeCursor, seq); err != nil {
+			return purged, err
+		}
+		if len(changes) < purgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
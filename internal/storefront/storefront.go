@@ -0,0 +1,131 @@
+package storefront
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+)
+
+// Surrogate keys of the responses, the CDN purges the responses by them.
+// Every list carries KeyBooks, so that any book change purges them along
+// with the BookKey of the book.
+const (
+	KeyBooks      = "books"
+	KeyCategories = "categories"
+)
+
+// BookKey is the surrogate key of the responses of the book of id.
+func BookKey(id string) string {
+	return "book-" + id
+}
+
+// CategoryKey is the surrogate key of the responses of the category of id.
+func CategoryKey(id string) string {
+	return "category-" + id
+}
+
+// Keys returns the surrogate keys to purge on a change to the book of id.
+func Keys(id string) []string {
+	return []string{BookKey(id), KeyBooks}
+}
+
+// Book is the public view of a live book, without its stock, shelf and
+// vendor.
+type Book struct {
+	ID              string           `json:"id"`
+	ISBN            string           `json:"isbn"`
+	Title           string           `json:"title"`
+	Series          string           `json:"series,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	CoverURL        string           `json:"cover_url,omitempty"`
+	Authors         []catalog.Author `json:"authors"`
+	Categories      []catalog.Genre  `json:"categories"`
+	PublicationYear string           `json:"publication_year"`
+	Price           float64          `json:"price"`
+	Currency        string           `json:"currency,omitempty"`
+	InStock         bool             `json:"in_stock"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// Category is a genre of the live books, along with their number.
+type Category struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Books int    `json:"books"`
+}
+
+// Cursor is the last change of the catalog change log acted upon by a
+// reader of the log, e.g. the purger.
+type Cursor struct {
+	Name      string    `json:"name" gorm:"primary_key"`
+	Seq       int64     `json:"seq"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Cursor) TableName() string {
+	return "storefront_cursors"
+}
+
+// public returns the public view of b, the base price in currency.
+func public(b catalog.Book, currency string) Book {
+	p := Book{
+		ID:              b.ID,
+		ISBN:            b.ISBN,
+		Title:           b.Title,
+		Series:          b.Series,
+		Description:     b.Description,
+		CoverURL:        b.CoverURL,
+		Authors:         b.Authors,
+		Categories:      b.Genres,
+		PublicationYear: b.PublicationYear,
+		Price:           b.Price,
+		Currency:        currency,
+		InStock:         b.Stock > 0 || b.PrintOnDemand,
+		UpdatedAt:       b.UpdatedAt,
+	}
+	if p.Authors == nil {
+		p.Authors = make([]catalog.Author, 0)
+	}
+	if p.Categories == nil {
+		p.Categories = make([]catalog.Genre, 0)
+	}
+	return p
+}
+
+// live returns the books live at now and not delisted, by title.
+func live(books []catalog.Book, now time.Time) []catalog.Book {
+	list := make([]catalog.Book, 0, len(books))
+	for _, b := range books {
+		if !b.Delisted && b.LiveAt(now) {
+			list = append(list, b)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Title != list[j].Title {
+			return list[i].Title < list[j].Title
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// categories returns the genres of the books along with their number of
+// books, by name.
+func categories(books []catalog.Book) []Category {
+	index := make(map[string]int)
+	list := make([]Category, 0)
+	for _, b := range books {
+		for _, g := range b.Genres {
+			i, ok := index[g.ID]
+			if !ok {
+				i = len(list)
+				index[g.ID] = i
+				list = append(list, Category{ID: g.ID, Name: g.Name})
+			}
+			list[i].Books++
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
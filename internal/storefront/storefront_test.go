@@ -0,0 +1,156 @@
+package storefront_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/storefront"
+)
+
+// repo keeps the cursors in memory.
+type repo struct {
+	storefront.Repo
+	cursors map[string]int64
+}
+
+func (r *repo) Cursor(name string) (int64, error) {
+	return r.cursors[name], nil
+}
+
+func (r *repo) SaveCursor(name string, seq int64) error {
+	r.cursors[name] = seq
+	return nil
+}
+
+// catalogRepo keeps the books and their change log in memory.
+type catalogRepo struct {
+	catalog.Repo
+	books   []catalog.Book
+	changes []catalog.Change
+}
+
+func (r *catalogRepo) ListAll() ([]catalog.Book, error) {
+	return r.books, nil
+}
+
+func (r *catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	for _, b := range r.books {
+		if b.ID == ID {
+			return b, nil
+		}
+	}
+	return catalog.Book{}, db.ErrNotFound
+}
+
+func (r *catalogRepo) ListChanges(afterSeq int64, since time.Time, limit int) ([]catalog.Change, error) {
+	var list []catalog.Change
+	for _, c := range r.changes {
+		if c.Seq > afterSeq && len(list) < limit {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+// purger records the keys purged.
+type purger struct {
+	keys []string
+}
+
+func (p *purger) Purge(ctx context.Context, keys []string) error {
+	p.keys = append(p.keys, keys...)
+	return nil
+}
+
+func TestStorefront(t *testing.T) {
+	ctx := context.Background()
+	fantasy := catalog.Genre{ID: "g1", Name: "Fantasy"}
+	books := &catalogRepo{books: []catalog.Book{
+		{ID: "b1", Title: "The Hobbit", Genres: []catalog.Genre{fantasy}, Stock: 2, Location: "B-12"},
+		{ID: "b2", Title: "Dune", Genres: []catalog.Genre{{ID: "g2", Name: "Science fiction"}}, Visibility: catalog.VisibilityDraft},
+		{ID: "b3", Title: "Earthsea", Genres: []catalog.Genre{fantasy}, Delisted: true},
+		{ID: "b4", Title: "Eragon", Genres: []catalog.Genre{fantasy}, PrintOnDemand: true},
+	}}
+	s := storefront.NewService(&repo{cursors: make(map[string]int64)}, books, nil, storefront.Config{Currency: "EUR"})
+
+	if _, err := s.Book(ctx, "b2"); err != storefront.ErrBookNotFound {
+		t.Errorf("draft book: expected ErrBookNotFound, got %v", err)
+	}
+	list, total, err := s.Books(ctx, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(list) != 1 || list[0].ID != "b1" || !list[0].InStock || list[0].Currency != "EUR" {
+		t.Errorf("expected the second live book by title, got %d %+v", total, list)
+	}
+	cats, err := s.Categories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cats) != 1 || cats[0].ID != "g1" || cats[0].Books != 2 {
+		t.Errorf("expected the fantasy of the live books only, got %+v", cats)
+	}
+	if _, _, err := s.CategoryBooks(ctx, "g2", 20, 0); err != storefront.ErrCategoryNotFound {
+		t.Errorf("category of no live book: expected ErrCategoryNotFound, got %v", err)
+	}
+
+	h := storefront.MakeHTTPHandler(ctx, s, storefront.Cache{MaxAge: time.Minute, EdgeMaxAge: time.Hour}, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/public/v1/categories/g1/books", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60, s-maxage=3600, stale-while-revalidate=60, stale-if-error=3600" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+	if k := w.Header().Get("Surrogate-Key"); k != "books category-g1" {
+		t.Errorf("unexpected Surrogate-Key %q", k)
+	}
+	var body struct {
+		Data struct {
+			Books []map[string]interface{} `json:"books"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data.Books) != 2 || body.Data.Books[0]["stock"] != nil || body.Data.Books[0]["location"] != nil {
+		t.Errorf("expected the public view of 2 books, got %+v", body.Data.Books)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/public/v1/books/b2", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Surrogate-Key") != "" {
+		t.Errorf("draft book: expected an uncached 404, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	books := &catalogRepo{changes: []catalog.Change{{Seq: 1, BookID: "b1"}, {Seq: 2, BookID: "b2"}, {Seq: 3, BookID: "b1"}}}
+	r := &repo{cursors: make(map[string]int64)}
+	p := &purger{}
+	s := storefront.NewService(r, books, p, storefront.Config{})
+
+	n, err := s.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(p.keys) != 3 || p.keys[0] != "book-b1" || p.keys[1] != "books" || p.keys[2] != "book-b2" {
+		t.Errorf("expected the keys of b1 and b2 purged once, got %v", p.keys)
+	}
+	books.changes = append(books.changes, catalog.Change{Seq: 4, BookID: "b3"})
+	p.keys = nil
+	if _, err := s.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.keys) != 2 || p.keys[0] != "book-b3" {
+		t.Errorf("expected only the new change purged, got %v", p.keys)
+	}
+}
@@ -0,0 +1,227 @@
+package storefront
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+	ErrBadLimit   = errors.New("limit must be between 1 and 100")
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Defaults of Cache.
+const (
+	DefaultMaxAge     = time.Minute
+	DefaultEdgeMaxAge = 24 * time.Hour
+)
+
+// Cache is how long the responses are cached. The CDN keeps them for
+// EdgeMaxAge, the purges of the changed books evicting them earlier, the
+// browsers for MaxAge.
+type Cache struct {
+	MaxAge     time.Duration
+	EdgeMaxAge time.Duration
+}
+
+// control returns the Cache-Control of the responses. The CDN may serve
+// a stale response while it revalidates it, or when the shop is down.
+func (c Cache) control() string {
+	if c.MaxAge == 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	if c.EdgeMaxAge == 0 {
+		c.EdgeMaxAge = DefaultEdgeMaxAge
+	}
+	return fmt.Sprintf("public, max-age=%d, s-maxage=%d, stale-while-revalidate=%d, stale-if-error=%d",
+		int(c.MaxAge.Seconds()), int(c.EdgeMaxAge.Seconds()), int(c.MaxAge.Seconds()), int(c.EdgeMaxAge.Seconds()))
+}
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrBookNotFound:     "storefront.book_not_found",
+		ErrCategoryNotFound: "storefront.category_not_found",
+		ErrBadLimit:         "storefront.bad_limit",
+	})
+}
+
+func MakeHTTPHandler(ctx context.Context, s Service, c Cache, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+	}
+	encode := encodeCachedResponse(c.control())
+
+	booksHandler := httptransport.NewServer(
+		e.BooksEndpoint,
+		decodeListRequest,
+		encode,
+		options...,
+	)
+	bookHandler := httptransport.NewServer(
+		e.BookEndpoint,
+		decodeBookRequest,
+		encode,
+		options...,
+	)
+	categoriesHandler := httptransport.NewServer(
+		e.CategoriesEndpoint,
+		decodeCategoriesRequest,
+		encode,
+		options...,
+	)
+	categoryBooksHandler := httptransport.NewServer(
+		e.CategoryBooksEndpoint,
+		decodeListRequest,
+		encode,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/public/v1/books", booksHandler).Methods("GET", "HEAD")
+	r.Handle("/public/v1/books/{id}", bookHandler).Methods("GET", "HEAD")
+	r.Handle("/public/v1/categories", categoriesHandler).Methods("GET", "HEAD")
+	r.Handle("/public/v1/categories/{id}/books", categoryBooksHandler).Methods("GET", "HEAD")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	lreq := listRequest{URL: req.URL, CategoryID: mux.Vars(req)["id"], Limit: defaultPageLimit}
+
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return nil, ErrBadLimit
+		}
+		lreq.Limit = n
+	}
+	// Ignoring errors since zero value makes sense for offset
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+	if lreq.Offset < 0 {
+		lreq.Offset = 0
+	}
+	return lreq, nil
+}
+
+func decodeBookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(req)["id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "id")
+	}
+	return bookRequest{ID: id}, nil
+}
+
+func decodeCategoriesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// pager used to paginate any transport response.
+type pager interface {
+	page() (total int, previous, next string)
+}
+
+// keyer is implemented by the responses the CDN caches, tagged with their
+// surrogate keys.
+type keyer interface {
+	surrogateKeys() []string
+}
+
+// encodeCachedResponse adds the cache headers to the responses, the
+// surrogate keys both as Surrogate-Key, of Fastly and Varnish, and as
+// Cache-Tag, of Cloudflare and Akamai. The responses vary on the headers
+// changing their field case and envelope.
+func encodeCachedResponse(control string) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+		if e, ok := d.(errorer); !ok || e.error() == nil {
+			if k, ok := d.(keyer); ok {
+				keys := k.surrogateKeys()
+				w.Header().Set("Cache-Control", control)
+				w.Header().Set("Surrogate-Key", strings.Join(keys, " "))
+				w.Header().Set("Cache-Tag", strings.Join(keys, ","))
+				w.Header().Set("Vary", transport.FieldCaseHeader+", "+transport.RawHeader)
+			}
+		}
+		return encodeResponse(ctx, w, d)
+	}
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+
+	if page, ok := d.(pager); ok {
+		t, p, n := page.page()
+		f.Meta.Total = t
+		f.Meta.Previous = p
+		f.Meta.Next = n
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrBookNotFound, ErrCategoryNotFound:
+		return http.StatusNotFound
+	case ErrBadRouting, ErrBadLimit:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,137 @@
+package support
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the support service endpoints under single type.
+type Endpoints struct {
+	OpenEndpoint         endpoint.Endpoint
+	OrderTicketsEndpoint endpoint.Endpoint
+	UserTicketsEndpoint  endpoint.Endpoint
+	TicketEndpoint       endpoint.Endpoint
+	WebhookEndpoint      endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the support service endpoints. The tickets of the user are
+// restricted by account, e.g. to the unscoped tokens of the users.
+func MakeEndpoints(s Service, account endpoint.Middleware) Endpoints {
+	return Endpoints{
+		OpenEndpoint:         MakeOpenEndpoint(s),
+		OrderTicketsEndpoint: MakeOrderTicketsEndpoint(s),
+		UserTicketsEndpoint:  account(MakeUserTicketsEndpoint(s)),
+		TicketEndpoint:       MakeTicketEndpoint(s),
+		WebhookEndpoint:      MakeWebhookEndpoint(s),
+	}
+}
+
+func MakeOpenEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(openRequest)
+		t, e := s.Open(ctx, req.OrderID, req.NewTicket)
+		if e != nil {
+			return ticketResponse{Ticket: nil, Error: e}, nil
+		}
+		return ticketResponse{Ticket: &t, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeOrderTicketsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ticketsRequest)
+		tickets, e := s.OrderTickets(ctx, req.ID)
+		if e != nil {
+			return ticketsResponse{Tickets: make([]Ticket, 0), Error: e}, nil
+		}
+		return ticketsResponse{Tickets: tickets}, nil
+	}
+}
+
+// MakeUserTicketsEndpoint lists the tickets of the user of the request.
+func MakeUserTicketsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		tickets, e := s.UserTickets(ctx, userID)
+		if e != nil {
+			return ticketsResponse{Tickets: make([]Ticket, 0), Error: e}, nil
+		}
+		return ticketsResponse{Tickets: tickets}, nil
+	}
+}
+
+func MakeTicketEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ticketRequest)
+		t, e := s.Ticket(ctx, req.ID)
+		if e != nil {
+			return ticketResponse{Ticket: nil, Error: e}, nil
+		}
+		return ticketResponse{Ticket: &t}, nil
+	}
+}
+
+func MakeWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(webhookRequest)
+		e := s.Webhook(ctx, req.Signature, req.Body)
+		return webhookResponse{Error: e}, nil
+	}
+}
+
+type openRequest struct {
+	OrderID string `json:"-"`
+	NewTicket
+}
+
+// ticketsRequest lists the tickets of the order ID.
+type ticketsRequest struct {
+	ID string
+}
+
+type ticketRequest struct {
+	ID string
+}
+
+type ticketResponse struct {
+	Status int     `json:"-"`
+	Ticket *Ticket `json:"ticket,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r ticketResponse) status() int {
+	return r.Status
+}
+
+func (r ticketResponse) error() error {
+	return r.Error
+}
+
+type ticketsResponse struct {
+	Tickets []Ticket `json:"tickets"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r ticketsResponse) error() error {
+	return r.Error
+}
+
+type webhookRequest struct {
+	Signature string
+	Body      []byte
+}
+
+type webhookResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r webhookResponse) error() error {
+	return r.Error
+}
@@ -0,0 +1,81 @@
+package support
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Open(ctx context.Context, orderID string, n NewTicket) (ticket Ticket, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "open", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	ticket, err = mw.next.Open(ctx, orderID, n)
+	return
+}
+
+func (mw instrmw) OrderTickets(ctx context.Context, orderID string) (tickets []Ticket, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_tickets", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	tickets, err = mw.next.OrderTickets(ctx, orderID)
+	return
+}
+
+func (mw instrmw) UserTickets(ctx context.Context, userID string) (tickets []Ticket, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "user_tickets", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	tickets, err = mw.next.UserTickets(ctx, userID)
+	return
+}
+
+func (mw instrmw) Ticket(ctx context.Context, ID string) (ticket Ticket, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ticket", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	ticket, err = mw.next.Ticket(ctx, ID)
+	return
+}
+
+func (mw instrmw) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "webhook", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Webhook(ctx, signature, body)
+	return
+}
@@ -0,0 +1,78 @@
+package support
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Open(ctx context.Context, orderID string, n NewTicket) (ticket Ticket, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "open",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Open(ctx, orderID, n)
+}
+
+func (s loggingService) OrderTickets(ctx context.Context, orderID string) (tickets []Ticket, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_tickets",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderTickets(ctx, orderID)
+}
+
+func (s loggingService) UserTickets(ctx context.Context, userID string) (tickets []Ticket, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "user_tickets",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UserTickets(ctx, userID)
+}
+
+func (s loggingService) Ticket(ctx context.Context, ID string) (ticket Ticket, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "ticket",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Ticket(ctx, ID)
+}
+
+func (s loggingService) Webhook(ctx context.Context, signature string, body []byte) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "webhook",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Webhook(ctx, signature, body)
+}
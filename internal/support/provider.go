@@ -0,0 +1,158 @@
+package support
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kavirajk/bookshop/user"
+	"github.com/pkg/errors"
+)
+
+// Provider abstracts the support desk the tickets are handled in.
+type Provider interface {
+	// Name uniquely identifies the provider, empty for the shop itself.
+	Name() string
+
+	// Create opens the ticket in the support desk on behalf of requester,
+	// and returns its id and its URL for the staff.
+	Create(ctx context.Context, t Ticket, requester user.User) (id, url string, err error)
+
+	// VerifyWebhook tells whether the webhook body is signed by the provider.
+	VerifyWebhook(signature string, body []byte) bool
+}
+
+type localProvider struct{}
+
+// NewLocalProvider returns Provider keeping the tickets in the shop only,
+// for shops without a support desk and for development.
+func NewLocalProvider() Provider {
+	return localProvider{}
+}
+
+func (localProvider) Name() string {
+	return ""
+}
+
+func (localProvider) Create(ctx context.Context, t Ticket, requester user.User) (string, string, error) {
+	return "", "", nil
+}
+
+func (localProvider) VerifyWebhook(signature string, body []byte) bool {
+	return false
+}
+
+type zendeskProvider struct {
+	baseURL string
+	apiKey  string
+	secret  string
+	client  *http.Client
+}
+
+// NewZendeskProvider returns Provider opening the tickets through a
+// Zendesk-style tickets API at baseURL. secret is used to verify the
+// webhook signatures.
+func NewZendeskProvider(baseURL, apiKey, secret string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return zendeskProvider{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, secret: secret, client: client}
+}
+
+func (p zendeskProvider) Name() string {
+	return "zendesk"
+}
+
+type zendeskTicket struct {
+	ID         int64           `json:"id,omitempty"`
+	Subject    string          `json:"subject"`
+	Comment    *zendeskComment `json:"comment,omitempty"`
+	Requester  *zendeskUser    `json:"requester,omitempty"`
+	ExternalID string          `json:"external_id"`
+	Tags       []string        `json:"tags,omitempty"`
+	Status     string          `json:"status,omitempty"`
+}
+
+type zendeskComment struct {
+	Body string `json:"body"`
+}
+
+type zendeskUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Create tags the ticket with its category and references the order, so
+// that the agents find the order from the ticket.
+func (p zendeskProvider) Create(ctx context.Context, t Ticket, requester user.User) (string, string, error) {
+	description := t.Description
+	if description == "" {
+		description = t.Subject
+	}
+	body, err := json.Marshal(map[string]zendeskTicket{"ticket": {
+		Subject:   t.Subject,
+		Comment:   &zendeskComment{Body: description + "\n\nOrder: " + t.OrderID},
+		Requester: &zendeskUser{Name: strings.TrimSpace(requester.FirstName + " " + requester.LastName), Email: requester.Email},
+		// The shop ticket id finds the ticket back from the webhooks.
+		ExternalID: t.ID,
+		Tags:       []string{"bookshop", t.Category},
+	}})
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequest("POST", p.baseURL+"/api/v2/tickets.json", bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", "", errors.Wrap(err, "zendesk create")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", "", fmt.Errorf("zendesk create: unexpected status %d", resp.StatusCode)
+	}
+	var res struct {
+		Ticket zendeskTicket `json:"ticket"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", "", errors.Wrap(err, "zendesk create")
+	}
+	id := strconv.FormatInt(res.Ticket.ID, 10)
+	return id, p.baseURL + "/agent/tickets/" + id, nil
+}
+
+// VerifyWebhook checks the hex encoded HMAC-SHA256 of the body.
+func (p zendeskProvider) VerifyWebhook(signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Status maps the status of a support desk ticket to the shop's, the
+// Zendesk new and hold being open and pending.
+func Status(status string) string {
+	switch strings.ToLower(status) {
+	case "new", StatusOpen:
+		return StatusOpen
+	case "hold", StatusPending:
+		return StatusPending
+	case StatusSolved:
+		return StatusSolved
+	case StatusClosed:
+		return StatusClosed
+	}
+	return ""
+}
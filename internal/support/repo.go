@@ -0,0 +1,14 @@
+package support
+
+// Repo abstracts all the persistant storage operations of Support service.
+type Repo interface {
+	Create(t *Ticket) error
+	Save(t *Ticket) error
+	GetByID(ID string) (Ticket, error)
+	GetByExternalID(provider, externalID string) (Ticket, error)
+	// ListByOrder returns the tickets of an order, latest first.
+	ListByOrder(orderID string) ([]Ticket, error)
+	// ListByUser returns the tickets of a user, latest first.
+	ListByUser(userID string) ([]Ticket, error)
+	Drop() error
+}
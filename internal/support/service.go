@@ -0,0 +1,140 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/user"
+)
+
+var (
+	ErrTicketNotFound   = errors.New("ticket not found")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidStatus    = errors.New("unknown ticket status")
+)
+
+type Service interface {
+	// Open opens a ticket about an issue of an order, in the support desk
+	// of the provider, linked to the order and its customer.
+	Open(ctx context.Context, orderID string, n NewTicket) (Ticket, error)
+
+	// OrderTickets returns the tickets of an order, latest first.
+	OrderTickets(ctx context.Context, orderID string) ([]Ticket, error)
+
+	// UserTickets returns the tickets of a user, latest first.
+	UserTickets(ctx context.Context, userID string) ([]Ticket, error)
+
+	// Ticket returns a ticket.
+	Ticket(ctx context.Context, ID string) (Ticket, error)
+
+	// Webhook applies the status update pushed by the provider.
+	Webhook(ctx context.Context, signature string, body []byte) error
+}
+
+type basicService struct {
+	r        Repo
+	orders   order.Repo
+	users    user.Repo
+	provider Provider
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, orders order.Repo, users user.Repo, provider Provider) Service {
+	return basicService{r: r, orders: orders, users: users, provider: provider}
+}
+
+// Open stores the ticket before opening it at the provider, which gets the
+// ticket id to send the webhooks with. A ticket the provider failed to open
+// stays in the shop without ExternalID.
+func (s basicService) Open(ctx context.Context, orderID string, n NewTicket) (Ticket, error) {
+	if err := n.Validate(); err != nil {
+		return Ticket{}, err
+	}
+	o, err := s.orders.GetByID(orderID)
+	if err != nil {
+		return Ticket{}, order.ErrOrderNotFound
+	}
+	requester, err := s.users.GetByID(o.CreatedByID)
+	if err != nil {
+		return Ticket{}, user.ErrUserNotFound
+	}
+	now := time.Now().UTC()
+	t := Ticket{
+		OrderID:     o.ID,
+		UserID:      o.CreatedByID,
+		Category:    n.Category,
+		Subject:     n.Subject,
+		Description: n.Description,
+		Status:      StatusOpen,
+		Provider:    s.provider.Name(),
+		OpenedBy:    n.OpenedBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.r.Create(&t); err != nil {
+		return Ticket{}, err
+	}
+	if t.Provider == "" {
+		return t, nil
+	}
+	if t.ExternalID, t.ExternalURL, err = s.provider.Create(ctx, t, requester); err != nil {
+		return Ticket{}, err
+	}
+	if err := s.r.Save(&t); err != nil {
+		return Ticket{}, err
+	}
+	return t, nil
+}
+
+// OrderTickets returns ErrOrderNotFound for unknown orders.
+func (s basicService) OrderTickets(ctx context.Context, orderID string) ([]Ticket, error) {
+	if _, err := s.orders.GetByID(orderID); err != nil {
+		return nil, order.ErrOrderNotFound
+	}
+	return s.r.ListByOrder(orderID)
+}
+
+// UserTickets returns the tickets of a user.
+func (s basicService) UserTickets(ctx context.Context, userID string) ([]Ticket, error) {
+	return s.r.ListByUser(userID)
+}
+
+// Ticket returns ErrTicketNotFound for unknown IDs.
+func (s basicService) Ticket(ctx context.Context, ID string) (Ticket, error) {
+	t, err := s.r.GetByID(ID)
+	if err == db.ErrNotFound {
+		return Ticket{}, ErrTicketNotFound
+	}
+	return t, err
+}
+
+// Webhook maps the status of the provider to the shop's.
+func (s basicService) Webhook(ctx context.Context, signature string, body []byte) error {
+	if !s.provider.VerifyWebhook(signature, body) {
+		return ErrInvalidSignature
+	}
+	var u Update
+	if err := json.Unmarshal(body, &u); err != nil {
+		return err
+	}
+	status := Status(u.Status)
+	if status == "" {
+		return ErrInvalidStatus
+	}
+	t, err := s.r.GetByExternalID(s.provider.Name(), u.ExternalID)
+	if err != nil {
+		return ErrTicketNotFound
+	}
+	if t.Status == status {
+		return nil
+	}
+	t.Status, t.UpdatedAt = status, time.Now().UTC()
+	return s.r.Save(&t)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
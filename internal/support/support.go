@@ -0,0 +1,85 @@
+package support
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Categories of the order issues a ticket is opened for.
+const (
+	CategoryDamaged   = "damaged"
+	CategoryMissing   = "missing"
+	CategoryLate      = "late"
+	CategoryWrongItem = "wrong_item"
+	CategoryRefund    = "refund"
+	CategoryOther     = "other"
+)
+
+var categories = map[string]bool{
+	CategoryDamaged:   true,
+	CategoryMissing:   true,
+	CategoryLate:      true,
+	CategoryWrongItem: true,
+	CategoryRefund:    true,
+	CategoryOther:     true,
+}
+
+// Statuses of a ticket, kept in sync with the provider by its webhook.
+const (
+	StatusOpen    = "open"
+	StatusPending = "pending"
+	StatusSolved  = "solved"
+	StatusClosed  = "closed"
+)
+
+// Ticket is a support ticket about an order issue, linked to the order and
+// its customer. Provider and ExternalID reference the ticket in the support
+// desk, they are empty for the tickets only tracked by the shop.
+type Ticket struct {
+	ID          string    `json:"id"`
+	OrderID     string    `json:"order_id" gorm:"index"`
+	UserID      string    `json:"user_id" gorm:"index"`
+	Category    string    `json:"category"`
+	Subject     string    `json:"subject"`
+	Description string    `json:"description" sql:"type:text"`
+	Status      string    `json:"status"`
+	Provider    string    `json:"provider,omitempty"`
+	ExternalID  string    `json:"external_id,omitempty"`
+	ExternalURL string    `json:"external_url,omitempty"`
+	OpenedBy    string    `json:"opened_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Ticket) TableName() string {
+	return "support_tickets"
+}
+
+// NewTicket is the request to open a ticket about an order. OpenedBy is
+// the customer or the staff member reporting the issue.
+type NewTicket struct {
+	Category    string `json:"category"`
+	Subject     string `json:"subject"`
+	Description string `json:"description"`
+	OpenedBy    string `json:"opened_by"`
+}
+
+// Validate checks the ticket is complete.
+func (n *NewTicket) Validate() error {
+	var v validate.Validator
+	if v.Required("category", n.Category) {
+		v.Check(categories[n.Category], "category", validate.CodeInvalid, "category must be damaged, missing, late, wrong_item, refund or other")
+	}
+	v.Required("subject", n.Subject)
+	v.MaxLength("subject", n.Subject, 200)
+	v.MaxLength("description", n.Description, 5000)
+	v.Required("opened_by", n.OpenedBy)
+	return v.Err()
+}
+
+// Update is the status of a ticket pushed by the provider.
+type Update struct {
+	ExternalID string `json:"id"`
+	Status     string `json:"status"`
+}
@@ -0,0 +1,107 @@
+package support_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/support"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// repo has a ticket for each of u1 and u2.
+type repo struct {
+	support.Repo
+}
+
+func (repo) ListByUser(userID string) ([]support.Ticket, error) {
+	tickets := make([]support.Ticket, 0)
+	for _, t := range []support.Ticket{{ID: "t1", UserID: "u1"}, {ID: "t2", UserID: "u2"}} {
+		if t.UserID == userID {
+			tickets = append(tickets, t)
+		}
+	}
+	return tickets, nil
+}
+
+func TestHandler(t *testing.T) {
+	name := func(n string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte(n)) })
+	}
+	h := support.Handler(name("tickets"), name("orders"))
+	for path, want := range map[string]string{
+		"/orders/v1/o1/tickets": "tickets",
+		"/orders/v1/o1":         "orders",
+		"/orders/v1/tickets":    "orders",
+		"/orders/v1//tickets":   "orders",
+		"/orders/v1/o1/items":   "orders",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if got := w.Body.String(); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	for status, want := range map[string]string{
+		"new":     support.StatusOpen,
+		"Open":    support.StatusOpen,
+		"hold":    support.StatusPending,
+		"solved":  support.StatusSolved,
+		"closed":  support.StatusClosed,
+		"deleted": "",
+	} {
+		if got := support.Status(status); got != want {
+			t.Errorf("%s: expected %q, got %q", status, want, got)
+		}
+	}
+}
+
+func TestUserTickets(t *testing.T) {
+	s := support.NewService(repo{}, nil, nil, support.NewLocalProvider())
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	h := support.MakeHTTPHandler(context.Background(), s, account, log.NewNopLogger())
+	u1, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	scoped, _, _ := tokens.SignScoped("u9", user.RoleAdmin, "", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, path, token string
+		status            int
+	}{
+		{"without token", "/support/v1/tickets", "", http.StatusUnauthorized},
+		{"scoped token", "/support/v1/tickets", scoped, http.StatusForbidden},
+		{"tickets", "/support/v1/tickets", u1, http.StatusOK},
+		{"at the user route", "/support/v1/users/u2/tickets", u1, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+		if c.status == http.StatusOK {
+			var res struct {
+				Data struct {
+					Tickets []support.Ticket `json:"tickets"`
+				} `json:"data"`
+			}
+			json.NewDecoder(w.Body).Decode(&res)
+			if len(res.Data.Tickets) != 1 || res.Data.Tickets[0].ID != "t1" {
+				t.Errorf("%s: expected the tickets of the user of the token, got %+v", c.name, res.Data.Tickets)
+			}
+		}
+	}
+}
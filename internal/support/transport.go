@@ -0,0 +1,220 @@
+package support
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrTicketNotFound: "support.ticket_not_found",
+		ErrInvalidStatus:  "support.invalid_status",
+	})
+}
+
+// maxWebhookSize limits the webhook body read into memory.
+const maxWebhookSize = 1 << 20
+
+// MakeHTTPHandler mounts the support endpoints, the tickets of the user
+// served to the requests account lets through, e.g. auth.NewMiddleware
+// chained with rbac.RequireUnscoped.
+func MakeHTTPHandler(ctx context.Context, s Service, account endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	openHandler := httptransport.NewServer(
+		e.OpenEndpoint,
+		decodeOpenRequest,
+		encodeResponse,
+		options...,
+	)
+	orderTicketsHandler := httptransport.NewServer(
+		e.OrderTicketsEndpoint,
+		decodeTicketsRequest("order-id"),
+		encodeResponse,
+		options...,
+	)
+	userTicketsHandler := httptransport.NewServer(
+		e.UserTicketsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	ticketHandler := httptransport.NewServer(
+		e.TicketEndpoint,
+		decodeTicketRequest,
+		encodeResponse,
+		options...,
+	)
+	webhookHandler := httptransport.NewServer(
+		e.WebhookEndpoint,
+		decodeWebhookRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/orders/v1/{order-id}/tickets", openHandler).Methods("POST")
+	r.Handle("/support/v1/webhook", webhookHandler).Methods("POST")
+	r.Handle("/support/v1/tickets", userTicketsHandler).Methods("GET")
+
+	// Staff endpoints
+	r.Handle("/orders/v1/{order-id}/tickets", orderTicketsHandler).Methods("GET")
+	r.Handle("/support/v1/tickets/{ticket-id}", ticketHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+// Handler hands the ticket routes of the orders, /orders/v1/{id}/tickets,
+// to tickets and the other requests to next, the order handler.
+func Handler(tickets, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/orders/v1/"), "/")
+		if len(parts) == 2 && parts[0] != "" && parts[1] == "tickets" {
+			tickets.ServeHTTP(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func decodeOpenRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r openRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeTicketsRequest(name string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		ID, ok := mux.Vars(req)[name]
+		if !ok {
+			return nil, errors.Wrap(ErrBadRouting, name)
+		}
+		return ticketsRequest{ID: ID}, nil
+	}
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeTicketRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	ID, ok := mux.Vars(req)["ticket-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "ticket-id")
+	}
+	return ticketRequest{ID: ID}, nil
+}
+
+func decodeWebhookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxWebhookSize))
+	if err != nil {
+		return nil, err
+	}
+	return webhookRequest{
+		Signature: req.Header.Get("X-Signature"),
+		Body:      body,
+	}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case ErrTicketNotFound, order.ErrOrderNotFound, user.ErrUserNotFound:
+		return http.StatusNotFound
+	case ErrInvalidSignature, auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrBadRouting, ErrInvalidStatus, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,207 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrAddressNotFound  = errors.New("address not found")
+	ErrNoDefaultAddress = errors.New("user has no default address")
+	ErrTooManyAddresses = errors.New("address book is full")
+)
+
+// MaxAddresses is the most addresses in the address book of a user.
+const MaxAddresses = 20
+
+// Address is an entry of the address book of a user, to ship or bill to.
+// The default one is the one checkout preselects.
+type Address struct {
+	ID     string `json:"id"`
+	UserID string `json:"-" sql:"index"`
+	// Label names the address for the user, e.g. "Home" or "Office".
+	Label      string `json:"label,omitempty"`
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	// Country is the ISO 3166-1 alpha-2 code, e.g. "DE".
+	Country   string    `json:"country"`
+	Phone     string    `json:"phone,omitempty"`
+	IsDefault bool      `json:"default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Address) TableName() string {
+	return "user_addresses"
+}
+
+// Normalize trims and collapses white space, upper cases the country and the
+// postal code.
+func (a *Address) Normalize() {
+	clean := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	a.Label, a.Name, a.Phone = clean(a.Label), clean(a.Name), clean(a.Phone)
+	a.Line1, a.Line2, a.City, a.Region = clean(a.Line1), clean(a.Line2), clean(a.City), clean(a.Region)
+	a.PostalCode, a.Country = strings.ToUpper(clean(a.PostalCode)), strings.ToUpper(clean(a.Country))
+}
+
+// Validate checks the address can be shipped to, reporting all the failing
+// fields.
+func (a Address) Validate() error {
+	var v validate.Validator
+	for _, f := range []struct {
+		name, value string
+		required    bool
+		max         int
+	}{
+		{"label", a.Label, false, 40},
+		{"name", a.Name, true, 100},
+		{"line1", a.Line1, true, 100},
+		{"line2", a.Line2, false, 100},
+		{"city", a.City, true, 100},
+		{"region", a.Region, false, 100},
+		{"postal_code", a.PostalCode, true, 20},
+		{"phone", a.Phone, false, 30},
+	} {
+		if f.required && !v.Required(f.name, f.value) {
+			continue
+		}
+		v.MaxLength(f.name, f.value, f.max)
+	}
+	if v.Required("country", a.Country) {
+		v.Check(len(a.Country) == 2 && strings.IndexFunc(a.Country, func(r rune) bool { return !unicode.IsUpper(r) }) < 0,
+			"country", validate.CodeInvalid, "country is not an ISO 3166-1 alpha-2 code")
+	}
+	return v.Err()
+}
+
+func (s service) Addresses(_ context.Context, userID string) ([]Address, error) {
+	if _, err := s.repo.GetByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListAddresses(userID)
+}
+
+func (s service) Address(_ context.Context, userID, addressID string) (Address, error) {
+	return s.address(userID, addressID)
+}
+
+// address returns the address of the user, ErrAddressNotFound for the
+// ones of the other users.
+func (s service) address(userID, addressID string) (Address, error) {
+	list, err := s.repo.ListAddresses(userID)
+	if err != nil {
+		return Address{}, err
+	}
+	for _, a := range list {
+		if a.ID == addressID {
+			return a, nil
+		}
+	}
+	return Address{}, ErrAddressNotFound
+}
+
+// CreateAddress makes the first address of the user the default one.
+func (s service) CreateAddress(_ context.Context, userID string, a Address) (Address, error) {
+	a.Normalize()
+	if err := a.Validate(); err != nil {
+		return Address{}, err
+	}
+	if err := s.writable(userID); err != nil {
+		return Address{}, err
+	}
+	list, err := s.repo.ListAddresses(userID)
+	if err != nil {
+		return Address{}, err
+	}
+	if len(list) >= MaxAddresses {
+		return Address{}, ErrTooManyAddresses
+	}
+	now := time.Now().UTC()
+	a.ID, a.UserID, a.CreatedAt, a.UpdatedAt = "", userID, now, now
+	a.IsDefault = a.IsDefault || len(list) == 0
+	if err := s.repo.CreateAddress(&a); err != nil {
+		return Address{}, err
+	}
+	return a, nil
+}
+
+// UpdateAddress replaces the address. The default address stays the
+// default until another one is made the default.
+func (s service) UpdateAddress(_ context.Context, userID, addressID string, a Address) (Address, error) {
+	a.Normalize()
+	if err := a.Validate(); err != nil {
+		return Address{}, err
+	}
+	if err := s.writable(userID); err != nil {
+		return Address{}, err
+	}
+	prev, err := s.address(userID, addressID)
+	if err != nil {
+		return Address{}, err
+	}
+	a.ID, a.UserID, a.CreatedAt, a.UpdatedAt = prev.ID, userID, prev.CreatedAt, time.Now().UTC()
+	a.IsDefault = a.IsDefault || prev.IsDefault
+	if err := s.repo.SaveAddress(&a); err != nil {
+		return Address{}, err
+	}
+	return a, nil
+}
+
+// DeleteAddress makes the latest updated address left the default one
+// when the default is deleted.
+func (s service) DeleteAddress(_ context.Context, userID, addressID string) error {
+	if err := s.writable(userID); err != nil {
+		return err
+	}
+	a, err := s.address(userID, addressID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAddress(a.ID); err != nil {
+		return err
+	}
+	if !a.IsDefault {
+		return nil
+	}
+	list, err := s.repo.ListAddresses(userID)
+	if err != nil || len(list) == 0 {
+		return err
+	}
+	next := list[0]
+	next.IsDefault = true
+	return s.repo.SaveAddress(&next)
+}
+
+func (s service) DefaultAddress(_ context.Context, userID string) (Address, error) {
+	list, err := s.repo.ListAddresses(userID)
+	if err != nil {
+		return Address{}, err
+	}
+	if len(list) == 0 || !list[0].IsDefault {
+		return Address{}, ErrNoDefaultAddress
+	}
+	return list[0], nil
+}
+
+// writable checks the user exists and isn't deleted.
+func (s service) writable(userID string) error {
+	u, err := s.repo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if u.DeletedAt != nil {
+		return ErrDeactivated
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func home(label string) user.Address {
+	return user.Address{Label: label, Name: " Anna  Berg", Line1: "Hauptstr. 1", City: "Berlin", PostalCode: "10115", Country: "de"}
+}
+
+func TestAddresses(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", Username: "anna"})
+	s := user.NewService(r, nil, user.Config{})
+
+	if _, err := s.DefaultAddress(ctx, "u1"); err != user.ErrNoDefaultAddress {
+		t.Errorf("empty address book: expected ErrNoDefaultAddress, got %v", err)
+	}
+	a1, err := s.CreateAddress(ctx, "u1", home("Home"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a1.IsDefault || a1.Name != "Anna Berg" || a1.Country != "DE" {
+		t.Errorf("expected the first address normalized and the default, got %+v", a1)
+	}
+	a2, err := s.CreateAddress(ctx, "u1", home("Office"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2.IsDefault {
+		t.Errorf("expected the second address not the default")
+	}
+
+	a2.IsDefault = true
+	if _, err := s.UpdateAddress(ctx, "u1", a2.ID, a2); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := s.DefaultAddress(ctx, "u1"); d.ID != a2.ID {
+		t.Errorf("expected the office the default, got %+v", d)
+	}
+	a2.IsDefault = false
+	if a, _ := s.UpdateAddress(ctx, "u1", a2.ID, a2); !a.IsDefault {
+		t.Errorf("expected the default to stay the default")
+	}
+	if _, err := s.UpdateAddress(ctx, "u2", a1.ID, a1); err != user.ErrUserNotFound {
+		t.Errorf("another user: expected ErrUserNotFound, got %v", err)
+	}
+
+	if err := s.DeleteAddress(ctx, "u1", a2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := s.DefaultAddress(ctx, "u1"); d.ID != a1.ID {
+		t.Errorf("expected the home the default after deleting the office, got %+v", d)
+	}
+	if _, err := s.Address(ctx, "u1", a2.ID); err != user.ErrAddressNotFound {
+		t.Errorf("deleted address: expected ErrAddressNotFound, got %v", err)
+	}
+}
+
+func TestAddressLimits(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", Username: "anna"})
+	s := user.NewService(r, nil, user.Config{})
+
+	bad := home("Home")
+	bad.Line1, bad.Country = "", "Germany"
+	if _, err := s.CreateAddress(ctx, "u1", bad); len(validate.Fields(err)) != 2 {
+		t.Errorf("expected line1 and country failing, got %v", err)
+	}
+	for i := 0; i < user.MaxAddresses; i++ {
+		if _, err := s.CreateAddress(ctx, "u1", home("")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.CreateAddress(ctx, "u1", home("")); err != user.ErrTooManyAddresses {
+		t.Errorf("full address book: expected ErrTooManyAddresses, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var (
+	ErrBanned                = errors.New("user is banned")
+	ErrNotBanned             = errors.New("user is not banned")
+	ErrBanReasonRequired     = errors.New("ban reason is required")
+	ErrPasswordResetRequired = errors.New("password must be reset before login")
+	ErrOwnAccount            = errors.New("admins can't ban or change the role of their own account")
+	ErrLoginsUnavailable     = errors.New("login history not available")
+)
+
+// Ban deactivates the account like a deletion would, but keeps it listed
+// and can be undone, see Unban.
+func (s service) Ban(_ context.Context, adminID, userID, reason string) (User, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return User{}, ErrBanReasonRequired
+	}
+	if adminID == userID {
+		return User{}, ErrOwnAccount
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	switch {
+	case user.DeletedAt != nil:
+		return User{}, ErrDeactivated
+	case user.BannedAt != nil:
+		return User{}, ErrBanned
+	}
+	now := time.Now().UTC()
+	user.BanDeactivated = !user.Deactivated
+	user.Deactivated, user.AuthToken = true, ""
+	user.BannedAt, user.BannedBy, user.BanReason = &now, adminID, reason
+	if err := s.repo.Save(&user); err != nil {
+		return User{}, err
+	}
+	if err := s.logout(userID, now); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Unban leaves the accounts deactivated before the ban deactivated.
+func (s service) Unban(_ context.Context, userID string) (User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	switch {
+	case user.DeletedAt != nil:
+		return User{}, ErrDeactivated
+	case user.BannedAt == nil:
+		return User{}, ErrNotBanned
+	}
+	if user.BanDeactivated {
+		user.Deactivated, user.BanDeactivated = false, false
+	}
+	user.BannedAt, user.BannedBy, user.BanReason = nil, "", ""
+	if err := s.repo.Save(&user); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// ForcePasswordReset sends no reset to the deactivated users, nor without
+// a notifier, they can still use a forgotten password once reactivated.
+func (s service) ForcePasswordReset(_ context.Context, userID string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.DeletedAt != nil {
+		return ErrDeactivated
+	}
+	now := time.Now().UTC()
+	user.PasswordResetRequired, user.AuthToken = true, ""
+	if err := s.repo.Save(&user); err != nil {
+		return err
+	}
+	if err := s.logout(userID, now); err != nil {
+		return err
+	}
+	if user.Deactivated || s.notifier == nil {
+		return nil
+	}
+	return s.sendReset(user)
+}
+
+// SetRole logs the user out of every session when the role changes, so
+// that their tokens don't keep the former one.
+func (s service) SetRole(_ context.Context, adminID, userID, role string) (User, error) {
+	if !ValidRole(role) {
+		return User{}, ErrInvalidRole
+	}
+	if adminID == userID {
+		return User{}, ErrOwnAccount
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	if user.DeletedAt != nil {
+		return User{}, ErrDeactivated
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	user.Role, user.AuthToken = role, ""
+	if err := s.repo.Save(&user); err != nil {
+		return User{}, err
+	}
+	if err := s.logout(userID, time.Now().UTC()); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// LoginHistory takes the failed logins of the user by their current email,
+// the audit log only knows the user of the successful ones.
+func (s service) LoginHistory(ctx context.Context, userID string, limit, offset int) ([]audit.Event, int, error) {
+	if s.cfg.Logins == nil {
+		return nil, 0, ErrLoginsUnavailable
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, 0, ErrUserNotFound
+	}
+	f := filter.Expr{
+		SQL:  "action = ? AND (actor_id = ? OR (actor_id = '' AND actor = ?))",
+		Args: []interface{}{audit.ActionLogin, user.ID, user.Email},
+	}
+	return s.cfg.Logins.Events(ctx, f, limit, offset, db.CountExact)
+}
+
+// canLogin tells why the user can't log in, by a password or a social
+// login alike.
+func canLogin(user User) error {
+	switch {
+	case user.BannedAt != nil:
+		return ErrBanned
+	case user.Deactivated:
+		return ErrDeactivated
+	case user.PasswordResetRequired:
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+// logout revokes the sessions and the trusted devices of the user.
+func (s service) logout(userID string, at time.Time) error {
+	if err := s.repo.RevokeSessions(userID, at); err != nil {
+		return err
+	}
+	return s.repo.RevokeTrustedDevices(userID, at)
+}
@@ -0,0 +1,196 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// loginLog returns the events of the audit log, recording the filter of
+// the last list.
+type loginLog struct {
+	audit.Service
+	events []audit.Event
+	f      filter.Expr
+}
+
+func (l *loginLog) Events(_ context.Context, f filter.Expr, limit, offset int, count db.Count) ([]audit.Event, int, error) {
+	l.f = f
+	return l.events, len(l.events), nil
+}
+
+// newAdminRepo returns a repo of the customer u1 and of an admin, along
+// with users.
+func newAdminRepo(t *testing.T, users ...user.User) *memRepo {
+	nu := user.NewUser{Email: "jo@example.com", Password: "secret"}
+	u := nu.User()
+	u.ID = "u1"
+	return newRepo(t, append([]user.User{u, {ID: "admin", Role: user.RoleAdmin}}, users...)...)
+}
+
+func TestBan(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t)
+	s := user.NewService(r, nil, user.Config{})
+
+	if _, err := s.Ban(ctx, "admin", "u1", " "); err != user.ErrBanReasonRequired {
+		t.Errorf("no reason: expected ErrBanReasonRequired, got %v", err)
+	}
+	if _, err := s.Ban(ctx, "admin", "admin", "spam"); err != user.ErrOwnAccount {
+		t.Errorf("own account: expected ErrOwnAccount, got %v", err)
+	}
+	u, err := s.Ban(ctx, "admin", "u1", "spam")
+	if err != nil || !u.Deactivated || u.BannedAt == nil || u.BannedBy != "admin" || r.logouts["u1"] != 1 {
+		t.Fatalf("ban: expected the user banned and logged out, got %+v, %v", u, err)
+	}
+	if _, err := s.Ban(ctx, "admin", "u1", "spam"); err != user.ErrBanned {
+		t.Errorf("banned: expected ErrBanned, got %v", err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != user.ErrBanned {
+		t.Errorf("login: expected ErrBanned, got %v", err)
+	}
+
+	if u, err = s.Unban(ctx, "u1"); err != nil || u.Deactivated || u.BannedAt != nil || u.BanReason != "" {
+		t.Fatalf("unban: expected the user reactivated, got %+v, %v", u, err)
+	}
+	if _, err := s.Unban(ctx, "u1"); err != user.ErrNotBanned {
+		t.Errorf("unbanned: expected ErrNotBanned, got %v", err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != nil {
+		t.Errorf("login: unexpected error %v", err)
+	}
+}
+
+func TestUnbanKeepsDeactivated(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t)
+	r.set(t, "u1", func(u *user.User) { u.Deactivated = true })
+	s := user.NewService(r, nil, user.Config{})
+
+	if _, err := s.Ban(ctx, "admin", "u1", "spam"); err != nil {
+		t.Fatalf("ban: unexpected error %v", err)
+	}
+	if u, err := s.Unban(ctx, "u1"); err != nil || !u.Deactivated || u.BannedAt != nil {
+		t.Fatalf("unban: expected the user still deactivated, got %+v, %v", u, err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != user.ErrDeactivated {
+		t.Errorf("login: expected ErrDeactivated, got %v", err)
+	}
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t)
+	sent := outbox{}
+	s := user.NewService(r, sent, user.Config{})
+
+	if err := s.ForcePasswordReset(ctx, "u1"); err != nil || r.logouts["u1"] != 1 {
+		t.Fatalf("force: expected the user logged out, got %v", err)
+	}
+	token, ok := sent["jo@example.com"]
+	if !ok {
+		t.Fatal("force: expected a reset sent")
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != user.ErrPasswordResetRequired {
+		t.Errorf("login: expected ErrPasswordResetRequired, got %v", err)
+	}
+	if err := s.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("reset: unexpected error %v", err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "new-password"); err != nil {
+		t.Errorf("login after reset: unexpected error %v", err)
+	}
+	if err := s.ForcePasswordReset(ctx, "u2"); err != user.ErrUserNotFound {
+		t.Errorf("unknown: expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t)
+	s := user.NewService(r, nil, user.Config{})
+
+	if _, err := s.SetRole(ctx, "admin", "u1", "owner"); err != user.ErrInvalidRole {
+		t.Errorf("unknown role: expected ErrInvalidRole, got %v", err)
+	}
+	if _, err := s.SetRole(ctx, "admin", "admin", user.RoleCustomer); err != user.ErrOwnAccount {
+		t.Errorf("own account: expected ErrOwnAccount, got %v", err)
+	}
+	if u, err := s.SetRole(ctx, "admin", "u1", user.RoleCustomer); err != nil || r.logouts["u1"] != 0 {
+		t.Errorf("same role: expected no change, got %+v, %v", u, err)
+	}
+	if u, err := s.SetRole(ctx, "admin", "u1", user.RoleSupport); err != nil || u.Role != user.RoleSupport || r.logouts["u1"] != 1 {
+		t.Errorf("support: expected the role changed and the user logged out, got %+v, %v", u, err)
+	}
+}
+
+func TestLoginHistory(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t)
+	if _, _, err := user.NewService(r, nil, user.Config{}).LoginHistory(ctx, "u1", 20, 0); err != user.ErrLoginsUnavailable {
+		t.Errorf("no audit log: expected ErrLoginsUnavailable, got %v", err)
+	}
+
+	logins := &loginLog{events: []audit.Event{{ID: "e1", Action: audit.ActionLogin, ActorID: "u1"}}}
+	s := user.NewService(r, nil, user.Config{Logins: logins})
+	events, total, err := s.LoginHistory(ctx, "u1", 20, 0)
+	if err != nil || total != 1 || len(events) != 1 {
+		t.Fatalf("history: expected the login, got %v, %d, %v", events, total, err)
+	}
+	if args := logins.f.Args; len(args) != 3 || args[0] != audit.ActionLogin || args[1] != "u1" || args[2] != "jo@example.com" {
+		t.Errorf("history: expected the logins of u1 by ID or email, got %v", args)
+	}
+	if _, _, err := s.LoginHistory(ctx, "u2", 20, 0); err != user.ErrUserNotFound {
+		t.Errorf("unknown: expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestBanAndSetRoleRevokeTokens(t *testing.T) {
+	ctx := context.Background()
+	r := newAdminRepo(t, user.User{ID: "a2", Role: user.RoleAdmin})
+	s := user.NewService(r, nil, user.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), user.NewSessions(r))
+
+	scoped := func() string {
+		session, err := s.StartTokenSession(ctx, "u1", user.Client{}, []string{user.ScopeUsersRead}, "", time.Hour)
+		if err != nil {
+			t.Fatalf("start: unexpected error %v", err)
+		}
+		token, _, _ := tokens.SignScoped("u1", user.RoleCustomer, session.ID, []string{user.ScopeUsersRead}, time.Hour)
+		return token
+	}
+
+	token := scoped()
+	if _, err := s.SetRole(ctx, "admin", "u1", user.RoleSupport); err != nil {
+		t.Fatalf("set role: unexpected error %v", err)
+	}
+	if _, err := tokens.Verify(token); err != auth.ErrRevokedToken {
+		t.Errorf("set role: expected the scoped token revoked, got %v", err)
+	}
+
+	token = scoped()
+	session, _ := s.StartTokenSession(ctx, "u1", user.Client{}, nil, "a2", time.Minute)
+	impersonation, _, _ := tokens.SignImpersonation("u1", user.RoleSupport, session.ID, "a2", time.Minute)
+	if _, err := s.SetRole(ctx, "admin", "a2", user.RoleCustomer); err != nil {
+		t.Fatalf("demote: unexpected error %v", err)
+	}
+	if _, err := tokens.Verify(impersonation); err != auth.ErrRevokedToken {
+		t.Errorf("demote: expected the impersonation token revoked, got %v", err)
+	}
+	if _, err := tokens.Verify(token); err != nil {
+		t.Errorf("demote: expected the token of u1 kept, got %v", err)
+	}
+
+	if _, err := s.Ban(ctx, "admin", "u1", "spam"); err != nil {
+		t.Fatalf("ban: unexpected error %v", err)
+	}
+	if _, err := tokens.Verify(token); err != auth.ErrRevokedToken {
+		t.Errorf("ban: expected the scoped token revoked, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package user
+
+import "github.com/kavirajk/bookshop/audit"
+
+// The describers tell the audit log about the register, login, password
+// and role change, account deletion, ban and forced password reset
+// requests, see audit.NewMiddleware.
+
+func describeRegister(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionRegister, Actor: request.(registerRequest).Email}
+	res, ok := response.(registerResponse)
+	if !ok {
+		return e, nil
+	}
+	if res.User != nil {
+		e.ActorID = res.User.ID
+	}
+	return e, res.Error
+}
+
+func describeLogin(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionLogin}
+	if req, ok := request.(loginRequest); ok {
+		e.Actor = req.Email
+	} else {
+		e.Subject = "two_factor"
+	}
+	res, ok := response.(loginResponse)
+	if !ok {
+		return e, nil
+	}
+	if res.User != nil {
+		e.ActorID = res.User.ID
+	}
+	return e, res.Error
+}
+
+func describeChangePassword(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionPasswordChange}
+	res, _ := response.(changePasswordResponse)
+	return e, res.Error
+}
+
+// describeResetPassword records the resets by reset token, the user being
+// unknown to the request.
+func describeResetPassword(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionPasswordChange, Subject: "reset"}
+	res, _ := response.(resetPasswordResponse)
+	return e, res.Error
+}
+
+// describeStartJob only records the jobs changing the role of users.
+func describeStartJob(request, response interface{}) (audit.Event, error) {
+	req := request.(startJobRequest)
+	if req.Op != OpRole {
+		return audit.Event{}, nil
+	}
+	e := audit.Event{Action: audit.ActionRoleChange, Subject: req.Role}
+	res, _ := response.(jobResponse)
+	return e, res.Error
+}
+
+// describeDelete records the deleted user, the actor being the user or an
+// admin.
+func describeDelete(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionDelete, Subject: request.(revokeUserRequest).UserID}
+	res, _ := response.(revokeResponse)
+	return e, res.Error
+}
+
+// describeImpersonate records the admin of the request impersonating the
+// user.
+func describeImpersonate(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionImpersonate, Subject: request.(revokeUserRequest).UserID}
+	res, _ := response.(impersonateResponse)
+	return e, res.Error
+}
+
+// describeBan records the banned user along with the reason, the actor
+// being the admin.
+func describeBan(request, response interface{}) (audit.Event, error) {
+	req := request.(banRequest)
+	e := audit.Event{Action: audit.ActionBan, Subject: req.UserID + ": " + req.Reason}
+	res, _ := response.(profileResponse)
+	return e, res.Error
+}
+
+func describeUnban(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionUnban, Subject: request.(revokeUserRequest).UserID}
+	res, _ := response.(profileResponse)
+	return e, res.Error
+}
+
+func describeForcePasswordReset(request, response interface{}) (audit.Event, error) {
+	e := audit.Event{Action: audit.ActionPasswordReset, Subject: request.(revokeUserRequest).UserID}
+	res, _ := response.(revokeResponse)
+	return e, res.Error
+}
+
+// describeSetRole records the user and the role they're changed to, as
+// "<user-id>: <role>".
+func describeSetRole(request, response interface{}) (audit.Event, error) {
+	req := request.(roleRequest)
+	e := audit.Event{Action: audit.ActionRoleChange, Subject: req.UserID + ": " + req.Role}
+	res, _ := response.(profileResponse)
+	return e, res.Error
+}
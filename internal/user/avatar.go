@@ -0,0 +1,148 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"time"
+
+	// The decoders of the uploaded avatars.
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/kavirajk/bookshop/db"
+)
+
+var (
+	ErrInvalidImage       = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrAvatarTooLarge     = errors.New("avatar is too large")
+	ErrAvatarConflict     = errors.New("profile changed during the avatar upload, upload it again")
+	ErrAvatarsUnavailable = errors.New("avatar upload not available")
+)
+
+// Avatar upload limits.
+const (
+	MaxAvatarSize   = 5 << 20  // bytes of the uploaded file
+	MaxAvatarPixels = 25000000 // pixels of the uploaded image, e.g. 5000x5000
+)
+
+// AvatarSizes are the sides in pixels of the square avatars an upload is
+// resized to, the largest one becoming the avatar of the profile.
+var AvatarSizes = []int{64, 128, 256}
+
+// avatarQuality is the JPEG quality the avatars are stored at.
+const avatarQuality = 85
+
+// Avatar is an avatar of the user of a size.
+type Avatar struct {
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+}
+
+// UploadAvatar crops the image to its center square, stores it at each
+// of AvatarSizes, and sets the largest one as the avatar of the user. Each
+// upload is stored under new keys, the caches never serve a stale avatar.
+func (s service) UploadAvatar(ctx context.Context, userID string, data []byte) (User, []Avatar, error) {
+	if s.cfg.Avatars == nil {
+		return User{}, nil, ErrAvatarsUnavailable
+	}
+	if len(data) > MaxAvatarSize {
+		return User{}, nil, ErrAvatarTooLarge
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, nil, ErrUserNotFound
+	}
+	if user.DeletedAt != nil {
+		return User{}, nil, ErrDeactivated
+	}
+	img, err := decodeAvatar(data)
+	if err != nil {
+		return User{}, nil, err
+	}
+
+	stamp := time.Now().UTC().UnixNano()
+	var avatars []Avatar
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeSquare(img, size), &jpeg.Options{Quality: avatarQuality}); err != nil {
+			return User{}, nil, err
+		}
+		key := fmt.Sprintf("avatars/%s/%d-%d.jpg", user.ID, stamp, size)
+		u, err := s.cfg.Avatars.Put(ctx, key, "image/jpeg", buf.Bytes())
+		if err != nil {
+			return User{}, nil, err
+		}
+		avatars = append(avatars, Avatar{Size: size, URL: u})
+	}
+	user.Avatar = avatars[len(avatars)-1].URL
+	if err := s.repo.Save(&user); err != nil {
+		if err == db.ErrConflict {
+			return User{}, nil, ErrAvatarConflict
+		}
+		return User{}, nil, err
+	}
+	return user, avatars, nil
+}
+
+// decodeAvatar decodes the image, checking its dimensions before decoding
+// its pixels, so that a small file can't claim a huge image.
+func decodeAvatar(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > MaxAvatarPixels {
+		return nil, ErrAvatarTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	return img, nil
+}
+
+// resizeSquare crops img to its center square and resizes it to size,
+// each pixel averaging the pixels of the square it covers.
+func resizeSquare(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0, y0 := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := span(y, side, size)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := span(x, side, size)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(x0+sx, y0+sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) the pixel i of size covers in
+// side, at least one when enlarging.
+func span(i, side, size int) (from, to int) {
+	from, to = i*side/size, (i+1)*side/size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
@@ -0,0 +1,84 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/kavirajk/bookshop/storage"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// memStorage keeps the stored files in memory.
+type memStorage struct {
+	storage.Storage
+	files map[string][]byte
+}
+
+func (s *memStorage) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	s.files[key] = data
+	return "https://cdn.example.com/" + key, nil
+}
+
+// stripes returns a PNG of a red, a green and a blue square side by side.
+func stripes(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for x := 0; x < 300; x++ {
+		c := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}[x/100]
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadAvatar(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", FirstName: "Anna", LastName: "Berg", Username: "anna"})
+	if _, _, err := user.NewService(r, nil, user.Config{}).UploadAvatar(ctx, "u1", stripes(t)); err != user.ErrAvatarsUnavailable {
+		t.Errorf("no storage: expected ErrAvatarsUnavailable, got %v", err)
+	}
+
+	files := &memStorage{files: make(map[string][]byte)}
+	s := user.NewService(r, nil, user.Config{Avatars: files})
+	if _, _, err := s.UploadAvatar(ctx, "u1", []byte("not an image")); err != user.ErrInvalidImage {
+		t.Errorf("text file: expected ErrInvalidImage, got %v", err)
+	}
+	if _, _, err := s.UploadAvatar(ctx, "u1", make([]byte, user.MaxAvatarSize+1)); err != user.ErrAvatarTooLarge {
+		t.Errorf("large file: expected ErrAvatarTooLarge, got %v", err)
+	}
+
+	u, avatars, err := s.UploadAvatar(ctx, "u1", stripes(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(avatars) != len(user.AvatarSizes) || len(files.files) != len(user.AvatarSizes) {
+		t.Fatalf("expected an avatar per size, got %+v", avatars)
+	}
+	if last := avatars[len(avatars)-1]; u.Avatar != last.URL || r.user(t, "u1").Avatar != last.URL || last.Size != 256 {
+		t.Errorf("expected the largest avatar on the profile, got %q", r.user(t, "u1").Avatar)
+	}
+	for _, a := range avatars {
+		img, err := jpeg.Decode(bytes.NewReader(files.files[a.URL[len("https://cdn.example.com/"):]]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != a.Size || b.Dy() != a.Size {
+			t.Errorf("expected a %d square, got %v", a.Size, b)
+		}
+		// The center square is the green one, the JPEG shifting the
+		// colors a little.
+		cr, cg, cb, _ := img.At(0, a.Size-1).RGBA()
+		if cr>>8 > 40 || cg>>8 < 215 || cb>>8 > 40 {
+			t.Errorf("%d: expected the center square kept, got %d %d %d", a.Size, cr>>8, cg>>8, cb>>8)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package user
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrInvalidOp    = errors.New("op must be deactivate, role or export")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrEmptyFilter  = errors.New("filter must select some users")
+	ErrJobNotDone   = errors.New("job is not done yet")
+	ErrMissingActor = errors.New("requested_by is required")
+)
+
+// Bulk operations.
+const (
+	OpDeactivate = "deactivate"
+	OpRole       = "role"
+	OpExport     = "export"
+)
+
+// Bulk job status.
+const (
+	JobQueued     = "queued"
+	JobProcessing = "processing"
+	JobDone       = "done"
+)
+
+// Bulk job result status.
+const (
+	ResultOK      = "ok"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+)
+
+// Filter selects the users of a bulk job or of the user list. Every
+// criterion set must match, the zero Filter selects every user.
+type Filter struct {
+	IDs []string `json:"ids,omitempty"`
+	// Email matches the email, case insensitively.
+	Email       string `json:"email,omitempty"`
+	Role        string `json:"role,omitempty"`
+	Deactivated *bool  `json:"deactivated,omitempty"`
+	// Verified selects the users whose email is verified, see
+	// User.EmailVerifiedAt.
+	Verified *bool `json:"verified,omitempty"`
+	// Segment selects the members of a customer segment.
+	Segment string `json:"segment,omitempty"`
+	// NameContains matches a part of the first, last or full name, case
+	// insensitively.
+	NameContains string `json:"name_contains,omitempty"`
+	// CreatedAfter and CreatedBefore bound the registration time,
+	// exclusively.
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+}
+
+// Empty tells whether f selects every user.
+func (f Filter) Empty() bool {
+	return len(f.IDs) == 0 && f.Email == "" && f.Role == "" && f.Deactivated == nil && f.Verified == nil && f.Segment == "" &&
+		f.NameContains == "" && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// ParseFilter reads a Filter from the query parameters of the user list,
+// e.g. email=ann@example.com&created_after=2017-01-01&verified=true. The
+// times are RFC 3339 or dates, ids are comma separated.
+func ParseFilter(q url.Values) (Filter, error) {
+	f := Filter{
+		Email:        strings.TrimSpace(q.Get("email")),
+		Role:         q.Get("role"),
+		Segment:      q.Get("segment"),
+		NameContains: strings.TrimSpace(q.Get("name_contains")),
+	}
+	for _, id := range strings.Split(q.Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			f.IDs = append(f.IDs, id)
+		}
+	}
+
+	var v validate.Validator
+	for _, p := range []struct {
+		name  string
+		value **bool
+	}{{"deactivated", &f.Deactivated}, {"verified", &f.Verified}} {
+		if s := q.Get(p.name); s != "" {
+			b, err := strconv.ParseBool(s)
+			v.Check(err == nil, p.name, validate.CodeType, p.name+" must be true or false")
+			*p.value = &b
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		value **time.Time
+	}{{"created_after", &f.CreatedAfter}, {"created_before", &f.CreatedBefore}} {
+		if s := q.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				t, err = time.Parse("2006-01-02", s)
+			}
+			v.Check(err == nil, p.name, validate.CodeType, p.name+" must be a date or an RFC 3339 time")
+			*p.value = &t
+		}
+	}
+	return f, v.Err()
+}
+
+// Job is a background bulk operation on a filtered set of users, e.g.
+// deactivating the accounts of a closed company.
+type Job struct {
+	ID string `json:"id"`
+	Op string `json:"op"`
+	// Role is the new role of the users of an OpRole job.
+	Role         string     `json:"role,omitempty"`
+	Filter       Filter     `json:"filter" gorm:"-"`
+	FilterString string     `json:"-" sql:"type:text"`
+	RequestedBy  string     `json:"requested_by"`
+	Status       string     `json:"status"`
+	Total        int        `json:"total"`
+	Succeeded    int        `json:"succeeded"`
+	Failed       int        `json:"failed"`
+	Skipped      int        `json:"skipped"`
+	Export       string     `json:"-" sql:"type:text"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Results      []Result   `json:"results,omitempty" gorm:"-"`
+}
+
+func (Job) TableName() string {
+	return "user_jobs"
+}
+
+// BeforeSave serializes the filter into FilterString.
+func (j *Job) BeforeSave() error {
+	b, err := json.Marshal(j.Filter)
+	if err != nil {
+		return err
+	}
+	j.FilterString = string(b)
+	return nil
+}
+
+// AfterFind restores the filter from FilterString.
+func (j *Job) AfterFind() error {
+	if j.FilterString == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(j.FilterString), &j.Filter)
+}
+
+// Validate checks the op of the job and that the ops changing users are
+// given a filter, so that a forgotten filter doesn't hit every account.
+func (j *Job) Validate() error {
+	switch j.Op {
+	case OpDeactivate, OpExport:
+	case OpRole:
+		if !ValidRole(j.Role) {
+			return ErrInvalidRole
+		}
+	default:
+		return ErrInvalidOp
+	}
+	if j.Filter.Role != "" && !ValidRole(j.Filter.Role) {
+		return ErrInvalidRole
+	}
+	if j.Op != OpExport && j.Filter.Empty() {
+		return ErrEmptyFilter
+	}
+	if j.RequestedBy == "" {
+		return ErrMissingActor
+	}
+	return nil
+}
+
+// Count sets the totals of the job from its results.
+func (j *Job) Count(results []Result) {
+	j.Succeeded, j.Failed, j.Skipped = 0, 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case ResultOK:
+			j.Succeeded++
+		case ResultFailed:
+			j.Failed++
+		case ResultSkipped:
+			j.Skipped++
+		}
+	}
+}
+
+// Result is the outcome of a bulk job for one user.
+type Result struct {
+	ID     string `json:"-"`
+	JobID  string `json:"-"`
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (Result) TableName() string {
+	return "user_job_results"
+}
+
+var exportHeader = []string{"id", "email", "username", "first_name", "last_name", "role", "deactivated"}
+
+// exportCSV writes users as CSV, without their credentials.
+func exportCSV(users []User) (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write(exportHeader)
+	for _, u := range users {
+		w.Write([]string{u.ID, u.Email, u.Username, u.FirstName, u.LastName, u.Role, strconv.FormatBool(u.Deactivated)})
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
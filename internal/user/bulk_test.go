@@ -0,0 +1,68 @@
+package user_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func TestJobValidate(t *testing.T) {
+	deactivated := false
+	cases := []struct {
+		name string
+		job  user.Job
+		err  error
+	}{
+		{"deactivate", user.Job{Op: user.OpDeactivate, Filter: user.Filter{Email: "ann@example.com"}, RequestedBy: "1"}, nil},
+		{"export all", user.Job{Op: user.OpExport, RequestedBy: "1"}, nil},
+		{"role", user.Job{Op: user.OpRole, Role: user.RoleSupport, Filter: user.Filter{IDs: []string{"2"}}, RequestedBy: "1"}, nil},
+		{"deactivate all", user.Job{Op: user.OpDeactivate, RequestedBy: "1"}, user.ErrEmptyFilter},
+		{"unknown role", user.Job{Op: user.OpRole, Role: "owner", Filter: user.Filter{Deactivated: &deactivated}, RequestedBy: "1"}, user.ErrInvalidRole},
+		{"unknown filter role", user.Job{Op: user.OpExport, Filter: user.Filter{Role: "owner"}, RequestedBy: "1"}, user.ErrInvalidRole},
+		{"unknown op", user.Job{Op: "delete", RequestedBy: "1"}, user.ErrInvalidOp},
+		{"no actor", user.Job{Op: user.OpExport}, user.ErrMissingActor},
+	}
+	for _, c := range cases {
+		if err := c.job.Validate(); err != c.err {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestJobCount(t *testing.T) {
+	var j user.Job
+	j.Count([]user.Result{
+		{Status: user.ResultOK},
+		{Status: user.ResultOK},
+		{Status: user.ResultFailed},
+		{Status: user.ResultSkipped},
+	})
+	if j.Succeeded != 2 || j.Failed != 1 || j.Skipped != 1 {
+		t.Errorf("count: expected 2 succeeded, 1 failed and 1 skipped, got %d, %d and %d", j.Succeeded, j.Failed, j.Skipped)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	q, _ := url.ParseQuery("email=Ann@example.com&name_contains=+ann+&created_after=2017-01-02&deactivated=false&verified=true&ids=1,,2")
+	f, err := user.ParseFilter(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Email != "Ann@example.com" || f.NameContains != "ann" || len(f.IDs) != 2 || f.Deactivated == nil || *f.Deactivated || f.Verified == nil || !*f.Verified {
+		t.Errorf("unexpected filter %+v", f)
+	}
+	if f.CreatedAfter == nil || f.CreatedAfter.Format("2006-01-02") != "2017-01-02" || f.CreatedBefore != nil {
+		t.Errorf("expected created after 2017-01-02, got %v", f.CreatedAfter)
+	}
+	if f, _ := user.ParseFilter(url.Values{}); !f.Empty() {
+		t.Errorf("no parameters: expected the empty filter, got %+v", f)
+	}
+
+	q, _ = url.ParseQuery("created_before=yesterday&deactivated=maybe&verified=yes")
+	_, err = user.ParseFilter(q)
+	if fields := validate.Fields(err); len(fields) != 3 {
+		t.Errorf("expected created_before, deactivated and verified invalid, got %v", err)
+	}
+}
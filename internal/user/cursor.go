@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var (
+	ErrBadCursor   = errors.New("bad cursor")
+	ErrCursorOrder = errors.New("cursor pagination can't be ordered")
+)
+
+// Cursor is a position in the user list paged by keyset, in registration
+// order: the page after the user of CreatedAt and ID, or before it if
+// Before is set. The zero Cursor is the start of the list.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+	Before    bool
+}
+
+// Start tells whether c is the start of the list.
+func (c Cursor) Start() bool {
+	return c.ID == ""
+}
+
+const cursorPrefix = "u1:"
+
+// EncodeCursor returns the opaque token of a cursor.
+func EncodeCursor(c Cursor) string {
+	dir := "a"
+	if c.Before {
+		dir = "b"
+	}
+	s := cursorPrefix + dir + ":" + strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// DecodeCursor returns the cursor of a token.
+func DecodeCursor(token string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return Cursor{}, ErrBadCursor
+	}
+	parts := strings.SplitN(strings.TrimPrefix(string(b), cursorPrefix), ":", 3)
+	if len(parts) != 3 || (parts[0] != "a" && parts[0] != "b") || parts[2] == "" {
+		return Cursor{}, ErrBadCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrBadCursor
+	}
+	return Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: parts[2], Before: parts[0] == "b"}, nil
+}
+
+// ListByCursor returns, along with the users, the tokens of the pages
+// before and after them, empty at the ends of the list. An empty page has
+// none, the list having changed under the cursor.
+func (s service) ListByCursor(ctx context.Context, f filter.Expr, by Filter, c Cursor, limit int) ([]User, string, string, error) {
+	users, more, err := s.repo.ListByCursor(f, by, c, limit)
+	if err != nil || len(users) == 0 {
+		return users, "", "", err
+	}
+	first, last := users[0], users[len(users)-1]
+	var prev, next string
+	// Going forth, there are users before unless at the start, going back
+	// there are users after the ones we came from.
+	if (!c.Before && !c.Start()) || (c.Before && more) {
+		prev = EncodeCursor(Cursor{CreatedAt: first.CreatedAt, ID: first.ID, Before: true})
+	}
+	if c.Before || more {
+		next = EncodeCursor(Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return users, prev, next, nil
+}
@@ -0,0 +1,32 @@
+package user_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestCursor(t *testing.T) {
+	for _, c := range []user.Cursor{
+		{CreatedAt: time.Date(2017, 3, 1, 10, 0, 0, 123000, time.UTC), ID: "42"},
+		{CreatedAt: time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC), ID: "a:b", Before: true},
+	} {
+		got, err := user.DecodeCursor(user.EncodeCursor(c))
+		if err != nil || !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID || got.Before != c.Before {
+			t.Errorf("%+v: expected it back, got %+v, %v", c, got, err)
+		}
+	}
+	for _, token := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("c1:a:0:42")),
+		base64.RawURLEncoding.EncodeToString([]byte("u1:x:0:42")),
+		base64.RawURLEncoding.EncodeToString([]byte("u1:a:soon:42")),
+		base64.RawURLEncoding.EncodeToString([]byte("u1:a:0:")),
+	} {
+		if _, err := user.DecodeCursor(token); err != user.ErrBadCursor {
+			t.Errorf("%q: expected ErrBadCursor, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrDeviceNotFound  = errors.New("trusted device not found")
+	ErrUntrustedDevice = errors.New("device is not trusted")
+)
+
+// TrustedDeviceTTL is how long a device skips 2FA after a login with a
+// code.
+const TrustedDeviceTTL = 30 * 24 * time.Hour
+
+// TrustedDevice is a device the user logs in from without a TOTP code, by
+// sending back its device token with the password. Only the hash of the
+// token is stored, with the fingerprint of the browser it was issued to.
+type TrustedDevice struct {
+	ID     string `json:"id"`
+	UserID string `json:"-" sql:"index"`
+	Hash   string `json:"-" sql:"unique_index"`
+	// Fingerprint is the hash of the user agent, its versions left out so
+	// that updating the browser keeps the device trusted.
+	Fingerprint string `json:"-"`
+	// Binding signs the device with the password and the TOTP secret of
+	// the user, a password change or a new secret voids it.
+	Binding    string     `json:"-"`
+	Device     string     `json:"device,omitempty"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (TrustedDevice) TableName() string {
+	return "user_trusted_devices"
+}
+
+var versionRe = regexp.MustCompile(`[0-9][0-9._]*`)
+
+// fingerprint returns the fingerprint of a user agent.
+func fingerprint(ua string) string {
+	h := sha256.Sum256([]byte(versionRe.ReplaceAllString(ua, "")))
+	return hex.EncodeToString(h[:])
+}
+
+// binding returns the Binding of the device of token hash of u.
+func (s *sealer) binding(hash string, u User) string {
+	return s.signature("device."+hash, u.Password+"."+u.TOTPSecret)
+}
+
+func (s service) TrustDevice(_ context.Context, u User, c Client) (TrustedDevice, string, error) {
+	if s.sealer == nil {
+		return TrustedDevice{}, "", ErrTwoFactorUnavailable
+	}
+	if !u.TwoFactorEnabled {
+		return TrustedDevice{}, "", ErrTwoFactorDisabled
+	}
+	now := time.Now().UTC()
+	token, t, err := newRefreshToken(u.ID, now)
+	if err != nil {
+		return TrustedDevice{}, "", err
+	}
+	d := TrustedDevice{
+		UserID:      u.ID,
+		Hash:        t.Hash,
+		Fingerprint: fingerprint(c.UserAgent),
+		Device:      deviceOf(c.UserAgent),
+		IP:          c.IP,
+		UserAgent:   c.UserAgent,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(TrustedDeviceTTL),
+	}
+	d.Binding = s.sealer.binding(d.Hash, u)
+	if err := s.repo.CreateTrustedDevice(&d); err != nil {
+		return TrustedDevice{}, "", err
+	}
+	return d, token, nil
+}
+
+// CheckTrustedDevice returns ErrUntrustedDevice for the tokens of the other
+// users, the expired or revoked devices, and the ones another browser sends.
+func (s service) CheckTrustedDevice(_ context.Context, u User, token string, c Client) error {
+	if s.sealer == nil || !u.TwoFactorEnabled {
+		return ErrUntrustedDevice
+	}
+	d, err := s.repo.GetTrustedDevice(hashRefreshToken(token))
+	if err != nil || d.UserID != u.ID || d.RevokedAt != nil {
+		return ErrUntrustedDevice
+	}
+	now := time.Now().UTC()
+	if !now.Before(d.ExpiresAt) || d.Fingerprint != fingerprint(c.UserAgent) ||
+		!hmac.Equal([]byte(d.Binding), []byte(s.sealer.binding(d.Hash, u))) {
+		return ErrUntrustedDevice
+	}
+	return s.repo.UseTrustedDevice(d.ID, c.IP, now)
+}
+
+// TrustedDevices leaves out the expired devices.
+func (s service) TrustedDevices(_ context.Context, userID string) ([]TrustedDevice, error) {
+	all, err := s.repo.ListTrustedDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	devices := make([]TrustedDevice, 0, len(all))
+	for _, d := range all {
+		if now.Before(d.ExpiresAt) {
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
+
+// RevokeTrustedDevice returns ErrDeviceNotFound for the devices of the
+// other users.
+func (s service) RevokeTrustedDevice(_ context.Context, userID, deviceID string) error {
+	devices, err := s.repo.ListTrustedDevices(userID)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		if d.ID == deviceID {
+			return s.repo.RevokeTrustedDevice(deviceID, time.Now().UTC())
+		}
+	}
+	return ErrDeviceNotFound
+}
+
+func (s service) RevokeTrustedDevices(_ context.Context, userID string) error {
+	return s.repo.RevokeTrustedDevices(userID, time.Now().UTC())
+}
@@ -0,0 +1,75 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestTrustedDevices(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t,
+		user.User{ID: "u1", Email: "jo@example.com", Password: "hash"},
+		user.User{ID: "u2", Email: "al@example.com", Password: "hash", TwoFactorEnabled: true, TOTPSecret: "x"},
+	)
+	s := user.NewService(r, nil, user.Config{TwoFactorKey: []byte("key")})
+	setup, err := s.Enable2FA(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify2FA(ctx, "u1", code(t, setup.Secret, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	firefox := user.Client{IP: "10.0.0.1", UserAgent: "Mozilla/5.0 (Windows NT 10.0; rv:130.0) Gecko/20100101 Firefox/130.0"}
+	d, token, err := s.TrustDevice(ctx, r.user(t, "u1"), firefox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Device != "Windows" || !d.ExpiresAt.After(time.Now().Add(29*24*time.Hour)) || d.Hash == token {
+		t.Errorf("expected a Windows device trusted for 30 days, got %+v", d)
+	}
+
+	updated := user.Client{IP: "10.0.0.2", UserAgent: "Mozilla/5.0 (Windows NT 10.0; rv:131.0) Gecko/20100101 Firefox/131.0"}
+	if err := s.CheckTrustedDevice(ctx, r.user(t, "u1"), token, updated); err != nil {
+		t.Errorf("updated browser: expected the device trusted, got %v", err)
+	}
+	if devices, _ := r.ListTrustedDevices("u1"); devices[0].IP != "10.0.0.2" {
+		t.Errorf("expected the use recorded, got %+v", devices[0])
+	}
+	for name, c := range map[string]struct {
+		u      user.User
+		token  string
+		client user.Client
+	}{
+		"other browser": {r.user(t, "u1"), token, user.Client{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"}},
+		"other user":    {r.user(t, "u2"), token, firefox},
+		"unknown token": {r.user(t, "u1"), "nope", firefox},
+	} {
+		if err := s.CheckTrustedDevice(ctx, c.u, c.token, c.client); err != user.ErrUntrustedDevice {
+			t.Errorf("%s: expected ErrUntrustedDevice, got %v", name, err)
+		}
+	}
+
+	// a password change voids the devices.
+	u := r.user(t, "u1")
+	u.Password = "new hash"
+	if err := s.CheckTrustedDevice(ctx, u, token, firefox); err != user.ErrUntrustedDevice {
+		t.Errorf("password changed: expected ErrUntrustedDevice, got %v", err)
+	}
+
+	if err := s.RevokeTrustedDevice(ctx, "u2", d.ID); err != user.ErrDeviceNotFound {
+		t.Errorf("device of another user: expected ErrDeviceNotFound, got %v", err)
+	}
+	if err := s.RevokeTrustedDevice(ctx, "u1", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckTrustedDevice(ctx, r.user(t, "u1"), token, firefox); err != user.ErrUntrustedDevice {
+		t.Errorf("revoked: expected ErrUntrustedDevice, got %v", err)
+	}
+	if devices, _ := s.TrustedDevices(ctx, "u1"); len(devices) != 0 {
+		t.Errorf("expected no trusted device left, got %+v", devices)
+	}
+}
@@ -3,117 +3,1026 @@ package user
 import (
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/ratelimit"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/transport"
 )
 
 // Endpoints combine all the user service endpoints under single type.
 type Endpoints struct {
 	RegisterEndpoint       endpoint.Endpoint
 	LoginEndpoint          endpoint.Endpoint
+	ForgotPasswordEndpoint endpoint.Endpoint
 	ResetPasswordEndpoint  endpoint.Endpoint
 	ChangePasswordEndpoint endpoint.Endpoint
 	ListEndpoint           endpoint.Endpoint
+	SearchEndpoint         endpoint.Endpoint
+	GetEndpoint            endpoint.Endpoint
+	PatchEndpoint          endpoint.Endpoint
+	DeleteEndpoint         endpoint.Endpoint
+	UploadAvatarEndpoint   endpoint.Endpoint
+	AddressesEndpoint      endpoint.Endpoint
+	PreferencesEndpoint    endpoint.Endpoint
+	SetPreferencesEndpoint endpoint.Endpoint
+	AddressEndpoint        endpoint.Endpoint
+	CreateAddressEndpoint  endpoint.Endpoint
+	UpdateAddressEndpoint  endpoint.Endpoint
+	DeleteAddressEndpoint  endpoint.Endpoint
+	RefreshEndpoint        endpoint.Endpoint
+	RevokeEndpoint         endpoint.Endpoint
+	RevokeUserEndpoint     endpoint.Endpoint
+	UnlockEndpoint         endpoint.Endpoint
+	LogoutEndpoint         endpoint.Endpoint
+	OAuthLoginEndpoint     endpoint.Endpoint
+	OAuthCallbackEndpoint  endpoint.Endpoint
+	Login2FAEndpoint       endpoint.Endpoint
+	Enable2FAEndpoint      endpoint.Endpoint
+	Verify2FAEndpoint      endpoint.Endpoint
+	Disable2FAEndpoint     endpoint.Endpoint
+	SessionsEndpoint       endpoint.Endpoint
+	RevokeSessionEndpoint  endpoint.Endpoint
+	RevokeSessionsEndpoint endpoint.Endpoint
+	DevicesEndpoint        endpoint.Endpoint
+	RevokeDeviceEndpoint   endpoint.Endpoint
+	RevokeDevicesEndpoint  endpoint.Endpoint
+	CSRFEndpoint           endpoint.Endpoint
+	ScopedTokenEndpoint    endpoint.Endpoint
+	ScopedTokensEndpoint   endpoint.Endpoint
+	RevokeTokenEndpoint    endpoint.Endpoint
+	ScopesEndpoint         endpoint.Endpoint
+	ImpersonateEndpoint    endpoint.Endpoint
+
+	BanEndpoint                endpoint.Endpoint
+	UnbanEndpoint              endpoint.Endpoint
+	ForcePasswordResetEndpoint endpoint.Endpoint
+	SetRoleEndpoint            endpoint.Endpoint
+	LoginHistoryEndpoint       endpoint.Endpoint
+
+	StartJobEndpoint  endpoint.Endpoint
+	JobsEndpoint      endpoint.Endpoint
+	JobEndpoint       endpoint.Endpoint
+	JobExportEndpoint endpoint.Endpoint
+}
+
+// Limits are the rate limiters of the user endpoints, a nil one not
+// limiting.
+type Limits struct {
+	// Requests limits the requests to every endpoint, per user or else per
+	// IP address.
+	Requests ratelimit.Limiter
+	// Login limits the logins and the forgotten passwords per IP address,
+	// on top of Requests, against the guessing of passwords and emails.
+	Login ratelimit.Limiter
+}
+
+// CSRF tells which route groups check the CSRF token of the requests of
+// the cookie sessions, see csrf.NewMiddleware.
+type CSRF struct {
+	Account bool // the routes of the signed in users
+	Admin   bool // the staff and admin routes
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the user service endpoints. The tokens are issued on login and
+// refresh, checked on change password and revoke, and revoked on logout.
+// The social logins go through the providers of social. The user list is
+// restricted to the staff, the admin endpoints to the admins. The
+// registrations, logins, password and role changes, deletions, bans and
+// forced password resets are recorded by audited. The requests over limits fail with ratelimit.ErrLimited. The
+// scoped tokens only reach the endpoints of their scopes, never the ones
+// of the account itself, nor do the admins impersonating a user.
+func MakeEndpoints(s Service, tokens auth.Service, social *oauth.Login, audited audit.AuditLogger, limits Limits, forgery CSRF) Endpoints {
+	limit := ratelimit.NewMiddleware(limits.Requests, ratelimit.ByClient)
+	login := endpoint.Chain(limit, ratelimit.NewMiddleware(limits.Login, ratelimit.ByIP))
+	protect := func(on bool) endpoint.Middleware {
+		if !on {
+			return func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+		}
+		return csrf.NewMiddleware()
+	}
+	authed := endpoint.Chain(protect(forgery.Account), auth.NewMiddleware(tokens), limit)
+	account := endpoint.Chain(authed, rbac.RequireUnscoped())
+	authedStaff := endpoint.Chain(protect(forgery.Admin), auth.NewMiddleware(tokens), limit)
+	staff := endpoint.Chain(authedStaff, rbac.RequireRole(RoleAdmin, RoleSupport))
+	admin := endpoint.Chain(authedStaff, rbac.RequireRole(RoleAdmin))
+	read, write := rbac.RequireScope(ScopeUsersRead), rbac.RequireScope(ScopeUsersWrite)
+	record := func(describe audit.Describer) endpoint.Middleware {
+		return audit.NewMiddleware(audited, describe)
+	}
+	return Endpoints{
+		RegisterEndpoint:       limit(record(describeRegister)(MakeRegisterEndpoint(s))),
+		LoginEndpoint:          login(record(describeLogin)(MakeLoginEndpoint(s, tokens))),
+		ForgotPasswordEndpoint: login(MakeForgotPasswordEndpoint(s)),
+		ResetPasswordEndpoint:  limit(record(describeResetPassword)(MakeResetPasswordEndpoint(s))),
+		ChangePasswordEndpoint: account(record(describeChangePassword)(MakeChangePasswordEndpoint(s))),
+		ListEndpoint:           staff(read(MakeListEndpoint(s))),
+		SearchEndpoint:         staff(read(MakeSearchEndpoint(s))),
+		GetEndpoint:            authed(MakeGetEndpoint(s)),
+		PatchEndpoint:          authed(MakePatchEndpoint(s)),
+		DeleteEndpoint:         authed(record(describeDelete)(MakeDeleteEndpoint(s))),
+		UploadAvatarEndpoint:   authed(MakeUploadAvatarEndpoint(s)),
+		AddressesEndpoint:      authed(MakeAddressesEndpoint(s)),
+		PreferencesEndpoint:    account(MakePreferencesEndpoint(s)),
+		SetPreferencesEndpoint: account(MakeSetPreferencesEndpoint(s)),
+		AddressEndpoint:        authed(MakeAddressEndpoint(s)),
+		CreateAddressEndpoint:  authed(MakeCreateAddressEndpoint(s)),
+		UpdateAddressEndpoint:  authed(MakeUpdateAddressEndpoint(s)),
+		DeleteAddressEndpoint:  authed(MakeDeleteAddressEndpoint(s)),
+		RefreshEndpoint:        limit(MakeRefreshEndpoint(s, tokens)),
+		RevokeEndpoint:         account(MakeRevokeEndpoint(s)),
+		RevokeUserEndpoint:     admin(write(MakeRevokeUserEndpoint(s))),
+		UnlockEndpoint:         admin(write(MakeUnlockEndpoint(s))),
+		ImpersonateEndpoint:    admin(rbac.RequireUnscoped()(record(describeImpersonate)(MakeImpersonateEndpoint(s, tokens)))),
+		LogoutEndpoint:         authed(MakeLogoutEndpoint(s, tokens)),
+		OAuthLoginEndpoint:     limit(MakeOAuthLoginEndpoint(social)),
+		OAuthCallbackEndpoint:  limit(MakeOAuthCallbackEndpoint(s, tokens, social)),
+		Login2FAEndpoint:       limit(record(describeLogin)(MakeLogin2FAEndpoint(s, tokens))),
+		Enable2FAEndpoint:      account(MakeEnable2FAEndpoint(s)),
+		Verify2FAEndpoint:      account(MakeVerify2FAEndpoint(s)),
+		Disable2FAEndpoint:     account(MakeDisable2FAEndpoint(s)),
+		SessionsEndpoint:       account(MakeSessionsEndpoint(s)),
+		RevokeSessionEndpoint:  account(MakeRevokeSessionEndpoint(s)),
+		RevokeSessionsEndpoint: account(MakeRevokeSessionsEndpoint(s)),
+		DevicesEndpoint:        account(MakeDevicesEndpoint(s)),
+		RevokeDeviceEndpoint:   account(MakeRevokeDeviceEndpoint(s)),
+		RevokeDevicesEndpoint:  account(MakeRevokeDevicesEndpoint(s)),
+		CSRFEndpoint:           limit(MakeCSRFEndpoint()),
+		ScopedTokenEndpoint:    account(MakeScopedTokenEndpoint(s, tokens)),
+		ScopedTokensEndpoint:   account(MakeScopedTokensEndpoint(s)),
+		RevokeTokenEndpoint:    account(MakeRevokeTokenEndpoint(s)),
+		ScopesEndpoint:         limit(MakeScopesEndpoint()),
+
+		BanEndpoint:                admin(write(record(describeBan)(MakeBanEndpoint(s)))),
+		UnbanEndpoint:              admin(write(record(describeUnban)(MakeUnbanEndpoint(s)))),
+		ForcePasswordResetEndpoint: admin(write(record(describeForcePasswordReset)(MakeForcePasswordResetEndpoint(s)))),
+		SetRoleEndpoint:            admin(write(record(describeSetRole)(MakeSetRoleEndpoint(s)))),
+		LoginHistoryEndpoint:       admin(read(MakeLoginHistoryEndpoint(s))),
+
+		StartJobEndpoint:  admin(write(record(describeStartJob)(MakeStartJobEndpoint(s)))),
+		JobsEndpoint:      admin(read(MakeJobsEndpoint(s))),
+		JobEndpoint:       admin(read(MakeJobEndpoint(s))),
+		JobExportEndpoint: admin(read(MakeJobExportEndpoint(s))),
+	}
+}
+
+// MakeCSRFEndpoint returns the CSRF token of the browser, a new one if it
+// has none yet.
+func MakeCSRFEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		if token, ok := csrf.CookieToken(ctx); ok {
+			return csrfResponse{Token: token}, nil
+		}
+		token, e := csrf.NewToken()
+		if e != nil {
+			return csrfResponse{Error: e}, nil
+		}
+		return csrfResponse{Token: token}, nil
+	}
+}
+
+// MakeScopedTokenEndpoint issues a scoped token of the user of the
+// request, with the role of its token, in a session of its own.
+func MakeScopedTokenEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(scopedTokenRequest)
+		c, ok := auth.ClaimsFrom(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		if e := req.Validate(); e != nil {
+			return scopedTokenResponse{Error: e}, nil
+		}
+		session, e := s.StartTokenSession(ctx, c.Subject, clientFrom(ctx), req.Scopes, "", req.TTL())
+		if e != nil {
+			return scopedTokenResponse{Error: e}, nil
+		}
+		token, exp, e := tokens.SignScoped(c.Subject, c.Role, session.ID, req.Scopes, req.TTL())
+		if e != nil {
+			return scopedTokenResponse{Error: e}, nil
+		}
+		return scopedTokenResponse{ID: session.ID, Token: token, ExpiresAt: &exp, Scopes: req.Scopes, Status: http.StatusCreated}, nil
+	}
+}
+
+// MakeScopedTokensEndpoint lists the scoped tokens of the user of the
+// request by their sessions, the tokens themselves aren't stored.
+func MakeScopedTokensEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		sessions, e := s.ScopedTokens(ctx, userID)
+		if e != nil {
+			return scopedTokensResponse{Error: e}, nil
+		}
+		return scopedTokensResponse{Tokens: sessions}, nil
+	}
+}
+
+func MakeRevokeTokenEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		e := s.RevokeScopedToken(ctx, userID, req.SessionID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeScopesEndpoint lists the scopes a token can be granted.
+func MakeScopesEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return scopesResponse{Scopes: rbac.Scopes()}, nil
+	}
+}
+
+func MakeRegisterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(registerRequest)
+		u, e := s.Register(ctx, req.NewUser)
+		if e != nil {
+			return registerResponse{User: nil, Error: e}, nil
+		}
+		return registerResponse{User: &u, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeLoginEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(loginRequest)
+		u, e := s.Login(ctx, req.Email, req.Password)
+		if e != nil {
+			return loginResponse{User: nil, Error: e}, nil
+		}
+		return signIn(ctx, s, tokens, u, req.DeviceToken)
+	}
+}
+
+// MakeLogin2FAEndpoint completes the login of a user with 2FA enabled,
+// trusting the device if asked to.
+func MakeLogin2FAEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(login2FARequest)
+		u, e := s.Login2FA(ctx, req.Challenge, req.Code)
+		if e != nil {
+			return loginResponse{Error: e}, nil
+		}
+		var device string
+		if req.TrustDevice {
+			if _, device, e = s.TrustDevice(ctx, u, clientFrom(ctx)); e != nil {
+				return loginResponse{Error: e}, nil
+			}
+		}
+		res, err := issueTokens(ctx, s, tokens, u)
+		res.DeviceToken = device
+		return res, err
+	}
+}
+
+// signIn issues the tokens of u, or the challenge to complete the login
+// with if u has 2FA enabled and device isn't the token of one of their
+// trusted devices.
+func signIn(ctx context.Context, s Service, tokens auth.Service, u User, device string) (interface{}, error) {
+	if !u.TwoFactorEnabled {
+		return issueTokens(ctx, s, tokens, u)
+	}
+	// Any failure to check the device falls back to asking for a code.
+	if device != "" && s.CheckTrustedDevice(ctx, u, device, clientFrom(ctx)) == nil {
+		return issueTokens(ctx, s, tokens, u)
+	}
+	challenge, exp, e := s.Challenge2FA(ctx, u)
+	if e != nil {
+		return loginResponse{Error: e}, nil
+	}
+	return loginResponse{TwoFactorRequired: true, Challenge: challenge, ExpiresAt: &exp}, nil
+}
+
+// issueTokens starts a session of u from the client of the request.
+func issueTokens(ctx context.Context, s Service, tokens auth.Service, u User) (loginResponse, error) {
+	session, refresh, err := s.StartSession(ctx, u.ID, clientFrom(ctx))
+	if err != nil {
+		return loginResponse{}, err
+	}
+	token, exp, err := tokens.Sign(u.ID, u.Role, session.ID)
+	if err != nil {
+		return loginResponse{}, err
+	}
+	return loginResponse{User: &u, Token: token, ExpiresAt: &exp, RefreshToken: refresh}, nil
+}
+
+// MakeForgotPasswordEndpoint answers the same whether the email is known
+// or not.
+func MakeForgotPasswordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(forgotPasswordRequest)
+		e := s.ForgotPassword(ctx, req.Email)
+		if e != nil {
+			return resetPasswordResponse{Error: e}, nil
+		}
+		return resetPasswordResponse{
+			Status:  http.StatusAccepted,
+			Message: "a password reset link is sent if the email is registered",
+		}, nil
+	}
+}
+
+func MakeResetPasswordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resetPasswordRequest)
+		if req.NewPassword != req.ConfirmNewPassword {
+			return nil, ErrPasswordMismatch
+		}
+		e := s.ResetPassword(ctx, req.Key, req.NewPassword)
+		if e != nil {
+			return resetPasswordResponse{Error: e}, nil
+		}
+		return resetPasswordResponse{Message: "password reset success"}, nil
+	}
+}
+
+func MakeChangePasswordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changePasswordRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		if req.NewPassword != req.ConfirmNewPassword {
+			return nil, ErrPasswordMismatch
+		}
+		e := s.ChangePassword(ctx, userID, req.OldPassword, req.NewPassword)
+		if e != nil {
+			return changePasswordResponse{Error: e}, nil
+		}
+		return changePasswordResponse{Message: "change password success"}, nil
+
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		if req.Cursor != nil {
+			return listByCursor(ctx, s, req)
+		}
+		var next, prev string
+		users, total, e := s.List(ctx, req.Filter, req.By, req.Order, req.Limit, req.Offset, req.Count)
+		if e != nil {
+			return listResponse{Error: e}, nil
+		}
+		limit, offset, err := nextLimitOffset(total, req.Limit, req.Offset)
+		if err == nil {
+			params := req.URL.Query()
+			params = appendLimitOffset(params, limit, offset)
+			next = req.URL.Path + "?" + params.Encode()
+		}
+		limit, offset, err = prevLimitOffset(total, req.Limit, req.Offset)
+		if err == nil {
+			params := req.URL.Query()
+			params = appendLimitOffset(params, limit, offset)
+			prev = req.URL.Path + "?" + params.Encode()
+		}
+
+		if req.Count == db.CountNone {
+			// total only tells about the next page, leave it out.
+			total = 0
+		}
+		return listResponse{
+			Users: users, Total: total,
+			Prev: prev, Next: next,
+		}, nil
+
+	}
+}
+
+// listByCursor lists the users of the cursor of req, linking the pages
+// around by their cursor.
+func listByCursor(ctx context.Context, s Service, req listRequest) (interface{}, error) {
+	users, prev, next, e := s.ListByCursor(ctx, req.Filter, req.By, *req.Cursor, req.Limit)
+	if e != nil {
+		return listResponse{Error: e}, nil
+	}
+	link := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		params := req.URL.Query()
+		params.Del("offset")
+		params.Set("cursor", cursor)
+		params.Set("limit", strconv.Itoa(req.Limit))
+		return req.URL.Path + "?" + params.Encode()
+	}
+	return listResponse{
+		Users: users,
+		Prev:  link(prev), Next: link(next),
+		PrevCursor: prev, NextCursor: next,
+	}, nil
+}
+
+// MakeSearchEndpoint pages the matches of a search like the user list.
+func MakeSearchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(searchRequest)
+		users, total, e := s.Search(ctx, req.Q, req.Limit, req.Offset)
+		if e != nil {
+			return listResponse{Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		return listResponse{
+			Users: users, Total: total,
+			Prev: prev, Next: next,
+		}, nil
+	}
+}
+
+// MakeGetEndpoint returns the profile of a user to the user, and to the
+// staff granted the users:read scope.
+func MakeGetEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		c, ok := auth.ClaimsFrom(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		if c.Subject != req.UserID {
+			if c.Role != RoleAdmin && c.Role != RoleSupport {
+				return nil, rbac.ErrForbidden
+			}
+			if !c.Allows(ScopeUsersRead) {
+				return nil, rbac.ErrInsufficientScope
+			}
+		}
+		u, e := s.Get(ctx, req.UserID)
+		if e != nil {
+			return profileResponse{User: nil, Error: e}, nil
+		}
+		p := u.Profile()
+		return profileResponse{User: &p}, nil
+	}
+}
+
+// MakeDeleteEndpoint deletes the account of the user of the request, or
+// of any user for the admins granted the users:write scope. The users
+// delete their account themselves with an unscoped token of their own.
+func MakeDeleteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		c, ok := auth.ClaimsFrom(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		switch {
+		case c.Subject == req.UserID:
+			if c.Scoped() {
+				return nil, rbac.ErrInsufficientScope
+			}
+			if c.ImpersonatedBy != "" {
+				return nil, rbac.ErrImpersonated
+			}
+		case c.Role != RoleAdmin:
+			return nil, rbac.ErrForbidden
+		case !c.Allows(ScopeUsersWrite):
+			return nil, rbac.ErrInsufficientScope
+		}
+		e := s.Delete(ctx, req.UserID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeUploadAvatarEndpoint uploads the avatar of the user of the request,
+// or of any user for the admins granted the users:write scope, e.g. to
+// replace an offending one.
+func MakeUploadAvatarEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(uploadAvatarRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersWrite); err != nil {
+			return nil, err
+		}
+		u, avatars, e := s.UploadAvatar(ctx, req.UserID, req.Data)
+		if e != nil {
+			return avatarResponse{Error: e}, nil
+		}
+		return avatarResponse{Avatar: u.Avatar, Sizes: avatars}, nil
+	}
+}
+
+// owner checks the request reaches the account of userID: the user of the
+// request with an unscoped token, or an admin granted scope.
+func owner(ctx context.Context, userID, scope string) error {
+	c, ok := auth.ClaimsFrom(ctx)
+	if !ok {
+		return ErrUnauthorized
+	}
+	switch {
+	case c.Subject == userID:
+		if c.Scoped() {
+			return rbac.ErrInsufficientScope
+		}
+	case c.Role != RoleAdmin:
+		return rbac.ErrForbidden
+	case !c.Allows(scope):
+		return rbac.ErrInsufficientScope
+	}
+	return nil
+}
+
+// MakePreferencesEndpoint returns the preferences of the user of the
+// request.
+func MakePreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		p, e := s.Preferences(ctx, userID)
+		if e != nil {
+			return preferencesResponse{Preferences: nil, Error: e}, nil
+		}
+		return preferencesResponse{Preferences: &p}, nil
+	}
+}
+
+func MakeSetPreferencesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(Preferences)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		p, e := s.SetPreferences(ctx, userID, req)
+		if e != nil {
+			return preferencesResponse{Preferences: nil, Error: e}, nil
+		}
+		return preferencesResponse{Preferences: &p}, nil
+	}
+}
+
+// MakeAddressesEndpoint lists the address book of the user of the
+// request, or of any user for the admins granted the users:read scope.
+func MakeAddressesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addressRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersRead); err != nil {
+			return nil, err
+		}
+		list, e := s.Addresses(ctx, req.UserID)
+		if e != nil {
+			return addressesResponse{Addresses: make([]Address, 0), Error: e}, nil
+		}
+		return addressesResponse{Addresses: list}, nil
+	}
+}
+
+func MakeAddressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addressRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersRead); err != nil {
+			return nil, err
+		}
+		a, e := s.Address(ctx, req.UserID, req.AddressID)
+		if e != nil {
+			return addressResponse{Address: nil, Error: e}, nil
+		}
+		return addressResponse{Address: &a}, nil
+	}
+}
+
+// MakeCreateAddressEndpoint adds an address to the address book of the
+// user of the request, or of any user for the admins granted the
+// users:write scope. So do the update and delete endpoints.
+func MakeCreateAddressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addressRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersWrite); err != nil {
+			return nil, err
+		}
+		a, e := s.CreateAddress(ctx, req.UserID, req.Address)
+		if e != nil {
+			return addressResponse{Address: nil, Error: e}, nil
+		}
+		return addressResponse{Address: &a, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeUpdateAddressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addressRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersWrite); err != nil {
+			return nil, err
+		}
+		a, e := s.UpdateAddress(ctx, req.UserID, req.AddressID, req.Address)
+		if e != nil {
+			return addressResponse{Address: nil, Error: e}, nil
+		}
+		return addressResponse{Address: &a}, nil
+	}
+}
+
+func MakeDeleteAddressEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addressRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersWrite); err != nil {
+			return nil, err
+		}
+		e := s.DeleteAddress(ctx, req.UserID, req.AddressID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakePatchEndpoint patches the profile of a user for the user, and for
+// the admins granted the users:write scope.
+func MakePatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(patchRequest)
+		if err := owner(ctx, req.UserID, ScopeUsersWrite); err != nil {
+			return nil, err
+		}
+		u, e := s.Patch(ctx, req.UserID, req.Match, req.Patch)
+		if e != nil {
+			return patchResponse{User: nil, Error: e}, nil
+		}
+		p := u.Profile()
+		return patchResponse{User: &p}, nil
+	}
+}
+
+func MakeStartJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(startJobRequest)
+		j, e := s.StartJob(ctx, req.Job)
+		if e != nil {
+			return jobResponse{Job: nil, Error: e}, nil
+		}
+		return jobResponse{Job: &j, Status: http.StatusAccepted}, nil
+	}
+}
+
+func MakeJobsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		jobs, e := s.Jobs(ctx)
+		if e != nil {
+			return jobsResponse{Jobs: make([]Job, 0), Error: e}, nil
+		}
+		return jobsResponse{Jobs: jobs}, nil
+	}
+}
+
+func MakeJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobRequest)
+		j, e := s.Job(ctx, req.JobID, req.Status...)
+		if e != nil {
+			return jobResponse{Job: nil, Error: e}, nil
+		}
+		return jobResponse{Job: &j}, nil
+	}
+}
+
+func MakeJobExportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(jobRequest)
+		data, e := s.JobExport(ctx, req.JobID)
+		if e != nil {
+			return exportResponse{Error: e}, nil
+		}
+		return exportResponse{Name: "users-" + req.JobID + ".csv", Data: []byte(data)}, nil
+	}
+}
+
+func MakeRefreshEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refreshRequest)
+		u, sessionID, refresh, e := s.RefreshToken(ctx, req.RefreshToken)
+		if e != nil {
+			return loginResponse{Error: e}, nil
+		}
+		token, exp, err := tokens.Sign(u.ID, u.Role, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return loginResponse{Token: token, ExpiresAt: &exp, RefreshToken: refresh}, nil
+	}
+}
+
+func MakeRevokeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		e := s.RevokeRefreshTokens(ctx, userID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+func MakeRevokeUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		e := s.RevokeRefreshTokens(ctx, req.UserID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeUnlockEndpoint unlocks an account locked after failed logins.
+func MakeUnlockEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		e := s.Unlock(ctx, req.UserID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeBanEndpoint bans a user on behalf of the admin of the request.
+func MakeBanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(banRequest)
+		adminID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		u, e := s.Ban(ctx, adminID, req.UserID, req.Reason)
+		if e != nil {
+			return profileResponse{Error: e}, nil
+		}
+		p := u.Profile()
+		return profileResponse{User: &p}, nil
+	}
+}
+
+func MakeUnbanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		u, e := s.Unban(ctx, req.UserID)
+		if e != nil {
+			return profileResponse{Error: e}, nil
+		}
+		p := u.Profile()
+		return profileResponse{User: &p}, nil
+	}
+}
+
+// MakeForcePasswordResetEndpoint logs a user out and makes them reset
+// their password, e.g. once it leaked.
+func MakeForcePasswordResetEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		e := s.ForcePasswordReset(ctx, req.UserID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeSetRoleEndpoint changes the role of a user on behalf of the admin of
+// the request, who can't change their own.
+func MakeSetRoleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(roleRequest)
+		adminID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		u, e := s.SetRole(ctx, adminID, req.UserID, req.Role)
+		if e != nil {
+			return profileResponse{Error: e}, nil
+		}
+		p := u.Profile()
+		return profileResponse{User: &p}, nil
+	}
+}
+
+// MakeLoginHistoryEndpoint returns a page of the logins of a user, linking
+// the pages around.
+func MakeLoginHistoryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(loginHistoryRequest)
+		logins, total, e := s.LoginHistory(ctx, req.UserID, req.Limit, req.Offset)
+		if e != nil {
+			return loginHistoryResponse{Error: e}, nil
+		}
+		prev, next := transport.PageLinks(req.URL, total, req.Limit, req.Offset)
+		return loginHistoryResponse{
+			Logins: logins, Total: total,
+			Prev: prev, Next: next,
+		}, nil
+	}
+}
+
+// ImpersonationTTL is the lifetime of the tokens of the admins acting as a
+// user.
+const ImpersonationTTL = 15 * time.Minute
+
+// MakeImpersonateEndpoint issues the admin of the request a token acting as
+// the user, valid for ImpersonationTTL. It isn't refreshed, its session is
+// revoked with the sessions of the user or of the admin.
+func MakeImpersonateEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeUserRequest)
+		adminID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		u, e := s.Impersonate(ctx, req.UserID)
+		if e != nil {
+			return impersonateResponse{Error: e}, nil
+		}
+		session, e := s.StartTokenSession(ctx, u.ID, clientFrom(ctx), nil, adminID, ImpersonationTTL)
+		if e != nil {
+			return impersonateResponse{Error: e}, nil
+		}
+		token, exp, e := tokens.SignImpersonation(u.ID, u.Role, session.ID, adminID, ImpersonationTTL)
+		if e != nil {
+			return impersonateResponse{Error: e}, nil
+		}
+		return impersonateResponse{User: &u, Token: token, ExpiresAt: &exp, ImpersonatedBy: adminID}, nil
+	}
 }
 
-// MakeEndpoints returns Endpoints type which is the combination of
-// all the user service endpoints.
-func MakeEndpoints(s Service) Endpoints {
-	return Endpoints{
-		RegisterEndpoint:       MakeRegisterEndpoint(s),
-		LoginEndpoint:          MakeLoginEndpoint(s),
-		ResetPasswordEndpoint:  MakeResetPasswordEndpoint(s),
-		ChangePasswordEndpoint: MakeChangePasswordEndpoint(s),
-		ListEndpoint:           MakeListEndpoint(s),
+// MakeLogoutEndpoint revokes the access token of the request and its
+// session, if any. The other sessions are revoked by the sessions endpoints.
+func MakeLogoutEndpoint(s Service, tokens auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		c, ok := auth.ClaimsFrom(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		if err := tokens.Revoke(c); err != nil {
+			return nil, err
+		}
+		if c.Session != "" {
+			if e := s.RevokeSession(ctx, c.Subject, c.Session); e != nil {
+				return revokeResponse{Error: e}, nil
+			}
+		}
+		return revokeResponse{}, nil
 	}
 }
 
-func MakeRegisterEndpoint(s Service) endpoint.Endpoint {
+// MakeSessionsEndpoint lists the sessions of the user of the request, the
+// session of the request being marked current.
+func MakeSessionsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(registerRequest)
-		u, e := s.Register(ctx, req.NewUser)
+		c, ok := auth.ClaimsFrom(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		sessions, e := s.Sessions(ctx, c.Subject)
 		if e != nil {
-			return registerResponse{User: nil, Error: e}, nil
+			return sessionsResponse{Error: e}, nil
 		}
-		return registerResponse{User: &u, Status: http.StatusCreated}, nil
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == c.Session
+		}
+		return sessionsResponse{Sessions: sessions}, nil
 	}
 }
 
-func MakeLoginEndpoint(s Service) endpoint.Endpoint {
+func MakeRevokeSessionEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(loginRequest)
-		u, e := s.Login(ctx, req.Email, req.Password)
-		if e != nil {
-			return loginResponse{User: nil, Error: e}, nil
+		req := request.(sessionRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
 		}
-		return loginResponse{User: &u}, nil
+		e := s.RevokeSession(ctx, userID, req.SessionID)
+		return revokeResponse{Error: e}, nil
 	}
 }
 
-func MakeResetPasswordEndpoint(s Service) endpoint.Endpoint {
+// MakeRevokeSessionsEndpoint logs the user out everywhere, the device of the
+// request included.
+func MakeRevokeSessionsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(resetPasswordRequest)
-		if req.NewPassword != req.ConfirmNewPassword {
-			return nil, ErrPasswordMismatch
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
 		}
-		e := s.ResetPassword(ctx, req.Key, req.NewPassword)
+		e := s.RevokeSessions(ctx, userID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeDevicesEndpoint lists the trusted devices of the user of the request.
+func MakeDevicesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		devices, e := s.TrustedDevices(ctx, userID)
 		if e != nil {
-			return resetPasswordResponse{Error: e}, nil
+			return devicesResponse{Error: e}, nil
 		}
-		return resetPasswordResponse{Message: "password reset success"}, nil
+		return devicesResponse{Devices: devices}, nil
 	}
 }
 
-func MakeChangePasswordEndpoint(s Service) endpoint.Endpoint {
+func MakeRevokeDeviceEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(changePasswordRequest)
-		if req.Token == "" {
+		req := request.(deviceRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		e := s.RevokeTrustedDevice(ctx, userID, req.DeviceID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+func MakeRevokeDevicesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
 			return nil, ErrUnauthorized
 		}
-		u, e := s.AuthToken(ctx, req.Token)
+		e := s.RevokeTrustedDevices(ctx, userID)
+		return revokeResponse{Error: e}, nil
+	}
+}
+
+// MakeOAuthLoginEndpoint returns the provider URL to send the user to,
+// along with the state the callback checks.
+func MakeOAuthLoginEndpoint(social *oauth.Login) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(oauthLoginRequest)
+		u, state, e := social.Redirect(req.Provider)
 		if e != nil {
-			return nil, e
+			return oauthLoginResponse{Error: e}, nil
 		}
-		if req.NewPassword != req.ConfirmNewPassword {
-			return nil, ErrPasswordMismatch
+		return oauthLoginResponse{URL: u, State: state}, nil
+	}
+}
+
+// MakeOAuthCallbackEndpoint signs the user of the provider in, issuing the
+// same tokens as the login.
+func MakeOAuthCallbackEndpoint(s Service, tokens auth.Service, social *oauth.Login) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(oauthCallbackRequest)
+		id, e := social.Callback(ctx, req.Provider, req.Code, req.State, req.Cookie)
+		if e != nil {
+			return loginResponse{Error: e}, nil
 		}
-		e = s.ChangePassword(ctx, u.ID, req.OldPassword, req.NewPassword)
+		u, e := s.SocialLogin(ctx, id)
 		if e != nil {
-			return changePasswordResponse{Error: e}, nil
+			return loginResponse{Error: e}, nil
 		}
-		return changePasswordResponse{Message: "change password success"}, nil
-
+		return signIn(ctx, s, tokens, u, "")
 	}
 }
 
-func MakeListEndpoint(s Service) endpoint.Endpoint {
+func MakeEnable2FAEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(listRequest)
-		var next, prev string
-		users, total, e := s.List(ctx, req.Order, req.Limit, req.Offset)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		setup, e := s.Enable2FA(ctx, userID)
 		if e != nil {
-			return listResponse{Error: e}, nil
+			return enable2FAResponse{Error: e}, nil
 		}
-		limit, offset, err := nextLimitOffset(total, req.Limit, req.Offset)
-		if err == nil {
-			params := req.URL.Query()
-			params = appendLimitOffset(params, limit, offset)
-			next = req.URL.Path + "?" + params.Encode()
+		return enable2FAResponse{Setup: &setup}, nil
+	}
+}
+
+func MakeVerify2FAEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(twoFactorRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
 		}
-		limit, offset, err = prevLimitOffset(total, req.Limit, req.Offset)
-		if err == nil {
-			params := req.URL.Query()
-			params = appendLimitOffset(params, limit, offset)
-			prev = req.URL.Path + "?" + params.Encode()
+		if e := s.Verify2FA(ctx, userID, req.Code); e != nil {
+			return twoFactorResponse{Error: e}, nil
 		}
+		return twoFactorResponse{Message: "two-factor authentication enabled"}, nil
+	}
+}
 
-		return listResponse{
-			Users: users, Total: total,
-			Prev: prev, Next: next,
-		}, nil
-
+func MakeDisable2FAEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(twoFactorRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+		if e := s.Disable2FA(ctx, userID, req.Code); e != nil {
+			return twoFactorResponse{Error: e}, nil
+		}
+		return twoFactorResponse{Message: "two-factor authentication disabled"}, nil
 	}
 }
 
@@ -138,12 +1047,78 @@ func (r registerResponse) error() error {
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// DeviceToken is the token of a trusted device, skipping 2FA.
+	DeviceToken string `json:"device_token"`
+}
+
+type scopedTokenRequest struct {
+	ScopedToken
+}
+
+type scopedTokenResponse struct {
+	Status int `json:"-"`
+	// ID is the ID of the session of the token, to revoke it.
+	ID string `json:"id,omitempty"`
+	// Token is sent back as "Authorization: Bearer" like the tokens of
+	// the logins, it isn't refreshed.
+	Token     string     `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	Error     error      `json:"error,omitempty"`
+}
+
+func (r scopedTokenResponse) status() int {
+	return r.Status
+}
+
+func (r scopedTokenResponse) error() error {
+	return r.Error
+}
+
+type scopedTokensResponse struct {
+	Tokens []Session `json:"tokens"`
+	Error  error     `json:"error,omitempty"`
+}
+
+func (r scopedTokensResponse) error() error {
+	return r.Error
+}
+
+type impersonateResponse struct {
+	User           *User      `json:"user,omitempty"`
+	Token          string     `json:"token,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	ImpersonatedBy string     `json:"impersonated_by,omitempty"`
+	Error          error      `json:"error,omitempty"`
+}
+
+func (r impersonateResponse) error() error {
+	return r.Error
+}
+
+type scopesResponse struct {
+	Scopes []rbac.Scope `json:"scopes"`
 }
 
 type loginResponse struct {
 	Status int   `json:"-"`
 	User   *User `json:"user,omitempty"`
-	Error  error `json:"error,omitempty"`
+	// Token is the JWT access token, sent back as "Authorization: Bearer"
+	// on the protected routes.
+	Token     string     `json:"token,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// RefreshToken is exchanged for the next access token at
+	// /users/v1/token/refresh, once.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// TwoFactorRequired is set instead of the tokens for the users with
+	// 2FA enabled, the login is completed at /users/v1/login/2fa with
+	// Challenge before ExpiresAt.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	Challenge         string `json:"challenge,omitempty"`
+	// DeviceToken is set when the login trusts the device, it is sent
+	// along the password of the next logins.
+	DeviceToken string `json:"device_token,omitempty"`
+	Error       error  `json:"error,omitempty"`
 }
 
 func (l loginResponse) status() int {
@@ -154,6 +1129,192 @@ func (r loginResponse) error() error {
 	return r.Error
 }
 
+type login2FARequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+	// TrustDevice skips 2FA on the device for TrustedDeviceTTL.
+	TrustDevice bool `json:"trust_device"`
+}
+
+type twoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+type twoFactorResponse struct {
+	Message string `json:"message,omitempty"`
+	Error   error  `json:"error,omitempty"`
+}
+
+func (r twoFactorResponse) error() error {
+	return r.Error
+}
+
+type enable2FAResponse struct {
+	Setup *TwoFactorSetup `json:"two_factor,omitempty"`
+	Error error           `json:"error,omitempty"`
+}
+
+func (r enable2FAResponse) error() error {
+	return r.Error
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type sessionRequest struct {
+	SessionID string
+}
+
+type sessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r sessionsResponse) error() error {
+	return r.Error
+}
+
+type deviceRequest struct {
+	DeviceID string
+}
+
+type devicesResponse struct {
+	Devices []TrustedDevice `json:"devices"`
+	Error   error           `json:"error,omitempty"`
+}
+
+func (r devicesResponse) error() error {
+	return r.Error
+}
+
+type oauthLoginRequest struct {
+	Provider string
+}
+
+type oauthLoginResponse struct {
+	URL   string
+	State string
+	Error error
+}
+
+func (r oauthLoginResponse) error() error {
+	return r.Error
+}
+
+type oauthCallbackRequest struct {
+	Provider string
+	Code     string
+	State    string
+	// Cookie is the state set in the cookie on login.
+	Cookie string
+}
+
+type revokeUserRequest struct {
+	UserID string
+}
+
+type revokeResponse struct {
+	Error error `json:"error,omitempty"`
+}
+
+func (r revokeResponse) error() error {
+	return r.Error
+}
+
+type banRequest struct {
+	UserID string `json:"-"`
+	Reason string `json:"reason"`
+}
+
+type roleRequest struct {
+	UserID string `json:"-"`
+	Role   string `json:"role"`
+}
+
+type loginHistoryRequest struct {
+	UserID string
+	Limit  int
+	Offset int
+
+	URL *url.URL
+}
+
+type loginHistoryResponse struct {
+	Logins []audit.Event `json:"logins"`
+	Error  error         `json:"error,omitempty"`
+
+	Total int    `json:"-"`
+	Prev  string `json:"-"`
+	Next  string `json:"-"`
+}
+
+func (r loginHistoryResponse) error() error {
+	return r.Error
+}
+
+func (r loginHistoryResponse) page() (int, string, string) {
+	return r.Total, r.Prev, r.Next
+}
+
+type uploadAvatarRequest struct {
+	UserID string
+	Data   []byte
+}
+
+type avatarResponse struct {
+	// Avatar is the URL of the largest size, the avatar of the profile.
+	Avatar string   `json:"avatar,omitempty"`
+	Sizes  []Avatar `json:"sizes,omitempty"`
+	Error  error    `json:"error,omitempty"`
+}
+
+func (r avatarResponse) error() error {
+	return r.Error
+}
+
+type preferencesResponse struct {
+	Preferences *Preferences `json:"preferences,omitempty"`
+	Error       error        `json:"error,omitempty"`
+}
+
+func (r preferencesResponse) error() error {
+	return r.Error
+}
+
+type addressRequest struct {
+	UserID    string
+	AddressID string
+	Address   Address
+}
+
+type addressesResponse struct {
+	Addresses []Address `json:"addresses"`
+	Error     error     `json:"error,omitempty"`
+}
+
+func (r addressesResponse) error() error {
+	return r.Error
+}
+
+type addressResponse struct {
+	Status  int      `json:"-"`
+	Address *Address `json:"address,omitempty"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r addressResponse) status() int {
+	return r.Status
+}
+
+func (r addressResponse) error() error {
+	return r.Error
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
 type resetPasswordRequest struct {
 	Key                string `json:"key"`
 	NewPassword        string `json:"new_password"`
@@ -179,8 +1340,6 @@ type authTokenRequest struct {
 }
 
 type changePasswordRequest struct {
-	UserID             string `json:"-"`
-	Token              string `json:"-"`
 	OldPassword        string `json:"old_password"`
 	NewPassword        string `json:"new_password"`
 	ConfirmNewPassword string `json:"confirm_new_password"`
@@ -201,13 +1360,26 @@ func (r changePasswordResponse) error() error {
 }
 
 type listRequest struct {
-	Order  string `json:"order"`
-	Limit  int    `json:"limit"`
-	Offset int    `json:"offset"`
+	Order  string      `json:"order"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Count  db.Count    `json:"-"`
+	Filter filter.Expr `json:"-"`
+	By     Filter      `json:"-"`
+	// Cursor is set when the list is paged by cursor.
+	Cursor *Cursor `json:"-"`
 
 	URL *url.URL `json:"-"`
 }
 
+type searchRequest struct {
+	Q      string
+	Limit  int
+	Offset int
+
+	URL *url.URL
+}
+
 type listResponse struct {
 	Status int    `json:"-"`
 	Users  []User `json:"users"`
@@ -216,6 +1388,9 @@ type listResponse struct {
 	Total int    `json:"-"`
 	Prev  string `json:"-"`
 	Next  string `json:"-"`
+
+	PrevCursor string `json:"-"`
+	NextCursor string `json:"-"`
 }
 
 func (r listResponse) status() int {
@@ -229,3 +1404,80 @@ func (r listResponse) error() error {
 func (r listResponse) page() (int, string, string) {
 	return r.Total, r.Prev, r.Next
 }
+
+func (r listResponse) cursors() (string, string) {
+	return r.PrevCursor, r.NextCursor
+}
+
+type patchRequest struct {
+	UserID string
+	Match  etag.Condition
+	Patch  []byte
+}
+
+type profileResponse struct {
+	User  *Profile `json:"user,omitempty"`
+	Error error    `json:"error,omitempty"`
+}
+
+func (r profileResponse) error() error {
+	return r.Error
+}
+
+type patchResponse struct {
+	User  *Profile `json:"user,omitempty"`
+	Error error    `json:"error,omitempty"`
+}
+
+func (r patchResponse) error() error {
+	return r.Error
+}
+
+type startJobRequest struct {
+	Job
+}
+
+type jobRequest struct {
+	JobID  string
+	Status []string
+}
+
+type jobResponse struct {
+	Status int   `json:"-"`
+	Job    *Job  `json:"job,omitempty"`
+	Error  error `json:"error,omitempty"`
+}
+
+func (r jobResponse) status() int {
+	return r.Status
+}
+
+func (r jobResponse) error() error {
+	return r.Error
+}
+
+type jobsResponse struct {
+	Jobs  []Job `json:"jobs"`
+	Error error `json:"error,omitempty"`
+}
+
+func (r jobsResponse) error() error {
+	return r.Error
+}
+
+type exportResponse struct {
+	Name  string
+	Data  []byte
+	Error error
+}
+
+type csrfResponse struct {
+	// Token is sent back in the X-CSRF-Token header by the cookie
+	// sessions, it's set in the csrf_token cookie as well.
+	Token string `json:"token,omitempty"`
+	Error error  `json:"error,omitempty"`
+}
+
+func (r csrfResponse) error() error {
+	return r.Error
+}
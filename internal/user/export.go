@@ -0,0 +1,59 @@
+package user
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+var exportColumns = export.Columns{
+	{Name: "id", Value: func(r interface{}) string { return r.(User).ID }},
+	{Name: "email", Value: func(r interface{}) string { return r.(User).Email }},
+	{Name: "username", Value: func(r interface{}) string { return r.(User).Username }},
+	{Name: "first_name", Value: func(r interface{}) string { return r.(User).FirstName }},
+	{Name: "last_name", Value: func(r interface{}) string { return r.(User).LastName }},
+	{Name: "role", Value: func(r interface{}) string { return r.(User).Role }},
+	{Name: "deactivated", Value: func(r interface{}) string { return strconv.FormatBool(r.(User).Deactivated) }},
+}
+
+// ExportSource exports the user list as CSV, filtered and ordered by the
+// same query parameters, to the requests access lets through.
+func ExportSource(s Service, access endpoint.Middleware) export.Source {
+	return export.Source{
+		Name:    "users",
+		Path:    "/users/v1/list",
+		Columns: exportColumns,
+		Access:  access,
+		Rows: func(ctx context.Context, query url.Values) (export.Rows, error) {
+			f, err := filter.Parse(query.Get("filter"), ListFields)
+			if err != nil {
+				return nil, err
+			}
+			by, err := ParseFilter(query)
+			if err != nil {
+				return nil, err
+			}
+			order, err := filter.Order(query.Get("order"), ListFields)
+			if err != nil {
+				return nil, err
+			}
+			if order == "" {
+				// pages of an export must not overlap.
+				order = "id"
+			}
+			return func(limit, offset int) ([]interface{}, error) {
+				users, _, err := s.List(ctx, f, by, order, limit, offset, db.CountNone)
+				rows := make([]interface{}, len(users))
+				for i, u := range users {
+					rows[i] = u
+				}
+				return rows, err
+			}, nil
+		},
+	}
+}
@@ -7,6 +7,11 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type instrmw struct {
@@ -58,6 +63,127 @@ func (mw instrmw) AuthToken(ctx context.Context, token string) (user User, err e
 	return
 }
 
+func (mw instrmw) StartSession(ctx context.Context, userID string, c Client) (session Session, token string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start_session", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	session, token, err = mw.next.StartSession(ctx, userID, c)
+	return
+}
+
+func (mw instrmw) StartTokenSession(ctx context.Context, userID string, c Client, scopes []string, impersonatedBy string, ttl time.Duration) (session Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start_token_session", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	session, err = mw.next.StartTokenSession(ctx, userID, c, scopes, impersonatedBy, ttl)
+	return
+}
+
+func (mw instrmw) ScopedTokens(ctx context.Context, userID string) (sessions []Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "scoped_tokens", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sessions, err = mw.next.ScopedTokens(ctx, userID)
+	return
+}
+
+func (mw instrmw) RevokeScopedToken(ctx context.Context, userID, sessionID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_scoped_token", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeScopedToken(ctx, userID, sessionID)
+	return
+}
+
+func (mw instrmw) RefreshToken(ctx context.Context, token string) (user User, sessionID, next string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "refresh_token", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, sessionID, next, err = mw.next.RefreshToken(ctx, token)
+	return
+}
+
+func (mw instrmw) Sessions(ctx context.Context, userID string) (sessions []Session, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sessions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	sessions, err = mw.next.Sessions(ctx, userID)
+	return
+}
+
+func (mw instrmw) RevokeSession(ctx context.Context, userID, sessionID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_session", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeSession(ctx, userID, sessionID)
+	return
+}
+
+func (mw instrmw) RevokeSessions(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_sessions", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeSessions(ctx, userID)
+	return
+}
+
+func (mw instrmw) RevokeRefreshTokens(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_refresh_tokens", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeRefreshTokens(ctx, userID)
+	return
+}
+
+func (mw instrmw) SocialLogin(ctx context.Context, id oauth.Identity) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "social_login", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.SocialLogin(ctx, id)
+	return
+}
+
+func (mw instrmw) ForgotPassword(ctx context.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "forgot_password", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ForgotPassword(ctx, email)
+	return
+}
+
 func (mw instrmw) ResetPassword(ctx context.Context, key, newpass string) (err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "reset_password", "error", fmt.Sprint(err != nil)}
@@ -80,13 +206,409 @@ func (mw instrmw) ChangePassword(ctx context.Context, userID string, oldpass, ne
 	return
 }
 
-func (mw instrmw) List(ctx context.Context, order string, limit, offset int) (users []User, total int, err error) {
+func (mw instrmw) List(ctx context.Context, f filter.Expr, by Filter, order string, limit, offset int, count db.Count) (users []User, total int, err error) {
 	defer func(begin time.Time) {
 		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
 		mw.requestCount.With(lvs...).Add(1)
 		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	users, total, err = mw.next.List(ctx, order, limit, offset)
+	users, total, err = mw.next.List(ctx, f, by, order, limit, offset, count)
+	return
+}
+
+func (mw instrmw) ListByCursor(ctx context.Context, f filter.Expr, by Filter, c Cursor, limit int) (users []User, prev, next string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list_by_cursor", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	users, prev, next, err = mw.next.ListByCursor(ctx, f, by, c, limit)
+	return
+}
+
+func (mw instrmw) Search(ctx context.Context, query string, limit, offset int) (users []User, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "search", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	users, total, err = mw.next.Search(ctx, query, limit, offset)
+	return
+}
+
+func (mw instrmw) Patch(ctx context.Context, userID string, match etag.Condition, p []byte) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "patch", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Patch(ctx, userID, match, p)
+	return
+}
+
+func (mw instrmw) StartJob(ctx context.Context, j Job) (job Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "start_job", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	job, err = mw.next.StartJob(ctx, j)
+	return
+}
+
+func (mw instrmw) Jobs(ctx context.Context) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "jobs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	jobs, err = mw.next.Jobs(ctx)
+	return
+}
+
+func (mw instrmw) Job(ctx context.Context, id string, status ...string) (job Job, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "job", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	job, err = mw.next.Job(ctx, id, status...)
+	return
+}
+
+func (mw instrmw) JobExport(ctx context.Context, id string) (data string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "job_export", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	data, err = mw.next.JobExport(ctx, id)
+	return
+}
+
+func (mw instrmw) ProcessJobs(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "process_jobs", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ProcessJobs(ctx)
+	return
+}
+
+func (mw instrmw) Enable2FA(ctx context.Context, userID string) (setup TwoFactorSetup, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "enable_2fa", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	setup, err = mw.next.Enable2FA(ctx, userID)
+	return
+}
+
+func (mw instrmw) Verify2FA(ctx context.Context, userID, code string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "verify_2fa", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Verify2FA(ctx, userID, code)
+	return
+}
+
+func (mw instrmw) Disable2FA(ctx context.Context, userID, code string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "disable_2fa", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Disable2FA(ctx, userID, code)
+	return
+}
+
+func (mw instrmw) Challenge2FA(ctx context.Context, u User) (challenge string, exp time.Time, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "challenge_2fa", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	challenge, exp, err = mw.next.Challenge2FA(ctx, u)
+	return
+}
+
+func (mw instrmw) Login2FA(ctx context.Context, challenge, code string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "login_2fa", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Login2FA(ctx, challenge, code)
+	return
+}
+
+func (mw instrmw) TrustDevice(ctx context.Context, u User, c Client) (d TrustedDevice, token string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "trust_device", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	d, token, err = mw.next.TrustDevice(ctx, u, c)
+	return
+}
+
+func (mw instrmw) CheckTrustedDevice(ctx context.Context, u User, token string, c Client) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "check_trusted_device", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.CheckTrustedDevice(ctx, u, token, c)
+	return
+}
+
+func (mw instrmw) TrustedDevices(ctx context.Context, userID string) (devices []TrustedDevice, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "trusted_devices", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	devices, err = mw.next.TrustedDevices(ctx, userID)
+	return
+}
+
+func (mw instrmw) RevokeTrustedDevice(ctx context.Context, userID, deviceID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_trusted_device", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeTrustedDevice(ctx, userID, deviceID)
+	return
+}
+
+func (mw instrmw) RevokeTrustedDevices(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_trusted_devices", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeTrustedDevices(ctx, userID)
+	return
+}
+
+func (mw instrmw) Impersonate(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "impersonate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Impersonate(ctx, userID)
+	return
+}
+
+func (mw instrmw) Ban(ctx context.Context, adminID, userID, reason string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "ban", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Ban(ctx, adminID, userID, reason)
+	return
+}
+
+func (mw instrmw) Unban(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "unban", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Unban(ctx, userID)
+	return
+}
+
+func (mw instrmw) ForcePasswordReset(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "force_password_reset", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ForcePasswordReset(ctx, userID)
+	return
+}
+
+func (mw instrmw) SetRole(ctx context.Context, adminID, userID, role string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_role", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.SetRole(ctx, adminID, userID, role)
+	return
+}
+
+func (mw instrmw) LoginHistory(ctx context.Context, userID string, limit, offset int) (logins []audit.Event, total int, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "login_history", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	logins, total, err = mw.next.LoginHistory(ctx, userID, limit, offset)
+	return
+}
+
+func (mw instrmw) Get(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "get", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, err = mw.next.Get(ctx, userID)
+	return
+}
+
+func (mw instrmw) Delete(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Delete(ctx, userID)
+	return
+}
+
+func (mw instrmw) UploadAvatar(ctx context.Context, userID string, data []byte) (user User, avatars []Avatar, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "upload_avatar", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	user, avatars, err = mw.next.UploadAvatar(ctx, userID, data)
+	return
+}
+
+func (mw instrmw) Addresses(ctx context.Context, userID string) (list []Address, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "addresses", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.Addresses(ctx, userID)
+	return
+}
+
+func (mw instrmw) Address(ctx context.Context, userID, addressID string) (a Address, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "address", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	a, err = mw.next.Address(ctx, userID, addressID)
+	return
+}
+
+func (mw instrmw) CreateAddress(ctx context.Context, userID string, a Address) (res Address, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_address", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	res, err = mw.next.CreateAddress(ctx, userID, a)
+	return
+}
+
+func (mw instrmw) UpdateAddress(ctx context.Context, userID, addressID string, a Address) (res Address, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "update_address", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	res, err = mw.next.UpdateAddress(ctx, userID, addressID, a)
+	return
+}
+
+func (mw instrmw) DeleteAddress(ctx context.Context, userID, addressID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "delete_address", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.DeleteAddress(ctx, userID, addressID)
+	return
+}
+
+func (mw instrmw) DefaultAddress(ctx context.Context, userID string) (a Address, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "default_address", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	a, err = mw.next.DefaultAddress(ctx, userID)
+	return
+}
+
+func (mw instrmw) Preferences(ctx context.Context, userID string) (p Preferences, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "preferences", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	p, err = mw.next.Preferences(ctx, userID)
+	return
+}
+
+func (mw instrmw) SetPreferences(ctx context.Context, userID string, p Preferences) (out Preferences, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "set_preferences", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	out, err = mw.next.SetPreferences(ctx, userID, p)
+	return
+}
+
+func (mw instrmw) Unlock(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "unlock", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.Unlock(ctx, userID)
 	return
 }
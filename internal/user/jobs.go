@@ -0,0 +1,24 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunJobs processes the queued bulk jobs every interval until ctx is done.
+func RunJobs(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessJobs(ctx); err != nil {
+				logger.Log("jobs", "user", "err", err)
+			}
+		}
+	}
+}
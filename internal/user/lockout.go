@@ -0,0 +1,55 @@
+package user
+
+import (
+	"time"
+)
+
+// Lockout defaults, see Config.
+const (
+	DefaultMaxFailedLogins = 5
+	DefaultLockoutWindow   = 15 * time.Minute
+	DefaultLockoutDuration = 30 * time.Minute
+)
+
+// LoginFailure is a failed login of a user, a wrong password or 2FA code.
+type LoginFailure struct {
+	ID     string    `json:"-"`
+	UserID string    `json:"-" sql:"index"`
+	At     time.Time `json:"at"`
+}
+
+func (LoginFailure) TableName() string {
+	return "user_login_failures"
+}
+
+// Locked tells whether the account is locked at now.
+func (u User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// loginFailed records a failure of user, locking the account once it
+// failed MaxFailedLogins times within LockoutWindow. It returns
+// ErrAccountLocked if it did, fail otherwise.
+func (s service) loginFailed(user User, fail error) error {
+	now := time.Now().UTC()
+	if err := s.repo.RecordLoginFailure(user.ID, now); err != nil {
+		return err
+	}
+	n, err := s.repo.CountLoginFailures(user.ID, now.Add(-s.cfg.LockoutWindow))
+	if err != nil {
+		return err
+	}
+	if n < s.cfg.MaxFailedLogins {
+		return fail
+	}
+	until := now.Add(s.cfg.LockoutDuration)
+	if err := s.repo.SetLockedUntil(user.ID, &until); err != nil {
+		return err
+	}
+	return ErrAccountLocked
+}
+
+// loginSucceeded forgets the failures of user.
+func (s service) loginSucceeded(user User) error {
+	return s.repo.ClearLoginFailures(user.ID)
+}
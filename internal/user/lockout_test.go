@@ -0,0 +1,50 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	nu := user.NewUser{Email: "jo@example.com", Password: "secret"}
+	u := nu.User()
+	u.ID = "u1"
+	r := newRepo(t, u)
+	failures := func() int {
+		n, _ := r.CountLoginFailures("u1", time.Time{})
+		return n
+	}
+	s := user.NewService(r, nil, user.Config{MaxFailedLogins: 3, LockoutWindow: time.Hour, LockoutDuration: time.Hour})
+
+	if _, err := s.Login(ctx, "jo@example.com", "wrong"); err != user.ErrUnauthorized {
+		t.Errorf("wrong: expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != nil || failures() != 0 {
+		t.Errorf("login: expected the failures cleared, got %d, %v", failures(), err)
+	}
+
+	// An old failure is out of the window.
+	r.RecordLoginFailure("u1", time.Now().Add(-2*time.Hour))
+	for i, want := range []error{user.ErrUnauthorized, user.ErrUnauthorized, user.ErrAccountLocked} {
+		if _, err := s.Login(ctx, "jo@example.com", "wrong"); err != want {
+			t.Errorf("failure %d: expected %v, got %v", i+1, want, err)
+		}
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != user.ErrAccountLocked {
+		t.Errorf("locked: expected ErrAccountLocked, got %v", err)
+	}
+
+	if err := s.Unlock(ctx, "u1"); err != nil || r.user(t, "u1").LockedUntil != nil {
+		t.Fatalf("unlock: expected the account unlocked, got %v, %v", r.user(t, "u1").LockedUntil, err)
+	}
+	if _, err := s.Login(ctx, "jo@example.com", "secret"); err != nil {
+		t.Errorf("unlocked: unexpected error %v", err)
+	}
+	if err := s.Unlock(ctx, "u2"); err != user.ErrUserNotFound {
+		t.Errorf("unknown: expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -5,6 +5,11 @@ import (
 
 	"context"
 	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
 )
 
 type loggingService struct {
@@ -54,6 +59,149 @@ func (s loggingService) AuthToken(ctx context.Context, token string) (user User,
 	return s.next.AuthToken(ctx, token)
 }
 
+func (s loggingService) StartSession(ctx context.Context, userID string, c Client) (session Session, token string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start_session",
+			"user_id", userID,
+			"session_id", session.ID,
+			"ip", c.IP,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.StartSession(ctx, userID, c)
+}
+
+func (s loggingService) StartTokenSession(ctx context.Context, userID string, c Client, scopes []string, impersonatedBy string, ttl time.Duration) (session Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start_token_session",
+			"user_id", userID,
+			"session_id", session.ID,
+			"impersonated_by", impersonatedBy,
+			"ip", c.IP,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.StartTokenSession(ctx, userID, c, scopes, impersonatedBy, ttl)
+}
+
+func (s loggingService) ScopedTokens(ctx context.Context, userID string) (sessions []Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "scoped_tokens",
+			"user_id", userID,
+			"count", len(sessions),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ScopedTokens(ctx, userID)
+}
+
+func (s loggingService) RevokeScopedToken(ctx context.Context, userID, sessionID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_scoped_token",
+			"user_id", userID,
+			"session_id", sessionID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeScopedToken(ctx, userID, sessionID)
+}
+
+func (s loggingService) RefreshToken(ctx context.Context, token string) (user User, sessionID, next string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "refresh_token",
+			"user_id", user.ID,
+			"session_id", sessionID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RefreshToken(ctx, token)
+}
+
+func (s loggingService) Sessions(ctx context.Context, userID string) (sessions []Session, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sessions",
+			"user_id", userID,
+			"sessions", len(sessions),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Sessions(ctx, userID)
+}
+
+func (s loggingService) RevokeSession(ctx context.Context, userID, sessionID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_session",
+			"user_id", userID,
+			"session_id", sessionID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeSession(ctx, userID, sessionID)
+}
+
+func (s loggingService) RevokeSessions(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_sessions",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeSessions(ctx, userID)
+}
+
+func (s loggingService) RevokeRefreshTokens(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_refresh_tokens",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeRefreshTokens(ctx, userID)
+}
+
+func (s loggingService) SocialLogin(ctx context.Context, id oauth.Identity) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "social_login",
+			"provider", id.Provider,
+			"user_id", user.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SocialLogin(ctx, id)
+}
+
+func (s loggingService) ForgotPassword(ctx context.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "forgot-password",
+			"email", email,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ForgotPassword(ctx, email)
+}
+
 func (s loggingService) ResetPassword(ctx context.Context, key, newpass string) (err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
@@ -77,7 +225,7 @@ func (s loggingService) ChangePassword(ctx context.Context, userID string, oldpa
 	return s.next.ChangePassword(ctx, userID, oldpass, newpass)
 }
 
-func (s loggingService) List(ctx context.Context, order string, limit, offset int) (users []User, total int, err error) {
+func (s loggingService) List(ctx context.Context, f filter.Expr, by Filter, order string, limit, offset int, count db.Count) (users []User, total int, err error) {
 	defer func(begin time.Time) {
 		s.logger.Log(
 			"method", "list",
@@ -86,5 +234,449 @@ func (s loggingService) List(ctx context.Context, order string, limit, offset in
 		)
 	}(time.Now())
 
-	return s.next.List(ctx, order, limit, offset)
+	return s.next.List(ctx, f, by, order, limit, offset, count)
+}
+
+func (s loggingService) ListByCursor(ctx context.Context, f filter.Expr, by Filter, c Cursor, limit int) (users []User, prev, next string, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "list_by_cursor",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return s.next.ListByCursor(ctx, f, by, c, limit)
+}
+
+func (s loggingService) Search(ctx context.Context, query string, limit, offset int) (users []User, total int, err error) {
+	defer func(begin time.Time) {
+		s.logger.Log(
+			"method", "search",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	return s.next.Search(ctx, query, limit, offset)
+}
+
+func (s loggingService) Patch(ctx context.Context, userID string, match etag.Condition, p []byte) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "patch",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Patch(ctx, userID, match, p)
+}
+
+func (s loggingService) StartJob(ctx context.Context, j Job) (job Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "start_job",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.StartJob(ctx, j)
+}
+
+func (s loggingService) Jobs(ctx context.Context) (jobs []Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "jobs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Jobs(ctx)
+}
+
+func (s loggingService) Job(ctx context.Context, id string, status ...string) (job Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "job",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Job(ctx, id, status...)
+}
+
+func (s loggingService) JobExport(ctx context.Context, id string) (data string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "job_export",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.JobExport(ctx, id)
+}
+
+func (s loggingService) ProcessJobs(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "process_jobs",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ProcessJobs(ctx)
+}
+
+func (s loggingService) Enable2FA(ctx context.Context, userID string) (setup TwoFactorSetup, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "enable_2fa",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Enable2FA(ctx, userID)
+}
+
+func (s loggingService) Verify2FA(ctx context.Context, userID, code string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "verify_2fa",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Verify2FA(ctx, userID, code)
+}
+
+func (s loggingService) Disable2FA(ctx context.Context, userID, code string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "disable_2fa",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Disable2FA(ctx, userID, code)
+}
+
+func (s loggingService) Challenge2FA(ctx context.Context, u User) (challenge string, exp time.Time, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "challenge_2fa",
+			"user_id", u.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Challenge2FA(ctx, u)
+}
+
+func (s loggingService) Login2FA(ctx context.Context, challenge, code string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "login_2fa",
+			"user_id", user.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Login2FA(ctx, challenge, code)
+}
+
+func (s loggingService) TrustDevice(ctx context.Context, u User, c Client) (d TrustedDevice, token string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "trust_device",
+			"user_id", u.ID,
+			"device_id", d.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.TrustDevice(ctx, u, c)
+}
+
+func (s loggingService) CheckTrustedDevice(ctx context.Context, u User, token string, c Client) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "check_trusted_device",
+			"user_id", u.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CheckTrustedDevice(ctx, u, token, c)
+}
+
+func (s loggingService) TrustedDevices(ctx context.Context, userID string) (devices []TrustedDevice, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "trusted_devices",
+			"user_id", userID,
+			"devices", len(devices),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.TrustedDevices(ctx, userID)
+}
+
+func (s loggingService) RevokeTrustedDevice(ctx context.Context, userID, deviceID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_trusted_device",
+			"user_id", userID,
+			"device_id", deviceID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeTrustedDevice(ctx, userID, deviceID)
+}
+
+func (s loggingService) RevokeTrustedDevices(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_trusted_devices",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeTrustedDevices(ctx, userID)
+}
+
+func (s loggingService) Impersonate(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "impersonate",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Impersonate(ctx, userID)
+}
+
+func (s loggingService) Ban(ctx context.Context, adminID, userID, reason string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "ban",
+			"admin_id", adminID,
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Ban(ctx, adminID, userID, reason)
+}
+
+func (s loggingService) Unban(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "unban",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Unban(ctx, userID)
+}
+
+func (s loggingService) ForcePasswordReset(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "force_password_reset",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ForcePasswordReset(ctx, userID)
+}
+
+func (s loggingService) SetRole(ctx context.Context, adminID, userID, role string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_role",
+			"admin_id", adminID,
+			"user_id", userID,
+			"role", role,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetRole(ctx, adminID, userID, role)
+}
+
+func (s loggingService) LoginHistory(ctx context.Context, userID string, limit, offset int) (logins []audit.Event, total int, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "login_history",
+			"user_id", userID,
+			"limit", limit,
+			"offset", offset,
+			"total", total,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.LoginHistory(ctx, userID, limit, offset)
+}
+
+func (s loggingService) Get(ctx context.Context, userID string) (user User, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Get(ctx, userID)
+}
+
+func (s loggingService) Delete(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Delete(ctx, userID)
+}
+
+func (s loggingService) UploadAvatar(ctx context.Context, userID string, data []byte) (user User, avatars []Avatar, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "upload_avatar",
+			"user_id", userID,
+			"bytes", len(data),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UploadAvatar(ctx, userID, data)
+}
+
+func (s loggingService) Addresses(ctx context.Context, userID string) (list []Address, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "addresses",
+			"user_id", userID,
+			"addresses", len(list),
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Addresses(ctx, userID)
+}
+
+func (s loggingService) Address(ctx context.Context, userID, addressID string) (a Address, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "address",
+			"user_id", userID,
+			"address_id", addressID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Address(ctx, userID, addressID)
+}
+
+func (s loggingService) CreateAddress(ctx context.Context, userID string, a Address) (res Address, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_address",
+			"user_id", userID,
+			"address_id", res.ID,
+			"default", res.IsDefault,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreateAddress(ctx, userID, a)
+}
+
+func (s loggingService) UpdateAddress(ctx context.Context, userID, addressID string, a Address) (res Address, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "update_address",
+			"user_id", userID,
+			"address_id", addressID,
+			"default", res.IsDefault,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UpdateAddress(ctx, userID, addressID, a)
+}
+
+func (s loggingService) DeleteAddress(ctx context.Context, userID, addressID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_address",
+			"user_id", userID,
+			"address_id", addressID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DeleteAddress(ctx, userID, addressID)
+}
+
+func (s loggingService) DefaultAddress(ctx context.Context, userID string) (a Address, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "default_address",
+			"user_id", userID,
+			"address_id", a.ID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.DefaultAddress(ctx, userID)
+}
+
+func (s loggingService) Preferences(ctx context.Context, userID string) (p Preferences, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "preferences",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Preferences(ctx, userID)
+}
+
+func (s loggingService) SetPreferences(ctx context.Context, userID string, p Preferences) (out Preferences, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_preferences",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SetPreferences(ctx, userID, p)
+}
+
+func (s loggingService) Unlock(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "unlock",
+			"user_id", userID,
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Unlock(ctx, userID)
 }
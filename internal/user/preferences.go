@@ -0,0 +1,119 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// MaxPreferredGenres is the most genres a user can prefer.
+const MaxPreferredGenres = 20
+
+// Preferences are the settings of a user. Locale is the one of the
+// profile, the others are stored along with it as PreferenceString.
+type Preferences struct {
+	// Newsletter tells whether the user gets the newsletter. The consent
+	// to the marketing emails is kept apart, by the consent service.
+	Newsletter bool `json:"newsletter"`
+	// Genres are the IDs of the genres the user prefers, e.g. for the
+	// recommendations.
+	Genres []string `json:"genres"`
+	// Currency is the ISO 4217 code the prices are shown in, e.g. "EUR".
+	// Empty means the one of the shop.
+	Currency string `json:"currency,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+// Preferences returns the preferences of u, the default ones if it has
+// none stored.
+func (u User) Preferences() Preferences {
+	var p Preferences
+	if u.PreferenceString != "" {
+		// The stored preferences were validated, a broken one resets them.
+		_ = json.Unmarshal([]byte(u.PreferenceString), &p)
+	}
+	if p.Genres == nil {
+		p.Genres = make([]string, 0)
+	}
+	p.Locale = u.Locale
+	return p
+}
+
+// Normalize trims the values, upper cases the currency, and drops the
+// empty and repeated genres.
+func (p *Preferences) Normalize() {
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	p.Locale = strings.TrimSpace(p.Locale)
+	seen := make(map[string]bool)
+	genres := make([]string, 0, len(p.Genres))
+	for _, g := range p.Genres {
+		g = strings.TrimSpace(g)
+		if g != "" && !seen[g] {
+			seen[g] = true
+			genres = append(genres, g)
+		}
+	}
+	p.Genres = genres
+}
+
+// Validate checks the values of the preferences, their keys are checked
+// by the schema of the request.
+func (p Preferences) Validate() error {
+	var v validate.Validator
+	v.Check(len(p.Genres) <= MaxPreferredGenres, "genres", validate.CodeOutOfRange, "too many genres")
+	if p.Currency != "" {
+		v.Check(len(p.Currency) == 3 && strings.Trim(p.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "",
+			"currency", validate.CodeInvalid, "currency is not an ISO 4217 code")
+	}
+	if p.Locale != "" {
+		v.Check(i18n.Supported(p.Locale), "locale", validate.CodeInvalid, "locale is not supported")
+	}
+	return v.Err()
+}
+
+func (s service) Preferences(_ context.Context, userID string) (Preferences, error) {
+	u, err := s.repo.GetByID(userID)
+	if err != nil {
+		return Preferences{}, ErrUserNotFound
+	}
+	if u.DeletedAt != nil {
+		return Preferences{}, ErrDeactivated
+	}
+	return u.Preferences(), nil
+}
+
+// SetPreferences replaces the preferences of the user. A concurrent save
+// of the user is retried over, the preferences being replaced as a whole.
+func (s service) SetPreferences(_ context.Context, userID string, p Preferences) (Preferences, error) {
+	p.Normalize()
+	if err := p.Validate(); err != nil {
+		return Preferences{}, err
+	}
+	stored := p
+	stored.Locale = ""
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return Preferences{}, err
+	}
+	for attempt := 0; ; attempt++ {
+		u, err := s.repo.GetByID(userID)
+		if err != nil {
+			return Preferences{}, ErrUserNotFound
+		}
+		if u.DeletedAt != nil {
+			return Preferences{}, ErrDeactivated
+		}
+		u.PreferenceString, u.Locale = string(data), p.Locale
+		err = s.repo.Save(&u)
+		if err == nil {
+			return u.Preferences(), nil
+		}
+		if err != db.ErrConflict || attempt == 2 {
+			return Preferences{}, err
+		}
+	}
+}
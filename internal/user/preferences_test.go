@@ -0,0 +1,38 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func TestPreferences(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", Username: "anna", Locale: "de"})
+	s := user.NewService(r, nil, user.Config{})
+
+	p, err := s.Preferences(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Newsletter || p.Genres == nil || p.Locale != "de" {
+		t.Errorf("expected the default preferences in the locale of the profile, got %+v", p)
+	}
+
+	bad := user.Preferences{Currency: "euro", Locale: "xx"}
+	if _, err := s.SetPreferences(ctx, "u1", bad); len(validate.Fields(err)) != 2 {
+		t.Errorf("expected currency and locale failing, got %v", err)
+	}
+	p, err = s.SetPreferences(ctx, "u1", user.Preferences{Newsletter: true, Genres: []string{"g1", " g1", "", "g2"}, Currency: "eur", Locale: "fr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Newsletter || len(p.Genres) != 2 || p.Currency != "EUR" || r.user(t, "u1").Locale != "fr" {
+		t.Errorf("expected the preferences normalized and the locale on the profile, got %+v", p)
+	}
+	if p, _ := s.Preferences(ctx, "u1"); !p.Newsletter || p.Genres[1] != "g2" {
+		t.Errorf("expected the preferences stored, got %+v", p)
+	}
+}
@@ -0,0 +1,155 @@
+package user_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func TestPatchProfile(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", FirstName: "Anna", LastName: "Berg", Username: "anna", Role: user.RoleCustomer})
+	s := user.NewService(r, nil, user.Config{})
+
+	u, err := s.Patch(ctx, "u1", etag.Any, []byte(`{"avatar":"https://cdn.example.com/anna.png","bio":"Reads crime novels"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Avatar != "https://cdn.example.com/anna.png" || u.Bio != "Reads crime novels" || u.FirstName != "Anna" || u.Version != 1 {
+		t.Errorf("expected only the avatar and the bio changed, got %+v", u)
+	}
+
+	fields := validate.Fields(func() error {
+		_, err := s.Patch(ctx, "u1", etag.Any, []byte(`{"avatar":"javascript:alert(1)","first_name":""}`))
+		return err
+	}())
+	if len(fields) != 2 || fields[0].Field != "first_name" || fields[1].Field != "avatar" {
+		t.Errorf("expected the first name and the avatar rejected, got %+v", fields)
+	}
+	if _, err := s.Patch(ctx, "u1", etag.Any, []byte(`{"role":"admin"}`)); err == nil || r.user(t, "u1").Role != user.RoleCustomer {
+		t.Errorf("expected the role left out of the patch, got %v", err)
+	}
+
+	// The profile was read at version 0 by another client meanwhile.
+	req := httptest.NewRequest("PATCH", "/users/v1/u1", nil)
+	req.Header.Set("If-Match", `"0"`)
+	stale, err := etag.IfMatch(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Patch(ctx, "u1", stale, []byte(`{"bio":""}`)); err != etag.ErrPreconditionFailed {
+		t.Errorf("stale version: expected ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestPatchEndpoint(t *testing.T) {
+	r := newRepo(t, user.User{ID: "u1", FirstName: "Anna", LastName: "Berg", Username: "anna", Role: user.RoleCustomer})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	h := user.MakeHTTPHandler(context.Background(), user.NewService(r, nil, user.Config{}), tokens, nil, nil, nil, user.Limits{}, user.CSRF{}, log.NewNopLogger())
+	own, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	other, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"other user", other, http.StatusForbidden},
+		{"own profile", own, http.StatusOK},
+	} {
+		req := httptest.NewRequest("PATCH", "/users/v1/u1", strings.NewReader(`{"bio":"Reads crime novels"}`))
+		req.Header.Set("Content-Type", patch.ContentType)
+		req.Header.Set("If-Match", "*")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d", c.name, c.status, w.Code)
+		}
+		if c.status != http.StatusOK && r.user(t, "u1").Bio != "" {
+			t.Errorf("%s: expected the profile left as it is, got %q", c.name, r.user(t, "u1").Bio)
+		}
+		if c.status == http.StatusOK && strings.Contains(w.Body.String(), "password_reset_required") {
+			t.Errorf("%s: expected the profile, not the user, got %s", c.name, w.Body)
+		}
+	}
+	if r.user(t, "u1").Bio != "Reads crime novels" {
+		t.Errorf("expected the own profile patched, got %q", r.user(t, "u1").Bio)
+	}
+}
+
+func TestGetEndpoint(t *testing.T) {
+	nu := user.NewUser{Email: "anna@example.com", Password: "secret"}
+	u := nu.User()
+	u.ID, u.FirstName = "u1", "Anna"
+	r := newRepo(t, u)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	h := user.MakeHTTPHandler(context.Background(), user.NewService(r, nil, user.Config{}), tokens, nil, nil, nil, user.Limits{}, user.CSRF{}, log.NewNopLogger())
+	own, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	other, _, _ := tokens.Sign("u2", user.RoleCustomer, "")
+	support, _, _ := tokens.SignScoped("s1", user.RoleSupport, "", []string{user.ScopeUsersRead}, time.Hour)
+	writer, _, _ := tokens.SignScoped("a1", user.RoleAdmin, "", []string{user.ScopeUsersWrite}, time.Hour)
+	admin, _, _ := tokens.Sign("a1", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, path, token string
+		status            int
+	}{
+		{"no token", "/users/v1/u1", "", http.StatusUnauthorized},
+		{"other user", "/users/v1/u1", other, http.StatusForbidden},
+		{"staff without users:read", "/users/v1/u1", writer, http.StatusForbidden},
+		{"own profile", "/users/v1/u1", own, http.StatusOK},
+		{"staff with users:read", "/users/v1/u1", support, http.StatusOK},
+		{"unknown user", "/users/v1/u9", admin, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+		if c.status == http.StatusOK && (!strings.Contains(w.Body.String(), `"first_name":"Anna"`) || strings.Contains(w.Body.String(), "password")) {
+			t.Errorf("%s: expected the profile without the credentials, got %s", c.name, w.Body)
+		}
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", Email: "anna@example.com", AuthToken: "t1"})
+	s := user.NewService(r, nil, user.Config{})
+
+	if err := s.Delete(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if u := r.user(t, "u1"); !u.Deactivated || u.DeletedAt == nil || u.AuthToken != "" || u.Email != "anna@example.com" {
+		t.Errorf("expected the account deactivated and kept, got %+v", u)
+	}
+	if r.logouts["u1"] != 1 || r.devices["u1"] != 1 {
+		t.Errorf("expected the sessions and the devices revoked, got %d and %d", r.logouts["u1"], r.devices["u1"])
+	}
+	if _, err := s.Get(ctx, "u1"); err != user.ErrDeactivated {
+		t.Errorf("deleted user: expected ErrDeactivated, got %v", err)
+	}
+	if err := s.Delete(ctx, "u1"); err != user.ErrDeactivated {
+		t.Errorf("second delete: expected ErrDeactivated, got %v", err)
+	}
+	if err := s.Delete(ctx, "u2"); err != user.ErrUserNotFound {
+		t.Errorf("unknown user: expected ErrUserNotFound, got %v", err)
+	}
+}
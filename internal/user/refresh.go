@@ -0,0 +1,51 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshToken is a long-lived credential a client exchanges for a new
+// access token. Only the hash of the token is stored. A token is rotated on
+// use, its replacement being issued along with the access token.
+type RefreshToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id" sql:"index"`
+	SessionID string     `json:"session_id,omitempty" sql:"index"`
+	Hash      string     `json:"-" sql:"unique_index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// ReplacedBy is the ID of the token issued when it was used.
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+func (RefreshToken) TableName() string {
+	return "user_refresh_tokens"
+}
+
+// newRefreshToken returns a random token for the user and its record.
+func newRefreshToken(userID string, now time.Time) (string, RefreshToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", RefreshToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, RefreshToken{
+		UserID:    userID,
+		Hash:      hashRefreshToken(token),
+		ExpiresAt: now.Add(RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
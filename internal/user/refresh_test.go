@@ -0,0 +1,50 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1"}, user.User{ID: "u2", Deactivated: true})
+	s := user.NewService(r, nil, user.Config{})
+
+	session, first, err := s.StartSession(ctx, "u1", user.Client{})
+	if err != nil {
+		t.Fatalf("start: unexpected error %v", err)
+	}
+	u, sessionID, second, err := s.RefreshToken(ctx, first)
+	if err != nil || u.ID != "u1" || sessionID != session.ID || second == "" || second == first {
+		t.Fatalf("refresh: expected a new token for u1, got %v, %q, %q, %v", u.ID, sessionID, second, err)
+	}
+	if _, _, _, err := s.RefreshToken(ctx, "unknown"); err != user.ErrInvalidRefreshToken {
+		t.Errorf("unknown: expected ErrInvalidRefreshToken, got %v", err)
+	}
+
+	// reusing the first token revokes the second one too.
+	if _, _, _, err := s.RefreshToken(ctx, first); err != user.ErrInvalidRefreshToken {
+		t.Errorf("reuse: expected ErrInvalidRefreshToken, got %v", err)
+	}
+	if _, _, _, err := s.RefreshToken(ctx, second); err != user.ErrInvalidRefreshToken {
+		t.Errorf("after reuse: expected ErrInvalidRefreshToken, got %v", err)
+	}
+
+	_, third, _ := s.StartSession(ctx, "u1", user.Client{})
+	if err := s.RevokeRefreshTokens(ctx, "u1"); err != nil {
+		t.Fatalf("revoke: unexpected error %v", err)
+	}
+	if _, _, _, err := s.RefreshToken(ctx, third); err != user.ErrInvalidRefreshToken {
+		t.Errorf("revoked: expected ErrInvalidRefreshToken, got %v", err)
+	}
+	if err := s.RevokeRefreshTokens(ctx, "u3"); err != user.ErrUserNotFound {
+		t.Errorf("revoke unknown: expected ErrUserNotFound, got %v", err)
+	}
+
+	_, deactivated, _ := s.StartSession(ctx, "u2", user.Client{})
+	if _, _, _, err := s.RefreshToken(ctx, deactivated); err != user.ErrDeactivated {
+		t.Errorf("deactivated: expected ErrDeactivated, got %v", err)
+	}
+}
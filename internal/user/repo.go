@@ -1,14 +1,118 @@
 package user
 
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
 // Repo abstracts all the persistant storage operations of User service.
 type Repo interface {
 	Create(user *User) error
+	// Save bumps the Version of user, it fails with db.ErrConflict if the
+	// stored user has another version.
 	Save(user *User) error
 	GetByID(id string) (User, error)
 	GetByUserName(username string) (User, error)
 	GetByEmail(email string) (User, error)
 	GetByToken(token string) (User, error)
-	GetByResetKey(email string) (User, error)
-	List(order string, limit, offset int) (users []User, total int, err error)
+	// List and ListByFilter leave out the deleted users. List selects the
+	// users matching both f and by.
+	List(f filter.Expr, by Filter, order string, limit, offset int, count db.Count) (users []User, total int, err error)
+	// ListByCursor returns the limit users after c in registration order,
+	// or before it, and whether there are more beyond them.
+	ListByCursor(f filter.Expr, by Filter, c Cursor, limit int) (users []User, more bool, err error)
+	// Search returns the users whose name or email matches query, ranked,
+	// leaving out the deleted ones.
+	Search(query string, limit, offset int) (users []User, total int, err error)
+	// ListByFilter returns all the users f selects, by email.
+	ListByFilter(f Filter) ([]User, error)
+
+	CreateJob(j *Job) error
+	GetJob(ID string) (Job, error)
+	SaveJob(j *Job) error
+	// ListJobs returns the bulk jobs, latest first.
+	ListJobs() ([]Job, error)
+	// ListJobsByStatus returns the jobs of any of status, oldest first.
+	ListJobsByStatus(status ...string) ([]Job, error)
+	// CreateResults stores the results of a job in one go.
+	CreateResults(results []Result) error
+	// ListResults returns the results of a job of any of status, all if
+	// none.
+	ListResults(jobID string, status ...string) ([]Result, error)
+
+	CreateRefreshToken(t *RefreshToken) error
+	// GetRefreshToken returns the token of hash, revoked or not.
+	GetRefreshToken(hash string) (RefreshToken, error)
+	// RotateRefreshToken revokes old, replaced by next, and creates next in
+	// one go. It fails with db.ErrConflict if old was revoked meanwhile.
+	RotateRefreshToken(old, next *RefreshToken) error
+	// RevokeRefreshTokens revokes the tokens of the user not revoked yet.
+	RevokeRefreshTokens(userID string, at time.Time) error
+
+	CreateSession(s *Session) error
+	GetSession(id string) (Session, error)
+	// ListSessions returns the sessions of the user not revoked, latest seen
+	// first.
+	ListSessions(userID string) ([]Session, error)
+	// SeeSession sets the last seen time of the session.
+	SeeSession(id string, at time.Time) error
+	// RevokeSession revokes the session and its refresh tokens.
+	RevokeSession(id string, at time.Time) error
+	// RevokeSessions revokes the sessions of the user, the ones of the user
+	// impersonating others, and their refresh tokens, the refresh tokens
+	// without a session too.
+	RevokeSessions(userID string, at time.Time) error
+
+	CreateTrustedDevice(d *TrustedDevice) error
+	// GetTrustedDevice returns the device of the token hash, revoked or not.
+	GetTrustedDevice(hash string) (TrustedDevice, error)
+	// ListTrustedDevices returns the devices of the user not revoked, latest
+	// used first.
+	ListTrustedDevices(userID string) ([]TrustedDevice, error)
+	// UseTrustedDevice sets the last use time and IP address of the device.
+	UseTrustedDevice(id, ip string, at time.Time) error
+	RevokeTrustedDevice(id string, at time.Time) error
+	// RevokeTrustedDevices revokes the devices of the user not revoked yet.
+	RevokeTrustedDevices(userID string, at time.Time) error
+
+	// CreateAddress and SaveAddress store the address, unsetting the
+	// default of the other addresses of the user in one go if it's the
+	// default one.
+	CreateAddress(a *Address) error
+	SaveAddress(a *Address) error
+	// ListAddresses returns the addresses of the user, the default one
+	// first, then the latest updated.
+	ListAddresses(userID string) ([]Address, error)
+	DeleteAddress(id string) error
+
+	// GetSocialAccount returns the account of subject at provider.
+	GetSocialAccount(provider, subject string) (SocialAccount, error)
+	// LinkSocialAccount links a to u, creating u along if it has no ID yet.
+	LinkSocialAccount(u *User, a *SocialAccount) error
+
+	// SetSegments replaces the segments of the user. It doesn't bump the
+	// Version, the segments aren't edited by the user.
+	SetSegments(userID string, segments []string) error
+
+	CreateResetToken(t *ResetToken) error
+	// GetResetToken returns the token of hash, used or not.
+	GetResetToken(hash string) (ResetToken, error)
+	// UseResetToken marks t used at at. It fails with db.ErrConflict if t
+	// was used meanwhile.
+	UseResetToken(t *ResetToken, at time.Time) error
+
+	RecordLoginFailure(userID string, at time.Time) error
+	// CountLoginFailures returns the failures of the user since since.
+	CountLoginFailures(userID string, since time.Time) (int, error)
+	ClearLoginFailures(userID string) error
+	// SetLockedUntil locks the account until until, unlocks it if nil. It
+	// doesn't bump the Version.
+	SetLockedUntil(userID string, until *time.Time) error
+	// Forget deletes the sessions, trusted devices, refresh and reset
+	// tokens, social accounts, addresses and login failures of the user in
+	// one go, on its erasure. The user itself is left to anonymize.
+	Forget(userID string) error
 	Drop() error
 }
@@ -0,0 +1,66 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/db/inmem"
+	"github.com/kavirajk/bookshop/user"
+)
+
+// memRepo is the in-memory repo the tests of the service share, counting
+// by user the logouts, the revoked devices and the reset tokens sent.
+type memRepo struct {
+	user.Repo
+	logouts map[string]int
+	devices map[string]int
+	resets  map[string]int
+}
+
+func newRepo(t *testing.T, users ...user.User) *memRepo {
+	r := &memRepo{
+		Repo:    inmem.NewUserRepo(),
+		logouts: make(map[string]int),
+		devices: make(map[string]int),
+		resets:  make(map[string]int),
+	}
+	for _, u := range users {
+		if err := r.Create(&u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// user returns the stored user with the ID.
+func (r *memRepo) user(t *testing.T, ID string) user.User {
+	u, err := r.GetByID(ID)
+	if err != nil {
+		t.Fatalf("user %s: %v", ID, err)
+	}
+	return u
+}
+
+// set changes the stored user with the ID, behind the back of the service.
+func (r *memRepo) set(t *testing.T, ID string, change func(u *user.User)) {
+	u := r.user(t, ID)
+	change(&u)
+	if err := r.Save(&u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (r *memRepo) RevokeSessions(userID string, at time.Time) error {
+	r.logouts[userID]++
+	return r.Repo.RevokeSessions(userID, at)
+}
+
+func (r *memRepo) RevokeTrustedDevices(userID string, at time.Time) error {
+	r.devices[userID]++
+	return r.Repo.RevokeTrustedDevices(userID, at)
+}
+
+func (r *memRepo) CreateResetToken(t *user.ResetToken) error {
+	r.resets[t.UserID]++
+	return r.Repo.CreateResetToken(t)
+}
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/notification/email"
+)
+
+// DefaultResetTokenTTL is how long a password reset token can be used,
+// unless configured otherwise.
+const DefaultResetTokenTTL = time.Hour
+
+// ResetToken lets the user set a new password once, e.g. after forgetting
+// it. Only the hash of the token is stored, the token itself is emailed.
+type ResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id" sql:"index"`
+	Hash      string     `json:"-" sql:"unique_index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+func (ResetToken) TableName() string {
+	return "user_reset_tokens"
+}
+
+// Notifier tells the users about their account.
+type Notifier interface {
+	// ResetPassword sends the password reset token of u, valid until
+	// expiresAt.
+	ResetPassword(u User, token string, expiresAt time.Time) error
+}
+
+type emailNotifier struct {
+	resetURL string
+}
+
+// NewEmailNotifier returns a Notifier sending emails, linking to the reset
+// page at resetURL with the token as key parameter.
+func NewEmailNotifier(resetURL string) Notifier {
+	return emailNotifier{resetURL: resetURL}
+}
+
+func (n emailNotifier) ResetPassword(u User, token string, expiresAt time.Time) error {
+	return email.ResetPassword([]string{u.Email}, map[string]interface{}{
+		"user":       u,
+		"link":       n.resetURL + "?key=" + token,
+		"expires_at": expiresAt,
+	})
+}
+
+// ForgotPassword doesn't tell whether a user has email, a reset is only
+// sent to the active users.
+func (s service) ForgotPassword(_ context.Context, email string) error {
+	user, err := s.repo.GetByEmail(email)
+	switch {
+	case err == db.ErrNotFound:
+		return nil
+	case err != nil:
+		return err
+	case user.Deactivated || s.notifier == nil:
+		return nil
+	}
+	return s.sendReset(user)
+}
+
+// sendReset sends user a new reset token.
+func (s service) sendReset(user User) error {
+	token, t, err := newResetToken(user.ID, time.Now().UTC(), s.cfg.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateResetToken(&t); err != nil {
+		return err
+	}
+	return s.notifier.ResetPassword(user, token, t.ExpiresAt)
+}
+
+// ResetPassword sets the password of the user of the reset token key,
+// which can be used once before it expires.
+func (s service) ResetPassword(ctx context.Context, key, newPass string) error {
+	t, err := s.repo.GetResetToken(hashRefreshToken(key))
+	if err != nil {
+		if err == db.ErrNotFound {
+			return ErrInvalidResetKey
+		}
+		return err
+	}
+	now := time.Now().UTC()
+	if t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return ErrInvalidResetKey
+	}
+	user, err := s.repo.GetByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UseResetToken(&t, now); err != nil {
+		if err == db.ErrConflict {
+			return ErrInvalidResetKey
+		}
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		// The key was mailed to the user.
+		user.EmailVerifiedAt = &now
+	}
+	return s.changePassword(ctx, user, newPass)
+}
+
+// newResetToken returns a random token for the user and its record.
+func newResetToken(userID string, now time.Time, ttl time.Duration) (string, ResetToken, error) {
+	token, rt, err := newRefreshToken(userID, now)
+	if err != nil {
+		return "", ResetToken{}, err
+	}
+	return token, ResetToken{
+		UserID:    userID,
+		Hash:      rt.Hash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+// outbox records the reset tokens sent, by email.
+type outbox map[string]string
+
+func (o outbox) ResetPassword(u user.User, token string, expiresAt time.Time) error {
+	o[u.Email] = token
+	return nil
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t,
+		user.User{ID: "u1", Email: "a@example.com", Salt: "s"},
+		user.User{ID: "u2", Email: "b@example.com", Deactivated: true},
+	)
+	sent := outbox{}
+	s := user.NewService(r, sent, user.Config{})
+
+	for _, email := range []string{"a@example.com", "b@example.com", "nobody@example.com"} {
+		if err := s.ForgotPassword(ctx, email); err != nil {
+			t.Errorf("forgot %s: unexpected error %v", email, err)
+		}
+	}
+	token, ok := sent["a@example.com"]
+	if !ok || len(sent) != 1 || len(r.resets) != 1 || r.resets["u1"] != 1 {
+		t.Fatalf("forgot: expected a token sent to a@example.com only, got %v", sent)
+	}
+
+	if err := s.ResetPassword(ctx, "forged", "new-password"); err != user.ErrInvalidResetKey {
+		t.Errorf("forged: expected ErrInvalidResetKey, got %v", err)
+	}
+	old := r.user(t, "u1").Password
+	if err := s.ResetPassword(ctx, token, "new-password"); err != nil || r.user(t, "u1").Password == old || r.user(t, "u1").EmailVerifiedAt == nil {
+		t.Fatalf("reset: expected the password changed and the email verified, got %v", err)
+	}
+	if err := s.ResetPassword(ctx, token, "other-password"); err != user.ErrInvalidResetKey {
+		t.Errorf("reused: expected ErrInvalidResetKey, got %v", err)
+	}
+
+	// The tokens of the shortest TTL are expired by the time they are used.
+	s = user.NewService(r, sent, user.Config{ResetTokenTTL: time.Nanosecond})
+	if err := s.ForgotPassword(ctx, "a@example.com"); err != nil {
+		t.Fatalf("forgot: unexpected error %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := s.ResetPassword(ctx, sent["a@example.com"], "new-password"); err != user.ErrInvalidResetKey {
+		t.Errorf("expired: expected ErrInvalidResetKey, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package user
+
+import (
+	"time"
+
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Scopes of the user endpoints.
+const (
+	ScopeUsersRead  = "users:read"  // list the users, their logins and the bulk jobs
+	ScopeUsersWrite = "users:write" // run bulk jobs, revoke, unlock, ban and manage users
+)
+
+func init() {
+	rbac.Register(map[string]string{
+		ScopeUsersRead:  "list the users, their logins and the bulk jobs",
+		ScopeUsersWrite: "run bulk jobs, revoke the tokens of, unlock, ban, force the password reset of and change the role of the users",
+	})
+}
+
+// Scoped token lifetimes, in seconds.
+const (
+	DefaultScopedTTL = 30 * 24 * 60 * 60
+	MaxScopedTTL     = 90 * 24 * 60 * 60
+)
+
+// ScopedToken is the request of a user for a token of an integration,
+// acting as the user within Scopes.
+type ScopedToken struct {
+	Scopes []string `json:"scopes"`
+	// ExpiresIn is the lifetime of the token in seconds, DefaultScopedTTL
+	// if zero.
+	ExpiresIn int `json:"expires_in,omitempty"`
+}
+
+// Validate checks the scopes are registered ones.
+func (t ScopedToken) Validate() error {
+	var v validate.Validator
+	v.Check(len(t.Scopes) > 0, "scopes", validate.CodeRequired, "scopes is required")
+	for _, s := range t.Scopes {
+		if !v.Check(rbac.Known(s), "scopes", validate.CodeUnknown, "scope "+s+" is unknown") {
+			break
+		}
+	}
+	if t.ExpiresIn != 0 {
+		v.Range("expires_in", t.ExpiresIn, 1, MaxScopedTTL)
+	}
+	return v.Err()
+}
+
+// TTL returns the lifetime of the token.
+func (t ScopedToken) TTL() time.Duration {
+	if t.ExpiresIn == 0 {
+		return DefaultScopedTTL * time.Second
+	}
+	return time.Duration(t.ExpiresIn) * time.Second
+}
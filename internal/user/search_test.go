@@ -0,0 +1,77 @@
+package user_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestSearchEndpoint(t *testing.T) {
+	r := newRepo(t,
+		user.User{ID: "u1", FirstName: "Anna", LastName: "Berg", Email: "anna@example.com", Username: "anna"},
+		user.User{ID: "u2", FirstName: "Jo", LastName: "Lind", Email: "jo@example.com", Username: "jo"},
+	)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	h := user.MakeHTTPHandler(context.Background(), user.NewService(r, nil, user.Config{}), tokens, nil, nil, nil, user.Limits{}, user.CSRF{}, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	writer, _, _ := tokens.SignScoped("a1", user.RoleAdmin, "", []string{user.ScopeUsersWrite}, time.Hour)
+	support, _, _ := tokens.SignScoped("s1", user.RoleSupport, "", []string{user.ScopeUsersRead}, time.Hour)
+
+	for _, c := range []struct {
+		name, path, token string
+		status            int
+		ids               []string
+	}{
+		{"no token", "/users/v1/search?q=berg", "", http.StatusUnauthorized, nil},
+		{"customer", "/users/v1/search?q=berg", customer, http.StatusForbidden, nil},
+		{"staff without users:read", "/users/v1/search?q=berg", writer, http.StatusForbidden, nil},
+		{"empty query", "/users/v1/search?q=+", support, http.StatusBadRequest, nil},
+		{"bad limit", "/users/v1/search?q=berg&limit=0", support, http.StatusBadRequest, nil},
+		{"last name", "/users/v1/search?q=berg", support, http.StatusOK, []string{"u1"}},
+		{"email", "/users/v1/search?q=EXAMPLE.com", support, http.StatusOK, []string{"u1", "u2"}},
+		{"no match", "/users/v1/search?q=nobody", support, http.StatusOK, []string{}},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+			continue
+		}
+		if c.ids == nil {
+			continue
+		}
+		var res struct {
+			Data struct {
+				Users []user.User `json:"users"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		ids := make([]string, 0)
+		for _, u := range res.Data.Users {
+			ids = append(ids, u.ID)
+		}
+		if len(ids) != len(c.ids) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.ids, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != c.ids[i] {
+				t.Errorf("%s: expected %v, got %v", c.name, c.ids, ids)
+				break
+			}
+		}
+	}
+}
@@ -2,8 +2,20 @@ package user
 
 import (
 	"errors"
+	"net/url"
+	"time"
 
 	"context"
+
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/storage"
+	"github.com/kavirajk/bookshop/validate"
 )
 
 var (
@@ -11,8 +23,28 @@ var (
 	ErrInvalidPassword = errors.New("invalid password")
 	ErrInvalidResetKey = errors.New("invalid resetkey")
 	ErrUserNotFound    = errors.New("user not found")
+
+	ErrRegistrationDenied = errors.New("registration denied")
+	ErrDeactivated        = errors.New("user is deactivated")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrUnverifiedEmail     = errors.New("email not verified by the provider")
+
+	ErrInvalidCode          = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorDisabled    = errors.New("two-factor authentication not enabled")
+	ErrTwoFactorUnavailable = errors.New("two-factor authentication not available")
+
+	ErrAccountLocked = errors.New("account locked after too many failed logins")
+
+	ErrImpersonateStaff = errors.New("staff users can't be impersonated")
 )
 
+// resultsEvery is the number of users a bulk job goes through between
+// two saves of its results.
+const resultsEvery = 100
+
 // Service defines all the services provided user package.
 type Service interface {
 	Register(ctx context.Context, user NewUser) (User, error)
@@ -21,6 +53,126 @@ type Service interface {
 	// Used to authenticate via token
 	AuthToken(ctx context.Context, token string) (User, error)
 
+	// StartSession starts a login session of the user from the client,
+	// returning it along with its first refresh token.
+	StartSession(ctx context.Context, userID string, c Client) (Session, string, error)
+
+	// RefreshToken exchanges a refresh token for its replacement and
+	// returns the user and the session it belongs to. A token can be used
+	// once, using it again revokes every session of the user.
+	RefreshToken(ctx context.Context, token string) (user User, sessionID, next string, err error)
+
+	// StartTokenSession starts the session of a token issued to the user
+	// out of a login, granted scopes or of the admin impersonatedBy, for
+	// ttl. Revoking the session revokes the token.
+	StartTokenSession(ctx context.Context, userID string, c Client, scopes []string, impersonatedBy string, ttl time.Duration) (Session, error)
+
+	// Sessions returns the active sessions of the user, latest seen first.
+	Sessions(ctx context.Context, userID string) ([]Session, error)
+
+	// RevokeSession revokes a session of the user, along with its tokens.
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+
+	// RevokeSessions revokes every session of the user.
+	RevokeSessions(ctx context.Context, userID string) error
+
+	// ScopedTokens returns the sessions of the scoped tokens of the user
+	// not expired nor revoked, latest seen first.
+	ScopedTokens(ctx context.Context, userID string) ([]Session, error)
+
+	// RevokeScopedToken revokes a scoped token of the user by the ID of
+	// its session.
+	RevokeScopedToken(ctx context.Context, userID, sessionID string) error
+
+	// RevokeRefreshTokens revokes every refresh token of the user, the
+	// access tokens already issued stay valid until they expire.
+	RevokeRefreshTokens(ctx context.Context, userID string) error
+
+	// SocialLogin returns the user of an identity told by an OAuth
+	// provider. An identity seen for the first time is linked to the user
+	// of its email, created if none.
+	SocialLogin(ctx context.Context, id oauth.Identity) (User, error)
+
+	// Enable2FA returns a new TOTP secret for the user to add to an
+	// authenticator app. 2FA is enabled once Verify2FA checks a first code.
+	Enable2FA(ctx context.Context, userID string) (TwoFactorSetup, error)
+
+	// Verify2FA checks a TOTP code of the user, enabling 2FA if pending. A
+	// code is accepted once.
+	Verify2FA(ctx context.Context, userID, code string) error
+
+	// Disable2FA turns 2FA off, a current code being required.
+	Disable2FA(ctx context.Context, userID, code string) error
+
+	// Challenge2FA returns the challenge a login of an user with 2FA
+	// enabled answers with, along with its expiry.
+	Challenge2FA(ctx context.Context, u User) (string, time.Time, error)
+
+	// Login2FA completes the login of a challenge with a TOTP code.
+	Login2FA(ctx context.Context, challenge, code string) (User, error)
+
+	// TrustDevice trusts the client of a login of the user with a TOTP
+	// code for TrustedDeviceTTL, returning the device along with its token.
+	TrustDevice(ctx context.Context, u User, c Client) (TrustedDevice, string, error)
+
+	// CheckTrustedDevice tells whether the login of the user from the
+	// client with the device token can skip 2FA, ErrUntrustedDevice if not.
+	CheckTrustedDevice(ctx context.Context, u User, token string, c Client) error
+
+	// TrustedDevices returns the trusted devices of the user, latest used
+	// first.
+	TrustedDevices(ctx context.Context, userID string) ([]TrustedDevice, error)
+
+	// RevokeTrustedDevice makes a device of the user enter codes again.
+	RevokeTrustedDevice(ctx context.Context, userID, deviceID string) error
+
+	// RevokeTrustedDevices revokes every trusted device of the user.
+	RevokeTrustedDevices(ctx context.Context, userID string) error
+
+	// Preferences returns the preferences of the user.
+	Preferences(ctx context.Context, userID string) (Preferences, error)
+
+	// SetPreferences replaces the preferences of the user.
+	SetPreferences(ctx context.Context, userID string, p Preferences) (Preferences, error)
+
+	// Unlock unlocks an account locked after failed logins.
+	Unlock(ctx context.Context, userID string) error
+
+	// Impersonate returns the user an admin is to act as, to debug their
+	// issues. The staff users can't be impersonated.
+	Impersonate(ctx context.Context, userID string) (User, error)
+
+	// Ban deactivates the account of the user for reason and logs them out
+	// of every session, on behalf of the admin adminID.
+	Ban(ctx context.Context, adminID, userID, reason string) (User, error)
+
+	// Unban reactivates the account of a banned user.
+	Unban(ctx context.Context, userID string) (User, error)
+
+	// ForcePasswordReset logs the user out of every session and keeps them
+	// from logging in until they reset their password, sending them a
+	// reset.
+	ForcePasswordReset(ctx context.Context, userID string) error
+
+	// SetRole changes the role of the user, on behalf of the admin adminID.
+	SetRole(ctx context.Context, adminID, userID, role string) (User, error)
+
+	// LoginHistory returns a page of the logins of the user from the audit
+	// log, successful or not, newest first.
+	LoginHistory(ctx context.Context, userID string, limit, offset int) (logins []audit.Event, total int, err error)
+
+	// Get returns the user of userID, ErrDeactivated if the account is
+	// deleted.
+	Get(ctx context.Context, userID string) (User, error)
+
+	// Delete deactivates the account of a user and marks it deleted,
+	// keeping the user for their orders and documents. Its sessions,
+	// refresh tokens and trusted devices are revoked along.
+	Delete(ctx context.Context, userID string) error
+
+	// ForgotPassword emails a password reset token to the user of email.
+	ForgotPassword(ctx context.Context, email string) error
+
 	// Used to change user's password without old password (e.g: Forget Password)
 	ResetPassword(ctx context.Context, key, newpass string) error
 
@@ -30,17 +182,115 @@ type Service interface {
 	// List available user based on limit and offset.
 	// order takes string in the format "username asc" or " username desc"
 	// or in combination of multiple fields like "username asc, email desc"
-	List(ctx context.Context, order string, limit, offset int) (users []User, total int, err error)
+	// count tells how to compute the total, skipping it saves a COUNT(*).
+	// f filters the users on ListFields, by on the criteria of the query
+	// parameters of the list, see ParseFilter.
+	List(ctx context.Context, f filter.Expr, by Filter, order string, limit, offset int, count db.Count) (users []User, total int, err error)
+
+	// ListByCursor pages the users like List by keyset instead, in
+	// registration order, returning the cursors of the pages around.
+	ListByCursor(ctx context.Context, f filter.Expr, by Filter, c Cursor, limit int) (users []User, prev, next string, err error)
+
+	// Search returns the users whose name or email matches query, the
+	// best matches first, along with their total.
+	Search(ctx context.Context, query string, limit, offset int) (users []User, total int, err error)
+
+	// Patch applies a JSON merge patch to the profile of an user, if it
+	// matches the If-Match condition.
+	Patch(ctx context.Context, userID string, match etag.Condition, p []byte) (User, error)
+
+	// UploadAvatar resizes the image to AvatarSizes, stores them and sets
+	// the largest one as the avatar of the user.
+	UploadAvatar(ctx context.Context, userID string, data []byte) (User, []Avatar, error)
+
+	// Addresses returns the address book of the user, the default address
+	// first, then the latest updated.
+	Addresses(ctx context.Context, userID string) ([]Address, error)
+
+	// Address returns an address of the user.
+	Address(ctx context.Context, userID, addressID string) (Address, error)
+
+	// CreateAddress adds an address to the address book of the user, up to
+	// MaxAddresses. Adding the default address unsets the previous one.
+	CreateAddress(ctx context.Context, userID string, a Address) (Address, error)
+
+	// UpdateAddress replaces an address of the user, making it the default
+	// one if set so.
+	UpdateAddress(ctx context.Context, userID, addressID string, a Address) (Address, error)
+
+	// DeleteAddress removes an address of the user.
+	DeleteAddress(ctx context.Context, userID, addressID string) error
+
+	// DefaultAddress returns the address checkout preselects,
+	// ErrNoDefaultAddress if the user has none.
+	DefaultAddress(ctx context.Context, userID string) (Address, error)
+
+	// StartJob queues a bulk deactivate, role change or export of the
+	// users selected by the filter of j.
+	StartJob(ctx context.Context, j Job) (Job, error)
+
+	// Jobs returns all the bulk jobs.
+	Jobs(ctx context.Context) ([]Job, error)
+
+	// Job returns a bulk job along with its results of any of status.
+	Job(ctx context.Context, id string, status ...string) (Job, error)
+
+	// JobExport returns the CSV export of a done OpExport job.
+	JobExport(ctx context.Context, id string) (string, error)
+
+	// ProcessJobs runs the queued bulk jobs.
+	ProcessJobs(ctx context.Context) error
+}
+
+// Config controls the user service.
+type Config struct {
+	// TwoFactorKey encrypts the TOTP secrets and signs the login
+	// challenges. 2FA can't be enabled without it.
+	TwoFactorKey []byte
+	// Issuer names the shop in the authenticator apps.
+	Issuer string
+	// The account is locked for LockoutDuration after MaxFailedLogins
+	// failed logins within LockoutWindow, or until an admin unlocks it.
+	MaxFailedLogins int
+	LockoutWindow   time.Duration
+	LockoutDuration time.Duration
+	// ResetTokenTTL is how long a password reset token can be used.
+	ResetTokenTTL time.Duration
+	// Avatars stores the uploaded avatars. They can't be uploaded without
+	// it, only patched as URLs.
+	Avatars storage.Storage
+	// Logins is the audit log the login history is read from, it isn't
+	// available without it.
+	Logins audit.Service
 }
 
 // service is a simple implementation of Service interface.
 type service struct {
-	repo Repo
+	repo     Repo
+	notifier Notifier
+	cfg      Config
+	sealer   *sealer
 }
 
-// NewService takes User Repo and returns new User Service.
-func NewService(repo Repo) Service {
-	return service{repo: repo}
+// NewService takes User Repo and returns new User Service. A nil notifier
+// sends no password reset.
+func NewService(repo Repo, notifier Notifier, cfg Config) Service {
+	if cfg.Issuer == "" {
+		cfg.Issuer = "Bookshop"
+	}
+	if cfg.MaxFailedLogins <= 0 {
+		cfg.MaxFailedLogins = DefaultMaxFailedLogins
+	}
+	if cfg.LockoutWindow <= 0 {
+		cfg.LockoutWindow = DefaultLockoutWindow
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = DefaultLockoutDuration
+	}
+	if cfg.ResetTokenTTL <= 0 {
+		cfg.ResetTokenTTL = DefaultResetTokenTTL
+	}
+	return service{repo: repo, notifier: notifier, cfg: cfg, sealer: newSealer(cfg.TwoFactorKey)}
 }
 
 // Register registers the new user.
@@ -62,8 +312,17 @@ func (s service) Login(_ context.Context, email, password string) (User, error)
 	if err != nil {
 		return User{}, ErrUserNotFound
 	}
+	if user.Locked(time.Now()) {
+		return User{}, ErrAccountLocked
+	}
 	if user.Password != calculatePassHash(password, user.Salt) {
-		return User{}, ErrUnauthorized
+		return User{}, s.loginFailed(user, ErrUnauthorized)
+	}
+	if err := canLogin(user); err != nil {
+		return User{}, err
+	}
+	if err := s.loginSucceeded(user); err != nil {
+		return User{}, err
 	}
 	return user, nil
 }
@@ -74,20 +333,128 @@ func (s service) AuthToken(_ context.Context, token string) (User, error) {
 	if err != nil {
 		return User{}, err
 	}
+	if user.Deactivated {
+		return User{}, ErrDeactivated
+	}
 	return user, nil
 }
 
-// ResetPassword is used to change the users' password with key and newPass.
-// Typical use-case would be forgot password.
-func (s service) ResetPassword(ctx context.Context, key, newPass string) error {
-	user, err := s.repo.GetByResetKey(key)
+// RefreshToken rotates the token. The reuse of a rotated token tells a copy
+// of it leaked, revoking all the sessions of the user logs out both the
+// client and the one holding the copy.
+func (s service) RefreshToken(_ context.Context, token string) (User, string, string, error) {
+	if token == "" {
+		return User{}, "", "", ErrInvalidRefreshToken
+	}
+	old, err := s.repo.GetRefreshToken(hashRefreshToken(token))
+	if err == db.ErrNotFound {
+		return User{}, "", "", ErrInvalidRefreshToken
+	}
 	if err != nil {
-		return err
+		return User{}, "", "", err
 	}
-	if user.ResetKey != key {
-		return ErrInvalidResetKey
+	now := time.Now().UTC()
+	if old.RevokedAt != nil {
+		if old.ReplacedBy == "" {
+			// revoked along with its session, or on request.
+			return User{}, "", "", ErrInvalidRefreshToken
+		}
+		if err := s.repo.RevokeSessions(old.UserID, now); err != nil {
+			return User{}, "", "", err
+		}
+		return User{}, "", "", ErrInvalidRefreshToken
 	}
-	return s.changePassword(ctx, user, newPass)
+	if !now.Before(old.ExpiresAt) {
+		return User{}, "", "", ErrInvalidRefreshToken
+	}
+	user, err := s.repo.GetByID(old.UserID)
+	if err != nil {
+		return User{}, "", "", ErrInvalidRefreshToken
+	}
+	if user.Deactivated {
+		return User{}, "", "", ErrDeactivated
+	}
+	next, t, err := newRefreshToken(user.ID, now)
+	if err != nil {
+		return User{}, "", "", err
+	}
+	t.SessionID = old.SessionID
+	if err := s.repo.RotateRefreshToken(&old, &t); err != nil {
+		if err == db.ErrConflict {
+			// used concurrently, the other use got the replacement.
+			return User{}, "", "", ErrInvalidRefreshToken
+		}
+		return User{}, "", "", err
+	}
+	if old.SessionID != "" {
+		if err := s.repo.SeeSession(old.SessionID, now); err != nil {
+			return User{}, "", "", err
+		}
+	}
+	return user, old.SessionID, next, nil
+}
+
+// RevokeRefreshTokens returns ErrUserNotFound for unknown users.
+func (s service) RevokeRefreshTokens(_ context.Context, userID string) error {
+	if _, err := s.repo.GetByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	return s.repo.RevokeRefreshTokens(userID, time.Now().UTC())
+}
+
+// SocialLogin only links the identity to an existing user if the provider
+// verified the email, anyone could otherwise take over an account by
+// signing up at a provider with its email.
+func (s service) SocialLogin(_ context.Context, id oauth.Identity) (User, error) {
+	a, err := s.repo.GetSocialAccount(id.Provider, id.Subject)
+	switch {
+	case err == nil:
+		user, err := s.repo.GetByID(a.UserID)
+		if err != nil {
+			return User{}, ErrUserNotFound
+		}
+		if err := canLogin(user); err != nil {
+			return User{}, err
+		}
+		return user, nil
+	case err != db.ErrNotFound:
+		return User{}, err
+	}
+
+	if id.Email == "" || !id.EmailVerified {
+		return User{}, ErrUnverifiedEmail
+	}
+	user, err := s.repo.GetByEmail(id.Email)
+	switch {
+	case err == db.ErrNotFound:
+		if user, err = socialUser(id); err != nil {
+			return User{}, err
+		}
+	case err != nil:
+		return User{}, err
+	default:
+		if err := canLogin(user); err != nil {
+			return User{}, err
+		}
+	}
+	a = SocialAccount{
+		Provider:  id.Provider,
+		Subject:   id.Subject,
+		UserID:    user.ID,
+		Email:     id.Email,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.LinkSocialAccount(&user, &a); err != nil {
+		return User{}, err
+	}
+	if user.EmailVerifiedAt == nil {
+		// The provider verified the email of the existing user.
+		user.EmailVerifiedAt = &a.CreatedAt
+		if err := s.repo.Save(&user); err != nil {
+			return User{}, err
+		}
+	}
+	return user, nil
 }
 
 // ChangePassword is used to change the user's password with oldpassword.
@@ -104,13 +471,222 @@ func (s service) ChangePassword(ctx context.Context, userID, oldPass, newPass st
 }
 
 // ListUser lists all the available users in the system.
-func (s service) List(ctx context.Context, order string, limit, offset int) ([]User, int, error) {
-	return s.repo.List(order, limit, offset)
+func (s service) List(ctx context.Context, f filter.Expr, by Filter, order string, limit, offset int, count db.Count) ([]User, int, error) {
+	return s.repo.List(f, by, order, limit, offset, count)
+}
+
+func (s service) Search(ctx context.Context, query string, limit, offset int) ([]User, int, error) {
+	return s.repo.Search(query, limit, offset)
+}
+
+// Patch applies a JSON merge patch to the first_name, last_name, username,
+// timezone, locale, avatar or bio of an user, the other fields can't be
+// changed this way. The user must still be at a version match allows.
+func (s service) Patch(_ context.Context, userID string, match etag.Condition, p []byte) (User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	if err := match.Check(user.Version); err != nil {
+		return User{}, err
+	}
+	if err := patch.Apply(&user, p, "first_name", "last_name", "username", "timezone", "locale", "avatar", "bio"); err != nil {
+		return User{}, err
+	}
+	var v validate.Validator
+	v.Required("first_name", user.FirstName)
+	v.Required("last_name", user.LastName)
+	if v.Required("username", user.Username) {
+		other, err := s.repo.GetByUserName(user.Username)
+		v.Check(err != nil || other.ID == user.ID, "username", validate.CodeTaken, "username is taken")
+	}
+	if user.Timezone != "" {
+		_, err := time.LoadLocation(user.Timezone)
+		v.Check(err == nil && user.Timezone != "Local", "timezone", validate.CodeInvalid, "timezone is not an IANA time zone")
+	}
+	if user.Locale != "" {
+		v.Check(i18n.Supported(user.Locale), "locale", validate.CodeInvalid, "locale is not supported")
+	}
+	if user.Avatar != "" && v.MaxLength("avatar", user.Avatar, MaxAvatarLength) {
+		u, err := url.Parse(user.Avatar)
+		v.Check(err == nil && u.Scheme == "https" && u.Host != "", "avatar", validate.CodeInvalid, "avatar is not an https URL")
+	}
+	v.MaxLength("bio", user.Bio, MaxBioLength)
+	if err := v.Err(); err != nil {
+		return User{}, err
+	}
+	if err := s.repo.Save(&user); err != nil {
+		if err == db.ErrConflict {
+			return User{}, etag.ErrPreconditionFailed
+		}
+		return User{}, err
+	}
+	return user, nil
+}
+
+// StartJob validates and queues a bulk job, the users are only selected
+// when it runs.
+func (s service) StartJob(_ context.Context, j Job) (Job, error) {
+	if err := j.Validate(); err != nil {
+		return Job{}, err
+	}
+	if j.Op != OpRole {
+		j.Role = ""
+	}
+	now := time.Now().UTC()
+	j.ID = ""
+	j.Status = JobQueued
+	j.Total, j.Succeeded, j.Failed, j.Skipped = 0, 0, 0, 0
+	j.Export, j.FinishedAt, j.Results = "", nil, nil
+	j.CreatedAt, j.UpdatedAt = now, now
+	if err := s.repo.CreateJob(&j); err != nil {
+		return Job{}, err
+	}
+	return j, nil
+}
+
+// Jobs returns all the bulk jobs, latest first.
+func (s service) Jobs(_ context.Context) ([]Job, error) {
+	return s.repo.ListJobs()
+}
+
+// Job returns a bulk job with its results of any of status, e.g. only the
+// failed ones.
+func (s service) Job(_ context.Context, id string, status ...string) (Job, error) {
+	j, err := s.repo.GetJob(id)
+	if err != nil {
+		return Job{}, ErrJobNotFound
+	}
+	if j.Results, err = s.repo.ListResults(j.ID, status...); err != nil {
+		return Job{}, err
+	}
+	return j, nil
+}
+
+// JobExport returns the CSV of an export job once it's done.
+func (s service) JobExport(_ context.Context, id string) (string, error) {
+	j, err := s.repo.GetJob(id)
+	if err != nil || j.Op != OpExport {
+		return "", ErrJobNotFound
+	}
+	if j.Status != JobDone {
+		return "", ErrJobNotDone
+	}
+	return j.Export, nil
+}
+
+// ProcessJobs runs the queued jobs one after another. A job interrupted
+// half way resumes with the users it has no result for yet.
+func (s service) ProcessJobs(ctx context.Context) error {
+	jobs, err := s.repo.ListJobsByStatus(JobQueued, JobProcessing)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if err := s.process(ctx, &j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s service) process(ctx context.Context, j *Job) error {
+	users, err := s.repo.ListByFilter(j.Filter)
+	if err != nil {
+		return err
+	}
+	results, err := s.repo.ListResults(j.ID)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool)
+	for _, r := range results {
+		done[r.UserID] = true
+	}
+	j.Status = JobProcessing
+	j.Total = len(users)
+	if err := s.saveJob(j, results); err != nil {
+		return err
+	}
+
+	var batch []Result
+	for _, u := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if done[u.ID] {
+			continue
+		}
+		batch = append(batch, s.apply(j, u))
+		if len(batch) == resultsEvery {
+			if err := s.repo.CreateResults(batch); err != nil {
+				return err
+			}
+			results, batch = append(results, batch...), nil
+			if err := s.saveJob(j, results); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.repo.CreateResults(batch); err != nil {
+		return err
+	}
+	results = append(results, batch...)
+
+	if j.Op == OpExport {
+		if j.Export, err = exportCSV(users); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	j.Status, j.FinishedAt = JobDone, &now
+	return s.saveJob(j, results)
+}
+
+// apply runs the op of job j on user u. The admin who requested the job
+// never deactivates or demotes their own account.
+func (s service) apply(j *Job, u User) Result {
+	r := Result{JobID: j.ID, UserID: u.ID, Email: u.Email, Status: ResultOK}
+	skip := func(reason string) Result {
+		r.Status, r.Reason = ResultSkipped, reason
+		return r
+	}
+	switch j.Op {
+	case OpExport:
+		return r
+	case OpDeactivate:
+		if u.Deactivated {
+			return skip("already deactivated")
+		}
+		if u.ID == j.RequestedBy {
+			return skip("own account")
+		}
+		// Dropping the token logs the user out right away.
+		u.Deactivated, u.AuthToken = true, ""
+	case OpRole:
+		if u.Role == j.Role {
+			return skip("already " + j.Role)
+		}
+		if u.ID == j.RequestedBy {
+			return skip("own account")
+		}
+		u.Role = j.Role
+	}
+	if err := s.repo.Save(&u); err != nil {
+		r.Status, r.Reason = ResultFailed, err.Error()
+	}
+	return r
+}
+
+func (s service) saveJob(j *Job, results []Result) error {
+	j.Count(results)
+	j.UpdatedAt = time.Now().UTC()
+	return s.repo.SaveJob(j)
 }
 
 // changePassword is an unexpoted helper function to change the password of the user.
 func (s service) changePassword(_ context.Context, user User, newPass string) error {
-	user.Password = calculatePassHash(newPass, user.Salt)
+	user.Password, user.PasswordResetRequired = calculatePassHash(newPass, user.Salt), false
 	if err := s.repo.Save(&user); err != nil {
 		return err
 	}
@@ -119,3 +695,192 @@ func (s service) changePassword(_ context.Context, user User, newPass string) er
 
 // Middleware is a Service middleware for user Service
 type Middleware func(Service) Service
+
+// Enable2FA replaces a pending secret, the user may have lost it.
+func (s service) Enable2FA(_ context.Context, userID string) (TwoFactorSetup, error) {
+	if s.sealer == nil {
+		return TwoFactorSetup{}, ErrTwoFactorUnavailable
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return TwoFactorSetup{}, ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return TwoFactorSetup{}, ErrTwoFactorEnabled
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TwoFactorSetup{}, err
+	}
+	if user.TOTPSecret, err = s.sealer.seal(secret); err != nil {
+		return TwoFactorSetup{}, err
+	}
+	user.TOTPStep = 0
+	if err := s.repo.Save(&user); err != nil {
+		return TwoFactorSetup{}, err
+	}
+	return TwoFactorSetup{
+		Secret: totpEncoding.EncodeToString(secret),
+		URI:    totpURI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s service) Verify2FA(_ context.Context, userID, code string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.TOTPSecret == "" {
+		return ErrTwoFactorDisabled
+	}
+	if err := s.checkCode(&user, code); err != nil {
+		return err
+	}
+	user.TwoFactorEnabled = true
+	return s.save(&user)
+}
+
+// Disable2FA drops the secret, enabling again starts with a new one.
+func (s service) Disable2FA(_ context.Context, userID, code string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorDisabled
+	}
+	if err := s.checkCode(&user, code); err != nil {
+		return err
+	}
+	user.TwoFactorEnabled, user.TOTPSecret, user.TOTPStep = false, "", 0
+	return s.save(&user)
+}
+
+// Challenge2FA signs the challenge with the password hash of the user, it
+// doesn't outlive a password change.
+func (s service) Challenge2FA(_ context.Context, u User) (string, time.Time, error) {
+	if s.sealer == nil {
+		return "", time.Time{}, ErrTwoFactorUnavailable
+	}
+	exp := time.Now().Add(ChallengeTTL)
+	return s.sealer.challenge(u, exp), exp, nil
+}
+
+func (s service) Login2FA(_ context.Context, challenge, code string) (User, error) {
+	if s.sealer == nil {
+		return User{}, ErrTwoFactorUnavailable
+	}
+	userID, ok := challengeUser(challenge, time.Now())
+	if !ok {
+		return User{}, ErrInvalidChallenge
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil || !s.sealer.verifyChallenge(challenge, user) || !user.TwoFactorEnabled {
+		return User{}, ErrInvalidChallenge
+	}
+	if user.Deactivated {
+		return User{}, ErrDeactivated
+	}
+	if user.Locked(time.Now()) {
+		return User{}, ErrAccountLocked
+	}
+	if err := s.checkCode(&user, code); err != nil {
+		if err == ErrInvalidCode {
+			return User{}, s.loginFailed(user, err)
+		}
+		return User{}, err
+	}
+	if err := s.save(&user); err != nil {
+		return User{}, err
+	}
+	if err := s.loginSucceeded(user); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (s service) Unlock(_ context.Context, userID string) error {
+	if _, err := s.repo.GetByID(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.repo.ClearLoginFailures(userID); err != nil {
+		return err
+	}
+	return s.repo.SetLockedUntil(userID, nil)
+}
+
+func (s service) Impersonate(_ context.Context, userID string) (User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	if user.Role != RoleCustomer {
+		return User{}, ErrImpersonateStaff
+	}
+	if user.Deactivated {
+		return User{}, ErrDeactivated
+	}
+	return user, nil
+}
+
+func (s service) Get(_ context.Context, userID string) (User, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return User{}, ErrUserNotFound
+	}
+	if user.DeletedAt != nil {
+		return User{}, ErrDeactivated
+	}
+	return user, nil
+}
+
+// Delete is a soft delete, dropping the token logs the user out right
+// away.
+func (s service) Delete(_ context.Context, userID string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.DeletedAt != nil {
+		return ErrDeactivated
+	}
+	now := time.Now().UTC()
+	user.Deactivated, user.DeletedAt, user.AuthToken = true, &now, ""
+	if err := s.repo.Save(&user); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeSessions(userID, now); err != nil {
+		return err
+	}
+	return s.repo.RevokeTrustedDevices(userID, now)
+}
+
+// checkCode checks code against the secret of user and records its step,
+// the caller saving user.
+func (s service) checkCode(user *User, code string) error {
+	if s.sealer == nil {
+		return ErrTwoFactorUnavailable
+	}
+	secret, err := s.sealer.open(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+	step, ok := checkTOTP(secret, code, time.Now(), user.TOTPStep)
+	if !ok {
+		return ErrInvalidCode
+	}
+	user.TOTPStep = step
+	return nil
+}
+
+// save saves the user after checkCode, a concurrent use of the same code
+// failing the version check.
+func (s service) save(user *User) error {
+	if err := s.repo.Save(user); err != nil {
+		if err == db.ErrConflict {
+			return ErrInvalidCode
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// seenEvery is how often the last seen time of a session is written, at
+// most.
+const seenEvery = time.Minute
+
+// Session is a login of a user on a device, the refresh and access tokens
+// of the login belonging to it. Revoking the session logs the device out.
+// The scoped and the impersonation tokens have a session of their own, it
+// is never refreshed and ends with the token.
+type Session struct {
+	ID        string `json:"id"`
+	UserID    string `json:"-" sql:"index"`
+	Device    string `json:"device,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	// ImpersonatedBy is the admin acting as the user, the session being
+	// revoked with the sessions of the admin too.
+	ImpersonatedBy string     `json:"impersonated_by,omitempty" sql:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	// Current is set on the session of the request listing them.
+	Current bool `json:"current" sql:"-"`
+}
+
+func (Session) TableName() string {
+	return "user_sessions"
+}
+
+// Client is where a login is made from.
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// clientFrom returns the client of the request, see transport.ClientIP.
+func clientFrom(ctx context.Context) Client {
+	return Client{IP: transport.ClientIP(ctx), UserAgent: transport.UserAgent(ctx)}
+}
+
+// deviceOf names the device of a user agent for the user to recognize the
+// session, e.g. "iPhone".
+func deviceOf(ua string) string {
+	for _, d := range []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"CrOS", "Chromebook"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, d.token) {
+			return d.name
+		}
+	}
+	return ""
+}
+
+func (s service) StartSession(_ context.Context, userID string, c Client) (Session, string, error) {
+	now := time.Now().UTC()
+	session := Session{
+		UserID:     userID,
+		Device:     deviceOf(c.UserAgent),
+		IP:         c.IP,
+		UserAgent:  c.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.repo.CreateSession(&session); err != nil {
+		return Session{}, "", err
+	}
+	token, t, err := newRefreshToken(userID, now)
+	if err != nil {
+		return Session{}, "", err
+	}
+	t.SessionID = session.ID
+	if err := s.repo.CreateRefreshToken(&t); err != nil {
+		return Session{}, "", err
+	}
+	return session, token, nil
+}
+
+// StartTokenSession takes the times of the session from the token, valid
+// for ttl, and creates no refresh token.
+func (s service) StartTokenSession(_ context.Context, userID string, c Client, scopes []string, impersonatedBy string, ttl time.Duration) (Session, error) {
+	now := time.Now().UTC()
+	exp := now.Add(ttl)
+	session := Session{
+		UserID:         userID,
+		Device:         deviceOf(c.UserAgent),
+		IP:             c.IP,
+		UserAgent:      c.UserAgent,
+		Scope:          strings.Join(scopes, " "),
+		ImpersonatedBy: impersonatedBy,
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		ExpiresAt:      &exp,
+	}
+	if err := s.repo.CreateSession(&session); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// Sessions leaves out the sessions not seen for longer than a refresh
+// token lives, they can't be refreshed anymore, and the sessions of the
+// expired tokens.
+func (s service) Sessions(_ context.Context, userID string) ([]Session, error) {
+	all, err := s.repo.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	since := now.Add(-RefreshTokenTTL)
+	sessions := make([]Session, 0, len(all))
+	for _, session := range all {
+		if session.ExpiresAt != nil {
+			if session.ExpiresAt.After(now) {
+				sessions = append(sessions, session)
+			}
+			continue
+		}
+		if session.LastSeenAt.After(since) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession returns ErrSessionNotFound for the sessions of the other
+// users.
+func (s service) RevokeSession(_ context.Context, userID, sessionID string) error {
+	session, err := s.repo.GetSession(sessionID)
+	if err == db.ErrNotFound || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeSession(sessionID, time.Now().UTC())
+}
+
+func (s service) RevokeSessions(_ context.Context, userID string) error {
+	return s.repo.RevokeSessions(userID, time.Now().UTC())
+}
+
+func (s service) ScopedTokens(ctx context.Context, userID string) ([]Session, error) {
+	sessions, err := s.Sessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	scoped := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Scope != "" {
+			scoped = append(scoped, session)
+		}
+	}
+	return scoped, nil
+}
+
+// RevokeScopedToken returns ErrSessionNotFound for the sessions of the
+// other users and the login sessions, see RevokeSession.
+func (s service) RevokeScopedToken(_ context.Context, userID, sessionID string) error {
+	session, err := s.repo.GetSession(sessionID)
+	if err == db.ErrNotFound || (err == nil && (session.UserID != userID || session.Scope == "")) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeSession(sessionID, time.Now().UTC())
+}
+
+type sessions struct {
+	repo Repo
+}
+
+// NewSessions returns the auth.Sessions of the users stored in repo, the
+// access tokens of a revoked session being rejected.
+func NewSessions(repo Repo) auth.Sessions {
+	return sessions{repo: repo}
+}
+
+// Seen writes the last seen time every seenEvery, not on every request.
+func (s sessions) Seen(id string, at time.Time) error {
+	session, err := s.repo.GetSession(id)
+	if err == db.ErrNotFound {
+		return auth.ErrRevokedToken
+	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return auth.ErrRevokedToken
+	}
+	if at.Sub(session.LastSeenAt) < seenEvery {
+		return nil
+	}
+	return s.repo.SeeSession(id, at.UTC())
+}
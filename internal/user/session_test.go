@@ -0,0 +1,98 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1"}, user.User{ID: "u2"})
+	s := user.NewService(r, nil, user.Config{})
+	iphone := user.Client{IP: "192.0.2.1", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"}
+
+	phone, refresh, err := s.StartSession(ctx, "u1", iphone)
+	if err != nil || phone.Device != "iPhone" || phone.IP != "192.0.2.1" {
+		t.Fatalf("start: expected an iPhone session, got %+v, %v", phone, err)
+	}
+	laptop, _, _ := s.StartSession(ctx, "u1", user.Client{UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"})
+	other, _, _ := s.StartSession(ctx, "u2", user.Client{})
+	if sessions, err := s.Sessions(ctx, "u1"); err != nil || len(sessions) != 2 {
+		t.Errorf("sessions: expected 2 sessions, got %+v, %v", sessions, err)
+	}
+
+	seen := user.NewSessions(r)
+	later := phone.LastSeenAt.Add(time.Hour)
+	if err := seen.Seen(phone.ID, later); err != nil {
+		t.Errorf("seen: unexpected error %v", err)
+	}
+	if got, _ := r.GetSession(phone.ID); !got.LastSeenAt.Equal(later) {
+		t.Errorf("seen: expected the last seen time updated, got %v", got.LastSeenAt)
+	}
+
+	if err := s.RevokeSession(ctx, "u1", other.ID); err != user.ErrSessionNotFound {
+		t.Errorf("other user: expected ErrSessionNotFound, got %v", err)
+	}
+	if err := s.RevokeSession(ctx, "u1", phone.ID); err != nil {
+		t.Fatalf("revoke: unexpected error %v", err)
+	}
+	if err := seen.Seen(phone.ID, later); err != auth.ErrRevokedToken {
+		t.Errorf("revoked: expected ErrRevokedToken, got %v", err)
+	}
+	if _, _, _, err := s.RefreshToken(ctx, refresh); err != user.ErrInvalidRefreshToken {
+		t.Errorf("revoked refresh: expected ErrInvalidRefreshToken, got %v", err)
+	}
+	if sessions, _ := s.Sessions(ctx, "u1"); len(sessions) != 1 || sessions[0].ID != laptop.ID {
+		t.Errorf("sessions: expected the laptop only, got %+v", sessions)
+	}
+
+	if err := s.RevokeSessions(ctx, "u1"); err != nil {
+		t.Fatalf("revoke all: unexpected error %v", err)
+	}
+	if sessions, _ := s.Sessions(ctx, "u1"); len(sessions) != 0 {
+		t.Errorf("revoke all: expected no session, got %+v", sessions)
+	}
+	if err := seen.Seen(other.ID, later); err != nil {
+		t.Errorf("other user: expected the session kept, got %v", err)
+	}
+}
+
+func TestScopedTokens(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1"}, user.User{ID: "u2"})
+	s := user.NewService(r, nil, user.Config{})
+	scopes := []string{user.ScopeUsersRead}
+
+	login, _, _ := s.StartSession(ctx, "u1", user.Client{})
+	token, err := s.StartTokenSession(ctx, "u1", user.Client{}, scopes, "", time.Hour)
+	if err != nil || token.Scope != user.ScopeUsersRead || token.ExpiresAt == nil {
+		t.Fatalf("start: expected a scoped session, got %+v, %v", token, err)
+	}
+	other, _ := s.StartTokenSession(ctx, "u2", user.Client{}, scopes, "", time.Hour)
+	if tokens, err := s.ScopedTokens(ctx, "u1"); err != nil || len(tokens) != 1 || tokens[0].ID != token.ID {
+		t.Errorf("tokens: expected the scoped token only, got %+v, %v", tokens, err)
+	}
+
+	if err := s.RevokeScopedToken(ctx, "u1", login.ID); err != user.ErrSessionNotFound {
+		t.Errorf("login session: expected ErrSessionNotFound, got %v", err)
+	}
+	if err := s.RevokeScopedToken(ctx, "u1", other.ID); err != user.ErrSessionNotFound {
+		t.Errorf("other user: expected ErrSessionNotFound, got %v", err)
+	}
+	if err := s.RevokeScopedToken(ctx, "u1", token.ID); err != nil {
+		t.Fatalf("revoke: unexpected error %v", err)
+	}
+	if err := user.NewSessions(r).Seen(token.ID, time.Now()); err != auth.ErrRevokedToken {
+		t.Errorf("revoked: expected ErrRevokedToken, got %v", err)
+	}
+	if tokens, _ := s.ScopedTokens(ctx, "u1"); len(tokens) != 0 {
+		t.Errorf("revoked: expected no token, got %+v", tokens)
+	}
+	if sessions, _ := s.Sessions(ctx, "u1"); len(sessions) != 1 || sessions[0].ID != login.ID {
+		t.Errorf("sessions: expected the login session kept, got %+v", sessions)
+	}
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/auth/oauth"
+)
+
+// SocialAccount links the account of a user at an OAuth provider to the
+// user, the next social logins find the user by it.
+type SocialAccount struct {
+	Provider string `json:"provider" sql:"primary_key"`
+	Subject  string `json:"subject" sql:"primary_key"`
+	UserID   string `json:"user_id" sql:"index"`
+	// Email is the email told by the provider when linked.
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SocialAccount) TableName() string {
+	return "user_social_accounts"
+}
+
+// socialUser returns a new customer of the identity. Its password is random,
+// the user can set one with a password reset.
+func socialUser(id oauth.Identity) (User, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return User{}, err
+	}
+	u := New()
+	u.FirstName = id.FirstName
+	u.LastName = id.LastName
+	u.Email = id.Email
+	now := time.Now().UTC()
+	u.EmailVerifiedAt = &now
+	u.Password = calculatePassHash(base64.RawURLEncoding.EncodeToString(b), u.Salt)
+	u.Username = strings.Split(id.Email, "@")[0]
+	u.Role = RoleCustomer
+	return u, nil
+}
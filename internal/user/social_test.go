@@ -0,0 +1,88 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/user"
+)
+
+func TestSocialLogin(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t,
+		user.User{ID: "u1", Email: "jo@example.com"},
+		user.User{ID: "u2", Email: "gone@example.com", Deactivated: true},
+	)
+	s := user.NewService(repo, nil, user.Config{})
+
+	id := oauth.Identity{Provider: oauth.GitHub, Subject: "1", Email: "jo@example.com"}
+	if _, err := s.SocialLogin(ctx, id); err != user.ErrUnverifiedEmail {
+		t.Errorf("unverified: expected ErrUnverifiedEmail, got %v", err)
+	}
+
+	id.EmailVerified = true
+	u, err := s.SocialLogin(ctx, id)
+	if err != nil || u.ID != "u1" {
+		t.Fatalf("link: expected u1, got %+v, %v", u, err)
+	}
+	if repo.user(t, "u1").EmailVerifiedAt == nil {
+		t.Errorf("link: expected the email of u1 verified")
+	}
+	// linked, the email of the provider doesn't matter anymore.
+	id.Email, id.EmailVerified = "other@example.com", false
+	if u, err := s.SocialLogin(ctx, id); err != nil || u.ID != "u1" {
+		t.Errorf("linked: expected u1, got %+v, %v", u, err)
+	}
+
+	id = oauth.Identity{Provider: oauth.Google, Subject: "2", Email: "new@example.com", EmailVerified: true, FirstName: "New"}
+	u, err = s.SocialLogin(ctx, id)
+	if err != nil || u.Email != "new@example.com" || u.Username != "new" || u.Role != user.RoleCustomer || u.EmailVerifiedAt == nil {
+		t.Fatalf("create: unexpected user %+v, %v", u, err)
+	}
+	if a, _ := repo.GetSocialAccount(oauth.Google, "2"); a.UserID != u.ID {
+		t.Errorf("create: expected account of %s, got %+v", u.ID, a)
+	}
+
+	id = oauth.Identity{Provider: oauth.Google, Subject: "3", Email: "gone@example.com", EmailVerified: true}
+	if _, err := s.SocialLogin(ctx, id); err != user.ErrDeactivated {
+		t.Errorf("deactivated: expected ErrDeactivated, got %v", err)
+	}
+}
+
+// linkedRepo returns a repo of u, linked to the GitHub account 1.
+func linkedRepo(t *testing.T, u user.User) *memRepo {
+	r := newRepo(t, u)
+	if err := r.LinkSocialAccount(&u, &user.SocialAccount{Provider: oauth.GitHub, Subject: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSocialLoginBanned(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := user.NewService(linkedRepo(t, user.User{ID: "u1", Email: "jo@example.com", Deactivated: true, BannedAt: &now}), nil, user.Config{})
+
+	if _, err := s.SocialLogin(ctx, oauth.Identity{Provider: oauth.GitHub, Subject: "1"}); err != user.ErrBanned {
+		t.Errorf("linked: expected ErrBanned, got %v", err)
+	}
+	id := oauth.Identity{Provider: oauth.Google, Subject: "2", Email: "jo@example.com", EmailVerified: true}
+	if _, err := s.SocialLogin(ctx, id); err != user.ErrBanned {
+		t.Errorf("by email: expected ErrBanned, got %v", err)
+	}
+}
+
+func TestSocialLoginPasswordResetRequired(t *testing.T) {
+	ctx := context.Background()
+	s := user.NewService(linkedRepo(t, user.User{ID: "u1", Email: "jo@example.com", PasswordResetRequired: true}), nil, user.Config{})
+
+	if _, err := s.SocialLogin(ctx, oauth.Identity{Provider: oauth.GitHub, Subject: "1"}); err != user.ErrPasswordResetRequired {
+		t.Errorf("linked: expected ErrPasswordResetRequired, got %v", err)
+	}
+	id := oauth.Identity{Provider: oauth.Google, Subject: "2", Email: "jo@example.com", EmailVerified: true}
+	if _, err := s.SocialLogin(ctx, id); err != user.ErrPasswordResetRequired {
+		t.Errorf("by email: expected ErrPasswordResetRequired, got %v", err)
+	}
+}
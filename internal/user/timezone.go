@@ -0,0 +1,32 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/transport"
+)
+
+// TimezoneHandler sets the time zone preference of the user of the bearer
+// token of GET requests, for the reports to display their times in. The
+// requests with a tz parameter, or without a user token, are handed to next
+// as is.
+func TimezoneHandler(s Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		if req.Method != "GET" || !strings.HasPrefix(auth, "Bearer ") || req.URL.Query().Get(transport.TimezoneParam) != "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		u, err := s.AuthToken(req.Context(), strings.TrimPrefix(auth, "Bearer "))
+		if err != nil || u.Timezone == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if loc, err := time.LoadLocation(u.Timezone); err == nil {
+			req = req.WithContext(transport.WithTimezone(req.Context(), loc))
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -2,35 +2,129 @@ package user
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"context"
 
 	"github.com/go-kit/kit/log"
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/auth/oauth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/passwordpolicy"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/ratelimit"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
 	"github.com/pkg/errors"
 )
 
 var (
 	ErrNoNextPage = errors.New("no next page")
 	ErrNoPrevPage = errors.New("no prev page")
+	ErrBadRouting = errors.New("bad routing")
+	ErrEmptyQuery = errors.New("empty query")
+	ErrBadLimit   = errors.New("limit must be between 1 and 100")
 )
 
 const (
 	defaultPageLimit = 20
+	maxPageLimit     = 100
 )
 
-func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Handler {
-	e := MakeEndpoints(s)
+func init() {
+	i18n.Register(map[error]string{
+		ErrUnauthorized:       "user.unauthorized",
+		ErrInvalidPassword:    "user.invalid_password",
+		ErrInvalidResetKey:    "user.invalid_reset_key",
+		ErrUserNotFound:       "user.not_found",
+		ErrRegistrationDenied: "user.registration_denied",
+		ErrDeactivated:        "user.deactivated",
+		ErrPasswordMismatch:   "user.password_mismatch",
+
+		ErrInvalidRefreshToken: "user.invalid_refresh_token",
+		ErrUnverifiedEmail:     "user.unverified_email",
+
+		ErrInvalidCode:          "user.invalid_two_factor_code",
+		ErrInvalidChallenge:     "user.invalid_login_challenge",
+		ErrTwoFactorEnabled:     "user.two_factor_enabled",
+		ErrTwoFactorDisabled:    "user.two_factor_disabled",
+		ErrTwoFactorUnavailable: "user.two_factor_unavailable",
+
+		ErrAccountLocked: "user.account_locked",
+
+		ErrBanned:                "user.banned",
+		ErrNotBanned:             "user.not_banned",
+		ErrBanReasonRequired:     "user.ban_reason_required",
+		ErrPasswordResetRequired: "user.password_reset_required",
+		ErrOwnAccount:            "user.own_account",
+		ErrLoginsUnavailable:     "user.logins_unavailable",
+
+		ErrInvalidImage:       "user.invalid_image",
+		ErrAvatarTooLarge:     "user.avatar_too_large",
+		ErrAvatarConflict:     "user.avatar_conflict",
+		ErrAvatarsUnavailable: "user.avatars_unavailable",
+
+		ErrAddressNotFound:  "user.address_not_found",
+		ErrNoDefaultAddress: "user.no_default_address",
+		ErrTooManyAddresses: "user.too_many_addresses",
+
+		ErrEmptyQuery:  "user.empty_query",
+		ErrBadLimit:    "user.bad_limit",
+		ErrBadCursor:   "user.bad_cursor",
+		ErrCursorOrder: "user.cursor_order",
+
+		ErrSessionNotFound: "user.session_not_found",
+		ErrDeviceNotFound:  "user.device_not_found",
+
+		oauth.ErrUnknownProvider: "oauth.unknown_provider",
+		oauth.ErrInvalidState:    "oauth.invalid_state",
+		oauth.ErrProvider:        "oauth.provider_failed",
+
+		rbac.ErrForbidden:         "rbac.forbidden",
+		rbac.ErrInsufficientScope: "rbac.insufficient_scope",
+		rbac.ErrImpersonated:      "rbac.impersonated",
+		ErrImpersonateStaff:       "user.impersonate_staff",
+
+		ratelimit.ErrLimited: "ratelimit.limited",
+		csrf.ErrInvalidToken: "csrf.invalid_token",
+
+		passwordpolicy.ErrUnavailable: "passwordpolicy.unavailable",
+	})
+}
+
+// MakeHTTPHandler mounts the user endpoints. The new passwords of the
+// register, reset-password and change-password requests must pass policy,
+// the requests are limited by limits, see MakeEndpoints.
+func MakeHTTPHandler(ctx context.Context, s Service, tokens auth.Service, social *oauth.Login, policy passwordpolicy.Policy, audited audit.AuditLogger, limits Limits, forgery CSRF, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, tokens, social, audited, limits, forgery)
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(httptransport.PopulateRequestContext),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
 	}
 	registerHandler := httptransport.NewServer(
 		e.RegisterEndpoint,
-		decodeRegisterRequest,
+		decodeRegisterRequest(policy),
 		encodeResponse,
 		options...,
 	)
@@ -40,15 +134,21 @@ func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Han
 		encodeResponse,
 		options...,
 	)
+	forgotPasswordHandler := httptransport.NewServer(
+		e.ForgotPasswordEndpoint,
+		decodeForgotPasswordRequest,
+		encodeResponse,
+		options...,
+	)
 	resetPasswordHandler := httptransport.NewServer(
 		e.ResetPasswordEndpoint,
-		decodeResetPasswordRequest,
+		decodeResetPasswordRequest(policy),
 		encodeResponse,
 		options...,
 	)
 	changePasswordHandler := httptransport.NewServer(
 		e.ChangePasswordEndpoint,
-		decodeChangePasswordRequest,
+		decodeChangePasswordRequest(policy),
 		encodeResponse,
 		options...,
 	)
@@ -58,41 +158,622 @@ func MakeHTTPHandler(ctx context.Context, s Service, logger log.Logger) http.Han
 		encodeResponse,
 		options...,
 	)
+	searchHandler := httptransport.NewServer(
+		e.SearchEndpoint,
+		decodeSearchRequest,
+		encodeResponse,
+		options...,
+	)
+
+	getHandler := httptransport.NewServer(
+		e.GetEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+
+	patchHandler := httptransport.NewServer(
+		e.PatchEndpoint,
+		decodePatchRequest,
+		encodePatchResponse,
+		options...,
+	)
+
+	deleteHandler := httptransport.NewServer(
+		e.DeleteEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+
+	uploadAvatarHandler := httptransport.NewServer(
+		e.UploadAvatarEndpoint,
+		decodeUploadAvatarRequest,
+		encodeResponse,
+		options...,
+	)
+
+	preferencesHandler := httptransport.NewServer(
+		e.PreferencesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	setPreferencesHandler := httptransport.NewServer(
+		e.SetPreferencesEndpoint,
+		decodePreferencesRequest,
+		encodeResponse,
+		options...,
+	)
+	addressesHandler := httptransport.NewServer(
+		e.AddressesEndpoint,
+		decodeAddressRequest,
+		encodeResponse,
+		options...,
+	)
+	addressHandler := httptransport.NewServer(
+		e.AddressEndpoint,
+		decodeAddressRequest,
+		encodeResponse,
+		options...,
+	)
+	createAddressHandler := httptransport.NewServer(
+		e.CreateAddressEndpoint,
+		decodeAddressRequest,
+		encodeResponse,
+		options...,
+	)
+	updateAddressHandler := httptransport.NewServer(
+		e.UpdateAddressEndpoint,
+		decodeAddressRequest,
+		encodeResponse,
+		options...,
+	)
+	deleteAddressHandler := httptransport.NewServer(
+		e.DeleteAddressEndpoint,
+		decodeAddressRequest,
+		encodeResponse,
+		options...,
+	)
+
+	refreshHandler := httptransport.NewServer(
+		e.RefreshEndpoint,
+		decodeRefreshRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeHandler := httptransport.NewServer(
+		e.RevokeEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	logoutHandler := httptransport.NewServer(
+		e.LogoutEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	oauthLoginHandler := httptransport.NewServer(
+		e.OAuthLoginEndpoint,
+		decodeOAuthLoginRequest,
+		encodeOAuthLoginResponse,
+		options...,
+	)
+	oauthCallbackHandler := httptransport.NewServer(
+		e.OAuthCallbackEndpoint,
+		decodeOAuthCallbackRequest,
+		encodeOAuthCallbackResponse,
+		options...,
+	)
+	login2FAHandler := httptransport.NewServer(
+		e.Login2FAEndpoint,
+		decodeLogin2FARequest,
+		encodeResponse,
+		options...,
+	)
+	enable2FAHandler := httptransport.NewServer(
+		e.Enable2FAEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	verify2FAHandler := httptransport.NewServer(
+		e.Verify2FAEndpoint,
+		decodeTwoFactorRequest,
+		encodeResponse,
+		options...,
+	)
+	disable2FAHandler := httptransport.NewServer(
+		e.Disable2FAEndpoint,
+		decodeTwoFactorRequest,
+		encodeResponse,
+		options...,
+	)
+	sessionsHandler := httptransport.NewServer(
+		e.SessionsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeSessionHandler := httptransport.NewServer(
+		e.RevokeSessionEndpoint,
+		decodeSessionRequest,
+		encodeResponse,
+		options...,
+	)
+	devicesHandler := httptransport.NewServer(
+		e.DevicesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeDeviceHandler := httptransport.NewServer(
+		e.RevokeDeviceEndpoint,
+		decodeDeviceRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeDevicesHandler := httptransport.NewServer(
+		e.RevokeDevicesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeSessionsHandler := httptransport.NewServer(
+		e.RevokeSessionsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeUserHandler := httptransport.NewServer(
+		e.RevokeUserEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	unlockHandler := httptransport.NewServer(
+		e.UnlockEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	impersonateHandler := httptransport.NewServer(
+		e.ImpersonateEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	banHandler := httptransport.NewServer(
+		e.BanEndpoint,
+		decodeBanRequest,
+		encodeResponse,
+		options...,
+	)
+	unbanHandler := httptransport.NewServer(
+		e.UnbanEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	forcePasswordResetHandler := httptransport.NewServer(
+		e.ForcePasswordResetEndpoint,
+		decodeRevokeUserRequest,
+		encodeResponse,
+		options...,
+	)
+	setRoleHandler := httptransport.NewServer(
+		e.SetRoleEndpoint,
+		decodeRoleRequest,
+		encodeResponse,
+		options...,
+	)
+	loginHistoryHandler := httptransport.NewServer(
+		e.LoginHistoryEndpoint,
+		decodeLoginHistoryRequest,
+		encodeResponse,
+		options...,
+	)
+
+	startJobHandler := httptransport.NewServer(
+		e.StartJobEndpoint,
+		decodeStartJobRequest,
+		encodeResponse,
+		options...,
+	)
+	jobsHandler := httptransport.NewServer(
+		e.JobsEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	jobHandler := httptransport.NewServer(
+		e.JobEndpoint,
+		decodeJobRequest,
+		encodeResponse,
+		options...,
+	)
+	jobExportHandler := httptransport.NewServer(
+		e.JobExportEndpoint,
+		decodeJobRequest,
+		encodeExport,
+		options...,
+	)
+	csrfHandler := httptransport.NewServer(
+		e.CSRFEndpoint,
+		decodeEmptyRequest,
+		encodeCSRFResponse,
+		options...,
+	)
+	scopedTokenHandler := httptransport.NewServer(
+		e.ScopedTokenEndpoint,
+		decodeScopedTokenRequest,
+		encodeResponse,
+		options...,
+	)
+	scopedTokensHandler := httptransport.NewServer(
+		e.ScopedTokensEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeTokenHandler := httptransport.NewServer(
+		e.RevokeTokenEndpoint,
+		decodeTokenRequest,
+		encodeResponse,
+		options...,
+	)
+	scopesHandler := httptransport.NewServer(
+		e.ScopesEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	)
 
 	r := mux.NewRouter()
 
 	r.Handle("/users/v1/register", registerHandler).Methods("POST")
 	r.Handle("/users/v1/login", loginHandler).Methods("POST")
+	r.Handle("/users/v1/login/2fa", login2FAHandler).Methods("POST")
+	r.Handle("/users/v1/2fa/enable", enable2FAHandler).Methods("POST")
+	r.Handle("/users/v1/2fa/verify", verify2FAHandler).Methods("POST")
+	r.Handle("/users/v1/2fa/disable", disable2FAHandler).Methods("POST")
+	r.Handle("/users/v1/forgot-password", forgotPasswordHandler).Methods("POST")
 	r.Handle("/users/v1/reset-password", resetPasswordHandler).Methods("POST")
 	r.Handle("/users/v1/change-password", changePasswordHandler).Methods("POST")
+	r.Handle("/users/v1/token/refresh", refreshHandler).Methods("POST")
+	r.Handle("/users/v1/token/revoke", revokeHandler).Methods("POST")
+	r.Handle("/users/v1/logout", logoutHandler).Methods("POST")
+	r.Handle("/users/v1/tokens", scopedTokenHandler).Methods("POST")
+	r.Handle("/users/v1/tokens", scopedTokensHandler).Methods("GET")
+	r.Handle("/users/v1/tokens/{token-id}", revokeTokenHandler).Methods("DELETE")
+	r.Handle("/users/v1/scopes", scopesHandler).Methods("GET")
+	r.Handle("/users/v1/csrf", csrfHandler).Methods("GET")
+	r.Handle("/users/v1/sessions", sessionsHandler).Methods("GET")
+	r.Handle("/users/v1/sessions", revokeSessionsHandler).Methods("DELETE")
+	r.Handle("/users/v1/sessions/{session-id}", revokeSessionHandler).Methods("DELETE")
+	r.Handle("/users/v1/devices", devicesHandler).Methods("GET")
+	r.Handle("/users/v1/devices", revokeDevicesHandler).Methods("DELETE")
+	r.Handle("/users/v1/devices/{device-id}", revokeDeviceHandler).Methods("DELETE")
+	r.Handle("/users/v1/oauth/{provider}/login", oauthLoginHandler).Methods("GET")
+	r.Handle("/users/v1/oauth/{provider}/callback", oauthCallbackHandler).Methods("GET")
+	r.Handle("/users/v1/me/preferences", preferencesHandler).Methods("GET")
+	r.Handle("/users/v1/me/preferences", setPreferencesHandler).Methods("PUT")
 	r.Handle("/users/v1/list", listHandler).Methods("GET")
+	r.Handle("/users/v1/search", searchHandler).Methods("GET")
+	r.Handle("/users/v1/{user-id}", getHandler).Methods("GET")
+	r.Handle("/users/v1/{user-id}", patchHandler).Methods("PATCH")
+	r.Handle("/users/v1/{user-id}", deleteHandler).Methods("DELETE")
+	r.Handle("/users/v1/{user-id}/avatar", uploadAvatarHandler).Methods("POST")
+	r.Handle("/users/v1/{user-id}/addresses", addressesHandler).Methods("GET")
+	r.Handle("/users/v1/{user-id}/addresses", createAddressHandler).Methods("POST")
+	r.Handle("/users/v1/{user-id}/addresses/{address-id}", addressHandler).Methods("GET")
+	r.Handle("/users/v1/{user-id}/addresses/{address-id}", updateAddressHandler).Methods("PUT")
+	r.Handle("/users/v1/{user-id}/addresses/{address-id}", deleteAddressHandler).Methods("DELETE")
+	r.Handle("/users/v1/{user-id}/impersonate", impersonateHandler).Methods("POST")
+
+	r.Handle("/users/v1/admin/jobs", startJobHandler).Methods("POST")
+	r.Handle("/users/v1/admin/jobs", jobsHandler).Methods("GET")
+	r.Handle("/users/v1/admin/jobs/{job-id}", jobHandler).Methods("GET")
+	r.Handle("/users/v1/admin/jobs/{job-id}/export", jobExportHandler).Methods("GET")
+	r.Handle("/users/v1/admin/users/{user-id}/tokens", revokeUserHandler).Methods("DELETE")
+	r.Handle("/users/v1/admin/users/{user-id}/unlock", unlockHandler).Methods("POST")
+	r.Handle("/users/v1/admin/users/{user-id}/ban", banHandler).Methods("POST")
+	r.Handle("/users/v1/admin/users/{user-id}/ban", unbanHandler).Methods("DELETE")
+	r.Handle("/users/v1/admin/users/{user-id}/password-reset", forcePasswordResetHandler).Methods("POST")
+	r.Handle("/users/v1/admin/users/{user-id}/role", setRoleHandler).Methods("PUT")
+	r.Handle("/users/v1/admin/users/{user-id}/logins", loginHistoryHandler).Methods("GET")
+
+	allow.Methods(r)
 
 	return r
 }
-func decodeRegisterRequest(ctx context.Context, req *http.Request) (interface{}, error) {
-	var r registerRequest
-	err := json.NewDecoder(req.Body).Decode(&r)
-	return r, err
+func decodeRegisterRequest(policy passwordpolicy.Policy) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		var r registerRequest
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+		return r, policy.Check(ctx, "password", r.Password)
+	}
 }
 
 func decodeLoginRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	var r loginRequest
-	err := json.NewDecoder(req.Body).Decode(&r)
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeLogin2FARequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r login2FARequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeTwoFactorRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r twoFactorRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeForgotPasswordRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r forgotPasswordRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	var v validate.Validator
+	v.Required("email", r.Email)
+	return r, v.Err()
+}
+
+func decodeResetPasswordRequest(policy passwordpolicy.Policy) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		var r resetPasswordRequest
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+		return r, policy.Check(ctx, "new_password", r.NewPassword)
+	}
+}
+
+func decodeChangePasswordRequest(policy passwordpolicy.Policy) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, req *http.Request) (interface{}, error) {
+		var r changePasswordRequest
+		if err := schema.Decode(req.Body, &r); err != nil {
+			return nil, err
+		}
+		return r, policy.Check(ctx, "new_password", r.NewPassword)
+	}
+}
+
+func decodeScopedTokenRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r scopedTokenRequest
+	err := schema.Decode(req.Body, &r)
 	return r, err
 }
 
-func decodeResetPasswordRequest(ctx context.Context, req *http.Request) (interface{}, error) {
-	var r resetPasswordRequest
-	err := json.NewDecoder(req.Body).Decode(&r)
+func decodeRefreshRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r refreshRequest
+	err := schema.Decode(req.Body, &r)
 	return r, err
 }
 
-func decodeChangePasswordRequest(ctx context.Context, req *http.Request) (interface{}, error) {
-	var r resetPasswordRequest
-	err := json.NewDecoder(req.Body).Decode(&r)
+func decodeRevokeUserRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	return revokeUserRequest{UserID: userID}, nil
+}
+
+func decodeBanRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	var r banRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	r.UserID = userID
+	return r, nil
+}
+
+func decodeRoleRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	var r roleRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	r.UserID = userID
+	return r, nil
+}
+
+// decodeLoginHistoryRequest pages the logins by ?limit and ?offset like
+// the search.
+func decodeLoginHistoryRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	lreq := loginHistoryRequest{UserID: userID, Limit: defaultPageLimit, URL: req.URL}
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return nil, ErrBadLimit
+		}
+		lreq.Limit = n
+	}
+	// Ignoring errors since zero value makes sense for offset
+	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+	if lreq.Offset < 0 {
+		lreq.Offset = 0
+	}
+	return lreq, nil
+}
+
+// decodeUploadAvatarRequest reads the image of the multipart field "file",
+// up to MaxAvatarSize along with the rest of the form.
+func decodeUploadAvatarRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	req.Body = http.MaxBytesReader(nil, req.Body, MaxAvatarSize+64<<10)
+	f, _, err := req.FormFile("file")
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return nil, ErrInvalidImage
+	}
+	if err != nil {
+		return nil, ErrAvatarTooLarge
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, ErrAvatarTooLarge
+	}
+	return uploadAvatarRequest{UserID: userID, Data: data}, nil
+}
+
+// decodePreferencesRequest rejects the unknown keys whatever the strict
+// mode of the schema, a misspelt preference being lost otherwise.
+func decodePreferencesRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Check(b, reflect.TypeOf(Preferences{}), true); err != nil {
+		return nil, err
+	}
+	var p Preferences
+	err = json.Unmarshal(b, &p)
+	return p, err
+}
+
+// decodeAddressRequest reads the address of the POST and PUT requests, the
+// address id being in the path but for the list and the creation.
+func decodeAddressRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	userID, ok := vars["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	r := addressRequest{UserID: userID, AddressID: vars["address-id"]}
+	if req.Method == "POST" || req.Method == "PUT" {
+		if err := schema.Decode(req.Body, &r.Address); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func decodeSessionRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	sessionID, ok := mux.Vars(req)["session-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "session-id")
+	}
+	return sessionRequest{SessionID: sessionID}, nil
+}
+
+// decodeTokenRequest takes the ID of a scoped token, the ID of its session.
+func decodeTokenRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	tokenID, ok := mux.Vars(req)["token-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "token-id")
+	}
+	return sessionRequest{SessionID: tokenID}, nil
+}
+
+func decodeDeviceRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	deviceID, ok := mux.Vars(req)["device-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "device-id")
+	}
+	return deviceRequest{DeviceID: deviceID}, nil
+}
+
+func decodeOAuthLoginRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	provider, ok := mux.Vars(req)["provider"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "provider")
+	}
+	return oauthLoginRequest{Provider: provider}, nil
+}
+
+// decodeOAuthCallbackRequest reads the code and state the provider
+// redirected with, and the state of the cookie set on login.
+func decodeOAuthCallbackRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	provider, ok := mux.Vars(req)["provider"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "provider")
+	}
+	r := oauthCallbackRequest{
+		Provider: provider,
+		Code:     req.FormValue("code"),
+		State:    req.FormValue("state"),
+	}
+	if c, err := req.Cookie(oauthStateCookie); err == nil {
+		r.Cookie = c.Value
+	}
+	return r, nil
+}
+
+func decodeEmptyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeStartJobRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r startJobRequest
+	err := schema.Decode(req.Body, &r)
 	return r, err
 }
 
+// decodeJobRequest takes the results to return from ?status, e.g.
+// ?status=failed&status=skipped.
+func decodeJobRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	jobID, ok := mux.Vars(req)["job-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "job-id")
+	}
+	req.ParseForm()
+	return jobRequest{
+		JobID:  jobID,
+		Status: req.Form["status"],
+	}, nil
+}
+
+func decodePatchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	userID, ok := mux.Vars(req)["user-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "user-id")
+	}
+	p, err := patch.Read(req)
+	if err != nil {
+		return nil, err
+	}
+	match, err := etag.IfMatch(req)
+	if err != nil {
+		return nil, err
+	}
+	return patchRequest{
+		UserID: userID,
+		Match:  match,
+		Patch:  p,
+	}, nil
+}
+
+// encodePatchResponse sets the ETag of the patched user, for the If-Match
+// of the next update.
+func encodePatchResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if res, ok := d.(patchResponse); ok && res.User != nil {
+		w.Header().Set("ETag", etag.Tag(res.User.Version))
+	}
+	return encodeResponse(ctx, w, d)
+}
+
 func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
 	lreq := listRequest{}
 	lreq.Order = req.FormValue("order")
@@ -104,6 +785,36 @@ func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, err
 	}
 	lreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
 
+	// count=false or count=estimate saves the COUNT(*) of every page.
+	count, err := db.ParseCount(req.FormValue("count"))
+	if err != nil {
+		return nil, err
+	}
+	lreq.Count = count
+
+	// filter=role eq "admin" and deactivated eq false
+	if lreq.Filter, err = filter.Parse(req.FormValue("filter"), ListFields); err != nil {
+		return nil, err
+	}
+	// email=ann@example.com&name_contains=ann&created_after=2017-01-01
+	if lreq.By, err = ParseFilter(req.URL.Query()); err != nil {
+		return nil, err
+	}
+
+	// cursor= pages by keyset instead of offset, empty for the first page.
+	if q := req.URL.Query(); q["cursor"] != nil {
+		if lreq.Order != "" {
+			return nil, ErrCursorOrder
+		}
+		c := Cursor{}
+		if token := q.Get("cursor"); token != "" {
+			if c, err = DecodeCursor(token); err != nil {
+				return nil, err
+			}
+		}
+		lreq.Cursor = &c
+	}
+
 	// url := req.URL
 	// url.Scheme = "http" // TODO(kaviraj): fix it by removing this hardcode values
 	// if url.Host == "" {
@@ -115,6 +826,84 @@ func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, err
 	return lreq, nil
 }
 
+func decodeSearchRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	q := strings.TrimSpace(req.FormValue("q"))
+	if q == "" {
+		return nil, ErrEmptyQuery
+	}
+	sreq := searchRequest{Q: q, Limit: defaultPageLimit, URL: req.URL}
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return nil, ErrBadLimit
+		}
+		sreq.Limit = n
+	}
+	// Ignoring errors since zero value makes sense for offset
+	sreq.Offset, _ = strconv.Atoi(req.FormValue("offset"))
+	if sreq.Offset < 0 {
+		sreq.Offset = 0
+	}
+	return sreq, nil
+}
+
+// encodeExport sends the CSV export of a job as an attachment.
+func encodeExport(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(exportResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(res.Data)))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+res.Name+`"`)
+	_, err := w.Write(res.Data)
+	return err
+}
+
+// oauthStateCookie holds the state of a social login in the browser, for
+// the callback.
+const oauthStateCookie = "oauth_state"
+
+// encodeOAuthLoginResponse redirects the browser to the provider.
+func encodeOAuthLoginResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	res := d.(oauthLoginResponse)
+	if res.Error != nil {
+		encodeError(ctx, res.Error, w)
+		return nil
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    res.State,
+		Path:     "/users/v1/oauth/",
+		MaxAge:   int(oauth.StateTTL / time.Second),
+		HttpOnly: true,
+	})
+	w.Header().Set("Location", res.URL)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+// encodeOAuthCallbackResponse drops the state cookie, it's used once.
+func encodeOAuthCallbackResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/users/v1/oauth/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	return encodeResponse(ctx, w, d)
+}
+
+// encodeCSRFResponse sets the CSRF token in its cookie, for the browser to
+// send it back.
+func encodeCSRFResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if res := d.(csrfResponse); res.Error == nil {
+		http.SetCookie(w, csrf.Cookie(res.Token))
+	}
+	return encodeResponse(ctx, w, d)
+}
+
 type errorer interface {
 	error() error
 }
@@ -127,6 +916,11 @@ type pager interface {
 	page() (total int, previous, next string)
 }
 
+// cursorer is implemented by the responses paged by cursor.
+type cursorer interface {
+	cursors() (previous, next string)
+}
+
 // formatResponse is the uniform response format used throughout the users service,
 // for every endpoint response.
 type formatResponse struct {
@@ -136,11 +930,20 @@ type formatResponse struct {
 
 // metaResponse is part of response json that tells about basic meta information.
 type metaResponse struct {
-	Status   int    `json:"status"`
-	Error    string `json:"error,omitempty"`
-	Previous string `json:"previous,omitempty"`
-	Next     string `json:"next,omitempty"`
-	Total    int    `json:"total,omitempty"`
+	Status int `json:"status"`
+	// Code is the stable code of the error, Error its message in the
+	// language of the request.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Errors lists every failing field of an invalid request.
+	Errors   validate.Errors `json:"errors,omitempty"`
+	Previous string          `json:"previous,omitempty"`
+	Next     string          `json:"next,omitempty"`
+	Total    int             `json:"total,omitempty"`
+	// PreviousCursor and NextCursor are the opaque cursors of the pages
+	// around, of the lists paged by cursor.
+	PreviousCursor string `json:"previous_cursor,omitempty"`
+	NextCursor     string `json:"next_cursor,omitempty"`
 }
 
 func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
@@ -168,31 +971,63 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) e
 		f.Meta.Previous = p
 		f.Meta.Next = n
 	}
+	if c, ok := d.(cursorer); ok {
+		f.Meta.PreviousCursor, f.Meta.NextCursor = c.cursors()
+	}
 
-	return json.NewEncoder(w).Encode(f)
+	return transport.Encode(ctx, w, transport.FormatResponse{Data: f.Data, Meta: transport.MetaResponse(f.Meta)})
 }
 
-func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if after, ok := ratelimit.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", after)
+	}
 	// Its important to pass errors.Cause() as we decide status code based on
 	// root error which is domain specific
 	code := codeFrom(errors.Cause(err))
 	w.WriteHeader(code)
-	f := formatResponse{Meta: metaResponse{Status: code, Error: err.Error()}}
+	f := formatResponse{Meta: metaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
 	json.NewEncoder(w).Encode(f)
 }
 
 func codeFrom(err error) int {
 	switch err {
-	case ErrUserNotFound:
+	case ErrUserNotFound, ErrJobNotFound, ErrSessionNotFound, ErrDeviceNotFound, ErrAddressNotFound, ErrNoDefaultAddress, oauth.ErrUnknownProvider:
 		return http.StatusNotFound
-	case ErrUnauthorized:
+	case ErrJobNotDone, ErrTwoFactorEnabled, ErrTwoFactorDisabled, ErrAvatarConflict, ErrTooManyAddresses, ErrNotBanned:
+		return http.StatusConflict
+	case ErrAvatarTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case patch.ErrContentType, ErrInvalidImage:
+		return http.StatusUnsupportedMediaType
+	case etag.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case etag.ErrPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case ErrAccountLocked:
+		return http.StatusLocked
+	case ErrDeactivated:
+		return http.StatusGone
+	case ratelimit.ErrLimited:
+		return http.StatusTooManyRequests
+	case ErrUnauthorized, ErrInvalidRefreshToken, ErrInvalidCode, ErrInvalidChallenge, auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
 		return http.StatusUnauthorized
-	case ErrInvalidPassword, ErrInvalidResetKey, ErrMissingField, ErrPasswordMismatch:
+	case ErrRegistrationDenied, ErrUnverifiedEmail, rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, ErrImpersonateStaff, csrf.ErrInvalidToken,
+		ErrBanned, ErrPasswordResetRequired, ErrOwnAccount:
+		return http.StatusForbidden
+	case ErrInvalidPassword, ErrInvalidResetKey, validate.ErrInvalid, ErrPasswordMismatch,
+		db.ErrBadCount, filter.ErrInvalid, patch.ErrNotObject, ErrBadRouting, ErrInvalidOp, ErrInvalidRole,
+		ErrEmptyFilter, ErrMissingActor, oauth.ErrInvalidState, ErrEmptyQuery, ErrBadLimit,
+		ErrBadCursor, ErrCursorOrder, ErrBanReasonRequired:
 		return http.StatusBadRequest
+	case oauth.ErrProvider:
+		return http.StatusBadGateway
+	case ErrTwoFactorUnavailable, ErrAvatarsUnavailable, ErrLoginsUnavailable, passwordpolicy.ErrUnavailable:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238), the defaults of the authenticator apps.
+const (
+	totpStep   = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is the steps a code is accepted before or after the current
+	// one, for the clock drift of the phones.
+	totpSkew = 1
+)
+
+// ChallengeTTL is how long the user has to enter the code of a login.
+const ChallengeTTL = 5 * time.Minute
+
+// TwoFactorSetup is the TOTP secret of an user enabling 2FA, to add to an
+// authenticator app by hand or by scanning the URI as a QR code.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret returns a random 160 bits secret, the size RFC 4226
+// recommends.
+func newTOTPSecret() ([]byte, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	return secret, err
+}
+
+// totpCode returns the code of secret at step.
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	n := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, n%1000000)
+}
+
+// checkTOTP returns the step of code around now, only accepting the steps
+// after last so that a code can't be replayed. It returns false if code
+// doesn't match.
+func checkTOTP(secret []byte, code string, now time.Time, last int64) (int64, bool) {
+	code = strings.Replace(code, " ", "", -1)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	cur := now.Unix() / int64(totpStep/time.Second)
+	for step := cur - totpSkew; step <= cur+totpSkew; step++ {
+		if step > last && hmac.Equal([]byte(totpCode(secret, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI returns the otpauth URI of the secret of account.
+func totpURI(issuer, account string, secret []byte) string {
+	label := url.PathEscape(issuer + ":" + account)
+	v := url.Values{"secret": {totpEncoding.EncodeToString(secret)}, "issuer": {issuer}}
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// sealer encrypts the TOTP secrets at rest with AES-GCM, and signs the
+// login challenges.
+type sealer struct {
+	aead cipher.AEAD
+	sign []byte
+}
+
+// newSealer derives the encryption and signing keys from key, nil if key
+// is empty.
+func newSealer(key []byte) *sealer {
+	if len(key) == 0 {
+		return nil
+	}
+	enc := sha256.Sum256(append([]byte("totp-secret:"), key...))
+	block, _ := aes.NewCipher(enc[:])
+	aead, _ := cipher.NewGCM(block)
+	sign := sha256.Sum256(append([]byte("login-challenge:"), key...))
+	return &sealer{aead: aead, sign: sign[:]}
+}
+
+func (s *sealer) seal(secret []byte) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, secret, nil)), nil
+}
+
+func (s *sealer) open(sealed string) ([]byte, error) {
+	b, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(b) < s.aead.NonceSize() {
+		return nil, ErrTwoFactorUnavailable
+	}
+	n := s.aead.NonceSize()
+	secret, err := s.aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return nil, ErrTwoFactorUnavailable
+	}
+	return secret, nil
+}
+
+// challenge returns a login challenge of the user expiring at exp. It's
+// bound to the password of the user, a password change voids it.
+func (s *sealer) challenge(u User, exp time.Time) string {
+	payload := u.ID + "." + strconv.FormatInt(exp.Unix(), 10)
+	return payload + "." + s.signature(payload, u.Password)
+}
+
+// challengeUser returns the ID of the user of challenge c if it hasn't
+// expired at now. Its signature is checked by verifyChallenge once the user
+// is read.
+func challengeUser(c string, now time.Time) (string, bool) {
+	parts := strings.Split(c, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() >= exp {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func (s *sealer) verifyChallenge(c string, u User) bool {
+	parts := strings.Split(c, ".")
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(c), []byte(s.challenge(u, time.Unix(exp, 0))))
+}
+
+func (s *sealer) signature(payload, password string) string {
+	mac := hmac.New(sha256.New, s.sign)
+	mac.Write([]byte(payload + "." + password))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package user_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/user"
+)
+
+// code returns the code of the base32 secret at t, as an authenticator app
+// does.
+func code(t *testing.T, secret string, at time.Time) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t, user.User{ID: "u1", Email: "jo@example.com", Password: "hash"})
+
+	if _, err := user.NewService(r, nil, user.Config{}).Enable2FA(ctx, "u1"); err != user.ErrTwoFactorUnavailable {
+		t.Errorf("no key: expected ErrTwoFactorUnavailable, got %v", err)
+	}
+
+	s := user.NewService(r, nil, user.Config{TwoFactorKey: []byte("key"), Issuer: "Shop"})
+	setup, err := s.Enable2FA(ctx, "u1")
+	if err != nil {
+		t.Fatalf("enable: unexpected error %v", err)
+	}
+	if r.user(t, "u1").TOTPSecret == setup.Secret || r.user(t, "u1").TwoFactorEnabled {
+		t.Errorf("enable: expected a pending encrypted secret, got %+v", r.user(t, "u1"))
+	}
+
+	now := time.Now()
+	if err := s.Verify2FA(ctx, "u1", "12345"); err != user.ErrInvalidCode {
+		t.Errorf("verify: expected ErrInvalidCode, got %v", err)
+	}
+	if err := s.Verify2FA(ctx, "u1", code(t, setup.Secret, now)); err != nil {
+		t.Fatalf("verify: unexpected error %v", err)
+	}
+	if !r.user(t, "u1").TwoFactorEnabled {
+		t.Fatal("verify: expected 2FA enabled")
+	}
+
+	challenge, _, err := s.Challenge2FA(ctx, r.user(t, "u1"))
+	if err != nil {
+		t.Fatalf("challenge: unexpected error %v", err)
+	}
+	if _, err := s.Login2FA(ctx, challenge, code(t, setup.Secret, now)); err != user.ErrInvalidCode {
+		t.Errorf("replay: expected ErrInvalidCode, got %v", err)
+	}
+	if _, err := s.Login2FA(ctx, challenge+"x", code(t, setup.Secret, now.Add(30*time.Second))); err != user.ErrInvalidChallenge {
+		t.Errorf("tampered: expected ErrInvalidChallenge, got %v", err)
+	}
+	if u, err := s.Login2FA(ctx, challenge, code(t, setup.Secret, now.Add(30*time.Second))); err != nil || u.ID != "u1" {
+		t.Errorf("login: expected u1, got %+v, %v", u, err)
+	}
+
+	// a password change voids the challenges.
+	r.set(t, "u1", func(u *user.User) { u.Password = "new hash" })
+	if _, err := s.Login2FA(ctx, challenge, code(t, setup.Secret, now.Add(30*time.Second))); err != user.ErrInvalidChallenge {
+		t.Errorf("password changed: expected ErrInvalidChallenge, got %v", err)
+	}
+
+	if _, err := s.Enable2FA(ctx, "u1"); err != user.ErrTwoFactorEnabled {
+		t.Errorf("enabled: expected ErrTwoFactorEnabled, got %v", err)
+	}
+}
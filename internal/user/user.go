@@ -8,25 +8,172 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/validate"
 	"github.com/pkg/errors"
 )
 
 var (
-	ErrMissingField     = errors.New("missing field")
 	ErrPasswordMismatch = errors.New("passwords didn't match")
 )
 
+// User roles.
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	RoleAdmin    = "admin"
+)
+
+// ValidRole tells whether role is one of the user roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleSupport, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Profile field limits, in characters.
+const (
+	MaxAvatarLength = 500
+	MaxBioLength    = 500
+)
+
 // User represents domain model of user service.
 type User struct {
 	ID        string `json:"id" sql:"primary_key"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
-	Username  string `json:"username"`
-	Password  string `json:"-"`
-	Salt      string `json:"-"`
-	ResetKey  string `json:"-"`
-	AuthToken string `json:"-"`
+	// EmailVerifiedAt is set once the user proves the email is theirs, by
+	// a password reset mailed to it or a login with a provider which
+	// verified it. A change of the email unsets it.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Username        string     `json:"username"`
+	Password        string     `json:"-"`
+	Salt            string     `json:"-"`
+	AuthToken       string     `json:"-"`
+	Role            string     `json:"role" sql:"not null;default:'customer'"`
+	// Deactivated users can't login anymore. DeletedAt is set once the
+	// account is deleted, deactivating it and leaving it out of the lists.
+	Deactivated bool       `json:"deactivated" sql:"not null;default:false"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" sql:"index"`
+	// Timezone is the IANA time zone the reports are displayed in for the
+	// user, e.g. "Europe/Paris". Empty means UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is the language of the documents and emails of the user, e.g.
+	// "de". Empty means the one of their requests.
+	Locale string `json:"locale,omitempty"`
+	// Avatar is the URL of the picture of the user, Bio a few words about
+	// them shown with their reviews.
+	Avatar string `json:"avatar,omitempty"`
+	Bio    string `json:"bio,omitempty"`
+	// TwoFactorEnabled users enter a TOTP code on login. TOTPSecret is
+	// encrypted, TOTPStep is the step of the last code used.
+	TwoFactorEnabled bool   `json:"two_factor_enabled" sql:"not null;default:false"`
+	TOTPSecret       string `json:"-"`
+	TOTPStep         int64  `json:"-"`
+	// LockedUntil is set when the account is locked after failed logins.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	// BannedAt is set when an admin bans the user, deactivating the
+	// account for BanReason until unbanned.
+	BannedAt  *time.Time `json:"banned_at,omitempty"`
+	BannedBy  string     `json:"banned_by,omitempty"`
+	BanReason string     `json:"ban_reason,omitempty"`
+	// BanDeactivated is set when the ban deactivated the account, Unban
+	// reactivating it then only, not the accounts deactivated before.
+	BanDeactivated bool `json:"-" sql:"not null;default:false"`
+	// PasswordResetRequired users can't login until they reset their
+	// password, an admin forced them to.
+	PasswordResetRequired bool `json:"password_reset_required" sql:"not null;default:false"`
+	// SegmentString is the comma separated names of the customer segments
+	// the user is a member of, set by the nightly segment evaluation.
+	SegmentString string `json:"-"`
+	// PreferenceString is the JSON of the preferences of the user but the
+	// locale, see Preferences.
+	PreferenceString string    `json:"-" sql:"type:text"`
+	CreatedAt        time.Time `json:"created_at" sql:"not null;default:now()"`
+	// Version is bumped on every save, a save of an older version fails.
+	Version int `json:"version" sql:"not null;default:0"`
+}
+
+// Profile is the user as read over HTTP, without its credentials, secrets
+// and segments.
+type Profile struct {
+	ID               string     `json:"id"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Email            string     `json:"email"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at,omitempty"`
+	Username         string     `json:"username"`
+	Role             string     `json:"role"`
+	Deactivated      bool       `json:"deactivated"`
+	Timezone         string     `json:"timezone,omitempty"`
+	Locale           string     `json:"locale,omitempty"`
+	Avatar           string     `json:"avatar,omitempty"`
+	Bio              string     `json:"bio,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	BannedAt         *time.Time `json:"banned_at,omitempty"`
+	BanReason        string     `json:"ban_reason,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Version          int        `json:"version"`
+}
+
+// Profile returns the profile of u.
+func (u User) Profile() Profile {
+	return Profile{
+		ID:               u.ID,
+		FirstName:        u.FirstName,
+		LastName:         u.LastName,
+		Email:            u.Email,
+		EmailVerifiedAt:  u.EmailVerifiedAt,
+		Username:         u.Username,
+		Role:             u.Role,
+		Deactivated:      u.Deactivated,
+		Timezone:         u.Timezone,
+		Locale:           u.Locale,
+		Avatar:           u.Avatar,
+		Bio:              u.Bio,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		LockedUntil:      u.LockedUntil,
+		BannedAt:         u.BannedAt,
+		BanReason:        u.BanReason,
+		DeletedAt:        u.DeletedAt,
+		CreatedAt:        u.CreatedAt,
+		Version:          u.Version,
+	}
+}
+
+// ListFields are the fields the user list can be filtered on.
+var ListFields = filter.Fields{
+	"id":          {Column: "id", Type: filter.String},
+	"email":       {Column: "email", Type: filter.String},
+	"username":    {Column: "username", Type: filter.String},
+	"first_name":  {Column: "first_name", Type: filter.String},
+	"last_name":   {Column: "last_name", Type: filter.String},
+	"role":        {Column: "role", Type: filter.String},
+	"deactivated": {Column: "deactivated", Type: filter.Bool},
+}
+
+// Segments returns the names of the segments of the user.
+func (u User) Segments() []string {
+	if u.SegmentString == "" {
+		return nil
+	}
+	return strings.Split(u.SegmentString, ",")
+}
+
+// InSegment tells whether the user is a member of the segment name, e.g. to
+// target a promotion or a newsletter.
+func (u User) InSegment(name string) bool {
+	for _, s := range u.Segments() {
+		if s == name {
+			return true
+		}
+	}
+	return false
 }
 
 // New create empty user with random salt.
@@ -53,27 +200,17 @@ type NewUser struct {
 	ConfirmPassword string `json:"confirm_password"`
 }
 
-// Validate does basic validation before saving into db.
+// Validate does basic validation before saving into db, reporting all
+// the failing fields.
 func (n *NewUser) Validate() error {
-	if n.FirstName == "" {
-		return errors.Wrap(ErrMissingField, ": first_name")
-	}
-	if n.LastName == "" {
-		return errors.Wrap(ErrMissingField, ": last_name")
-	}
-	if n.Email == "" {
-		return errors.Wrap(ErrMissingField, ": email")
-	}
-	if n.Password == "" {
-		return errors.Wrap(ErrMissingField, ": password")
-	}
-	if n.ConfirmPassword == "" {
-		return errors.Wrap(ErrMissingField, ": confirm_password")
-	}
-	if n.Password != n.ConfirmPassword {
-		return ErrPasswordMismatch
-	}
-	return nil
+	var v validate.Validator
+	v.Required("first_name", n.FirstName)
+	v.Required("last_name", n.LastName)
+	v.Required("email", n.Email)
+	v.Required("password", n.Password)
+	v.Required("confirm_password", n.ConfirmPassword)
+	v.Equal("confirm_password", n.ConfirmPassword, "password", n.Password)
+	return v.Err()
 }
 
 // User map NewUser with domain User.
@@ -85,6 +222,7 @@ func (n *NewUser) User() User {
 	u.Email = n.Email
 	u.Password = calculatePassHash(n.Password, u.Salt)
 	u.Username = strings.Split(n.Email, "@")[0]
+	u.Role = RoleCustomer
 	return u
 }
 
@@ -0,0 +1,129 @@
+package vat
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+// Endpoints combine all the vat service endpoints under single type.
+type Endpoints struct {
+	ValidateEndpoint endpoint.Endpoint
+	ApplyEndpoint    endpoint.Endpoint
+	OrderTaxEndpoint endpoint.Endpoint
+	ReportEndpoint   endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the vat service endpoints. The checkout endpoints are restricted by
+// account, e.g. to the unscoped tokens of the buyers, the OSS report by
+// admin.
+func MakeEndpoints(s Service, account, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		ValidateEndpoint: account(MakeValidateEndpoint(s)),
+		ApplyEndpoint:    account(MakeApplyEndpoint(s)),
+		OrderTaxEndpoint: account(MakeOrderTaxEndpoint(s)),
+		ReportEndpoint:   admin(MakeReportEndpoint(s)),
+	}
+}
+
+func MakeValidateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(validateRequest)
+		v, e := s.Validate(ctx, req.VATID)
+		if e != nil {
+			return validateResponse{Validation: nil, Error: e}, nil
+		}
+		return validateResponse{Validation: &v}, nil
+	}
+}
+
+// MakeApplyEndpoint taxes an order of the user of the request.
+func MakeApplyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(applyRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		t, e := s.Apply(ctx, userID, req.OrderID, req.VATID)
+		if e != nil {
+			return orderTaxResponse{OrderTax: nil, Error: e}, nil
+		}
+		return orderTaxResponse{OrderTax: &t}, nil
+	}
+}
+
+// MakeOrderTaxEndpoint returns the VAT of an order of the user of the
+// request.
+func MakeOrderTaxEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(orderTaxRequest)
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, auth.ErrMissingToken
+		}
+		t, e := s.OrderTax(ctx, userID, req.OrderID)
+		if e != nil {
+			return orderTaxResponse{OrderTax: nil, Error: e}, nil
+		}
+		return orderTaxResponse{OrderTax: &t}, nil
+	}
+}
+
+func MakeReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reportRequest)
+		r, e := s.Report(ctx, req.Year, req.Quarter)
+		if e != nil {
+			return reportResponse{Report: nil, Error: e}, nil
+		}
+		return reportResponse{Report: &r}, nil
+	}
+}
+
+type validateRequest struct {
+	VATID string `json:"vat_id"`
+}
+
+type validateResponse struct {
+	Validation *Validation `json:"validation,omitempty"`
+	Error      error       `json:"error,omitempty"`
+}
+
+func (r validateResponse) error() error {
+	return r.Error
+}
+
+type applyRequest struct {
+	OrderID string `json:"-"`
+	VATID   string `json:"vat_id"`
+}
+
+type orderTaxRequest struct {
+	OrderID string
+}
+
+type orderTaxResponse struct {
+	OrderTax *OrderTax `json:"order_tax,omitempty"`
+	Error    error     `json:"error,omitempty"`
+}
+
+func (r orderTaxResponse) error() error {
+	return r.Error
+}
+
+type reportRequest struct {
+	Year    int
+	Quarter int
+}
+
+type reportResponse struct {
+	Report *Report `json:"report,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r reportResponse) error() error {
+	return r.Error
+}
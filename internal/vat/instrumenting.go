@@ -0,0 +1,70 @@
+package vat
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Validate(ctx context.Context, vatID string) (validation Validation, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "validate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	validation, err = mw.next.Validate(ctx, vatID)
+	return
+}
+
+func (mw instrmw) Apply(ctx context.Context, userID, orderID, vatID string) (orderTax OrderTax, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "apply", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orderTax, err = mw.next.Apply(ctx, userID, orderID, vatID)
+	return
+}
+
+func (mw instrmw) OrderTax(ctx context.Context, userID, orderID string) (orderTax OrderTax, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "order_tax", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orderTax, err = mw.next.OrderTax(ctx, userID, orderID)
+	return
+}
+
+func (mw instrmw) Report(ctx context.Context, year, quarter int) (report Report, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	report, err = mw.next.Report(ctx, year, quarter)
+	return
+}
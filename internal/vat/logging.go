@@ -0,0 +1,67 @@
+package vat
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Validate(ctx context.Context, vatID string) (validation Validation, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "validate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Validate(ctx, vatID)
+}
+
+func (s loggingService) Apply(ctx context.Context, userID, orderID, vatID string) (orderTax OrderTax, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "apply",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Apply(ctx, userID, orderID, vatID)
+}
+
+func (s loggingService) OrderTax(ctx context.Context, userID, orderID string) (orderTax OrderTax, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "order_tax",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.OrderTax(ctx, userID, orderID)
+}
+
+func (s loggingService) Report(ctx context.Context, year, quarter int) (report Report, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "report",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Report(ctx, year, quarter)
+}
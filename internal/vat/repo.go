@@ -0,0 +1,12 @@
+package vat
+
+import "time"
+
+// Repo abstracts all the persistant storage operations of VAT Service
+type Repo interface {
+	CreateValidation(v *Validation) error
+	CreateOrderTax(t *OrderTax) error
+	GetOrderTax(orderID string) (OrderTax, error)
+	ListOrderTaxes(from, to time.Time) ([]OrderTax, error)
+	Drop() error
+}
@@ -0,0 +1,229 @@
+package vat
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidQuarter = errors.New("quarter must be between 1 and 4")
+	ErrTaxNotFound    = errors.New("order tax not found")
+	ErrAlreadyPaid    = errors.New("order is already paid")
+)
+
+type Service interface {
+	// Validate checks the VAT ID against VIES and stores the result as
+	// proof. Invalid VAT IDs are not an error, Validation.Valid is false.
+	Validate(ctx context.Context, vatID string) (Validation, error)
+
+	// Apply computes the VAT of an order of the user at checkout, from
+	// the country of its shipping address and optional VAT ID, adding it
+	// to the total of the order. Orders are taxed once, applying again
+	// returns the first result.
+	Apply(ctx context.Context, userID, orderID, vatID string) (OrderTax, error)
+
+	// OrderTax returns the VAT applied to an order of the user.
+	OrderTax(ctx context.Context, userID, orderID string) (OrderTax, error)
+
+	// Report summarizes the VAT due per member state for the OSS return of
+	// the quarter.
+	Report(ctx context.Context, year, quarter int) (Report, error)
+}
+
+// Config controls the VAT service.
+type Config struct {
+	SellerCountry string // member state the shop is established in
+}
+
+type basicService struct {
+	r         Repo
+	orders    order.Repo
+	addresses address.Repo
+	checker   Checker
+	cfg       Config
+}
+
+// NewService return basic Service implementation. The orders are taxed at
+// the country of their checked shipping address in addresses.
+func NewService(r Repo, orders order.Repo, addresses address.Repo, checker Checker, cfg Config) Service {
+	cfg.SellerCountry = Country(cfg.SellerCountry)
+	return basicService{r: r, orders: orders, addresses: addresses, checker: checker, cfg: cfg}
+}
+
+// Validate checks the VAT ID against VIES and stores the result as proof.
+func (s basicService) Validate(ctx context.Context, vatID string) (Validation, error) {
+	country, number, err := ParseVATID(vatID)
+	if err != nil {
+		return Validation{}, err
+	}
+	v, err := s.checker.Check(ctx, country, number)
+	if err != nil {
+		return Validation{}, err
+	}
+	v.CreatedAt = time.Now().UTC()
+	if err := s.r.CreateValidation(&v); err != nil {
+		return Validation{}, err
+	}
+	return v, nil
+}
+
+// Apply computes the VAT of the order at checkout, before its payment.
+// Prices are net, VAT is added at the book rate of the country the order
+// is shipped to. Businesses in another member state with a valid VAT ID are
+// zero rated under reverse charge. When VIES can't be reached VAT is
+// charged, the customer can reclaim it later.
+func (s basicService) Apply(ctx context.Context, userID, orderID, vatID string) (OrderTax, error) {
+	o, err := s.orders.GetByID(orderID)
+	if err != nil || o.CreatedByID != userID {
+		return OrderTax{}, order.ErrOrderNotFound
+	}
+	if t, err := s.r.GetOrderTax(orderID); err == nil {
+		return t, s.charge(o, t)
+	}
+	if o.PaidAt != nil {
+		return OrderTax{}, ErrAlreadyPaid
+	}
+	c, err := s.addresses.GetCheck(orderID)
+	if err != nil {
+		return OrderTax{}, address.ErrCheckNotFound
+	}
+
+	country := Country(c.Country)
+	t := OrderTax{
+		OrderID:   o.ID,
+		Country:   country,
+		VATID:     vatID,
+		Rate:      BookRates[country], // zero outside EU
+		Net:       o.TotalPrice,
+		Currency:  o.Currency,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if vatID != "" && InEU(country) && country != s.cfg.SellerCountry {
+		v, err := s.Validate(ctx, vatID)
+		switch errors.Cause(err) {
+		case nil:
+			if v.Valid && v.Country == country {
+				t.ValidationID = v.ID
+				t.ReverseCharge = true
+				t.Rate = 0
+			}
+		case ErrInvalidVATID, ErrVIESUnavailable:
+			// Taxed like a consumer.
+		default:
+			return OrderTax{}, err
+		}
+	}
+
+	t.VAT = round(t.Net * t.Rate / 100)
+	t.Gross = round(t.Net + t.VAT)
+	if err := s.r.CreateOrderTax(&t); err != nil {
+		return OrderTax{}, err
+	}
+	return t, s.charge(o, t)
+}
+
+// charge sets the total of the order to its gross, so that the VAT is paid
+// along with the order. The total of an order already charged is left
+// as is, a retry only charging the order the tax was recorded for.
+func (s basicService) charge(o order.Order, t OrderTax) error {
+	if o.PaidAt != nil || cents(o.TotalPrice) != cents(t.Net) || t.VAT == 0 {
+		return nil
+	}
+	o.TotalPrice = t.Gross
+	if err := s.orders.Save(&o); err != nil {
+		return errors.Wrapf(err, "order %s taxed, total not charged", o.ID)
+	}
+	return nil
+}
+
+// OrderTax returns the VAT applied to the order, the orders of the other
+// users aren't found.
+func (s basicService) OrderTax(ctx context.Context, userID, orderID string) (OrderTax, error) {
+	if o, err := s.orders.GetByID(orderID); err != nil || o.CreatedByID != userID {
+		return OrderTax{}, order.ErrOrderNotFound
+	}
+	t, err := s.r.GetOrderTax(orderID)
+	if err != nil {
+		return OrderTax{}, ErrTaxNotFound
+	}
+	return t, nil
+}
+
+// Report summarizes the VAT due per member state for the OSS return. OSS
+// covers the B2C sales to the other member states, domestic sales and
+// reverse charged orders are declared elsewhere.
+func (s basicService) Report(ctx context.Context, year, quarter int) (Report, error) {
+	if quarter < 1 || quarter > 4 {
+		return Report{}, ErrInvalidQuarter
+	}
+	from := time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, time.UTC)
+	taxes, err := s.r.ListOrderTaxes(from, from.AddDate(0, 3, 0))
+	if err != nil {
+		return Report{}, err
+	}
+
+	type key struct {
+		country, currency string
+		rate              float64
+	}
+	type sums struct {
+		orders   int
+		net, vat int64
+	}
+	lines := make(map[key]*sums)
+	for _, t := range taxes {
+		if t.ReverseCharge || !InEU(t.Country) || t.Country == s.cfg.SellerCountry {
+			continue
+		}
+		k := key{t.Country, t.Currency, t.Rate}
+		l, ok := lines[k]
+		if !ok {
+			l = &sums{}
+			lines[k] = l
+		}
+		l.orders++
+		l.net += cents(t.Net)
+		l.vat += cents(t.VAT)
+	}
+
+	r := Report{Year: year, Quarter: quarter, Countries: make([]Summary, 0, len(lines))}
+	for k, l := range lines {
+		r.Countries = append(r.Countries, Summary{
+			Country:  k.country,
+			Rate:     k.rate,
+			Currency: k.currency,
+			Orders:   l.orders,
+			Net:      float64(l.net) / 100,
+			VAT:      float64(l.vat) / 100,
+		})
+	}
+	sort.Slice(r.Countries, func(i, j int) bool {
+		a, b := r.Countries[i], r.Countries[j]
+		if a.Country != b.Country {
+			return a.Country < b.Country
+		}
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Rate < b.Rate
+	})
+	return r, nil
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Floor(amount*100 + 0.5))
+}
+
+func round(amount float64) float64 {
+	return float64(cents(amount)) / 100
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
@@ -0,0 +1,194 @@
+package vat
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting  = errors.New("bad routing")
+	ErrInvalidYear = errors.New("invalid year")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrInvalidVATID:    "vat.invalid_id",
+		ErrVIESUnavailable: "vat.vies_unavailable",
+		ErrAlreadyPaid:     "vat.already_paid",
+	})
+}
+
+// MakeHTTPHandler mounts the vat endpoints, the checkout ones served to the
+// requests account lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireUnscoped, the OSS report to the ones of admin, e.g. with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, account, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, account, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	validateHandler := httptransport.NewServer(
+		e.ValidateEndpoint,
+		decodeValidateRequest,
+		encodeResponse,
+		options...,
+	)
+	applyHandler := httptransport.NewServer(
+		e.ApplyEndpoint,
+		decodeApplyRequest,
+		encodeResponse,
+		options...,
+	)
+	orderTaxHandler := httptransport.NewServer(
+		e.OrderTaxEndpoint,
+		decodeOrderTaxRequest,
+		encodeResponse,
+		options...,
+	)
+	reportHandler := httptransport.NewServer(
+		e.ReportEndpoint,
+		decodeReportRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/vat/v1/validate", validateHandler).Methods("POST")
+	r.Handle("/vat/v1/orders/{order-id}", applyHandler).Methods("POST")
+	r.Handle("/vat/v1/orders/{order-id}", orderTaxHandler).Methods("GET")
+	r.Handle("/vat/v1/report", reportHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeValidateRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r validateRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeApplyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r applyRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	r.OrderID = orderID
+	return r, nil
+}
+
+func decodeOrderTaxRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	orderID, ok := mux.Vars(req)["order-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "order-id")
+	}
+	return orderTaxRequest{OrderID: orderID}, nil
+}
+
+func decodeReportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	year, err := strconv.Atoi(req.FormValue("year"))
+	if err != nil {
+		return nil, ErrInvalidYear
+	}
+	quarter, err := strconv.Atoi(req.FormValue("quarter"))
+	if err != nil {
+		return nil, ErrInvalidQuarter
+	}
+	return reportRequest{Year: year, Quarter: quarter}, nil
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrTaxNotFound, order.ErrOrderNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyPaid, address.ErrCheckNotFound:
+		return http.StatusConflict
+	case ErrVIESUnavailable:
+		return http.StatusServiceUnavailable
+	case ErrBadRouting, ErrInvalidVATID, ErrInvalidQuarter, ErrInvalidYear, validate.ErrInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
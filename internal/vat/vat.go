@@ -0,0 +1,115 @@
+package vat
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidVATID = errors.New("malformed vat id")
+)
+
+// StandardRates are the standard VAT rates, in percent, of the EU member
+// states keyed by the country code used by VIES (Greece is EL).
+var StandardRates = map[string]float64{
+	"AT": 20, "BE": 21, "BG": 20, "CY": 19, "CZ": 21, "DE": 19, "DK": 25,
+	"EE": 24, "EL": 24, "ES": 21, "FI": 25.5, "FR": 20, "HR": 25, "HU": 27,
+	"IE": 23, "IT": 22, "LT": 21, "LU": 17, "LV": 21, "MT": 18, "NL": 21,
+	"PL": 23, "PT": 23, "RO": 21, "SE": 25, "SI": 22, "SK": 23,
+}
+
+// BookRates are the reduced VAT rates, in percent, the member states
+// charge on books, printed or electronic. Denmark has none, books being
+// charged its standard rate.
+var BookRates = map[string]float64{
+	"AT": 10, "BE": 6, "BG": 9, "CY": 5, "CZ": 0, "DE": 7, "DK": 25,
+	"EE": 9, "EL": 6, "ES": 4, "FI": 13.5, "FR": 5.5, "HR": 5, "HU": 5,
+	"IE": 0, "IT": 4, "LT": 9, "LU": 3, "LV": 12, "MT": 5, "NL": 9,
+	"PL": 5, "PT": 6, "RO": 11, "SE": 6, "SI": 5, "SK": 5,
+}
+
+// Country normalizes ISO country code to the VIES one.
+func Country(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "GR" {
+		return "EL"
+	}
+	return code
+}
+
+// InEU tells whether the country is an EU member state.
+func InEU(country string) bool {
+	_, ok := StandardRates[Country(country)]
+	return ok
+}
+
+// ParseVATID splits a VAT ID like "DE 123456789" into country code and
+// number.
+func ParseVATID(id string) (country, number string, err error) {
+	id = strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(id))
+	if len(id) < 4 {
+		return "", "", ErrInvalidVATID
+	}
+	country, number = id[:2], id[2:]
+	if !InEU(country) {
+		return "", "", errors.Wrap(ErrInvalidVATID, "not an EU country: "+country)
+	}
+	return country, number, nil
+}
+
+// Validation is the proof of checking a VAT ID against VIES. Consultation
+// number, issued by VIES, proves the check to the tax authorities.
+type Validation struct {
+	ID                 string    `json:"id"`
+	Country            string    `json:"country"`
+	Number             string    `json:"number"`
+	Valid              bool      `json:"valid"`
+	TraderName         string    `json:"trader_name,omitempty"`
+	TraderAddress      string    `json:"trader_address,omitempty"`
+	ConsultationNumber string    `json:"consultation_number,omitempty"`
+	RequestDate        time.Time `json:"request_date"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func (Validation) TableName() string {
+	return "vat_validations"
+}
+
+// OrderTax is the VAT charged on an order. Reverse charged B2B orders are
+// zero rated and carry the validation of the customer's VAT ID.
+type OrderTax struct {
+	OrderID       string    `json:"order_id" gorm:"primary_key"`
+	Country       string    `json:"country"`
+	VATID         string    `json:"vat_id,omitempty"`
+	ValidationID  string    `json:"validation_id,omitempty"`
+	ReverseCharge bool      `json:"reverse_charge"`
+	Rate          float64   `json:"rate"`
+	Net           float64   `json:"net"`
+	VAT           float64   `json:"vat"`
+	Gross         float64   `json:"gross"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (OrderTax) TableName() string {
+	return "order_taxes"
+}
+
+// Summary is the OSS return line of a member state for a quarter.
+type Summary struct {
+	Country  string  `json:"country"`
+	Rate     float64 `json:"rate"`
+	Currency string  `json:"currency"`
+	Orders   int     `json:"orders"`
+	Net      float64 `json:"net"`
+	VAT      float64 `json:"vat"`
+}
+
+// Report is the OSS summary of a quarter.
+type Report struct {
+	Year      int       `json:"year"`
+	Quarter   int       `json:"quarter"`
+	Countries []Summary `json:"countries"`
+}
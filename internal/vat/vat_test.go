@@ -0,0 +1,179 @@
+package vat_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vat"
+	"github.com/pkg/errors"
+)
+
+func TestParseVATID(t *testing.T) {
+	cases := []struct {
+		id, country, number string
+	}{
+		{"DE123456789", "DE", "123456789"},
+		{"de 123 456 789", "DE", "123456789"},
+		{"EL-094259216", "EL", "094259216"},
+		{"NL.8594.07.212.B01", "NL", "859407212B01"},
+	}
+	for _, c := range cases {
+		country, number, err := vat.ParseVATID(c.id)
+		if err != nil {
+			t.Fatalf("ParseVATID(%q): %v", c.id, err)
+		}
+		if country != c.country || number != c.number {
+			t.Errorf("ParseVATID(%q): expected %s %s, got %s %s", c.id, c.country, c.number, country, number)
+		}
+	}
+
+	for _, id := range []string{"", "DE1", "US123456789", "GB123456789"} {
+		if _, _, err := vat.ParseVATID(id); errors.Cause(err) != vat.ErrInvalidVATID {
+			t.Errorf("ParseVATID(%q): expected %v, got %v", id, vat.ErrInvalidVATID, err)
+		}
+	}
+}
+
+// taxRepo keeps the order taxes in memory.
+type taxRepo struct {
+	vat.Repo
+	taxes map[string]vat.OrderTax
+}
+
+func (r *taxRepo) CreateOrderTax(t *vat.OrderTax) error {
+	r.taxes[t.OrderID] = *t
+	return nil
+}
+
+func (r *taxRepo) GetOrderTax(orderID string) (vat.OrderTax, error) {
+	t, ok := r.taxes[orderID]
+	if !ok {
+		return vat.OrderTax{}, db.ErrNotFound
+	}
+	return t, nil
+}
+
+func (r *taxRepo) ListOrderTaxes(from, to time.Time) ([]vat.OrderTax, error) {
+	var taxes []vat.OrderTax
+	for _, t := range r.taxes {
+		if !t.CreatedAt.Before(from) && t.CreatedAt.Before(to) {
+			taxes = append(taxes, t)
+		}
+	}
+	return taxes, nil
+}
+
+// orderRepo keeps the orders in memory.
+type orderRepo struct {
+	order.Repo
+	orders map[string]order.Order
+}
+
+func (r *orderRepo) GetByID(ID string) (order.Order, error) {
+	o, ok := r.orders[ID]
+	if !ok {
+		return order.Order{}, db.ErrNotFound
+	}
+	return o, nil
+}
+
+func (r *orderRepo) Save(o *order.Order) error {
+	r.orders[o.ID] = *o
+	return nil
+}
+
+// addressRepo returns the checked addresses by order.
+type addressRepo struct {
+	address.Repo
+	checks map[string]address.Check
+}
+
+func (r addressRepo) GetCheck(orderID string) (address.Check, error) {
+	c, ok := r.checks[orderID]
+	if !ok {
+		return address.Check{}, db.ErrNotFound
+	}
+	return c, nil
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	paid := time.Now().UTC()
+	orders := &orderRepo{orders: map[string]order.Order{
+		"o1": {ID: "o1", CreatedByID: "u1", TotalPrice: 20, Currency: "EUR"},
+		"o2": {ID: "o2", CreatedByID: "u1", TotalPrice: 20, Currency: "EUR", PaidAt: &paid},
+		"o3": {ID: "o3", CreatedByID: "u1", TotalPrice: 20, Currency: "EUR"},
+	}}
+	addresses := addressRepo{checks: map[string]address.Check{
+		"o1": {OrderID: "o1", Address: address.Address{Country: "fr"}, Deliverable: true},
+		"o2": {OrderID: "o2", Address: address.Address{Country: "DE"}, Deliverable: true},
+	}}
+	s := vat.NewService(&taxRepo{taxes: make(map[string]vat.OrderTax)}, orders, addresses, nil, vat.Config{SellerCountry: "DE"})
+
+	tax, err := s.Apply(ctx, "u1", "o1", "")
+	if err != nil || tax.Country != "FR" || tax.Rate != 5.5 || tax.VAT != 1.1 || tax.Gross != 21.1 {
+		t.Fatalf("expected the french book rate, got %+v, %v", tax, err)
+	}
+	if o := orders.orders["o1"]; o.TotalPrice != 21.1 {
+		t.Errorf("expected the VAT added to the total, got %v", o.TotalPrice)
+	}
+	if again, err := s.Apply(ctx, "u1", "o1", ""); err != nil || again.Gross != 21.1 || orders.orders["o1"].TotalPrice != 21.1 {
+		t.Errorf("again: expected the tax and the total unchanged, got %+v, %v", again, err)
+	}
+
+	if _, err := s.Apply(ctx, "u2", "o1", ""); err != order.ErrOrderNotFound {
+		t.Errorf("other user: expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := s.OrderTax(ctx, "u2", "o1"); err != order.ErrOrderNotFound {
+		t.Errorf("other user tax: expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := s.Apply(ctx, "u1", "o2", ""); err != vat.ErrAlreadyPaid {
+		t.Errorf("paid: expected ErrAlreadyPaid, got %v", err)
+	}
+	if _, err := s.Apply(ctx, "u1", "o3", ""); err != address.ErrCheckNotFound {
+		t.Errorf("no address: expected ErrCheckNotFound, got %v", err)
+	}
+}
+
+func TestHTTPAccess(t *testing.T) {
+	s := vat.NewService(&taxRepo{taxes: make(map[string]vat.OrderTax)}, &orderRepo{orders: make(map[string]order.Order)}, addressRepo{}, nil, vat.Config{})
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	account := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireUnscoped())
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := vat.MakeHTTPHandler(context.Background(), s, account, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, method, path, token string
+		status                    int
+	}{
+		{"apply without token", "POST", "/vat/v1/orders/o1", "", http.StatusUnauthorized},
+		{"tax without token", "GET", "/vat/v1/orders/o1", "", http.StatusUnauthorized},
+		{"apply unknown order", "POST", "/vat/v1/orders/o1", customer, http.StatusNotFound},
+		{"report by customer", "GET", "/vat/v1/report?year=2026&quarter=1", customer, http.StatusForbidden},
+		{"report by admin", "GET", "/vat/v1/report?year=2026&quarter=1", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest(c.method, c.path, strings.NewReader(`{}`))
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
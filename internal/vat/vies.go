@@ -0,0 +1,131 @@
+package vat
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrVIESUnavailable = errors.New("vies is unavailable")
+)
+
+// DefaultVIESURL is the VIES SOAP endpoint of the European Commission.
+const DefaultVIESURL = "https://ec.europa.eu/taxation_customs/vies/services/checkVatService"
+
+// Checker validates VAT IDs.
+type Checker interface {
+	Check(ctx context.Context, country, number string) (Validation, error)
+}
+
+type viesChecker struct {
+	url              string
+	requesterCountry string
+	requesterNumber  string
+	client           *http.Client
+}
+
+// NewVIESChecker returns Checker calling checkVatApprox on VIES. Requester
+// VAT ID, the shop's own, makes VIES issue a consultation number.
+func NewVIESChecker(url, requesterVATID string, client *http.Client) Checker {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if url == "" {
+		url = DefaultVIESURL
+	}
+	c := viesChecker{url: url, client: client}
+	if country, number, err := ParseVATID(requesterVATID); err == nil {
+		c.requesterCountry, c.requesterNumber = country, number
+	}
+	return c
+}
+
+const checkVatApprox = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:urn="urn:ec.europa.eu:taxud:vies:services:checkVat:types">
+<soapenv:Body>
+<urn:checkVatApprox>
+<urn:countryCode>%s</urn:countryCode>
+<urn:vatNumber>%s</urn:vatNumber>
+<urn:requesterCountryCode>%s</urn:requesterCountryCode>
+<urn:requesterVatNumber>%s</urn:requesterVatNumber>
+</urn:checkVatApprox>
+</soapenv:Body>
+</soapenv:Envelope>`
+
+type viesEnvelope struct {
+	Body struct {
+		Response *struct {
+			Valid             bool   `xml:"valid"`
+			RequestDate       string `xml:"requestDate"`
+			TraderName        string `xml:"traderName"`
+			TraderAddress     string `xml:"traderAddress"`
+			RequestIdentifier string `xml:"requestIdentifier"`
+		} `xml:"checkVatApproxResponse"`
+		Fault *struct {
+			String string `xml:"faultstring"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+func (c viesChecker) Check(ctx context.Context, country, number string) (Validation, error) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, checkVatApprox, escape(country), escape(number), escape(c.requesterCountry), escape(c.requesterNumber))
+
+	req, err := http.NewRequest("POST", c.url, &body)
+	if err != nil {
+		return Validation{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Validation{}, errors.Wrap(ErrVIESUnavailable, err.Error())
+	}
+	defer resp.Body.Close()
+
+	var env viesEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return Validation{}, errors.Wrap(ErrVIESUnavailable, err.Error())
+	}
+	if f := env.Body.Fault; f != nil {
+		// INVALID_INPUT means the number is malformed, the rest are
+		// temporary failures of VIES or the member state's service.
+		if strings.TrimSpace(f.String) == "INVALID_INPUT" {
+			return Validation{}, ErrInvalidVATID
+		}
+		return Validation{}, errors.Wrap(ErrVIESUnavailable, f.String)
+	}
+	r := env.Body.Response
+	if r == nil {
+		return Validation{}, errors.Wrap(ErrVIESUnavailable, fmt.Sprintf("unexpected response, status %d", resp.StatusCode))
+	}
+
+	// requestDate comes as date with zone, e.g 2017-06-01+02:00
+	date, err := time.Parse("2006-01-02Z07:00", r.RequestDate)
+	if err != nil {
+		date = time.Now()
+	}
+	return Validation{
+		Country:            country,
+		Number:             number,
+		Valid:              r.Valid,
+		TraderName:         strings.TrimSpace(r.TraderName),
+		TraderAddress:      strings.TrimSpace(r.TraderAddress),
+		ConsultationNumber: r.RequestIdentifier,
+		RequestDate:        date.UTC(),
+	}, nil
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
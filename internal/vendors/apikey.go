@@ -0,0 +1,47 @@
+package vendors
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+const keyPrefix = "vk_"
+
+// newKey generates a random API key. Returned prefix is used to look up the
+// key, the rest is only ever compared by hash.
+func newKey() (key, prefix string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := hex.EncodeToString(b)
+	return keyPrefix + secret, secret[:8], nil
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// parseKey returns the lookup prefix of the key.
+func parseKey(key string) (prefix string, ok bool) {
+	if !strings.HasPrefix(key, keyPrefix) || len(key) < len(keyPrefix)+8 {
+		return "", false
+	}
+	return key[len(keyPrefix) : len(keyPrefix)+8], true
+}
+
+func matchKey(k APIKey, key string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashKey(key))) == 1
+}
+
+func validScope(scope string) bool {
+	switch scope {
+	case ScopeCatalogWrite, ScopeInventoryWrite, ScopeOrdersRead, ScopePayoutsRead:
+		return true
+	}
+	return false
+}
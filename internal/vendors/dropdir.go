@@ -0,0 +1,100 @@
+package vendors
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+const settleTime = time.Minute
+
+// RunDropScanner submits the CSV files vendors drop under dir every interval
+// until ctx is done. Files are expected at <dir>/<vendor-id>/<name>.csv,
+// e.g uploaded through SFTP chrooted to the vendor's directory. Submitted
+// files are moved to <dir>/<vendor-id>/done, rejected ones to
+// <dir>/<vendor-id>/failed. Files modified within settleTime are assumed to
+// be still uploading and are left for the next scan.
+func RunDropScanner(ctx context.Context, s Service, dir string, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := scanDropDir(ctx, s, dir, logger); err != nil {
+				logger.Log("scanner", "vendor_sync", "err", err)
+			}
+		}
+	}
+}
+
+func scanDropDir(ctx context.Context, s Service, dir string, logger log.Logger) error {
+	vendorDirs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, vd := range vendorDirs {
+		if !vd.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(dir, vd.Name()))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(strings.ToLower(f.Name()), ".csv") {
+				continue
+			}
+			if time.Since(f.ModTime()) < settleTime {
+				continue
+			}
+			path := filepath.Join(dir, vd.Name(), f.Name())
+			batch, err := submitDropFile(ctx, s, vd.Name(), path)
+			dest := "done"
+			switch errors.Cause(err) {
+			case nil:
+				logger.Log("scanner", "vendor_sync", "file", path, "batch", batch.ID)
+			case ErrMalformedCSV, ErrEmptyBatch, ErrBatchTooLarge, ErrVendorNotFound, ErrNotApproved:
+				dest = "failed"
+				logger.Log("scanner", "vendor_sync", "file", path, "err", err)
+			default:
+				// Keep the file to retry on the next scan.
+				return err
+			}
+			if err := moveTo(path, dest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func submitDropFile(ctx context.Context, s Service, vendorID, path string) (SyncBatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SyncBatch{}, err
+	}
+	defer f.Close()
+
+	items, err := ParseCSV(f)
+	if err != nil {
+		return SyncBatch{}, err
+	}
+	return s.SubmitSync(ctx, vendorID, SourceCSV, items)
+}
+
+// moveTo moves the file into sub directory of its own directory.
+func moveTo(path, sub string) error {
+	dir := filepath.Join(filepath.Dir(path), sub)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Rename(path, filepath.Join(dir, filepath.Base(path)))
+}
@@ -0,0 +1,379 @@
+package vendors
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+)
+
+// Endpoints combine all the vendor service endpoints under single type.
+type Endpoints struct {
+	RegisterEndpoint    endpoint.Endpoint
+	ListEndpoint        endpoint.Endpoint
+	ReviewEndpoint      endpoint.Endpoint
+	SuspendEndpoint     endpoint.Endpoint
+	CreateKeyEndpoint   endpoint.Endpoint
+	RevokeKeyEndpoint   endpoint.Endpoint
+	BooksEndpoint       endpoint.Endpoint
+	SaveBookEndpoint    endpoint.Endpoint
+	UpdateStockEndpoint endpoint.Endpoint
+	OrdersEndpoint      endpoint.Endpoint
+	SubmitSyncEndpoint  endpoint.Endpoint
+	SyncBatchesEndpoint endpoint.Endpoint
+	SyncReportEndpoint  endpoint.Endpoint
+}
+
+// MakeEndpoints returns Endpoints type which is the combination of
+// all the vendor service endpoints. The shop admin endpoints are restricted
+// by admin.
+func MakeEndpoints(s Service, admin endpoint.Middleware) Endpoints {
+	return Endpoints{
+		RegisterEndpoint:    MakeRegisterEndpoint(s),
+		ListEndpoint:        admin(MakeListEndpoint(s)),
+		ReviewEndpoint:      admin(MakeReviewEndpoint(s)),
+		SuspendEndpoint:     admin(MakeSuspendEndpoint(s)),
+		CreateKeyEndpoint:   admin(MakeCreateKeyEndpoint(s)),
+		RevokeKeyEndpoint:   admin(MakeRevokeKeyEndpoint(s)),
+		BooksEndpoint:       MakeBooksEndpoint(s),
+		SaveBookEndpoint:    MakeSaveBookEndpoint(s),
+		UpdateStockEndpoint: MakeUpdateStockEndpoint(s),
+		OrdersEndpoint:      MakeOrdersEndpoint(s),
+		SubmitSyncEndpoint:  MakeSubmitSyncEndpoint(s),
+		SyncBatchesEndpoint: MakeSyncBatchesEndpoint(s),
+		SyncReportEndpoint:  MakeSyncReportEndpoint(s),
+	}
+}
+
+func MakeRegisterEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(registerRequest)
+		v, e := s.Register(ctx, req.NewVendor)
+		if e != nil {
+			return vendorResponse{Vendor: nil, Error: e}, nil
+		}
+		return vendorResponse{Vendor: &v, Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeListEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRequest)
+		vendors, e := s.List(ctx, req.Status, req.Filter)
+		if e != nil {
+			return listResponse{Vendors: make([]Vendor, 0), Error: e}, nil
+		}
+		return listResponse{Vendors: vendors}, nil
+	}
+}
+
+func MakeReviewEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reviewRequest)
+		v, e := s.Review(ctx, req.VendorID, req.Approve, req.Note)
+		if e != nil {
+			return vendorResponse{Vendor: nil, Error: e}, nil
+		}
+		return vendorResponse{Vendor: &v}, nil
+	}
+}
+
+func MakeSuspendEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reviewRequest)
+		v, e := s.Suspend(ctx, req.VendorID, req.Note)
+		if e != nil {
+			return vendorResponse{Vendor: nil, Error: e}, nil
+		}
+		return vendorResponse{Vendor: &v}, nil
+	}
+}
+
+func MakeCreateKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createKeyRequest)
+		k, key, e := s.CreateKey(ctx, req.VendorID, req.Scopes)
+		if e != nil {
+			return createKeyResponse{Error: e}, nil
+		}
+		return createKeyResponse{APIKey: &k, Key: key, Scopes: k.Scopes(), Status: http.StatusCreated}, nil
+	}
+}
+
+func MakeRevokeKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeKeyRequest)
+		e := s.RevokeKey(ctx, req.VendorID, req.KeyID)
+		if e != nil {
+			return revokeKeyResponse{Error: e}, nil
+		}
+		return revokeKeyResponse{Message: "api key revoked"}, nil
+	}
+}
+
+func MakeBooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(vendorRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeCatalogWrite)
+		if e != nil {
+			return nil, e
+		}
+		books, e := s.Books(ctx, v.ID)
+		if e != nil {
+			return booksResponse{Books: make([]catalog.Book, 0), Error: e}, nil
+		}
+		return booksResponse{Books: books}, nil
+	}
+}
+
+func MakeSaveBookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(saveBookRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeCatalogWrite)
+		if e != nil {
+			return nil, e
+		}
+		b, e := s.SaveBook(ctx, v.ID, req.VendorBook)
+		if e != nil {
+			return bookResponse{Book: nil, Error: e}, nil
+		}
+		return bookResponse{Book: &b}, nil
+	}
+}
+
+func MakeUpdateStockEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateStockRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeInventoryWrite)
+		if e != nil {
+			return nil, e
+		}
+		b, e := s.UpdateStock(ctx, v.ID, req.BookID, req.Stock)
+		if e != nil {
+			return bookResponse{Book: nil, Error: e}, nil
+		}
+		return bookResponse{Book: &b}, nil
+	}
+}
+
+func MakeOrdersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(vendorRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeOrdersRead)
+		if e != nil {
+			return nil, e
+		}
+		orders, e := s.Orders(ctx, v.ID)
+		if e != nil {
+			return ordersResponse{Orders: make([]order.Order, 0), Error: e}, nil
+		}
+		return ordersResponse{Orders: orders}, nil
+	}
+}
+
+func MakeSubmitSyncEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(submitSyncRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeInventoryWrite)
+		if e != nil {
+			return nil, e
+		}
+		b, e := s.SubmitSync(ctx, v.ID, req.Source, req.Items)
+		if e != nil {
+			return syncBatchResponse{Batch: nil, Error: e}, nil
+		}
+		return syncBatchResponse{Batch: &b, Status: http.StatusAccepted}, nil
+	}
+}
+
+func MakeSyncBatchesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(vendorRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeInventoryWrite)
+		if e != nil {
+			return nil, e
+		}
+		batches, e := s.SyncBatches(ctx, v.ID)
+		if e != nil {
+			return syncBatchesResponse{Batches: make([]SyncBatch, 0), Error: e}, nil
+		}
+		return syncBatchesResponse{Batches: batches}, nil
+	}
+}
+
+func MakeSyncReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(syncReportRequest)
+		v, e := s.Authenticate(ctx, req.APIKey, ScopeInventoryWrite)
+		if e != nil {
+			return nil, e
+		}
+		report, e := s.SyncReport(ctx, v.ID, req.BatchID)
+		if e != nil {
+			return syncReportResponse{Report: nil, Error: e}, nil
+		}
+		return syncReportResponse{Report: &report}, nil
+	}
+}
+
+type registerRequest struct {
+	NewVendor
+}
+
+type vendorResponse struct {
+	Status int     `json:"-"`
+	Vendor *Vendor `json:"vendor,omitempty"`
+	Error  error   `json:"error,omitempty"`
+}
+
+func (r vendorResponse) status() int {
+	return r.Status
+}
+
+func (r vendorResponse) error() error {
+	return r.Error
+}
+
+type listRequest struct {
+	Status string      `json:"status"`
+	Filter filter.Expr `json:"-"`
+}
+
+type listResponse struct {
+	Vendors []Vendor `json:"vendors"`
+	Error   error    `json:"error,omitempty"`
+}
+
+func (r listResponse) error() error {
+	return r.Error
+}
+
+type reviewRequest struct {
+	VendorID string `json:"-"`
+	Approve  bool   `json:"approve"`
+	Note     string `json:"note"`
+}
+
+type createKeyRequest struct {
+	VendorID string   `json:"-"`
+	Scopes   []string `json:"scopes"`
+}
+
+type createKeyResponse struct {
+	Status int      `json:"-"`
+	APIKey *APIKey  `json:"api_key,omitempty"`
+	Key    string   `json:"key,omitempty"` // shown only once
+	Scopes []string `json:"scopes,omitempty"`
+	Error  error    `json:"error,omitempty"`
+}
+
+func (r createKeyResponse) status() int {
+	return r.Status
+}
+
+func (r createKeyResponse) error() error {
+	return r.Error
+}
+
+type revokeKeyRequest struct {
+	VendorID string `json:"-"`
+	KeyID    string `json:"-"`
+}
+
+type revokeKeyResponse struct {
+	Message string `json:"message,omitempty"`
+	Error   error  `json:"error,omitempty"`
+}
+
+func (r revokeKeyResponse) error() error {
+	return r.Error
+}
+
+// vendorRequest is any request authenticated by vendor's API key.
+type vendorRequest struct {
+	APIKey string `json:"-"` // We get from header
+}
+
+type saveBookRequest struct {
+	APIKey string `json:"-"`
+	VendorBook
+}
+
+type updateStockRequest struct {
+	APIKey string `json:"-"`
+	BookID string `json:"-"`
+	Stock  int    `json:"stock"`
+}
+
+type booksResponse struct {
+	Books []catalog.Book `json:"books"`
+	Error error          `json:"error,omitempty"`
+}
+
+func (r booksResponse) error() error {
+	return r.Error
+}
+
+type bookResponse struct {
+	Book  *catalog.Book `json:"book,omitempty"`
+	Error error         `json:"error,omitempty"`
+}
+
+func (r bookResponse) error() error {
+	return r.Error
+}
+
+type ordersResponse struct {
+	Orders []order.Order `json:"orders"`
+	Error  error         `json:"error,omitempty"`
+}
+
+func (r ordersResponse) error() error {
+	return r.Error
+}
+
+type submitSyncRequest struct {
+	APIKey string     `json:"-"`
+	Source string     `json:"-"`
+	Items  []SyncItem `json:"items"`
+}
+
+type syncBatchResponse struct {
+	Status int        `json:"-"`
+	Batch  *SyncBatch `json:"batch,omitempty"`
+	Error  error      `json:"error,omitempty"`
+}
+
+func (r syncBatchResponse) status() int {
+	return r.Status
+}
+
+func (r syncBatchResponse) error() error {
+	return r.Error
+}
+
+type syncBatchesResponse struct {
+	Batches []SyncBatch `json:"batches"`
+	Error   error       `json:"error,omitempty"`
+}
+
+func (r syncBatchesResponse) error() error {
+	return r.Error
+}
+
+type syncReportRequest struct {
+	APIKey  string
+	BatchID string
+}
+
+type syncReportResponse struct {
+	Report *SyncReport `json:"report,omitempty"`
+	Error  error       `json:"error,omitempty"`
+}
+
+func (r syncReportResponse) error() error {
+	return r.Error
+}
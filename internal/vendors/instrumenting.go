@@ -0,0 +1,194 @@
+package vendors
+
+import (
+	"fmt"
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+)
+
+type instrmw struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           Service
+}
+
+func InstrumentingMiddleware(counter metrics.Counter, latency metrics.Histogram) Middleware {
+	return func(next Service) Service {
+		return instrmw{
+			requestCount:   counter,
+			requestLatency: latency,
+			next:           next,
+		}
+	}
+}
+
+func (mw instrmw) Register(ctx context.Context, nv NewVendor) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "register", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	vendor, err = mw.next.Register(ctx, nv)
+	return
+}
+
+func (mw instrmw) List(ctx context.Context, status string, f filter.Expr) (list []Vendor, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "list", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	list, err = mw.next.List(ctx, status, f)
+	return
+}
+
+func (mw instrmw) Review(ctx context.Context, vendorID string, approve bool, note string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "review", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	vendor, err = mw.next.Review(ctx, vendorID, approve, note)
+	return
+}
+
+func (mw instrmw) Suspend(ctx context.Context, vendorID, note string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "suspend", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	vendor, err = mw.next.Suspend(ctx, vendorID, note)
+	return
+}
+
+func (mw instrmw) CreateKey(ctx context.Context, vendorID string, scopes []string) (apiKey APIKey, plain string, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "create_key", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	apiKey, plain, err = mw.next.CreateKey(ctx, vendorID, scopes)
+	return
+}
+
+func (mw instrmw) RevokeKey(ctx context.Context, vendorID, keyID string) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "revoke_key", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.RevokeKey(ctx, vendorID, keyID)
+	return
+}
+
+func (mw instrmw) Authenticate(ctx context.Context, key, scope string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "authenticate", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	vendor, err = mw.next.Authenticate(ctx, key, scope)
+	return
+}
+
+func (mw instrmw) Books(ctx context.Context, vendorID string) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "books", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	books, err = mw.next.Books(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) SaveBook(ctx context.Context, vendorID string, book VendorBook) (saved catalog.Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "save_book", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	saved, err = mw.next.SaveBook(ctx, vendorID, book)
+	return
+}
+
+func (mw instrmw) UpdateStock(ctx context.Context, vendorID, bookID string, stock int) (book catalog.Book, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "update_stock", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	book, err = mw.next.UpdateStock(ctx, vendorID, bookID, stock)
+	return
+}
+
+func (mw instrmw) Orders(ctx context.Context, vendorID string) (orders []order.Order, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "orders", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	orders, err = mw.next.Orders(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) SubmitSync(ctx context.Context, vendorID, source string, items []SyncItem) (batch SyncBatch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "submit_sync", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batch, err = mw.next.SubmitSync(ctx, vendorID, source, items)
+	return
+}
+
+func (mw instrmw) SyncReport(ctx context.Context, vendorID, batchID string) (report SyncReport, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sync_report", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	report, err = mw.next.SyncReport(ctx, vendorID, batchID)
+	return
+}
+
+func (mw instrmw) SyncBatches(ctx context.Context, vendorID string) (batches []SyncBatch, err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "sync_batches", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	batches, err = mw.next.SyncBatches(ctx, vendorID)
+	return
+}
+
+func (mw instrmw) ProcessSync(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		lvs := []string{"method", "process_sync", "error", fmt.Sprint(err != nil)}
+		mw.requestCount.With(lvs...).Add(1)
+		mw.requestLatency.With(lvs...).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	err = mw.next.ProcessSync(ctx)
+	return
+}
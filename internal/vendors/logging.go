@@ -0,0 +1,191 @@
+package vendors
+
+import (
+	"time"
+
+	"context"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+)
+
+type loggingService struct {
+	logger log.Logger
+	next   Service
+}
+
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next Service) Service {
+		return loggingService{
+			logger: logger,
+			next:   next,
+		}
+	}
+}
+
+func (s loggingService) Register(ctx context.Context, nv NewVendor) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "register",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Register(ctx, nv)
+}
+
+func (s loggingService) List(ctx context.Context, status string, f filter.Expr) (list []Vendor, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.List(ctx, status, f)
+}
+
+func (s loggingService) Review(ctx context.Context, vendorID string, approve bool, note string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "review",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Review(ctx, vendorID, approve, note)
+}
+
+func (s loggingService) Suspend(ctx context.Context, vendorID, note string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "suspend",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Suspend(ctx, vendorID, note)
+}
+
+func (s loggingService) CreateKey(ctx context.Context, vendorID string, scopes []string) (apiKey APIKey, plain string, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_key",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.CreateKey(ctx, vendorID, scopes)
+}
+
+func (s loggingService) RevokeKey(ctx context.Context, vendorID, keyID string) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "revoke_key",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.RevokeKey(ctx, vendorID, keyID)
+}
+
+func (s loggingService) Authenticate(ctx context.Context, key, scope string) (vendor Vendor, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "authenticate",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Authenticate(ctx, key, scope)
+}
+
+func (s loggingService) Books(ctx context.Context, vendorID string) (books []catalog.Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "books",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Books(ctx, vendorID)
+}
+
+func (s loggingService) SaveBook(ctx context.Context, vendorID string, book VendorBook) (saved catalog.Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "save_book",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SaveBook(ctx, vendorID, book)
+}
+
+func (s loggingService) UpdateStock(ctx context.Context, vendorID, bookID string, stock int) (book catalog.Book, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "update_stock",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.UpdateStock(ctx, vendorID, bookID, stock)
+}
+
+func (s loggingService) Orders(ctx context.Context, vendorID string) (orders []order.Order, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "orders",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.Orders(ctx, vendorID)
+}
+
+func (s loggingService) SubmitSync(ctx context.Context, vendorID, source string, items []SyncItem) (batch SyncBatch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "submit_sync",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SubmitSync(ctx, vendorID, source, items)
+}
+
+func (s loggingService) SyncReport(ctx context.Context, vendorID, batchID string) (report SyncReport, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sync_report",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SyncReport(ctx, vendorID, batchID)
+}
+
+func (s loggingService) SyncBatches(ctx context.Context, vendorID string) (batches []SyncBatch, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "sync_batches",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.SyncBatches(ctx, vendorID)
+}
+
+func (s loggingService) ProcessSync(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "process_sync",
+			"err", err,
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+	return s.next.ProcessSync(ctx)
+}
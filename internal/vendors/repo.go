@@ -0,0 +1,26 @@
+package vendors
+
+import "github.com/kavirajk/bookshop/filter"
+
+// Repo abstracts all the persistant storage operations of Vendor Service
+type Repo interface {
+	Create(v *Vendor) error
+	Save(v *Vendor) error
+	GetByID(ID string) (Vendor, error)
+	GetByEmail(email string) (Vendor, error)
+	List(status string, f filter.Expr) ([]Vendor, error)
+	CreateKey(k *APIKey) error
+	SaveKey(k *APIKey) error
+	GetKeyByPrefix(prefix string) (APIKey, error)
+	ListKeys(vendorID string) ([]APIKey, error)
+	CreateBatch(b *SyncBatch) error
+	SaveBatch(b *SyncBatch) error
+	GetBatch(ID string) (SyncBatch, error)
+	ListBatches(vendorID string) ([]SyncBatch, error)
+	// ListPendingBatches returns the queued batches and the ones left in
+	// processing, oldest first.
+	ListPendingBatches() ([]SyncBatch, error)
+	CreateSyncErrors(errs []SyncError) error
+	ListSyncErrors(batchID string) ([]SyncError, error)
+	Drop() error
+}
@@ -0,0 +1,441 @@
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/order"
+)
+
+var (
+	ErrVendorNotFound   = errors.New("vendor not found")
+	ErrAlreadyExists    = errors.New("vendor already registered")
+	ErrNotApproved      = errors.New("vendor is not approved")
+	ErrAlreadyReviewed  = errors.New("vendor is already reviewed")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("api key is not granted the scope")
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrKeyNotFound      = errors.New("api key not found")
+	ErrInvalidStock     = errors.New("stock can't be negative")
+	ErrNotVendorBook    = errors.New("book doesn't belong to the vendor")
+	ErrInvalidBookField = errors.New("missing isbn or title")
+	ErrEmptyBatch       = errors.New("sync batch has no items")
+	ErrBatchTooLarge    = errors.New("sync batch has too many items")
+	ErrBatchNotFound    = errors.New("sync batch not found")
+	ErrNothingToSync    = errors.New("neither stock nor price given")
+	ErrInvalidPrice     = errors.New("price can't be negative")
+)
+
+type Service interface {
+	// Register signs up a new vendor, waiting for approval.
+	Register(ctx context.Context, nv NewVendor) (Vendor, error)
+
+	// List vendors in the given status, all of them if status is empty,
+	// filtered by f on ListFields.
+	List(ctx context.Context, status string, f filter.Expr) ([]Vendor, error)
+
+	// Review approves or rejects a pending vendor.
+	Review(ctx context.Context, vendorID string, approve bool, note string) (Vendor, error)
+
+	// Suspend blocks an approved vendor, all its keys stop working.
+	Suspend(ctx context.Context, vendorID, note string) (Vendor, error)
+
+	// CreateKey issues new API key to an approved vendor. Plain key is only
+	// returned here, it is not possible to get it again.
+	CreateKey(ctx context.Context, vendorID string, scopes []string) (APIKey, string, error)
+
+	// RevokeKey revokes an API key of the vendor.
+	RevokeKey(ctx context.Context, vendorID, keyID string) error
+
+	// Authenticate returns the vendor owning the key if the key is granted scope.
+	Authenticate(ctx context.Context, key, scope string) (Vendor, error)
+
+	// Books lists the vendor's own catalog.
+	Books(ctx context.Context, vendorID string) ([]catalog.Book, error)
+
+	// SaveBook creates or updates a book in the vendor's own catalog.
+	SaveBook(ctx context.Context, vendorID string, book VendorBook) (catalog.Book, error)
+
+	// UpdateStock sets the stock of the vendor's book.
+	UpdateStock(ctx context.Context, vendorID, bookID string, stock int) (catalog.Book, error)
+
+	// Orders lists orders containing any of the vendor's books.
+	Orders(ctx context.Context, vendorID string) ([]order.Order, error)
+
+	// SubmitSync queues a bulk stock and price update of the vendor's books.
+	SubmitSync(ctx context.Context, vendorID, source string, items []SyncItem) (SyncBatch, error)
+
+	// SyncReport returns the batch with the items failed to apply.
+	SyncReport(ctx context.Context, vendorID, batchID string) (SyncReport, error)
+
+	// SyncBatches lists the sync batches of the vendor, latest first.
+	SyncBatches(ctx context.Context, vendorID string) ([]SyncBatch, error)
+
+	// ProcessSync applies all the queued sync batches.
+	ProcessSync(ctx context.Context) error
+}
+
+type basicService struct {
+	r       Repo
+	catalog catalog.Repo
+	orders  order.Repo
+}
+
+// NewService return basic Service implementation.
+func NewService(r Repo, catalog catalog.Repo, orders order.Repo) Service {
+	return basicService{r: r, catalog: catalog, orders: orders}
+}
+
+// Register signs up a new vendor, waiting for approval.
+func (s basicService) Register(ctx context.Context, nv NewVendor) (Vendor, error) {
+	if err := nv.Validate(); err != nil {
+		return Vendor{}, err
+	}
+	email := strings.ToLower(strings.TrimSpace(nv.Email))
+	if _, err := s.r.GetByEmail(email); err == nil {
+		return Vendor{}, ErrAlreadyExists
+	}
+	v := Vendor{
+		Name:      strings.TrimSpace(nv.Name),
+		Email:     email,
+		Status:    StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.Create(&v); err != nil {
+		return Vendor{}, err
+	}
+	return v, nil
+}
+
+// List vendors in the given status.
+func (s basicService) List(ctx context.Context, status string, f filter.Expr) ([]Vendor, error) {
+	return s.r.List(status, f)
+}
+
+// Review approves or rejects a pending vendor.
+func (s basicService) Review(ctx context.Context, vendorID string, approve bool, note string) (Vendor, error) {
+	v, err := s.r.GetByID(vendorID)
+	if err != nil {
+		return Vendor{}, ErrVendorNotFound
+	}
+	if v.Status != StatusPending {
+		return Vendor{}, ErrAlreadyReviewed
+	}
+	now := time.Now().UTC()
+	v.Status = StatusRejected
+	if approve {
+		v.Status = StatusApproved
+	}
+	v.ReviewNote = note
+	v.ReviewedAt = &now
+	if err := s.r.Save(&v); err != nil {
+		return Vendor{}, err
+	}
+	return v, nil
+}
+
+// Suspend blocks an approved vendor.
+func (s basicService) Suspend(ctx context.Context, vendorID, note string) (Vendor, error) {
+	v, err := s.r.GetByID(vendorID)
+	if err != nil {
+		return Vendor{}, ErrVendorNotFound
+	}
+	if v.Status != StatusApproved {
+		return Vendor{}, ErrNotApproved
+	}
+	v.Status = StatusSuspended
+	v.ReviewNote = note
+	if err := s.r.Save(&v); err != nil {
+		return Vendor{}, err
+	}
+	return v, nil
+}
+
+// CreateKey issues new API key to an approved vendor.
+func (s basicService) CreateKey(ctx context.Context, vendorID string, scopes []string) (APIKey, string, error) {
+	v, err := s.r.GetByID(vendorID)
+	if err != nil {
+		return APIKey{}, "", ErrVendorNotFound
+	}
+	if v.Status != StatusApproved {
+		return APIKey{}, "", ErrNotApproved
+	}
+	if len(scopes) == 0 {
+		return APIKey{}, "", ErrInvalidScope
+	}
+	for _, sc := range scopes {
+		if !validScope(sc) {
+			return APIKey{}, "", ErrInvalidScope
+		}
+	}
+	key, prefix, err := newKey()
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	k := APIKey{
+		VendorID:  v.ID,
+		Prefix:    prefix,
+		Hash:      hashKey(key),
+		ScopeList: strings.Join(scopes, ","),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.CreateKey(&k); err != nil {
+		return APIKey{}, "", err
+	}
+	return k, key, nil
+}
+
+// RevokeKey revokes an API key of the vendor.
+func (s basicService) RevokeKey(ctx context.Context, vendorID, keyID string) error {
+	keys, err := s.r.ListKeys(vendorID)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.ID != keyID {
+			continue
+		}
+		if k.RevokedAt != nil {
+			return nil
+		}
+		now := time.Now().UTC()
+		k.RevokedAt = &now
+		return s.r.SaveKey(&k)
+	}
+	return ErrKeyNotFound
+}
+
+// Authenticate returns the vendor owning the key if the key is granted scope.
+func (s basicService) Authenticate(ctx context.Context, key, scope string) (Vendor, error) {
+	prefix, ok := parseKey(key)
+	if !ok {
+		return Vendor{}, ErrUnauthorized
+	}
+	k, err := s.r.GetKeyByPrefix(prefix)
+	if err != nil || k.RevokedAt != nil || !matchKey(k, key) {
+		return Vendor{}, ErrUnauthorized
+	}
+	v, err := s.r.GetByID(k.VendorID)
+	if err != nil {
+		return Vendor{}, ErrUnauthorized
+	}
+	if v.Status != StatusApproved {
+		return Vendor{}, ErrNotApproved
+	}
+	if !k.Allows(scope) {
+		return Vendor{}, ErrForbidden
+	}
+	return v, nil
+}
+
+// Books lists the vendor's own catalog.
+func (s basicService) Books(ctx context.Context, vendorID string) ([]catalog.Book, error) {
+	return s.catalog.ListByVendor(vendorID)
+}
+
+// SaveBook creates or updates a book in the vendor's own catalog. Only the
+// fields of VendorBook are changed on a stored book, vendors can't take
+// over books of the shop or the other vendors.
+func (s basicService) SaveBook(ctx context.Context, vendorID string, vb VendorBook) (catalog.Book, error) {
+	if vb.ISBN == "" || vb.Title == "" {
+		return catalog.Book{}, ErrInvalidBookField
+	}
+	if vb.Stock < 0 {
+		return catalog.Book{}, ErrInvalidStock
+	}
+	if vb.Price < 0 {
+		return catalog.Book{}, ErrInvalidPrice
+	}
+	if vb.ID == "" {
+		book := catalog.Book{VendorID: vendorID}
+		vb.apply(&book)
+		if err := s.catalog.Create(&book); err != nil {
+			return catalog.Book{}, err
+		}
+		return book, nil
+	}
+	book, err := s.catalog.GetByID(vb.ID)
+	if err != nil {
+		return catalog.Book{}, catalog.ErrBookNotFound
+	}
+	if book.VendorID != vendorID {
+		return catalog.Book{}, ErrNotVendorBook
+	}
+	vb.apply(&book)
+	if vb.Version != 0 {
+		book.Version = vb.Version
+	}
+	if err := s.catalog.Save(&book); err != nil {
+		return catalog.Book{}, err
+	}
+	return book, nil
+}
+
+// UpdateStock sets the stock of the vendor's book.
+func (s basicService) UpdateStock(ctx context.Context, vendorID, bookID string, stock int) (catalog.Book, error) {
+	if stock < 0 {
+		return catalog.Book{}, ErrInvalidStock
+	}
+	b, err := s.catalog.GetByID(bookID)
+	if err != nil {
+		return catalog.Book{}, catalog.ErrBookNotFound
+	}
+	if b.VendorID != vendorID {
+		return catalog.Book{}, ErrNotVendorBook
+	}
+	b.Stock = stock
+	if err := s.catalog.Save(&b); err != nil {
+		return catalog.Book{}, err
+	}
+	return b, nil
+}
+
+// Orders lists orders containing any of the vendor's books.
+func (s basicService) Orders(ctx context.Context, vendorID string) ([]order.Order, error) {
+	return s.orders.ListByVendor(vendorID)
+}
+
+// SubmitSync queues a bulk stock and price update of the vendor's books.
+// Items are applied in the background by ProcessSync.
+func (s basicService) SubmitSync(ctx context.Context, vendorID, source string, items []SyncItem) (SyncBatch, error) {
+	v, err := s.r.GetByID(vendorID)
+	if err != nil {
+		return SyncBatch{}, ErrVendorNotFound
+	}
+	if v.Status != StatusApproved {
+		return SyncBatch{}, ErrNotApproved
+	}
+	if len(items) == 0 {
+		return SyncBatch{}, ErrEmptyBatch
+	}
+	if len(items) > MaxSyncItems {
+		return SyncBatch{}, ErrBatchTooLarge
+	}
+	payload, err := json.Marshal(items)
+	if err != nil {
+		return SyncBatch{}, err
+	}
+	b := SyncBatch{
+		VendorID:  vendorID,
+		Source:    source,
+		Status:    BatchQueued,
+		Total:     len(items),
+		Payload:   string(payload),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.r.CreateBatch(&b); err != nil {
+		return SyncBatch{}, err
+	}
+	return b, nil
+}
+
+// SyncReport returns the batch with the items failed to apply.
+func (s basicService) SyncReport(ctx context.Context, vendorID, batchID string) (SyncReport, error) {
+	b, err := s.r.GetBatch(batchID)
+	if err != nil || b.VendorID != vendorID {
+		return SyncReport{}, ErrBatchNotFound
+	}
+	errs, err := s.r.ListSyncErrors(batchID)
+	if err != nil {
+		return SyncReport{}, err
+	}
+	return SyncReport{SyncBatch: b, Errors: errs}, nil
+}
+
+// SyncBatches lists the sync batches of the vendor, latest first.
+func (s basicService) SyncBatches(ctx context.Context, vendorID string) ([]SyncBatch, error) {
+	return s.r.ListBatches(vendorID)
+}
+
+// ProcessSync applies all the queued sync batches, oldest first.
+func (s basicService) ProcessSync(ctx context.Context) error {
+	batches, err := s.r.ListPendingBatches()
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if err := s.process(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process applies the items of the batch. Items set absolute values, so a
+// batch interrupted in processing is safe to apply again.
+func (s basicService) process(b SyncBatch) error {
+	b.Status = BatchProcessing
+	if err := s.r.SaveBatch(&b); err != nil {
+		return err
+	}
+	var items []SyncItem
+	if err := json.Unmarshal([]byte(b.Payload), &items); err != nil {
+		return err
+	}
+	books, err := s.catalog.ListByVendor(b.VendorID)
+	if err != nil {
+		return err
+	}
+	byISBN := make(map[string]int, len(books))
+	for i, book := range books {
+		if _, ok := byISBN[book.ISBN]; !ok {
+			byISBN[book.ISBN] = i
+		}
+	}
+
+	errs := make([]SyncError, 0)
+	fail := func(line int, item SyncItem, err error) {
+		errs = append(errs, SyncError{BatchID: b.ID, Line: line, ISBN: item.ISBN, Error: err.Error()})
+	}
+	b.Updated = 0
+	for i, item := range items {
+		line := i + 1
+		idx, ok := byISBN[item.ISBN]
+		switch {
+		case item.Stock == nil && item.Price == nil:
+			fail(line, item, ErrNothingToSync)
+			continue
+		case item.Stock != nil && *item.Stock < 0:
+			fail(line, item, ErrInvalidStock)
+			continue
+		case item.Price != nil && *item.Price < 0:
+			fail(line, item, ErrInvalidPrice)
+			continue
+		case !ok:
+			fail(line, item, catalog.ErrBookNotFound)
+			continue
+		}
+		book := &books[idx]
+		if item.Stock != nil {
+			book.Stock = *item.Stock
+		}
+		if item.Price != nil {
+			book.Price = *item.Price
+		}
+		if err := s.catalog.Save(book); err != nil {
+			fail(line, item, err)
+			continue
+		}
+		b.Updated++
+	}
+
+	if len(errs) > 0 {
+		if err := s.r.CreateSyncErrors(errs); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	b.Failed = len(errs)
+	b.Status = BatchDone
+	b.Payload = ""
+	b.FinishedAt = &now
+	return s.r.SaveBatch(&b)
+}
+
+// Middleware is a service middleware that takes service return service
+type Middleware func(Service) Service
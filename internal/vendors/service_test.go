@@ -0,0 +1,234 @@
+package vendors_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/user"
+	"github.com/kavirajk/bookshop/vendors"
+)
+
+// vendorRepo keeps the vendors and their keys in memory, the other methods
+// of the Repo aren't used.
+type vendorRepo struct {
+	vendors.Repo
+	vendors map[string]vendors.Vendor
+	keys    []vendors.APIKey
+}
+
+func (r *vendorRepo) GetByID(ID string) (vendors.Vendor, error) {
+	v, ok := r.vendors[ID]
+	if !ok {
+		return vendors.Vendor{}, db.ErrNotFound
+	}
+	return v, nil
+}
+
+func (r *vendorRepo) Save(v *vendors.Vendor) error {
+	r.vendors[v.ID] = *v
+	return nil
+}
+
+func (r *vendorRepo) List(status string, f filter.Expr) ([]vendors.Vendor, error) {
+	var out []vendors.Vendor
+	for _, v := range r.vendors {
+		if status == "" || v.Status == status {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (r *vendorRepo) CreateKey(k *vendors.APIKey) error {
+	k.ID = k.Prefix
+	r.keys = append(r.keys, *k)
+	return nil
+}
+
+func (r *vendorRepo) SaveKey(k *vendors.APIKey) error {
+	for i := range r.keys {
+		if r.keys[i].ID == k.ID {
+			r.keys[i] = *k
+		}
+	}
+	return nil
+}
+
+func (r *vendorRepo) GetKeyByPrefix(prefix string) (vendors.APIKey, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return k, nil
+		}
+	}
+	return vendors.APIKey{}, db.ErrNotFound
+}
+
+func (r *vendorRepo) ListKeys(vendorID string) ([]vendors.APIKey, error) {
+	var out []vendors.APIKey
+	for _, k := range r.keys {
+		if k.VendorID == vendorID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// catalogRepo keeps the books in memory, the other methods of the Repo
+// aren't used.
+type catalogRepo struct {
+	catalog.Repo
+	books map[string]catalog.Book
+}
+
+func (r *catalogRepo) GetByID(ID string) (catalog.Book, error) {
+	b, ok := r.books[ID]
+	if !ok {
+		return catalog.Book{}, db.ErrNotFound
+	}
+	return b, nil
+}
+
+func (r *catalogRepo) Create(b *catalog.Book) error {
+	b.ID = "new"
+	r.books[b.ID] = *b
+	return nil
+}
+
+func (r *catalogRepo) Save(b *catalog.Book) error {
+	if r.books[b.ID].Version != b.Version {
+		return db.ErrConflict
+	}
+	b.Version++
+	r.books[b.ID] = *b
+	return nil
+}
+
+func newRepos() (*vendorRepo, *catalogRepo) {
+	return &vendorRepo{vendors: map[string]vendors.Vendor{
+		"v1": {ID: "v1", Status: vendors.StatusApproved},
+		"v2": {ID: "v2", Status: vendors.StatusApproved},
+		"v3": {ID: "v3", Status: vendors.StatusPending},
+	}}, &catalogRepo{books: map[string]catalog.Book{
+		"b1": {ID: "b1", ISBN: "978-1", Title: "Own", VendorID: "v1", Location: "B-12-3", Delisted: true, Visibility: catalog.VisibilityRetired, Version: 2},
+		"b2": {ID: "b2", ISBN: "978-2", Title: "Other", VendorID: "v2", Stock: 5},
+		"b3": {ID: "b3", ISBN: "978-3", Title: "Shop", Stock: 5},
+	}}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	r, books := newRepos()
+	s := vendors.NewService(r, books, nil)
+
+	_, key, err := s.CreateKey(ctx, "v1", []string{vendors.ScopeCatalogWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedKey, _ := s.CreateKey(ctx, "v1", []string{vendors.ScopeCatalogWrite})
+	if err := s.RevokeKey(ctx, "v1", revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, suspendedKey, _ := s.CreateKey(ctx, "v2", []string{vendors.ScopeCatalogWrite})
+	if _, err := s.Suspend(ctx, "v2", "chargebacks"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.CreateKey(ctx, "v3", []string{vendors.ScopeCatalogWrite}); err != vendors.ErrNotApproved {
+		t.Errorf("pending vendor: expected ErrNotApproved, got %v", err)
+	}
+
+	v, err := s.Authenticate(ctx, key, vendors.ScopeCatalogWrite)
+	if err != nil || v.ID != "v1" {
+		t.Errorf("valid key: expected vendor v1, got %+v, %v", v, err)
+	}
+	cases := []struct {
+		name, key, scope string
+		expected         error
+	}{
+		{"other scope", key, vendors.ScopeOrdersRead, vendors.ErrForbidden},
+		{"malformed", "secret", vendors.ScopeCatalogWrite, vendors.ErrUnauthorized},
+		{"wrong secret", key[:len(key)-1] + "x", vendors.ScopeCatalogWrite, vendors.ErrUnauthorized},
+		{"revoked", revokedKey, vendors.ScopeCatalogWrite, vendors.ErrUnauthorized},
+		{"suspended vendor", suspendedKey, vendors.ScopeCatalogWrite, vendors.ErrNotApproved},
+	}
+	for _, c := range cases {
+		if _, err := s.Authenticate(ctx, c.key, c.scope); err != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestVendorBooks(t *testing.T) {
+	ctx := context.Background()
+	r, books := newRepos()
+	s := vendors.NewService(r, books, nil)
+
+	for _, id := range []string{"b2", "b3"} {
+		if _, err := s.SaveBook(ctx, "v1", vendors.VendorBook{ID: id, ISBN: "978-9", Title: "Taken"}); err != vendors.ErrNotVendorBook {
+			t.Errorf("save %s: expected ErrNotVendorBook, got %v", id, err)
+		}
+		if _, err := s.UpdateStock(ctx, "v1", id, 0); err != vendors.ErrNotVendorBook {
+			t.Errorf("stock of %s: expected ErrNotVendorBook, got %v", id, err)
+		}
+		if b := books.books[id]; b.Title == "Taken" || b.Stock != 5 {
+			t.Errorf("%s changed by another vendor: %+v", id, b)
+		}
+	}
+
+	b, err := s.SaveBook(ctx, "v1", vendors.VendorBook{ID: "b1", ISBN: "978-1", Title: "Renamed", Price: 9.5, Stock: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Title != "Renamed" || b.Price != 9.5 || b.Stock != 3 || b.Version != 3 {
+		t.Errorf("expected the editable fields saved, got %+v", b)
+	}
+	if b.VendorID != "v1" || b.Location != "B-12-3" || !b.Delisted || b.Visibility != catalog.VisibilityRetired {
+		t.Errorf("expected the shop fields kept, got %+v", b)
+	}
+	if _, err := s.SaveBook(ctx, "v1", vendors.VendorBook{ID: "b1", ISBN: "978-1", Title: "Stale", Version: 1}); err != db.ErrConflict {
+		t.Errorf("stale version: expected ErrConflict, got %v", err)
+	}
+
+	b, err = s.SaveBook(ctx, "v2", vendors.VendorBook{ISBN: "978-4", Title: "New"})
+	if err != nil || b.VendorID != "v2" {
+		t.Errorf("new book: expected vendor v2, got %+v, %v", b, err)
+	}
+}
+
+func TestListRequiresAdmin(t *testing.T) {
+	r, books := newRepos()
+	s := vendors.NewService(r, books, nil)
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	admin := endpoint.Chain(auth.NewMiddleware(tokens), rbac.RequireRole(user.RoleAdmin))
+	h := vendors.MakeHTTPHandler(context.Background(), s, admin, log.NewNopLogger())
+	customer, _, _ := tokens.Sign("u1", user.RoleCustomer, "")
+	staff, _, _ := tokens.Sign("u9", user.RoleAdmin, "")
+
+	for _, c := range []struct {
+		name, token string
+		status      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"customer", customer, http.StatusForbidden},
+		{"admin", staff, http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/vendors/v1/admin/list", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.status, w.Code, w.Body)
+		}
+	}
+}
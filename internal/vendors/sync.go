@@ -0,0 +1,135 @@
+package vendors
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMalformedCSV = errors.New("malformed csv")
+)
+
+// MaxSyncItems is the maximum number of items accepted in a single batch.
+const MaxSyncItems = 50000
+
+const (
+	SourceAPI = "api"
+	SourceCSV = "csv"
+)
+
+const (
+	BatchQueued     = "queued"
+	BatchProcessing = "processing"
+	BatchDone       = "done"
+)
+
+// SyncItem updates stock and/or price of a vendor book identified by ISBN.
+// Nil fields are left unchanged.
+type SyncItem struct {
+	ISBN  string   `json:"isbn"`
+	Stock *int     `json:"stock,omitempty"`
+	Price *float64 `json:"price,omitempty"`
+}
+
+// SyncBatch is a bulk update pushed by a vendor, processed in the
+// background.
+type SyncBatch struct {
+	ID         string     `json:"id"`
+	VendorID   string     `json:"vendor_id"`
+	Source     string     `json:"source"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Updated    int        `json:"updated"`
+	Failed     int        `json:"failed"`
+	Payload    string     `json:"-" sql:"type:text"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (SyncBatch) TableName() string {
+	return "vendor_sync_batches"
+}
+
+// SyncError reports an item of the batch which couldn't be applied.
+// Line is the 1-based position of the item in the batch.
+type SyncError struct {
+	ID      string `json:"-"`
+	BatchID string `json:"-"`
+	Line    int    `json:"line"`
+	ISBN    string `json:"isbn"`
+	Error   string `json:"error"`
+}
+
+func (SyncError) TableName() string {
+	return "vendor_sync_errors"
+}
+
+// SyncReport is the result of a batch.
+type SyncReport struct {
+	SyncBatch
+	Errors []SyncError `json:"errors"`
+}
+
+// ParseCSV reads sync items from CSV with the header isbn,stock,price.
+// stock and price columns are optional and empty cells are left unchanged.
+func ParseCSV(r io.Reader) ([]SyncItem, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformedCSV, "header")
+	}
+	cols := map[string]int{"isbn": -1, "stock": -1, "price": -1}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, ok := cols[h]; ok {
+			cols[h] = i
+		}
+	}
+	if cols["isbn"] < 0 {
+		return nil, errors.Wrap(ErrMalformedCSV, "missing isbn column")
+	}
+
+	cell := func(rec []string, col string) string {
+		i := cols[col]
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	items := make([]SyncItem, 0)
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(ErrMalformedCSV, err.Error())
+		}
+		item := SyncItem{ISBN: cell(rec, "isbn")}
+		if v := cell(rec, "stock"); v != "" {
+			stock, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, errors.Wrap(ErrMalformedCSV, fmt.Sprintf("line %d: stock", line))
+			}
+			item.Stock = &stock
+		}
+		if v := cell(rec, "price"); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, errors.Wrap(ErrMalformedCSV, fmt.Sprintf("line %d: price", line))
+			}
+			item.Price = &price
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,40 @@
+package vendors_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/vendors"
+	"github.com/pkg/errors"
+)
+
+func TestParseCSV(t *testing.T) {
+	in := "ISBN,price,stock\n978-1,12.50,3\n978-2,,0\n978-3,9.99,\n"
+	items, err := vendors.ParseCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	if items[0].ISBN != "978-1" || *items[0].Stock != 3 || *items[0].Price != 12.50 {
+		t.Errorf("unexpected first item: %+v", items[0])
+	}
+	if items[1].Price != nil || items[1].Stock == nil || *items[1].Stock != 0 {
+		t.Errorf("expected only stock 0 on second item: %+v", items[1])
+	}
+	if items[2].Stock != nil || *items[2].Price != 9.99 {
+		t.Errorf("expected only price on third item: %+v", items[2])
+	}
+
+	cases := []string{
+		"",
+		"sku,stock\n1,2\n",
+		"isbn,stock\n978-1,many\n",
+	}
+	for _, c := range cases {
+		if _, err := vendors.ParseCSV(strings.NewReader(c)); errors.Cause(err) != vendors.ErrMalformedCSV {
+			t.Errorf("ParseCSV(%q): expected %v, got %v", c, vendors.ErrMalformedCSV, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package vendors
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// RunSyncWorker processes the queued sync batches every interval until ctx
+// is done.
+func RunSyncWorker(ctx context.Context, s Service, interval time.Duration, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ProcessSync(ctx); err != nil {
+				logger.Log("worker", "vendor_sync", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package vendors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/csrf"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/rbac"
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBadRouting = errors.New("bad routing")
+)
+
+// MakeHTTPHandler mounts the vendor endpoints, the shop admin ones served to
+// the requests admin lets through, e.g. auth.NewMiddleware chained with
+// rbac.RequireRole.
+func MakeHTTPHandler(ctx context.Context, s Service, admin endpoint.Middleware, logger log.Logger) http.Handler {
+	e := MakeEndpoints(s, admin)
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeError),
+		httptransport.ServerBefore(i18n.PopulateLocale),
+		httptransport.ServerBefore(transport.PopulateRaw),
+		httptransport.ServerBefore(transport.PopulateFieldCase),
+		httptransport.ServerBefore(auth.PopulateToken),
+		httptransport.ServerBefore(csrf.Populate),
+	}
+	registerHandler := httptransport.NewServer(
+		e.RegisterEndpoint,
+		decodeRegisterRequest,
+		encodeResponse,
+		options...,
+	)
+	listHandler := httptransport.NewServer(
+		e.ListEndpoint,
+		decodeListRequest,
+		encodeResponse,
+		options...,
+	)
+	reviewHandler := httptransport.NewServer(
+		e.ReviewEndpoint,
+		decodeReviewRequest,
+		encodeResponse,
+		options...,
+	)
+	suspendHandler := httptransport.NewServer(
+		e.SuspendEndpoint,
+		decodeReviewRequest,
+		encodeResponse,
+		options...,
+	)
+	createKeyHandler := httptransport.NewServer(
+		e.CreateKeyEndpoint,
+		decodeCreateKeyRequest,
+		encodeResponse,
+		options...,
+	)
+	revokeKeyHandler := httptransport.NewServer(
+		e.RevokeKeyEndpoint,
+		decodeRevokeKeyRequest,
+		encodeResponse,
+		options...,
+	)
+	booksHandler := httptransport.NewServer(
+		e.BooksEndpoint,
+		decodeVendorRequest,
+		encodeResponse,
+		options...,
+	)
+	saveBookHandler := httptransport.NewServer(
+		e.SaveBookEndpoint,
+		decodeSaveBookRequest,
+		encodeResponse,
+		options...,
+	)
+	updateStockHandler := httptransport.NewServer(
+		e.UpdateStockEndpoint,
+		decodeUpdateStockRequest,
+		encodeResponse,
+		options...,
+	)
+	ordersHandler := httptransport.NewServer(
+		e.OrdersEndpoint,
+		decodeVendorRequest,
+		encodeResponse,
+		options...,
+	)
+	submitSyncHandler := httptransport.NewServer(
+		e.SubmitSyncEndpoint,
+		decodeSubmitSyncRequest,
+		encodeResponse,
+		options...,
+	)
+	syncBatchesHandler := httptransport.NewServer(
+		e.SyncBatchesEndpoint,
+		decodeVendorRequest,
+		encodeResponse,
+		options...,
+	)
+	syncReportHandler := httptransport.NewServer(
+		e.SyncReportEndpoint,
+		decodeSyncReportRequest,
+		encodeResponse,
+		options...,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/vendors/v1/register", registerHandler).Methods("POST")
+
+	// Shop admin endpoints
+	r.Handle("/vendors/v1/admin/list", listHandler).Methods("GET")
+	r.Handle("/vendors/v1/admin/{vendor-id}/review", reviewHandler).Methods("POST")
+	r.Handle("/vendors/v1/admin/{vendor-id}/suspend", suspendHandler).Methods("POST")
+	r.Handle("/vendors/v1/admin/{vendor-id}/keys", createKeyHandler).Methods("POST")
+	r.Handle("/vendors/v1/admin/{vendor-id}/keys/{key-id}", revokeKeyHandler).Methods("DELETE")
+
+	// Vendor endpoints, authenticated by the vendor's API key
+	r.Handle("/vendors/v1/me/books", booksHandler).Methods("GET")
+	r.Handle("/vendors/v1/me/books", saveBookHandler).Methods("POST")
+	r.Handle("/vendors/v1/me/books/{book-id}/stock", updateStockHandler).Methods("PUT")
+	r.Handle("/vendors/v1/me/orders", ordersHandler).Methods("GET")
+	r.Handle("/vendors/v1/me/sync", submitSyncHandler).Methods("POST")
+	r.Handle("/vendors/v1/me/sync", syncBatchesHandler).Methods("GET")
+	r.Handle("/vendors/v1/me/sync/{batch-id}", syncReportHandler).Methods("GET")
+
+	allow.Methods(r)
+
+	return r
+}
+
+func decodeRegisterRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r registerRequest
+	err := schema.Decode(req.Body, &r)
+	return r, err
+}
+
+func decodeListRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	f, err := filter.Parse(req.FormValue("filter"), ListFields)
+	if err != nil {
+		return nil, err
+	}
+	return listRequest{Status: req.FormValue("status"), Filter: f}, nil
+}
+
+func decodeReviewRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r reviewRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	vendorID, ok := mux.Vars(req)["vendor-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "vendor-id")
+	}
+	r.VendorID = vendorID
+	return r, nil
+}
+
+func decodeCreateKeyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r createKeyRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	vendorID, ok := mux.Vars(req)["vendor-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "vendor-id")
+	}
+	r.VendorID = vendorID
+	return r, nil
+}
+
+func decodeRevokeKeyRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	vars := mux.Vars(req)
+	vendorID, ok := vars["vendor-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "vendor-id")
+	}
+	keyID, ok := vars["key-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "key-id")
+	}
+	return revokeKeyRequest{VendorID: vendorID, KeyID: keyID}, nil
+}
+
+func decodeVendorRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	return vendorRequest{APIKey: APIKeyFrom(req)}, nil
+}
+
+func decodeSaveBookRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var b VendorBook
+	if err := schema.Decode(req.Body, &b); err != nil {
+		return nil, err
+	}
+	return saveBookRequest{APIKey: APIKeyFrom(req), VendorBook: b}, nil
+}
+
+func decodeUpdateStockRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	var r updateStockRequest
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	bookID, ok := mux.Vars(req)["book-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "book-id")
+	}
+	r.BookID = bookID
+	r.APIKey = APIKeyFrom(req)
+	return r, nil
+}
+
+// decodeSubmitSyncRequest accepts either JSON {"items": [...]} or CSV body
+// with text/csv content type.
+func decodeSubmitSyncRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	r := submitSyncRequest{APIKey: APIKeyFrom(req), Source: SourceAPI}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "text/csv") {
+		items, err := ParseCSV(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Source = SourceCSV
+		r.Items = items
+		return r, nil
+	}
+	if err := schema.Decode(req.Body, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func decodeSyncReportRequest(ctx context.Context, req *http.Request) (interface{}, error) {
+	batchID, ok := mux.Vars(req)["batch-id"]
+	if !ok {
+		return nil, errors.Wrap(ErrBadRouting, "batch-id")
+	}
+	return syncReportRequest{APIKey: APIKeyFrom(req), BatchID: batchID}, nil
+}
+
+// APIKeyFrom reads the API key either from X-API-Key or Authorization
+// bearer header.
+func APIKeyFrom(req *http.Request) string {
+	if key := req.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	auth := req.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// errorer interface should be implemented by all the doman specific errors.
+// easy to set different status code in case of different errors.
+type errorer interface {
+	error() error
+}
+
+// statuser allows any response to get customer status code
+// e.g: 201 for successfull resource creation.
+type statuser interface {
+	status() int
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, d interface{}) error {
+	if e, ok := d.(errorer); ok && e.error() != nil {
+		// Now its a business logic error.
+		// Extract base domain error.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	status := http.StatusOK
+	if s, ok := d.(statuser); ok && s.status() != 0 {
+		status = s.status()
+	}
+
+	f := transport.FormatResponse{
+		Data: d,
+		Meta: transport.MetaResponse{Status: status},
+	}
+
+	return transport.Encode(ctx, w, f)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	if err == nil {
+		panic("encodeError with nil error")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	// Its important to pass errors.Cause() as we decide status code based on
+	// root error which is domain specific
+	code := codeFrom(errors.Cause(err))
+	w.WriteHeader(code)
+	f := transport.FormatResponse{Meta: transport.MetaResponse{Status: code, Code: i18n.Code(err), Error: i18n.Message(ctx, err), Errors: i18n.Fields(ctx, validate.Fields(err))}}
+	json.NewEncoder(w).Encode(f)
+}
+
+func codeFrom(err error) int {
+	switch err {
+	case auth.ErrMissingToken, auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+		return http.StatusUnauthorized
+	case rbac.ErrForbidden, rbac.ErrInsufficientScope, rbac.ErrImpersonated, csrf.ErrInvalidToken:
+		return http.StatusForbidden
+	case ErrVendorNotFound, ErrKeyNotFound, ErrBatchNotFound, catalog.ErrBookNotFound:
+		return http.StatusNotFound
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrForbidden, ErrNotApproved, ErrNotVendorBook:
+		return http.StatusForbidden
+	case ErrAlreadyExists, ErrAlreadyReviewed:
+		return http.StatusConflict
+	case ErrBatchTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrBadRouting, validate.ErrInvalid, filter.ErrInvalid, ErrInvalidScope, ErrInvalidStock, ErrInvalidBookField,
+		ErrMalformedCSV, ErrEmptyBatch:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
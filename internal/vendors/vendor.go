@@ -0,0 +1,120 @@
+package vendors
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusSuspended = "suspended"
+)
+
+// Scopes an API key can be granted.
+const (
+	ScopeCatalogWrite   = "catalog:write"
+	ScopeInventoryWrite = "inventory:write"
+	ScopeOrdersRead     = "orders:read"
+	ScopePayoutsRead    = "payouts:read"
+)
+
+// Vendor is a third-party seller on the marketplace.
+type Vendor struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Status     string     `json:"status"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ListFields are the fields the vendor list can be filtered on.
+var ListFields = filter.Fields{
+	"id":          {Column: "id", Type: filter.String},
+	"name":        {Column: "name", Type: filter.String},
+	"email":       {Column: "email", Type: filter.String},
+	"status":      {Column: "status", Type: filter.String},
+	"created_at":  {Column: "created_at", Type: filter.Time},
+	"reviewed_at": {Column: "reviewed_at", Type: filter.Time},
+}
+
+// NewVendor represents vendor who is about to register.
+type NewVendor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Validate does basic validation before saving into db, reporting all
+// the failing fields.
+func (n *NewVendor) Validate() error {
+	var v validate.Validator
+	v.Required("name", n.Name)
+	v.Required("email", n.Email)
+	return v.Err()
+}
+
+// VendorBook is the part of a book its vendor can edit, the rest of the
+// book, e.g. its visibility or its shelf, is kept by the shop.
+type VendorBook struct {
+	ID              string  `json:"id"`
+	ISBN            string  `json:"isbn"`
+	Title           string  `json:"title"`
+	Series          string  `json:"series,omitempty"`
+	Description     string  `json:"description,omitempty"`
+	CoverURL        string  `json:"cover_url,omitempty"`
+	PublicationYear string  `json:"publication_year"`
+	Price           float64 `json:"price"`
+	Stock           int     `json:"stock"`
+	// Version is the version of the book edited, zero overwrites the
+	// current one.
+	Version int `json:"version"`
+}
+
+// apply sets the vendor editable fields of b on book.
+func (b VendorBook) apply(book *catalog.Book) {
+	book.ISBN = b.ISBN
+	book.Title = b.Title
+	book.Series = b.Series
+	book.Description = b.Description
+	book.CoverURL = b.CoverURL
+	book.PublicationYear = b.PublicationYear
+	book.Price = b.Price
+	book.Stock = b.Stock
+}
+
+// APIKey lets a vendor manage its own catalog, inventory and orders.
+// Only the hash of the key is stored, the key itself is shown once.
+type APIKey struct {
+	ID        string     `json:"id"`
+	VendorID  string     `json:"vendor_id"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	ScopeList string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Scopes returns the scopes granted to the key.
+func (k APIKey) Scopes() []string {
+	if k.ScopeList == "" {
+		return nil
+	}
+	return strings.Split(k.ScopeList, ",")
+}
+
+// Allows tells whether the key is granted the scope.
+func (k APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// allow answers the requests to the routes of a router with a method they
+// don't accept. OPTIONS requests are answered with the accepted methods in
+// the Allow header, other methods with 405 Method Not Allowed.
+package allow
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+var ErrMethodNotAllowed = errors.New("method not allowed")
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrMethodNotAllowed: "method_not_allowed",
+	})
+}
+
+// Methods sets the NotFoundHandler of r, answering the requests matching a
+// route of r but for their method. The requests matching no route at all
+// are still not found.
+func Methods(r *mux.Router) {
+	notFound := r.NotFoundHandler
+	if notFound == nil {
+		notFound = http.NotFoundHandler()
+	}
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		methods := Allowed(r, req)
+		if len(methods) == 0 {
+			notFound.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(append(methods, "OPTIONS"), ", "))
+		if req.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		ctx := i18n.PopulateLocale(req.Context(), req)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		f := transport.FormatResponse{Meta: transport.MetaResponse{
+			Status: http.StatusMethodNotAllowed,
+			Code:   i18n.Code(ErrMethodNotAllowed),
+			Error:  i18n.Message(ctx, ErrMethodNotAllowed),
+		}}
+		json.NewEncoder(w).Encode(f)
+	})
+}
+
+// Allowed returns the sorted methods of the routes of r which match req
+// but for its method.
+func Allowed(r *mux.Router, req *http.Request) []string {
+	seen := make(map[string]bool)
+	r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, m := range methods {
+			other := new(http.Request)
+			*other = *req
+			other.Method = m
+			if route.Match(other, &mux.RouteMatch{}) {
+				seen[m] = true
+			}
+		}
+		return nil
+	})
+	methods := make([]string, 0, len(seen))
+	for m := range seen {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,44 @@
+package allow_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/kavirajk/bookshop/allow"
+)
+
+func TestMethods(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	r := mux.NewRouter()
+	r.Handle("/books/v1/{book-id}", ok).Methods("GET")
+	r.Handle("/books/v1/{book-id}", ok).Methods("PATCH", "DELETE")
+	r.Handle("/books/v1/", ok).Methods("POST")
+	allow.Methods(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/books/v1/b_1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("put: expected 405, got %d", w.Code)
+	}
+	if a := w.Header().Get("Allow"); a != "DELETE, GET, PATCH, OPTIONS" {
+		t.Errorf("put: expected the book methods, got %q", a)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"code":"method_not_allowed"`) || !strings.Contains(body, `"status":405`) {
+		t.Errorf("put: expected the error envelope, got %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/books/v1/", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "POST, OPTIONS" {
+		t.Errorf("options: expected 204 with POST, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/authors/v1/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown: expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,293 @@
+// auth issues the JWT access tokens of the users on login and checks them
+// on the protected routes. Tokens are signed with HMAC-SHA256 (HS256) and
+// carry the user ID as subject, along with the role of the user. A token
+// revoked on logout is listed by its ID in Revocations until it expires.
+// The tokens of a login session are rejected once the session is revoked,
+// see Sessions. The tokens of the third-party integrations are scoped,
+// they only give access to the endpoints of their scopes, see
+// rbac.RequireScope.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+var (
+	ErrMissingToken = errors.New("access token is required")
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrExpiredToken = errors.New("access token expired")
+	ErrRevokedToken = errors.New("access token revoked")
+
+	errNoScope = errors.New("scoped token without scope")
+)
+
+// DefaultTTL is how long a token is valid by default.
+const DefaultTTL = 24 * time.Hour
+
+// header is the JOSE header of every token, only HS256 is accepted back.
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the registered claims of a token.
+type Claims struct {
+	ID        string `json:"jti"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// Role is the role of the user when the token was signed, a role
+	// change applies to the tokens signed after.
+	Role string `json:"role,omitempty"`
+	// Session is the ID of the login session the token is issued for.
+	Session string `json:"sid,omitempty"`
+	// Scope is the space separated scopes of a scoped token, e.g.
+	// "users:read users:write". The tokens of the logins are unscoped.
+	Scope string `json:"scope,omitempty"`
+	// ImpersonatedBy is the admin a token acting as the user is issued to.
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+}
+
+// Scoped tells whether c is the claims of a scoped token.
+func (c Claims) Scoped() bool {
+	return c.Scope != ""
+}
+
+// Allows tells whether the token of c is granted scope, an unscoped token
+// being granted all of them.
+func (c Claims) Allows(scope string) bool {
+	if !c.Scoped() {
+		return true
+	}
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Sessions tracks the login sessions the tokens are issued for.
+type Sessions interface {
+	// Seen records the session seen at at, failing with ErrRevokedToken if
+	// it is revoked.
+	Seen(id string, at time.Time) error
+}
+
+// Service signs and verifies access tokens.
+type Service interface {
+	// Sign returns a token for the user of role in the login session,
+	// valid for the TTL of the service.
+	Sign(userID, role, session string) (token string, expiresAt time.Time, err error)
+
+	// SignScoped returns a token for the user of role in session granted
+	// scopes only, valid for ttl, the TTL of the service if zero.
+	SignScoped(userID, role, session string, scopes []string, ttl time.Duration) (token string, expiresAt time.Time, err error)
+
+	// SignImpersonation returns a token of adminID acting as the user of
+	// role in session, valid for ttl.
+	SignImpersonation(userID, role, session, adminID string, ttl time.Duration) (token string, expiresAt time.Time, err error)
+
+	// Verify checks the signature, the expiry and the revocation of token
+	// and of its session and returns its claims.
+	Verify(token string) (Claims, error)
+
+	// Revoke invalidates the token of c before its expiry, e.g. on logout.
+	Revoke(c Claims) error
+}
+
+// Keys are the secrets the tokens are signed with, rotating, e.g. a
+// secrets.Value. The tokens are signed with the first one, the others
+// verifying the tokens signed before a rotation.
+type Keys interface {
+	Keys() [][]byte
+}
+
+type service struct {
+	secret   []byte
+	keys     Keys
+	ttl      time.Duration
+	revoked  Revocations
+	sessions Sessions
+	now      func() time.Time
+}
+
+// NewService returns a Service signing with secret, tokens being valid
+// for ttl, DefaultTTL if zero. The revoked tokens are listed in revoked,
+// the sessions of the tokens are checked with sessions if not nil.
+func NewService(secret []byte, ttl time.Duration, revoked Revocations, sessions Sessions) Service {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return service{secret: secret, ttl: ttl, revoked: revoked, sessions: sessions, now: time.Now}
+}
+
+// NewRotatingService returns a Service like NewService, signing with the
+// current key of keys.
+func NewRotatingService(keys Keys, ttl time.Duration, revoked Revocations, sessions Sessions) Service {
+	s := NewService(nil, ttl, revoked, sessions).(service)
+	s.keys = keys
+	return s
+}
+
+func (s service) Sign(userID, role, session string) (string, time.Time, error) {
+	return s.sign(Claims{Subject: userID, Role: role, Session: session}, s.ttl)
+}
+
+// SignScoped issues a token that isn't refreshed, the session being there
+// to revoke it.
+func (s service) SignScoped(userID, role, session string, scopes []string, ttl time.Duration) (string, time.Time, error) {
+	if len(scopes) == 0 {
+		return "", time.Time{}, errNoScope
+	}
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	return s.sign(Claims{Subject: userID, Role: role, Session: session, Scope: strings.Join(scopes, " ")}, ttl)
+}
+
+// SignImpersonation issues a token that isn't refreshed like SignScoped.
+func (s service) SignImpersonation(userID, role, session, adminID string, ttl time.Duration) (string, time.Time, error) {
+	return s.sign(Claims{Subject: userID, Role: role, Session: session, ImpersonatedBy: adminID}, ttl)
+}
+
+// sign fills the ID and the times of c, valid for ttl, and signs it.
+func (s service) sign(c Claims, ttl time.Duration) (string, time.Time, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, err
+	}
+	now := s.now()
+	exp := now.Add(ttl)
+	c.ID = base64.RawURLEncoding.EncodeToString(id)
+	c.IssuedAt, c.ExpiresAt = now.Unix(), exp.Unix()
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(b)
+	return unsigned + "." + s.signature(unsigned, s.signingKeys()[0]), exp, nil
+}
+
+func (s service) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return Claims{}, ErrInvalidToken
+	}
+	valid := false
+	for _, key := range s.signingKeys() {
+		if hmac.Equal([]byte(parts[2]), []byte(s.signature(parts[0]+"."+parts[1], key))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return Claims{}, ErrInvalidToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(b, &c); err != nil || c.Subject == "" || c.ID == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	if s.now().Unix() >= c.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+	revoked, err := s.revoked.Revoked(c.ID)
+	if err != nil {
+		return Claims{}, err
+	}
+	if revoked {
+		return Claims{}, ErrRevokedToken
+	}
+	if c.Session != "" && s.sessions != nil {
+		if err := s.sessions.Seen(c.Session, s.now()); err != nil {
+			return Claims{}, err
+		}
+	}
+	return c, nil
+}
+
+// Revoke lists the token until it expires, it's rejected by then anyway.
+func (s service) Revoke(c Claims) error {
+	return s.revoked.Revoke(c.ID, time.Unix(c.ExpiresAt, 0))
+}
+
+// signingKeys returns the keys of s, the current one first.
+func (s service) signingKeys() [][]byte {
+	if s.keys != nil {
+		return s.keys.Keys()
+	}
+	return [][]byte{s.secret}
+}
+
+func (s service) signature(unsigned string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type tokenKey struct{}
+
+type claimsKey struct{}
+
+// SessionCookie is the cookie holding the access token of the browsers
+// signed in by a cookie session. Those requests are to be checked against
+// cross-site request forgery, see csrf.NewMiddleware.
+const SessionCookie = "session"
+
+// PopulateToken is a ServerBefore func storing the bearer token of the
+// Authorization header of req in the context, or else the token of the
+// session cookie.
+func PopulateToken(ctx context.Context, req *http.Request) context.Context {
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		if c, err := req.Cookie(SessionCookie); err == nil && c.Value != "" {
+			return context.WithValue(ctx, tokenKey{}, c.Value)
+		}
+		return ctx
+	}
+	return context.WithValue(ctx, tokenKey{}, strings.TrimPrefix(h, "Bearer "))
+}
+
+// NewMiddleware returns an endpoint middleware rejecting the requests
+// without a valid token, PopulateToken having to run before. The claims
+// of the token are in the context of the next endpoint, see UserID and
+// ClaimsFrom.
+func NewMiddleware(s Service) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			token, _ := ctx.Value(tokenKey{}).(string)
+			if token == "" {
+				return nil, ErrMissingToken
+			}
+			c, err := s.Verify(token)
+			if err != nil {
+				return nil, err
+			}
+			return next(context.WithValue(ctx, claimsKey{}, c), request)
+		}
+	}
+}
+
+// UserID returns the ID of the user authenticated by the middleware.
+func UserID(ctx context.Context) (string, bool) {
+	c, ok := ClaimsFrom(ctx)
+	return c.Subject, ok
+}
+
+// ClaimsFrom returns the claims of the token checked by the middleware.
+func ClaimsFrom(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(Claims)
+	return c, ok
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	s := service{secret: []byte("secret"), ttl: time.Hour, revoked: NewMemoryRevocations(), now: func() time.Time { return now }}
+	token, exp, err := s.Sign("u1", "customer", "")
+	if err != nil {
+		t.Fatalf("sign: unexpected error %v", err)
+	}
+	if !exp.Equal(now.Add(time.Hour)) {
+		t.Errorf("sign: expected expiry %v, got %v", now.Add(time.Hour), exp)
+	}
+	if c, err := s.Verify(token); err != nil || c.Subject != "u1" {
+		t.Errorf("verify: expected u1, got %+v, %v", c, err)
+	}
+
+	parts := strings.Split(token, ".")
+	other := service{secret: []byte("other"), ttl: time.Hour, revoked: s.revoked, now: s.now}
+	forged, _, _ := other.Sign("u2", "customer", "")
+	for name, bad := range map[string]string{
+		"empty":     "",
+		"garbage":   "a.b",
+		"signature": parts[0] + "." + parts[1] + "." + parts[2][1:],
+		"payload":   parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2],
+		"secret":    forged,
+		"alg none":  "eyJhbGciOiJub25lIn0." + parts[1] + ".",
+	} {
+		if _, err := s.Verify(bad); err != ErrInvalidToken {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	c, _ := s.Verify(token)
+	kept, _, _ := s.Sign("u1", "customer", "")
+	if err := s.Revoke(c); err != nil {
+		t.Fatalf("revoke: unexpected error %v", err)
+	}
+	if _, err := s.Verify(token); err != ErrRevokedToken {
+		t.Errorf("revoked: expected ErrRevokedToken, got %v", err)
+	}
+	if _, err := s.Verify(kept); err != nil {
+		t.Errorf("other token: unexpected error %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := s.Verify(token); err != ErrExpiredToken {
+		t.Errorf("expired: expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := NewService([]byte("secret"), 0, NewMemoryRevocations(), nil)
+	token, _, _ := s.Sign("u1", "customer", "")
+	e := NewMiddleware(s)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		id, _ := UserID(ctx)
+		return id, nil
+	})
+	for header, want := range map[string]error{
+		"":                      ErrMissingToken,
+		"Basic dTE6cGFzcw==":    ErrMissingToken,
+		"Bearer " + token + "x": ErrInvalidToken,
+		"Bearer " + token:       nil,
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", header)
+		id, err := e(PopulateToken(context.Background(), req), nil)
+		if err != want {
+			t.Errorf("%q: expected %v, got %v", header, want, err)
+		}
+		if want == nil && id != "u1" {
+			t.Errorf("%q: expected user u1, got %v", header, id)
+		}
+	}
+}
+
+// sessions records when the sessions are seen, s2 being revoked.
+type sessions map[string]time.Time
+
+func (s sessions) Seen(id string, at time.Time) error {
+	if id == "s2" {
+		return ErrRevokedToken
+	}
+	s[id] = at
+	return nil
+}
+
+func TestSessions(t *testing.T) {
+	seen := sessions{}
+	s := NewService([]byte("secret"), 0, NewMemoryRevocations(), seen)
+	for session, want := range map[string]error{"": nil, "s1": nil, "s2": ErrRevokedToken} {
+		token, _, _ := s.Sign("u1", "customer", session)
+		c, err := s.Verify(token)
+		if err != want {
+			t.Errorf("%q: expected %v, got %v", session, want, err)
+		}
+		if want == nil && c.Session != session {
+			t.Errorf("%q: expected the session in the claims, got %q", session, c.Session)
+		}
+	}
+	if _, ok := seen["s1"]; !ok || len(seen) != 1 {
+		t.Errorf("seen: expected s1 only, got %v", seen)
+	}
+}
+
+type keys [][]byte
+
+func (k *keys) Keys() [][]byte {
+	return *k
+}
+
+func TestRotatingKeys(t *testing.T) {
+	k := &keys{[]byte("old")}
+	s := NewRotatingService(k, 0, NewMemoryRevocations(), nil)
+	token, _, err := s.Sign("u1", "customer", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	*k = keys{[]byte("new"), []byte("old")}
+	if _, err := s.Verify(token); err != nil {
+		t.Errorf("token of the key rotated from: expected valid, got %v", err)
+	}
+	fresh, _, _ := s.Sign("u1", "customer", "")
+	*k = keys{[]byte("newer"), []byte("new")}
+	if _, err := s.Verify(token); err != ErrInvalidToken {
+		t.Errorf("token of a key rotated out: expected ErrInvalidToken, got %v", err)
+	}
+	if _, err := s.Verify(fresh); err != nil {
+		t.Errorf("token of the previous key: expected valid, got %v", err)
+	}
+}
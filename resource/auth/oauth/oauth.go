@@ -0,0 +1,243 @@
+// oauth signs the users in with the OAuth2 authorization code flow of
+// social providers, e.g. Google or GitHub. The providers only differ by
+// their endpoints and the way they tell who the user is, the login and
+// callback handling being the same for all.
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	ErrInvalidState    = errors.New("invalid or expired oauth state")
+	ErrProvider        = errors.New("oauth provider failed")
+)
+
+// StateTTL is how long the user has to sign in at the provider.
+const StateTTL = 10 * time.Minute
+
+// Identity is the user as told by a provider.
+type Identity struct {
+	Provider string
+	// Subject is the stable ID of the user at the provider.
+	Subject string
+	Email   string
+	// EmailVerified tells whether the provider checked the user owns the
+	// email, only then is it linked to an existing account.
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// Provider is a social login provider.
+type Provider interface {
+	Name() string
+	// AuthCodeURL is the URL the user is sent to, to sign in and consent.
+	AuthCodeURL(state string) string
+	// Exchange trades the code of the callback for the identity of the
+	// user.
+	Exchange(ctx context.Context, code string) (Identity, error)
+}
+
+// Login runs the flow of the configured providers. The state sent to the
+// provider is signed and expires, the callback also requires it in the
+// cookie set on login so that it can't be replayed into another browser.
+type Login struct {
+	secret    []byte
+	providers map[string]Provider
+}
+
+// NewLogin returns the Login of providers, states signed with secret.
+func NewLogin(secret []byte, providers ...Provider) *Login {
+	l := &Login{secret: secret, providers: make(map[string]Provider)}
+	for _, p := range providers {
+		l.providers[p.Name()] = p
+	}
+	return l
+}
+
+// Redirect returns the URL of the provider to send the user to and the
+// state to set in the cookie.
+func (l *Login) Redirect(provider string) (string, string, error) {
+	p, ok := l.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	payload := provider + "." + base64.RawURLEncoding.EncodeToString(nonce) + "." +
+		strconv.FormatInt(time.Now().Add(StateTTL).Unix(), 10)
+	state := payload + "." + l.signature(payload)
+	return p.AuthCodeURL(state), state, nil
+}
+
+// Callback checks the state of the callback against the one of the cookie
+// and exchanges code for the identity of the user.
+func (l *Login) Callback(ctx context.Context, provider, code, state, cookie string) (Identity, error) {
+	p, ok := l.providers[provider]
+	if !ok {
+		return Identity{}, ErrUnknownProvider
+	}
+	if err := l.checkState(provider, state, cookie); err != nil {
+		return Identity{}, err
+	}
+	if code == "" {
+		return Identity{}, ErrInvalidState
+	}
+	return p.Exchange(ctx, code)
+}
+
+func (l *Login) checkState(provider, state, cookie string) error {
+	if state == "" || !hmac.Equal([]byte(state), []byte(cookie)) {
+		return ErrInvalidState
+	}
+	parts := strings.Split(state, ".")
+	if len(parts) != 4 || parts[0] != provider {
+		return ErrInvalidState
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(l.signature(payload))) {
+		return ErrInvalidState
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() >= exp {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+func (l *Login) signature(payload string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Endpoint are the OAuth2 endpoints and credentials of a provider.
+type Endpoint struct {
+	AuthURL      string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// provider is the authorization code flow against an Endpoint, identify
+// reading the user with the access token.
+type provider struct {
+	name     string
+	endpoint Endpoint
+	client   *http.Client
+	identify func(ctx context.Context, get getFunc) (Identity, error)
+}
+
+// getFunc reads a JSON resource of the provider API with the access token.
+type getFunc func(ctx context.Context, url string, v interface{}) error
+
+func newProvider(name string, e Endpoint, client *http.Client, identify func(context.Context, getFunc) (Identity, error)) Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return provider{name: name, endpoint: e, client: client, identify: identify}
+}
+
+func (p provider) Name() string {
+	return p.name
+}
+
+func (p provider) AuthCodeURL(state string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.endpoint.ClientID},
+		"redirect_uri":  {p.endpoint.RedirectURL},
+		"scope":         {strings.Join(p.endpoint.Scopes, " ")},
+		"state":         {state},
+	}
+	sep := "?"
+	if strings.Contains(p.endpoint.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.endpoint.AuthURL + sep + v.Encode()
+}
+
+func (p provider) Exchange(ctx context.Context, code string) (Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.endpoint.RedirectURL},
+		"client_id":     {p.endpoint.ClientID},
+		"client_secret": {p.endpoint.ClientSecret},
+	}
+	req, err := http.NewRequest("POST", p.endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return Identity{}, err
+	}
+	if token.AccessToken == "" {
+		return Identity{}, errors.Wrapf(ErrProvider, "no access token: %s", token.Error)
+	}
+	get := func(ctx context.Context, url string, v interface{}) error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		req.Header.Set("Accept", "application/json")
+		return p.do(req, v)
+	}
+	id, err := p.identify(ctx, get)
+	if err != nil {
+		return Identity{}, err
+	}
+	if id.Subject == "" {
+		return Identity{}, errors.Wrap(ErrProvider, "no user id")
+	}
+	id.Provider = p.name
+	return id, nil
+}
+
+// do runs req and decodes its JSON response into v.
+func (p provider) do(req *http.Request, v interface{}) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(ErrProvider, err.Error())
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrap(ErrProvider, err.Error())
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Wrapf(ErrProvider, "%s responded %s", req.URL.Host, res.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(ErrProvider, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.FormValue("code") != "good" || r.FormValue("client_secret") != "shh" {
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "at"})
+		case "/me":
+			if r.Header.Get("Authorization") != "Bearer at" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"id": "42", "email": "jo@example.com"})
+		}
+	}))
+	defer srv.Close()
+
+	p := newProvider("test", Endpoint{
+		AuthURL:      srv.URL + "/auth",
+		TokenURL:     srv.URL + "/token",
+		ClientID:     "id",
+		ClientSecret: "shh",
+		RedirectURL:  "http://shop/callback",
+	}, srv.Client(), func(ctx context.Context, get getFunc) (Identity, error) {
+		var me struct{ ID, Email string }
+		if err := get(ctx, srv.URL+"/me", &me); err != nil {
+			return Identity{}, err
+		}
+		return Identity{Subject: me.ID, Email: me.Email, EmailVerified: true}, nil
+	})
+	l := NewLogin([]byte("secret"), p)
+
+	if _, _, err := l.Redirect("other"); err != ErrUnknownProvider {
+		t.Errorf("redirect: expected ErrUnknownProvider, got %v", err)
+	}
+	u, state, err := l.Redirect("test")
+	if err != nil {
+		t.Fatalf("redirect: unexpected error %v", err)
+	}
+	parsed, _ := url.Parse(u)
+	if parsed.Query().Get("state") != state || parsed.Query().Get("client_id") != "id" {
+		t.Errorf("redirect: unexpected URL %s", u)
+	}
+
+	id, err := l.Callback(context.Background(), "test", "good", state, state)
+	if err != nil {
+		t.Fatalf("callback: unexpected error %v", err)
+	}
+	if id.Provider != "test" || id.Subject != "42" || id.Email != "jo@example.com" {
+		t.Errorf("callback: unexpected identity %+v", id)
+	}
+
+	_, other, _ := NewLogin([]byte("other"), p).Redirect("test")
+	for name, c := range map[string]struct{ state, cookie string }{
+		"no cookie":   {state, ""},
+		"other state": {state, state[:len(state)-1]},
+		"forged":      {other, other},
+	} {
+		if _, err := l.Callback(context.Background(), "test", "good", c.state, c.cookie); err != ErrInvalidState {
+			t.Errorf("%s: expected ErrInvalidState, got %v", name, err)
+		}
+	}
+	if _, err := l.Callback(context.Background(), "test", "bad", state, state); errors.Cause(err) != ErrProvider {
+		t.Errorf("bad code: expected ErrProvider, got %v", err)
+	}
+}
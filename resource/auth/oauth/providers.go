@@ -0,0 +1,97 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Names of the providers.
+const (
+	Google = "google"
+	GitHub = "github"
+)
+
+// NewGoogleProvider returns the Google provider, reading the user from
+// the OpenID Connect userinfo endpoint.
+func NewGoogleProvider(clientID, clientSecret, redirectURL string, client *http.Client) Provider {
+	e := Endpoint{
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+	return newProvider(Google, e, client, func(ctx context.Context, get getFunc) (Identity, error) {
+		var u struct {
+			Sub           string `json:"sub"`
+			Email         string `json:"email"`
+			EmailVerified bool   `json:"email_verified"`
+			GivenName     string `json:"given_name"`
+			FamilyName    string `json:"family_name"`
+		}
+		if err := get(ctx, "https://openidconnect.googleapis.com/v1/userinfo", &u); err != nil {
+			return Identity{}, err
+		}
+		return Identity{
+			Subject:       u.Sub,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			FirstName:     u.GivenName,
+			LastName:      u.FamilyName,
+		}, nil
+	})
+}
+
+// NewGitHubProvider returns the GitHub provider. The profile email of a
+// GitHub user is optional and unverified, the primary verified email of
+// the user is read instead.
+func NewGitHubProvider(clientID, clientSecret, redirectURL string, client *http.Client) Provider {
+	e := Endpoint{
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+	}
+	return newProvider(GitHub, e, client, func(ctx context.Context, get getFunc) (Identity, error) {
+		var u struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}
+		if err := get(ctx, "https://api.github.com/user", &u); err != nil {
+			return Identity{}, err
+		}
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := get(ctx, "https://api.github.com/user/emails", &emails); err != nil {
+			return Identity{}, err
+		}
+		id := Identity{Subject: strconv.FormatInt(u.ID, 10)}
+		if u.ID == 0 {
+			id.Subject = ""
+		}
+		for _, e := range emails {
+			if e.Primary {
+				id.Email, id.EmailVerified = e.Email, e.Verified
+			}
+		}
+		name := strings.TrimSpace(u.Name)
+		if name == "" {
+			name = u.Login
+		}
+		parts := strings.SplitN(name, " ", 2)
+		id.FirstName = parts[0]
+		if len(parts) == 2 {
+			id.LastName = parts[1]
+		}
+		return id, nil
+	})
+}
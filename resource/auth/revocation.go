@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kavirajk/bookshop/redis"
+)
+
+// Revocations lists the IDs of the tokens revoked before their expiry.
+type Revocations interface {
+	// Revoke lists id until the expiry of its token, until.
+	Revoke(id string, until time.Time) error
+	Revoked(id string) (bool, error)
+}
+
+type memoryRevocations struct {
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+// NewMemoryRevocations returns Revocations kept in memory, for a single
+// server. They are lost on restart.
+func NewMemoryRevocations() Revocations {
+	return &memoryRevocations{ids: make(map[string]time.Time)}
+}
+
+// Revoke drops the expired IDs along the way.
+func (r *memoryRevocations) Revoke(id string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range r.ids {
+		if !now.Before(exp) {
+			delete(r.ids, k)
+		}
+	}
+	if now.Before(until) {
+		r.ids[id] = until
+	}
+	return nil
+}
+
+func (r *memoryRevocations) Revoked(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exp, ok := r.ids[id]
+	return ok && time.Now().Before(exp), nil
+}
+
+// redisPrefix prefixes the keys of the revoked IDs.
+const redisPrefix = "auth:revoked:"
+
+type redisRevocations struct {
+	c *redis.Client
+}
+
+// NewRedisRevocations returns Revocations stored in the Redis server at
+// addr, shared by all the servers. The keys expire with their tokens.
+func NewRedisRevocations(addr, password string) Revocations {
+	return &redisRevocations{c: redis.NewClient(addr, password)}
+}
+
+func (r *redisRevocations) Revoke(id string, until time.Time) error {
+	ttl := until.Sub(time.Now()) / time.Millisecond
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.c.Do("SET", redisPrefix+id, "1", "PX", strconv.FormatInt(int64(ttl), 10))
+	return err
+}
+
+func (r *redisRevocations) Revoked(id string) (bool, error) {
+	n, err := r.c.Do("EXISTS", redisPrefix+id)
+	if err != nil {
+		return false, err
+	}
+	return n == "1", nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRevocations(t *testing.T, name string, r Revocations) {
+	if err := r.Revoke("a", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("%s: revoke: unexpected error %v", name, err)
+	}
+	if err := r.Revoke("b", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("%s: revoke expired: unexpected error %v", name, err)
+	}
+	for id, want := range map[string]bool{"a": true, "b": false, "c": false} {
+		if got, err := r.Revoked(id); err != nil || got != want {
+			t.Errorf("%s: %s: expected revoked %v, got %v, %v", name, id, want, got, err)
+		}
+	}
+}
+
+func TestMemoryRevocations(t *testing.T) {
+	testRevocations(t, "memory", NewMemoryRevocations())
+}
+
+// fakeRedis answers SET and EXISTS of the keys it was sent, ignoring the
+// expiry.
+func fakeRedis(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	keys := make(map[string]bool)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					var args []string
+					for n := count(line); n > 0; n-- {
+						r.ReadString('\n')
+						arg, _ := r.ReadString('\n')
+						args = append(args, strings.TrimSpace(arg))
+					}
+					switch args[0] {
+					case "SET":
+						keys[args[1]] = true
+						conn.Write([]byte("+OK\r\n"))
+					case "EXISTS":
+						if keys[args[1]] {
+							conn.Write([]byte(":1\r\n"))
+						} else {
+							conn.Write([]byte(":0\r\n"))
+						}
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+	return l
+}
+
+func TestRedisRevocations(t *testing.T) {
+	l := fakeRedis(t)
+	defer l.Close()
+	testRevocations(t, "redis", NewRedisRevocations(l.Addr().String(), ""))
+}
+
+func count(header string) int {
+	n := 0
+	for _, c := range strings.TrimSpace(header[1:]) {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
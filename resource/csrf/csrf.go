@@ -0,0 +1,106 @@
+// csrf protects the cookie sessions against cross-site request forgery,
+// by double-submit tokens: the token is set in a cookie which the scripts
+// of the shop read and send back in the X-CSRF-Token header, which another
+// site can't do. The requests authenticated by a bearer token can't be
+// forged by another site and aren't checked.
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+var (
+	ErrInvalidToken = errors.New("missing or invalid CSRF token")
+)
+
+const (
+	// CookieName is the cookie holding the token, readable by the scripts.
+	CookieName = "csrf_token"
+	// HeaderName is the header the token is sent back in.
+	HeaderName = "X-CSRF-Token"
+)
+
+// tokenBytes is the entropy of a token.
+const tokenBytes = 32
+
+// NewToken returns a random token.
+func NewToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Cookie returns the cookie setting token for the whole site. It's left
+// readable by the scripts, a cookie sent along cross-site requests being
+// of no use to forge the header.
+func Cookie(token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+type requestKey struct{}
+
+// request is what the middleware checks of a request.
+type request struct {
+	method string
+	cookie string
+	header string
+	// session is set for the requests authenticated by the session cookie
+	// rather than by a bearer token.
+	session bool
+}
+
+// Populate is a ServerBefore func storing the tokens of the cookie and the
+// header of req in the context.
+func Populate(ctx context.Context, req *http.Request) context.Context {
+	r := request{method: req.Method, header: req.Header.Get(HeaderName)}
+	if c, err := req.Cookie(CookieName); err == nil {
+		r.cookie = c.Value
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		c, err := req.Cookie(auth.SessionCookie)
+		r.session = err == nil && c.Value != ""
+	}
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// CookieToken returns the token of the cookie of the request, if any.
+func CookieToken(ctx context.Context) (string, bool) {
+	r, _ := ctx.Value(requestKey{}).(request)
+	return r.cookie, r.cookie != ""
+}
+
+// NewMiddleware returns an endpoint middleware failing with ErrInvalidToken
+// the requests of the cookie sessions whose header doesn't match their
+// cookie, Populate having to run before. The safe methods aren't checked,
+// they change nothing.
+func NewMiddleware() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			r, _ := ctx.Value(requestKey{}).(request)
+			switch r.method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(ctx, req)
+			}
+			if r.session && (r.cookie == "" || subtle.ConstantTimeCompare([]byte(r.cookie), []byte(r.header)) != 1) {
+				return nil, ErrInvalidToken
+			}
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package csrf_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/csrf"
+)
+
+func TestMiddleware(t *testing.T) {
+	e := csrf.NewMiddleware()(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	token, err := csrf.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		method  string
+		session bool   // signed in by the session cookie
+		bearer  bool   // signed in by a bearer token
+		cookie  string // token of the CSRF cookie
+		header  string // token of the CSRF header
+		err     error
+	}{
+		{"matching", "POST", true, false, token, token, nil},
+		{"missing header", "POST", true, false, token, "", csrf.ErrInvalidToken},
+		{"missing cookie", "DELETE", true, false, "", token, csrf.ErrInvalidToken},
+		{"none", "POST", true, false, "", "", csrf.ErrInvalidToken},
+		{"mismatch", "PATCH", true, false, token, token + "x", csrf.ErrInvalidToken},
+		{"safe method", "GET", true, false, "", "", nil},
+		{"bearer", "POST", true, true, "", "", nil},
+		{"no session", "POST", false, false, "", "", nil},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, "/users/v1/change-password", nil)
+		if c.session {
+			req.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: "jwt"})
+		}
+		if c.bearer {
+			req.Header.Set("Authorization", "Bearer jwt")
+		}
+		if c.cookie != "" {
+			req.AddCookie(csrf.Cookie(c.cookie))
+		}
+		if c.header != "" {
+			req.Header.Set(csrf.HeaderName, c.header)
+		}
+		if _, err := e(csrf.Populate(context.Background(), req), nil); err != c.err {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+}
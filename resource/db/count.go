@@ -0,0 +1,33 @@
+package db
+
+import "errors"
+
+var ErrBadCount = errors.New("count must be exact, estimate or none")
+
+// Count tells a list query how to compute the total of its rows.
+type Count int
+
+const (
+	// CountExact counts all the rows, the default.
+	CountExact Count = iota
+	// CountEstimate takes the total from the table statistics, which may be
+	// stale. It is never less than the rows seen so far.
+	CountEstimate
+	// CountNone skips counting, the total only tells whether there is a
+	// next page.
+	CountNone
+)
+
+// ParseCount reads the count mode of a list request, "false" is the same
+// as "none" and empty the same as "exact".
+func ParseCount(s string) (Count, error) {
+	switch s {
+	case "", "exact", "true":
+		return CountExact, nil
+	case "estimate":
+		return CountEstimate, nil
+	case "none", "false":
+		return CountNone, nil
+	}
+	return CountExact, ErrBadCount
+}
@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("entity already exists")
+	// ErrConflict is returned when saving an entity whose version changed
+	// since it was read.
+	ErrConflict = errors.New("entity changed since read")
 )
@@ -1,100 +1,752 @@
 package inmem
 
 import (
-	"fmt"
+	"errors"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 	"github.com/kavirajk/bookshop/user"
 	"github.com/twinj/uuid"
 )
 
-type userRepo map[string]user.User
+// ErrUnsupportedFilter is returned by the lists given a filter.Expr, its
+// SQL can't be evaluated in memory.
+var ErrUnsupportedFilter = errors.New("filter expressions are not supported in memory")
 
-type userID struct {
-	ID string
-	sync.Mutex
+// userRepo keeps the users and everything they own in memory, e.g. for the
+// tests of the services using a user.Repo.
+type userRepo struct {
+	mu        sync.Mutex
+	users     map[string]user.User
+	jobs      map[string]user.Job
+	results   []user.Result
+	refreshes map[string]user.RefreshToken
+	sessions  map[string]user.Session
+	devices   map[string]user.TrustedDevice
+	addresses map[string]user.Address
+	accounts  map[string]user.SocialAccount
+	resets    map[string]user.ResetToken
+	failures  []user.LoginFailure
 }
 
-var global userID
+func NewUserRepo() user.Repo {
+	r := &userRepo{}
+	r.Drop()
+	return r
+}
 
-func (r userRepo) GetByID(ID string) (user.User, error) {
-	u, ok := r[ID]
-	if !ok {
-		return user.User{}, fmt.Errorf("user %v", db.ErrNotFound)
-	}
-	return u, nil
+func newID() string {
+	return uuid.NewV4().String()
 }
 
-func (r userRepo) GetByUserName(username string) (user.User, error) {
-	for _, v := range r {
-		if v.Username == username {
-			return v, nil
+func (r *userRepo) get(match func(user.User) bool) (user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if match(u) {
+			return u, nil
 		}
 	}
-	return user.User{}, fmt.Errorf("user %v", db.ErrNotFound)
+	return user.User{}, db.ErrNotFound
+}
+
+func (r *userRepo) GetByID(ID string) (user.User, error) {
+	return r.get(func(u user.User) bool { return u.ID == ID })
+}
+
+func (r *userRepo) GetByUserName(username string) (user.User, error) {
+	return r.get(func(u user.User) bool { return u.Username == username })
+}
+
+func (r *userRepo) GetByEmail(email string) (user.User, error) {
+	return r.get(func(u user.User) bool { return u.Email == email })
+}
+
+func (r *userRepo) GetByToken(token string) (user.User, error) {
+	return r.get(func(u user.User) bool { return u.AuthToken == token })
 }
 
-func (r userRepo) GetByEmail(email string) (user.User, error) {
-	for _, v := range r {
-		if v.Email == email {
-			return v, nil
+// selected returns the users not deleted f selects, in registration order.
+func (r *userRepo) selected(f user.Filter) []user.User {
+	users := make([]user.User, 0)
+	for _, u := range r.users {
+		if u.DeletedAt == nil && matches(f, u) {
+			users = append(users, u)
 		}
 	}
-	return user.User{}, fmt.Errorf("user %v", db.ErrNotFound)
+	sort.Slice(users, func(i, j int) bool { return before(users[i], users[j]) })
+	return users
+}
+
+// before tells whether a registered before b, by the keyset of the cursor.
+func before(a, b user.User) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
 }
 
-func (r userRepo) GetByToken(token string) (user.User, error) {
-	for _, v := range r {
-		if v.AuthToken == token {
-			return v, nil
+// matches tells whether f selects u, as the postgres scopes do.
+func matches(f user.Filter, u user.User) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, id := range f.IDs {
+			found = found || id == u.ID
+		}
+		if !found {
+			return false
 		}
 	}
-	return user.User{}, fmt.Errorf("user %v", db.ErrNotFound)
+	switch {
+	case f.Email != "" && !strings.EqualFold(f.Email, u.Email),
+		f.Role != "" && f.Role != u.Role,
+		f.Deactivated != nil && *f.Deactivated != u.Deactivated,
+		f.Verified != nil && *f.Verified != (u.EmailVerifiedAt != nil),
+		f.Segment != "" && !strings.Contains(","+u.SegmentString+",", ","+f.Segment+","),
+		f.NameContains != "" && !strings.Contains(strings.ToLower(u.FirstName+" "+u.LastName), strings.ToLower(f.NameContains)),
+		f.CreatedAfter != nil && !u.CreatedAt.After(*f.CreatedAfter),
+		f.CreatedBefore != nil && !u.CreatedAt.Before(*f.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+// List orders the users by registration only, whatever order is.
+func (r *userRepo) List(f filter.Expr, by user.Filter, order string, limit, offset int, count db.Count) ([]user.User, int, error) {
+	if !f.Empty() {
+		return make([]user.User, 0), 0, ErrUnsupportedFilter
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.selected(by)
+	total := len(users)
+	if offset > len(users) {
+		offset = len(users)
+	}
+	users = users[offset:]
+	if count == db.CountNone {
+		// As in postgres, one row past the page tells of a next one.
+		limit++
+	}
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	if count == db.CountNone {
+		total = offset + len(users)
+	}
+	return users, total, nil
 }
 
-func (r userRepo) GetByResetKey(key string) (user.User, error) {
-	for _, v := range r {
-		if v.ResetKey == key {
-			return v, nil
+func (r *userRepo) ListByCursor(f filter.Expr, by user.Filter, c user.Cursor, limit int) ([]user.User, bool, error) {
+	if !f.Empty() {
+		return make([]user.User, 0), false, ErrUnsupportedFilter
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := user.User{ID: c.ID, CreatedAt: c.CreatedAt}
+	users := make([]user.User, 0)
+	for _, u := range r.selected(by) {
+		if c.Start() || (c.Before && before(u, at)) || (!c.Before && before(at, u)) {
+			users = append(users, u)
 		}
 	}
-	return user.User{}, fmt.Errorf("user %v", db.ErrNotFound)
+	more := len(users) > limit
+	if !more {
+		return users, false, nil
+	}
+	if c.Before {
+		return users[len(users)-limit:], true, nil
+	}
+	return users[:limit], true, nil
 }
 
-func (r userRepo) List() ([]user.User, error) {
+// Search matches a part of the name or email of the users, unranked.
+func (r *userRepo) Search(query string, limit, offset int) ([]user.User, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	query = strings.ToLower(query)
 	users := make([]user.User, 0)
-	for _, v := range r {
-		users = append(users, v)
+	for _, u := range r.selected(user.Filter{}) {
+		if strings.Contains(strings.ToLower(u.FirstName+" "+u.LastName+" "+u.Email), query) {
+			users = append(users, u)
+		}
+	}
+	total := len(users)
+	if offset > len(users) {
+		offset = len(users)
+	}
+	users = users[offset:]
+	if len(users) > limit {
+		users = users[:limit]
 	}
+	return users, total, nil
+}
+
+func (r *userRepo) ListByFilter(f user.Filter) ([]user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.selected(f)
+	sort.SliceStable(users, func(i, j int) bool { return users[i].Email < users[j].Email })
 	return users, nil
 }
 
-func (r userRepo) Create(user *user.User) error {
-	global.Lock()
-	global.ID = uuid.NewV4().String()
-	user.ID = global.ID
-	global.Unlock()
+func (r *userRepo) Create(u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if _, ok := r[user.ID]; ok {
+	if u.ID == "" {
+		u.ID = newID()
+	}
+	if _, ok := r.users[u.ID]; ok {
 		return db.ErrAlreadyExists
 	}
-	r[user.ID] = *user
+	r.users[u.ID] = *u
 	return nil
 }
 
-func (r userRepo) Save(user *user.User) error {
-	r[user.ID] = *user
+func (r *userRepo) Save(u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.users[u.ID]
+	if !ok {
+		return db.ErrNotFound
+	}
+	if cur.Version != u.Version {
+		return db.ErrConflict
+	}
+	u.Version++
+	r.users[u.ID] = *u
 	return nil
 }
 
-func (r userRepo) Drop() error {
-	for k := range r {
-		delete(r, k)
+// update changes the stored user with the ID in place, without bumping its
+// version.
+func (r *userRepo) update(ID string, change func(u *user.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[ID]
+	if !ok {
+		return nil
 	}
+	change(&u)
+	r.users[ID] = u
 	return nil
 }
 
-func NewUserRepo() user.Repo {
-	return userRepo{}
+func (r *userRepo) CreateJob(j *user.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if j.ID == "" {
+		j.ID = newID()
+	}
+	r.jobs[j.ID] = *j
+	return nil
+}
+
+func (r *userRepo) GetJob(ID string) (user.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.jobs[ID]
+	if !ok {
+		return user.Job{}, db.ErrNotFound
+	}
+	return j, nil
+}
+
+func (r *userRepo) SaveJob(j *user.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[j.ID] = *j
+	return nil
+}
+
+func (r *userRepo) ListJobs() ([]user.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]user.Job, 0)
+	for _, j := range r.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].CreatedAt.After(jobs[k].CreatedAt) })
+	return jobs, nil
+}
+
+func (r *userRepo) ListJobsByStatus(status ...string) ([]user.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]user.Job, 0)
+	for _, j := range r.jobs {
+		if in(j.Status, status) {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].CreatedAt.Before(jobs[k].CreatedAt) })
+	return jobs, nil
+}
+
+func (r *userRepo) CreateResults(results []user.Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n := range results {
+		results[n].ID = newID()
+	}
+	r.results = append(r.results, results...)
+	return nil
+}
+
+func (r *userRepo) ListResults(jobID string, status ...string) ([]user.Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]user.Result, 0)
+	for _, res := range r.results {
+		if res.JobID == jobID && (len(status) == 0 || in(res.Status, status)) {
+			results = append(results, res)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Email < results[j].Email })
+	return results, nil
+}
+
+func (r *userRepo) CreateRefreshToken(t *user.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.ID == "" {
+		t.ID = newID()
+	}
+	r.refreshes[t.ID] = *t
+	return nil
+}
+
+func (r *userRepo) GetRefreshToken(hash string) (user.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.refreshes {
+		if t.Hash == hash {
+			return t, nil
+		}
+	}
+	return user.RefreshToken{}, db.ErrNotFound
+}
+
+func (r *userRepo) RotateRefreshToken(old, next *user.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.refreshes[old.ID]
+	if !ok || cur.RevokedAt != nil {
+		return db.ErrConflict
+	}
+	if next.ID == "" {
+		next.ID = newID()
+	}
+	cur.RevokedAt, cur.ReplacedBy = &next.CreatedAt, next.ID
+	r.refreshes[cur.ID] = cur
+	r.refreshes[next.ID] = *next
+	old.RevokedAt, old.ReplacedBy = cur.RevokedAt, cur.ReplacedBy
+	return nil
+}
+
+func (r *userRepo) RevokeRefreshTokens(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revokeRefreshTokens(func(t user.RefreshToken) bool { return t.UserID == userID }, at)
+	return nil
+}
+
+func (r *userRepo) revokeRefreshTokens(match func(user.RefreshToken) bool, at time.Time) {
+	for id, t := range r.refreshes {
+		if t.RevokedAt == nil && match(t) {
+			t.RevokedAt = &at
+			r.refreshes[id] = t
+		}
+	}
+}
+
+func (r *userRepo) CreateSession(s *user.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.ID == "" {
+		s.ID = newID()
+	}
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *userRepo) GetSession(ID string) (user.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[ID]
+	if !ok {
+		return user.Session{}, db.ErrNotFound
+	}
+	return s, nil
+}
+
+func (r *userRepo) ListSessions(userID string) ([]user.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]user.Session, 0)
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *userRepo) SeeSession(ID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[ID]; ok {
+		s.LastSeenAt = at
+		r.sessions[ID] = s
+	}
+	return nil
+}
+
+func (r *userRepo) RevokeSession(ID string, at time.Time) error {
+	return r.revokeSessions(at,
+		func(s user.Session) bool { return s.ID == ID },
+		func(t user.RefreshToken) bool { return t.SessionID == ID })
+}
+
+func (r *userRepo) RevokeSessions(userID string, at time.Time) error {
+	return r.revokeSessions(at,
+		func(s user.Session) bool { return s.UserID == userID || s.ImpersonatedBy == userID },
+		func(t user.RefreshToken) bool { return t.UserID == userID })
+}
+
+func (r *userRepo) revokeSessions(at time.Time, session func(user.Session) bool, token func(user.RefreshToken) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, s := range r.sessions {
+		if s.RevokedAt == nil && session(s) {
+			s.RevokedAt = &at
+			r.sessions[id] = s
+		}
+	}
+	r.revokeRefreshTokens(token, at)
+	return nil
+}
+
+func (r *userRepo) CreateTrustedDevice(d *user.TrustedDevice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d.ID == "" {
+		d.ID = newID()
+	}
+	r.devices[d.ID] = *d
+	return nil
+}
+
+func (r *userRepo) GetTrustedDevice(hash string) (user.TrustedDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.devices {
+		if d.Hash == hash {
+			return d, nil
+		}
+	}
+	return user.TrustedDevice{}, db.ErrNotFound
+}
+
+func (r *userRepo) ListTrustedDevices(userID string) ([]user.TrustedDevice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	devices := make([]user.TrustedDevice, 0)
+	for _, d := range r.devices {
+		if d.UserID == userID && d.RevokedAt == nil {
+			devices = append(devices, d)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].LastUsedAt.After(devices[j].LastUsedAt) })
+	return devices, nil
+}
+
+func (r *userRepo) UseTrustedDevice(ID, ip string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d, ok := r.devices[ID]; ok {
+		d.LastUsedAt, d.IP = at, ip
+		r.devices[ID] = d
+	}
+	return nil
+}
+
+func (r *userRepo) RevokeTrustedDevice(ID string, at time.Time) error {
+	return r.revokeTrustedDevices(func(d user.TrustedDevice) bool { return d.ID == ID }, at)
+}
+
+func (r *userRepo) RevokeTrustedDevices(userID string, at time.Time) error {
+	return r.revokeTrustedDevices(func(d user.TrustedDevice) bool { return d.UserID == userID }, at)
+}
+
+func (r *userRepo) revokeTrustedDevices(match func(user.TrustedDevice) bool, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, d := range r.devices {
+		if d.RevokedAt == nil && match(d) {
+			d.RevokedAt = &at
+			r.devices[id] = d
+		}
+	}
+	return nil
+}
+
+func (r *userRepo) CreateAddress(a *user.Address) error {
+	if a.ID == "" {
+		a.ID = newID()
+	}
+	return r.SaveAddress(a)
+}
+
+// SaveAddress unsets the other defaults of the user along with saving a
+// default address.
+func (r *userRepo) SaveAddress(a *user.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a.IsDefault {
+		for id, other := range r.addresses {
+			if other.UserID == a.UserID && other.IsDefault {
+				other.IsDefault = false
+				r.addresses[id] = other
+			}
+		}
+	}
+	r.addresses[a.ID] = *a
+	return nil
+}
+
+func (r *userRepo) ListAddresses(userID string) ([]user.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	addresses := make([]user.Address, 0)
+	for _, a := range r.addresses {
+		if a.UserID == userID {
+			addresses = append(addresses, a)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IsDefault != addresses[j].IsDefault {
+			return addresses[i].IsDefault
+		}
+		return addresses[i].UpdatedAt.After(addresses[j].UpdatedAt)
+	})
+	return addresses, nil
+}
+
+func (r *userRepo) DeleteAddress(ID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.addresses, ID)
+	return nil
+}
+
+func (r *userRepo) GetSocialAccount(provider, subject string) (user.SocialAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.accounts[provider+"/"+subject]
+	if !ok {
+		return user.SocialAccount{}, db.ErrNotFound
+	}
+	return a, nil
+}
+
+func (r *userRepo) LinkSocialAccount(u *user.User, a *user.SocialAccount) error {
+	if u.ID == "" {
+		if err := r.Create(u); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a.UserID = u.ID
+	r.accounts[a.Provider+"/"+a.Subject] = *a
+	return nil
+}
+
+func (r *userRepo) SetSegments(userID string, segments []string) error {
+	return r.update(userID, func(u *user.User) { u.SegmentString = strings.Join(segments, ",") })
+}
+
+func (r *userRepo) CreateResetToken(t *user.ResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.ID == "" {
+		t.ID = newID()
+	}
+	r.resets[t.ID] = *t
+	return nil
+}
+
+func (r *userRepo) GetResetToken(hash string) (user.ResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.resets {
+		if t.Hash == hash {
+			return t, nil
+		}
+	}
+	return user.ResetToken{}, db.ErrNotFound
+}
+
+func (r *userRepo) UseResetToken(t *user.ResetToken, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.resets[t.ID]
+	if !ok || cur.UsedAt != nil {
+		return db.ErrConflict
+	}
+	cur.UsedAt = &at
+	r.resets[t.ID] = cur
+	t.UsedAt = &at
+	return nil
+}
+
+func (r *userRepo) RecordLoginFailure(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failures = append(r.failures, user.LoginFailure{ID: newID(), UserID: userID, At: at})
+	return nil
+}
+
+func (r *userRepo) CountLoginFailures(userID string, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, f := range r.failures {
+		if f.UserID == userID && !f.At.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *userRepo) ClearLoginFailures(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failures = r.failuresBut(userID)
+	return nil
+}
+
+// failuresBut returns the login failures of the users other than userID.
+func (r *userRepo) failuresBut(userID string) []user.LoginFailure {
+	var kept []user.LoginFailure
+	for _, f := range r.failures {
+		if f.UserID != userID {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func (r *userRepo) SetLockedUntil(userID string, until *time.Time) error {
+	return r.update(userID, func(u *user.User) { u.LockedUntil = until })
+}
+
+func (r *userRepo) Forget(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, s := range r.sessions {
+		if s.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	for id, d := range r.devices {
+		if d.UserID == userID {
+			delete(r.devices, id)
+		}
+	}
+	for id, t := range r.refreshes {
+		if t.UserID == userID {
+			delete(r.refreshes, id)
+		}
+	}
+	for id, t := range r.resets {
+		if t.UserID == userID {
+			delete(r.resets, id)
+		}
+	}
+	for key, a := range r.accounts {
+		if a.UserID == userID {
+			delete(r.accounts, key)
+		}
+	}
+	for id, a := range r.addresses {
+		if a.UserID == userID {
+			delete(r.addresses, id)
+		}
+	}
+	r.failures = r.failuresBut(userID)
+	return nil
+}
+
+func (r *userRepo) Drop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users = make(map[string]user.User)
+	r.jobs = make(map[string]user.Job)
+	r.results = nil
+	r.refreshes = make(map[string]user.RefreshToken)
+	r.sessions = make(map[string]user.Session)
+	r.devices = make(map[string]user.TrustedDevice)
+	r.addresses = make(map[string]user.Address)
+	r.accounts = make(map[string]user.SocialAccount)
+	r.resets = make(map[string]user.ResetToken)
+	r.failures = nil
+	return nil
+}
+
+func in(s string, list []string) bool {
+	for _, l := range list {
+		if s == l {
+			return true
+		}
+	}
+	return false
 }
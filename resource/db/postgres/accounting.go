@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/accounting"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type accountingRepo struct {
+	db *gorm.DB
+}
+
+func NewAccountingRepo(driver, source string) (accounting.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&accounting.Batch{}, &accounting.Total{})
+	return &accountingRepo{db: db}, nil
+}
+
+// Create stores the batch and its totals in one transaction.
+func (r *accountingRepo) Create(b *accounting.Batch) error {
+	tx := r.db.New().Begin()
+
+	if b.ID == "" {
+		b.ID = NewID()
+	}
+	totals := b.Totals
+	b.Totals = nil
+	if err := tx.Create(b).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range totals {
+		totals[i].BatchID = b.ID
+		if err := tx.Create(&totals[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	b.Totals = totals
+	return tx.Commit().Error
+}
+
+func (r *accountingRepo) get(where ...interface{}) (accounting.Batch, error) {
+	var b accounting.Batch
+	d := r.db.New()
+
+	if err := d.Preload("Totals").First(&b, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return accounting.Batch{}, db.ErrNotFound
+		}
+		return accounting.Batch{}, err
+	}
+	return b, nil
+}
+
+func (r *accountingRepo) Get(ID string) (accounting.Batch, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *accountingRepo) GetByPeriod(start time.Time) (accounting.Batch, error) {
+	return r.get("period_start=?", start)
+}
+
+func (r *accountingRepo) Last() (accounting.Batch, error) {
+	var b accounting.Batch
+	d := r.db.New()
+
+	if err := d.Order("period_start DESC").First(&b).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return accounting.Batch{}, db.ErrNotFound
+		}
+		return accounting.Batch{}, err
+	}
+	return b, nil
+}
+
+// List leaves the data of the batches out.
+func (r *accountingRepo) List() ([]accounting.Batch, error) {
+	batches := make([]accounting.Batch, 0)
+	d := r.db.New()
+
+	err := d.Select("id, period_start, period_end, format, lines, created_at").
+		Preload("Totals").Order("period_start DESC").Find(&batches).Error
+	return batches, err
+}
+
+func (r *accountingRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ACCOUNTING_TOTALS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM ACCOUNTING_BATCHES").Error
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/address"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type addressRepo struct {
+	db *gorm.DB
+}
+
+func NewAddressRepo(driver, source string) (address.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&address.Check{})
+	return &addressRepo{db: db}, nil
+}
+
+func (r *addressRepo) SaveCheck(c *address.Check) error {
+	d := r.db.New()
+
+	var count int
+	if err := d.Model(&address.Check{}).Where("order_id=?", c.OrderID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return d.Create(c).Error
+	}
+
+	if err := d.Save(c).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *addressRepo) GetCheck(orderID string) (address.Check, error) {
+	var c address.Check
+	d := r.db.New()
+
+	if err := d.First(&c, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return address.Check{}, db.ErrNotFound
+		}
+		return address.Check{}, err
+	}
+	return c, nil
+}
+
+func (r *addressRepo) Drop() error {
+	return r.db.Exec("DELETE FROM ADDRESS_CHECKS").Error
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/affiliate"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	_ "github.com/lib/pq"
+)
+
+type affiliateRepo struct {
+	db *gorm.DB
+}
+
+func NewAffiliateRepo(driver, source string) (affiliate.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&affiliate.Affiliate{}, &affiliate.Click{}, &affiliate.Attribution{})
+	return &affiliateRepo{db: db}, nil
+}
+
+func (r *affiliateRepo) get(where ...interface{}) (affiliate.Affiliate, error) {
+	var a affiliate.Affiliate
+	d := r.db.New()
+
+	if err := d.First(&a, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return affiliate.Affiliate{}, db.ErrNotFound
+		}
+		return affiliate.Affiliate{}, err
+	}
+	return a, nil
+}
+
+func (r *affiliateRepo) Create(a *affiliate.Affiliate) error {
+	d := r.db.New()
+
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+
+	if err := d.Create(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *affiliateRepo) Save(a *affiliate.Affiliate) error {
+	d := r.db.New()
+
+	if err := d.Save(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *affiliateRepo) GetByID(id string) (affiliate.Affiliate, error) {
+	return r.get("id=?", id)
+}
+
+func (r *affiliateRepo) GetByCode(code string) (affiliate.Affiliate, error) {
+	return r.get("code=?", code)
+}
+
+func (r *affiliateRepo) List(f filter.Expr) ([]affiliate.Affiliate, error) {
+	affiliates := make([]affiliate.Affiliate, 0)
+	d, _ := filtered(r.db.New(), f, db.CountNone)
+
+	err := d.Order("created_at").Find(&affiliates).Error
+	return affiliates, err
+}
+
+func (r *affiliateRepo) CreateClick(c *affiliate.Click) error {
+	d := r.db.New()
+
+	if c.ID == "" {
+		c.ID = NewID()
+	}
+
+	if err := d.Create(c).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *affiliateRepo) CountClicks(affiliateID string, from, to time.Time) (int, error) {
+	var count int
+	d := r.db.New()
+
+	err := d.Model(&affiliate.Click{}).
+		Where("affiliate_id=? AND at >= ? AND at < ?", affiliateID, from, to).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *affiliateRepo) CreateAttribution(a *affiliate.Attribution) error {
+	d := r.db.New()
+
+	if err := d.Create(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *affiliateRepo) GetAttribution(orderID string) (affiliate.Attribution, error) {
+	var a affiliate.Attribution
+	d := r.db.New()
+
+	if err := d.First(&a, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return affiliate.Attribution{}, db.ErrNotFound
+		}
+		return affiliate.Attribution{}, err
+	}
+	return a, nil
+}
+
+func (r *affiliateRepo) ListAttributions(affiliateID string, from, to time.Time) ([]affiliate.Attribution, error) {
+	attributions := make([]affiliate.Attribution, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").
+		Find(&attributions, "affiliate_id=? AND created_at >= ? AND created_at < ?", affiliateID, from, to).Error
+	return attributions, err
+}
+
+func (r *affiliateRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM AFFILIATE_ATTRIBUTIONS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM AFFILIATE_CLICKS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM AFFILIATES").Error
+}
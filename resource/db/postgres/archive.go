@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/archive"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type archiveRepo struct {
+	db *gorm.DB
+}
+
+func NewArchiveRepo(driver, source string) (archive.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&archive.Document{}, &archive.Refund{}, &archive.Footer{})
+	return &archiveRepo{db: db}, nil
+}
+
+// Append locks the table against other appends for the transaction, so
+// that every document is sealed after the actual last one.
+func (r *archiveRepo) Append(docs ...*archive.Document) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Exec("LOCK TABLE archived_documents IN EXCLUSIVE MODE").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	var last archive.Document
+	if err := tx.Order("seq DESC").First(&last).Error; err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return err
+	}
+	for _, d := range docs {
+		d.ID = NewID()
+		d.Seal(last)
+		if err := tx.Create(d).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		last = *d
+	}
+	return tx.Commit().Error
+}
+
+// AppendRefund locks the ledger as well as the chain, so that the credit
+// notes are numbered without gaps, in the order they are sealed.
+func (r *archiveRepo) AppendRefund(rf *archive.Refund, note func(rf archive.Refund) (*archive.Document, error)) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Exec("LOCK TABLE refund_ledger, archived_documents IN EXCLUSIVE MODE").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	var lastRefund archive.Refund
+	if err := tx.Order("seq DESC").First(&lastRefund).Error; err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return err
+	}
+	var last archive.Document
+	if err := tx.Order("seq DESC").First(&last).Error; err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return err
+	}
+	rf.ID = NewID()
+	rf.Seq = lastRefund.Seq + 1
+	rf.Number = archive.Number(rf.Seq)
+	d, err := note(*rf)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	d.ID = NewID()
+	d.Seal(last)
+	if err := tx.Create(d).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	rf.DocumentID, rf.IssuedAt = d.ID, d.IssuedAt
+	if err := tx.Create(rf).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *archiveRepo) GetRefund(ID string) (archive.Refund, error) {
+	var rf archive.Refund
+	d := r.db.New()
+
+	if err := d.First(&rf, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return archive.Refund{}, db.ErrNotFound
+		}
+		return archive.Refund{}, err
+	}
+	return rf, nil
+}
+
+func (r *archiveRepo) ListRefunds(from, to time.Time) ([]archive.Refund, error) {
+	refunds := make([]archive.Refund, 0)
+	d := r.db.New()
+
+	err := d.Order("seq").Find(&refunds, "issued_at>=? AND issued_at<?", from, to).Error
+	return refunds, err
+}
+
+func (r *archiveRepo) ListFooters() ([]archive.Footer, error) {
+	footers := make([]archive.Footer, 0)
+	d := r.db.New()
+
+	err := d.Order("country").Find(&footers).Error
+	return footers, err
+}
+
+func (r *archiveRepo) GetFooter(country string) (archive.Footer, error) {
+	var f archive.Footer
+	d := r.db.New()
+
+	if err := d.First(&f, "country=?", country).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return archive.Footer{}, db.ErrNotFound
+		}
+		return archive.Footer{}, err
+	}
+	return f, nil
+}
+
+// SaveFooter relies on the country being the primary key, Save updating
+// the footer of the country if any.
+func (r *archiveRepo) SaveFooter(f *archive.Footer) error {
+	d := r.db.New()
+
+	return d.Save(f).Error
+}
+
+// DeleteFooter is the only delete of the archive, the footers aren't
+// archived documents.
+func (r *archiveRepo) DeleteFooter(country string) error {
+	d := r.db.New().Delete(&archive.Footer{}, "country=?", country)
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (r *archiveRepo) Get(ID string) (archive.Document, error) {
+	var doc archive.Document
+	d := r.db.New()
+
+	if err := d.First(&doc, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return archive.Document{}, db.ErrNotFound
+		}
+		return archive.Document{}, err
+	}
+	return doc, nil
+}
+
+func (r *archiveRepo) ListByOrder(orderID string) ([]archive.Document, error) {
+	docs := make([]archive.Document, 0)
+	d := r.db.New()
+
+	err := d.Order("seq").Find(&docs, "order_id=?", orderID).Error
+	return docs, err
+}
+
+func (r *archiveRepo) ListIssued(kind string, from, to time.Time) ([]archive.Document, error) {
+	docs := make([]archive.Document, 0)
+	d := r.db.New()
+
+	err := d.Order("seq").Find(&docs, "kind=? AND issued_at>=? AND issued_at<?", kind, from, to).Error
+	return docs, err
+}
+
+func (r *archiveRepo) List() ([]archive.Document, error) {
+	docs := make([]archive.Document, 0)
+	d := r.db.New()
+
+	err := d.Order("seq").Find(&docs).Error
+	return docs, err
+}
+
+func (r *archiveRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM REFUND_LEDGER").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM LEGAL_FOOTERS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM ARCHIVED_DOCUMENTS").Error
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/audiobook"
+	_ "github.com/lib/pq"
+)
+
+type audiobookRepo struct {
+	db *gorm.DB
+}
+
+func NewAudiobookRepo(driver, source string) (audiobook.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&audiobook.Variant{}, &audiobook.Position{})
+	return &audiobookRepo{db: db}, nil
+}
+
+func (r *audiobookRepo) CreateVariant(v *audiobook.Variant) error {
+	d := r.db.New()
+
+	if v.ID == "" {
+		v.ID = NewID()
+	}
+
+	if err := d.Create(v).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *audiobookRepo) ListVariants(bookID string) ([]audiobook.Variant, error) {
+	variants := make([]audiobook.Variant, 0)
+	d := r.db.New()
+
+	err := d.Order("bitrate").Find(&variants, "book_id=?", bookID).Error
+	return variants, err
+}
+
+func (r *audiobookRepo) SavePosition(p *audiobook.Position) error {
+	d := r.db.New()
+
+	if p.ID == "" {
+		p.ID = NewID()
+		return d.Create(p).Error
+	}
+
+	if err := d.Save(p).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *audiobookRepo) ListPositions(userID, bookID string) ([]audiobook.Position, error) {
+	positions := make([]audiobook.Position, 0)
+	d := r.db.New()
+
+	err := d.Order("updated_at desc").Find(&positions, "user_id=? AND book_id=?", userID, bookID).Error
+	return positions, err
+}
+
+func (r *audiobookRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM POSITIONS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM VARIANTS").Error
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/audit"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	_ "github.com/lib/pq"
+)
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(driver, source string) (audit.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&audit.Event{})
+	return &auditRepo{db: db}, nil
+}
+
+func (r *auditRepo) Create(e *audit.Event) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+
+	if err := d.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *auditRepo) List(f filter.Expr, limit, offset int, count db.Count) ([]audit.Event, int, error) {
+	events := make([]audit.Event, 0)
+	d, count := filtered(r.db.New().Order("created_at desc, id"), f, count)
+
+	if err := d.Limit(fetchLimit(limit, count)).Offset(offset).Find(&events).Error; err != nil {
+		return events, 0, err
+	}
+	total, err := total(d, &audit.Event{}, offset+len(events), count)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, total, err
+}
+
+func (r *auditRepo) Drop() error {
+	return r.db.Exec("DELETE FROM AUDIT_EVENTS").Error
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/browsing"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type browsingRepo struct {
+	db *gorm.DB
+}
+
+func NewBrowsingRepo(driver, source string) (browsing.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&browsing.Visitor{}, &browsing.View{})
+	return &browsingRepo{db: db}, nil
+}
+
+func (r *browsingRepo) Visitor(id string) (browsing.Visitor, error) {
+	var v browsing.Visitor
+	d := r.db.New()
+
+	if err := d.First(&v, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return browsing.Visitor{}, db.ErrNotFound
+		}
+		return browsing.Visitor{}, err
+	}
+	return v, nil
+}
+
+func (r *browsingRepo) SaveVisitor(v *browsing.Visitor) error {
+	d := r.db.New()
+	return d.Save(v).Error
+}
+
+func (r *browsingRepo) Views(visitorID string) ([]browsing.View, error) {
+	views := make([]browsing.View, 0)
+	d := r.db.New()
+
+	err := d.Order("viewed_at desc").Find(&views, "visitor_id=?", visitorID).Error
+	return views, err
+}
+
+func (r *browsingRepo) UserViews(userID string) ([]browsing.View, error) {
+	views := make([]browsing.View, 0)
+	d := r.db.New()
+
+	err := d.Order("viewed_at desc").Find(&views, "user_id=?", userID).Error
+	return views, err
+}
+
+func (r *browsingRepo) SaveView(v *browsing.View) error {
+	d := r.db.New()
+	if v.ID == "" {
+		v.ID = NewID()
+		return d.Create(v).Error
+	}
+	return d.Save(v).Error
+}
+
+func (r *browsingRepo) DeleteViews(ids []string) error {
+	d := r.db.New()
+	return d.Where("id IN (?)", ids).Delete(&browsing.View{}).Error
+}
+
+func (r *browsingRepo) LinkViews(visitorID, userID string) error {
+	d := r.db.New()
+	return d.Exec("UPDATE browsing_views SET user_id=? WHERE visitor_id=? AND user_id=''", userID, visitorID).Error
+}
+
+func (r *browsingRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM BROWSING_VIEWS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM BROWSING_VISITORS").Error
+}
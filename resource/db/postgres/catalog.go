@@ -1,11 +1,14 @@
 package postgres
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/kavirajk/bookshop/catalog"
 	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 	_ "github.com/lib/pq"
 )
 
@@ -14,11 +17,11 @@ type catalogRepo struct {
 }
 
 func NewCatalogRepo(driver, source string) (catalog.Repo, error) {
-	db, err := gorm.Open(driver, source)
+	db, err := open(driver, source)
 	if err != nil {
 		return nil, err
 	}
-	db.AutoMigrate(&catalog.Book{}, &catalog.Author{}, &catalog.Publisher{}, &catalog.Genre{})
+	db.AutoMigrate(&catalog.Book{}, &catalog.Author{}, &catalog.Publisher{}, &catalog.Genre{}, &catalog.SearchConfig{}, &catalog.Rule{}, &catalog.RuleChange{}, &catalog.Change{}, &catalog.PriceChange{}, &catalog.PriceList{}, &catalog.ListPrice{})
 	return &catalogRepo{db: db}, nil
 }
 
@@ -26,7 +29,7 @@ func (r *catalogRepo) get(where ...interface{}) (catalog.Book, error) {
 	var b catalog.Book
 	d := r.db.New()
 
-	if err := d.First(&b, where...).Error; err != nil {
+	if err := d.Preload("Authors").Preload("Genres").Preload("Publisher").First(&b, where...).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return catalog.Book{}, db.ErrNotFound
 		}
@@ -57,6 +60,10 @@ func (r *catalogRepo) ListByAuthor(authorID string) ([]catalog.Book, error) {
 	return nil, nil
 }
 
+func (r *catalogRepo) ListByVendor(vendorID string) ([]catalog.Book, error) {
+	return r.filter("vendor_id=?", vendorID)
+}
+
 func (r *catalogRepo) GetByToken(token string) (catalog.Book, error) {
 	return r.get("auth_token=?", token)
 }
@@ -65,51 +72,403 @@ func (r *catalogRepo) GetByResetKey(key string) (catalog.Book, error) {
 	return r.get("reset_key=?", key)
 }
 
-func (r *catalogRepo) List(order string, limit, offset int) ([]catalog.Book, int, error) {
+func (r *catalogRepo) List(f filter.Expr, order string, limit, offset int, count db.Count) ([]catalog.Book, int, error) {
 	catalogs := make([]catalog.Book, 0)
-	db := r.db.New()
+	d, count := filtered(r.db.New().Order(order), f, count)
 
-	var total int
-	if err := db.Model(&catalog.Book{}).Order(order).Count(&total).Error; err != nil {
+	if err := d.Limit(fetchLimit(limit, count)).Offset(offset).Find(&catalogs).Error; err != nil {
 		return catalogs, 0, err
 	}
-
-	err := db.Order(order).Limit(limit).Offset(offset).Find(&catalogs).Error
+	total, err := total(d, &catalog.Book{}, offset+len(catalogs), count)
+	if len(catalogs) > limit {
+		catalogs = catalogs[:limit]
+	}
 	return catalogs, total, err
 }
 
+// ListAll returns every book in the catalog along with its authors and
+// genres.
+func (r *catalogRepo) ListAll() ([]catalog.Book, error) {
+	books := make([]catalog.Book, 0)
+	d := r.db.New()
+
+	err := d.Preload("Authors").Preload("Genres").Find(&books).Error
+	return books, err
+}
+
+func (r *catalogRepo) ListDue(now time.Time) ([]catalog.Book, error) {
+	return r.filter("(visibility = ? AND publish_at <= ?) OR (visibility IN (?) AND retire_at <= ?)",
+		catalog.VisibilityScheduled, now, []string{catalog.VisibilityScheduled, catalog.VisibilityLive}, now)
+}
+
 func (r *catalogRepo) Search(title string) ([]catalog.Book, error) {
 	books := make([]catalog.Book, 0)
 	db := r.db.New()
 	q := fmt.Sprintf("%%%s%%", title)
-	if err := db.Where("title ILIKE ?", q).Find(&books).Error; err != nil {
+	if err := db.Preload("Genres").Preload("Authors").Where("title ILIKE ?", q).Find(&books).Error; err != nil {
 		return books, err
 	}
 	return books, nil
 }
 
 func (r *catalogRepo) Create(u *catalog.Book) error {
-	d := r.db.New()
+	tx := r.db.New().Begin()
 
 	if u.ID == "" {
 		u.ID = NewID()
 	}
+	u.UpdatedAt = time.Now().UTC()
 
-	if err := d.Create(u).Error; err != nil {
+	if err := tx.Create(u).Error; err != nil {
+		tx.Rollback()
 		return err
 	}
-	return nil
+	if err := r.recordChange(tx, u, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
 }
 
 func (r *catalogRepo) Save(u *catalog.Book) error {
+	tx := r.db.New().Begin()
+
+	if err := r.save(tx, u); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// save saves u in tx along with its change, the caller rolling back on
+// error.
+func (r *catalogRepo) save(tx *gorm.DB, u *catalog.Book) error {
+	var prev catalog.Book
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&prev, "id=?", u.ID).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	if prev.ID != "" && prev.Version != u.Version {
+		return db.ErrConflict
+	}
+	u.UpdatedAt = time.Now().UTC()
+	u.Version++
+
+	if err := tx.Save(u).Error; err != nil {
+		u.Version--
+		return err
+	}
+	p := &prev
+	if prev.ID == "" {
+		p = nil
+	}
+	return r.recordChange(tx, u, p)
+}
+
+func (r *catalogRepo) recordChange(tx *gorm.DB, b, prev *catalog.Book) error {
+	c, err := catalog.NewChange(b, prev)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&c).Error
+}
+
+func (r *catalogRepo) ListChanges(afterSeq int64, since time.Time, limit int) ([]catalog.Change, error) {
+	changes := make([]catalog.Change, 0)
+	d := r.db.New()
+
+	err := d.Where("seq > ? AND changed_at >= ?", afterSeq, since).
+		Order("seq").Limit(limit).Find(&changes).Error
+	return changes, err
+}
+
+func (r *catalogRepo) ActiveSearchConfig() (catalog.SearchConfig, error) {
+	var c catalog.SearchConfig
+	d := r.db.New()
+
+	if err := d.First(&c, "active=?", true).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return catalog.SearchConfig{}, db.ErrNotFound
+		}
+		return catalog.SearchConfig{}, err
+	}
+	return c, nil
+}
+
+func (r *catalogRepo) ListSearchConfigs() ([]catalog.SearchConfig, error) {
+	configs := make([]catalog.SearchConfig, 0)
+	d := r.db.New()
+
+	err := d.Order("version desc").Find(&configs).Error
+	return configs, err
+}
+
+func (r *catalogRepo) CreateSearchConfig(c *catalog.SearchConfig) error {
+	d := r.db.New()
+
+	if err := d.Create(c).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ActivateSearchConfig flags version as the only active search config.
+func (r *catalogRepo) ActivateSearchConfig(version int) error {
+	tx := r.db.New().Begin()
+
+	var count int
+	if err := tx.Model(&catalog.SearchConfig{}).Where("version=?", version).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if count == 0 {
+		tx.Rollback()
+		return db.ErrNotFound
+	}
+	if err := tx.Model(&catalog.SearchConfig{}).Where("active=?", true).UpdateColumn("active", false).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&catalog.SearchConfig{}).Where("version=?", version).UpdateColumn("active", true).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *catalogRepo) ListRules() ([]catalog.Rule, error) {
+	rules := make([]catalog.Rule, 0)
+	d := r.db.New()
+
+	err := d.Order("query, created_at").Find(&rules).Error
+	return rules, err
+}
+
+func (r *catalogRepo) ListRulesByQuery(query string) ([]catalog.Rule, error) {
+	rules := make([]catalog.Rule, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&rules, "query=?", query).Error
+	return rules, err
+}
+
+func (r *catalogRepo) GetRule(id string) (catalog.Rule, error) {
+	var rule catalog.Rule
+	d := r.db.New()
+
+	if err := d.First(&rule, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return catalog.Rule{}, db.ErrNotFound
+		}
+		return catalog.Rule{}, err
+	}
+	return rule, nil
+}
+
+func (r *catalogRepo) CreateRule(rule *catalog.Rule, c *catalog.RuleChange) error {
+	if rule.ID == "" {
+		rule.ID = NewID()
+	}
+	c.RuleID = rule.ID
+	if c.After == "" {
+		b, err := json.Marshal(rule)
+		if err != nil {
+			return err
+		}
+		c.After = string(b)
+	}
+	return r.writeRule(c, func(tx *gorm.DB) error {
+		return tx.Create(rule).Error
+	})
+}
+
+func (r *catalogRepo) SaveRule(rule *catalog.Rule, c *catalog.RuleChange) error {
+	return r.writeRule(c, func(tx *gorm.DB) error {
+		return tx.Save(rule).Error
+	})
+}
+
+func (r *catalogRepo) DeleteRule(id string, c *catalog.RuleChange) error {
+	return r.writeRule(c, func(tx *gorm.DB) error {
+		return tx.Delete(&catalog.Rule{}, "id=?", id).Error
+	})
+}
+
+// writeRule runs write and records the change c in the same transaction.
+func (r *catalogRepo) writeRule(c *catalog.RuleChange, write func(tx *gorm.DB) error) error {
+	tx := r.db.New().Begin()
+
+	if err := write(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if c.ID == "" {
+		c.ID = NewID()
+	}
+	if err := tx.Create(c).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *catalogRepo) ListRuleChanges(ruleID string) ([]catalog.RuleChange, error) {
+	changes := make([]catalog.RuleChange, 0)
+	d := r.db.New().Order("changed_at desc")
+
+	if ruleID != "" {
+		d = d.Where("rule_id=?", ruleID)
+	}
+	err := d.Find(&changes).Error
+	return changes, err
+}
+
+func (r *catalogRepo) CreatePriceChange(c *catalog.PriceChange) error {
+	d := r.db.New()
+
+	if c.ID == "" {
+		c.ID = NewID()
+	}
+	return d.Create(c).Error
+}
+
+func (r *catalogRepo) GetPriceChange(id string) (catalog.PriceChange, error) {
+	var c catalog.PriceChange
+	d := r.db.New()
+
+	if err := d.First(&c, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return catalog.PriceChange{}, db.ErrNotFound
+		}
+		return catalog.PriceChange{}, err
+	}
+	return c, nil
+}
+
+func (r *catalogRepo) ListPriceChanges(bookID string, status ...string) ([]catalog.PriceChange, error) {
+	changes := make([]catalog.PriceChange, 0)
+	d := r.db.New()
+
+	if bookID != "" {
+		d = d.Where("book_id=?", bookID)
+	}
+	if len(status) > 0 {
+		d = d.Where("status IN (?)", status)
+	}
+	err := d.Order("starts_at").Find(&changes).Error
+	return changes, err
+}
+
+func (r *catalogRepo) ListDuePriceChanges(now time.Time) ([]catalog.PriceChange, error) {
+	changes := make([]catalog.PriceChange, 0)
 	d := r.db.New()
 
-	if err := d.Save(u).Error; err != nil {
+	err := d.Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)",
+		catalog.PriceScheduled, now, catalog.PriceActive, now).
+		Order("starts_at").Find(&changes).Error
+	return changes, err
+}
+
+// SavePriceChanges only updates a change still at its version, two runs
+// of the job can't both apply it.
+func (r *catalogRepo) SavePriceChanges(book *catalog.Book, changes ...*catalog.PriceChange) error {
+	tx := r.db.New().Begin()
+
+	if book != nil {
+		if err := r.save(tx, book); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	rollback := func(err error) error {
+		tx.Rollback()
+		if book != nil {
+			book.Version--
+		}
 		return err
 	}
+	for _, c := range changes {
+		res := tx.Model(&catalog.PriceChange{}).Where("id=? AND version=?", c.ID, c.Version).
+			Updates(map[string]interface{}{
+				"status":        c.Status,
+				"regular_price": c.RegularPrice,
+				"updated_at":    c.UpdatedAt,
+				"version":       c.Version + 1,
+			})
+		if res.Error != nil {
+			return rollback(res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return rollback(db.ErrConflict)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return rollback(err)
+	}
+	for _, c := range changes {
+		c.Version++
+	}
 	return nil
 }
 
+func (r *catalogRepo) CreatePriceList(l *catalog.PriceList) error {
+	tx := r.db.New().Begin()
+
+	if l.ID == "" {
+		l.ID = NewID()
+	}
+	if err := tx.Create(l).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range l.Prices {
+		l.Prices[i].PriceListID = l.ID
+		if err := tx.Create(&l.Prices[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *catalogRepo) ListPriceLists(market string) ([]catalog.PriceList, error) {
+	lists := make([]catalog.PriceList, 0)
+	d := r.db.New()
+
+	if market != "" {
+		d = d.Where("market=?", market)
+	}
+	err := d.Order("market, effective_from").Find(&lists).Error
+	return lists, err
+}
+
+func (r *catalogRepo) PriceListAt(market string, at time.Time) (catalog.PriceList, error) {
+	var l catalog.PriceList
+	d := r.db.New()
+
+	err := d.Where("market=? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", market, at, at).
+		Order("effective_from DESC").First(&l).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return catalog.PriceList{}, db.ErrNotFound
+		}
+		return catalog.PriceList{}, err
+	}
+	return l, nil
+}
+
+func (r *catalogRepo) ListPrices(listID string, bookIDs ...string) ([]catalog.ListPrice, error) {
+	prices := make([]catalog.ListPrice, 0)
+	d := r.db.New()
+
+	err := d.Where("price_list_id=? AND book_id IN (?)", listID, bookIDs).Find(&prices).Error
+	return prices, err
+}
+
 func (r *catalogRepo) Drop() error {
+	for _, table := range []string{"CATALOG_LIST_PRICES", "CATALOG_PRICE_LISTS", "CATALOG_PRICE_CHANGES"} {
+		if err := r.db.Exec("DELETE FROM " + table).Error; err != nil {
+			return err
+		}
+	}
 	return r.db.Exec("DELETE FROM CATALOGS").Error
 }
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/consent"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type consentRepo struct {
+	db *gorm.DB
+}
+
+func NewConsentRepo(driver, source string) (consent.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&consent.Consent{}, &consent.Record{})
+	return &consentRepo{db: db}, nil
+}
+
+func (r *consentRepo) List(userID string) ([]consent.Consent, error) {
+	consents := make([]consent.Consent, 0)
+	d := r.db.New()
+
+	err := d.Find(&consents, "user_id=?", userID).Error
+	return consents, err
+}
+
+func (r *consentRepo) Get(userID, purpose string) (consent.Consent, error) {
+	var c consent.Consent
+	d := r.db.New()
+
+	if err := d.First(&c, "user_id=? AND purpose=?", userID, purpose).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return consent.Consent{}, db.ErrNotFound
+		}
+		return consent.Consent{}, err
+	}
+	return c, nil
+}
+
+// Save stores the consent and its record in one transaction, so that no
+// change goes unrecorded.
+func (r *consentRepo) Save(c *consent.Consent, rec *consent.Record) error {
+	tx := r.db.New().Begin()
+
+	if rec.ID == "" {
+		rec.ID = NewID()
+	}
+	if err := tx.Save(c).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Create(rec).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *consentRepo) ListRecords(userID string) ([]consent.Record, error) {
+	records := make([]consent.Record, 0)
+	d := r.db.New()
+
+	err := d.Order("at").Find(&records, "user_id=?", userID).Error
+	return records, err
+}
+
+func (r *consentRepo) EraseRecords(userID string) error {
+	d := r.db.New()
+
+	return d.Model(&consent.Record{}).Where("user_id=?", userID).
+		UpdateColumns(map[string]interface{}{"ip": "", "user_agent": ""}).Error
+}
+
+func (r *consentRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM CONSENT_RECORDS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM CONSENTS").Error
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+)
+
+// fetchLimit returns the rows a page of limit rows fetches. Unless the
+// count is exact one more row is fetched, so that the next page is known
+// without a COUNT(*).
+func fetchLimit(limit int, count db.Count) int {
+	if count == db.CountExact {
+		return limit
+	}
+	return limit + 1
+}
+
+// total returns the total of the list query q of model as asked by count,
+// seen is the offset plus the rows fetched.
+func total(q *gorm.DB, model interface{}, seen int, count db.Count) (int, error) {
+	switch count {
+	case db.CountExact:
+		var n int
+		err := q.Model(model).Count(&n).Error
+		return n, err
+	case db.CountEstimate:
+		var n int64
+		// reltuples is kept up to date by autovacuum, it is -1 or 0 for
+		// tables never analyzed.
+		row := q.New().Raw("SELECT reltuples::bigint FROM pg_class WHERE relname = ?", q.NewScope(model).TableName()).Row()
+		if err := row.Scan(&n); err != nil {
+			return 0, err
+		}
+		if int(n) > seen {
+			return int(n), nil
+		}
+	}
+	return seen, nil
+}
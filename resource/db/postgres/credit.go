@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/credit"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type creditRepo struct {
+	db *gorm.DB
+}
+
+func NewCreditRepo(driver, source string) (credit.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&credit.Transaction{})
+	return &creditRepo{db: db}, nil
+}
+
+// creditLots returns the query of the lots with credit left at now.
+func creditLots(q *gorm.DB, now time.Time) *gorm.DB {
+	return q.Where("kind IN (?) AND remaining>0 AND (expires_at IS NULL OR expires_at>?)",
+		[]string{credit.KindRefund, credit.KindPromotion}, now)
+}
+
+func (r *creditRepo) Create(t *credit.Transaction) error {
+	d := r.db.New()
+
+	if t.ID == "" {
+		t.ID = NewID()
+	}
+
+	if err := d.Create(t).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *creditRepo) Get(ID string) (credit.Transaction, error) {
+	var t credit.Transaction
+	d := r.db.New()
+
+	if err := d.First(&t, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return credit.Transaction{}, db.ErrNotFound
+		}
+		return credit.Transaction{}, err
+	}
+	return t, nil
+}
+
+func (r *creditRepo) ListLots(userID string, now time.Time) ([]credit.Transaction, error) {
+	ts := make([]credit.Transaction, 0)
+	d := r.db.New()
+
+	err := creditLots(d, now).Order("created_at").Find(&ts, "user_id=?", userID).Error
+	return ts, err
+}
+
+// Spend locks the lots of the user in the currency until the transaction
+// commits, the payments of the user waiting for one another.
+func (r *creditRepo) Spend(t *credit.Transaction, now time.Time, spend func(lots []credit.Transaction) ([]credit.Transaction, error)) error {
+	tx := r.db.New().Begin()
+
+	available := make([]credit.Transaction, 0)
+	err := creditLots(tx, now).Set("gorm:query_option", "FOR UPDATE").Order("created_at").
+		Find(&available, "user_id=? AND currency=?", t.UserID, t.Currency).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	spent, err := spend(available)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, l := range spent {
+		if err := tx.Model(&credit.Transaction{}).Where("id=?", l.ID).Update("remaining", l.Remaining).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	t.ID = NewID()
+	if err := tx.Create(t).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// List can't estimate the transactions of a user from the table
+// statistics, they aren't counted then.
+func (r *creditRepo) List(userID string, limit, offset int, count db.Count) ([]credit.Transaction, int, error) {
+	ts := make([]credit.Transaction, 0)
+	if count == db.CountEstimate {
+		count = db.CountNone
+	}
+	d := r.db.New().Where("user_id=?", userID).Order("created_at desc, id")
+
+	if err := d.Limit(fetchLimit(limit, count)).Offset(offset).Find(&ts).Error; err != nil {
+		return ts, 0, err
+	}
+	total, err := total(d, &credit.Transaction{}, offset+len(ts), count)
+	if len(ts) > limit {
+		ts = ts[:limit]
+	}
+	return ts, total, err
+}
+
+func (r *creditRepo) ListExpired(now time.Time) ([]credit.Transaction, error) {
+	ts := make([]credit.Transaction, 0)
+	d := r.db.New()
+
+	err := d.Order("expires_at").Find(&ts, "kind IN (?) AND remaining>0 AND expires_at<=?",
+		[]string{credit.KindRefund, credit.KindPromotion}, now).Error
+	return ts, err
+}
+
+// Expire locks the lot, a payment spending it meanwhile being applied
+// first.
+func (r *creditRepo) Expire(t *credit.Transaction) error {
+	tx := r.db.New().Begin()
+
+	var lot credit.Transaction
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&lot, "id=?", t.LotID).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return db.ErrNotFound
+		}
+		return err
+	}
+	if lot.Remaining <= 0 {
+		tx.Rollback()
+		t.Amount = 0
+		return nil
+	}
+	t.ID, t.Amount = NewID(), -lot.Remaining
+	if err := tx.Create(t).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&lot).Update("remaining", 0).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *creditRepo) Drop() error {
+	return r.db.Exec("DELETE FROM CREDIT_TRANSACTIONS").Error
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/currency"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type currencyRepo struct {
+	db *gorm.DB
+}
+
+func NewCurrencyRepo(driver, source string) (currency.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&currency.Quote{}, &currency.OrderRate{})
+	return &currencyRepo{db: db}, nil
+}
+
+func (r *currencyRepo) SaveRates(rates currency.Rates) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Delete(&currency.Quote{}, "base=? AND date=?", rates.Base, rates.Date).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for code, rate := range rates.Rates {
+		q := currency.Quote{
+			ID:        NewID(),
+			Base:      rates.Base,
+			Currency:  code,
+			Date:      rates.Date,
+			Rate:      rate,
+			FetchedAt: rates.FetchedAt,
+		}
+		if err := tx.Create(&q).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *currencyRepo) LatestRates(base string) (currency.Rates, error) {
+	var latest currency.Quote
+	d := r.db.New()
+
+	if err := d.Order("date desc").First(&latest, "base=?", base).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return currency.Rates{}, db.ErrNotFound
+		}
+		return currency.Rates{}, err
+	}
+
+	quotes := make([]currency.Quote, 0)
+	if err := d.Find(&quotes, "base=? AND date=?", base, latest.Date).Error; err != nil {
+		return currency.Rates{}, err
+	}
+	rates := currency.Rates{
+		Base:      base,
+		Date:      latest.Date,
+		Rates:     make(map[string]float64, len(quotes)),
+		FetchedAt: latest.FetchedAt,
+	}
+	for _, q := range quotes {
+		rates.Rates[q.Currency] = q.Rate
+	}
+	return rates, nil
+}
+
+func (r *currencyRepo) CreateOrderRate(o *currency.OrderRate) error {
+	d := r.db.New()
+
+	if err := d.Create(o).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *currencyRepo) GetOrderRate(orderID string) (currency.OrderRate, error) {
+	var o currency.OrderRate
+	d := r.db.New()
+
+	if err := d.First(&o, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return currency.OrderRate{}, db.ErrNotFound
+		}
+		return currency.OrderRate{}, err
+	}
+	return o, nil
+}
+
+func (r *currencyRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ORDER_FX_RATES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM FX_RATES").Error
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/customs"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type customsRepo struct {
+	db *gorm.DB
+}
+
+func NewCustomsRepo(driver, source string) (customs.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&customs.HSCode{}, &customs.Declaration{}, &customs.Item{})
+	return &customsRepo{db: db}, nil
+}
+
+func (r *customsRepo) ListHSCodes() ([]customs.HSCode, error) {
+	codes := make([]customs.HSCode, 0)
+	d := r.db.New()
+
+	err := d.Order("genre").Find(&codes).Error
+	return codes, err
+}
+
+// SaveHSCode relies on the genre being the primary key, Save updating the
+// code if any.
+func (r *customsRepo) SaveHSCode(c *customs.HSCode) error {
+	d := r.db.New()
+
+	return d.Save(c).Error
+}
+
+func (r *customsRepo) DeleteHSCode(genre string) error {
+	d := r.db.New().Delete(&customs.HSCode{}, "genre=?", genre)
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// CreateDeclaration creates the declaration and its items in one
+// transaction.
+func (r *customsRepo) CreateDeclaration(dc *customs.Declaration) error {
+	tx := r.db.New().Begin()
+
+	if dc.ID == "" {
+		dc.ID = NewID()
+	}
+	items := dc.Items
+	dc.Items = nil
+	if err := tx.Create(dc).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range items {
+		items[i].DeclarationID = dc.ID
+		if err := tx.Create(&items[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	dc.Items = items
+	return tx.Commit().Error
+}
+
+func (r *customsRepo) get(where ...interface{}) (customs.Declaration, error) {
+	var dc customs.Declaration
+	d := r.db.New()
+
+	if err := d.Preload("Items").First(&dc, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return customs.Declaration{}, db.ErrNotFound
+		}
+		return customs.Declaration{}, err
+	}
+	return dc, nil
+}
+
+func (r *customsRepo) GetDeclaration(ID string) (customs.Declaration, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *customsRepo) GetByShipment(shipmentID string) (customs.Declaration, error) {
+	return r.get("shipment_id=?", shipmentID)
+}
+
+func (r *customsRepo) ListByOrder(orderID string) ([]customs.Declaration, error) {
+	declarations := make([]customs.Declaration, 0)
+	d := r.db.New()
+
+	err := d.Preload("Items").Order("created_at").Find(&declarations, "order_id=?", orderID).Error
+	return declarations, err
+}
+
+func (r *customsRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM CUSTOMS_ITEMS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM CUSTOMS_DECLARATIONS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM HS_CODES").Error
+}
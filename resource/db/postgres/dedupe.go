@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/catalog"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/dedupe"
+	_ "github.com/lib/pq"
+)
+
+type dedupeRepo struct {
+	db *gorm.DB
+	// books saves the merged books along with their change log.
+	books *catalogRepo
+}
+
+func NewDedupeRepo(driver, source string) (dedupe.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&dedupe.Redirect{})
+	return &dedupeRepo{db: db, books: &catalogRepo{db: db}}, nil
+}
+
+// MergeBooks saves the books and moves the lines and entries of dup in one
+// transaction.
+func (r *dedupeRepo) MergeBooks(keep, dup *catalog.Book, red *dedupe.Redirect) error {
+	tx := r.db.New().Begin()
+
+	for _, b := range []*catalog.Book{keep, dup} {
+		if err := r.books.save(tx, b); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, q := range []string{
+		`UPDATE order_lines SET book_id = ? WHERE book_id = ?
+			AND order_id NOT IN (SELECT order_id FROM order_lines WHERE book_id = ?)`,
+		`UPDATE order_cart_lines SET book_id = ? WHERE book_id = ?
+			AND user_id NOT IN (SELECT user_id FROM order_cart_lines WHERE book_id = ?)`,
+	} {
+		if err := tx.Exec(q, keep.ID, dup.ID, keep.ID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	// The ratings of dup carry over to the entries of keep not rated yet.
+	if err := tx.Exec(`UPDATE shelf_entries k SET rating = d.rating FROM shelf_entries d
+		WHERE k.book_id = ? AND d.book_id = ? AND d.user_id = k.user_id AND k.rating = 0`, keep.ID, dup.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Exec(`UPDATE shelf_entries SET book_id = ? WHERE book_id = ?
+		AND user_id NOT IN (SELECT user_id FROM shelf_entries WHERE book_id = ?)`, keep.ID, dup.ID, keep.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := r.redirect(tx, red); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// MergeAuthors moves the books of dup in one transaction. The authors are
+// linked to their books through book_authors, left alone if it isn't
+// migrated yet.
+func (r *dedupeRepo) MergeAuthors(keepID, dupID string, red *dedupe.Redirect) error {
+	tx := r.db.New().Begin()
+
+	if tx.HasTable("book_authors") {
+		if err := tx.Exec(`UPDATE book_authors SET author_id = ? WHERE author_id = ?
+			AND book_id NOT IN (SELECT book_id FROM book_authors WHERE author_id = ?)`, keepID, dupID, keepID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Exec("DELETE FROM book_authors WHERE author_id = ?", dupID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Delete(&catalog.Author{}, "id=?", dupID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := r.redirect(tx, red); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// redirect stores red, pointing the redirects to its merged record to the
+// record kept, so that no redirect chains.
+func (r *dedupeRepo) redirect(tx *gorm.DB, red *dedupe.Redirect) error {
+	if err := tx.Model(&dedupe.Redirect{}).Where("kind = ? AND to_id = ?", red.Kind, red.FromID).
+		Update("to_id", red.ToID).Error; err != nil {
+		return err
+	}
+	return tx.Create(red).Error
+}
+
+func (r *dedupeRepo) Author(ID string) (catalog.Author, error) {
+	var a catalog.Author
+	d := r.db.New()
+
+	if err := d.First(&a, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return catalog.Author{}, db.ErrNotFound
+		}
+		return catalog.Author{}, err
+	}
+	return a, nil
+}
+
+func (r *dedupeRepo) Redirect(kind, fromID string) (dedupe.Redirect, error) {
+	var red dedupe.Redirect
+	d := r.db.New()
+
+	if err := d.First(&red, "kind = ? AND from_id = ?", kind, fromID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return dedupe.Redirect{}, db.ErrNotFound
+		}
+		return dedupe.Redirect{}, err
+	}
+	return red, nil
+}
+
+func (r *dedupeRepo) ListRedirects(kind string) ([]dedupe.Redirect, error) {
+	list := make([]dedupe.Redirect, 0)
+	d := r.db.New().Order("created_at desc")
+
+	if kind != "" {
+		d = d.Where("kind = ?", kind)
+	}
+	err := d.Find(&list).Error
+	return list, err
+}
+
+func (r *dedupeRepo) Drop() error {
+	return r.db.Exec("DELETE FROM DEDUPE_REDIRECTS").Error
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/delivery"
+	_ "github.com/lib/pq"
+)
+
+type deliveryRepo struct {
+	db *gorm.DB
+}
+
+func NewDeliveryRepo(driver, source string) (delivery.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&delivery.Transit{}, &delivery.Promise{})
+	return &deliveryRepo{db: db}, nil
+}
+
+func (r *deliveryRepo) ListTransits() ([]delivery.Transit, error) {
+	transits := make([]delivery.Transit, 0)
+	d := r.db.New()
+
+	err := d.Order("option, country").Find(&transits).Error
+	return transits, err
+}
+
+// SaveTransit relies on the option and the country being the primary key,
+// Save updating the transit if any.
+func (r *deliveryRepo) SaveTransit(t *delivery.Transit) error {
+	d := r.db.New()
+
+	return d.Save(t).Error
+}
+
+func (r *deliveryRepo) DeleteTransit(option, country string) error {
+	d := r.db.New().Delete(&delivery.Transit{}, "option=? AND country=?", option, country)
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (r *deliveryRepo) SavePromise(p *delivery.Promise) error {
+	d := r.db.New()
+
+	return d.Save(p).Error
+}
+
+func (r *deliveryRepo) GetPromise(orderID string) (delivery.Promise, error) {
+	var p delivery.Promise
+	d := r.db.New()
+
+	if err := d.First(&p, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return delivery.Promise{}, db.ErrNotFound
+		}
+		return delivery.Promise{}, err
+	}
+	return p, nil
+}
+
+func (r *deliveryRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM DELIVERY_PROMISES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM DELIVERY_TRANSITS").Error
+}
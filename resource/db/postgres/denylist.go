@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/denylist"
+	_ "github.com/lib/pq"
+)
+
+type denylistRepo struct {
+	db *gorm.DB
+}
+
+func NewDenylistRepo(driver, source string) (denylist.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&denylist.Entry{}, &denylist.Hit{})
+	return &denylistRepo{db: db}, nil
+}
+
+func (r *denylistRepo) GetByID(ID string) (denylist.Entry, error) {
+	var e denylist.Entry
+	d := r.db.New()
+
+	if err := d.First(&e, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return denylist.Entry{}, db.ErrNotFound
+		}
+		return denylist.Entry{}, err
+	}
+	return e, nil
+}
+
+func (r *denylistRepo) List(list, kind string) ([]denylist.Entry, error) {
+	entries := make([]denylist.Entry, 0)
+	d := r.db.New().Order("created_at")
+
+	if list != "" {
+		d = d.Where("list=?", list)
+	}
+	if kind != "" {
+		d = d.Where("kind=?", kind)
+	}
+	err := d.Find(&entries).Error
+	return entries, err
+}
+
+func (r *denylistRepo) ListActive(kind string, t time.Time) ([]denylist.Entry, error) {
+	entries := make([]denylist.Entry, 0)
+	d := r.db.New()
+
+	err := d.Find(&entries, "kind=? AND (expires_at IS NULL OR expires_at>?)", kind, t).Error
+	return entries, err
+}
+
+func (r *denylistRepo) Create(e *denylist.Entry) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+
+	if err := d.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *denylistRepo) Delete(ID string) error {
+	d := r.db.New()
+
+	return d.Delete(&denylist.Entry{}, "id=?", ID).Error
+}
+
+func (r *denylistRepo) CreateHit(h *denylist.Hit) error {
+	d := r.db.New()
+
+	if h.ID == "" {
+		h.ID = NewID()
+	}
+
+	if err := d.Create(h).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *denylistRepo) ListHits(entryID string, limit int) ([]denylist.Hit, error) {
+	hits := make([]denylist.Hit, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Limit(limit).Find(&hits, "entry_id=?", entryID).Error
+	return hits, err
+}
+
+func (r *denylistRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM DENYLIST_HITS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM DENYLIST_ENTRIES").Error
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/deprecation"
+	_ "github.com/lib/pq"
+)
+
+type deprecationRepo struct {
+	db *gorm.DB
+}
+
+func NewDeprecationRepo(driver, source string) (deprecation.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&deprecation.Route{}, &deprecation.Call{})
+	return &deprecationRepo{db: db}, nil
+}
+
+func (r *deprecationRepo) CreateRoute(route *deprecation.Route) error {
+	d := r.db.New()
+
+	if route.ID == "" {
+		route.ID = NewID()
+	}
+	return d.Create(route).Error
+}
+
+func (r *deprecationRepo) ListRoutes() ([]deprecation.Route, error) {
+	routes := make([]deprecation.Route, 0)
+	d := r.db.New()
+
+	err := d.Order("path, method").Find(&routes).Error
+	return routes, err
+}
+
+func (r *deprecationRepo) DeleteRoute(id string) error {
+	d := r.db.New().Delete(&deprecation.Route{}, "id=?", id)
+
+	if d.Error != nil {
+		return d.Error
+	}
+	if d.RowsAffected == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// AddCalls upserts the counts of every route and client in one
+// transaction, keeping the first time a client was seen.
+func (r *deprecationRepo) AddCalls(calls []deprecation.Call) error {
+	tx := r.db.New().Begin()
+
+	for _, c := range calls {
+		err := tx.Exec(`INSERT INTO deprecated_route_calls (route_id, client_kind, client, calls, first_seen, last_seen)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (route_id, client_kind, client) DO UPDATE
+			SET calls = deprecated_route_calls.calls + EXCLUDED.calls,
+				last_seen = GREATEST(deprecated_route_calls.last_seen, EXCLUDED.last_seen)`,
+			c.RouteID, c.ClientKind, c.Client, c.Calls, c.FirstSeen, c.LastSeen).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *deprecationRepo) ListCalls(routeID string) ([]deprecation.Call, error) {
+	calls := make([]deprecation.Call, 0)
+	d := r.db.New().Order("calls desc")
+
+	if routeID != "" {
+		d = d.Where("route_id=?", routeID)
+	}
+	err := d.Find(&calls).Error
+	return calls, err
+}
+
+func (r *deprecationRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM DEPRECATED_ROUTE_CALLS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM DEPRECATED_ROUTES").Error
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/donation"
+	_ "github.com/lib/pq"
+)
+
+type donationRepo struct {
+	db *gorm.DB
+}
+
+func NewDonationRepo(driver, source string) (donation.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&donation.Donation{})
+	return &donationRepo{db: db}, nil
+}
+
+func (r *donationRepo) filter(where ...interface{}) ([]donation.Donation, error) {
+	donations := make([]donation.Donation, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&donations, where...).Error
+	return donations, err
+}
+
+func (r *donationRepo) ListByOrder(orderID string) ([]donation.Donation, error) {
+	return r.filter("order_id=?", orderID)
+}
+
+func (r *donationRepo) ListBetween(from, to time.Time) ([]donation.Donation, error) {
+	return r.filter("created_at>=? AND created_at<?", from, to)
+}
+
+func (r *donationRepo) Create(dn *donation.Donation) error {
+	d := r.db.New()
+
+	if dn.ID == "" {
+		dn.ID = NewID()
+	}
+
+	if err := d.Create(dn).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *donationRepo) Drop() error {
+	return r.db.Exec("DELETE FROM DONATIONS").Error
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/ebook"
+	_ "github.com/lib/pq"
+)
+
+type ebookRepo struct {
+	db *gorm.DB
+}
+
+func NewEbookRepo(driver, source string) (ebook.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&ebook.File{}, &ebook.Artifact{})
+	return &ebookRepo{db: db}, nil
+}
+
+func (r *ebookRepo) CreateFile(f *ebook.File) error {
+	d := r.db.New()
+
+	if f.ID == "" {
+		f.ID = NewID()
+	}
+
+	if err := d.Create(f).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ebookRepo) ListFiles(bookID string) ([]ebook.File, error) {
+	files := make([]ebook.File, 0)
+	d := r.db.New()
+
+	err := d.Find(&files, "book_id=?", bookID).Error
+	return files, err
+}
+
+func (r *ebookRepo) GetArtifact(orderID, bookID, format string) (ebook.Artifact, error) {
+	var a ebook.Artifact
+	d := r.db.New()
+
+	if err := d.First(&a, "order_id=? AND book_id=? AND format=?", orderID, bookID, format).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ebook.Artifact{}, db.ErrNotFound
+		}
+		return ebook.Artifact{}, err
+	}
+	return a, nil
+}
+
+func (r *ebookRepo) CreateArtifact(a *ebook.Artifact) error {
+	d := r.db.New()
+
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+
+	if err := d.Create(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ebookRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ARTIFACTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM FILES").Error
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/erasure"
+	_ "github.com/lib/pq"
+)
+
+type erasureRepo struct {
+	db *gorm.DB
+}
+
+func NewErasureRepo(driver, source string) (erasure.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&erasure.Request{}, &erasure.Event{})
+	return &erasureRepo{db: db}, nil
+}
+
+func (r *erasureRepo) Create(req *erasure.Request) error {
+	d := r.db.New()
+
+	if req.ID == "" {
+		req.ID = NewID()
+	}
+
+	if err := d.Create(req).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *erasureRepo) Save(req *erasure.Request) error {
+	d := r.db.New()
+
+	if err := d.Save(req).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *erasureRepo) GetByID(ID string) (erasure.Request, error) {
+	var req erasure.Request
+	d := r.db.New()
+
+	if err := d.First(&req, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return erasure.Request{}, db.ErrNotFound
+		}
+		return erasure.Request{}, err
+	}
+	return req, nil
+}
+
+func (r *erasureRepo) ListByUser(userID string) ([]erasure.Request, error) {
+	requests := make([]erasure.Request, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&requests, "user_id=?", userID).Error
+	return requests, err
+}
+
+func (r *erasureRepo) ListByStatus(status string) ([]erasure.Request, error) {
+	requests := make([]erasure.Request, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&requests, "status=?", status).Error
+	return requests, err
+}
+
+func (r *erasureRepo) AddEvent(e *erasure.Event) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+
+	if err := d.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *erasureRepo) ListEvents(requestID string) ([]erasure.Event, error) {
+	events := make([]erasure.Event, 0)
+	d := r.db.New()
+
+	err := d.Order("at").Find(&events, "request_id=?", requestID).Error
+	return events, err
+}
+
+func (r *erasureRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ERASURE_EVENTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM ERASURE_REQUESTS").Error
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/export"
+	_ "github.com/lib/pq"
+)
+
+type exportRepo struct {
+	db *gorm.DB
+}
+
+func NewExportRepo(driver, source string) (export.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&export.Job{})
+	return &exportRepo{db: db}, nil
+}
+
+func (r *exportRepo) CreateJob(j *export.Job) error {
+	d := r.db.New()
+
+	if j.ID == "" {
+		j.ID = NewID()
+	}
+	return d.Create(j).Error
+}
+
+func (r *exportRepo) GetJob(id string) (export.Job, error) {
+	var j export.Job
+	d := r.db.New()
+
+	if err := d.First(&j, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return export.Job{}, db.ErrNotFound
+		}
+		return export.Job{}, err
+	}
+	return j, nil
+}
+
+func (r *exportRepo) SaveJob(j *export.Job) error {
+	return r.db.New().Save(j).Error
+}
+
+func (r *exportRepo) ListJobsByStatus(status ...string) ([]export.Job, error) {
+	jobs := make([]export.Job, 0)
+	d := r.db.New()
+
+	err := d.Where("status IN (?)", status).Order("created_at").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *exportRepo) DeleteExpired(t time.Time) error {
+	return r.db.New().Delete(&export.Job{}, "expires_at < ?", t).Error
+}
+
+func (r *exportRepo) Drop() error {
+	return r.db.Exec("DELETE FROM EXPORT_JOBS").Error
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/feeds"
+	_ "github.com/lib/pq"
+)
+
+type feedRepo struct {
+	db *gorm.DB
+}
+
+func NewFeedRepo(driver, source string) (feeds.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&feeds.Webhook{})
+	return &feedRepo{db: db}, nil
+}
+
+func (r *feedRepo) CreateWebhook(w *feeds.Webhook) error {
+	d := r.db.New()
+
+	if w.ID == "" {
+		w.ID = NewID()
+	}
+
+	if err := d.Create(w).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *feedRepo) SaveWebhook(w *feeds.Webhook) error {
+	d := r.db.New()
+
+	if err := d.Save(w).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *feedRepo) GetWebhook(id string) (feeds.Webhook, error) {
+	var w feeds.Webhook
+	d := r.db.New()
+
+	if err := d.First(&w, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return feeds.Webhook{}, db.ErrNotFound
+		}
+		return feeds.Webhook{}, err
+	}
+	return w, nil
+}
+
+func (r *feedRepo) ListWebhooks() ([]feeds.Webhook, error) {
+	hooks := make([]feeds.Webhook, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&hooks).Error
+	return hooks, err
+}
+
+func (r *feedRepo) ListActiveWebhooks() ([]feeds.Webhook, error) {
+	hooks := make([]feeds.Webhook, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&hooks, "active=?", true).Error
+	return hooks, err
+}
+
+func (r *feedRepo) DeleteWebhook(id string) error {
+	return r.db.New().Delete(&feeds.Webhook{}, "id=?", id).Error
+}
+
+func (r *feedRepo) Drop() error {
+	return r.db.Exec("DELETE FROM FEED_WEBHOOKS").Error
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+)
+
+// filtered applies the filter f to the list query q. The table statistics
+// know nothing of the filter, so the estimated count of a filtered list
+// falls back to the rows seen.
+func filtered(q *gorm.DB, f filter.Expr, count db.Count) (*gorm.DB, db.Count) {
+	if f.Empty() {
+		return q, count
+	}
+	if count == db.CountEstimate {
+		count = db.CountNone
+	}
+	return q.Where(f.SQL, f.Args...), count
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fraud"
+	_ "github.com/lib/pq"
+)
+
+type fraudRepo struct {
+	db *gorm.DB
+}
+
+func NewFraudRepo(driver, source string) (fraud.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&fraud.Assessment{})
+	return &fraudRepo{db: db}, nil
+}
+
+func (r *fraudRepo) get(where ...interface{}) (fraud.Assessment, error) {
+	var a fraud.Assessment
+	d := r.db.New()
+
+	if err := d.First(&a, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fraud.Assessment{}, db.ErrNotFound
+		}
+		return fraud.Assessment{}, err
+	}
+	return a, nil
+}
+
+func (r *fraudRepo) GetByID(ID string) (fraud.Assessment, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *fraudRepo) GetByOrder(orderID string) (fraud.Assessment, error) {
+	return r.get("order_id=?", orderID)
+}
+
+func (r *fraudRepo) ListByStatus(status string) ([]fraud.Assessment, error) {
+	assessments := make([]fraud.Assessment, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&assessments, "status=?", status).Error
+	return assessments, err
+}
+
+func (r *fraudRepo) CountSince(userID, ip string, since time.Time) (int, error) {
+	var count int
+	d := r.db.New()
+
+	err := d.Model(&fraud.Assessment{}).
+		Where("created_at>? AND (user_id=? OR (ip<>'' AND ip=?))", since, userID, ip).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *fraudRepo) Create(a *fraud.Assessment) error {
+	d := r.db.New()
+
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+
+	if err := d.Create(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *fraudRepo) Save(a *fraud.Assessment) error {
+	d := r.db.New()
+
+	if err := d.Save(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *fraudRepo) Drop() error {
+	return r.db.Exec("DELETE FROM FRAUD_ASSESSMENTS").Error
+}
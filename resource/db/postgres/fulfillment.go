@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/fulfillment"
+	_ "github.com/lib/pq"
+)
+
+type fulfillmentRepo struct {
+	db *gorm.DB
+}
+
+func NewFulfillmentRepo(driver, source string) (fulfillment.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&fulfillment.Job{}, &fulfillment.Shipment{}, &fulfillment.Cost{})
+	return &fulfillmentRepo{db: db}, nil
+}
+
+func (r *fulfillmentRepo) filter(where ...interface{}) ([]fulfillment.Job, error) {
+	jobs := make([]fulfillment.Job, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&jobs, where...).Error
+	return jobs, err
+}
+
+func (r *fulfillmentRepo) GetByReference(provider, ref string) (fulfillment.Job, error) {
+	var j fulfillment.Job
+	d := r.db.New()
+
+	if err := d.First(&j, "provider=? AND reference=?", provider, ref).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fulfillment.Job{}, db.ErrNotFound
+		}
+		return fulfillment.Job{}, err
+	}
+	return j, nil
+}
+
+func (r *fulfillmentRepo) ListByOrder(orderID string) ([]fulfillment.Job, error) {
+	return r.filter("order_id=?", orderID)
+}
+
+func (r *fulfillmentRepo) ListOpen() ([]fulfillment.Job, error) {
+	return r.filter("status NOT IN (?)", []string{fulfillment.StatusShipped, fulfillment.StatusFailed})
+}
+
+func (r *fulfillmentRepo) Create(j *fulfillment.Job) error {
+	d := r.db.New()
+
+	if j.ID == "" {
+		j.ID = NewID()
+	}
+
+	if err := d.Create(j).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *fulfillmentRepo) Save(j *fulfillment.Job) error {
+	d := r.db.New()
+
+	if err := d.Save(j).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *fulfillmentRepo) CreateShipment(sh *fulfillment.Shipment) error {
+	d := r.db.New()
+
+	if sh.ID == "" {
+		sh.ID = NewID()
+	}
+	return d.Create(sh).Error
+}
+
+func (r *fulfillmentRepo) SaveShipment(sh *fulfillment.Shipment) error {
+	return r.db.New().Save(sh).Error
+}
+
+func (r *fulfillmentRepo) GetShipment(ID string) (fulfillment.Shipment, error) {
+	var sh fulfillment.Shipment
+	d := r.db.New()
+
+	if err := d.First(&sh, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fulfillment.Shipment{}, db.ErrNotFound
+		}
+		return fulfillment.Shipment{}, err
+	}
+	return sh, nil
+}
+
+func (r *fulfillmentRepo) ListShipments(orderID string) ([]fulfillment.Shipment, error) {
+	shipments := make([]fulfillment.Shipment, 0)
+	err := r.db.New().Order("created_at").Find(&shipments, "order_id=?", orderID).Error
+	return shipments, err
+}
+
+// SaveLabel saves the shipment and appends its postage in one transaction.
+func (r *fulfillmentRepo) SaveLabel(sh *fulfillment.Shipment, postage *fulfillment.Cost) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Save(sh).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if postage.ID == "" {
+		postage.ID = NewID()
+	}
+	if err := tx.Create(postage).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *fulfillmentRepo) ListCosts(orderID string) ([]fulfillment.Cost, error) {
+	costs := make([]fulfillment.Cost, 0)
+	err := r.db.New().Order("created_at").Find(&costs, "order_id=?", orderID).Error
+	return costs, err
+}
+
+func (r *fulfillmentRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM FULFILLMENT_COSTS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM SHIPMENTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM FULFILLMENT_JOBS").Error
+}
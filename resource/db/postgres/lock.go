@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"time"
+
+	"github.com/kavirajk/bookshop/lock"
+	_ "github.com/lib/pq"
+)
+
+type advisoryLocker struct {
+	db *sql.DB
+}
+
+// NewLocker returns a lock.Locker of the advisory locks of the database,
+// shared by all the servers. An advisory lock is held by a connection of
+// its own, until released or the connection drops, regardless of the TTL.
+func NewLocker(driver, source string) (lock.Locker, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	return &advisoryLocker{db: db.DB()}, nil
+}
+
+// advisoryKey returns the key of the advisory lock name.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("bookshop:" + name))
+	return int64(h.Sum64())
+}
+
+func (l *advisoryLocker) Acquire(ctx context.Context, name string, _ time.Duration) (lock.Lock, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := advisoryKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, lock.ErrLocked
+	}
+	return &advisoryLock{conn: conn, key: key}, nil
+}
+
+type advisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Refresh checks the connection holding the lock is alive still.
+func (l *advisoryLock) Refresh(ctx context.Context) error {
+	var one int
+	if err := l.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		l.conn.Close()
+		return lock.ErrLost
+	}
+	return nil
+}
+
+// Release unlocks and closes the connection, which releases the lock if
+// the unlock fails.
+func (l *advisoryLock) Release(ctx context.Context) error {
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	l.conn.Close()
+	return err
+}
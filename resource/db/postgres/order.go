@@ -3,6 +3,7 @@ package postgres
 import (
 	"github.com/jinzhu/gorm"
 	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 	"github.com/kavirajk/bookshop/order"
 	_ "github.com/lib/pq"
 )
@@ -12,11 +13,11 @@ type orderRepo struct {
 }
 
 func NewOrderRepo(driver, source string) (order.Repo, error) {
-	db, err := gorm.Open(driver, source)
+	db, err := open(driver, source)
 	if err != nil {
 		return nil, err
 	}
-	db.AutoMigrate(&order.Order{})
+	db.AutoMigrate(&order.Order{}, &order.CartLine{}, &order.Line{}, &order.Adjustment{})
 	return &orderRepo{db: db}, nil
 }
 
@@ -24,7 +25,7 @@ func (r *orderRepo) get(where ...interface{}) (order.Order, error) {
 	var b order.Order
 	d := r.db.New()
 
-	if err := d.First(&b, where...).Error; err != nil {
+	if err := d.Preload("Lines").First(&b, where...).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return order.Order{}, db.ErrNotFound
 		}
@@ -49,6 +50,34 @@ func (r *orderRepo) ListByUser(userID string) ([]order.Order, error) {
 	return r.filter("created_by_id=?", userID)
 }
 
+// ListByVendor returns the orders having atleast one book of the vendor.
+func (r *orderRepo) ListByVendor(vendorID string) ([]order.Order, error) {
+	orders := make([]order.Order, 0)
+	d := r.db.New()
+
+	err := d.Preload("Items").
+		Joins("JOIN order_items ON order_items.order_id = orders.id").
+		Joins("JOIN books ON books.id = order_items.book_id").
+		Where("books.vendor_id=?", vendorID).
+		Group("orders.id").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepo) List(f filter.Expr, limit, offset int, count db.Count) ([]order.Order, int, error) {
+	orders := make([]order.Order, 0)
+	d, count := filtered(r.db.New().Order("created_at desc, id"), f, count)
+
+	if err := d.Limit(fetchLimit(limit, count)).Offset(offset).Find(&orders).Error; err != nil {
+		return orders, 0, err
+	}
+	total, err := total(d, &order.Order{}, offset+len(orders), count)
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, total, err
+}
+
 func (r *orderRepo) Create(u *order.Order) error {
 	d := r.db.New()
 
@@ -71,6 +100,86 @@ func (r *orderRepo) Save(u *order.Order) error {
 	return nil
 }
 
+func (r *orderRepo) ListCartLines(userID string) ([]order.CartLine, error) {
+	lines := make([]order.CartLine, 0)
+	d := r.db.New()
+
+	err := d.Where("user_id=?", userID).Order("added_at").Find(&lines).Error
+	return lines, err
+}
+
+func (r *orderRepo) GetCartLine(userID, bookID string) (order.CartLine, error) {
+	var l order.CartLine
+	d := r.db.New()
+
+	if err := d.First(&l, "user_id=? AND book_id=?", userID, bookID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return order.CartLine{}, db.ErrNotFound
+		}
+		return order.CartLine{}, err
+	}
+	return l, nil
+}
+
+func (r *orderRepo) SaveCartLine(l *order.CartLine) error {
+	d := r.db.New()
+
+	return d.Save(l).Error
+}
+
+func (r *orderRepo) DeleteCartLine(userID, bookID string) error {
+	d := r.db.New()
+
+	return d.Where("user_id=? AND book_id=?", userID, bookID).Delete(&order.CartLine{}).Error
+}
+
+// SaveLines rebuilds the lines and the items of the order in a transaction,
+// the total being the only column of the order changed.
+func (r *orderRepo) SaveLines(o *order.Order, a *order.Adjustment) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Where("order_id=?", o.ID).Delete(&order.Line{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Exec("DELETE FROM order_items WHERE order_id=?", o.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for n := range o.Lines {
+		o.Lines[n].OrderID = o.ID
+		if err := tx.Create(&o.Lines[n]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Exec("INSERT INTO order_items (order_id, book_id) VALUES (?, ?)", o.ID, o.Lines[n].BookID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Model(&order.Order{}).Where("id=?", o.ID).UpdateColumn("total_price", o.TotalPrice).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if a != nil {
+		a.ID = NewID()
+		if err := tx.Create(a).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
 func (r *orderRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ORDER_ADJUSTMENTS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM ORDER_LINES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM ORDER_CART_LINES").Error; err != nil {
+		return err
+	}
 	return r.db.Exec("DELETE FROM ORDERS").Error
 }
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/payment"
+	_ "github.com/lib/pq"
+)
+
+type paymentRepo struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepo(driver, source string) (payment.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&payment.Authorization{}, &payment.Intent{}, &payment.Dispute{})
+	return &paymentRepo{db: db}, nil
+}
+
+func (r *paymentRepo) CreateAuthorization(a *payment.Authorization) error {
+	d := r.db.New()
+
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+
+	if err := d.Create(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *paymentRepo) SaveAuthorization(a *payment.Authorization) error {
+	d := r.db.New()
+
+	return d.Save(a).Error
+}
+
+func (r *paymentRepo) first(where ...interface{}) (payment.Authorization, error) {
+	var a payment.Authorization
+	d := r.db.New()
+
+	if err := d.Order("created_at DESC").First(&a, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return payment.Authorization{}, db.ErrNotFound
+		}
+		return payment.Authorization{}, err
+	}
+	return a, nil
+}
+
+func (r *paymentRepo) GetAuthorization(orderID string) (payment.Authorization, error) {
+	return r.first("order_id=?", orderID)
+}
+
+func (r *paymentRepo) GetAuthorizationByReference(provider, ref string) (payment.Authorization, error) {
+	return r.first("provider=? AND reference=?", provider, ref)
+}
+
+func (r *paymentRepo) ListAuthorizations(status string) ([]payment.Authorization, error) {
+	auths := make([]payment.Authorization, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&auths, "status=?", status).Error
+	return auths, err
+}
+
+func (r *paymentRepo) CreateIntent(i *payment.Intent) error {
+	d := r.db.New()
+
+	if i.ID == "" {
+		i.ID = NewID()
+	}
+
+	if err := d.Create(i).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *paymentRepo) SaveIntent(i *payment.Intent) error {
+	d := r.db.New()
+
+	return d.Save(i).Error
+}
+
+func (r *paymentRepo) firstIntent(where ...interface{}) (payment.Intent, error) {
+	var i payment.Intent
+	d := r.db.New()
+
+	if err := d.Order("created_at DESC").First(&i, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return payment.Intent{}, db.ErrNotFound
+		}
+		return payment.Intent{}, err
+	}
+	return i, nil
+}
+
+func (r *paymentRepo) GetIntent(orderID string) (payment.Intent, error) {
+	return r.firstIntent("order_id=?", orderID)
+}
+
+func (r *paymentRepo) GetIntentByReference(ref string) (payment.Intent, error) {
+	return r.firstIntent("reference=?", ref)
+}
+
+func (r *paymentRepo) CreateDispute(p *payment.Dispute) error {
+	d := r.db.New()
+
+	if p.ID == "" {
+		p.ID = NewID()
+	}
+
+	if err := d.Create(p).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *paymentRepo) SaveDispute(p *payment.Dispute) error {
+	d := r.db.New()
+
+	return d.Save(p).Error
+}
+
+func (r *paymentRepo) firstDispute(where ...interface{}) (payment.Dispute, error) {
+	var p payment.Dispute
+	d := r.db.New()
+
+	if err := d.First(&p, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return payment.Dispute{}, db.ErrNotFound
+		}
+		return payment.Dispute{}, err
+	}
+	return p, nil
+}
+
+func (r *paymentRepo) GetDispute(ID string) (payment.Dispute, error) {
+	return r.firstDispute("id=?", ID)
+}
+
+func (r *paymentRepo) GetDisputeByReference(ref string) (payment.Dispute, error) {
+	return r.firstDispute("reference=?", ref)
+}
+
+func (r *paymentRepo) ListDisputes(status string) ([]payment.Dispute, error) {
+	disputes := make([]payment.Dispute, 0)
+	d := r.db.New().Order("evidence_due_by IS NULL, evidence_due_by, created_at")
+
+	if status != "" {
+		d = d.Where("status=?", status)
+	}
+	err := d.Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *paymentRepo) ListLostDisputes(from, to time.Time) ([]payment.Dispute, error) {
+	disputes := make([]payment.Dispute, 0)
+	d := r.db.New()
+
+	err := d.Order("closed_at").Find(&disputes, "status=? AND closed_at >= ? AND closed_at < ?", payment.DisputeLost, from, to).Error
+	return disputes, err
+}
+
+func (r *paymentRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM PAYMENT_DISPUTES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM PAYMENT_INTENTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM PAYMENT_AUTHORIZATIONS").Error
+}
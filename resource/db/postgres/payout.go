@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/payout"
+	_ "github.com/lib/pq"
+)
+
+type payoutRepo struct {
+	db *gorm.DB
+}
+
+func NewPayoutRepo(driver, source string) (payout.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&payout.Agreement{}, &payout.Entry{}, &payout.Payout{})
+	return &payoutRepo{db: db}, nil
+}
+
+func (r *payoutRepo) GetAgreement(vendorID string) (payout.Agreement, error) {
+	var a payout.Agreement
+	d := r.db.New()
+
+	if err := d.First(&a, "vendor_id=?", vendorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return payout.Agreement{}, db.ErrNotFound
+		}
+		return payout.Agreement{}, err
+	}
+	return a, nil
+}
+
+func (r *payoutRepo) SaveAgreement(a *payout.Agreement) error {
+	d := r.db.New()
+
+	var count int
+	if err := d.Model(&payout.Agreement{}).Where("vendor_id=?", a.VendorID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return d.Create(a).Error
+	}
+
+	if err := d.Save(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *payoutRepo) filterEntries(where ...interface{}) ([]payout.Entry, error) {
+	entries := make([]payout.Entry, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&entries, where...).Error
+	return entries, err
+}
+
+func (r *payoutRepo) CreateEntry(e *payout.Entry) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+
+	if err := d.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *payoutRepo) ListEntriesByOrder(orderID string) ([]payout.Entry, error) {
+	return r.filterEntries("order_id=?", orderID)
+}
+
+func (r *payoutRepo) ListEntriesByPayout(payoutID string) ([]payout.Entry, error) {
+	return r.filterEntries("payout_id=?", payoutID)
+}
+
+func (r *payoutRepo) ListUnpaid(vendorID string) ([]payout.Entry, error) {
+	if vendorID == "" {
+		return r.filterEntries("payout_id=''")
+	}
+	return r.filterEntries("payout_id='' AND vendor_id=?", vendorID)
+}
+
+func (r *payoutRepo) CreatePayout(p *payout.Payout, entryIDs []string) error {
+	tx := r.db.New().Begin()
+
+	if p.ID == "" {
+		p.ID = NewID()
+	}
+
+	if err := tx.Create(p).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Only unpaid entries are updated, so an entry is never paid twice.
+	res := tx.Model(&payout.Entry{}).
+		Where("id IN (?) AND payout_id=''", entryIDs).
+		Update("payout_id", p.ID)
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected != int64(len(entryIDs)) {
+		tx.Rollback()
+		return fmt.Errorf("payout: %d of %d entries already paid", int64(len(entryIDs))-res.RowsAffected, len(entryIDs))
+	}
+	return tx.Commit().Error
+}
+
+func (r *payoutRepo) GetPayout(ID string) (payout.Payout, error) {
+	var p payout.Payout
+	d := r.db.New()
+
+	if err := d.First(&p, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return payout.Payout{}, db.ErrNotFound
+		}
+		return payout.Payout{}, err
+	}
+	return p, nil
+}
+
+func (r *payoutRepo) ListPayouts(vendorID string) ([]payout.Payout, error) {
+	payouts := make([]payout.Payout, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&payouts, "vendor_id=?", vendorID).Error
+	return payouts, err
+}
+
+func (r *payoutRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM PAYOUT_ENTRIES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM PAYOUTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM AGREEMENTS").Error
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/picking"
+	_ "github.com/lib/pq"
+)
+
+type pickingRepo struct {
+	db *gorm.DB
+}
+
+func NewPickingRepo(driver, source string) (picking.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&picking.PickList{}, &picking.PickOrder{}, &picking.PackItem{}, &picking.PickLine{})
+	return &pickingRepo{db: db}, nil
+}
+
+// Create creates the pick list, its orders with their items and its lines
+// in one transaction.
+func (r *pickingRepo) Create(l *picking.PickList) error {
+	tx := r.db.New().Begin()
+
+	if l.ID == "" {
+		l.ID = NewID()
+	}
+	orders, lines := l.Orders, l.Lines
+	l.Orders, l.Lines = nil, nil
+	if err := tx.Create(l).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range orders {
+		o := &orders[i]
+		o.PickListID = l.ID
+		items := o.Items
+		o.Items = nil
+		if err := tx.Create(o).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		for j := range items {
+			items[j].OrderID = o.OrderID
+			if err := tx.Create(&items[j]).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		o.Items = items
+	}
+	for i := range lines {
+		lines[i].PickListID = l.ID
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	l.Orders, l.Lines = orders, lines
+	return tx.Commit().Error
+}
+
+// Get loads the orders in the order of their totes and the lines in the
+// order of the walk.
+func (r *pickingRepo) Get(ID string) (picking.PickList, error) {
+	var l picking.PickList
+	d := r.db.New()
+
+	if err := d.First(&l, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return picking.PickList{}, db.ErrNotFound
+		}
+		return picking.PickList{}, err
+	}
+	if err := d.Preload("Items").Order("tote").Find(&l.Orders, "pick_list_id=?", ID).Error; err != nil {
+		return picking.PickList{}, err
+	}
+	if err := d.Order("seq").Find(&l.Lines, "pick_list_id=?", ID).Error; err != nil {
+		return picking.PickList{}, err
+	}
+	return l, nil
+}
+
+func (r *pickingRepo) List(status string) ([]picking.PickList, error) {
+	lists := make([]picking.PickList, 0)
+	d := r.db.New().Order("created_at desc")
+
+	if status != "" {
+		d = d.Where("status=?", status)
+	}
+	err := d.Find(&lists).Error
+	return lists, err
+}
+
+func (r *pickingRepo) Done(l *picking.PickList) error {
+	d := r.db.New()
+
+	return d.Model(&picking.PickList{ID: l.ID}).UpdateColumns(map[string]interface{}{
+		"status":  l.Status,
+		"done_at": l.DoneAt,
+	}).Error
+}
+
+func (r *pickingRepo) GetOrder(orderID string) (picking.PickOrder, error) {
+	var o picking.PickOrder
+	d := r.db.New()
+
+	if err := d.Preload("Items").First(&o, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return picking.PickOrder{}, db.ErrNotFound
+		}
+		return picking.PickOrder{}, err
+	}
+	return o, nil
+}
+
+// SaveOrder saves the order and the scans of its items in one
+// transaction.
+func (r *pickingRepo) SaveOrder(o *picking.PickOrder) error {
+	tx := r.db.New().Begin()
+
+	err := tx.Model(&picking.PickOrder{OrderID: o.OrderID}).UpdateColumns(map[string]interface{}{
+		"status":    o.Status,
+		"packed_at": o.PackedAt,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, it := range o.Items {
+		err := tx.Model(&picking.PackItem{}).Where("order_id=? AND book_id=?", it.OrderID, it.BookID).
+			UpdateColumn("scanned", it.Scanned).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *pickingRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM PICK_LINES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM PICK_PACK_ITEMS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM PICK_ORDERS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM PICK_LISTS").Error
+}
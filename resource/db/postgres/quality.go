@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/quality"
+	_ "github.com/lib/pq"
+)
+
+type qualityRepo struct {
+	db *gorm.DB
+}
+
+func NewQualityRepo(driver, source string) (quality.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&quality.Report{}, &quality.Issue{})
+	return &qualityRepo{db: db}, nil
+}
+
+// Create creates the report and its issues in one transaction.
+func (r *qualityRepo) Create(rep *quality.Report) error {
+	tx := r.db.New().Begin()
+
+	if rep.ID == "" {
+		rep.ID = NewID()
+	}
+	issues := rep.Issues
+	rep.Issues = nil
+	if err := tx.Create(rep).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range issues {
+		issues[i].ReportID = rep.ID
+		if err := tx.Create(&issues[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	rep.Issues = issues
+	return tx.Commit().Error
+}
+
+func (r *qualityRepo) Get(ID string) (quality.Report, error) {
+	return r.first("id=?", ID)
+}
+
+func (r *qualityRepo) Latest() (quality.Report, error) {
+	return r.first("")
+}
+
+// first returns the latest report of the condition, along with its
+// issues.
+func (r *qualityRepo) first(where string, args ...interface{}) (quality.Report, error) {
+	var rep quality.Report
+	d := r.db.New().Order("created_at desc")
+
+	if where != "" {
+		d = d.Where(where, args...)
+	}
+	if err := d.Preload("Issues").First(&rep).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return quality.Report{}, db.ErrNotFound
+		}
+		return quality.Report{}, err
+	}
+	return rep, nil
+}
+
+func (r *qualityRepo) List() ([]quality.Report, error) {
+	reports := make([]quality.Report, 0)
+	d := r.db.New().Order("created_at desc")
+
+	err := d.Find(&reports).Error
+	return reports, err
+}
+
+func (r *qualityRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM QUALITY_ISSUES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM QUALITY_REPORTS").Error
+}
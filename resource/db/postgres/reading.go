@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/reading"
+	_ "github.com/lib/pq"
+)
+
+type readingRepo struct {
+	db *gorm.DB
+}
+
+func NewReadingRepo(driver, source string) (reading.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&reading.Progress{}, &reading.Annotation{})
+	return &readingRepo{db: db}, nil
+}
+
+func (r *readingRepo) GetProgress(userID, bookID string) (reading.Progress, error) {
+	var p reading.Progress
+	d := r.db.New()
+
+	if err := d.First(&p, "user_id=? AND book_id=?", userID, bookID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return reading.Progress{}, db.ErrNotFound
+		}
+		return reading.Progress{}, err
+	}
+	return p, nil
+}
+
+func (r *readingRepo) SaveProgress(p *reading.Progress) error {
+	d := r.db.New()
+
+	if p.ID == "" {
+		p.ID = NewID()
+		return d.Create(p).Error
+	}
+
+	if err := d.Save(p).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *readingRepo) GetAnnotation(ID string) (reading.Annotation, error) {
+	var a reading.Annotation
+	d := r.db.New()
+
+	if err := d.First(&a, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return reading.Annotation{}, db.ErrNotFound
+		}
+		return reading.Annotation{}, err
+	}
+	return a, nil
+}
+
+// SaveAnnotation upserts the annotation, IDs are generated by the clients.
+func (r *readingRepo) SaveAnnotation(a *reading.Annotation) error {
+	d := r.db.New()
+
+	var count int
+	if err := d.Model(&reading.Annotation{}).Where("id=?", a.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return d.Create(a).Error
+	}
+
+	if err := d.Save(a).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *readingRepo) ListAnnotations(userID, bookID string, since time.Time) ([]reading.Annotation, error) {
+	annotations := make([]reading.Annotation, 0)
+	d := r.db.New()
+
+	err := d.Order("changed_at").
+		Find(&annotations, "user_id=? AND book_id=? AND changed_at>?", userID, bookID, since).Error
+	return annotations, err
+}
+
+func (r *readingRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ANNOTATIONS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM PROGRESSES").Error
+}
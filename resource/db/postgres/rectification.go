@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/rectification"
+	_ "github.com/lib/pq"
+)
+
+type rectificationRepo struct {
+	db *gorm.DB
+}
+
+func NewRectificationRepo(driver, source string) (rectification.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&rectification.Request{}, &rectification.Event{})
+	return &rectificationRepo{db: db}, nil
+}
+
+func (r *rectificationRepo) Create(req *rectification.Request) error {
+	d := r.db.New()
+
+	if req.ID == "" {
+		req.ID = NewID()
+	}
+
+	if err := d.Create(req).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *rectificationRepo) Save(req *rectification.Request) error {
+	d := r.db.New()
+
+	if err := d.Save(req).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *rectificationRepo) GetByID(ID string) (rectification.Request, error) {
+	var req rectification.Request
+	d := r.db.New()
+
+	if err := d.First(&req, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return rectification.Request{}, db.ErrNotFound
+		}
+		return rectification.Request{}, err
+	}
+	return req, nil
+}
+
+func (r *rectificationRepo) ListByUser(userID string) ([]rectification.Request, error) {
+	requests := make([]rectification.Request, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&requests, "user_id=?", userID).Error
+	return requests, err
+}
+
+func (r *rectificationRepo) ListByStatus(status string) ([]rectification.Request, error) {
+	requests := make([]rectification.Request, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&requests, "status=?", status).Error
+	return requests, err
+}
+
+func (r *rectificationRepo) AddEvent(e *rectification.Event) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+	}
+
+	if err := d.Create(e).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *rectificationRepo) ListEvents(requestID string) ([]rectification.Event, error) {
+	events := make([]rectification.Event, 0)
+	d := r.db.New()
+
+	err := d.Order("at").Find(&events, "request_id=?", requestID).Error
+	return events, err
+}
+
+// Erase blanks the requests and their trail in a transaction, the
+// submissions carrying the reasons of the user.
+func (r *rectificationRepo) Erase(userID string) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Exec("UPDATE rectification_events SET note='' WHERE request_id IN (SELECT id FROM rectification_requests WHERE user_id=?)", userID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	err := tx.Model(&rectification.Request{}).Where("user_id=?", userID).
+		UpdateColumns(map[string]interface{}{"current": "", "value": "", "reason": "", "evidence": ""}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *rectificationRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM RECTIFICATION_EVENTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM RECTIFICATION_REQUESTS").Error
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/restock"
+	_ "github.com/lib/pq"
+)
+
+type restockRepo struct {
+	db *gorm.DB
+}
+
+func NewRestockRepo(driver, source string) (restock.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&restock.PurchaseOrder{}, &restock.Line{}, &restock.Subscription{})
+	return &restockRepo{db: db}, nil
+}
+
+func (r *restockRepo) CreatePurchaseOrder(po *restock.PurchaseOrder) error {
+	tx := r.db.New().Begin()
+
+	if po.ID == "" {
+		po.ID = NewID()
+	}
+	for i := range po.Lines {
+		po.Lines[i].ID = NewID()
+		po.Lines[i].PurchaseOrderID = po.ID
+	}
+
+	if err := tx.Create(po).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *restockRepo) SavePurchaseOrder(po *restock.PurchaseOrder) error {
+	d := r.db.New()
+
+	err := d.Model(po).Updates(map[string]interface{}{
+		"status":      po.Status,
+		"expected_at": po.ExpectedAt,
+		"received_at": po.ReceivedAt,
+	}).Error
+	return err
+}
+
+func (r *restockRepo) GetPurchaseOrder(id string) (restock.PurchaseOrder, error) {
+	var po restock.PurchaseOrder
+	d := r.db.New()
+
+	if err := d.Preload("Lines").First(&po, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return restock.PurchaseOrder{}, db.ErrNotFound
+		}
+		return restock.PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+func (r *restockRepo) ListPurchaseOrders(status string) ([]restock.PurchaseOrder, error) {
+	pos := make([]restock.PurchaseOrder, 0)
+	d := r.db.New().Preload("Lines").Order("expected_at")
+
+	if status != "" {
+		d = d.Where("status=?", status)
+	}
+	err := d.Find(&pos).Error
+	return pos, err
+}
+
+func (r *restockRepo) ListOpenByBook(bookID string) ([]restock.PurchaseOrder, error) {
+	pos := make([]restock.PurchaseOrder, 0)
+	d := r.db.New()
+
+	err := d.Preload("Lines").
+		Where("status=? AND id IN (SELECT purchase_order_id FROM purchase_order_lines WHERE book_id=?)", restock.StatusOpen, bookID).
+		Order("expected_at").Find(&pos).Error
+	return pos, err
+}
+
+func (r *restockRepo) CreateSubscription(s *restock.Subscription) error {
+	d := r.db.New()
+
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+
+	if err := d.Create(s).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *restockRepo) SaveSubscription(s *restock.Subscription) error {
+	d := r.db.New()
+
+	if err := d.Save(s).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *restockRepo) ListSubscriptions(bookID string) ([]restock.Subscription, error) {
+	subs := make([]restock.Subscription, 0)
+	d := r.db.New()
+
+	err := d.Find(&subs, "book_id=? AND done=?", bookID, false).Error
+	return subs, err
+}
+
+func (r *restockRepo) DeleteSubscriptions(email string) error {
+	d := r.db.New()
+
+	return d.Where("email=?", email).Delete(&restock.Subscription{}).Error
+}
+
+func (r *restockRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM PURCHASE_ORDER_LINES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM PURCHASE_ORDERS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM RESTOCK_SUBSCRIPTIONS").Error
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/returns"
+	_ "github.com/lib/pq"
+)
+
+type returnsRepo struct {
+	db *gorm.DB
+}
+
+func NewReturnsRepo(driver, source string) (returns.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&returns.Return{}, &returns.Line{})
+	return &returnsRepo{db: db}, nil
+}
+
+// Create creates the return and its lines in one transaction.
+func (r *returnsRepo) Create(rt *returns.Return) error {
+	tx := r.db.New().Begin()
+
+	if rt.ID == "" {
+		rt.ID = NewID()
+	}
+	lines := rt.Lines
+	rt.Lines = nil
+	if err := tx.Create(rt).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range lines {
+		lines[i].ReturnID = rt.ID
+		if err := tx.Create(&lines[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	rt.Lines = lines
+	return tx.Commit().Error
+}
+
+func (r *returnsRepo) get(where ...interface{}) (returns.Return, error) {
+	var rt returns.Return
+	d := r.db.New()
+
+	if err := d.Preload("Lines").First(&rt, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return returns.Return{}, db.ErrNotFound
+		}
+		return returns.Return{}, err
+	}
+	return rt, nil
+}
+
+func (r *returnsRepo) Get(ID string) (returns.Return, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *returnsRepo) GetByTracking(provider, trackingNumber string) (returns.Return, error) {
+	return r.get("provider=? AND tracking_number=?", provider, trackingNumber)
+}
+
+func (r *returnsRepo) ListByUser(userID string) ([]returns.Return, error) {
+	list := make([]returns.Return, 0)
+	d := r.db.New()
+
+	err := d.Preload("Lines").Order("created_at desc").Find(&list, "user_id=?", userID).Error
+	return list, err
+}
+
+func (r *returnsRepo) ListByOrder(orderID string) ([]returns.Return, error) {
+	list := make([]returns.Return, 0)
+	d := r.db.New()
+
+	err := d.Preload("Lines").Order("created_at").Find(&list, "order_id=?", orderID).Error
+	return list, err
+}
+
+// Save leaves the lines alone, they don't change after the creation.
+func (r *returnsRepo) Save(rt *returns.Return) error {
+	d := r.db.New()
+
+	lines := rt.Lines
+	rt.Lines = nil
+	err := d.Save(rt).Error
+	rt.Lines = lines
+	return err
+}
+
+func (r *returnsRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM RETURN_LINES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM RETURNS").Error
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	_ "github.com/lib/pq"
+)
+
+type schemaRepo struct {
+	db *gorm.DB
+}
+
+func NewSchemaRepo(driver, source string) (db.SchemaRepo, error) {
+	d, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	d.AutoMigrate(&db.Schema{})
+	return &schemaRepo{db: d}, nil
+}
+
+func (r *schemaRepo) Schema() (db.Schema, error) {
+	var s db.Schema
+	err := r.db.New().First(&s, "id = 1").Error
+	if err == gorm.ErrRecordNotFound {
+		return db.Schema{}, nil
+	}
+	return s, err
+}
+
+// Upgrade keeps the newer schema of a binary migrating the database at the
+// same time.
+func (r *schemaRepo) Upgrade(s db.Schema) error {
+	return r.db.Exec(`INSERT INTO schema_version (id, version, compatible, updated_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, compatible = EXCLUDED.compatible, updated_at = EXCLUDED.updated_at
+		WHERE schema_version.version < EXCLUDED.version`, s.Version, s.Compatible, time.Now().UTC()).Error
+}
+
+// ReadOnly returns the data source, an URL or keyword/value pairs, of the
+// connections unable to write, e.g. of a binary the schema of the database
+// is incompatible with.
+func ReadOnly(source string) string {
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		if u, err := url.Parse(source); err == nil {
+			q := u.Query()
+			q.Set("default_transaction_read_only", "on")
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return strings.TrimSpace(source + " default_transaction_read_only=on")
+}
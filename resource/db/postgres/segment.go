@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/order"
+	"github.com/kavirajk/bookshop/segment"
+	_ "github.com/lib/pq"
+)
+
+type segmentRepo struct {
+	db *gorm.DB
+}
+
+func NewSegmentRepo(driver, source string) (segment.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&segment.Segment{}, &segment.Rule{})
+	return &segmentRepo{db: db}, nil
+}
+
+func (r *segmentRepo) get(where ...interface{}) (segment.Segment, error) {
+	var s segment.Segment
+	d := r.db.New()
+
+	if err := d.First(&s, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return segment.Segment{}, db.ErrNotFound
+		}
+		return segment.Segment{}, err
+	}
+	if err := d.Where("segment_id=?", s.ID).Find(&s.Rules).Error; err != nil {
+		return segment.Segment{}, err
+	}
+	return s, nil
+}
+
+func (r *segmentRepo) GetByID(id string) (segment.Segment, error) {
+	return r.get("id=?", id)
+}
+
+func (r *segmentRepo) GetByName(name string) (segment.Segment, error) {
+	return r.get("name=?", name)
+}
+
+func (r *segmentRepo) Create(s *segment.Segment) error {
+	tx := r.db.New().Begin()
+
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	if err := tx.Create(s).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range s.Rules {
+		s.Rules[i].ID = NewID()
+		s.Rules[i].SegmentID = s.ID
+		if err := tx.Create(&s.Rules[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *segmentRepo) List() ([]segment.Segment, error) {
+	segments := make([]segment.Segment, 0)
+	d := r.db.New()
+
+	if err := d.Order("name").Find(&segments).Error; err != nil {
+		return nil, err
+	}
+	var rules []segment.Rule
+	if err := d.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]int, len(segments))
+	for i, s := range segments {
+		byID[s.ID] = i
+	}
+	for _, rule := range rules {
+		if i, ok := byID[rule.SegmentID]; ok {
+			segments[i].Rules = append(segments[i].Rules, rule)
+		}
+	}
+	return segments, nil
+}
+
+func (r *segmentRepo) Delete(id string) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Where("segment_id=?", id).Delete(&segment.Rule{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("id=?", id).Delete(&segment.Segment{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *segmentRepo) SetEvaluated(id string, members int, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&segment.Segment{}).Where("id=?", id).
+		Updates(map[string]interface{}{"members": members, "evaluated_at": at}).Error
+}
+
+func (r *segmentRepo) ListOrders(userID string) ([]order.Order, error) {
+	orders := make([]order.Order, 0)
+	d := r.db.New()
+
+	err := d.Preload("Items").Preload("Items.Genres").Where("created_by_id=?", userID).Find(&orders).Error
+	return orders, err
+}
+
+func (r *segmentRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM SEGMENT_RULES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM SEGMENTS").Error
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/shelf"
+	_ "github.com/lib/pq"
+)
+
+type shelfRepo struct {
+	db *gorm.DB
+}
+
+func NewShelfRepo(driver, source string) (shelf.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&shelf.Entry{}, &shelf.Import{}, &shelf.Row{})
+	return &shelfRepo{db: db}, nil
+}
+
+func (r *shelfRepo) GetEntry(userID, bookID string) (shelf.Entry, error) {
+	var e shelf.Entry
+	d := r.db.New()
+
+	if err := d.First(&e, "user_id=? AND book_id=?", userID, bookID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return shelf.Entry{}, db.ErrNotFound
+		}
+		return shelf.Entry{}, err
+	}
+	return e, nil
+}
+
+func (r *shelfRepo) SaveEntry(e *shelf.Entry) error {
+	d := r.db.New()
+
+	if e.ID == "" {
+		e.ID = NewID()
+		return d.Create(e).Error
+	}
+	return d.Save(e).Error
+}
+
+func (r *shelfRepo) ListEntries(userID, s string) ([]shelf.Entry, error) {
+	entries := make([]shelf.Entry, 0)
+	d := r.db.New().Where("user_id=?", userID).Order("added_at desc")
+
+	if s != "" {
+		d = d.Where("shelf=?", s)
+	}
+	err := d.Find(&entries).Error
+	return entries, err
+}
+
+func (r *shelfRepo) CreateImport(i *shelf.Import, rows []shelf.Row) error {
+	tx := r.db.New().Begin()
+
+	if i.ID == "" {
+		i.ID = NewID()
+	}
+	if err := tx.Create(i).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for n := range rows {
+		rows[n].ID = NewID()
+		rows[n].ImportID = i.ID
+		if err := tx.Create(&rows[n]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *shelfRepo) GetImport(ID string) (shelf.Import, error) {
+	var i shelf.Import
+	d := r.db.New()
+
+	if err := d.First(&i, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return shelf.Import{}, db.ErrNotFound
+		}
+		return shelf.Import{}, err
+	}
+	return i, nil
+}
+
+func (r *shelfRepo) SaveImport(i *shelf.Import) error {
+	d := r.db.New()
+
+	return d.Save(i).Error
+}
+
+func (r *shelfRepo) ListImports(userID string) ([]shelf.Import, error) {
+	imports := make([]shelf.Import, 0)
+	d := r.db.New()
+
+	err := d.Where("user_id=?", userID).Order("created_at desc").Find(&imports).Error
+	return imports, err
+}
+
+func (r *shelfRepo) ListImportsByStatus(status ...string) ([]shelf.Import, error) {
+	imports := make([]shelf.Import, 0)
+	d := r.db.New()
+
+	err := d.Where("status IN (?)", status).Order("created_at").Find(&imports).Error
+	return imports, err
+}
+
+func (r *shelfRepo) GetRow(ID string) (shelf.Row, error) {
+	var row shelf.Row
+	d := r.db.New()
+
+	if err := d.First(&row, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return shelf.Row{}, db.ErrNotFound
+		}
+		return shelf.Row{}, err
+	}
+	return row, nil
+}
+
+func (r *shelfRepo) SaveRow(row *shelf.Row) error {
+	d := r.db.New()
+
+	return d.Save(row).Error
+}
+
+func (r *shelfRepo) ListRows(importID string, status ...string) ([]shelf.Row, error) {
+	rows := make([]shelf.Row, 0)
+	d := r.db.New().Where("import_id=?", importID).Order("line")
+
+	if len(status) > 0 {
+		d = d.Where("status IN (?)", status)
+	}
+	err := d.Find(&rows).Error
+	return rows, err
+}
+
+func (r *shelfRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM SHELF_IMPORT_ROWS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM SHELF_IMPORTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM SHELF_ENTRIES").Error
+}
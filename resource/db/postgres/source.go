@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// dialects are the drivers of RegisterSource, along with the dialect of
+// the driver they connect with. They are registered on startup, before
+// the repos are opened.
+var dialects = make(map[string]string)
+
+// RegisterSource registers the sql driver name connecting with driver to
+// the data source returned by source at the time, e.g. of the credentials
+// of a secrets.Value, so that the new connections pick up the rotated
+// credentials. The repos opened with name ignore their source. Like
+// sql.Register it panics if name is registered twice.
+func RegisterSource(name, driver string, source func() string) error {
+	db, err := sql.Open(driver, "")
+	if err != nil {
+		return err
+	}
+	d := db.Driver()
+	db.Close()
+	sql.Register(name, sourceDriver{Driver: d, source: source})
+	dialects[name] = driver
+	return nil
+}
+
+type sourceDriver struct {
+	driver.Driver
+	source func() string
+}
+
+func (d sourceDriver) Open(string) (driver.Conn, error) {
+	return d.Driver.Open(d.source())
+}
+
+// open opens the database of driver, with the dialect of the driver it
+// connects with if it's one of RegisterSource.
+func open(driver, source string) (*gorm.DB, error) {
+	if dialect, ok := dialects[driver]; ok {
+		return gorm.Open(dialect, driver, source)
+	}
+	return gorm.Open(driver, source)
+}
+
+// Credentials returns the data source, an URL or keyword/value pairs,
+// connecting as user with password.
+func Credentials(source, user, password string) string {
+	if strings.HasPrefix(source, "postgres://") || strings.HasPrefix(source, "postgresql://") {
+		if u, err := url.Parse(source); err == nil {
+			u.User = url.UserPassword(user, password)
+			return u.String()
+		}
+	}
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return strings.TrimSpace(source + " user='" + quote.Replace(user) + "' password='" + quote.Replace(password) + "'")
+}
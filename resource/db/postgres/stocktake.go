@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/stocktake"
+	_ "github.com/lib/pq"
+)
+
+type stocktakeRepo struct {
+	db *gorm.DB
+}
+
+func NewStocktakeRepo(driver, source string) (stocktake.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&stocktake.Session{}, &stocktake.Count{})
+	return &stocktakeRepo{db: db}, nil
+}
+
+// Create creates the session and its counts in one transaction.
+func (r *stocktakeRepo) Create(s *stocktake.Session) error {
+	tx := r.db.New().Begin()
+
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	counts := s.Counts
+	s.Counts = nil
+	if err := tx.Create(s).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range counts {
+		counts[i].SessionID = s.ID
+		if err := tx.Create(&counts[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	s.Counts = counts
+	return tx.Commit().Error
+}
+
+// Get loads the counts in the order of the walk.
+func (r *stocktakeRepo) Get(ID string) (stocktake.Session, error) {
+	var s stocktake.Session
+	d := r.db.New()
+
+	if err := d.First(&s, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return stocktake.Session{}, db.ErrNotFound
+		}
+		return stocktake.Session{}, err
+	}
+	if err := d.Order("seq").Find(&s.Counts, "session_id=?", ID).Error; err != nil {
+		return stocktake.Session{}, err
+	}
+	return s, nil
+}
+
+func (r *stocktakeRepo) List(status string) ([]stocktake.Session, error) {
+	sessions := make([]stocktake.Session, 0)
+	d := r.db.New().Order("opened_at desc")
+
+	if status != "" {
+		d = d.Where("status=?", status)
+	}
+	err := d.Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *stocktakeRepo) Active(location string) (stocktake.Session, error) {
+	var s stocktake.Session
+	d := r.db.New()
+
+	err := d.First(&s, "location=? AND status IN (?)", location, []string{stocktake.StatusOpen, stocktake.StatusSubmitted}).Error
+	if err == gorm.ErrRecordNotFound {
+		return stocktake.Session{}, db.ErrNotFound
+	}
+	return s, err
+}
+
+// Save saves the session and its counts in one transaction.
+func (r *stocktakeRepo) Save(s *stocktake.Session) error {
+	tx := r.db.New().Begin()
+
+	counts := s.Counts
+	s.Counts = nil
+	err := tx.Save(s).Error
+	s.Counts = counts
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range counts {
+		if err := tx.Save(&counts[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *stocktakeRepo) SaveCount(c *stocktake.Count) error {
+	return r.db.New().Save(c).Error
+}
+
+func (r *stocktakeRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM STOCKTAKE_COUNTS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM STOCKTAKE_SESSIONS").Error
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/storefront"
+	_ "github.com/lib/pq"
+)
+
+type storefrontRepo struct {
+	db *gorm.DB
+}
+
+func NewStorefrontRepo(driver, source string) (storefront.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&storefront.Cursor{})
+	return &storefrontRepo{db: db}, nil
+}
+
+func (r *storefrontRepo) Cursor(name string) (int64, error) {
+	var c storefront.Cursor
+	d := r.db.New()
+
+	if err := d.First(&c, "name=?", name).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return c.Seq, nil
+}
+
+func (r *storefrontRepo) SaveCursor(name string, seq int64) error {
+	d := r.db.New()
+
+	return d.Save(&storefront.Cursor{Name: name, Seq: seq, UpdatedAt: time.Now().UTC()}).Error
+}
+
+func (r *storefrontRepo) Drop() error {
+	return r.db.Exec("DELETE FROM STOREFRONT_CURSORS").Error
+}
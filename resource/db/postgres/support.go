@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/support"
+	_ "github.com/lib/pq"
+)
+
+type supportRepo struct {
+	db *gorm.DB
+}
+
+func NewSupportRepo(driver, source string) (support.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&support.Ticket{})
+	return &supportRepo{db: db}, nil
+}
+
+func (r *supportRepo) Create(t *support.Ticket) error {
+	d := r.db.New()
+
+	if t.ID == "" {
+		t.ID = NewID()
+	}
+	return d.Create(t).Error
+}
+
+func (r *supportRepo) Save(t *support.Ticket) error {
+	d := r.db.New()
+
+	return d.Save(t).Error
+}
+
+func (r *supportRepo) get(where ...interface{}) (support.Ticket, error) {
+	var t support.Ticket
+	d := r.db.New()
+
+	if err := d.First(&t, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return support.Ticket{}, db.ErrNotFound
+		}
+		return support.Ticket{}, err
+	}
+	return t, nil
+}
+
+func (r *supportRepo) GetByID(ID string) (support.Ticket, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *supportRepo) GetByExternalID(provider, externalID string) (support.Ticket, error) {
+	return r.get("provider=? AND external_id=?", provider, externalID)
+}
+
+func (r *supportRepo) ListByOrder(orderID string) ([]support.Ticket, error) {
+	tickets := make([]support.Ticket, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&tickets, "order_id=?", orderID).Error
+	return tickets, err
+}
+
+func (r *supportRepo) ListByUser(userID string) ([]support.Ticket, error) {
+	tickets := make([]support.Ticket, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&tickets, "user_id=?", userID).Error
+	return tickets, err
+}
+
+func (r *supportRepo) Drop() error {
+	return r.db.Exec("DELETE FROM SUPPORT_TICKETS").Error
+}
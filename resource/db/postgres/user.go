@@ -3,9 +3,12 @@ package postgres
 import (
 	"bytes"
 	"encoding/base32"
+	"strings"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
 	"github.com/kavirajk/bookshop/user"
 	_ "github.com/lib/pq"
 	"github.com/pborman/uuid"
@@ -13,15 +16,30 @@ import (
 
 type userRepo struct {
 	db *gorm.DB
+	// trigram tells whether pg_trgm is installed, the search matches
+	// misspellings and parts of words only then.
+	trigram bool
 }
 
+// userDocument is the searched text of a user, as indexed.
+const userDocument = "(first_name||' '||last_name||' '||email)"
+
 func NewUserRepo(driver, source string) (user.Repo, error) {
-	db, err := gorm.Open(driver, source)
+	db, err := open(driver, source)
 	if err != nil {
 		return nil, err
 	}
-	db.AutoMigrate(&user.User{})
-	return &userRepo{db: db}, nil
+	db.AutoMigrate(&user.User{}, &user.Job{}, &user.Result{}, &user.RefreshToken{}, &user.SocialAccount{}, &user.LoginFailure{}, &user.ResetToken{}, &user.Session{}, &user.TrustedDevice{}, &user.Address{})
+	// Creating the extension takes a privilege the shop may not have, the
+	// search falls back to the words and substrings of the document then.
+	trigram := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error == nil
+	db.Exec("CREATE INDEX IF NOT EXISTS users_search_words ON users USING gin (to_tsvector('simple', " + userDocument + "))")
+	if trigram {
+		db.Exec("CREATE INDEX IF NOT EXISTS users_search_trigrams ON users USING gin (" + userDocument + " gin_trgm_ops)")
+	}
+	// The keyset of the list paged by cursor.
+	db.Exec("CREATE INDEX IF NOT EXISTS users_created_at_id ON users (created_at, id)")
+	return &userRepo{db: db, trigram: trigram}, nil
 }
 
 func (r *userRepo) get(where ...interface{}) (user.User, error) {
@@ -53,23 +71,135 @@ func (r *userRepo) GetByToken(token string) (user.User, error) {
 	return r.get("auth_token=?", token)
 }
 
-func (r *userRepo) GetByResetKey(key string) (user.User, error) {
-	return r.get("reset_key=?", key)
+func (r *userRepo) List(f filter.Expr, by user.Filter, order string, limit, offset int, count db.Count) ([]user.User, int, error) {
+	users := make([]user.User, 0)
+	d, count := filtered(r.db.New().Order(order).Where("deleted_at IS NULL").Scopes(userScopes(by)...), f, count)
+	if !by.Empty() && count == db.CountEstimate {
+		// The table statistics know nothing of the filter either.
+		count = db.CountNone
+	}
+
+	if err := d.Limit(fetchLimit(limit, count)).Offset(offset).Find(&users).Error; err != nil {
+		return users, 0, err
+	}
+	total, err := total(d, &user.User{}, offset+len(users), count)
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, total, err
 }
 
-func (r *userRepo) List(order string, limit, offset int) ([]user.User, int, error) {
+// ListByCursor seeks past the keyset of c, fetching one more user to know
+// whether there are more. Before the cursor the users are fetched backwards
+// and put back in order.
+func (r *userRepo) ListByCursor(f filter.Expr, by user.Filter, c user.Cursor, limit int) ([]user.User, bool, error) {
 	users := make([]user.User, 0)
-	db := r.db.New()
+	d, _ := filtered(r.db.New().Where("deleted_at IS NULL").Scopes(userScopes(by)...), f, db.CountNone)
+	switch {
+	case c.Start():
+		d = d.Order("created_at, id")
+	case c.Before:
+		d = d.Where("(created_at, id) < (?, ?)", c.CreatedAt, c.ID).Order("created_at DESC, id DESC")
+	default:
+		d = d.Where("(created_at, id) > (?, ?)", c.CreatedAt, c.ID).Order("created_at, id")
+	}
+	if err := d.Limit(limit + 1).Find(&users).Error; err != nil {
+		return users, false, err
+	}
+	more := len(users) > limit
+	if more {
+		users = users[:limit]
+	}
+	if c.Before {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	return users, more, nil
+}
+
+// Search matches the words of query, a substring of the document, or with
+// pg_trgm a similar text, ranking the matches by both.
+func (r *userRepo) Search(query string, limit, offset int) ([]user.User, int, error) {
+	users := make([]user.User, 0)
+	words := "to_tsvector('simple', " + userDocument + ")"
+	match := words + " @@ plainto_tsquery('simple', ?) OR " + userDocument + " ILIKE ?"
+	rank := "ts_rank(" + words + ", plainto_tsquery('simple', ?))"
+	args := []interface{}{query, "%" + escapeLike(query) + "%"}
+	if r.trigram {
+		match += " OR " + userDocument + " % ?"
+		rank += " + similarity(" + userDocument + ", ?)"
+		args = append(args, query)
+	}
+	d := r.db.New()
 
 	var total int
-	if err := db.Model(&user.User{}).Order(order).Count(&total).Error; err != nil {
+	if err := d.Model(&user.User{}).Where("deleted_at IS NULL").Where(match, args...).Count(&total).Error; err != nil {
 		return users, 0, err
 	}
-
-	err := db.Order(order).Limit(limit).Offset(offset).Find(&users).Error
+	// The rank takes the query again, without the ILIKE pattern.
+	rankArgs := []interface{}{query}
+	if r.trigram {
+		rankArgs = append(rankArgs, query)
+	}
+	err := d.Raw("SELECT * FROM users WHERE deleted_at IS NULL AND ("+match+") ORDER BY "+rank+" DESC, id LIMIT ? OFFSET ?",
+		append(append(args, rankArgs...), limit, offset)...).Scan(&users).Error
 	return users, total, err
 }
 
+func (r *userRepo) ListByFilter(f user.Filter) ([]user.User, error) {
+	users := make([]user.User, 0)
+	d := r.db.New().Order("email").Where("deleted_at IS NULL").Scopes(userScopes(f)...)
+
+	err := d.Find(&users).Error
+	return users, err
+}
+
+// userScopes returns the scopes of the criteria set of f.
+func userScopes(f user.Filter) []func(*gorm.DB) *gorm.DB {
+	var scopes []func(*gorm.DB) *gorm.DB
+	where := func(query string, args ...interface{}) {
+		scopes = append(scopes, func(d *gorm.DB) *gorm.DB { return d.Where(query, args...) })
+	}
+	if len(f.IDs) > 0 {
+		where("id IN (?)", f.IDs)
+	}
+	if f.Email != "" {
+		where("lower(email)=lower(?)", f.Email)
+	}
+	if f.Role != "" {
+		where("role=?", f.Role)
+	}
+	if f.Deactivated != nil {
+		where("deactivated=?", *f.Deactivated)
+	}
+	if f.Verified != nil {
+		if *f.Verified {
+			where("email_verified_at IS NOT NULL")
+		} else {
+			where("email_verified_at IS NULL")
+		}
+	}
+	if f.Segment != "" {
+		where("','||segment_string||',' LIKE ?", "%,"+f.Segment+",%")
+	}
+	if f.NameContains != "" {
+		where("first_name||' '||last_name ILIKE ?", "%"+escapeLike(f.NameContains)+"%")
+	}
+	if f.CreatedAfter != nil {
+		where("created_at > ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		where("created_at < ?", *f.CreatedBefore)
+	}
+	return scopes
+}
+
+// escapeLike escapes the LIKE wildcards of s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 func (r *userRepo) Create(u *user.User) error {
 	d := r.db.New()
 
@@ -83,16 +213,461 @@ func (r *userRepo) Create(u *user.User) error {
 	return nil
 }
 
+// Save updates u and bumps its version, it fails with db.ErrConflict if
+// the stored user is not at the version of u anymore.
 func (r *userRepo) Save(u *user.User) error {
+	tx := r.db.New().Begin()
+
+	var cur user.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&cur, "id=?", u.ID).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return db.ErrNotFound
+		}
+		return err
+	}
+	if cur.Version != u.Version {
+		tx.Rollback()
+		return db.ErrConflict
+	}
+	u.Version++
+	if err := tx.Save(u).Error; err != nil {
+		tx.Rollback()
+		u.Version--
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) CreateJob(j *user.Job) error {
+	d := r.db.New()
+
+	if j.ID == "" {
+		j.ID = NewID()
+	}
+	return d.Create(j).Error
+}
+
+func (r *userRepo) GetJob(ID string) (user.Job, error) {
+	var j user.Job
+	d := r.db.New()
+
+	if err := d.First(&j, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.Job{}, db.ErrNotFound
+		}
+		return user.Job{}, err
+	}
+	return j, nil
+}
+
+func (r *userRepo) SaveJob(j *user.Job) error {
+	d := r.db.New()
+
+	return d.Save(j).Error
+}
+
+func (r *userRepo) ListJobs() ([]user.Job, error) {
+	jobs := make([]user.Job, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at desc").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *userRepo) ListJobsByStatus(status ...string) ([]user.Job, error) {
+	jobs := make([]user.Job, 0)
+	d := r.db.New()
+
+	err := d.Where("status IN (?)", status).Order("created_at").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *userRepo) CreateResults(results []user.Result) error {
+	tx := r.db.New().Begin()
+
+	for n := range results {
+		results[n].ID = NewID()
+		if err := tx.Create(&results[n]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) ListResults(jobID string, status ...string) ([]user.Result, error) {
+	results := make([]user.Result, 0)
+	d := r.db.New().Where("job_id=?", jobID).Order("email")
+
+	if len(status) > 0 {
+		d = d.Where("status IN (?)", status)
+	}
+	err := d.Find(&results).Error
+	return results, err
+}
+
+func (r *userRepo) CreateRefreshToken(t *user.RefreshToken) error {
+	d := r.db.New()
+
+	if t.ID == "" {
+		t.ID = NewID()
+	}
+	return d.Create(t).Error
+}
+
+func (r *userRepo) GetRefreshToken(hash string) (user.RefreshToken, error) {
+	var t user.RefreshToken
+	d := r.db.New()
+
+	if err := d.First(&t, "hash=?", hash).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.RefreshToken{}, db.ErrNotFound
+		}
+		return user.RefreshToken{}, err
+	}
+	return t, nil
+}
+
+// RotateRefreshToken only revokes old if it isn't yet, two concurrent uses
+// of a token can't both get a replacement.
+func (r *userRepo) RotateRefreshToken(old, next *user.RefreshToken) error {
+	tx := r.db.New().Begin()
+
+	if next.ID == "" {
+		next.ID = NewID()
+	}
+	res := tx.Model(&user.RefreshToken{}).Where("id=? AND revoked_at IS NULL", old.ID).
+		Updates(map[string]interface{}{"revoked_at": next.CreatedAt, "replaced_by": next.ID})
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return db.ErrConflict
+	}
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	old.RevokedAt, old.ReplacedBy = &next.CreatedAt, next.ID
+	return nil
+}
+
+func (r *userRepo) RevokeRefreshTokens(userID string, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.RefreshToken{}).Where("user_id=? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+func (r *userRepo) GetSocialAccount(provider, subject string) (user.SocialAccount, error) {
+	var a user.SocialAccount
+	d := r.db.New()
+
+	if err := d.First(&a, "provider=? AND subject=?", provider, subject).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.SocialAccount{}, db.ErrNotFound
+		}
+		return user.SocialAccount{}, err
+	}
+	return a, nil
+}
+
+// LinkSocialAccount creates the new user and its account in one go, a
+// failed link doesn't leave a user behind.
+func (r *userRepo) LinkSocialAccount(u *user.User, a *user.SocialAccount) error {
+	tx := r.db.New().Begin()
+
+	if u.ID == "" {
+		u.ID = NewID()
+		if err := tx.Create(u).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	a.UserID = u.ID
+	if err := tx.Create(a).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) SetSegments(userID string, segments []string) error {
+	d := r.db.New()
+
+	return d.Model(&user.User{}).Where("id=?", userID).
+		UpdateColumn("segment_string", strings.Join(segments, ",")).Error
+}
+
+func (r *userRepo) RecordLoginFailure(userID string, at time.Time) error {
 	d := r.db.New()
 
-	if err := d.Save(u).Error; err != nil {
+	return d.Create(&user.LoginFailure{ID: NewID(), UserID: userID, At: at}).Error
+}
+
+func (r *userRepo) CountLoginFailures(userID string, since time.Time) (int, error) {
+	d := r.db.New()
+
+	var n int
+	err := d.Model(&user.LoginFailure{}).Where("user_id=? AND at>=?", userID, since).Count(&n).Error
+	return n, err
+}
+
+func (r *userRepo) ClearLoginFailures(userID string) error {
+	d := r.db.New()
+
+	return d.Where("user_id=?", userID).Delete(&user.LoginFailure{}).Error
+}
+
+func (r *userRepo) SetLockedUntil(userID string, until *time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.User{}).Where("id=?", userID).UpdateColumn("locked_until", until).Error
+}
+
+// Forget deletes the traces of the user in a transaction, so that an
+// erasure failing halfway is retried in full.
+func (r *userRepo) Forget(userID string) error {
+	tx := r.db.New().Begin()
+
+	for _, model := range []interface{}{
+		&user.Session{}, &user.TrustedDevice{}, &user.RefreshToken{}, &user.ResetToken{},
+		&user.SocialAccount{}, &user.Address{}, &user.LoginFailure{},
+	} {
+		if err := tx.Where("user_id=?", userID).Delete(model).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) CreateSession(s *user.Session) error {
+	d := r.db.New()
+
+	if s.ID == "" {
+		s.ID = NewID()
+	}
+	return d.Create(s).Error
+}
+
+func (r *userRepo) GetSession(id string) (user.Session, error) {
+	var s user.Session
+	d := r.db.New()
+
+	if err := d.First(&s, "id=?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.Session{}, db.ErrNotFound
+		}
+		return user.Session{}, err
+	}
+	return s, nil
+}
+
+func (r *userRepo) ListSessions(userID string) ([]user.Session, error) {
+	sessions := make([]user.Session, 0)
+	d := r.db.New()
+
+	err := d.Order("last_seen_at DESC").Find(&sessions, "user_id=? AND revoked_at IS NULL", userID).Error
+	return sessions, err
+}
+
+func (r *userRepo) SeeSession(id string, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.Session{}).Where("id=?", id).UpdateColumn("last_seen_at", at).Error
+}
+
+func (r *userRepo) RevokeSession(id string, at time.Time) error {
+	return r.revokeSessions(at, "id=?", "session_id=?", id)
+}
+
+func (r *userRepo) RevokeSessions(userID string, at time.Time) error {
+	return r.revokeSessions(at, "? IN (user_id, impersonated_by)", "user_id=?", userID)
+}
+
+// revokeSessions revokes the sessions where sessions and the refresh tokens
+// where tokens, selecting on arg, in one go.
+func (r *userRepo) revokeSessions(at time.Time, sessions, tokens string, arg interface{}) error {
+	tx := r.db.New().Begin()
+
+	if err := tx.Model(&user.Session{}).Where(sessions+" AND revoked_at IS NULL", arg).
+		UpdateColumn("revoked_at", at).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&user.RefreshToken{}).Where(tokens+" AND revoked_at IS NULL", arg).
+		UpdateColumn("revoked_at", at).Error; err != nil {
+		tx.Rollback()
 		return err
 	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) CreateTrustedDevice(dv *user.TrustedDevice) error {
+	d := r.db.New()
+
+	if dv.ID == "" {
+		dv.ID = NewID()
+	}
+	return d.Create(dv).Error
+}
+
+func (r *userRepo) GetTrustedDevice(hash string) (user.TrustedDevice, error) {
+	var dv user.TrustedDevice
+	d := r.db.New()
+
+	if err := d.First(&dv, "hash=?", hash).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.TrustedDevice{}, db.ErrNotFound
+		}
+		return user.TrustedDevice{}, err
+	}
+	return dv, nil
+}
+
+func (r *userRepo) ListTrustedDevices(userID string) ([]user.TrustedDevice, error) {
+	devices := make([]user.TrustedDevice, 0)
+	d := r.db.New()
+
+	err := d.Order("last_used_at DESC").Find(&devices, "user_id=? AND revoked_at IS NULL", userID).Error
+	return devices, err
+}
+
+func (r *userRepo) UseTrustedDevice(id, ip string, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.TrustedDevice{}).Where("id=?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": at, "ip": ip}).Error
+}
+
+func (r *userRepo) RevokeTrustedDevice(id string, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.TrustedDevice{}).Where("id=? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", at).Error
+}
+
+func (r *userRepo) RevokeTrustedDevices(userID string, at time.Time) error {
+	d := r.db.New()
+
+	return d.Model(&user.TrustedDevice{}).Where("user_id=? AND revoked_at IS NULL", userID).UpdateColumn("revoked_at", at).Error
+}
+
+func (r *userRepo) CreateResetToken(t *user.ResetToken) error {
+	d := r.db.New()
+
+	if t.ID == "" {
+		t.ID = NewID()
+	}
+	return d.Create(t).Error
+}
+
+func (r *userRepo) GetResetToken(hash string) (user.ResetToken, error) {
+	var t user.ResetToken
+	d := r.db.New()
+
+	if err := d.First(&t, "hash=?", hash).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user.ResetToken{}, db.ErrNotFound
+		}
+		return user.ResetToken{}, err
+	}
+	return t, nil
+}
+
+// UseResetToken only marks t used if it isn't yet, two concurrent resets
+// with a token can't both set the password.
+func (r *userRepo) UseResetToken(t *user.ResetToken, at time.Time) error {
+	d := r.db.New()
+
+	res := d.Model(&user.ResetToken{}).Where("id=? AND used_at IS NULL", t.ID).Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return db.ErrConflict
+	}
+	t.UsedAt = &at
 	return nil
 }
 
+// CreateAddress unsets the other defaults of the user in the same
+// transaction as the creation of a default address. So does SaveAddress.
+func (r *userRepo) CreateAddress(a *user.Address) error {
+	if a.ID == "" {
+		a.ID = NewID()
+	}
+	return r.saveAddress(a, func(tx *gorm.DB) error { return tx.Create(a).Error })
+}
+
+func (r *userRepo) SaveAddress(a *user.Address) error {
+	return r.saveAddress(a, func(tx *gorm.DB) error { return tx.Save(a).Error })
+}
+
+func (r *userRepo) saveAddress(a *user.Address, save func(tx *gorm.DB) error) error {
+	tx := r.db.New().Begin()
+
+	if a.IsDefault {
+		if err := tx.Exec("UPDATE user_addresses SET is_default=false WHERE user_id=? AND id<>?", a.UserID, a.ID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := save(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (r *userRepo) ListAddresses(userID string) ([]user.Address, error) {
+	addresses := make([]user.Address, 0)
+	d := r.db.New()
+
+	err := d.Where("user_id=?", userID).Order("is_default desc, updated_at desc").Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *userRepo) DeleteAddress(id string) error {
+	d := r.db.New()
+
+	return d.Where("id=?", id).Delete(&user.Address{}).Error
+}
+
 func (r *userRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM USER_ADDRESSES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_TRUSTED_DEVICES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_SESSIONS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_RESET_TOKENS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_LOGIN_FAILURES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_SOCIAL_ACCOUNTS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_REFRESH_TOKENS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_JOB_RESULTS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM USER_JOBS").Error; err != nil {
+		return err
+	}
 	return r.db.Exec("DELETE FROM USERS").Error
 }
 
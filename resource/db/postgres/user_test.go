@@ -136,7 +136,7 @@ func TestList(t *testing.T) {
 	}
 
 	t.Run("test limit", func(t *testing.T) {
-		us, total, err := repo.List("", 2, 0)
+		us, total, err := repo.List("", 2, 0, db.CountExact)
 		if err != nil {
 			t.Errorf("expected nil error, got %v\n", err)
 		}
@@ -148,7 +148,7 @@ func TestList(t *testing.T) {
 		}
 	})
 	t.Run("test offset", func(t *testing.T) {
-		us, total, err := repo.List("", 5, 1) // starting from offset 1
+		us, total, err := repo.List("", 5, 1, db.CountExact) // starting from offset 1
 
 		if err != nil {
 			t.Errorf("expected nil error, got %v\n", err)
@@ -160,8 +160,25 @@ func TestList(t *testing.T) {
 			t.Errorf("expected return length 3, got %v\n", len(us))
 		}
 	})
+	t.Run("test without count", func(t *testing.T) {
+		us, total, err := repo.List("", 2, 1, db.CountNone)
+
+		if err != nil {
+			t.Errorf("expected nil error, got %v\n", err)
+		}
+		if total <= 3 {
+			t.Errorf("expected total past the page to tell about the next one, got %v\n", total)
+		}
+		if len(us) != 2 {
+			t.Errorf("expected return length 2, got %v\n", len(us))
+		}
+		_, total, _ = repo.List("", 5, 1, db.CountNone)
+		if total != 4 {
+			t.Errorf("expected total 4 on the last page, got %v\n", total)
+		}
+	})
 	t.Run("test ordering", func(t *testing.T) {
-		us, _, err := repo.List("username", 3, 0)
+		us, _, err := repo.List("username", 3, 0, db.CountExact)
 
 		if err != nil {
 			t.Errorf("expected nil error, got %v\n", err)
@@ -170,7 +187,7 @@ func TestList(t *testing.T) {
 		if us[0].Username != "test1" {
 			t.Errorf("ordering failed. expected test1, got %v\n", us[0].Username)
 		}
-		us, _, err = repo.List("username desc", 3, 0)
+		us, _, err = repo.List("username desc", 3, 0, db.CountExact)
 		if us[0].Username != "test4" {
 			t.Errorf("ordering failed. expected test1, got %v\n", us[0].Username)
 		}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/vat"
+	_ "github.com/lib/pq"
+)
+
+type vatRepo struct {
+	db *gorm.DB
+}
+
+func NewVATRepo(driver, source string) (vat.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&vat.Validation{}, &vat.OrderTax{})
+	return &vatRepo{db: db}, nil
+}
+
+func (r *vatRepo) CreateValidation(v *vat.Validation) error {
+	d := r.db.New()
+
+	if v.ID == "" {
+		v.ID = NewID()
+	}
+
+	if err := d.Create(v).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vatRepo) CreateOrderTax(t *vat.OrderTax) error {
+	d := r.db.New()
+
+	if err := d.Create(t).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vatRepo) GetOrderTax(orderID string) (vat.OrderTax, error) {
+	var t vat.OrderTax
+	d := r.db.New()
+
+	if err := d.First(&t, "order_id=?", orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return vat.OrderTax{}, db.ErrNotFound
+		}
+		return vat.OrderTax{}, err
+	}
+	return t, nil
+}
+
+func (r *vatRepo) ListOrderTaxes(from, to time.Time) ([]vat.OrderTax, error) {
+	taxes := make([]vat.OrderTax, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&taxes, "created_at>=? AND created_at<?", from, to).Error
+	return taxes, err
+}
+
+func (r *vatRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM ORDER_TAXES").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM VAT_VALIDATIONS").Error
+}
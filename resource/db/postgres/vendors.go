@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/vendors"
+	_ "github.com/lib/pq"
+)
+
+type vendorRepo struct {
+	db *gorm.DB
+}
+
+func NewVendorRepo(driver, source string) (vendors.Repo, error) {
+	db, err := open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	db.AutoMigrate(&vendors.Vendor{}, &vendors.APIKey{}, &vendors.SyncBatch{}, &vendors.SyncError{})
+	return &vendorRepo{db: db}, nil
+}
+
+func (r *vendorRepo) get(where ...interface{}) (vendors.Vendor, error) {
+	var v vendors.Vendor
+	d := r.db.New()
+
+	if err := d.First(&v, where...).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return vendors.Vendor{}, db.ErrNotFound
+		}
+		return vendors.Vendor{}, err
+	}
+	return v, nil
+}
+
+func (r *vendorRepo) GetByID(ID string) (vendors.Vendor, error) {
+	return r.get("id=?", ID)
+}
+
+func (r *vendorRepo) GetByEmail(email string) (vendors.Vendor, error) {
+	return r.get("email=?", email)
+}
+
+func (r *vendorRepo) List(status string, f filter.Expr) ([]vendors.Vendor, error) {
+	list := make([]vendors.Vendor, 0)
+	d, _ := filtered(r.db.New().Order("created_at"), f, db.CountNone)
+
+	if status != "" {
+		d = d.Where("status=?", status)
+	}
+	err := d.Find(&list).Error
+	return list, err
+}
+
+func (r *vendorRepo) Create(v *vendors.Vendor) error {
+	d := r.db.New()
+
+	if v.ID == "" {
+		v.ID = NewID()
+	}
+
+	if err := d.Create(v).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) Save(v *vendors.Vendor) error {
+	d := r.db.New()
+
+	if err := d.Save(v).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) CreateKey(k *vendors.APIKey) error {
+	d := r.db.New()
+
+	if k.ID == "" {
+		k.ID = NewID()
+	}
+
+	if err := d.Create(k).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) SaveKey(k *vendors.APIKey) error {
+	d := r.db.New()
+
+	if err := d.Save(k).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) GetKeyByPrefix(prefix string) (vendors.APIKey, error) {
+	var k vendors.APIKey
+	d := r.db.New()
+
+	if err := d.First(&k, "prefix=?", prefix).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return vendors.APIKey{}, db.ErrNotFound
+		}
+		return vendors.APIKey{}, err
+	}
+	return k, nil
+}
+
+func (r *vendorRepo) ListKeys(vendorID string) ([]vendors.APIKey, error) {
+	keys := make([]vendors.APIKey, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").Find(&keys, "vendor_id=?", vendorID).Error
+	return keys, err
+}
+
+func (r *vendorRepo) CreateBatch(b *vendors.SyncBatch) error {
+	d := r.db.New()
+
+	if b.ID == "" {
+		b.ID = NewID()
+	}
+
+	if err := d.Create(b).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) SaveBatch(b *vendors.SyncBatch) error {
+	d := r.db.New()
+
+	if err := d.Save(b).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *vendorRepo) GetBatch(ID string) (vendors.SyncBatch, error) {
+	var b vendors.SyncBatch
+	d := r.db.New()
+
+	if err := d.First(&b, "id=?", ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return vendors.SyncBatch{}, db.ErrNotFound
+		}
+		return vendors.SyncBatch{}, err
+	}
+	return b, nil
+}
+
+func (r *vendorRepo) ListBatches(vendorID string) ([]vendors.SyncBatch, error) {
+	batches := make([]vendors.SyncBatch, 0)
+	d := r.db.New()
+
+	// Payload can be large, it's only needed for processing.
+	err := d.Select("id, vendor_id, source, status, total, updated, failed, created_at, finished_at").
+		Order("created_at desc").
+		Find(&batches, "vendor_id=?", vendorID).Error
+	return batches, err
+}
+
+func (r *vendorRepo) ListPendingBatches() ([]vendors.SyncBatch, error) {
+	batches := make([]vendors.SyncBatch, 0)
+	d := r.db.New()
+
+	err := d.Order("created_at").
+		Find(&batches, "status IN (?)", []string{vendors.BatchQueued, vendors.BatchProcessing}).Error
+	return batches, err
+}
+
+func (r *vendorRepo) CreateSyncErrors(errs []vendors.SyncError) error {
+	tx := r.db.New().Begin()
+
+	for i := range errs {
+		if errs[i].ID == "" {
+			errs[i].ID = NewID()
+		}
+		if err := tx.Create(&errs[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (r *vendorRepo) ListSyncErrors(batchID string) ([]vendors.SyncError, error) {
+	errs := make([]vendors.SyncError, 0)
+	d := r.db.New()
+
+	err := d.Order("line").Find(&errs, "batch_id=?", batchID).Error
+	return errs, err
+}
+
+func (r *vendorRepo) Drop() error {
+	if err := r.db.Exec("DELETE FROM VENDOR_SYNC_ERRORS").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM VENDOR_SYNC_BATCHES").Error; err != nil {
+		return err
+	}
+	if err := r.db.Exec("DELETE FROM API_KEYS").Error; err != nil {
+		return err
+	}
+	return r.db.Exec("DELETE FROM VENDORS").Error
+}
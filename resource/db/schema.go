@@ -0,0 +1,48 @@
+package db
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrIncompatibleSchema = errors.New("database schema incompatible with this version")
+
+// Schema is the version of the schema of the database, the one of the
+// newest binary migrating it, and the oldest version of the binaries still
+// able to run against it.
+type Schema struct {
+	ID         int `gorm:"primary_key"`
+	Version    int
+	Compatible int
+	UpdatedAt  time.Time
+}
+
+func (Schema) TableName() string {
+	return "schema_version"
+}
+
+// CurrentSchema is the schema of this binary. Bump its Version on a change
+// of the tables, and its Compatible to the new Version too on a change the
+// binaries before can't run against, e.g. a column dropped, renamed or
+// made NOT NULL.
+var CurrentSchema = Schema{Version: 1, Compatible: 1}
+
+// SchemaRepo records the schema of the database.
+type SchemaRepo interface {
+	// Schema returns the schema of the database, the zero Schema if none
+	// is recorded.
+	Schema() (Schema, error)
+	// Upgrade records s unless the schema recorded is as new already.
+	Upgrade(s Schema) error
+}
+
+// CheckSchema fails with ErrIncompatibleSchema if the binary of current
+// can't run against the database of stored, a binary newer than the
+// versions compatible with current having migrated it. A binary newer
+// than the database migrates it.
+func CheckSchema(current, stored Schema) error {
+	if current.Version < stored.Compatible {
+		return ErrIncompatibleSchema
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// etag maps the version column of the models to entity tags, for the
+// If-Match conditional updates.
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPreconditionFailed   = errors.New("resource changed since it was read")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+)
+
+// Tag returns the strong entity tag of version.
+func Tag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Condition is the If-Match header of an update request.
+type Condition struct {
+	any      bool
+	versions []int
+}
+
+// Any is the condition of "If-Match: *", it matches every version.
+var Any = Condition{any: true}
+
+// IfMatch reads the If-Match header of req, which a PUT or PATCH of a
+// versioned resource must send. Weak and unknown tags never match, as
+// If-Match uses the strong comparison.
+func IfMatch(req *http.Request) (Condition, error) {
+	h := strings.TrimSpace(req.Header.Get("If-Match"))
+	if h == "" {
+		return Condition{}, ErrPreconditionRequired
+	}
+	if h == "*" {
+		return Any, nil
+	}
+	var c Condition
+	for _, t := range strings.Split(h, ",") {
+		t = strings.TrimSpace(t)
+		if len(t) < 2 || t[0] != '"' || t[len(t)-1] != '"' {
+			continue
+		}
+		v, err := strconv.Atoi(t[1 : len(t)-1])
+		if err != nil {
+			continue
+		}
+		c.versions = append(c.versions, v)
+	}
+	return c, nil
+}
+
+// Check returns ErrPreconditionFailed unless c matches version.
+func (c Condition) Check(version int) error {
+	if c.any {
+		return nil
+	}
+	for _, v := range c.versions {
+		if v == version {
+			return nil
+		}
+	}
+	return ErrPreconditionFailed
+}
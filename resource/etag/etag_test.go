@@ -0,0 +1,43 @@
+package etag_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kavirajk/bookshop/etag"
+)
+
+func TestIfMatch(t *testing.T) {
+	cases := []struct {
+		header  string
+		version int
+		err     error
+	}{
+		{`*`, 3, nil},
+		{`"3"`, 3, nil},
+		{`"1", "3"`, 3, nil},
+		{`"2"`, 3, etag.ErrPreconditionFailed},
+		{`W/"3"`, 3, etag.ErrPreconditionFailed},
+		{`"abc"`, 3, etag.ErrPreconditionFailed},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest("PATCH", "/", nil)
+		req.Header.Set("If-Match", c.header)
+		cond, err := etag.IfMatch(req)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.header, err)
+			continue
+		}
+		if err := cond.Check(c.version); err != c.err {
+			t.Errorf("%s: expected %v, got %v", c.header, c.err, err)
+		}
+	}
+
+	req, _ := http.NewRequest("PATCH", "/", nil)
+	if _, err := etag.IfMatch(req); err != etag.ErrPreconditionRequired {
+		t.Errorf("missing header: expected ErrPreconditionRequired, got %v", err)
+	}
+	if tag := etag.Tag(3); tag != `"3"` {
+		t.Errorf("tag: expected \"3\", got %s", tag)
+	}
+}
@@ -0,0 +1,421 @@
+// filter parses the filter query of the admin list endpoints, e.g.
+// `status eq "paid" and total gt 50`, into a parameterized SQL condition.
+// Only the fields a resource whitelists can be filtered on, and values are
+// always passed as query arguments.
+//
+// The syntax is
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | comparison
+//	comparison = field op value | field "in" "(" value { "," value } ")"
+//	op         = "eq" | "ne" | "gt" | "ge" | "lt" | "le" | "contains" | "startswith"
+//	value      = "string" | number | true | false | null
+//
+// Keywords are case insensitive. Time fields take "2006-01-02" or RFC 3339
+// strings, contains and startswith only apply to strings and null only to
+// eq and ne.
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalid is the cause of every parse error.
+var ErrInvalid = errors.New("invalid filter")
+
+// Limits of a filter, to keep the generated queries small.
+const (
+	MaxLength      = 1000
+	MaxComparisons = 20
+	maxDepth       = 8
+)
+
+// Type is the type of the values of a field.
+type Type int
+
+// Field types.
+const (
+	String Type = iota
+	Number
+	Bool
+	Time
+)
+
+// Field is a filterable field of a resource.
+type Field struct {
+	Column string
+	Type   Type
+}
+
+// Fields whitelists the filterable fields of a resource by their name in
+// the filter, usually the JSON name.
+type Fields map[string]Field
+
+// Expr is a parsed filter. The zero Expr matches everything.
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Empty tells whether e has no condition.
+func (e Expr) Empty() bool {
+	return e.SQL == ""
+}
+
+// Parse compiles the filter s on fields. An empty s is the empty Expr.
+func Parse(s string, fields Fields) (Expr, error) {
+	if strings.TrimSpace(s) == "" {
+		return Expr{}, nil
+	}
+	if len(s) > MaxLength {
+		return Expr{}, errors.Wrapf(ErrInvalid, "longer than %d characters", MaxLength)
+	}
+	tokens, err := lex(s)
+	if err != nil {
+		return Expr{}, err
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	sql, err := p.expr(0)
+	if err != nil {
+		return Expr{}, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return Expr{}, p.unexpected(t)
+	}
+	return Expr{SQL: sql, Args: p.args}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, errors.Wrapf(ErrInvalid, "unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokenString, b.String(), i})
+			i = j + 1
+		case c == '-' || c == '.' || unicode.IsDigit(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '.' || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:j], i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:j], i})
+			i = j
+		default:
+			return nil, errors.Wrapf(ErrInvalid, "unexpected %q at %d", c, i)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(s)}), nil
+}
+
+var operators = map[string]string{
+	"eq": "=",
+	"ne": "<>",
+	"gt": ">",
+	"ge": ">=",
+	"lt": "<",
+	"le": "<=",
+}
+
+type parser struct {
+	tokens      []token
+	n           int
+	fields      Fields
+	args        []interface{}
+	comparisons int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.n]
+	if t.kind != tokenEOF {
+		p.n++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the keyword kw.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, kw) {
+		p.n++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return errors.Wrap(ErrInvalid, "unexpected end")
+	}
+	return errors.Wrapf(ErrInvalid, "unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) expr(depth int) (string, error) {
+	if depth > maxDepth {
+		return "", errors.Wrap(ErrInvalid, "too deeply nested")
+	}
+	sql, err := p.term(depth)
+	if err != nil {
+		return "", err
+	}
+	terms := []string{sql}
+	for p.keyword("or") {
+		sql, err := p.term(depth)
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, sql)
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", nil
+}
+
+func (p *parser) term(depth int) (string, error) {
+	sql, err := p.factor(depth)
+	if err != nil {
+		return "", err
+	}
+	factors := []string{sql}
+	for p.keyword("and") {
+		sql, err := p.factor(depth)
+		if err != nil {
+			return "", err
+		}
+		factors = append(factors, sql)
+	}
+	if len(factors) == 1 {
+		return factors[0], nil
+	}
+	return "(" + strings.Join(factors, " AND ") + ")", nil
+}
+
+func (p *parser) factor(depth int) (string, error) {
+	if p.keyword("not") {
+		sql, err := p.factor(depth + 1)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + sql, nil
+	}
+	if p.peek().kind == tokenLParen {
+		p.next()
+		sql, err := p.expr(depth + 1)
+		if err != nil {
+			return "", err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return "", p.unexpected(t)
+		}
+		return sql, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", p.unexpected(t)
+	}
+	f, ok := p.fields[t.text]
+	if !ok {
+		return "", errors.Wrapf(ErrInvalid, "unknown field %q", t.text)
+	}
+	if p.comparisons++; p.comparisons > MaxComparisons {
+		return "", errors.Wrapf(ErrInvalid, "more than %d comparisons", MaxComparisons)
+	}
+
+	opt := p.next()
+	if opt.kind != tokenIdent {
+		return "", p.unexpected(opt)
+	}
+	op := strings.ToLower(opt.text)
+	switch op {
+	case "in":
+		return p.in(t.text, f)
+	case "contains", "startswith":
+		if f.Type != String {
+			return "", errors.Wrapf(ErrInvalid, "%s only applies to string fields", op)
+		}
+		v := p.next()
+		if v.kind != tokenString {
+			return "", errors.Wrapf(ErrInvalid, "%s expects a string", t.text)
+		}
+		pattern := escapeLike(v.text) + "%"
+		if op == "contains" {
+			pattern = "%" + pattern
+		}
+		p.args = append(p.args, pattern)
+		return f.Column + " ILIKE ?", nil
+	}
+	sqlOp, ok := operators[op]
+	if !ok {
+		return "", errors.Wrapf(ErrInvalid, "unknown operator %q", opt.text)
+	}
+	if p.keyword("null") {
+		switch op {
+		case "eq":
+			return f.Column + " IS NULL", nil
+		case "ne":
+			return f.Column + " IS NOT NULL", nil
+		}
+		return "", errors.Wrap(ErrInvalid, "null only applies to eq and ne")
+	}
+	v, err := p.value(t.text, f)
+	if err != nil {
+		return "", err
+	}
+	p.args = append(p.args, v)
+	return f.Column + " " + sqlOp + " ?", nil
+}
+
+func (p *parser) in(name string, f Field) (string, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return "", p.unexpected(t)
+	}
+	var marks []string
+	for {
+		v, err := p.value(name, f)
+		if err != nil {
+			return "", err
+		}
+		p.args = append(p.args, v)
+		marks = append(marks, "?")
+		t := p.next()
+		if t.kind == tokenRParen {
+			break
+		}
+		if t.kind != tokenComma {
+			return "", p.unexpected(t)
+		}
+	}
+	return f.Column + " IN (" + strings.Join(marks, ", ") + ")", nil
+}
+
+// value reads a value of the type of the field name.
+func (p *parser) value(name string, f Field) (interface{}, error) {
+	t := p.next()
+	switch f.Type {
+	case String:
+		if t.kind == tokenString {
+			return t.text, nil
+		}
+	case Number:
+		if t.kind == tokenNumber {
+			if n, err := strconv.ParseFloat(t.text, 64); err == nil {
+				return n, nil
+			}
+		}
+	case Bool:
+		if t.kind == tokenIdent {
+			switch strings.ToLower(t.text) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+		}
+	case Time:
+		if t.kind == tokenString {
+			if tm, err := time.Parse(time.RFC3339, t.text); err == nil {
+				return tm, nil
+			}
+			if tm, err := time.Parse("2006-01-02", t.text); err == nil {
+				return tm, nil
+			}
+		}
+	}
+	if t.kind == tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return nil, errors.Wrapf(ErrInvalid, "bad value %q of %s at %d", t.text, name, t.pos)
+}
+
+// escapeLike escapes the LIKE wildcards of s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Order compiles the sort order s of a list, e.g. "price desc, title", on
+// fields. An empty s is the empty order.
+func Order(s string, fields Fields) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	var order []string
+	for _, term := range strings.Split(s, ",") {
+		words := strings.Fields(term)
+		if len(words) == 0 || len(words) > 2 {
+			return "", errors.Wrapf(ErrInvalid, "bad order %q", term)
+		}
+		f, ok := fields[words[0]]
+		if !ok {
+			return "", errors.Wrapf(ErrInvalid, "unknown field %q", words[0])
+		}
+		dir := "asc"
+		if len(words) == 2 {
+			dir = strings.ToLower(words[1])
+		}
+		if dir != "asc" && dir != "desc" {
+			return "", errors.Wrapf(ErrInvalid, "bad order direction %q", words[1])
+		}
+		order = append(order, f.Column+" "+dir)
+	}
+	return strings.Join(order, ", "), nil
+}
@@ -0,0 +1,82 @@
+package filter_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/pkg/errors"
+)
+
+var fields = filter.Fields{
+	"status":     {Column: "status", Type: filter.String},
+	"total":      {Column: "total_price", Type: filter.Number},
+	"paid":       {Column: "paid", Type: filter.Bool},
+	"created_at": {Column: "created_at", Type: filter.Time},
+}
+
+func TestParse(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		filter string
+		sql    string
+		args   []interface{}
+	}{
+		{`status eq "paid" and total gt 50`, "(status = ? AND total_price > ?)", []interface{}{"paid", 50.0}},
+		{`status eq "paid" or status eq "shipped" and total le 10`, "(status = ? OR (status = ? AND total_price <= ?))", []interface{}{"paid", "shipped", 10.0}},
+		{`(status ne "paid" OR paid EQ true) and not total ge 2.5`, "((status <> ? OR paid = ?) AND NOT total_price >= ?)", []interface{}{"paid", true, 2.5}},
+		{`status in ("paid", "shipped")`, "status IN (?, ?)", []interface{}{"paid", "shipped"}},
+		{`status contains "50%_off"`, "status ILIKE ?", []interface{}{`%50\%\_off%`}},
+		{`status startswith "pa"`, "status ILIKE ?", []interface{}{"pa%"}},
+		{`created_at lt "2026-10-01"`, "created_at < ?", []interface{}{day}},
+		{`status eq null`, "status IS NULL", nil},
+		{`status eq "say \"hi\""`, "status = ?", []interface{}{`say "hi"`}},
+		{"  ", "", nil},
+	}
+	for _, c := range cases {
+		e, err := filter.Parse(c.filter, fields)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.filter, err)
+			continue
+		}
+		if e.SQL != c.sql || !reflect.DeepEqual(e.Args, c.args) {
+			t.Errorf("%s: expected %s %v, got %s %v", c.filter, c.sql, c.args, e.SQL, e.Args)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []string{
+		`password eq "x"`,
+		`status eq 1`,
+		`total eq "1"`,
+		`paid eq "true"`,
+		`total contains "1"`,
+		`total gt null`,
+		`status like "x"`,
+		`status eq "paid" and`,
+		`(status eq "paid"`,
+		`status eq "paid") or (total gt 1`,
+		`status eq "paid`,
+		`status eq "x"; DROP TABLE orders`,
+		`created_at gt "yesterday"`,
+	}
+	for _, c := range cases {
+		if _, err := filter.Parse(c, fields); errors.Cause(err) != filter.ErrInvalid {
+			t.Errorf("%s: expected ErrInvalid, got %v", c, err)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	order, err := filter.Order("total DESC, status", fields)
+	if err != nil || order != "total_price desc, status asc" {
+		t.Errorf("order: expected total_price desc, status asc, got %q %v", order, err)
+	}
+	for _, c := range []string{"password", "status sideways", "status asc nulls", "status,,total", "status; DROP TABLE orders"} {
+		if _, err := filter.Order(c, fields); errors.Cause(err) != filter.ErrInvalid {
+			t.Errorf("%s: expected ErrInvalid, got %v", c, err)
+		}
+	}
+}
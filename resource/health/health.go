@@ -0,0 +1,118 @@
+// health tells whether the server is ready to serve at /readyz, see
+// Handler, and turns the requests away while it isn't, see Guard. A server
+// of a database schema it's incompatible with, e.g. during a rolling deploy,
+// serves read-only or not at all rather than corrupting the data.
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+var (
+	ErrUnavailable = errors.New("service unavailable")
+	ErrReadOnly    = errors.New("service is read-only")
+)
+
+func init() {
+	i18n.Register(map[error]string{
+		ErrUnavailable: "health.unavailable",
+		ErrReadOnly:    "health.read_only",
+	})
+}
+
+// Status is the readiness of the server.
+type Status string
+
+const (
+	StatusReady Status = "ready"
+	// StatusReadOnly serves the reads only.
+	StatusReadOnly    Status = "read_only"
+	StatusUnavailable Status = "unavailable"
+)
+
+// Monitor holds the status of the server. It is safe for concurrent use.
+type Monitor struct {
+	mu     sync.RWMutex
+	status Status
+	reason string
+}
+
+// NewMonitor returns a Monitor of a ready server.
+func NewMonitor() *Monitor {
+	return &Monitor{status: StatusReady}
+}
+
+// Set sets the status of the server, reason telling why it isn't ready.
+func (m *Monitor) Set(s Status, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status, m.reason = s, reason
+}
+
+// Status returns the status of the server and its reason.
+func (m *Monitor) Status() (Status, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status, m.reason
+}
+
+type statusResponse struct {
+	Status Status `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Handler answers the status of m, 200 OK unless unavailable, a read-only
+// server still taking the reads, and 503 Service Unavailable then.
+func Handler(m *Monitor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s, reason := m.Status()
+		code := http.StatusOK
+		if s == StatusUnavailable {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(statusResponse{Status: s, Reason: reason})
+	})
+}
+
+// Guard answers 503 Service Unavailable to the requests the status of m
+// turns away, all of them while unavailable and the ones but GET, HEAD and
+// OPTIONS while read-only, passing the others to next.
+func Guard(m *Monitor, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var err error
+		switch s, _ := m.Status(); s {
+		case StatusUnavailable:
+			err = ErrUnavailable
+		case StatusReadOnly:
+			if !safe(req.Method) {
+				err = ErrReadOnly
+			}
+		}
+		if err == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		ctx := i18n.PopulateLocale(req.Context(), req)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		f := transport.FormatResponse{Meta: transport.MetaResponse{
+			Status: http.StatusServiceUnavailable,
+			Code:   i18n.Code(err),
+			Error:  i18n.Message(ctx, err),
+		}}
+		json.NewEncoder(w).Encode(f)
+	})
+}
+
+func safe(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "OPTIONS"
+}
@@ -0,0 +1,49 @@
+package health_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/health"
+)
+
+func TestGuard(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	m := health.NewMonitor()
+	guarded, readyz := health.Guard(m, ok), health.Handler(m)
+
+	for _, c := range []struct {
+		status      health.Status
+		get, post   int
+		readyz      int
+		code, reply string
+	}{
+		{health.StatusReady, 200, 200, 200, "", `"status":"ready"`},
+		{health.StatusReadOnly, 200, 503, 200, `"code":"health.read_only"`, `"status":"read_only","reason":"schema"`},
+		{health.StatusUnavailable, 503, 503, 503, `"code":"health.unavailable"`, `"status":"unavailable","reason":"schema"`},
+	} {
+		reason := "schema"
+		if c.status == health.StatusReady {
+			reason = ""
+		}
+		m.Set(c.status, reason)
+
+		w := httptest.NewRecorder()
+		guarded.ServeHTTP(w, httptest.NewRequest("GET", "/books/v1/", nil))
+		if w.Code != c.get {
+			t.Errorf("%s: get: expected %d, got %d", c.status, c.get, w.Code)
+		}
+		w = httptest.NewRecorder()
+		guarded.ServeHTTP(w, httptest.NewRequest("POST", "/books/v1/", nil))
+		if w.Code != c.post || !strings.Contains(w.Body.String(), c.code) {
+			t.Errorf("%s: post: expected %d %s, got %d %s", c.status, c.post, c.code, w.Code, w.Body)
+		}
+		w = httptest.NewRecorder()
+		readyz.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		if w.Code != c.readyz || !strings.Contains(w.Body.String(), c.reply) {
+			t.Errorf("%s: readyz: expected %d %s, got %d %s", c.status, c.readyz, c.reply, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package i18n
+
+var de = map[string]string{
+	"not_found":             "nicht gefunden",
+	"bad_count":             "count muss exact, estimate oder none sein",
+	"invalid":               "ungültige Anfrage",
+	"patch.content_type":    "der Inhaltstyp muss application/merge-patch+json sein",
+	"patch.not_object":      "der Merge Patch muss ein JSON-Objekt sein",
+	"precondition_failed":   "die Ressource wurde seit dem Lesen geändert",
+	"precondition_required": "der If-Match-Header ist erforderlich",
+	"filter.invalid":        "ungültiger Filter",
+	"bad_timezone":          "unbekannte Zeitzone",
+	"method_not_allowed":    "Methode nicht erlaubt",
+
+	"field.required":     "%s ist erforderlich",
+	"field.invalid":      "%s ist ungültig",
+	"field.mismatch":     "%s stimmt nicht überein",
+	"field.too_long":     "%s ist zu lang",
+	"field.out_of_range": "%s liegt außerhalb des zulässigen Bereichs",
+	"field.read_only":    "%s kann nicht geändert werden",
+	"field.taken":        "%s ist bereits vergeben",
+	"field.type":         "%s hat den falschen Typ",
+	"field.unknown":      "%s ist unbekannt",
+	"field.too_short":    "%s ist zu kurz",
+	"field.too_simple":   "%s ist zu einfach",
+	"field.breached":     "%s ist aus einem Datenleck bekannt",
+
+	"user.unauthorized":        "nicht autorisiert",
+	"user.invalid_password":    "ungültiges Passwort",
+	"user.invalid_reset_key":   "ungültiger Zurücksetzungsschlüssel",
+	"user.not_found":           "Benutzer nicht gefunden",
+	"user.registration_denied": "Registrierung abgelehnt",
+	"user.deactivated":         "das Konto ist deaktiviert",
+	"user.password_mismatch":   "die Passwörter stimmen nicht überein",
+
+	"catalog.book_not_found": "Buch nicht gefunden",
+	"catalog.empty_query":    "die Suchanfrage ist leer",
+	"catalog.bad_limit":      "ungültiges Limit",
+
+	"order.not_found": "Bestellung nicht gefunden",
+
+	"shelf.bad_csv":          "fehlerhafte CSV-Datei",
+	"shelf.empty_import":     "die CSV-Datei enthält keine Bücher",
+	"shelf.file_too_large":   "die Importdatei ist zu groß",
+	"shelf.import_not_found": "Import nicht gefunden",
+	"shelf.invalid_rating":   "die Bewertung muss zwischen 0 und 5 liegen",
+	"shelf.invalid_shelf":    "das Regal muss to-read, currently-reading oder read sein",
+	"shelf.no_title":         "die CSV-Datei hat keine Titelspalte",
+	"shelf.row_not_found":    "Importzeile nicht gefunden",
+	"shelf.row_resolved":     "die Importzeile ist bereits aufgelöst",
+	"shelf.too_many_rows":    "zu viele Zeilen in einem Import",
+
+	"address.check_not_found": "die Lieferadresse ist nicht geprüft",
+
+	"donation.already_donated":     "die Bestellung hat bereits eine Spende",
+	"donation.already_paid":        "die Bestellung ist bereits bezahlt",
+	"donation.disabled":            "Spenden sind deaktiviert",
+	"donation.invalid_amount":      "ungültiger Spendenbetrag",
+	"donation.invalid_kind":        "ungültige Spendenart",
+	"donation.nothing_to_round_up": "die Bestellsumme ist bereits ein runder Betrag",
+
+	"reading.invalid_kind":       "ungültige Art der Anmerkung",
+	"reading.missing_id":         "fehlende Anmerkungs-ID",
+	"reading.progress_not_found": "Lesefortschritt nicht gefunden",
+
+	"ebook.format_not_found":   "E-Book-Format nicht gefunden",
+	"ebook.not_purchased":      "das Buch wurde in dieser Bestellung nicht gekauft",
+	"ebook.unpaid":             "die Bestellung ist nicht bezahlt",
+	"ebook.unsupported_format": "nicht unterstütztes E-Book-Format",
+
+	"audiobook.missing_device":    "fehlende Geräte-ID",
+	"audiobook.not_entitled":      "kein Zugriff auf das Hörbuch",
+	"audiobook.variant_not_found": "Hörbuchvariante nicht gefunden",
+
+	"affiliate.no_referral":  "kein Empfehlungscode oder -cookie",
+	"affiliate.unknown_code": "unbekannter Empfehlungscode",
+	"affiliate.no_consent":   "die Empfehlungsverfolgung erfordert die Marketing-Einwilligung des Benutzers",
+
+	"vat.invalid_id":       "fehlerhafte USt-IdNr.",
+	"vat.vies_unavailable": "VIES ist nicht erreichbar",
+	"vat.already_paid":     "die Bestellung ist bereits bezahlt",
+
+	"rectification.request_not_found": "Berichtigungsanfrage nicht gefunden",
+	"rectification.already_pending":   "eine Berichtigung des Feldes ist bereits offen",
+	"rectification.unchanged":         "der Wert ist bereits gespeichert",
+
+	"consent.invalid_purpose": "der Zweck muss marketing, analytics oder profiling sein",
+	"consent.no_changes":      "keine Änderungen der Einwilligungen",
+
+	"archive.document_not_found": "Dokument nicht gefunden",
+	"archive.already_archived":   "der Verkauf ist bereits archiviert",
+	"archive.no_invoice":         "für die Bestellung ist keine Rechnung archiviert",
+	"archive.credit_exceeds":     "der gutgeschriebene Betrag übersteigt den Bestellbetrag",
+	"archive.tampered":           "der Inhalt des Dokuments stimmt nicht mit seinem Hash überein",
+
+	"accounting.batch_not_found":      "Buchungsexport nicht gefunden",
+	"accounting.period_open":          "der Tag ist noch nicht vorbei",
+	"accounting.invalid_date":         "ungültiges Datum, erwartet wird das Format 2006-01-02",
+	"support.ticket_not_found":        "Support-Ticket nicht gefunden",
+	"support.invalid_status":          "ungültiger Ticketstatus",
+	"auth.missing_token":              "Zugriffstoken erforderlich",
+	"auth.invalid_token":              "ungültiges Zugriffstoken",
+	"auth.expired_token":              "Zugriffstoken abgelaufen",
+	"user.invalid_refresh_token":      "ungültiges Aktualisierungstoken",
+	"auth.revoked_token":              "Zugriffstoken widerrufen",
+	"user.unverified_email":           "E-Mail-Adresse vom Anbieter nicht bestätigt",
+	"oauth.unknown_provider":          "unbekannter Anmeldeanbieter",
+	"oauth.invalid_state":             "ungültiger oder abgelaufener Anmeldestatus",
+	"oauth.provider_failed":           "Anmeldeanbieter fehlgeschlagen",
+	"catalog.price_change_not_found":  "Preisänderung nicht gefunden",
+	"catalog.price_overlap":           "Aktion überschneidet sich mit einer anderen Aktion des Buchs",
+	"catalog.price_not_scheduled":     "Preisänderung ist nicht mehr geplant",
+	"user.invalid_two_factor_code":    "Ungültiger Bestätigungscode",
+	"user.invalid_login_challenge":    "Ungültige oder abgelaufene Anmeldung",
+	"user.two_factor_enabled":         "Zwei-Faktor-Authentifizierung bereits aktiviert",
+	"user.two_factor_disabled":        "Zwei-Faktor-Authentifizierung nicht aktiviert",
+	"user.two_factor_unavailable":     "Zwei-Faktor-Authentifizierung nicht verfügbar",
+	"segment.not_found":               "Segment nicht gefunden",
+	"segment.name_taken":              "Segmentname bereits vergeben",
+	"rbac.forbidden":                  "Zugriff für diese Rolle verweigert",
+	"order.cart_empty":                "Warenkorb ist leer",
+	"order.cart_changed":              "Preise oder Verfügbarkeit im Warenkorb haben sich geändert, bitte zuerst bestätigen",
+	"order.cart_currency":             "Warenkorbpositionen haben unterschiedliche Währungen",
+	"user.account_locked":             "Konto nach zu vielen fehlgeschlagenen Anmeldungen gesperrt",
+	"order.not_paid":                  "Bestellung ist nicht bezahlt",
+	"order.shipped":                   "Bestellung ist bereits versandt",
+	"order.no_lines":                  "Bestellung hat keine bearbeitbaren Positionen",
+	"order.line_not_found":            "Bestellposition nicht gefunden",
+	"order.invalid_quantity":          "Ungültige Menge",
+	"order.empty_order":               "Bestellung muss eine Position behalten, stornieren Sie sie stattdessen",
+	"order.currency_mismatch":         "Buch ist in einer anderen Währung als die Bestellung ausgezeichnet",
+	"passwordpolicy.unavailable":      "Passwortprüfung nicht verfügbar",
+	"payment.unknown_wallet":          "Wallet muss apple_pay oder google_pay sein",
+	"payment.wallet_disabled":         "Wallet-Zahlungen sind deaktiviert",
+	"payment.invalid_validation_url":  "Validierungs-URL ist keine Apple-Pay-URL",
+	"payment.missing_token":           "Wallet-Token ist erforderlich",
+	"payment.already_paid":            "Bestellung ist bereits bezahlt",
+	"payment.declined":                "Zahlung abgelehnt",
+	"payment.bnpl_disabled":           "Später bezahlen ist deaktiviert",
+	"payment.not_eligible":            "Die Bestellung kann nicht später bezahlt werden",
+	"payment.authorization_not_found": "Autorisierung nicht gefunden",
+	"payment.invalid_signature":       "Ungültige Webhook-Signatur",
+	"payment.intent_not_found":        "Zahlung nicht gefunden",
+	"user.session_not_found":          "Sitzung nicht gefunden",
+	"payment.dispute_not_found":       "Zahlungsstreit nicht gefunden",
+	"ratelimit.limited":               "Zu viele Anfragen, bitte später erneut versuchen",
+	"archive.unknown_reason":          "unbekannter Erstattungsgrund",
+	"archive.refund_not_found":        "Erstattung nicht gefunden",
+	"archive.invalid_period":          "ungültiger Zeitraum",
+	"archive.invalid_date":            "ungültiges Datum",
+	"csrf.invalid_token":              "CSRF-Token fehlt oder ist ungültig",
+	"credit.insufficient":             "das Guthaben deckt den Bestellbetrag nicht",
+	"credit.already_paid":             "die Bestellung ist bereits bezahlt",
+	"archive.footer_not_found":        "rechtlicher Fußtext nicht gefunden",
+	"archive.unknown_locale":          "nicht unterstützte Sprache",
+	"doc.invoice":                     "Rechnung",
+	"doc.credit_note":                 "Gutschrift",
+	"doc.issued":                      "Ausgestellt am",
+	"doc.book":                        "Buch",
+	"doc.quantity":                    "Menge",
+	"doc.price":                       "Preis",
+	"doc.amount":                      "Betrag",
+	"doc.net":                         "Netto",
+	"doc.vat":                         "MwSt.",
+	"doc.vat_id":                      "USt-IdNr.",
+	"doc.reverse_charge":              "Steuerschuldnerschaft des Leistungsempfängers",
+	"doc.total":                       "Gesamt",
+	"doc.order":                       "Bestellung",
+	"doc.refund":                      "Erstattung",
+	"doc.refund_full":                 "Vollständige Erstattung",
+	"doc.refund_partial":              "Teilerstattung",
+	"doc.reason":                      "Grund",
+	"doc.note":                        "Hinweis",
+	"doc.issued_by":                   "Ausgestellt von",
+	"doc.credited":                    "Gutgeschrieben",
+	"doc.reason.returned":             "Zurückgesendet",
+	"doc.reason.damaged":              "Beim Transport beschädigt",
+	"doc.reason.not_received":         "Nicht erhalten",
+	"doc.reason.wrong_item":           "Falscher Artikel",
+	"doc.reason.cancelled":            "Storniert",
+	"doc.reason.goodwill":             "Kulanz",
+	"doc.receipt_subject":             "Ihre Rechnung zur Bestellung %s",
+	"doc.credit_note_subject":         "Ihre Gutschrift %s",
+	"rbac.insufficient_scope":         "Dem Zugriffstoken fehlt die Berechtigung",
+	"fulfillment.shipment_not_found":  "Sendung nicht gefunden",
+	"fulfillment.no_label":            "das Versandetikett der Sendung ist noch nicht gekauft",
+	"fulfillment.undeliverable":       "die Lieferadresse ist nicht zustellbar",
+	"fulfillment.unpaid":              "die Bestellung ist nicht bezahlt",
+	"rbac.impersonated":               "nicht erlaubt, während der Benutzer imitiert wird",
+	"user.impersonate_staff":          "Mitarbeiter können nicht imitiert werden",
+	"delivery.unknown_option":         "die Versandart wird für dieses Land nicht angeboten",
+	"delivery.transit_not_found":      "Laufzeit nicht gefunden",
+	"delivery.promise_not_found":      "Lieferzusage nicht gefunden",
+	"delivery.promise_final":          "die Lieferzusage einer bezahlten Bestellung kann nicht geändert werden",
+	"delivery.no_country":             "Land ist erforderlich",
+	"customs.embargoed":               "in dieses Land wird nicht versandt",
+	"customs.hs_code_not_found":       "HS-Code nicht gefunden",
+	"customs.declaration_not_found":   "Zollinhaltserklärung nicht gefunden",
+	"user.device_not_found":           "vertrauenswürdiges Gerät nicht gefunden",
+	"picking.list_not_found":          "Pickliste nicht gefunden",
+	"picking.nothing_to_pick":         "Keine offene Bestellung zu kommissionieren",
+	"picking.not_picked":              "Die Bestellung steht auf keiner Pickliste",
+	"picking.not_in_order":            "Das Buch ist nicht in der Bestellung",
+	"picking.already_scanned":         "Alle Exemplare des Buches sind bereits gescannt",
+	"picking.packed":                  "Die Bestellung ist bereits gepackt",
+	"picking.unknown_status":          "Der Status muss open oder done sein",
+	"returns.return_not_found":        "Rücksendung nicht gefunden",
+	"returns.not_shipped":             "Die Bestellung ist noch nicht versandt",
+	"returns.window_closed":           "Die Rückgabefrist der Bestellung ist abgelaufen",
+	"returns.no_lines":                "Die Bestellung hat keine Positionen zum Zurücksenden",
+	"returns.not_in_order":            "Das Buch ist nicht in der Bestellung",
+	"returns.too_many":                "Mehr Exemplare zurückgesendet als bestellt",
+	"returns.no_label":                "Das Rücksendeetikett ist noch nicht gekauft",
+	"stocktake.session_not_found":     "Zählung nicht gefunden",
+	"stocktake.session_active":        "Der Lagerplatz wird bereits gezählt",
+	"stocktake.no_books":              "An dem Lagerplatz liegt kein Buch",
+	"stocktake.not_open":              "Die Zählung ist nicht offen",
+	"stocktake.not_submitted":         "Die Zählung ist nicht eingereicht",
+	"stocktake.self_review":           "Die Zählung muss von einer anderen Person als der einreichenden geprüft werden",
+	"stocktake.partly_posted":         "Die Zählung ist teilweise gebucht, genehmigen Sie sie, um den Rest zu buchen",
+	"stocktake.unknown_status":        "Der Status muss open, submitted oder posted sein",
+	"quality.report_not_found":        "Qualitätsbericht nicht gefunden",
+	"quality.unknown_severity":        "Der Schweregrad muss error oder warning sein",
+	"quality.unknown_rule":            "Unbekannte Regel",
+	"user.invalid_image":              "der Avatar muss ein JPEG-, PNG- oder GIF-Bild sein",
+	"user.avatar_too_large":           "der Avatar ist zu groß",
+	"user.avatar_conflict":            "das Profil wurde während des Hochladens geändert, bitte den Avatar erneut hochladen",
+	"user.avatars_unavailable":        "Hochladen von Avataren nicht verfügbar",
+	"dedupe.unknown_kind":             "die Art muss book oder author sein",
+	"dedupe.invalid_score":            "min_score muss zwischen 0 und 1 liegen",
+	"dedupe.author_not_found":         "Autor nicht gefunden",
+	"dedupe.already_merged":           "der Datensatz ist bereits zusammengeführt",
+	"dedupe.no_redirect":              "der Datensatz ist nicht zusammengeführt",
+	"dedupe.conflict":                 "die Bücher wurden während der Zusammenführung geändert, bitte erneut zusammenführen",
+	"user.address_not_found":          "Adresse nicht gefunden",
+	"user.no_default_address":         "keine Standardadresse hinterlegt",
+	"user.too_many_addresses":         "das Adressbuch ist voll",
+	"images.invalid_signature":        "die Signatur der Bild-URL ist ungültig",
+	"images.invalid_image":            "das Quellbild ist kein JPEG-, PNG- oder GIF-Bild",
+	"images.source_too_large":         "das Quellbild ist zu groß",
+	"images.unknown_kind":             "unbekannte Bildart",
+	"images.image_not_found":          "Bild nicht gefunden",
+	"images.source_unavailable":       "das Quellbild ist nicht verfügbar",
+	"storefront.book_not_found":       "Buch nicht gefunden",
+	"storefront.category_not_found":   "Kategorie nicht gefunden",
+	"storefront.bad_limit":            "das Limit muss zwischen 1 und 100 liegen",
+	"browsing.no_visitor":             "kein Besucher",
+	"browsing.book_not_found":         "Buch nicht gefunden",
+	"browsing.bad_limit":              "das Limit muss zwischen 1 und 50 liegen",
+	"user.empty_query":                "die Suchanfrage ist leer",
+	"user.bad_limit":                  "das Limit muss zwischen 1 und 100 liegen",
+	"user.bad_cursor":                 "ungültiger Cursor",
+	"user.cursor_order":               "die Seiten per Cursor können nicht sortiert werden",
+	"health.unavailable":              "Dienst nicht verfügbar",
+	"health.read_only":                "der Dienst ist schreibgeschützt",
+	"erasure.request_not_found":       "Löschantrag nicht gefunden",
+	"erasure.already_requested":       "die Löschung wurde bereits beantragt",
+	"erasure.already_resolved":        "der Löschantrag ist bereits entschieden",
+	"erasure.self_review":             "eine Löschung kann nicht vom eigenen Benutzer geprüft werden",
+	"erasure.note_required":           "eine Ablehnung braucht eine Notiz für den Benutzer",
+	"user.banned":                     "der Benutzer ist gesperrt",
+	"user.not_banned":                 "der Benutzer ist nicht gesperrt",
+	"user.ban_reason_required":        "ein Grund für die Sperre ist erforderlich",
+	"user.password_reset_required":    "das Passwort muss vor der Anmeldung zurückgesetzt werden",
+	"user.own_account":                "Administratoren können ihr eigenes Konto nicht sperren oder seine Rolle ändern",
+	"user.logins_unavailable":         "Anmeldeverlauf nicht verfügbar",
+}
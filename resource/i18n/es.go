@@ -0,0 +1,267 @@
+package i18n
+
+var es = map[string]string{
+	"not_found":             "no encontrado",
+	"bad_count":             "count debe ser exact, estimate o none",
+	"invalid":               "solicitud no válida",
+	"patch.content_type":    "el tipo de contenido debe ser application/merge-patch+json",
+	"patch.not_object":      "el merge patch debe ser un objeto JSON",
+	"precondition_failed":   "el recurso ha cambiado desde que se leyó",
+	"precondition_required": "la cabecera If-Match es obligatoria",
+	"filter.invalid":        "filtro no válido",
+	"bad_timezone":          "zona horaria desconocida",
+	"method_not_allowed":    "método no permitido",
+
+	"field.required":     "%s es obligatorio",
+	"field.invalid":      "%s no es válido",
+	"field.mismatch":     "%s no coincide",
+	"field.too_long":     "%s es demasiado largo",
+	"field.out_of_range": "%s está fuera de rango",
+	"field.read_only":    "%s no se puede modificar",
+	"field.taken":        "%s ya está en uso",
+	"field.type":         "%s tiene un tipo incorrecto",
+	"field.unknown":      "%s es desconocido",
+	"field.too_short":    "%s es demasiado corto",
+	"field.too_simple":   "%s es demasiado simple",
+	"field.breached":     "%s apareció en una filtración de datos",
+
+	"user.unauthorized":        "no autorizado",
+	"user.invalid_password":    "contraseña no válida",
+	"user.invalid_reset_key":   "clave de restablecimiento no válida",
+	"user.not_found":           "usuario no encontrado",
+	"user.registration_denied": "registro denegado",
+	"user.deactivated":         "la cuenta está desactivada",
+	"user.password_mismatch":   "las contraseñas no coinciden",
+
+	"catalog.book_not_found": "libro no encontrado",
+	"catalog.empty_query":    "la búsqueda está vacía",
+	"catalog.bad_limit":      "límite no válido",
+
+	"order.not_found": "pedido no encontrado",
+
+	"shelf.bad_csv":          "archivo csv mal formado",
+	"shelf.empty_import":     "el archivo csv no contiene libros",
+	"shelf.file_too_large":   "el archivo de importación es demasiado grande",
+	"shelf.import_not_found": "importación no encontrada",
+	"shelf.invalid_rating":   "la valoración debe estar entre 0 y 5",
+	"shelf.invalid_shelf":    "la estantería debe ser to-read, currently-reading o read",
+	"shelf.no_title":         "el archivo csv no tiene columna de título",
+	"shelf.row_not_found":    "fila de importación no encontrada",
+	"shelf.row_resolved":     "la fila de importación ya está resuelta",
+	"shelf.too_many_rows":    "demasiadas filas en una sola importación",
+
+	"address.check_not_found": "la dirección de envío no está verificada",
+
+	"donation.already_donated":     "el pedido ya tiene una donación",
+	"donation.already_paid":        "el pedido ya está pagado",
+	"donation.disabled":            "las donaciones están desactivadas",
+	"donation.invalid_amount":      "importe de donación no válido",
+	"donation.invalid_kind":        "tipo de donación no válido",
+	"donation.nothing_to_round_up": "el total del pedido ya es un importe redondo",
+
+	"reading.invalid_kind":       "tipo de anotación no válido",
+	"reading.missing_id":         "falta el identificador de la anotación",
+	"reading.progress_not_found": "progreso de lectura no encontrado",
+
+	"ebook.format_not_found":   "formato de ebook no encontrado",
+	"ebook.not_purchased":      "el libro no se compró en este pedido",
+	"ebook.unpaid":             "el pedido no está pagado",
+	"ebook.unsupported_format": "formato de ebook no compatible",
+
+	"audiobook.missing_device":    "falta el identificador del dispositivo",
+	"audiobook.not_entitled":      "no tienes acceso a este audiolibro",
+	"audiobook.variant_not_found": "variante del audiolibro no encontrada",
+
+	"affiliate.no_referral":  "no hay código ni cookie de referido",
+	"affiliate.unknown_code": "código de referido desconocido",
+	"affiliate.no_consent":   "el seguimiento de referidos requiere el consentimiento de marketing del usuario",
+
+	"vat.invalid_id":       "número de IVA mal formado",
+	"vat.vies_unavailable": "el servicio VIES no está disponible",
+	"vat.already_paid":     "el pedido ya está pagado",
+
+	"rectification.request_not_found": "solicitud de rectificación no encontrada",
+	"rectification.already_pending":   "ya hay una rectificación pendiente del campo",
+	"rectification.unchanged":         "el valor ya está guardado",
+
+	"consent.invalid_purpose": "el propósito debe ser marketing, analytics o profiling",
+	"consent.no_changes":      "ningún cambio de consentimiento",
+
+	"archive.document_not_found": "documento no encontrado",
+	"archive.already_archived":   "la venta ya está archivada",
+	"archive.no_invoice":         "el pedido no tiene ninguna factura archivada",
+	"archive.credit_exceeds":     "el importe abonado supera el total del pedido",
+	"archive.tampered":           "el contenido del documento no coincide con su hash",
+
+	"accounting.batch_not_found":      "exportación contable no encontrada",
+	"accounting.period_open":          "el día aún no ha terminado",
+	"accounting.invalid_date":         "fecha no válida, se espera el formato 2006-01-02",
+	"support.ticket_not_found":        "ticket de soporte no encontrado",
+	"support.invalid_status":          "estado de ticket no válido",
+	"auth.missing_token":              "se requiere un token de acceso",
+	"auth.invalid_token":              "token de acceso no válido",
+	"auth.expired_token":              "el token de acceso ha caducado",
+	"user.invalid_refresh_token":      "token de actualización no válido",
+	"auth.revoked_token":              "token de acceso revocado",
+	"user.unverified_email":           "correo electrónico no verificado por el proveedor",
+	"oauth.unknown_provider":          "proveedor de inicio de sesión desconocido",
+	"oauth.invalid_state":             "estado de inicio de sesión no válido o caducado",
+	"oauth.provider_failed":           "fallo del proveedor de inicio de sesión",
+	"catalog.price_change_not_found":  "cambio de precio no encontrado",
+	"catalog.price_overlap":           "la promoción se solapa con otra promoción del libro",
+	"catalog.price_not_scheduled":     "el cambio de precio ya no está programado",
+	"user.invalid_two_factor_code":    "Código de verificación no válido",
+	"user.invalid_login_challenge":    "Inicio de sesión no válido o caducado",
+	"user.two_factor_enabled":         "La autenticación en dos pasos ya está activada",
+	"user.two_factor_disabled":        "La autenticación en dos pasos no está activada",
+	"user.two_factor_unavailable":     "Autenticación en dos pasos no disponible",
+	"segment.not_found":               "Segmento no encontrado",
+	"segment.name_taken":              "El nombre del segmento ya existe",
+	"rbac.forbidden":                  "Acceso denegado para este rol",
+	"order.cart_empty":                "El carrito está vacío",
+	"order.cart_changed":              "Los precios o la disponibilidad del carrito han cambiado, confírmalo primero",
+	"order.cart_currency":             "Las líneas del carrito tienen monedas distintas",
+	"user.account_locked":             "Cuenta bloqueada tras demasiados inicios de sesión fallidos",
+	"order.not_paid":                  "El pedido no está pagado",
+	"order.shipped":                   "El pedido ya está enviado",
+	"order.no_lines":                  "El pedido no tiene líneas que editar",
+	"order.line_not_found":            "Línea de pedido no encontrada",
+	"order.invalid_quantity":          "Cantidad no válida",
+	"order.empty_order":               "El pedido debe conservar una línea, cancélelo en su lugar",
+	"order.currency_mismatch":         "El libro tiene un precio en otra moneda que el pedido",
+	"passwordpolicy.unavailable":      "Comprobación de contraseñas no disponible",
+	"payment.unknown_wallet":          "El monedero debe ser apple_pay o google_pay",
+	"payment.wallet_disabled":         "Los pagos con monedero están desactivados",
+	"payment.invalid_validation_url":  "La URL de validación no es de Apple Pay",
+	"payment.missing_token":           "El token del monedero es obligatorio",
+	"payment.already_paid":            "El pedido ya está pagado",
+	"payment.declined":                "Pago rechazado",
+	"payment.bnpl_disabled":           "El pago aplazado está desactivado",
+	"payment.not_eligible":            "El pedido no admite el pago aplazado",
+	"payment.authorization_not_found": "Autorización no encontrada",
+	"payment.invalid_signature":       "Firma de webhook no válida",
+	"payment.intent_not_found":        "Pago no encontrado",
+	"user.session_not_found":          "Sesión no encontrada",
+	"payment.dispute_not_found":       "Disputa de pago no encontrada",
+	"ratelimit.limited":               "Demasiadas solicitudes, inténtalo más tarde",
+	"archive.unknown_reason":          "motivo de reembolso desconocido",
+	"archive.refund_not_found":        "reembolso no encontrado",
+	"archive.invalid_period":          "periodo no válido",
+	"archive.invalid_date":            "fecha no válida",
+	"csrf.invalid_token":              "falta el token CSRF o no es válido",
+	"credit.insufficient":             "el saldo no cubre el total del pedido",
+	"credit.already_paid":             "el pedido ya está pagado",
+	"archive.footer_not_found":        "pie legal no encontrado",
+	"archive.unknown_locale":          "idioma no admitido",
+	"doc.invoice":                     "Factura",
+	"doc.credit_note":                 "Nota de crédito",
+	"doc.issued":                      "Emitida el",
+	"doc.book":                        "Libro",
+	"doc.quantity":                    "Cant.",
+	"doc.price":                       "Precio",
+	"doc.amount":                      "Importe",
+	"doc.net":                         "Base imponible",
+	"doc.vat":                         "IVA",
+	"doc.vat_id":                      "NIF-IVA",
+	"doc.reverse_charge":              "Inversión del sujeto pasivo",
+	"doc.total":                       "Total",
+	"doc.order":                       "Pedido",
+	"doc.refund":                      "Reembolso",
+	"doc.refund_full":                 "Reembolso total",
+	"doc.refund_partial":              "Reembolso parcial",
+	"doc.reason":                      "Motivo",
+	"doc.note":                        "Nota",
+	"doc.issued_by":                   "Emitida por",
+	"doc.credited":                    "Abonado",
+	"doc.reason.returned":             "Devuelto",
+	"doc.reason.damaged":              "Dañado en el transporte",
+	"doc.reason.not_received":         "No recibido",
+	"doc.reason.wrong_item":           "Artículo equivocado",
+	"doc.reason.cancelled":            "Cancelado",
+	"doc.reason.goodwill":             "Gesto comercial",
+	"doc.receipt_subject":             "Su factura del pedido %s",
+	"doc.credit_note_subject":         "Su nota de crédito %s",
+	"rbac.insufficient_scope":         "El token de acceso no tiene el permiso",
+	"fulfillment.shipment_not_found":  "envío no encontrado",
+	"fulfillment.no_label":            "la etiqueta del envío aún no está comprada",
+	"fulfillment.undeliverable":       "la dirección de envío no es entregable",
+	"fulfillment.unpaid":              "el pedido no está pagado",
+	"rbac.impersonated":               "no permitido al suplantar al usuario",
+	"user.impersonate_staff":          "no se puede suplantar al personal",
+	"delivery.unknown_option":         "la opción de envío no se ofrece para el país",
+	"delivery.transit_not_found":      "plazo de tránsito no encontrado",
+	"delivery.promise_not_found":      "promesa de entrega no encontrada",
+	"delivery.promise_final":          "la promesa de entrega de un pedido pagado no puede cambiar",
+	"delivery.no_country":             "el país es obligatorio",
+	"customs.embargoed":               "no se realizan envíos a este país",
+	"customs.hs_code_not_found":       "código SA no encontrado",
+	"customs.declaration_not_found":   "declaración de aduana no encontrada",
+	"user.device_not_found":           "dispositivo de confianza no encontrado",
+	"picking.list_not_found":          "Lista de picking no encontrada",
+	"picking.nothing_to_pick":         "No hay pedidos abiertos para preparar",
+	"picking.not_picked":              "El pedido no figura en ninguna lista de picking",
+	"picking.not_in_order":            "El libro no forma parte del pedido",
+	"picking.already_scanned":         "Todos los ejemplares del libro ya se han escaneado",
+	"picking.packed":                  "El pedido ya está empaquetado",
+	"picking.unknown_status":          "El estado debe ser open o done",
+	"returns.return_not_found":        "Devolución no encontrada",
+	"returns.not_shipped":             "El pedido aún no se ha enviado",
+	"returns.window_closed":           "El plazo de devolución del pedido ha terminado",
+	"returns.no_lines":                "El pedido no tiene líneas que devolver",
+	"returns.not_in_order":            "El libro no forma parte del pedido",
+	"returns.too_many":                "Se devuelven más ejemplares de los pedidos",
+	"returns.no_label":                "La etiqueta de devolución aún no se ha comprado",
+	"stocktake.session_not_found":     "Recuento no encontrado",
+	"stocktake.session_active":        "La ubicación ya se está contando",
+	"stocktake.no_books":              "No hay ningún libro en la ubicación",
+	"stocktake.not_open":              "El recuento no está abierto",
+	"stocktake.not_submitted":         "El recuento no está enviado",
+	"stocktake.self_review":           "El recuento debe revisarlo otra persona distinta de quien lo envió",
+	"stocktake.partly_posted":         "El recuento está contabilizado en parte, apruébelo para contabilizar el resto",
+	"stocktake.unknown_status":        "El estado debe ser open, submitted o posted",
+	"quality.report_not_found":        "Informe de calidad no encontrado",
+	"quality.unknown_severity":        "La gravedad debe ser error o warning",
+	"quality.unknown_rule":            "Regla desconocida",
+	"user.invalid_image":              "el avatar debe ser una imagen JPEG, PNG o GIF",
+	"user.avatar_too_large":           "el avatar es demasiado grande",
+	"user.avatar_conflict":            "el perfil cambió durante la subida del avatar, súbelo de nuevo",
+	"user.avatars_unavailable":        "Subida de avatares no disponible",
+	"dedupe.unknown_kind":             "el tipo debe ser book o author",
+	"dedupe.invalid_score":            "min_score debe estar entre 0 y 1",
+	"dedupe.author_not_found":         "autor no encontrado",
+	"dedupe.already_merged":           "el registro ya está fusionado",
+	"dedupe.no_redirect":              "el registro no está fusionado",
+	"dedupe.conflict":                 "los libros cambiaron durante la fusión, vuelve a fusionarlos",
+	"user.address_not_found":          "dirección no encontrada",
+	"user.no_default_address":         "el usuario no tiene dirección predeterminada",
+	"user.too_many_addresses":         "la libreta de direcciones está llena",
+	"images.invalid_signature":        "la firma de la URL de la imagen no es válida",
+	"images.invalid_image":            "la imagen de origen no es una imagen JPEG, PNG o GIF",
+	"images.source_too_large":         "la imagen de origen es demasiado grande",
+	"images.unknown_kind":             "tipo de imagen desconocido",
+	"images.image_not_found":          "imagen no encontrada",
+	"images.source_unavailable":       "la imagen de origen no está disponible",
+	"storefront.book_not_found":       "libro no encontrado",
+	"storefront.category_not_found":   "categoría no encontrada",
+	"storefront.bad_limit":            "el límite debe estar entre 1 y 100",
+	"browsing.no_visitor":             "sin visitante",
+	"browsing.book_not_found":         "libro no encontrado",
+	"browsing.bad_limit":              "el límite debe estar entre 1 y 50",
+	"user.empty_query":                "la búsqueda está vacía",
+	"user.bad_limit":                  "el límite debe estar entre 1 y 100",
+	"user.bad_cursor":                 "cursor no válido",
+	"user.cursor_order":               "las páginas por cursor no se pueden ordenar",
+	"health.unavailable":              "servicio no disponible",
+	"health.read_only":                "el servicio es de solo lectura",
+	"erasure.request_not_found":       "solicitud de supresión no encontrada",
+	"erasure.already_requested":       "la supresión ya está solicitada",
+	"erasure.already_resolved":        "la solicitud de supresión ya está resuelta",
+	"erasure.self_review":             "una supresión no puede ser revisada por su propio usuario",
+	"erasure.note_required":           "un rechazo necesita una nota para el usuario",
+	"user.banned":                     "el usuario está vetado",
+	"user.not_banned":                 "el usuario no está vetado",
+	"user.ban_reason_required":        "se requiere un motivo para el veto",
+	"user.password_reset_required":    "la contraseña debe restablecerse antes de iniciar sesión",
+	"user.own_account":                "los administradores no pueden vetar su propia cuenta ni cambiar su rol",
+	"user.logins_unavailable":         "historial de inicios de sesión no disponible",
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// format is how a locale writes numbers, amounts and dates.
+type format struct {
+	decimal, group string
+	// symbolAfter writes the currency symbol after the amount, spaced.
+	symbolAfter bool
+	date        string
+}
+
+var formats = map[string]format{
+	DefaultLocale: {decimal: ".", group: ",", date: "2 Jan 2006"},
+	"de":          {decimal: ",", group: ".", symbolAfter: true, date: "02.01.2006"},
+	"es":          {decimal: ",", group: ".", symbolAfter: true, date: "02/01/2006"},
+	// French groups with a no-break space, which Latin-1 has unlike the
+	// narrow one.
+	"fr": {decimal: ",", group: "\u00a0", symbolAfter: true, date: "02/01/2006"},
+}
+
+// symbols are the currency symbols written instead of the ISO code.
+var symbols = map[string]string{
+	"EUR": "€",
+	"GBP": "£",
+	"USD": "$",
+	"JPY": "¥",
+}
+
+// zeroDecimal are the currencies without minor unit.
+var zeroDecimal = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"ISK": true,
+}
+
+// Supported tells whether locale is DefaultLocale or a bundled one.
+func Supported(locale string) bool {
+	_, ok := formats[locale]
+	return ok
+}
+
+// Translate returns the text of key in locale, falling back to message,
+// the English text, when locale has no translation. The keys of the
+// documents and emails are prefixed with "doc.".
+func Translate(locale, key, message string) string {
+	if m, ok := bundles[locale][key]; ok {
+		return m
+	}
+	return message
+}
+
+// FormatNumber writes amount with decimals digits in locale, its digits
+// grouped by thousands.
+func FormatNumber(locale string, amount float64, decimals int) string {
+	f := localeFormat(locale)
+	s := strconv.FormatFloat(math.Abs(amount), 'f', decimals, 64)
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	var b strings.Builder
+	if amount < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(d)
+	}
+	if frac != "" {
+		b.WriteString(f.decimal + frac)
+	}
+	return b.String()
+}
+
+// FormatMoney writes amount of currency in locale, with the symbol of the
+// currency if it has one, e.g. "€1,234.50" in English and "1.234,50 €" in
+// German, else its code. A symbol or code after the amount is joined by a
+// no-break space.
+func FormatMoney(locale string, amount float64, currency string) string {
+	decimals := 2
+	if zeroDecimal[currency] {
+		decimals = 0
+	}
+	n := FormatNumber(locale, amount, decimals)
+	sym, ok := symbols[currency]
+	switch {
+	case !ok:
+		return n + "\u00a0" + currency
+	case localeFormat(locale).symbolAfter:
+		return n + "\u00a0" + sym
+	case strings.HasPrefix(n, "-"):
+		return "-" + sym + n[1:]
+	default:
+		return sym + n
+	}
+}
+
+// FormatDate writes the date of t in locale, in the time zone of t.
+func FormatDate(locale string, t time.Time) string {
+	return t.Format(localeFormat(locale).date)
+}
+
+func localeFormat(locale string) format {
+	if f, ok := formats[locale]; ok {
+		return f
+	}
+	return formats[DefaultLocale]
+}
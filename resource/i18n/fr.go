@@ -0,0 +1,267 @@
+package i18n
+
+var fr = map[string]string{
+	"not_found":             "introuvable",
+	"bad_count":             "count doit valoir exact, estimate ou none",
+	"invalid":               "requête invalide",
+	"patch.content_type":    "le type de contenu doit être application/merge-patch+json",
+	"patch.not_object":      "le merge patch doit être un objet JSON",
+	"precondition_failed":   "la ressource a changé depuis sa lecture",
+	"precondition_required": "l'en-tête If-Match est obligatoire",
+	"filter.invalid":        "filtre invalide",
+	"bad_timezone":          "fuseau horaire inconnu",
+	"method_not_allowed":    "méthode non autorisée",
+
+	"field.required":     "%s est obligatoire",
+	"field.invalid":      "%s n'est pas valide",
+	"field.mismatch":     "%s ne correspond pas",
+	"field.too_long":     "%s est trop long",
+	"field.out_of_range": "%s est hors limites",
+	"field.read_only":    "%s ne peut pas être modifié",
+	"field.taken":        "%s est déjà pris",
+	"field.type":         "%s n'a pas le bon type",
+	"field.unknown":      "%s est inconnu",
+	"field.too_short":    "%s est trop court",
+	"field.too_simple":   "%s est trop simple",
+	"field.breached":     "%s figure dans une fuite de données",
+
+	"user.unauthorized":        "non autorisé",
+	"user.invalid_password":    "mot de passe invalide",
+	"user.invalid_reset_key":   "clé de réinitialisation invalide",
+	"user.not_found":           "utilisateur introuvable",
+	"user.registration_denied": "inscription refusée",
+	"user.deactivated":         "le compte est désactivé",
+	"user.password_mismatch":   "les mots de passe ne correspondent pas",
+
+	"catalog.book_not_found": "livre introuvable",
+	"catalog.empty_query":    "la recherche est vide",
+	"catalog.bad_limit":      "limite invalide",
+
+	"order.not_found": "commande introuvable",
+
+	"shelf.bad_csv":          "fichier csv mal formé",
+	"shelf.empty_import":     "le fichier csv ne contient aucun livre",
+	"shelf.file_too_large":   "le fichier d'import est trop volumineux",
+	"shelf.import_not_found": "import introuvable",
+	"shelf.invalid_rating":   "la note doit être comprise entre 0 et 5",
+	"shelf.invalid_shelf":    "l'étagère doit être to-read, currently-reading ou read",
+	"shelf.no_title":         "le fichier csv n'a pas de colonne de titre",
+	"shelf.row_not_found":    "ligne d'import introuvable",
+	"shelf.row_resolved":     "la ligne d'import est déjà résolue",
+	"shelf.too_many_rows":    "trop de lignes dans un seul import",
+
+	"address.check_not_found": "l'adresse de livraison n'est pas vérifiée",
+
+	"donation.already_donated":     "la commande a déjà un don",
+	"donation.already_paid":        "la commande est déjà payée",
+	"donation.disabled":            "les dons sont désactivés",
+	"donation.invalid_amount":      "montant du don invalide",
+	"donation.invalid_kind":        "type de don invalide",
+	"donation.nothing_to_round_up": "le total de la commande est déjà un montant rond",
+
+	"reading.invalid_kind":       "type d'annotation invalide",
+	"reading.missing_id":         "identifiant d'annotation manquant",
+	"reading.progress_not_found": "progression de lecture introuvable",
+
+	"ebook.format_not_found":   "format d'ebook introuvable",
+	"ebook.not_purchased":      "le livre n'a pas été acheté dans cette commande",
+	"ebook.unpaid":             "la commande n'est pas payée",
+	"ebook.unsupported_format": "format d'ebook non pris en charge",
+
+	"audiobook.missing_device":    "identifiant d'appareil manquant",
+	"audiobook.not_entitled":      "vous n'avez pas accès à ce livre audio",
+	"audiobook.variant_not_found": "version du livre audio introuvable",
+
+	"affiliate.no_referral":  "aucun code ou cookie de parrainage",
+	"affiliate.unknown_code": "code de parrainage inconnu",
+	"affiliate.no_consent":   "le suivi des parrainages nécessite le consentement marketing de l'utilisateur",
+
+	"vat.invalid_id":       "numéro de TVA mal formé",
+	"vat.vies_unavailable": "le service VIES est indisponible",
+	"vat.already_paid":     "la commande est déjà payée",
+
+	"rectification.request_not_found": "demande de rectification introuvable",
+	"rectification.already_pending":   "une rectification du champ est déjà en attente",
+	"rectification.unchanged":         "la valeur est déjà enregistrée",
+
+	"consent.invalid_purpose": "la finalité doit être marketing, analytics ou profiling",
+	"consent.no_changes":      "aucun changement de consentement",
+
+	"archive.document_not_found": "document introuvable",
+	"archive.already_archived":   "la vente est déjà archivée",
+	"archive.no_invoice":         "la commande n'a aucune facture archivée",
+	"archive.credit_exceeds":     "le montant crédité dépasse le total de la commande",
+	"archive.tampered":           "le contenu du document ne correspond pas à son hash",
+
+	"accounting.batch_not_found":      "export comptable introuvable",
+	"accounting.period_open":          "la journée n'est pas encore terminée",
+	"accounting.invalid_date":         "date invalide, format attendu 2006-01-02",
+	"support.ticket_not_found":        "ticket de support introuvable",
+	"support.invalid_status":          "statut de ticket invalide",
+	"auth.missing_token":              "jeton d'accès requis",
+	"auth.invalid_token":              "jeton d'accès invalide",
+	"auth.expired_token":              "jeton d'accès expiré",
+	"user.invalid_refresh_token":      "jeton de rafraîchissement invalide",
+	"auth.revoked_token":              "jeton d'accès révoqué",
+	"user.unverified_email":           "e-mail non vérifié par le fournisseur",
+	"oauth.unknown_provider":          "fournisseur de connexion inconnu",
+	"oauth.invalid_state":             "état de connexion invalide ou expiré",
+	"oauth.provider_failed":           "échec du fournisseur de connexion",
+	"catalog.price_change_not_found":  "changement de prix introuvable",
+	"catalog.price_overlap":           "la promotion chevauche une autre promotion du livre",
+	"catalog.price_not_scheduled":     "le changement de prix n'est plus planifié",
+	"user.invalid_two_factor_code":    "Code de vérification invalide",
+	"user.invalid_login_challenge":    "Connexion invalide ou expirée",
+	"user.two_factor_enabled":         "Authentification à deux facteurs déjà activée",
+	"user.two_factor_disabled":        "Authentification à deux facteurs non activée",
+	"user.two_factor_unavailable":     "Authentification à deux facteurs indisponible",
+	"segment.not_found":               "Segment introuvable",
+	"segment.name_taken":              "Nom de segment déjà utilisé",
+	"rbac.forbidden":                  "Accès refusé pour ce rôle",
+	"order.cart_empty":                "Le panier est vide",
+	"order.cart_changed":              "Les prix ou la disponibilité du panier ont changé, confirmez-le d’abord",
+	"order.cart_currency":             "Les lignes du panier sont dans des devises différentes",
+	"user.account_locked":             "Compte verrouillé après trop de connexions échouées",
+	"order.not_paid":                  "La commande n’est pas payée",
+	"order.shipped":                   "La commande est déjà expédiée",
+	"order.no_lines":                  "La commande n’a pas de lignes à modifier",
+	"order.line_not_found":            "Ligne de commande introuvable",
+	"order.invalid_quantity":          "Quantité invalide",
+	"order.empty_order":               "La commande doit garder une ligne, annulez-la plutôt",
+	"order.currency_mismatch":         "Le livre est vendu dans une autre devise que la commande",
+	"passwordpolicy.unavailable":      "Vérification des mots de passe indisponible",
+	"payment.unknown_wallet":          "Le portefeuille doit être apple_pay ou google_pay",
+	"payment.wallet_disabled":         "Les paiements par portefeuille sont désactivés",
+	"payment.invalid_validation_url":  "L’URL de validation n’est pas une URL Apple Pay",
+	"payment.missing_token":           "Le jeton du portefeuille est obligatoire",
+	"payment.already_paid":            "La commande est déjà payée",
+	"payment.declined":                "Paiement refusé",
+	"payment.bnpl_disabled":           "Le paiement différé est désactivé",
+	"payment.not_eligible":            "La commande ne peut pas être payée plus tard",
+	"payment.authorization_not_found": "Autorisation introuvable",
+	"payment.invalid_signature":       "Signature de webhook invalide",
+	"payment.intent_not_found":        "Paiement introuvable",
+	"user.session_not_found":          "Session introuvable",
+	"payment.dispute_not_found":       "Litige de paiement introuvable",
+	"ratelimit.limited":               "Trop de requêtes, réessayez plus tard",
+	"archive.unknown_reason":          "motif de remboursement inconnu",
+	"archive.refund_not_found":        "remboursement introuvable",
+	"archive.invalid_period":          "période invalide",
+	"archive.invalid_date":            "date invalide",
+	"csrf.invalid_token":              "jeton CSRF manquant ou invalide",
+	"credit.insufficient":             "l'avoir ne couvre pas le total de la commande",
+	"credit.already_paid":             "la commande est déjà payée",
+	"archive.footer_not_found":        "mentions légales introuvables",
+	"archive.unknown_locale":          "langue non prise en charge",
+	"doc.invoice":                     "Facture",
+	"doc.credit_note":                 "Avoir",
+	"doc.issued":                      "Émis le",
+	"doc.book":                        "Livre",
+	"doc.quantity":                    "Qté",
+	"doc.price":                       "Prix",
+	"doc.amount":                      "Montant",
+	"doc.net":                         "Total HT",
+	"doc.vat":                         "TVA",
+	"doc.vat_id":                      "N° TVA",
+	"doc.reverse_charge":              "Autoliquidation, TVA due par le preneur",
+	"doc.total":                       "Total",
+	"doc.order":                       "Commande",
+	"doc.refund":                      "Remboursement",
+	"doc.refund_full":                 "Remboursement total",
+	"doc.refund_partial":              "Remboursement partiel",
+	"doc.reason":                      "Motif",
+	"doc.note":                        "Note",
+	"doc.issued_by":                   "Émis par",
+	"doc.credited":                    "Crédité",
+	"doc.reason.returned":             "Retourné",
+	"doc.reason.damaged":              "Endommagé pendant le transport",
+	"doc.reason.not_received":         "Non reçu",
+	"doc.reason.wrong_item":           "Mauvais article",
+	"doc.reason.cancelled":            "Annulé",
+	"doc.reason.goodwill":             "Geste commercial",
+	"doc.receipt_subject":             "Votre facture de la commande %s",
+	"doc.credit_note_subject":         "Votre avoir %s",
+	"rbac.insufficient_scope":         "Le jeton d'accès n'a pas la portée requise",
+	"fulfillment.shipment_not_found":  "expédition introuvable",
+	"fulfillment.no_label":            "l'étiquette de l'expédition n'est pas encore achetée",
+	"fulfillment.undeliverable":       "l'adresse de livraison n'est pas livrable",
+	"fulfillment.unpaid":              "la commande n'est pas payée",
+	"rbac.impersonated":               "interdit en se faisant passer pour l'utilisateur",
+	"user.impersonate_staff":          "impossible de se faire passer pour un membre du personnel",
+	"delivery.unknown_option":         "le mode de livraison n'est pas proposé pour ce pays",
+	"delivery.transit_not_found":      "délai de transport introuvable",
+	"delivery.promise_not_found":      "promesse de livraison introuvable",
+	"delivery.promise_final":          "la promesse de livraison d'une commande payée ne peut pas changer",
+	"delivery.no_country":             "le pays est obligatoire",
+	"customs.embargoed":               "aucun envoi vers ce pays",
+	"customs.hs_code_not_found":       "code SH introuvable",
+	"customs.declaration_not_found":   "déclaration en douane introuvable",
+	"user.device_not_found":           "appareil de confiance introuvable",
+	"picking.list_not_found":          "Liste de préparation introuvable",
+	"picking.nothing_to_pick":         "Aucune commande ouverte à préparer",
+	"picking.not_picked":              "La commande ne figure sur aucune liste de préparation",
+	"picking.not_in_order":            "Le livre ne fait pas partie de la commande",
+	"picking.already_scanned":         "Tous les exemplaires du livre sont déjà scannés",
+	"picking.packed":                  "La commande est déjà emballée",
+	"picking.unknown_status":          "Le statut doit être open ou done",
+	"returns.return_not_found":        "Retour introuvable",
+	"returns.not_shipped":             "La commande n’est pas encore expédiée",
+	"returns.window_closed":           "Le délai de retour de la commande est dépassé",
+	"returns.no_lines":                "La commande n’a aucune ligne à retourner",
+	"returns.not_in_order":            "Le livre ne fait pas partie de la commande",
+	"returns.too_many":                "Plus d’exemplaires retournés que commandés",
+	"returns.no_label":                "L’étiquette de retour n’est pas encore achetée",
+	"stocktake.session_not_found":     "Inventaire introuvable",
+	"stocktake.session_active":        "L’emplacement est déjà en cours d’inventaire",
+	"stocktake.no_books":              "Aucun livre n’est rangé à cet emplacement",
+	"stocktake.not_open":              "L’inventaire n’est pas ouvert",
+	"stocktake.not_submitted":         "L’inventaire n’est pas soumis",
+	"stocktake.self_review":           "L’inventaire doit être validé par une autre personne que celle qui l’a soumis",
+	"stocktake.partly_posted":         "L’inventaire est en partie comptabilisé, approuvez-le pour comptabiliser le reste",
+	"stocktake.unknown_status":        "Le statut doit être open, submitted ou posted",
+	"quality.report_not_found":        "Rapport de qualité introuvable",
+	"quality.unknown_severity":        "La gravité doit être error ou warning",
+	"quality.unknown_rule":            "Règle inconnue",
+	"user.invalid_image":              "l’avatar doit être une image JPEG, PNG ou GIF",
+	"user.avatar_too_large":           "l’avatar est trop volumineux",
+	"user.avatar_conflict":            "le profil a changé pendant l’envoi de l’avatar, envoyez-le à nouveau",
+	"user.avatars_unavailable":        "Envoi d’avatars indisponible",
+	"dedupe.unknown_kind":             "le type doit être book ou author",
+	"dedupe.invalid_score":            "min_score doit être compris entre 0 et 1",
+	"dedupe.author_not_found":         "auteur introuvable",
+	"dedupe.already_merged":           "l’enregistrement est déjà fusionné",
+	"dedupe.no_redirect":              "l’enregistrement n’est pas fusionné",
+	"dedupe.conflict":                 "les livres ont changé pendant la fusion, fusionnez-les à nouveau",
+	"user.address_not_found":          "adresse introuvable",
+	"user.no_default_address":         "l’utilisateur n’a pas d’adresse par défaut",
+	"user.too_many_addresses":         "le carnet d’adresses est plein",
+	"images.invalid_signature":        "la signature de l’URL de l’image est invalide",
+	"images.invalid_image":            "l’image source n’est pas une image JPEG, PNG ou GIF",
+	"images.source_too_large":         "l’image source est trop grande",
+	"images.unknown_kind":             "type d’image inconnu",
+	"images.image_not_found":          "image introuvable",
+	"images.source_unavailable":       "l’image source est indisponible",
+	"storefront.book_not_found":       "livre introuvable",
+	"storefront.category_not_found":   "catégorie introuvable",
+	"storefront.bad_limit":            "la limite doit être comprise entre 1 et 100",
+	"browsing.no_visitor":             "aucun visiteur",
+	"browsing.book_not_found":         "livre introuvable",
+	"browsing.bad_limit":              "la limite doit être comprise entre 1 et 50",
+	"user.empty_query":                "la recherche est vide",
+	"user.bad_limit":                  "la limite doit être comprise entre 1 et 100",
+	"user.bad_cursor":                 "curseur invalide",
+	"user.cursor_order":               "les pages par curseur ne peuvent pas être triées",
+	"health.unavailable":              "service indisponible",
+	"health.read_only":                "le service est en lecture seule",
+	"erasure.request_not_found":       "demande d'effacement introuvable",
+	"erasure.already_requested":       "l'effacement est déjà demandé",
+	"erasure.already_resolved":        "la demande d'effacement est déjà traitée",
+	"erasure.self_review":             "un effacement ne peut pas être examiné par son propre utilisateur",
+	"erasure.note_required":           "un refus nécessite une note pour l'utilisateur",
+	"user.banned":                     "l'utilisateur est banni",
+	"user.not_banned":                 "l'utilisateur n'est pas banni",
+	"user.ban_reason_required":        "un motif de bannissement est requis",
+	"user.password_reset_required":    "le mot de passe doit être réinitialisé avant la connexion",
+	"user.own_account":                "les administrateurs ne peuvent ni bannir leur propre compte ni en changer le rôle",
+	"user.logins_unavailable":         "historique des connexions indisponible",
+}
@@ -0,0 +1,157 @@
+// i18n translates the error messages of the responses to the language the
+// client asks for in Accept-Language. Errors are translated by a stable
+// code, which the packages register for their user facing errors.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/db"
+	"github.com/kavirajk/bookshop/etag"
+	"github.com/kavirajk/bookshop/filter"
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/shopctx"
+	"github.com/kavirajk/bookshop/transport"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+// DefaultLocale is the language of the error messages themselves, used
+// when no bundled locale is accepted.
+const DefaultLocale = "en"
+
+// bundles holds the translations of every locale by code. Field error
+// templates are under "field." and the validate code, with the field name
+// as argument.
+var bundles = map[string]map[string]string{
+	"de": de,
+	"es": es,
+	"fr": fr,
+}
+
+var codes = make(map[error]string)
+
+func init() {
+	Register(map[error]string{
+		db.ErrNotFound:               "not_found",
+		db.ErrBadCount:               "bad_count",
+		validate.ErrInvalid:          "invalid",
+		patch.ErrContentType:         "patch.content_type",
+		patch.ErrNotObject:           "patch.not_object",
+		etag.ErrPreconditionFailed:   "precondition_failed",
+		etag.ErrPreconditionRequired: "precondition_required",
+		filter.ErrInvalid:            "filter.invalid",
+		transport.ErrBadTimezone:     "bad_timezone",
+		auth.ErrMissingToken:         "auth.missing_token",
+		auth.ErrInvalidToken:         "auth.invalid_token",
+		auth.ErrExpiredToken:         "auth.expired_token",
+		auth.ErrRevokedToken:         "auth.revoked_token",
+	})
+}
+
+// Register sets the stable codes of errors, to be called from the init of
+// the packages. Codes are prefixed with the package, e.g.
+// "catalog.book_not_found".
+func Register(errs map[error]string) {
+	for err, code := range errs {
+		codes[err] = code
+	}
+}
+
+// Code returns the code registered for the cause of err, if any.
+func Code(err error) string {
+	return codes[errors.Cause(err)]
+}
+
+type localeKey struct{}
+
+// PopulateLocale is a ServerBefore func storing the best bundled locale
+// of the Accept-Language of req in the context, or else of the locale of
+// the shop context.
+func PopulateLocale(ctx context.Context, req *http.Request) context.Context {
+	accept := req.Header.Get("Accept-Language")
+	if c, ok := shopctx.FromContext(ctx); ok && accept == "" {
+		accept = c.Locale
+	}
+	return context.WithValue(ctx, localeKey{}, Negotiate(accept))
+}
+
+// Locale returns the locale of the request, DefaultLocale if none.
+func Locale(ctx context.Context) string {
+	if l, ok := ctx.Value(localeKey{}).(string); ok {
+		return l
+	}
+	return DefaultLocale
+}
+
+// Negotiate picks the locale of the Accept-Language header the client
+// prefers among DefaultLocale and the bundled ones. A language with a
+// region, e.g. "fr-CH", matches its base language.
+func Negotiate(header string) string {
+	type lang struct {
+		tag string
+		q   float64
+	}
+	var langs []lang
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		l := lang{tag: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				q, err := strconv.ParseFloat(p[2:], 64)
+				if err != nil {
+					q = 0
+				}
+				l.q = q
+			}
+		}
+		if l.tag != "" && l.q > 0 {
+			langs = append(langs, l)
+		}
+	}
+	// Stable so that languages of the same quality keep the client order.
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	for _, l := range langs {
+		base := strings.Split(l.tag, "-")[0]
+		if base == DefaultLocale {
+			return DefaultLocale
+		}
+		if _, ok := bundles[base]; ok {
+			return base
+		}
+	}
+	return DefaultLocale
+}
+
+// Message returns the message of err in the locale of the request. It
+// falls back to the message of err itself, in English, if its code has
+// no translation.
+func Message(ctx context.Context, err error) string {
+	if m, ok := bundles[Locale(ctx)][Code(err)]; ok {
+		return m
+	}
+	return err.Error()
+}
+
+// Fields translates the messages of the field errors by their code.
+func Fields(ctx context.Context, errs validate.Errors) validate.Errors {
+	b, ok := bundles[Locale(ctx)]
+	if !ok || len(errs) == 0 {
+		return errs
+	}
+	out := make(validate.Errors, len(errs))
+	for i, e := range errs {
+		if t, ok := b["field."+e.Code]; ok {
+			e.Message = fmt.Sprintf(t, e.Field)
+		}
+		out[i] = e
+	}
+	return out
+}
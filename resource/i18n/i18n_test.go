@@ -0,0 +1,89 @@
+package i18n_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kavirajk/bookshop/i18n"
+	"github.com/kavirajk/bookshop/validate"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header, expected string
+	}{
+		{"", "en"},
+		{"fr", "fr"},
+		{"fr-CH, fr;q=0.9, en;q=0.8", "fr"},
+		{"ja, de;q=0.5", "de"},
+		{"en-US, fr;q=0.9", "en"},
+		{"fr;q=0.5, es;q=0.8", "es"},
+		{"de;q=0, ja", "en"},
+		{"*", "en"},
+	}
+	for _, c := range cases {
+		if got := i18n.Negotiate(c.header); got != c.expected {
+			t.Errorf("%q: expected %s, got %s", c.header, c.expected, got)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	errNotFound := errors.New("thing not found")
+	errOther := errors.New("something else")
+	i18n.Register(map[error]string{errNotFound: "order.not_found"})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "fr-FR")
+	ctx := i18n.PopulateLocale(context.Background(), req)
+
+	err := pkgerrors.Wrap(errNotFound, "get")
+	if code := i18n.Code(err); code != "order.not_found" {
+		t.Errorf("code: expected order.not_found, got %q", code)
+	}
+	if msg := i18n.Message(ctx, err); msg != "commande introuvable" {
+		t.Errorf("message: expected the french translation, got %q", msg)
+	}
+	if msg := i18n.Message(context.Background(), err); msg != "get: thing not found" {
+		t.Errorf("message: expected the english error, got %q", msg)
+	}
+	if msg := i18n.Message(ctx, errOther); msg != "something else" {
+		t.Errorf("message: expected english for unregistered errors, got %q", msg)
+	}
+
+	fields := i18n.Fields(ctx, validate.Errors{{Field: "email", Code: validate.CodeRequired, Message: "email is required"}})
+	if fields[0].Message != "email est obligatoire" {
+		t.Errorf("fields: expected the french translation, got %q", fields[0].Message)
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	cases := []struct {
+		locale   string
+		amount   float64
+		currency string
+		expected string
+	}{
+		{"en", 1234.5, "EUR", "€1,234.50"},
+		{"en", -0.5, "USD", "-$0.50"},
+		{"de", 1234567.891, "EUR", "1.234.567,89\u00a0€"},
+		{"fr", 1234.5, "EUR", "1\u00a0234,50\u00a0€"},
+		{"es", 999, "CHF", "999,00\u00a0CHF"},
+		{"de", 1500, "JPY", "1.500\u00a0¥"},
+		{"ja", 12, "GBP", "£12.00"},
+	}
+	for _, c := range cases {
+		if got := i18n.FormatMoney(c.locale, c.amount, c.currency); got != c.expected {
+			t.Errorf("%s %v %s: expected %q, got %q", c.locale, c.amount, c.currency, c.expected, got)
+		}
+	}
+	if got := i18n.Translate("de", "doc.invoice", "Invoice"); got != "Rechnung" {
+		t.Errorf("translate: expected the german text, got %q", got)
+	}
+	if got := i18n.Translate("en", "doc.invoice", "Invoice"); got != "Invoice" {
+		t.Errorf("translate: expected the english text, got %q", got)
+	}
+}
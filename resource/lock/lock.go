@@ -0,0 +1,263 @@
+// lock takes the locks shared by the servers of the shop, so that a
+// scheduled job runs on a single server at a time however many are
+// deployed, see Jobs. A lock is held for a TTL and refreshed by its holder,
+// another server taking it over once the holder is gone.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/redis"
+)
+
+var (
+	ErrLocked = errors.New("lock held by another holder")
+	ErrLost   = errors.New("lock lost")
+)
+
+// DefaultTTL is how long a lock is held unless refreshed, by default.
+const DefaultTTL = 30 * time.Second
+
+// Locker takes the named locks.
+type Locker interface {
+	// Acquire takes the lock name for ttl, failing with ErrLocked if it's
+	// held.
+	Acquire(ctx context.Context, name string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is a lock held.
+type Lock interface {
+	// Refresh holds the lock for its TTL again, failing with ErrLost if
+	// it expired and was taken over.
+	Refresh(ctx context.Context) error
+	// Release frees the lock for the others.
+	Release(ctx context.Context) error
+}
+
+// token returns a random token telling the holders apart.
+func token() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type memoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+}
+
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemoryLocker returns a Locker of the jobs of a single server.
+func NewMemoryLocker() Locker {
+	return &memoryLocker{locks: make(map[string]memoryLock)}
+}
+
+func (l *memoryLocker) Acquire(_ context.Context, name string, ttl time.Duration) (Lock, error) {
+	t, err := token()
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if held, ok := l.locks[name]; ok && now.Before(held.expires) {
+		return nil, ErrLocked
+	}
+	l.locks[name] = memoryLock{token: t, expires: now.Add(ttl)}
+	return &memoryHeld{l: l, name: name, token: t, ttl: ttl}, nil
+}
+
+type memoryHeld struct {
+	l     *memoryLocker
+	name  string
+	token string
+	ttl   time.Duration
+}
+
+func (h *memoryHeld) Refresh(context.Context) error {
+	h.l.mu.Lock()
+	defer h.l.mu.Unlock()
+	now := time.Now()
+	if held := h.l.locks[h.name]; held.token != h.token || !now.Before(held.expires) {
+		return ErrLost
+	}
+	h.l.locks[h.name] = memoryLock{token: h.token, expires: now.Add(h.ttl)}
+	return nil
+}
+
+func (h *memoryHeld) Release(context.Context) error {
+	h.l.mu.Lock()
+	defer h.l.mu.Unlock()
+	if h.l.locks[h.name].token == h.token {
+		delete(h.l.locks, h.name)
+	}
+	return nil
+}
+
+type nopLocker struct{}
+
+// NewNopLocker returns a Locker never acquiring, of a server not to run the
+// jobs, e.g. one serving read-only.
+func NewNopLocker() Locker {
+	return nopLocker{}
+}
+
+func (nopLocker) Acquire(context.Context, string, time.Duration) (Lock, error) {
+	return nil, ErrLocked
+}
+
+// redisPrefix prefixes the keys of the locks.
+const redisPrefix = "lock:"
+
+// The scripts refreshing and releasing a lock if its key still holds the
+// token of the holder.
+const (
+	refreshScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+)
+
+type redisLocker struct {
+	c *redis.Client
+}
+
+// NewRedisLocker returns a Locker of the keys of the Redis server at
+// addr, shared by all the servers. A key holds the token of the holder and
+// expires with the lock.
+func NewRedisLocker(addr, password string) Locker {
+	return &redisLocker{c: redis.NewClient(addr, password)}
+}
+
+func (l *redisLocker) Acquire(_ context.Context, name string, ttl time.Duration) (Lock, error) {
+	t, err := token()
+	if err != nil {
+		return nil, err
+	}
+	_, err = l.c.Do("SET", redisPrefix+name, t, "NX", "PX", millis(ttl))
+	if err == redis.ErrNil {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &redisHeld{c: l.c, key: redisPrefix + name, token: t, ttl: ttl}, nil
+}
+
+type redisHeld struct {
+	c     *redis.Client
+	key   string
+	token string
+	ttl   time.Duration
+}
+
+func (h *redisHeld) Refresh(context.Context) error {
+	n, err := h.c.Do("EVAL", refreshScript, "1", h.key, h.token, millis(h.ttl))
+	if err != nil {
+		return err
+	}
+	if n != "1" {
+		return ErrLost
+	}
+	return nil
+}
+
+func (h *redisHeld) Release(context.Context) error {
+	_, err := h.c.Do("EVAL", releaseScript, "1", h.key, h.token)
+	return err
+}
+
+func millis(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
+
+// Jobs runs the scheduled jobs, each on the server holding its lock.
+type Jobs struct {
+	l      Locker
+	ttl    time.Duration
+	logger log.Logger
+}
+
+// NewJobs returns Jobs locked by l for ttl, DefaultTTL if zero.
+func NewJobs(l Locker, ttl time.Duration, logger log.Logger) *Jobs {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Jobs{l: l, ttl: ttl, logger: logger}
+}
+
+// Run runs job while holding the lock name, until ctx is done. It tries
+// to take the lock every third of the TTL and refreshes it as often while
+// it holds it. The context of job is canceled once the lock is lost, job
+// should return then, and a job returning frees the lock. A job running
+// past the loss of its lock, e.g. of a server cut off from the locker,
+// can overlap with the one of the new holder for the rest of its run.
+func (j *Jobs) Run(ctx context.Context, name string, job func(context.Context)) {
+	ticker := time.NewTicker(j.ttl / 3)
+	defer ticker.Stop()
+	for {
+		held, err := j.l.Acquire(ctx, name, j.ttl)
+		switch err {
+		case nil:
+			j.logger.Log("job", name, "event", "acquired")
+			if done := j.hold(ctx, name, held, ticker.C, job); done {
+				return
+			}
+		case ErrLocked:
+		default:
+			j.logger.Log("job", name, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// hold runs job while refreshing held, returning whether it's done, ctx
+// being done or job having returned.
+func (j *Jobs) hold(ctx context.Context, name string, held Lock, tick <-chan time.Time, job func(context.Context)) bool {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		job(jobCtx)
+	}()
+	for {
+		select {
+		case <-returned:
+			held.Release(context.Background())
+			return true
+		case <-ctx.Done():
+			cancel()
+			<-returned
+			held.Release(context.Background())
+			return true
+		case <-tick:
+			if err := held.Refresh(ctx); err != nil {
+				j.logger.Log("job", name, "event", "lost", "err", err)
+				cancel()
+				<-returned
+				// The lock may be held still, e.g. on a network error, the
+				// release is a no-op if it was taken over.
+				if err != ErrLost {
+					held.Release(context.Background())
+				}
+				return false
+			}
+		}
+	}
+}
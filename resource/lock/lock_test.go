@@ -0,0 +1,143 @@
+package lock_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kavirajk/bookshop/lock"
+)
+
+func testLocker(t *testing.T, name string, l lock.Locker) {
+	ctx := context.Background()
+	held, err := l.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatalf("%s: acquire: unexpected error %v", name, err)
+	}
+	if _, err := l.Acquire(ctx, "job", time.Minute); err != lock.ErrLocked {
+		t.Errorf("%s: acquire held: expected ErrLocked, got %v", name, err)
+	}
+	if err := held.Refresh(ctx); err != nil {
+		t.Errorf("%s: refresh: unexpected error %v", name, err)
+	}
+	if err := held.Release(ctx); err != nil {
+		t.Errorf("%s: release: unexpected error %v", name, err)
+	}
+	if err := held.Refresh(ctx); err != lock.ErrLost {
+		t.Errorf("%s: refresh released: expected ErrLost, got %v", name, err)
+	}
+	if _, err := l.Acquire(ctx, "job", time.Minute); err != nil {
+		t.Errorf("%s: acquire released: unexpected error %v", name, err)
+	}
+}
+
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, "memory", lock.NewMemoryLocker())
+}
+
+// fakeRedis answers the SET NX of the lock keys and the EVAL of their
+// scripts, ignoring the expiry.
+func fakeRedis(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	var mu sync.Mutex
+	keys := make(map[string]string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					var args []string
+					for ; n > 0; n-- {
+						r.ReadString('\n')
+						arg, _ := r.ReadString('\n')
+						args = append(args, strings.TrimRight(arg, "\r\n"))
+					}
+					mu.Lock()
+					switch {
+					case args[0] == "SET" && keys[args[1]] == "":
+						keys[args[1]] = args[2]
+						conn.Write([]byte("+OK\r\n"))
+					case args[0] == "SET":
+						conn.Write([]byte("$-1\r\n"))
+					case args[0] == "EVAL" && keys[args[3]] == args[4]:
+						if strings.Contains(args[1], "del") {
+							delete(keys, args[3])
+						}
+						conn.Write([]byte(":1\r\n"))
+					case args[0] == "EVAL":
+						conn.Write([]byte(":0\r\n"))
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+					mu.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	return l
+}
+
+func TestRedisLocker(t *testing.T) {
+	l := fakeRedis(t)
+	defer l.Close()
+	testLocker(t, "redis", lock.NewRedisLocker(l.Addr().String(), ""))
+}
+
+func TestJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	locker := lock.NewMemoryLocker()
+	var running, runs int32
+	job := func(ctx context.Context) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			t.Error("expected the job to run on a single server at a time")
+		}
+		atomic.AddInt32(&runs, 1)
+		<-ctx.Done()
+		atomic.AddInt32(&running, -1)
+	}
+
+	// Two servers run the job, the second one taking it over once the
+	// first one is gone.
+	first, firstCancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, c := range []context.Context{first, ctx} {
+		wg.Add(1)
+		go func(c context.Context) {
+			defer wg.Done()
+			lock.NewJobs(locker, 30*time.Millisecond, log.NewNopLogger()).Run(c, "job", job)
+		}(c)
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatalf("expected the job run once, got %d", n)
+	}
+	firstCancel()
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&runs) < 2 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("expected the job taken over, got %d runs", n)
+	}
+	cancel()
+	wg.Wait()
+}
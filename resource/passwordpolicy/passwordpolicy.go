@@ -0,0 +1,149 @@
+// passwordpolicy checks the new passwords against a set of rules, e.g. a
+// minimum length or a breached password check, reporting every failing
+// rule as a field error.
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+// ErrUnavailable is the cause of the errors of the rules failing to check a
+// password, e.g. the breached password API being down.
+var ErrUnavailable = errors.New("password policy unavailable")
+
+// Codes of the field errors of the rules.
+const (
+	CodeTooShort  = "too_short"
+	CodeTooSimple = "too_simple"
+	CodeBreached  = "breached"
+)
+
+// Rule checks a single property of a password. code is empty when the
+// password passes, message completes the field name otherwise, e.g. "is too
+// short".
+type Rule interface {
+	Check(ctx context.Context, password string) (code, message string, err error)
+}
+
+// Policy is the rules a new password must pass, none accepting any.
+type Policy []Rule
+
+// Check checks password against all the rules, the failing ones being
+// reported as errors of field, which validate.Fields returns.
+func (p Policy) Check(ctx context.Context, field, password string) error {
+	var v validate.Validator
+	for _, r := range p {
+		code, message, err := r.Check(ctx, password)
+		if err != nil {
+			return errors.Wrap(ErrUnavailable, err.Error())
+		}
+		if code != "" {
+			v.Add(field, code, field+" "+message)
+		}
+	}
+	return v.Err()
+}
+
+type minLength int
+
+// MinLength fails the passwords shorter than n characters.
+func MinLength(n int) Rule {
+	return minLength(n)
+}
+
+func (n minLength) Check(ctx context.Context, password string) (string, string, error) {
+	if utf8.RuneCountInString(password) < int(n) {
+		return CodeTooShort, fmt.Sprintf("must be at least %d characters long", n), nil
+	}
+	return "", "", nil
+}
+
+type classes int
+
+// Classes fails the passwords with less than n of the character classes
+// lower case, upper case, digit and symbol.
+func Classes(n int) Rule {
+	return classes(n)
+}
+
+func (n classes) Check(ctx context.Context, password string) (string, string, error) {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	if lower+upper+digit+symbol < int(n) {
+		return CodeTooSimple, fmt.Sprintf("must mix at least %d of lower case, upper case, digits and symbols", n), nil
+	}
+	return "", "", nil
+}
+
+type breached struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Breached fails the passwords found in data breaches by the Pwned
+// Passwords API at baseURL, e.g. https://api.pwnedpasswords.com. Only the
+// first 5 characters of the SHA-1 of the password are sent, the API
+// returning the suffixes of all the breached hashes in that range.
+func Breached(baseURL string, client *http.Client) Rule {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return breached{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (b breached) Check(ctx context.Context, password string) (string, string, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	req, err := http.NewRequest("GET", b.baseURL+"/range/"+hash[:5], nil)
+	if err != nil {
+		return "", "", err
+	}
+	req = req.WithContext(ctx)
+	// Padding hides the number of the breached hashes of the range.
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("pwned passwords: unexpected status %d", resp.StatusCode)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		// SUFFIX:COUNT, the padding having a count of 0
+		parts := strings.SplitN(strings.TrimSpace(sc.Text()), ":", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], hash[5:]) {
+			continue
+		}
+		if n, _ := strconv.Atoi(parts[1]); n > 0 {
+			return CodeBreached, "appeared in a data breach, choose another one", nil
+		}
+	}
+	return "", "", sc.Err()
+}
@@ -0,0 +1,75 @@
+package passwordpolicy_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/passwordpolicy"
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+// pwned serves the ranges of the Pwned Passwords API, breached being the
+// only breached password.
+func pwned(t *testing.T, breached string) *httptest.Server {
+	sum := sha1.Sum([]byte(breached))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("expected padding to be asked")
+		}
+		fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n")
+		if r.URL.Path == "/range/"+hash[:5] {
+			fmt.Fprintf(w, "%s:3\r\n", hash[5:])
+		}
+	}))
+}
+
+func TestCheck(t *testing.T) {
+	srv := pwned(t, "Password1")
+	defer srv.Close()
+	p := passwordpolicy.Policy{
+		passwordpolicy.MinLength(8),
+		passwordpolicy.Classes(3),
+		passwordpolicy.Breached(srv.URL, nil),
+	}
+	ctx := context.Background()
+
+	cases := []struct {
+		password string
+		codes    []string
+	}{
+		{"correct-Horse-7", nil},
+		{"Password1", []string{passwordpolicy.CodeBreached}},
+		{"abc", []string{passwordpolicy.CodeTooShort, passwordpolicy.CodeTooSimple}},
+		{"abcdefghij", []string{passwordpolicy.CodeTooSimple}},
+	}
+	for _, c := range cases {
+		err := p.Check(ctx, "password", c.password)
+		var codes []string
+		for _, f := range validate.Fields(err) {
+			if f.Field != "password" {
+				t.Errorf("%s: unexpected field %s", c.password, f.Field)
+			}
+			codes = append(codes, f.Code)
+		}
+		if !reflect.DeepEqual(codes, c.codes) {
+			t.Errorf("%s: expected %v, got %v", c.password, c.codes, codes)
+		}
+		if c.codes != nil && errors.Cause(err) != validate.ErrInvalid {
+			t.Errorf("%s: expected validate.ErrInvalid, got %v", c.password, err)
+		}
+	}
+
+	srv.Close()
+	if err := p.Check(ctx, "password", "correct-Horse-7"); errors.Cause(err) != passwordpolicy.ErrUnavailable {
+		t.Errorf("down: expected ErrUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// patch applies JSON Merge Patch (RFC 7396) documents to the domain
+// models, for the partial update endpoints.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// ContentType is the media type of a merge patch request.
+const ContentType = "application/merge-patch+json"
+
+// maxSize limits the patch read into memory.
+const maxSize = 1 << 20
+
+var (
+	ErrContentType = errors.New("content type must be " + ContentType)
+	ErrNotObject   = errors.New("merge patch must be a JSON object")
+)
+
+// Read returns the merge patch sent in the request body.
+func Read(req *http.Request) ([]byte, error) {
+	t, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || t != ContentType {
+		return nil, ErrContentType
+	}
+	return ioutil.ReadAll(http.MaxBytesReader(nil, req.Body, maxSize))
+}
+
+// Merge applies patch to the JSON document doc. Members set to null in
+// the patch are removed, objects are merged recursively and any other
+// value replaces the one of doc.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	var d interface{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(d, p))
+}
+
+func merge(doc, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		d = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+			continue
+		}
+		d[k] = merge(d[k], v)
+	}
+	return d
+}
+
+// Apply patches the struct v points to. Only the members named in fields,
+// by their JSON name, may be given. A null member resets the field to its
+// zero value, a member left out keeps it as is.
+//
+// The members which can't be patched or don't decode are reported all at
+// once as validate.Errors, v is left as is then.
+func Apply(v interface{}, patch []byte, fields ...string) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return ErrNotObject
+	}
+	// Patch a copy so that v is left as is if any member fails.
+	orig := reflect.ValueOf(v).Elem()
+	rv := reflect.New(orig.Type()).Elem()
+	rv.Set(orig)
+	byName := jsonFields(rv.Type())
+	allowed := make(map[string]bool)
+	for _, f := range fields {
+		allowed[f] = true
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vd validate.Validator
+	for _, name := range names {
+		raw := members[name]
+		i, ok := byName[name]
+		if !vd.Check(ok && allowed[name], name, validate.CodeReadOnly, name+" can't be changed") {
+			continue
+		}
+		field := rv.Field(i)
+		if string(bytes.TrimSpace(raw)) == "null" {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		cur, err := json.Marshal(field.Interface())
+		if err != nil {
+			return err
+		}
+		merged, err := Merge(cur, raw)
+		if err != nil {
+			return err
+		}
+		nv := reflect.New(field.Type())
+		if !vd.Check(json.Unmarshal(merged, nv.Interface()) == nil, name, validate.CodeInvalid, name+" has the wrong type") {
+			continue
+		}
+		field.Set(nv.Elem())
+	}
+	if err := vd.Err(); err != nil {
+		return err
+	}
+	orig.Set(rv)
+	return nil
+}
+
+// jsonFields maps the JSON names of the exported fields of struct type t
+// to their index.
+func jsonFields(t reflect.Type) map[string]int {
+	m := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Anonymous {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		m[name] = i
+	}
+	return m
+}
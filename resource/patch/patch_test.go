@@ -0,0 +1,108 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kavirajk/bookshop/patch"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+func TestMerge(t *testing.T) {
+	// Examples of RFC 7396 appendix A.
+	cases := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		out, err := patch.Merge([]byte(c.doc), []byte(c.patch))
+		if err != nil {
+			t.Errorf("%s + %s: unexpected error %v", c.doc, c.patch, err)
+			continue
+		}
+		var got, expected interface{}
+		json.Unmarshal(out, &got)
+		json.Unmarshal([]byte(c.expected), &expected)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s + %s: expected %s, got %s", c.doc, c.patch, c.expected, out)
+		}
+	}
+}
+
+type address struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type profile struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Bio      *string           `json:"bio,omitempty"`
+	Age      int               `json:"age"`
+	Address  address           `json:"address"`
+	Labels   map[string]string `json:"labels"`
+	Password string            `json:"-"`
+}
+
+func TestApply(t *testing.T) {
+	bio := "reader"
+	p := profile{
+		ID:       "1",
+		Name:     "Jane",
+		Bio:      &bio,
+		Age:      30,
+		Address:  address{City: "Chennai", Country: "IN"},
+		Labels:   map[string]string{"a": "1", "b": "2"},
+		Password: "secret",
+	}
+	fields := []string{"name", "bio", "age", "address", "labels"}
+
+	err := patch.Apply(&p, []byte(`{"bio":null,"address":{"city":"Madurai"},"labels":{"a":null,"c":"3"}}`), fields...)
+	if err != nil {
+		t.Fatalf("apply: unexpected error %v", err)
+	}
+	expected := profile{
+		ID:       "1",
+		Name:     "Jane",
+		Age:      30,
+		Address:  address{City: "Madurai", Country: "IN"},
+		Labels:   map[string]string{"b": "2", "c": "3"},
+		Password: "secret",
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("apply: expected %+v, got %+v", expected, p)
+	}
+
+	err = patch.Apply(&p, []byte(`{"id":"2","age":"old","Password":"x","name":"Joe"}`), fields...)
+	fieldErrs := validate.Fields(err)
+	codes := make(map[string]string)
+	for _, f := range fieldErrs {
+		codes[f.Field] = f.Code
+	}
+	expectedCodes := map[string]string{"id": validate.CodeReadOnly, "age": validate.CodeInvalid, "Password": validate.CodeReadOnly}
+	if !reflect.DeepEqual(codes, expectedCodes) {
+		t.Errorf("apply: expected field errors %v, got %v", expectedCodes, codes)
+	}
+	if p.Name != "Jane" {
+		t.Errorf("apply: expected no change on errors, got name %q", p.Name)
+	}
+
+	if err := patch.Apply(&p, []byte(`[1]`), fields...); err != patch.ErrNotObject {
+		t.Errorf("apply: expected ErrNotObject, got %v", err)
+	}
+}
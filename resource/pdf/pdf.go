@@ -0,0 +1,168 @@
+// pdf is a minimal PDF writer: pages of Helvetica text, filled rectangles
+// and lines, which is all labels, packing slips and credit notes need.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Sizes in points.
+const (
+	MM = 72 / 25.4
+
+	A4Width  = 595
+	A4Height = 842
+)
+
+// Document is a PDF of pages of the same size.
+type Document struct {
+	width, height float64
+	pages         []*Page
+}
+
+// Page is a page of a Document, its coordinates are in points from its
+// top left corner.
+type Page struct {
+	height float64
+	buf    bytes.Buffer
+}
+
+// New returns an empty document of pages width by height points.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// AddPage appends a blank page.
+func (d *Document) AddPage() *Page {
+	p := &Page{height: d.height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages returns the number of pages of d.
+func (d *Document) Pages() int {
+	return len(d.pages)
+}
+
+// Text writes s with its baseline at x, y from the top left corner.
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.buf, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.height-y, escape(s))
+}
+
+// Rect fills a rectangle whose top left corner is x, y.
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.buf, "%.2f %.2f %.2f %.2f re f\n", x, p.height-y-h, w, h)
+}
+
+// Line strokes a horizontal line at y from x1 to x2.
+func (p *Page) Line(x1, x2, y float64) {
+	fmt.Fprintf(&p.buf, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, p.height-y, x2, p.height-y)
+}
+
+// Bytes serializes the document.
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	// 1 catalog, 2 page tree, 3 and 4 fonts, then a page and its content
+	// stream for every page.
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			d.width, d.height, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.buf.Len(), p.buf.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// winAnsi are the characters WinAnsi has outside Latin-1, by code.
+var winAnsi = map[rune]byte{
+	'€': 0x80,
+	'‘': 0x91,
+	'’': 0x92,
+	'“': 0x93,
+	'”': 0x94,
+	'–': 0x96,
+	'—': 0x97,
+	'…': 0x85,
+}
+
+// escape encodes s as a WinAnsi PDF string, the other characters become
+// '?'.
+func escape(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r < 0x100:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Wrap breaks s into lines of at most n characters at the spaces, and at
+// its line breaks.
+func Wrap(s string, n int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, w := range strings.Fields(para) {
+			if line != "" && len([]rune(line))+1+len([]rune(w)) > n {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += w
+		}
+		lines = append(lines, Truncate(line, n))
+	}
+	return lines
+}
+
+// Truncate cuts s to n characters, Helvetica has no wrapping.
+func Truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,55 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/kavirajk/bookshop/pdf"
+)
+
+func TestDocumentXref(t *testing.T) {
+	d := pdf.New(62*pdf.MM, 40*pdf.MM)
+	for i := 0; i < 3; i++ {
+		p := d.AddPage()
+		p.Text(10, 10, 8, true, "Les Misérables (tome 1) \\ €…✓")
+		p.Rect(10, 20, 50, 50)
+		p.Line(10, 60, 75)
+	}
+	out := d.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 11\n")) {
+		t.Fatalf("startxref doesn't point to a xref table of 11 entries: %q", out[xref:xref+12])
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 10 {
+		t.Fatalf("expected 10 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if !bytes.HasPrefix(out[off:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("xref entry of object %d points to %q", i+1, out[off:off+10])
+		}
+	}
+	if !bytes.Contains(out, []byte(`(Les Mis\351rables \(tome 1\) \\ \200\205?)`)) {
+		t.Error("text is not escaped to WinAnsi")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := pdf.Wrap("Registered in Berlin, HRB 12345\nManaging director: A. Example", 20)
+	expected := []string{"Registered in", "Berlin, HRB 12345", "Managing director:", "A. Example"}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
@@ -0,0 +1,197 @@
+// ratelimit limits the rate of the requests of a client, by token buckets
+// kept per key, e.g. the IP address or the user of the request.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/redis"
+	"github.com/kavirajk/bookshop/transport"
+)
+
+var (
+	ErrLimited = errors.New("too many requests, retry later")
+)
+
+// Rate allows Limit requests Per period, at once or spread over it. Limit
+// must be positive.
+type Rate struct {
+	Limit int
+	Per   time.Duration
+}
+
+// every is the time a token of the bucket takes to come back.
+func (r Rate) every() time.Duration {
+	return r.Per / time.Duration(r.Limit)
+}
+
+// Limiter keeps a bucket of tokens per key, a request taking one.
+type Limiter interface {
+	// Allow takes a token of the bucket of key, telling how long until the
+	// next one if the bucket is empty.
+	Allow(key string) (ok bool, retryAfter time.Duration, err error)
+}
+
+// sweepEvery is how often the full buckets are dropped from memory.
+const sweepEvery = time.Minute
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+type memoryLimiter struct {
+	rate Rate
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// NewMemoryLimiter returns a Limiter keeping the buckets in memory, for a
+// single server.
+func NewMemoryLimiter(rate Rate) Limiter {
+	return &memoryLimiter{rate: rate, buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+// Allow refills the bucket of key for the time since its last request. The
+// buckets refilled since are dropped along the way, a new bucket being
+// full.
+func (l *memoryLimiter) Allow(key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	burst, every := float64(l.rate.Limit), l.rate.every()
+	if now.Sub(l.swept) >= sweepEvery {
+		for k, b := range l.buckets {
+			if b.tokens+float64(now.Sub(b.at))/float64(every) >= burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.at))/float64(every))
+	b.at = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(every)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// takeScript takes a token of the bucket KEYS[1] of ARGV[1] tokens, one
+// coming back every ARGV[2] milliseconds, at ARGV[3] milliseconds. It
+// returns 0 if a token was taken, or else the milliseconds until the next
+// one. A bucket expires once full again.
+const takeScript = `
+local burst, every, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(b[1]) or burst
+local at = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) / every)
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) * every)
+else
+	tokens = tokens - 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * every) + 1)
+return wait
+`
+
+type redisLimiter struct {
+	c      *redis.Client
+	prefix string
+	rate   Rate
+}
+
+// NewRedisLimiter returns a Limiter keeping the buckets in the Redis server
+// of c, shared by all the servers. The keys of the buckets start with
+// prefix, the limiters of different rates needing different prefixes.
+func NewRedisLimiter(c *redis.Client, prefix string, rate Rate) Limiter {
+	return &redisLimiter{c: c, prefix: prefix, rate: rate}
+}
+
+// Allow takes the token in a script, the concurrent requests of a key
+// being run one after the other by the server.
+func (l *redisLimiter) Allow(key string) (bool, time.Duration, error) {
+	every := strconv.FormatInt(int64(l.rate.every()/time.Millisecond), 10)
+	now := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	reply, err := l.c.Do("EVAL", takeScript, "1", l.prefix+key, strconv.Itoa(l.rate.Limit), every, now)
+	if err != nil {
+		return false, 0, err
+	}
+	wait, err := strconv.ParseInt(reply, 10, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return wait == 0, time.Duration(wait) * time.Millisecond, nil
+}
+
+// limitedError is ErrLimited along with the time to wait before a retry.
+type limitedError struct {
+	retryAfter time.Duration
+}
+
+func (e limitedError) Error() string { return ErrLimited.Error() }
+
+// Cause makes errors.Cause return ErrLimited.
+func (e limitedError) Cause() error { return ErrLimited }
+
+// RetryAfter returns the seconds to wait before a retry of a request
+// failed with ErrLimited, for the Retry-After header.
+func RetryAfter(err error) (string, bool) {
+	e, ok := err.(limitedError)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(int(math.Ceil(e.retryAfter.Seconds()))), true
+}
+
+// ByIP keys the requests by the IP address of the client, see
+// transport.ClientIP.
+func ByIP(ctx context.Context) string {
+	return "ip:" + transport.ClientIP(ctx)
+}
+
+// ByClient keys the requests by the user of the access token, checked by
+// auth.NewMiddleware before, or else by the IP address of the client.
+func ByClient(ctx context.Context) string {
+	if id, ok := auth.UserID(ctx); ok {
+		return "user:" + id
+	}
+	return ByIP(ctx)
+}
+
+// NewMiddleware returns an endpoint middleware failing the requests over
+// the rate of l with ErrLimited, the requests being keyed by key. A nil
+// l doesn't limit. The requests are let through when l fails, to not
+// depend on its store.
+func NewMiddleware(l Limiter, key func(ctx context.Context) string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if l == nil {
+			return next
+		}
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ok, retryAfter, err := l.Allow(key(ctx))
+			if err == nil && !ok {
+				return nil, limitedError{retryAfter: retryAfter}
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/kavirajk/bookshop/ratelimit"
+	"github.com/pkg/errors"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	l := ratelimit.NewMemoryLimiter(ratelimit.Rate{Limit: 3, Per: time.Minute})
+	for i := 0; i < 3; i++ {
+		if ok, _, err := l.Allow("a"); !ok || err != nil {
+			t.Fatalf("request %d: expected allowed, got %v, %v", i, ok, err)
+		}
+	}
+	ok, retryAfter, err := l.Allow("a")
+	if ok || err != nil || retryAfter <= 0 || retryAfter > 20*time.Second {
+		t.Errorf("over: expected a retry within 20s, got %v, %v, %v", ok, retryAfter, err)
+	}
+	if ok, _, _ := l.Allow("b"); !ok {
+		t.Errorf("b: expected its own bucket")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := ratelimit.NewMemoryLimiter(ratelimit.Rate{Limit: 1, Per: time.Hour})
+	e := ratelimit.NewMiddleware(l, ratelimit.ByIP)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	from := func(ip string) context.Context {
+		return context.WithValue(context.Background(), httptransport.ContextKeyRequestRemoteAddr, ip+":1234")
+	}
+
+	if res, err := e(from("10.0.0.1"), nil); err != nil || res != "ok" {
+		t.Fatalf("first: expected ok, got %v, %v", res, err)
+	}
+	_, err := e(from("10.0.0.1"), nil)
+	if errors.Cause(err) != ratelimit.ErrLimited {
+		t.Fatalf("second: expected ErrLimited, got %v", err)
+	}
+	if after, ok := ratelimit.RetryAfter(err); !ok || after != "3600" {
+		t.Errorf("second: expected a retry after 3600s, got %q", after)
+	}
+	if _, err := e(from("10.0.0.2"), nil); err != nil {
+		t.Errorf("other IP: unexpected error %v", err)
+	}
+
+	open := ratelimit.NewMiddleware(nil, ratelimit.ByIP)(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	if _, err := open(from("10.0.0.1"), nil); err != nil {
+		t.Errorf("nil limiter: unexpected error %v", err)
+	}
+}
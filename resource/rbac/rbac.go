@@ -0,0 +1,120 @@
+// rbac restricts the endpoints to the users of some roles, by the role the
+// access token carries, and to the tokens granted some scopes.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kavirajk/bookshop/auth"
+)
+
+var (
+	ErrForbidden         = errors.New("access denied for the role of the user")
+	ErrInsufficientScope = errors.New("access token is not granted the scope")
+	ErrImpersonated      = errors.New("not allowed while impersonating the user")
+)
+
+// RequireRole returns an endpoint middleware rejecting the requests of the
+// users not in one of roles. It runs after auth.NewMiddleware, a request
+// without claims is rejected with auth.ErrMissingToken.
+func RequireRole(roles ...string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			c, ok := auth.ClaimsFrom(ctx)
+			if !ok {
+				return nil, auth.ErrMissingToken
+			}
+			for _, r := range roles {
+				if c.Role == r {
+					return next(ctx, request)
+				}
+			}
+			return nil, ErrForbidden
+		}
+	}
+}
+
+// RequireScope returns an endpoint middleware rejecting the requests of
+// the scoped tokens not granted scope, the unscoped ones passing. It runs
+// after auth.NewMiddleware, along with RequireRole, a scope only narrowing
+// what the role of the user allows.
+func RequireScope(scope string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			c, ok := auth.ClaimsFrom(ctx)
+			if !ok {
+				return nil, auth.ErrMissingToken
+			}
+			if !c.Allows(scope) {
+				return nil, ErrInsufficientScope
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// RequireUnscoped returns an endpoint middleware rejecting the requests of
+// the scoped tokens, for the endpoints no integration is given access to,
+// e.g. the password change. The admins impersonating the user are rejected
+// with ErrImpersonated.
+func RequireUnscoped() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			c, ok := auth.ClaimsFrom(ctx)
+			if !ok {
+				return nil, auth.ErrMissingToken
+			}
+			if c.Scoped() {
+				return nil, ErrInsufficientScope
+			}
+			if c.ImpersonatedBy != "" {
+				return nil, ErrImpersonated
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// Staff returns the endpoint middleware of the shop staff endpoints,
+// authenticating the request by auth.NewMiddleware and letting through the
+// unscoped access tokens of the users in one of roles. No integration is
+// given access to them.
+func Staff(tokens auth.Service, roles ...string) endpoint.Middleware {
+	return endpoint.Chain(auth.NewMiddleware(tokens), RequireUnscoped(), RequireRole(roles...))
+}
+
+var scopes = make(map[string]string)
+
+// Register adds the scopes the tokens can be granted, by name along with
+// their description, to be called from the init of the packages. Scopes
+// are named by resource and access, e.g. "users:read".
+func Register(s map[string]string) {
+	for name, description := range s {
+		scopes[name] = description
+	}
+}
+
+// Known tells whether scope is registered.
+func Known(scope string) bool {
+	_, ok := scopes[scope]
+	return ok
+}
+
+// Scope is a registered scope.
+type Scope struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Scopes returns the registered scopes by name.
+func Scopes() []Scope {
+	list := make([]Scope, 0, len(scopes))
+	for name, description := range scopes {
+		list = append(list, Scope{Name: name, Description: description})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,118 @@
+package rbac_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/auth"
+	"github.com/kavirajk/bookshop/rbac"
+)
+
+func TestRequireRole(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), 0, auth.NewMemoryRevocations(), nil)
+	ok := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	e := auth.NewMiddleware(tokens)(rbac.RequireRole("admin", "support")(ok))
+
+	for name, c := range map[string]struct {
+		role string
+		err  error
+	}{
+		"admin":    {"admin", nil},
+		"support":  {"support", nil},
+		"customer": {"customer", rbac.ErrForbidden},
+		"no role":  {"", rbac.ErrForbidden},
+	} {
+		token, _, _ := tokens.Sign("u1", c.role, "")
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if _, err := e(auth.PopulateToken(context.Background(), req), nil); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+
+	if _, err := rbac.RequireRole("admin")(ok)(context.Background(), nil); err != auth.ErrMissingToken {
+		t.Errorf("no token: expected ErrMissingToken, got %v", err)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), 0, auth.NewMemoryRevocations(), nil)
+	ok := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	read := auth.NewMiddleware(tokens)(rbac.RequireScope("users:read")(ok))
+	account := auth.NewMiddleware(tokens)(rbac.RequireUnscoped()(ok))
+
+	unscoped, _, _ := tokens.Sign("u1", "admin", "")
+	readOnly, _, _ := tokens.SignScoped("u1", "admin", "", []string{"users:read"}, 0)
+	writeOnly, _, _ := tokens.SignScoped("u1", "admin", "", []string{"users:write"}, 0)
+	impersonation, _, _ := tokens.SignImpersonation("u2", "customer", "", "u1", time.Minute)
+
+	for name, c := range map[string]struct {
+		e     func(context.Context, interface{}) (interface{}, error)
+		token string
+		err   error
+	}{
+		"read unscoped":        {read, unscoped, nil},
+		"read users:read":      {read, readOnly, nil},
+		"read users:write":     {read, writeOnly, rbac.ErrInsufficientScope},
+		"account unscoped":     {account, unscoped, nil},
+		"account users:read":   {account, readOnly, rbac.ErrInsufficientScope},
+		"account impersonated": {account, impersonation, rbac.ErrImpersonated},
+		"read impersonated":    {read, impersonation, nil},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		if _, err := c.e(auth.PopulateToken(context.Background(), req), nil); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+
+	if _, _, err := tokens.SignScoped("u1", "admin", "", nil, 0); err == nil {
+		t.Error("no scopes: expected an error")
+	}
+}
+
+func TestScopes(t *testing.T) {
+	rbac.Register(map[string]string{"b:write": "write b", "a:read": "read a"})
+	if !rbac.Known("a:read") || rbac.Known("c:read") {
+		t.Error("expected only the registered scopes known")
+	}
+	s := rbac.Scopes()
+	if len(s) != 2 || s[0] != (rbac.Scope{Name: "a:read", Description: "read a"}) {
+		t.Errorf("expected the scopes by name, got %+v", s)
+	}
+}
+
+func TestStaff(t *testing.T) {
+	tokens := auth.NewService([]byte("secret"), time.Hour, auth.NewMemoryRevocations(), nil)
+	ok := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	e := rbac.Staff(tokens, "admin")(ok)
+
+	admin, _, _ := tokens.Sign("u1", "admin", "")
+	customer, _, _ := tokens.Sign("u2", "customer", "")
+	scoped, _, _ := tokens.SignScoped("u1", "admin", "s1", []string{"users:read"}, time.Hour)
+	for name, c := range map[string]struct {
+		token string
+		err   error
+	}{
+		"admin":        {admin, nil},
+		"customer":     {customer, rbac.ErrForbidden},
+		"scoped admin": {scoped, rbac.ErrInsufficientScope},
+		"no token":     {"", auth.ErrMissingToken},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if _, err := e(auth.PopulateToken(context.Background(), req), nil); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+}
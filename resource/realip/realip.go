@@ -0,0 +1,143 @@
+// realip resolves the IP address of the client of a request. The
+// forwarding headers, Forwarded and X-Forwarded-For, are only believed as
+// far as the proxies appending to them are trusted, anyone can send them
+// otherwise. The rate limits, fraud scoring and audit logs key on the
+// resolved address.
+package realip
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrInvalidProxy = errors.New("trusted proxy must be an IP address or a CIDR range")
+)
+
+// DefaultProxies are the loopback and private ranges, where the load
+// balancers of a deployment usually are.
+const DefaultProxies = "127.0.0.0/8, ::1/128, 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16, fc00::/7"
+
+// Proxies are the networks of the trusted proxies.
+type Proxies []*net.IPNet
+
+// ParseProxies parses a comma separated list of IP addresses and CIDR
+// ranges, e.g. "10.0.0.0/8, 203.0.113.7". An empty list trusts no proxy.
+func ParseProxies(s string) (Proxies, error) {
+	var p Proxies
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, ErrInvalidProxy
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			p = append(p, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, ErrInvalidProxy
+		}
+		p = append(p, n)
+	}
+	return p, nil
+}
+
+// Trusted tells whether ip is the address of a trusted proxy.
+func (p Proxies) Trusted(ip net.IP) bool {
+	for _, n := range p {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client of req. The peer is the
+// client unless it's a trusted proxy, the hops it forwarded for are then
+// walked back to the first one not trusted. Forwarded is preferred to
+// X-Forwarded-For. A hop that isn't an IP address ends the walk on the
+// last trusted one, as does the start of the list.
+func (p Proxies) ClientIP(req *http.Request) string {
+	peer := host(req.RemoteAddr)
+	ip := net.ParseIP(peer)
+	if ip == nil || !p.Trusted(ip) {
+		return peer
+	}
+	hops := forwarded(req.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(host(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !p.Trusted(hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// forwarded returns the addresses of the Forwarded header, else of the
+// X-Forwarded-For one, the closest last. Repeated headers are one list.
+func forwarded(h http.Header) []string {
+	var hops []string
+	if values := h["Forwarded"]; len(values) > 0 {
+		for _, element := range strings.Split(strings.Join(values, ","), ",") {
+			// The hops without a for parameter are left out.
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hops = append(hops, strings.Trim(kv[1], `"`))
+				}
+			}
+		}
+		return hops
+	}
+	for _, v := range strings.Split(strings.Join(h["X-Forwarded-For"], ","), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			hops = append(hops, v)
+		}
+	}
+	return hops
+}
+
+// host strips the port and IPv6 brackets of addr, if any.
+func host(addr string) string {
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+// Handler sets the remote address of the requests to their client, for all
+// the services behind next, and drops their forwarding headers, so that no
+// one reads the client off them unchecked.
+func Handler(p Proxies, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r := req.WithContext(req.Context())
+		_, port, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			port = "0"
+		}
+		r.RemoteAddr = net.JoinHostPort(p.ClientIP(req), port)
+		r.Header = make(http.Header, len(req.Header))
+		for k, v := range req.Header {
+			r.Header[k] = v
+		}
+		for _, k := range []string{"Forwarded", "X-Forwarded-For", "X-Real-Ip"} {
+			r.Header.Del(k)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
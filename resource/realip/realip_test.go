@@ -0,0 +1,66 @@
+package realip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kavirajk/bookshop/realip"
+)
+
+func TestClientIP(t *testing.T) {
+	p, err := realip.ParseProxies("10.0.0.0/8, 2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name, peer string
+		header     map[string]string
+		want       string
+	}{
+		{"direct", "203.0.113.9:4242", nil, "203.0.113.9"},
+		{"spoofed", "203.0.113.9:4242", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "203.0.113.9"},
+		{"proxied", "10.0.0.1:4242", map[string]string{"X-Forwarded-For": "203.0.113.9"}, "203.0.113.9"},
+		{"spoofed through the proxy", "10.0.0.1:4242", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9, 10.0.0.2"}, "203.0.113.9"},
+		{"garbage hop", "10.0.0.1:4242", map[string]string{"X-Forwarded-For": "203.0.113.9, unknown, 10.0.0.2"}, "10.0.0.2"},
+		{"no header", "10.0.0.1:4242", nil, "10.0.0.1"},
+		{"forwarded", "[2001:db8::1]:4242", map[string]string{
+			"Forwarded":       `for=1.2.3.4, for="[2001:db8:cafe::17]:4711";proto=https`,
+			"X-Forwarded-For": "5.6.7.8",
+		}, "2001:db8:cafe::17"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = c.peer
+		for k, v := range c.header {
+			req.Header.Set(k, v)
+		}
+		if got := p.ClientIP(req); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+
+	if _, err := realip.ParseProxies("10.0.0.0/33"); err != realip.ErrInvalidProxy {
+		t.Errorf("bad range: expected ErrInvalidProxy, got %v", err)
+	}
+	if p, err := realip.ParseProxies(realip.DefaultProxies); err != nil || len(p) != 6 {
+		t.Errorf("defaults: expected 6 ranges, got %v, %v", p, err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	p, _ := realip.ParseProxies("10.0.0.0/8")
+	var remote, fwd string
+	h := realip.Handler(p, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		remote, fwd = req.RemoteAddr, req.Header.Get("X-Forwarded-For")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:4242"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if remote != "203.0.113.9:4242" || fwd != "" {
+		t.Errorf("expected the client as remote address and no X-Forwarded-For, got %s and %q", remote, fwd)
+	}
+	if req.Header.Get("X-Forwarded-For") == "" {
+		t.Errorf("expected the headers of the original request kept")
+	}
+}
@@ -0,0 +1,128 @@
+// redis is a minimal client of a Redis server, speaking enough of the
+// protocol (RESP) for the simple string, error, integer and bulk string
+// replies.
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNil is the reply of a missing key, e.g. to GET, or of a command not
+// run, e.g. SET NX of a key set.
+var ErrNil = errors.New("redis: nil reply")
+
+// maxIdleConns is the Redis connections kept open between commands.
+const maxIdleConns = 8
+
+// Client runs commands on the Redis server at an address, over a pool of
+// connections. It is safe for concurrent use.
+type Client struct {
+	addr     string
+	password string
+	timeout  time.Duration
+	idle     chan *conn
+}
+
+// NewClient returns a Client of the Redis server at addr, authenticating
+// with password if not empty.
+func NewClient(addr, password string) *Client {
+	return &Client{
+		addr:     addr,
+		password: password,
+		timeout:  2 * time.Second,
+		idle:     make(chan *conn, maxIdleConns),
+	}
+}
+
+// Do runs a command on an idle connection, or a new one, and returns its
+// reply. A connection failing a command is closed.
+func (r *Client) Do(args ...string) (string, error) {
+	var c *conn
+	select {
+	case c = <-r.idle:
+	default:
+		var err error
+		if c, err = r.dial(); err != nil {
+			return "", err
+		}
+	}
+	reply, err := c.do(r.timeout, args...)
+	if err != nil {
+		c.Close()
+		return "", err
+	}
+	select {
+	case r.idle <- c:
+	default:
+		c.Close()
+	}
+	return reply, nil
+}
+
+func (r *Client) dial() (*conn, error) {
+	nc, err := net.DialTimeout("tcp", r.addr, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	if r.password != "" {
+		if _, err := c.do(r.timeout, "AUTH", r.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *conn) do(timeout time.Duration, args ...string) (string, error) {
+	c.SetDeadline(time.Now().Add(timeout))
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := c.Write(b.Bytes()); err != nil {
+		return "", err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New("redis: " + line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", errors.New("redis: unexpected reply " + line)
+		}
+		if n < 0 {
+			return "", ErrNil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return "", err
+		}
+		return string(b[:n]), nil
+	}
+	return "", errors.New("redis: unexpected reply " + line)
+}
@@ -0,0 +1,272 @@
+// schema checks the JSON body of a request against the schema of the
+// request type it is decoded into, before decoding it. The schema follows
+// the json tags and the Go types of the fields, so that the request types
+// stay the only definition of the payloads. Every failing value is reported
+// by its JSON pointer, e.g. "/lines/0/quantity", as validate.Errors.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kavirajk/bookshop/validate"
+)
+
+// Body is the field of the errors about the body as a whole, e.g. a body
+// which isn't JSON.
+const Body = "body"
+
+// strict rejects the unknown fields and the nulls of fields which can't be
+// null, the default is to ignore them like encoding/json does.
+var strict bool
+
+// SetStrict turns the strict mode on or off. It is to be called once from
+// main.
+func SetStrict(on bool) {
+	strict = on
+}
+
+// Decode decodes the JSON body r into v, a pointer to a request, once the
+// body matches the schema of v.
+func Decode(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := Check(b, reflect.TypeOf(v).Elem(), strict); err != nil {
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Check checks the JSON document b against the schema of t, returning the
+// failing values as validate.Errors.
+func Check(b []byte, t reflect.Type, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return validate.Errors{{Field: Body, Code: validate.CodeRequired, Message: "body is required"}}
+		}
+		return validate.Errors{{Field: Body, Code: validate.CodeInvalid, Message: "body is not valid JSON: " + err.Error()}}
+	}
+	c := checker{strict: strict}
+	c.check("", doc, t)
+	if len(c.errs) > 0 {
+		return c.errs
+	}
+	return nil
+}
+
+type checker struct {
+	strict bool
+	errs   validate.Errors
+}
+
+func (c *checker) add(ptr, code, message string) {
+	name := ptr
+	if name == "" {
+		name = Body
+	}
+	c.errs = append(c.errs, validate.FieldError{Field: name, Code: code, Message: name + " " + message})
+}
+
+// check checks the decoded JSON value v at ptr against the type t.
+func (c *checker) check(ptr string, v interface{}, t reflect.Type) {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			return
+		}
+		if c.strict {
+			c.add(ptr, validate.CodeType, "can't be null")
+		}
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		s, ok := v.(string)
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be a RFC 3339 time string")
+		} else if _, err := time.Parse(time.RFC3339, s); err != nil {
+			c.add(ptr, validate.CodeInvalid, "is not a RFC 3339 time")
+		}
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		// Decoded by the type itself, its errors are its own.
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			c.add(ptr, validate.CodeType, "must be a string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			c.add(ptr, validate.CodeType, "must be a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.(json.Number)
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be an integer")
+			return
+		}
+		i, err := strconv.ParseInt(n.String(), 10, 64)
+		if err != nil {
+			c.add(ptr, validate.CodeType, "must be an integer")
+		} else if reflect.Zero(t).OverflowInt(i) {
+			c.add(ptr, validate.CodeOutOfRange, "is out of range")
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be a positive integer")
+			return
+		}
+		i, err := strconv.ParseUint(n.String(), 10, 64)
+		if err != nil {
+			c.add(ptr, validate.CodeType, "must be a positive integer")
+		} else if reflect.Zero(t).OverflowUint(i) {
+			c.add(ptr, validate.CodeOutOfRange, "is out of range")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(json.Number); !ok {
+			c.add(ptr, validate.CodeType, "must be a number")
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is a base64 string.
+			if _, ok := v.(string); !ok {
+				c.add(ptr, validate.CodeType, "must be a base64 string")
+			}
+			return
+		}
+		items, ok := v.([]interface{})
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be an array")
+			return
+		}
+		for i, item := range items {
+			c.check(ptr+"/"+strconv.Itoa(i), item, t.Elem())
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be an object")
+			return
+		}
+		for k, item := range obj {
+			c.check(ptr+"/"+escape(k), item, t.Elem())
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			c.add(ptr, validate.CodeType, "must be an object")
+			return
+		}
+		fields := fieldsOf(t)
+		for k, item := range obj {
+			f, ok := fields.lookup(k)
+			if !ok {
+				if c.strict {
+					c.add(ptr+"/"+escape(k), validate.CodeUnknown, "is not a known field")
+				}
+				continue
+			}
+			if f.quoted {
+				continue
+			}
+			c.check(ptr+"/"+escape(k), item, f.typ)
+		}
+	}
+}
+
+type field struct {
+	typ reflect.Type
+	// quoted fields are tagged ",string", their value is JSON within a
+	// string.
+	quoted bool
+}
+
+type fields map[string]field
+
+// lookup finds the field of key, which encoding/json matches without case
+// if no field has the exact name.
+func (fs fields) lookup(key string) (field, bool) {
+	if f, ok := fs[key]; ok {
+		return f, true
+	}
+	for name, f := range fs {
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// fieldsOf returns the JSON fields of the struct t, the untagged embedded
+// structs promoting theirs like encoding/json does. The shallower field
+// wins a name used twice.
+func fieldsOf(t reflect.Type) fields {
+	fs := make(fields)
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		ft := sf.Type
+		if sf.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := field{typ: ft}
+		for _, o := range opts[1:] {
+			f.quoted = f.quoted || o == "string"
+		}
+		fs[name] = f
+	}
+	for _, et := range embedded {
+		for name, f := range fieldsOf(et) {
+			if _, ok := fs[name]; !ok {
+				fs[name] = f
+			}
+		}
+	}
+	return fs
+}
+
+// escape escapes a key for a JSON pointer, RFC 6901.
+func escape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,84 @@
+package schema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/schema"
+	"github.com/kavirajk/bookshop/validate"
+)
+
+type line struct {
+	BookID   string `json:"book_id"`
+	Quantity int8   `json:"quantity"`
+}
+
+type audit struct {
+	Note string `json:"note"`
+}
+
+type orderRequest struct {
+	audit
+	Lines    []line            `json:"lines"`
+	Gift     *bool             `json:"gift,omitempty"`
+	At       time.Time         `json:"at"`
+	Tags     map[string]string `json:"tags"`
+	Total    float64           `json:"total,string"`
+	Internal string            `json:"-"`
+}
+
+func TestCheck(t *testing.T) {
+	typ := reflect.TypeOf(orderRequest{})
+	ok := `{"note":"n","lines":[{"book_id":"b_1","quantity":2}],"gift":null,"at":"2026-10-01T08:00:00Z","tags":{"a/b":"c"},"total":"9.5"}`
+	if err := schema.Check([]byte(ok), typ, true); err != nil {
+		t.Errorf("ok: unexpected error %v", err)
+	}
+
+	bad := `{"note":1,"lines":[{"book_id":"b_1","quantity":2},{"book_id":3,"quantity":1.5},{"quantity":300}],"at":"01/10/2026","tags":{"a/b":1}}`
+	want := map[string]string{
+		"/note":             validate.CodeType,
+		"/lines/1/book_id":  validate.CodeType,
+		"/lines/1/quantity": validate.CodeType,
+		"/lines/2/quantity": validate.CodeOutOfRange,
+		"/at":               validate.CodeInvalid,
+		"/tags/a~1b":        validate.CodeType,
+	}
+	got := make(map[string]string)
+	for _, e := range validate.Fields(schema.Check([]byte(bad), typ, false)) {
+		got[e.Field] = e.Code
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad: expected %v, got %v", want, got)
+	}
+
+	strict := `{"lines":null,"note":null,"Internal":"x","extra":true}`
+	got = make(map[string]string)
+	for _, e := range validate.Fields(schema.Check([]byte(strict), typ, true)) {
+		got[e.Field] = e.Code
+	}
+	want = map[string]string{"/note": validate.CodeType, "/Internal": validate.CodeUnknown, "/extra": validate.CodeUnknown}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("strict: expected %v, got %v", want, got)
+	}
+	if err := schema.Check([]byte(strict), typ, false); err != nil {
+		t.Errorf("lenient: unexpected error %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var r orderRequest
+	if err := schema.Decode(strings.NewReader(`{"NOTE":"n","lines":[{"book_id":"b_1","quantity":2}]}`), &r); err != nil {
+		t.Fatalf("decode: unexpected error %v", err)
+	}
+	if r.Note != "n" || len(r.Lines) != 1 || r.Lines[0].Quantity != 2 {
+		t.Errorf("decode: unexpected request %+v", r)
+	}
+	for body, code := range map[string]string{"": validate.CodeRequired, `{"note":`: validate.CodeInvalid} {
+		errs := validate.Fields(schema.Decode(strings.NewReader(body), &r))
+		if len(errs) != 1 || errs[0].Field != schema.Body || errs[0].Code != code {
+			t.Errorf("%q: expected a body %s error, got %v", body, code, errs)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AWSConfig locates the AWS Secrets Manager of a region.
+type AWSConfig struct {
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// Endpoint is the URL of the service,
+	// "https://secretsmanager.<Region>.amazonaws.com" if empty.
+	Endpoint string
+}
+
+type awsStore struct {
+	c      AWSConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewAWS returns the Store of the secrets of AWS Secrets Manager, the
+// paths being the names or the ARNs of the secrets. The secrets rotated
+// by Secrets Manager are picked up once their new version is current.
+func NewAWS(c AWSConfig, client *http.Client) Store {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "https://secretsmanager." + c.Region + ".amazonaws.com"
+	}
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	return awsStore{c: c, client: client, now: time.Now}
+}
+
+func (s awsStore) Fetch(ctx context.Context, path string) (Secret, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return Secret{}, err
+	}
+	req, err := http.NewRequest("POST", s.c.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return Secret{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if s.c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.c.SessionToken)
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Secret{}, errors.Wrap(err, "secrets manager")
+	}
+	defer resp.Body.Close()
+	var v struct {
+		SecretString string
+		VersionId    string
+		// The errors of Secrets Manager tell what failed, not the secrets.
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil && resp.StatusCode < 300 {
+		return Secret{}, errors.Wrap(err, "secrets manager")
+	}
+	if resp.StatusCode >= 300 {
+		if strings.HasSuffix(v.Type, "ResourceNotFoundException") {
+			return Secret{}, ErrNotFound
+		}
+		return Secret{}, fmt.Errorf("secrets manager: unexpected status %d: %s %s", resp.StatusCode, v.Type, v.Message)
+	}
+
+	secret := Secret{Version: v.VersionId}
+	// The secrets of several key/value pairs are JSON objects, e.g. the
+	// database credentials.
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(v.SecretString), &fields); err == nil && fields != nil {
+		secret.Data = make(map[string]string, len(fields))
+		for k, value := range fields {
+			secret.Data[k] = stringOf(value)
+		}
+	} else {
+		secret.Data = map[string]string{"": v.SecretString}
+	}
+	return secret, nil
+}
+
+// sign sets the Authorization header of req, see
+// https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html.
+func (s awsStore) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	date, stamp := now.Format("20060102"), now.Format("20060102T150405Z")
+	payload := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", stamp)
+
+	values := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		values[strings.ToLower(name)] = req.Header.Get(name)
+	}
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, n := range names {
+		headers.WriteString(n + ":" + strings.TrimSpace(values[n]) + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{req.Method, "/", "", headers.String(), signed, hex.EncodeToString(payload[:])}, "\n")
+	hash := sha256.Sum256([]byte(canonical))
+
+	scope := date + "/" + s.c.Region + "/secretsmanager/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + s.c.SecretKey)
+	for _, p := range []string{date, s.c.Region, "secretsmanager", "aws4_request"} {
+		key = hmacSHA256(key, p)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.c.AccessKey+"/"+scope+", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,338 @@
+// secrets resolves the secrets of the shop, e.g. the database credentials,
+// the JWT key and the API keys of the providers, from a reference to a
+// secret store: HashiCorp Vault, AWS Secrets Manager or a file. A value
+// that isn't a reference, e.g. the one of an environment variable, is the
+// secret itself. The secrets of the stores are fetched again to pick up
+// their rotation and their leases are renewed, see Resolver.Run. A Value
+// prints redacted, so that no secret ends up in the logs.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+var (
+	ErrNotFound = errors.New("secret not found")
+	ErrNoField  = errors.New("secret has no such field")
+	ErrNoStore  = errors.New("secret store not configured")
+)
+
+// Defaults of Config.
+const (
+	DefaultRefresh = 5 * time.Minute
+	checkInterval  = 10 * time.Second
+)
+
+// redacted is how a Value prints.
+const redacted = "[redacted]"
+
+// stores are the schemes of the stores of the package, a reference to one
+// not registered fails rather than being taken for the secret.
+var stores = map[string]bool{"vault": true, "aws": true}
+
+// Secret is a version of a secret of a store.
+type Secret struct {
+	// Data are the fields of the secret, e.g. "username" and "password". A
+	// secret of a single value, e.g. a file, has it under the empty key.
+	Data map[string]string
+	// Version identifies the version of the secret, empty if the store
+	// has none.
+	Version string
+	// LeaseID is the lease of a leased secret, which expires after TTL
+	// unless renewed. Zero TTL is a secret that doesn't expire.
+	LeaseID   string
+	TTL       time.Duration
+	Renewable bool
+}
+
+// Store is a store of secrets.
+type Store interface {
+	// Fetch returns the current version of the secret at path.
+	Fetch(ctx context.Context, path string) (Secret, error)
+}
+
+// Renewer is implemented by the stores leasing their secrets, e.g. the
+// database credentials of Vault.
+type Renewer interface {
+	// Renew extends the lease of s, returning it along with its new TTL.
+	Renew(ctx context.Context, s Secret) (Secret, error)
+}
+
+// Value is a secret resolved by a Resolver, kept up to date with the
+// store. It is safe for concurrent use.
+type Value struct {
+	name   string
+	store  Store
+	path   string
+	field  string
+	static bool
+
+	mu       sync.RWMutex
+	secret   Secret
+	previous string
+	lease    time.Duration
+	fetched  time.Time
+}
+
+// Get returns the current value of the secret, the field of its reference
+// or else its only one.
+func (v *Value) Get() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return pick(v.secret, v.field)
+}
+
+// Bytes returns the current value of the secret as bytes.
+func (v *Value) Bytes() []byte {
+	return []byte(v.Get())
+}
+
+// Field returns the field name of the current version of the secret, e.g.
+// the password of database credentials.
+func (v *Value) Field(name string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.secret.Data[name]
+}
+
+// Keys returns the current value and the one it was rotated from, if any,
+// so that the signatures made before a rotation still verify. It is
+// auth.Keys.
+func (v *Value) Keys() [][]byte {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	keys := [][]byte{[]byte(pick(v.secret, v.field))}
+	if v.previous != "" {
+		keys = append(keys, []byte(v.previous))
+	}
+	return keys
+}
+
+// Static tells whether the value never changes, not being of a store.
+func (v *Value) Static() bool {
+	return v.static
+}
+
+// String redacts the value, use Get.
+func (v *Value) String() string {
+	return redacted
+}
+
+// GoString redacts the value of %#v.
+func (v *Value) GoString() string {
+	return redacted
+}
+
+// pick returns the field of s, or its only one if field is empty.
+func pick(s Secret, field string) string {
+	if field != "" {
+		return s.Data[field]
+	}
+	if value, ok := s.Data[""]; ok || len(s.Data) != 1 {
+		return value
+	}
+	for _, value := range s.Data {
+		return value
+	}
+	return ""
+}
+
+// Config configures a Resolver.
+type Config struct {
+	// Refresh is how often the secrets of the stores are fetched again,
+	// DefaultRefresh if zero. The leased secrets are renewed before they
+	// expire regardless.
+	Refresh time.Duration
+	Logger  log.Logger
+}
+
+// Resolver resolves the references to the secrets of its stores. A
+// reference is "<store>:<path>", or "<store>:<path>#<field>" for a field
+// of the secret, e.g. "vault:secret/data/bookshop#jwt_key". The stores are
+// "env", the environment variable of path, "file", the file of path, and
+// the ones registered, any other value being the secret itself.
+type Resolver struct {
+	c      Config
+	stores map[string]Store
+	now    func() time.Time
+
+	mu     sync.Mutex
+	values []*Value
+}
+
+// NewResolver returns a Resolver of the files, register the other stores
+// before resolving their references.
+func NewResolver(c Config) *Resolver {
+	if c.Refresh <= 0 {
+		c.Refresh = DefaultRefresh
+	}
+	if c.Logger == nil {
+		c.Logger = log.NewNopLogger()
+	}
+	return &Resolver{c: c, stores: map[string]Store{"file": files{}}, now: time.Now}
+}
+
+// Register adds the store of the references of scheme, e.g. "vault".
+func (r *Resolver) Register(scheme string, s Store) {
+	r.stores[scheme] = s
+}
+
+// Resolve returns the value of the reference ref of the secret name, name
+// standing for the secret in the logs.
+func (r *Resolver) Resolve(ctx context.Context, name, ref string) (*Value, error) {
+	v := &Value{name: name, static: true, secret: Secret{Data: map[string]string{"": ref}}}
+	i := strings.Index(ref, ":")
+	if i < 0 {
+		return v, nil
+	}
+	scheme, path := ref[:i], ref[i+1:]
+	if j := strings.LastIndex(path, "#"); j >= 0 {
+		path, v.field = path[:j], path[j+1:]
+	}
+	if scheme == "env" {
+		value, ok := os.LookupEnv(path)
+		if !ok {
+			return nil, ErrNotFound
+		}
+		v.secret, v.field = Secret{Data: map[string]string{"": value}}, ""
+		return v, nil
+	}
+	store, ok := r.stores[scheme]
+	if !ok && stores[scheme] {
+		return nil, ErrNoStore
+	}
+	if !ok {
+		// Not a reference, e.g. a password with a colon.
+		v.field = ""
+		return v, nil
+	}
+	v.store, v.path, v.static = store, path, false
+	s, err := r.fetch(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	v.secret, v.lease, v.fetched = s, s.TTL, r.now()
+
+	r.mu.Lock()
+	r.values = append(r.values, v)
+	r.mu.Unlock()
+	return v, nil
+}
+
+// fetch returns the current version of the secret of v, checking it has
+// the field of v.
+func (r *Resolver) fetch(ctx context.Context, v *Value) (Secret, error) {
+	s, err := v.store.Fetch(ctx, v.path)
+	if err != nil {
+		return Secret{}, err
+	}
+	if _, ok := s.Data[v.field]; v.field != "" && !ok {
+		return Secret{}, ErrNoField
+	}
+	return s, nil
+}
+
+// Run refreshes the values until ctx is done.
+func (r *Resolver) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh renews the leases past two thirds of their TTL and fetches the
+// values due again, the ones of the leases not renewed, failing to or
+// renewed for less than half their TTL, e.g. past their max TTL, and the
+// others every Refresh. A value failing to refresh is kept as it is.
+func (r *Resolver) Refresh(ctx context.Context) {
+	r.mu.Lock()
+	values := append([]*Value(nil), r.values...)
+	r.mu.Unlock()
+
+	for _, v := range values {
+		v.mu.RLock()
+		s, lease, fetched := v.secret, v.lease, v.fetched
+		v.mu.RUnlock()
+
+		now := r.now()
+		due := fetched.Add(r.c.Refresh)
+		if s.TTL > 0 {
+			if renewAt := fetched.Add(s.TTL * 2 / 3); renewAt.Before(due) {
+				due = renewAt
+			}
+		}
+		if now.Before(due) {
+			continue
+		}
+		if renewer, ok := v.store.(Renewer); ok && s.LeaseID != "" && s.Renewable {
+			renewed, err := renewer.Renew(ctx, s)
+			if err == nil && renewed.TTL >= lease/2 {
+				v.mu.Lock()
+				v.secret, v.fetched = renewed, now
+				v.mu.Unlock()
+				r.c.Logger.Log("secret", v.name, "event", "renewed")
+				continue
+			}
+			if err != nil {
+				r.c.Logger.Log("secret", v.name, "event", "renew", "err", err)
+			}
+		}
+		fresh, err := r.fetch(ctx, v)
+		if err != nil {
+			r.c.Logger.Log("secret", v.name, "event", "refresh", "err", err)
+			continue
+		}
+		v.mu.Lock()
+		rotated := !sameData(v.secret.Data, fresh.Data)
+		if old := pick(v.secret, v.field); old != pick(fresh, v.field) {
+			v.previous = old
+		}
+		v.secret, v.lease, v.fetched = fresh, fresh.TTL, now
+		v.mu.Unlock()
+		if rotated {
+			r.c.Logger.Log("secret", v.name, "event", "rotated", "version", fresh.Version)
+		}
+	}
+}
+
+func sameData(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// files is the store of the files, e.g. the secrets mounted by
+// Kubernetes or Docker, which rotate them in place.
+type files struct{}
+
+func (files) Fetch(_ context.Context, path string) (Secret, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Secret{}, ErrNotFound
+	}
+	if err != nil {
+		return Secret{}, err
+	}
+	return Secret{Data: map[string]string{"": string(bytes.TrimRight(b, "\r\n"))}}, nil
+}
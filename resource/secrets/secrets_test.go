@@ -0,0 +1,190 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "jwt")
+	if err := ioutil.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("SECRETS_TEST", "from-env")
+	defer os.Unsetenv("SECRETS_TEST")
+
+	r := NewResolver(Config{})
+	for _, c := range []struct {
+		ref, value string
+	}{
+		{"plain", "plain"},
+		{"postgres://u:p@db/shop#x", "postgres://u:p@db/shop#x"},
+		{"env:SECRETS_TEST", "from-env"},
+		{"file:" + file, "from-file"},
+	} {
+		v, err := r.Resolve(ctx, "test", c.ref)
+		if err != nil || v.Get() != c.value {
+			t.Errorf("%q: expected %q, got %q, %v", c.ref, c.value, v.Get(), err)
+			continue
+		}
+		if s := fmt.Sprintf("%v %s %+v %#v", v, v, v, v); strings.Contains(s, c.value) {
+			t.Errorf("%q: expected the value redacted, got %s", c.ref, s)
+		}
+	}
+	for _, c := range []struct {
+		ref string
+		err error
+	}{
+		{"env:SECRETS_TEST_UNSET", ErrNotFound},
+		{"file:" + filepath.Join(dir, "none"), ErrNotFound},
+		{"vault:secret/data/bookshop#jwt", ErrNoStore},
+	} {
+		if _, err := r.Resolve(ctx, "test", c.ref); err != c.err {
+			t.Errorf("%q: expected %v, got %v", c.ref, c.err, err)
+		}
+	}
+}
+
+// vaultServer fakes the token, key/value version 2 and lease endpoints of
+// Vault.
+type vaultServer struct {
+	version  int
+	lease    int
+	leases   int
+	renewed  int
+	maxLease int
+}
+
+func (s *vaultServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	var resp map[string]interface{}
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		resp = map[string]interface{}{"data": map[string]interface{}{"ttl": 0, "renewable": false}}
+	case "/v1/secret/data/bookshop":
+		resp = map[string]interface{}{"data": map[string]interface{}{
+			"data":     map[string]interface{}{"jwt": fmt.Sprintf("key-%d", s.version)},
+			"metadata": map[string]interface{}{"version": s.version},
+		}}
+	case "/v1/database/creds/bookshop":
+		s.leases++
+		resp = map[string]interface{}{
+			"lease_id": fmt.Sprintf("database/creds/bookshop/%d", s.leases), "lease_duration": s.lease, "renewable": true,
+			"data": map[string]interface{}{"username": fmt.Sprintf("user-%d", s.leases), "password": "secret"},
+		}
+	case "/v1/sys/leases/renew":
+		s.renewed++
+		ttl := s.lease
+		if s.renewed > s.maxLease {
+			ttl = 1
+		}
+		resp = map[string]interface{}{"lease_duration": ttl, "renewable": true}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+	vs := &vaultServer{version: 1, lease: 60, maxLease: 1}
+	srv := httptest.NewServer(vs)
+	defer srv.Close()
+
+	now := time.Now()
+	r := NewResolver(Config{Refresh: time.Hour})
+	r.now = func() time.Time { return now }
+	r.Register("vault", NewVault(VaultConfig{Addr: srv.URL, Token: "token"}, nil))
+
+	key, err := r.Resolve(ctx, "jwt-secret", "vault:secret/data/bookshop#jwt")
+	if err != nil || key.Get() != "key-1" {
+		t.Fatalf("expected key-1, got %q, %v", key.Get(), err)
+	}
+	creds, err := r.Resolve(ctx, "db-credentials", "vault:database/creds/bookshop")
+	if err != nil || creds.Field("username") != "user-1" {
+		t.Fatalf("expected user-1, got %q, %v", creds.Field("username"), err)
+	}
+	if _, err := r.Resolve(ctx, "none", "vault:secret/data/bookshop#none"); err != ErrNoField {
+		t.Errorf("unknown field: expected ErrNoField, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, "none", "vault:secret/data/none"); err != ErrNotFound {
+		t.Errorf("unknown secret: expected ErrNotFound, got %v", err)
+	}
+
+	// Past two thirds of the lease, it's renewed.
+	now = now.Add(45 * time.Second)
+	r.Refresh(ctx)
+	if vs.renewed != 1 || creds.Field("username") != "user-1" {
+		t.Errorf("expected the lease renewed, got %d renewals of %q", vs.renewed, creds.Field("username"))
+	}
+	// Renewed for less than half its TTL, new credentials are fetched.
+	now = now.Add(45 * time.Second)
+	r.Refresh(ctx)
+	if creds.Field("username") != "user-2" {
+		t.Errorf("expected new credentials, got %q", creds.Field("username"))
+	}
+
+	// The key rotates, the previous one still verifies.
+	vs.version = 2
+	now = now.Add(time.Hour)
+	r.Refresh(ctx)
+	keys := key.Keys()
+	if len(keys) != 2 || string(keys[0]) != "key-2" || string(keys[1]) != "key-1" {
+		t.Errorf("expected key-2 then key-1, got %q", keys)
+	}
+}
+
+func TestAWS(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.SecretId {
+		case "bookshop/jwt":
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": "plain", "VersionId": "v1"})
+		case "bookshop/db":
+			json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"username":"shop","password":"secret"}`, "VersionId": "v1"})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException"})
+		}
+	}))
+	defer srv.Close()
+
+	r := NewResolver(Config{})
+	r.Register("aws", NewAWS(AWSConfig{Region: "eu-central-1", AccessKey: "AKID", SecretKey: "secret", Endpoint: srv.URL}, nil))
+	if v, err := r.Resolve(ctx, "jwt-secret", "aws:bookshop/jwt"); err != nil || v.Get() != "plain" {
+		t.Errorf("plain secret: expected plain, got %v", err)
+	}
+	if v, err := r.Resolve(ctx, "db-credentials", "aws:bookshop/db#password"); err != nil || v.Get() != "secret" {
+		t.Errorf("JSON secret: expected its password, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, "none", "aws:bookshop/none"); err != ErrNotFound {
+		t.Errorf("unknown secret: expected ErrNotFound, got %v", err)
+	}
+}
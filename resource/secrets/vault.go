@@ -0,0 +1,170 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// VaultConfig locates a Vault server.
+type VaultConfig struct {
+	// Addr is the URL of the server, e.g. "https://vault:8200".
+	Addr string
+	// Token authenticates the shop, it is renewed before it expires if
+	// renewable.
+	Token string
+}
+
+type vault struct {
+	c      VaultConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu sync.Mutex
+	// renewAt is when the token is due for renewal, zero until looked up,
+	// and never for a token that doesn't expire or isn't renewable.
+	renewAt time.Time
+	never   bool
+}
+
+// NewVault returns the Store of the secrets of a Vault server, the paths
+// being the API ones, e.g. "secret/data/bookshop" for a key/value version
+// 2 secret and "database/creds/bookshop" for leased database credentials.
+func NewVault(c VaultConfig, client *http.Client) Store {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c.Addr = strings.TrimSuffix(c.Addr, "/")
+	return &vault{c: c, client: client, now: time.Now}
+}
+
+// vaultResponse is the envelope of the responses of the Vault API.
+type vaultResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		LeaseDuration int  `json:"lease_duration"`
+		Renewable     bool `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func (v *vault) Fetch(ctx context.Context, path string) (Secret, error) {
+	v.renewToken(ctx)
+	var resp vaultResponse
+	if err := v.do(ctx, "GET", "/v1/"+strings.TrimPrefix(path, "/"), nil, &resp); err != nil {
+		return Secret{}, err
+	}
+	data := resp.Data
+	s := Secret{LeaseID: resp.LeaseID, TTL: time.Duration(resp.LeaseDuration) * time.Second, Renewable: resp.Renewable}
+	// A key/value version 2 secret nests its data along with its version.
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if meta, ok := data["metadata"].(map[string]interface{}); ok {
+			data = inner
+			if version, ok := meta["version"].(float64); ok {
+				s.Version = strconv.Itoa(int(version))
+			}
+		}
+	}
+	s.Data = make(map[string]string, len(data))
+	for k, value := range data {
+		s.Data[k] = stringOf(value)
+	}
+	return s, nil
+}
+
+func (v *vault) Renew(ctx context.Context, s Secret) (Secret, error) {
+	v.renewToken(ctx)
+	body := map[string]interface{}{"lease_id": s.LeaseID, "increment": int(s.TTL.Seconds())}
+	var resp vaultResponse
+	if err := v.do(ctx, "PUT", "/v1/sys/leases/renew", body, &resp); err != nil {
+		return Secret{}, err
+	}
+	s.TTL, s.Renewable = time.Duration(resp.LeaseDuration)*time.Second, resp.Renewable
+	return s, nil
+}
+
+// renewToken renews the token past two thirds of its TTL. A failure is
+// left to the next request, the token being valid still, or else failing
+// the request.
+func (v *vault) renewToken(ctx context.Context) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.never || v.now().Before(v.renewAt) {
+		return
+	}
+	var resp vaultResponse
+	if v.renewAt.IsZero() {
+		if err := v.do(ctx, "GET", "/v1/auth/token/lookup-self", nil, &resp); err != nil {
+			return
+		}
+		ttl, _ := resp.Data["ttl"].(float64)
+		renewable, _ := resp.Data["renewable"].(bool)
+		v.schedule(time.Duration(ttl)*time.Second, renewable)
+		return
+	}
+	if err := v.do(ctx, "POST", "/v1/auth/token/renew-self", map[string]interface{}{}, &resp); err != nil || resp.Auth == nil {
+		return
+	}
+	v.schedule(time.Duration(resp.Auth.LeaseDuration)*time.Second, resp.Auth.Renewable)
+}
+
+func (v *vault) schedule(ttl time.Duration, renewable bool) {
+	v.never = ttl == 0 || !renewable
+	v.renewAt = v.now().Add(ttl * 2 / 3)
+}
+
+func (v *vault) do(ctx context.Context, method, path string, body interface{}, resp *vaultResponse) error {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, v.c.Addr+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", v.c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	r, err := v.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "vault")
+	}
+	defer r.Body.Close()
+	if r.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	// The errors of Vault tell what failed, not the secrets.
+	if err := json.NewDecoder(r.Body).Decode(resp); err != nil && r.StatusCode < 300 {
+		return errors.Wrap(err, "vault")
+	}
+	if r.StatusCode >= 300 {
+		return fmt.Errorf("vault %s: unexpected status %d: %s", path, r.StatusCode, strings.Join(resp.Errors, "; "))
+	}
+	return nil
+}
+
+// stringOf returns the string of a field of a secret, the JSON of one not
+// being a string.
+func stringOf(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
@@ -0,0 +1,72 @@
+// secure sets the security headers of the responses: Strict-Transport-
+// Security, X-Content-Type-Options, X-Frame-Options and
+// Content-Security-Policy. The services answer JSON and files, nothing a
+// browser should render within a page or run scripts of.
+package secure
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults of the headers.
+const (
+	DefaultHSTSMaxAge            = 365 * 24 * time.Hour
+	DefaultFrameOptions          = "DENY"
+	DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+)
+
+// Config is the headers to set. The empty ones are left out.
+type Config struct {
+	// HSTSMaxAge is how long the browsers keep to HTTPS, rounded down to
+	// the second. Zero leaves Strict-Transport-Security out.
+	HSTSMaxAge time.Duration
+	// HSTSSubdomains extends HTTPS to the subdomains.
+	HSTSSubdomains bool
+	// FrameOptions is DENY or SAMEORIGIN.
+	FrameOptions          string
+	ContentSecurityPolicy string
+}
+
+// DefaultConfig returns the config with the default headers.
+func DefaultConfig() Config {
+	return Config{
+		HSTSMaxAge:            DefaultHSTSMaxAge,
+		FrameOptions:          DefaultFrameOptions,
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+	}
+}
+
+// Headers returns the headers of c.
+func (c Config) Headers() http.Header {
+	h := make(http.Header)
+	if secs := int64(c.HSTSMaxAge / time.Second); secs > 0 {
+		v := "max-age=" + strconv.FormatInt(secs, 10)
+		if c.HSTSSubdomains {
+			v += "; includeSubDomains"
+		}
+		h.Set("Strict-Transport-Security", v)
+	}
+	h.Set("X-Content-Type-Options", "nosniff")
+	if c.FrameOptions != "" {
+		h.Set("X-Frame-Options", c.FrameOptions)
+	}
+	if c.ContentSecurityPolicy != "" {
+		h.Set("Content-Security-Policy", c.ContentSecurityPolicy)
+	}
+	return h
+}
+
+// Handler sets the headers of c on every response of next. They're set
+// before next is served, a service sending a header of its own has the
+// last word.
+func Handler(c Config, next http.Handler) http.Handler {
+	headers := c.Headers()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for k, v := range headers {
+			w.Header()[k] = v
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,48 @@
+package secure_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/secure"
+)
+
+func TestHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/framed" {
+			w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	c := secure.DefaultConfig()
+	c.HSTSSubdomains = true
+	h := secure.Handler(c, next)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/books/v1/", nil))
+	expected := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Content-Security-Policy":   secure.DefaultContentSecurityPolicy,
+	}
+	for k, v := range expected {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/framed", nil))
+	if got := w.Header().Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Errorf("expected the header of the service kept, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	secure.Handler(secure.Config{HSTSMaxAge: time.Second / 2}, next).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if _, ok := w.Header()["Strict-Transport-Security"]; ok || w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("expected only nosniff of an empty config, got %v", w.Header())
+	}
+}
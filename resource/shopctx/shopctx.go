@@ -0,0 +1,179 @@
+// shopctx carries the shopping context of a session, its currency, locale,
+// store, experiment bucket and visitor, in the signed X-Shop-Context header. The
+// server resolves it on the first request and sends it back, the client
+// echoes it on the next ones, so every service of a session sees the same
+// context.
+package shopctx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalid = errors.New("invalid shop context")
+)
+
+// Header is the request and response header of the context.
+const Header = "X-Shop-Context"
+
+// DefaultBuckets is the number of experiment buckets by default.
+const DefaultBuckets = 100
+
+// Context is the shopping context of a session.
+type Context struct {
+	Currency string `json:"currency"`
+	Locale   string `json:"locale"`
+	Store    string `json:"store,omitempty"`
+	// Bucket is the experiment bucket of the session, in [0, Buckets).
+	Bucket int `json:"bucket"`
+	// Visitor identifies the browser of the session, signed in or not, so
+	// that its browsing history outlives the sessions and follows it into
+	// the account at login.
+	Visitor string `json:"visitor"`
+}
+
+// Config sets the context of new sessions.
+type Config struct {
+	// Secret signs the header, a client can't pick its bucket.
+	Secret   []byte
+	Currency string
+	Locale   string
+	Store    string
+	Buckets  int
+}
+
+// Resolver reads and signs the contexts.
+type Resolver struct {
+	cfg Config
+}
+
+// NewResolver returns a Resolver of cfg, with DefaultBuckets if none.
+func NewResolver(cfg Config) Resolver {
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = DefaultBuckets
+	}
+	cfg.Currency = strings.ToUpper(cfg.Currency)
+	return Resolver{cfg: cfg}
+}
+
+// Encode returns the signed header value of c.
+func (r Resolver) Encode(c Context) string {
+	b, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + r.signature(payload)
+}
+
+// Decode checks the signature of a header value and returns its context.
+func (r Resolver) Decode(v string) (Context, error) {
+	parts := strings.Split(v, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(r.signature(parts[0]))) {
+		return Context{}, ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Context{}, ErrInvalid
+	}
+	var c Context
+	if err := json.Unmarshal(b, &c); err != nil || c.Bucket < 0 || c.Bucket >= r.cfg.Buckets {
+		return Context{}, ErrInvalid
+	}
+	return c, nil
+}
+
+func (r Resolver) signature(payload string) string {
+	mac := hmac.New(sha256.New, r.cfg.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+var currencyRe = regexp.MustCompile(`^[A-Za-z]{3}$`)
+
+// Resolve returns the context of req and whether it differs from the one
+// of its header. A missing or invalid header starts a new context, in a
+// random bucket and for a new visitor, its locale from Accept-Language. The currency and store
+// query parameters switch the currency and store of the session, keeping
+// its bucket.
+func (r Resolver) Resolve(req *http.Request) (Context, bool) {
+	c, err := r.Decode(req.Header.Get(Header))
+	changed := err != nil
+	if changed {
+		c = Context{
+			Currency: r.cfg.Currency,
+			Locale:   language(req.Header.Get("Accept-Language"), r.cfg.Locale),
+			Store:    r.cfg.Store,
+			Bucket:   r.bucket(),
+		}
+	}
+	if c.Visitor == "" {
+		// The contexts issued before the visitors get one.
+		c.Visitor, changed = visitor(), true
+	}
+	q := req.URL.Query()
+	if v := q.Get("currency"); currencyRe.MatchString(v) && strings.ToUpper(v) != c.Currency {
+		c.Currency, changed = strings.ToUpper(v), true
+	}
+	if v := q.Get("store"); v != "" && v != c.Store {
+		c.Store, changed = v, true
+	}
+	return c, changed
+}
+
+func (r Resolver) bucket() int {
+	var b [4]byte
+	rand.Read(b[:])
+	return int(binary.BigEndian.Uint32(b[:]) % uint32(r.cfg.Buckets))
+}
+
+// visitor returns a new random visitor ID.
+func visitor() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// language returns the primary language of the first tag of an
+// Accept-Language header, def if none.
+func language(header, def string) string {
+	tag := strings.TrimSpace(strings.SplitN(strings.SplitN(header, ",", 2)[0], ";", 2)[0])
+	tag = strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	if tag == "" || tag == "*" {
+		return def
+	}
+	return tag
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying the shop context c.
+func NewContext(ctx context.Context, c Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the shop context of the request, set by Handler.
+func FromContext(ctx context.Context) (Context, bool) {
+	c, ok := ctx.Value(contextKey{}).(Context)
+	return c, ok
+}
+
+// Handler resolves the context of the requests into their context, for
+// all the services behind next, and sends it back when it changed.
+func Handler(r Resolver, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c, changed := r.Resolve(req)
+		if changed {
+			w.Header().Set(Header, r.Encode(c))
+		}
+		next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), c)))
+	})
+}
@@ -0,0 +1,75 @@
+package shopctx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kavirajk/bookshop/shopctx"
+)
+
+func TestResolve(t *testing.T) {
+	r := shopctx.NewResolver(shopctx.Config{Secret: []byte("secret"), Currency: "eur", Locale: "en", Buckets: 10})
+
+	req := httptest.NewRequest("GET", "/catalog/v1/books", nil)
+	req.Header.Set("Accept-Language", "fr-CH, fr;q=0.9")
+	c, changed := r.Resolve(req)
+	if !changed || c.Currency != "EUR" || c.Locale != "fr" || c.Bucket < 0 || c.Bucket >= 10 || len(c.Visitor) != 32 {
+		t.Fatalf("new: unexpected context %+v, changed %v", c, changed)
+	}
+
+	// the next requests of the session keep it.
+	req = httptest.NewRequest("GET", "/catalog/v1/books", nil)
+	req.Header.Set(shopctx.Header, r.Encode(c))
+	req.Header.Set("Accept-Language", "de")
+	if got, changed := r.Resolve(req); changed || got != c {
+		t.Errorf("echoed: expected %+v unchanged, got %+v, changed %v", c, got, changed)
+	}
+
+	req = httptest.NewRequest("GET", "/catalog/v1/books?currency=usd&store=ch", nil)
+	req.Header.Set(shopctx.Header, r.Encode(c))
+	if got, changed := r.Resolve(req); !changed || got.Currency != "USD" || got.Store != "ch" || got.Bucket != c.Bucket || got.Visitor != c.Visitor {
+		t.Errorf("switch: expected USD in store ch, bucket %d, got %+v, changed %v", c.Bucket, got, changed)
+	}
+
+	// a context issued before the visitors keeps its bucket.
+	req = httptest.NewRequest("GET", "/catalog/v1/books", nil)
+	req.Header.Set(shopctx.Header, r.Encode(shopctx.Context{Currency: "EUR", Bucket: 3}))
+	if got, changed := r.Resolve(req); !changed || got.Bucket != 3 || got.Visitor == "" {
+		t.Errorf("no visitor: expected a visitor in bucket 3, got %+v, changed %v", got, changed)
+	}
+
+	other := shopctx.NewResolver(shopctx.Config{Secret: []byte("other"), Buckets: 10})
+	for name, v := range map[string]string{
+		"garbage":   "abc",
+		"signature": other.Encode(shopctx.Context{Currency: "EUR", Bucket: 3}),
+		"bucket":    r.Encode(shopctx.Context{Currency: "EUR", Bucket: 42}),
+	} {
+		if _, err := r.Decode(v); err != shopctx.ErrInvalid {
+			t.Errorf("%s: expected ErrInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := shopctx.NewResolver(shopctx.Config{Secret: []byte("secret"), Currency: "EUR", Locale: "en"})
+	var got shopctx.Context
+	h := shopctx.Handler(r, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got, _ = shopctx.FromContext(req.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	header := w.Header().Get(shopctx.Header)
+	if c, err := r.Decode(header); err != nil || c != got || got.Locale != "en" {
+		t.Fatalf("new: expected the context %+v in the header, got %+v, %v", got, c, err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(shopctx.Header, header)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Header().Get(shopctx.Header) != "" {
+		t.Errorf("echoed: expected no header sent back, got %s", w.Header().Get(shopctx.Header))
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal returns Storage keeping the files under dir, served at
+// <baseURL>/<key>, e.g. by an http.FileServer of dir.
+func NewLocal(dir, baseURL string) Storage {
+	return local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Put writes to a temporary file renamed over the file, so that the file
+// is never read half written.
+func (l local) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return l.baseURL + "/" + key, nil
+}
+
+func (l local) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// S3Config locates a bucket of an S3 compatible service.
+type S3Config struct {
+	// Endpoint is the URL of the service, e.g.
+	// "https://s3.eu-central-1.amazonaws.com". The bucket is addressed by
+	// path, which the S3 compatible services support too.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// BaseURL is the public URL of the bucket, e.g. the one of a CDN,
+	// <Endpoint>/<Bucket> if empty. The bucket policy is expected to let
+	// anyone read the files.
+	BaseURL string
+}
+
+type s3 struct {
+	c      S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 returns Storage putting the files into a bucket, the requests
+// signed with AWS Signature Version 4.
+func NewS3(c S3Config, client *http.Client) Storage {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	if c.BaseURL == "" {
+		c.BaseURL = c.Endpoint + "/" + c.Bucket
+	}
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	return s3{c: c, client: client, now: time.Now}
+}
+
+func (s s3) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	req, err := s.request(ctx, "PUT", key, contentType, data)
+	if err != nil {
+		return "", err
+	}
+	if err := s.do(req, "put"); err != nil {
+		return "", err
+	}
+	return s.c.BaseURL + "/" + escapePath(key), nil
+}
+
+func (s s3) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	req, err := s.request(ctx, "DELETE", key, "", nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, "delete")
+}
+
+func (s s3) do(req *http.Request, op string) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "s3 "+op)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("s3 %s: unexpected status %d", op, resp.StatusCode)
+	}
+	return nil
+}
+
+// request returns the signed request of key.
+func (s s3) request(ctx context.Context, method, key, contentType string, body []byte) (*http.Request, error) {
+	path := "/" + escapePath(s.c.Bucket) + "/" + escapePath(key)
+	req, err := http.NewRequest(method, s.c.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, path, body)
+	return req, nil
+}
+
+// sign sets the Authorization header of req, see
+// https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html.
+func (s s3) sign(req *http.Request, path string, body []byte) {
+	now := s.now().UTC()
+	date, stamp := now.Format("20060102"), now.Format("20060102T150405Z")
+	payload := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+
+	names := []string{"host"}
+	values := map[string]string{"host": req.URL.Host}
+	for _, h := range []string{"Content-Type", "X-Amz-Content-Sha256", "X-Amz-Date"} {
+		if v := req.Header.Get(h); v != "" {
+			names = append(names, strings.ToLower(h))
+			values[strings.ToLower(h)] = v
+		}
+	}
+	var headers strings.Builder
+	for _, n := range names {
+		headers.WriteString(n + ":" + strings.TrimSpace(values[n]) + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical := strings.Join([]string{req.Method, path, "", headers.String(), signed, hex.EncodeToString(payload[:])}, "\n")
+	hash := sha256.Sum256([]byte(canonical))
+
+	scope := date + "/" + s.c.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + s.c.SecretKey)
+	for _, p := range []string{date, s.c.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, p)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.c.AccessKey+"/"+scope+", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath escapes the slash separated path p the way S3 expects, every
+// byte but the unreserved ones and the slashes.
+func escapePath(p string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
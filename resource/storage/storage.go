@@ -0,0 +1,38 @@
+// storage keeps the files uploaded to the shop, e.g. the avatars of the
+// users, on the local disk or in an S3 bucket, serving them at a public
+// URL.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidKey is returned for the keys escaping the storage, e.g.
+// "../etc/passwd".
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage stores files by key, e.g. "avatars/u1/256.jpg".
+type Storage interface {
+	// Put stores data under key, replacing any file of the same key, and
+	// returns the public URL of the file.
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+
+	// Delete removes the file of key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey tells whether key is a relative slash separated path without
+// any empty, "." or ".." element.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, p := range strings.Split(key, "/") {
+		if p == "" || p == "." || p == ".." {
+			return false
+		}
+	}
+	return true
+}
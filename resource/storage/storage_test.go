@@ -0,0 +1,79 @@
+package storage_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/storage"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := storage.NewLocal(dir, "http://localhost:8080/media/")
+
+	u, err := s.Put(ctx, "avatars/u1/64.jpg", "image/jpeg", []byte("jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "http://localhost:8080/media/avatars/u1/64.jpg" {
+		t.Errorf("unexpected URL %s", u)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "avatars", "u1", "64.jpg")); err != nil || string(data) != "jpeg" {
+		t.Errorf("expected the file written, got %q, %v", data, err)
+	}
+	for _, key := range []string{"../escape.jpg", "/abs.jpg", "a//b.jpg", ""} {
+		if _, err := s.Put(ctx, key, "image/jpeg", nil); err != storage.ErrInvalidKey {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+	if err := s.Delete(ctx, "avatars/u1/64.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "avatars/u1/64.jpg"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+}
+
+func TestS3(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer srv.Close()
+	s := storage.NewS3(storage.S3Config{Endpoint: srv.URL, Region: "eu-central-1", Bucket: "shop", AccessKey: "AK", SecretKey: "SK", BaseURL: "https://cdn.example.com"}, nil)
+
+	u, err := s.Put(context.Background(), "avatars/u 1/64.jpg", "image/jpeg", []byte("jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "https://cdn.example.com/avatars/u%201/64.jpg" {
+		t.Errorf("unexpected URL %s", u)
+	}
+	if got.Method != "PUT" || got.URL.EscapedPath() != "/shop/avatars/u%201/64.jpg" || body != "jpeg" {
+		t.Errorf("expected the file put into the bucket, got %s %s %q", got.Method, got.URL.EscapedPath(), body)
+	}
+	a := got.Header.Get("Authorization")
+	if !strings.HasPrefix(a, "AWS4-HMAC-SHA256 Credential=AK/") || !strings.Contains(a, "/eu-central-1/s3/aws4_request, SignedHeaders=host;content-type;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("expected a signed request, got %q", a)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if _, err := s.Put(context.Background(), "avatars/u1/64.jpg", "image/jpeg", nil); err == nil {
+		t.Error("expected a refused put to fail")
+	}
+}
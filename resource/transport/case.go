@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// FieldCaseHeader is the request header choosing the case of the field
+// names of the response, e.g. "X-Field-Case: camel".
+const FieldCaseHeader = "X-Field-Case"
+
+// Field cases.
+const (
+	SnakeCase = "snake"
+	CamelCase = "camel"
+)
+
+// camelVersions are the API versions responding in camelCase by default.
+var camelVersions = make(map[string]bool)
+
+// CamelCaseVersions sets the API versions, e.g. "v2", responding in
+// camelCase unless FieldCaseHeader says otherwise. It is to be called once
+// from main, the other versions respond in snake_case.
+func CamelCaseVersions(versions ...string) {
+	for _, v := range versions {
+		if v = strings.TrimSpace(v); v != "" {
+			camelVersions[v] = true
+		}
+	}
+}
+
+var versionRe = regexp.MustCompile(`/(v[0-9]+)/`)
+
+type fieldCaseKey struct{}
+
+// PopulateFieldCase is a ServerBefore func reading the field case of the
+// request from FieldCaseHeader, or else from its API version.
+func PopulateFieldCase(ctx context.Context, req *http.Request) context.Context {
+	c := SnakeCase
+	if m := versionRe.FindStringSubmatch(req.URL.Path); m != nil && camelVersions[m[1]] {
+		c = CamelCase
+	}
+	switch h := strings.ToLower(req.Header.Get(FieldCaseHeader)); h {
+	case SnakeCase, CamelCase:
+		c = h
+	}
+	return context.WithValue(ctx, fieldCaseKey{}, c)
+}
+
+// FieldCase returns the field case of the request, snake_case by default.
+func FieldCase(ctx context.Context) string {
+	if c, ok := ctx.Value(fieldCaseKey{}).(string); ok {
+		return c
+	}
+	return SnakeCase
+}
+
+// encodeJSON writes v to w in the field case of the request. The structs
+// keep their snake_case tags, the keys are renamed once marshalled, the
+// object keys of maps included.
+func encodeJSON(ctx context.Context, w io.Writer, v interface{}) error {
+	if FieldCase(ctx) != CamelCase {
+		return json.NewEncoder(w).Encode(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if b, err = camelKeys(b); err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// camelKeys renames the object keys of the JSON document b to camelCase,
+// keeping their order.
+func camelKeys(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out bytes.Buffer
+	// n counts the tokens of each open object or array, the even tokens of
+	// an object are its keys.
+	type container struct {
+		object bool
+		n      int
+	}
+	var stack []container
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return out.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := t.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			out.WriteByte(byte(d))
+			continue
+		}
+		key := false
+		if len(stack) > 0 {
+			c := &stack[len(stack)-1]
+			switch {
+			case c.object && c.n%2 == 1:
+				out.WriteByte(':')
+			case c.n > 0:
+				out.WriteByte(',')
+			}
+			key = c.object && c.n%2 == 0
+			c.n++
+		}
+		switch t := t.(type) {
+		case json.Delim:
+			stack = append(stack, container{object: t == '{'})
+			out.WriteByte(byte(t))
+		case string:
+			if key {
+				t = Camel(t)
+			}
+			s, _ := json.Marshal(t)
+			out.Write(s)
+		case json.Number:
+			out.WriteString(t.String())
+		case bool:
+			s, _ := json.Marshal(t)
+			out.Write(s)
+		case nil:
+			out.WriteString("null")
+		}
+	}
+}
+
+// Camel returns the snake_case name s in camelCase, e.g. "created_at" is
+// "createdAt". Other names are returned as is.
+func Camel(s string) string {
+	if !strings.Contains(s, "_") || strings.ToLower(s) != s {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
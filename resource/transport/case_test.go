@@ -0,0 +1,58 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/transport"
+)
+
+type order struct {
+	ID         string            `json:"id"`
+	TotalPrice float64           `json:"total_price"`
+	ShippedAt  *string           `json:"shipped_at"`
+	Lines      []map[string]bool `json:"order_lines"`
+}
+
+func TestEncodeCamelCase(t *testing.T) {
+	f := transport.FormatResponse{
+		Data: order{ID: "o_1", TotalPrice: 12.5, Lines: []map[string]bool{{"gift_wrap": true}}},
+		Meta: transport.MetaResponse{Status: http.StatusOK},
+	}
+	encode := func(req *http.Request) string {
+		w := httptest.NewRecorder()
+		if err := transport.Encode(transport.PopulateFieldCase(req.Context(), req), w, f); err != nil {
+			t.Fatalf("encode: unexpected error %v", err)
+		}
+		return strings.TrimSpace(w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/orders/v1/o_1", nil)
+	if body := encode(req); body != `{"data":{"id":"o_1","total_price":12.5,"shipped_at":null,"order_lines":[{"gift_wrap":true}]},"meta":{"status":200}}` {
+		t.Errorf("snake: expected the fields as tagged, got %s", body)
+	}
+	req.Header.Set(transport.FieldCaseHeader, "camel")
+	if body := encode(req); body != `{"data":{"id":"o_1","totalPrice":12.5,"shippedAt":null,"orderLines":[{"giftWrap":true}]},"meta":{"status":200}}` {
+		t.Errorf("camel: expected the fields in camelCase, got %s", body)
+	}
+
+	transport.CamelCaseVersions("v9")
+	req = httptest.NewRequest("GET", "/orders/v9/o_1", nil)
+	if body := encode(req); !strings.Contains(body, `"totalPrice"`) {
+		t.Errorf("version: expected camelCase, got %s", body)
+	}
+	req.Header.Set(transport.FieldCaseHeader, "snake")
+	if body := encode(req); !strings.Contains(body, `"total_price"`) {
+		t.Errorf("version: expected the header to win, got %s", body)
+	}
+}
+
+func TestCamel(t *testing.T) {
+	for s, want := range map[string]string{"created_at": "createdAt", "id": "id", "a_b_c": "aBC", "DE": "DE", "Mixed_Case": "Mixed_Case"} {
+		if got := transport.Camel(s); got != want {
+			t.Errorf("%s: expected %s, got %s", s, want, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package transport
+
+import (
+	"context"
+	"net"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// ClientIP returns the IP address of the client of the request, which must
+// have been populated by httptransport.PopulateRequestContext. The remote
+// address is the client one as resolved by realip.Handler, through the
+// trusted proxies only.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(httptransport.ContextKeyRequestRemoteAddr).(string)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
+
+// UserAgent returns the user agent of the client of the request, populated
+// as for ClientIP.
+func UserAgent(ctx context.Context) string {
+	ua, _ := ctx.Value(httptransport.ContextKeyRequestUserAgent).(string)
+	return ua
+}
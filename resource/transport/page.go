@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// PageLinks returns the links to the previous and next pages of the list
+// requested at u, of total rows paged by limit and offset. A link is empty
+// if there is no such page.
+func PageLinks(u *url.URL, total, limit, offset int) (previous, next string) {
+	link := func(offset int) string {
+		params := u.Query()
+		params.Set("limit", strconv.Itoa(limit))
+		params.Set("offset", strconv.Itoa(offset))
+		return u.Path + "?" + params.Encode()
+	}
+	if offset+limit < total {
+		next = link(offset + limit)
+	}
+	if total > 0 && offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		previous = link(prev)
+	}
+	return previous, next
+}
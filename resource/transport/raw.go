@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RawHeader is the request header asking for the resource itself instead
+// of the data/meta envelope, e.g. "X-Raw-Response: true".
+const RawHeader = "X-Raw-Response"
+
+type rawKey struct{}
+
+// PopulateRaw is a ServerBefore func flagging the requests which want raw
+// responses. Routes under /v2/ are raw unless RawHeader says otherwise.
+func PopulateRaw(ctx context.Context, req *http.Request) context.Context {
+	raw := strings.Contains(req.URL.Path, "/v2/")
+	if h := req.Header.Get(RawHeader); h != "" {
+		raw, _ = strconv.ParseBool(h)
+	}
+	return context.WithValue(ctx, rawKey{}, raw)
+}
+
+// Raw tells whether the request wants a raw response.
+func Raw(ctx context.Context) bool {
+	raw, _ := ctx.Value(rawKey{}).(bool)
+	return raw
+}
+
+// Encode writes the success response f, or only the resource of its data
+// if the request wants a raw response. A raw response moves the meta to
+// the HTTP status, the X-Total-Count header and the Link header pointing
+// to the previous and next pages. Errors always keep the envelope.
+//
+// The times of the response are in UTC, or in the time zone of a report
+// request. The field names are in the field case of the request.
+func Encode(ctx context.Context, w http.ResponseWriter, f FormatResponse) error {
+	loc, _ := Timezone(ctx)
+	f.Data = InLocation(f.Data, loc)
+	if !Raw(ctx) {
+		return encodeJSON(ctx, w, f)
+	}
+	if f.Meta.Total != 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(f.Meta.Total))
+	}
+	var links []string
+	if f.Meta.Previous != "" {
+		links = append(links, `<`+f.Meta.Previous+`>; rel="prev"`)
+	}
+	if f.Meta.Next != "" {
+		links = append(links, `<`+f.Meta.Next+`>; rel="next"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	w.WriteHeader(f.Meta.Status)
+	return encodeJSON(ctx, w, Resource(f.Data))
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Resource returns the resource carried by the endpoint response d, e.g.
+// the book of a getResponse. Responses with more than one JSON field,
+// leaving the error out, are returned as is.
+func Resource(d interface{}) interface{} {
+	v := reflect.ValueOf(d)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return d
+	}
+	var resource reflect.Value
+	n := 0
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" || f.Type == errorType || strings.Split(f.Tag.Get("json"), ",")[0] == "-" {
+			continue
+		}
+		resource = v.Field(i)
+		n++
+	}
+	if n != 1 {
+		return d
+	}
+	return resource.Interface()
+}
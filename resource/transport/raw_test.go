@@ -0,0 +1,66 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kavirajk/bookshop/transport"
+)
+
+type book struct {
+	Title string `json:"title"`
+}
+
+type listResponse struct {
+	Status int    `json:"-"`
+	Books  []book `json:"books"`
+	Error  error  `json:"error,omitempty"`
+}
+
+func TestEncodeRaw(t *testing.T) {
+	f := transport.FormatResponse{
+		Data: listResponse{Status: http.StatusCreated, Books: []book{{Title: "Dune"}}},
+		Meta: transport.MetaResponse{Status: http.StatusCreated, Total: 7, Next: "/books?offset=2"},
+	}
+
+	req := httptest.NewRequest("GET", "/books/v1/list", nil)
+	req.Header.Set(transport.RawHeader, "true")
+	w := httptest.NewRecorder()
+	if err := transport.Encode(transport.PopulateRaw(req.Context(), req), w, f); err != nil {
+		t.Fatalf("encode: unexpected error %v", err)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `[{"title":"Dune"}]` {
+		t.Errorf("raw: expected the books only, got %s", body)
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("raw: expected status 201, got %d", w.Code)
+	}
+	if total := w.Header().Get("X-Total-Count"); total != "7" {
+		t.Errorf("raw: expected X-Total-Count 7, got %q", total)
+	}
+	if link := w.Header().Get("Link"); link != `</books?offset=2>; rel="next"` {
+		t.Errorf("raw: expected next link, got %q", link)
+	}
+
+	req = httptest.NewRequest("GET", "/books/v1/list", nil)
+	w = httptest.NewRecorder()
+	transport.Encode(transport.PopulateRaw(req.Context(), req), w, f)
+	if body := w.Body.String(); !strings.Contains(body, `"meta"`) {
+		t.Errorf("envelope: expected data and meta, got %s", body)
+	}
+}
+
+func TestResource(t *testing.T) {
+	two := struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}{1, 2}
+	if r := transport.Resource(two); r != two {
+		t.Errorf("resource: expected responses with two fields as is, got %v", r)
+	}
+	if r := transport.Resource("x"); r != "x" {
+		t.Errorf("resource: expected non structs as is, got %v", r)
+	}
+}
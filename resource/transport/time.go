@@ -0,0 +1,179 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+var (
+	ErrAmbiguousDate = errors.New("ambiguous date, expected YYYY-MM-DD or a RFC 3339 time")
+	ErrBadTimezone   = errors.New("unknown time zone, expected an IANA name such as Europe/Paris")
+)
+
+// DateLayout is the layout of the dates of the requests.
+const DateLayout = "2006-01-02"
+
+// TimezoneParam is the query parameter naming the time zone the times of a
+// report are displayed in, e.g. "tz=Europe/Paris".
+const TimezoneParam = "tz"
+
+// ParseDate reads a date of a request, either a YYYY-MM-DD day starting at
+// midnight in loc or a RFC 3339 time with its offset. Anything else, such
+// as 01/02/2026 which reads differently in the US and in Europe, or a time
+// without offset, is ErrAmbiguousDate.
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(DateLayout, s, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, ErrAmbiguousDate
+}
+
+type (
+	preferenceKey struct{}
+	timezoneKey   struct{}
+)
+
+type timezone struct {
+	loc *time.Location
+	err error
+}
+
+// WithTimezone sets the preferred time zone of the caller, used by the
+// reports when the request has no tz parameter.
+func WithTimezone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, preferenceKey{}, loc)
+}
+
+// PopulateTimezone is a ServerBefore func of the report routes, reading the
+// time zone of the tz parameter or else the preference of the caller. The
+// times of the response are then displayed in it, the other routes respond
+// in UTC.
+func PopulateTimezone(ctx context.Context, req *http.Request) context.Context {
+	tz := timezone{loc: time.UTC}
+	if loc, ok := ctx.Value(preferenceKey{}).(*time.Location); ok {
+		tz.loc = loc
+	}
+	if name := req.URL.Query().Get(TimezoneParam); name != "" {
+		tz.loc, tz.err = time.LoadLocation(name)
+		if tz.err != nil {
+			tz.loc, tz.err = time.UTC, ErrBadTimezone
+		}
+	}
+	return context.WithValue(ctx, timezoneKey{}, tz)
+}
+
+// Timezone returns the time zone of a report request, UTC unless set by
+// PopulateTimezone. An unknown tz parameter is ErrBadTimezone.
+func Timezone(ctx context.Context) (*time.Location, error) {
+	tz, ok := ctx.Value(timezoneKey{}).(timezone)
+	if !ok {
+		return time.UTC, nil
+	}
+	return tz.loc, tz.err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// InLocation returns a copy of the response d with its times, down its
+// fields, slices and maps, set to loc. d itself is left as is.
+func InLocation(d interface{}, loc *time.Location) interface{} {
+	v := reflect.ValueOf(d)
+	if !v.IsValid() || !hasTime(v.Type(), map[reflect.Type]bool{}) {
+		return d
+	}
+	return inLocation(v, loc).Interface()
+}
+
+func inLocation(v reflect.Value, loc *time.Location) reflect.Value {
+	if v.Type() == errorType {
+		return v
+	}
+	if v.Type() == timeType {
+		return reflect.ValueOf(v.Interface().(time.Time).In(loc))
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(inLocation(v.Elem(), loc))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(inLocation(v.Elem(), loc))
+		return i
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				s.Field(i).Set(inLocation(v.Field(i), loc))
+			}
+		}
+		return s
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(inLocation(v.Index(i), loc))
+		}
+		return s
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			a.Index(i).Set(inLocation(v.Index(i), loc))
+		}
+		return a
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			m.SetMapIndex(k, inLocation(v.MapIndex(k), loc))
+		}
+		return m
+	}
+	return v
+}
+
+// hasTime tells whether values of t may hold a time, so that responses
+// without any are not copied. Interfaces other than errors may hold
+// anything.
+func hasTime(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == timeType {
+		return true
+	}
+	if t == errorType {
+		return false
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasTime(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" && hasTime(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
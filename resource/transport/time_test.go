@@ -0,0 +1,75 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kavirajk/bookshop/transport"
+)
+
+func TestParseDate(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	d, err := transport.ParseDate("2026-10-01", paris)
+	if err != nil || !d.Equal(time.Date(2026, 9, 30, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("date: expected midnight in Paris, got %v %v", d, err)
+	}
+	d, err = transport.ParseDate("2026-10-01T08:30:00+02:00", time.UTC)
+	if err != nil || !d.Equal(time.Date(2026, 10, 1, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("time: expected 06:30 UTC, got %v %v", d, err)
+	}
+	for _, c := range []string{"01/10/2026", "10/01/2026", "2026-10-1", "2026-10-01T08:30:00", "1 Oct 2026"} {
+		if _, err := transport.ParseDate(c, time.UTC); err != transport.ErrAmbiguousDate {
+			t.Errorf("%s: expected ErrAmbiguousDate, got %v", c, err)
+		}
+	}
+}
+
+type report struct {
+	From    time.Time   `json:"from"`
+	Last    *time.Time  `json:"last"`
+	Periods []time.Time `json:"periods"`
+	Error   error       `json:"error,omitempty"`
+}
+
+func TestEncodeTimezone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, paris)
+	r := report{From: day, Last: &day, Periods: []time.Time{day}}
+	f := transport.FormatResponse{Data: r, Meta: transport.MetaResponse{Status: http.StatusOK}}
+
+	req := httptest.NewRequest("GET", "/donations/v1/report", nil)
+	w := httptest.NewRecorder()
+	transport.Encode(req.Context(), w, f)
+	if body := w.Body.String(); !strings.Contains(body, `{"from":"2026-09-30T22:00:00Z","last":"2026-09-30T22:00:00Z","periods":["2026-09-30T22:00:00Z"]}`) {
+		t.Errorf("utc: expected the times in UTC, got %s", body)
+	}
+	if r.From.Location() != paris || r.Last.Location() != paris {
+		t.Errorf("utc: expected the response left as is")
+	}
+
+	req = httptest.NewRequest("GET", "/donations/v1/report?tz=America/New_York", nil)
+	ctx := transport.PopulateTimezone(transport.WithTimezone(req.Context(), paris), req)
+	w = httptest.NewRecorder()
+	transport.Encode(ctx, w, f)
+	if body := w.Body.String(); !strings.Contains(body, `"from":"2026-09-30T18:00:00-04:00"`) {
+		t.Errorf("tz: expected the times in New York, got %s", body)
+	}
+
+	req = httptest.NewRequest("GET", "/donations/v1/report", nil)
+	if loc, err := transport.Timezone(transport.PopulateTimezone(transport.WithTimezone(req.Context(), paris), req)); loc != paris || err != nil {
+		t.Errorf("preference: expected Paris, got %v %v", loc, err)
+	}
+	req = httptest.NewRequest("GET", "/donations/v1/report?tz=Mars/Olympus", nil)
+	if _, err := transport.Timezone(transport.PopulateTimezone(req.Context(), req)); err != transport.ErrBadTimezone {
+		t.Errorf("unknown: expected ErrBadTimezone, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 // tranport contains common tranport utils for all the services.
 package transport
 
+import "github.com/kavirajk/bookshop/validate"
+
 // formatResponse is the uniform response format used throughout the books service,
 // for every endpoint response.
 type FormatResponse struct {
@@ -10,9 +12,18 @@ type FormatResponse struct {
 
 // metaResponse is part of response json that tells about basic meta information.
 type MetaResponse struct {
-	Status   int    `json:"status"`
-	Error    string `json:"error,omitempty"`
-	Previous string `json:"previous,omitempty"`
-	Next     string `json:"next,omitempty"`
-	Total    int    `json:"total,omitempty"`
+	Status int `json:"status"`
+	// Code is the stable code of the error, Error its message in the
+	// language of the request.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Errors lists every failing field of an invalid request.
+	Errors   validate.Errors `json:"errors,omitempty"`
+	Previous string          `json:"previous,omitempty"`
+	Next     string          `json:"next,omitempty"`
+	Total    int             `json:"total,omitempty"`
+	// PreviousCursor and NextCursor are the opaque cursors of the pages
+	// around, of the lists paged by cursor.
+	PreviousCursor string `json:"previous_cursor,omitempty"`
+	NextCursor     string `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,141 @@
+// validate collects the failing fields of a request so that all of them
+// are reported at once, instead of stopping at the first one.
+package validate
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalid is the cause of Errors, services map it to a status code like
+// any other domain error.
+var ErrInvalid = errors.New("invalid fields")
+
+// Machine readable codes of the field errors.
+const (
+	CodeRequired   = "required"
+	CodeInvalid    = "invalid"
+	CodeMismatch   = "mismatch"
+	CodeTooLong    = "too_long"
+	CodeOutOfRange = "out_of_range"
+	CodeReadOnly   = "read_only"
+	CodeTaken      = "taken"
+	CodeType       = "type"
+	CodeUnknown    = "unknown"
+)
+
+// FieldError is a failing field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of the failing fields of a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, f := range e {
+		msgs = append(msgs, f.Message)
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(msgs, ", ")
+}
+
+// Cause returns ErrInvalid, so that errors.Cause of a wrapped Errors can be
+// compared to it.
+func (e Errors) Cause() error {
+	return ErrInvalid
+}
+
+// Fields returns the failing fields of err, or nil if it isn't caused by
+// Errors.
+func Fields(err error) Errors {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		if e, ok := err.(Errors); ok {
+			return e
+		}
+		c, ok := err.(causer)
+		if !ok {
+			return nil
+		}
+		err = c.Cause()
+	}
+	return nil
+}
+
+// Validator collects field errors. The zero value is ready to use, every
+// check returns whether it passed.
+type Validator struct {
+	errs Errors
+}
+
+// Add records a failing field.
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records a field error with code unless ok. Fields already failing
+// aren't checked further, e.g. a missing email isn't reported as invalid
+// too.
+func (v *Validator) Check(ok bool, field, code, message string) bool {
+	if v.failed(field) {
+		return false
+	}
+	if !ok {
+		v.Add(field, code, message)
+	}
+	return ok
+}
+
+// Required checks value isn't blank.
+func (v *Validator) Required(field, value string) bool {
+	return v.Check(strings.TrimSpace(value) != "", field, CodeRequired, field+" is required")
+}
+
+// Email checks value is an email address.
+func (v *Validator) Email(field, value string) bool {
+	a, err := mail.ParseAddress(value)
+	return v.Check(err == nil && a.Address == strings.TrimSpace(value), field, CodeInvalid, field+" is not a valid email")
+}
+
+// MaxLength checks value is at most n characters long.
+func (v *Validator) MaxLength(field, value string, n int) bool {
+	return v.Check(utf8.RuneCountInString(value) <= n, field, CodeTooLong, field+" is too long")
+}
+
+// Range checks value is between min and max, both included.
+func (v *Validator) Range(field string, value, min, max int) bool {
+	return v.Check(value >= min && value <= max, field, CodeOutOfRange, field+" is out of range")
+}
+
+// Equal checks value is the same as the one of field other, unless other
+// is failing already.
+func (v *Validator) Equal(field, value, other, otherValue string) bool {
+	if v.failed(other) {
+		return false
+	}
+	return v.Check(value == otherValue, field, CodeMismatch, field+" doesn't match "+other)
+}
+
+// Err returns the collected field errors, nil if all the checks passed.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (v *Validator) failed(field string) bool {
+	for _, e := range v.errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package validate_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kavirajk/bookshop/validate"
+	"github.com/pkg/errors"
+)
+
+func TestValidator(t *testing.T) {
+	var v validate.Validator
+	v.Required("name", " ")
+	v.Required("email", "")
+	v.Email("email", "")
+	v.Email("contact", "not an email")
+	v.Equal("confirm_password", "b", "password", "a")
+	v.MaxLength("bio", "héllo", 5)
+	v.Range("rating", 6, 0, 5)
+
+	err := v.Err()
+	if errors.Cause(err) != validate.ErrInvalid {
+		t.Fatalf("cause: expected ErrInvalid, got %v", err)
+	}
+	expected := validate.Errors{
+		{Field: "name", Code: validate.CodeRequired, Message: "name is required"},
+		{Field: "email", Code: validate.CodeRequired, Message: "email is required"},
+		{Field: "contact", Code: validate.CodeInvalid, Message: "contact is not a valid email"},
+		{Field: "confirm_password", Code: validate.CodeMismatch, Message: "confirm_password doesn't match password"},
+		{Field: "rating", Code: validate.CodeOutOfRange, Message: "rating is out of range"},
+	}
+	fields := validate.Fields(errors.Wrap(err, "register"))
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("fields: expected %v, got %v", expected, fields)
+	}
+
+	var ok validate.Validator
+	ok.Required("name", "Jane")
+	ok.Email("email", "jane@example.com")
+	if err := ok.Err(); err != nil {
+		t.Errorf("err: expected nil, got %v", err)
+	}
+	if fields := validate.Fields(errors.New("other")); fields != nil {
+		t.Errorf("fields: expected none for other errors, got %v", fields)
+	}
+}